@@ -13,7 +13,7 @@ import (
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	log.Println("K8s Dashboard Agent starting...")
+	log.Printf("K8s Dashboard Agent %s starting...", internal.Version)
 
 	cfg, err := internal.LoadConfig()
 	if err != nil {
@@ -32,25 +32,20 @@ func main() {
 		cancel()
 	}()
 
+	// Create the K8s proxy that handles requests from the dashboard.
+	proxy := internal.NewProxy()
+
+	// Create the connector.
+	connector := internal.NewConnector(cfg, proxy.HandleRequest, proxy.HandleStreamRequest)
+
 	// Start health server for Kubernetes probes.
 	go func() {
-		mux := http.NewServeMux()
-		mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("ok")) //nolint:errcheck
-		})
 		log.Println("Health server listening on :8443")
-		if err := http.ListenAndServe(":8443", mux); err != nil {
+		if err := http.ListenAndServe(":8443", internal.HealthHandler(connector)); err != nil {
 			log.Printf("Health server error: %v", err)
 		}
 	}()
 
-	// Create the K8s proxy that handles requests from the dashboard.
-	proxy := internal.NewProxy()
-
-	// Create and run the connector.
-	connector := internal.NewConnector(cfg, proxy.HandleRequest)
-
 	log.Printf("Connecting to dashboard at %s...", cfg.DashboardURL)
 	if err := connector.Run(ctx); err != nil && ctx.Err() == nil {
 		log.Fatalf("Agent failed: %v", err)
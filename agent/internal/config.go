@@ -3,6 +3,7 @@ package internal
 import (
 	"fmt"
 	"os"
+	"strings"
 )
 
 type Config struct {
@@ -11,6 +12,9 @@ type Config struct {
 	ClusterName  string
 	AgentToken   string
 	ClusterID    string
+	// Compression lists the response encodings offered to the dashboard, in
+	// order of preference. Empty disables compression.
+	Compression []string
 }
 
 func LoadConfig() (*Config, error) {
@@ -20,6 +24,7 @@ func LoadConfig() (*Config, error) {
 		ClusterName:  getEnv("CLUSTER_NAME", ""),
 		AgentToken:   getEnv("AGENT_TOKEN", ""),
 		ClusterID:    getEnv("CLUSTER_ID", ""),
+		Compression:  parseCompression(getEnv("AGENT_COMPRESSION", "zstd,gzip")),
 	}
 
 	if cfg.DashboardURL == "" {
@@ -43,6 +48,22 @@ func (c *Config) IsRegistered() bool {
 	return c.AgentToken != "" && c.ClusterID != ""
 }
 
+// parseCompression parses a comma-separated encoding list; "none" disables
+// compression.
+func parseCompression(v string) []string {
+	var encodings []string
+	for _, enc := range strings.Split(v, ",") {
+		enc = strings.ToLower(strings.TrimSpace(enc))
+		if enc == "none" {
+			return nil
+		}
+		if enc != "" {
+			encodings = append(encodings, enc)
+		}
+	}
+	return encodings
+}
+
 func getEnv(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCompression(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"zstd,gzip", []string{"zstd", "gzip"}},
+		{" GZIP , ", []string{"gzip"}},
+		{"none", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := parseCompression(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseCompression(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfig_CompressionDefault(t *testing.T) {
+	t.Setenv("DASHBOARD_URL", "dashboard:9090")
+	t.Setenv("AGENT_TOKEN", "token")
+	t.Setenv("AGENT_COMPRESSION", "")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Compression, []string{"zstd", "gzip"}) {
+		t.Errorf("expected default compression [zstd gzip], got %v", cfg.Compression)
+	}
+}
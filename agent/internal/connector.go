@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	pb "github.com/darkden-lab/argus/backend/pkg/agentpb"
@@ -17,23 +20,48 @@ import (
 // RequestHandler processes incoming K8s requests from the dashboard.
 type RequestHandler func(ctx context.Context, req *pb.K8SRequest) *pb.K8SResponse
 
+// StreamRequestHandler processes a streaming K8s request, passing each
+// response chunk to send as it is produced. It returns once the final chunk
+// has been sent or ctx is cancelled.
+type StreamRequestHandler func(ctx context.Context, req *pb.K8SRequest, send func(*pb.K8SResponseChunk) error)
+
 // Connector manages the gRPC connection to the dashboard backend.
 type Connector struct {
-	config   *Config
-	handler  RequestHandler
-	watchMgr *WatchManager
-	conn     *grpc.ClientConn
-	client   pb.ClusterAgentClient
+	config        *Config
+	handler       RequestHandler
+	streamHandler StreamRequestHandler
+	watchMgr      *WatchManager
+	heartbeat     *Heartbeat
+	conn          *grpc.ClientConn
+	client        pb.ClusterAgentClient
+	// connected is true while the stream to the dashboard is open.
+	connected atomic.Bool
 }
 
-func NewConnector(cfg *Config, handler RequestHandler) *Connector {
+func NewConnector(cfg *Config, handler RequestHandler, streamHandler StreamRequestHandler) *Connector {
 	return &Connector{
-		config:   cfg,
-		handler:  handler,
-		watchMgr: NewWatchManager(),
+		config:        cfg,
+		handler:       handler,
+		streamHandler: streamHandler,
+		watchMgr:      NewWatchManager(),
+		heartbeat:     NewHeartbeat(0),
 	}
 }
 
+// lockedStream serializes Send calls: responses, stream chunks and watch
+// events are produced by separate goroutines, and a gRPC stream does not
+// allow concurrent sends.
+type lockedStream struct {
+	pb.ClusterAgent_StreamClient
+	mu sync.Mutex
+}
+
+func (s *lockedStream) Send(msg *pb.AgentMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ClusterAgent_StreamClient.Send(msg)
+}
+
 // Run connects to the dashboard, registers if needed, and maintains the stream.
 // It retries with exponential backoff on failure.
 func (c *Connector) Run(ctx context.Context) error {
@@ -86,6 +114,11 @@ func (c *Connector) Run(ctx context.Context) error {
 	}
 }
 
+// Connected reports whether the stream to the dashboard is currently open.
+func (c *Connector) Connected() bool {
+	return c.connected.Load()
+}
+
 func (c *Connector) dial(ctx context.Context) error {
 	conn, err := grpc.NewClient(
 		c.config.DashboardURL,
@@ -105,6 +138,7 @@ func (c *Connector) register(ctx context.Context) error {
 	resp, err := c.client.Register(ctx, &pb.RegisterRequest{
 		Token:       c.config.Token,
 		ClusterName: c.config.ClusterName,
+		ClusterInfo: CollectClusterInfo(ctx),
 	})
 	if err != nil {
 		return err
@@ -118,20 +152,37 @@ func (c *Connector) register(ctx context.Context) error {
 }
 
 func (c *Connector) stream(ctx context.Context) error {
-	// Attach the agent token as metadata.
-	md := metadata.Pairs("authorization", "Bearer "+c.config.AgentToken)
+	// Attach the agent token, our version and the response encodings we can
+	// produce.
+	md := metadata.Pairs("authorization", "Bearer "+c.config.AgentToken, pb.AgentVersionMetadataKey, Version)
+	if len(c.config.Compression) > 0 {
+		md.Set(pb.AcceptEncodingMetadataKey, strings.Join(c.config.Compression, ","))
+	}
 	streamCtx := metadata.NewOutgoingContext(ctx, md)
 
-	stream, err := c.client.Stream(streamCtx)
+	raw, err := c.client.Stream(streamCtx)
 	if err != nil {
 		return fmt.Errorf("failed to open stream: %w", err)
 	}
+	stream := &lockedStream{ClusterAgent_StreamClient: raw}
 
-	log.Printf("Stream connected: cluster_id=%s", c.config.ClusterID)
+	encoding := c.negotiatedEncoding(stream)
+	log.Printf("Stream connected: cluster_id=%s encoding=%q", c.config.ClusterID, encoding)
+	c.connected.Store(true)
+	defer c.connected.Store(false)
 
 	// Stop all watches when stream disconnects.
 	defer c.watchMgr.StopAll()
 
+	// Streaming requests run under ctx and stop when the stream disconnects.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	streams := make(map[string]context.CancelFunc)
+	var streamsMu sync.Mutex
+
+	// Report cluster info for the dashboard's cluster summary.
+	go c.heartbeat.RunPeriodicInfo(ctx, stream)
+
 	// Process incoming messages from the dashboard.
 	for {
 		msg, err := stream.Recv()
@@ -141,7 +192,31 @@ func (c *Connector) stream(ctx context.Context) error {
 
 		switch payload := msg.Payload.(type) {
 		case *pb.DashboardMessage_K8SRequest:
-			go c.handleK8sRequest(ctx, stream, payload.K8SRequest)
+			go c.handleK8sRequest(ctx, stream, payload.K8SRequest, encoding)
+		case *pb.DashboardMessage_K8SStreamRequest:
+			req := payload.K8SStreamRequest.GetRequest()
+			if req == nil {
+				continue
+			}
+			reqCtx, reqCancel := context.WithCancel(ctx)
+			streamsMu.Lock()
+			streams[req.RequestId] = reqCancel
+			streamsMu.Unlock()
+			go func() {
+				defer func() {
+					streamsMu.Lock()
+					delete(streams, req.RequestId)
+					streamsMu.Unlock()
+					reqCancel()
+				}()
+				c.handleK8sStreamRequest(reqCtx, stream, req)
+			}()
+		case *pb.DashboardMessage_K8SStreamCancel:
+			streamsMu.Lock()
+			if cancelReq, ok := streams[payload.K8SStreamCancel.RequestId]; ok {
+				cancelReq()
+			}
+			streamsMu.Unlock()
 		case *pb.DashboardMessage_Ping:
 			_ = stream.Send(&pb.AgentMessage{
 				Payload: &pb.AgentMessage_Pong{
@@ -169,8 +244,34 @@ func (c *Connector) stream(ctx context.Context) error {
 	}
 }
 
-func (c *Connector) handleK8sRequest(ctx context.Context, stream pb.ClusterAgent_StreamClient, req *pb.K8SRequest) {
+// negotiatedEncoding reads the encoding the dashboard picked from the stream
+// header. Dashboards that predate compression send none, and an encoding we
+// did not offer is ignored, so responses are then sent uncompressed.
+func (c *Connector) negotiatedEncoding(stream pb.ClusterAgent_StreamClient) string {
+	if len(c.config.Compression) == 0 {
+		return ""
+	}
+	header, err := stream.Header()
+	if err != nil {
+		return ""
+	}
+	values := header.Get(pb.EncodingMetadataKey)
+	if len(values) == 0 {
+		return ""
+	}
+	for _, enc := range c.config.Compression {
+		if enc == values[0] {
+			return enc
+		}
+	}
+	return ""
+}
+
+func (c *Connector) handleK8sRequest(ctx context.Context, stream pb.ClusterAgent_StreamClient, req *pb.K8SRequest, encoding string) {
 	resp := c.handler(ctx, req)
+	if err := resp.CompressBody(encoding); err != nil {
+		log.Printf("Failed to compress K8s response for request %s, sending uncompressed: %v", req.RequestId, err)
+	}
 	if err := stream.Send(&pb.AgentMessage{
 		Payload: &pb.AgentMessage_K8SResponse{
 			K8SResponse: resp,
@@ -180,6 +281,32 @@ func (c *Connector) handleK8sRequest(ctx context.Context, stream pb.ClusterAgent
 	}
 }
 
+func (c *Connector) handleK8sStreamRequest(ctx context.Context, stream pb.ClusterAgent_StreamClient, req *pb.K8SRequest) {
+	send := func(chunk *pb.K8SResponseChunk) error {
+		return stream.Send(&pb.AgentMessage{
+			Payload: &pb.AgentMessage_K8SResponseChunk{
+				K8SResponseChunk: chunk,
+			},
+		})
+	}
+	if c.streamHandler == nil {
+		_ = send(&pb.K8SResponseChunk{
+			RequestId:  req.RequestId,
+			StatusCode: 501,
+			Final:      true,
+			Error:      "streaming requests are not supported by this agent",
+		})
+		return
+	}
+	c.streamHandler(ctx, req, func(chunk *pb.K8SResponseChunk) error {
+		if err := send(chunk); err != nil {
+			log.Printf("Failed to send K8s response chunk for request %s: %v", req.RequestId, err)
+			return err
+		}
+		return nil
+	})
+}
+
 // backoff returns an exponential backoff duration capped at 60s.
 func backoff(attempt int) time.Duration {
 	base := time.Second
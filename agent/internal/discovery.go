@@ -4,19 +4,26 @@ import (
 	"context"
 	"log"
 	"runtime"
+	"time"
 
 	pb "github.com/darkden-lab/argus/backend/pkg/agentpb"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
 
-const agentVersion = "0.1.0"
+// Version is the agent's build version, reported to the dashboard so it can
+// flag or refuse agents older than it supports. Release builds set it with
+// -ldflags "-X github.com/darkden-lab/argus/agent/internal.Version=x.y.z".
+var Version = "0.1.0"
 
 // CollectClusterInfo gathers information about the local Kubernetes cluster.
 func CollectClusterInfo(ctx context.Context) *pb.ClusterInfo {
 	info := &pb.ClusterInfo{
-		AgentVersion: agentVersion,
+		AgentVersion: Version,
 		Platform:     runtime.GOOS + "/" + runtime.GOARCH,
 	}
 
@@ -40,12 +47,14 @@ func CollectClusterInfo(ctx context.Context) *pb.ClusterInfo {
 		info.KubernetesVersion = serverVersion.GitVersion
 	}
 
-	// Node count.
+	// Node count and capacity.
 	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		log.Printf("Discovery: failed to list nodes: %v", err)
 	} else {
 		info.NodeCount = int32(len(nodes.Items))
+		info.Capacity = sumNodeResources(nodes.Items, func(n corev1.Node) corev1.ResourceList { return n.Status.Capacity })
+		info.Allocatable = sumNodeResources(nodes.Items, func(n corev1.Node) corev1.ResourceList { return n.Status.Allocatable })
 	}
 
 	// Namespaces.
@@ -73,3 +82,54 @@ func CollectClusterInfo(ctx context.Context) *pb.ClusterInfo {
 
 	return info
 }
+
+// sumNodeResources totals the CPU, memory and pod resources of the nodes.
+func sumNodeResources(nodes []corev1.Node, resources func(corev1.Node) corev1.ResourceList) *pb.NodeResources {
+	var cpu, memory, pods resource.Quantity
+	for _, n := range nodes {
+		list := resources(n)
+		cpu.Add(list[corev1.ResourceCPU])
+		memory.Add(list[corev1.ResourceMemory])
+		pods.Add(list[corev1.ResourcePods])
+	}
+	return &pb.NodeResources{Cpu: cpu.String(), Memory: memory.String(), Pods: pods.String()}
+}
+
+// WatchNodeChanges calls notify whenever a node joins or leaves the cluster,
+// until ctx is done. The watch is re-established when the API server ends it.
+func WatchNodeChanges(ctx context.Context, notify func()) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Printf("Discovery: failed to create clientset for node watch: %v", err)
+		return
+	}
+
+	for ctx.Err() == nil {
+		// Watch from the current list so existing nodes are not replayed as
+		// ADDED events.
+		var w watch.Interface
+		nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err == nil {
+			w, err = clientset.CoreV1().Nodes().Watch(ctx, metav1.ListOptions{ResourceVersion: nodes.ResourceVersion})
+		}
+		if err != nil {
+			log.Printf("Discovery: failed to watch nodes: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(30 * time.Second):
+			}
+			continue
+		}
+		for event := range w.ResultChan() {
+			if event.Type == watch.Added || event.Type == watch.Deleted {
+				notify()
+			}
+		}
+		w.Stop()
+	}
+}
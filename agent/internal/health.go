@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// streamStatus reports whether the dashboard stream is connected.
+type streamStatus interface {
+	Connected() bool
+}
+
+// HealthHandler serves the agent's Kubernetes probes. /livez and /healthz
+// report that the process is alive and include the stream state; /readyz
+// returns 503 while the stream to the dashboard is disconnected.
+func HealthHandler(stream streamStatus) http.Handler {
+	mux := http.NewServeMux()
+	live := func(w http.ResponseWriter, _ *http.Request) {
+		writeHealth(w, http.StatusOK, "ok", stream.Connected())
+	}
+	mux.HandleFunc("/livez", live)
+	mux.HandleFunc("/healthz", live)
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if !stream.Connected() {
+			writeHealth(w, http.StatusServiceUnavailable, "not_ready", false)
+			return
+		}
+		writeHealth(w, http.StatusOK, "ready", true)
+	})
+	return mux
+}
+
+func writeHealth(w http.ResponseWriter, status int, state string, connected bool) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{ //nolint:errcheck
+		"status":           state,
+		"stream_connected": connected,
+	})
+}
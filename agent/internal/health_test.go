@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeStream bool
+
+func (f fakeStream) Connected() bool { return bool(f) }
+
+func probe(t *testing.T, h http.Handler, path string) (int, map[string]interface{}) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("%s: invalid JSON body: %v", path, err)
+	}
+	return rec.Code, body
+}
+
+func TestHealthHandler_Disconnected(t *testing.T) {
+	h := HealthHandler(fakeStream(false))
+
+	code, body := probe(t, h, "/healthz")
+	if code != http.StatusOK {
+		t.Errorf("/healthz: expected 200 while disconnected, got %d", code)
+	}
+	if body["stream_connected"] != false {
+		t.Errorf("/healthz: expected stream_connected=false, got %v", body["stream_connected"])
+	}
+
+	if code, _ := probe(t, h, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz: expected 503 while disconnected, got %d", code)
+	}
+}
+
+func TestHealthHandler_Connected(t *testing.T) {
+	h := HealthHandler(fakeStream(true))
+
+	code, body := probe(t, h, "/readyz")
+	if code != http.StatusOK {
+		t.Errorf("/readyz: expected 200 while connected, got %d", code)
+	}
+	if body["stream_connected"] != true {
+		t.Errorf("/readyz: expected stream_connected=true, got %v", body["stream_connected"])
+	}
+}
@@ -51,11 +51,21 @@ func (h *Heartbeat) RespondToPing(sender StreamSender) {
 	}
 }
 
-// RunPeriodicInfo periodically sends cluster info updates.
+// RunPeriodicInfo periodically sends cluster info updates, and sends one
+// early whenever a node joins or leaves the cluster.
 func (h *Heartbeat) RunPeriodicInfo(ctx context.Context, sender StreamSender) {
 	ticker := time.NewTicker(h.interval)
 	defer ticker.Stop()
 
+	// Node changes arriving while a report is pending collapse into it.
+	nodesChanged := make(chan struct{}, 1)
+	go WatchNodeChanges(ctx, func() {
+		select {
+		case nodesChanged <- struct{}{}:
+		default:
+		}
+	})
+
 	// Send initial info immediately.
 	h.SendClusterInfo(ctx, sender)
 
@@ -65,6 +75,8 @@ func (h *Heartbeat) RunPeriodicInfo(ctx context.Context, sender StreamSender) {
 			return
 		case <-ticker.C:
 			h.SendClusterInfo(ctx, sender)
+		case <-nodesChanged:
+			h.SendClusterInfo(ctx, sender)
 		}
 	}
 }
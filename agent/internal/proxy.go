@@ -38,6 +38,9 @@ func NewProxy() *Proxy {
 	}
 }
 
+// streamChunkSize is the largest body piece sent in one K8sResponseChunk.
+const streamChunkSize = 32 * 1024
+
 // HandleRequest processes a K8s API request and returns the response.
 func (p *Proxy) HandleRequest(ctx context.Context, req *pb.K8SRequest) *pb.K8SResponse {
 	if p.config == nil || p.client == nil {
@@ -48,21 +51,7 @@ func (p *Proxy) HandleRequest(ctx context.Context, req *pb.K8SRequest) *pb.K8SRe
 		}
 	}
 
-	url := p.config.Host + req.Path
-	if len(req.QueryParams) > 0 {
-		params := make([]string, 0, len(req.QueryParams))
-		for k, v := range req.QueryParams {
-			params = append(params, k+"="+v)
-		}
-		url += "?" + strings.Join(params, "&")
-	}
-
-	var bodyReader io.Reader
-	if len(req.Body) > 0 {
-		bodyReader = strings.NewReader(string(req.Body))
-	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, req.Method, url, bodyReader)
+	httpReq, err := p.newHTTPRequest(ctx, req)
 	if err != nil {
 		return &pb.K8SResponse{
 			RequestId:  req.RequestId,
@@ -71,13 +60,6 @@ func (p *Proxy) HandleRequest(ctx context.Context, req *pb.K8SRequest) *pb.K8SRe
 		}
 	}
 
-	for k, v := range req.Headers {
-		httpReq.Header.Set(k, v)
-	}
-	if httpReq.Header.Get("Content-Type") == "" && len(req.Body) > 0 {
-		httpReq.Header.Set("Content-Type", "application/json")
-	}
-
 	resp, err := p.client.Do(httpReq)
 	if err != nil {
 		return &pb.K8SResponse{
@@ -103,9 +85,113 @@ func (p *Proxy) HandleRequest(ctx context.Context, req *pb.K8SRequest) *pb.K8SRe
 	}
 
 	return &pb.K8SResponse{
-		RequestId:  req.RequestId,
-		StatusCode: int32(resp.StatusCode),
-		Body:       body,
-		Headers:    headers,
+		RequestId:   req.RequestId,
+		StatusCode:  int32(resp.StatusCode),
+		Body:        body,
+		Headers:     headers,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+}
+
+// HandleStreamRequest executes a K8s API request and sends the response body
+// to the dashboard as it is read, one chunk at a time. The first chunk carries
+// the status and headers; the last one is marked final. Watches and followed
+// logs run until the API server ends them or ctx is cancelled.
+func (p *Proxy) HandleStreamRequest(ctx context.Context, req *pb.K8SRequest, send func(*pb.K8SResponseChunk) error) {
+	var seq int64
+	emit := func(chunk *pb.K8SResponseChunk) error {
+		chunk.RequestId = req.RequestId
+		chunk.Sequence = seq
+		seq++
+		return send(chunk)
+	}
+	fail := func(status int, msg string) {
+		_ = emit(&pb.K8SResponseChunk{StatusCode: int32(status), Final: true, Error: msg})
+	}
+
+	if p.config == nil || p.client == nil {
+		fail(http.StatusServiceUnavailable, "agent not running in a Kubernetes cluster")
+		return
+	}
+
+	httpReq, err := p.newHTTPRequest(ctx, req)
+	if err != nil {
+		fail(http.StatusInternalServerError, fmt.Sprintf("failed to create request: %v", err))
+		return
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		fail(http.StatusBadGateway, fmt.Sprintf("k8s API request failed: %v", err))
+		return
+	}
+	defer resp.Body.Close()
+
+	headers := make(map[string]string)
+	for k := range resp.Header {
+		headers[k] = resp.Header.Get(k)
+	}
+
+	// Send the status right away: a followed log or watch may stay silent
+	// for a long time before the first byte of body.
+	if err := emit(&pb.K8SResponseChunk{
+		StatusCode:  int32(resp.StatusCode),
+		Headers:     headers,
+		ContentType: resp.Header.Get("Content-Type"),
+	}); err != nil {
+		return
+	}
+
+	buf := make([]byte, streamChunkSize)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			data := append([]byte(nil), buf[:n]...)
+			if err := emit(&pb.K8SResponseChunk{Data: data}); err != nil {
+				return
+			}
+		}
+		if readErr == io.EOF {
+			_ = emit(&pb.K8SResponseChunk{Final: true})
+			return
+		}
+		if readErr != nil {
+			if ctx.Err() != nil {
+				// Cancelled by the dashboard; it is no longer listening.
+				return
+			}
+			_ = emit(&pb.K8SResponseChunk{Final: true, Error: fmt.Sprintf("failed to read response body: %v", readErr)})
+			return
+		}
+	}
+}
+
+// newHTTPRequest builds the HTTP request for the local API server.
+func (p *Proxy) newHTTPRequest(ctx context.Context, req *pb.K8SRequest) (*http.Request, error) {
+	url := p.config.Host + req.Path
+	if len(req.QueryParams) > 0 {
+		params := make([]string, 0, len(req.QueryParams))
+		for k, v := range req.QueryParams {
+			params = append(params, k+"="+v)
+		}
+		url += "?" + strings.Join(params, "&")
+	}
+
+	var bodyReader io.Reader
+	if len(req.Body) > 0 {
+		bodyReader = strings.NewReader(string(req.Body))
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, url, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range req.Headers {
+		httpReq.Header.Set(k, v)
+	}
+	if httpReq.Header.Get("Content-Type") == "" && len(req.Body) > 0 {
+		httpReq.Header.Set("Content-Type", "application/json")
 	}
+	return httpReq, nil
 }
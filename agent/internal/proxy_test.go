@@ -3,9 +3,11 @@ package internal
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	pb "github.com/darkden-lab/argus/backend/pkg/agentpb"
+	"k8s.io/client-go/rest"
 )
 
 func TestProxyHandleRequest_NoCluster(t *testing.T) {
@@ -28,6 +30,61 @@ func TestProxyHandleRequest_NoCluster(t *testing.T) {
 	}
 }
 
+func TestProxyHandleStreamRequest_NoCluster(t *testing.T) {
+	p := &Proxy{}
+
+	var chunks []*pb.K8SResponseChunk
+	p.HandleStreamRequest(context.Background(), &pb.K8SRequest{RequestId: "test-1"}, func(c *pb.K8SResponseChunk) error {
+		chunks = append(chunks, c)
+		return nil
+	})
+
+	if len(chunks) != 1 {
+		t.Fatalf("expected a single chunk, got %d", len(chunks))
+	}
+	if !chunks[0].Final || chunks[0].Error == "" || chunks[0].StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected final 503 error chunk, got %+v", chunks[0])
+	}
+}
+
+func TestProxyHandleStreamRequest_Chunks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("line 1\n"))
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte("line 2\n"))
+	}))
+	defer srv.Close()
+
+	p := &Proxy{config: &rest.Config{Host: srv.URL}, client: srv.Client()}
+
+	var chunks []*pb.K8SResponseChunk
+	p.HandleStreamRequest(context.Background(), &pb.K8SRequest{RequestId: "logs-1", Method: "GET", Path: "/api/v1/namespaces/default/pods/web/log"}, func(c *pb.K8SResponseChunk) error {
+		chunks = append(chunks, c)
+		return nil
+	})
+
+	if len(chunks) < 3 {
+		t.Fatalf("expected header, data and final chunks, got %d", len(chunks))
+	}
+	if chunks[0].StatusCode != http.StatusOK || chunks[0].ContentType != "text/plain" {
+		t.Errorf("unexpected header chunk %+v", chunks[0])
+	}
+	var body string
+	for i, c := range chunks {
+		if c.RequestId != "logs-1" || c.Sequence != int64(i) {
+			t.Errorf("chunk %d: request_id=%q sequence=%d", i, c.RequestId, c.Sequence)
+		}
+		body += string(c.Data)
+	}
+	if body != "line 1\nline 2\n" {
+		t.Errorf("unexpected body %q", body)
+	}
+	if last := chunks[len(chunks)-1]; !last.Final || last.Error != "" {
+		t.Errorf("expected clean final chunk, got %+v", last)
+	}
+}
+
 func TestNewProxy_OutsideCluster(t *testing.T) {
 	// Outside a cluster, NewProxy should not panic.
 	p := NewProxy()
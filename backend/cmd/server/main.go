@@ -4,12 +4,15 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"flag"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -21,18 +24,25 @@ import (
 	"github.com/darkden-lab/argus/backend/internal/ai/providers"
 	"github.com/darkden-lab/argus/backend/internal/ai/rag"
 	"github.com/darkden-lab/argus/backend/internal/audit"
+	"github.com/darkden-lab/argus/backend/internal/backup"
+	"github.com/darkden-lab/argus/backend/internal/capabilities"
 	"github.com/darkden-lab/argus/backend/internal/auth"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
 	"github.com/darkden-lab/argus/backend/internal/config"
 	"github.com/darkden-lab/argus/backend/internal/core"
 	"github.com/darkden-lab/argus/backend/internal/db"
+	"github.com/darkden-lab/argus/backend/internal/health"
+	"github.com/darkden-lab/argus/backend/internal/metrics"
 	mw "github.com/darkden-lab/argus/backend/internal/middleware"
 	"github.com/darkden-lab/argus/backend/internal/notifications"
+	"github.com/darkden-lab/argus/backend/internal/outbound"
 	"github.com/darkden-lab/argus/backend/internal/plugin"
 	"github.com/darkden-lab/argus/backend/internal/proxy"
 	"github.com/darkden-lab/argus/backend/internal/pvcbrowser"
 	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/redact"
 	"github.com/darkden-lab/argus/backend/internal/settings"
+	"github.com/darkden-lab/argus/backend/internal/share"
 	"github.com/darkden-lab/argus/backend/internal/sse"
 	"github.com/darkden-lab/argus/backend/internal/setup"
 	"github.com/darkden-lab/argus/backend/internal/terminal"
@@ -50,20 +60,58 @@ import (
 )
 
 func main() {
+	migrateOnly := flag.Bool("migrate-only", false, "apply database migrations, verify the schema version and exit")
+	flag.Parse()
+
 	cfg := config.Load()
 	if err := cfg.Validate(); err != nil {
 		log.Fatalf("Config validation failed: %v", err)
 	}
 
+	// Outbound TLS and proxy settings, shared by every outbound HTTP client
+	if err := outbound.Configure(outbound.Config{
+		CAFile:   cfg.OutboundCAFile,
+		CertFile: cfg.OutboundClientCert,
+		KeyFile:  cfg.OutboundClientKey,
+		ProxyURL: cfg.OutboundProxy,
+		NoProxy:  cfg.OutboundNoProxy,
+	}); err != nil {
+		log.Fatalf("Outbound connection setup failed: %v", err)
+	}
+
 	// Database
 	ctx := context.Background()
-	database, err := db.New(ctx, cfg.DatabaseURL)
+	if *migrateOnly {
+		if err := runMigrateOnly(ctx, cfg); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		log.Println("Migrations applied, schema is up to date")
+		return
+	}
+
+	var migrationsApplied, grpcListening atomic.Bool
+	database, err := db.Connect(ctx, cfg.DatabaseURL, db.PoolConfig{
+		MaxConns:        cfg.DBMaxConns,
+		MinConns:        cfg.DBMinConns,
+		MaxConnLifetime: cfg.DBMaxConnLifetime,
+	}, cfg.DBConnectTimeout)
 	if err != nil {
+		// Without a database most endpoints are broken; in production exit so
+		// the orchestrator restarts us. In development keep serving, with
+		// /readyz reporting not-ready.
+		if cfg.AppEnv == "production" {
+			log.Fatalf("Database connection failed: %v", err)
+		}
 		log.Printf("WARNING: database connection failed: %v (continuing without DB)", err)
 	} else {
 		defer database.Close()
-		if err := db.RunMigrations(cfg.DatabaseURL, cfg.MigrationsPath); err != nil {
-			log.Printf("WARNING: migrations failed: %v", err)
+		if err := prepareSchema(cfg, cfg.RunMigrations); err != nil {
+			if cfg.MigrationsFailFast {
+				log.Fatalf("Database schema not ready: %v", err)
+			}
+			log.Printf("WARNING: database schema not ready: %v", err)
+		} else {
+			migrationsApplied.Store(true)
 		}
 	}
 
@@ -73,43 +121,75 @@ func main() {
 		pool = database.Pool
 	}
 	clusterMgr := cluster.NewManager(pool, cfg.EncryptionKey)
+	clusterMgr.SetDiscoveryTTL(cfg.DiscoveryCacheTTL)
 	if pool != nil {
 		if err := clusterMgr.LoadExisting(ctx); err != nil {
 			log.Printf("WARNING: failed to load existing clusters: %v", err)
 		}
+		if cfg.InClusterEnabled {
+			if _, err := clusterMgr.RegisterInCluster(ctx, cfg.InClusterName); err != nil {
+				log.Printf("WARNING: failed to register in-cluster cluster: %v", err)
+			}
+		}
 	}
 
 	// gRPC Agent Server
 	agentStore := cluster.NewStore(pool)
 	agentServer := cluster.NewAgentServer(pool, agentStore, cfg.JWTSecret)
+	agentServer.SetAgentVersionPolicy(cfg.MinAgentVersion, cfg.AgentVersionPolicy == "reject")
+	agentServer.SetMaxInFlight(cfg.AgentMaxInFlight)
 	clusterMgr.SetAgentServer(agentServer)
-	go startGRPCServer(cfg, agentServer)
+	go startGRPCServer(cfg, agentServer, &grpcListening)
 
 	// RBAC Engine
 	rbacEngine := rbac.NewEngine(pool)
+	// Optionally defer to the clusters' own RBAC for requests on their resources
+	if mode, err := rbac.ParseDelegationMode(cfg.RBACDelegation); err == nil && mode != rbac.DelegationOff {
+		rbacEngine.SetClusterDelegation(clusterMgr, mode, cfg.RBACDelegationTTL)
+		log.Printf("RBAC: delegating Kubernetes resource checks to clusters (mode=%s, ttl=%s)", mode, cfg.RBACDelegationTTL)
+	}
 	rbacHandlers := rbac.NewHandlers(rbacEngine)
 
 	// JWT & Auth
 	jwtService := auth.NewJWTService(cfg.JWTSecret)
 	authService := auth.NewAuthService(database, jwtService)
 	authHandlers := auth.NewHandlers(authService)
+	// Preload permissions at sign-in so the first page load hits the cache
+	authHandlers.SetPermissionWarmer(rbacEngine)
+	// TOTP second factor for local accounts; secrets are encrypted at rest
+	authHandlers.SetMFAService(auth.NewMFAService(pool, cfg.EncryptionKey))
 	apiKeyService := auth.NewAPIKeyService(pool)
+	serviceAccountService := auth.NewServiceAccountService(pool)
+	shareService := share.NewService(pool)
 
 	// Setup wizard
 	setupService := setup.NewService(pool)
 	setupHandlers := setup.NewHandlers(setupService, authService, jwtService, pool)
 
-	// OIDC (optional)
+	// OIDC (optional). In cookie mode the callback sets HttpOnly session
+	// cookies, which refresh and logout then accept in place of the body.
+	sessionCookies := auth.CookieConfig{
+		Secure:   cfg.AuthCookieSecure,
+		SameSite: auth.ParseSameSite(cfg.AuthCookieSameSite),
+	}
+	if cfg.OIDCTokenDelivery == auth.TokenDeliveryCookie {
+		authHandlers.SetSessionCookies(sessionCookies)
+	}
 	oidcService, err := auth.NewOIDCService(ctx, auth.OIDCConfig{
-		Issuer:       cfg.OIDCIssuer,
-		ClientID:     cfg.OIDCClientID,
-		ClientSecret: cfg.OIDCClientSecret,
-		RedirectURL:  cfg.OIDCRedirectURL,
-		FrontendURL:  cfg.FrontendURL,
+		Issuer:        cfg.OIDCIssuer,
+		ClientID:      cfg.OIDCClientID,
+		ClientSecret:  cfg.OIDCClientSecret,
+		RedirectURL:   cfg.OIDCRedirectURL,
+		FrontendURL:   cfg.FrontendURL,
+		TokenDelivery: cfg.OIDCTokenDelivery,
+		Cookies:       sessionCookies,
 	}, database, jwtService, pool)
 	if err != nil {
 		log.Printf("WARNING: OIDC setup failed: %v (OIDC disabled)", err)
 	}
+	if oidcService != nil {
+		oidcService.SetPermissionWarmer(rbacEngine)
+	}
 
 	// RBAC Guards for endpoint protection
 	settingsWriteGuard := rbac.RBACMiddleware(rbacEngine, "settings", "write")
@@ -118,13 +198,36 @@ func main() {
 	notificationsWriteGuard := rbac.RBACMiddleware(rbacEngine, "notifications", "write")
 	aiWriteGuard := rbac.RBACMiddleware(rbacEngine, "ai", "write")
 	auditReadGuard := rbac.RBACMiddleware(rbacEngine, "audit", "read")
-	_ = rbac.RBACMiddleware(rbacEngine, "clusters", "read")   // clustersReadGuard — available for future endpoint protection
-	_ = rbac.RBACMiddleware(rbacEngine, "settings", "read")   // settingsReadGuard — available for future endpoint protection
-	_ = rbac.RBACMiddleware(rbacEngine, "terminal", "write")  // terminalWriteGuard — available for future endpoint protection
+	settingsReadGuard := rbac.RBACMiddleware(rbacEngine, "settings", "read")
+	// config:backup is not granted by any built-in role except admin's wildcard
+	configBackupGuard := rbac.RBACMiddleware(rbacEngine, "config", "backup")
+	// clusters:maintenance is likewise only granted by admin's wildcard
+	clustersMaintenanceGuard := rbac.RBACMiddleware(rbacEngine, "clusters", "maintenance")
+	_ = rbac.RBACMiddleware(rbacEngine, "clusters", "read")  // clustersReadGuard — available for future endpoint protection
+	_ = rbac.RBACMiddleware(rbacEngine, "terminal", "write") // terminalWriteGuard — available for future endpoint protection
 
 	// Audit Log
 	auditStore := audit.NewStore(pool)
 	auditHandlers := audit.NewHandlers(auditStore, auditReadGuard)
+	// Handlers that audit their own actions take a nil store without a database
+	var handlerAuditStore *audit.Store
+	if pool != nil {
+		handlerAuditStore = auditStore
+	}
+
+	// Internal metrics, served at /metrics
+	metricsRegistry := metrics.NewRegistry()
+	agentServer.RegisterMetrics(metricsRegistry)
+
+	// Audit middleware writes through a background queue unless AUDIT_QUEUE_SIZE is 0
+	var auditRecorder audit.Recorder = auditStore
+	var auditWriter *audit.Writer
+	if cfg.AuditQueueSize > 0 {
+		auditWriter = audit.NewWriter(auditStore, cfg.AuditQueueSize, cfg.AuditOverflow, cfg.AuditBlockTimeout)
+		auditWriter.Start()
+		auditWriter.RegisterMetrics(metricsRegistry)
+		auditRecorder = auditWriter
+	}
 
 	// Plugin Engine
 	pluginEngine := plugin.NewEngine(pool)
@@ -137,6 +240,36 @@ func main() {
 	hub := ws.NewHub()
 	go hub.Run()
 	wsHandler := ws.NewWSHandler(hub, jwtService)
+	wsHandler.SetPermissionWarmer(rbacEngine)
+	// Live watches on any GVR, scoped to what the user may read
+	hub.SetWatchSource(cluster.NewResourceWatcher(clusterMgr), func(ctx context.Context, userID string, spec ws.WatchSpec) bool {
+		allowed, err := rbacEngine.Evaluate(ctx, rbac.Request{
+			UserID:      userID,
+			Action:      "read",
+			Resource:    rbac.ResourceCategory(spec.Resource),
+			ClusterID:   spec.Cluster,
+			Namespace:   spec.Namespace,
+			K8sResource: rbac.QualifiedResource(spec.Group, spec.Resource),
+		})
+		return err == nil && allowed
+	})
+	// Mask secret material in Kubernetes objects for users without secrets:reveal
+	var responseRedactor *redact.Redactor
+	if cfg.ResponseRedaction {
+		responseRedactor = redact.NewRedactor(cfg.RedactKeys)
+		hub.SetWatchRedaction(func(obj json.RawMessage) json.RawMessage {
+			return responseRedactor.RedactJSON(obj)
+		}, func(ctx context.Context, userID string, spec ws.WatchSpec) bool {
+			allowed, err := rbacEngine.Evaluate(ctx, rbac.Request{
+				UserID:    userID,
+				Action:    redact.RevealAction,
+				Resource:  redact.RevealResource,
+				ClusterID: spec.Cluster,
+			})
+			return err == nil && allowed
+		})
+		log.Println("Response redaction enabled")
+	}
 
 	// Notifications System
 	broker, err := notifications.NewBroker(cfg)
@@ -144,6 +277,8 @@ func main() {
 		log.Printf("WARNING: notification broker setup failed: %v", err)
 	}
 	var notifHandlers *notifications.Handlers
+	var notifChannelStore *notifications.ChannelStore
+	var notifRouter *notifications.Router
 	if broker != nil {
 		defer broker.Close() //nolint:errcheck // best-effort cleanup on shutdown
 
@@ -151,27 +286,71 @@ func main() {
 		prefStore := notifications.NewPreferencesStore(pool)
 		chanStore := notifications.NewChannelStore(pool)
 		tmplStore := notifications.NewTemplateStore(pool)
-		notifRouter := notifications.NewRouter(notifStore, prefStore, chanStore)
+		notifRouter = notifications.NewRouter(notifStore, prefStore, chanStore)
 
 		// Wire template provider so email channels use DB-stored templates
 		tmplProvider := notifications.NewDBTemplateProvider(tmplStore)
 		notifRouter.SetTemplateProvider(tmplProvider)
 
-		// EventProducer: hooks into K8s watch events and publishes to broker
+		// Snoozes and maintenance windows keep notifications off external channels
+		muteStore := notifications.NewMuteStore(pool)
+		notifRouter.SetMuteStore(muteStore)
+
+		// Channel deliveries are queued; failed sends are retried with backoff
+		deliveryStore := notifications.NewDeliveryStore(pool)
+		notifRouter.SetDeliveryStore(deliveryStore)
+		deliveryWorker := notifications.NewDeliveryWorker(notifRouter, deliveryStore)
+		deliveryWorker.Start()
+		defer deliveryWorker.Stop()
+
+		// EventProducer: hooks into K8s watch events and publishes to broker.
+		// Kubernetes Events are filtered by type and reason, Warning by default
 		producer := notifications.NewEventProducer(broker)
+		producer.SetEventFilter(notifications.EventFilter{
+			Types:   cfg.NotificationEventTypes,
+			Reasons: cfg.NotificationEventReasons,
+		})
 		producer.HookIntoHub(hub)
 
+		// Unschedulable pods and blocking disruption budgets are checked for
+		// periodically, whether or not anyone is watching them
+		if pool != nil {
+			schedulingWatcher := notifications.NewSchedulingWatcher(broker, clusterMgr, func(ctx context.Context) ([]notifications.WatchedCluster, error) {
+				clusters, err := clusterMgr.ListClusters(ctx)
+				if err != nil {
+					return nil, err
+				}
+				var watched []notifications.WatchedCluster
+				for _, c := range clusters {
+					if c.Status == "connected" {
+						watched = append(watched, notifications.WatchedCluster{ID: c.ID, Name: c.Name})
+					}
+				}
+				return watched, nil
+			})
+			schedulingWatcher.Start()
+			defer schedulingWatcher.Stop()
+		}
+
+		// Digest aggregator: the router buffers events for preferences with
+		// a digest frequency, so it is set up before the consumer starts
+		digest := notifications.NewDigestAggregator(prefStore, chanStore, notifStore, notifRouter.GetChannels())
+		digest.SetDefaults(cfg.NotificationDigestFrequency, cfg.NotificationDigestGroupBy)
+		digest.SetLinkBase(cfg.FrontendURL)
+		notifRouter.SetDigestAggregator(digest)
+		digest.Start()
+		defer digest.Stop()
+
 		// Consumer: subscribes to all topics and routes to channels
 		consumer := notifications.NewConsumer(broker, notifRouter)
 		if err := consumer.Start(); err != nil {
 			log.Printf("WARNING: notification consumer failed to start: %v", err)
 		}
 
-		// Digest aggregator
-		digest := notifications.NewDigestAggregator(prefStore, chanStore, notifStore, notifRouter.GetChannels())
-		digest.Start()
-
 		notifHandlers = notifications.NewHandlers(notifStore, prefStore, chanStore, tmplStore, notifRouter, cfg.EncryptionKey, notificationsWriteGuard)
+		notifHandlers.SetMuteStore(muteStore)
+		notifHandlers.SetDeliveryStore(deliveryStore)
+		notifChannelStore = chanStore
 		log.Println("Notifications system initialized")
 	}
 
@@ -181,15 +360,40 @@ func main() {
 	// Rate limiting: 100 req/s per IP with burst of 200
 	r.Use(mw.RateLimitMiddleware(100, 200))
 
-	// Health check (no auth)
-	r.HandleFunc("/healthz", healthzHandler).Methods("GET")
+	// Request body limits: 1 MB unless the route needs less (credentials) or
+	// more (manifests, chart values, file uploads). Oversized bodies get 413.
+	r.Use(mw.BodyLimitMiddleware(1<<20,
+		mw.BodyLimit{PathPrefix: "/api/auth/", MaxBytes: 64 << 10},
+		mw.BodyLimit{PathPrefix: "/api/setup/", MaxBytes: 64 << 10},
+		mw.BodyLimit{PathPrefix: "/api/clusters/{clusterID}/resources/", MaxBytes: 2 << 20},
+		mw.BodyLimit{PathPrefix: "/api/clusters/{clusterID}/bulk", MaxBytes: 2 << 20},
+		mw.BodyLimit{PathPrefix: "/api/clusters/{clusterID}/manifests/validate", MaxBytes: 2 << 20},
+		mw.BodyLimit{PathPrefix: "/api/clusters/compare", MaxBytes: 2 << 20},
+		mw.BodyLimit{PathPrefix: "/api/plugins/helm/", MaxBytes: 8 << 20},
+		mw.BodyLimit{PathPrefix: "/api/clusters/{clusterID}/pvc-browser/sessions/{sessionID}/write", MaxBytes: 10 << 20},
+		mw.BodyLimit{PathPrefix: "/api/clusters/{clusterID}/pvc-browser/sessions/{sessionID}/upload", MaxBytes: 100 << 20},
+		mw.BodyLimit{PathPrefix: "/api/clusters/{clusterID}/namespaces/{namespace}/pods/{pod}/files", MaxBytes: 100 << 20},
+	))
+
+	// Liveness and readiness probes (no auth)
+	healthChecker := health.NewChecker()
+	healthChecker.Register("database", health.DatabaseCheck(pool))
+	healthChecker.Register("migrations", health.FlagCheck(&migrationsApplied, "migrations not applied"))
+	healthChecker.Register("grpc", health.FlagCheck(&grpcListening, "gRPC listener not started"))
+	healthChecker.RegisterRoutes(r)
+
+	// Prometheus metrics (no auth)
+	metricsRegistry.RegisterRoutes(r)
 
 	// API documentation (no auth)
 	docs.RegisterRoutes(r)
 
-	// Agent install script (no auth - must be curl-able)
+	// Agent install script (no auth - must be curl-able, optionally only via signed links)
 	agentRegistry := cluster.NewAgentRegistry(pool)
 	agentHandlers := cluster.NewAgentHandlers(clusterMgr, agentRegistry)
+	if cfg.AgentInstallScript == "signed" {
+		agentHandlers.RequireSignedInstallLinks(cfg.JWTSecret, cfg.AgentInstallLinkTTL)
+	}
 	agentHandlers.RegisterPublicRoutes(r)
 
 	// Setup wizard routes (public, no auth required)
@@ -203,6 +407,10 @@ func main() {
 	authSubrouter := r.PathPrefix("").Subrouter()
 	authSubrouter.Use(mw.StrictRateLimitMiddleware(10, 20))
 	authHandlers.RegisterRoutes(authSubrouter)
+
+	// Read-only share links: redemption needs no account
+	shareHandlers := share.NewHandlers(shareService, clusterMgr, rbacEngine, handlerAuditStore)
+	shareHandlers.RegisterPublicRoutes(authSubrouter)
 	if oidcService != nil && oidcService.Enabled() {
 		oidcService.RegisterRoutes(authSubrouter)
 		log.Println("OIDC authentication enabled")
@@ -210,11 +418,20 @@ func main() {
 
 	// Protected routes
 	protected := r.PathPrefix("").Subrouter()
-	protected.Use(mw.AuthMiddleware(jwtService, apiKeyService))
+	protected.Use(mw.AuthMiddlewareWithShareSessions(jwtService, apiKeyService, serviceAccountService, shareService))
 	// Guard: block all protected routes if initial setup is pending
 	protected.Use(setup.GuardMiddleware(setupService))
 	if pool != nil {
-		protected.Use(audit.Middleware(auditStore))
+		protected.Use(audit.Middleware(auditRecorder))
+	}
+	// Confine share sessions to reading resources within their link's scope
+	protected.Use(share.SessionGuard(rbacEngine))
+	// Confine kubectl exec credentials to the proxy of their cluster
+	protected.Use(proxy.CredentialGuard())
+	// Refuse writes to clusters in maintenance mode
+	protected.Use(cluster.MaintenanceMiddleware(clusterMgr))
+	if responseRedactor != nil {
+		protected.Use(redact.Middleware(rbacEngine, responseRedactor))
 	}
 
 	// Auth protected routes (/api/auth/me, /api/auth/permissions)
@@ -237,33 +454,144 @@ func main() {
 	apiKeyHandlers := auth.NewAPIKeyHandlers(apiKeyService)
 	apiKeyHandlers.RegisterRoutes(protected)
 
+	// Service account token routes for CI/automation (require roles RBAC)
+	serviceAccountHandlers := auth.NewServiceAccountHandlers(serviceAccountService,
+		rbac.RBACMiddleware(rbacEngine, "roles", "read"), rbac.RBACMiddleware(rbacEngine, "roles", "write"))
+	serviceAccountHandlers.RegisterRoutes(protected)
+
+	// Share link management (minting requires read access to what is shared; audited inside the handler)
+	shareHandlers.RegisterRoutes(protected)
+
 	// OIDC group -> role mapping routes (write endpoints require settings:write RBAC)
 	oidcMappingHandlers := auth.NewOIDCMappingHandlers(pool, rbac.RBACMiddleware(rbacEngine, "settings", "write"))
 	oidcMappingHandlers.RegisterRoutes(protected)
 
-	// Cluster routes
+	// Cluster routes (the maintenance toggle requires clusters:maintenance RBAC and is audited inside the handler)
 	clusterHandlers := cluster.NewHandlers(clusterMgr, clustersWriteGuard)
+	clusterHandlers.SetMaintenance(clustersMaintenanceGuard, handlerAuditStore)
 	clusterHandlers.RegisterRoutes(protected)
 
+	// Cluster groups (write endpoints require clusters:write RBAC; group-scoped permissions follow membership)
+	clusterGroupHandlers := cluster.NewGroupHandlers(agentStore, rbacEngine, clustersWriteGuard)
+	clusterGroupHandlers.RegisterRoutes(protected)
+
 	// Agent token management routes (protected)
 	agentHandlers.RegisterRoutes(protected)
 
+	// Labels, annotations and namespace creation for editor and AI applies
+	applyPolicy := cluster.ApplyPolicy{
+		CreateNamespace: cfg.ApplyCreateNamespace,
+		Labels:          cfg.ApplyLabels,
+		Annotations:     cfg.ApplyAnnotations,
+	}
+	if err := applyPolicy.Validate(); err != nil {
+		log.Fatalf("Invalid APPLY_LABELS or APPLY_ANNOTATIONS: %v", err)
+	}
+
 	// Core resource routes
-	resourceHandler := core.NewResourceHandler(clusterMgr)
+	discoveryCache := core.NewDiscoveryCache(clusterMgr)
+	resourceHandler := core.NewResourceHandler(clusterMgr, discoveryCache)
+	resourceHandler.SetApplyPolicy(applyPolicy, rbacEngine)
 	resourceHandler.RegisterRoutes(protected)
 
+	// Resource explorer kind list (API discovery, including CRDs)
+	discoveryHandler := core.NewDiscoveryHandler(discoveryCache)
+	discoveryHandler.RegisterRoutes(protected)
+
 	// Convenience routes (namespaces, nodes, events)
 	convenienceHandlers := core.NewConvenienceHandlers(clusterMgr, pool, clustersWriteGuard)
 	convenienceHandlers.RegisterRoutes(protected)
 
-	// Pod logs endpoint (auth handled internally to support EventSource SSE)
-	logsHandler := core.NewLogsHandler(clusterMgr, jwtService)
+	// Pod logs and log search endpoints (auth handled internally to support EventSource SSE)
+	logsHandler := core.NewLogsHandler(clusterMgr, jwtService, rbacEngine)
 	logsHandler.RegisterRoutes(r)
 
 	// Network Policy simulator
 	netpolSimHandler := core.NewNetPolSimulatorHandler(clusterMgr)
 	netpolSimHandler.RegisterRoutes(protected)
 
+	// Bulk operations and fleet restarts (per-object RBAC enforced inside the handler)
+	bulkHandler := core.NewBulkHandler(clusterMgr, rbacEngine)
+	bulkHandler.RegisterRoutes(protected)
+
+	// Cross-cluster config drift comparison (read RBAC enforced per resource on both clusters; Secret values need secrets:reveal)
+	driftHandler := core.NewDriftHandler(clusterMgr, rbacEngine)
+	driftHandler.SetRedactor(responseRedactor)
+	driftHandler.RegisterRoutes(protected)
+
+	// Owner/ownership tree of an object (read RBAC enforced per object inside the handler)
+	ownerTreeHandler := core.NewOwnerTreeHandler(clusterMgr, rbacEngine, discoveryCache)
+	ownerTreeHandler.RegisterRoutes(protected)
+
+	// Describe-style aggregated object view (read RBAC enforced per section inside the handler)
+	describeHandler := core.NewDescribeHandler(clusterMgr, rbacEngine, discoveryCache)
+	describeHandler.RegisterRoutes(protected)
+
+	// Container image digests and tag hygiene of a pod or workload (read RBAC enforced per object inside the handler)
+	imageInfoHandler := core.NewImageInfoHandler(clusterMgr, rbacEngine, discoveryCache)
+	imageInfoHandler.RegisterRoutes(protected)
+
+	// Effective env and mounted config of a pod (pods read RBAC enforced inside the handler; Secret values need secrets:reveal)
+	podConfigHandler := core.NewPodConfigHandler(clusterMgr, rbacEngine)
+	podConfigHandler.SetRedactor(responseRedactor)
+	podConfigHandler.RegisterRoutes(protected)
+
+	// Rolling node drain (nodes write RBAC enforced inside the handler; each cordon and drain is audited)
+	nodeDrainHandler := core.NewNodeDrainHandler(clusterMgr, rbacEngine)
+	if pool != nil {
+		nodeDrainHandler.SetAuditLog(auditStore)
+	}
+	nodeDrainHandler.RegisterRoutes(protected)
+
+	// Deep link resolver (read RBAC enforced per object inside the handler)
+	deepLinkHandler := core.NewDeepLinkHandler(clusterMgr, rbacEngine, discoveryCache)
+	deepLinkHandler.RegisterRoutes(protected)
+
+	// ServiceAccount permission check via SubjectAccessReview (serviceaccounts read RBAC enforced inside the handler)
+	saAccessHandler := core.NewServiceAccountAccessHandler(clusterMgr, rbacEngine)
+	saAccessHandler.RegisterRoutes(protected)
+
+	// Apply manifests from allowlisted URLs or git sources, and dry-run pasted ones (per-object RBAC enforced inside the handler)
+	manifestImportHandler, err := core.NewManifestImportHandler(clusterMgr, rbacEngine, discoveryCache, core.ManifestImportOptions{
+		AllowedURLs: cfg.ManifestAllowedURLs,
+		GitSources:  cfg.ManifestGitSources,
+		MaxBytes:    cfg.ManifestMaxBytes,
+	})
+	if err != nil {
+		log.Fatalf("Manifest import setup failed: %v", err)
+	}
+	manifestImportHandler.RegisterRoutes(protected)
+
+	// Namespace snapshots as clean manifest bundles (read RBAC enforced per resource type inside the handler)
+	manifestExportHandler := core.NewManifestExportHandler(clusterMgr, rbacEngine, discoveryCache)
+	manifestExportHandler.SetRedactor(responseRedactor)
+	manifestExportHandler.RegisterRoutes(protected)
+
+	// Capacity report: requests/limits vs usage (namespace RBAC enforced inside the handler)
+	capacityHandler := core.NewCapacityHandler(clusterMgr, rbacEngine)
+	capacityHandler.RegisterRoutes(protected)
+
+	// Advisory request and HPA recommendations from sampled usage (namespace RBAC enforced inside the handler)
+	recommendationHandler := core.NewRecommendationHandler(clusterMgr, rbacEngine)
+	recommendationHandler.RegisterRoutes(protected)
+
+	// Per-cluster activity feed merging audit entries, events and Helm revisions
+	// (audit:read and namespace RBAC enforced per source inside the handler)
+	activityHandler := core.NewActivityHandler(clusterMgr, rbacEngine)
+	if pool != nil {
+		activityHandler.SetAuditLog(auditStore)
+	}
+	activityHandler.RegisterRoutes(protected)
+
+	// Live kubectl-style tables of a namespace's pods, deployments and services,
+	// sharing watches with the WebSocket hub (read RBAC enforced by the hub)
+	namespaceWatchHandler := core.NewNamespaceWatchHandler(hub)
+	namespaceWatchHandler.RegisterRoutes(protected)
+
+	// Per-user default and favorite namespaces (namespace RBAC enforced inside the handler)
+	namespacePrefsHandler := core.NewNamespacePrefsHandler(pool, rbacEngine)
+	namespacePrefsHandler.RegisterRoutes(protected)
+
 	// Audit log routes
 	auditHandlers.RegisterRoutes(protected)
 
@@ -290,17 +618,27 @@ func main() {
 	pluginEngine.RegisterAllRoutes(pluginRouter, clusterMgr)
 	pluginEngine.RegisterAllWatchers(hub, clusterMgr)
 
+	// Configuration bundle export/import (config:backup RBAC, audited inside the handler)
+	backupService := backup.NewService(pool, cfg.EncryptionKey, rbacEngine, pluginEngine)
+	backupHandlers := backup.NewHandlers(backupService, configBackupGuard, handlerAuditStore)
+	backupHandlers.RegisterRoutes(protected)
+
 	// K8s Reverse Proxy (protected)
 	k8sProxy := proxy.NewK8sProxy(clusterMgr, rbacEngine)
+	k8sProxy.SetCredentialIssuer(jwtService)
 	k8sProxy.RegisterRoutes(protected)
 
 	// Legacy WebSocket (kept for backwards compatibility during migration)
 	wsHandler.RegisterRoutes(r)
 
-	// Legacy Terminal WebSocket
-	terminalHandler := terminal.NewHandler(jwtService, clusterMgr)
+	// Legacy Terminal WebSocket (terminal:write RBAC enforced on the handshake and on context changes)
+	terminalHandler := terminal.NewHandler(jwtService, clusterMgr, rbacEngine)
 	terminalHandler.RegisterRoutes(r)
 
+	// Pod file upload/download over exec (terminal:write RBAC and audit enforced inside the handler)
+	fileTransferHandler := terminal.NewFileTransferHandler(clusterMgr, rbacEngine, handlerAuditStore)
+	fileTransferHandler.RegisterRoutes(protected)
+
 	// PVC Browser
 	pvcSessionMgr := pvcbrowser.NewSessionManager()
 	pvcSessionMgr.StartCleanup()
@@ -336,6 +674,17 @@ func main() {
 
 	// Create Service first so the embedder can track its active provider.
 	aiService := ai.NewService(aiProvider, nil, clusterMgr, pluginEngine, pool, aiCfg, aiMemoryStore)
+	aiService.SetAuthorizer(rbacEngine)
+	aiService.SetApplyPolicy(applyPolicy)
+	aiService.Breaker().RegisterMetrics(metricsRegistry)
+
+	// Named providers chat requests may select (AI_PROVIDERS), built once
+	// since they are only configured through env
+	namedAIProviders := make(map[string]ai.LLMProvider, len(aiCfg.Providers))
+	for _, p := range aiCfg.Providers {
+		namedAIProviders[p.Name] = aiProviderFactory(aiCfg.WithProvider(p))
+	}
+	aiService.SetProviders(namedAIProviders)
 
 	var aiIndexer *rag.Indexer
 	if pool != nil {
@@ -372,7 +721,81 @@ func main() {
 		aiConvHandlers.RegisterRoutes(protected)
 	}
 
-	log.Printf("AI system initialized (provider=%s, enabled=%v)", aiCfg.Provider, aiCfg.Enabled)
+	log.Printf("AI system initialized (provider=%s, named_providers=%d, enabled=%v)", aiCfg.Provider, len(aiCfg.Providers), aiCfg.Enabled)
+
+	// Capabilities (which optional subsystems are active, read from live service state)
+	capabilityHandlers := capabilities.NewHandlers(oidcService, aiService, pluginEngine)
+	if notifChannelStore != nil {
+		capabilityHandlers.SetNotificationChannels(notifChannelStore)
+	}
+	capabilityHandlers.RegisterRoutes(protected)
+
+	// Admin diagnostics: on-demand self-test of every subsystem (settings:read RBAC)
+	diagnostics := health.NewDiagnostics(settingsReadGuard)
+	diagnostics.Register("database", health.DatabaseCheck(pool))
+	diagnostics.Register("migrations", func(context.Context) error {
+		if pool == nil {
+			return errors.New("database not connected")
+		}
+		return db.CheckSchemaVersion(cfg.DatabaseURL, cfg.MigrationsPath)
+	})
+	diagnostics.Register("grpc", func(ctx context.Context) error {
+		if !grpcListening.Load() {
+			return errors.New("gRPC listener not started")
+		}
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", "localhost:"+cfg.GRPCPort)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	})
+	diagnostics.RegisterTargets("clusters", func(ctx context.Context) (map[string]health.CheckFunc, error) {
+		if pool == nil {
+			return nil, errors.New("database not connected")
+		}
+		clusters, err := clusterMgr.ListClusters(ctx)
+		if err != nil {
+			return nil, err
+		}
+		probes := make(map[string]health.CheckFunc, len(clusters))
+		for _, c := range clusters {
+			id := c.ID
+			probes[c.Name+" ("+id+")"] = func(ctx context.Context) error { return clusterMgr.Ping(ctx, id) }
+		}
+		return probes, nil
+	})
+	diagnostics.Register("oidc", func(ctx context.Context) error {
+		if !oidcService.Enabled() {
+			return health.Skip("OIDC is not configured")
+		}
+		return oidcService.CheckDiscovery(ctx)
+	})
+	diagnostics.Register("ai", func(ctx context.Context) error {
+		if enabled, _ := aiService.Status(); !enabled {
+			return health.Skip("AI assistant is not enabled")
+		}
+		return aiService.CheckProvider(ctx)
+	})
+	diagnostics.RegisterTargets("notifications", func(ctx context.Context) (map[string]health.CheckFunc, error) {
+		if notifRouter == nil {
+			return nil, health.Skip("notification system is not running")
+		}
+		if pool == nil {
+			return nil, errors.New("database not connected")
+		}
+		configs, err := notifChannelStore.ListEnabled(ctx)
+		if err != nil {
+			return nil, err
+		}
+		probes := make(map[string]health.CheckFunc, len(configs))
+		for _, ch := range configs {
+			id := ch.ID
+			probes[ch.Name+" ("+id+")"] = func(ctx context.Context) error { return notifRouter.CheckChannel(ctx, id) }
+		}
+		return probes, nil
+	})
+	diagnostics.RegisterRoutes(protected)
 
 	// SSE Hub + Handlers (replaces Socket.IO)
 	sseHub := sse.NewHub()
@@ -433,6 +856,34 @@ func main() {
 
 	log.Println("Server stopped")
 
+	if auditWriter != nil {
+		drainCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		auditWriter.Close(drainCtx)
+		cancel()
+	}
+}
+
+// prepareSchema applies pending migrations (when runMigrations is set) and
+// verifies the schema version matches the migrations shipped with this binary.
+func prepareSchema(cfg *config.Config, runMigrations bool) error {
+	if runMigrations {
+		if err := db.RunMigrations(cfg.DatabaseURL, cfg.MigrationsPath); err != nil {
+			return err
+		}
+	}
+	return db.CheckSchemaVersion(cfg.DatabaseURL, cfg.MigrationsPath)
+}
+
+// runMigrateOnly implements --migrate-only: wait for the database, apply
+// migrations and verify the result, so migrations can run as a separate Job
+// before the app is rolled out.
+func runMigrateOnly(ctx context.Context, cfg *config.Config) error {
+	database, err := db.Connect(ctx, cfg.DatabaseURL, db.PoolConfig{MaxConns: 1}, cfg.DBConnectTimeout)
+	if err != nil {
+		return err
+	}
+	database.Close()
+	return prepareSchema(cfg, true)
 }
 
 func registerPlugins(engine *plugin.Engine, pool *pgxpool.Pool) {
@@ -473,13 +924,9 @@ func registerPluginWithError[T plugin.Plugin](engine *plugin.Engine, name string
 	}
 }
 
-func healthzHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
-}
-
-func startGRPCServer(cfg *config.Config, agentSrv *cluster.AgentServer) {
+// startGRPCServer serves the agent gRPC API. listening is set while the
+// listener is accepting connections and feeds the readiness probe.
+func startGRPCServer(cfg *config.Config, agentSrv *cluster.AgentServer, listening *atomic.Bool) {
 	var opts []grpc.ServerOption
 
 	if cfg.GRPCTLSCert != "" && cfg.GRPCTLSKey != "" {
@@ -503,6 +950,8 @@ func startGRPCServer(cfg *config.Config, agentSrv *cluster.AgentServer) {
 	}
 
 	log.Printf("gRPC agent server listening on :%s", cfg.GRPCPort)
+	listening.Store(true)
+	defer listening.Store(false)
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("gRPC server failed: %v", err)
 	}
@@ -547,7 +996,7 @@ func corsMiddleware(next http.Handler) http.Handler {
 			}
 		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Requested-With")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 		w.Header().Set("Access-Control-Max-Age", "86400")
 
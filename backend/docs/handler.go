@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 //go:embed openapi.yaml
@@ -16,7 +18,7 @@ func RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/api/docs/openapi.yaml", func(w http.ResponseWriter, r *http.Request) {
 		data, err := openAPISpec.ReadFile("openapi.yaml")
 		if err != nil {
-			http.Error(w, "spec not found", http.StatusInternalServerError)
+			httputil.WriteError(w, http.StatusInternalServerError, "spec not found")
 			return
 		}
 		w.Header().Set("Content-Type", "application/yaml")
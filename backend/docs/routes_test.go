@@ -0,0 +1,229 @@
+package docs
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// route is an HTTP route registered on a gorilla/mux router.
+type route struct {
+	method string
+	path   string
+	pos    string
+}
+
+// pathParam matches mux path variables, including regexp constraints such as
+// {path:.*}, so differently named parameters compare equal.
+var pathParam = regexp.MustCompile(`\{[^}]*\}`)
+
+func normalizePath(p string) string {
+	return pathParam.ReplaceAllString(p, "{}")
+}
+
+// registeredRoutes statically collects routes registered in the backend
+// source with literal paths: r.HandleFunc("/path", h).Methods("GET"), where r
+// may be a subrouter created with PathPrefix("/prefix").Subrouter() in the
+// same function.
+func registeredRoutes(t *testing.T, root string) []route {
+	t.Helper()
+	fset := token.NewFileSet()
+	var routes []route
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); name == "node_modules" || name == "testdata" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			routes = append(routes, routesInFunc(fset, fn.Body)...)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to scan sources: %v", err)
+	}
+	return routes
+}
+
+func routesInFunc(fset *token.FileSet, body *ast.BlockStmt) []route {
+	prefixes := map[string]string{} // subrouter variable -> path prefix
+	var routes []route
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			// sub := r.PathPrefix("/api/x").Subrouter()
+			if len(n.Lhs) != 1 || len(n.Rhs) != 1 {
+				return true
+			}
+			lhs, ok := n.Lhs[0].(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if prefix, ok := subrouterPrefix(n.Rhs[0], prefixes); ok {
+				prefixes[lhs.Name] = prefix
+			}
+		case *ast.CallExpr:
+			// r.HandleFunc("/path", h).Methods("GET", ...)
+			sel, ok := n.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "Methods" {
+				return true
+			}
+			inner, ok := sel.X.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			innerSel, ok := inner.Fun.(*ast.SelectorExpr)
+			if !ok || (innerSel.Sel.Name != "HandleFunc" && innerSel.Sel.Name != "Handle") || len(inner.Args) == 0 {
+				return true
+			}
+			path, ok := stringLit(inner.Args[0])
+			if !ok {
+				return true
+			}
+			prefix := ""
+			if recv, ok := innerSel.X.(*ast.Ident); ok {
+				prefix = prefixes[recv.Name]
+			}
+			for _, arg := range n.Args {
+				method, ok := methodArg(arg)
+				if !ok || method == http.MethodOptions {
+					continue
+				}
+				routes = append(routes, route{method: method, path: prefix + path, pos: fset.Position(n.Pos()).String()})
+			}
+		}
+		return true
+	})
+	return routes
+}
+
+// subrouterPrefix resolves X.PathPrefix("lit").Subrouter() to X's prefix + lit.
+func subrouterPrefix(expr ast.Expr, prefixes map[string]string) (string, bool) {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Subrouter" {
+		return "", false
+	}
+	pp, ok := sel.X.(*ast.CallExpr)
+	if !ok || len(pp.Args) != 1 {
+		return "", false
+	}
+	ppSel, ok := pp.Fun.(*ast.SelectorExpr)
+	if !ok || ppSel.Sel.Name != "PathPrefix" {
+		return "", false
+	}
+	lit, ok := stringLit(pp.Args[0])
+	if !ok {
+		return "", false
+	}
+	parent := ""
+	if recv, ok := ppSel.X.(*ast.Ident); ok {
+		parent = prefixes[recv.Name]
+	}
+	return parent + lit, true
+}
+
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// methodArg accepts "GET" literals and http.MethodGet-style constants.
+func methodArg(expr ast.Expr) (string, bool) {
+	if s, ok := stringLit(expr); ok {
+		return strings.ToUpper(s), true
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || !strings.HasPrefix(sel.Sel.Name, "Method") {
+		return "", false
+	}
+	return strings.ToUpper(strings.TrimPrefix(sel.Sel.Name, "Method")), true
+}
+
+// specOperations returns the set of "METHOD normalized-path" pairs in the spec.
+func specOperations(t *testing.T) map[string]bool {
+	t.Helper()
+	data, err := openAPISpec.ReadFile("openapi.yaml")
+	if err != nil {
+		t.Fatalf("failed to read spec: %v", err)
+	}
+	var spec struct {
+		Paths map[string]map[string]interface{} `yaml:"paths"`
+	}
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("failed to parse spec: %v", err)
+	}
+	ops := make(map[string]bool)
+	for path, item := range spec.Paths {
+		for method := range item {
+			ops[strings.ToUpper(method)+" "+normalizePath(path)] = true
+		}
+	}
+	return ops
+}
+
+// TestSpecCoversRegisteredRoutes fails when a route registered in the backend
+// is missing from openapi.yaml, keeping the published spec in sync with the
+// real handlers.
+func TestSpecCoversRegisteredRoutes(t *testing.T) {
+	routes := registeredRoutes(t, "..")
+	if len(routes) < 100 {
+		t.Fatalf("found only %d routes; the source scanner is probably broken", len(routes))
+	}
+	ops := specOperations(t)
+
+	var missing []string
+	for _, r := range routes {
+		if !ops[r.method+" "+normalizePath(r.path)] {
+			missing = append(missing, r.method+" "+r.path+" ("+r.pos+")")
+		}
+	}
+	sort.Strings(missing)
+	for _, m := range missing {
+		t.Errorf("route missing from openapi.yaml: %s", m)
+	}
+}
+
+func TestSpecIsServed(t *testing.T) {
+	ops := specOperations(t)
+	for _, op := range []string{"GET /api/docs/openapi.yaml", "POST /api/auth/login", "GET /api/clusters"} {
+		if !ops[op] {
+			t.Errorf("expected spec to document %s", op)
+		}
+	}
+}
@@ -3,14 +3,19 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/darkden-lab/argus/backend/internal/ai/rag"
+	"github.com/darkden-lab/argus/backend/internal/ai/tools"
 	"github.com/darkden-lab/argus/backend/internal/crypto"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -27,6 +32,7 @@ type AdminHandlers struct {
 	providerFactory ProviderFactory
 	encryptionKey   string
 	reloadMu        sync.Mutex
+	models          *modelCache
 }
 
 // NewAdminHandlers creates admin API handlers for AI.
@@ -39,6 +45,7 @@ func NewAdminHandlers(pool *pgxpool.Pool, indexer *rag.Indexer, config AIConfig,
 		service:         service,
 		providerFactory: factory,
 		encryptionKey:   encryptionKey,
+		models:          newModelCache(modelCacheTTL),
 	}
 }
 
@@ -56,7 +63,9 @@ func (h *AdminHandlers) RegisterRoutes(r *mux.Router) {
 	}
 	writeAI.HandleFunc("/config", h.updateConfig).Methods(http.MethodPut)
 	writeAI.HandleFunc("/config/test", h.testConnection).Methods(http.MethodPost)
+	writeAI.HandleFunc("/config/models", h.listModels).Methods(http.MethodPost)
 	writeAI.HandleFunc("/rag/reindex", h.triggerReindex).Methods(http.MethodPost)
+	writeAI.HandleFunc("/breaker/reset", h.resetBreaker).Methods(http.MethodPost)
 }
 
 // AIStatus is the response for the /api/ai/status endpoint.
@@ -67,6 +76,12 @@ type AIStatus struct {
 	Model               string `json:"model"`
 	ToolPermissionLevel string `json:"tool_permission_level"`
 	Message             string `json:"message,omitempty"`
+	// Breaker is the state of the circuit breaker guarding provider calls.
+	Breaker *BreakerStats `json:"breaker,omitempty"`
+	// Providers are the providers a chat request may select, and
+	// DefaultProvider the one it gets when it selects none.
+	Providers       []string `json:"providers,omitempty"`
+	DefaultProvider string   `json:"default_provider,omitempty"`
 }
 
 func (h *AdminHandlers) getStatus(w http.ResponseWriter, r *http.Request) {
@@ -124,12 +139,56 @@ func (h *AdminHandlers) getStatus(w http.ResponseWriter, r *http.Request) {
 		status.Message = "AI assistant is ready."
 	}
 
+	if h.service != nil {
+		status.Providers, status.DefaultProvider = h.service.ProviderNames()
+	}
+
+	if h.service != nil && h.service.Breaker() != nil {
+		stats := h.service.Breaker().Stats()
+		status.Breaker = &stats
+		if status.Configured && stats.State == BreakerOpen {
+			status.Message = "AI provider is failing; new requests are refused until it recovers."
+		}
+	}
+
 	writeAIJSON(w, http.StatusOK, status)
 }
 
+// ToolSetting describes one of the assistant's tools and whether the current
+// configuration offers it to the model.
+type ToolSetting struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Class       tools.ToolClass `json:"class"`
+	Enabled     bool            `json:"enabled"`
+}
+
+// AIConfigResponse is the AI configuration returned to admins, along with
+// the built-in system prompt and the tool catalogue they edit it against.
+type AIConfigResponse struct {
+	AIConfig
+	DefaultSystemPrompt string        `json:"default_system_prompt"`
+	Tools               []ToolSetting `json:"tools"`
+}
+
+func newConfigResponse(cfg AIConfig) AIConfigResponse {
+	policy := cfg.ToolPolicy()
+	all := tools.AllTools()
+	settings := make([]ToolSetting, 0, len(all))
+	for _, t := range all {
+		settings = append(settings, ToolSetting{
+			Name:        t.Name,
+			Description: t.Description,
+			Class:       tools.ClassifyTool(t.Name),
+			Enabled:     policy.Allows(t.Name),
+		})
+	}
+	return AIConfigResponse{AIConfig: cfg, DefaultSystemPrompt: systemPrompt, Tools: settings}
+}
+
 func (h *AdminHandlers) getConfig(w http.ResponseWriter, r *http.Request) {
 	if h.pool == nil {
-		writeAIJSON(w, http.StatusOK, DefaultConfig())
+		writeAIJSON(w, http.StatusOK, newConfigResponse(DefaultConfig()))
 		return
 	}
 
@@ -137,11 +196,13 @@ func (h *AdminHandlers) getConfig(w http.ResponseWriter, r *http.Request) {
 	var headersJSON []byte
 	var encAPIKey []byte
 	err := h.pool.QueryRow(r.Context(),
-		`SELECT provider, model, embed_model, COALESCE(base_url, ''), max_tokens, temperature, enabled, tool_permission_level, COALESCE(custom_headers, '{}'), encrypted_api_key
+		`SELECT provider, model, embed_model, COALESCE(base_url, ''), max_tokens, temperature, enabled, tool_permission_level, COALESCE(custom_headers, '{}'), encrypted_api_key,
+		        system_prompt, enabled_tools, disabled_tools, allowed_namespaces, denied_namespaces, allowed_kinds, denied_kinds
 		 FROM ai_config LIMIT 1`,
-	).Scan(&cfg.Provider, &cfg.Model, &cfg.EmbedModel, &cfg.BaseURL, &cfg.MaxTokens, &cfg.Temperature, &cfg.Enabled, &cfg.ToolPermissionLevel, &headersJSON, &encAPIKey)
+	).Scan(&cfg.Provider, &cfg.Model, &cfg.EmbedModel, &cfg.BaseURL, &cfg.MaxTokens, &cfg.Temperature, &cfg.Enabled, &cfg.ToolPermissionLevel, &headersJSON, &encAPIKey,
+		&cfg.SystemPrompt, &cfg.EnabledTools, &cfg.DisabledTools, &cfg.AllowedNamespaces, &cfg.DeniedNamespaces, &cfg.AllowedKinds, &cfg.DeniedKinds)
 	if err != nil {
-		writeAIJSON(w, http.StatusOK, DefaultConfig())
+		writeAIJSON(w, http.StatusOK, newConfigResponse(DefaultConfig()))
 		return
 	}
 	if len(headersJSON) > 0 {
@@ -163,29 +224,65 @@ func (h *AdminHandlers) getConfig(w http.ResponseWriter, r *http.Request) {
 	// Mask custom header values (they often contain API keys / subscription keys)
 	cfg.CustomHeaders = maskHeaderValues(cfg.CustomHeaders)
 
-	writeAIJSON(w, http.StatusOK, cfg)
+	// Which providers chat and embeddings use comes from env, not the DB
+	if h.service != nil {
+		_, svcCfg := h.service.Snapshot()
+		cfg.DefaultProvider = svcCfg.DefaultProvider
+		cfg.EmbedProvider = svcCfg.EmbedProvider
+	}
+
+	writeAIJSON(w, http.StatusOK, newConfigResponse(cfg))
+}
+
+// configUpdate is the body of PUT /api/ai/config. The system prompt, tool
+// lists, namespace lists and kind lists are optional so clients that don't
+// know about them leave them unchanged.
+type configUpdate struct {
+	AIConfig
+	SystemPrompt      *string   `json:"system_prompt"`
+	EnabledTools      *[]string `json:"enabled_tools"`
+	DisabledTools     *[]string `json:"disabled_tools"`
+	AllowedNamespaces *[]string `json:"allowed_namespaces"`
+	DeniedNamespaces  *[]string `json:"denied_namespaces"`
+	AllowedKinds      *[]string `json:"allowed_kinds"`
+	DeniedKinds       *[]string `json:"denied_kinds"`
+}
+
+// updatedList returns the list a config update sets, or stored when it
+// leaves the list unset. It is never nil, so the column is stored as an
+// empty array rather than NULL.
+func updatedList(stored []string, requested *[]string) []string {
+	list := stored
+	if requested != nil {
+		list = *requested
+	}
+	if list == nil {
+		return []string{}
+	}
+	return list
 }
 
 func (h *AdminHandlers) updateConfig(w http.ResponseWriter, r *http.Request) {
-	var cfg AIConfig
-	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	var req configUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
+	cfg := req.AIConfig
 
 	if h.pool == nil {
-		writeAIJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "database not available"})
+		httputil.WriteError(w, http.StatusServiceUnavailable, "database not available")
 		return
 	}
 
 	// Parameter validation before DB write
 	validProviders := map[ProviderType]bool{ProviderClaude: true, ProviderOpenAI: true, ProviderOllama: true}
 	if !validProviders[cfg.Provider] {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "provider must be one of: claude, openai, ollama"})
+		httputil.WriteError(w, http.StatusBadRequest, "provider must be one of: claude, openai, ollama")
 		return
 	}
 	if cfg.Model == "" {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "model must not be empty"})
+		httputil.WriteError(w, http.StatusBadRequest, "model must not be empty")
 		return
 	}
 	if cfg.MaxTokens < 1 || cfg.MaxTokens > 128000 {
@@ -202,16 +299,39 @@ func (h *AdminHandlers) updateConfig(w http.ResponseWriter, r *http.Request) {
 
 	// Resolve custom headers: merge masked values with existing stored values.
 	// This prevents losing header secrets when the frontend sends them back masked.
+	// The stored prompt, tool lists, namespace lists and kind lists are kept
+	// unless the request sets them.
 	var storedHeaders map[string]string
 	if h.pool != nil {
 		var storedJSON []byte
-		_ = h.pool.QueryRow(r.Context(), `SELECT COALESCE(custom_headers, '{}') FROM ai_config LIMIT 1`).Scan(&storedJSON)
+		err := h.pool.QueryRow(r.Context(),
+			`SELECT COALESCE(custom_headers, '{}'), system_prompt, enabled_tools, disabled_tools, allowed_namespaces, denied_namespaces, allowed_kinds, denied_kinds FROM ai_config LIMIT 1`,
+		).Scan(&storedJSON, &cfg.SystemPrompt, &cfg.EnabledTools, &cfg.DisabledTools, &cfg.AllowedNamespaces, &cfg.DeniedNamespaces, &cfg.AllowedKinds, &cfg.DeniedKinds)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("ai: updateConfig: failed to load stored config: %v", err)
+			httputil.WriteError(w, http.StatusInternalServerError, "failed to load stored AI config")
+			return
+		}
 		if len(storedJSON) > 0 {
 			if unmarshalErr := json.Unmarshal(storedJSON, &storedHeaders); unmarshalErr != nil {
 				log.Printf("ai: updateConfig: failed to unmarshal stored custom_headers: %v", unmarshalErr)
 			}
 		}
 	}
+	if req.SystemPrompt != nil {
+		cfg.SystemPrompt = strings.TrimSpace(*req.SystemPrompt)
+	}
+	cfg.EnabledTools = updatedList(cfg.EnabledTools, req.EnabledTools)
+	cfg.DisabledTools = updatedList(cfg.DisabledTools, req.DisabledTools)
+	cfg.AllowedNamespaces = updatedList(cfg.AllowedNamespaces, req.AllowedNamespaces)
+	cfg.DeniedNamespaces = updatedList(cfg.DeniedNamespaces, req.DeniedNamespaces)
+	cfg.AllowedKinds = updatedList(cfg.AllowedKinds, req.AllowedKinds)
+	cfg.DeniedKinds = updatedList(cfg.DeniedKinds, req.DeniedKinds)
+	if err := cfg.validatePromptAndTools(); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Fall back to in-memory service headers if DB has none
 	if len(storedHeaders) == 0 && h.service != nil {
 		_, svcCfg := h.service.Snapshot()
@@ -237,13 +357,13 @@ func (h *AdminHandlers) updateConfig(w http.ResponseWriter, r *http.Request) {
 	// when it was set via env vars and never stored in DB).
 	var encAPIKey []byte
 	apiKeyChanged := cfg.APIKey != maskedValue && cfg.APIKey != ""
-	log.Printf("ai: updateConfig: provider=%s model=%s enabled=%v tools=%s apiKeyLen=%d apiKeyChanged=%v headersChanged=%v headerCount=%d",
-		cfg.Provider, cfg.Model, cfg.Enabled, cfg.ToolPermissionLevel, len(cfg.APIKey), apiKeyChanged, headersChanged, len(cfg.CustomHeaders))
+	log.Printf("ai: updateConfig: provider=%s model=%s enabled=%v tools=%s enabledTools=%v disabledTools=%v allowedNamespaces=%v deniedNamespaces=%v allowedKinds=%v deniedKinds=%v customPrompt=%v apiKeyLen=%d apiKeyChanged=%v headersChanged=%v headerCount=%d",
+		cfg.Provider, cfg.Model, cfg.Enabled, cfg.ToolPermissionLevel, cfg.EnabledTools, cfg.DisabledTools, cfg.AllowedNamespaces, cfg.DeniedNamespaces, cfg.AllowedKinds, cfg.DeniedKinds, cfg.SystemPrompt != "", len(cfg.APIKey), apiKeyChanged, headersChanged, len(cfg.CustomHeaders))
 	if apiKeyChanged {
 		encAPIKey, err = crypto.Encrypt([]byte(cfg.APIKey), h.encryptionKey)
 		if err != nil {
 			log.Printf("ai: failed to encrypt api key: %v", err)
-			writeAIJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to encrypt API key"})
+			httputil.WriteError(w, http.StatusInternalServerError, "failed to encrypt API key")
 			return
 		}
 	}
@@ -257,24 +377,30 @@ func (h *AdminHandlers) updateConfig(w http.ResponseWriter, r *http.Request) {
 		_, err = h.pool.Exec(r.Context(),
 			`UPDATE ai_config SET
 				provider = $1, model = $2, embed_model = $3, base_url = NULLIF($4, ''),
-				max_tokens = $5, temperature = $6, enabled = $7, tool_permission_level = $8, custom_headers = $9, encrypted_api_key = $10, updated_at = NOW()
+				max_tokens = $5, temperature = $6, enabled = $7, tool_permission_level = $8, custom_headers = $9,
+				system_prompt = $10, enabled_tools = $11, disabled_tools = $12, allowed_namespaces = $13, denied_namespaces = $14,
+				allowed_kinds = $15, denied_kinds = $16, encrypted_api_key = $17, updated_at = NOW()
 			 WHERE true`,
-			cfg.Provider, cfg.Model, cfg.EmbedModel, cfg.BaseURL, cfg.MaxTokens, cfg.Temperature, cfg.Enabled, cfg.ToolPermissionLevel, headersJSON, encAPIKey,
+			cfg.Provider, cfg.Model, cfg.EmbedModel, cfg.BaseURL, cfg.MaxTokens, cfg.Temperature, cfg.Enabled, cfg.ToolPermissionLevel, headersJSON,
+			cfg.SystemPrompt, cfg.EnabledTools, cfg.DisabledTools, cfg.AllowedNamespaces, cfg.DeniedNamespaces, cfg.AllowedKinds, cfg.DeniedKinds, encAPIKey,
 		)
 	} else {
 		// Keep existing API key untouched
 		_, err = h.pool.Exec(r.Context(),
 			`UPDATE ai_config SET
 				provider = $1, model = $2, embed_model = $3, base_url = NULLIF($4, ''),
-				max_tokens = $5, temperature = $6, enabled = $7, tool_permission_level = $8, custom_headers = $9, updated_at = NOW()
+				max_tokens = $5, temperature = $6, enabled = $7, tool_permission_level = $8, custom_headers = $9,
+				system_prompt = $10, enabled_tools = $11, disabled_tools = $12, allowed_namespaces = $13, denied_namespaces = $14,
+				allowed_kinds = $15, denied_kinds = $16, updated_at = NOW()
 			 WHERE true`,
 			cfg.Provider, cfg.Model, cfg.EmbedModel, cfg.BaseURL, cfg.MaxTokens, cfg.Temperature, cfg.Enabled, cfg.ToolPermissionLevel, headersJSON,
+			cfg.SystemPrompt, cfg.EnabledTools, cfg.DisabledTools, cfg.AllowedNamespaces, cfg.DeniedNamespaces, cfg.AllowedKinds, cfg.DeniedKinds,
 		)
 	}
 	if err != nil {
 		h.reloadMu.Unlock()
 		log.Printf("ai: failed to update config: %v", err)
-		writeAIJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update config"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to update config")
 		return
 	}
 
@@ -320,6 +446,16 @@ func (h *AdminHandlers) updateConfig(w http.ResponseWriter, r *http.Request) {
 		if cfg.Model == "" {
 			cfg.Model = current.Model
 		}
+		if cfg.ContextWindow <= 0 {
+			cfg.ContextWindow = current.ContextWindow
+		}
+		cfg.LogMaxBytes = current.LogMaxBytes
+		cfg.ToolResultMaxBytes = current.ToolResultMaxBytes
+		cfg.RedactSecrets = current.RedactSecrets
+		cfg.RedactKeys = current.RedactKeys
+		cfg.Providers = current.Providers
+		cfg.DefaultProvider = current.DefaultProvider
+		cfg.EmbedProvider = current.EmbedProvider
 		newProvider := h.providerFactory(cfg)
 		h.service.UpdateProvider(newProvider, cfg)
 	}
@@ -331,26 +467,54 @@ func (h *AdminHandlers) updateConfig(w http.ResponseWriter, r *http.Request) {
 		cfg.APIKey = maskedValue
 	}
 	cfg.CustomHeaders = maskHeaderValues(cfg.CustomHeaders)
-	writeAIJSON(w, http.StatusOK, cfg)
+	writeAIJSON(w, http.StatusOK, newConfigResponse(cfg))
 }
 
 func (h *AdminHandlers) testConnection(w http.ResponseWriter, r *http.Request) {
 	var cfg AIConfig
 	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	if err := cfg.Validate(); err != nil {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Resolve masked/empty secrets from stored config
+	h.resolveSecrets(r.Context(), &cfg)
+
+	if h.providerFactory == nil {
+		writeAIJSON(w, http.StatusOK, map[string]string{"status": "ok", "message": "Configuration is valid (no provider factory to test)"})
+		return
+	}
+
+	provider := h.providerFactory(cfg)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	_, err := provider.Chat(ctx, ChatRequest{
+		Messages:    []Message{{Role: RoleUser, Content: "Hello"}},
+		MaxTokens:   16,
+		Temperature: 0,
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "Connection failed: "+err.Error())
+		return
+	}
+
+	writeAIJSON(w, http.StatusOK, map[string]string{"status": "ok", "message": "Connection successful"})
+}
+
+// resolveSecrets replaces a masked or empty API key and masked custom header
+// values in cfg with the stored ones, so the admin UI can test a config
+// without re-entering its secrets.
+func (h *AdminHandlers) resolveSecrets(ctx context.Context, cfg *AIConfig) {
 	if cfg.APIKey == maskedValue || cfg.APIKey == "" {
 		if h.pool != nil {
 			var encKey []byte
-			err := h.pool.QueryRow(r.Context(), `SELECT encrypted_api_key FROM ai_config LIMIT 1`).Scan(&encKey)
+			err := h.pool.QueryRow(ctx, `SELECT encrypted_api_key FROM ai_config LIMIT 1`).Scan(&encKey)
 			if err == nil && len(encKey) > 0 {
 				decrypted, err := crypto.Decrypt(encKey, h.encryptionKey)
 				if err == nil {
@@ -367,33 +531,10 @@ func (h *AdminHandlers) testConnection(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
-	// Resolve masked custom header values
 	if headersAllMasked(cfg.CustomHeaders) && h.service != nil {
 		_, svcCfg := h.service.Snapshot()
 		cfg.CustomHeaders = mergeHeaders(cfg.CustomHeaders, svcCfg.CustomHeaders)
 	}
-
-	if h.providerFactory == nil {
-		writeAIJSON(w, http.StatusOK, map[string]string{"status": "ok", "message": "Configuration is valid (no provider factory to test)"})
-		return
-	}
-
-	provider := h.providerFactory(cfg)
-
-	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
-	defer cancel()
-
-	_, err := provider.Chat(ctx, ChatRequest{
-		Messages:    []Message{{Role: RoleUser, Content: "Hello"}},
-		MaxTokens:   16,
-		Temperature: 0,
-	})
-	if err != nil {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "Connection failed: " + err.Error()})
-		return
-	}
-
-	writeAIJSON(w, http.StatusOK, map[string]string{"status": "ok", "message": "Connection successful"})
 }
 
 func (h *AdminHandlers) ragStatus(w http.ResponseWriter, r *http.Request) {
@@ -407,7 +548,7 @@ func (h *AdminHandlers) ragStatus(w http.ResponseWriter, r *http.Request) {
 
 func (h *AdminHandlers) triggerReindex(w http.ResponseWriter, r *http.Request) {
 	if h.indexer == nil {
-		writeAIJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "indexer not configured"})
+		httputil.WriteError(w, http.StatusServiceUnavailable, "indexer not configured")
 		return
 	}
 
@@ -415,6 +556,18 @@ func (h *AdminHandlers) triggerReindex(w http.ResponseWriter, r *http.Request) {
 	writeAIJSON(w, http.StatusAccepted, map[string]string{"status": "reindex_started"})
 }
 
+// resetBreaker closes the circuit breaker so requests reach the provider
+// again without waiting for the cooldown, e.g. once an outage is resolved.
+func (h *AdminHandlers) resetBreaker(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil || h.service.Breaker() == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "AI service not configured")
+		return
+	}
+
+	h.service.Breaker().Reset()
+	writeAIJSON(w, http.StatusOK, h.service.Breaker().Stats())
+}
+
 const maskedValue = "••••••••"
 
 // maskHeaderValues returns a copy of headers with all values replaced by the mask.
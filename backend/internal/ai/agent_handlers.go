@@ -7,6 +7,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 // AgentHandlers provides REST endpoints for agent CRUD and task management.
@@ -44,13 +45,13 @@ func (h *AgentHandlers) RegisterRoutes(r *mux.Router) {
 func (h *AgentHandlers) listAgents(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	agents, err := h.store.List(r.Context(), claims.UserID)
 	if err != nil {
-		writeAIJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	if agents == nil {
@@ -62,20 +63,20 @@ func (h *AgentHandlers) listAgents(w http.ResponseWriter, r *http.Request) {
 func (h *AgentHandlers) getAgent(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	id := mux.Vars(r)["id"]
 	agent, err := h.store.GetByID(r.Context(), id)
 	if err != nil {
-		writeAIJSON(w, http.StatusNotFound, map[string]string{"error": "agent not found"})
+		httputil.WriteError(w, http.StatusNotFound, "agent not found")
 		return
 	}
 
 	// Private agents are only visible to their owner
 	if !agent.IsBuiltin && !agent.IsPublic && (agent.OwnerUserID == nil || *agent.OwnerUserID != claims.UserID) {
-		writeAIJSON(w, http.StatusNotFound, map[string]string{"error": "agent not found"})
+		httputil.WriteError(w, http.StatusNotFound, "agent not found")
 		return
 	}
 
@@ -99,18 +100,18 @@ type createAgentRequest struct {
 func (h *AgentHandlers) createAgent(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	var req createAgentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	if req.Slug == "" || req.Name == "" || req.SystemPrompt == "" {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "slug, name, and system_prompt are required"})
+		httputil.WriteError(w, http.StatusBadRequest, "slug, name, and system_prompt are required")
 		return
 	}
 
@@ -131,7 +132,7 @@ func (h *AgentHandlers) createAgent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.store.Create(r.Context(), agent); err != nil {
-		writeAIJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	writeAIJSON(w, http.StatusCreated, agent)
@@ -142,7 +143,7 @@ func (h *AgentHandlers) updateAgent(w http.ResponseWriter, r *http.Request) {
 
 	var req createAgentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
@@ -162,7 +163,7 @@ func (h *AgentHandlers) updateAgent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.store.Update(r.Context(), agent); err != nil {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 	writeAIJSON(w, http.StatusOK, agent)
@@ -171,13 +172,13 @@ func (h *AgentHandlers) updateAgent(w http.ResponseWriter, r *http.Request) {
 func (h *AgentHandlers) deleteAgent(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	id := mux.Vars(r)["id"]
 	if err := h.store.Delete(r.Context(), id, claims.UserID); err != nil {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 	writeAIJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
@@ -188,13 +189,13 @@ func (h *AgentHandlers) deleteAgent(w http.ResponseWriter, r *http.Request) {
 func (h *AgentHandlers) listTasks(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	tasks, err := h.store.ListTasks(r.Context(), claims.UserID)
 	if err != nil {
-		writeAIJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	if tasks == nil {
@@ -206,18 +207,18 @@ func (h *AgentHandlers) listTasks(w http.ResponseWriter, r *http.Request) {
 func (h *AgentHandlers) getTask(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	id := mux.Vars(r)["id"]
 	task, err := h.store.GetTask(r.Context(), id)
 	if err != nil {
-		writeAIJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		httputil.WriteError(w, http.StatusNotFound, "task not found")
 		return
 	}
 	if task.UserID != claims.UserID {
-		writeAIJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		httputil.WriteError(w, http.StatusNotFound, "task not found")
 		return
 	}
 	writeAIJSON(w, http.StatusOK, task)
@@ -232,25 +233,25 @@ type createTaskRequest struct {
 func (h *AgentHandlers) createTask(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	var req createTaskRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	if req.AgentID == "" || req.Title == "" {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "agent_id and title are required"})
+		httputil.WriteError(w, http.StatusBadRequest, "agent_id and title are required")
 		return
 	}
 
 	// Verify agent exists
 	agent, err := h.store.GetByID(r.Context(), req.AgentID)
 	if err != nil {
-		writeAIJSON(w, http.StatusNotFound, map[string]string{"error": "agent not found"})
+		httputil.WriteError(w, http.StatusNotFound, "agent not found")
 		return
 	}
 
@@ -263,7 +264,7 @@ func (h *AgentHandlers) createTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.store.CreateTask(r.Context(), task); err != nil {
-		writeAIJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -280,7 +281,7 @@ func (h *AgentHandlers) createTask(w http.ResponseWriter, r *http.Request) {
 func (h *AgentHandlers) cancelTask(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
@@ -289,7 +290,7 @@ func (h *AgentHandlers) cancelTask(w http.ResponseWriter, r *http.Request) {
 	// Verify the user owns this task before cancelling
 	task, err := h.store.GetTask(r.Context(), id)
 	if err != nil || task.UserID != claims.UserID {
-		writeAIJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		httputil.WriteError(w, http.StatusNotFound, "task not found")
 		return
 	}
 
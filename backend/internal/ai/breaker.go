@@ -0,0 +1,301 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/darkden-lab/argus/backend/internal/metrics"
+)
+
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState string
+
+const (
+	// BreakerClosed lets every call through.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen fast-fails every call until the cooldown has passed.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a single probe call through to decide whether
+	// the provider has recovered.
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// UnavailableError is returned instead of calling the provider while the
+// circuit breaker is open.
+type UnavailableError struct {
+	RetryAfter time.Duration
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("AI temporarily unavailable: the AI provider is failing, retry in %d seconds", e.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds, as used by the
+// Retry-After header.
+func (e *UnavailableError) RetryAfterSeconds() int {
+	secs := int((e.RetryAfter + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return secs
+}
+
+// BreakerStats is a snapshot of a CircuitBreaker for operators.
+type BreakerStats struct {
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	Threshold           int          `json:"threshold"`
+	CooldownSeconds     int          `json:"cooldown_seconds"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	RetryAfterSeconds   int          `json:"retry_after_seconds,omitempty"`
+	LastError           string       `json:"last_error,omitempty"`
+	Opens               uint64       `json:"opens_total"`
+	Rejected            uint64       `json:"rejected_total"`
+}
+
+// CircuitBreaker stops calls to a failing LLM provider. After threshold
+// consecutive failures it opens and rejects calls for the cooldown, then
+// half-opens and lets one probe through: a successful probe closes it
+// again, a failed one reopens it for another cooldown.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	probeAt   time.Time
+	lastError string
+	opens     uint64
+	rejected  uint64
+}
+
+// NewCircuitBreaker creates a closed breaker. Non-positive arguments fall
+// back to the defaults.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = defaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     BreakerClosed,
+	}
+}
+
+// Allow reports whether a call may go to the provider. It returns an
+// *UnavailableError while the breaker is open, or while a half-open probe is
+// already in flight. Every allowed call must be followed by Record.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	switch b.state {
+	case BreakerOpen:
+		if remaining := b.openedAt.Add(b.cooldown).Sub(now); remaining > 0 {
+			b.rejected++
+			return &UnavailableError{RetryAfter: remaining}
+		}
+		b.state = BreakerHalfOpen
+		fallthrough
+	case BreakerHalfOpen:
+		// A probe whose outcome never arrives is given up after a cooldown
+		// so the breaker cannot stay half-open forever.
+		if b.probing && now.Sub(b.probeAt) < b.cooldown {
+			b.rejected++
+			return &UnavailableError{RetryAfter: b.probeAt.Add(b.cooldown).Sub(now)}
+		}
+		b.probing = true
+		b.probeAt = now
+	}
+	return nil
+}
+
+// Check returns the *UnavailableError Allow would return, without taking
+// the half-open probe slot. Handlers use it to refuse a request up front
+// rather than accepting it and failing later.
+func (b *CircuitBreaker) Check() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	var retryAt time.Time
+	switch {
+	case b.state == BreakerOpen:
+		retryAt = b.openedAt.Add(b.cooldown)
+	case b.state == BreakerHalfOpen && b.probing:
+		retryAt = b.probeAt.Add(b.cooldown)
+	}
+	if remaining := retryAt.Sub(now); remaining > 0 {
+		b.rejected++
+		return &UnavailableError{RetryAfter: remaining}
+	}
+	return nil
+}
+
+// Record reports the outcome of an allowed call. A nil error closes the
+// breaker. A cancelled call says nothing about the provider and only frees
+// the probe slot. Any other error counts as a failure.
+func (b *CircuitBreaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasProbe := b.state == BreakerHalfOpen && b.probing
+	b.probing = false
+	switch {
+	case err == nil:
+		b.state = BreakerClosed
+		b.failures = 0
+	case errors.Is(err, context.Canceled):
+	default:
+		b.failures++
+		b.lastError = err.Error()
+		if wasProbe || (b.state == BreakerClosed && b.failures >= b.threshold) {
+			b.open()
+		}
+	}
+}
+
+func (b *CircuitBreaker) open() {
+	b.state = BreakerOpen
+	b.openedAt = b.now()
+	b.opens++
+}
+
+// Reset closes the breaker and clears its failure count, e.g. after the
+// provider configuration changed.
+func (b *CircuitBreaker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+	b.lastError = ""
+}
+
+// Stats returns a snapshot of the breaker.
+func (b *CircuitBreaker) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := BreakerStats{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		Threshold:           b.threshold,
+		CooldownSeconds:     int(b.cooldown / time.Second),
+		LastError:           b.lastError,
+		Opens:               b.opens,
+		Rejected:            b.rejected,
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		stats.OpenedAt = &openedAt
+	}
+	if b.state == BreakerOpen {
+		if remaining := b.openedAt.Add(b.cooldown).Sub(b.now()); remaining > 0 {
+			stats.RetryAfterSeconds = (&UnavailableError{RetryAfter: remaining}).RetryAfterSeconds()
+		}
+	}
+	return stats
+}
+
+// RegisterMetrics exposes the breaker's state and counters.
+func (b *CircuitBreaker) RegisterMetrics(reg *metrics.Registry) {
+	reg.RegisterValue("argus_ai_breaker_state", "AI provider circuit breaker state (0 closed, 1 half-open, 2 open).", metrics.Gauge,
+		func() float64 {
+			switch b.Stats().State {
+			case BreakerHalfOpen:
+				return 1
+			case BreakerOpen:
+				return 2
+			}
+			return 0
+		})
+	reg.RegisterValue("argus_ai_breaker_consecutive_failures", "Consecutive failed AI provider calls.", metrics.Gauge,
+		func() float64 { return float64(b.Stats().ConsecutiveFailures) })
+	reg.RegisterValue("argus_ai_breaker_opens_total", "Times the AI provider circuit breaker opened.", metrics.Counter,
+		func() float64 { return float64(b.Stats().Opens) })
+	reg.RegisterValue("argus_ai_breaker_rejected_total", "AI requests rejected while the circuit breaker was open.", metrics.Counter,
+		func() float64 { return float64(b.Stats().Rejected) })
+}
+
+// breakerProvider guards an LLMProvider's chat calls with a CircuitBreaker.
+// Embeddings are left unguarded: they feed RAG indexing, which already
+// tolerates failures and should not open the breaker for chat.
+type breakerProvider struct {
+	LLMProvider
+	breaker *CircuitBreaker
+}
+
+func (p *breakerProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if err := p.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	resp, err := p.LLMProvider.Chat(ctx, req)
+	p.breaker.Record(err)
+	return resp, err
+}
+
+func (p *breakerProvider) ChatStream(ctx context.Context, req ChatRequest) (StreamReader, error) {
+	if err := p.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	stream, err := p.LLMProvider.ChatStream(ctx, req)
+	if err != nil {
+		p.breaker.Record(err)
+		return nil, err
+	}
+	return &breakerStream{StreamReader: stream, ctx: ctx, breaker: p.breaker}, nil
+}
+
+// breakerStream records the outcome of a streamed call once it is known:
+// success at the first delta, failure at an error before it. A stream
+// closed before producing anything, e.g. by the chunk timeout, is a failure
+// unless its request was cancelled. Next and Close may run on different
+// goroutines, so the outcome is recorded through a sync.Once.
+type breakerStream struct {
+	StreamReader
+	ctx     context.Context
+	breaker *CircuitBreaker
+	once    sync.Once
+}
+
+func (s *breakerStream) Next() (*StreamDelta, error) {
+	delta, err := s.StreamReader.Next()
+	if err == nil || errors.Is(err, io.EOF) {
+		s.record(nil)
+	} else {
+		s.record(err)
+	}
+	return delta, err
+}
+
+func (s *breakerStream) Close() error {
+	if err := s.ctx.Err(); err != nil {
+		s.record(err)
+	} else {
+		s.record(errors.New("stream closed before any response"))
+	}
+	return s.StreamReader.Close()
+}
+
+func (s *breakerStream) record(err error) {
+	s.once.Do(func() { s.breaker.Record(err) })
+}
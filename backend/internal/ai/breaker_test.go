@@ -0,0 +1,244 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/darkden-lab/argus/backend/internal/metrics"
+)
+
+// fakeClock is a settable time source for CircuitBreaker.now.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestBreaker(threshold int, cooldown time.Duration) (*CircuitBreaker, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)}
+	b := NewCircuitBreaker(threshold, cooldown)
+	b.now = clock.now
+	return b, clock
+}
+
+func failCalls(t *testing.T, b *CircuitBreaker, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("call %d: unexpected rejection: %v", i+1, err)
+		}
+		b.Record(errors.New("upstream 500"))
+	}
+}
+
+func TestCircuitBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	b, clock := newTestBreaker(3, 30*time.Second)
+
+	failCalls(t, b, 2)
+	b.Allow() //nolint:errcheck
+	b.Record(nil)
+	if got := b.Stats(); got.State != BreakerClosed || got.ConsecutiveFailures != 0 {
+		t.Fatalf("expected a success to reset the count, got %+v", got)
+	}
+
+	failCalls(t, b, 3)
+	err := b.Allow()
+	var unavailable *UnavailableError
+	if !errors.As(err, &unavailable) {
+		t.Fatalf("expected an UnavailableError, got %v", err)
+	}
+	if unavailable.RetryAfterSeconds() != 30 || !strings.Contains(err.Error(), "AI temporarily unavailable") {
+		t.Errorf("unexpected error %q (retry after %ds)", err, unavailable.RetryAfterSeconds())
+	}
+
+	clock.t = clock.t.Add(20 * time.Second)
+	stats := b.Stats()
+	if stats.State != BreakerOpen || stats.RetryAfterSeconds != 10 || stats.Opens != 1 || stats.Rejected != 1 || stats.LastError != "upstream 500" {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	b, clock := newTestBreaker(1, 30*time.Second)
+	failCalls(t, b, 1)
+
+	clock.t = clock.t.Add(31 * time.Second)
+	if err := b.Check(); err != nil {
+		t.Fatalf("expected Check to pass once the cooldown is over, got %v", err)
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected the probe to be allowed, got %v", err)
+	}
+	if b.Stats().State != BreakerHalfOpen {
+		t.Fatalf("expected half-open, got %s", b.Stats().State)
+	}
+	if err := b.Allow(); err == nil {
+		t.Fatal("expected a second call to wait for the probe")
+	}
+	if err := b.Check(); err == nil {
+		t.Fatal("expected Check to refuse while the probe is in flight")
+	}
+
+	// A failed probe reopens for another cooldown.
+	b.Record(errors.New("still down"))
+	if stats := b.Stats(); stats.State != BreakerOpen || stats.Opens != 2 {
+		t.Fatalf("expected the breaker to reopen, got %+v", stats)
+	}
+
+	// A successful probe closes it.
+	clock.t = clock.t.Add(31 * time.Second)
+	b.Allow() //nolint:errcheck
+	b.Record(nil)
+	if err := b.Allow(); err != nil || b.Stats().State != BreakerClosed {
+		t.Errorf("expected the breaker to close, got %v %s", err, b.Stats().State)
+	}
+}
+
+func TestCircuitBreaker_CancelledCallsAreNeutral(t *testing.T) {
+	b, clock := newTestBreaker(1, 30*time.Second)
+	b.Allow() //nolint:errcheck
+	b.Record(context.Canceled)
+	if b.Stats().State != BreakerClosed {
+		t.Fatal("expected a cancelled call not to open the breaker")
+	}
+
+	failCalls(t, b, 1)
+	clock.t = clock.t.Add(31 * time.Second)
+	b.Allow() //nolint:errcheck
+	b.Record(context.Canceled)
+	if err := b.Allow(); err != nil || b.Stats().State != BreakerHalfOpen {
+		t.Errorf("expected a cancelled probe to free the probe slot, got %v %s", err, b.Stats().State)
+	}
+}
+
+func TestCircuitBreaker_Reset(t *testing.T) {
+	b, _ := newTestBreaker(1, time.Hour)
+	failCalls(t, b, 1)
+	b.Reset()
+	if err := b.Allow(); err != nil {
+		t.Errorf("expected a reset breaker to allow calls, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_RegisterMetrics(t *testing.T) {
+	b, _ := newTestBreaker(1, time.Minute)
+	failCalls(t, b, 1)
+	b.Allow() //nolint:errcheck
+
+	reg := metrics.NewRegistry()
+	b.RegisterMetrics(reg)
+	var sb strings.Builder
+	if err := reg.Write(&sb); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"argus_ai_breaker_state 2",
+		"argus_ai_breaker_consecutive_failures 1",
+		"argus_ai_breaker_opens_total 1",
+		"argus_ai_breaker_rejected_total 1",
+	} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, sb.String())
+		}
+	}
+}
+
+// stubProvider returns canned results from its chat calls.
+type stubProvider struct {
+	chatErr error
+	stream  StreamReader
+	calls   int
+}
+
+func (p *stubProvider) Chat(_ context.Context, _ ChatRequest) (*ChatResponse, error) {
+	p.calls++
+	if p.chatErr != nil {
+		return nil, p.chatErr
+	}
+	return &ChatResponse{}, nil
+}
+
+func (p *stubProvider) ChatStream(_ context.Context, _ ChatRequest) (StreamReader, error) {
+	p.calls++
+	return p.stream, nil
+}
+
+func (p *stubProvider) Embed(_ context.Context, _ EmbedRequest) (*EmbedResponse, error) {
+	return &EmbedResponse{}, nil
+}
+
+func (p *stubProvider) Name() string { return "stub" }
+
+// stubStream yields its deltas, then err.
+type stubStream struct {
+	deltas []*StreamDelta
+	err    error
+}
+
+func (s *stubStream) Next() (*StreamDelta, error) {
+	if len(s.deltas) == 0 {
+		return nil, s.err
+	}
+	d := s.deltas[0]
+	s.deltas = s.deltas[1:]
+	return d, nil
+}
+
+func (s *stubStream) Close() error { return nil }
+
+func TestBreakerProvider_FastFailsWhenOpen(t *testing.T) {
+	b, _ := newTestBreaker(2, time.Minute)
+	inner := &stubProvider{chatErr: errors.New("connection refused")}
+	p := guardProvider(inner, b)
+
+	for i := 0; i < 3; i++ {
+		p.Chat(context.Background(), ChatRequest{}) //nolint:errcheck
+	}
+	var unavailable *UnavailableError
+	if _, err := p.Chat(context.Background(), ChatRequest{}); !errors.As(err, &unavailable) {
+		t.Errorf("expected an UnavailableError, got %v", err)
+	}
+	if inner.calls != 2 {
+		t.Errorf("expected the provider to be called only until the breaker opened, got %d calls", inner.calls)
+	}
+	if p.Name() != "stub" {
+		t.Errorf("expected the wrapper to keep the provider name, got %q", p.Name())
+	}
+}
+
+func TestBreakerProvider_StreamOutcome(t *testing.T) {
+	b, _ := newTestBreaker(1, time.Minute)
+	inner := &stubProvider{stream: &stubStream{deltas: []*StreamDelta{{Content: "hi"}}, err: io.EOF}}
+	p := guardProvider(inner, b)
+
+	stream, err := p.ChatStream(context.Background(), ChatRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.Next() //nolint:errcheck
+	stream.Close()
+	if b.Stats().State != BreakerClosed {
+		t.Fatalf("expected a streamed delta to count as success, got %s", b.Stats().State)
+	}
+
+	// A stream abandoned before producing anything, e.g. by the chunk
+	// timeout, is a failure.
+	inner.stream = &stubStream{err: io.EOF}
+	stream, _ = p.ChatStream(context.Background(), ChatRequest{})
+	stream.Close()
+	if b.Stats().State != BreakerOpen {
+		t.Errorf("expected an empty abandoned stream to open the breaker, got %s", b.Stats().State)
+	}
+
+	// The same when the client went away is not the provider's fault.
+	b.Reset()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	stream, _ = p.ChatStream(ctx, ChatRequest{})
+	stream.Close()
+	if b.Stats().State != BreakerClosed {
+		t.Errorf("expected a cancelled stream not to count, got %s", b.Stats().State)
+	}
+}
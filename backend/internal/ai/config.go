@@ -6,8 +6,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
+	"github.com/darkden-lab/argus/backend/internal/ai/tools"
 	"github.com/darkden-lab/argus/backend/internal/crypto"
+	"github.com/darkden-lab/argus/backend/internal/redact"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -20,6 +24,23 @@ const (
 	ProviderOllama ProviderType = "ollama"
 )
 
+// PrimaryProvider names the provider configured by AIConfig's own fields,
+// for chat requests and AI_DEFAULT_PROVIDER/AI_EMBED_PROVIDER to select it
+// alongside the named providers.
+const PrimaryProvider = "primary"
+
+// NamedProvider is an additional LLM backend chat requests may select by
+// name, e.g. a local Ollama for quick lookups next to Claude. It shares the
+// primary provider's token and temperature settings.
+type NamedProvider struct {
+	Name          string            `json:"name"`
+	Provider      ProviderType      `json:"provider"`
+	APIKey        string            `json:"api_key,omitempty"`
+	Model         string            `json:"model"`
+	BaseURL       string            `json:"base_url,omitempty"`
+	CustomHeaders map[string]string `json:"custom_headers,omitempty"`
+}
+
 // ToolPermissionLevel controls which tools the AI assistant can use.
 type ToolPermissionLevel string
 
@@ -37,12 +58,34 @@ type AIConfig struct {
 	BaseURL             string              `json:"base_url,omitempty"` // For Ollama or custom endpoints
 	EmbedModel          string              `json:"embed_model,omitempty"`
 	MaxTokens           int                 `json:"max_tokens"`
+	ContextWindow       int                 `json:"context_window,omitempty"`        // Prompt token budget; 0 uses the default
+	LogMaxBytes         int                 `json:"log_max_bytes,omitempty"`         // Bytes of logs get_logs returns; 0 uses the default
+	ToolResultMaxBytes  int                 `json:"tool_result_max_bytes,omitempty"` // Bytes a tool result may take before it is trimmed; 0 uses the default
 	Temperature         float64             `json:"temperature"`
 	Enabled             bool                `json:"enabled"`
 	ToolPermissionLevel ToolPermissionLevel `json:"tool_permission_level"`
 	CustomHeaders       map[string]string   `json:"custom_headers,omitempty"`
+	RedactSecrets       bool                `json:"redact_secrets"`        // Redact secret values from tool results sent to the provider
+	RedactKeys          []string            `json:"redact_keys,omitempty"` // Extra sensitive key substrings for ConfigMaps/annotations
+	SystemPrompt        string              `json:"system_prompt"`         // Replaces the built-in system prompt when set
+	EnabledTools        []string            `json:"enabled_tools"`         // Destructive tools to offer; they are off unless listed
+	DisabledTools       []string            `json:"disabled_tools"`        // Tools never offered to or run for the model
+	AllowedNamespaces   []string            `json:"allowed_namespaces"`    // Namespaces tools may reach; empty means all not denied
+	DeniedNamespaces    []string            `json:"denied_namespaces"`     // Namespaces tools may never reach, whatever the user's RBAC
+	AllowedKinds        []string            `json:"allowed_kinds"`         // Kinds tools may change; empty means all not denied
+	DeniedKinds         []string            `json:"denied_kinds"`          // Kinds tools may never change, whatever the user's RBAC
+
+	// Providers are the providers chat requests may select besides the
+	// primary one. They carry secrets and are never serialized.
+	Providers       []NamedProvider `json:"-"`
+	DefaultProvider string          `json:"default_provider,omitempty"` // Provider of chat requests that don't select one; empty is the primary
+	EmbedProvider   string          `json:"embed_provider,omitempty"`   // Provider computing RAG embeddings; empty is the primary
 }
 
+// maxSystemPromptLen bounds a custom system prompt, which is sent with every
+// request to the provider.
+const maxSystemPromptLen = 32000
+
 // DefaultConfig returns sensible defaults for AI configuration.
 func DefaultConfig() AIConfig {
 	return AIConfig{
@@ -50,9 +93,11 @@ func DefaultConfig() AIConfig {
 		Model:       "claude-sonnet-4-20250514",
 		EmbedModel:  "text-embedding-3-small",
 		MaxTokens:   4096,
+		ContextWindow: defaultContextWindow,
 		Temperature: 0.1,
 		Enabled:             false,
 		ToolPermissionLevel: ToolsAll,
+		RedactSecrets:       true,
 	}
 }
 
@@ -76,6 +121,33 @@ func LoadConfigFromEnv() AIConfig {
 	if em := os.Getenv("AI_EMBED_MODEL"); em != "" {
 		cfg.EmbedModel = em
 	}
+	if cw := os.Getenv("AI_CONTEXT_WINDOW"); cw != "" {
+		if n, err := strconv.Atoi(cw); err == nil && n > 0 {
+			cfg.ContextWindow = n
+		} else {
+			log.Printf("ai: ignoring invalid AI_CONTEXT_WINDOW %q", cw)
+		}
+	}
+	if lb := os.Getenv("AI_LOG_MAX_BYTES"); lb != "" {
+		if n, err := strconv.Atoi(lb); err == nil && n > 0 {
+			cfg.LogMaxBytes = n
+		} else {
+			log.Printf("ai: ignoring invalid AI_LOG_MAX_BYTES %q", lb)
+		}
+	}
+	if rb := os.Getenv("AI_TOOL_RESULT_MAX_BYTES"); rb != "" {
+		if n, err := strconv.Atoi(rb); err == nil && n > 0 {
+			cfg.ToolResultMaxBytes = n
+		} else {
+			log.Printf("ai: ignoring invalid AI_TOOL_RESULT_MAX_BYTES %q", rb)
+		}
+	}
+	if os.Getenv("AI_REDACT_SECRETS") == "false" {
+		cfg.RedactSecrets = false
+	}
+	if rk := os.Getenv("AI_REDACT_KEYS"); rk != "" {
+		cfg.RedactKeys = strings.Split(rk, ",")
+	}
 	if os.Getenv("AI_ENABLED") == "true" {
 		cfg.Enabled = true
 	}
@@ -87,10 +159,75 @@ func LoadConfigFromEnv() AIConfig {
 			cfg.CustomHeaders = headers
 		}
 	}
+	if p := os.Getenv("AI_PROVIDERS"); p != "" {
+		var named []NamedProvider
+		if err := json.Unmarshal([]byte(p), &named); err != nil {
+			log.Printf("ai: failed to unmarshal AI_PROVIDERS: %v", err)
+		} else {
+			cfg.Providers = cfg.validNamedProviders(named)
+		}
+	}
+	cfg.DefaultProvider = cfg.knownProvider("AI_DEFAULT_PROVIDER")
+	cfg.EmbedProvider = cfg.knownProvider("AI_EMBED_PROVIDER")
 
 	return cfg
 }
 
+// validNamedProviders returns the named providers that are complete and
+// uniquely named, logging the ones it drops.
+func (c AIConfig) validNamedProviders(named []NamedProvider) []NamedProvider {
+	seen := make(map[string]bool, len(named))
+	var valid []NamedProvider
+	for _, p := range named {
+		if p.Name == "" || p.Name == PrimaryProvider || seen[p.Name] {
+			log.Printf("ai: ignoring AI_PROVIDERS entry with missing, reserved or duplicate name %q", p.Name)
+			continue
+		}
+		if err := c.WithProvider(p).Validate(); err != nil {
+			log.Printf("ai: ignoring AI_PROVIDERS entry %q: %v", p.Name, err)
+			continue
+		}
+		seen[p.Name] = true
+		valid = append(valid, p)
+	}
+	return valid
+}
+
+// knownProvider reads a provider name from the environment variable key,
+// ignoring names that aren't configured.
+func (c AIConfig) knownProvider(key string) string {
+	name := os.Getenv(key)
+	if name == "" || name == PrimaryProvider {
+		return ""
+	}
+	if _, ok := c.NamedProvider(name); !ok {
+		log.Printf("ai: ignoring %s %q: no such provider in AI_PROVIDERS", key, name)
+		return ""
+	}
+	return name
+}
+
+// NamedProvider returns the named provider called name.
+func (c AIConfig) NamedProvider(name string) (NamedProvider, bool) {
+	for _, p := range c.Providers {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return NamedProvider{}, false
+}
+
+// WithProvider returns the configuration with the named provider's backend,
+// model and credentials in place of the primary ones.
+func (c AIConfig) WithProvider(p NamedProvider) AIConfig {
+	c.Provider = p.Provider
+	c.APIKey = p.APIKey
+	c.Model = p.Model
+	c.BaseURL = p.BaseURL
+	c.CustomHeaders = p.CustomHeaders
+	return c
+}
+
 // LoadConfigFromDB loads AI configuration from the database. DB values always
 // take precedence; the fallback (env vars) is only used when the DB has no row
 // or the query fails.
@@ -103,9 +240,11 @@ func LoadConfigFromDB(ctx context.Context, pool *pgxpool.Pool, fallback AIConfig
 	var headersJSON []byte
 	var encAPIKey []byte
 	err := pool.QueryRow(ctx,
-		`SELECT provider, model, embed_model, COALESCE(base_url, ''), max_tokens, temperature, enabled, tool_permission_level, COALESCE(custom_headers, '{}'), encrypted_api_key
+		`SELECT provider, model, embed_model, COALESCE(base_url, ''), max_tokens, temperature, enabled, tool_permission_level, COALESCE(custom_headers, '{}'), encrypted_api_key,
+		        system_prompt, enabled_tools, disabled_tools, allowed_namespaces, denied_namespaces, allowed_kinds, denied_kinds
 		 FROM ai_config LIMIT 1`,
-	).Scan(&dbCfg.Provider, &dbCfg.Model, &dbCfg.EmbedModel, &dbCfg.BaseURL, &dbCfg.MaxTokens, &dbCfg.Temperature, &dbCfg.Enabled, &dbCfg.ToolPermissionLevel, &headersJSON, &encAPIKey)
+	).Scan(&dbCfg.Provider, &dbCfg.Model, &dbCfg.EmbedModel, &dbCfg.BaseURL, &dbCfg.MaxTokens, &dbCfg.Temperature, &dbCfg.Enabled, &dbCfg.ToolPermissionLevel, &headersJSON, &encAPIKey,
+		&dbCfg.SystemPrompt, &dbCfg.EnabledTools, &dbCfg.DisabledTools, &dbCfg.AllowedNamespaces, &dbCfg.DeniedNamespaces, &dbCfg.AllowedKinds, &dbCfg.DeniedKinds)
 	if err != nil {
 		return fallback
 	}
@@ -130,10 +269,85 @@ func LoadConfigFromDB(ctx context.Context, pool *pgxpool.Pool, fallback AIConfig
 	if dbCfg.CustomHeaders == nil && len(fallback.CustomHeaders) > 0 {
 		dbCfg.CustomHeaders = fallback.CustomHeaders
 	}
+	// The context window and log and result sizes are not stored in the DB;
+	// they always come from env.
+	dbCfg.ContextWindow = fallback.ContextWindow
+	dbCfg.LogMaxBytes = fallback.LogMaxBytes
+	dbCfg.ToolResultMaxBytes = fallback.ToolResultMaxBytes
+	// Neither is redaction, so it can't be turned off from the UI.
+	dbCfg.RedactSecrets = fallback.RedactSecrets
+	dbCfg.RedactKeys = fallback.RedactKeys
+	// Nor are the named providers and which of them chat and embeddings use.
+	dbCfg.Providers = fallback.Providers
+	dbCfg.DefaultProvider = fallback.DefaultProvider
+	dbCfg.EmbedProvider = fallback.EmbedProvider
 
 	return dbCfg
 }
 
+// Redactor returns the tool-result redactor for the configuration, or nil
+// when redaction is disabled.
+func (c AIConfig) Redactor() *redact.Redactor {
+	if !c.RedactSecrets {
+		return nil
+	}
+	return redact.NewRedactor(c.RedactKeys)
+}
+
+// ToolPolicy returns the policy deciding which tools the assistant is offered
+// and may run under the configuration.
+func (c AIConfig) ToolPolicy() tools.ToolPolicy {
+	return tools.ToolPolicy{
+		Level:             string(c.ToolPermissionLevel),
+		Enabled:           c.EnabledTools,
+		Disabled:          c.DisabledTools,
+		AllowedNamespaces: c.AllowedNamespaces,
+		DeniedNamespaces:  c.DeniedNamespaces,
+		AllowedKinds:      c.AllowedKinds,
+		DeniedKinds:       c.DeniedKinds,
+	}
+}
+
+// validatePromptAndTools checks the admin-editable system prompt, tool lists,
+// namespace lists and kind lists. Tool names must name one of the
+// assistant's tools.
+func (c AIConfig) validatePromptAndTools() error {
+	if len(c.SystemPrompt) > maxSystemPromptLen {
+		return fmt.Errorf("system_prompt must be %d characters or less", maxSystemPromptLen)
+	}
+	for _, name := range c.EnabledTools {
+		if !tools.IsKnownTool(name) {
+			return fmt.Errorf("enabled_tools contains unknown tool %q", name)
+		}
+	}
+	for _, name := range c.DisabledTools {
+		if !tools.IsKnownTool(name) {
+			return fmt.Errorf("disabled_tools contains unknown tool %q", name)
+		}
+	}
+	for _, pattern := range c.AllowedNamespaces {
+		if err := tools.ValidateNamespacePattern(pattern); err != nil {
+			return fmt.Errorf("allowed_namespaces: %w", err)
+		}
+	}
+	for _, pattern := range c.DeniedNamespaces {
+		if err := tools.ValidateNamespacePattern(pattern); err != nil {
+			return fmt.Errorf("denied_namespaces: %w", err)
+		}
+	}
+	for _, entry := range c.AllowedKinds {
+		if err := tools.ValidateKindEntry(entry); err != nil {
+			return fmt.Errorf("allowed_kinds: %w", err)
+		}
+	}
+	for _, entry := range c.DeniedKinds {
+		if err := tools.ValidateKindEntry(entry); err != nil {
+			return fmt.Errorf("denied_kinds: %w", err)
+		}
+	}
+	return nil
+}
+
 // Validate checks that the configuration has all required fields for the
 // selected provider. Also validates common fields like Model, MaxTokens, and Temperature.
 func (c AIConfig) Validate() error {
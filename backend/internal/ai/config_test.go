@@ -223,4 +223,111 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.ToolPermissionLevel != ToolsAll {
 		t.Errorf("DefaultConfig().ToolPermissionLevel = %q, want %q", cfg.ToolPermissionLevel, ToolsAll)
 	}
+	if !cfg.RedactSecrets || cfg.Redactor() == nil {
+		t.Error("DefaultConfig() should redact secrets from tool results")
+	}
+	if policy := cfg.ToolPolicy(); policy.Allows("delete_resource") || !policy.Allows("scale_resource") {
+		t.Error("DefaultConfig() should offer write tools but not destructive ones")
+	}
+}
+
+func TestLoadConfigFromEnv_Redaction(t *testing.T) {
+	t.Setenv("AI_REDACT_SECRETS", "false")
+	t.Setenv("AI_REDACT_KEYS", "license,dsn")
+
+	cfg := LoadConfigFromEnv()
+	if cfg.RedactSecrets {
+		t.Error("AI_REDACT_SECRETS=false should disable redaction")
+	}
+	if cfg.Redactor() != nil {
+		t.Error("Redactor() should be nil when redaction is disabled")
+	}
+	if len(cfg.RedactKeys) != 2 || cfg.RedactKeys[1] != "dsn" {
+		t.Errorf("RedactKeys = %v, want [license dsn]", cfg.RedactKeys)
+	}
+}
+
+func TestLoadConfigFromEnv_LogMaxBytes(t *testing.T) {
+	t.Setenv("AI_LOG_MAX_BYTES", "262144")
+	if cfg := LoadConfigFromEnv(); cfg.LogMaxBytes != 262144 {
+		t.Errorf("LogMaxBytes = %d, want 262144", cfg.LogMaxBytes)
+	}
+
+	t.Setenv("AI_LOG_MAX_BYTES", "lots")
+	if cfg := LoadConfigFromEnv(); cfg.LogMaxBytes != 0 {
+		t.Errorf("expected an invalid value to be ignored, got %d", cfg.LogMaxBytes)
+	}
+}
+
+func TestLoadConfigFromEnv_ToolResultMaxBytes(t *testing.T) {
+	t.Setenv("AI_TOOL_RESULT_MAX_BYTES", "16384")
+	if cfg := LoadConfigFromEnv(); cfg.ToolResultMaxBytes != 16384 {
+		t.Errorf("ToolResultMaxBytes = %d, want 16384", cfg.ToolResultMaxBytes)
+	}
+
+	t.Setenv("AI_TOOL_RESULT_MAX_BYTES", "-1")
+	if cfg := LoadConfigFromEnv(); cfg.ToolResultMaxBytes != 0 {
+		t.Errorf("expected an invalid value to be ignored, got %d", cfg.ToolResultMaxBytes)
+	}
+}
+
+func TestLoadConfigFromEnv_NamedProviders(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "claude")
+	t.Setenv("AI_API_KEY", "sk-test")
+	t.Setenv("AI_PROVIDERS", `[
+		{"name":"local","provider":"ollama","base_url":"http://ollama:11434","model":"llama3"},
+		{"name":"local","provider":"ollama","base_url":"http://other:11434","model":"llama3"},
+		{"name":"primary","provider":"ollama","base_url":"http://ollama:11434","model":"llama3"},
+		{"name":"gpt","provider":"openai","model":"gpt-4o"}
+	]`)
+	t.Setenv("AI_DEFAULT_PROVIDER", "local")
+	t.Setenv("AI_EMBED_PROVIDER", "gpt")
+
+	cfg := LoadConfigFromEnv()
+	if len(cfg.Providers) != 1 || cfg.Providers[0].BaseURL != "http://ollama:11434" {
+		t.Fatalf("expected only the first complete, uniquely named provider, got %+v", cfg.Providers)
+	}
+	if cfg.DefaultProvider != "local" {
+		t.Errorf("DefaultProvider = %q, want local", cfg.DefaultProvider)
+	}
+	if cfg.EmbedProvider != "" {
+		t.Errorf("expected an embed provider that was dropped to be ignored, got %q", cfg.EmbedProvider)
+	}
+
+	local := cfg.WithProvider(cfg.Providers[0])
+	if local.Provider != ProviderOllama || local.Model != "llama3" || local.APIKey != "" || local.MaxTokens != cfg.MaxTokens {
+		t.Errorf("unexpected config for the named provider: %+v", local)
+	}
+}
+
+func TestValidatePromptAndTools(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  AIConfig
+		wantErr string
+	}{
+		{"empty", AIConfig{}, ""},
+		{"known tools", AIConfig{EnabledTools: []string{"get_pod_exec"}, DisabledTools: []string{"apply_yaml"}}, ""},
+		{"unknown enabled tool", AIConfig{EnabledTools: []string{"rm_rf"}}, "enabled_tools contains unknown tool"},
+		{"unknown disabled tool", AIConfig{DisabledTools: []string{"rm_rf"}}, "disabled_tools contains unknown tool"},
+		{"namespace patterns", AIConfig{AllowedNamespaces: []string{"team-*"}, DeniedNamespaces: []string{"kube-system"}}, ""},
+		{"bad denied namespace", AIConfig{DeniedNamespaces: []string{"kube-["}}, "denied_namespaces: invalid namespace pattern"},
+		{"kinds", AIConfig{AllowedKinds: []string{"Deployment", "ConfigMap"}, DeniedKinds: []string{"ClusterRoleBinding.rbac.authorization.k8s.io"}}, ""},
+		{"bad allowed kind", AIConfig{AllowedKinds: []string{"deployments/scale"}}, "allowed_kinds: invalid kind"},
+		{"prompt too long", AIConfig{SystemPrompt: strings.Repeat("a", maxSystemPromptLen+1)}, "system_prompt must be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.validatePromptAndTools()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
 }
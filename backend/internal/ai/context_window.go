@@ -0,0 +1,63 @@
+package ai
+
+// defaultContextWindow is the token budget for the prompt (system prompt,
+// history and RAG context) when AI_CONTEXT_WINDOW is not set. It is kept
+// conservative so it fits every supported provider, including small Ollama models.
+const defaultContextWindow = 32000
+
+// minPromptBudget is the floor applied when MaxTokens eats most of the window,
+// so a misconfiguration never truncates history down to nothing.
+const minPromptBudget = 2000
+
+// promptBudget returns how many tokens the prompt may use, reserving
+// cfg.MaxTokens of the context window for the completion.
+func promptBudget(cfg AIConfig) int {
+	window := cfg.ContextWindow
+	if window <= 0 {
+		window = defaultContextWindow
+	}
+	budget := window - cfg.MaxTokens
+	if budget < minPromptBudget {
+		budget = minPromptBudget
+	}
+	return budget
+}
+
+// estimateTokens approximates the token count of a message using the common
+// ~4 characters per token heuristic plus a small per-message overhead.
+func estimateTokens(m Message) int {
+	n := len(m.Content)
+	for _, tc := range m.ToolCalls {
+		n += len(tc.Name) + len(tc.Arguments)
+	}
+	return n/4 + 4
+}
+
+// fitContextWindow drops the oldest non-system messages until the estimated
+// size fits within budget. System messages (prompt, summary, RAG context) and
+// the final message are always kept. Trimming continues up to the next user
+// turn so the history never starts with an assistant reply or a tool result
+// whose tool-call message was dropped, which providers reject.
+func fitContextWindow(messages []Message, budget int) []Message {
+	total := 0
+	for _, m := range messages {
+		total += estimateTokens(m)
+	}
+
+	kept := make([]Message, 0, len(messages))
+	last := len(messages) - 1
+	trimming := true
+	for i, m := range messages {
+		if m.Role == RoleSystem || i == last {
+			kept = append(kept, m)
+			continue
+		}
+		if trimming && (total > budget || m.Role != RoleUser) {
+			total -= estimateTokens(m)
+			continue
+		}
+		trimming = false
+		kept = append(kept, m)
+	}
+	return kept
+}
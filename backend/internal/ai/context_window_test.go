@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPromptBudget(t *testing.T) {
+	if got := promptBudget(AIConfig{ContextWindow: 10000, MaxTokens: 4000}); got != 6000 {
+		t.Errorf("expected 6000, got %d", got)
+	}
+	if got := promptBudget(AIConfig{MaxTokens: 4096}); got != defaultContextWindow-4096 {
+		t.Errorf("expected default window minus max tokens, got %d", got)
+	}
+	if got := promptBudget(AIConfig{ContextWindow: 4000, MaxTokens: 4096}); got != minPromptBudget {
+		t.Errorf("expected floor of %d, got %d", minPromptBudget, got)
+	}
+}
+
+func TestFitContextWindow_UnderBudget(t *testing.T) {
+	messages := []Message{
+		{Role: RoleSystem, Content: "system"},
+		{Role: RoleUser, Content: "hello"},
+		{Role: RoleAssistant, Content: "hi"},
+		{Role: RoleUser, Content: "list pods"},
+	}
+	got := fitContextWindow(messages, 10000)
+	if len(got) != len(messages) {
+		t.Fatalf("expected all %d messages kept, got %d", len(messages), len(got))
+	}
+}
+
+func TestFitContextWindow_DropsOldestKeepsSystem(t *testing.T) {
+	big := strings.Repeat("x", 400) // ~104 tokens each
+	messages := []Message{
+		{Role: RoleSystem, Content: "system"},
+		{Role: RoleUser, Content: big},
+		{Role: RoleAssistant, Content: big},
+		{Role: RoleUser, Content: big},
+		{Role: RoleAssistant, Content: big},
+		{Role: RoleUser, Content: "latest"},
+	}
+	got := fitContextWindow(messages, 250)
+
+	if got[0].Role != RoleSystem {
+		t.Fatalf("expected system prompt to be kept first, got %s", got[0].Role)
+	}
+	if got[len(got)-1].Content != "latest" {
+		t.Fatalf("expected latest user message to be kept last, got %q", got[len(got)-1].Content)
+	}
+	if len(got) != 4 {
+		t.Fatalf("expected 4 messages after trimming, got %d", len(got))
+	}
+	if got[1].Role != RoleUser {
+		t.Errorf("expected history to resume at a user turn, got %s", got[1].Role)
+	}
+}
+
+func TestFitContextWindow_NoOrphanToolResults(t *testing.T) {
+	big := strings.Repeat("x", 400)
+	messages := []Message{
+		{Role: RoleSystem, Content: "system"},
+		{Role: RoleUser, Content: big},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call-1", Name: "get_pods", Arguments: "{}"}}},
+		{Role: RoleTool, Content: big, ToolCallID: "call-1"},
+		{Role: RoleAssistant, Content: "done"},
+		{Role: RoleUser, Content: "thanks"},
+	}
+	got := fitContextWindow(messages, 120)
+
+	for _, m := range got {
+		if m.Role == RoleTool {
+			t.Fatalf("expected orphaned tool result to be dropped, got %+v", got)
+		}
+	}
+	if got[len(got)-1].Content != "thanks" {
+		t.Errorf("expected final user message to be kept, got %q", got[len(got)-1].Content)
+	}
+}
+
+func TestDecodeToolCalls(t *testing.T) {
+	calls := decodeToolCalls([]byte(`[{"id":"call-1","name":"get_pods","arguments":"{\"namespace\":\"default\"}"}]`))
+	if len(calls) != 1 || calls[0].ID != "call-1" || calls[0].Name != "get_pods" {
+		t.Fatalf("unexpected tool calls: %+v", calls)
+	}
+	if calls := decodeToolCalls(nil); calls != nil {
+		t.Errorf("expected nil for empty column, got %+v", calls)
+	}
+	if calls := decodeToolCalls([]byte("not json")); calls != nil {
+		t.Errorf("expected nil for malformed column, got %+v", calls)
+	}
+}
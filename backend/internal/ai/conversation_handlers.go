@@ -8,6 +8,8 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 // ConversationHandlers provides REST endpoints for AI conversation CRUD.
@@ -33,7 +35,7 @@ func (h *ConversationHandlers) RegisterRoutes(r *mux.Router) {
 func (h *ConversationHandlers) listConversations(w http.ResponseWriter, r *http.Request) {
 	userID := getMemoryUserID(r)
 	if userID == "" {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
@@ -47,9 +49,15 @@ func (h *ConversationHandlers) listConversations(w http.ResponseWriter, r *http.
 		limit = 200
 	}
 
-	conversations, err := h.store.ListConversations(r.Context(), userID, limit)
+	clusterID := r.URL.Query().Get("cluster_id")
+	if len(clusterID) > 64 {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid cluster_id")
+		return
+	}
+
+	conversations, err := h.store.ListConversations(r.Context(), userID, clusterID, limit)
 	if err != nil {
-		writeAIJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to list conversations"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to list conversations")
 		return
 	}
 	if conversations == nil {
@@ -66,7 +74,7 @@ type conversationWithMessages struct {
 func (h *ConversationHandlers) getConversation(w http.ResponseWriter, r *http.Request) {
 	userID := getMemoryUserID(r)
 	if userID == "" {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
@@ -75,16 +83,16 @@ func (h *ConversationHandlers) getConversation(w http.ResponseWriter, r *http.Re
 	found, err := h.store.GetConversation(r.Context(), id, userID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			writeAIJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+			httputil.WriteError(w, http.StatusNotFound, "conversation not found")
 		} else {
-			writeAIJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch conversation"})
+			httputil.WriteError(w, http.StatusInternalServerError, "failed to fetch conversation")
 		}
 		return
 	}
 
 	messages, err := h.store.GetMessages(r.Context(), id, 0)
 	if err != nil {
-		writeAIJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to fetch messages"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to fetch messages")
 		return
 	}
 	if messages == nil {
@@ -104,7 +112,7 @@ type updateTitleRequest struct {
 func (h *ConversationHandlers) updateConversation(w http.ResponseWriter, r *http.Request) {
 	userID := getMemoryUserID(r)
 	if userID == "" {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
@@ -112,26 +120,26 @@ func (h *ConversationHandlers) updateConversation(w http.ResponseWriter, r *http
 
 	var req updateTitleRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 	if req.Title == "" {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "title is required"})
+		httputil.WriteError(w, http.StatusBadRequest, "title is required")
 		return
 	}
 
 	// Verify ownership via direct lookup (O(1) instead of listing all conversations)
 	if _, err := h.store.GetConversation(r.Context(), id, userID); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			writeAIJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+			httputil.WriteError(w, http.StatusNotFound, "conversation not found")
 		} else {
-			writeAIJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to verify ownership"})
+			httputil.WriteError(w, http.StatusInternalServerError, "failed to verify ownership")
 		}
 		return
 	}
 
 	if err := h.store.UpdateTitle(r.Context(), id, req.Title, userID); err != nil {
-		writeAIJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update title"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to update title")
 		return
 	}
 	writeAIJSON(w, http.StatusOK, map[string]string{"status": "updated"})
@@ -140,14 +148,14 @@ func (h *ConversationHandlers) updateConversation(w http.ResponseWriter, r *http
 func (h *ConversationHandlers) deleteConversation(w http.ResponseWriter, r *http.Request) {
 	userID := getMemoryUserID(r)
 	if userID == "" {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	id := mux.Vars(r)["id"]
 
 	if err := h.store.DeleteConversation(r.Context(), id, userID); err != nil {
-		writeAIJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 	writeAIJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
@@ -0,0 +1,177 @@
+package ai
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+)
+
+// defaultEmbedCacheSize bounds how many text→vector results are kept. At
+// 1536 dimensions that is about 6 MB.
+const defaultEmbedCacheSize = 1024
+
+// embedFunc embeds a batch of texts, returning one vector per input.
+type embedFunc func(ctx context.Context, input []string) ([][]float32, error)
+
+// embedCache is a bounded LRU of recent embedding results. It also
+// coalesces identical texts that are being embedded concurrently, so an
+// indexing pass and a live query asking for the same text share one call.
+type embedCache struct {
+	mu       sync.Mutex
+	maxSize  int
+	entries  map[string]*list.Element
+	order    *list.List // front is most recently used
+	inflight map[string]*embedCall
+}
+
+type embedCacheEntry struct {
+	key string
+	vec []float32
+}
+
+// embedCall is an embedding in progress. done is closed once vec or err is
+// set.
+type embedCall struct {
+	done chan struct{}
+	vec  []float32
+	err  error
+}
+
+func newEmbedCache(maxSize int) *embedCache {
+	if maxSize <= 0 {
+		maxSize = defaultEmbedCacheSize
+	}
+	return &embedCache{
+		maxSize:  maxSize,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		inflight: make(map[string]*embedCall),
+	}
+}
+
+// embed returns one vector per input. Cached texts are answered from the
+// cache, texts another caller is already embedding are waited for, and the
+// rest are sent to fn in a single batch. scope identifies the provider and
+// model, so vectors from different models are never mixed.
+func (c *embedCache) embed(ctx context.Context, scope string, input []string, fn embedFunc) ([][]float32, error) {
+	result := make([][]float32, len(input))
+	keys := make([]string, len(input))
+	waits := make(map[int]*embedCall)
+	owned := make(map[string]*embedCall)
+	var batch []string
+	var batchKeys []string
+
+	c.mu.Lock()
+	for i, text := range input {
+		key := embedCacheKey(scope, text)
+		keys[i] = key
+		if el, ok := c.entries[key]; ok {
+			c.order.MoveToFront(el)
+			result[i] = el.Value.(*embedCacheEntry).vec
+			continue
+		}
+		if call, ok := c.inflight[key]; ok {
+			waits[i] = call
+			continue
+		}
+		call := &embedCall{done: make(chan struct{})}
+		c.inflight[key] = call
+		owned[key] = call
+		waits[i] = call
+		batch = append(batch, text)
+		batchKeys = append(batchKeys, key)
+	}
+	c.mu.Unlock()
+
+	if len(batch) > 0 {
+		vecs, err := fn(ctx, batch)
+		if err == nil && len(vecs) != len(batch) {
+			err = fmt.Errorf("ai embedder: expected %d embeddings, got %d", len(batch), len(vecs))
+		}
+		c.mu.Lock()
+		for j, key := range batchKeys {
+			call := owned[key]
+			if err != nil {
+				call.err = err
+			} else {
+				call.vec = vecs[j]
+				c.store(key, vecs[j])
+			}
+			delete(c.inflight, key)
+			close(call.done)
+		}
+		c.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Wait for texts embedded by other callers. If their call failed (for
+	// example because that caller's context was cancelled), embed the text
+	// ourselves rather than inherit the error.
+	var retry []int
+	for i, call := range waits {
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if call.err != nil {
+			retry = append(retry, i)
+			continue
+		}
+		result[i] = call.vec
+	}
+	if len(retry) > 0 {
+		texts := make([]string, len(retry))
+		for j, i := range retry {
+			texts[j] = input[i]
+		}
+		vecs, err := fn(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		if len(vecs) != len(texts) {
+			return nil, fmt.Errorf("ai embedder: expected %d embeddings, got %d", len(texts), len(vecs))
+		}
+		c.mu.Lock()
+		for j, i := range retry {
+			result[i] = vecs[j]
+			c.store(keys[i], vecs[j])
+		}
+		c.mu.Unlock()
+	}
+
+	return result, nil
+}
+
+// store adds a result, evicting the least recently used entry when full.
+// The caller must hold c.mu.
+func (c *embedCache) store(key string, vec []float32) {
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*embedCacheEntry).vec = vec
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&embedCacheEntry{key: key, vec: vec})
+	for c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*embedCacheEntry).key)
+	}
+}
+
+// len returns the number of cached results.
+func (c *embedCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// embedCacheKey hashes the text so long chunks don't bloat the key set.
+func embedCacheKey(scope, text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return fmt.Sprintf("%s\x00%x", scope, sum)
+}
@@ -0,0 +1,187 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingEmbed returns a vector holding each text's length and records how
+// many texts it was asked to embed.
+func countingEmbed(calls *atomic.Int64) embedFunc {
+	return func(ctx context.Context, input []string) ([][]float32, error) {
+		calls.Add(int64(len(input)))
+		vecs := make([][]float32, len(input))
+		for i, text := range input {
+			vecs[i] = []float32{float32(len(text))}
+		}
+		return vecs, nil
+	}
+}
+
+func TestEmbedCache_CachesResults(t *testing.T) {
+	c := newEmbedCache(10)
+	var calls atomic.Int64
+	fn := countingEmbed(&calls)
+
+	if _, err := c.embed(context.Background(), "m", []string{"a", "bb"}, fn); err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	vecs, err := c.embed(context.Background(), "m", []string{"bb", "ccc", "a"}, fn)
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 texts sent to the provider, got %d", calls.Load())
+	}
+	for i, want := range []float32{2, 3, 1} {
+		if vecs[i][0] != want {
+			t.Errorf("vector %d: expected %v, got %v", i, want, vecs[i][0])
+		}
+	}
+}
+
+func TestEmbedCache_DeduplicatesWithinBatch(t *testing.T) {
+	c := newEmbedCache(10)
+	var calls atomic.Int64
+
+	vecs, err := c.embed(context.Background(), "m", []string{"a", "a", "a"}, countingEmbed(&calls))
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 text sent to the provider, got %d", calls.Load())
+	}
+	if len(vecs) != 3 || vecs[2] == nil {
+		t.Errorf("expected a vector for every input, got %v", vecs)
+	}
+}
+
+func TestEmbedCache_ScopesByModel(t *testing.T) {
+	c := newEmbedCache(10)
+	var calls atomic.Int64
+	fn := countingEmbed(&calls)
+
+	c.embed(context.Background(), "model-a", []string{"a"}, fn) //nolint:errcheck
+	c.embed(context.Background(), "model-b", []string{"a"}, fn) //nolint:errcheck
+	if calls.Load() != 2 {
+		t.Errorf("expected each model to embed the text, got %d calls", calls.Load())
+	}
+}
+
+func TestEmbedCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newEmbedCache(2)
+	var calls atomic.Int64
+	fn := countingEmbed(&calls)
+	ctx := context.Background()
+
+	c.embed(ctx, "m", []string{"a", "b"}, fn) //nolint:errcheck
+	c.embed(ctx, "m", []string{"a"}, fn)      //nolint:errcheck
+	c.embed(ctx, "m", []string{"c"}, fn)      //nolint:errcheck
+	if c.len() != 2 {
+		t.Fatalf("expected the cache to hold 2 entries, got %d", c.len())
+	}
+
+	calls.Store(0)
+	c.embed(ctx, "m", []string{"a"}, fn) //nolint:errcheck
+	if calls.Load() != 0 {
+		t.Error("expected a recently used entry to stay cached")
+	}
+	c.embed(ctx, "m", []string{"b"}, fn) //nolint:errcheck
+	if calls.Load() != 1 {
+		t.Error("expected the least recently used entry to be evicted")
+	}
+}
+
+func TestEmbedCache_CoalescesConcurrentRequests(t *testing.T) {
+	c := newEmbedCache(10)
+	var calls atomic.Int64
+	release := make(chan struct{})
+	fn := func(ctx context.Context, input []string) ([][]float32, error) {
+		<-release
+		return countingEmbed(&calls)(ctx, input)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.embed(context.Background(), "m", []string{"shared"}, fn)
+			errs <- err
+		}()
+	}
+	// Let both callers register before the provider answers.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("embed: %v", err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected concurrent requests to share one call, got %d", calls.Load())
+	}
+}
+
+func TestEmbedCache_ErrorsAreNotCached(t *testing.T) {
+	c := newEmbedCache(10)
+	failing := func(ctx context.Context, input []string) ([][]float32, error) {
+		return nil, errors.New("provider down")
+	}
+	if _, err := c.embed(context.Background(), "m", []string{"a"}, failing); err == nil {
+		t.Fatal("expected the provider error")
+	}
+
+	var calls atomic.Int64
+	if _, err := c.embed(context.Background(), "m", []string{"a"}, countingEmbed(&calls)); err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected the text to be embedded again after a failure, got %d calls", calls.Load())
+	}
+}
+
+func TestEmbedCache_WaiterRetriesAfterLeaderFails(t *testing.T) {
+	c := newEmbedCache(10)
+	release := make(chan struct{})
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leader := func(ctx context.Context, input []string) ([][]float32, error) {
+		<-release
+		return nil, ctx.Err()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.embed(leaderCtx, "m", []string{"a"}, leader)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	var calls atomic.Int64
+	result := make(chan error, 1)
+	go func() {
+		_, err := c.embed(context.Background(), "m", []string{"a"}, countingEmbed(&calls))
+		result <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	close(release)
+
+	if err := <-done; err == nil {
+		t.Error("expected the cancelled caller to fail")
+	}
+	if err := <-result; err != nil {
+		t.Fatalf("expected the waiting caller to succeed, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected the waiting caller to embed the text itself, got %d calls", calls.Load())
+	}
+}
@@ -2,6 +2,7 @@ package ai
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -45,7 +46,8 @@ func (s *HistoryStore) CreateConversation(ctx context.Context, userID, title, cl
 }
 
 // ListConversations returns all conversations for a user, ordered by most recent.
-func (s *HistoryStore) ListConversations(ctx context.Context, userID string, limit int) ([]Conversation, error) {
+// A non-empty clusterID restricts the result to conversations started on that cluster.
+func (s *HistoryStore) ListConversations(ctx context.Context, userID, clusterID string, limit int) ([]Conversation, error) {
 	if limit <= 0 {
 		limit = 50
 	}
@@ -53,10 +55,10 @@ func (s *HistoryStore) ListConversations(ctx context.Context, userID string, lim
 	rows, err := s.pool.Query(ctx,
 		`SELECT id, user_id, title, COALESCE(cluster_id::text, ''), COALESCE(namespace, ''), created_at, updated_at
 		 FROM ai_conversations
-		 WHERE user_id = $1
+		 WHERE user_id = $1 AND ($2 = '' OR cluster_id::text = $2)
 		 ORDER BY updated_at DESC
-		 LIMIT $2`,
-		userID, limit,
+		 LIMIT $3`,
+		userID, clusterID, limit,
 	)
 	if err != nil {
 		return nil, err
@@ -75,14 +77,15 @@ func (s *HistoryStore) ListConversations(ctx context.Context, userID string, lim
 	return conversations, rows.Err()
 }
 
-// GetMessages returns all messages in a conversation.
+// GetMessages returns all messages in a conversation, including the tool calls
+// made by the assistant and the tool results.
 func (s *HistoryStore) GetMessages(ctx context.Context, conversationID string, limit int) ([]Message, error) {
 	if limit <= 0 {
 		limit = 100
 	}
 
 	rows, err := s.pool.Query(ctx,
-		`SELECT role, content, COALESCE(tool_call_id, '')
+		`SELECT role, content, tool_calls, COALESCE(tool_call_id, '')
 		 FROM ai_messages
 		 WHERE conversation_id = $1
 		 ORDER BY created_at ASC
@@ -98,10 +101,12 @@ func (s *HistoryStore) GetMessages(ctx context.Context, conversationID string, l
 	for rows.Next() {
 		var m Message
 		var roleStr string
-		if err := rows.Scan(&roleStr, &m.Content, &m.ToolCallID); err != nil {
+		var toolCallsJSON []byte
+		if err := rows.Scan(&roleStr, &m.Content, &toolCallsJSON, &m.ToolCallID); err != nil {
 			continue
 		}
 		m.Role = Role(roleStr)
+		m.ToolCalls = decodeToolCalls(toolCallsJSON)
 		messages = append(messages, m)
 	}
 
@@ -155,3 +160,16 @@ func (s *HistoryStore) UpdateTitle(ctx context.Context, conversationID, title, u
 	)
 	return err
 }
+
+// decodeToolCalls unmarshals the tool_calls JSONB column of ai_messages.
+// Malformed or empty values yield no tool calls.
+func decodeToolCalls(raw []byte) []ToolCall {
+	if len(raw) == 0 {
+		return nil
+	}
+	var calls []ToolCall
+	if err := json.Unmarshal(raw, &calls); err != nil {
+		return nil
+	}
+	return calls
+}
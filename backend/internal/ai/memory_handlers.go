@@ -8,6 +8,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 // MemoryHandlers provides REST endpoints for AI memory CRUD.
@@ -33,13 +34,13 @@ func (h *MemoryHandlers) RegisterRoutes(r *mux.Router) {
 func (h *MemoryHandlers) listMemories(w http.ResponseWriter, r *http.Request) {
 	userID := getMemoryUserID(r)
 	if userID == "" {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	memories, err := h.store.List(r.Context(), userID)
 	if err != nil {
-		writeAIJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to list memories"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to list memories")
 		return
 	}
 	if memories == nil {
@@ -56,23 +57,23 @@ type createMemoryRequest struct {
 func (h *MemoryHandlers) createMemory(w http.ResponseWriter, r *http.Request) {
 	userID := getMemoryUserID(r)
 	if userID == "" {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	var req createMemoryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 	if req.Content == "" {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "content is required"})
+		httputil.WriteError(w, http.StatusBadRequest, "content is required")
 		return
 	}
 
 	memory, err := h.store.Create(r.Context(), userID, req.Content, req.Category)
 	if err != nil {
-		writeAIJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create memory"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to create memory")
 		return
 	}
 	writeAIJSON(w, http.StatusCreated, memory)
@@ -81,7 +82,7 @@ func (h *MemoryHandlers) createMemory(w http.ResponseWriter, r *http.Request) {
 func (h *MemoryHandlers) updateMemory(w http.ResponseWriter, r *http.Request) {
 	userID := getMemoryUserID(r)
 	if userID == "" {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
@@ -89,16 +90,16 @@ func (h *MemoryHandlers) updateMemory(w http.ResponseWriter, r *http.Request) {
 
 	var req createMemoryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 	if req.Content == "" {
-		writeAIJSON(w, http.StatusBadRequest, map[string]string{"error": "content is required"})
+		httputil.WriteError(w, http.StatusBadRequest, "content is required")
 		return
 	}
 
 	if err := h.store.Update(r.Context(), id, userID, req.Content, req.Category); err != nil {
-		writeAIJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 	writeAIJSON(w, http.StatusOK, map[string]string{"status": "updated"})
@@ -107,14 +108,14 @@ func (h *MemoryHandlers) updateMemory(w http.ResponseWriter, r *http.Request) {
 func (h *MemoryHandlers) deleteMemory(w http.ResponseWriter, r *http.Request) {
 	userID := getMemoryUserID(r)
 	if userID == "" {
-		writeAIJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	id := mux.Vars(r)["id"]
 
 	if err := h.store.Delete(r.Context(), id, userID); err != nil {
-		writeAIJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 	writeAIJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
@@ -0,0 +1,161 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// modelCacheTTL is how long a provider's model list is reused. Lists change
+// rarely, but a freshly pulled Ollama model should show up within minutes.
+const modelCacheTTL = 5 * time.Minute
+
+// modelCache keeps recent model lists per provider endpoint and credentials,
+// so reopening the settings page does not query the provider every time.
+type modelCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]modelCacheEntry
+}
+
+type modelCacheEntry struct {
+	models  []ModelInfo
+	expires time.Time
+}
+
+func newModelCache(ttl time.Duration) *modelCache {
+	return &modelCache{ttl: ttl, now: time.Now, entries: make(map[string]modelCacheEntry)}
+}
+
+func (c *modelCache) get(key string) ([]ModelInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || c.now().After(e.expires) {
+		return nil, false
+	}
+	return e.models, true
+}
+
+func (c *modelCache) put(key string, models []ModelInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = modelCacheEntry{models: models, expires: now.Add(c.ttl)}
+}
+
+// modelCacheKey identifies the endpoint and credentials a list was fetched
+// with. Secrets are hashed so the key never holds them in clear.
+func modelCacheKey(cfg AIConfig) string {
+	headers := make([]string, 0, len(cfg.CustomHeaders))
+	for k, v := range cfg.CustomHeaders {
+		headers = append(headers, k+"="+v)
+	}
+	sort.Strings(headers)
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%q", cfg.Provider, cfg.BaseURL, cfg.APIKey, headers)))
+	return fmt.Sprintf("%x", sum)
+}
+
+// ModelList is the response for the /api/ai/config/models endpoint.
+type ModelList struct {
+	Provider string `json:"provider"`
+	// Supported is false when the provider cannot list its models; the
+	// model name must then be entered by hand.
+	Supported bool        `json:"supported"`
+	Models    []ModelInfo `json:"models"`
+	Model     string      `json:"model,omitempty"`
+	// ModelAvailable reports whether Model is in Models. It is omitted when
+	// no model was given or the list is not supported.
+	ModelAvailable *bool  `json:"model_available,omitempty"`
+	Cached         bool   `json:"cached"`
+	Message        string `json:"message,omitempty"`
+}
+
+// listModels returns the models the provider in the request body offers and
+// whether the chosen model is one of them. Masked secrets are resolved like
+// in testConnection. Pass ?refresh=true to bypass the cache.
+func (h *AdminHandlers) listModels(w http.ResponseWriter, r *http.Request) {
+	var cfg AIConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	switch cfg.Provider {
+	case ProviderClaude, ProviderOpenAI, ProviderOllama:
+	default:
+		httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("ai: unknown provider %q", cfg.Provider))
+		return
+	}
+
+	h.resolveSecrets(r.Context(), &cfg)
+
+	result := ModelList{Provider: string(cfg.Provider), Models: []ModelInfo{}, Model: cfg.Model}
+	unsupported := func() {
+		result.Message = "This provider cannot list its models; enter the model name manually."
+		writeAIJSON(w, http.StatusOK, result)
+	}
+
+	if h.providerFactory == nil {
+		unsupported()
+		return
+	}
+	lister, ok := h.providerFactory(cfg).(ModelLister)
+	if !ok {
+		unsupported()
+		return
+	}
+
+	key := modelCacheKey(cfg)
+	models, cached := h.models.get(key)
+	if !cached || r.URL.Query().Get("refresh") == "true" {
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		var err error
+		models, err = lister.ListModels(ctx)
+		if errors.Is(err, ErrModelListingUnsupported) {
+			unsupported()
+			return
+		}
+		if err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, "Listing models failed: "+err.Error())
+			return
+		}
+		h.models.put(key, models)
+		cached = false
+	}
+
+	result.Supported = true
+	result.Cached = cached
+	if models != nil {
+		result.Models = models
+	}
+	if cfg.Model != "" {
+		available := false
+		for _, m := range models {
+			if m.ID == cfg.Model {
+				available = true
+				break
+			}
+		}
+		result.ModelAvailable = &available
+		if !available {
+			result.Message = fmt.Sprintf("Model %q is not offered by this provider.", cfg.Model)
+		}
+	}
+	writeAIJSON(w, http.StatusOK, result)
+}
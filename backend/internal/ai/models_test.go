@@ -0,0 +1,120 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listingProvider is a stubProvider that can also list models.
+type listingProvider struct {
+	stubProvider
+	models []ModelInfo
+	err    error
+	calls  *int
+}
+
+func (p *listingProvider) ListModels(_ context.Context) ([]ModelInfo, error) {
+	*p.calls++
+	return p.models, p.err
+}
+
+func postModels(h *AdminHandlers, target, body string) (*httptest.ResponseRecorder, ModelList) {
+	w := httptest.NewRecorder()
+	h.listModels(w, httptest.NewRequest(http.MethodPost, target, strings.NewReader(body)))
+	var result ModelList
+	json.Unmarshal(w.Body.Bytes(), &result) //nolint:errcheck
+	return w, result
+}
+
+func TestListModels_ValidatesAndCaches(t *testing.T) {
+	calls := 0
+	h := NewAdminHandlers(nil, nil, AIConfig{}, nil, nil, func(AIConfig) LLMProvider {
+		return &listingProvider{models: []ModelInfo{{ID: "gpt-4o"}, {ID: "gpt-4o-mini"}}, calls: &calls}
+	}, "")
+
+	w, result := postModels(h, "/api/ai/config/models", `{"provider": "openai", "api_key": "sk-1", "model": "gpt-4o"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !result.Supported || len(result.Models) != 2 || result.ModelAvailable == nil || !*result.ModelAvailable || result.Cached {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	_, result = postModels(h, "/api/ai/config/models", `{"provider": "openai", "api_key": "sk-1", "model": "gpt-4"}`)
+	if !result.Cached || *result.ModelAvailable || !strings.Contains(result.Message, `"gpt-4"`) {
+		t.Errorf("expected a cached list without the typo'd model, got %+v", result)
+	}
+	if calls != 1 {
+		t.Errorf("expected the list to be fetched once, got %d", calls)
+	}
+
+	// Other credentials and an explicit refresh query the provider again.
+	postModels(h, "/api/ai/config/models", `{"provider": "openai", "api_key": "sk-2"}`)
+	postModels(h, "/api/ai/config/models?refresh=true", `{"provider": "openai", "api_key": "sk-1"}`)
+	if calls != 3 {
+		t.Errorf("expected 3 fetches, got %d", calls)
+	}
+}
+
+func TestListModels_Unsupported(t *testing.T) {
+	calls := 0
+	tests := []struct {
+		name     string
+		provider LLMProvider
+	}{
+		{"no lister", &stubProvider{}},
+		{"unsupported endpoint", &listingProvider{err: ErrModelListingUnsupported, calls: &calls}},
+	}
+	for _, tt := range tests {
+		h := NewAdminHandlers(nil, nil, AIConfig{}, nil, nil, func(AIConfig) LLMProvider { return tt.provider }, "")
+		w, result := postModels(h, "/api/ai/config/models", `{"provider": "openai", "model": "gpt-4o"}`)
+		if w.Code != http.StatusOK || result.Supported || result.Models == nil || result.ModelAvailable != nil || result.Message == "" {
+			t.Errorf("%s: unexpected response %d %s", tt.name, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestListModels_Errors(t *testing.T) {
+	calls := 0
+	h := NewAdminHandlers(nil, nil, AIConfig{}, nil, nil, func(AIConfig) LLMProvider {
+		return &listingProvider{err: errors.New("openai: models API error 401: invalid key"), calls: &calls}
+	}, "")
+
+	if w, _ := postModels(h, "/api/ai/config/models", `{"provider": "gemini"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown provider to be refused, got %d", w.Code)
+	}
+	w, _ := postModels(h, "/api/ai/config/models", `{"provider": "openai", "api_key": "bad"}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "401") {
+		t.Errorf("expected the provider error, got %d %s", w.Code, w.Body.String())
+	}
+
+	// Failures are not cached.
+	postModels(h, "/api/ai/config/models", `{"provider": "openai", "api_key": "bad"}`)
+	if calls != 2 {
+		t.Errorf("expected a failed list to be retried, got %d calls", calls)
+	}
+}
+
+func TestModelCache_Expires(t *testing.T) {
+	c := newModelCache(time.Minute)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	c.put("k", []ModelInfo{{ID: "m"}})
+	if _, ok := c.get("k"); !ok {
+		t.Fatal("expected a cache hit")
+	}
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.get("k"); ok {
+		t.Error("expected the entry to expire")
+	}
+	c.put("other", nil)
+	if len(c.entries) != 1 {
+		t.Errorf("expected expired entries to be pruned, got %d", len(c.entries))
+	}
+}
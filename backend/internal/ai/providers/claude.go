@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/darkden-lab/argus/backend/internal/ai"
+	"github.com/darkden-lab/argus/backend/internal/outbound"
 )
 
 const (
@@ -41,7 +42,7 @@ func NewClaude(apiKey, model, baseURL string, customHeaders map[string]string) *
 		model:         model,
 		baseURL:       strings.TrimRight(baseURL, "/"),
 		customHeaders: customHeaders,
-		client:        &http.Client{Timeout: 5 * time.Minute},
+		client:        outbound.Client(5 * time.Minute),
 	}
 }
 
@@ -172,6 +173,25 @@ func (c *Claude) Embed(_ context.Context, _ ai.EmbedRequest) (*ai.EmbedResponse,
 	return nil, fmt.Errorf("claude: embedding not supported natively, use OpenAI or Ollama for embeddings")
 }
 
+// claudeModels are the Messages API models known to work with Argus, sorted
+// by ID.
+var claudeModels = []ai.ModelInfo{
+	{ID: "claude-haiku-4-5-20251001", Description: "Claude Haiku 4.5"},
+	{ID: "claude-opus-4-1-20250805", Description: "Claude Opus 4.1"},
+	{ID: "claude-opus-4-6", Description: "Claude Opus 4.6"},
+	{ID: "claude-sonnet-4-20250514", Description: "Claude Sonnet 4"},
+	{ID: "claude-sonnet-4-5-20250929", Description: "Claude Sonnet 4.5"},
+	{ID: "claude-sonnet-4-6", Description: "Claude Sonnet 4.6"},
+}
+
+// ListModels returns the known Claude models. It does not call the API, so
+// it also works behind proxies that only forward the Messages endpoint.
+func (c *Claude) ListModels(_ context.Context) ([]ai.ModelInfo, error) {
+	models := make([]ai.ModelInfo, len(claudeModels))
+	copy(models, claudeModels)
+	return models, nil
+}
+
 func (c *Claude) setHeaders(req *http.Request) {
 	req.Header.Set("Content-Type", "application/json")
 	if c.apiKey != "" {
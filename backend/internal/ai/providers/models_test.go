@@ -0,0 +1,87 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/darkden-lab/argus/backend/internal/ai"
+)
+
+func TestOpenAIListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" || r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("unexpected request %s %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"data": [{"id": "gpt-4o-mini", "owned_by": "system"}, {"id": "gpt-4o", "owned_by": "system"}]}`))
+	}))
+	defer srv.Close()
+
+	models, err := NewOpenAI("test-key", "", srv.URL+"/v1", nil).ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(models) != 2 || models[0].ID != "gpt-4o" || models[1].ID != "gpt-4o-mini" {
+		t.Errorf("expected the models sorted by ID, got %+v", models)
+	}
+}
+
+func TestOpenAIListModels_Errors(t *testing.T) {
+	status := http.StatusNotFound
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"error": {"message": "bad key"}}`))
+	}))
+	defer srv.Close()
+
+	o := NewOpenAI("test-key", "", srv.URL, nil)
+	if _, err := o.ListModels(context.Background()); !errors.Is(err, ai.ErrModelListingUnsupported) {
+		t.Errorf("expected a gateway without /models to be unsupported, got %v", err)
+	}
+	status = http.StatusUnauthorized
+	if _, err := o.ListModels(context.Background()); err == nil || errors.Is(err, ai.ErrModelListingUnsupported) {
+		t.Errorf("expected an authentication error, got %v", err)
+	}
+}
+
+func TestOllamaListModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"models": [
+		  {"name": "qwen2.5:7b", "details": {"parameter_size": "7.6B", "quantization_level": "Q4_K_M"}},
+		  {"name": "llama3.1:latest", "details": {"parameter_size": "8.0B"}}]}`))
+	}))
+	defer srv.Close()
+
+	models, err := NewOllama(srv.URL, "", nil).ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(models) != 2 || models[0].ID != "llama3.1:latest" || models[0].Description != "8.0B" || models[1].Description != "7.6B Q4_K_M" {
+		t.Errorf("unexpected models %+v", models)
+	}
+}
+
+func TestClaudeListModels(t *testing.T) {
+	c := NewClaude("test-key", "", "", nil)
+	models, err := c.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	found := false
+	for i, m := range models {
+		if m.ID == c.model {
+			found = true
+		}
+		if i > 0 && models[i-1].ID >= m.ID {
+			t.Errorf("expected the models sorted by ID, got %s before %s", models[i-1].ID, m.ID)
+		}
+	}
+	if !found {
+		t.Errorf("expected the default model %s to be listed", c.model)
+	}
+}
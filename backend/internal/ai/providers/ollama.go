@@ -7,10 +7,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/darkden-lab/argus/backend/internal/ai"
+	"github.com/darkden-lab/argus/backend/internal/outbound"
 	"github.com/google/uuid"
 )
 
@@ -34,7 +36,7 @@ func NewOllama(baseURL, model string, customHeaders map[string]string) *Ollama {
 		baseURL:       strings.TrimRight(baseURL, "/"),
 		model:         model,
 		customHeaders: customHeaders,
-		client:        &http.Client{Timeout: 5 * time.Minute},
+		client:        outbound.Client(5 * time.Minute),
 	}
 }
 
@@ -244,6 +246,51 @@ func (o *Ollama) Embed(ctx context.Context, req ai.EmbedRequest) (*ai.EmbedRespo
 	return &ai.EmbedResponse{Embeddings: embeddings}, nil
 }
 
+// ListModels returns the models pulled into the Ollama server, from
+// /api/tags.
+func (o *Ollama) ListModels(ctx context.Context) ([]ai.ModelInfo, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, o.baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: create tags request: %w", err)
+	}
+	o.setHeaders(httpReq)
+
+	resp, err := o.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: tags request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, readErr := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if readErr != nil {
+			return nil, fmt.Errorf("ollama: failed to read error response: %w", readErr)
+		}
+		return nil, fmt.Errorf("ollama: tags API error %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var tr struct {
+		Models []struct {
+			Name    string `json:"name"`
+			Details struct {
+				ParameterSize     string `json:"parameter_size"`
+				QuantizationLevel string `json:"quantization_level"`
+			} `json:"details"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return nil, fmt.Errorf("ollama: decode tags: %w", err)
+	}
+
+	models := make([]ai.ModelInfo, 0, len(tr.Models))
+	for _, m := range tr.Models {
+		desc := strings.TrimSpace(m.Details.ParameterSize + " " + m.Details.QuantizationLevel)
+		models = append(models, ai.ModelInfo{ID: m.Name, Description: desc})
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
 func (o *Ollama) buildRequest(req ai.ChatRequest) ollamaRequest {
 	or := ollamaRequest{
 		Model: o.model,
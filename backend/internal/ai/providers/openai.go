@@ -8,10 +8,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/darkden-lab/argus/backend/internal/ai"
+	"github.com/darkden-lab/argus/backend/internal/outbound"
 )
 
 const openaiAPIURL = "https://api.openai.com/v1"
@@ -38,7 +40,7 @@ func NewOpenAI(apiKey, model, baseURL string, customHeaders map[string]string) *
 		model:         model,
 		baseURL:       strings.TrimRight(baseURL, "/"),
 		customHeaders: customHeaders,
-		client:        &http.Client{Timeout: 5 * time.Minute},
+		client:        outbound.Client(5 * time.Minute),
 	}
 }
 
@@ -258,6 +260,50 @@ func (o *OpenAI) Embed(ctx context.Context, req ai.EmbedRequest) (*ai.EmbedRespo
 	}, nil
 }
 
+// ListModels returns the models from the /models endpoint. Gateways that
+// answer it with 404 or 405 report ai.ErrModelListingUnsupported.
+func (o *OpenAI) ListModels(ctx context.Context) ([]ai.ModelInfo, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, o.baseURL+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("openai: create models request: %w", err)
+	}
+	o.setHeaders(httpReq)
+
+	resp, err := o.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("openai: models request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return nil, ai.ErrModelListingUnsupported
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, readErr := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if readErr != nil {
+			return nil, fmt.Errorf("openai: failed to read error response: %w", readErr)
+		}
+		return nil, fmt.Errorf("openai: models API error %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var mr struct {
+		Data []struct {
+			ID      string `json:"id"`
+			OwnedBy string `json:"owned_by"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&mr); err != nil {
+		return nil, fmt.Errorf("openai: decode models: %w", err)
+	}
+
+	models := make([]ai.ModelInfo, 0, len(mr.Data))
+	for _, m := range mr.Data {
+		models = append(models, ai.ModelInfo{ID: m.ID, Description: m.OwnedBy})
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
 func (o *OpenAI) setHeaders(req *http.Request) {
 	req.Header.Set("Content-Type", "application/json")
 	if o.apiKey != "" {
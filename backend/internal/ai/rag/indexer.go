@@ -2,6 +2,7 @@ package rag
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"strings"
 	"sync"
@@ -26,6 +27,9 @@ type Indexer struct {
 	Status    string    `json:"status"` // "idle", "running", "error"
 	DocsCount int64     `json:"docs_count"`
 	Error     string    `json:"error,omitempty"`
+	// SkippedClusters lists the clusters whose CRDs the last pass could not
+	// index.
+	SkippedClusters cluster.ClusterErrors `json:"skipped_clusters"`
 }
 
 // NewIndexer creates a new RAG indexer.
@@ -124,9 +128,13 @@ func (idx *Indexer) RunOnce(_ context.Context) {
 		indexErr = err
 	}
 
-	if err := idx.indexClusterCRDs(ctx); err != nil {
+	skipped, err := idx.indexClusterCRDs(ctx)
+	if err != nil {
 		log.Printf("rag indexer: CRD indexing error: %v", err)
 	}
+	idx.mu.Lock()
+	idx.SkippedClusters = skipped
+	idx.mu.Unlock()
 
 	log.Printf("rag indexer: pass complete")
 }
@@ -176,26 +184,25 @@ func (idx *Indexer) indexK8sDocs(ctx context.Context) error {
 	return nil
 }
 
-func (idx *Indexer) indexClusterCRDs(ctx context.Context) error {
+// indexClusterCRDs indexes the custom resources of every cluster. Clusters
+// that cannot be indexed are skipped and returned with the reason.
+func (idx *Indexer) indexClusterCRDs(ctx context.Context) (cluster.ClusterErrors, error) {
 	clusters, err := idx.clusterMgr.ListClusters(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	var skipped cluster.ClusterErrors
 	for _, c := range clusters {
-		client, err := idx.clusterMgr.GetClient(c.ID)
-		if err != nil {
-			continue
-		}
-
-		crdList, err := client.Clientset.Discovery().ServerPreferredResources()
+		d, err := idx.clusterMgr.Discovery(ctx, c.ID)
 		if err != nil {
 			log.Printf("rag indexer: failed to discover resources for cluster %s: %v", c.ID, err)
+			skipped.Add(c, fmt.Errorf("discover resources: %w", err))
 			continue
 		}
 
 		var crdDocs []docChunk
-		for _, group := range crdList {
+		for _, group := range d.PreferredResources() {
 			for _, res := range group.APIResources {
 				if !strings.Contains(group.GroupVersion, "/") {
 					continue
@@ -228,6 +235,7 @@ func (idx *Indexer) indexClusterCRDs(ctx context.Context) error {
 			vecs, err := idx.embedder.EmbedTexts(ctx, texts)
 			if err != nil {
 				log.Printf("rag indexer: embed CRDs error: %v", err)
+				skipped.Add(c, fmt.Errorf("embed CRDs: %w", err))
 				break
 			}
 
@@ -244,6 +252,7 @@ func (idx *Indexer) indexClusterCRDs(ctx context.Context) error {
 
 			if err := idx.store.InsertBatch(ctx, embeddings); err != nil {
 				log.Printf("rag indexer: insert CRDs error: %v", err)
+				skipped.Add(c, fmt.Errorf("store CRDs: %w", err))
 				break
 			}
 		}
@@ -251,7 +260,10 @@ func (idx *Indexer) indexClusterCRDs(ctx context.Context) error {
 		log.Printf("rag indexer: indexed %d CRDs from cluster %s", len(crdDocs), c.Name)
 	}
 
-	return nil
+	if skipped.Partial() {
+		log.Printf("rag indexer: skipped CRDs of %d of %d clusters", len(skipped), len(clusters))
+	}
+	return skipped, nil
 }
 
 // GetStatus returns the current indexer status.
@@ -270,6 +282,7 @@ func (idx *Indexer) GetStatus() map[string]interface{} {
 		"is_indexing":       idx.Status == "running",
 		"status":            idx.Status,
 		"error":             idx.Error,
+		"skipped_clusters":  idx.SkippedClusters,
 	}
 }
 
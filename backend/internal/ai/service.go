@@ -2,6 +2,7 @@ package ai
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"sync"
@@ -11,9 +12,12 @@ import (
 	"github.com/darkden-lab/argus/backend/internal/ai/tools"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
 	"github.com/darkden-lab/argus/backend/internal/plugin"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// systemPrompt is the built-in system prompt, used unless the deployment
+// configures its own.
 const systemPrompt = `You are a Kubernetes assistant integrated into the K8s Dashboard.
 You help users understand and manage their Kubernetes clusters.
 
@@ -42,6 +46,25 @@ type Service struct {
 	memoryStore *MemoryStore
 	agentStore  *AgentStore
 	rateLimiter *RateLimiter
+	breaker     *CircuitBreaker
+	named       map[string]namedLLM // providers chat requests may select besides the primary one
+}
+
+// namedLLM is a named provider with the circuit breaker guarding it, so one
+// failing provider does not shed requests for the others.
+type namedLLM struct {
+	provider LLMProvider
+	breaker  *CircuitBreaker
+}
+
+// UnknownProviderError is returned when a chat request selects a provider
+// that is not configured.
+type UnknownProviderError struct {
+	Name string
+}
+
+func (e *UnknownProviderError) Error() string {
+	return fmt.Sprintf("unknown AI provider %q", e.Name)
 }
 
 // NewService creates a new AI service orchestrator.
@@ -55,6 +78,13 @@ func NewService(
 	memoryStore *MemoryStore,
 ) *Service {
 	exec := tools.NewExecutor(clusterMgr, pluginEngine, pool)
+	confirmMgr := tools.NewConfirmationManager()
+	confirmMgr.SetStore(tools.NewConfirmationStore(pool))
+	exec.SetConfirmationManager(confirmMgr)
+	exec.SetRedactor(config.Redactor())
+	exec.SetToolPolicy(config.ToolPolicy())
+	exec.SetLogMaxBytes(config.LogMaxBytes)
+	exec.SetResultMaxBytes(config.ToolResultMaxBytes)
 	if memoryStore != nil {
 		exec.SetMemoryOps(memoryStore)
 	}
@@ -62,16 +92,108 @@ func NewService(
 	auditLogger := tools.NewAuditLogger(pool)
 	exec.SetAuditLogger(auditLogger)
 
+	breaker := NewCircuitBreaker(defaultBreakerThreshold, defaultBreakerCooldown)
 	return &Service{
-		provider:    provider,
+		provider:    guardProvider(provider, breaker),
 		retriever:   retriever,
 		executor:    exec,
-		confirmMgr:  tools.NewConfirmationManager(),
+		confirmMgr:  confirmMgr,
 		pool:        pool,
 		config:      config,
 		memoryStore: memoryStore,
 		rateLimiter: NewRateLimiter(defaultMaxMessages, defaultWindowPeriod),
+		breaker:     breaker,
+	}
+}
+
+// guardProvider wraps provider so its chat calls go through breaker.
+func guardProvider(provider LLMProvider, breaker *CircuitBreaker) LLMProvider {
+	if provider == nil || breaker == nil {
+		return nil
+	}
+	return &breakerProvider{LLMProvider: provider, breaker: breaker}
+}
+
+// Breaker returns the circuit breaker guarding the provider's chat calls.
+func (s *Service) Breaker() *CircuitBreaker {
+	return s.breaker
+}
+
+// SetProviders sets the named providers chat requests may select, keyed by
+// name. They are built once from AI_PROVIDERS and are not affected by
+// UpdateProvider.
+func (s *Service) SetProviders(named map[string]LLMProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.named = make(map[string]namedLLM, len(named))
+	for name, provider := range named {
+		breaker := NewCircuitBreaker(defaultBreakerThreshold, defaultBreakerCooldown)
+		s.named[name] = namedLLM{provider: guardProvider(provider, breaker), breaker: breaker}
+	}
+}
+
+// ProviderNames returns the providers chat requests may select, the primary
+// one first, and the one used when a request doesn't select any.
+func (s *Service) ProviderNames() ([]string, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.named)+1)
+	names = append(names, PrimaryProvider)
+	for _, p := range s.config.Providers {
+		if _, ok := s.named[p.Name]; ok {
+			names = append(names, p.Name)
+		}
+	}
+	def := s.config.DefaultProvider
+	if def == "" {
+		def = PrimaryProvider
+	}
+	return names, def
+}
+
+// ProviderBreaker returns the circuit breaker guarding the provider a chat
+// request selects, empty for the default one. It returns an
+// *UnknownProviderError for a provider that is not configured.
+func (s *Service) ProviderBreaker(name string) (*CircuitBreaker, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if name == "" {
+		name = s.config.DefaultProvider
+	}
+	if name == "" || name == PrimaryProvider {
+		return s.breaker, nil
+	}
+	named, ok := s.named[name]
+	if !ok {
+		return nil, &UnknownProviderError{Name: name}
+	}
+	return named.breaker, nil
+}
+
+// chatProvider returns the provider a chat request selects, empty for the
+// default one, and the configuration to call it with.
+func (s *Service) chatProvider(name string) (LLMProvider, AIConfig, error) {
+	if name == "" {
+		_, cfg := s.Snapshot()
+		name = cfg.DefaultProvider
 	}
+	return s.providerFor(name)
+}
+
+// providerFor returns the named provider, empty for the primary one, and
+// the configuration to call it with.
+func (s *Service) providerFor(name string) (LLMProvider, AIConfig, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if name == "" || name == PrimaryProvider {
+		return s.provider, s.config, nil
+	}
+	named, ok := s.named[name]
+	p, configured := s.config.NamedProvider(name)
+	if !ok || !configured {
+		return nil, AIConfig{}, &UnknownProviderError{Name: name}
+	}
+	return named.provider, s.config.WithProvider(p), nil
 }
 
 // SetRetriever sets the RAG retriever after construction.
@@ -80,6 +202,18 @@ func (s *Service) SetRetriever(r *rag.Retriever) {
 	s.retriever = r
 }
 
+// SetAuthorizer sets the RBAC authorizer that scopes tool execution to the
+// requesting user's permissions. Without it all cluster tools are denied.
+func (s *Service) SetAuthorizer(a rbac.Evaluator) {
+	s.executor.SetAuthorizer(a)
+}
+
+// SetApplyPolicy sets the labels, annotations and namespace creation the
+// apply_yaml tool applies.
+func (s *Service) SetApplyPolicy(p cluster.ApplyPolicy) {
+	s.executor.SetApplyPolicy(p)
+}
+
 // SetAgentStore sets the agent store after construction.
 func (s *Service) SetAgentStore(store *AgentStore) {
 	s.agentStore = store
@@ -111,22 +245,37 @@ func (s *Service) CreateTask(ctx context.Context, task *AgentTask) error {
 	return s.agentStore.CreateTask(ctx, task)
 }
 
-// UpdateProvider swaps the active LLM provider and config at runtime.
+// UpdateProvider swaps the active LLM provider and config at runtime,
+// including the tool policy the executor enforces. The circuit breaker is
+// reset, since failures of the old provider say nothing about the new one.
 // This is safe to call concurrently with ProcessMessage.
 func (s *Service) UpdateProvider(provider LLMProvider, config AIConfig) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.provider = provider
+	s.provider = guardProvider(provider, s.breaker)
+	if s.breaker != nil {
+		s.breaker.Reset()
+	}
 	s.config = config
+	if s.executor != nil {
+		s.executor.SetToolPolicy(config.ToolPolicy())
+	}
 	log.Printf("ai service: provider updated to %s (model=%s, enabled=%v)", config.Provider, config.Model, config.Enabled)
 }
 
+// contextDefaultsNote tells the model that tool calls default to the page
+// context, so it can leave cluster_id and namespace out.
+const contextDefaultsNote = "\n\nTool calls default to this cluster and namespace when you leave out cluster_id or namespace. Pass namespace \"\" to cover all namespaces."
+
 // ChatContext holds the page context sent by the frontend.
 type ChatContext struct {
 	ClusterID string `json:"cluster_id,omitempty"`
 	Namespace string `json:"namespace,omitempty"`
 	Resource  string `json:"resource,omitempty"`
 	Name      string `json:"name,omitempty"`
+	// Provider is the named provider to answer with; empty uses the
+	// default one.
+	Provider string `json:"provider,omitempty"`
 }
 
 // Snapshot returns a consistent copy of the current provider and config.
@@ -136,8 +285,32 @@ func (s *Service) Snapshot() (LLMProvider, AIConfig) {
 	return s.provider, s.config
 }
 
+// Status reports whether the assistant is enabled and which provider it
+// currently uses.
+func (s *Service) Status() (bool, string) {
+	_, cfg := s.Snapshot()
+	return cfg.Enabled, string(cfg.Provider)
+}
+
+// CheckProvider sends the provider a minimal chat request to check it is
+// reachable and accepts the configured credentials. It goes through the
+// circuit breaker, so an open breaker is reported without calling out.
+func (s *Service) CheckProvider(ctx context.Context) error {
+	provider, cfg := s.Snapshot()
+	if !cfg.Enabled {
+		return fmt.Errorf("AI assistant is not enabled")
+	}
+	_, err := provider.Chat(ctx, ChatRequest{
+		Messages:    []Message{{Role: RoleUser, Content: "Hello"}},
+		MaxTokens:   16,
+		Temperature: 0,
+	})
+	return err
+}
+
 // buildConversationMessages assembles the full message list for an LLM call:
-// system prompt + conversation history + RAG context + user message.
+// system prompt + conversation history + RAG context + user message, trimmed
+// to fit the configured context window.
 func (s *Service) buildConversationMessages(ctx context.Context, userID, conversationID, userMessage string, pageCtx ChatContext) []Message {
 	messages := []Message{
 		{Role: RoleSystem, Content: s.buildSystemPrompt(ctx, userID, pageCtx)},
@@ -174,7 +347,8 @@ func (s *Service) buildConversationMessages(ctx context.Context, userID, convers
 		messages = append(messages, Message{Role: RoleUser, Content: userMessage})
 	}
 
-	return messages
+	_, cfg := s.Snapshot()
+	return fitContextWindow(messages, promptBudget(cfg))
 }
 
 // ProcessMessage handles a user message through the full AI pipeline:
@@ -190,7 +364,10 @@ func (s *Service) ProcessMessage(ctx context.Context, userID string, conversatio
 		return nil, err
 	}
 
-	provider, cfg := s.Snapshot()
+	provider, cfg, err := s.chatProvider(pageCtx.Provider)
+	if err != nil {
+		return nil, err
+	}
 
 	if !cfg.Enabled {
 		return nil, fmt.Errorf("AI assistant is not enabled, enable it in Settings > AI Configuration")
@@ -198,8 +375,8 @@ func (s *Service) ProcessMessage(ctx context.Context, userID string, conversatio
 
 	messages := s.buildConversationMessages(ctx, userID, conversationID, userMessage, pageCtx)
 
-	// Call LLM with tools based on permission level
-	allTools := tools.ToolsForLevel(string(cfg.ToolPermissionLevel))
+	// Call LLM with the tools the permission level and tool policy allow
+	allTools := cfg.ToolPolicy().Tools()
 	req := ChatRequest{
 		Messages:    messages,
 		Tools:       allTools,
@@ -212,42 +389,52 @@ func (s *Service) ProcessMessage(ctx context.Context, userID string, conversatio
 		return nil, fmt.Errorf("ai service: LLM call failed: %w", err)
 	}
 
+	// Save the user message first so tool turns are stored after it
+	s.saveMessage(ctx, conversationID, Message{Role: RoleUser, Content: userMessage})
+
 	// Handle tool calls
 	if resp.FinishReason == "tool_calls" && len(resp.Message.ToolCalls) > 0 {
-		return s.handleToolCalls(ctx, userID, messages, resp, allTools)
+		resp, err = s.handleToolCalls(ctx, userID, conversationID, messages, resp, allTools, pageCtx)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Save messages
-	s.saveMessage(ctx, conversationID, Message{Role: RoleUser, Content: userMessage})
 	s.saveMessage(ctx, conversationID, resp.Message)
 
 	log.Printf("ai: ProcessMessage user=%s conv=%s provider=%s model=%s duration_ms=%d", userID, conversationID, cfg.Provider, cfg.Model, time.Since(start).Milliseconds())
 	return resp, nil
 }
 
-// handleToolCalls executes tool calls and continues the conversation.
-func (s *Service) handleToolCalls(ctx context.Context, userID string, messages []Message, resp *ChatResponse, allTools []Tool) (*ChatResponse, error) {
-	// Add assistant message with tool calls
-	messages = append(messages, resp.Message)
+// handleToolCalls executes tool calls and continues the conversation. The
+// assistant tool-call message and every tool result are persisted so resumed
+// conversations keep the full context.
+func (s *Service) handleToolCalls(ctx context.Context, userID, conversationID string, messages []Message, resp *ChatResponse, allTools []Tool, pageCtx ChatContext) (*ChatResponse, error) {
+	record := func(m Message) {
+		messages = append(messages, m)
+		s.saveMessage(ctx, conversationID, m)
+	}
+
+	// Add assistant message with tool calls, defaulted to the page context
+	resp.Message.ToolCalls = s.toolDefaults(ctx, userID, pageCtx).applyTo(resp.Message.ToolCalls)
+	record(resp.Message)
 
 	for _, call := range resp.Message.ToolCalls {
-		// Check if tool requires confirmation
+		// Disabled tools are refused before the user is asked to confirm them
+		if !s.executor.Allows(call.Name) {
+			record(disabledToolMessage(call))
+			continue
+		}
+
+		// Write and destructive tools only run once the user confirms them
 		if tools.RequiresConfirm(call.Name) {
-			status, err := s.confirmMgr.RequestConfirmation(ctx, userID, call)
-			if err != nil || status != tools.ConfirmationApproved {
-				// Return a message saying the action was cancelled
-				messages = append(messages, Message{
-					Role:       RoleTool,
-					Content:    "Action cancelled by user or timed out.",
-					ToolCallID: call.ID,
-				})
-				continue
-			}
+			record(s.confirmAndExecute(ctx, userID, call, nil))
+			continue
 		}
 
 		// Execute the tool
 		result := s.executor.ExecuteForUser(ctx, call, userID)
-		messages = append(messages, Message{
+		record(Message{
 			Role:       RoleTool,
 			Content:    result.Content,
 			ToolCallID: call.ID,
@@ -255,7 +442,10 @@ func (s *Service) handleToolCalls(ctx context.Context, userID string, messages [
 	}
 
 	// Re-invoke LLM with tool results
-	provider, cfg := s.Snapshot()
+	provider, cfg, err := s.chatProvider(pageCtx.Provider)
+	if err != nil {
+		return nil, err
+	}
 	req := ChatRequest{
 		Messages:    messages,
 		Tools:       allTools,
@@ -274,7 +464,10 @@ func (s *Service) ProcessMessageStream(ctx context.Context, userID string, conve
 		return nil, err
 	}
 
-	provider, cfg := s.Snapshot()
+	provider, cfg, err := s.chatProvider(pageCtx.Provider)
+	if err != nil {
+		return nil, err
+	}
 
 	if !cfg.Enabled {
 		return nil, fmt.Errorf("AI assistant is not enabled, enable it in Settings > AI Configuration")
@@ -282,7 +475,7 @@ func (s *Service) ProcessMessageStream(ctx context.Context, userID string, conve
 
 	messages := s.buildConversationMessages(ctx, userID, conversationID, userMessage, pageCtx)
 
-	toolDefs := tools.ToolsForLevel(string(cfg.ToolPermissionLevel))
+	toolDefs := cfg.ToolPolicy().Tools()
 	req := ChatRequest{
 		Messages:    messages,
 		Tools:       toolDefs,
@@ -306,54 +499,45 @@ type ConfirmNotifyFunc func(req *tools.ConfirmationRequest)
 // ExecuteTools handles the tool-call loop for streaming: it rebuilds the
 // conversation, executes the accumulated tool calls, and re-invokes the LLM.
 // If confirmNotify is non-nil, it is called before blocking on each
-// confirmation (for Socket.IO event emission).
+// confirmation (for Socket.IO event emission). The assistant tool-call message
+// and the tool results are persisted; the caller saves the final reply.
 func (s *Service) ExecuteTools(ctx context.Context, userID string, conversationID string, userMessage string, pageCtx ChatContext, assistantContent string, toolCalls []ToolCall, confirmNotify ConfirmNotifyFunc) (*ChatResponse, error) {
 	start := time.Now()
 
-	provider, cfg := s.Snapshot()
+	provider, cfg, err := s.chatProvider(pageCtx.Provider)
+	if err != nil {
+		return nil, err
+	}
 
 	messages := s.buildConversationMessages(ctx, userID, conversationID, userMessage, pageCtx)
+	record := func(m Message) {
+		messages = append(messages, m)
+		s.saveMessage(ctx, conversationID, m)
+	}
 
-	// Add assistant message with tool calls
-	messages = append(messages, Message{
+	// Add assistant message with tool calls, defaulted to the page context
+	toolCalls = s.toolDefaults(ctx, userID, pageCtx).applyTo(toolCalls)
+	record(Message{
 		Role:      RoleAssistant,
 		Content:   assistantContent,
 		ToolCalls: toolCalls,
 	})
 
-	allTools := tools.ToolsForLevel(string(cfg.ToolPermissionLevel))
+	allTools := cfg.ToolPolicy().Tools()
 
 	// Execute each tool
 	for _, call := range toolCalls {
+		// Disabled tools are refused before the user is asked to confirm them
+		if !s.executor.Allows(call.Name) {
+			record(disabledToolMessage(call))
+			continue
+		}
 		if tools.RequiresConfirm(call.Name) {
-			if confirmNotify != nil {
-				// Create the confirmation first (non-blocking), notify the client, then wait
-				req := s.confirmMgr.CreateRequest(userID, tools.ToolCall(call))
-				confirmNotify(req)
-				status, err := s.confirmMgr.WaitForRequest(ctx, req.ID)
-				if err != nil || status != tools.ConfirmationApproved {
-					messages = append(messages, Message{
-						Role:       RoleTool,
-						Content:    "Action cancelled by user or timed out.",
-						ToolCallID: call.ID,
-					})
-					continue
-				}
-			} else {
-				// Blocking confirmation (no notifier)
-				status, err := s.confirmMgr.RequestConfirmation(ctx, userID, call)
-				if err != nil || status != tools.ConfirmationApproved {
-					messages = append(messages, Message{
-						Role:       RoleTool,
-						Content:    "Action cancelled by user or timed out.",
-						ToolCallID: call.ID,
-					})
-					continue
-				}
-			}
+			record(s.confirmAndExecute(ctx, userID, call, confirmNotify))
+			continue
 		}
 		result := s.executor.ExecuteForUser(ctx, call, userID)
-		messages = append(messages, Message{
+		record(Message{
 			Role:       RoleTool,
 			Content:    result.Content,
 			ToolCallID: call.ID,
@@ -371,6 +555,68 @@ func (s *Service) ExecuteTools(ctx context.Context, userID string, conversationI
 	return provider.Chat(ctx, req)
 }
 
+// confirmAndExecute proposes a write or destructive tool call to the user,
+// waits for their decision and runs the call only under that approval. If
+// notify is non-nil it is called before blocking so the client can present
+// the proposed call and its classification.
+func (s *Service) confirmAndExecute(ctx context.Context, userID string, call ToolCall, notify ConfirmNotifyFunc) Message {
+	req := s.confirmMgr.CreateRequest(userID, tools.ToolCall(call))
+	if notify != nil {
+		notify(req)
+	}
+	status, err := s.confirmMgr.WaitForRequest(ctx, req.ID)
+	if err != nil || status != tools.ConfirmationApproved {
+		return Message{
+			Role:       RoleTool,
+			Content:    "Action cancelled by user or timed out.",
+			ToolCallID: call.ID,
+		}
+	}
+
+	result := s.executor.ExecuteConfirmed(ctx, call, userID, req.ID)
+	return Message{
+		Role:       RoleTool,
+		Content:    result.Content,
+		ToolCallID: call.ID,
+	}
+}
+
+// toolDefaults returns the page context that tool calls default to. A
+// selected cluster the user may not read is dropped, so a stale or forged
+// page context cannot point tools at it.
+func (s *Service) toolDefaults(ctx context.Context, userID string, pageCtx ChatContext) ChatContext {
+	if pageCtx.ClusterID == "" {
+		return ChatContext{}
+	}
+	if !s.executor.CanReadCluster(ctx, userID, pageCtx.ClusterID, pageCtx.Namespace) {
+		log.Printf("ai: not defaulting tool calls to cluster %s: user %s may not read it", pageCtx.ClusterID, userID)
+		return ChatContext{}
+	}
+	return pageCtx
+}
+
+// applyTo returns the tool calls with the context's cluster and namespace
+// filled in where the model left them out; see tools.ApplyContextDefaults.
+func (c ChatContext) applyTo(calls []ToolCall) []ToolCall {
+	if c.ClusterID == "" {
+		return calls
+	}
+	out := make([]ToolCall, len(calls))
+	for i, call := range calls {
+		out[i] = tools.ApplyContextDefaults(call, c.ClusterID, c.Namespace)
+	}
+	return out
+}
+
+// disabledToolMessage is the tool result for a call the tool policy refused.
+func disabledToolMessage(call ToolCall) Message {
+	return Message{
+		Role:       RoleTool,
+		Content:    tools.DisabledResult(tools.ToolCall(call)).Content,
+		ToolCallID: call.ID,
+	}
+}
+
 // ExecuteToolsAndRespond is a convenience wrapper that calls ExecuteTools with no confirmation notifier.
 func (s *Service) ExecuteToolsAndRespond(ctx context.Context, userID string, conversationID string, userMessage string, pageCtx ChatContext, assistantContent string, toolCalls []ToolCall) (*ChatResponse, error) {
 	return s.ExecuteTools(ctx, userID, conversationID, userMessage, pageCtx, assistantContent, toolCalls, nil)
@@ -387,7 +633,11 @@ func (s *Service) GetConfirmationManager() *tools.ConfirmationManager {
 }
 
 func (s *Service) buildSystemPrompt(ctx context.Context, userID string, pageCtx ChatContext) string {
+	_, cfg := s.Snapshot()
 	prompt := systemPrompt
+	if cfg.SystemPrompt != "" {
+		prompt = cfg.SystemPrompt
+	}
 	if pageCtx.ClusterID != "" {
 		prompt += fmt.Sprintf("\n\nUser's current context:\n- Cluster: %s", pageCtx.ClusterID)
 		if pageCtx.Namespace != "" {
@@ -399,6 +649,7 @@ func (s *Service) buildSystemPrompt(ctx context.Context, userID string, pageCtx
 				prompt += fmt.Sprintf("/%s", pageCtx.Name)
 			}
 		}
+		prompt += contextDefaultsNote
 	}
 
 	// Inject user memories
@@ -411,12 +662,19 @@ func (s *Service) buildSystemPrompt(ctx context.Context, userID string, pageCtx
 		}
 
 		// Instruct AI about memory tools when they are available
-		_, cfg := s.Snapshot()
-		if cfg.ToolPermissionLevel != "disabled" {
-			prompt += "\n\n## Memory System\nYou have access to a memory system via tools: save_memory, recall_memory, delete_memory. " +
+		policy := cfg.ToolPolicy()
+		if policy.Allows("save_memory") && policy.Allows("recall_memory") {
+			canForget := policy.Allows("delete_memory")
+			memoryTools := "save_memory, recall_memory"
+			if canForget {
+				memoryTools += ", delete_memory"
+			}
+			prompt += "\n\n## Memory System\nYou have access to a memory system via tools: " + memoryTools + ". " +
 				"Proactively save important user preferences, environment details, and recurring patterns using save_memory. " +
-				"Always use recall_memory before saving to avoid duplicates. " +
-				"When the user asks you to forget something, use delete_memory."
+				"Always use recall_memory before saving to avoid duplicates."
+			if canForget {
+				prompt += " When the user asks you to forget something, use delete_memory."
+			}
 		}
 	}
 
@@ -439,6 +697,7 @@ func (s *Service) BuildAgentSystemPrompt(ctx context.Context, userID string, age
 				prompt += fmt.Sprintf("/%s", pageCtx.Name)
 			}
 		}
+		prompt += contextDefaultsNote
 	}
 
 	if s.memoryStore != nil && userID != "" {
@@ -473,7 +732,7 @@ func (s *Service) ResolveAgentTools(agent *Agent) []Tool {
 		effectiveLevel = "read_only"
 	}
 
-	allTools := tools.ToolsForLevel(effectiveLevel)
+	allTools := cfg.ToolPolicy().Filter(tools.ToolsForLevel(effectiveLevel))
 	if len(agent.AllowedTools) == 0 {
 		return allTools
 	}
@@ -602,6 +861,9 @@ func (s *Service) getHistoryWithSummary(ctx context.Context, conversationID stri
 		if toolCallID != nil {
 			m.ToolCallID = *toolCallID
 		}
+		if toolCallsJSON != nil {
+			m.ToolCalls = decodeToolCalls(*toolCallsJSON)
+		}
 		recent = append(recent, m)
 	}
 	if err := rows.Err(); err != nil {
@@ -740,6 +1002,9 @@ func (s *Service) loadHistory(ctx context.Context, conversationID string) ([]Mes
 		if toolCallID != nil {
 			m.ToolCallID = *toolCallID
 		}
+		if toolCallsJSON != nil {
+			m.ToolCalls = decodeToolCalls(*toolCallsJSON)
+		}
 		messages = append(messages, m)
 	}
 	if err := rows.Err(); err != nil {
@@ -754,13 +1019,30 @@ func (s *Service) saveMessage(ctx context.Context, conversationID string, msg Me
 		return
 	}
 
+	var toolCallsJSON []byte
+	if len(msg.ToolCalls) > 0 {
+		var err error
+		if toolCallsJSON, err = json.Marshal(msg.ToolCalls); err != nil {
+			log.Printf("ai service: failed to marshal tool calls: %v", err)
+		}
+	}
+
 	_, err := s.pool.Exec(ctx,
-		`INSERT INTO ai_messages (conversation_id, role, content, tool_call_id)
-		 VALUES ($1, $2, $3, $4)`,
-		conversationID, string(msg.Role), msg.Content, nilIfEmpty(msg.ToolCallID),
+		`INSERT INTO ai_messages (conversation_id, role, content, tool_calls, tool_call_id)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		conversationID, string(msg.Role), msg.Content, toolCallsJSON, nilIfEmpty(msg.ToolCallID),
 	)
 	if err != nil {
 		log.Printf("ai service: failed to save message: %v", err)
+		return
+	}
+
+	// Keep the conversation list ordered by latest activity
+	if _, err := s.pool.Exec(ctx,
+		`UPDATE ai_conversations SET updated_at = NOW() WHERE id = $1`,
+		conversationID,
+	); err != nil {
+		log.Printf("ai service: failed to touch conversation: %v", err)
 	}
 }
 
@@ -772,26 +1054,38 @@ func nilIfEmpty(s string) *string {
 }
 
 // ProviderEmbedder adapts an LLMProvider to the rag.Embedder interface.
-// It reads the current provider from the Service via snapshot() so that
-// hot-reloads are reflected in embedding calls.
+// It reads the embedding provider, AI_EMBED_PROVIDER or else the primary
+// one, from the Service on every call so that hot-reloads are reflected in
+// embedding calls. Recent results are cached
+// and identical concurrent requests share one provider call.
 type ProviderEmbedder struct {
 	service *Service
+	cache   *embedCache
 }
 
 // NewProviderEmbedder creates an embedder that tracks the Service's active provider.
 func NewProviderEmbedder(s *Service) *ProviderEmbedder {
-	return &ProviderEmbedder{service: s}
+	return &ProviderEmbedder{service: s, cache: newEmbedCache(defaultEmbedCacheSize)}
 }
 
 // EmbedTexts implements rag.Embedder.
 func (pe *ProviderEmbedder) EmbedTexts(ctx context.Context, input []string) ([][]float32, error) {
-	provider, _ := pe.service.Snapshot()
+	_, current := pe.service.Snapshot()
+	provider, cfg, err := pe.service.providerFor(current.EmbedProvider)
+	if err != nil {
+		return nil, fmt.Errorf("ai embedder: %w", err)
+	}
 	if provider == nil {
 		return nil, fmt.Errorf("ai embedder: no LLM provider configured")
 	}
-	resp, err := provider.Embed(ctx, EmbedRequest{Input: input})
-	if err != nil {
-		return nil, err
-	}
-	return resp.Embeddings, nil
+	// Vectors from different providers or models are not comparable, so
+	// they are cached separately.
+	scope := fmt.Sprintf("%s\x00%s\x00%s\x00%s", cfg.Provider, cfg.BaseURL, cfg.Model, cfg.EmbedModel)
+	return pe.cache.embed(ctx, scope, input, func(ctx context.Context, texts []string) ([][]float32, error) {
+		resp, err := provider.Embed(ctx, EmbedRequest{Input: texts})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Embeddings, nil
+	})
 }
@@ -2,8 +2,12 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
+
+	"github.com/darkden-lab/argus/backend/internal/ai/tools"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
 )
 
 func TestBuildSystemPrompt_NoContext(t *testing.T) {
@@ -38,6 +42,111 @@ func TestBuildSystemPrompt_WithContext(t *testing.T) {
 	}
 }
 
+func TestBuildSystemPrompt_CustomPrompt(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SystemPrompt = "You are the platform team's on-call helper."
+	s := &Service{config: cfg}
+	prompt := s.buildSystemPrompt(context.Background(), "", ChatContext{ClusterID: "cluster-1"})
+
+	if !strings.HasPrefix(prompt, cfg.SystemPrompt) {
+		t.Errorf("expected custom prompt to replace the built-in one, got %q", prompt)
+	}
+	if strings.Contains(prompt, "Kubernetes assistant") {
+		t.Error("expected built-in prompt to be replaced")
+	}
+	if !strings.Contains(prompt, "cluster-1") {
+		t.Error("expected page context to still be appended")
+	}
+}
+
+func TestUpdateProvider_HotReloadsToolPolicy(t *testing.T) {
+	s := &Service{executor: tools.NewExecutor(nil, nil, nil)}
+	cfg := DefaultConfig()
+	s.UpdateProvider(nil, cfg)
+	if s.executor.Allows("get_pod_exec") {
+		t.Fatal("expected destructive tool to be disabled by default")
+	}
+
+	cfg.EnabledTools = []string{"get_pod_exec"}
+	cfg.DisabledTools = []string{"apply_yaml"}
+	s.UpdateProvider(nil, cfg)
+	if !s.executor.Allows("get_pod_exec") || s.executor.Allows("apply_yaml") {
+		t.Error("expected the executor to follow the updated tool policy")
+	}
+}
+
+func TestChatProvider_SelectsNamedProvider(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Providers = []NamedProvider{{Name: "local", Provider: ProviderOllama, BaseURL: "http://ollama:11434", Model: "llama3"}}
+	primary, local := &stubProvider{}, &stubProvider{}
+	s := &Service{config: cfg, breaker: NewCircuitBreaker(0, 0)}
+	s.provider = guardProvider(primary, s.breaker)
+	s.SetProviders(map[string]LLMProvider{"local": local})
+
+	provider, got, err := s.chatProvider("local")
+	if err != nil || got.Model != "llama3" || got.Provider != ProviderOllama {
+		t.Fatalf("expected the local provider's config, got %+v (%v)", got, err)
+	}
+	provider.Chat(context.Background(), ChatRequest{}) //nolint:errcheck
+	if local.calls != 1 || primary.calls != 0 {
+		t.Errorf("expected the call to go to the local provider, got local=%d primary=%d", local.calls, primary.calls)
+	}
+
+	if _, got, _ := s.chatProvider(""); got.Model != cfg.Model {
+		t.Errorf("expected the primary provider by default, got %q", got.Model)
+	}
+	s.config.DefaultProvider = "local"
+	if _, got, _ := s.chatProvider(""); got.Model != "llama3" {
+		t.Errorf("expected the configured default provider, got %q", got.Model)
+	}
+	if _, got, _ := s.chatProvider(PrimaryProvider); got.Model != cfg.Model {
+		t.Errorf("expected the primary provider to stay selectable, got %q", got.Model)
+	}
+
+	var unknown *UnknownProviderError
+	if _, _, err := s.chatProvider("gpt"); !errors.As(err, &unknown) {
+		t.Errorf("expected an UnknownProviderError, got %v", err)
+	}
+	if _, err := s.ProviderBreaker("gpt"); !errors.As(err, &unknown) {
+		t.Errorf("expected ProviderBreaker to refuse an unknown provider, got %v", err)
+	}
+	if b, _ := s.ProviderBreaker("local"); b == nil || b == s.breaker {
+		t.Error("expected the local provider to have its own breaker")
+	}
+
+	names, def := s.ProviderNames()
+	if strings.Join(names, ",") != "primary,local" || def != "local" {
+		t.Errorf("ProviderNames() = %v, %q", names, def)
+	}
+}
+
+// clusterReaders lets the listed users read clusters.
+type clusterReaders map[string]bool
+
+func (a clusterReaders) Evaluate(_ context.Context, req rbac.Request) (bool, error) {
+	return a[req.UserID] && req.Resource == "clusters" && req.Action == "read", nil
+}
+
+func TestToolDefaults_RequiresClusterAccess(t *testing.T) {
+	s := &Service{executor: tools.NewExecutor(nil, nil, nil)}
+	s.SetAuthorizer(clusterReaders{"u1": true})
+	pageCtx := ChatContext{ClusterID: "c1", Namespace: "team-a"}
+	calls := []ToolCall{{ID: "1", Name: "get_resources", Arguments: `{"kind":"pods"}`}}
+
+	got := s.toolDefaults(context.Background(), "u1", pageCtx).applyTo(calls)
+	if !strings.Contains(got[0].Arguments, `"cluster_id":"c1"`) || !strings.Contains(got[0].Arguments, `"namespace":"team-a"`) {
+		t.Errorf("expected the page context to be filled in, got %s", got[0].Arguments)
+	}
+	if calls[0].Arguments != `{"kind":"pods"}` {
+		t.Error("expected the original tool calls to be left unchanged")
+	}
+
+	got = s.toolDefaults(context.Background(), "u2", pageCtx).applyTo(calls)
+	if got[0].Arguments != `{"kind":"pods"}` {
+		t.Errorf("expected no defaults for a user who may not read the cluster, got %s", got[0].Arguments)
+	}
+}
+
 func TestNilIfEmpty(t *testing.T) {
 	if nilIfEmpty("") != nil {
 		t.Error("expected nil for empty string")
@@ -268,7 +268,8 @@ func (tr *TaskRunner) resolveAgentTools(agent *Agent, levelOverride string) []To
 		level = agent.ToolPermissionLevel
 	}
 
-	allTools := tools.ToolsForLevel(level)
+	_, cfg := tr.service.Snapshot()
+	allTools := cfg.ToolPolicy().Filter(tools.ToolsForLevel(level))
 	if len(agent.AllowedTools) == 0 {
 		return allTools
 	}
@@ -21,19 +21,27 @@ func NewAuditLogger(pool *pgxpool.Pool) *AuditLogger {
 	return &AuditLogger{pool: pool}
 }
 
-// Log records a tool execution in the ai_tool_audit table.
-func (a *AuditLogger) Log(ctx context.Context, userID, toolName, arguments, result string, isError bool, durationMs int64) {
+// Log records a tool execution in the ai_tool_audit table. For write and
+// destructive tools, confirmation identifies the approval the call ran under
+// and who confirmed it; it is nil for read tools.
+func (a *AuditLogger) Log(ctx context.Context, userID, toolName, arguments, result string, isError bool, durationMs int64, confirmation *ConfirmationRequest) {
 	if a == nil || a.pool == nil {
 		return
 	}
+	var confirmationID, confirmedBy string
+	if confirmation != nil {
+		confirmationID = confirmation.ID
+		confirmedBy = confirmation.ConfirmedBy
+	}
 	// Fire and forget — don't block the tool execution pipeline
 	go func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		_, err := a.pool.Exec(ctx,
-			`INSERT INTO ai_tool_audit (user_id, tool_name, arguments, result, is_error, duration_ms)
-			 VALUES ($1, $2, $3, $4, $5, $6)`,
+			`INSERT INTO ai_tool_audit (user_id, tool_name, arguments, result, is_error, duration_ms, classification, confirmation_id, confirmed_by)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, '')::uuid, NULLIF($9, '')::uuid)`,
 			userID, toolName, truncateForAudit(arguments, 4096), truncateForAudit(result, 4096), isError, durationMs,
+			string(ClassifyTool(toolName)), confirmationID, confirmedBy,
 		)
 		if err != nil {
 			log.Printf("ai audit: failed to log tool execution: %v", err)
@@ -0,0 +1,184 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+// permissionError is returned when the requesting user's RBAC scope does not
+// cover a tool call.
+type permissionError struct {
+	tool string
+	req  rbac.Request
+}
+
+func (e *permissionError) Error() string {
+	msg := fmt.Sprintf("permission denied: %s requires %s access to %s", e.tool, e.req.Action, e.req.Resource)
+	if e.req.Namespace != "" {
+		msg += " in namespace " + e.req.Namespace
+	}
+	if e.req.ClusterID != "" {
+		msg += " on cluster " + e.req.ClusterID
+	}
+	return msg
+}
+
+// toolPermissions maps a tool call to the RBAC requests the user must pass,
+// so the AI cannot do anything the user could not do directly. UserID is
+// filled in by the caller. Unknown tools yield an error so new tools are
+// denied until they are mapped here.
+func toolPermissions(name string, args map[string]string) ([]rbac.Request, error) {
+	clusterID := args["cluster_id"]
+	namespace := args["namespace"]
+
+	onCluster := func(resource, action string) []rbac.Request {
+		return []rbac.Request{{Resource: resource, Action: action, ClusterID: clusterID, Namespace: namespace}}
+	}
+	onKind := func(kind, action string) []rbac.Request {
+		gvr := kindToGVR(kind)
+		reqs := onCluster(rbac.ResourceCategory(gvr.Resource), action)
+		reqs[0].K8sResource = rbac.QualifiedResource(gvr.Group, gvr.Resource)
+		return reqs
+	}
+
+	switch name {
+	case "get_resources", "describe_resource":
+		return onKind(args["kind"], "read"), nil
+	case "search_resources":
+		if args["kind"] == "" {
+			return onCluster("clusters", "read"), nil
+		}
+		return onKind(args["kind"], "read"), nil
+	case "get_events":
+		return onKind("events", "read"), nil
+	case "get_logs", "port_forward_info":
+		return onKind("pods", "read"), nil
+	case "get_network_policies":
+		return onKind("networkpolicies", "read"), nil
+	case "get_helm_releases":
+		return onCluster("plugins", "read"), nil
+	case "get_metrics", "query_prometheus", "get_alerts":
+		return onCluster("monitoring", "read"), nil
+	case "analyze_rbac", "cluster_health_check", "security_scan", "resource_usage_report":
+		return onCluster("clusters", "read"), nil
+	case "compare_clusters":
+		return []rbac.Request{
+			{Resource: "clusters", Action: "read", ClusterID: args["cluster_id_1"]},
+			{Resource: "clusters", Action: "read", ClusterID: args["cluster_id_2"]},
+		}, nil
+	case "get_pod_exec":
+		return onCluster("terminal", "write"), nil
+	case "delete_resource":
+		return onKind(args["kind"], "delete"), nil
+	case "scale_resource", "restart_resource":
+		return onKind(args["kind"], "write"), nil
+	case "rollback_deployment":
+		return onKind("deployments", "write"), nil
+	case "apply_yaml":
+		// An early check on the manifest as written; applyYAML checks again
+		// with the kind's resolved scope through authorizeApply.
+		var obj unstructured.Unstructured
+		decoder := yamlutil.NewYAMLOrJSONDecoder(strings.NewReader(args["yaml"]), 4096)
+		if err := decoder.Decode(&obj); err != nil {
+			return nil, fmt.Errorf("failed to decode YAML: %w", err)
+		}
+		if ns := obj.GetNamespace(); ns != "" {
+			namespace = ns
+		}
+		return onKind(obj.GetKind(), "write"), nil
+	default:
+		return nil, fmt.Errorf("no permission mapping for tool %s", name)
+	}
+}
+
+// authorize checks every RBAC request a tool call implies for the given user.
+// Without an authorizer all cluster tools are denied.
+func (e *Executor) authorize(ctx context.Context, name string, args map[string]string, userID string) error {
+	reqs, err := toolPermissions(name, args)
+	if err != nil {
+		return err
+	}
+	if e.authorizer == nil || userID == "" {
+		return fmt.Errorf("permission denied: no user permissions available for %s", name)
+	}
+
+	for _, req := range reqs {
+		req.UserID = userID
+		allowed, err := e.authorizer.Evaluate(ctx, req)
+		if err != nil {
+			return fmt.Errorf("permission check failed: %w", err)
+		}
+		if !allowed {
+			return &permissionError{tool: name, req: req}
+		}
+	}
+	return nil
+}
+
+// authorizeNamespaceCreate checks that the user may create namespaces on the
+// cluster, which apply_yaml does for a missing target namespace when the
+// apply policy allows it.
+func (e *Executor) authorizeNamespaceCreate(ctx context.Context, userID, clusterID string) error {
+	return e.authorizeApplyRequest(ctx, rbac.Request{
+		UserID:      userID,
+		Resource:    rbac.ResourceCategory("namespaces"),
+		Action:      "write",
+		ClusterID:   clusterID,
+		K8sResource: rbac.QualifiedResource("", "namespaces"),
+	})
+}
+
+// authorizeApply checks that the user may write the object apply_yaml is
+// about to apply, as resolved by mapping. Root-scoped kinds such as
+// ClusterRoleBindings are checked cluster-wide, whatever namespace the
+// manifest names.
+func (e *Executor) authorizeApply(ctx context.Context, userID, clusterID string, mapping *meta.RESTMapping, namespace string) error {
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		namespace = ""
+	}
+	return e.authorizeApplyRequest(ctx, rbac.Request{
+		UserID:      userID,
+		Resource:    rbac.ResourceCategory(mapping.Resource.Resource),
+		Action:      "write",
+		ClusterID:   clusterID,
+		Namespace:   namespace,
+		K8sResource: rbac.QualifiedResource(mapping.Resource.Group, mapping.Resource.Resource),
+	})
+}
+
+func (e *Executor) authorizeApplyRequest(ctx context.Context, req rbac.Request) error {
+	if e.authorizer == nil || req.UserID == "" {
+		return &permissionError{tool: "apply_yaml", req: req}
+	}
+	allowed, err := e.authorizer.Evaluate(ctx, req)
+	if err != nil {
+		return fmt.Errorf("permission check failed: %w", err)
+	}
+	if !allowed {
+		return &permissionError{tool: "apply_yaml", req: req}
+	}
+	return nil
+}
+
+// CanReadCluster reports whether the user may read the cluster, or the given
+// namespace of it. Without an authorizer no cluster is readable.
+func (e *Executor) CanReadCluster(ctx context.Context, userID, clusterID, namespace string) bool {
+	if e.authorizer == nil || userID == "" || clusterID == "" {
+		return false
+	}
+	allowed, err := e.authorizer.Evaluate(ctx, rbac.Request{
+		UserID:    userID,
+		Resource:  "clusters",
+		Action:    "read",
+		ClusterID: clusterID,
+		Namespace: namespace,
+	})
+	return err == nil && allowed
+}
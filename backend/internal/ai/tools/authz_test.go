@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+// fakeAuthorizer grants the listed "resource:action" pairs on any scope.
+type fakeAuthorizer struct {
+	allowed map[string]bool
+	calls   []rbac.Request
+}
+
+func (f *fakeAuthorizer) Evaluate(_ context.Context, req rbac.Request) (bool, error) {
+	f.calls = append(f.calls, req)
+	return f.allowed[req.Resource+":"+req.Action] || f.allowed["*:*"], nil
+}
+
+func readOnlyAuthorizer() *fakeAuthorizer {
+	return &fakeAuthorizer{allowed: map[string]bool{
+		"clusters:read":   true,
+		"apps:read":       true,
+		"jobs:read":       true,
+		"monitoring:read": true,
+	}}
+}
+
+func TestExecute_ReadOnlyUserCannotDeletePod(t *testing.T) {
+	authz := readOnlyAuthorizer()
+	// No cluster manager: reaching the Kubernetes call would panic, so a
+	// clean error result proves the call was rejected before dispatch.
+	e := &Executor{authorizer: authz}
+
+	result := e.Execute(context.Background(), ToolCall{
+		ID:        "call-1",
+		Name:      "delete_resource",
+		Arguments: `{"cluster_id":"cluster-1","kind":"pod","name":"nginx","namespace":"default"}`,
+	}, "viewer-user")
+
+	if !result.IsError {
+		t.Fatalf("expected delete to be denied, got %q", result.Content)
+	}
+	if !strings.Contains(result.Content, "permission denied") {
+		t.Errorf("expected permission denied message, got %q", result.Content)
+	}
+	if result.ToolCallID != "call-1" {
+		t.Errorf("expected tool call ID to be preserved, got %q", result.ToolCallID)
+	}
+
+	if len(authz.calls) != 1 {
+		t.Fatalf("expected one RBAC check, got %d", len(authz.calls))
+	}
+	got := authz.calls[0]
+	want := rbac.Request{UserID: "viewer-user", Action: "delete", Resource: "apps", ClusterID: "cluster-1", Namespace: "default", K8sResource: "pods"}
+	if got != want {
+		t.Errorf("unexpected RBAC request: got %+v, want %+v", got, want)
+	}
+}
+
+func TestAuthorize_ReadOnlyUserCanRead(t *testing.T) {
+	e := &Executor{authorizer: readOnlyAuthorizer()}
+	args := map[string]string{"cluster_id": "cluster-1", "kind": "pods", "namespace": "default"}
+
+	if err := e.authorize(context.Background(), "get_resources", args, "viewer-user"); err != nil {
+		t.Errorf("expected read to be allowed, got %v", err)
+	}
+	if err := e.authorize(context.Background(), "scale_resource", args, "viewer-user"); err == nil {
+		t.Error("expected scale to be denied for read-only user")
+	}
+}
+
+func TestAuthorize_NoAuthorizerDenies(t *testing.T) {
+	e := &Executor{}
+	args := map[string]string{"cluster_id": "cluster-1", "kind": "pods"}
+	if err := e.authorize(context.Background(), "get_resources", args, "user-1"); err == nil {
+		t.Error("expected tool call to be denied without an authorizer")
+	}
+}
+
+func TestAuthorize_AdminAllowed(t *testing.T) {
+	e := &Executor{authorizer: &fakeAuthorizer{allowed: map[string]bool{"*:*": true}}}
+	args := map[string]string{"cluster_id": "cluster-1", "kind": "deployment", "name": "web", "namespace": "prod"}
+	if err := e.authorize(context.Background(), "delete_resource", args, "admin"); err != nil {
+		t.Errorf("expected admin to be allowed, got %v", err)
+	}
+}
+
+func TestToolPermissions(t *testing.T) {
+	tests := []struct {
+		tool     string
+		args     map[string]string
+		resource string
+		action   string
+	}{
+		{"get_resources", map[string]string{"kind": "cronjobs"}, "jobs", "read"},
+		{"get_logs", map[string]string{"pod_name": "web-0"}, "apps", "read"},
+		{"get_metrics", map[string]string{}, "monitoring", "read"},
+		{"cluster_health_check", map[string]string{}, "clusters", "read"},
+		{"get_pod_exec", map[string]string{}, "terminal", "write"},
+		{"restart_resource", map[string]string{"kind": "statefulset"}, "apps", "write"},
+		{"rollback_deployment", map[string]string{}, "apps", "write"},
+		{"apply_yaml", map[string]string{"yaml": "apiVersion: batch/v1\nkind: Job\nmetadata:\n  name: migrate\n  namespace: jobs-ns\n"}, "jobs", "write"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			reqs, err := toolPermissions(tt.tool, tt.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(reqs) != 1 || reqs[0].Resource != tt.resource || reqs[0].Action != tt.action {
+				t.Errorf("toolPermissions(%q) = %+v, want %s/%s", tt.tool, reqs, tt.resource, tt.action)
+			}
+		})
+	}
+}
+
+func TestToolPermissions_ApplyYAMLUsesManifestNamespace(t *testing.T) {
+	reqs, err := toolPermissions("apply_yaml", map[string]string{
+		"namespace": "default",
+		"yaml":      "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cfg\n  namespace: kube-system\n",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reqs[0].Namespace != "kube-system" {
+		t.Errorf("expected manifest namespace to be checked, got %q", reqs[0].Namespace)
+	}
+	if reqs[0].K8sResource != "configmaps" {
+		t.Errorf("expected the manifest's resource to be named for cluster checks, got %q", reqs[0].K8sResource)
+	}
+}
+
+// namespaceWriter grants every action in one namespace and nothing
+// cluster-wide.
+type namespaceWriter string
+
+func (n namespaceWriter) Evaluate(_ context.Context, req rbac.Request) (bool, error) {
+	return req.Namespace == string(n), nil
+}
+
+func TestAuthorizeApply_RootScopedKindsNeedClusterWideWrite(t *testing.T) {
+	e := &Executor{authorizer: namespaceWriter("team-a")}
+	manifest := "apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRoleBinding\nmetadata:\n  name: escalate\n  namespace: team-a\n"
+
+	// The early check only sees the namespace the manifest names.
+	if err := e.authorize(context.Background(), "apply_yaml", map[string]string{"cluster_id": "c1", "yaml": manifest}, "u1"); err != nil {
+		t.Fatalf("expected the early check to pass, got %v", err)
+	}
+
+	crb := &meta.RESTMapping{
+		Resource: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"},
+		Scope:    meta.RESTScopeRoot,
+	}
+	err := e.authorizeApply(context.Background(), "u1", "c1", crb, "team-a")
+	if err == nil || !strings.Contains(err.Error(), "permission denied") || strings.Contains(err.Error(), "namespace") {
+		t.Errorf("expected a cluster-wide denial for a ClusterRoleBinding, got %v", err)
+	}
+
+	rb := &meta.RESTMapping{
+		Resource: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"},
+		Scope:    meta.RESTScopeNamespace,
+	}
+	if err := e.authorizeApply(context.Background(), "u1", "c1", rb, "team-a"); err != nil {
+		t.Errorf("expected a RoleBinding in team-a to be allowed, got %v", err)
+	}
+}
+
+func TestToolPermissions_CompareClustersChecksBoth(t *testing.T) {
+	reqs, err := toolPermissions("compare_clusters", map[string]string{"cluster_id_1": "a", "cluster_id_2": "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(reqs) != 2 || reqs[0].ClusterID != "a" || reqs[1].ClusterID != "b" {
+		t.Errorf("expected checks on both clusters, got %+v", reqs)
+	}
+}
+
+func TestToolPermissions_UnknownToolDenied(t *testing.T) {
+	if _, err := toolPermissions("format_disk", map[string]string{}); err == nil {
+		t.Error("expected unmapped tool to be rejected")
+	}
+}
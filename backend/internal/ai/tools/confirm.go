@@ -12,6 +12,9 @@ import (
 
 const confirmationTimeout = 60 * time.Second
 
+// approvalTTL bounds how long an approved confirmation stays executable.
+const approvalTTL = 5 * time.Minute
+
 // ConfirmationStatus represents the state of a confirmation request.
 type ConfirmationStatus string
 
@@ -23,20 +26,30 @@ const (
 )
 
 // ConfirmationRequest represents a pending request for user approval of a
-// destructive tool call.
+// write or destructive tool call.
 type ConfirmationRequest struct {
-	ID        string             `json:"id"`
-	ToolCall  ToolCall            `json:"tool_call"`
-	Status    ConfirmationStatus `json:"status"`
-	UserID    string             `json:"user_id"`
-	CreatedAt time.Time          `json:"created_at"`
+	ID             string             `json:"id"`
+	ToolCall       ToolCall           `json:"tool_call"`
+	Classification ToolClass          `json:"classification"`
+	Status         ConfirmationStatus `json:"status"`
+	UserID         string             `json:"user_id"`
+	ConfirmedBy    string             `json:"confirmed_by,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	ResolvedAt     *time.Time         `json:"resolved_at,omitempty"`
 }
 
 // ConfirmationManager tracks pending confirmation requests and coordinates
 // approval between the AI service and the WebSocket frontend.
+//
+// The contract is: the service proposes a tool call (CreateRequest), the
+// owning user approves or rejects that specific confirmation ID (Resolve),
+// and the executor consumes the approval exactly once (Consume) before running
+// the call. Approvals are bound to the exact tool call they were issued for.
 type ConfirmationManager struct {
-	pending map[string]*pendingConfirmation
-	mu      sync.Mutex
+	pending  map[string]*pendingConfirmation
+	approved map[string]ConfirmationRequest
+	store    *ConfirmationStore
+	mu       sync.Mutex
 }
 
 type pendingConfirmation struct {
@@ -47,35 +60,43 @@ type pendingConfirmation struct {
 // NewConfirmationManager creates a new confirmation manager.
 func NewConfirmationManager() *ConfirmationManager {
 	return &ConfirmationManager{
-		pending: make(map[string]*pendingConfirmation),
+		pending:  make(map[string]*pendingConfirmation),
+		approved: make(map[string]ConfirmationRequest),
+	}
+}
+
+// SetStore sets the persistent store used to record confirmations and guard
+// against replay across restarts. Without a store confirmations are in-memory only.
+func (m *ConfirmationManager) SetStore(store *ConfirmationStore) {
+	m.store = store
+}
+
+func newConfirmationRequest(userID string, call ToolCall) ConfirmationRequest {
+	return ConfirmationRequest{
+		ID:             uuid.New().String(),
+		ToolCall:       call,
+		Classification: ClassifyTool(call.Name),
+		Status:         ConfirmationPending,
+		UserID:         userID,
+		CreatedAt:      time.Now(),
 	}
 }
 
 // RequestConfirmation creates a new confirmation request and blocks until
 // the user responds or the timeout expires. Returns the confirmation status.
 func (m *ConfirmationManager) RequestConfirmation(ctx context.Context, userID string, call ToolCall) (ConfirmationStatus, error) {
-	reqID := uuid.New().String()
-
 	pc := &pendingConfirmation{
-		request: ConfirmationRequest{
-			ID:        reqID,
-			ToolCall:  call,
-			Status:    ConfirmationPending,
-			UserID:    userID,
-			CreatedAt: time.Now(),
-		},
+		request:  newConfirmationRequest(userID, call),
 		resultCh: make(chan ConfirmationStatus, 1),
 	}
+	reqID := pc.request.ID
 
 	m.mu.Lock()
 	m.pending[reqID] = pc
 	m.mu.Unlock()
+	m.store.create(pc.request)
 
-	defer func() {
-		m.mu.Lock()
-		delete(m.pending, reqID)
-		m.mu.Unlock()
-	}()
+	defer m.expire(reqID)
 
 	log.Printf("ai: confirmation requested for tool %s (id=%s, user=%s)", call.Name, reqID, userID)
 
@@ -90,24 +111,45 @@ func (m *ConfirmationManager) RequestConfirmation(ctx context.Context, userID st
 }
 
 // Approve marks a pending confirmation as approved and unblocks the waiting
-// goroutine.
+// goroutine. The confirmation is attributed to its owner.
 func (m *ConfirmationManager) Approve(requestID string) error {
-	return m.resolve(requestID, ConfirmationApproved)
+	return m.resolve(requestID, "", ConfirmationApproved)
 }
 
 // Reject marks a pending confirmation as rejected.
 func (m *ConfirmationManager) Reject(requestID string) error {
-	return m.resolve(requestID, ConfirmationRejected)
+	return m.resolve(requestID, "", ConfirmationRejected)
 }
 
-func (m *ConfirmationManager) resolve(requestID string, status ConfirmationStatus) error {
+// Resolve approves or rejects a pending confirmation on behalf of userID.
+// Only the user the confirmation was issued to may resolve it.
+func (m *ConfirmationManager) Resolve(requestID, userID string, approved bool) error {
+	if userID == "" {
+		return fmt.Errorf("confirmation %s not found or already resolved", requestID)
+	}
+	status := ConfirmationRejected
+	if approved {
+		status = ConfirmationApproved
+	}
+	return m.resolve(requestID, userID, status)
+}
+
+// resolve settles a pending confirmation. A non-empty userID must match the
+// confirmation's owner; an empty userID is used by trusted internal callers.
+func (m *ConfirmationManager) resolve(requestID, userID string, status ConfirmationStatus) error {
 	m.mu.Lock()
 	pc, ok := m.pending[requestID]
-	if !ok {
+	if !ok || (userID != "" && pc.request.UserID != userID) {
 		m.mu.Unlock()
 		return fmt.Errorf("confirmation %s not found or already resolved", requestID)
 	}
+	now := time.Now()
 	pc.request.Status = status
+	pc.request.ResolvedAt = &now
+	if status == ConfirmationApproved {
+		pc.request.ConfirmedBy = pc.request.UserID
+		m.approved[requestID] = pc.request
+	}
 	// Send on channel while still under the lock to prevent races.
 	// Use non-blocking send to handle the edge case where the channel is already full.
 	select {
@@ -115,12 +157,52 @@ func (m *ConfirmationManager) resolve(requestID string, status ConfirmationStatu
 	default:
 	}
 	delete(m.pending, requestID)
+	resolved := pc.request
 	m.mu.Unlock()
 
+	m.store.resolve(resolved)
 	log.Printf("ai: confirmation %s resolved as %s", requestID, status)
 	return nil
 }
 
+// Consume claims an approved confirmation for execution. It succeeds at most
+// once per confirmation, only for the user it was issued to, and only for the
+// exact tool call that was approved, so an approval cannot be replayed or
+// reused for different arguments.
+func (m *ConfirmationManager) Consume(ctx context.Context, requestID, userID string, call ToolCall) (*ConfirmationRequest, error) {
+	m.mu.Lock()
+	req, ok := m.approved[requestID]
+	if ok && time.Since(*req.ResolvedAt) > approvalTTL {
+		delete(m.approved, requestID)
+		ok = false
+	}
+	if !ok || req.UserID != userID || req.ToolCall != call {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("no approved confirmation %s for tool %s", requestID, call.Name)
+	}
+	delete(m.approved, requestID)
+	m.mu.Unlock()
+
+	if err := m.store.markExecuted(ctx, requestID, userID); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// expire drops a confirmation that was never resolved and records it as timed out.
+func (m *ConfirmationManager) expire(requestID string) {
+	m.mu.Lock()
+	pc, stillPending := m.pending[requestID]
+	delete(m.pending, requestID)
+	m.mu.Unlock()
+
+	if stillPending {
+		expired := pc.request
+		expired.Status = ConfirmationTimedOut
+		m.store.resolve(expired)
+	}
+}
+
 // GetPending returns the pending confirmation request for a given ID, if any.
 func (m *ConfirmationManager) GetPending(requestID string) (*ConfirmationRequest, bool) {
 	m.mu.Lock()
@@ -136,22 +218,16 @@ func (m *ConfirmationManager) GetPending(requestID string) (*ConfirmationRequest
 // CreateRequest creates a confirmation request and registers it, without blocking.
 // Call WaitForRequest to block until the user responds.
 func (m *ConfirmationManager) CreateRequest(userID string, call ToolCall) *ConfirmationRequest {
-	reqID := uuid.New().String()
-
 	pc := &pendingConfirmation{
-		request: ConfirmationRequest{
-			ID:        reqID,
-			ToolCall:  call,
-			Status:    ConfirmationPending,
-			UserID:    userID,
-			CreatedAt: time.Now(),
-		},
+		request:  newConfirmationRequest(userID, call),
 		resultCh: make(chan ConfirmationStatus, 1),
 	}
+	reqID := pc.request.ID
 
 	m.mu.Lock()
 	m.pending[reqID] = pc
 	m.mu.Unlock()
+	m.store.create(pc.request)
 
 	log.Printf("ai: confirmation created for tool %s (id=%s, user=%s)", call.Name, reqID, userID)
 	return &pc.request
@@ -167,11 +243,7 @@ func (m *ConfirmationManager) WaitForRequest(ctx context.Context, reqID string)
 		return ConfirmationTimedOut, fmt.Errorf("confirmation %s not found", reqID)
 	}
 
-	defer func() {
-		m.mu.Lock()
-		delete(m.pending, reqID)
-		m.mu.Unlock()
-	}()
+	defer m.expire(reqID)
 
 	select {
 	case status := <-pc.resultCh:
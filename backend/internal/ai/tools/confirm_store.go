@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ConfirmationStore persists confirmation requests in ai_tool_confirmations so
+// every approval has a durable record and can be consumed only once.
+type ConfirmationStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewConfirmationStore creates a new ConfirmationStore.
+func NewConfirmationStore(pool *pgxpool.Pool) *ConfirmationStore {
+	if pool == nil {
+		return nil
+	}
+	return &ConfirmationStore{pool: pool}
+}
+
+// create records a new pending confirmation. Failures are logged: the
+// in-memory manager remains the source of truth for pending requests.
+func (s *ConfirmationStore) create(req ConfirmationRequest) {
+	if s == nil || s.pool == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO ai_tool_confirmations (id, user_id, tool_call_id, tool_name, arguments, classification, status, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		req.ID, req.UserID, req.ToolCall.ID, req.ToolCall.Name, truncateForAudit(req.ToolCall.Arguments, 4096),
+		string(req.Classification), string(req.Status), req.CreatedAt,
+	)
+	if err != nil {
+		log.Printf("ai confirmations: failed to record confirmation %s: %v", req.ID, err)
+	}
+}
+
+// resolve records the outcome of a confirmation.
+func (s *ConfirmationStore) resolve(req ConfirmationRequest) {
+	if s == nil || s.pool == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := s.pool.Exec(ctx,
+		`UPDATE ai_tool_confirmations
+		 SET status = $2, confirmed_by = NULLIF($3, '')::uuid, resolved_at = NOW()
+		 WHERE id = $1 AND status = 'pending'`,
+		req.ID, string(req.Status), req.ConfirmedBy,
+	)
+	if err != nil {
+		log.Printf("ai confirmations: failed to resolve confirmation %s: %v", req.ID, err)
+	}
+}
+
+// markExecuted atomically claims an approved confirmation. It fails if the
+// confirmation was already executed, so a replayed approval is rejected even
+// after a restart or on another replica.
+func (s *ConfirmationStore) markExecuted(ctx context.Context, id, userID string) error {
+	if s == nil || s.pool == nil {
+		return nil
+	}
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE ai_tool_confirmations SET executed_at = NOW()
+		 WHERE id = $1 AND user_id = $2 AND status = 'approved' AND executed_at IS NULL`,
+		id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to claim confirmation %s: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("confirmation %s is not approved or was already executed", id)
+	}
+	return nil
+}
@@ -323,3 +323,68 @@ func TestConcurrentApprovals(t *testing.T) {
 		t.Errorf("expected %d approvals, got %d", N, count)
 	}
 }
+
+func TestResolve_OnlyOwnerCanResolve(t *testing.T) {
+	mgr := NewConfirmationManager()
+	req := mgr.CreateRequest("user-a", ToolCall{ID: "tc-1", Name: "delete_resource", Arguments: "{}"})
+
+	if err := mgr.Resolve(req.ID, "user-b", true); err == nil {
+		t.Fatal("expected another user's approval to be rejected")
+	}
+	if _, ok := mgr.GetPending(req.ID); !ok {
+		t.Fatal("expected confirmation to remain pending")
+	}
+	if err := mgr.Resolve(req.ID, "user-a", true); err != nil {
+		t.Fatalf("expected owner approval to succeed, got %v", err)
+	}
+}
+
+func TestConsume_OnlyOnce(t *testing.T) {
+	mgr := NewConfirmationManager()
+	call := ToolCall{ID: "tc-1", Name: "scale_resource", Arguments: `{"replicas":"3"}`}
+	req := mgr.CreateRequest("user-a", call)
+	if req.Classification != ToolClassWrite {
+		t.Errorf("expected write classification, got %q", req.Classification)
+	}
+	if err := mgr.Resolve(req.ID, "user-a", true); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	got, err := mgr.Consume(context.Background(), req.ID, "user-a", call)
+	if err != nil {
+		t.Fatalf("expected first consume to succeed, got %v", err)
+	}
+	if got.ConfirmedBy != "user-a" || got.ResolvedAt == nil {
+		t.Errorf("expected confirmation to record approver and time, got %+v", got)
+	}
+	if _, err := mgr.Consume(context.Background(), req.ID, "user-a", call); err == nil {
+		t.Error("expected replayed confirmation to be rejected")
+	}
+}
+
+func TestConsume_RejectsMismatchedCall(t *testing.T) {
+	mgr := NewConfirmationManager()
+	call := ToolCall{ID: "tc-1", Name: "scale_resource", Arguments: `{"replicas":"3"}`}
+	req := mgr.CreateRequest("user-a", call)
+	_ = mgr.Resolve(req.ID, "user-a", true)
+
+	tampered := call
+	tampered.Arguments = `{"replicas":"0"}`
+	if _, err := mgr.Consume(context.Background(), req.ID, "user-a", tampered); err == nil {
+		t.Error("expected approval to be rejected for different arguments")
+	}
+	if _, err := mgr.Consume(context.Background(), req.ID, "user-b", call); err == nil {
+		t.Error("expected approval to be rejected for a different user")
+	}
+}
+
+func TestConsume_RejectedConfirmation(t *testing.T) {
+	mgr := NewConfirmationManager()
+	call := ToolCall{ID: "tc-1", Name: "delete_resource", Arguments: "{}"}
+	req := mgr.CreateRequest("user-a", call)
+	_ = mgr.Resolve(req.ID, "user-a", false)
+
+	if _, err := mgr.Consume(context.Background(), req.ID, "user-a", call); err == nil {
+		t.Error("expected rejected confirmation not to be consumable")
+	}
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ApplyContextDefaults fills the cluster and namespace the user has selected
+// into a tool call that leaves them out, so the model need not restate them.
+// Arguments the model set are kept, including an explicitly empty namespace
+// meaning all namespaces. The namespace is only filled in on the selected
+// cluster, and not when listing cluster-scoped kinds.
+func ApplyContextDefaults(call ToolCall, clusterID, namespace string) ToolCall {
+	toolNameSets()
+	if clusterID == "" || !clusterToolNames[call.Name] {
+		return call
+	}
+
+	args := map[string]interface{}{}
+	if strings.TrimSpace(call.Arguments) != "" {
+		if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil || args == nil {
+			return call
+		}
+	}
+
+	if v, ok := args["cluster_id"]; !ok || v == "" {
+		args["cluster_id"] = clusterID
+	} else if v != clusterID {
+		// The selected namespace may not exist on another cluster.
+		return call
+	}
+
+	if _, ok := args["namespace"]; !ok && namespace != "" && namespacedToolNames[call.Name] {
+		kind, _ := args["kind"].(string)
+		if kind == "" || !clusterScopedKinds[kindToGVR(kind).Resource] {
+			args["namespace"] = namespace
+		}
+	}
+
+	data, err := json.Marshal(args)
+	if err != nil {
+		return call
+	}
+	call.Arguments = string(data)
+	return call
+}
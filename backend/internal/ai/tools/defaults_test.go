@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestApplyContextDefaults(t *testing.T) {
+	tests := []struct {
+		name string
+		call ToolCall
+		want map[string]string
+	}{
+		{
+			name: "fills cluster and namespace",
+			call: ToolCall{Name: "get_resources", Arguments: `{"kind":"pods"}`},
+			want: map[string]string{"cluster_id": "c1", "namespace": "team-a", "kind": "pods"},
+		},
+		{
+			name: "empty arguments",
+			call: ToolCall{Name: "cluster_health_check"},
+			want: map[string]string{"cluster_id": "c1"},
+		},
+		{
+			name: "explicit namespace wins",
+			call: ToolCall{Name: "get_resources", Arguments: `{"kind":"pods","namespace":"team-b"}`},
+			want: map[string]string{"cluster_id": "c1", "namespace": "team-b", "kind": "pods"},
+		},
+		{
+			name: "explicitly empty namespace means all namespaces",
+			call: ToolCall{Name: "get_resources", Arguments: `{"kind":"pods","namespace":""}`},
+			want: map[string]string{"cluster_id": "c1", "namespace": "", "kind": "pods"},
+		},
+		{
+			name: "other cluster gets no namespace",
+			call: ToolCall{Name: "get_resources", Arguments: `{"cluster_id":"c2","kind":"pods"}`},
+			want: map[string]string{"cluster_id": "c2", "kind": "pods"},
+		},
+		{
+			name: "cluster-scoped kind gets no namespace",
+			call: ToolCall{Name: "get_resources", Arguments: `{"kind":"nodes"}`},
+			want: map[string]string{"cluster_id": "c1", "kind": "nodes"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ApplyContextDefaults(tt.call, "c1", "team-a")
+			var args map[string]string
+			if err := json.Unmarshal([]byte(got.Arguments), &args); err != nil {
+				t.Fatalf("invalid arguments %q: %v", got.Arguments, err)
+			}
+			if len(args) != len(tt.want) {
+				t.Fatalf("got %v, want %v", args, tt.want)
+			}
+			for k, v := range tt.want {
+				if args[k] != v {
+					t.Errorf("%s = %q, want %q (args %v)", k, args[k], v, args)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyContextDefaults_LeavesOtherCallsAlone(t *testing.T) {
+	calls := []ToolCall{
+		{Name: "recall_memory", Arguments: `{"query":"db"}`},
+		{Name: "compare_clusters", Arguments: `{"cluster_id_1":"a","cluster_id_2":"b"}`},
+		{Name: "get_resources", Arguments: `not json`},
+	}
+	for _, call := range calls {
+		if got := ApplyContextDefaults(call, "c1", "team-a"); got != call {
+			t.Errorf("expected %s to be unchanged, got %q", call.Name, got.Arguments)
+		}
+	}
+	call := ToolCall{Name: "get_resources", Arguments: `{"kind":"pods"}`}
+	if got := ApplyContextDefaults(call, "", "team-a"); got != call {
+		t.Errorf("expected no change without a cluster, got %q", got.Arguments)
+	}
+}
+
+func TestCanReadCluster(t *testing.T) {
+	authz := &fakeAuthorizer{allowed: map[string]bool{"clusters:read": true}}
+	e := &Executor{authorizer: authz}
+	if !e.CanReadCluster(context.Background(), "u1", "c1", "team-a") {
+		t.Fatal("expected clusters:read to allow the cluster")
+	}
+	if got := authz.calls[0]; got.ClusterID != "c1" || got.Namespace != "team-a" || got.UserID != "u1" {
+		t.Errorf("unexpected RBAC request %+v", got)
+	}
+	if (&Executor{authorizer: &fakeAuthorizer{}}).CanReadCluster(context.Background(), "u1", "c1", "") {
+		t.Error("expected a user without clusters:read to be refused")
+	}
+	if (&Executor{}).CanReadCluster(context.Background(), "u1", "c1", "") {
+		t.Error("expected no cluster to be readable without an authorizer")
+	}
+}
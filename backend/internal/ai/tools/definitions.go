@@ -29,18 +29,36 @@ type ToolParam struct {
 	Enum        []string `json:"enum,omitempty"`
 }
 
-// RequiresConfirm returns true if the named tool is a write operation that
-// must be confirmed by the user before execution.
-func RequiresConfirm(toolName string) bool {
+// ToolClass classifies a tool by its effect, so clients can present proposed
+// calls with the appropriate level of warning.
+type ToolClass string
+
+const (
+	ToolClassRead        ToolClass = "read"
+	ToolClassWrite       ToolClass = "write"
+	ToolClassDestructive ToolClass = "destructive"
+)
+
+// ClassifyTool returns the effect class of the named tool. Destructive tools
+// remove data or run arbitrary commands; write tools change state in a way
+// that can be reverted.
+func ClassifyTool(toolName string) ToolClass {
 	switch toolName {
-	case "apply_yaml", "delete_resource", "scale_resource", "restart_resource",
-		"rollback_deployment", "get_pod_exec", "delete_memory":
-		return true
+	case "delete_resource", "delete_memory", "get_pod_exec":
+		return ToolClassDestructive
+	case "apply_yaml", "scale_resource", "restart_resource", "rollback_deployment":
+		return ToolClassWrite
 	default:
-		return false
+		return ToolClassRead
 	}
 }
 
+// RequiresConfirm returns true if the named tool is a write or destructive
+// operation that must be confirmed by the user before execution.
+func RequiresConfirm(toolName string) bool {
+	return ClassifyTool(toolName) != ToolClassRead
+}
+
 // ToolsForLevel returns the tools available for the given permission level.
 // Uses deny-by-default: unrecognized levels get no tools.
 func ToolsForLevel(level string) []Tool {
@@ -108,16 +126,17 @@ func ReadOnlyTools() []Tool {
 		},
 		{
 			Name:        "get_logs",
-			Description: "Get container logs from a pod.",
+			Description: "Get container logs from a pod. Output over the configured size is cut, with a note saying how many bytes were left out.",
 			Parameters: ToolParams{
 				Type: "object",
 				Properties: map[string]ToolParam{
-					"cluster_id": {Type: "string", Description: "The cluster ID"},
-					"namespace":  {Type: "string", Description: "Pod namespace"},
-					"pod_name":   {Type: "string", Description: "Name of the pod"},
-					"container":  {Type: "string", Description: "Container name (optional, uses first container if empty)"},
-					"tail_lines": {Type: "string", Description: "Number of lines from the end to return (default: 100)"},
-					"previous":   {Type: "string", Description: "If 'true', return previous terminated container logs"},
+					"cluster_id":     {Type: "string", Description: "The cluster ID"},
+					"namespace":      {Type: "string", Description: "Pod namespace"},
+					"pod_name":       {Type: "string", Description: "Name of the pod"},
+					"container":      {Type: "string", Description: "Container name (optional, uses first container if empty)"},
+					"all_containers": {Type: "string", Description: "If 'true', return the logs of every container, init containers included, each line prefixed with [container]. Ignores container"},
+					"tail_lines":     {Type: "string", Description: "Number of lines from the end to return, per container (default: 100)"},
+					"previous":       {Type: "string", Description: "If 'true', return previous terminated container logs"},
 				},
 				Required: []string{"cluster_id", "namespace", "pod_name"},
 			},
@@ -324,6 +343,7 @@ func WriteTools() []Tool {
 					"cluster_id": {Type: "string", Description: "The cluster ID"},
 					"namespace":  {Type: "string", Description: "Target namespace"},
 					"yaml":       {Type: "string", Description: "The YAML manifest to apply"},
+					"force":      {Type: "string", Description: "If 'true', take ownership of fields other field managers own. Only set after a conflict and with the user's agreement"},
 				},
 				Required: []string{"cluster_id", "namespace", "yaml"},
 			},
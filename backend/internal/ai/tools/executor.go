@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"strconv"
 	"strings"
@@ -13,8 +12,11 @@ import (
 
 	"github.com/darkden-lab/argus/backend/internal/cluster"
 	"github.com/darkden-lab/argus/backend/internal/plugin"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/redact"
+	"github.com/darkden-lab/argus/backend/internal/validate"
 	"github.com/jackc/pgx/v5/pgxpool"
-	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -56,6 +58,23 @@ func validateRequiredArgs(toolName string, args map[string]string) error {
 	return nil
 }
 
+// identifierArgs are the tool arguments that end up in Kubernetes API paths.
+var identifierArgs = []string{"namespace", "name", "pod", "pod_name", "container", "deployment", "involved_name", "resource_name"}
+
+// validateIdentifierArgs rejects malformed object names, namespaces and
+// selectors before a tool call reaches a cluster.
+func validateIdentifierArgs(args map[string]string) error {
+	for _, arg := range identifierArgs {
+		if !validate.Segment(args[arg]) {
+			return fmt.Errorf("invalid argument %s: %q", arg, args[arg])
+		}
+	}
+	if err := validate.LabelSelector(args["label_selector"]); err != nil {
+		return err
+	}
+	return validate.FieldSelector(args["field_selector"])
+}
+
 // ToolResult is the outcome of executing a tool call.
 type ToolResult struct {
 	ToolCallID string `json:"tool_call_id"`
@@ -73,22 +92,34 @@ type MemoryOps interface {
 
 // Executor runs AI tool calls against Kubernetes clusters.
 type Executor struct {
-	clusterMgr   *cluster.Manager
-	pluginEngine *plugin.Engine
-	pool         *pgxpool.Pool
-	memoryOps    MemoryOps
-	auditLogger  *AuditLogger
+	clusterMgr     *cluster.Manager
+	pluginEngine   *plugin.Engine
+	pool           *pgxpool.Pool
+	memoryOps      MemoryOps
+	auditLogger    *AuditLogger
+	authorizer     rbac.Evaluator
+	confirmMgr     *ConfirmationManager
+	redactor       *redact.Redactor
+	applyPolicy    cluster.ApplyPolicy
+	logMaxBytes    int
+	resultMaxBytes int
+
+	policyMu sync.RWMutex
+	policy   ToolPolicy
 }
 
 // NewExecutor creates a tool executor.
 func NewExecutor(clusterMgr *cluster.Manager, pluginEngine *plugin.Engine, pool *pgxpool.Pool) *Executor {
-	return &Executor{clusterMgr: clusterMgr, pluginEngine: pluginEngine, pool: pool}
+	return &Executor{clusterMgr: clusterMgr, pluginEngine: pluginEngine, pool: pool, redactor: redact.NewRedactor(nil)}
 }
 
-// Execute runs a single tool call and returns the result. Write operations
-// should only be executed after user confirmation (checked by the caller).
-func (e *Executor) Execute(ctx context.Context, call ToolCall) ToolResult {
-	result, err := e.dispatch(ctx, call)
+// Execute runs a single tool call on behalf of userID and returns the result.
+// The call is checked against the tool policy's namespace lists and the
+// user's RBAC scope first, and writes against its kind lists; a refused call
+// returns an error result without touching the cluster. It does not enforce
+// confirmation: use ExecuteForUser or ExecuteConfirmed for LLM-proposed calls.
+func (e *Executor) Execute(ctx context.Context, call ToolCall, userID string) ToolResult {
+	result, err := e.dispatch(ctx, call, userID)
 	if err != nil {
 		log.Printf("ai tools: tool %s failed: %v", call.Name, err)
 		return ToolResult{
@@ -99,7 +130,7 @@ func (e *Executor) Execute(ctx context.Context, call ToolCall) ToolResult {
 	}
 	return ToolResult{
 		ToolCallID: call.ID,
-		Content:    result,
+		Content:    e.limitResult(call.Name, result),
 	}
 }
 
@@ -113,9 +144,117 @@ func (e *Executor) SetAuditLogger(logger *AuditLogger) {
 	e.auditLogger = logger
 }
 
+// SetAuthorizer sets the RBAC authorizer used to scope tool calls to the
+// requesting user's permissions.
+func (e *Executor) SetAuthorizer(a rbac.Evaluator) {
+	e.authorizer = a
+}
+
+// SetConfirmationManager sets the manager whose approvals gate write and
+// destructive tools.
+func (e *Executor) SetConfirmationManager(mgr *ConfirmationManager) {
+	e.confirmMgr = mgr
+}
+
+// SetRedactor sets the redactor applied to objects returned by tools. A nil
+// redactor disables redaction; executors redact redact.DefaultSensitiveKeys
+// by default.
+func (e *Executor) SetRedactor(r *redact.Redactor) {
+	e.redactor = r
+}
+
+// SetApplyPolicy sets the labels, annotations and namespace creation
+// applied by apply_yaml.
+func (e *Executor) SetApplyPolicy(p cluster.ApplyPolicy) {
+	e.applyPolicy = p
+}
+
+// SetLogMaxBytes sets how many bytes of logs get_logs returns per call.
+// Non-positive values use DefaultLogMaxBytes.
+func (e *Executor) SetLogMaxBytes(n int) {
+	e.logMaxBytes = n
+}
+
+// SetResultMaxBytes sets how large a tool result may be before it is
+// trimmed. Non-positive values use DefaultResultMaxBytes.
+func (e *Executor) SetResultMaxBytes(n int) {
+	e.resultMaxBytes = n
+}
+
+// SetToolPolicy sets which tools may run. It is safe to call while tools are
+// executing, so configuration changes apply without a restart. Until a
+// policy is set no tool runs through ExecuteForUser or ExecuteConfirmed.
+func (e *Executor) SetToolPolicy(p ToolPolicy) {
+	e.policyMu.Lock()
+	defer e.policyMu.Unlock()
+	e.policy = p
+}
+
+// Allows reports whether the current tool policy lets the named tool run.
+func (e *Executor) Allows(toolName string) bool {
+	return e.toolPolicy().Allows(toolName)
+}
+
+func (e *Executor) toolPolicy() ToolPolicy {
+	e.policyMu.RLock()
+	defer e.policyMu.RUnlock()
+	return e.policy
+}
+
+// DisabledResult is the result reported for a call to a tool the policy
+// does not allow.
+func DisabledResult(call ToolCall) ToolResult {
+	return ToolResult{
+		ToolCallID: call.ID,
+		Content:    fmt.Sprintf("Error: %s is disabled and was not executed", call.Name),
+		IsError:    true,
+	}
+}
+
 // ExecuteForUser runs a tool call with a user ID context, enabling memory tools.
-// Falls back to Execute for non-memory tools. Logs execution to audit trail.
+// Falls back to Execute for non-memory tools, which enforces the user's RBAC
+// scope. Tools disabled by the tool policy are refused, as are write and
+// destructive tools: they only run through ExecuteConfirmed. Logs execution
+// to audit trail.
 func (e *Executor) ExecuteForUser(ctx context.Context, call ToolCall, userID string) ToolResult {
+	if !e.Allows(call.Name) {
+		return DisabledResult(call)
+	}
+	if RequiresConfirm(call.Name) {
+		return ToolResult{
+			ToolCallID: call.ID,
+			Content:    fmt.Sprintf("Error: %s requires user confirmation and was not executed", call.Name),
+			IsError:    true,
+		}
+	}
+	return e.run(ctx, call, userID, nil)
+}
+
+// ExecuteConfirmed runs a write or destructive tool call that the user approved
+// via confirmationID. The approval is consumed before execution so it cannot be
+// replayed, and the audit record notes who confirmed the call. A tool the
+// policy has disabled since the call was proposed is refused.
+func (e *Executor) ExecuteConfirmed(ctx context.Context, call ToolCall, userID, confirmationID string) ToolResult {
+	if !e.Allows(call.Name) {
+		return DisabledResult(call)
+	}
+	if e.confirmMgr == nil {
+		return ToolResult{ToolCallID: call.ID, Content: "Error: confirmations are not available", IsError: true}
+	}
+	confirmation, err := e.confirmMgr.Consume(ctx, confirmationID, userID, call)
+	if err != nil {
+		log.Printf("ai tools: refusing %s for user %s: %v", call.Name, userID, err)
+		return ToolResult{
+			ToolCallID: call.ID,
+			Content:    fmt.Sprintf("Error: %s was not confirmed and was not executed", call.Name),
+			IsError:    true,
+		}
+	}
+	return e.run(ctx, call, userID, confirmation)
+}
+
+// run dispatches a tool call and records it in the audit trail.
+func (e *Executor) run(ctx context.Context, call ToolCall, userID string, confirmation *ConfirmationRequest) ToolResult {
 	start := time.Now()
 
 	var result ToolResult
@@ -134,12 +273,12 @@ func (e *Executor) ExecuteForUser(ctx context.Context, call ToolCall, userID str
 			}
 		}
 	} else {
-		result = e.Execute(ctx, call)
+		result = e.Execute(ctx, call, userID)
 	}
 
 	durationMs := time.Since(start).Milliseconds()
 	if e.auditLogger != nil {
-		e.auditLogger.Log(ctx, userID, call.Name, call.Arguments, result.Content, result.IsError, durationMs)
+		e.auditLogger.Log(ctx, userID, call.Name, call.Arguments, result.Content, result.IsError, durationMs, confirmation)
 	}
 
 	return result
@@ -205,7 +344,7 @@ func IsMemoryTool(name string) bool {
 	return isMemoryTool(name)
 }
 
-func (e *Executor) dispatch(ctx context.Context, call ToolCall) (string, error) {
+func (e *Executor) dispatch(ctx context.Context, call ToolCall, userID string) (string, error) {
 	var args map[string]string
 	if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
 		return "", fmt.Errorf("invalid tool arguments: %w", err)
@@ -215,6 +354,25 @@ func (e *Executor) dispatch(ctx context.Context, call ToolCall) (string, error)
 	if err := validateRequiredArgs(call.Name, args); err != nil {
 		return "", err
 	}
+	if err := validateIdentifierArgs(args); err != nil {
+		return "", err
+	}
+
+	// Namespace guardrails apply on top of, and before, the user's RBAC.
+	if err := e.toolPolicy().checkNamespaceScope(call.Name, args); err != nil {
+		return "", err
+	}
+
+	if err := e.authorize(ctx, call.Name, args, userID); err != nil {
+		return "", err
+	}
+
+	// Clusters in maintenance mode refuse every write, whoever asks.
+	if ClassifyTool(call.Name) != ToolClassRead && e.clusterMgr != nil {
+		if err := e.clusterMgr.CheckWritable(ctx, args["cluster_id"]); err != nil {
+			return "", err
+		}
+	}
 
 	switch call.Name {
 	case "get_resources":
@@ -230,7 +388,7 @@ func (e *Executor) dispatch(ctx context.Context, call ToolCall) (string, error)
 	case "search_resources":
 		return e.searchResources(ctx, args)
 	case "apply_yaml":
-		return e.applyYAML(ctx, args)
+		return e.applyYAML(ctx, args, userID)
 	case "delete_resource":
 		return e.deleteResource(ctx, args)
 	case "scale_resource":
@@ -323,8 +481,18 @@ func (e *Executor) describeResource(ctx context.Context, args map[string]string)
 		return "", fmt.Errorf("failed to get %s/%s: %w", args["kind"], args["name"], err)
 	}
 
-	data, _ := json.MarshalIndent(obj.Object, "", "  ")
-	return string(data), nil
+	return e.renderObject(obj.Object), nil
+}
+
+// renderObject formats an object for the LLM, redacting secret values first.
+func (e *Executor) renderObject(obj map[string]interface{}) string {
+	e.redactor.Redact(obj)
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // keep "<redacted>" readable
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(obj)
+	return strings.TrimSuffix(buf.String(), "\n")
 }
 
 func (e *Executor) getEvents(ctx context.Context, args map[string]string) (string, error) {
@@ -373,43 +541,6 @@ func (e *Executor) getEvents(ctx context.Context, args map[string]string) (strin
 	return fmt.Sprintf("Found %d events:\n%s", len(summaries), string(data)), nil
 }
 
-func (e *Executor) getLogs(ctx context.Context, args map[string]string) (string, error) {
-	client, err := e.clusterMgr.GetClient(args["cluster_id"])
-	if err != nil {
-		return "", err
-	}
-
-	tailLines := int64(100)
-	if tl := args["tail_lines"]; tl != "" {
-		if n, err := strconv.ParseInt(tl, 10, 64); err == nil && n > 0 {
-			tailLines = n
-		}
-	}
-
-	opts := &corev1.PodLogOptions{
-		TailLines: &tailLines,
-	}
-	if c := args["container"]; c != "" {
-		opts.Container = c
-	}
-	if args["previous"] == "true" {
-		opts.Previous = true
-	}
-
-	stream, err := client.Clientset.CoreV1().Pods(args["namespace"]).GetLogs(args["pod_name"], opts).Stream(ctx)
-	if err != nil {
-		return "", fmt.Errorf("failed to get logs for %s: %w", args["pod_name"], err)
-	}
-	defer stream.Close()
-
-	logBytes, err := io.ReadAll(io.LimitReader(stream, 64*1024)) // 64KB max
-	if err != nil {
-		return "", fmt.Errorf("failed to read logs: %w", err)
-	}
-
-	return string(logBytes), nil
-}
-
 func (e *Executor) getMetrics(ctx context.Context, args map[string]string) (string, error) {
 	_, err := e.clusterMgr.GetClient(args["cluster_id"])
 	if err != nil {
@@ -461,7 +592,7 @@ func (e *Executor) searchResources(ctx context.Context, args map[string]string)
 	return fmt.Sprintf("Found %d resources matching %q:\n%s", len(results), args["query"], string(data)), nil
 }
 
-func (e *Executor) applyYAML(ctx context.Context, args map[string]string) (string, error) {
+func (e *Executor) applyYAML(ctx context.Context, args map[string]string, userID string) (string, error) {
 	client, err := e.clusterMgr.GetClient(args["cluster_id"])
 	if err != nil {
 		return "", err
@@ -474,28 +605,51 @@ func (e *Executor) applyYAML(ctx context.Context, args map[string]string) (strin
 	}
 
 	gvk := obj.GroupVersionKind()
-	gvr := schema.GroupVersionResource{
-		Group:    gvk.Group,
-		Version:  gvk.Version,
-		Resource: strings.ToLower(gvk.Kind) + "s",
+	mapping, err := e.clusterMgr.RESTMapping(ctx, args["cluster_id"], gvk)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", gvk.Kind, err)
+	}
+	if err := e.toolPolicy().checkKind("apply_yaml", mapping.GroupVersionKind.GroupKind()); err != nil {
+		return "", err
 	}
 
 	ns := args["namespace"]
 	if obj.GetNamespace() != "" {
 		ns = obj.GetNamespace()
 	}
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		ns = ""
+	}
+	if err := e.authorizeApply(ctx, userID, args["cluster_id"], mapping, ns); err != nil {
+		return "", err
+	}
 
-	result, err := client.DynClient.Resource(gvr).Namespace(ns).Apply(
+	e.applyPolicy.Decorate(&obj, userID)
+	createdNS, err := e.applyPolicy.EnsureNamespace(ctx, e.clusterMgr, args["cluster_id"], ns, cluster.FieldManagerAI, userID, func() error {
+		return e.authorizeNamespaceCreate(ctx, userID, args["cluster_id"])
+	})
+	if err != nil {
+		return "", err
+	}
+
+	result, err := client.DynClient.Resource(mapping.Resource).Namespace(ns).Apply(
 		ctx,
 		obj.GetName(),
 		&obj,
-		metav1.ApplyOptions{FieldManager: "argus-ai"},
+		metav1.ApplyOptions{FieldManager: cluster.FieldManagerAI, Force: args["force"] == "true"},
 	)
 	if err != nil {
+		if conflict, ok := cluster.AsApplyConflict(err); ok {
+			return "", conflict
+		}
 		return "", fmt.Errorf("failed to apply: %w", err)
 	}
 
-	return fmt.Sprintf("Applied %s/%s in namespace %s", result.GetKind(), result.GetName(), result.GetNamespace()), nil
+	msg := fmt.Sprintf("Applied %s/%s in namespace %s", result.GetKind(), result.GetName(), result.GetNamespace())
+	if createdNS {
+		msg = fmt.Sprintf("Created namespace %s. %s", ns, msg)
+	}
+	return msg, nil
 }
 
 func (e *Executor) deleteResource(ctx context.Context, args map[string]string) (string, error) {
@@ -505,6 +659,9 @@ func (e *Executor) deleteResource(ctx context.Context, args map[string]string) (
 	}
 
 	gvr := kindToGVR(args["kind"])
+	if err := e.checkResourceKind(ctx, "delete_resource", args["cluster_id"], gvr); err != nil {
+		return "", err
+	}
 	err = client.DynClient.Resource(gvr).Namespace(args["namespace"]).Delete(ctx, args["name"], metav1.DeleteOptions{})
 	if err != nil {
 		return "", fmt.Errorf("failed to delete %s/%s: %w", args["kind"], args["name"], err)
@@ -514,27 +671,18 @@ func (e *Executor) deleteResource(ctx context.Context, args map[string]string) (
 }
 
 func (e *Executor) scaleResource(ctx context.Context, args map[string]string) (string, error) {
-	client, err := e.clusterMgr.GetClient(args["cluster_id"])
-	if err != nil {
-		return "", err
-	}
-
 	replicas, err := strconv.Atoi(args["replicas"])
 	if err != nil {
 		return "", fmt.Errorf("invalid replicas value: %w", err)
 	}
 
 	gvr := kindToGVR(args["kind"])
-	patch := fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas)
+	if err := e.checkResourceKind(ctx, "scale_resource", args["cluster_id"], gvr); err != nil {
+		return "", err
+	}
 
-	_, err = client.DynClient.Resource(gvr).Namespace(args["namespace"]).Patch(
-		ctx,
-		args["name"],
-		types.MergePatchType,
-		[]byte(patch),
-		metav1.PatchOptions{},
-	)
-	if err != nil {
+	patch := fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas)
+	if err := e.patchResource(ctx, args["cluster_id"], gvr, args["namespace"], args["name"], []byte(patch)); err != nil {
 		return "", fmt.Errorf("failed to scale %s/%s: %w", args["kind"], args["name"], err)
 	}
 
@@ -542,30 +690,54 @@ func (e *Executor) scaleResource(ctx context.Context, args map[string]string) (s
 }
 
 func (e *Executor) restartResource(ctx context.Context, args map[string]string) (string, error) {
-	client, err := e.clusterMgr.GetClient(args["cluster_id"])
-	if err != nil {
+	gvr := kindToGVR(args["kind"])
+	if err := e.checkResourceKind(ctx, "restart_resource", args["cluster_id"], gvr); err != nil {
 		return "", err
 	}
 
-	gvr := kindToGVR(args["kind"])
-
 	now := time.Now().Format(time.RFC3339)
 	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{"kubectl.kubernetes.io/restartedAt":"%s"}}}}}`, now)
-
-	_, err = client.DynClient.Resource(gvr).Namespace(args["namespace"]).Patch(
-		ctx,
-		args["name"],
-		types.MergePatchType,
-		[]byte(patch),
-		metav1.PatchOptions{},
-	)
-	if err != nil {
+	if err := e.patchResource(ctx, args["cluster_id"], gvr, args["namespace"], args["name"], []byte(patch)); err != nil {
 		return "", fmt.Errorf("failed to restart %s/%s: %w", args["kind"], args["name"], err)
 	}
 
 	return fmt.Sprintf("Rolling restart triggered for %s/%s in namespace %s", args["kind"], args["name"], args["namespace"]), nil
 }
 
+// checkResourceKind refuses a change to gvr when the tool policy does not
+// allow its kind. While the policy restricts kinds, the kind is resolved
+// against the cluster's discovery data, so plurals and short names are
+// checked as the kind they name; a resource that cannot be resolved is
+// refused.
+func (e *Executor) checkResourceKind(ctx context.Context, tool, clusterID string, gvr schema.GroupVersionResource) error {
+	policy := e.toolPolicy()
+	if !policy.RestrictsKinds() {
+		return nil
+	}
+	mapper, err := e.clusterMgr.RESTMapper(ctx, clusterID)
+	if err == nil {
+		var gvk schema.GroupVersionKind
+		if gvk, err = mapper.KindFor(gvr); err == nil {
+			return policy.checkKind(tool, gvk.GroupKind())
+		}
+	}
+	return fmt.Errorf("refused: the kind of %s could not be resolved while the assistant is limited to specific kinds: %w", gvr.Resource, err)
+}
+
+// patchResource merge-patches an object as the AI field manager, through
+// the same dry-run-validated path as the resource PATCH endpoint.
+func (e *Executor) patchResource(ctx context.Context, clusterID string, gvr schema.GroupVersionResource, namespace, name string, patch []byte) error {
+	_, err := cluster.PatchResource(ctx, e.clusterMgr, clusterID, cluster.PatchRequest{
+		Resource:     gvr,
+		Namespace:    namespace,
+		Name:         name,
+		Type:         types.MergePatchType,
+		Patch:        patch,
+		FieldManager: cluster.FieldManagerAI,
+	})
+	return err
+}
+
 // kindToGVR maps common kubectl resource names to GroupVersionResource.
 func kindToGVR(kind string) schema.GroupVersionResource {
 	kind = strings.ToLower(kind)
@@ -11,7 +11,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/remotecommand"
 )
@@ -273,6 +273,10 @@ func (e *Executor) portForwardInfo(_ context.Context, args map[string]string) (s
 }
 
 func (e *Executor) rollbackDeployment(ctx context.Context, args map[string]string) (string, error) {
+	if err := e.toolPolicy().checkKind("rollback_deployment", schema.GroupKind{Group: "apps", Kind: "Deployment"}); err != nil {
+		return "", err
+	}
+
 	client, err := e.clusterMgr.GetClient(args["cluster_id"])
 	if err != nil {
 		return "", err
@@ -354,14 +358,7 @@ func (e *Executor) rollbackDeployment(ctx context.Context, args map[string]strin
 		}
 	}
 
-	_, err = client.DynClient.Resource(kindToGVR("deployments")).Namespace(ns).Patch(
-		ctx,
-		name,
-		types.MergePatchType,
-		[]byte(patch),
-		metav1.PatchOptions{},
-	)
-	if err != nil {
+	if err := e.patchResource(ctx, args["cluster_id"], kindToGVR("deployments"), ns, name, []byte(patch)); err != nil {
 		return "", fmt.Errorf("failed to rollback deployment %s: %w", name, err)
 	}
 
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/darkden-lab/argus/backend/internal/outbound"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -82,7 +83,7 @@ func (e *Executor) queryPrometheus(ctx context.Context, args map[string]string)
 
 	promURL := fmt.Sprintf("http://prometheus-server.monitoring.svc.cluster.local:80%s?%s", endpoint, params.Encode())
 
-	httpClient := &http.Client{Timeout: 30 * time.Second}
+	httpClient := outbound.Client(30 * time.Second)
 	req, err := http.NewRequestWithContext(ctx, "GET", promURL, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
@@ -120,7 +121,7 @@ func (e *Executor) getAlerts(ctx context.Context, args map[string]string) (strin
 
 	alertURL := "http://prometheus-alertmanager.monitoring.svc.cluster.local:9093/api/v2/alerts"
 
-	httpClient := &http.Client{Timeout: 30 * time.Second}
+	httpClient := outbound.Client(30 * time.Second)
 	req, err := http.NewRequestWithContext(ctx, "GET", alertURL, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
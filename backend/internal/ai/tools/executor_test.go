@@ -1,6 +1,8 @@
 package tools
 
 import (
+	"context"
+	"strings"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -60,6 +62,117 @@ func TestRequiresConfirm(t *testing.T) {
 	}
 }
 
+func TestClassifyTool(t *testing.T) {
+	tests := map[string]ToolClass{
+		"get_resources":       ToolClassRead,
+		"search_resources":    ToolClassRead,
+		"apply_yaml":          ToolClassWrite,
+		"scale_resource":      ToolClassWrite,
+		"rollback_deployment": ToolClassWrite,
+		"delete_resource":     ToolClassDestructive,
+		"get_pod_exec":        ToolClassDestructive,
+	}
+	for name, want := range tests {
+		if got := ClassifyTool(name); got != want {
+			t.Errorf("ClassifyTool(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestExecuteForUser_RefusesUnconfirmedWrite(t *testing.T) {
+	// Even an admin cannot run a write tool without a consumed confirmation.
+	e := &Executor{authorizer: &fakeAuthorizer{allowed: map[string]bool{"*:*": true}}}
+	e.SetToolPolicy(ToolPolicy{Level: "all", Enabled: []string{"delete_resource"}})
+	result := e.ExecuteForUser(context.Background(), ToolCall{
+		ID:        "call-1",
+		Name:      "delete_resource",
+		Arguments: `{"cluster_id":"cluster-1","kind":"pod","name":"nginx","namespace":"default"}`,
+	}, "admin")
+	if !result.IsError {
+		t.Fatalf("expected unconfirmed delete to be refused, got %q", result.Content)
+	}
+}
+
+func TestExecuteConfirmed_RequiresApproval(t *testing.T) {
+	mgr := NewConfirmationManager()
+	e := &Executor{authorizer: &fakeAuthorizer{allowed: map[string]bool{"*:*": true}}}
+	e.SetConfirmationManager(mgr)
+	e.SetToolPolicy(ToolPolicy{Level: "all", Enabled: []string{"delete_resource"}})
+
+	call := ToolCall{ID: "call-1", Name: "delete_resource", Arguments: `{"name":"nginx"}`}
+	req := mgr.CreateRequest("admin", call)
+
+	// Still pending: nothing to consume.
+	if result := e.ExecuteConfirmed(context.Background(), call, "admin", req.ID); !result.IsError {
+		t.Fatalf("expected pending confirmation to be refused, got %q", result.Content)
+	}
+}
+
+func TestExecute_RefusesDisabledTools(t *testing.T) {
+	mgr := NewConfirmationManager()
+	e := &Executor{authorizer: &fakeAuthorizer{allowed: map[string]bool{"*:*": true}}}
+	e.SetConfirmationManager(mgr)
+	e.SetToolPolicy(ToolPolicy{Level: "all", Disabled: []string{"get_resources"}})
+
+	// No cluster manager: reaching dispatch would panic.
+	call := ToolCall{ID: "call-1", Name: "get_resources", Arguments: `{"cluster_id":"cluster-1","kind":"pods"}`}
+	if result := e.ExecuteForUser(context.Background(), call, "admin"); !strings.Contains(result.Content, "disabled") {
+		t.Errorf("expected disabled read tool to be refused, got %q", result.Content)
+	}
+
+	// An approved destructive call is still refused unless the policy enables it.
+	call = ToolCall{ID: "call-2", Name: "delete_resource", Arguments: `{"name":"nginx"}`}
+	req := mgr.CreateRequest("admin", call)
+	if err := mgr.Resolve(req.ID, "admin", true); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if result := e.ExecuteConfirmed(context.Background(), call, "admin", req.ID); !strings.Contains(result.Content, "disabled") {
+		t.Errorf("expected destructive tool to be disabled by default, got %q", result.Content)
+	}
+}
+
+func TestExecute_RefusesProtectedNamespace(t *testing.T) {
+	e := &Executor{authorizer: &fakeAuthorizer{allowed: map[string]bool{"*:*": true}}}
+	e.SetToolPolicy(ToolPolicy{Level: "all", DeniedNamespaces: []string{"kube-system"}})
+
+	// No cluster manager: reaching the tool would panic.
+	call := ToolCall{ID: "call-1", Name: "get_resources", Arguments: `{"cluster_id":"cluster-1","kind":"pods","namespace":"kube-system"}`}
+	result := e.ExecuteForUser(context.Background(), call, "admin")
+	if !result.IsError || !strings.Contains(result.Content, "may not use get_resources in namespace kube-system") {
+		t.Errorf("expected a refusal for kube-system, got %q", result.Content)
+	}
+}
+
+func TestExecute_RefusesProtectedKind(t *testing.T) {
+	e := &Executor{authorizer: &fakeAuthorizer{allowed: map[string]bool{"*:*": true}}}
+	e.SetToolPolicy(ToolPolicy{Level: "all", AllowedKinds: []string{"ConfigMap"}})
+
+	// No cluster manager: reaching the cluster would panic.
+	call := ToolCall{ID: "call-1", Name: "rollback_deployment", Arguments: `{"cluster_id":"cluster-1","namespace":"default","deployment":"web"}`}
+	result := e.Execute(context.Background(), call, "admin")
+	if !result.IsError || !strings.Contains(result.Content, "may not use rollback_deployment on Deployment.apps objects") {
+		t.Errorf("expected a refusal for Deployments, got %q", result.Content)
+	}
+}
+
+func TestExecute_RefusesInvalidIdentifiers(t *testing.T) {
+	e := &Executor{authorizer: &fakeAuthorizer{allowed: map[string]bool{"*:*": true}}}
+	e.SetToolPolicy(ToolPolicy{Level: "all"})
+
+	// No cluster manager: reaching the tool would panic.
+	args := []string{
+		`{"cluster_id":"cluster-1","kind":"pods","namespace":"../kube-system"}`,
+		`{"cluster_id":"cluster-1","kind":"events","involved_name":"web,reason=Killing"}`,
+		`{"cluster_id":"cluster-1","kind":"pods","label_selector":"app in (web"}`,
+	}
+	for _, a := range args {
+		result := e.ExecuteForUser(context.Background(), ToolCall{ID: "call-1", Name: "get_resources", Arguments: a}, "admin")
+		if !result.IsError || !strings.Contains(result.Content, "invalid") {
+			t.Errorf("expected %s to be refused, got %q", a, result.Content)
+		}
+	}
+}
+
 func TestAllToolsCount(t *testing.T) {
 	all := AllTools()
 	readOnly := ReadOnlyTools()
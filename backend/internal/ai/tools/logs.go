@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultLogMaxBytes is how many bytes of logs get_logs returns when no
+// limit is configured. Every byte ends up in the prompt, so it is kept
+// small; AI_LOG_MAX_BYTES raises it for models with larger windows.
+const DefaultLogMaxBytes = 64 * 1024
+
+// logScanFactor bounds how far past the byte limit a log stream is read to
+// count the bytes left out.
+const logScanFactor = 8
+
+func (e *Executor) getLogs(ctx context.Context, args map[string]string) (string, error) {
+	client, err := e.clusterMgr.GetClient(args["cluster_id"])
+	if err != nil {
+		return "", err
+	}
+
+	tailLines := int64(100)
+	if tl := args["tail_lines"]; tl != "" {
+		if n, err := strconv.ParseInt(tl, 10, 64); err == nil && n > 0 {
+			tailLines = n
+		}
+	}
+	limit := int64(e.logMaxBytes)
+	if limit <= 0 {
+		limit = DefaultLogMaxBytes
+	}
+
+	podName := args["pod_name"]
+	pods := client.Clientset.CoreV1().Pods(args["namespace"])
+	readContainer := func(container string, limit int64) (string, error) {
+		scanLimit := limit * logScanFactor
+		opts := &corev1.PodLogOptions{
+			Container:  container,
+			TailLines:  &tailLines,
+			Previous:   args["previous"] == "true",
+			LimitBytes: &scanLimit,
+		}
+		stream, err := pods.GetLogs(podName, opts).Stream(ctx)
+		if err != nil {
+			return "", err
+		}
+		defer stream.Close()
+		return readLogs(stream, limit, scanLimit)
+	}
+
+	if args["all_containers"] != "true" {
+		logs, err := readContainer(args["container"], limit)
+		if err != nil {
+			return "", fmt.Errorf("failed to get logs for %s: %w", podName, err)
+		}
+		return logs, nil
+	}
+
+	pod, err := pods.Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get pod %s: %w", podName, err)
+	}
+	containers := podContainerNames(pod)
+	if len(containers) == 0 {
+		return "Pod has no containers.", nil
+	}
+
+	// The limit is shared so one chatty container cannot crowd out the others.
+	perContainer := limit / int64(len(containers))
+	var b strings.Builder
+	for _, container := range containers {
+		logs, err := readContainer(container, perContainer)
+		if err != nil {
+			logs = "error: " + err.Error()
+		}
+		b.WriteString(labelLogLines(container, logs))
+	}
+	return b.String(), nil
+}
+
+// readLogs reads up to limit bytes of a log stream that ends after at most
+// scanLimit bytes, and notes how many bytes were left out.
+func readLogs(r io.Reader, limit, scanLimit int64) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit))
+	if err != nil {
+		return "", fmt.Errorf("failed to read logs: %w", err)
+	}
+	rest, err := io.Copy(io.Discard, r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read logs: %w", err)
+	}
+	logs := string(data)
+	if rest == 0 {
+		return logs, nil
+	}
+
+	if !strings.HasSuffix(logs, "\n") {
+		logs += "\n"
+	}
+	if limit+rest >= scanLimit {
+		return logs + fmt.Sprintf("[truncated at least %d bytes; request fewer tail_lines to see the latest lines]\n", rest), nil
+	}
+	return logs + fmt.Sprintf("[truncated %d bytes; request fewer tail_lines to see the latest lines]\n", rest), nil
+}
+
+// labelLogLines prefixes every line of a container's logs with its name.
+func labelLogLines(container, logs string) string {
+	logs = strings.TrimRight(logs, "\n")
+	if logs == "" {
+		logs = "(no logs)"
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(logs, "\n") {
+		b.WriteString("[" + container + "] " + line + "\n")
+	}
+	return b.String()
+}
+
+// podContainerNames lists a pod's init containers, which are often the ones
+// failing, followed by its containers.
+func podContainerNames(pod *corev1.Pod) []string {
+	names := make([]string, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	for _, c := range pod.Spec.InitContainers {
+		names = append(names, c.Name)
+	}
+	for _, c := range pod.Spec.Containers {
+		names = append(names, c.Name)
+	}
+	return names
+}
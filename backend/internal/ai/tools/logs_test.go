@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestReadLogs(t *testing.T) {
+	logs, err := readLogs(strings.NewReader("line 1\nline 2\n"), 64, 512)
+	if err != nil || logs != "line 1\nline 2\n" {
+		t.Errorf("expected logs under the limit to be returned as is, got %q %v", logs, err)
+	}
+
+	logs, _ = readLogs(strings.NewReader("line 1\nline 2\nline 3\n"), 10, 80)
+	want := "line 1\nlin\n[truncated 11 bytes; request fewer tail_lines to see the latest lines]\n"
+	if logs != want {
+		t.Errorf("expected a truncation note, got %q", logs)
+	}
+
+	// The server stopped at scanLimit, so more may have been left out.
+	logs, _ = readLogs(strings.NewReader(strings.Repeat("x", 80)), 10, 80)
+	if !strings.HasSuffix(logs, "[truncated at least 70 bytes; request fewer tail_lines to see the latest lines]\n") {
+		t.Errorf("expected a lower bound once the scan limit is reached, got %q", logs)
+	}
+}
+
+func TestLabelLogLines(t *testing.T) {
+	if got := labelLogLines("app", "started\nready\n"); got != "[app] started\n[app] ready\n" {
+		t.Errorf("unexpected labelled logs %q", got)
+	}
+	if got := labelLogLines("init", ""); got != "[init] (no logs)\n" {
+		t.Errorf("expected empty logs to be marked, got %q", got)
+	}
+}
+
+func TestPodContainerNames(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "migrate"}},
+		Containers:     []corev1.Container{{Name: "app"}, {Name: "proxy"}},
+	}}
+	if got := strings.Join(podContainerNames(pod), ","); got != "migrate,app,proxy" {
+		t.Errorf("expected init containers first, got %s", got)
+	}
+}
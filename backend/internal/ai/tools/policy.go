@@ -0,0 +1,280 @@
+package tools
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+var (
+	toolNamesOnce     sync.Once
+	allToolNames      map[string]bool
+	readOnlyToolNames map[string]bool
+	// namespacedToolNames are the tools that take a namespace argument.
+	namespacedToolNames map[string]bool
+	// clusterToolNames are the tools that take a cluster_id argument.
+	clusterToolNames map[string]bool
+)
+
+func toolNameSets() (all, readOnly map[string]bool) {
+	toolNamesOnce.Do(func() {
+		allToolNames = make(map[string]bool)
+		readOnlyToolNames = make(map[string]bool)
+		namespacedToolNames = make(map[string]bool)
+		clusterToolNames = make(map[string]bool)
+		for _, t := range ReadOnlyTools() {
+			allToolNames[t.Name] = true
+			readOnlyToolNames[t.Name] = true
+		}
+		for _, t := range WriteTools() {
+			allToolNames[t.Name] = true
+		}
+		for _, t := range AllTools() {
+			if _, ok := t.Parameters.Properties["namespace"]; ok {
+				namespacedToolNames[t.Name] = true
+			}
+			if _, ok := t.Parameters.Properties["cluster_id"]; ok {
+				clusterToolNames[t.Name] = true
+			}
+		}
+	})
+	return allToolNames, readOnlyToolNames
+}
+
+// IsKnownTool returns true if name is one of the assistant's tools.
+func IsKnownTool(name string) bool {
+	all, _ := toolNameSets()
+	return all[name]
+}
+
+// ToolPolicy decides which tools the assistant is offered and may run. A
+// tool is allowed when the permission level includes it and it is not
+// listed in Disabled. Destructive tools are also off unless listed in
+// Enabled, so a deployment has to opt in to them explicitly.
+//
+// AllowedNamespaces and DeniedNamespaces limit the namespaces tools may
+// reach, whatever the user's RBAC allows. Entries are names or path.Match
+// patterns such as "kube-*".
+//
+// AllowedKinds and DeniedKinds likewise limit the kinds of object tools may
+// change. Entries are kinds such as "ClusterRoleBinding", matching that kind
+// in any API group, or kinds qualified with their group such as
+// "Ingress.networking.k8s.io". Matching is case-insensitive.
+type ToolPolicy struct {
+	Level             string
+	Enabled           []string
+	Disabled          []string
+	AllowedNamespaces []string
+	DeniedNamespaces  []string
+	AllowedKinds      []string
+	DeniedKinds       []string
+}
+
+// Allows reports whether the policy lets the named tool be offered and run.
+// Like ToolsForLevel it denies by default: an unrecognized level or tool
+// name is not allowed.
+func (p ToolPolicy) Allows(name string) bool {
+	all, readOnly := toolNameSets()
+	switch p.Level {
+	case "all":
+		if !all[name] {
+			return false
+		}
+	case "read_only":
+		if !readOnly[name] {
+			return false
+		}
+	default:
+		return false
+	}
+	if slices.Contains(p.Disabled, name) {
+		return false
+	}
+	if ClassifyTool(name) == ToolClassDestructive {
+		return slices.Contains(p.Enabled, name)
+	}
+	return true
+}
+
+// Filter returns the tools the policy allows, preserving their order.
+func (p ToolPolicy) Filter(tools []Tool) []Tool {
+	var allowed []Tool
+	for _, t := range tools {
+		if p.Allows(t.Name) {
+			allowed = append(allowed, t)
+		}
+	}
+	return allowed
+}
+
+// Tools returns the tools to offer the model under the policy.
+func (p ToolPolicy) Tools() []Tool {
+	return p.Filter(ToolsForLevel(p.Level))
+}
+
+// RestrictsNamespaces reports whether the policy limits the namespaces tools
+// may reach.
+func (p ToolPolicy) RestrictsNamespaces() bool {
+	return len(p.AllowedNamespaces) > 0 || len(p.DeniedNamespaces) > 0
+}
+
+// AllowsNamespace reports whether tools may reach the named namespace: it
+// matches no denied entry and, when AllowedNamespaces is set, an allowed one.
+func (p ToolPolicy) AllowsNamespace(namespace string) bool {
+	if matchesNamespace(p.DeniedNamespaces, namespace) {
+		return false
+	}
+	return len(p.AllowedNamespaces) == 0 || matchesNamespace(p.AllowedNamespaces, namespace)
+}
+
+func matchesNamespace(patterns []string, namespace string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateNamespacePattern checks an entry of AllowedNamespaces or
+// DeniedNamespaces.
+func ValidateNamespacePattern(pattern string) error {
+	if pattern == "" || len(pattern) > 63 || strings.ContainsAny(pattern, "/ ") {
+		return fmt.Errorf("invalid namespace pattern %q", pattern)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid namespace pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// RestrictsKinds reports whether the policy limits the kinds tools may
+// change.
+func (p ToolPolicy) RestrictsKinds() bool {
+	return len(p.AllowedKinds) > 0 || len(p.DeniedKinds) > 0
+}
+
+// AllowsKind reports whether tools may change objects of the kind: it
+// matches no denied entry and, when AllowedKinds is set, an allowed one.
+func (p ToolPolicy) AllowsKind(gk schema.GroupKind) bool {
+	if matchesKind(p.DeniedKinds, gk) {
+		return false
+	}
+	return len(p.AllowedKinds) == 0 || matchesKind(p.AllowedKinds, gk)
+}
+
+func matchesKind(entries []string, gk schema.GroupKind) bool {
+	for _, entry := range entries {
+		kind, group, qualified := strings.Cut(entry, ".")
+		if strings.EqualFold(kind, gk.Kind) && (!qualified || strings.EqualFold(group, gk.Group)) {
+			return true
+		}
+	}
+	return false
+}
+
+// kindEntry matches an entry of AllowedKinds or DeniedKinds: a kind,
+// optionally followed by a dot and its API group.
+var kindEntry = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*)?$`)
+
+// ValidateKindEntry checks an entry of AllowedKinds or DeniedKinds.
+func ValidateKindEntry(entry string) error {
+	if len(entry) > 253 || !kindEntry.MatchString(entry) {
+		return fmt.Errorf("invalid kind %q", entry)
+	}
+	return nil
+}
+
+// kindScopeError is returned when a tool call would change an object of a
+// kind the policy does not allow.
+type kindScopeError struct {
+	tool string
+	kind schema.GroupKind
+}
+
+func (e *kindScopeError) Error() string {
+	return fmt.Sprintf("refused: the assistant may not use %s on %s objects", e.tool, e.kind.String())
+}
+
+// checkKind refuses a tool call that would change an object of a kind the
+// policy does not allow.
+func (p ToolPolicy) checkKind(tool string, gk schema.GroupKind) error {
+	if !p.AllowsKind(gk) {
+		return &kindScopeError{tool: tool, kind: gk}
+	}
+	return nil
+}
+
+// namespaceScopeError is returned when a tool call reaches outside the
+// namespaces the policy allows.
+type namespaceScopeError struct {
+	tool      string
+	namespace string
+}
+
+func (e *namespaceScopeError) Error() string {
+	if e.namespace == "" {
+		return fmt.Sprintf("refused: %s must name a namespace because the assistant is limited to specific namespaces", e.tool)
+	}
+	return fmt.Sprintf("refused: the assistant may not use %s in namespace %s", e.tool, e.namespace)
+}
+
+// clusterScopedKinds are resources kindToGVR knows to have no namespace, so
+// listing them without one does not reach into any namespace.
+var clusterScopedKinds = map[string]bool{
+	"namespaces":        true,
+	"nodes":             true,
+	"persistentvolumes": true,
+}
+
+// checkNamespaceScope refuses a tool call that reaches a namespace the
+// policy does not allow. The namespace is the call's namespace argument, the
+// manifest's namespace for apply_yaml, or the name of a Namespace object
+// being acted on. A namespaced tool called without a namespace would span
+// every namespace, so it is refused while the policy restricts namespaces,
+// except for listing cluster-scoped kinds.
+func (p ToolPolicy) checkNamespaceScope(name string, args map[string]string) error {
+	if !p.RestrictsNamespaces() {
+		return nil
+	}
+	toolNameSets()
+	if !namespacedToolNames[name] {
+		return nil
+	}
+
+	namespace := args["namespace"]
+	kind := args["kind"]
+	objectName := args["name"]
+	if name == "apply_yaml" {
+		var obj unstructured.Unstructured
+		decoder := yamlutil.NewYAMLOrJSONDecoder(strings.NewReader(args["yaml"]), 4096)
+		if err := decoder.Decode(&obj); err != nil {
+			return fmt.Errorf("failed to decode YAML: %w", err)
+		}
+		if ns := obj.GetNamespace(); ns != "" {
+			namespace = ns
+		}
+		kind, objectName = obj.GetKind(), obj.GetName()
+	}
+	if kind != "" && kindToGVR(kind).Resource == "namespaces" && objectName != "" {
+		namespace = objectName
+	}
+
+	if namespace == "" {
+		if kind != "" && clusterScopedKinds[kindToGVR(kind).Resource] {
+			return nil
+		}
+		return &namespaceScopeError{tool: name}
+	}
+	if !p.AllowsNamespace(namespace) {
+		return &namespaceScopeError{tool: name, namespace: namespace}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestToolPolicyAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy ToolPolicy
+		tool   string
+		want   bool
+	}{
+		{"read tool at all", ToolPolicy{Level: "all"}, "get_resources", true},
+		{"write tool at all", ToolPolicy{Level: "all"}, "scale_resource", true},
+		{"write tool at read_only", ToolPolicy{Level: "read_only"}, "scale_resource", false},
+		{"destructive tool off by default", ToolPolicy{Level: "all"}, "delete_resource", false},
+		{"destructive tool enabled", ToolPolicy{Level: "all", Enabled: []string{"delete_resource"}}, "delete_resource", true},
+		{"enabling does not raise the level", ToolPolicy{Level: "read_only", Enabled: []string{"get_pod_exec"}}, "get_pod_exec", false},
+		{"disabled read tool", ToolPolicy{Level: "all", Disabled: []string{"get_logs"}}, "get_logs", false},
+		{"disabled wins over enabled", ToolPolicy{Level: "all", Enabled: []string{"delete_memory"}, Disabled: []string{"delete_memory"}}, "delete_memory", false},
+		{"unknown tool", ToolPolicy{Level: "all"}, "rm_rf", false},
+		{"unknown level", ToolPolicy{}, "get_resources", false},
+		{"disabled level", ToolPolicy{Level: "disabled"}, "get_resources", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Allows(tt.tool); got != tt.want {
+				t.Errorf("Allows(%q) = %v, want %v", tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolPolicyTools_OmitsDestructiveByDefault(t *testing.T) {
+	offered := ToolPolicy{Level: "all"}.Tools()
+	if len(offered) == 0 {
+		t.Fatal("expected tools to be offered")
+	}
+	for _, tool := range offered {
+		if ClassifyTool(tool.Name) == ToolClassDestructive {
+			t.Errorf("destructive tool %q offered without being enabled", tool.Name)
+		}
+	}
+
+	offered = ToolPolicy{Level: "all", Enabled: []string{"get_pod_exec"}}.Tools()
+	found := false
+	for _, tool := range offered {
+		found = found || tool.Name == "get_pod_exec"
+	}
+	if !found {
+		t.Error("expected enabled destructive tool to be offered")
+	}
+}
+
+func TestIsKnownTool(t *testing.T) {
+	if !IsKnownTool("apply_yaml") || !IsKnownTool("recall_memory") {
+		t.Error("expected built-in tools to be known")
+	}
+	if IsKnownTool("rm_rf") {
+		t.Error("expected unknown tool to be rejected")
+	}
+}
+
+func TestToolPolicyAllowsNamespace(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    ToolPolicy
+		namespace string
+		want      bool
+	}{
+		{"no lists", ToolPolicy{}, "kube-system", true},
+		{"denied", ToolPolicy{DeniedNamespaces: []string{"kube-system"}}, "kube-system", false},
+		{"denied pattern", ToolPolicy{DeniedNamespaces: []string{"kube-*"}}, "kube-public", false},
+		{"not denied", ToolPolicy{DeniedNamespaces: []string{"kube-*"}}, "default", true},
+		{"allowed", ToolPolicy{AllowedNamespaces: []string{"team-*"}}, "team-a", true},
+		{"not allowed", ToolPolicy{AllowedNamespaces: []string{"team-*"}}, "default", false},
+		{"deny wins", ToolPolicy{AllowedNamespaces: []string{"*"}, DeniedNamespaces: []string{"kube-system"}}, "kube-system", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.AllowsNamespace(tt.namespace); got != tt.want {
+				t.Errorf("AllowsNamespace(%q) = %v, want %v", tt.namespace, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolPolicyCheckNamespaceScope(t *testing.T) {
+	policy := ToolPolicy{Level: "all", DeniedNamespaces: []string{"kube-system"}}
+	tests := []struct {
+		name    string
+		tool    string
+		args    map[string]string
+		allowed bool
+	}{
+		{"allowed namespace", "get_resources", map[string]string{"kind": "pods", "namespace": "default"}, true},
+		{"denied namespace", "get_logs", map[string]string{"namespace": "kube-system", "pod_name": "coredns"}, false},
+		{"all namespaces", "get_resources", map[string]string{"kind": "pods"}, false},
+		{"cluster-scoped kind", "get_resources", map[string]string{"kind": "nodes"}, true},
+		{"tool without namespace", "cluster_health_check", map[string]string{"cluster_id": "c1"}, true},
+		{"namespace object", "delete_resource", map[string]string{"kind": "namespace", "name": "kube-system", "namespace": "default"}, false},
+		{"manifest namespace", "apply_yaml", map[string]string{"namespace": "default", "yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: x\n  namespace: kube-system\n"}, false},
+		{"manifest in allowed namespace", "apply_yaml", map[string]string{"namespace": "default", "yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: x\n"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.checkNamespaceScope(tt.tool, tt.args)
+			if (err == nil) != tt.allowed {
+				t.Errorf("checkNamespaceScope(%s, %v) = %v, want allowed=%v", tt.tool, tt.args, err, tt.allowed)
+			}
+		})
+	}
+
+	if err := (ToolPolicy{Level: "all"}).checkNamespaceScope("get_resources", map[string]string{"kind": "pods"}); err != nil {
+		t.Errorf("expected no restriction without namespace lists, got %v", err)
+	}
+}
+
+func TestValidateNamespacePattern(t *testing.T) {
+	for _, pattern := range []string{"kube-system", "kube-*", "team-?"} {
+		if err := ValidateNamespacePattern(pattern); err != nil {
+			t.Errorf("%q: unexpected error %v", pattern, err)
+		}
+	}
+	for _, pattern := range []string{"", "a/b", "[", strings.Repeat("a", 64)} {
+		if err := ValidateNamespacePattern(pattern); err == nil {
+			t.Errorf("%q: expected an error", pattern)
+		}
+	}
+}
+
+func TestToolPolicyAllowsKind(t *testing.T) {
+	crb := schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}
+	ingress := schema.GroupKind{Group: "networking.k8s.io", Kind: "Ingress"}
+	tests := []struct {
+		name   string
+		policy ToolPolicy
+		kind   schema.GroupKind
+		want   bool
+	}{
+		{"no lists", ToolPolicy{}, crb, true},
+		{"denied", ToolPolicy{DeniedKinds: []string{"ClusterRoleBinding"}}, crb, false},
+		{"denied case-insensitively", ToolPolicy{DeniedKinds: []string{"clusterrolebinding"}}, crb, false},
+		{"denied in group", ToolPolicy{DeniedKinds: []string{"ClusterRoleBinding.rbac.authorization.k8s.io"}}, crb, false},
+		{"denied in another group", ToolPolicy{DeniedKinds: []string{"Ingress.extensions"}}, ingress, true},
+		{"allowed", ToolPolicy{AllowedKinds: []string{"Deployment", "Ingress"}}, ingress, true},
+		{"not allowed", ToolPolicy{AllowedKinds: []string{"Deployment"}}, crb, false},
+		{"deny wins", ToolPolicy{AllowedKinds: []string{"Ingress"}, DeniedKinds: []string{"Ingress.networking.k8s.io"}}, ingress, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.AllowsKind(tt.kind); got != tt.want {
+				t.Errorf("AllowsKind(%s) = %v, want %v", tt.kind, got, tt.want)
+			}
+		})
+	}
+
+	err := ToolPolicy{DeniedKinds: []string{"ClusterRoleBinding"}}.checkKind("apply_yaml", crb)
+	if err == nil || err.Error() != "refused: the assistant may not use apply_yaml on ClusterRoleBinding.rbac.authorization.k8s.io objects" {
+		t.Errorf("unexpected refusal %v", err)
+	}
+}
+
+func TestValidateKindEntry(t *testing.T) {
+	for _, entry := range []string{"Secret", "ClusterRoleBinding", "ValidatingWebhookConfiguration.admissionregistration.k8s.io"} {
+		if err := ValidateKindEntry(entry); err != nil {
+			t.Errorf("%q: unexpected error %v", entry, err)
+		}
+	}
+	for _, entry := range []string{"", "deployments/scale", "Ingress.", "*", "1Pod", "Role.RBAC"} {
+		if err := ValidateKindEntry(entry); err == nil {
+			t.Errorf("%q: expected an error", entry)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/darkden-lab/argus/backend/internal/redact"
+)
+
+func secretObject() map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":      "db-credentials",
+			"namespace": "default",
+			"annotations": map[string]interface{}{
+				"kubectl.kubernetes.io/last-applied-configuration": `{"apiVersion":"v1","kind":"Secret","data":{"password":"aHVudGVyMg=="},"stringData":{"token":"plain-token-value"}}`,
+				"owner": "team-a",
+			},
+		},
+		"type":       "Opaque",
+		"data":       map[string]interface{}{"password": "aHVudGVyMg==", "username": "YWRtaW4="},
+		"stringData": map[string]interface{}{"token": "plain-token-value"},
+	}
+}
+
+func TestRenderObject_RedactsSecretData(t *testing.T) {
+	e := NewExecutor(nil, nil, nil)
+	out := e.renderObject(secretObject())
+
+	for _, leaked := range []string{"aHVudGVyMg==", "YWRtaW4=", "plain-token-value"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("tool result contains secret value %q:\n%s", leaked, out)
+		}
+	}
+	for _, kept := range []string{"db-credentials", "password", "username", "team-a", redact.Value} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("tool result is missing %q:\n%s", kept, out)
+		}
+	}
+}
+
+func TestRenderObject_RedactsSensitiveConfigMapKeys(t *testing.T) {
+	e := NewExecutor(nil, nil, nil)
+	e.SetRedactor(redact.NewRedactor([]string{"DSN"}))
+	out := e.renderObject(map[string]interface{}{
+		"kind": "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":        "app-config",
+			"annotations": map[string]interface{}{"vendor.io/api-token": "annotation-token-value"},
+		},
+		"data": map[string]interface{}{
+			"DB_PASSWORD":  "configmap-password-value",
+			"DATABASE_DSN": "postgres://user:pw@db/app",
+			"LOG_LEVEL":    "debug",
+		},
+	})
+
+	for _, leaked := range []string{"configmap-password-value", "postgres://user:pw@db/app", "annotation-token-value"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("tool result contains sensitive value %q:\n%s", leaked, out)
+		}
+	}
+	if !strings.Contains(out, `"LOG_LEVEL": "debug"`) {
+		t.Errorf("non-sensitive ConfigMap values should be kept:\n%s", out)
+	}
+}
+
+func TestRedactor_NilDisablesRedaction(t *testing.T) {
+	e := NewExecutor(nil, nil, nil)
+	e.SetRedactor(nil)
+	out := e.renderObject(secretObject())
+	if !strings.Contains(out, "aHVudGVyMg==") {
+		t.Errorf("expected secret data to be kept with redaction disabled:\n%s", out)
+	}
+}
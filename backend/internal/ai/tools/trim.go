@@ -0,0 +1,312 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultResultMaxBytes is how large a tool result may be when no limit is
+// configured. Like logs, every byte of a result is sent to the provider, and
+// a single describe_resource of a busy object can fill the context window;
+// AI_TOOL_RESULT_MAX_BYTES changes it.
+const DefaultResultMaxBytes = 64 * 1024
+
+// lastAppliedAnnotation holds a full copy of the object as last applied by
+// kubectl, which repeats the spec the model already sees.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// essentialFields are the top-level fields kept once an object has to be cut
+// down to what matters for troubleshooting, and essentialMetadata the
+// metadata fields kept with them.
+var (
+	essentialFields   = map[string]bool{"apiVersion": true, "kind": true, "metadata": true, "spec": true, "status": true}
+	essentialMetadata = map[string]bool{"name": true, "namespace": true, "labels": true, "ownerReferences": true, "creationTimestamp": true, "deletionTimestamp": true}
+)
+
+// trimLevel is one pass at reducing a result: lists are cut to lists items
+// and strings to strings bytes, and with essentials set objects keep only
+// essentialFields.
+type trimLevel struct {
+	lists, strings int
+	essentials     bool
+}
+
+// trimLevels are tried in order until a result fits, least lossy first.
+// Verbose fields are dropped at every level.
+var trimLevels = []trimLevel{
+	{},
+	{lists: 20, strings: 1024},
+	{lists: 20, strings: 1024, essentials: true},
+	{lists: 5, strings: 128, essentials: true},
+	{lists: 1, strings: 128, essentials: true},
+}
+
+// limitResult applies the result size limit to a tool's output. get_logs is
+// left alone: it has its own limit and already notes what it cut.
+func (e *Executor) limitResult(toolName, content string) string {
+	if toolName == "get_logs" {
+		return content
+	}
+	limit := e.resultMaxBytes
+	if limit <= 0 {
+		limit = DefaultResultMaxBytes
+	}
+	return trimResult(content, limit)
+}
+
+// trimResult returns content unchanged when it fits within limit bytes.
+// Otherwise a JSON result, optionally preceded by a one-line header such as
+// "Found 3 pods:", is reduced field by field so it still parses, and a note
+// tells the model what was left out. Anything else is cut at a line
+// boundary.
+func trimResult(content string, limit int) string {
+	if len(content) <= limit {
+		return content
+	}
+	header, _, ok := decodeResult(content)
+	if !ok {
+		return truncateResult(content, limit)
+	}
+
+	for _, level := range trimLevels {
+		_, v, _ := decodeResult(content)
+		removed := []string{dropVerboseFields(v)}
+		if level.essentials {
+			removed = append(removed, keepEssentialFields(v))
+		}
+		if level.lists > 0 {
+			var what string
+			v, what = shortenLists(v, level.lists)
+			removed = append(removed, what)
+		}
+		if level.strings > 0 {
+			removed = append(removed, shortenStrings(v, level.strings))
+		}
+		out := header + encodeResult(v)
+		note := fmt.Sprintf("\n[result trimmed from %d bytes to fit the %d byte tool result limit: %s; ask about a specific object or narrow the query to see the rest]",
+			len(content), limit, joinNonEmpty(removed))
+		if len(out)+len(note) <= limit {
+			return out + note
+		}
+	}
+	return truncateResult(content, limit)
+}
+
+func joinNonEmpty(parts []string) string {
+	kept := parts[:0]
+	for _, p := range parts {
+		if p != "" {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == 0 {
+		return "reformatted"
+	}
+	return strings.Join(kept, "; ")
+}
+
+// decodeResult splits a result into an optional header line and its JSON
+// body.
+func decodeResult(content string) (string, interface{}, bool) {
+	header, body := "", content
+	if !strings.HasPrefix(body, "{") && !strings.HasPrefix(body, "[") {
+		i := strings.IndexByte(content, '\n')
+		if i < 0 {
+			return "", nil, false
+		}
+		header, body = content[:i+1], content[i+1:]
+	}
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return "", nil, false
+	}
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return header, v, true
+	}
+	return "", nil, false
+}
+
+// encodeResult formats a trimmed result the way renderObject does.
+func encodeResult(v interface{}) string {
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// truncateResult cuts content that cannot be trimmed structurally at the
+// last line break that fits, noting how much was left out.
+func truncateResult(content string, limit int) string {
+	// The number of bytes left out is at most len(content), so a note
+	// with that count is as long as the final one can be.
+	note := func(n int) string {
+		return fmt.Sprintf("\n[truncated %d of %d bytes; ask about a specific object or narrow the query to see the rest]", n, len(content))
+	}
+	keep := limit - len(note(len(content)))
+	if keep < 0 {
+		keep = 0
+	}
+	cut := prefix(content, keep)
+	if i := strings.LastIndexByte(cut, '\n'); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + note(len(content)-len(cut))
+}
+
+// dropVerboseFields removes managedFields and last-applied annotations
+// wherever they appear.
+func dropVerboseFields(v interface{}) string {
+	n := 0
+	walkMaps(v, func(m map[string]interface{}) {
+		meta, ok := m["metadata"].(map[string]interface{})
+		if !ok {
+			return
+		}
+		if _, ok := meta["managedFields"]; ok {
+			delete(meta, "managedFields")
+			n++
+		}
+		if ann, ok := meta["annotations"].(map[string]interface{}); ok {
+			if _, ok := ann[lastAppliedAnnotation]; ok {
+				delete(ann, lastAppliedAnnotation)
+				n++
+			}
+		}
+	})
+	if n == 0 {
+		return ""
+	}
+	return "removed managedFields and last-applied-configuration"
+}
+
+// keepEssentialFields reduces every Kubernetes object to its apiVersion,
+// kind, spec, status and identifying metadata.
+func keepEssentialFields(v interface{}) string {
+	n := 0
+	walkMaps(v, func(m map[string]interface{}) {
+		meta, ok := m["metadata"].(map[string]interface{})
+		if !ok || m["kind"] == nil {
+			return
+		}
+		for k := range m {
+			if !essentialFields[k] {
+				delete(m, k)
+				n++
+			}
+		}
+		for k := range meta {
+			if !essentialMetadata[k] {
+				delete(meta, k)
+				n++
+			}
+		}
+	})
+	if n == 0 {
+		return ""
+	}
+	return "kept only kind, identifying metadata, spec and status"
+}
+
+// shortenLists keeps the first max items of every longer list, such as env
+// vars or volumes, replacing the rest with a count. v itself may be a list,
+// so the shortened value is returned.
+func shortenLists(v interface{}, max int) (interface{}, string) {
+	n := 0
+	var walk func(v interface{}) interface{}
+	walk = func(v interface{}) interface{} {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for k, child := range t {
+				t[k] = walk(child)
+			}
+		case []interface{}:
+			for i, child := range t {
+				t[i] = walk(child)
+			}
+			if len(t) > max {
+				n++
+				return append(t[:max:max], fmt.Sprintf("... %d more items omitted", len(t)-max))
+			}
+		}
+		return v
+	}
+	v = walk(v)
+	if n == 0 {
+		return v, ""
+	}
+	return v, fmt.Sprintf("cut %s to %d items", plural(n, "list"), max)
+}
+
+// shortenStrings cuts every string value longer than max bytes, such as
+// embedded config files or certificates.
+func shortenStrings(v interface{}, max int) string {
+	n := 0
+	cut := func(s string) string {
+		keep := prefix(s, max)
+		n++
+		return fmt.Sprintf("%s... (%d more bytes)", keep, len(s)-len(keep))
+	}
+	var walk func(v interface{}) interface{}
+	walk = func(v interface{}) interface{} {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for k, child := range t {
+				t[k] = walk(child)
+			}
+		case []interface{}:
+			for i, child := range t {
+				t[i] = walk(child)
+			}
+		case string:
+			if len(t) > max {
+				return cut(t)
+			}
+		}
+		return v
+	}
+	walk(v)
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf("cut %s to %d bytes", plural(n, "string"), max)
+}
+
+// prefix returns at most n bytes of s without splitting a character.
+func prefix(s string, n int) string {
+	if n >= len(s) {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// walkMaps calls fn for every object in v, parents before children.
+func walkMaps(v interface{}, fn func(map[string]interface{})) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		fn(t)
+		for _, child := range t {
+			walkMaps(child, fn)
+		}
+	case []interface{}:
+		for _, child := range t {
+			walkMaps(child, fn)
+		}
+	}
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
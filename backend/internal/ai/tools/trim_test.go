@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTrimResult_FitsUnchanged(t *testing.T) {
+	if got := trimResult(`{"kind":"Pod"}`, 100); got != `{"kind":"Pod"}` {
+		t.Errorf("expected a small result to be returned as is, got %q", got)
+	}
+}
+
+func TestTrimResult_DropsVerboseFields(t *testing.T) {
+	obj := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":          "web-0",
+			"managedFields": []interface{}{strings.Repeat("m", 2000)},
+			"annotations": map[string]interface{}{
+				lastAppliedAnnotation: strings.Repeat("a", 2000),
+				"team":                "shop",
+			},
+		},
+		"spec": map[string]interface{}{"nodeName": "node-1"},
+	}
+	content := encodeResult(obj)
+
+	got := trimResult(content, 1000)
+	if len(got) > 1000 {
+		t.Fatalf("result is %d bytes, over the limit", len(got))
+	}
+	body, note, _ := strings.Cut(got, "\n[result trimmed")
+	var out map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &out); err != nil {
+		t.Fatalf("trimmed result is not valid JSON: %v\n%s", err, got)
+	}
+	meta := out["metadata"].(map[string]interface{})
+	if meta["managedFields"] != nil || meta["annotations"].(map[string]interface{})["team"] != "shop" ||
+		out["spec"].(map[string]interface{})["nodeName"] != "node-1" {
+		t.Errorf("unexpected trimmed object %v", out)
+	}
+	if !strings.Contains(note, "removed managedFields") || !strings.Contains(note, fmt.Sprintf("from %d bytes", len(content))) {
+		t.Errorf("expected the note to say what was removed, got %q", note)
+	}
+}
+
+func TestTrimResult_ShortensListsWithHeader(t *testing.T) {
+	var env []interface{}
+	for i := 0; i < 200; i++ {
+		env = append(env, map[string]interface{}{"name": fmt.Sprintf("VAR_%d", i), "value": "x"})
+	}
+	content := "Found 1 pods:\n" + encodeResult([]interface{}{
+		map[string]interface{}{"kind": "Pod", "metadata": map[string]interface{}{"name": "web-0"}, "spec": map[string]interface{}{"env": env}},
+	})
+
+	got := trimResult(content, 4000)
+	if len(got) > 4000 || !strings.HasPrefix(got, "Found 1 pods:\n") {
+		t.Fatalf("unexpected result %q", got)
+	}
+	body, note, _ := strings.Cut(strings.TrimPrefix(got, "Found 1 pods:\n"), "\n[result trimmed")
+	var out []map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &out); err != nil {
+		t.Fatalf("trimmed result is not valid JSON: %v", err)
+	}
+	kept := out[0]["spec"].(map[string]interface{})["env"].([]interface{})
+	if len(kept) != 21 || kept[20] != "... 180 more items omitted" {
+		t.Errorf("expected the env list cut to 20 items, got %d: %v", len(kept), kept[len(kept)-1])
+	}
+	if !strings.Contains(note, "cut 1 list to 20 items") {
+		t.Errorf("unexpected note %q", note)
+	}
+}
+
+func TestTrimResult_TopLevelList(t *testing.T) {
+	var items []interface{}
+	for i := 0; i < 500; i++ {
+		items = append(items, map[string]interface{}{"name": fmt.Sprintf("pod-%d", i)})
+	}
+	got := trimResult(encodeResult(items), 2000)
+	body, _, _ := strings.Cut(got, "\n[result trimmed")
+	var out []interface{}
+	if err := json.Unmarshal([]byte(body), &out); err != nil || len(out) > 21 || len(got) > 2000 {
+		t.Errorf("expected a shortened list, got %d items (%v)", len(out), err)
+	}
+}
+
+func TestTrimResult_PlainText(t *testing.T) {
+	content := strings.Repeat("line of text\n", 100)
+	got := trimResult(content, 300)
+	if len(got) > 300 || !strings.HasPrefix(got, "line of text\n") {
+		t.Fatalf("unexpected result %q", got)
+	}
+	if !strings.HasSuffix(got, fmt.Sprintf("of %d bytes; ask about a specific object or narrow the query to see the rest]", len(content))) {
+		t.Errorf("expected a truncation note, got %q", got)
+	}
+	if cut := strings.Split(got, "\n[truncated")[0]; strings.HasSuffix(cut, "line of") {
+		t.Errorf("expected the cut on a line boundary, got %q", cut)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	if got := prefix("héllo", 2); got != "h" {
+		t.Errorf("expected the cut before a multi-byte character, got %q", got)
+	}
+	if got := prefix("abc", 10); got != "abc" {
+		t.Errorf("got %q", got)
+	}
+}
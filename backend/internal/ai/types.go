@@ -2,6 +2,7 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"io"
 
 	"github.com/darkden-lab/argus/backend/internal/ai/tools"
@@ -92,6 +93,26 @@ type LLMProvider interface {
 	Name() string
 }
 
+// ModelInfo describes a model offered by a provider.
+type ModelInfo struct {
+	// ID is the model name to put in AIConfig.Model.
+	ID string `json:"id"`
+	// Description is a short human-readable hint, e.g. the parameter size.
+	Description string `json:"description,omitempty"`
+}
+
+// ModelLister is implemented by providers that can list the models they
+// offer. Providers whose API has no listing endpoint do not implement it.
+type ModelLister interface {
+	// ListModels returns the available models, sorted by ID.
+	ListModels(ctx context.Context) ([]ModelInfo, error)
+}
+
+// ErrModelListingUnsupported is returned by ListModels when the endpoint a
+// provider is configured with cannot list models, e.g. an OpenAI-compatible
+// gateway without /models.
+var ErrModelListingUnsupported = errors.New("provider does not support listing models")
+
 // StreamReader reads streaming deltas from an LLM response.
 type StreamReader interface {
 	// Next returns the next delta. Returns io.EOF when the stream is complete.
@@ -8,27 +8,39 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Actor types distinguish entries recorded for users from those recorded
+// for service account tokens.
+const (
+	ActorUser           = "user"
+	ActorServiceAccount = "service_account"
+)
+
 // Entry represents a single audit log entry.
 type Entry struct {
-	ID        string          `json:"id"`
-	UserID    *string         `json:"user_id"`
-	Username  *string         `json:"username"`
-	ClusterID *string         `json:"cluster_id"`
-	Action    string          `json:"action"`
-	Resource  string          `json:"resource"`
-	Details   json.RawMessage `json:"details"`
-	Timestamp time.Time       `json:"timestamp"`
+	ID                 string          `json:"id"`
+	ActorType          string          `json:"actor_type"`
+	UserID             *string         `json:"user_id"`
+	Username           *string         `json:"username"`
+	ServiceAccountID   *string         `json:"service_account_id,omitempty"`
+	ServiceAccountName *string         `json:"service_account_name,omitempty"`
+	ClusterID          *string         `json:"cluster_id"`
+	Action             string          `json:"action"`
+	Resource           string          `json:"resource"`
+	Details            json.RawMessage `json:"details"`
+	Timestamp          time.Time       `json:"timestamp"`
 }
 
 // ListParams holds the query filters for listing audit entries.
 type ListParams struct {
-	UserID    string
-	ClusterID string
-	Action    string
-	FromDate  string
-	ToDate    string
-	Limit     int
-	Offset    int
+	UserID           string
+	ServiceAccountID string
+	ActorType        string // ActorUser or ActorServiceAccount; empty means both
+	ClusterID        string
+	Action           string
+	FromDate         string
+	ToDate           string
+	Limit            int
+	Offset           int
 }
 
 // Store provides CRUD operations for the audit_log table.
@@ -41,14 +53,15 @@ func NewStore(pool *pgxpool.Pool) *Store {
 	return &Store{pool: pool}
 }
 
-// Insert records a new audit log entry.
-func (s *Store) Insert(ctx context.Context, userID, clusterID *string, action, resource string, details json.RawMessage) error {
+// Insert records a new audit log entry. At most one of userID and
+// serviceAccountID identifies the actor.
+func (s *Store) Insert(ctx context.Context, userID, serviceAccountID, clusterID *string, action, resource string, details json.RawMessage) error {
 	if details == nil {
 		details = json.RawMessage("{}")
 	}
 	_, err := s.pool.Exec(ctx,
-		`INSERT INTO audit_log (user_id, cluster_id, action, resource, details) VALUES ($1, $2, $3, $4, $5)`,
-		userID, clusterID, action, resource, details,
+		`INSERT INTO audit_log (user_id, service_account_id, cluster_id, action, resource, details) VALUES ($1, $2, $3, $4, $5, $6)`,
+		userID, serviceAccountID, clusterID, action, resource, details,
 	)
 	return err
 }
@@ -60,7 +73,7 @@ func (s *Store) List(ctx context.Context, params ListParams) ([]Entry, int, erro
 	}
 
 	// Build dynamic query
-	query := `SELECT a.id, a.user_id, u.display_name, a.cluster_id, a.action, a.resource, a.details, a.timestamp FROM audit_log a LEFT JOIN users u ON a.user_id = u.id WHERE 1=1`
+	query := `SELECT a.id, a.user_id, u.display_name, a.service_account_id, sa.name, a.cluster_id, a.action, a.resource, a.details, a.timestamp FROM audit_log a LEFT JOIN users u ON a.user_id = u.id LEFT JOIN service_account_tokens sa ON a.service_account_id = sa.id WHERE 1=1`
 	countQuery := `SELECT COUNT(*) FROM audit_log a WHERE 1=1`
 	args := []interface{}{}
 	argIdx := 1
@@ -71,6 +84,20 @@ func (s *Store) List(ctx context.Context, params ListParams) ([]Entry, int, erro
 		args = append(args, params.UserID)
 		argIdx++
 	}
+	if params.ServiceAccountID != "" {
+		query += ` AND a.service_account_id = $` + itoa(argIdx)
+		countQuery += ` AND a.service_account_id = $` + itoa(argIdx)
+		args = append(args, params.ServiceAccountID)
+		argIdx++
+	}
+	switch params.ActorType {
+	case ActorServiceAccount:
+		query += ` AND a.service_account_id IS NOT NULL`
+		countQuery += ` AND a.service_account_id IS NOT NULL`
+	case ActorUser:
+		query += ` AND a.service_account_id IS NULL`
+		countQuery += ` AND a.service_account_id IS NULL`
+	}
 	if params.ClusterID != "" {
 		query += ` AND a.cluster_id = $` + itoa(argIdx)
 		countQuery += ` AND a.cluster_id = $` + itoa(argIdx)
@@ -119,9 +146,13 @@ func (s *Store) List(ctx context.Context, params ListParams) ([]Entry, int, erro
 	var entries []Entry
 	for rows.Next() {
 		var e Entry
-		if err := rows.Scan(&e.ID, &e.UserID, &e.Username, &e.ClusterID, &e.Action, &e.Resource, &e.Details, &e.Timestamp); err != nil {
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Username, &e.ServiceAccountID, &e.ServiceAccountName, &e.ClusterID, &e.Action, &e.Resource, &e.Details, &e.Timestamp); err != nil {
 			return nil, 0, err
 		}
+		e.ActorType = ActorUser
+		if e.ServiceAccountID != nil {
+			e.ActorType = ActorServiceAccount
+		}
 		entries = append(entries, e)
 	}
 
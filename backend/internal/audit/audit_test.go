@@ -1,12 +1,15 @@
 package audit
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
 )
 
 func TestItoaSingleDigit(t *testing.T) {
@@ -159,6 +162,46 @@ func TestEntry_NilOptionalFields(t *testing.T) {
 	}
 }
 
+func TestEntry_ServiceAccountActor(t *testing.T) {
+	saID := "sa-1"
+	saName := "ci-deployer"
+	entry := Entry{
+		ID:                 "entry-3",
+		ActorType:          ActorServiceAccount,
+		ServiceAccountID:   &saID,
+		ServiceAccountName: &saName,
+		Action:             "post /api/clusters",
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if decoded["actor_type"] != ActorServiceAccount || decoded["service_account_name"] != "ci-deployer" {
+		t.Errorf("expected service account actor, got %v", decoded)
+	}
+	if decoded["user_id"] != nil {
+		t.Errorf("expected no user_id, got %v", decoded["user_id"])
+	}
+}
+
+func TestHandlers_ListRejectsUnknownActorType(t *testing.T) {
+	h := NewHandlers(NewStore(nil), nil)
+
+	req := httptest.NewRequest("GET", "/api/audit-log?actor_type=robot", nil)
+	rec := httptest.NewRecorder()
+	h.List(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
+
 func TestHandlers_RegisterRoutes(t *testing.T) {
 	store := NewStore(nil)
 	h := NewHandlers(store, nil)
@@ -248,6 +291,66 @@ func TestMiddleware_SkipsGetMethods(t *testing.T) {
 	}
 }
 
+// recordedActions collects the actions passed to Record.
+type recordedActions []string
+
+func (r *recordedActions) Record(ctx context.Context, userID, serviceAccountID, clusterID *string, action, resource string, details json.RawMessage) {
+	*r = append(*r, action)
+}
+
+// recordedDetails collects the details passed to Record.
+type recordedDetails []json.RawMessage
+
+func (r *recordedDetails) Record(ctx context.Context, userID, serviceAccountID, clusterID *string, action, resource string, details json.RawMessage) {
+	*r = append(*r, details)
+}
+
+func TestMiddleware_RecordsNamespace(t *testing.T) {
+	var recorded recordedDetails
+	handler := Middleware(&recorded)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/api/clusters/c1/resources/apps/v1/deployments/web?namespace=shop", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/clusters", nil))
+
+	if len(recorded) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(recorded))
+	}
+	var details map[string]interface{}
+	if err := json.Unmarshal(recorded[0], &details); err != nil || details["namespace"] != "shop" {
+		t.Errorf("expected the namespace in %s", recorded[0])
+	}
+	details = nil
+	if err := json.Unmarshal(recorded[1], &details); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := details["namespace"]; ok {
+		t.Errorf("expected no namespace in %s", recorded[1])
+	}
+}
+
+func TestMiddleware_RecordsWriteMethods(t *testing.T) {
+	var recorded recordedActions
+	handler := Middleware(&recorded)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/api/test", nil))
+	}
+
+	want := []string{"post /api/test", "put /api/test", "patch /api/test", "delete /api/test"}
+	if len(recorded) != len(want) {
+		t.Fatalf("expected %v, got %v", want, recorded)
+	}
+	for i := range want {
+		if recorded[i] != want[i] {
+			t.Errorf("expected %q, got %q", want[i], recorded[i])
+		}
+	}
+}
+
 func TestMiddleware_SkipsFailedWrites(t *testing.T) {
 	store := NewStore(nil)
 	mw := Middleware(store)
@@ -267,3 +370,25 @@ func TestMiddleware_SkipsFailedWrites(t *testing.T) {
 		t.Errorf("expected status 400, got %d", rec.Code)
 	}
 }
+
+func TestActorFromContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   string
+		wantUser bool
+		wantSA   bool
+	}{
+		{"user", "user-1", true, false},
+		{"service account", auth.ServiceAccountPrincipalPrefix + "sa-1", false, true},
+		{"share session", auth.ShareLinkPrincipalPrefix + "link-1", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := auth.ContextWithClaims(context.Background(), &auth.Claims{UserID: tt.userID})
+			userID, saID := ActorFromContext(ctx)
+			if (userID != nil) != tt.wantUser || (saID != nil) != tt.wantSA {
+				t.Errorf("got user=%v service account=%v", userID, saID)
+			}
+		})
+	}
+}
@@ -44,14 +44,22 @@ func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
 		offset = 0
 	}
 
+	actorType := q.Get("actor_type")
+	if actorType != "" && actorType != ActorUser && actorType != ActorServiceAccount {
+		httputil.WriteError(w, http.StatusBadRequest, "actor_type must be user or service_account")
+		return
+	}
+
 	params := ListParams{
-		UserID:    q.Get("user_id"),
-		ClusterID: q.Get("cluster_id"),
-		Action:    q.Get("action"),
-		FromDate:  q.Get("from_date"),
-		ToDate:    q.Get("to_date"),
-		Limit:     limit,
-		Offset:    offset,
+		UserID:           q.Get("user_id"),
+		ServiceAccountID: q.Get("service_account_id"),
+		ActorType:        actorType,
+		ClusterID:        q.Get("cluster_id"),
+		Action:           q.Get("action"),
+		FromDate:         q.Get("from_date"),
+		ToDate:           q.Get("to_date"),
+		Limit:            limit,
+		Offset:           offset,
 	}
 
 	entries, total, err := h.store.List(r.Context(), params)
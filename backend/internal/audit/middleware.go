@@ -1,8 +1,8 @@
 package audit
 
 import (
+	"context"
 	"encoding/json"
-	"log"
 	"net/http"
 	"strings"
 
@@ -10,12 +10,13 @@ import (
 	"github.com/darkden-lab/argus/backend/internal/auth"
 )
 
-// Middleware records all write operations (POST, PUT, DELETE) to the audit_log table.
-func Middleware(store *Store) mux.MiddlewareFunc {
+// Middleware records all write operations (POST, PUT, PATCH, DELETE) to the audit_log
+// table through recorder: a *Store writes synchronously, a *Writer in the background.
+func Middleware(recorder Recorder) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Only audit write operations
-			if r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+			if r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodPatch && r.Method != http.MethodDelete {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -29,12 +30,10 @@ func Middleware(store *Store) mux.MiddlewareFunc {
 				return
 			}
 
-			// Extract user from context
-			var userID *string
-			claims, ok := auth.ClaimsFromContext(r.Context())
-			if ok && claims.UserID != "" {
-				uid := claims.UserID
-				userID = &uid
+			userID, serviceAccountID := ActorFromContext(r.Context())
+			actor := ActorUser
+			if serviceAccountID != nil {
+				actor = ActorServiceAccount
 			}
 
 			// Extract cluster ID from path if present
@@ -55,20 +54,54 @@ func Middleware(store *Store) mux.MiddlewareFunc {
 			// Build resource from URL path
 			resource := r.URL.Path
 
-			details, _ := json.Marshal(map[string]interface{}{
+			detailMap := map[string]interface{}{
 				"method":      r.Method,
 				"path":        r.URL.Path,
 				"status":      rec.status,
 				"remote_addr": r.RemoteAddr,
-			})
-
-			if err := store.Insert(r.Context(), userID, clusterID, action, resource, details); err != nil {
-				log.Printf("audit: failed to log entry: %v", err)
+				"actor":       actor,
+			}
+			// Resource endpoints take the namespace as a query parameter,
+			// which the path alone would lose.
+			if ns := r.URL.Query().Get("namespace"); ns != "" {
+				detailMap["namespace"] = ns
 			}
+			details, _ := json.Marshal(detailMap)
+
+			recorder.Record(r.Context(), userID, serviceAccountID, clusterID, action, resource, details)
 		})
 	}
 }
 
+// ActorFromContext returns who performed a request, for Store.Insert: the
+// user ID, or the service account ID when a service account token was used.
+// Both are nil for unauthenticated requests.
+func ActorFromContext(ctx context.Context) (userID, serviceAccountID *string) {
+	claims, ok := auth.ClaimsFromContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	if saID, isServiceAccount := claims.ServiceAccountID(); isServiceAccount {
+		return nil, &saID
+	}
+	if _, isShare := claims.ShareLinkID(); isShare {
+		// Share sessions are read-only and have no user to attribute to.
+		return nil, nil
+	}
+	if claims.UserID != "" {
+		uid := claims.UserID
+		return &uid, nil
+	}
+	return nil, nil
+}
+
+// DetachedContext returns the context to insert the audit entry of a request
+// with. It keeps ctx's values but not its cancellation: the request may have
+// been cancelled, and the audit entry must still land.
+func DetachedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+}
+
 // statusRecorder wraps http.ResponseWriter to capture the status code.
 type statusRecorder struct {
 	http.ResponseWriter
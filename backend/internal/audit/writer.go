@@ -0,0 +1,195 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/darkden-lab/argus/backend/internal/metrics"
+)
+
+// Overflow policies for a full Writer queue.
+const (
+	// OverflowDrop discards the entry and counts it as dropped.
+	OverflowDrop = "drop"
+	// OverflowBlock waits up to the block timeout for room, then drops.
+	OverflowBlock = "block"
+)
+
+// writeTimeout bounds a single background insert.
+const writeTimeout = 5 * time.Second
+
+// Recorder records audit entries. The middleware uses it so requests need not
+// wait for the database when a Writer is in front of the Store.
+type Recorder interface {
+	Record(ctx context.Context, userID, serviceAccountID, clusterID *string, action, resource string, details json.RawMessage)
+}
+
+// Record inserts the entry synchronously and logs a failure.
+func (s *Store) Record(ctx context.Context, userID, serviceAccountID, clusterID *string, action, resource string, details json.RawMessage) {
+	if err := s.Insert(ctx, userID, serviceAccountID, clusterID, action, resource, details); err != nil {
+		log.Printf("audit: failed to log entry: %v", err)
+	}
+}
+
+type inserter interface {
+	Insert(ctx context.Context, userID, serviceAccountID, clusterID *string, action, resource string, details json.RawMessage) error
+}
+
+type pendingEntry struct {
+	userID, serviceAccountID, clusterID *string
+	action, resource                    string
+	details                             json.RawMessage
+}
+
+// WriterStats is a snapshot of a Writer's counters.
+type WriterStats struct {
+	Queued   int    `json:"queued"`
+	Capacity int    `json:"capacity"`
+	Written  uint64 `json:"written"`
+	Failed   uint64 `json:"failed"`
+	Dropped  uint64 `json:"dropped"`
+}
+
+// Writer queues audit entries and inserts them from a background goroutine,
+// so a slow or unavailable database delays the audit log rather than the
+// requests being audited. When the queue is full the overflow policy decides
+// whether an entry is dropped at once or after waiting for room.
+type Writer struct {
+	store        inserter
+	queue        chan pendingEntry
+	overflow     string
+	blockTimeout time.Duration
+
+	written atomic.Uint64
+	failed  atomic.Uint64
+	dropped atomic.Uint64
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewWriter creates a Writer in front of store holding up to queueSize
+// entries. overflow is OverflowDrop or OverflowBlock; blockTimeout only
+// applies to the latter. Call Start before recording.
+func NewWriter(store *Store, queueSize int, overflow string, blockTimeout time.Duration) *Writer {
+	return newWriter(store, queueSize, overflow, blockTimeout)
+}
+
+func newWriter(store inserter, queueSize int, overflow string, blockTimeout time.Duration) *Writer {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	return &Writer{
+		store:        store,
+		queue:        make(chan pendingEntry, queueSize),
+		overflow:     overflow,
+		blockTimeout: blockTimeout,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+// Start launches the background writer.
+func (w *Writer) Start() {
+	w.startOnce.Do(func() { go w.run() })
+}
+
+// Close stops the background writer after it has written the queued entries,
+// or when ctx is done, whichever comes first. Entries recorded after Close
+// are not written.
+func (w *Writer) Close(ctx context.Context) {
+	w.stopOnce.Do(func() { close(w.stop) })
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		log.Printf("audit: shutting down with %d entries unwritten", len(w.queue))
+	}
+}
+
+// Record queues an entry, applying the overflow policy when the queue is full.
+func (w *Writer) Record(ctx context.Context, userID, serviceAccountID, clusterID *string, action, resource string, details json.RawMessage) {
+	entry := pendingEntry{userID, serviceAccountID, clusterID, action, resource, details}
+	select {
+	case w.queue <- entry:
+		return
+	default:
+	}
+
+	if w.overflow == OverflowBlock && w.blockTimeout > 0 {
+		timer := time.NewTimer(w.blockTimeout)
+		defer timer.Stop()
+		select {
+		case w.queue <- entry:
+			return
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+
+	// Log the first drop and then every hundredth, to avoid flooding the log
+	// while the database is down.
+	if n := w.dropped.Add(1); n == 1 || n%100 == 0 {
+		log.Printf("audit: queue full, %d entries dropped so far", n)
+	}
+}
+
+// Stats returns the current queue length and the writer's counters.
+func (w *Writer) Stats() WriterStats {
+	return WriterStats{
+		Queued:   len(w.queue),
+		Capacity: cap(w.queue),
+		Written:  w.written.Load(),
+		Failed:   w.failed.Load(),
+		Dropped:  w.dropped.Load(),
+	}
+}
+
+// RegisterMetrics exposes the writer's counters and queue length.
+func (w *Writer) RegisterMetrics(reg *metrics.Registry) {
+	reg.RegisterValue("argus_audit_entries_written_total", "Audit entries written to the database.", metrics.Counter,
+		func() float64 { return float64(w.written.Load()) })
+	reg.RegisterValue("argus_audit_entries_failed_total", "Audit entries whose database insert failed.", metrics.Counter,
+		func() float64 { return float64(w.failed.Load()) })
+	reg.RegisterValue("argus_audit_entries_dropped_total", "Audit entries dropped because the queue was full.", metrics.Counter,
+		func() float64 { return float64(w.dropped.Load()) })
+	reg.RegisterValue("argus_audit_queue_length", "Audit entries waiting to be written.", metrics.Gauge,
+		func() float64 { return float64(len(w.queue)) })
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+	for {
+		select {
+		case entry := <-w.queue:
+			w.write(entry)
+		case <-w.stop:
+			for {
+				select {
+				case entry := <-w.queue:
+					w.write(entry)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *Writer) write(e pendingEntry) {
+	// The request that produced the entry may be long gone, so its context
+	// is not used.
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	if err := w.store.Insert(ctx, e.userID, e.serviceAccountID, e.clusterID, e.action, e.resource, e.details); err != nil {
+		w.failed.Add(1)
+		log.Printf("audit: failed to log entry: %v", err)
+		return
+	}
+	w.written.Add(1)
+}
@@ -0,0 +1,131 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/darkden-lab/argus/backend/internal/metrics"
+)
+
+type fakeInserter struct {
+	mu      sync.Mutex
+	actions []string
+	release chan struct{} // when set, Insert waits for it
+	err     error
+}
+
+func (f *fakeInserter) Insert(_ context.Context, _, _, _ *string, action, _ string, _ json.RawMessage) error {
+	if f.release != nil {
+		<-f.release
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.actions = append(f.actions, action)
+	return f.err
+}
+
+func (f *fakeInserter) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.actions)
+}
+
+func TestWriter_WritesInBackgroundAndDrainsOnClose(t *testing.T) {
+	store := &fakeInserter{}
+	w := newWriter(store, 10, OverflowDrop, 0)
+	w.Start()
+	for i := 0; i < 5; i++ {
+		w.Record(context.Background(), nil, nil, nil, "post /x", "/x", nil)
+	}
+	w.Close(context.Background())
+
+	if store.count() != 5 {
+		t.Fatalf("expected 5 entries written, got %d", store.count())
+	}
+	if s := w.Stats(); s.Written != 5 || s.Dropped != 0 || s.Queued != 0 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+}
+
+func TestWriter_DropsWhenFull(t *testing.T) {
+	store := &fakeInserter{}
+	// Not started, so nothing leaves the queue.
+	w := newWriter(store, 2, OverflowDrop, 0)
+	for i := 0; i < 5; i++ {
+		w.Record(context.Background(), nil, nil, nil, "post /x", "/x", nil)
+	}
+	if s := w.Stats(); s.Queued != 2 || s.Dropped != 3 {
+		t.Errorf("expected 2 queued and 3 dropped, got %+v", s)
+	}
+}
+
+func TestWriter_BlockWaitsForRoom(t *testing.T) {
+	store := &fakeInserter{release: make(chan struct{})}
+	w := newWriter(store, 1, OverflowBlock, time.Second)
+	w.Start()
+	// The first entry is taken by the writer and blocks in Insert; the second fills the queue.
+	w.Record(context.Background(), nil, nil, nil, "post /1", "/1", nil)
+	deadline := time.Now().Add(time.Second)
+	for len(w.queue) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	w.Record(context.Background(), nil, nil, nil, "post /2", "/2", nil)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(store.release)
+	}()
+	w.Record(context.Background(), nil, nil, nil, "post /3", "/3", nil)
+	w.Close(context.Background())
+
+	if s := w.Stats(); s.Dropped != 0 || s.Written != 3 {
+		t.Errorf("expected all 3 entries written, got %+v", s)
+	}
+}
+
+func TestWriter_BlockTimesOut(t *testing.T) {
+	w := newWriter(&fakeInserter{}, 1, OverflowBlock, 10*time.Millisecond)
+	w.Record(context.Background(), nil, nil, nil, "post /1", "/1", nil)
+
+	start := time.Now()
+	w.Record(context.Background(), nil, nil, nil, "post /2", "/2", nil)
+	if time.Since(start) < 10*time.Millisecond {
+		t.Error("expected Record to wait for the block timeout")
+	}
+	if s := w.Stats(); s.Dropped != 1 {
+		t.Errorf("expected 1 dropped entry, got %+v", s)
+	}
+}
+
+func TestWriter_CountsFailures(t *testing.T) {
+	w := newWriter(&fakeInserter{err: errors.New("db down")}, 4, OverflowDrop, 0)
+	w.Start()
+	w.Record(context.Background(), nil, nil, nil, "post /x", "/x", nil)
+	w.Close(context.Background())
+	if s := w.Stats(); s.Failed != 1 || s.Written != 0 {
+		t.Errorf("expected 1 failed entry, got %+v", s)
+	}
+}
+
+func TestWriter_RegisterMetrics(t *testing.T) {
+	w := newWriter(&fakeInserter{}, 1, OverflowDrop, 0)
+	w.Record(context.Background(), nil, nil, nil, "post /1", "/1", nil)
+	w.Record(context.Background(), nil, nil, nil, "post /2", "/2", nil)
+
+	reg := metrics.NewRegistry()
+	w.RegisterMetrics(reg)
+	var sb strings.Builder
+	if err := reg.Write(&sb); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"argus_audit_entries_dropped_total 1\n", "argus_audit_queue_length 1\n"} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, sb.String())
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package auth
 
-import "context"
+import (
+	"context"
+	"log"
+)
 
 type contextKey string
 
@@ -14,3 +17,20 @@ func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
 	claims, ok := ctx.Value(claimsKey).(*Claims)
 	return claims, ok
 }
+
+// PermissionWarmer preloads a user's permissions so the first authorized
+// requests after sign-in are served from cache. Implemented by *rbac.Engine.
+type PermissionWarmer interface {
+	Warm(ctx context.Context, userID string) error
+}
+
+// warmPermissions preloads userID's permissions when a warmer is set. A
+// failure only costs the cache hit, so it is logged and otherwise ignored.
+func warmPermissions(ctx context.Context, warmer PermissionWarmer, userID string) {
+	if warmer == nil || userID == "" {
+		return
+	}
+	if err := warmer.Warm(ctx, userID); err != nil {
+		log.Printf("auth: failed to warm permissions for user %s: %v", userID, err)
+	}
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// ClusterCredentialTokenPrefix marks bearer tokens minted for kubectl's
+	// exec credential plugin, so they can be told apart from access tokens
+	// without parsing them. Endpoints that only take access tokens refuse
+	// them as malformed.
+	ClusterCredentialTokenPrefix = "argus_kc_"
+
+	// TokenTypeClusterCredential is the Claims.TokenType of cluster
+	// credentials. They are only accepted by the Kubernetes proxy of the
+	// cluster in Claims.ClusterID.
+	TokenTypeClusterCredential = "cluster_credential"
+)
+
+// ClusterCredentialDuration is how long a cluster credential is valid.
+// kubectl asks the exec plugin for a new one once it expires.
+const ClusterCredentialDuration = 10 * time.Minute
+
+// GenerateClusterCredential issues a short-lived token that authenticates
+// userID to the Kubernetes proxy of clusterID only. It returns the token and
+// when it expires.
+func (j *JWTService) GenerateClusterCredential(userID, email, clusterID string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ClusterCredentialDuration)
+	claims := Claims{
+		UserID:    userID,
+		Email:     email,
+		TokenType: TokenTypeClusterCredential,
+		ClusterID: clusterID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secretKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return ClusterCredentialTokenPrefix + token, expiresAt, nil
+}
+
+// ValidateClusterCredential validates a token issued by
+// GenerateClusterCredential. The caller must still confine the request to
+// the cluster in the returned claims.
+func (j *JWTService) ValidateClusterCredential(tokenString string) (*Claims, error) {
+	if !strings.HasPrefix(tokenString, ClusterCredentialTokenPrefix) {
+		return nil, fmt.Errorf("invalid token: expected cluster credential")
+	}
+	claims, err := j.parseClaims(strings.TrimPrefix(tokenString, ClusterCredentialTokenPrefix))
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != TokenTypeClusterCredential || claims.ClusterID == "" {
+		return nil, fmt.Errorf("invalid token: expected cluster credential")
+	}
+	return claims, nil
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+)
+
+// How the OIDC callback hands the issued tokens to the frontend.
+const (
+	// TokenDeliveryFragment redirects to the frontend with the tokens in the
+	// URL fragment, for SPA setups that keep them in browser storage.
+	TokenDeliveryFragment = "fragment"
+	// TokenDeliveryCookie sets the tokens as HttpOnly session cookies and
+	// redirects to a URL without them.
+	TokenDeliveryCookie = "cookie"
+)
+
+// Session cookie names. The access token cookie has the name the frontend
+// checks before rendering signed-in pages; the refresh token cookie is only
+// sent to the /api/auth endpoints.
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+
+	refreshCookiePath = "/api/auth"
+)
+
+// CSRFHeader must be present on state-changing requests authenticated by
+// the session cookie. Browsers only send custom headers cross-origin after
+// a CORS preflight, which the allowed origins decide.
+const CSRFHeader = "X-Requested-With"
+
+// CookieConfig holds the attributes of the session cookies.
+type CookieConfig struct {
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// ParseSameSite returns the SameSite mode named "strict" or "lax"; anything
+// else is lax.
+func ParseSameSite(mode string) http.SameSite {
+	if mode == "strict" {
+		return http.SameSiteStrictMode
+	}
+	return http.SameSiteLaxMode
+}
+
+// setSessionCookies sets the access and refresh token cookies. Both last as
+// long as the refresh token, so the frontend still sees a session while an
+// expired access token is being refreshed.
+func (c CookieConfig) setSessionCookies(w http.ResponseWriter, accessToken, refreshToken string, maxAge time.Duration) {
+	http.SetCookie(w, c.cookie(AccessTokenCookie, "/", accessToken, maxAge))
+	if refreshToken != "" {
+		http.SetCookie(w, c.cookie(RefreshTokenCookie, refreshCookiePath, refreshToken, maxAge))
+	}
+}
+
+// clearSessionCookies expires both session cookies.
+func (c CookieConfig) clearSessionCookies(w http.ResponseWriter) {
+	http.SetCookie(w, c.cookie(AccessTokenCookie, "/", "", -1))
+	http.SetCookie(w, c.cookie(RefreshTokenCookie, refreshCookiePath, "", -1))
+}
+
+// cookie builds an HttpOnly session cookie. A negative maxAge deletes it.
+func (c CookieConfig) cookie(name, path, value string, maxAge time.Duration) *http.Cookie {
+	seconds := int(maxAge / time.Second)
+	if maxAge < 0 {
+		seconds = -1
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   seconds,
+		HttpOnly: true,
+		Secure:   c.Secure,
+		SameSite: c.SameSite,
+	}
+}
+
+// AccessTokenFromCookie returns the access token of the session cookie, or
+// "" when the request has none.
+func AccessTokenFromCookie(r *http.Request) string {
+	if c, err := r.Cookie(AccessTokenCookie); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+// refreshTokenFromCookie returns the refresh token of the session cookie,
+// or "" when the request has none.
+func refreshTokenFromCookie(r *http.Request) string {
+	if c, err := r.Cookie(RefreshTokenCookie); err == nil {
+		return c.Value
+	}
+	return ""
+}
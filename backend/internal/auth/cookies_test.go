@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDeliverTokensCookie(t *testing.T) {
+	svc := &OIDCService{
+		jwt:         NewJWTService("test-secret"),
+		frontendURL: "https://argus.example.com",
+		delivery:    TokenDeliveryCookie,
+		cookies:     CookieConfig{Secure: true, SameSite: http.SameSiteStrictMode},
+	}
+	rec := httptest.NewRecorder()
+	svc.deliverTokens(rec, httptest.NewRequest("GET", "/api/auth/oidc/callback", nil), "access", "refresh")
+
+	if loc := rec.Header().Get("Location"); loc != "https://argus.example.com/auth/oidc/callback" {
+		t.Errorf("expected a redirect without tokens, got %q", loc)
+	}
+	cookies := map[string]*http.Cookie{}
+	for _, c := range rec.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	for name, want := range map[string]struct{ value, path string }{
+		AccessTokenCookie:  {"access", "/"},
+		RefreshTokenCookie: {"refresh", "/api/auth"},
+	} {
+		c := cookies[name]
+		if c == nil {
+			t.Fatalf("expected a %s cookie", name)
+		}
+		if c.Value != want.value || c.Path != want.path || !c.HttpOnly || !c.Secure ||
+			c.SameSite != http.SameSiteStrictMode || c.MaxAge != int(svc.jwt.refreshDuration.Seconds()) {
+			t.Errorf("unexpected %s cookie %+v", name, c)
+		}
+	}
+}
+
+func TestDeliverTokensFragment(t *testing.T) {
+	svc := &OIDCService{frontendURL: "https://argus.example.com"}
+	rec := httptest.NewRecorder()
+	svc.deliverTokens(rec, httptest.NewRequest("GET", "/api/auth/oidc/callback", nil), "access", "refresh")
+
+	if loc := rec.Header().Get("Location"); !strings.HasSuffix(loc, "#access_token=access&refresh_token=refresh") {
+		t.Errorf("expected tokens in the fragment, got %q", loc)
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Errorf("expected no cookies, got %v", rec.Result().Cookies())
+	}
+}
+
+func TestHandleRefreshFromCookie(t *testing.T) {
+	tests := []struct {
+		name     string
+		cookies  bool
+		csrf     bool
+		wantCode int
+	}{
+		// Without session cookies enabled the cookie is ignored.
+		{"cookies disabled", false, true, http.StatusBadRequest},
+		{"missing CSRF header", true, false, http.StatusForbidden},
+		// The cookie's token is used, and is invalid.
+		{"with CSRF header", true, true, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandlers(&AuthService{jwt: NewJWTService("test-secret")})
+			if tt.cookies {
+				h.SetSessionCookies(CookieConfig{})
+			}
+			req := httptest.NewRequest("POST", "/api/auth/refresh", bytes.NewBufferString("{}"))
+			req.AddCookie(&http.Cookie{Name: RefreshTokenCookie, Value: "not-a-token"})
+			if tt.csrf {
+				req.Header.Set(CSRFHeader, "XMLHttpRequest")
+			}
+			rec := httptest.NewRecorder()
+			h.handleRefresh(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
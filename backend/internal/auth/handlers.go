@@ -2,6 +2,7 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -10,11 +11,56 @@ import (
 )
 
 type Handlers struct {
-	service *AuthService
+	service     *AuthService
+	warmer      PermissionWarmer
+	mfa         mfaBackend
+	mfaAttempts *mfaAttemptTracker
+	cookies     *CookieConfig
 }
 
 func NewHandlers(service *AuthService) *Handlers {
-	return &Handlers{service: service}
+	return &Handlers{service: service, mfaAttempts: newMFAAttemptTracker()}
+}
+
+// SetPermissionWarmer sets the warmer that preloads a user's permissions
+// after a successful login or token refresh.
+func (h *Handlers) SetPermissionWarmer(warmer PermissionWarmer) {
+	h.warmer = warmer
+}
+
+// SetSessionCookies lets refresh and logout requests carry the refresh
+// token in the session cookie that cookie-mode OIDC sign-in sets, instead
+// of the request body.
+func (h *Handlers) SetSessionCookies(cfg CookieConfig) {
+	h.cookies = &cfg
+}
+
+// refreshTokenCookie returns the refresh token of the session cookie, when
+// session cookies are enabled. ok is false when the cookie is present but
+// the request lacks the CSRF header, in which case an error was written.
+func (h *Handlers) refreshTokenCookie(w http.ResponseWriter, r *http.Request) (token string, ok bool) {
+	if h.cookies == nil {
+		return "", true
+	}
+	token = refreshTokenFromCookie(r)
+	if token != "" && r.Header.Get(CSRFHeader) == "" {
+		httputil.WriteError(w, http.StatusForbidden, "missing "+CSRFHeader+" header")
+		return "", false
+	}
+	return token, true
+}
+
+// warmFromToken preloads the permissions of the user an access token was
+// just issued to.
+func (h *Handlers) warmFromToken(r *http.Request, accessToken string) {
+	if h.warmer == nil {
+		return
+	}
+	claims, err := h.service.jwt.ValidateToken(accessToken)
+	if err != nil {
+		return
+	}
+	warmPermissions(r.Context(), h.warmer, claims.UserID)
 }
 
 // RegisterRoutes registers public auth routes (no auth middleware required).
@@ -22,12 +68,20 @@ func (h *Handlers) RegisterRoutes(r *mux.Router) {
 	api := r.PathPrefix("/api/auth").Subrouter()
 	api.HandleFunc("/login", h.handleLogin).Methods("POST")
 	api.HandleFunc("/refresh", h.handleRefresh).Methods("POST")
+	api.HandleFunc("/login/mfa", h.handleLoginMFA).Methods("POST")
+	api.HandleFunc("/login/mfa/enroll", h.handleLoginMFAEnroll).Methods("POST")
+	api.HandleFunc("/login/mfa/enroll/confirm", h.handleLoginMFAEnrollConfirm).Methods("POST")
 }
 
 // RegisterProtectedRoutes registers auth routes that require authentication.
 func (h *Handlers) RegisterProtectedRoutes(r *mux.Router) {
 	r.HandleFunc("/api/auth/me", h.handleMe).Methods("GET")
 	r.HandleFunc("/api/auth/logout", h.handleLogout).Methods("POST")
+	r.HandleFunc("/api/auth/mfa", h.handleMFAStatus).Methods("GET")
+	r.HandleFunc("/api/auth/mfa/enroll", h.handleMFAEnroll).Methods("POST")
+	r.HandleFunc("/api/auth/mfa/enroll/confirm", h.handleMFAEnrollConfirm).Methods("POST")
+	r.HandleFunc("/api/auth/mfa/disable", h.handleMFADisable).Methods("POST")
+	r.HandleFunc("/api/auth/mfa/recovery-codes", h.handleMFARecoveryCodes).Methods("POST")
 }
 
 type loginRequest struct {
@@ -45,14 +99,10 @@ type logoutRequest struct {
 
 type authResponse struct {
 	User         *User  `json:"user,omitempty"`
-	AccessToken  string `json:"access_token"`
+	AccessToken  string `json:"access_token,omitempty"`
 	RefreshToken string `json:"refresh_token,omitempty"`
 }
 
-type errorResponse struct {
-	Error string `json:"error"`
-}
-
 func (h *Handlers) handleLogin(w http.ResponseWriter, r *http.Request) {
 	var req loginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -65,16 +115,12 @@ func (h *Handlers) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, refreshToken, err := h.service.Login(r.Context(), req.Email, req.Password)
+	userID, err := h.service.Authenticate(r.Context(), req.Email, req.Password)
 	if err != nil {
 		httputil.WriteError(w, http.StatusUnauthorized, "invalid credentials")
 		return
 	}
-
-	httputil.WriteJSON(w, http.StatusOK, authResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	})
+	h.completeLogin(w, r, userID, req.Email)
 }
 
 func (h *Handlers) handleRefresh(w http.ResponseWriter, r *http.Request) {
@@ -84,17 +130,37 @@ func (h *Handlers) handleRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	fromCookie := false
+	if req.RefreshToken == "" {
+		token, ok := h.refreshTokenCookie(w, r)
+		if !ok {
+			return
+		}
+		req.RefreshToken, fromCookie = token, token != ""
+	}
 	if req.RefreshToken == "" {
 		httputil.WriteError(w, http.StatusBadRequest, "refresh_token is required")
 		return
 	}
 
 	accessToken, err := h.service.RefreshToken(r.Context(), req.RefreshToken)
+	if errors.Is(err, ErrSessionIdle) {
+		httputil.WriteErrorCode(w, http.StatusUnauthorized, httputil.CodeSessionIdle, "session expired due to inactivity")
+		return
+	}
 	if err != nil {
 		httputil.WriteError(w, http.StatusUnauthorized, "invalid refresh token")
 		return
 	}
+	h.warmFromToken(r, accessToken)
 
+	// A refresh token from the cookie gets its access token as a cookie
+	// too, keeping both out of reach of scripts.
+	if fromCookie {
+		h.cookies.setSessionCookies(w, accessToken, "", h.service.jwt.refreshDuration)
+		httputil.WriteJSON(w, http.StatusOK, authResponse{})
+		return
+	}
 	httputil.WriteJSON(w, http.StatusOK, authResponse{
 		AccessToken: accessToken,
 	})
@@ -123,6 +189,13 @@ func (h *Handlers) handleLogout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.RefreshToken == "" {
+		token, ok := h.refreshTokenCookie(w, r)
+		if !ok {
+			return
+		}
+		req.RefreshToken = token
+	}
 	if req.RefreshToken == "" {
 		httputil.WriteError(w, http.StatusBadRequest, "refresh_token is required")
 		return
@@ -132,6 +205,9 @@ func (h *Handlers) handleLogout(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusBadRequest, "failed to revoke token")
 		return
 	}
+	if h.cookies != nil {
+		h.cookies.clearSessionCookies(w)
+	}
 
 	httputil.WriteJSON(w, http.StatusOK, map[string]string{"message": "logged out successfully"})
 }
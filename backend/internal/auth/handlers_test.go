@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 func TestHandleLoginBadJSON(t *testing.T) {
@@ -320,7 +322,7 @@ func TestHandleRefreshWithTamperedToken(t *testing.T) {
 // TestWriteJSONContentType verifies JSON responses always have correct Content-Type.
 func TestWriteJSONContentType(t *testing.T) {
 	rec := httptest.NewRecorder()
-	writeJSON(rec, http.StatusBadRequest, errorResponse{Error: "test error"})
+	writeJSON(rec, http.StatusBadRequest, map[string]string{"error": "test error"})
 
 	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
 		t.Errorf("expected Content-Type 'application/json', got '%s'", ct)
@@ -345,6 +347,9 @@ func TestRegisterRoutes(t *testing.T) {
 	}{
 		{"/api/auth/login", "POST"},
 		{"/api/auth/refresh", "POST"},
+		{"/api/auth/login/mfa", "POST"},
+		{"/api/auth/login/mfa/enroll", "POST"},
+		{"/api/auth/login/mfa/enroll/confirm", "POST"},
 	}
 
 	for _, rt := range publicRoutes {
@@ -432,7 +437,7 @@ func TestHandleRefreshWithInvalidToken(t *testing.T) {
 		t.Errorf("expected 401 for invalid refresh token, got %d", rec.Code)
 	}
 
-	var resp errorResponse
+	var resp httputil.ErrorResponse
 	json.NewDecoder(rec.Body).Decode(&resp)
 	if resp.Error == "" {
 		t.Error("expected non-empty error message")
@@ -608,3 +613,31 @@ func TestNewHandlers(t *testing.T) {
 		t.Fatal("expected service to be set")
 	}
 }
+
+type recordingWarmer struct {
+	userIDs []string
+}
+
+func (w *recordingWarmer) Warm(ctx context.Context, userID string) error {
+	w.userIDs = append(w.userIDs, userID)
+	return nil
+}
+
+func TestWarmFromTokenWarmsIssuedUser(t *testing.T) {
+	svc := NewJWTService("test-secret")
+	h := NewHandlers(&AuthService{jwt: svc})
+	warmer := &recordingWarmer{}
+	h.SetPermissionWarmer(warmer)
+
+	token, err := svc.GenerateToken("user-1", "user@test.com")
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	req := httptest.NewRequest("POST", "/api/auth/login", nil)
+	h.warmFromToken(req, token)
+	h.warmFromToken(req, "not-a-token")
+
+	if len(warmer.userIDs) != 1 || warmer.userIDs[0] != "user-1" {
+		t.Errorf("expected permissions of user-1 to be warmed once, got %v", warmer.userIDs)
+	}
+}
@@ -8,16 +8,31 @@ import (
 	"github.com/google/uuid"
 )
 
-// TokenType constants distinguish access tokens from refresh tokens.
+// TokenType constants distinguish access tokens from refresh tokens and MFA
+// challenge tokens.
 const (
 	TokenTypeAccess  = "access"
 	TokenTypeRefresh = "refresh"
+	// TokenTypeMFA is issued after a correct password for an account that
+	// still has to pass its second factor. It only proves the password step
+	// and is accepted by the MFA login endpoints alone.
+	TokenTypeMFA = "mfa"
 )
 
+// mfaTokenDuration is how long a user has to enter their second factor
+// after the password step.
+const mfaTokenDuration = 5 * time.Minute
+
+// accessTokenDuration is how long an access token is valid. Clients refresh
+// it when it expires, so it also bounds how often a session is used.
+const accessTokenDuration = 15 * time.Minute
+
 type Claims struct {
 	UserID    string `json:"sub"`
 	Email     string `json:"email"`
 	TokenType string `json:"token_type,omitempty"`
+	// ClusterID scopes a cluster credential to the proxy of one cluster.
+	ClusterID string `json:"cluster_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -30,7 +45,7 @@ type JWTService struct {
 func NewJWTService(secretKey string) *JWTService {
 	return &JWTService{
 		secretKey:       []byte(secretKey),
-		accessDuration:  15 * time.Minute,
+		accessDuration:  accessTokenDuration,
 		refreshDuration: 7 * 24 * time.Hour,
 	}
 }
@@ -53,29 +68,69 @@ func (j *JWTService) GenerateToken(userID, email string) (string, error) {
 }
 
 func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return j.secretKey, nil
-	})
+	claims, err := j.parseClaims(tokenString)
 	if err != nil {
-		return nil, fmt.Errorf("invalid token: %w", err)
-	}
-	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
-		return nil, fmt.Errorf("invalid token claims")
+		return nil, err
 	}
-	// Reject refresh tokens when an access token is expected.
+	// Reject refresh and MFA challenge tokens when an access token is expected.
 	// Tokens without a TokenType (legacy) are allowed for backward compatibility.
-	if claims.TokenType == TokenTypeRefresh {
+	switch claims.TokenType {
+	case TokenTypeRefresh:
 		return nil, fmt.Errorf("invalid token: refresh token cannot be used as access token")
+	case TokenTypeMFA:
+		return nil, fmt.Errorf("invalid token: MFA challenge token cannot be used as access token")
+	case TokenTypeClusterCredential:
+		return nil, fmt.Errorf("invalid token: cluster credential cannot be used as access token")
 	}
 	return claims, nil
 }
 
 // ValidateRefreshToken validates a token string and ensures it is a refresh token.
 func (j *JWTService) ValidateRefreshToken(tokenString string) (*Claims, error) {
+	claims, err := j.parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != TokenTypeRefresh {
+		return nil, fmt.Errorf("invalid token: expected refresh token")
+	}
+	return claims, nil
+}
+
+// GenerateMFAToken issues a short-lived MFA challenge token for a user who
+// passed the password step.
+func (j *JWTService) GenerateMFAToken(userID, email string) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		UserID:    userID,
+		Email:     email,
+		TokenType: TokenTypeMFA,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(mfaTokenDuration)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(j.secretKey)
+}
+
+// ValidateMFAToken validates a token string and ensures it is an MFA
+// challenge token.
+func (j *JWTService) ValidateMFAToken(tokenString string) (*Claims, error) {
+	claims, err := j.parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenType != TokenTypeMFA {
+		return nil, fmt.Errorf("invalid token: expected MFA challenge token")
+	}
+	return claims, nil
+}
+
+// parseClaims verifies a token's signature and expiry and returns its claims.
+func (j *JWTService) parseClaims(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -89,13 +144,17 @@ func (j *JWTService) ValidateRefreshToken(tokenString string) (*Claims, error) {
 	if !ok || !token.Valid {
 		return nil, fmt.Errorf("invalid token claims")
 	}
-	if claims.TokenType != TokenTypeRefresh {
-		return nil, fmt.Errorf("invalid token: expected refresh token")
-	}
 	return claims, nil
 }
 
 func (j *JWTService) GenerateRefreshToken(userID string) (string, error) {
+	token, _, err := j.generateRefreshToken(userID)
+	return token, err
+}
+
+// generateRefreshToken issues a refresh token and also returns its claims,
+// so callers can track the session it starts.
+func (j *JWTService) generateRefreshToken(userID string) (string, *Claims, error) {
 	now := time.Now()
 	claims := Claims{
 		UserID:    userID,
@@ -107,6 +166,9 @@ func (j *JWTService) GenerateRefreshToken(userID string) (string, error) {
 			ExpiresAt: jwt.NewNumericDate(now.Add(j.refreshDuration)),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.secretKey)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secretKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, &claims, nil
 }
@@ -260,3 +260,63 @@ func TestJWTSignatureStripping(t *testing.T) {
 		t.Fatal("SECURITY: accepted token with stripped signature")
 	}
 }
+
+func TestMFATokenOnlyValidatesAsMFAToken(t *testing.T) {
+	svc := NewJWTService("test-secret-key")
+
+	token, err := svc.GenerateMFAToken("user-123", "test@example.com")
+	if err != nil {
+		t.Fatalf("GenerateMFAToken failed: %v", err)
+	}
+	if _, err := svc.ValidateToken(token); err == nil {
+		t.Error("expected MFA token to be rejected as access token")
+	}
+	if _, err := svc.ValidateRefreshToken(token); err == nil {
+		t.Error("expected MFA token to be rejected as refresh token")
+	}
+	claims, err := svc.ValidateMFAToken(token)
+	if err != nil {
+		t.Fatalf("ValidateMFAToken failed: %v", err)
+	}
+	if claims.UserID != "user-123" || claims.Email != "test@example.com" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	access, _ := svc.GenerateToken("user-123", "test@example.com")
+	if _, err := svc.ValidateMFAToken(access); err == nil {
+		t.Error("expected access token to be rejected as MFA token")
+	}
+}
+
+func TestClusterCredentialOnlyValidatesAsClusterCredential(t *testing.T) {
+	svc := NewJWTService("test-secret-key")
+
+	token, expiresAt, err := svc.GenerateClusterCredential("user-123", "test@example.com", "c1")
+	if err != nil {
+		t.Fatalf("GenerateClusterCredential failed: %v", err)
+	}
+	if !strings.HasPrefix(token, ClusterCredentialTokenPrefix) {
+		t.Errorf("expected the credential prefix, got %q", token)
+	}
+	if d := time.Until(expiresAt); d <= 0 || d > ClusterCredentialDuration {
+		t.Errorf("unexpected expiry in %v", d)
+	}
+	if _, err := svc.ValidateToken(token); err == nil {
+		t.Error("expected cluster credential to be rejected as access token")
+	}
+	if _, err := svc.ValidateToken(strings.TrimPrefix(token, ClusterCredentialTokenPrefix)); err == nil {
+		t.Error("expected unprefixed cluster credential to be rejected as access token")
+	}
+	claims, err := svc.ValidateClusterCredential(token)
+	if err != nil {
+		t.Fatalf("ValidateClusterCredential failed: %v", err)
+	}
+	if claims.UserID != "user-123" || claims.ClusterID != "c1" || claims.TokenType != TokenTypeClusterCredential {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	access, _ := svc.GenerateToken("user-123", "test@example.com")
+	if _, err := svc.ValidateClusterCredential(ClusterCredentialTokenPrefix + access); err == nil {
+		t.Error("expected access token to be rejected as cluster credential")
+	}
+}
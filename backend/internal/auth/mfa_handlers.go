@@ -0,0 +1,425 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// mfaBackend is the part of *MFAService the handlers use.
+type mfaBackend interface {
+	LoginStep(ctx context.Context, userID string) (string, error)
+	Status(ctx context.Context, userID string) (*MFAStatus, error)
+	BeginEnrollment(ctx context.Context, userID string) (*MFAEnrollment, error)
+	ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error)
+	Verify(ctx context.Context, userID, code, recoveryCode string) error
+	Disable(ctx context.Context, userID, code, recoveryCode string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error)
+}
+
+// maxMFAAttempts is how many wrong codes one MFA challenge token accepts
+// before the user has to sign in with their password again. The
+// self-service endpoints accept as many per user before locking for
+// mfaSelfServiceLockout.
+const maxMFAAttempts = 5
+
+// mfaSelfServiceLockout is how long a user's self-service MFA endpoints stay
+// locked after their last wrong code once the attempts are used up.
+const mfaSelfServiceLockout = 15 * time.Minute
+
+// mfaAttemptTracker counts failed second-factor attempts per challenge token,
+// and per user for the self-service endpoints.
+type mfaAttemptTracker struct {
+	mu       sync.Mutex
+	failures map[string]int
+	expires  map[string]time.Time
+}
+
+func newMFAAttemptTracker() *mfaAttemptTracker {
+	return &mfaAttemptTracker{failures: make(map[string]int), expires: make(map[string]time.Time)}
+}
+
+// exhausted reports whether the challenge token has no attempts left.
+func (t *mfaAttemptTracker) exhausted(jti string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failures[jti] >= maxMFAAttempts && time.Now().Before(t.expires[jti])
+}
+
+// fail records a failed attempt. Entries of expired tokens are dropped on
+// the way, since those tokens are rejected anyway.
+func (t *mfaAttemptTracker) fail(jti string, expiresAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	for id, exp := range t.expires {
+		if now.After(exp) {
+			delete(t.expires, id)
+			delete(t.failures, id)
+		}
+	}
+	t.failures[jti]++
+	t.expires[jti] = expiresAt
+}
+
+type mfaChallengeResponse struct {
+	Step     string `json:"step"`
+	MFAToken string `json:"mfa_token"`
+}
+
+type mfaLoginRequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type mfaCodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type mfaEnrollLoginResponse struct {
+	authResponse
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// SetMFAService enables TOTP multi-factor authentication for local accounts.
+func (h *Handlers) SetMFAService(svc *MFAService) {
+	if svc != nil {
+		h.mfa = svc
+	}
+}
+
+// completeLogin finishes a password login: it either issues tokens or, when
+// the account has or must set up a second factor, answers with an MFA
+// challenge that the /api/auth/login/mfa endpoints exchange for tokens.
+func (h *Handlers) completeLogin(w http.ResponseWriter, r *http.Request, userID, email string) {
+	if h.mfa != nil {
+		step, err := h.mfa.LoginStep(r.Context(), userID)
+		if err != nil {
+			log.Printf("auth: failed to check MFA for user %s: %v", userID, err)
+			httputil.WriteError(w, http.StatusInternalServerError, "failed to check MFA")
+			return
+		}
+		if step != "" {
+			token, err := h.service.jwt.GenerateMFAToken(userID, email)
+			if err != nil {
+				httputil.WriteError(w, http.StatusInternalServerError, "failed to issue MFA challenge")
+				return
+			}
+			httputil.WriteJSON(w, http.StatusOK, mfaChallengeResponse{Step: step, MFAToken: token})
+			return
+		}
+	}
+
+	accessToken, refreshToken, err := h.service.IssueTokens(r.Context(), userID, email)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to issue tokens")
+		return
+	}
+	h.warmFromToken(r, accessToken)
+
+	httputil.WriteJSON(w, http.StatusOK, authResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	})
+}
+
+// mfaChallenge validates an MFA challenge token and checks that the user is
+// still at the expected login step. It writes the error response and
+// returns nil on failure.
+func (h *Handlers) mfaChallenge(w http.ResponseWriter, r *http.Request, token, wantStep string) *Claims {
+	if h.mfa == nil {
+		httputil.WriteError(w, http.StatusNotFound, "MFA is not enabled on this server")
+		return nil
+	}
+	if token == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "mfa_token is required")
+		return nil
+	}
+	claims, err := h.service.jwt.ValidateMFAToken(token)
+	if err != nil {
+		httputil.WriteError(w, http.StatusUnauthorized, "invalid or expired MFA token")
+		return nil
+	}
+	if h.mfaAttempts.exhausted(claims.ID) {
+		httputil.WriteError(w, http.StatusUnauthorized, "too many invalid codes, sign in again")
+		return nil
+	}
+	step, err := h.mfa.LoginStep(r.Context(), claims.UserID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to check MFA")
+		return nil
+	}
+	if step != wantStep {
+		httputil.WriteError(w, http.StatusConflict, "MFA login step no longer applies, sign in again")
+		return nil
+	}
+	return claims
+}
+
+// handleLoginMFA handles POST /api/auth/login/mfa. It exchanges an MFA
+// challenge token plus a TOTP or recovery code for access tokens.
+func (h *Handlers) handleLoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req mfaLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "code or recovery_code is required")
+		return
+	}
+	claims := h.mfaChallenge(w, r, req.MFAToken, MFAStepRequired)
+	if claims == nil {
+		return
+	}
+
+	if err := h.mfa.Verify(r.Context(), claims.UserID, req.Code, req.RecoveryCode); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			h.mfaAttempts.fail(claims.ID, claims.ExpiresAt.Time)
+		}
+		writeMFAError(w, err)
+		return
+	}
+
+	accessToken, refreshToken, err := h.service.IssueTokens(r.Context(), claims.UserID, claims.Email)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to issue tokens")
+		return
+	}
+	h.warmFromToken(r, accessToken)
+
+	httputil.WriteJSON(w, http.StatusOK, authResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	})
+}
+
+// handleLoginMFAEnroll handles POST /api/auth/login/mfa/enroll. A user whom
+// policy requires to use MFA starts enrollment with their challenge token.
+func (h *Handlers) handleLoginMFAEnroll(w http.ResponseWriter, r *http.Request) {
+	var req mfaLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	claims := h.mfaChallenge(w, r, req.MFAToken, MFAStepEnrollmentRequired)
+	if claims == nil {
+		return
+	}
+
+	enrollment, err := h.mfa.BeginEnrollment(r.Context(), claims.UserID)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, enrollment)
+}
+
+// handleLoginMFAEnrollConfirm handles POST /api/auth/login/mfa/enroll/confirm.
+// It enables MFA with the first code from the authenticator and completes
+// the login, returning tokens together with the new recovery codes.
+func (h *Handlers) handleLoginMFAEnrollConfirm(w http.ResponseWriter, r *http.Request) {
+	var req mfaLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Code == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+	claims := h.mfaChallenge(w, r, req.MFAToken, MFAStepEnrollmentRequired)
+	if claims == nil {
+		return
+	}
+
+	codes, err := h.mfa.ConfirmEnrollment(r.Context(), claims.UserID, req.Code)
+	if err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			h.mfaAttempts.fail(claims.ID, claims.ExpiresAt.Time)
+		}
+		writeMFAError(w, err)
+		return
+	}
+
+	accessToken, refreshToken, err := h.service.IssueTokens(r.Context(), claims.UserID, claims.Email)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to issue tokens")
+		return
+	}
+	h.warmFromToken(r, accessToken)
+
+	httputil.WriteJSON(w, http.StatusOK, mfaEnrollLoginResponse{
+		authResponse: authResponse{
+			AccessToken:  accessToken,
+			RefreshToken: refreshToken,
+		},
+		RecoveryCodes: codes,
+	})
+}
+
+// mfaUser returns the authenticated user's ID for the self-service MFA
+// endpoints, writing the error response and returning "" on failure.
+func (h *Handlers) mfaUser(w http.ResponseWriter, r *http.Request) string {
+	if h.mfa == nil {
+		httputil.WriteError(w, http.StatusNotFound, "MFA is not enabled on this server")
+		return ""
+	}
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return ""
+	}
+	return claims.UserID
+}
+
+// mfaSelfServiceLocked reports whether the user has used up their
+// self-service attempts, writing the error response if so. Without the
+// limit a stolen session could guess the code to turn MFA off.
+func (h *Handlers) mfaSelfServiceLocked(w http.ResponseWriter, userID string) bool {
+	if h.mfaAttempts.exhausted("user:" + userID) {
+		httputil.WriteError(w, http.StatusTooManyRequests, "too many invalid codes, try again later")
+		return true
+	}
+	return false
+}
+
+// mfaSelfServiceFailed counts err against the user's self-service attempts
+// if it is a wrong code.
+func (h *Handlers) mfaSelfServiceFailed(userID string, err error) {
+	if errors.Is(err, ErrInvalidMFACode) {
+		h.mfaAttempts.fail("user:"+userID, time.Now().Add(mfaSelfServiceLockout))
+	}
+}
+
+// handleMFAStatus handles GET /api/auth/mfa.
+func (h *Handlers) handleMFAStatus(w http.ResponseWriter, r *http.Request) {
+	userID := h.mfaUser(w, r)
+	if userID == "" {
+		return
+	}
+	status, err := h.mfa.Status(r.Context(), userID)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, status)
+}
+
+// handleMFAEnroll handles POST /api/auth/mfa/enroll.
+func (h *Handlers) handleMFAEnroll(w http.ResponseWriter, r *http.Request) {
+	userID := h.mfaUser(w, r)
+	if userID == "" {
+		return
+	}
+	enrollment, err := h.mfa.BeginEnrollment(r.Context(), userID)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, enrollment)
+}
+
+// handleMFAEnrollConfirm handles POST /api/auth/mfa/enroll/confirm.
+func (h *Handlers) handleMFAEnrollConfirm(w http.ResponseWriter, r *http.Request) {
+	userID := h.mfaUser(w, r)
+	if userID == "" {
+		return
+	}
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Code == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+	codes, err := h.mfa.ConfirmEnrollment(r.Context(), userID, req.Code)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// handleMFADisable handles POST /api/auth/mfa/disable.
+func (h *Handlers) handleMFADisable(w http.ResponseWriter, r *http.Request) {
+	userID := h.mfaUser(w, r)
+	if userID == "" {
+		return
+	}
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if h.mfaSelfServiceLocked(w, userID) {
+		return
+	}
+	if err := h.mfa.Disable(r.Context(), userID, req.Code, req.RecoveryCode); err != nil {
+		h.mfaSelfServiceFailed(userID, err)
+		writeMFAError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleMFARecoveryCodes handles POST /api/auth/mfa/recovery-codes. It
+// replaces the user's recovery codes after verifying a current TOTP code.
+func (h *Handlers) handleMFARecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID := h.mfaUser(w, r)
+	if userID == "" {
+		return
+	}
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Code == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+	if h.mfaSelfServiceLocked(w, userID) {
+		return
+	}
+	codes, err := h.mfa.RegenerateRecoveryCodes(r.Context(), userID, req.Code)
+	if err != nil {
+		h.mfaSelfServiceFailed(userID, err)
+		writeMFAError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// writeMFAError maps MFAService errors to HTTP responses.
+func writeMFAError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidMFACode):
+		httputil.WriteError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, ErrMFARequiredByPolicy):
+		httputil.WriteError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, ErrMFANotLocal):
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrMFANotEnabled), errors.Is(err, ErrMFAAlreadyEnabled), errors.Is(err, ErrMFANotPending):
+		httputil.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrUserNotFound):
+		httputil.WriteError(w, http.StatusNotFound, "user not found")
+	default:
+		log.Printf("auth: MFA error: %v", err)
+		httputil.WriteError(w, http.StatusInternalServerError, "MFA request failed")
+	}
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeMFA is an mfaBackend that accepts the code "123456".
+type fakeMFA struct {
+	step     string
+	verified []string
+	checked  int
+}
+
+func (f *fakeMFA) LoginStep(ctx context.Context, userID string) (string, error) {
+	return f.step, nil
+}
+
+func (f *fakeMFA) Status(ctx context.Context, userID string) (*MFAStatus, error) {
+	return &MFAStatus{Enabled: f.step == MFAStepRequired}, nil
+}
+
+func (f *fakeMFA) BeginEnrollment(ctx context.Context, userID string) (*MFAEnrollment, error) {
+	return &MFAEnrollment{Secret: rfc6238Secret, ProvisioningURI: totpProvisioningURI(rfc6238Secret, "user@test.com")}, nil
+}
+
+func (f *fakeMFA) ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	if code != "123456" {
+		return nil, ErrInvalidMFACode
+	}
+	return []string{"aaaaa-bbbbb"}, nil
+}
+
+func (f *fakeMFA) Verify(ctx context.Context, userID, code, recoveryCode string) error {
+	f.verified = append(f.verified, userID)
+	if code != "123456" {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (f *fakeMFA) Disable(ctx context.Context, userID, code, recoveryCode string) error {
+	f.checked++
+	return ErrMFARequiredByPolicy
+}
+
+func (f *fakeMFA) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	f.checked++
+	return nil, ErrInvalidMFACode
+}
+
+func newMFATestHandlers(step string) (*Handlers, *fakeMFA, *JWTService) {
+	jwtSvc := NewJWTService("test-secret")
+	h := NewHandlers(&AuthService{jwt: jwtSvc})
+	mfa := &fakeMFA{step: step}
+	h.mfa = mfa
+	return h, mfa, jwtSvc
+}
+
+func TestCompleteLoginReturnsMFAChallenge(t *testing.T) {
+	for _, step := range []string{MFAStepRequired, MFAStepEnrollmentRequired} {
+		h, _, jwtSvc := newMFATestHandlers(step)
+		req := httptest.NewRequest("POST", "/api/auth/login", nil)
+		rec := httptest.NewRecorder()
+
+		h.completeLogin(rec, req, "user-1", "user@test.com")
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", step, rec.Code)
+		}
+		var resp map[string]interface{}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		if resp["step"] != step {
+			t.Errorf("expected step %q, got %v", step, resp["step"])
+		}
+		if _, ok := resp["access_token"]; ok {
+			t.Errorf("%s: access token must not be issued before the second factor", step)
+		}
+		token, _ := resp["mfa_token"].(string)
+		claims, err := jwtSvc.ValidateMFAToken(token)
+		if err != nil || claims.UserID != "user-1" {
+			t.Errorf("expected an MFA token for user-1, got %v", err)
+		}
+		if _, err := jwtSvc.ValidateToken(token); err == nil {
+			t.Error("SECURITY: MFA challenge token must not be accepted as an access token")
+		}
+	}
+}
+
+func TestHandleLoginMFARejectsNonChallengeTokens(t *testing.T) {
+	h, mfa, jwtSvc := newMFATestHandlers(MFAStepRequired)
+	access, _ := jwtSvc.GenerateToken("user-1", "user@test.com")
+	refresh, _ := jwtSvc.GenerateRefreshToken("user-1")
+
+	for _, token := range []string{access, refresh, "garbage"} {
+		body, _ := json.Marshal(mfaLoginRequest{MFAToken: token, Code: "123456"})
+		req := httptest.NewRequest("POST", "/api/auth/login/mfa", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		h.handleLoginMFA(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401, got %d", rec.Code)
+		}
+	}
+	if len(mfa.verified) != 0 {
+		t.Error("expected no code verification without a valid challenge token")
+	}
+}
+
+func TestHandleLoginMFALimitsAttempts(t *testing.T) {
+	h, mfa, jwtSvc := newMFATestHandlers(MFAStepRequired)
+	token, _ := jwtSvc.GenerateMFAToken("user-1", "user@test.com")
+
+	for i := 0; i < maxMFAAttempts+2; i++ {
+		body, _ := json.Marshal(mfaLoginRequest{MFAToken: token, Code: "000000"})
+		req := httptest.NewRequest("POST", "/api/auth/login/mfa", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		h.handleLoginMFA(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401, got %d", i, rec.Code)
+		}
+	}
+	if len(mfa.verified) != maxMFAAttempts {
+		t.Errorf("expected verification to stop after %d attempts, got %d", maxMFAAttempts, len(mfa.verified))
+	}
+}
+
+func TestSelfServiceMFALimitsAttempts(t *testing.T) {
+	h, mfa, _ := newMFATestHandlers(MFAStepRequired)
+	post := func(handler http.HandlerFunc, path, userID string) int {
+		body, _ := json.Marshal(mfaCodeRequest{Code: "000000"})
+		req := httptest.NewRequest("POST", path, bytes.NewReader(body))
+		req = req.WithContext(ContextWithClaims(req.Context(), &Claims{UserID: userID}))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Code
+	}
+
+	for i := 0; i < maxMFAAttempts; i++ {
+		if code := post(h.handleMFARecoveryCodes, "/api/auth/mfa/recovery-codes", "user-1"); code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401, got %d", i, code)
+		}
+	}
+	if code := post(h.handleMFARecoveryCodes, "/api/auth/mfa/recovery-codes", "user-1"); code != http.StatusTooManyRequests {
+		t.Errorf("expected recovery codes to be locked, got %d", code)
+	}
+	if code := post(h.handleMFADisable, "/api/auth/mfa/disable", "user-1"); code != http.StatusTooManyRequests {
+		t.Errorf("expected disabling MFA to be locked, got %d", code)
+	}
+	if mfa.checked != maxMFAAttempts {
+		t.Errorf("expected codes to be checked %d times, got %d", maxMFAAttempts, mfa.checked)
+	}
+	if code := post(h.handleMFARecoveryCodes, "/api/auth/mfa/recovery-codes", "user-2"); code != http.StatusUnauthorized {
+		t.Errorf("expected other users to keep their attempts, got %d", code)
+	}
+}
+
+func TestHandleLoginMFAEnrollRequiresEnrollmentStep(t *testing.T) {
+	// A user who already has MFA enabled cannot enroll a new secret with
+	// only a password-derived challenge token.
+	h, _, jwtSvc := newMFATestHandlers(MFAStepRequired)
+	token, _ := jwtSvc.GenerateMFAToken("user-1", "user@test.com")
+	body, _ := json.Marshal(mfaLoginRequest{MFAToken: token})
+	req := httptest.NewRequest("POST", "/api/auth/login/mfa/enroll", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	h.handleLoginMFAEnroll(rec, req)
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", rec.Code)
+	}
+}
+
+func TestHandleMFADisableRefusedByPolicy(t *testing.T) {
+	h, _, _ := newMFATestHandlers(MFAStepRequired)
+	body, _ := json.Marshal(mfaCodeRequest{Code: "123456"})
+	req := httptest.NewRequest("POST", "/api/auth/mfa/disable", bytes.NewReader(body))
+	req = req.WithContext(ContextWithClaims(req.Context(), &Claims{UserID: "user-1"}))
+	rec := httptest.NewRecorder()
+
+	h.handleMFADisable(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", rec.Code)
+	}
+}
+
+func TestMFAEndpointsWithoutService(t *testing.T) {
+	h := NewHandlers(&AuthService{jwt: NewJWTService("test-secret")})
+	req := httptest.NewRequest("GET", "/api/auth/mfa", nil)
+	req = req.WithContext(ContextWithClaims(req.Context(), &Claims{UserID: "user-1"}))
+	rec := httptest.NewRecorder()
+
+	h.handleMFAStatus(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,379 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/darkden-lab/argus/backend/internal/crypto"
+)
+
+// MFA errors returned by MFAService.
+var (
+	ErrInvalidMFACode      = errors.New("invalid MFA code")
+	ErrMFANotEnabled       = errors.New("MFA is not enabled")
+	ErrMFAAlreadyEnabled   = errors.New("MFA is already enabled")
+	ErrMFANotPending       = errors.New("no MFA enrollment in progress")
+	ErrMFARequiredByPolicy = errors.New("MFA is required for local accounts")
+	ErrMFANotLocal         = errors.New("MFA is only available for local accounts")
+)
+
+// Login steps returned by MFAService.LoginStep after a correct password.
+const (
+	// MFAStepRequired means the user must enter a TOTP or recovery code.
+	MFAStepRequired = "mfa_required"
+	// MFAStepEnrollmentRequired means policy requires MFA and the user has
+	// not enrolled yet, so they must enroll before tokens are issued.
+	MFAStepEnrollmentRequired = "mfa_enrollment_required"
+)
+
+// MFAPolicySettingKey is the settings table key holding the MFAPolicy.
+const MFAPolicySettingKey = "mfa_policy"
+
+// MFAPolicy is the admin-controlled MFA policy.
+type MFAPolicy struct {
+	// RequireForLocal makes every local account enroll in MFA at its next
+	// login. OIDC accounts are unaffected; their second factor is the
+	// identity provider's concern.
+	RequireForLocal bool `json:"require_for_local"`
+}
+
+// MFAStatus describes a user's MFA state.
+type MFAStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// MFAEnrollment is a pending TOTP enrollment. ProvisioningURI is the
+// otpauth:// URI to render as a QR code; Secret is for manual entry.
+type MFAEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFAService manages TOTP enrollment, verification and recovery codes for
+// local accounts.
+type MFAService struct {
+	pool          *pgxpool.Pool
+	encryptionKey string
+	now           func() time.Time
+}
+
+func NewMFAService(pool *pgxpool.Pool, encryptionKey string) *MFAService {
+	return &MFAService{pool: pool, encryptionKey: encryptionKey, now: time.Now}
+}
+
+// Policy returns the current MFA policy. A missing setting means MFA is
+// optional.
+func (s *MFAService) Policy(ctx context.Context) (MFAPolicy, error) {
+	var policy MFAPolicy
+	var raw []byte
+	err := s.pool.QueryRow(ctx, `SELECT value FROM settings WHERE key = $1`, MFAPolicySettingKey).Scan(&raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return policy, nil
+	}
+	if err != nil {
+		return policy, fmt.Errorf("failed to load MFA policy: %w", err)
+	}
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		return policy, fmt.Errorf("failed to decode MFA policy: %w", err)
+	}
+	return policy, nil
+}
+
+// Status returns the user's MFA state.
+func (s *MFAService) Status(ctx context.Context, userID string) (*MFAStatus, error) {
+	policy, err := s.Policy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	provider, _, err := s.lookupUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MFAStatus{Required: policy.RequireForLocal && provider == "local"}
+	err = s.pool.QueryRow(ctx,
+		`SELECT m.enabled,
+		        (SELECT COUNT(*) FROM user_mfa_recovery_codes c WHERE c.user_id = m.user_id AND c.used_at IS NULL)
+		 FROM user_mfa m WHERE m.user_id = $1`,
+		userID,
+	).Scan(&status.Enabled, &status.RecoveryCodesRemaining)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to load MFA status: %w", err)
+	}
+	if !status.Enabled {
+		status.RecoveryCodesRemaining = 0
+	}
+	return status, nil
+}
+
+// LoginStep returns the step a user who just passed the password check must
+// complete before tokens are issued, or "" if none.
+func (s *MFAService) LoginStep(ctx context.Context, userID string) (string, error) {
+	var enabled bool
+	err := s.pool.QueryRow(ctx, `SELECT enabled FROM user_mfa WHERE user_id = $1`, userID).Scan(&enabled)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("failed to load MFA status: %w", err)
+	}
+	if enabled {
+		return MFAStepRequired, nil
+	}
+	policy, err := s.Policy(ctx)
+	if err != nil {
+		return "", err
+	}
+	if policy.RequireForLocal {
+		return MFAStepEnrollmentRequired, nil
+	}
+	return "", nil
+}
+
+// BeginEnrollment generates a new TOTP secret for the user and stores it,
+// encrypted, as a pending enrollment. Starting again replaces a pending
+// secret; an enabled one must be disabled first.
+func (s *MFAService) BeginEnrollment(ctx context.Context, userID string) (*MFAEnrollment, error) {
+	provider, email, err := s.lookupUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if provider != "local" {
+		return nil, ErrMFANotLocal
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	encrypted, err := crypto.Encrypt([]byte(secret), s.encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt TOTP secret: %w", err)
+	}
+
+	tag, err := s.pool.Exec(ctx,
+		`INSERT INTO user_mfa (user_id, secret_enc, enabled, last_used_step, created_at)
+		 VALUES ($1, $2, false, 0, NOW())
+		 ON CONFLICT (user_id) DO UPDATE
+		   SET secret_enc = EXCLUDED.secret_enc, last_used_step = 0, created_at = NOW()
+		   WHERE user_mfa.enabled = false`,
+		userID, encrypted,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store TOTP secret: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	return &MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totpProvisioningURI(secret, email),
+	}, nil
+}
+
+// ConfirmEnrollment enables MFA once the user proves their authenticator
+// produces valid codes, and returns a fresh set of recovery codes. The codes
+// are only ever returned here and from RegenerateRecoveryCodes.
+func (s *MFAService) ConfirmEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	secret, enabled, lastStep, err := s.loadSecret(ctx, userID)
+	if errors.Is(err, ErrMFANotEnabled) {
+		return nil, ErrMFANotPending
+	}
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	step, ok := validateTOTP(secret, code, s.now(), lastStep)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE user_mfa SET enabled = true, enabled_at = NOW(), last_used_step = $2
+		 WHERE user_id = $1 AND enabled = false`,
+		userID, step,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable MFA: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	codes, err := replaceRecoveryCodes(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit MFA enrollment: %w", err)
+	}
+	return codes, nil
+}
+
+// Verify checks a second factor for a user with MFA enabled: either a TOTP
+// code or, when code is empty, a single-use recovery code. A TOTP code is
+// accepted at most once and a recovery code is consumed.
+func (s *MFAService) Verify(ctx context.Context, userID, code, recoveryCode string) error {
+	if code == "" {
+		if recoveryCode == "" {
+			return ErrInvalidMFACode
+		}
+		tag, err := s.pool.Exec(ctx,
+			`UPDATE user_mfa_recovery_codes SET used_at = NOW()
+			 WHERE id = (
+			   SELECT c.id FROM user_mfa_recovery_codes c
+			   JOIN user_mfa m ON m.user_id = c.user_id AND m.enabled
+			   WHERE c.user_id = $1 AND c.code_hash = $2 AND c.used_at IS NULL
+			   LIMIT 1
+			 )`,
+			userID, hashRecoveryCode(recoveryCode),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to check recovery code: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	secret, enabled, lastStep, err := s.loadSecret(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrMFANotEnabled
+	}
+	step, ok := validateTOTP(secret, code, s.now(), lastStep)
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	// The step guard makes concurrent submissions of the same code race for
+	// a single acceptance.
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE user_mfa SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`,
+		userID, step,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record MFA code use: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// Disable turns MFA off after verifying a current code. It is refused while
+// policy requires MFA for local accounts.
+func (s *MFAService) Disable(ctx context.Context, userID, code, recoveryCode string) error {
+	policy, err := s.Policy(ctx)
+	if err != nil {
+		return err
+	}
+	if policy.RequireForLocal {
+		return ErrMFARequiredByPolicy
+	}
+	if err := s.Verify(ctx, userID, code, recoveryCode); err != nil {
+		return err
+	}
+	if _, err := s.pool.Exec(ctx, `DELETE FROM user_mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if _, err := s.pool.Exec(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to disable MFA: %w", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes verifies a current code and replaces the user's
+// recovery codes with a new set.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code, ""); err != nil {
+		return nil, err
+	}
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	codes, err := replaceRecoveryCodes(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// lookupUser returns the user's auth provider and email.
+func (s *MFAService) lookupUser(ctx context.Context, userID string) (string, string, error) {
+	var provider, email string
+	err := s.pool.QueryRow(ctx,
+		`SELECT auth_provider, email FROM users WHERE id = $1`, userID,
+	).Scan(&provider, &email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load user: %w", err)
+	}
+	return provider, email, nil
+}
+
+// loadSecret returns the user's decrypted TOTP secret, whether it is enabled
+// and the last accepted time step. ErrMFANotEnabled means no secret exists.
+func (s *MFAService) loadSecret(ctx context.Context, userID string) (string, bool, int64, error) {
+	var encrypted []byte
+	var enabled bool
+	var lastStep int64
+	err := s.pool.QueryRow(ctx,
+		`SELECT secret_enc, enabled, last_used_step FROM user_mfa WHERE user_id = $1`, userID,
+	).Scan(&encrypted, &enabled, &lastStep)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, 0, ErrMFANotEnabled
+	}
+	if err != nil {
+		return "", false, 0, fmt.Errorf("failed to load TOTP secret: %w", err)
+	}
+	secret, err := crypto.Decrypt(encrypted, s.encryptionKey)
+	if err != nil {
+		return "", false, 0, fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+	return string(secret), enabled, lastStep, nil
+}
+
+// replaceRecoveryCodes deletes the user's recovery codes and stores a new
+// set, returning the plaintext codes.
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID string) ([]string, error) {
+	codes, err := generateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, code := range codes {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO user_mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`,
+			userID, hashRecoveryCode(code),
+		); err != nil {
+			return nil, fmt.Errorf("failed to store recovery code: %w", err)
+		}
+	}
+	return codes, nil
+}
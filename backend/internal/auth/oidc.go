@@ -15,6 +15,8 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/darkden-lab/argus/backend/internal/db"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/outbound"
 	"golang.org/x/oauth2"
 )
 
@@ -25,6 +27,106 @@ type OIDCConfig struct {
 	ClientSecret string
 	RedirectURL  string
 	FrontendURL  string
+	// TokenDelivery is TokenDeliveryFragment (the default) or
+	// TokenDeliveryCookie, which sets Cookies' session cookies instead.
+	TokenDelivery string
+	Cookies       CookieConfig
+}
+
+// defaultOIDCScopes are requested after openid when no scopes are configured.
+var defaultOIDCScopes = []string{"profile", "email"}
+
+// oidcSettings is the part of the stored OIDC configuration the login flow
+// reads on each request: the scopes to request and the ID token claims a user
+// is read from. Empty claim names mean the standard claims.
+type oidcSettings struct {
+	Scopes       []string `json:"scopes"`
+	ExtraScopes  []string `json:"extra_scopes"`
+	SubjectClaim string   `json:"subject_claim"`
+	EmailClaim   string   `json:"email_claim"`
+	NameClaim    string   `json:"name_claim"`
+	GroupsClaim  string   `json:"groups_claim"`
+}
+
+// scopes returns openid followed by the configured scopes, or the default
+// ones, and the extra scopes, without duplicates.
+func (c oidcSettings) scopes() []string {
+	base := c.Scopes
+	if len(base) == 0 {
+		base = defaultOIDCScopes
+	}
+	scopes := []string{oidc.ScopeOpenID}
+	seen := map[string]bool{oidc.ScopeOpenID: true}
+	for _, list := range [][]string{base, c.ExtraScopes} {
+		for _, scope := range list {
+			if scope != "" && !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
+
+// withDefaults fills in the standard claim names.
+func (c oidcSettings) withDefaults() oidcSettings {
+	if c.SubjectClaim == "" {
+		c.SubjectClaim = "sub"
+	}
+	if c.EmailClaim == "" {
+		c.EmailClaim = "email"
+	}
+	if c.NameClaim == "" {
+		c.NameClaim = "name"
+	}
+	if c.GroupsClaim == "" {
+		c.GroupsClaim = "groups"
+	}
+	return c
+}
+
+// oidcIdentity is the user an ID token describes.
+type oidcIdentity struct {
+	Subject string
+	Email   string
+	Name    string
+	Groups  []string
+}
+
+// identityFromClaims reads the user from the ID token claims using the
+// configured claim names. The subject and email claims are required; the
+// name falls back to the email and groups are optional.
+func identityFromClaims(claims map[string]interface{}, cfg oidcSettings) (oidcIdentity, error) {
+	cfg = cfg.withDefaults()
+	id := oidcIdentity{}
+	id.Subject, _ = claims[cfg.SubjectClaim].(string)
+	id.Email, _ = claims[cfg.EmailClaim].(string)
+	id.Name, _ = claims[cfg.NameClaim].(string)
+	if id.Subject == "" {
+		return oidcIdentity{}, fmt.Errorf("ID token has no %q claim", cfg.SubjectClaim)
+	}
+	if id.Email == "" {
+		return oidcIdentity{}, fmt.Errorf("ID token has no %q claim", cfg.EmailClaim)
+	}
+	if id.Name == "" {
+		id.Name = id.Email
+	}
+	id.Groups = groupsFromClaims(claims, cfg)
+	return id, nil
+}
+
+// groupsFromClaims reads the groups claim, skipping values that are not
+// strings.
+func groupsFromClaims(claims map[string]interface{}, cfg oidcSettings) []string {
+	var groups []string
+	if raw, ok := claims[cfg.withDefaults().GroupsClaim].([]interface{}); ok {
+		for _, g := range raw {
+			if gs, ok := g.(string); ok {
+				groups = append(groups, gs)
+			}
+		}
+	}
+	return groups
 }
 
 // oidcStateEntry holds a state value with its expiry time.
@@ -66,7 +168,10 @@ type OIDCService struct {
 	pool         *pgxpool.Pool
 	jwt          *JWTService
 	frontendURL  string
+	delivery     string
+	cookies      CookieConfig
 	groupMapper  *OIDCGroupMapper
+	warmer       PermissionWarmer
 }
 
 // NewOIDCService creates a new OIDCService. Returns nil, nil if OIDC is not configured.
@@ -75,7 +180,7 @@ func NewOIDCService(ctx context.Context, cfg OIDCConfig, database *db.DB, jwtSer
 		return nil, nil
 	}
 
-	provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+	provider, err := oidc.NewProvider(outbound.Context(ctx), cfg.Issuer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover OIDC provider: %w", err)
 	}
@@ -85,7 +190,7 @@ func NewOIDCService(ctx context.Context, cfg OIDCConfig, database *db.DB, jwtSer
 		ClientSecret: cfg.ClientSecret,
 		RedirectURL:  cfg.RedirectURL,
 		Endpoint:     provider.Endpoint(),
-		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+		Scopes:       oidcSettings{}.scopes(),
 	}
 
 	verifier := provider.Verifier(&oidc.Config{
@@ -107,6 +212,8 @@ func NewOIDCService(ctx context.Context, cfg OIDCConfig, database *db.DB, jwtSer
 		pool:         pool,
 		jwt:          jwtService,
 		frontendURL:  frontendURL,
+		delivery:     cfg.TokenDelivery,
+		cookies:      cfg.Cookies,
 		groupMapper:  NewOIDCGroupMapper(pool),
 	}, nil
 }
@@ -121,6 +228,28 @@ func (s *OIDCService) Enabled() bool {
 	return s.provider != nil
 }
 
+// CheckDiscovery repeats discovery against the configured issuer, to check
+// it is still reachable and serves a valid configuration.
+func (s *OIDCService) CheckDiscovery(ctx context.Context) error {
+	if !s.Enabled() {
+		return fmt.Errorf("OIDC is not configured")
+	}
+	s.mu.RLock()
+	provider := s.provider
+	s.mu.RUnlock()
+
+	var claims struct {
+		Issuer string `json:"issuer"`
+	}
+	if err := provider.Claims(&claims); err != nil {
+		return fmt.Errorf("failed to read OIDC provider configuration: %w", err)
+	}
+	if _, err := oidc.NewProvider(outbound.Context(ctx), claims.Issuer); err != nil {
+		return fmt.Errorf("failed to discover OIDC provider at %s: %w", claims.Issuer, err)
+	}
+	return nil
+}
+
 // Reload reads the OIDC configuration from the database and recreates the
 // provider, oauth2 config, and verifier. This allows runtime config changes
 // without restarting the server.
@@ -136,11 +265,13 @@ func (s *OIDCService) Reload(ctx context.Context, pool *pgxpool.Pool) error {
 	}
 
 	var cfg struct {
-		Enabled      bool   `json:"enabled"`
-		IssuerURL    string `json:"issuer_url"`
-		ClientID     string `json:"client_id"`
-		ClientSecret string `json:"client_secret"`
-		RedirectURL  string `json:"redirect_url"`
+		Enabled      bool     `json:"enabled"`
+		IssuerURL    string   `json:"issuer_url"`
+		ClientID     string   `json:"client_id"`
+		ClientSecret string   `json:"client_secret"`
+		RedirectURL  string   `json:"redirect_url"`
+		Scopes       []string `json:"scopes"`
+		ExtraScopes  []string `json:"extra_scopes"`
 	}
 	if err := json.Unmarshal(raw, &cfg); err != nil {
 		return fmt.Errorf("failed to parse OIDC config: %w", err)
@@ -155,7 +286,7 @@ func (s *OIDCService) Reload(ctx context.Context, pool *pgxpool.Pool) error {
 		return nil
 	}
 
-	provider, err := oidc.NewProvider(ctx, cfg.IssuerURL)
+	provider, err := oidc.NewProvider(outbound.Context(ctx), cfg.IssuerURL)
 	if err != nil {
 		return fmt.Errorf("failed to discover OIDC provider at %s: %w", cfg.IssuerURL, err)
 	}
@@ -165,7 +296,7 @@ func (s *OIDCService) Reload(ctx context.Context, pool *pgxpool.Pool) error {
 		ClientSecret: cfg.ClientSecret,
 		RedirectURL:  cfg.RedirectURL,
 		Endpoint:     provider.Endpoint(),
-		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+		Scopes:       oidcSettings{Scopes: cfg.Scopes, ExtraScopes: cfg.ExtraScopes}.scopes(),
 	}
 
 	verifier := provider.Verifier(&oidc.Config{
@@ -194,14 +325,17 @@ func (s *OIDCService) RegisterRoutes(r *mux.Router) {
 func (s *OIDCService) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
 	state, err := s.generateState()
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to generate state"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to generate state")
 		return
 	}
 
 	s.mu.RLock()
-	url := s.oauth2Config.AuthCodeURL(state)
+	oauth2Cfg := s.oauth2Config
 	s.mu.RUnlock()
-	http.Redirect(w, r, url, http.StatusFound)
+	if settings, ok := s.loadSettings(r.Context()); ok {
+		oauth2Cfg.Scopes = settings.scopes()
+	}
+	http.Redirect(w, r, oauth2Cfg.AuthCodeURL(state), http.StatusFound)
 }
 
 // HandleCallback processes the OIDC callback, exchanges the code for tokens,
@@ -209,20 +343,20 @@ func (s *OIDCService) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
 func (s *OIDCService) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	state := r.URL.Query().Get("state")
 	if !s.validateState(state) {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid state parameter"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid state parameter")
 		return
 	}
 
 	if errParam := r.URL.Query().Get("error"); errParam != "" {
 		desc := r.URL.Query().Get("error_description")
 		log.Printf("oidc: callback error: %s — %s", errParam, desc)
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "OIDC authentication failed"})
+		httputil.WriteError(w, http.StatusBadRequest, "OIDC authentication failed")
 		return
 	}
 
 	code := r.URL.Query().Get("code")
 	if code == "" {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "missing authorization code"})
+		httputil.WriteError(w, http.StatusBadRequest, "missing authorization code")
 		return
 	}
 
@@ -231,77 +365,43 @@ func (s *OIDCService) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	verifier := s.verifier
 	s.mu.RUnlock()
 
-	oauth2Token, err := oauth2Cfg.Exchange(r.Context(), code)
+	oauth2Token, err := oauth2Cfg.Exchange(outbound.Context(r.Context()), code)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to exchange authorization code"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to exchange authorization code")
 		return
 	}
 
 	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
 	if !ok {
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "no id_token in response"})
+		httputil.WriteError(w, http.StatusInternalServerError, "no id_token in response")
 		return
 	}
 
 	idToken, err := verifier.Verify(r.Context(), rawIDToken)
 	if err != nil {
-		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "invalid ID token"})
+		httputil.WriteError(w, http.StatusUnauthorized, "invalid ID token")
 		return
 	}
 
-	var claims struct {
-		Subject string `json:"sub"`
-		Email   string `json:"email"`
-		Name    string `json:"name"`
-		Groups  []string
-	}
-
-	// Extract standard claims (sub, email, name)
-	if err := idToken.Claims(&claims); err != nil {
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to extract claims"})
-		return
-	}
-
-	// Extract groups from a dynamic claim name (configurable via OIDC settings).
-	// Use allClaims map to support configurable claim names (not just "groups").
-	groupsClaim := "groups" // default
-	if s.pool != nil {
-		var raw []byte
-		if err := s.pool.QueryRow(r.Context(), "SELECT value FROM settings WHERE key = $1", "oidc").Scan(&raw); err == nil {
-			var oidcCfg struct {
-				GroupsClaim string `json:"groups_claim"`
-			}
-			if json.Unmarshal(raw, &oidcCfg) == nil && oidcCfg.GroupsClaim != "" {
-				groupsClaim = oidcCfg.GroupsClaim
-			}
-		}
-	}
-
+	// Claim names are configurable via OIDC settings, so read the claims
+	// into a map rather than a fixed struct.
 	var allClaims map[string]interface{}
-	if err := idToken.Claims(&allClaims); err == nil {
-		if groupsRaw, ok := allClaims[groupsClaim]; ok {
-			if groupsList, ok := groupsRaw.([]interface{}); ok {
-				for _, g := range groupsList {
-					if gs, ok := g.(string); ok {
-						claims.Groups = append(claims.Groups, gs)
-					}
-				}
-			}
-		}
-	}
-
-	if claims.Email == "" {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "email claim is required"})
+	if err := idToken.Claims(&allClaims); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to extract claims")
 		return
 	}
-	if claims.Name == "" {
-		claims.Name = claims.Email
+	settings, _ := s.loadSettings(r.Context())
+	claims, err := identityFromClaims(allClaims, settings)
+	if err != nil {
+		log.Printf("oidc: %v", err)
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	user, err := s.upsertOIDCUser(r.Context(), claims.Subject, claims.Email, claims.Name)
 	if err != nil {
 		log.Printf("oidc: failed to upsert user: %v", err)
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to create/update user"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to create/update user")
 		return
 	}
 
@@ -316,16 +416,29 @@ func (s *OIDCService) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	if err := s.groupMapper.ApplyDefaultRole(r.Context(), user.ID); err != nil {
 		log.Printf("oidc: failed to apply default role: %v", err)
 	}
+	warmPermissions(r.Context(), s.warmer, user.ID)
 
 	accessToken, err := s.jwt.GenerateToken(user.ID, user.Email)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to generate access token"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to generate access token")
 		return
 	}
 
 	refreshToken, err := s.jwt.GenerateRefreshToken(user.ID)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to generate refresh token"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to generate refresh token")
+		return
+	}
+
+	s.deliverTokens(w, r, accessToken, refreshToken)
+}
+
+// deliverTokens redirects to the frontend's OIDC callback page with the
+// issued tokens, either as session cookies or in the URL fragment.
+func (s *OIDCService) deliverTokens(w http.ResponseWriter, r *http.Request, accessToken, refreshToken string) {
+	if s.delivery == TokenDeliveryCookie {
+		s.cookies.setSessionCookies(w, accessToken, refreshToken, s.jwt.refreshDuration)
+		http.Redirect(w, r, s.frontendURL+"/auth/oidc/callback", http.StatusFound)
 		return
 	}
 
@@ -335,6 +448,55 @@ func (s *OIDCService) HandleCallback(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, redirectURL, http.StatusFound)
 }
 
+// loadSettings reads the stored OIDC settings. It reports false when there
+// are none, in which case the defaults apply.
+func (s *OIDCService) loadSettings(ctx context.Context) (oidcSettings, bool) {
+	return loadOIDCSettings(ctx, s.pool)
+}
+
+func loadOIDCSettings(ctx context.Context, pool *pgxpool.Pool) (oidcSettings, bool) {
+	if pool == nil {
+		return oidcSettings{}, false
+	}
+	var raw []byte
+	if err := pool.QueryRow(ctx, "SELECT value FROM settings WHERE key = $1", "oidc").Scan(&raw); err != nil {
+		return oidcSettings{}, false
+	}
+	var settings oidcSettings
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return oidcSettings{}, false
+	}
+	return settings, true
+}
+
+// SetPermissionWarmer sets the warmer that preloads a user's permissions
+// once the callback has applied their group-mapped roles.
+func (s *OIDCService) SetPermissionWarmer(warmer PermissionWarmer) {
+	s.warmer = warmer
+}
+
+// ProviderName returns the display name of the OIDC provider from the
+// settings DB, or "" if none is set.
+func (s *OIDCService) ProviderName(ctx context.Context) string {
+	if s == nil || s.pool == nil {
+		return ""
+	}
+	var raw []byte
+	err := s.pool.QueryRow(ctx,
+		"SELECT value FROM settings WHERE key = $1", "oidc",
+	).Scan(&raw)
+	if err != nil {
+		return ""
+	}
+	var oidcSettings struct {
+		ProviderName string `json:"provider_name"`
+	}
+	if json.Unmarshal(raw, &oidcSettings) != nil {
+		return ""
+	}
+	return oidcSettings.ProviderName
+}
+
 // HandleProviderInfo returns OIDC provider configuration for the frontend.
 func (s *OIDCService) HandleProviderInfo(w http.ResponseWriter, r *http.Request) {
 	info := map[string]interface{}{
@@ -342,20 +504,8 @@ func (s *OIDCService) HandleProviderInfo(w http.ResponseWriter, r *http.Request)
 	}
 	if s.Enabled() {
 		info["authorize_url"] = "/api/auth/oidc/authorize"
-		// Read provider_name from settings DB
-		if s.pool != nil {
-			var raw []byte
-			err := s.pool.QueryRow(r.Context(),
-				"SELECT value FROM settings WHERE key = $1", "oidc",
-			).Scan(&raw)
-			if err == nil {
-				var oidcSettings struct {
-					ProviderName string `json:"provider_name"`
-				}
-				if json.Unmarshal(raw, &oidcSettings) == nil && oidcSettings.ProviderName != "" {
-					info["provider_name"] = oidcSettings.ProviderName
-				}
-			}
+		if name := s.ProviderName(r.Context()); name != "" {
+			info["provider_name"] = name
 		}
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -1,9 +1,14 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
 
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -34,6 +39,7 @@ func (h *OIDCMappingHandlers) RegisterRoutes(r *mux.Router) {
 	writeRoutes := r.PathPrefix("").Subrouter()
 	writeRoutes.Use(h.rbacWriteGuard)
 	writeRoutes.HandleFunc("/api/settings/oidc/mappings", h.createMapping).Methods("POST")
+	writeRoutes.HandleFunc("/api/settings/oidc/mappings/preview", h.previewMappings).Methods("POST")
 	writeRoutes.HandleFunc("/api/settings/oidc/mappings/{id}", h.deleteMapping).Methods("DELETE")
 	writeRoutes.HandleFunc("/api/settings/oidc/default-role", h.updateDefaultRole).Methods("PUT")
 }
@@ -56,34 +62,153 @@ type createMappingRequest struct {
 }
 
 func (h *OIDCMappingHandlers) listMappings(w http.ResponseWriter, r *http.Request) {
-	rows, err := h.pool.Query(r.Context(),
+	mappings, err := h.loadMappings(r.Context())
+	if err != nil {
+		log.Printf("oidc mapping: %v", err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to list mappings")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, mappings)
+}
+
+// loadMappings returns every mapping ordered by group and role name.
+func (h *OIDCMappingHandlers) loadMappings(ctx context.Context) ([]mappingResponse, error) {
+	rows, err := h.pool.Query(ctx,
 		`SELECT m.id, m.oidc_group, m.role_id, r.name, m.cluster_id, m.namespace, m.created_at
 		 FROM oidc_role_mappings m
 		 JOIN roles r ON m.role_id = r.id
 		 ORDER BY m.oidc_group, r.name`)
 	if err != nil {
-		httputil.WriteError(w, http.StatusInternalServerError, "failed to list mappings")
-		return
+		return nil, fmt.Errorf("listing mappings: %w", err)
 	}
 	defer rows.Close()
 
-	var mappings []mappingResponse
+	mappings := []mappingResponse{}
 	for rows.Next() {
 		var m mappingResponse
 		if err := rows.Scan(&m.ID, &m.OIDCGroup, &m.RoleID, &m.RoleName, &m.ClusterID, &m.Namespace, &m.CreatedAt); err != nil {
-			httputil.WriteError(w, http.StatusInternalServerError, "failed to scan mapping")
-			return
+			return nil, fmt.Errorf("scanning mapping: %w", err)
 		}
 		mappings = append(mappings, m)
 	}
 	if err := rows.Err(); err != nil {
-		httputil.WriteError(w, http.StatusInternalServerError, "failed to iterate mappings")
+		return nil, fmt.Errorf("iterating mappings: %w", err)
+	}
+	return mappings, nil
+}
+
+type previewMappingRequest struct {
+	Groups  []string `json:"groups"`
+	IDToken string   `json:"id_token"`
+}
+
+// roleAssignment is a role a login would grant, at its cluster and
+// namespace scope.
+type roleAssignment struct {
+	RoleName  string  `json:"role_name"`
+	ClusterID *string `json:"cluster_id,omitempty"`
+	Namespace *string `json:"namespace,omitempty"`
+}
+
+// mappingPreview is what a login with the given groups would do.
+// DefaultRole is only granted when the login assigns no role and the user
+// has none from before.
+type mappingPreview struct {
+	Groups          []string          `json:"groups"`
+	GroupsClaim     string            `json:"groups_claim,omitempty"`
+	Matches         []mappingResponse `json:"matches"`
+	Roles           []roleAssignment  `json:"roles"`
+	UnmatchedGroups []string          `json:"unmatched_groups"`
+	DefaultRole     string            `json:"default_role,omitempty"`
+}
+
+// previewMappings reports which mappings a set of groups, or the groups in a
+// pasted ID token, would fire and the roles they would assign. Nothing is
+// assigned. The token's signature is not checked: only its groups claim is
+// read, using the configured claim name.
+func (h *OIDCMappingHandlers) previewMappings(w http.ResponseWriter, r *http.Request) {
+	var req previewMappingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
-	if mappings == nil {
-		mappings = []mappingResponse{}
+	if (req.IDToken == "") == (req.Groups == nil) {
+		httputil.WriteError(w, http.StatusBadRequest, "exactly one of groups and id_token is required")
+		return
 	}
-	httputil.WriteJSON(w, http.StatusOK, mappings)
+
+	groups, claim := req.Groups, ""
+	if req.IDToken != "" {
+		claims := jwt.MapClaims{}
+		if _, _, err := jwt.NewParser().ParseUnverified(strings.TrimSpace(req.IDToken), claims); err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, "id_token is not a JWT")
+			return
+		}
+		settings, _ := loadOIDCSettings(r.Context(), h.pool)
+		groups, claim = groupsFromClaims(claims, settings), settings.withDefaults().GroupsClaim
+	}
+
+	mappings, err := h.loadMappings(r.Context())
+	if err != nil {
+		log.Printf("oidc mapping: %v", err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to list mappings")
+		return
+	}
+	preview := previewGroupMappings(mappings, groups)
+	preview.GroupsClaim = claim
+	if len(preview.Roles) == 0 {
+		if role := h.defaultRole(r.Context()); role != "none" {
+			preview.DefaultRole = role
+		}
+	}
+	httputil.WriteJSON(w, http.StatusOK, preview)
+}
+
+// previewGroupMappings matches groups against mappings the way
+// OIDCGroupMapper.MapGroupsToRoles does: a mapping fires when its group is
+// one of the user's groups, exactly.
+func previewGroupMappings(mappings []mappingResponse, groups []string) mappingPreview {
+	preview := mappingPreview{
+		Groups:          []string{},
+		Matches:         []mappingResponse{},
+		Roles:           []roleAssignment{},
+		UnmatchedGroups: []string{},
+	}
+	matched := map[string]bool{}
+	for _, g := range groups {
+		if _, dup := matched[g]; g != "" && !dup {
+			preview.Groups = append(preview.Groups, g)
+			matched[g] = false
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, m := range mappings {
+		if _, ok := matched[m.OIDCGroup]; !ok {
+			continue
+		}
+		matched[m.OIDCGroup] = true
+		preview.Matches = append(preview.Matches, m)
+
+		key := m.RoleName + "\x00" + derefOr(m.ClusterID, "*") + "\x00" + derefOr(m.Namespace, "*")
+		if !seen[key] {
+			seen[key] = true
+			preview.Roles = append(preview.Roles, roleAssignment{RoleName: m.RoleName, ClusterID: m.ClusterID, Namespace: m.Namespace})
+		}
+	}
+	for _, g := range preview.Groups {
+		if !matched[g] {
+			preview.UnmatchedGroups = append(preview.UnmatchedGroups, g)
+		}
+	}
+	return preview
+}
+
+func derefOr(s *string, fallback string) string {
+	if s == nil {
+		return fallback
+	}
+	return *s
 }
 
 func (h *OIDCMappingHandlers) createMapping(w http.ResponseWriter, r *http.Request) {
@@ -140,8 +265,13 @@ func (h *OIDCMappingHandlers) deleteMapping(w http.ResponseWriter, r *http.Reque
 }
 
 func (h *OIDCMappingHandlers) getDefaultRole(w http.ResponseWriter, r *http.Request) {
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"default_role": h.defaultRole(r.Context())})
+}
+
+// defaultRole returns the configured OIDC default role, or "" if none is set.
+func (h *OIDCMappingHandlers) defaultRole(ctx context.Context) string {
 	var raw []byte
-	err := h.pool.QueryRow(r.Context(),
+	err := h.pool.QueryRow(ctx,
 		`SELECT value FROM settings WHERE key = $1`, "oidc_default_role",
 	).Scan(&raw)
 
@@ -149,7 +279,7 @@ func (h *OIDCMappingHandlers) getDefaultRole(w http.ResponseWriter, r *http.Requ
 	if err == nil {
 		json.Unmarshal(raw, &role) //nolint:errcheck // best-effort decode
 	}
-	httputil.WriteJSON(w, http.StatusOK, map[string]string{"default_role": role})
+	return role
 }
 
 // allowedDefaultRoles is the set of valid values for the OIDC default role.
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -69,6 +70,7 @@ func TestOIDCMappingHandlers_RegisterRoutes(t *testing.T) {
 	}{
 		{"/api/settings/oidc/mappings", "GET"},
 		{"/api/settings/oidc/mappings", "POST"},
+		{"/api/settings/oidc/mappings/preview", "POST"},
 		{"/api/settings/oidc/mappings/some-id", "DELETE"},
 		{"/api/settings/oidc/default-role", "GET"},
 		{"/api/settings/oidc/default-role", "PUT"},
@@ -337,3 +339,58 @@ func contains(s, substr string) bool {
 	}
 	return false
 }
+
+// --- previewMappings ---
+
+func TestPreviewMappings_InvalidRequests_Return400(t *testing.T) {
+	h := newMappingHandlers()
+	for _, body := range []string{
+		"{invalid",
+		`{}`,
+		`{"groups":["dev"],"id_token":"a.b.c"}`,
+		`{"id_token":"not-a-token"}`,
+	} {
+		req := httptest.NewRequest("POST", "/api/settings/oidc/mappings/preview", bytes.NewBufferString(body))
+		rec, panicked := callMappingHandler(h.previewMappings, req)
+		if panicked || rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d (panicked=%v)", body, rec.Code, panicked)
+		}
+	}
+}
+
+func TestPreviewMappings_Token_PanicsAtDB(t *testing.T) {
+	h := newMappingHandlers()
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"u1","groups":["dev"]}`))
+	body, _ := json.Marshal(previewMappingRequest{IDToken: "eyJhbGciOiJSUzI1NiJ9." + payload + ".sig"})
+	req := httptest.NewRequest("POST", "/api/settings/oidc/mappings/preview", bytes.NewBuffer(body))
+
+	if _, panicked := callMappingHandler(h.previewMappings, req); !panicked {
+		t.Error("expected an unverified token to be accepted and the handler to reach the DB")
+	}
+}
+
+func TestPreviewGroupMappings(t *testing.T) {
+	prod, ns := "prod", "shop"
+	mappings := []mappingResponse{
+		{ID: "m1", OIDCGroup: "admins", RoleName: "admin"},
+		{ID: "m2", OIDCGroup: "dev", RoleName: "developer", ClusterID: &prod, Namespace: &ns},
+		{ID: "m3", OIDCGroup: "dev-leads", RoleName: "developer", ClusterID: &prod, Namespace: &ns},
+		{ID: "m4", OIDCGroup: "ops", RoleName: "operator"},
+	}
+
+	p := previewGroupMappings(mappings, []string{"dev", "dev-leads", "dev", "sales", ""})
+	if len(p.Groups) != 3 || len(p.Matches) != 2 || p.Matches[0].ID != "m2" || p.Matches[1].ID != "m3" {
+		t.Fatalf("unexpected matches %+v", p)
+	}
+	if len(p.Roles) != 1 || p.Roles[0].RoleName != "developer" || *p.Roles[0].Namespace != "shop" {
+		t.Errorf("expected one developer assignment in shop, got %+v", p.Roles)
+	}
+	if len(p.UnmatchedGroups) != 1 || p.UnmatchedGroups[0] != "sales" {
+		t.Errorf("expected sales to be unmatched, got %v", p.UnmatchedGroups)
+	}
+
+	// Matching is exact, like at login.
+	if p := previewGroupMappings(mappings, []string{"Admins", "admin"}); len(p.Matches) != 0 || len(p.UnmatchedGroups) != 2 {
+		t.Errorf("expected no matches, got %+v", p)
+	}
+}
@@ -1,11 +1,15 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/gorilla/mux"
 )
 
@@ -35,6 +39,36 @@ func TestOIDCServiceEnabled(t *testing.T) {
 	}
 }
 
+func TestOIDCServiceCheckDiscovery(t *testing.T) {
+	var issuer string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                 issuer,
+			"authorization_endpoint": issuer + "/authorize",
+			"token_endpoint":         issuer + "/token",
+			"jwks_uri":               issuer + "/keys",
+		})
+	}))
+	issuer = srv.URL
+
+	provider, err := oidc.NewProvider(context.Background(), issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := &OIDCService{provider: provider}
+	if err := svc.CheckDiscovery(context.Background()); err != nil {
+		t.Errorf("expected discovery to succeed, got %v", err)
+	}
+
+	srv.Close()
+	if err := svc.CheckDiscovery(context.Background()); err == nil {
+		t.Error("expected an unreachable issuer to fail")
+	}
+	if err := (&OIDCService{}).CheckDiscovery(context.Background()); err == nil {
+		t.Error("expected an error when OIDC is not configured")
+	}
+}
+
 // State generation/validation tests require a database connection.
 // These are covered by integration tests.
 
@@ -234,3 +268,60 @@ func TestOIDCFrontendURLCustom(t *testing.T) {
 		t.Errorf("expected custom frontend URL, got %s", cfg.FrontendURL)
 	}
 }
+
+func TestOIDCSettingsScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings oidcSettings
+		want     []string
+	}{
+		{name: "defaults", want: []string{"openid", "profile", "email"}},
+		{name: "extra", settings: oidcSettings{ExtraScopes: []string{"offline_access", "email"}}, want: []string{"openid", "profile", "email", "offline_access"}},
+		{name: "override", settings: oidcSettings{Scopes: []string{"openid", "User.Read"}}, want: []string{"openid", "User.Read"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.settings.scopes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIdentityFromClaims(t *testing.T) {
+	claims := map[string]interface{}{
+		"sub":                "s-1",
+		"oid":                "o-1",
+		"email":              "a@example.com",
+		"preferred_username": "a@corp.example.com",
+		"roles":              []interface{}{"admins", 7, "devs"},
+	}
+
+	id, err := identityFromClaims(claims, oidcSettings{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id.Subject != "s-1" || id.Email != "a@example.com" || id.Name != "a@example.com" || id.Groups != nil {
+		t.Errorf("unexpected identity from standard claims: %+v", id)
+	}
+
+	id, err = identityFromClaims(claims, oidcSettings{SubjectClaim: "oid", EmailClaim: "preferred_username", GroupsClaim: "roles"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id.Subject != "o-1" || id.Email != "a@corp.example.com" || !reflect.DeepEqual(id.Groups, []string{"admins", "devs"}) {
+		t.Errorf("unexpected identity from configured claims: %+v", id)
+	}
+}
+
+func TestIdentityFromClaims_MissingClaim(t *testing.T) {
+	_, err := identityFromClaims(map[string]interface{}{"sub": "s-1"}, oidcSettings{EmailClaim: "upn"})
+	if err == nil || !strings.Contains(err.Error(), `"upn"`) {
+		t.Errorf("expected an error naming the upn claim, got %v", err)
+	}
+
+	_, err = identityFromClaims(map[string]interface{}{"email": "a@example.com"}, oidcSettings{})
+	if err == nil || !strings.Contains(err.Error(), `"sub"`) {
+		t.Errorf("expected an error naming the sub claim, got %v", err)
+	}
+}
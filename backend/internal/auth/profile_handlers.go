@@ -13,6 +13,8 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/crypto/bcrypt"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 // passwordChangeLimiter tracks the last password change per user to prevent abuse.
@@ -52,7 +54,7 @@ type UserPreferences struct {
 func (h *ProfileHandlers) handleUpdateProfile(w http.ResponseWriter, r *http.Request) {
 	claims, ok := ClaimsFromContext(r.Context())
 	if !ok {
-		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
@@ -61,7 +63,7 @@ func (h *ProfileHandlers) handleUpdateProfile(w http.ResponseWriter, r *http.Req
 		Email       string `json:"email"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
@@ -70,17 +72,17 @@ func (h *ProfileHandlers) handleUpdateProfile(w http.ResponseWriter, r *http.Req
 	var authProvider string
 	err := h.pool.QueryRow(ctx, "SELECT auth_provider FROM users WHERE id = $1", claims.UserID).Scan(&authProvider)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to fetch user"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to fetch user")
 		return
 	}
 
 	if req.Email != "" && authProvider == "oidc" {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "OIDC users cannot change their email"})
+		httputil.WriteError(w, http.StatusBadRequest, "OIDC users cannot change their email")
 		return
 	}
 	if req.Email != "" {
 		if _, err := mail.ParseAddress(req.Email); err != nil {
-			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid email format"})
+			httputil.WriteError(w, http.StatusBadRequest, "invalid email format")
 			return
 		}
 	}
@@ -99,7 +101,7 @@ func (h *ProfileHandlers) handleUpdateProfile(w http.ResponseWriter, r *http.Req
 		argIdx++
 	}
 	if len(setClauses) == 0 {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "no fields to update"})
+		httputil.WriteError(w, http.StatusBadRequest, "no fields to update")
 		return
 	}
 	args = append(args, claims.UserID)
@@ -110,7 +112,7 @@ func (h *ProfileHandlers) handleUpdateProfile(w http.ResponseWriter, r *http.Req
 	err = h.pool.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Email, &user.DisplayName, &user.AuthProvider, &user.CreatedAt)
 	if err != nil {
 		log.Printf("ERROR: failed to update profile for user %s: %v", claims.UserID, err)
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to update profile"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to update profile")
 		return
 	}
 
@@ -122,14 +124,14 @@ func (h *ProfileHandlers) handleUpdateProfile(w http.ResponseWriter, r *http.Req
 func (h *ProfileHandlers) handleChangePassword(w http.ResponseWriter, r *http.Request) {
 	claims, ok := ClaimsFromContext(r.Context())
 	if !ok {
-		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	// Per-user rate limit: 1 password change per 60 seconds
 	if lastChange, ok := passwordChangeLimiter.Load(claims.UserID); ok {
 		if time.Since(lastChange.(time.Time)) < passwordChangeCooldown {
-			writeJSON(w, http.StatusTooManyRequests, errorResponse{Error: "please wait before changing your password again"})
+			httputil.WriteError(w, http.StatusTooManyRequests, "please wait before changing your password again")
 			return
 		}
 	}
@@ -139,15 +141,15 @@ func (h *ProfileHandlers) handleChangePassword(w http.ResponseWriter, r *http.Re
 		NewPassword     string `json:"new_password"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 	if req.CurrentPassword == "" || req.NewPassword == "" {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "current_password and new_password are required"})
+		httputil.WriteError(w, http.StatusBadRequest, "current_password and new_password are required")
 		return
 	}
 	if len(req.NewPassword) < 8 {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "new password must be at least 8 characters"})
+		httputil.WriteError(w, http.StatusBadRequest, "new password must be at least 8 characters")
 		return
 	}
 
@@ -159,29 +161,29 @@ func (h *ProfileHandlers) handleChangePassword(w http.ResponseWriter, r *http.Re
 		claims.UserID,
 	).Scan(&authProvider, &passwordHash)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to fetch user"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to fetch user")
 		return
 	}
 	if authProvider == "oidc" {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "OIDC users cannot change their password"})
+		httputil.WriteError(w, http.StatusBadRequest, "OIDC users cannot change their password")
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.CurrentPassword)); err != nil {
-		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "current password is incorrect"})
+		httputil.WriteError(w, http.StatusUnauthorized, "current password is incorrect")
 		return
 	}
 
 	newHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to hash password"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to hash password")
 		return
 	}
 
 	_, err = h.pool.Exec(ctx, "UPDATE users SET password_hash = $1 WHERE id = $2", string(newHash), claims.UserID)
 	if err != nil {
 		log.Printf("ERROR: failed to change password for user %s: %v", claims.UserID, err)
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to change password"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to change password")
 		return
 	}
 
@@ -194,7 +196,7 @@ func (h *ProfileHandlers) handleChangePassword(w http.ResponseWriter, r *http.Re
 func (h *ProfileHandlers) handleGetPreferences(w http.ResponseWriter, r *http.Request) {
 	claims, ok := ClaimsFromContext(r.Context())
 	if !ok {
-		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
@@ -222,19 +224,19 @@ func (h *ProfileHandlers) handleGetPreferences(w http.ResponseWriter, r *http.Re
 func (h *ProfileHandlers) handleSetPreferences(w http.ResponseWriter, r *http.Request) {
 	claims, ok := ClaimsFromContext(r.Context())
 	if !ok {
-		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	var req UserPreferences
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	validThemes := map[string]bool{"dark": true, "light": true, "system": true}
 	if req.Theme != "" && !validThemes[req.Theme] {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid theme value"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid theme value")
 		return
 	}
 	if req.Theme == "" {
@@ -257,7 +259,7 @@ func (h *ProfileHandlers) handleSetPreferences(w http.ResponseWriter, r *http.Re
 	)
 	if err != nil {
 		log.Printf("ERROR: failed to save preferences for user %s: %v", claims.UserID, err)
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to save preferences"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to save preferences")
 		return
 	}
 
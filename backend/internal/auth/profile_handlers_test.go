@@ -9,6 +9,8 @@ import (
 	"testing"
 
 	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 // callProfileHandlerSafe calls a handler with panic recovery.
@@ -172,7 +174,7 @@ func TestHandleChangePassword_TooShort(t *testing.T) {
 		t.Errorf("expected 400 for short password, got %d", rec.Code)
 	}
 
-	var resp errorResponse
+	var resp httputil.ErrorResponse
 	json.NewDecoder(rec.Body).Decode(&resp)
 	if resp.Error != "new password must be at least 8 characters" {
 		t.Errorf("expected short password error, got %q", resp.Error)
@@ -290,7 +292,7 @@ func TestHandleSetPreferences_InvalidTheme(t *testing.T) {
 		t.Errorf("expected 400 for invalid theme, got %d", rec.Code)
 	}
 
-	var resp errorResponse
+	var resp httputil.ErrorResponse
 	json.NewDecoder(rec.Body).Decode(&resp)
 	if resp.Error != "invalid theme value" {
 		t.Errorf("expected 'invalid theme value' error, got %q", resp.Error)
@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 // --- Password Security Tests ---
@@ -214,7 +216,7 @@ func TestLoginReturnsGenericErrorMessage(t *testing.T) {
 	}
 
 	// If we got a response, it should say "invalid credentials" not "user not found"
-	var resp errorResponse
+	var resp httputil.ErrorResponse
 	json.NewDecoder(rec.Body).Decode(&resp)
 	if strings.Contains(strings.ToLower(resp.Error), "not found") {
 		t.Error("SECURITY: login error reveals user existence (says 'not found')")
@@ -23,14 +23,16 @@ type User struct {
 }
 
 type AuthService struct {
-	db  *db.DB
-	jwt *JWTService
+	db       *db.DB
+	jwt      *JWTService
+	sessions *SessionStore
 }
 
 func NewAuthService(database *db.DB, jwtService *JWTService) *AuthService {
 	return &AuthService{
-		db:  database,
-		jwt: jwtService,
+		db:       database,
+		jwt:      jwtService,
+		sessions: NewSessionStore(database),
 	}
 }
 
@@ -78,7 +80,7 @@ func (s *AuthService) RevokeRefreshToken(ctx context.Context, tokenString string
 		return fmt.Errorf("failed to revoke token: %w", err)
 	}
 
-	return nil
+	return s.sessions.Forget(ctx, jti)
 }
 
 // IsTokenRevoked checks whether the given JTI has been revoked.
@@ -94,8 +96,9 @@ func (s *AuthService) IsTokenRevoked(ctx context.Context, jti string) (bool, err
 	return exists, nil
 }
 
-// CleanupExpiredTokens removes revoked token entries that have passed their
-// expiration time. This should be called periodically to keep the table small.
+// CleanupExpiredTokens removes revoked token entries and sessions that have
+// passed their expiration time. This should be called periodically to keep
+// the tables small.
 func (s *AuthService) CleanupExpiredTokens(ctx context.Context) (int64, error) {
 	result, err := s.db.Pool.Exec(ctx,
 		`DELETE FROM revoked_tokens WHERE expires_at < NOW()`,
@@ -103,37 +106,60 @@ func (s *AuthService) CleanupExpiredTokens(ctx context.Context) (int64, error) {
 	if err != nil {
 		return 0, fmt.Errorf("failed to cleanup expired tokens: %w", err)
 	}
-	return result.RowsAffected(), nil
+	sessions, err := s.sessions.CleanupExpired(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected() + sessions, nil
 }
 
 func (s *AuthService) Login(ctx context.Context, email, password string) (string, string, error) {
+	id, err := s.Authenticate(ctx, email, password)
+	if err != nil {
+		return "", "", err
+	}
+	return s.IssueTokens(ctx, id, email)
+}
+
+// Authenticate checks a local account's password and returns the user ID.
+// It issues no tokens, so callers can require a second factor first.
+func (s *AuthService) Authenticate(ctx context.Context, email, password string) (string, error) {
 	var id, storedHash string
 	err := s.db.Pool.QueryRow(ctx,
 		`SELECT id, password_hash FROM users WHERE email = $1 AND auth_provider = 'local'`,
 		email,
 	).Scan(&id, &storedHash)
 	if err != nil {
-		return "", "", fmt.Errorf("invalid credentials")
+		return "", fmt.Errorf("invalid credentials")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(password)); err != nil {
-		return "", "", fmt.Errorf("invalid credentials")
+		return "", fmt.Errorf("invalid credentials")
 	}
+	return id, nil
+}
 
-	_, err = s.db.Pool.Exec(ctx, `UPDATE users SET last_login = NOW() WHERE id = $1`, id)
+// IssueTokens records the login and returns a new access and refresh token
+// pair for an authenticated user. The refresh token starts a session under
+// the session policy, which may revoke the user's oldest sessions.
+func (s *AuthService) IssueTokens(ctx context.Context, userID, email string) (string, string, error) {
+	_, err := s.db.Pool.Exec(ctx, `UPDATE users SET last_login = NOW() WHERE id = $1`, userID)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to update last login: %w", err)
 	}
 
-	accessToken, err := s.jwt.GenerateToken(id, email)
+	accessToken, err := s.jwt.GenerateToken(userID, email)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	refreshToken, err := s.jwt.GenerateRefreshToken(id)
+	refreshToken, refreshClaims, err := s.jwt.generateRefreshToken(userID)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
 	}
+	if err := s.sessions.Track(ctx, refreshClaims); err != nil {
+		return "", "", err
+	}
 
 	return accessToken, refreshToken, nil
 }
@@ -153,6 +179,9 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (st
 		if revoked {
 			return "", fmt.Errorf("refresh token has been revoked")
 		}
+		if err := s.sessions.Touch(ctx, claims); err != nil {
+			return "", err
+		}
 	}
 
 	var email string
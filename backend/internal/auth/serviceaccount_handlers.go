@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// ServiceAccountHandlers provides admin endpoints for service account tokens.
+type ServiceAccountHandlers struct {
+	service        *ServiceAccountService
+	rbacReadGuard  mux.MiddlewareFunc
+	rbacWriteGuard mux.MiddlewareFunc
+}
+
+// NewServiceAccountHandlers creates service account handlers. Listing is
+// guarded by rbacReadGuard; creating and revoking tokens by rbacWriteGuard.
+func NewServiceAccountHandlers(service *ServiceAccountService, rbacReadGuard, rbacWriteGuard mux.MiddlewareFunc) *ServiceAccountHandlers {
+	return &ServiceAccountHandlers{service: service, rbacReadGuard: rbacReadGuard, rbacWriteGuard: rbacWriteGuard}
+}
+
+func (h *ServiceAccountHandlers) RegisterRoutes(r *mux.Router) {
+	readRoutes := r.PathPrefix("").Subrouter()
+	if h.rbacReadGuard != nil {
+		readRoutes.Use(h.rbacReadGuard)
+	}
+	readRoutes.HandleFunc("/api/service-accounts", h.handleList).Methods("GET")
+
+	writeRoutes := r.PathPrefix("").Subrouter()
+	if h.rbacWriteGuard != nil {
+		writeRoutes.Use(h.rbacWriteGuard)
+	}
+	writeRoutes.HandleFunc("/api/service-accounts", h.handleCreate).Methods("POST")
+	writeRoutes.HandleFunc("/api/service-accounts/{id}", h.handleRevoke).Methods("DELETE")
+}
+
+type createServiceAccountRequest struct {
+	Name          string  `json:"name"`
+	Description   string  `json:"description"`
+	Role          string  `json:"role"`
+	ClusterID     *string `json:"cluster_id,omitempty"`
+	Namespace     *string `json:"namespace,omitempty"`
+	ExpiresInDays int     `json:"expires_in_days"`
+}
+
+func (h *ServiceAccountHandlers) handleCreate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	// A scoped token must not be able to mint a broader one.
+	if _, isServiceAccount := claims.ServiceAccountID(); isServiceAccount {
+		httputil.WriteError(w, http.StatusForbidden, "service accounts cannot create service accounts")
+		return
+	}
+
+	var req createServiceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Name == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if len(req.Name) > 255 {
+		httputil.WriteError(w, http.StatusBadRequest, "name must be 255 characters or less")
+		return
+	}
+	if req.Role == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "role is required")
+		return
+	}
+	if req.ClusterID != nil && *req.ClusterID == "" {
+		req.ClusterID = nil
+	}
+	if req.Namespace != nil && *req.Namespace == "" {
+		req.Namespace = nil
+	}
+	if req.Namespace != nil && req.ClusterID == nil {
+		httputil.WriteError(w, http.StatusBadRequest, "namespace scope requires cluster_id")
+		return
+	}
+	if req.ExpiresInDays < 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "expires_in_days must not be negative")
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	resp, err := h.service.Create(r.Context(), CreateServiceAccountRequest{
+		Name:        req.Name,
+		Description: req.Description,
+		RoleName:    req.Role,
+		ClusterID:   req.ClusterID,
+		Namespace:   req.Namespace,
+		CreatedBy:   &claims.UserID,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errUnknownRole):
+			httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		case strings.Contains(err.Error(), "duplicate key"):
+			httputil.WriteError(w, http.StatusConflict, "a service account with this name already exists")
+		default:
+			httputil.WriteError(w, http.StatusInternalServerError, "failed to create service account")
+		}
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusCreated, resp)
+}
+
+func (h *ServiceAccountHandlers) handleList(w http.ResponseWriter, r *http.Request) {
+	accounts, err := h.service.List(r.Context())
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to list service accounts")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, accounts)
+}
+
+func (h *ServiceAccountHandlers) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := h.service.Revoke(r.Context(), id); err != nil {
+		if errors.Is(err, ErrServiceAccountNotFound) {
+			httputil.WriteError(w, http.StatusNotFound, "service account not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to revoke service account")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestServiceAccountHandleCreateValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"bad json", "not json", "invalid request body"},
+		{"empty name", `{"name":"","role":"viewer"}`, "name is required"},
+		{"name too long", `{"name":"` + strings.Repeat("a", 256) + `","role":"viewer"}`, "name must be 255 characters or less"},
+		{"missing role", `{"name":"ci"}`, "role is required"},
+		{"namespace without cluster", `{"name":"ci","role":"viewer","namespace":"staging"}`, "namespace scope requires cluster_id"},
+		{"negative expiry", `{"name":"ci","role":"viewer","expires_in_days":-1}`, "expires_in_days must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewServiceAccountHandlers(&ServiceAccountService{}, nil, nil)
+			req := newAPIKeyHandlerRequest("POST", "/api/service-accounts", []byte(tt.body), "user1", "admin@test.com")
+			rec := httptest.NewRecorder()
+
+			h.handleCreate(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", rec.Code)
+			}
+			var resp map[string]string
+			json.Unmarshal(rec.Body.Bytes(), &resp) //nolint:errcheck
+			if resp["error"] != tt.wantErr {
+				t.Errorf("expected %q, got %q", tt.wantErr, resp["error"])
+			}
+		})
+	}
+}
+
+func TestServiceAccountHandleCreateNoClaims(t *testing.T) {
+	h := NewServiceAccountHandlers(&ServiceAccountService{}, nil, nil)
+
+	req := httptest.NewRequest("POST", "/api/service-accounts", nil)
+	rec := httptest.NewRecorder()
+
+	h.handleCreate(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", rec.Code)
+	}
+}
+
+func TestServiceAccountHandleCreateByServiceAccountForbidden(t *testing.T) {
+	h := NewServiceAccountHandlers(&ServiceAccountService{}, nil, nil)
+
+	body := []byte(`{"name":"escalate","role":"admin"}`)
+	req := newAPIKeyHandlerRequest("POST", "/api/service-accounts", body, ServiceAccountPrincipalPrefix+"sa-1", "serviceaccount:ci")
+	rec := httptest.NewRecorder()
+
+	h.handleCreate(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", rec.Code)
+	}
+}
+
+func TestServiceAccountHandlersRegisterRoutes(t *testing.T) {
+	h := NewServiceAccountHandlers(&ServiceAccountService{}, nil, nil)
+	r := mux.NewRouter()
+	h.RegisterRoutes(r)
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/api/service-accounts"},
+		{"POST", "/api/service-accounts"},
+		{"DELETE", "/api/service-accounts/sa-1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if !r.Match(req, &mux.RouteMatch{}) {
+			t.Errorf("expected route %s %s to match", tt.method, tt.path)
+		}
+	}
+}
+
+func TestServiceAccountHandlersGuards(t *testing.T) {
+	var guarded []string
+	guard := func(name string) mux.MiddlewareFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				guarded = append(guarded, name)
+				w.WriteHeader(http.StatusForbidden)
+			})
+		}
+	}
+	h := NewServiceAccountHandlers(&ServiceAccountService{}, guard("read"), guard("write"))
+	r := mux.NewRouter()
+	h.RegisterRoutes(r)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/api/service-accounts", nil),
+		httptest.NewRequest("POST", "/api/service-accounts", nil),
+		httptest.NewRequest("DELETE", "/api/service-accounts/sa-1", nil),
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	want := []string{"read", "write", "write"}
+	if strings.Join(guarded, ",") != strings.Join(want, ",") {
+		t.Errorf("expected guards %v, got %v", want, guarded)
+	}
+}
+
+func TestGenerateServiceAccountToken(t *testing.T) {
+	token, prefix, err := generateServiceAccountToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(token, ServiceAccountTokenPrefix) {
+		t.Errorf("token should start with %q", ServiceAccountTokenPrefix)
+	}
+	if prefix != token[:len(ServiceAccountTokenPrefix)+8] {
+		t.Errorf("unexpected prefix %q", prefix)
+	}
+
+	other, _, _ := generateServiceAccountToken()
+	if token == other {
+		t.Error("two generated tokens should not be identical")
+	}
+	if hashServiceAccountToken(token) == hashServiceAccountToken(other) {
+		t.Error("different tokens should hash differently")
+	}
+	if len(hashServiceAccountToken(token)) != 64 {
+		t.Error("expected a hex SHA-256 hash")
+	}
+}
+
+func TestClaimsServiceAccountID(t *testing.T) {
+	id, ok := (&Claims{UserID: ServiceAccountPrincipalPrefix + "sa-1"}).ServiceAccountID()
+	if !ok || id != "sa-1" {
+		t.Errorf("expected sa-1, got %q (ok=%v)", id, ok)
+	}
+	if _, ok := (&Claims{UserID: "user-1"}).ServiceAccountID(); ok {
+		t.Error("user claims should not be a service account")
+	}
+	var nilClaims *Claims
+	if _, ok := nilClaims.ServiceAccountID(); ok {
+		t.Error("nil claims should not be a service account")
+	}
+}
+
+func TestServiceAccountValidateRejectsOtherFormats(t *testing.T) {
+	s := &ServiceAccountService{}
+	if _, err := s.Validate(t.Context(), "argus_0123456789abcdef"); err == nil {
+		t.Error("expected API keys to be rejected")
+	}
+}
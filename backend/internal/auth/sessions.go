@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/darkden-lab/argus/backend/internal/db"
+)
+
+// ErrSessionIdle is returned when a refresh token is used after its session
+// has been idle for longer than the policy allows.
+var ErrSessionIdle = errors.New("session expired due to inactivity")
+
+// SessionPolicySettingKey is the settings table key holding the SessionPolicy.
+const SessionPolicySettingKey = "session_policy"
+
+// SessionPolicy is the admin-controlled policy for login sessions. A session
+// is one refresh token, from the login that issued it until it expires, is
+// revoked, or is ended by the policy. The zero value enforces nothing.
+type SessionPolicy struct {
+	// IdleTimeoutMinutes ends a session whose refresh token has not been
+	// used for this long. Clients only refresh when their access token
+	// expires, so it must be at least the access token lifetime. 0 disables
+	// the timeout.
+	IdleTimeoutMinutes int `json:"idle_timeout_minutes"`
+	// MaxSessionsPerUser caps how many sessions a user holds at once. A new
+	// login beyond the cap revokes the user's oldest sessions. 0 means no
+	// limit.
+	MaxSessionsPerUser int `json:"max_sessions_per_user"`
+}
+
+// Validate checks the policy's bounds.
+func (p SessionPolicy) Validate() error {
+	switch {
+	case p.IdleTimeoutMinutes < 0:
+		return fmt.Errorf("idle_timeout_minutes must not be negative")
+	case p.IdleTimeoutMinutes > 0 && p.idleTimeout() < accessTokenDuration:
+		return fmt.Errorf("idle_timeout_minutes must be at least %d, the access token lifetime", int(accessTokenDuration/time.Minute))
+	case p.MaxSessionsPerUser < 0:
+		return fmt.Errorf("max_sessions_per_user must not be negative")
+	}
+	return nil
+}
+
+func (p SessionPolicy) idleTimeout() time.Duration {
+	return time.Duration(p.IdleTimeoutMinutes) * time.Minute
+}
+
+// idle reports whether a session last used at lastUsed has exceeded the idle
+// timeout at now.
+func (p SessionPolicy) idle(lastUsed, now time.Time) bool {
+	return p.IdleTimeoutMinutes > 0 && now.Sub(lastUsed) > p.idleTimeout()
+}
+
+// SessionStore tracks live refresh tokens in the refresh_sessions table and
+// enforces the SessionPolicy on them. Ended sessions are recorded in
+// revoked_tokens, which RefreshToken already checks.
+type SessionStore struct {
+	db *db.DB
+}
+
+func NewSessionStore(database *db.DB) *SessionStore {
+	return &SessionStore{db: database}
+}
+
+// Policy returns the current session policy. A missing setting enforces
+// nothing.
+func (s *SessionStore) Policy(ctx context.Context) (SessionPolicy, error) {
+	var policy SessionPolicy
+	var raw []byte
+	err := s.db.Pool.QueryRow(ctx, `SELECT value FROM settings WHERE key = $1`, SessionPolicySettingKey).Scan(&raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return policy, nil
+	}
+	if err != nil {
+		return policy, fmt.Errorf("failed to load session policy: %w", err)
+	}
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		return policy, fmt.Errorf("failed to decode session policy: %w", err)
+	}
+	return policy, nil
+}
+
+// Track records a newly issued refresh token as a session and, when the
+// policy caps concurrent sessions, revokes the user's oldest sessions
+// beyond the cap.
+func (s *SessionStore) Track(ctx context.Context, claims *Claims) error {
+	policy, err := s.Policy(ctx)
+	if err != nil {
+		return err
+	}
+	return s.track(ctx, claims, policy)
+}
+
+func (s *SessionStore) track(ctx context.Context, claims *Claims, policy SessionPolicy) error {
+	_, err := s.db.Pool.Exec(ctx,
+		`INSERT INTO refresh_sessions (token_jti, user_id, created_at, expires_at)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT DO NOTHING`,
+		claims.RegisteredClaims.ID, claims.UserID, claims.IssuedAt.Time, claims.ExpiresAt.Time,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record session: %w", err)
+	}
+	if policy.MaxSessionsPerUser == 0 {
+		return nil
+	}
+
+	_, err = s.db.Pool.Exec(ctx,
+		`WITH ended AS (
+		   DELETE FROM refresh_sessions WHERE token_jti IN (
+		     SELECT token_jti FROM refresh_sessions
+		     WHERE user_id = $1 AND expires_at > NOW()
+		     ORDER BY created_at DESC, token_jti
+		     OFFSET $2)
+		   RETURNING token_jti, user_id, expires_at)
+		 INSERT INTO revoked_tokens (token_jti, user_id, expires_at)
+		 SELECT token_jti, user_id, expires_at FROM ended`,
+		claims.UserID, policy.MaxSessionsPerUser,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions over the limit: %w", err)
+	}
+	return nil
+}
+
+// Touch is called on every refresh. It ends the session with ErrSessionIdle
+// when the idle timeout has passed and otherwise marks it as used. Tokens
+// with no session yet, issued before sessions were tracked or by the OIDC
+// and setup flows, start one here.
+func (s *SessionStore) Touch(ctx context.Context, claims *Claims) error {
+	policy, err := s.Policy(ctx)
+	if err != nil {
+		return err
+	}
+
+	jti := claims.RegisteredClaims.ID
+	var lastUsed, now time.Time
+	err = s.db.Pool.QueryRow(ctx,
+		`SELECT last_used_at, NOW() FROM refresh_sessions WHERE token_jti = $1`,
+		jti,
+	).Scan(&lastUsed, &now)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s.track(ctx, claims, policy)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+
+	if policy.idle(lastUsed, now) {
+		_, err := s.db.Pool.Exec(ctx,
+			`WITH ended AS (
+			   DELETE FROM refresh_sessions WHERE token_jti = $1
+			   RETURNING token_jti, user_id, expires_at)
+			 INSERT INTO revoked_tokens (token_jti, user_id, expires_at)
+			 SELECT token_jti, user_id, expires_at FROM ended`,
+			jti,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to end idle session: %w", err)
+		}
+		return ErrSessionIdle
+	}
+
+	if _, err := s.db.Pool.Exec(ctx,
+		`UPDATE refresh_sessions SET last_used_at = NOW() WHERE token_jti = $1`, jti,
+	); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+// Forget drops a session whose refresh token was revoked.
+func (s *SessionStore) Forget(ctx context.Context, jti string) error {
+	if _, err := s.db.Pool.Exec(ctx, `DELETE FROM refresh_sessions WHERE token_jti = $1`, jti); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// CleanupExpired removes sessions whose refresh token has expired.
+func (s *SessionStore) CleanupExpired(ctx context.Context) (int64, error) {
+	result, err := s.db.Pool.Exec(ctx, `DELETE FROM refresh_sessions WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to cleanup expired sessions: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
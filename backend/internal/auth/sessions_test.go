@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionPolicyValidate(t *testing.T) {
+	tests := []struct {
+		policy  SessionPolicy
+		wantErr bool
+	}{
+		{SessionPolicy{}, false},
+		{SessionPolicy{IdleTimeoutMinutes: 15, MaxSessionsPerUser: 1}, false},
+		{SessionPolicy{IdleTimeoutMinutes: 480}, false},
+		{SessionPolicy{IdleTimeoutMinutes: 10}, true},
+		{SessionPolicy{IdleTimeoutMinutes: -1}, true},
+		{SessionPolicy{MaxSessionsPerUser: -1}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v: Validate() error = %v, wantErr %v", tt.policy, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSessionPolicyIdle(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	policy := SessionPolicy{IdleTimeoutMinutes: 30}
+
+	if policy.idle(now.Add(-29*time.Minute), now) {
+		t.Error("expected a session used 29 minutes ago to be active")
+	}
+	if !policy.idle(now.Add(-31*time.Minute), now) {
+		t.Error("expected a session used 31 minutes ago to be idle")
+	}
+	if (SessionPolicy{}).idle(now.Add(-24*time.Hour), now) {
+		t.Error("expected no idle timeout without a policy")
+	}
+}
+
+func TestGenerateRefreshTokenClaims(t *testing.T) {
+	svc := NewJWTService("test-secret-key")
+	token, claims, err := svc.generateRefreshToken("user-123")
+	if err != nil {
+		t.Fatalf("generateRefreshToken failed: %v", err)
+	}
+	parsed, err := svc.ValidateRefreshToken(token)
+	if err != nil {
+		t.Fatalf("ValidateRefreshToken failed: %v", err)
+	}
+	if parsed.ID != claims.ID || claims.ID == "" || claims.UserID != "user-123" || claims.IssuedAt == nil {
+		t.Errorf("expected the returned claims to match the token, got %+v and %+v", claims, parsed)
+	}
+}
@@ -0,0 +1,29 @@
+package auth
+
+import "strings"
+
+const (
+	// ShareLinkTokenPrefix marks the tokens embedded in shareable links.
+	// They are only accepted by the redeem endpoint.
+	ShareLinkTokenPrefix = "argus_share_"
+
+	// ShareSessionTokenPrefix marks bearer tokens issued when a share link
+	// is redeemed, so they can be told apart from JWTs without parsing them.
+	ShareSessionTokenPrefix = "argus_view_"
+
+	// ShareLinkPrincipalPrefix prefixes Claims.UserID for requests made
+	// with a share session. The RBAC engine resolves the principal to the
+	// link's read-only scope.
+	ShareLinkPrincipalPrefix = "share:"
+
+	// TokenTypeShareSession is the Claims.TokenType of share principals.
+	TokenTypeShareSession = "share_session"
+)
+
+// ShareLinkID returns the link ID when the claims belong to a share session.
+func (c *Claims) ShareLinkID() (string, bool) {
+	if c == nil || !strings.HasPrefix(c.UserID, ShareLinkPrincipalPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(c.UserID, ShareLinkPrincipalPrefix), true
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the RFC 6238 SHA-1 test key "12345678901234567890".
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B, truncated to the 6 digits Argus uses.
+	vectors := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, v := range vectors {
+		got, err := totpCode(rfc6238Secret, totpStep(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("totpCode: %v", err)
+		}
+		if got != v.want {
+			t.Errorf("T=%d: expected %s, got %s", v.unix, v.want, got)
+		}
+	}
+}
+
+func TestValidateTOTPSkewAndReplay(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := totpStep(now)
+	prev, _ := totpCode(rfc6238Secret, step-1)
+	old, _ := totpCode(rfc6238Secret, step-2)
+
+	got, ok := validateTOTP(rfc6238Secret, "050471", now, 0)
+	if !ok || got != step {
+		t.Fatalf("expected current code to match step %d, got %d, %v", step, got, ok)
+	}
+	if _, ok := validateTOTP(rfc6238Secret, prev, now, 0); !ok {
+		t.Error("expected the previous step's code to be accepted")
+	}
+	if _, ok := validateTOTP(rfc6238Secret, old, now, 0); ok {
+		t.Error("expected a code two steps old to be rejected")
+	}
+	if _, ok := validateTOTP(rfc6238Secret, "050471", now, step); ok {
+		t.Error("expected an already used step to be rejected")
+	}
+	if _, ok := validateTOTP(rfc6238Secret, "050 471", now, 0); !ok {
+		t.Error("expected spaces in the code to be ignored")
+	}
+	if _, ok := validateTOTP(rfc6238Secret, "12345", now, 0); ok {
+		t.Error("expected a short code to be rejected")
+	}
+}
+
+func TestGenerateTOTPSecretRoundTrips(t *testing.T) {
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		t.Fatalf("generateTOTPSecret: %v", err)
+	}
+	now := time.Now()
+	code, err := totpCode(secret, totpStep(now))
+	if err != nil {
+		t.Fatalf("totpCode: %v", err)
+	}
+	if _, ok := validateTOTP(secret, code, now, 0); !ok {
+		t.Error("expected a code from a generated secret to validate")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	raw := totpProvisioningURI(rfc6238Secret, "alice@example.com")
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("invalid URI %q: %v", raw, err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Argus:alice@example.com" {
+		t.Errorf("unexpected URI %q", raw)
+	}
+	q := u.Query()
+	if q.Get("secret") != rfc6238Secret || q.Get("issuer") != "Argus" || q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Errorf("unexpected query %v", q)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := generateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		t.Fatalf("generateRecoveryCodes: %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, c := range codes {
+		if len(c) != 11 || c[5] != '-' {
+			t.Errorf("unexpected code format %q", c)
+		}
+		seen[c] = true
+	}
+	if len(seen) != recoveryCodeCount {
+		t.Errorf("expected %d distinct codes, got %d", recoveryCodeCount, len(seen))
+	}
+	c := codes[0]
+	if hashRecoveryCode(c) != hashRecoveryCode(strings.ToUpper(strings.ReplaceAll(c, "-", ""))) {
+		t.Error("expected recovery codes to match case-insensitively and without the separator")
+	}
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 // UserManagementHandlers provides HTTP handlers for user CRUD operations.
@@ -34,12 +36,12 @@ func (h *UserManagementHandlers) RegisterRoutes(r *mux.Router) {
 func (h *UserManagementHandlers) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
 	claims, ok := ClaimsFromContext(r.Context())
 	if !ok {
-		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return false
 	}
 
 	if h.pool == nil {
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "service unavailable"})
+		httputil.WriteError(w, http.StatusInternalServerError, "service unavailable")
 		return false
 	}
 
@@ -51,7 +53,7 @@ func (h *UserManagementHandlers) requireAdmin(w http.ResponseWriter, r *http.Req
 			WHERE ur.user_id = $1 AND r.name = 'admin'
 		)`, claims.UserID).Scan(&exists)
 	if err != nil || !exists {
-		writeJSON(w, http.StatusForbidden, errorResponse{Error: "admin role required"})
+		httputil.WriteError(w, http.StatusForbidden, "admin role required")
 		return false
 	}
 	return true
@@ -71,7 +73,7 @@ func (h *UserManagementHandlers) handleListUsers(w http.ResponseWriter, r *http.
 
 	users, err := h.service.ListUsers(r.Context())
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to list users"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to list users")
 		return
 	}
 
@@ -86,28 +88,28 @@ func (h *UserManagementHandlers) handleCreateUser(w http.ResponseWriter, r *http
 
 	var req createUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	if req.Email == "" || req.Password == "" || req.DisplayName == "" {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "email, password, and display_name are required"})
+		httputil.WriteError(w, http.StatusBadRequest, "email, password, and display_name are required")
 		return
 	}
 
 	if _, err := mail.ParseAddress(req.Email); err != nil {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid email format"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid email format")
 		return
 	}
 
 	if len(req.Password) < 8 {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "password must be at least 8 characters"})
+		httputil.WriteError(w, http.StatusBadRequest, "password must be at least 8 characters")
 		return
 	}
 
 	user, err := h.service.Register(r.Context(), req.Email, req.Password, req.DisplayName)
 	if err != nil {
-		writeJSON(w, http.StatusConflict, errorResponse{Error: "user already exists or creation failed"})
+		httputil.WriteError(w, http.StatusConflict, "user already exists or creation failed")
 		return
 	}
 
@@ -123,27 +125,27 @@ func (h *UserManagementHandlers) handleDeleteUser(w http.ResponseWriter, r *http
 	vars := mux.Vars(r)
 	id := vars["id"]
 	if id == "" {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "user id is required"})
+		httputil.WriteError(w, http.StatusBadRequest, "user id is required")
 		return
 	}
 
 	// Prevent self-deletion
 	claims, ok := ClaimsFromContext(r.Context())
 	if !ok {
-		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 	if claims.UserID == id {
-		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "cannot delete your own account"})
+		httputil.WriteError(w, http.StatusBadRequest, "cannot delete your own account")
 		return
 	}
 
 	if err := h.service.DeleteUser(r.Context(), id); err != nil {
 		if errors.Is(err, ErrUserNotFound) {
-			writeJSON(w, http.StatusNotFound, errorResponse{Error: "user not found"})
+			httputil.WriteError(w, http.StatusNotFound, "user not found")
 			return
 		}
-		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to delete user"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to delete user")
 		return
 	}
 
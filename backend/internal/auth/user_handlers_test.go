@@ -9,6 +9,8 @@ import (
 	"net/mail"
 	"strings"
 	"testing"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 // newUserHandlersSvc returns a UserManagementHandlers with nil pool for
@@ -300,7 +302,7 @@ func TestRequireAdminNilPoolReturns500(t *testing.T) {
 		t.Errorf("expected 500 when pool is nil, got %d", rec.Code)
 	}
 
-	var resp errorResponse
+	var resp httputil.ErrorResponse
 	json.NewDecoder(rec.Body).Decode(&resp)
 	if resp.Error == "" {
 		t.Error("expected non-empty error message in response")
@@ -0,0 +1,209 @@
+package backup
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/darkden-lab/argus/backend/internal/crypto"
+)
+
+// BundleVersion is the format version written by Export. Import rejects
+// bundles from a newer format.
+const BundleVersion = 1
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported bundle version")
+	ErrPassphraseRequired = errors.New("bundle contains encrypted secrets; a passphrase is required")
+	ErrWrongPassphrase    = errors.New("passphrase does not match the bundle")
+)
+
+// Bundle is a portable copy of the control-plane configuration. It refers to
+// roles and clusters by name, never by ID, so it can be applied to another
+// installation.
+type Bundle struct {
+	Version              int                 `json:"version"`
+	ExportedAt           time.Time           `json:"exported_at"`
+	Encryption           *BundleEncryption   `json:"encryption,omitempty"`
+	Roles                []BundleRole        `json:"roles"`
+	OIDCMappings         []BundleOIDCMapping `json:"oidc_mappings"`
+	NotificationChannels []BundleChannel     `json:"notification_channels"`
+	Plugins              []BundlePlugin      `json:"plugins"`
+}
+
+// BundleEncryption describes how secrets in the bundle are encrypted. It is
+// absent when the bundle was exported without a passphrase, in which case
+// the bundle holds no secrets.
+type BundleEncryption struct {
+	KDF  string `json:"kdf"`
+	Salt string `json:"salt"`
+	// Check is a known value encrypted with the derived key, so a wrong
+	// passphrase is reported before anything is imported.
+	Check string `json:"check"`
+}
+
+type BundleRole struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Parents     []string           `json:"parents,omitempty"`
+	Permissions []BundlePermission `json:"permissions"`
+}
+
+// BundlePermission is a role permission. For cluster and namespace scopes
+// tied to a known cluster, Cluster names the cluster and ScopeID holds only
+// the namespace (if any).
+type BundlePermission struct {
+	Resource  string `json:"resource"`
+	Action    string `json:"action"`
+	ScopeType string `json:"scope_type"`
+	ScopeID   string `json:"scope_id,omitempty"`
+	Cluster   string `json:"cluster,omitempty"`
+}
+
+type BundleOIDCMapping struct {
+	Group     string `json:"oidc_group"`
+	Role      string `json:"role"`
+	Cluster   string `json:"cluster,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// BundleChannel is a notification channel. ConfigEnc is the channel config
+// encrypted with the bundle passphrase; it is empty when the bundle was
+// exported without one.
+type BundleChannel struct {
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Enabled   bool   `json:"enabled"`
+	ConfigEnc string `json:"config_enc,omitempty"`
+}
+
+type BundlePlugin struct {
+	ID      string `json:"id"`
+	Enabled bool   `json:"enabled"`
+}
+
+// scrypt parameters for deriving the bundle key from a passphrase.
+const (
+	scryptN       = 1 << 15
+	scryptR       = 8
+	scryptP       = 1
+	scryptKeySize = 32
+	bundleKDF     = "scrypt"
+	checkValue    = "argus-config-bundle"
+)
+
+// bundleKey is a hex-encoded AES-256 key derived from a passphrase, in the
+// form crypto.Encrypt expects.
+type bundleKey string
+
+// newBundleEncryption derives a key from passphrase with a fresh salt.
+func newBundleEncryption(passphrase string) (*BundleEncryption, bundleKey, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, "", err
+	}
+	enc := &BundleEncryption{KDF: bundleKDF, Salt: base64.StdEncoding.EncodeToString(salt)}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, "", err
+	}
+	check, err := key.seal([]byte(checkValue))
+	if err != nil {
+		return nil, "", err
+	}
+	enc.Check = check
+	return enc, key, nil
+}
+
+// openBundleEncryption derives the key of an encrypted bundle and verifies
+// the passphrase against the bundle's check value.
+func openBundleEncryption(enc *BundleEncryption, passphrase string) (bundleKey, error) {
+	if passphrase == "" {
+		return "", ErrPassphraseRequired
+	}
+	if enc.KDF != bundleKDF {
+		return "", fmt.Errorf("unsupported key derivation %q", enc.KDF)
+	}
+	salt, err := base64.StdEncoding.DecodeString(enc.Salt)
+	if err != nil {
+		return "", fmt.Errorf("invalid bundle salt: %w", err)
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	check, err := key.open(enc.Check)
+	if err != nil || string(check) != checkValue {
+		return "", ErrWrongPassphrase
+	}
+	return key, nil
+}
+
+func deriveKey(passphrase string, salt []byte) (bundleKey, error) {
+	raw, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeySize)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive bundle key: %w", err)
+	}
+	return bundleKey(hex.EncodeToString(raw)), nil
+}
+
+// seal encrypts plaintext and returns it base64-encoded.
+func (k bundleKey) seal(plaintext []byte) (string, error) {
+	ciphertext, err := crypto.Encrypt(plaintext, string(k))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// open decrypts a value produced by seal.
+func (k bundleKey) open(sealed string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Decrypt(ciphertext, string(k))
+}
+
+// exportScope rewrites a permission scope that refers to a cluster by ID into
+// a cluster name plus the remaining scope ID. Scopes naming an unknown
+// cluster are kept as they are.
+func exportScope(scopeType, scopeID string, clusterNames map[string]string) (string, string) {
+	switch scopeType {
+	case "cluster":
+		if name, ok := clusterNames[scopeID]; ok {
+			return "", name
+		}
+	case "namespace":
+		// Namespace scopes are either "namespace" or "clusterID/namespace".
+		if clusterID, ns, ok := strings.Cut(scopeID, "/"); ok {
+			if name, ok := clusterNames[clusterID]; ok {
+				return ns, name
+			}
+		}
+	}
+	return scopeID, ""
+}
+
+// importScope is the inverse of exportScope: it resolves the permission's
+// cluster name to a local cluster ID. ok is false if the cluster does not
+// exist here.
+func importScope(p BundlePermission, clusterIDs map[string]string) (string, bool) {
+	if p.Cluster == "" {
+		return p.ScopeID, true
+	}
+	id, ok := clusterIDs[p.Cluster]
+	if !ok {
+		return "", false
+	}
+	if p.ScopeType == "namespace" {
+		return id + "/" + p.ScopeID, true
+	}
+	return id, true
+}
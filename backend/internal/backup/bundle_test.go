@@ -0,0 +1,68 @@
+package backup
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBundleEncryptionRoundTrip(t *testing.T) {
+	enc, key, err := newBundleEncryption("correct horse")
+	if err != nil {
+		t.Fatalf("newBundleEncryption: %v", err)
+	}
+	sealed, err := key.seal([]byte(`{"webhook_url":"https://hooks.example.com/x"}`))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+
+	opened, err := openBundleEncryption(enc, "correct horse")
+	if err != nil {
+		t.Fatalf("openBundleEncryption: %v", err)
+	}
+	plain, err := opened.open(sealed)
+	if err != nil || string(plain) != `{"webhook_url":"https://hooks.example.com/x"}` {
+		t.Fatalf("unexpected plaintext %q, %v", plain, err)
+	}
+
+	if _, err := openBundleEncryption(enc, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("expected ErrWrongPassphrase, got %v", err)
+	}
+	if _, err := openBundleEncryption(enc, ""); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("expected ErrPassphraseRequired, got %v", err)
+	}
+}
+
+func TestScopeTranslation(t *testing.T) {
+	source := map[string]string{"11111111-1111-1111-1111-111111111111": "prod"}
+	target := map[string]string{"prod": "22222222-2222-2222-2222-222222222222"}
+
+	tests := []struct {
+		scopeType, scopeID string
+		wantScope          string
+		wantCluster        string
+		wantImported       string
+	}{
+		{"global", "", "", "", ""},
+		{"global", "*", "*", "", "*"},
+		{"cluster", "11111111-1111-1111-1111-111111111111", "", "prod", "22222222-2222-2222-2222-222222222222"},
+		{"namespace", "11111111-1111-1111-1111-111111111111/payments", "payments", "prod", "22222222-2222-2222-2222-222222222222/payments"},
+		{"namespace", "payments", "payments", "", "payments"},
+		// A scope naming a cluster the source doesn't know is kept verbatim.
+		{"cluster", "33333333-3333-3333-3333-333333333333", "33333333-3333-3333-3333-333333333333", "", "33333333-3333-3333-3333-333333333333"},
+	}
+	for _, tt := range tests {
+		scope, cluster := exportScope(tt.scopeType, tt.scopeID, source)
+		if scope != tt.wantScope || cluster != tt.wantCluster {
+			t.Errorf("exportScope(%s, %s) = %q, %q; want %q, %q", tt.scopeType, tt.scopeID, scope, cluster, tt.wantScope, tt.wantCluster)
+		}
+		p := BundlePermission{ScopeType: tt.scopeType, ScopeID: scope, Cluster: cluster}
+		imported, ok := importScope(p, target)
+		if !ok || imported != tt.wantImported {
+			t.Errorf("importScope(%+v) = %q, %v; want %q", p, imported, ok, tt.wantImported)
+		}
+	}
+
+	if _, ok := importScope(BundlePermission{ScopeType: "cluster", Cluster: "staging"}, target); ok {
+		t.Error("expected a permission on an unknown cluster to be unresolvable")
+	}
+}
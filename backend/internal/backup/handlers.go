@@ -0,0 +1,141 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/audit"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// maxBundleSize caps the import request body.
+const maxBundleSize = 10 << 20
+
+// bundler is the part of *Service the handlers use.
+type bundler interface {
+	Export(ctx context.Context, passphrase string) (*Bundle, error)
+	Import(ctx context.Context, b *Bundle, passphrase string, dryRun bool) (*ImportResult, error)
+}
+
+// Handlers serves configuration export and import.
+type Handlers struct {
+	service    bundler
+	guard      mux.MiddlewareFunc
+	auditStore *audit.Store
+}
+
+// NewHandlers creates backup handlers. guard should require a high-privilege
+// permission, since a bundle can grant any role any permission. auditStore
+// may be nil.
+func NewHandlers(service *Service, guard mux.MiddlewareFunc, auditStore *audit.Store) *Handlers {
+	return &Handlers{service: service, guard: guard, auditStore: auditStore}
+}
+
+// RegisterRoutes wires the export and import endpoints.
+func (h *Handlers) RegisterRoutes(r *mux.Router) {
+	api := r.PathPrefix("/api/config").Subrouter()
+	if h.guard != nil {
+		api.Use(h.guard)
+	}
+	api.HandleFunc("/export", h.handleExport).Methods(http.MethodPost)
+	api.HandleFunc("/import", h.handleImport).Methods(http.MethodPost)
+}
+
+type exportRequest struct {
+	Passphrase string `json:"passphrase"`
+}
+
+type importRequest struct {
+	Bundle     *Bundle `json:"bundle"`
+	Passphrase string  `json:"passphrase"`
+}
+
+// handleExport handles POST /api/config/export. The body is optional; with
+// a passphrase, notification channel secrets are included re-encrypted.
+func (h *Handlers) handleExport(w http.ResponseWriter, r *http.Request) {
+	var req exportRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	bundle, err := h.service.Export(r.Context(), req.Passphrase)
+	if err != nil {
+		log.Printf("backup: export failed: %v", err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to export configuration")
+		return
+	}
+
+	h.record(r, "config.export", map[string]interface{}{
+		"secrets_included":      bundle.Encryption != nil,
+		"roles":                 len(bundle.Roles),
+		"oidc_mappings":         len(bundle.OIDCMappings),
+		"notification_channels": len(bundle.NotificationChannels),
+		"plugins":               len(bundle.Plugins),
+	})
+
+	filename := fmt.Sprintf("argus-config-%s.json", bundle.ExportedAt.Format("20060102-150405"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	httputil.WriteJSON(w, http.StatusOK, bundle)
+}
+
+// handleImport handles POST /api/config/import. With ?dry_run=true it
+// reports what would change without saving anything.
+func (h *Handlers) handleImport(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBundleSize)
+	var req importRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Bundle == nil {
+		httputil.WriteError(w, http.StatusBadRequest, "bundle is required")
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	result, err := h.service.Import(r.Context(), req.Bundle, req.Passphrase, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnsupportedVersion), errors.Is(err, ErrPassphraseRequired), errors.Is(err, ErrWrongPassphrase):
+			httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, ErrInheritanceCycle):
+			httputil.WriteError(w, http.StatusConflict, err.Error())
+		default:
+			log.Printf("backup: import failed: %v", err)
+			httputil.WriteError(w, http.StatusInternalServerError, "failed to import configuration")
+		}
+		return
+	}
+
+	if !dryRun {
+		h.record(r, "config.import", map[string]interface{}{
+			"exported_at": req.Bundle.ExportedAt,
+			"applied":     result.Applied,
+			"skipped":     result.Skipped,
+		})
+	}
+	httputil.WriteJSON(w, http.StatusOK, result)
+}
+
+// record writes an audit entry describing an export or import.
+func (h *Handlers) record(r *http.Request, action string, details map[string]interface{}) {
+	if h.auditStore == nil {
+		return
+	}
+	raw, _ := json.Marshal(details)
+	userID, serviceAccountID := audit.ActorFromContext(r.Context())
+	ctx, cancel := audit.DetachedContext(r.Context())
+	defer cancel()
+	if err := h.auditStore.Insert(ctx, userID, serviceAccountID, nil, action, r.URL.Path, raw); err != nil {
+		log.Printf("backup: failed to audit %s: %v", action, err)
+	}
+}
@@ -0,0 +1,133 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type fakeBundler struct {
+	err        error
+	dryRun     bool
+	passphrase string
+}
+
+func (f *fakeBundler) Export(ctx context.Context, passphrase string) (*Bundle, error) {
+	f.passphrase = passphrase
+	b := &Bundle{Version: BundleVersion, ExportedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	if passphrase != "" {
+		b.Encryption = &BundleEncryption{KDF: bundleKDF}
+	}
+	return b, f.err
+}
+
+func (f *fakeBundler) Import(ctx context.Context, b *Bundle, passphrase string, dryRun bool) (*ImportResult, error) {
+	f.dryRun, f.passphrase = dryRun, passphrase
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &ImportResult{DryRun: dryRun, Applied: []ImportItem{{Kind: "role", Name: "admin", Result: "updated"}}}, nil
+}
+
+func TestRegisterRoutesAppliesGuard(t *testing.T) {
+	guarded := 0
+	guard := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			guarded++
+			w.WriteHeader(http.StatusForbidden)
+		})
+	}
+	h := &Handlers{service: &fakeBundler{}, guard: guard}
+	r := mux.NewRouter()
+	h.RegisterRoutes(r)
+
+	for _, path := range []string{"/api/config/export", "/api/config/import"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: expected the guard to answer, got %d", path, rec.Code)
+		}
+	}
+	if guarded != 2 {
+		t.Errorf("expected both routes to be guarded, got %d", guarded)
+	}
+}
+
+func TestHandleExport(t *testing.T) {
+	fake := &fakeBundler{}
+	h := &Handlers{service: fake}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/config/export", strings.NewReader(`{"passphrase":"s3cret"}`))
+	rec := httptest.NewRecorder()
+	h.handleExport(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if fake.passphrase != "s3cret" {
+		t.Errorf("expected the passphrase to be passed on, got %q", fake.passphrase)
+	}
+	if got := rec.Header().Get("Content-Disposition"); !strings.Contains(got, "argus-config-20260301-120000.json") {
+		t.Errorf("unexpected Content-Disposition %q", got)
+	}
+
+	// The body is optional.
+	rec = httptest.NewRecorder()
+	h.handleExport(rec, httptest.NewRequest(http.MethodPost, "/api/config/export", nil))
+	if rec.Code != http.StatusOK || fake.passphrase != "" {
+		t.Errorf("expected an export without secrets, got %d, %q", rec.Code, fake.passphrase)
+	}
+}
+
+func TestHandleImport(t *testing.T) {
+	body, _ := json.Marshal(importRequest{Bundle: &Bundle{Version: BundleVersion}, Passphrase: "s3cret"})
+
+	fake := &fakeBundler{}
+	h := &Handlers{service: fake}
+	req := httptest.NewRequest(http.MethodPost, "/api/config/import?dry_run=true", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.handleImport(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !fake.dryRun || fake.passphrase != "s3cret" {
+		t.Errorf("expected a dry run with the passphrase, got %+v", fake)
+	}
+	var result ImportResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil || !result.DryRun {
+		t.Errorf("unexpected result %+v, %v", result, err)
+	}
+}
+
+func TestHandleImportErrors(t *testing.T) {
+	valid, _ := json.Marshal(importRequest{Bundle: &Bundle{Version: BundleVersion}})
+	tests := []struct {
+		name string
+		body string
+		err  error
+		want int
+	}{
+		{"bad json", "{", nil, http.StatusBadRequest},
+		{"missing bundle", "{}", nil, http.StatusBadRequest},
+		{"wrong passphrase", string(valid), ErrWrongPassphrase, http.StatusBadRequest},
+		{"unsupported version", string(valid), ErrUnsupportedVersion, http.StatusBadRequest},
+		{"cycle", string(valid), ErrInheritanceCycle, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		h := &Handlers{service: &fakeBundler{err: tt.err}}
+		req := httptest.NewRequest(http.MethodPost, "/api/config/import", strings.NewReader(tt.body))
+		rec := httptest.NewRecorder()
+		h.handleImport(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, rec.Code)
+		}
+	}
+}
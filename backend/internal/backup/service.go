@@ -0,0 +1,588 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/darkden-lab/argus/backend/internal/crypto"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+// ErrInheritanceCycle is returned when importing role parents would make the
+// role inheritance graph cyclic.
+var ErrInheritanceCycle = errors.New("bundle role parents would create an inheritance cycle")
+
+// PluginController enables and disables plugins. Implemented by
+// *plugin.Engine.
+type PluginController interface {
+	IsEnabled(id string) bool
+	Enable(ctx context.Context, pluginID string) error
+	Disable(ctx context.Context, pluginID string) error
+}
+
+// ImportItem is one object an import created, changed or skipped.
+type ImportItem struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Result string `json:"result"`
+}
+
+// ImportResult reports what an import did, or would do for a dry run.
+type ImportResult struct {
+	DryRun  bool         `json:"dry_run"`
+	Applied []ImportItem `json:"applied"`
+	Skipped []ImportItem `json:"skipped"`
+}
+
+func (r *ImportResult) applied(kind, name, result string) {
+	r.Applied = append(r.Applied, ImportItem{Kind: kind, Name: name, Result: result})
+}
+
+func (r *ImportResult) skipped(kind, name, reason string) {
+	r.Skipped = append(r.Skipped, ImportItem{Kind: kind, Name: name, Result: reason})
+}
+
+// Service exports and imports configuration bundles.
+type Service struct {
+	pool          *pgxpool.Pool
+	encryptionKey string
+	engine        *rbac.Engine
+	plugins       PluginController
+}
+
+// NewService creates a Service. plugins may be nil, in which case plugin
+// enablement is exported from the database but not applied on import.
+func NewService(pool *pgxpool.Pool, encryptionKey string, engine *rbac.Engine, plugins PluginController) *Service {
+	return &Service{pool: pool, encryptionKey: encryptionKey, engine: engine, plugins: plugins}
+}
+
+// Export builds a bundle of the current configuration. With a passphrase,
+// notification channel configs are re-encrypted under a key derived from it;
+// without one they are left out.
+func (s *Service) Export(ctx context.Context, passphrase string) (*Bundle, error) {
+	b := &Bundle{
+		Version:              BundleVersion,
+		ExportedAt:           time.Now().UTC(),
+		Roles:                []BundleRole{},
+		OIDCMappings:         []BundleOIDCMapping{},
+		NotificationChannels: []BundleChannel{},
+		Plugins:              []BundlePlugin{},
+	}
+	var key bundleKey
+	if passphrase != "" {
+		enc, k, err := newBundleEncryption(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		b.Encryption, key = enc, k
+	}
+
+	clusterNames, err := s.clusterNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.exportRoles(ctx, b, clusterNames); err != nil {
+		return nil, err
+	}
+	if err := s.exportOIDCMappings(ctx, b, clusterNames); err != nil {
+		return nil, err
+	}
+	if err := s.exportChannels(ctx, b, key); err != nil {
+		return nil, err
+	}
+	if err := s.exportPlugins(ctx, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// clusterNames maps cluster IDs to names.
+func (s *Service) clusterNames(ctx context.Context) (map[string]string, error) {
+	rows, err := s.pool.Query(ctx, `SELECT id::text, name FROM clusters`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	defer rows.Close()
+	names := make(map[string]string)
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan cluster: %w", err)
+		}
+		names[id] = name
+	}
+	return names, rows.Err()
+}
+
+func (s *Service) exportRoles(ctx context.Context, b *Bundle, clusterNames map[string]string) error {
+	rows, err := s.pool.Query(ctx, `SELECT id::text, name, COALESCE(description, '') FROM roles ORDER BY name`)
+	if err != nil {
+		return fmt.Errorf("failed to list roles: %w", err)
+	}
+	index := make(map[string]int)
+	for rows.Next() {
+		var id string
+		var role BundleRole
+		if err := rows.Scan(&id, &role.Name, &role.Description); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan role: %w", err)
+		}
+		role.Permissions = []BundlePermission{}
+		index[id] = len(b.Roles)
+		b.Roles = append(b.Roles, role)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	rows, err = s.pool.Query(ctx,
+		`SELECT role_id::text, resource, action, scope_type, COALESCE(scope_id, '')
+		 FROM role_permissions ORDER BY resource, action, scope_type, scope_id`)
+	if err != nil {
+		return fmt.Errorf("failed to list role permissions: %w", err)
+	}
+	for rows.Next() {
+		var roleID, scopeID string
+		var p BundlePermission
+		if err := rows.Scan(&roleID, &p.Resource, &p.Action, &p.ScopeType, &scopeID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan role permission: %w", err)
+		}
+		p.ScopeID, p.Cluster = exportScope(p.ScopeType, scopeID, clusterNames)
+		if i, ok := index[roleID]; ok {
+			b.Roles[i].Permissions = append(b.Roles[i].Permissions, p)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list role permissions: %w", err)
+	}
+
+	rows, err = s.pool.Query(ctx,
+		`SELECT rp.role_id::text, p.name FROM role_parents rp
+		 JOIN roles p ON p.id = rp.parent_role_id
+		 ORDER BY p.name`)
+	if err != nil {
+		return fmt.Errorf("failed to list role parents: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var roleID, parent string
+		if err := rows.Scan(&roleID, &parent); err != nil {
+			return fmt.Errorf("failed to scan role parent: %w", err)
+		}
+		if i, ok := index[roleID]; ok {
+			b.Roles[i].Parents = append(b.Roles[i].Parents, parent)
+		}
+	}
+	return rows.Err()
+}
+
+func (s *Service) exportOIDCMappings(ctx context.Context, b *Bundle, clusterNames map[string]string) error {
+	rows, err := s.pool.Query(ctx,
+		`SELECT m.oidc_group, r.name, COALESCE(m.cluster_id::text, ''), COALESCE(m.namespace, '')
+		 FROM oidc_role_mappings m JOIN roles r ON r.id = m.role_id
+		 ORDER BY m.oidc_group, r.name`)
+	if err != nil {
+		return fmt.Errorf("failed to list OIDC mappings: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m BundleOIDCMapping
+		var clusterID string
+		if err := rows.Scan(&m.Group, &m.Role, &clusterID, &m.Namespace); err != nil {
+			return fmt.Errorf("failed to scan OIDC mapping: %w", err)
+		}
+		if clusterID != "" {
+			name, ok := clusterNames[clusterID]
+			if !ok {
+				continue
+			}
+			m.Cluster = name
+		}
+		b.OIDCMappings = append(b.OIDCMappings, m)
+	}
+	return rows.Err()
+}
+
+func (s *Service) exportChannels(ctx context.Context, b *Bundle, key bundleKey) error {
+	rows, err := s.pool.Query(ctx,
+		`SELECT type, name, config_enc, enabled FROM notification_channels ORDER BY created_at`)
+	if err != nil {
+		return fmt.Errorf("failed to list notification channels: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ch BundleChannel
+		var configEnc []byte
+		if err := rows.Scan(&ch.Type, &ch.Name, &configEnc, &ch.Enabled); err != nil {
+			return fmt.Errorf("failed to scan notification channel: %w", err)
+		}
+		if key != "" {
+			config, err := crypto.Decrypt(configEnc, s.encryptionKey)
+			if err != nil {
+				return fmt.Errorf("failed to decrypt config of channel %q: %w", ch.Name, err)
+			}
+			if ch.ConfigEnc, err = key.seal(config); err != nil {
+				return fmt.Errorf("failed to encrypt config of channel %q: %w", ch.Name, err)
+			}
+		}
+		b.NotificationChannels = append(b.NotificationChannels, ch)
+	}
+	return rows.Err()
+}
+
+// exportPlugins exports the persisted enablement of every plugin that has
+// ever been enabled; plugins without a row are disabled by default.
+func (s *Service) exportPlugins(ctx context.Context, b *Bundle) error {
+	rows, err := s.pool.Query(ctx, `SELECT id, enabled FROM plugins ORDER BY id`)
+	if err != nil {
+		return fmt.Errorf("failed to list plugins: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p BundlePlugin
+		if err := rows.Scan(&p.ID, &p.Enabled); err != nil {
+			return fmt.Errorf("failed to scan plugin: %w", err)
+		}
+		b.Plugins = append(b.Plugins, p)
+	}
+	return rows.Err()
+}
+
+// Import applies a bundle. It is idempotent: roles are matched by name and
+// get exactly the bundle's description, permissions and parents; OIDC
+// mappings are added if missing; channels are matched by type and name;
+// plugins are enabled or disabled to match. Objects not in the bundle are
+// left alone. Database changes are applied in one transaction, which a dry
+// run rolls back; plugins are only changed by a real import.
+func (s *Service) Import(ctx context.Context, b *Bundle, passphrase string, dryRun bool) (*ImportResult, error) {
+	if b.Version < 1 || b.Version > BundleVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, b.Version)
+	}
+	var key bundleKey
+	if b.Encryption != nil {
+		k, err := openBundleEncryption(b.Encryption, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		key = k
+	}
+
+	result := &ImportResult{DryRun: dryRun, Applied: []ImportItem{}, Skipped: []ImportItem{}}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	// Serialize with role parent edits, as the cycle check below needs a
+	// stable graph.
+	if _, err := tx.Exec(ctx, "LOCK TABLE role_parents IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return nil, fmt.Errorf("failed to lock role_parents: %w", err)
+	}
+
+	clusterIDs, err := clusterIDsByName(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	roleIDs, err := importRoles(ctx, tx, b.Roles, clusterIDs, result)
+	if err != nil {
+		return nil, err
+	}
+	if err := importOIDCMappings(ctx, tx, b.OIDCMappings, clusterIDs, result); err != nil {
+		return nil, err
+	}
+	if err := s.importChannels(ctx, tx, b.NotificationChannels, key, result); err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		s.importPlugins(ctx, b.Plugins, result, false)
+		return result, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	if s.engine != nil {
+		for _, id := range roleIDs {
+			s.engine.InvalidateUsersWithRole(ctx, id)
+		}
+	}
+	s.importPlugins(ctx, b.Plugins, result, true)
+	return result, nil
+}
+
+// clusterIDsByName maps cluster names to IDs. Names shared by several
+// clusters are left out, since a bundle reference to them is ambiguous.
+func clusterIDsByName(ctx context.Context, tx pgx.Tx) (map[string]string, error) {
+	rows, err := tx.Query(ctx, `SELECT id::text, name FROM clusters`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	defer rows.Close()
+	ids := make(map[string]string)
+	dup := make(map[string]bool)
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan cluster: %w", err)
+		}
+		if _, ok := ids[name]; ok {
+			dup[name] = true
+		}
+		ids[name] = id
+	}
+	for name := range dup {
+		delete(ids, name)
+	}
+	return ids, rows.Err()
+}
+
+// importRoles upserts the bundle's roles with their permissions, then their
+// parents once every role exists. It returns the IDs of the imported roles.
+func importRoles(ctx context.Context, tx pgx.Tx, roles []BundleRole, clusterIDs map[string]string, result *ImportResult) ([]string, error) {
+	ids := make(map[string]string, len(roles))
+	var imported []string
+	for _, role := range roles {
+		if role.Name == "" {
+			result.skipped("role", "", "role has no name")
+			continue
+		}
+		var id string
+		err := tx.QueryRow(ctx, `SELECT id::text FROM roles WHERE name = $1`, role.Name).Scan(&id)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			if err := tx.QueryRow(ctx,
+				`INSERT INTO roles (name, description) VALUES ($1, $2) RETURNING id::text`,
+				role.Name, role.Description,
+			).Scan(&id); err != nil {
+				return nil, fmt.Errorf("failed to create role %q: %w", role.Name, err)
+			}
+			result.applied("role", role.Name, "created")
+		case err != nil:
+			return nil, fmt.Errorf("failed to look up role %q: %w", role.Name, err)
+		default:
+			if _, err := tx.Exec(ctx, `UPDATE roles SET description = $2 WHERE id = $1`, id, role.Description); err != nil {
+				return nil, fmt.Errorf("failed to update role %q: %w", role.Name, err)
+			}
+			result.applied("role", role.Name, "updated")
+		}
+		ids[role.Name] = id
+		imported = append(imported, id)
+
+		if _, err := tx.Exec(ctx, `DELETE FROM role_permissions WHERE role_id = $1`, id); err != nil {
+			return nil, fmt.Errorf("failed to clear permissions of role %q: %w", role.Name, err)
+		}
+		for _, p := range role.Permissions {
+			scopeID, ok := importScope(p, clusterIDs)
+			if !ok {
+				result.skipped("permission", role.Name+": "+p.Resource+":"+p.Action,
+					fmt.Sprintf("cluster %q not found", p.Cluster))
+				continue
+			}
+			if _, err := tx.Exec(ctx,
+				`INSERT INTO role_permissions (role_id, resource, action, scope_type, scope_id)
+				 VALUES ($1, $2, $3, $4, NULLIF($5, ''))`,
+				id, p.Resource, p.Action, p.ScopeType, scopeID,
+			); err != nil {
+				return nil, fmt.Errorf("failed to add permission to role %q: %w", role.Name, err)
+			}
+		}
+	}
+
+	for _, role := range roles {
+		id, ok := ids[role.Name]
+		if !ok {
+			continue
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM role_parents WHERE role_id = $1`, id); err != nil {
+			return nil, fmt.Errorf("failed to clear parents of role %q: %w", role.Name, err)
+		}
+		for _, parent := range role.Parents {
+			tag, err := tx.Exec(ctx,
+				`INSERT INTO role_parents (role_id, parent_role_id)
+				 SELECT $1, id FROM roles WHERE name = $2 AND id <> $1
+				 ON CONFLICT DO NOTHING`,
+				id, parent,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to add parent %q to role %q: %w", parent, role.Name, err)
+			}
+			if tag.RowsAffected() == 0 {
+				result.skipped("role_parent", role.Name+" -> "+parent, "parent role not found")
+			}
+		}
+	}
+
+	var cyclic bool
+	if err := tx.QueryRow(ctx,
+		`WITH RECURSIVE walk(start_id, role_id) AS (
+			SELECT role_id, parent_role_id FROM role_parents
+			UNION
+			SELECT w.start_id, p.parent_role_id
+			FROM walk w JOIN role_parents p ON p.role_id = w.role_id
+		)
+		SELECT EXISTS(SELECT 1 FROM walk WHERE start_id = role_id)`,
+	).Scan(&cyclic); err != nil {
+		return nil, fmt.Errorf("failed to check role inheritance: %w", err)
+	}
+	if cyclic {
+		return nil, ErrInheritanceCycle
+	}
+	return imported, nil
+}
+
+// importOIDCMappings adds the bundle's OIDC group mappings that are missing.
+func importOIDCMappings(ctx context.Context, tx pgx.Tx, mappings []BundleOIDCMapping, clusterIDs map[string]string, result *ImportResult) error {
+	for _, m := range mappings {
+		name := m.Group + " -> " + m.Role
+		var clusterID *string
+		if m.Cluster != "" {
+			id, ok := clusterIDs[m.Cluster]
+			if !ok {
+				result.skipped("oidc_mapping", name, fmt.Sprintf("cluster %q not found", m.Cluster))
+				continue
+			}
+			clusterID = &id
+		}
+		var namespace *string
+		if m.Namespace != "" {
+			namespace = &m.Namespace
+		}
+
+		var roleID string
+		err := tx.QueryRow(ctx, `SELECT id::text FROM roles WHERE name = $1`, m.Role).Scan(&roleID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			result.skipped("oidc_mapping", name, "role not found")
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to look up role %q: %w", m.Role, err)
+		}
+
+		// The unique constraint treats NULL cluster and namespace values as
+		// distinct, so check for an existing mapping explicitly.
+		tag, err := tx.Exec(ctx,
+			`INSERT INTO oidc_role_mappings (oidc_group, role_id, cluster_id, namespace)
+			 SELECT $1, $2, $3, $4
+			 WHERE NOT EXISTS (
+			   SELECT 1 FROM oidc_role_mappings
+			   WHERE oidc_group = $1 AND role_id = $2
+			     AND cluster_id IS NOT DISTINCT FROM $3::uuid
+			     AND namespace IS NOT DISTINCT FROM $4
+			 )`,
+			m.Group, roleID, clusterID, namespace,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to add OIDC mapping %q: %w", name, err)
+		}
+		if tag.RowsAffected() == 0 {
+			result.applied("oidc_mapping", name, "unchanged")
+		} else {
+			result.applied("oidc_mapping", name, "created")
+		}
+	}
+	return nil
+}
+
+// importChannels creates or updates the bundle's notification channels,
+// matched by type and name. A channel exported without its config can only
+// update an existing channel's enabled flag.
+func (s *Service) importChannels(ctx context.Context, tx pgx.Tx, channels []BundleChannel, key bundleKey, result *ImportResult) error {
+	for _, ch := range channels {
+		var configEnc []byte
+		if ch.ConfigEnc != "" {
+			if key == "" {
+				result.skipped("notification_channel", ch.Name, "bundle has no encryption settings for the channel config")
+				continue
+			}
+			config, err := key.open(ch.ConfigEnc)
+			if err != nil {
+				result.skipped("notification_channel", ch.Name, "config could not be decrypted")
+				continue
+			}
+			if configEnc, err = crypto.Encrypt(config, s.encryptionKey); err != nil {
+				return fmt.Errorf("failed to encrypt config of channel %q: %w", ch.Name, err)
+			}
+		}
+
+		var id string
+		err := tx.QueryRow(ctx,
+			`SELECT id::text FROM notification_channels WHERE type = $1 AND name = $2 ORDER BY created_at LIMIT 1`,
+			ch.Type, ch.Name,
+		).Scan(&id)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			if configEnc == nil {
+				result.skipped("notification_channel", ch.Name, "bundle has no config for a new channel; export it with a passphrase")
+				continue
+			}
+			if _, err := tx.Exec(ctx,
+				`INSERT INTO notification_channels (type, name, config_enc, enabled) VALUES ($1, $2, $3, $4)`,
+				ch.Type, ch.Name, configEnc, ch.Enabled,
+			); err != nil {
+				return fmt.Errorf("failed to create channel %q: %w", ch.Name, err)
+			}
+			result.applied("notification_channel", ch.Name, "created")
+		case err != nil:
+			return fmt.Errorf("failed to look up channel %q: %w", ch.Name, err)
+		default:
+			if _, err := tx.Exec(ctx,
+				`UPDATE notification_channels
+				 SET enabled = $2, config_enc = COALESCE($3, config_enc), updated_at = NOW()
+				 WHERE id = $1`,
+				id, ch.Enabled, configEnc,
+			); err != nil {
+				return fmt.Errorf("failed to update channel %q: %w", ch.Name, err)
+			}
+			result.applied("notification_channel", ch.Name, "updated")
+		}
+	}
+	return nil
+}
+
+// importPlugins enables or disables plugins to match the bundle. Without
+// apply it only reports what it would change.
+func (s *Service) importPlugins(ctx context.Context, plugins []BundlePlugin, result *ImportResult, apply bool) {
+	for _, p := range plugins {
+		if s.plugins == nil {
+			result.skipped("plugin", p.ID, "plugin engine not available")
+			continue
+		}
+		if s.plugins.IsEnabled(p.ID) == p.Enabled {
+			result.applied("plugin", p.ID, "unchanged")
+			continue
+		}
+		if !apply {
+			result.applied("plugin", p.ID, pluginResult(p.Enabled))
+			continue
+		}
+		var err error
+		if p.Enabled {
+			err = s.plugins.Enable(ctx, p.ID)
+		} else {
+			err = s.plugins.Disable(ctx, p.ID)
+		}
+		if err != nil {
+			result.skipped("plugin", p.ID, err.Error())
+			continue
+		}
+		result.applied("plugin", p.ID, pluginResult(p.Enabled))
+	}
+}
+
+func pluginResult(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
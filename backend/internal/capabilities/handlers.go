@@ -0,0 +1,131 @@
+package capabilities
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// OIDCStatus reports whether OIDC login is active. Implemented by
+// *auth.OIDCService.
+type OIDCStatus interface {
+	Enabled() bool
+	ProviderName(ctx context.Context) string
+}
+
+// AIStatus reports the assistant's current state. Implemented by *ai.Service.
+type AIStatus interface {
+	Status() (enabled bool, provider string)
+}
+
+// ChannelCounter counts enabled notification channels. Implemented by
+// *notifications.ChannelStore.
+type ChannelCounter interface {
+	CountEnabled(ctx context.Context) (int, error)
+}
+
+// PluginRegistry lists registered plugins. Implemented by *plugin.Engine.
+type PluginRegistry interface {
+	IDs() []string
+	IsEnabled(id string) bool
+}
+
+// Capabilities describes which optional subsystems are active.
+type Capabilities struct {
+	OIDC          OIDCCapability          `json:"oidc"`
+	AI            AICapability            `json:"ai"`
+	Notifications NotificationsCapability `json:"notifications"`
+	Plugins       []PluginCapability      `json:"plugins"`
+}
+
+type OIDCCapability struct {
+	Enabled      bool   `json:"enabled"`
+	ProviderName string `json:"provider_name,omitempty"`
+}
+
+type AICapability struct {
+	Enabled  bool   `json:"enabled"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// NotificationsCapability reports whether the notification system is
+// running and how many channels it can deliver to. Configured is true when at
+// least one channel is enabled.
+type NotificationsCapability struct {
+	Enabled    bool `json:"enabled"`
+	Configured bool `json:"configured"`
+	Channels   int  `json:"channels"`
+}
+
+type PluginCapability struct {
+	ID      string `json:"id"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Handlers serves the capability endpoint.
+type Handlers struct {
+	oidc     OIDCStatus
+	ai       AIStatus
+	channels ChannelCounter
+	plugins  PluginRegistry
+}
+
+// NewHandlers creates capability handlers. Any source may be nil, in which
+// case that subsystem is reported as disabled.
+func NewHandlers(oidc OIDCStatus, ai AIStatus, plugins PluginRegistry) *Handlers {
+	return &Handlers{oidc: oidc, ai: ai, plugins: plugins}
+}
+
+// SetNotificationChannels sets the channel store. Leave it unset when the
+// notification broker is not running.
+func (h *Handlers) SetNotificationChannels(channels ChannelCounter) {
+	h.channels = channels
+}
+
+// RegisterRoutes wires the capability endpoint.
+func (h *Handlers) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/capabilities", h.handleGet).Methods(http.MethodGet)
+}
+
+// handleGet handles GET /api/capabilities. Every value is read from the
+// running services, so runtime changes (such as enabling AI or reloading
+// OIDC from settings) are reflected without a restart.
+func (h *Handlers) handleGet(w http.ResponseWriter, r *http.Request) {
+	httputil.WriteJSON(w, http.StatusOK, h.collect(r.Context()))
+}
+
+func (h *Handlers) collect(ctx context.Context) Capabilities {
+	caps := Capabilities{Plugins: []PluginCapability{}}
+
+	if h.oidc != nil && h.oidc.Enabled() {
+		caps.OIDC = OIDCCapability{Enabled: true, ProviderName: h.oidc.ProviderName(ctx)}
+	}
+
+	if h.ai != nil {
+		if enabled, provider := h.ai.Status(); enabled {
+			caps.AI = AICapability{Enabled: true, Provider: provider}
+		}
+	}
+
+	if h.channels != nil {
+		caps.Notifications.Enabled = true
+		n, err := h.channels.CountEnabled(ctx)
+		if err != nil {
+			log.Printf("capabilities: failed to count notification channels: %v", err)
+		}
+		caps.Notifications.Channels = n
+		caps.Notifications.Configured = n > 0
+	}
+
+	if h.plugins != nil {
+		for _, id := range h.plugins.IDs() {
+			caps.Plugins = append(caps.Plugins, PluginCapability{ID: id, Enabled: h.plugins.IsEnabled(id)})
+		}
+	}
+
+	return caps
+}
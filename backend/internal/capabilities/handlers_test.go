@@ -0,0 +1,111 @@
+package capabilities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+type fakeOIDC struct {
+	enabled bool
+	name    string
+}
+
+func (f fakeOIDC) Enabled() bool                           { return f.enabled }
+func (f fakeOIDC) ProviderName(ctx context.Context) string { return f.name }
+
+type fakeAI struct {
+	enabled  bool
+	provider string
+}
+
+func (f fakeAI) Status() (bool, string) { return f.enabled, f.provider }
+
+type fakeChannels struct {
+	n   int
+	err error
+}
+
+func (f fakeChannels) CountEnabled(ctx context.Context) (int, error) { return f.n, f.err }
+
+type fakePlugins map[string]bool
+
+func (f fakePlugins) IDs() []string {
+	return []string{"helm", "istio"}
+}
+
+func (f fakePlugins) IsEnabled(id string) bool { return f[id] }
+
+func getCapabilities(t *testing.T, h *Handlers) Capabilities {
+	t.Helper()
+	r := mux.NewRouter()
+	h.RegisterRoutes(r)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/capabilities", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var caps Capabilities
+	if err := json.NewDecoder(rec.Body).Decode(&caps); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return caps
+}
+
+func TestCapabilitiesAllEnabled(t *testing.T) {
+	h := NewHandlers(fakeOIDC{enabled: true, name: "Okta"}, fakeAI{enabled: true, provider: "claude"}, fakePlugins{"helm": true})
+	h.SetNotificationChannels(fakeChannels{n: 2})
+
+	caps := getCapabilities(t, h)
+	if !caps.OIDC.Enabled || caps.OIDC.ProviderName != "Okta" {
+		t.Errorf("unexpected oidc capability: %+v", caps.OIDC)
+	}
+	if !caps.AI.Enabled || caps.AI.Provider != "claude" {
+		t.Errorf("unexpected ai capability: %+v", caps.AI)
+	}
+	if !caps.Notifications.Enabled || !caps.Notifications.Configured || caps.Notifications.Channels != 2 {
+		t.Errorf("unexpected notifications capability: %+v", caps.Notifications)
+	}
+	want := []PluginCapability{{ID: "helm", Enabled: true}, {ID: "istio", Enabled: false}}
+	if len(caps.Plugins) != len(want) {
+		t.Fatalf("expected %d plugins, got %+v", len(want), caps.Plugins)
+	}
+	for i := range want {
+		if caps.Plugins[i] != want[i] {
+			t.Errorf("plugin %d: expected %+v, got %+v", i, want[i], caps.Plugins[i])
+		}
+	}
+}
+
+func TestCapabilitiesDisabled(t *testing.T) {
+	h := NewHandlers(fakeOIDC{name: "Okta"}, fakeAI{provider: "claude"}, nil)
+
+	caps := getCapabilities(t, h)
+	if caps.OIDC.Enabled || caps.OIDC.ProviderName != "" {
+		t.Errorf("expected oidc disabled without a provider name, got %+v", caps.OIDC)
+	}
+	if caps.AI.Enabled || caps.AI.Provider != "" {
+		t.Errorf("expected ai disabled without a provider, got %+v", caps.AI)
+	}
+	if caps.Notifications.Enabled {
+		t.Errorf("expected notifications disabled without a channel store, got %+v", caps.Notifications)
+	}
+	if caps.Plugins == nil || len(caps.Plugins) != 0 {
+		t.Errorf("expected an empty plugin list, got %v", caps.Plugins)
+	}
+}
+
+func TestCapabilitiesChannelCountError(t *testing.T) {
+	h := NewHandlers(nil, nil, nil)
+	h.SetNotificationChannels(fakeChannels{err: errors.New("db down")})
+
+	caps := getCapabilities(t, h)
+	if !caps.Notifications.Enabled || caps.Notifications.Configured {
+		t.Errorf("expected notifications enabled but not configured, got %+v", caps.Notifications)
+	}
+}
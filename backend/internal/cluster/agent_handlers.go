@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -18,6 +19,8 @@ var installScript []byte
 type AgentHandlers struct {
 	manager  *Manager
 	registry *AgentRegistry
+	// installLinks, when set, restricts the install script to signed links.
+	installLinks *installLinkSigner
 }
 
 func NewAgentHandlers(manager *Manager, registry *AgentRegistry) *AgentHandlers {
@@ -27,6 +30,13 @@ func NewAgentHandlers(manager *Manager, registry *AgentRegistry) *AgentHandlers
 	}
 }
 
+// RequireSignedInstallLinks serves the install script only through the
+// signed, expiring links that generated install commands carry. A link is
+// valid for ttl and only while the agent token it was issued for is unused.
+func (h *AgentHandlers) RequireSignedInstallLinks(secret string, ttl time.Duration) {
+	h.installLinks = newInstallLinkSigner(secret, ttl)
+}
+
 // RegisterPublicRoutes registers routes that don't require authentication (install script).
 func (h *AgentHandlers) RegisterPublicRoutes(r *mux.Router) {
 	r.HandleFunc("/api/agents/install.sh", h.handleInstallScript).Methods("GET")
@@ -43,6 +53,19 @@ func (h *AgentHandlers) RegisterRoutes(r *mux.Router) {
 }
 
 func (h *AgentHandlers) handleInstallScript(w http.ResponseWriter, r *http.Request) {
+	if h.installLinks != nil {
+		tokenID, err := h.installLinks.verify(r.URL.Query())
+		if err != nil {
+			httputil.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		token, err := h.registry.GetToken(r.Context(), tokenID)
+		if err != nil || token.Used || time.Now().After(token.ExpiresAt) {
+			httputil.WriteError(w, http.StatusForbidden, errInvalidInstallLink.Error())
+			return
+		}
+	}
+
 	w.Header().Set("Content-Type", "text/x-shellscript")
 	w.Header().Set("Content-Disposition", "attachment; filename=\"install.sh\"")
 	w.WriteHeader(http.StatusOK)
@@ -64,41 +87,33 @@ type generateTokenResponse struct {
 func (h *AgentHandlers) handleGenerateToken(w http.ResponseWriter, r *http.Request) {
 	var req generateTokenRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		httputil.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	if req.ClusterName == "" {
-		httputil.WriteJSON(w, http.StatusBadRequest, map[string]string{"error": "cluster_name is required"})
+		httputil.WriteError(w, http.StatusBadRequest, "cluster_name is required")
 		return
 	}
 
 	// Extract user ID from context (set by auth middleware).
 	userID := getUserIDFromContext(r)
 	if userID == "" {
-		httputil.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	rawToken, tokenInfo, err := h.registry.GenerateToken(r.Context(), req.ClusterName, userID, req.Permissions)
 	if err != nil {
-		httputil.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to generate token"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to generate token")
 		return
 	}
 
 	// Build the install command so the frontend gets it in a single request.
-	dashboardURL := r.Header.Get("X-Dashboard-URL")
-	if dashboardURL == "" {
-		scheme := "https"
-		if r.TLS == nil {
-			scheme = "http"
-		}
-		dashboardURL = fmt.Sprintf("%s://%s", scheme, r.Host)
-	}
-
+	dashboardURL := dashboardURLFromRequest(r)
 	installCmd := fmt.Sprintf(
-		"curl -sSL %s/api/agents/install.sh | bash -s -- \\\n  --dashboard-url %s \\\n  --cluster-name %q \\\n  --token %s",
-		dashboardURL, dashboardURL, req.ClusterName, rawToken,
+		"curl -sSL %s | bash -s -- \\\n  --dashboard-url %s \\\n  --cluster-name %q \\\n  --token %s",
+		h.installScriptURL(dashboardURL, tokenInfo.ID), dashboardURL, req.ClusterName, rawToken,
 	)
 
 	httputil.WriteJSON(w, http.StatusCreated, generateTokenResponse{
@@ -112,13 +127,13 @@ func (h *AgentHandlers) handleGenerateToken(w http.ResponseWriter, r *http.Reque
 func (h *AgentHandlers) handleListTokens(w http.ResponseWriter, r *http.Request) {
 	userID := getUserIDFromContext(r)
 	if userID == "" {
-		httputil.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	tokens, err := h.registry.ListTokens(r.Context(), userID)
 	if err != nil {
-		httputil.WriteJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to list tokens"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to list tokens")
 		return
 	}
 
@@ -134,18 +149,18 @@ func (h *AgentHandlers) handleGetToken(w http.ResponseWriter, r *http.Request) {
 
 	userID := getUserIDFromContext(r)
 	if userID == "" {
-		httputil.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	token, err := h.registry.GetToken(r.Context(), id)
 	if err != nil {
-		httputil.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "token not found"})
+		httputil.WriteError(w, http.StatusNotFound, "token not found")
 		return
 	}
 
 	if token.CreatedBy != userID {
-		httputil.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		httputil.WriteError(w, http.StatusForbidden, "forbidden")
 		return
 	}
 
@@ -157,44 +172,36 @@ func (h *AgentHandlers) handleInstallCommand(w http.ResponseWriter, r *http.Requ
 
 	userID := getUserIDFromContext(r)
 	if userID == "" {
-		httputil.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	token, err := h.registry.GetToken(r.Context(), id)
 	if err != nil {
-		httputil.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "token not found"})
+		httputil.WriteError(w, http.StatusNotFound, "token not found")
 		return
 	}
 
 	if token.CreatedBy != userID {
-		httputil.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		httputil.WriteError(w, http.StatusForbidden, "forbidden")
 		return
 	}
 
 	if token.Used {
-		httputil.WriteJSON(w, http.StatusConflict, map[string]string{"error": "token already used"})
+		httputil.WriteError(w, http.StatusConflict, "token already used")
 		return
 	}
 
 	// Build the install command. The actual token is not stored, so we just
 	// provide the helm install template. Users should use the token from the
 	// generation response.
-	dashboardURL := r.Header.Get("X-Dashboard-URL")
-	if dashboardURL == "" {
-		scheme := "https"
-		if r.TLS == nil {
-			scheme = "http"
-		}
-		dashboardURL = fmt.Sprintf("%s://%s", scheme, r.Host)
-	}
-
+	dashboardURL := dashboardURLFromRequest(r)
 	installCmd := fmt.Sprintf(
-		`curl -sSL %s/api/agents/install.sh | bash -s -- \
+		`curl -sSL %s | bash -s -- \
   --dashboard-url %s \
   --cluster-name %q \
   --token <YOUR_TOKEN>`,
-		dashboardURL, dashboardURL, token.ClusterName,
+		h.installScriptURL(dashboardURL, token.ID), dashboardURL, token.ClusterName,
 	)
 
 	httputil.WriteJSON(w, http.StatusOK, map[string]string{
@@ -208,30 +215,53 @@ func (h *AgentHandlers) handleRevokeToken(w http.ResponseWriter, r *http.Request
 
 	userID := getUserIDFromContext(r)
 	if userID == "" {
-		httputil.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	// Verify ownership before revoking.
 	token, err := h.registry.GetToken(r.Context(), id)
 	if err != nil {
-		httputil.WriteJSON(w, http.StatusNotFound, map[string]string{"error": "token not found"})
+		httputil.WriteError(w, http.StatusNotFound, "token not found")
 		return
 	}
 
 	if token.CreatedBy != userID {
-		httputil.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+		httputil.WriteError(w, http.StatusForbidden, "forbidden")
 		return
 	}
 
 	if err := h.registry.RevokeToken(r.Context(), id); err != nil {
-		httputil.WriteJSON(w, http.StatusConflict, map[string]string{"error": "token not found or already used"})
+		httputil.WriteError(w, http.StatusConflict, "token not found or already used")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// dashboardURLFromRequest returns the dashboard's external URL, from the
+// X-Dashboard-URL header or else the request's own scheme and host.
+func dashboardURLFromRequest(r *http.Request) string {
+	if dashboardURL := r.Header.Get("X-Dashboard-URL"); dashboardURL != "" {
+		return dashboardURL
+	}
+	scheme := "https"
+	if r.TLS == nil {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s", scheme, r.Host)
+}
+
+// installScriptURL returns the install script URL for an install command.
+// Signed links are quoted so the shell doesn't split the query string.
+func (h *AgentHandlers) installScriptURL(dashboardURL, tokenID string) string {
+	scriptURL := dashboardURL + "/api/agents/install.sh"
+	if h.installLinks == nil {
+		return scriptURL
+	}
+	return "'" + scriptURL + "?" + h.installLinks.query(tokenID) + "'"
+}
+
 // getUserIDFromContext extracts the user ID from JWT claims set by the auth middleware.
 func getUserIDFromContext(r *http.Request) string {
 	claims, ok := auth.ClaimsFromContext(r.Context())
@@ -3,6 +3,7 @@ package cluster
 import (
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -11,6 +12,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/darkden-lab/argus/backend/internal/metrics"
 	"github.com/darkden-lab/argus/backend/pkg/agentpb"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -19,14 +21,56 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// DefaultMaxInFlight is how many requests may wait on one agent at a time
+// unless SetMaxInFlight says otherwise.
+const DefaultMaxInFlight = 256
+
+var (
+	// ErrAgentOverloaded is returned when a cluster's agent already has the
+	// maximum number of requests in flight. The caller may retry shortly.
+	ErrAgentOverloaded = errors.New("agent has too many requests in flight")
+	// ErrAgentDisconnected is returned to requests still waiting for a
+	// response when their agent's stream ends.
+	ErrAgentDisconnected = errors.New("agent disconnected before responding")
+	// ErrAgentTimeout is returned when the agent does not respond before the
+	// request's deadline. It wraps context.DeadlineExceeded.
+	ErrAgentTimeout = errors.New("agent did not respond in time")
+	// ErrAgentNotConnected is returned for a cluster whose agent has no live
+	// stream. It also matches ErrClusterUnavailable, for callers that do not
+	// tell an offline agent from an unknown cluster.
+	ErrAgentNotConnected error = agentNotConnectedError{}
+)
+
+type agentNotConnectedError struct{}
+
+func (agentNotConnectedError) Error() string { return "cluster agent is not connected" }
+
+func (agentNotConnectedError) Is(target error) bool { return target == ErrClusterUnavailable }
+
 // AgentConnection represents a live agent connected via gRPC stream.
 type AgentConnection struct {
 	ClusterID string
 	Stream    grpc.BidiStreamingServer[agentpb.AgentMessage, agentpb.DashboardMessage]
+	// Encoding is the response compression negotiated at stream setup
+	// ("" when the agent sends bodies uncompressed).
+	Encoding string
 	// pending tracks in-flight K8s requests awaiting a response from the agent.
 	pending map[string]chan *agentpb.K8SResponse
+	// done is closed when the stream ends, failing every pending request.
+	done <-chan struct{}
+	// streams tracks in-flight streaming requests by request ID.
+	streams map[string]*pendingStream
 	mu      sync.Mutex
-	cancel  context.CancelFunc
+	// sendMu serializes Stream.Send, which is not safe for concurrent use.
+	sendMu sync.Mutex
+	cancel context.CancelFunc
+}
+
+// send writes a message to the agent stream.
+func (c *AgentConnection) send(msg *agentpb.DashboardMessage) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	return c.Stream.Send(msg)
 }
 
 // AgentServer implements the ClusterAgent gRPC service.
@@ -37,17 +81,110 @@ type AgentServer struct {
 	jwtSecret  []byte
 	agents     map[string]*AgentConnection // clusterID -> connection
 	mu         sync.RWMutex
+	// minAgentVersion is the oldest agent version accepted without a
+	// warning; rejectOutdated refuses older agents instead.
+	minAgentVersion string
+	rejectOutdated  bool
+	// maxInFlight caps the pending requests per agent connection. rejected
+	// counts requests refused by the cap, per cluster.
+	maxInFlight int
+	rejectedMu  sync.Mutex
+	rejected    map[string]uint64
 }
 
 func NewAgentServer(pool *pgxpool.Pool, store *Store, jwtSecret string) *AgentServer {
 	return &AgentServer{
-		pool:      pool,
-		store:     store,
-		jwtSecret: []byte(jwtSecret),
-		agents:    make(map[string]*AgentConnection),
+		pool:            pool,
+		store:           store,
+		jwtSecret:       []byte(jwtSecret),
+		agents:          make(map[string]*AgentConnection),
+		minAgentVersion: agentpb.MinSupportedAgentVersion,
+		maxInFlight:     DefaultMaxInFlight,
+		rejected:        make(map[string]uint64),
 	}
 }
 
+// SetMaxInFlight sets how many requests may wait on one agent at a time;
+// further requests fail with ErrAgentOverloaded until some complete.
+// Streaming requests are not counted. n <= 0 keeps the default. Call it
+// before the server accepts agents.
+func (s *AgentServer) SetMaxInFlight(n int) {
+	if n > 0 {
+		s.maxInFlight = n
+	}
+}
+
+// RegisterMetrics exposes the in-flight and rejected agent requests per
+// cluster.
+func (s *AgentServer) RegisterMetrics(reg *metrics.Registry) {
+	reg.Register("argus_agent_requests_in_flight", "Requests waiting for a response from a cluster agent.", metrics.Gauge,
+		func() []metrics.Sample {
+			s.mu.RLock()
+			conns := make([]*AgentConnection, 0, len(s.agents))
+			for _, conn := range s.agents {
+				conns = append(conns, conn)
+			}
+			s.mu.RUnlock()
+
+			samples := make([]metrics.Sample, 0, len(conns))
+			for _, conn := range conns {
+				conn.mu.Lock()
+				n := len(conn.pending)
+				conn.mu.Unlock()
+				samples = append(samples, metrics.Sample{Labels: map[string]string{"cluster_id": conn.ClusterID}, Value: float64(n)})
+			}
+			return samples
+		})
+	reg.Register("argus_agent_requests_rejected_total", "Agent requests refused because the agent had too many in flight.", metrics.Counter,
+		func() []metrics.Sample {
+			s.rejectedMu.Lock()
+			defer s.rejectedMu.Unlock()
+			samples := make([]metrics.Sample, 0, len(s.rejected))
+			for clusterID, n := range s.rejected {
+				samples = append(samples, metrics.Sample{Labels: map[string]string{"cluster_id": clusterID}, Value: float64(n)})
+			}
+			return samples
+		})
+}
+
+// SetAgentVersionPolicy sets the minimum agent version. Older agents are
+// logged and flagged on the cluster, or refused on register and connect when
+// reject is true. An empty min keeps the built-in minimum. Call it before
+// the server accepts agents.
+func (s *AgentServer) SetAgentVersionPolicy(min string, reject bool) {
+	if min != "" {
+		s.minAgentVersion = min
+	}
+	s.rejectOutdated = reject
+}
+
+// AgentVersionWarning describes why an agent version is outdated, or
+// returns "" if it is supported.
+func (s *AgentServer) AgentVersionWarning(version string) string {
+	if !agentpb.AgentVersionOutdated(version, s.minAgentVersion) {
+		return ""
+	}
+	if version == "" {
+		version = "unknown"
+	}
+	return fmt.Sprintf("agent version %s is older than the minimum supported version %s; upgrade the agent", version, s.minAgentVersion)
+}
+
+// checkAgentVersion applies the version policy to an agent that registers
+// or connects, returning an error if it must be refused.
+func (s *AgentServer) checkAgentVersion(cluster, version string) error {
+	warning := s.AgentVersionWarning(version)
+	if warning == "" {
+		return nil
+	}
+	if s.rejectOutdated {
+		log.Printf("Refusing agent for cluster %s: %s", cluster, warning)
+		return status.Error(codes.FailedPrecondition, warning)
+	}
+	log.Printf("WARNING: cluster %s: %s", cluster, warning)
+	return nil
+}
+
 // Register validates a one-time registration token, creates the cluster entry,
 // and returns permanent agent credentials.
 func (s *AgentServer) Register(ctx context.Context, req *agentpb.RegisterRequest) (*agentpb.RegisterResponse, error) {
@@ -57,6 +194,9 @@ func (s *AgentServer) Register(ctx context.Context, req *agentpb.RegisterRequest
 	if req.ClusterName == "" {
 		return nil, status.Error(codes.InvalidArgument, "cluster_name is required")
 	}
+	if err := s.checkAgentVersion(req.ClusterName, req.GetClusterInfo().GetAgentVersion()); err != nil {
+		return nil, err
+	}
 
 	// Hash the token and look it up.
 	tokenHash := hashToken(req.Token)
@@ -101,6 +241,20 @@ func (s *AgentServer) Register(ctx context.Context, req *agentpb.RegisterRequest
 		log.Printf("WARNING: failed to mark agent token as used: %v", err)
 	}
 
+	// Keep the version and summary the agent registered with.
+	if req.ClusterInfo != nil {
+		if err := s.store.UpsertClusterInfo(ctx, clusterID, clusterInfoFromProto(req.ClusterInfo)); err != nil {
+			log.Printf("Failed to store cluster info from %s: %v", clusterID, err)
+		}
+	}
+	s.recordConnectionEvent(ctx, &ConnectionEvent{
+		ClusterID:    clusterID,
+		Event:        ConnectionEventRegistered,
+		AgentID:      &agentID,
+		TokenID:      &tokenID,
+		AgentVersion: req.GetClusterInfo().GetAgentVersion(),
+	})
+
 	// Generate a permanent agent JWT.
 	agentToken, err := s.generateAgentToken(clusterID, agentID)
 	if err != nil {
@@ -141,12 +295,35 @@ func (s *AgentServer) Stream(stream grpc.BidiStreamingServer[agentpb.AgentMessag
 
 	clusterID := claims.ClusterID
 
+	// Agents send their version when opening the stream; older ones do not.
+	var agentVersion string
+	if v := md.Get(agentpb.AgentVersionMetadataKey); len(v) > 0 {
+		agentVersion = v[0]
+	}
+	if err := s.checkAgentVersion(clusterID, agentVersion); err != nil {
+		ev := connectionEvent(clusterID, ConnectionEventRejected, claims)
+		ev.Reason = status.Convert(err).Message()
+		ev.AgentVersion = agentVersion
+		s.recordConnectionEvent(stream.Context(), ev)
+		return err
+	}
+
+	// Negotiate response compression. The header is always sent so the agent
+	// does not wait for the first message to learn the outcome.
+	encoding := agentpb.NegotiateEncoding(md.Get(agentpb.AcceptEncodingMetadataKey))
+	if err := stream.SendHeader(metadata.Pairs(agentpb.EncodingMetadataKey, encoding)); err != nil {
+		return status.Errorf(codes.Internal, "failed to send stream header: %v", err)
+	}
+
 	// Set up the connection.
 	ctx, cancel := context.WithCancel(stream.Context())
 	conn := &AgentConnection{
 		ClusterID: clusterID,
 		Stream:    stream,
+		Encoding:  encoding,
 		pending:   make(map[string]chan *agentpb.K8SResponse),
+		done:      ctx.Done(),
+		streams:   make(map[string]*pendingStream),
 		cancel:    cancel,
 	}
 
@@ -162,17 +339,28 @@ func (s *AgentServer) Stream(stream grpc.BidiStreamingServer[agentpb.AgentMessag
 
 	// Update cluster status.
 	_ = s.store.UpdateClusterStatus(ctx, clusterID, "connected")
-	log.Printf("Agent stream started: cluster=%s", clusterID)
+	log.Printf("Agent stream started: cluster=%s version=%q encoding=%q", clusterID, agentVersion, encoding)
+	connected := connectionEvent(clusterID, ConnectionEventConnected, claims)
+	connected.AgentVersion = agentVersion
+	s.recordConnectionEvent(ctx, connected)
 
+	var recvErr error
 	defer func() {
 		s.mu.Lock()
-		if s.agents[clusterID] == conn {
+		replaced := s.agents[clusterID] != conn
+		if !replaced {
 			delete(s.agents, clusterID)
 		}
 		s.mu.Unlock()
 		cancel()
+		conn.closeStreams()
 		_ = s.store.UpdateClusterStatus(context.Background(), clusterID, "disconnected")
-		log.Printf("Agent stream ended: cluster=%s", clusterID)
+		reason := disconnectReason(recvErr, replaced)
+		log.Printf("Agent stream ended: cluster=%s reason=%q", clusterID, reason)
+		disconnected := connectionEvent(clusterID, ConnectionEventDisconnected, claims)
+		disconnected.Reason = reason
+		disconnected.AgentVersion = agentVersion
+		s.recordConnectionEvent(stream.Context(), disconnected)
 	}()
 
 	// Start a ping ticker for heartbeat.
@@ -182,12 +370,15 @@ func (s *AgentServer) Stream(stream grpc.BidiStreamingServer[agentpb.AgentMessag
 	for {
 		msg, err := stream.Recv()
 		if err != nil {
+			recvErr = err
 			return err
 		}
 
 		switch payload := msg.Payload.(type) {
 		case *agentpb.AgentMessage_K8SResponse:
 			s.handleK8sResponse(conn, payload.K8SResponse)
+		case *agentpb.AgentMessage_K8SResponseChunk:
+			conn.handleK8sResponseChunk(payload.K8SResponseChunk)
 		case *agentpb.AgentMessage_WatchEvent:
 			// Watch events will be forwarded to WebSocket hub in future tasks.
 			log.Printf("Watch event from cluster %s: watch=%s type=%s",
@@ -196,29 +387,50 @@ func (s *AgentServer) Stream(stream grpc.BidiStreamingServer[agentpb.AgentMessag
 			// Pong received, update health.
 			_ = s.store.UpdateClusterStatus(ctx, clusterID, "connected")
 		case *agentpb.AgentMessage_ClusterInfo:
-			log.Printf("Cluster info from %s: k8s=%s nodes=%d",
-				clusterID, payload.ClusterInfo.KubernetesVersion, payload.ClusterInfo.NodeCount)
+			if err := s.store.UpsertClusterInfo(ctx, clusterID, clusterInfoFromProto(payload.ClusterInfo)); err != nil {
+				log.Printf("Failed to store cluster info from %s: %v", clusterID, err)
+			}
 		}
 	}
 }
 
 // SendK8sRequest sends a K8s API request to a connected agent and waits for the response.
+// The returned body is always uncompressed.
 func (s *AgentServer) SendK8sRequest(ctx context.Context, clusterID string, req *agentpb.K8SRequest) (*agentpb.K8SResponse, error) {
+	resp, err := s.SendK8sRequestRaw(ctx, clusterID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.DecompressBody(); err != nil {
+		return nil, fmt.Errorf("failed to decompress agent response: %w", err)
+	}
+	return resp, nil
+}
+
+// SendK8sRequestRaw is like SendK8sRequest but returns the body exactly as the
+// agent sent it, which may be compressed (see K8SResponse.Compressed). Callers
+// that can forward the compressed bytes avoid decompressing them.
+func (s *AgentServer) SendK8sRequestRaw(ctx context.Context, clusterID string, req *agentpb.K8SRequest) (*agentpb.K8SResponse, error) {
 	s.mu.RLock()
 	conn, ok := s.agents[clusterID]
 	s.mu.RUnlock()
 
 	if !ok {
-		return nil, fmt.Errorf("no agent connected for cluster %s", clusterID)
+		return nil, fmt.Errorf("cluster %s: %w", clusterID, ErrAgentNotConnected)
 	}
 
 	if req.RequestId == "" {
 		req.RequestId = uuid.New().String()
 	}
 
-	// Create a response channel.
+	// Create a response channel, unless the agent is already at capacity.
 	ch := make(chan *agentpb.K8SResponse, 1)
 	conn.mu.Lock()
+	if s.maxInFlight > 0 && len(conn.pending) >= s.maxInFlight {
+		conn.mu.Unlock()
+		s.recordRejected(clusterID)
+		return nil, ErrAgentOverloaded
+	}
 	conn.pending[req.RequestId] = ch
 	conn.mu.Unlock()
 
@@ -229,7 +441,7 @@ func (s *AgentServer) SendK8sRequest(ctx context.Context, clusterID string, req
 	}()
 
 	// Send the request to the agent.
-	err := conn.Stream.Send(&agentpb.DashboardMessage{
+	err := conn.send(&agentpb.DashboardMessage{
 		Payload: &agentpb.DashboardMessage_K8SRequest{
 			K8SRequest: req,
 		},
@@ -238,15 +450,36 @@ func (s *AgentServer) SendK8sRequest(ctx context.Context, clusterID string, req
 		return nil, fmt.Errorf("failed to send request to agent: %w", err)
 	}
 
-	// Wait for response or context cancellation.
+	// Wait for the response, the agent disconnecting or context cancellation.
 	select {
 	case resp := <-ch:
 		return resp, nil
+	case <-conn.done:
+		// The response may have arrived just before the stream ended.
+		select {
+		case resp := <-ch:
+			return resp, nil
+		default:
+		}
+		return nil, ErrAgentDisconnected
 	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: %w", ErrAgentTimeout, ctx.Err())
+		}
 		return nil, ctx.Err()
 	}
 }
 
+// recordRejected counts a request refused by the in-flight cap.
+func (s *AgentServer) recordRejected(clusterID string) {
+	s.rejectedMu.Lock()
+	defer s.rejectedMu.Unlock()
+	if s.rejected == nil {
+		s.rejected = make(map[string]uint64)
+	}
+	s.rejected[clusterID]++
+}
+
 // IsAgentConnected checks whether a given cluster has a live agent stream.
 func (s *AgentServer) IsAgentConnected(clusterID string) bool {
 	s.mu.RLock()
@@ -279,7 +512,7 @@ func (s *AgentServer) pingLoop(ctx context.Context, conn *AgentConnection) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			err := conn.Stream.Send(&agentpb.DashboardMessage{
+			err := conn.send(&agentpb.DashboardMessage{
 				Payload: &agentpb.DashboardMessage_Ping{
 					Ping: &agentpb.Ping{
 						Timestamp: timestamppb.Now(),
@@ -337,3 +570,19 @@ func hashToken(token string) string {
 	h := sha256.Sum256([]byte(token))
 	return fmt.Sprintf("%x", h)
 }
+
+// clusterInfoFromProto converts an agent's ClusterInfo report.
+func clusterInfoFromProto(info *agentpb.ClusterInfo) *ClusterInfo {
+	return &ClusterInfo{
+		KubernetesVersion: info.GetKubernetesVersion(),
+		Platform:          info.GetPlatform(),
+		NodeCount:         int(info.GetNodeCount()),
+		Capacity:          nodeResourcesFromProto(info.GetCapacity()),
+		Allocatable:       nodeResourcesFromProto(info.GetAllocatable()),
+		AgentVersion:      info.GetAgentVersion(),
+	}
+}
+
+func nodeResourcesFromProto(r *agentpb.NodeResources) NodeResources {
+	return NodeResources{CPU: r.GetCpu(), Memory: r.GetMemory(), Pods: r.GetPods()}
+}
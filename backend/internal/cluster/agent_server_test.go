@@ -1,10 +1,21 @@
 package cluster
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/darkden-lab/argus/backend/internal/metrics"
+	"github.com/darkden-lab/argus/backend/pkg/agentpb"
 )
 
 func TestHashToken(t *testing.T) {
@@ -121,3 +132,202 @@ func TestIsAgentConnected(t *testing.T) {
 		t.Error("expected true for connected cluster")
 	}
 }
+
+// fakeAgentStream answers every K8s request with a gzip-compressed body and
+// status (200 when unset).
+type fakeAgentStream struct {
+	grpc.ServerStream
+	server *AgentServer
+	conn   *AgentConnection
+	body   []byte
+	status int32
+}
+
+func (f *fakeAgentStream) Send(msg *agentpb.DashboardMessage) error {
+	req := msg.GetK8SRequest()
+	status := f.status
+	if status == 0 {
+		status = 200
+	}
+	resp := &agentpb.K8SResponse{RequestId: req.RequestId, StatusCode: status, Body: f.body, ContentType: "application/json"}
+	if err := resp.CompressBody(agentpb.EncodingGzip); err != nil {
+		return err
+	}
+	f.server.handleK8sResponse(f.conn, resp)
+	return nil
+}
+
+func (f *fakeAgentStream) Recv() (*agentpb.AgentMessage, error) {
+	return nil, io.EOF
+}
+
+func TestSendK8sRequest_DecompressesBody(t *testing.T) {
+	server := NewAgentServer(nil, nil, "test-secret")
+	conn := &AgentConnection{
+		ClusterID: "c1",
+		Encoding:  agentpb.EncodingGzip,
+		pending:   make(map[string]chan *agentpb.K8SResponse),
+	}
+	body := bytes.Repeat([]byte(`{"kind":"Pod"},`), 500)
+	conn.Stream = &fakeAgentStream{server: server, conn: conn, body: body}
+	server.agents["c1"] = conn
+
+	raw, err := server.SendK8sRequestRaw(context.Background(), "c1", &agentpb.K8SRequest{Method: "GET", Path: "/api/v1/pods"})
+	if err != nil {
+		t.Fatalf("SendK8sRequestRaw failed: %v", err)
+	}
+	if !raw.Compressed || raw.ContentEncoding != agentpb.EncodingGzip {
+		t.Errorf("expected raw response to stay compressed, got compressed=%v encoding=%q", raw.Compressed, raw.ContentEncoding)
+	}
+
+	resp, err := server.SendK8sRequest(context.Background(), "c1", &agentpb.K8SRequest{Method: "GET", Path: "/api/v1/pods"})
+	if err != nil {
+		t.Fatalf("SendK8sRequest failed: %v", err)
+	}
+	if resp.Compressed || !bytes.Equal(resp.Body, body) {
+		t.Error("expected SendK8sRequest to return the decompressed body")
+	}
+	if resp.ContentType != "application/json" {
+		t.Errorf("expected content type to be preserved, got %q", resp.ContentType)
+	}
+}
+
+// silentAgentStream accepts requests without ever answering them.
+type silentAgentStream struct {
+	grpc.ServerStream
+	sent chan string
+}
+
+func (f *silentAgentStream) Send(msg *agentpb.DashboardMessage) error {
+	f.sent <- msg.GetK8SRequest().GetRequestId()
+	return nil
+}
+
+func (f *silentAgentStream) Recv() (*agentpb.AgentMessage, error) {
+	return nil, io.EOF
+}
+
+func TestSendK8sRequest_InFlightLimitAndDisconnect(t *testing.T) {
+	server := NewAgentServer(nil, nil, "test-secret")
+	server.SetMaxInFlight(1)
+	done := make(chan struct{})
+	agent := &silentAgentStream{sent: make(chan string, 1)}
+	server.agents["c1"] = &AgentConnection{
+		ClusterID: "c1",
+		Stream:    agent,
+		pending:   make(map[string]chan *agentpb.K8SResponse),
+		done:      done,
+	}
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := server.SendK8sRequest(context.Background(), "c1", &agentpb.K8SRequest{Method: "GET", Path: "/api/v1/pods"})
+		first <- err
+	}()
+	<-agent.sent
+
+	_, err := server.SendK8sRequest(context.Background(), "c1", &agentpb.K8SRequest{Method: "GET", Path: "/api/v1/pods"})
+	if !errors.Is(err, ErrAgentOverloaded) {
+		t.Fatalf("expected ErrAgentOverloaded above the limit, got %v", err)
+	}
+
+	reg := metrics.NewRegistry()
+	server.RegisterMetrics(reg)
+	var sb strings.Builder
+	if err := reg.Write(&sb); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`argus_agent_requests_in_flight{cluster_id="c1"} 1`, `argus_agent_requests_rejected_total{cluster_id="c1"} 1`} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, sb.String())
+		}
+	}
+
+	// Ending the stream fails the request that is still waiting.
+	close(done)
+	select {
+	case err := <-first:
+		if !errors.Is(err, ErrAgentDisconnected) {
+			t.Errorf("expected ErrAgentDisconnected, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pending request was not failed on disconnect")
+	}
+}
+
+func TestSendK8sRequest_TypedErrors(t *testing.T) {
+	server := NewAgentServer(nil, nil, "test-secret")
+	_, err := server.SendK8sRequest(context.Background(), "c1", &agentpb.K8SRequest{Method: "GET", Path: "/api/v1/pods"})
+	if !errors.Is(err, ErrAgentNotConnected) || !errors.Is(err, ErrClusterUnavailable) {
+		t.Errorf("expected ErrAgentNotConnected for a cluster without an agent, got %v", err)
+	}
+
+	agent := &silentAgentStream{sent: make(chan string, 1)}
+	server.agents["c1"] = &AgentConnection{
+		ClusterID: "c1",
+		Stream:    agent,
+		pending:   make(map[string]chan *agentpb.K8SResponse),
+		done:      make(chan struct{}),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = server.SendK8sRequest(ctx, "c1", &agentpb.K8SRequest{Method: "GET", Path: "/api/v1/pods"})
+	if !errors.Is(err, ErrAgentTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected ErrAgentTimeout wrapping the deadline, got %v", err)
+	}
+	if errors.Is(err, ErrAgentNotConnected) {
+		t.Error("a timeout should not read as a missing agent")
+	}
+}
+
+func TestClusterInfoFromProto(t *testing.T) {
+	info := clusterInfoFromProto(&agentpb.ClusterInfo{
+		KubernetesVersion: "v1.31.2",
+		NodeCount:         3,
+		Capacity:          &agentpb.NodeResources{Cpu: "12", Memory: "48Gi", Pods: "330"},
+		AgentVersion:      "0.1.0",
+	})
+
+	if info.KubernetesVersion != "v1.31.2" || info.NodeCount != 3 || info.AgentVersion != "0.1.0" {
+		t.Errorf("unexpected info: %+v", info)
+	}
+	if info.Capacity != (NodeResources{CPU: "12", Memory: "48Gi", Pods: "330"}) {
+		t.Errorf("unexpected capacity: %+v", info.Capacity)
+	}
+	// Agents that predate capacity reporting leave it unset.
+	if info.Allocatable != (NodeResources{}) {
+		t.Errorf("expected empty allocatable, got %+v", info.Allocatable)
+	}
+}
+
+func TestAgentVersionWarning(t *testing.T) {
+	server := NewAgentServer(nil, nil, "test-secret")
+	server.SetAgentVersionPolicy("0.2.0", false)
+
+	if w := server.AgentVersionWarning("0.2.1"); w != "" {
+		t.Errorf("expected no warning for a supported agent, got %q", w)
+	}
+	if w := server.AgentVersionWarning("0.1.0"); !strings.Contains(w, "0.1.0") || !strings.Contains(w, "0.2.0") {
+		t.Errorf("expected warning naming both versions, got %q", w)
+	}
+	if w := server.AgentVersionWarning(""); !strings.Contains(w, "unknown") {
+		t.Errorf("expected warning for an agent that does not report its version, got %q", w)
+	}
+	if err := server.checkAgentVersion("c1", "0.1.0"); err != nil {
+		t.Errorf("expected outdated agent to be accepted with a warning, got %v", err)
+	}
+}
+
+func TestRegister_RejectsOutdatedAgent(t *testing.T) {
+	server := NewAgentServer(nil, nil, "test-secret")
+	server.SetAgentVersionPolicy("0.2.0", true)
+
+	_, err := server.Register(context.Background(), &agentpb.RegisterRequest{
+		Token:       "token",
+		ClusterName: "prod",
+		ClusterInfo: &agentpb.ClusterInfo{AgentVersion: "0.1.0"},
+	})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition, got %v", err)
+	}
+}
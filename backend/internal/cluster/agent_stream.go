@@ -0,0 +1,234 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/darkden-lab/argus/backend/pkg/agentpb"
+)
+
+const (
+	// streamBufferChunks is how many chunks may queue for a slow reader before
+	// the stream is aborted. Blocking instead would stall every other request
+	// multiplexed on the same agent connection.
+	streamBufferChunks = 256
+
+	// streamHeaderTimeout bounds the wait for the first chunk, which carries
+	// the status code. Agents that predate streaming never answer.
+	streamHeaderTimeout = 30 * time.Second
+)
+
+// errStreamAborted is returned by AgentStream.Read when the stream ended
+// without a final chunk: the agent disconnected or the reader fell behind.
+var errStreamAborted = errors.New("agent stream aborted")
+
+// pendingStream is the dashboard side of an in-flight streaming request.
+type pendingStream struct {
+	chunks chan *agentpb.K8SResponseChunk
+	done   chan struct{}
+}
+
+// AgentStream is a K8s API response streamed from an agent. It reads the body
+// incrementally as chunks arrive, so watches and followed logs never have to
+// be buffered in full. StatusCode, Headers and ContentType come from the
+// first chunk.
+type AgentStream struct {
+	StatusCode  int
+	Headers     map[string]string
+	ContentType string
+
+	conn      *AgentConnection
+	requestID string
+	chunks    <-chan *agentpb.K8SResponseChunk
+	buf       []byte
+	err       error
+}
+
+// OpenK8sStream sends a streaming K8s API request to a connected agent and
+// waits for the first chunk. The stream stays open until the agent sends the
+// final chunk, ctx is cancelled or Close is called.
+func (s *AgentServer) OpenK8sStream(ctx context.Context, clusterID string, req *agentpb.K8SRequest) (*AgentStream, error) {
+	s.mu.RLock()
+	conn, ok := s.agents[clusterID]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("cluster %s: %w", clusterID, ErrAgentNotConnected)
+	}
+
+	if req.RequestId == "" {
+		req.RequestId = uuid.New().String()
+	}
+
+	ps := &pendingStream{
+		chunks: make(chan *agentpb.K8SResponseChunk, streamBufferChunks),
+		done:   make(chan struct{}),
+	}
+	conn.mu.Lock()
+	if conn.streams == nil {
+		conn.streams = make(map[string]*pendingStream)
+	}
+	conn.streams[req.RequestId] = ps
+	conn.mu.Unlock()
+
+	err := conn.send(&agentpb.DashboardMessage{
+		Payload: &agentpb.DashboardMessage_K8SStreamRequest{
+			K8SStreamRequest: &agentpb.K8SStreamRequest{Request: req},
+		},
+	})
+	if err != nil {
+		conn.finishStream(req.RequestId)
+		return nil, fmt.Errorf("failed to send stream request to agent: %w", err)
+	}
+
+	// Tell the agent to stop once the caller is gone.
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.cancelStream(req.RequestId)
+		case <-ps.done:
+		}
+	}()
+
+	stream := &AgentStream{conn: conn, requestID: req.RequestId, chunks: ps.chunks}
+
+	timer := time.NewTimer(streamHeaderTimeout)
+	defer timer.Stop()
+	select {
+	case first, ok := <-ps.chunks:
+		if !ok {
+			return nil, errStreamAborted
+		}
+		if first.Final && first.Error != "" {
+			return nil, fmt.Errorf("agent stream failed: %s", first.Error)
+		}
+		stream.StatusCode = int(first.StatusCode)
+		stream.Headers = first.Headers
+		stream.ContentType = first.ContentType
+		stream.push(first)
+		return stream, nil
+	case <-timer.C:
+		conn.cancelStream(req.RequestId)
+		return nil, fmt.Errorf("agent did not start streaming within %v", streamHeaderTimeout)
+	case <-ctx.Done():
+		conn.cancelStream(req.RequestId)
+		return nil, ctx.Err()
+	}
+}
+
+// Read implements io.Reader over the streamed body. It returns io.EOF after
+// the final chunk.
+func (st *AgentStream) Read(p []byte) (int, error) {
+	for len(st.buf) == 0 {
+		if st.err != nil {
+			return 0, st.err
+		}
+		chunk, ok := <-st.chunks
+		if !ok {
+			st.err = errStreamAborted
+			continue
+		}
+		st.push(chunk)
+	}
+	n := copy(p, st.buf)
+	st.buf = st.buf[n:]
+	return n, nil
+}
+
+// push appends a chunk's data and records the end of the stream.
+func (st *AgentStream) push(chunk *agentpb.K8SResponseChunk) {
+	st.buf = append(st.buf, chunk.Data...)
+	if !chunk.Final {
+		return
+	}
+	if chunk.Error != "" {
+		st.err = fmt.Errorf("agent stream failed: %s", chunk.Error)
+	} else {
+		st.err = io.EOF
+	}
+}
+
+// Close stops the stream. The agent is told to cancel the request if it is
+// still running.
+func (st *AgentStream) Close() error {
+	st.conn.cancelStream(st.requestID)
+	return nil
+}
+
+// handleK8sResponseChunk routes a chunk from an agent to its stream. A final
+// chunk ends the stream. If the reader has fallen behind, the stream is
+// aborted rather than blocking the connection's read loop.
+func (c *AgentConnection) handleK8sResponseChunk(chunk *agentpb.K8SResponseChunk) {
+	c.mu.Lock()
+	ps, ok := c.streams[chunk.RequestId]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+
+	select {
+	case ps.chunks <- chunk:
+	default:
+		c.mu.Unlock()
+		log.Printf("Aborting agent stream %s on cluster %s: reader too slow", chunk.RequestId, c.ClusterID)
+		c.cancelStream(chunk.RequestId)
+		return
+	}
+	c.mu.Unlock()
+
+	if chunk.Final {
+		c.finishStream(chunk.RequestId)
+	}
+}
+
+// finishStream removes a stream and closes its channels. It reports whether
+// the stream was still open. Chunks already queued remain readable.
+func (c *AgentConnection) finishStream(requestID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ps, ok := c.streams[requestID]
+	if !ok {
+		return false
+	}
+	delete(c.streams, requestID)
+	close(ps.chunks)
+	close(ps.done)
+	return true
+}
+
+// cancelStream ends a stream on the dashboard side and asks the agent to stop
+// producing it.
+func (c *AgentConnection) cancelStream(requestID string) {
+	if !c.finishStream(requestID) {
+		return
+	}
+	err := c.send(&agentpb.DashboardMessage{
+		Payload: &agentpb.DashboardMessage_K8SStreamCancel{
+			K8SStreamCancel: &agentpb.K8SStreamCancel{RequestId: requestID},
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to cancel agent stream %s on cluster %s: %v", requestID, c.ClusterID, err)
+	}
+}
+
+// closeStreams aborts every open stream, e.g. when the agent disconnects.
+func (c *AgentConnection) closeStreams() {
+	c.mu.Lock()
+	ids := make([]string, 0, len(c.streams))
+	for id := range c.streams {
+		ids = append(ids, id)
+	}
+	c.mu.Unlock()
+
+	for _, id := range ids {
+		c.finishStream(id)
+	}
+}
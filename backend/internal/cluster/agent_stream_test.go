@@ -0,0 +1,190 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"github.com/darkden-lab/argus/backend/pkg/agentpb"
+)
+
+// fakeStreamingAgent answers streaming requests with respond and records
+// stream cancellations.
+type fakeStreamingAgent struct {
+	grpc.ServerStream
+	conn    *AgentConnection
+	respond func(conn *AgentConnection, req *agentpb.K8SRequest)
+
+	mu        sync.Mutex
+	cancelled []string
+}
+
+func (f *fakeStreamingAgent) Send(msg *agentpb.DashboardMessage) error {
+	switch payload := msg.Payload.(type) {
+	case *agentpb.DashboardMessage_K8SStreamRequest:
+		go f.respond(f.conn, payload.K8SStreamRequest.Request)
+	case *agentpb.DashboardMessage_K8SStreamCancel:
+		f.mu.Lock()
+		f.cancelled = append(f.cancelled, payload.K8SStreamCancel.RequestId)
+		f.mu.Unlock()
+	}
+	return nil
+}
+
+func (f *fakeStreamingAgent) Recv() (*agentpb.AgentMessage, error) {
+	return nil, io.EOF
+}
+
+func (f *fakeStreamingAgent) cancelledIDs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.cancelled...)
+}
+
+func newStreamTestServer(respond func(*AgentConnection, *agentpb.K8SRequest)) (*AgentServer, *fakeStreamingAgent) {
+	server := NewAgentServer(nil, nil, "test-secret")
+	conn := &AgentConnection{
+		ClusterID: "c1",
+		pending:   make(map[string]chan *agentpb.K8SResponse),
+		streams:   make(map[string]*pendingStream),
+	}
+	agent := &fakeStreamingAgent{conn: conn, respond: respond}
+	conn.Stream = agent
+	server.agents["c1"] = conn
+	return server, agent
+}
+
+func sendChunks(conn *AgentConnection, id string, chunks ...*agentpb.K8SResponseChunk) {
+	for i, c := range chunks {
+		c.RequestId = id
+		c.Sequence = int64(i)
+		conn.handleK8sResponseChunk(c)
+	}
+}
+
+func TestOpenK8sStream_ReadsChunksInOrder(t *testing.T) {
+	server, _ := newStreamTestServer(func(conn *AgentConnection, req *agentpb.K8SRequest) {
+		sendChunks(conn, req.RequestId,
+			&agentpb.K8SResponseChunk{StatusCode: 200, ContentType: "text/plain"},
+			&agentpb.K8SResponseChunk{Data: []byte("line 1\n")},
+			&agentpb.K8SResponseChunk{Data: []byte("line 2\n")},
+			&agentpb.K8SResponseChunk{Final: true},
+		)
+	})
+
+	stream, err := server.OpenK8sStream(context.Background(), "c1", &agentpb.K8SRequest{Method: "GET", Path: "/api/v1/namespaces/default/pods/web/log"})
+	if err != nil {
+		t.Fatalf("OpenK8sStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	if stream.StatusCode != 200 || stream.ContentType != "text/plain" {
+		t.Errorf("unexpected header: status=%d content_type=%q", stream.StatusCode, stream.ContentType)
+	}
+	body, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(body) != "line 1\nline 2\n" {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestOpenK8sStream_FinalChunkError(t *testing.T) {
+	server, _ := newStreamTestServer(func(conn *AgentConnection, req *agentpb.K8SRequest) {
+		sendChunks(conn, req.RequestId,
+			&agentpb.K8SResponseChunk{StatusCode: 200},
+			&agentpb.K8SResponseChunk{Data: []byte("partial")},
+			&agentpb.K8SResponseChunk{Final: true, Error: "connection reset"},
+		)
+	})
+
+	stream, err := server.OpenK8sStream(context.Background(), "c1", &agentpb.K8SRequest{Method: "GET", Path: "/api/v1/pods"})
+	if err != nil {
+		t.Fatalf("OpenK8sStream failed: %v", err)
+	}
+	body, err := io.ReadAll(stream)
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("expected stream error, got %v", err)
+	}
+	if string(body) != "partial" {
+		t.Errorf("expected data before the error to be readable, got %q", body)
+	}
+}
+
+func TestOpenK8sStream_NoAgent(t *testing.T) {
+	server := NewAgentServer(nil, nil, "test-secret")
+	if _, err := server.OpenK8sStream(context.Background(), "missing", &agentpb.K8SRequest{}); err == nil {
+		t.Error("expected error for cluster without agent")
+	}
+}
+
+func TestAgentStream_CloseCancelsAgent(t *testing.T) {
+	server, agent := newStreamTestServer(func(conn *AgentConnection, req *agentpb.K8SRequest) {
+		sendChunks(conn, req.RequestId, &agentpb.K8SResponseChunk{StatusCode: 200})
+	})
+
+	stream, err := server.OpenK8sStream(context.Background(), "c1", &agentpb.K8SRequest{RequestId: "watch-1", Method: "GET", Path: "/api/v1/pods?watch=true"})
+	if err != nil {
+		t.Fatalf("OpenK8sStream failed: %v", err)
+	}
+	stream.Close()
+
+	if ids := agent.cancelledIDs(); len(ids) != 1 || ids[0] != "watch-1" {
+		t.Errorf("expected cancel for watch-1, got %v", ids)
+	}
+
+	// Late chunks for a closed stream are dropped.
+	agent.conn.handleK8sResponseChunk(&agentpb.K8SResponseChunk{RequestId: "watch-1", Data: []byte("late")})
+	if _, ok := agent.conn.streams["watch-1"]; ok {
+		t.Error("expected stream to be removed after Close")
+	}
+}
+
+func TestAgentStream_AbortsSlowReader(t *testing.T) {
+	server, agent := newStreamTestServer(func(conn *AgentConnection, req *agentpb.K8SRequest) {
+		sendChunks(conn, req.RequestId, &agentpb.K8SResponseChunk{StatusCode: 200})
+	})
+
+	stream, err := server.OpenK8sStream(context.Background(), "c1", &agentpb.K8SRequest{RequestId: "logs-1"})
+	if err != nil {
+		t.Fatalf("OpenK8sStream failed: %v", err)
+	}
+
+	for i := 0; i <= streamBufferChunks; i++ {
+		agent.conn.handleK8sResponseChunk(&agentpb.K8SResponseChunk{RequestId: "logs-1", Data: []byte("x")})
+	}
+
+	if ids := agent.cancelledIDs(); len(ids) != 1 || ids[0] != "logs-1" {
+		t.Errorf("expected slow stream to be cancelled, got %v", ids)
+	}
+	body, err := io.ReadAll(stream)
+	if !errors.Is(err, errStreamAborted) {
+		t.Errorf("expected errStreamAborted, got %v", err)
+	}
+	if len(body) != streamBufferChunks {
+		t.Errorf("expected queued chunks to remain readable, got %d bytes", len(body))
+	}
+}
+
+func TestAgentStream_AgentDisconnect(t *testing.T) {
+	server, agent := newStreamTestServer(func(conn *AgentConnection, req *agentpb.K8SRequest) {
+		sendChunks(conn, req.RequestId, &agentpb.K8SResponseChunk{StatusCode: 200})
+	})
+
+	stream, err := server.OpenK8sStream(context.Background(), "c1", &agentpb.K8SRequest{Method: "GET"})
+	if err != nil {
+		t.Fatalf("OpenK8sStream failed: %v", err)
+	}
+
+	agent.conn.closeStreams()
+
+	if _, err := io.ReadAll(stream); !errors.Is(err, errStreamAborted) {
+		t.Errorf("expected errStreamAborted after disconnect, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Server-side apply field managers. Each write path applies under its own
+// manager so managedFields show whether a field was last set by the AI
+// assistant, by a user in the editor or by a manifest import.
+const (
+	FieldManagerAI     = "argus-ai"
+	FieldManagerEditor = "argus-editor"
+	FieldManagerImport = "argus-import"
+)
+
+// ApplyConflict is a field an apply tried to change that another field
+// manager owns.
+type ApplyConflict struct {
+	Field   string `json:"field"`
+	Manager string `json:"manager"`
+	Message string `json:"message"`
+}
+
+// ApplyConflictError is a server-side apply rejected because it conflicts
+// with fields owned by other managers. Applying with force takes them over.
+type ApplyConflictError struct {
+	Conflicts []ApplyConflict
+}
+
+func (e *ApplyConflictError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "apply conflicts with %d field(s) owned by other managers:", len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		fmt.Fprintf(&b, "\n- %s is owned by %q", c.Field, c.Manager)
+	}
+	b.WriteString("\nre-apply with force to take ownership of these fields")
+	return b.String()
+}
+
+// conflictManagerRe extracts the manager from a conflict cause message such
+// as `conflict with "kube-controller-manager" using apps/v1`.
+var conflictManagerRe = regexp.MustCompile(`conflict with "([^"]*)"`)
+
+// AsApplyConflict returns the apply conflict err describes, if it is a 409
+// from server-side apply. Both *K8sRequestError and client-go status errors
+// are recognized.
+func AsApplyConflict(err error) (*ApplyConflictError, bool) {
+	var details *metav1.StatusDetails
+	var reqErr *K8sRequestError
+	var status apierrors.APIStatus
+	switch {
+	case errors.As(err, &reqErr):
+		if reqErr.StatusCode != http.StatusConflict {
+			return nil, false
+		}
+		details = reqErr.Details
+	case errors.As(err, &status):
+		if status.Status().Code != http.StatusConflict {
+			return nil, false
+		}
+		details = status.Status().Details
+	default:
+		return nil, false
+	}
+	if details == nil {
+		return nil, false
+	}
+
+	var conflicts []ApplyConflict
+	for _, cause := range details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		c := ApplyConflict{Field: cause.Field, Message: cause.Message}
+		if m := conflictManagerRe.FindStringSubmatch(cause.Message); m != nil {
+			c.Manager = m[1]
+		}
+		conflicts = append(conflicts, c)
+	}
+	if len(conflicts) == 0 {
+		return nil, false
+	}
+	return &ApplyConflictError{Conflicts: conflicts}, true
+}
@@ -0,0 +1,138 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ApplyUserPlaceholder in a label or annotation value of an ApplyPolicy
+// stands for the ID of the user applying the object.
+const ApplyUserPlaceholder = "{user}"
+
+// longestLabelUser is the longest value ApplyUserPlaceholder expands to in a
+// label: a service account's principal, see labelUser.
+const longestLabelUser = "serviceaccount.00000000-0000-0000-0000-000000000000"
+
+// ApplyPolicy is the deployment's policy for objects applied through the
+// editor and by the AI assistant.
+type ApplyPolicy struct {
+	// CreateNamespace creates the target namespace of an apply when it does
+	// not exist yet, like Helm's --create-namespace. Creating it needs
+	// permission to write namespaces on the cluster.
+	CreateNamespace bool
+	// Labels and Annotations are added to every applied object, and to
+	// namespaces created for an apply. Values the object sets itself win.
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// Validate checks that the policy's labels and annotations are valid
+// Kubernetes metadata.
+func (p ApplyPolicy) Validate() error {
+	for key, value := range p.Labels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid label key %q: %s", key, strings.Join(errs, "; "))
+		}
+		sample := strings.ReplaceAll(value, ApplyUserPlaceholder, longestLabelUser)
+		if errs := validation.IsValidLabelValue(sample); len(errs) > 0 {
+			return fmt.Errorf("invalid value for label %q: %s", key, strings.Join(errs, "; "))
+		}
+	}
+	for key := range p.Annotations {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid annotation key %q: %s", key, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// Decorate adds the policy's labels and annotations to obj, keeping any
+// value obj already sets.
+func (p ApplyPolicy) Decorate(obj *unstructured.Unstructured, userID string) {
+	if labels := mergeApplyMetadata(obj.GetLabels(), p.Labels, labelUser(userID)); labels != nil {
+		obj.SetLabels(labels)
+	}
+	if annotations := mergeApplyMetadata(obj.GetAnnotations(), p.Annotations, userID); annotations != nil {
+		obj.SetAnnotations(annotations)
+	}
+}
+
+// labelUser returns userID as a valid label value. User IDs are UUIDs and
+// are kept; principals such as "serviceaccount:<uuid>" have the colon, which
+// labels do not allow, replaced by a dot. Annotations keep the raw ID.
+func labelUser(userID string) string {
+	return strings.ReplaceAll(userID, ":", ".")
+}
+
+// mergeApplyMetadata returns existing with the policy entries it lacks
+// added, or nil if the policy has none.
+func mergeApplyMetadata(existing, policy map[string]string, userID string) map[string]string {
+	if len(policy) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(existing)+len(policy))
+	for k, v := range policy {
+		merged[k] = strings.ReplaceAll(v, ApplyUserPlaceholder, userID)
+	}
+	for k, v := range existing {
+		merged[k] = v
+	}
+	return merged
+}
+
+// K8sRequester sends requests to a cluster's Kubernetes API. Implemented by
+// *Manager.
+type K8sRequester interface {
+	DoK8sRequestWithContentType(ctx context.Context, clusterID, method, path, contentType string, body []byte) ([]byte, error)
+}
+
+// EnsureNamespace creates namespace on the cluster as fieldManager if the
+// policy creates namespaces and it does not exist yet, and reports whether
+// it did. authorize is called before creating it so the caller can check
+// the user may create namespaces; its error is returned as is.
+func (p ApplyPolicy) EnsureNamespace(ctx context.Context, k K8sRequester, clusterID, namespace, fieldManager, userID string, authorize func() error) (bool, error) {
+	if !p.CreateNamespace || namespace == "" {
+		return false, nil
+	}
+
+	path := "/api/v1/namespaces/" + namespace
+	_, err := k.DoK8sRequestWithContentType(ctx, clusterID, http.MethodGet, path, "application/json", nil)
+	var reqErr *K8sRequestError
+	switch {
+	case err == nil:
+		return false, nil
+	case !errors.As(err, &reqErr) || reqErr.StatusCode != http.StatusNotFound:
+		return false, fmt.Errorf("failed to look up namespace %s: %w", namespace, err)
+	}
+
+	if err := authorize(); err != nil {
+		return false, err
+	}
+
+	ns := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+	}}
+	ns.SetName(namespace)
+	p.Decorate(ns, userID)
+	body, err := json.Marshal(ns.Object)
+	if err != nil {
+		return false, err
+	}
+	_, err = k.DoK8sRequestWithContentType(ctx, clusterID, http.MethodPost, "/api/v1/namespaces?fieldManager="+fieldManager, "application/json", body)
+	if errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusConflict {
+		// Created concurrently by someone else.
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create namespace %s: %w", namespace, err)
+	}
+	return true, nil
+}
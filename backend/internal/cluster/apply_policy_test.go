@@ -0,0 +1,145 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestApplyPolicyValidate(t *testing.T) {
+	tests := []struct {
+		policy  ApplyPolicy
+		wantErr bool
+	}{
+		{ApplyPolicy{}, false},
+		{ApplyPolicy{
+			Labels:      map[string]string{"app.kubernetes.io/managed-by": "argus", "argus.io/owner": "{user}"},
+			Annotations: map[string]string{"argus.io/applied-by": "user {user}"},
+		}, false},
+		{ApplyPolicy{Labels: map[string]string{"bad key!": "x"}}, true},
+		{ApplyPolicy{Labels: map[string]string{"owner": "not a label value"}}, true},
+		{ApplyPolicy{Annotations: map[string]string{"/owner": "x"}}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v: Validate() error = %v, wantErr %v", tt.policy, err, tt.wantErr)
+		}
+	}
+}
+
+func TestApplyPolicyDecorate(t *testing.T) {
+	policy := ApplyPolicy{
+		Labels:      map[string]string{"app.kubernetes.io/managed-by": "argus", "team": "platform"},
+		Annotations: map[string]string{"argus.io/applied-by": "{user}"},
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "ConfigMap"}}
+	obj.SetLabels(map[string]string{"team": "payments"})
+	policy.Decorate(obj, "user-1")
+
+	labels := obj.GetLabels()
+	if labels["app.kubernetes.io/managed-by"] != "argus" || labels["team"] != "payments" {
+		t.Errorf("expected policy labels added without overriding the object's, got %v", labels)
+	}
+	if got := obj.GetAnnotations()["argus.io/applied-by"]; got != "user-1" {
+		t.Errorf("expected the user placeholder to be expanded, got %q", got)
+	}
+
+	plain := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "ConfigMap"}}
+	ApplyPolicy{}.Decorate(plain, "user-1")
+	if _, found := plain.Object["metadata"]; found {
+		t.Error("expected an empty policy to leave the object untouched")
+	}
+}
+
+func TestApplyPolicyDecorate_ServiceAccount(t *testing.T) {
+	policy := ApplyPolicy{
+		Labels:      map[string]string{"argus.io/applied-by": "{user}"},
+		Annotations: map[string]string{"argus.io/applied-by": "{user}"},
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("expected the policy to be valid, got %v", err)
+	}
+	principal := "serviceaccount:6f1c2a9e-3b4d-4e5f-8a7b-9c0d1e2f3a4b"
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"kind": "ConfigMap"}}
+	policy.Decorate(obj, principal)
+
+	label := obj.GetLabels()["argus.io/applied-by"]
+	if label != "serviceaccount.6f1c2a9e-3b4d-4e5f-8a7b-9c0d1e2f3a4b" {
+		t.Errorf("expected a label-safe service account value, got %q", label)
+	}
+	if errs := validation.IsValidLabelValue(label); len(errs) > 0 {
+		t.Errorf("expected a valid label value, got %v", errs)
+	}
+	if got := obj.GetAnnotations()["argus.io/applied-by"]; got != principal {
+		t.Errorf("expected the annotation to keep the raw principal, got %q", got)
+	}
+}
+
+// fakeRequester answers namespace lookups and records creations.
+type fakeRequester struct {
+	exists    bool
+	createErr error
+	created   map[string]interface{}
+	path      string
+}
+
+func (f *fakeRequester) DoK8sRequestWithContentType(_ context.Context, _, method, path, _ string, body []byte) ([]byte, error) {
+	switch method {
+	case http.MethodGet:
+		if !f.exists {
+			return nil, &K8sRequestError{StatusCode: http.StatusNotFound, Message: "not found"}
+		}
+		return []byte(`{}`), nil
+	case http.MethodPost:
+		f.path = path
+		if f.createErr != nil {
+			return nil, f.createErr
+		}
+		return body, json.Unmarshal(body, &f.created)
+	}
+	return nil, errors.New("unexpected request")
+}
+
+func TestApplyPolicyEnsureNamespace(t *testing.T) {
+	policy := ApplyPolicy{CreateNamespace: true, Labels: map[string]string{"app.kubernetes.io/managed-by": "argus"}}
+	allow := func() error { return nil }
+	ctx := context.Background()
+
+	k := &fakeRequester{}
+	created, err := policy.EnsureNamespace(ctx, k, "c1", "shop", FieldManagerEditor, "user-1", allow)
+	if err != nil || !created {
+		t.Fatalf("expected the namespace to be created, got %v %v", created, err)
+	}
+	ns := unstructured.Unstructured{Object: k.created}
+	if ns.GetKind() != "Namespace" || ns.GetName() != "shop" || ns.GetLabels()["app.kubernetes.io/managed-by"] != "argus" {
+		t.Errorf("unexpected namespace %v", k.created)
+	}
+	if k.path != "/api/v1/namespaces?fieldManager=argus-editor" {
+		t.Errorf("unexpected create path %q", k.path)
+	}
+
+	if created, err := policy.EnsureNamespace(ctx, &fakeRequester{exists: true}, "c1", "shop", FieldManagerEditor, "user-1", allow); err != nil || created {
+		t.Errorf("expected an existing namespace to be left alone, got %v %v", created, err)
+	}
+
+	denied := errors.New("denied")
+	k = &fakeRequester{}
+	if _, err := policy.EnsureNamespace(ctx, k, "c1", "shop", FieldManagerEditor, "user-1", func() error { return denied }); !errors.Is(err, denied) || k.created != nil {
+		t.Errorf("expected the authorization error and no namespace, got %v", err)
+	}
+
+	k = &fakeRequester{createErr: &K8sRequestError{StatusCode: http.StatusConflict, Message: "already exists"}}
+	if created, err := policy.EnsureNamespace(ctx, k, "c1", "shop", FieldManagerEditor, "user-1", allow); err != nil || created {
+		t.Errorf("expected a concurrent creation to count as existing, got %v %v", created, err)
+	}
+
+	k = &fakeRequester{}
+	if created, err := (ApplyPolicy{}).EnsureNamespace(ctx, k, "c1", "shop", FieldManagerEditor, "user-1", allow); err != nil || created || k.path != "" {
+		t.Error("expected no namespace creation when the policy does not allow it")
+	}
+}
@@ -0,0 +1,79 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const conflictStatus = `{
+	"kind": "Status",
+	"apiVersion": "v1",
+	"status": "Failure",
+	"message": "Apply failed with 1 conflict: conflict with \"kube-controller-manager\" using apps/v1: .spec.replicas",
+	"reason": "Conflict",
+	"details": {
+		"name": "web",
+		"group": "apps",
+		"kind": "deployments",
+		"causes": [
+			{"reason": "FieldManagerConflict", "message": "conflict with \"kube-controller-manager\" using apps/v1", "field": ".spec.replicas"}
+		]
+	},
+	"code": 409
+}`
+
+func TestAsApplyConflict_FromStatusBody(t *testing.T) {
+	err := fmt.Errorf("apply: %w", statusError(http.StatusConflict, []byte(conflictStatus)))
+
+	conflict, ok := AsApplyConflict(err)
+	if !ok {
+		t.Fatal("expected an apply conflict")
+	}
+	want := ApplyConflict{
+		Field:   ".spec.replicas",
+		Manager: "kube-controller-manager",
+		Message: `conflict with "kube-controller-manager" using apps/v1`,
+	}
+	if len(conflict.Conflicts) != 1 || conflict.Conflicts[0] != want {
+		t.Fatalf("unexpected conflicts: %+v", conflict.Conflicts)
+	}
+	if msg := conflict.Error(); !strings.Contains(msg, `.spec.replicas is owned by "kube-controller-manager"`) {
+		t.Errorf("expected a readable error, got %q", msg)
+	}
+}
+
+func TestAsApplyConflict_FromClientGoError(t *testing.T) {
+	err := &apierrors.StatusError{ErrStatus: metav1.Status{
+		Code: http.StatusConflict,
+		Details: &metav1.StatusDetails{Causes: []metav1.StatusCause{{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: `conflict with "helm" with subresource "scale" using apps/v1`,
+			Field:   ".spec.replicas",
+		}}},
+	}}
+
+	conflict, ok := AsApplyConflict(err)
+	if !ok || len(conflict.Conflicts) != 1 || conflict.Conflicts[0].Manager != "helm" {
+		t.Fatalf("unexpected result: %+v, %v", conflict, ok)
+	}
+}
+
+func TestAsApplyConflict_OtherErrors(t *testing.T) {
+	for _, err := range []error{
+		nil,
+		errors.New("boom"),
+		// An optimistic-lock conflict carries no field manager causes.
+		&K8sRequestError{StatusCode: http.StatusConflict, Message: "the object has been modified"},
+		statusError(http.StatusUnprocessableEntity, []byte(conflictStatus)),
+	} {
+		if _, ok := AsApplyConflict(err); ok {
+			t.Errorf("%v: expected no apply conflict", err)
+		}
+	}
+}
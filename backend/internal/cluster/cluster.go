@@ -3,14 +3,39 @@ package cluster
 import "time"
 
 type Cluster struct {
-	ID             string     `json:"id"`
-	Name           string     `json:"name"`
-	APIServerURL   string     `json:"api_server_url"`
-	Status         string     `json:"status"`
-	ConnectionType string     `json:"connection_type"`
-	AgentID        *string    `json:"agent_id,omitempty"`
-	Labels         string     `json:"labels,omitempty"`
-	NodeCount      *int       `json:"node_count,omitempty"`
-	CreatedAt      time.Time  `json:"created_at"`
-	LastHealth     *time.Time `json:"last_health,omitempty"`
+	ID             string       `json:"id"`
+	Name           string       `json:"name"`
+	APIServerURL   string       `json:"api_server_url"`
+	Status         string       `json:"status"`
+	ConnectionType string       `json:"connection_type"`
+	AgentID        *string      `json:"agent_id,omitempty"`
+	Labels         string       `json:"labels,omitempty"`
+	NodeCount      *int         `json:"node_count,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	LastHealth     *time.Time   `json:"last_health,omitempty"`
+	Info           *ClusterInfo `json:"info,omitempty"`
+	// Maintenance is set while the cluster is in maintenance mode.
+	Maintenance *Maintenance `json:"maintenance,omitempty"`
+}
+
+// ClusterInfo summarizes a cluster's version and capacity. For agent clusters
+// it is the latest report from the agent; ReportedAt tells how stale it is.
+type ClusterInfo struct {
+	KubernetesVersion string        `json:"kubernetes_version"`
+	Platform          string        `json:"platform,omitempty"`
+	NodeCount         int           `json:"node_count"`
+	Capacity          NodeResources `json:"capacity"`
+	Allocatable       NodeResources `json:"allocatable"`
+	AgentVersion      string        `json:"agent_version,omitempty"`
+	ReportedAt        time.Time     `json:"reported_at"`
+	// AgentVersionWarning is set when the agent is older than the minimum
+	// version the dashboard supports.
+	AgentVersionWarning string `json:"agent_version_warning,omitempty"`
+}
+
+// NodeResources are resource totals across all nodes, as quantity strings.
+type NodeResources struct {
+	CPU    string `json:"cpu"`
+	Memory string `json:"memory"`
+	Pods   string `json:"pods"`
 }
@@ -0,0 +1,204 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// Agent connection lifecycle events.
+const (
+	ConnectionEventRegistered   = "registered"
+	ConnectionEventConnected    = "connected"
+	ConnectionEventDisconnected = "disconnected"
+	ConnectionEventRejected     = "rejected"
+)
+
+// maxConnectionEvents is how many events are kept per cluster; older ones
+// are pruned as new ones are recorded.
+const maxConnectionEvents = 1000
+
+// connectionEventTimeout bounds how long recording an event may take.
+const connectionEventTimeout = 5 * time.Second
+
+// ConnectionEvent is an entry in an agent cluster's connection history.
+// TokenID is the registration token, set on registered events; AgentID and
+// TokenIssuedAt identify the agent credential that connected.
+type ConnectionEvent struct {
+	ID            int64      `json:"id"`
+	ClusterID     string     `json:"cluster_id"`
+	Event         string     `json:"event"`
+	Reason        string     `json:"reason,omitempty"`
+	AgentID       *string    `json:"agent_id,omitempty"`
+	TokenID       *string    `json:"token_id,omitempty"`
+	TokenIssuedAt *time.Time `json:"token_issued_at,omitempty"`
+	AgentVersion  string     `json:"agent_version,omitempty"`
+	RemoteAddr    string     `json:"remote_addr,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// RecordConnectionEvent stores an event and prunes the cluster's history to
+// the newest maxConnectionEvents entries.
+func (s *Store) RecordConnectionEvent(ctx context.Context, ev *ConnectionEvent) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO cluster_connection_events
+		     (cluster_id, event, reason, agent_id, token_id, token_issued_at, agent_version, remote_addr)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		ev.ClusterID, ev.Event, ev.Reason, ev.AgentID, ev.TokenID, ev.TokenIssuedAt, ev.AgentVersion, ev.RemoteAddr,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record connection event: %w", err)
+	}
+	_, err = s.pool.Exec(ctx,
+		`DELETE FROM cluster_connection_events
+		 WHERE cluster_id = $1 AND id <= (
+		     SELECT id FROM cluster_connection_events WHERE cluster_id = $1
+		     ORDER BY id DESC OFFSET $2 LIMIT 1)`,
+		ev.ClusterID, maxConnectionEvents,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to prune connection events: %w", err)
+	}
+	return nil
+}
+
+// ListConnectionEvents returns a cluster's connection events, newest first,
+// optionally limited to those recorded in [since, until).
+func (s *Store) ListConnectionEvents(ctx context.Context, clusterID string, since, until *time.Time, limit int) ([]ConnectionEvent, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, cluster_id, event, reason, agent_id, token_id, token_issued_at, agent_version, remote_addr, created_at
+		 FROM cluster_connection_events
+		 WHERE cluster_id = $1
+		   AND ($2::timestamptz IS NULL OR created_at >= $2)
+		   AND ($3::timestamptz IS NULL OR created_at < $3)
+		 ORDER BY id DESC
+		 LIMIT $4`,
+		clusterID, since, until, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list connection events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []ConnectionEvent{}
+	for rows.Next() {
+		var ev ConnectionEvent
+		if err := rows.Scan(&ev.ID, &ev.ClusterID, &ev.Event, &ev.Reason, &ev.AgentID, &ev.TokenID,
+			&ev.TokenIssuedAt, &ev.AgentVersion, &ev.RemoteAddr, &ev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan connection event: %w", err)
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// recordConnectionEvent stores an event, with the agent's address taken from
+// ctx. The history is best effort: failures are logged and never affect the
+// agent.
+func (s *AgentServer) recordConnectionEvent(ctx context.Context, ev *ConnectionEvent) {
+	if s.store == nil {
+		return
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ev.RemoteAddr = p.Addr.String()
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), connectionEventTimeout)
+	defer cancel()
+	if err := s.store.RecordConnectionEvent(ctx, ev); err != nil {
+		log.Printf("cluster %s: %v", ev.ClusterID, err)
+	}
+}
+
+// disconnectReason describes why an agent stream ended, given the error it
+// ended with and whether a newer stream from the same cluster replaced it.
+func disconnectReason(err error, replaced bool) string {
+	switch {
+	case replaced:
+		return "replaced by a new connection from the agent"
+	case err == nil, errors.Is(err, io.EOF):
+		return "agent closed the connection"
+	}
+	switch status.Code(err) {
+	case codes.Canceled:
+		return "connection closed"
+	case codes.DeadlineExceeded:
+		return "connection timed out"
+	case codes.Unavailable:
+		return "connection lost: " + status.Convert(err).Message()
+	}
+	return err.Error()
+}
+
+// handleConnectionEvents handles GET /api/clusters/{id}/connection-events.
+func (h *Handlers) handleConnectionEvents(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	q := r.URL.Query()
+
+	limit := 100
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxConnectionEvents {
+			httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxConnectionEvents))
+			return
+		}
+		limit = n
+	}
+	var since, until *time.Time
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"since", &since}, {"until", &until}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, p.name+" must be an RFC 3339 timestamp")
+			return
+		}
+		*p.dst = &t
+	}
+
+	if _, err := h.manager.store.GetCluster(r.Context(), id); err != nil {
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
+		return
+	}
+	events, err := h.manager.store.ListConnectionEvents(r.Context(), id, since, until, limit)
+	if err != nil {
+		log.Printf("cluster: %v", err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to list connection events")
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"events": events,
+		"limit":  limit,
+	})
+}
+
+// connectionEvent starts an event for the agent credential in claims.
+func connectionEvent(clusterID, event string, claims *AgentClaims) *ConnectionEvent {
+	ev := &ConnectionEvent{ClusterID: clusterID, Event: event}
+	if claims.AgentID != "" {
+		agentID := claims.AgentID
+		ev.AgentID = &agentID
+	}
+	if claims.IssuedAt != nil {
+		issuedAt := claims.IssuedAt.Time
+		ev.TokenIssuedAt = &issuedAt
+	}
+	return ev
+}
@@ -0,0 +1,50 @@
+package cluster
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDisconnectReason(t *testing.T) {
+	tests := []struct {
+		err      error
+		replaced bool
+		want     string
+	}{
+		{io.EOF, false, "agent closed the connection"},
+		{io.EOF, true, "replaced by a new connection from the agent"},
+		{status.Error(codes.Canceled, "context canceled"), false, "connection closed"},
+		{status.Error(codes.DeadlineExceeded, "deadline"), false, "connection timed out"},
+		{status.Error(codes.Unavailable, "transport is closing"), false, "connection lost: transport is closing"},
+		{errors.New("stream reset"), false, "stream reset"},
+	}
+	for _, tt := range tests {
+		if got := disconnectReason(tt.err, tt.replaced); got != tt.want {
+			t.Errorf("disconnectReason(%v, %t) = %q, want %q", tt.err, tt.replaced, got, tt.want)
+		}
+	}
+}
+
+func TestConnectionEvent(t *testing.T) {
+	issued := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	ev := connectionEvent("c1", ConnectionEventConnected, &AgentClaims{
+		AgentID:          "a1",
+		RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(issued)},
+	})
+	if ev.ClusterID != "c1" || ev.Event != ConnectionEventConnected {
+		t.Errorf("unexpected event %+v", ev)
+	}
+	if ev.AgentID == nil || *ev.AgentID != "a1" || ev.TokenIssuedAt == nil || !ev.TokenIssuedAt.Equal(issued) {
+		t.Errorf("expected the agent credential to be recorded, got %+v", ev)
+	}
+
+	if ev := connectionEvent("c1", ConnectionEventRejected, &AgentClaims{}); ev.AgentID != nil || ev.TokenIssuedAt != nil {
+		t.Errorf("expected no credential for empty claims, got %+v", ev)
+	}
+}
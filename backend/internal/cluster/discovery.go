@@ -0,0 +1,251 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/darkden-lab/argus/backend/pkg/agentpb"
+)
+
+// DefaultDiscoveryTTL bounds how long a cluster's discovery result is reused
+// unless SetDiscoveryTTL says otherwise. Resource types installed in the
+// meantime (e.g. a new CRD) show up once it expires or after an explicit
+// InvalidateDiscovery.
+const DefaultDiscoveryTTL = 10 * time.Minute
+
+// agentDiscoveryTimeout bounds the walk over a cluster's group versions
+// through its agent.
+const agentDiscoveryTimeout = 30 * time.Second
+
+// Discovery is the API discovery data of one cluster: its groups and the
+// resources served at every group version.
+type Discovery struct {
+	Groups    []metav1.APIGroup
+	Resources []*metav1.APIResourceList
+	FetchedAt time.Time
+}
+
+// PreferredResources returns the resource lists of each group's preferred
+// version, the same result as ServerPreferredResources.
+func (d *Discovery) PreferredResources() []*metav1.APIResourceList {
+	preferred := make(map[string]bool, len(d.Groups))
+	for _, g := range d.Groups {
+		preferred[g.PreferredVersion.GroupVersion] = true
+	}
+	lists := make([]*metav1.APIResourceList, 0, len(d.Groups))
+	for _, list := range d.Resources {
+		if list != nil && preferred[list.GroupVersion] {
+			lists = append(lists, list)
+		}
+	}
+	return lists
+}
+
+// RESTMapper returns a mapper between kinds and resources built from the
+// discovery data.
+func (d *Discovery) RESTMapper() meta.RESTMapper {
+	byGroupVersion := make(map[string][]metav1.APIResource, len(d.Resources))
+	for _, list := range d.Resources {
+		if list != nil {
+			byGroupVersion[list.GroupVersion] = list.APIResources
+		}
+	}
+	groupResources := make([]*restmapper.APIGroupResources, 0, len(d.Groups))
+	for _, g := range d.Groups {
+		gr := &restmapper.APIGroupResources{Group: g, VersionedResources: make(map[string][]metav1.APIResource, len(g.Versions))}
+		for _, v := range g.Versions {
+			if resources, ok := byGroupVersion[v.GroupVersion]; ok {
+				gr.VersionedResources[v.Version] = resources
+			}
+		}
+		groupResources = append(groupResources, gr)
+	}
+	return restmapper.NewDiscoveryRESTMapper(groupResources)
+}
+
+// discoveryCache keeps the discovery result of each cluster. Failed fetches
+// are not cached.
+type discoveryCache struct {
+	fetch func(ctx context.Context, clusterID string) (*Discovery, error)
+	now   func() time.Time
+
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*Discovery
+}
+
+func newDiscoveryCache(fetch func(ctx context.Context, clusterID string) (*Discovery, error), ttl time.Duration) *discoveryCache {
+	return &discoveryCache{
+		fetch:   fetch,
+		now:     time.Now,
+		ttl:     ttl,
+		entries: make(map[string]*Discovery),
+	}
+}
+
+func (c *discoveryCache) get(ctx context.Context, clusterID string) (*Discovery, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[clusterID]
+	fresh := ok && c.now().Sub(entry.FetchedAt) < c.ttl
+	c.mu.Unlock()
+	if fresh {
+		return entry, nil
+	}
+
+	entry, err := c.fetch(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	entry.FetchedAt = c.now()
+
+	c.mu.Lock()
+	c.entries[clusterID] = entry
+	c.mu.Unlock()
+	return entry, nil
+}
+
+func (c *discoveryCache) invalidate(clusterID string) {
+	c.mu.Lock()
+	delete(c.entries, clusterID)
+	c.mu.Unlock()
+}
+
+func (c *discoveryCache) setTTL(ttl time.Duration) {
+	c.mu.Lock()
+	c.ttl = ttl
+	c.mu.Unlock()
+}
+
+// SetDiscoveryTTL changes how long discovery results are reused.
+func (m *Manager) SetDiscoveryTTL(ttl time.Duration) {
+	m.discovery.setTTL(ttl)
+}
+
+// Discovery returns the cluster's API discovery data, fetching it when it is
+// missing or older than the discovery TTL. The result is shared by every
+// caller and must not be modified. Clusters without a direct client are
+// discovered through their agent.
+func (m *Manager) Discovery(ctx context.Context, clusterID string) (*Discovery, error) {
+	return m.discovery.get(ctx, clusterID)
+}
+
+// InvalidateDiscovery drops the cluster's cached discovery data so the next
+// Discovery call refetches it.
+func (m *Manager) InvalidateDiscovery(clusterID string) {
+	m.discovery.invalidate(clusterID)
+}
+
+// RESTMapper returns a kind/resource mapper for the cluster built from its
+// cached discovery data.
+func (m *Manager) RESTMapper(ctx context.Context, clusterID string) (meta.RESTMapper, error) {
+	d, err := m.Discovery(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	return d.RESTMapper(), nil
+}
+
+// RESTMapping resolves the resource serving a kind in the cluster, e.g.
+// apps/v1 deployments for apps/v1 Deployment, and whether it is namespaced.
+func (m *Manager) RESTMapping(ctx context.Context, clusterID string, gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
+	mapper, err := m.RESTMapper(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	return mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+}
+
+// discover runs API discovery against a cluster, directly or through its
+// agent.
+func (m *Manager) discover(ctx context.Context, clusterID string) (*Discovery, error) {
+	m.mu.RLock()
+	client, ok := m.clients[clusterID]
+	m.mu.RUnlock()
+	if !ok {
+		return m.discoverViaAgent(ctx, clusterID)
+	}
+
+	groups, lists, err := client.Clientset.Discovery().ServerGroupsAndResources()
+	if err != nil && lists == nil {
+		// Discovery may return partial results along with an error, e.g. when
+		// an aggregated API server is down; only fail when nothing came back.
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+	d := &Discovery{Groups: make([]metav1.APIGroup, 0, len(groups)), Resources: lists}
+	for _, g := range groups {
+		if g != nil {
+			d.Groups = append(d.Groups, *g)
+		}
+	}
+	return d, nil
+}
+
+// discoverViaAgent walks the legacy /api and the /apis group endpoints through
+// the cluster's agent. Group versions that fail to load are skipped, matching
+// the partial-result behaviour of direct discovery.
+func (m *Manager) discoverViaAgent(ctx context.Context, clusterID string) (*Discovery, error) {
+	if err := m.CheckAgent(ctx, clusterID); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, agentDiscoveryTimeout)
+	defer cancel()
+
+	get := func(path string, out interface{}) error {
+		resp, err := m.agentServer.SendK8sRequest(ctx, clusterID, &agentpb.K8SRequest{Method: "GET", Path: path})
+		if err != nil {
+			return err
+		}
+		if err := ResponseError(resp); err != nil {
+			return err
+		}
+		return json.Unmarshal(resp.Body, out)
+	}
+
+	var core metav1.APIVersions
+	if err := get("/api", &core); err != nil {
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+	var groupList metav1.APIGroupList
+	if err := get("/apis", &groupList); err != nil {
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+
+	coreGroup := metav1.APIGroup{}
+	for _, v := range core.Versions {
+		coreGroup.Versions = append(coreGroup.Versions, metav1.GroupVersionForDiscovery{GroupVersion: v, Version: v})
+	}
+	if len(coreGroup.Versions) > 0 {
+		coreGroup.PreferredVersion = coreGroup.Versions[0]
+	}
+	d := &Discovery{Groups: append([]metav1.APIGroup{coreGroup}, groupList.Groups...)}
+
+	for _, g := range d.Groups {
+		for _, v := range g.Versions {
+			path := "/apis/" + v.GroupVersion
+			if g.Name == "" {
+				path = "/api/" + v.GroupVersion
+			}
+			var list metav1.APIResourceList
+			if err := get(path, &list); err != nil {
+				if ctx.Err() != nil {
+					return nil, fmt.Errorf("discovery failed: %w", ctx.Err())
+				}
+				continue
+			}
+			if list.GroupVersion == "" {
+				list.GroupVersion = v.GroupVersion
+			}
+			d.Resources = append(d.Resources, &list)
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,157 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func testDiscovery() *Discovery {
+	return &Discovery{
+		Groups: []metav1.APIGroup{
+			{Name: "", Versions: []metav1.GroupVersionForDiscovery{{GroupVersion: "v1", Version: "v1"}},
+				PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: "v1", Version: "v1"}},
+			{Name: "example.com", Versions: []metav1.GroupVersionForDiscovery{
+				{GroupVersion: "example.com/v1", Version: "v1"}, {GroupVersion: "example.com/v1beta1", Version: "v1beta1"},
+			}, PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: "example.com/v1", Version: "v1"}},
+		},
+		Resources: []*metav1.APIResourceList{
+			{GroupVersion: "v1", APIResources: []metav1.APIResource{
+				{Name: "pods", Kind: "Pod", Namespaced: true},
+				{Name: "nodes", Kind: "Node"},
+			}},
+			{GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{{Name: "widgets", Kind: "Widget", Namespaced: true}}},
+			{GroupVersion: "example.com/v1beta1", APIResources: []metav1.APIResource{{Name: "widgets", Kind: "Widget", Namespaced: true}}},
+			nil,
+		},
+	}
+}
+
+func TestDiscoveryPreferredResources(t *testing.T) {
+	lists := testDiscovery().PreferredResources()
+	if len(lists) != 2 || lists[0].GroupVersion != "v1" || lists[1].GroupVersion != "example.com/v1" {
+		t.Fatalf("expected the preferred v1 and example.com/v1 lists, got %+v", lists)
+	}
+}
+
+func TestDiscoveryRESTMapper(t *testing.T) {
+	mapper := testDiscovery().RESTMapper()
+
+	mapping, err := mapper.RESTMapping(schema.GroupKind{Group: "example.com", Kind: "Widget"}, "v1beta1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mapping.Resource != (schema.GroupVersionResource{Group: "example.com", Version: "v1beta1", Resource: "widgets"}) {
+		t.Errorf("unexpected resource %v", mapping.Resource)
+	}
+
+	mapping, err = mapper.RESTMapping(schema.GroupKind{Kind: "Node"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mapping.Resource.Resource != "nodes" || mapping.Scope.Name() != "root" {
+		t.Errorf("expected cluster-scoped nodes, got %v %s", mapping.Resource, mapping.Scope.Name())
+	}
+
+	if _, err := mapper.RESTMapping(schema.GroupKind{Group: "example.com", Kind: "Gadget"}); err == nil {
+		t.Error("expected an unserved kind not to resolve")
+	}
+}
+
+func TestDiscoveryCache_TTLAndInvalidate(t *testing.T) {
+	calls := 0
+	cache := newDiscoveryCache(func(ctx context.Context, clusterID string) (*Discovery, error) {
+		calls++
+		return testDiscovery(), nil
+	}, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.get(context.Background(), "c1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 fetch while fresh, got %d", calls)
+	}
+
+	if _, err := cache.get(context.Background(), "c2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected clusters to be cached separately, got %d fetches", calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := cache.get(context.Background(), "c1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected refetch after TTL, got %d fetches", calls)
+	}
+
+	cache.invalidate("c1")
+	if _, err := cache.get(context.Background(), "c1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 4 {
+		t.Fatalf("expected refetch after invalidate, got %d fetches", calls)
+	}
+
+	cache.setTTL(0)
+	if _, err := cache.get(context.Background(), "c1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 5 {
+		t.Fatalf("expected a zero TTL to refetch every time, got %d fetches", calls)
+	}
+}
+
+func TestDiscoveryCache_ErrorsNotCached(t *testing.T) {
+	calls := 0
+	cache := newDiscoveryCache(func(ctx context.Context, clusterID string) (*Discovery, error) {
+		calls++
+		return nil, errors.New("boom")
+	}, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.get(context.Background(), "c1"); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected failed fetches to be retried, got %d fetches", calls)
+	}
+}
+
+func TestManagerDiscovery_SetClientInvalidates(t *testing.T) {
+	m := NewManager(nil, "")
+	calls := 0
+	m.discovery.fetch = func(ctx context.Context, clusterID string) (*Discovery, error) {
+		calls++
+		return testDiscovery(), nil
+	}
+
+	if _, err := m.Discovery(context.Background(), "c1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m.setClient("c1", &ClusterClient{})
+	if _, err := m.Discovery(context.Background(), "c1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected new credentials to drop the cached discovery, got %d fetches", calls)
+	}
+}
+
+func TestManagerDiscovery_ClusterUnavailable(t *testing.T) {
+	m := NewManager(nil, "")
+	if _, err := m.Discovery(context.Background(), "missing"); !errors.Is(err, ErrClusterUnavailable) {
+		t.Errorf("expected ErrClusterUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// GroupInvalidator is told when cluster group membership changes, so
+// group-scoped permissions follow it. Implemented by *rbac.Engine.
+type GroupInvalidator interface {
+	InvalidateClusterGroups()
+}
+
+// groupStore is the part of *Store the group handlers use.
+type groupStore interface {
+	ListGroups(ctx context.Context) ([]Group, error)
+	GetGroup(ctx context.Context, id string) (*Group, error)
+	CreateGroup(ctx context.Context, name, description string, clusterIDs []string) (*Group, error)
+	UpdateGroup(ctx context.Context, id, name, description string, clusterIDs []string) (*Group, error)
+	DeleteGroup(ctx context.Context, id string) error
+}
+
+// GroupHandlers serves cluster group CRUD.
+type GroupHandlers struct {
+	store          groupStore
+	invalidator    GroupInvalidator
+	rbacWriteGuard mux.MiddlewareFunc
+}
+
+// NewGroupHandlers creates cluster group handlers. Writes are guarded by
+// rbacWriteGuard.
+func NewGroupHandlers(store *Store, invalidator GroupInvalidator, rbacWriteGuard mux.MiddlewareFunc) *GroupHandlers {
+	return &GroupHandlers{store: store, invalidator: invalidator, rbacWriteGuard: rbacWriteGuard}
+}
+
+func (h *GroupHandlers) RegisterRoutes(r *mux.Router) {
+	api := r.PathPrefix("/api/cluster-groups").Subrouter()
+	api.HandleFunc("", h.handleList).Methods("GET")
+	api.HandleFunc("/{id}", h.handleGet).Methods("GET")
+
+	// Write endpoints require clusters:write RBAC
+	writeAPI := api.PathPrefix("").Subrouter()
+	if h.rbacWriteGuard != nil {
+		writeAPI.Use(h.rbacWriteGuard)
+	}
+	writeAPI.HandleFunc("", h.handleCreate).Methods("POST")
+	writeAPI.HandleFunc("/{id}", h.handleUpdate).Methods("PUT")
+	writeAPI.HandleFunc("/{id}", h.handleDelete).Methods("DELETE")
+}
+
+type groupRequest struct {
+	Name        *string   `json:"name"`
+	Description *string   `json:"description"`
+	ClusterIDs  *[]string `json:"cluster_ids"`
+}
+
+// validate trims and checks the fields that are set, deduplicating
+// cluster_ids.
+func (req *groupRequest) validate() string {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return "name must not be empty"
+		}
+		if len(name) > 255 {
+			return "name must be 255 characters or less"
+		}
+		req.Name = &name
+	}
+	if req.ClusterIDs != nil {
+		seen := make(map[string]bool, len(*req.ClusterIDs))
+		ids := make([]string, 0, len(*req.ClusterIDs))
+		for _, id := range *req.ClusterIDs {
+			if _, err := uuid.Parse(id); err != nil {
+				return "cluster_ids must contain cluster IDs"
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		req.ClusterIDs = &ids
+	}
+	return ""
+}
+
+func (h *GroupHandlers) handleList(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.store.ListGroups(r.Context())
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to list cluster groups")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, groups)
+}
+
+func (h *GroupHandlers) handleGet(w http.ResponseWriter, r *http.Request) {
+	group, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, group)
+}
+
+func (h *GroupHandlers) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req groupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Name == nil {
+		httputil.WriteError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		httputil.WriteError(w, http.StatusBadRequest, msg)
+		return
+	}
+	description := ""
+	if req.Description != nil {
+		description = *req.Description
+	}
+	var clusterIDs []string
+	if req.ClusterIDs != nil {
+		clusterIDs = *req.ClusterIDs
+	}
+
+	group, err := h.store.CreateGroup(r.Context(), *req.Name, description, clusterIDs)
+	if err != nil {
+		h.writeStoreError(w, err, "failed to create cluster group")
+		return
+	}
+	h.invalidate()
+	httputil.WriteJSON(w, http.StatusCreated, group)
+}
+
+func (h *GroupHandlers) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	var req groupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		httputil.WriteError(w, http.StatusBadRequest, msg)
+		return
+	}
+	existing, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	name, description := existing.Name, existing.Description
+	if req.Name != nil {
+		name = *req.Name
+	}
+	if req.Description != nil {
+		description = *req.Description
+	}
+	var clusterIDs []string
+	if req.ClusterIDs != nil {
+		clusterIDs = *req.ClusterIDs
+	}
+
+	group, err := h.store.UpdateGroup(r.Context(), existing.ID, name, description, clusterIDs)
+	if err != nil {
+		h.writeStoreError(w, err, "failed to update cluster group")
+		return
+	}
+	h.invalidate()
+	httputil.WriteJSON(w, http.StatusOK, group)
+}
+
+func (h *GroupHandlers) handleDelete(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, err := uuid.Parse(id); err != nil {
+		httputil.WriteError(w, http.StatusNotFound, ErrGroupNotFound.Error())
+		return
+	}
+	if err := h.store.DeleteGroup(r.Context(), id); err != nil {
+		h.writeStoreError(w, err, "failed to delete cluster group")
+		return
+	}
+	h.invalidate()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lookup loads the group named by the {id} route variable, writing a 404 if
+// there is none.
+func (h *GroupHandlers) lookup(w http.ResponseWriter, r *http.Request) (*Group, bool) {
+	id := mux.Vars(r)["id"]
+	if _, err := uuid.Parse(id); err != nil {
+		httputil.WriteError(w, http.StatusNotFound, ErrGroupNotFound.Error())
+		return nil, false
+	}
+	group, err := h.store.GetGroup(r.Context(), id)
+	if err != nil {
+		h.writeStoreError(w, err, "failed to get cluster group")
+		return nil, false
+	}
+	return group, true
+}
+
+func (h *GroupHandlers) writeStoreError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, ErrGroupNotFound):
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrGroupExists):
+		httputil.WriteError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrUnknownMember):
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+	default:
+		log.Printf("cluster: %s: %v", msg, err)
+		httputil.WriteError(w, http.StatusInternalServerError, msg)
+	}
+}
+
+func (h *GroupHandlers) invalidate() {
+	if h.invalidator != nil {
+		h.invalidator.InvalidateClusterGroups()
+	}
+}
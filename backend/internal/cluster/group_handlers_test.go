@@ -0,0 +1,135 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	testGroupID   = "7f3c2a4e-1d2b-4c5d-8e9f-0a1b2c3d4e5f"
+	testClusterID = "0d9e8f7a-6b5c-4d3e-9f1a-2b3c4d5e6f7a"
+)
+
+type fakeGroupStore struct {
+	created    []string
+	updated    []string
+	updateNil  bool
+	deleteErr  error
+	invalidate int
+}
+
+func (f *fakeGroupStore) ListGroups(ctx context.Context) ([]Group, error) { return []Group{}, nil }
+
+func (f *fakeGroupStore) GetGroup(ctx context.Context, id string) (*Group, error) {
+	if id != testGroupID {
+		return nil, ErrGroupNotFound
+	}
+	return &Group{ID: id, Name: "prod", ClusterIDs: []string{testClusterID}}, nil
+}
+
+func (f *fakeGroupStore) CreateGroup(ctx context.Context, name, description string, clusterIDs []string) (*Group, error) {
+	if name == "taken" {
+		return nil, ErrGroupExists
+	}
+	f.created = clusterIDs
+	return &Group{ID: testGroupID, Name: name, ClusterIDs: clusterIDs}, nil
+}
+
+func (f *fakeGroupStore) UpdateGroup(ctx context.Context, id, name, description string, clusterIDs []string) (*Group, error) {
+	f.updated, f.updateNil = clusterIDs, clusterIDs == nil
+	return &Group{ID: id, Name: name, ClusterIDs: clusterIDs}, nil
+}
+
+func (f *fakeGroupStore) DeleteGroup(ctx context.Context, id string) error { return f.deleteErr }
+
+func (f *fakeGroupStore) InvalidateClusterGroups() { f.invalidate++ }
+
+func serveGroups(store *fakeGroupStore, method, path, body string) *httptest.ResponseRecorder {
+	h := &GroupHandlers{store: store, invalidator: store}
+	r := mux.NewRouter()
+	h.RegisterRoutes(r)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+	return rec
+}
+
+func TestGroupCreateValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"missing name", `{}`, http.StatusBadRequest},
+		{"blank name", `{"name":"  "}`, http.StatusBadRequest},
+		{"bad cluster id", `{"name":"prod","cluster_ids":["not-a-uuid"]}`, http.StatusBadRequest},
+		{"duplicate name", `{"name":"taken"}`, http.StatusConflict},
+		{"valid", `{"name":"prod","cluster_ids":["` + testClusterID + `"]}`, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveGroups(&fakeGroupStore{}, http.MethodPost, "/api/cluster-groups", tt.body)
+			if rec.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestGroupCreateDeduplicatesAndInvalidates(t *testing.T) {
+	store := &fakeGroupStore{}
+	body := `{"name":"prod","cluster_ids":["` + testClusterID + `","` + testClusterID + `"]}`
+	if rec := serveGroups(store, http.MethodPost, "/api/cluster-groups", body); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	if len(store.created) != 1 {
+		t.Errorf("expected duplicate cluster IDs to be dropped, got %v", store.created)
+	}
+	if store.invalidate != 1 {
+		t.Errorf("expected RBAC group membership to be invalidated, got %d calls", store.invalidate)
+	}
+}
+
+func TestGroupUpdateKeepsMembersWhenOmitted(t *testing.T) {
+	store := &fakeGroupStore{}
+	rec := serveGroups(store, http.MethodPut, "/api/cluster-groups/"+testGroupID, `{"name":"production"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !store.updateNil {
+		t.Errorf("expected members to be left alone, got %v", store.updated)
+	}
+
+	rec = serveGroups(store, http.MethodPut, "/api/cluster-groups/"+testGroupID, `{"cluster_ids":[]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if store.updateNil || len(store.updated) != 0 {
+		t.Errorf("expected members to be cleared, got %v", store.updated)
+	}
+}
+
+func TestGroupNotFound(t *testing.T) {
+	for _, tt := range []struct{ method, path string }{
+		{http.MethodGet, "/api/cluster-groups/not-a-uuid"},
+		{http.MethodGet, "/api/cluster-groups/" + testClusterID},
+		{http.MethodPut, "/api/cluster-groups/" + testClusterID},
+	} {
+		rec := serveGroups(&fakeGroupStore{}, tt.method, tt.path, `{}`)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s %s: expected 404, got %d", tt.method, tt.path, rec.Code)
+		}
+	}
+
+	store := &fakeGroupStore{deleteErr: ErrGroupNotFound}
+	if rec := serveGroups(store, http.MethodDelete, "/api/cluster-groups/"+testGroupID, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+	if store.invalidate != 0 {
+		t.Error("expected no invalidation for a failed delete")
+	}
+}
@@ -0,0 +1,222 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrGroupNotFound = errors.New("cluster group not found")
+	ErrGroupExists   = errors.New("a cluster group with this name already exists")
+	ErrUnknownMember = errors.New("cluster_ids contains an unknown cluster")
+)
+
+// Group is a named set of clusters. Role permissions scoped to a group cover
+// every cluster in it.
+type Group struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	ClusterIDs  []string  `json:"cluster_ids"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+const groupSelect = `
+	SELECT g.id::text, g.name, g.description, g.created_at,
+	       COALESCE(array_agg(m.cluster_id::text ORDER BY m.cluster_id) FILTER (WHERE m.cluster_id IS NOT NULL), '{}')
+	FROM cluster_groups g
+	LEFT JOIN cluster_group_members m ON m.group_id = g.id`
+
+func scanGroup(row pgx.Row, g *Group) error {
+	return row.Scan(&g.ID, &g.Name, &g.Description, &g.CreatedAt, &g.ClusterIDs)
+}
+
+// groupError maps constraint violations to the package's group errors.
+func groupError(err error, action string) error {
+	switch {
+	case strings.Contains(err.Error(), "cluster_groups_name_key"):
+		return ErrGroupExists
+	case strings.Contains(err.Error(), "cluster_group_members_cluster_id_fkey"):
+		return ErrUnknownMember
+	}
+	return fmt.Errorf("failed to %s cluster group: %w", action, err)
+}
+
+func (s *Store) ListGroups(ctx context.Context) ([]Group, error) {
+	rows, err := s.pool.Query(ctx, groupSelect+` GROUP BY g.id ORDER BY g.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []Group{}
+	for rows.Next() {
+		var g Group
+		if err := scanGroup(rows, &g); err != nil {
+			return nil, fmt.Errorf("failed to scan cluster group: %w", err)
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+func (s *Store) GetGroup(ctx context.Context, id string) (*Group, error) {
+	return getGroup(ctx, s.pool, id)
+}
+
+// rowQuerier is satisfied by both the pool and a transaction.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func getGroup(ctx context.Context, q rowQuerier, id string) (*Group, error) {
+	var g Group
+	err := scanGroup(q.QueryRow(ctx, groupSelect+` WHERE g.id = $1 GROUP BY g.id`, id), &g)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrGroupNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster group: %w", err)
+	}
+	return &g, nil
+}
+
+// CreateGroup creates a group with the given member clusters.
+func (s *Store) CreateGroup(ctx context.Context, name, description string, clusterIDs []string) (*Group, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	var id string
+	err = tx.QueryRow(ctx,
+		`INSERT INTO cluster_groups (name, description) VALUES ($1, $2) RETURNING id::text`,
+		name, description,
+	).Scan(&id)
+	if err != nil {
+		return nil, groupError(err, "create")
+	}
+	if err := setGroupMembers(ctx, tx, id, clusterIDs); err != nil {
+		return nil, err
+	}
+	group, err := getGroup(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit cluster group: %w", err)
+	}
+	return group, nil
+}
+
+// UpdateGroup renames a group and, when clusterIDs is non-nil, replaces its
+// members.
+func (s *Store) UpdateGroup(ctx context.Context, id, name, description string, clusterIDs []string) (*Group, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE cluster_groups SET name = $2, description = $3 WHERE id = $1`,
+		id, name, description,
+	)
+	if err != nil {
+		return nil, groupError(err, "update")
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrGroupNotFound
+	}
+	if clusterIDs != nil {
+		if _, err := tx.Exec(ctx, `DELETE FROM cluster_group_members WHERE group_id = $1`, id); err != nil {
+			return nil, fmt.Errorf("failed to clear cluster group members: %w", err)
+		}
+		if err := setGroupMembers(ctx, tx, id, clusterIDs); err != nil {
+			return nil, err
+		}
+	}
+	group, err := getGroup(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit cluster group: %w", err)
+	}
+	return group, nil
+}
+
+func setGroupMembers(ctx context.Context, tx pgx.Tx, groupID string, clusterIDs []string) error {
+	for _, clusterID := range clusterIDs {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO cluster_group_members (group_id, cluster_id) VALUES ($1, $2)`,
+			groupID, clusterID,
+		); err != nil {
+			return groupError(err, "add member to")
+		}
+	}
+	return nil
+}
+
+// DeleteGroup removes a group along with the role permissions scoped to it,
+// which would otherwise dangle.
+func (s *Store) DeleteGroup(ctx context.Context, id string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	tag, err := tx.Exec(ctx, `DELETE FROM cluster_groups WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete cluster group: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrGroupNotFound
+	}
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM role_permissions WHERE scope_type = 'group' AND scope_id = $1`, id,
+	); err != nil {
+		return fmt.Errorf("failed to delete group-scoped permissions: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit cluster group deletion: %w", err)
+	}
+	return nil
+}
+
+// ListClustersInGroup lists the clusters in a group, like ListClusters.
+func (s *Store) ListClustersInGroup(ctx context.Context, groupID string) ([]*Cluster, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT c.id, c.name, c.api_server_url, c.status, c.connection_type, c.agent_id, c.created_at, c.last_health,
+		        c.maintenance_since, c.maintenance_reason, c.maintenance_by
+		 FROM clusters c
+		 JOIN cluster_group_members m ON m.cluster_id = c.id
+		 WHERE m.group_id = $1
+		 ORDER BY c.created_at DESC`,
+		groupID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters in group: %w", err)
+	}
+	defer rows.Close()
+
+	var clusters []*Cluster
+	for rows.Next() {
+		var c Cluster
+		var maint maintenanceColumns
+		if err := rows.Scan(&c.ID, &c.Name, &c.APIServerURL, &c.Status, &c.ConnectionType, &c.AgentID, &c.CreatedAt, &c.LastHealth,
+			&maint.since, &maint.reason, &maint.by); err != nil {
+			return nil, fmt.Errorf("failed to scan cluster: %w", err)
+		}
+		c.Maintenance = maint.maintenance()
+		clusters = append(clusters, &c)
+	}
+	return clusters, rows.Err()
+}
@@ -1,20 +1,29 @@
 package cluster
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/darkden-lab/argus/backend/internal/audit"
 	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 type Handlers struct {
-	manager        *Manager
-	rbacWriteGuard mux.MiddlewareFunc
+	manager          *Manager
+	rbacWriteGuard   mux.MiddlewareFunc
+	maintenanceGuard mux.MiddlewareFunc
+	auditStore       *audit.Store
 }
 
 func NewHandlers(manager *Manager, rbacWriteGuard mux.MiddlewareFunc) *Handlers {
@@ -25,6 +34,7 @@ func (h *Handlers) RegisterRoutes(r *mux.Router) {
 	api := r.PathPrefix("/api/clusters").Subrouter()
 	api.HandleFunc("", h.handleList).Methods("GET")
 	api.HandleFunc("/{id}", h.handleGet).Methods("GET")
+	api.HandleFunc("/{id}/connection-events", h.handleConnectionEvents).Methods("GET")
 
 	// Write endpoints require clusters:write RBAC
 	writeAPI := api.PathPrefix("").Subrouter()
@@ -35,6 +45,13 @@ func (h *Handlers) RegisterRoutes(r *mux.Router) {
 	writeAPI.HandleFunc("/{id}", h.handleUpdate).Methods("PUT")
 	writeAPI.HandleFunc("/{id}", h.handleDelete).Methods("DELETE")
 	writeAPI.HandleFunc("/{id}/health", h.handleHealthCheck).Methods("POST")
+
+	// Maintenance mode has its own permission, held by admins only.
+	if h.maintenanceGuard != nil {
+		maintenanceAPI := api.PathPrefix("").Subrouter()
+		maintenanceAPI.Use(h.maintenanceGuard)
+		maintenanceAPI.HandleFunc("/{id}/maintenance", h.handleSetMaintenance).Methods("PUT")
+	}
 }
 
 type createClusterRequest struct {
@@ -58,12 +75,14 @@ func (h *Handlers) handleCreate(w http.ResponseWriter, r *http.Request) {
 	cluster, err := h.manager.AddCluster(r.Context(), req.Name, req.APIServerURL, []byte(req.Kubeconfig))
 	if err != nil {
 		log.Printf("cluster: AddCluster error: %v", err)
-		if cluster != nil {
-			// Cluster was stored but client creation or connectivity failed
-			httputil.WriteJSON(w, http.StatusCreated, map[string]interface{}{
-				"cluster": cluster,
-				"warning": err.Error(),
-			})
+		var kcErr *KubeconfigError
+		if errors.As(err, &kcErr) {
+			status := http.StatusUnprocessableEntity
+			if kcErr.Problem == KubeconfigMalformed {
+				status = http.StatusBadRequest
+			}
+			httputil.WriteErrorDetails(w, status, httputil.CodeValidationFailed, kcErr.Error(),
+				map[string]KubeconfigProblem{"problem": kcErr.Problem})
 			return
 		}
 		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
@@ -117,8 +136,28 @@ func (h *Handlers) handleUpdate(w http.ResponseWriter, r *http.Request) {
 	httputil.WriteJSON(w, http.StatusOK, cluster)
 }
 
+// handleList handles GET /api/clusters, optionally filtered to the members
+// of the cluster group given by ?group=.
 func (h *Handlers) handleList(w http.ResponseWriter, r *http.Request) {
-	clusters, err := h.manager.ListClusters(r.Context())
+	var clusters []*Cluster
+	var err error
+	if groupID := r.URL.Query().Get("group"); groupID != "" {
+		if _, err := uuid.Parse(groupID); err != nil {
+			httputil.WriteError(w, http.StatusNotFound, ErrGroupNotFound.Error())
+			return
+		}
+		if _, err := h.manager.store.GetGroup(r.Context(), groupID); err != nil {
+			if errors.Is(err, ErrGroupNotFound) {
+				httputil.WriteError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			httputil.WriteError(w, http.StatusInternalServerError, "failed to list clusters")
+			return
+		}
+		clusters, err = h.manager.store.ListClustersInGroup(r.Context(), groupID)
+	} else {
+		clusters, err = h.manager.ListClusters(r.Context())
+	}
 	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, "failed to list clusters")
 		return
@@ -142,11 +181,64 @@ func (h *Handlers) handleGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.populateNodeCounts(r, []*Cluster{cl})
+	if cl.ConnectionType == "agent" {
+		info, err := h.manager.store.GetClusterInfo(r.Context(), id)
+		if err != nil {
+			log.Printf("cluster: %v", err)
+		}
+		if info != nil && h.manager.agentServer != nil {
+			info.AgentVersionWarning = h.manager.agentServer.AgentVersionWarning(info.AgentVersion)
+		}
+		cl.Info = info
+	} else if cl.Status == "connected" {
+		if client, err := h.manager.GetClient(id); err == nil {
+			cl.Info = collectClusterInfo(r.Context(), id, client)
+		}
+	}
+	if cl.Info != nil {
+		count := cl.Info.NodeCount
+		cl.NodeCount = &count
+	} else {
+		h.populateNodeCounts(r, []*Cluster{cl})
+	}
 
 	httputil.WriteJSON(w, http.StatusOK, cl)
 }
 
+// collectClusterInfo builds the summary of a directly connected cluster, the
+// same one agents report for theirs. It returns nil if the nodes cannot be
+// listed.
+func collectClusterInfo(ctx context.Context, clusterID string, client *ClusterClient) *ClusterInfo {
+	nodes, err := client.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		log.Printf("cluster: failed to list nodes for %s: %v", clusterID, err)
+		return nil
+	}
+	info := &ClusterInfo{
+		NodeCount:   len(nodes.Items),
+		Capacity:    sumNodeResources(nodes.Items, func(n corev1.Node) corev1.ResourceList { return n.Status.Capacity }),
+		Allocatable: sumNodeResources(nodes.Items, func(n corev1.Node) corev1.ResourceList { return n.Status.Allocatable }),
+		ReportedAt:  time.Now().UTC(),
+	}
+	if version, err := client.Clientset.Discovery().ServerVersion(); err == nil {
+		info.KubernetesVersion = version.GitVersion
+		info.Platform = version.Platform
+	}
+	return info
+}
+
+// sumNodeResources totals the CPU, memory and pod resources of the nodes.
+func sumNodeResources(nodes []corev1.Node, resources func(corev1.Node) corev1.ResourceList) NodeResources {
+	var cpu, memory, pods resource.Quantity
+	for _, n := range nodes {
+		list := resources(n)
+		cpu.Add(list[corev1.ResourceCPU])
+		memory.Add(list[corev1.ResourceMemory])
+		pods.Add(list[corev1.ResourcePods])
+	}
+	return NodeResources{CPU: cpu.String(), Memory: memory.String(), Pods: pods.String()}
+}
+
 func (h *Handlers) handleDelete(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 
@@ -0,0 +1,56 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"k8s.io/client-go/rest"
+)
+
+// InClusterID is the fixed ID of the cluster the dashboard itself runs in.
+// It is stored as a regular row in the clusters table, so role assignments,
+// audit entries and other cluster-scoped data reference it like any other
+// cluster ID.
+const InClusterID = "00000000-0000-0000-0000-000000000001"
+
+// ConnectionTypeInCluster marks a cluster reached through the dashboard pod's
+// own service account rather than a stored kubeconfig or an agent.
+const ConnectionTypeInCluster = "in_cluster"
+
+// inClusterConfig is replaced in tests.
+var inClusterConfig = rest.InClusterConfig
+
+// RegisterInCluster registers the cluster the dashboard runs in, using the
+// pod's service account credentials, under InClusterID. The name is only used
+// the first time the cluster is registered. It fails if the process is not
+// running inside Kubernetes.
+func (m *Manager) RegisterInCluster(ctx context.Context, name string) (*Cluster, error) {
+	config, err := inClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("in-cluster config unavailable: %w", err)
+	}
+
+	client, err := newClusterClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster, err := m.store.UpsertInClusterCluster(ctx, InClusterID, name, config.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	m.setClient(cluster.ID, client)
+
+	status := "connected"
+	if _, err := client.Clientset.Discovery().ServerVersion(); err != nil {
+		log.Printf("cluster: connectivity test failed for in-cluster cluster: %v", err)
+		status = "unreachable"
+	}
+	_ = m.store.UpdateClusterStatus(ctx, cluster.ID, status)
+	cluster.Status = status
+
+	log.Printf("cluster: registered in-cluster cluster %q (id=%s, api=%s)", cluster.Name, cluster.ID, config.Host)
+	return cluster, nil
+}
@@ -0,0 +1,45 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"k8s.io/client-go/rest"
+)
+
+func TestInClusterIDIsUUID(t *testing.T) {
+	// Cluster IDs are UUID columns and foreign keys (e.g. user_roles.cluster_id),
+	// so the synthetic ID must be a valid UUID for RBAC scoping to work.
+	if _, err := uuid.Parse(InClusterID); err != nil {
+		t.Fatalf("InClusterID %q is not a valid UUID: %v", InClusterID, err)
+	}
+}
+
+func TestRegisterInCluster_NotInCluster(t *testing.T) {
+	orig := inClusterConfig
+	defer func() { inClusterConfig = orig }()
+	inClusterConfig = func() (*rest.Config, error) {
+		return nil, rest.ErrNotInCluster
+	}
+
+	m := NewManager(nil, "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	_, err := m.RegisterInCluster(context.Background(), "local")
+	if !errors.Is(err, rest.ErrNotInCluster) {
+		t.Fatalf("expected ErrNotInCluster, got %v", err)
+	}
+	if _, err := m.GetClient(InClusterID); err == nil {
+		t.Error("expected no client to be registered")
+	}
+}
+
+func TestNewClusterClientFromRESTConfig(t *testing.T) {
+	client, err := newClusterClient(&rest.Config{Host: "https://10.96.0.1:443", BearerToken: "token"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.Clientset == nil || client.DynClient == nil || client.RestConfig.Host != "https://10.96.0.1:443" {
+		t.Errorf("unexpected client: %+v", client)
+	}
+}
@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// errInvalidInstallLink is returned for install script links that are
+// unsigned, tampered with, or expired.
+var errInvalidInstallLink = errors.New("invalid or expired install link")
+
+// installLinkSigner signs the query of install script links. A link names
+// the agent token it was issued for and expires after ttl; the handler also
+// refuses it once that token is used, so the link is no more reusable than
+// the token it installs.
+type installLinkSigner struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+// newInstallLinkSigner derives the signing key from secret so it differs from
+// other keys derived from the same secret.
+func newInstallLinkSigner(secret string, ttl time.Duration) *installLinkSigner {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("agent-install-link"))
+	return &installLinkSigner{key: mac.Sum(nil), ttl: ttl, now: time.Now}
+}
+
+func (s *installLinkSigner) signature(tokenID string, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(tokenID + "|" + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// query returns the signed query string of a link for tokenID.
+func (s *installLinkSigner) query(tokenID string) string {
+	expires := s.now().Add(s.ttl).Unix()
+	return url.Values{
+		"token_id": {tokenID},
+		"expires":  {strconv.FormatInt(expires, 10)},
+		"sig":      {s.signature(tokenID, expires)},
+	}.Encode()
+}
+
+// verify checks a link's query and returns the token ID it was issued for.
+func (s *installLinkSigner) verify(q url.Values) (string, error) {
+	tokenID := q.Get("token_id")
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if tokenID == "" || err != nil {
+		return "", errInvalidInstallLink
+	}
+	if !hmac.Equal([]byte(q.Get("sig")), []byte(s.signature(tokenID, expires))) {
+		return "", errInvalidInstallLink
+	}
+	if s.now().Unix() >= expires {
+		return "", errInvalidInstallLink
+	}
+	return tokenID, nil
+}
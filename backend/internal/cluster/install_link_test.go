@@ -0,0 +1,68 @@
+package cluster
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestInstallLinkSigner(t *testing.T) {
+	signer := newInstallLinkSigner("secret", time.Hour)
+	now := time.Now()
+	signer.now = func() time.Time { return now }
+
+	q, err := url.ParseQuery(signer.query("tok-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := signer.verify(q); err != nil || id != "tok-1" {
+		t.Fatalf("expected the link to verify for tok-1, got %q %v", id, err)
+	}
+
+	tampered := url.Values{"token_id": {"tok-2"}, "expires": q["expires"], "sig": q["sig"]}
+	if _, err := signer.verify(tampered); err != errInvalidInstallLink {
+		t.Errorf("expected a link for another token to fail, got %v", err)
+	}
+	if _, err := newInstallLinkSigner("other", time.Hour).verify(q); err != errInvalidInstallLink {
+		t.Errorf("expected a link signed with another secret to fail, got %v", err)
+	}
+	if _, err := signer.verify(url.Values{}); err != errInvalidInstallLink {
+		t.Errorf("expected an unsigned link to fail, got %v", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := signer.verify(q); err != errInvalidInstallLink {
+		t.Errorf("expected an expired link to fail, got %v", err)
+	}
+}
+
+func TestInstallScript_PublicAndSigned(t *testing.T) {
+	h := NewAgentHandlers(nil, nil)
+	r := mux.NewRouter()
+	h.RegisterPublicRoutes(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/agents/install.sh", nil))
+	if w.Code != http.StatusOK || len(w.Body.Bytes()) == 0 {
+		t.Fatalf("expected the script to be public by default, got %d", w.Code)
+	}
+	if got := h.installScriptURL("https://argus.example.com", "tok-1"); got != "https://argus.example.com/api/agents/install.sh" {
+		t.Errorf("unexpected public script URL %q", got)
+	}
+
+	h.RequireSignedInstallLinks("secret", time.Hour)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/agents/install.sh", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected an unsigned request to be refused, got %d", w.Code)
+	}
+	got := h.installScriptURL("https://argus.example.com", "tok-1")
+	if !strings.HasPrefix(got, "'https://argus.example.com/api/agents/install.sh?") || !strings.Contains(got, "token_id=tok-1") || !strings.HasSuffix(got, "'") {
+		t.Errorf("expected a quoted signed script URL, got %q", got)
+	}
+}
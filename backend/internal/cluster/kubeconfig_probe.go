@@ -0,0 +1,93 @@
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+)
+
+// kubeconfigProbeTimeout bounds the connectivity check of a new kubeconfig.
+const kubeconfigProbeTimeout = 10 * time.Second
+
+// KubeconfigProblem says why a kubeconfig cannot be used to add a cluster.
+type KubeconfigProblem string
+
+const (
+	KubeconfigMalformed   KubeconfigProblem = "malformed_kubeconfig"
+	KubeconfigUnreachable KubeconfigProblem = "unreachable"
+	KubeconfigAuthFailed  KubeconfigProblem = "auth_failed"
+	KubeconfigTLSFailed   KubeconfigProblem = "tls_verification_failed"
+)
+
+// KubeconfigError is returned by AddCluster when the kubeconfig does not
+// parse or its API server cannot be used. Nothing is stored in that case.
+type KubeconfigError struct {
+	Problem KubeconfigProblem
+	Err     error
+}
+
+func (e *KubeconfigError) Error() string {
+	switch e.Problem {
+	case KubeconfigMalformed:
+		return fmt.Sprintf("malformed kubeconfig: %v", e.Err)
+	case KubeconfigAuthFailed:
+		return fmt.Sprintf("API server rejected the kubeconfig credentials: %v", e.Err)
+	case KubeconfigTLSFailed:
+		return fmt.Sprintf("TLS verification of the API server failed: %v", e.Err)
+	default:
+		return fmt.Sprintf("API server unreachable: %v", e.Err)
+	}
+}
+
+func (e *KubeconfigError) Unwrap() error {
+	return e.Err
+}
+
+// probeKubeconfig builds a client from a kubeconfig and checks that its API
+// server answers a server version request with the given credentials.
+func (m *Manager) probeKubeconfig(ctx context.Context, kubeconfig []byte) (*ClusterClient, error) {
+	client, err := m.buildClient(kubeconfig)
+	if err != nil {
+		return nil, &KubeconfigError{Problem: KubeconfigMalformed, Err: err}
+	}
+
+	config := rest.CopyConfig(client.RestConfig)
+	config.Timeout = kubeconfigProbeTimeout
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < config.Timeout {
+		config.Timeout = time.Until(deadline)
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, &KubeconfigError{Problem: KubeconfigMalformed, Err: err}
+	}
+	if _, err := dc.ServerVersion(); err != nil {
+		return nil, &KubeconfigError{Problem: classifyProbeError(err), Err: err}
+	}
+	return client, nil
+}
+
+// classifyProbeError maps a failed server version request to the problem a
+// user has to fix.
+func classifyProbeError(err error) KubeconfigProblem {
+	if apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err) {
+		return KubeconfigAuthFailed
+	}
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		invalidCert      x509.CertificateInvalidError
+		verification     *tls.CertificateVerificationError
+	)
+	if errors.As(err, &unknownAuthority) || errors.As(err, &hostname) ||
+		errors.As(err, &invalidCert) || errors.As(err, &verification) {
+		return KubeconfigTLSFailed
+	}
+	return KubeconfigUnreachable
+}
@@ -0,0 +1,125 @@
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// testKubeconfig returns a token kubeconfig for server, trusting caPEM when
+// it is not empty.
+func testKubeconfig(server string, caPEM []byte) string {
+	ca := ""
+	if len(caPEM) > 0 {
+		ca = "\n    certificate-authority-data: " + base64.StdEncoding.EncodeToString(caPEM)
+	}
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: %s%s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user:
+    token: abc
+`, server, ca)
+}
+
+func versionHandler(status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			_, _ = w.Write([]byte(`{"major":"1","minor":"30","gitVersion":"v1.30.0"}`))
+			return
+		}
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"kind":"Status","apiVersion":"v1","status":"Failure","code":%d,"reason":"Unauthorized"}`, status)))
+	}
+}
+
+func TestProbeKubeconfig(t *testing.T) {
+	ok := httptest.NewServer(versionHandler(http.StatusOK))
+	defer ok.Close()
+	unauthorized := httptest.NewServer(versionHandler(http.StatusUnauthorized))
+	defer unauthorized.Close()
+	untrusted := httptest.NewTLSServer(versionHandler(http.StatusOK))
+	defer untrusted.Close()
+	trusted := httptest.NewTLSServer(versionHandler(http.StatusOK))
+	defer trusted.Close()
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: trusted.Certificate().Raw})
+	closed := httptest.NewServer(versionHandler(http.StatusOK))
+	closedURL := closed.URL
+	closed.Close()
+
+	tests := []struct {
+		name       string
+		kubeconfig string
+		want       KubeconfigProblem
+	}{
+		{name: "reachable", kubeconfig: testKubeconfig(ok.URL, nil)},
+		{name: "trusted TLS", kubeconfig: testKubeconfig(trusted.URL, caPEM)},
+		{name: "malformed", kubeconfig: "not: [a kubeconfig", want: KubeconfigMalformed},
+		{name: "auth failed", kubeconfig: testKubeconfig(unauthorized.URL, nil), want: KubeconfigAuthFailed},
+		{name: "untrusted TLS", kubeconfig: testKubeconfig(untrusted.URL, nil), want: KubeconfigTLSFailed},
+		{name: "unreachable", kubeconfig: testKubeconfig(closedURL, nil), want: KubeconfigUnreachable},
+	}
+	m := NewManager(nil, "")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := m.probeKubeconfig(context.Background(), []byte(tt.kubeconfig))
+			if tt.want == "" {
+				if err != nil || client == nil {
+					t.Fatalf("expected the probe to succeed, got %v", err)
+				}
+				return
+			}
+			var kcErr *KubeconfigError
+			if !errors.As(err, &kcErr) || kcErr.Problem != tt.want {
+				t.Fatalf("expected problem %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestHandleCreate_KubeconfigRejectedBeforeStoring(t *testing.T) {
+	srv := httptest.NewServer(versionHandler(http.StatusUnauthorized))
+	defer srv.Close()
+
+	// A nil pool makes any store access panic, so a passing test shows the
+	// kubeconfig was refused before anything was persisted.
+	r := mux.NewRouter()
+	NewHandlers(NewManager(nil, "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"), nil).RegisterRoutes(r)
+	body, _ := json.Marshal(createClusterRequest{Name: "prod", APIServerURL: srv.URL, Kubeconfig: testKubeconfig(srv.URL, nil)})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/clusters", strings.NewReader(string(body))))
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Code    string            `json:"code"`
+		Details map[string]string `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != "validation_failed" || resp.Details["problem"] != string(KubeconfigAuthFailed) {
+		t.Errorf("expected an auth_failed validation error, got %s", w.Body.String())
+	}
+}
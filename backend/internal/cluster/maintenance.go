@@ -0,0 +1,290 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/darkden-lab/argus/backend/internal/audit"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// ErrMaintenanceMode is returned for writes to a cluster in maintenance
+// mode.
+var ErrMaintenanceMode = errors.New("cluster in maintenance mode")
+
+// maxMaintenanceReason caps the length of a maintenance reason.
+const maxMaintenanceReason = 500
+
+// Maintenance describes a cluster's maintenance mode. While it is enabled
+// the dashboard refuses every write to the cluster regardless of RBAC:
+// write requests to its routes, the K8s proxy and plugins, AI write tools,
+// raw terminal commands and fleet operations.
+type Maintenance struct {
+	Reason    string    `json:"reason,omitempty"`
+	EnabledBy *string   `json:"enabled_by,omitempty"`
+	Since     time.Time `json:"since"`
+}
+
+// MaintenanceError is ErrMaintenanceMode with the maintenance that caused
+// it.
+type MaintenanceError struct {
+	ClusterID   string
+	Maintenance *Maintenance
+}
+
+func (e *MaintenanceError) Error() string {
+	if e.Maintenance.Reason != "" {
+		return fmt.Sprintf("%s: %s", ErrMaintenanceMode, e.Maintenance.Reason)
+	}
+	return ErrMaintenanceMode.Error()
+}
+
+func (e *MaintenanceError) Is(target error) bool {
+	return target == ErrMaintenanceMode
+}
+
+// maintenanceColumns receives the maintenance columns of a clusters row.
+type maintenanceColumns struct {
+	since  *time.Time
+	reason string
+	by     *string
+}
+
+// maintenance returns the scanned maintenance, or nil if it is disabled.
+func (m *maintenanceColumns) maintenance() *Maintenance {
+	if m.since == nil {
+		return nil
+	}
+	return &Maintenance{Reason: m.reason, EnabledBy: m.by, Since: *m.since}
+}
+
+// GetMaintenance returns a cluster's maintenance, or nil if it is disabled
+// or the cluster does not exist.
+func (s *Store) GetMaintenance(ctx context.Context, clusterID string) (*Maintenance, error) {
+	var cols maintenanceColumns
+	err := s.pool.QueryRow(ctx,
+		`SELECT maintenance_since, maintenance_reason, maintenance_by FROM clusters WHERE id = $1`,
+		clusterID,
+	).Scan(&cols.since, &cols.reason, &cols.by)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get maintenance mode: %w", err)
+	}
+	return cols.maintenance(), nil
+}
+
+// SetMaintenance enables maintenance on a cluster as userID, or disables it
+// when enabled is false. Enabling it again keeps the original start time and
+// updates the reason. It returns the resulting maintenance, nil when
+// disabled.
+func (s *Store) SetMaintenance(ctx context.Context, clusterID string, enabled bool, reason string, userID *string) (*Maintenance, error) {
+	var cols maintenanceColumns
+	var err error
+	if enabled {
+		err = s.pool.QueryRow(ctx,
+			`UPDATE clusters
+			 SET maintenance_since = COALESCE(maintenance_since, NOW()),
+			     maintenance_reason = $2, maintenance_by = $3
+			 WHERE id = $1
+			 RETURNING maintenance_since, maintenance_reason, maintenance_by`,
+			clusterID, reason, userID,
+		).Scan(&cols.since, &cols.reason, &cols.by)
+	} else {
+		err = s.pool.QueryRow(ctx,
+			`UPDATE clusters
+			 SET maintenance_since = NULL, maintenance_reason = '', maintenance_by = NULL
+			 WHERE id = $1
+			 RETURNING maintenance_since, maintenance_reason, maintenance_by`,
+			clusterID,
+		).Scan(&cols.since, &cols.reason, &cols.by)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set maintenance mode: %w", err)
+	}
+	return cols.maintenance(), nil
+}
+
+// CheckWritable returns a *MaintenanceError if the cluster is in
+// maintenance mode. Unknown clusters are writable as far as maintenance is
+// concerned; whatever handles the write reports them. The mode is read from
+// the database on every call so a toggle applies to all replicas at once.
+func (m *Manager) CheckWritable(ctx context.Context, clusterID string) error {
+	if m.pool == nil || clusterID == "" {
+		return nil
+	}
+	if _, err := uuid.Parse(clusterID); err != nil {
+		return nil
+	}
+	maint, err := m.store.GetMaintenance(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+	if maint != nil {
+		return &MaintenanceError{ClusterID: clusterID, Maintenance: maint}
+	}
+	return nil
+}
+
+// WriteChecker reports whether a cluster accepts writes. Implemented by
+// *Manager.
+type WriteChecker interface {
+	CheckWritable(ctx context.Context, clusterID string) error
+}
+
+// maintenanceExemptRoutes are write-verb routes that don't change the
+// cluster, so they keep working during maintenance: previews, reviews and
+// refreshes that only read, dashboard-side preferences, and stopping a PVC
+// browser session started before maintenance.
+var maintenanceExemptRoutes = map[string]bool{
+	"/api/clusters/{clusterID}/discovery/refresh":                                           true,
+	"/api/clusters/{clusterID}/manifests/preview":                                           true,
+	"/api/clusters/{clusterID}/namespace-preferences":                                       true,
+	"/api/clusters/{clusterID}/namespaces/{namespace}/serviceaccounts/{name}/access-review": true,
+	"/api/clusters/{clusterID}/pvc-browser/sessions/{sessionID}":                            true,
+	"/api/plugins/helm/{cluster}/releases/preview":                                          true,
+	"/api/plugins/prometheus/{cluster}/wizard/servicemonitor/preview":                       true,
+	"/api/plugins/prometheus/{cluster}/config":                                              true,
+	"/api/plugins/istio/{cluster}/config":                                                   true,
+}
+
+// MaintenanceMiddleware rejects POST, PUT, PATCH and DELETE requests aimed at
+// a cluster in maintenance mode with 423 Locked. The cluster is taken from
+// the route's {clusterID}, {cluster_id} or {cluster} variable, or else the
+// ?clusterID= query parameter plugins use. Routes of the cluster registry
+// itself (/api/clusters/{id}/...) are not affected, so maintenance can be
+// turned off again.
+//
+// It must be registered with Router.Use so the matched route is known.
+func MaintenanceMiddleware(checker WriteChecker) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isWriteMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil && maintenanceExemptRoutes[tpl] {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			clusterID := requestClusterID(r)
+			if clusterID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err := checker.CheckWritable(r.Context(), clusterID); err != nil {
+				WriteMaintenanceError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isWriteMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch || method == http.MethodDelete
+}
+
+// requestClusterID returns the cluster a request targets, if any.
+func requestClusterID(r *http.Request) string {
+	vars := mux.Vars(r)
+	for _, name := range []string{"clusterID", "cluster_id", "cluster"} {
+		if id := vars[name]; id != "" {
+			return id
+		}
+	}
+	return r.URL.Query().Get("clusterID")
+}
+
+// WriteMaintenanceError writes the response for a failed CheckWritable: 423
+// with the maintenance as details, or 500 if the mode could not be read.
+func WriteMaintenanceError(w http.ResponseWriter, err error) {
+	var maintErr *MaintenanceError
+	if errors.As(err, &maintErr) {
+		httputil.WriteErrorDetails(w, http.StatusLocked, httputil.CodeClusterMaintenance, maintErr.Error(), maintErr.Maintenance)
+		return
+	}
+	log.Printf("cluster: %v", err)
+	httputil.WriteError(w, http.StatusInternalServerError, "failed to check maintenance mode")
+}
+
+// SetMaintenance enables the maintenance toggle route, protected by guard,
+// and records toggles in auditStore when it is non-nil. Without a guard the
+// route is not registered. It must be called before RegisterRoutes.
+func (h *Handlers) SetMaintenance(guard mux.MiddlewareFunc, auditStore *audit.Store) {
+	h.maintenanceGuard = guard
+	h.auditStore = auditStore
+}
+
+type maintenanceRequest struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason"`
+}
+
+// handleSetMaintenance handles PUT /api/clusters/{id}/maintenance.
+func (h *Handlers) handleSetMaintenance(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req maintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if len(req.Reason) > maxMaintenanceReason {
+		httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("reason must be at most %d characters", maxMaintenanceReason))
+		return
+	}
+
+	if _, err := h.manager.store.GetCluster(r.Context(), id); err != nil {
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
+		return
+	}
+	userID, _ := audit.ActorFromContext(r.Context())
+	maint, err := h.manager.store.SetMaintenance(r.Context(), id, req.Enabled, req.Reason, userID)
+	if err != nil {
+		log.Printf("cluster: %v", err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to set maintenance mode")
+		return
+	}
+
+	action := "cluster.maintenance.disable"
+	if req.Enabled {
+		action = "cluster.maintenance.enable"
+	}
+	h.recordMaintenance(r, id, action, req.Reason)
+
+	httputil.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled":     maint != nil,
+		"maintenance": maint,
+	})
+}
+
+// recordMaintenance writes the audit entry of a maintenance toggle.
+func (h *Handlers) recordMaintenance(r *http.Request, clusterID, action, reason string) {
+	log.Printf("cluster audit: %s cluster=%s reason=%q", action, clusterID, reason)
+	if h.auditStore == nil {
+		return
+	}
+	details, _ := json.Marshal(map[string]interface{}{"reason": reason})
+	userID, serviceAccountID := audit.ActorFromContext(r.Context())
+	ctx, cancel := audit.DetachedContext(r.Context())
+	defer cancel()
+	if err := h.auditStore.Insert(ctx, userID, serviceAccountID, &clusterID, action, r.URL.Path, details); err != nil {
+		log.Printf("cluster: failed to audit %s: %v", action, err)
+	}
+}
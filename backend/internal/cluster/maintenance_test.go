@@ -0,0 +1,131 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// fakeWriteChecker reports the clusters in locked as in maintenance mode
+// and records the clusters it was asked about.
+type fakeWriteChecker struct {
+	locked  map[string]bool
+	checked []string
+}
+
+func (f *fakeWriteChecker) CheckWritable(_ context.Context, clusterID string) error {
+	f.checked = append(f.checked, clusterID)
+	if f.locked[clusterID] {
+		return &MaintenanceError{ClusterID: clusterID, Maintenance: &Maintenance{Reason: "node upgrades", Since: time.Now()}}
+	}
+	return nil
+}
+
+func serveMaintenance(checker WriteChecker, method, path string) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.Use(MaintenanceMiddleware(checker))
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	r.HandleFunc("/api/clusters/{clusterID}/resources/{resource}", ok)
+	r.HandleFunc("/api/clusters/{clusterID}/manifests/preview", ok)
+	r.HandleFunc("/api/proxy/k8s/{cluster_id}/api/v1/pods", ok)
+	r.HandleFunc("/api/plugins/helm/{cluster}/releases", ok)
+	r.HandleFunc("/api/plugins/calico/networkpolicies", ok)
+	r.HandleFunc("/api/clusters/{id}", ok)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestMaintenanceMiddleware_BlocksWrites(t *testing.T) {
+	checker := &fakeWriteChecker{locked: map[string]bool{"c1": true}}
+	paths := []string{
+		"/api/clusters/c1/resources/pods",
+		"/api/proxy/k8s/c1/api/v1/pods",
+		"/api/plugins/helm/c1/releases",
+		"/api/plugins/calico/networkpolicies?clusterID=c1",
+	}
+	for _, path := range paths {
+		for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			rec := serveMaintenance(checker, method, path)
+			if rec.Code != http.StatusLocked {
+				t.Errorf("%s %s: expected 423, got %d", method, path, rec.Code)
+				continue
+			}
+			var resp httputil.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Code != httputil.CodeClusterMaintenance || resp.Message != "cluster in maintenance mode: node upgrades" {
+				t.Errorf("%s %s: unexpected error %q: %q", method, path, resp.Code, resp.Message)
+			}
+		}
+	}
+}
+
+func TestMaintenanceMiddleware_AllowsOthers(t *testing.T) {
+	checker := &fakeWriteChecker{locked: map[string]bool{"c1": true}}
+	tests := []struct {
+		method, path string
+	}{
+		{http.MethodGet, "/api/clusters/c1/resources/pods"},
+		{http.MethodPost, "/api/clusters/c2/resources/pods"},
+		{http.MethodPost, "/api/clusters/c1/manifests/preview"},
+		{http.MethodPut, "/api/clusters/c1"},
+		{http.MethodPost, "/api/plugins/calico/networkpolicies"},
+	}
+	for _, tt := range tests {
+		if rec := serveMaintenance(checker, tt.method, tt.path); rec.Code != http.StatusNoContent {
+			t.Errorf("%s %s: expected 204, got %d", tt.method, tt.path, rec.Code)
+		}
+	}
+	if len(checker.checked) != 1 || checker.checked[0] != "c2" {
+		t.Errorf("expected only the write to c2 to be checked, got %v", checker.checked)
+	}
+}
+
+func TestWriteMaintenanceError_CheckFailed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteMaintenanceError(rec, errors.New("connection refused"))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rec.Code)
+	}
+}
+
+func TestMaintenanceError(t *testing.T) {
+	err := error(&MaintenanceError{ClusterID: "c1", Maintenance: &Maintenance{Since: time.Now()}})
+	if !errors.Is(err, ErrMaintenanceMode) {
+		t.Error("expected MaintenanceError to match ErrMaintenanceMode")
+	}
+	if err.Error() != "cluster in maintenance mode" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestMaintenanceColumns(t *testing.T) {
+	var cols maintenanceColumns
+	if cols.maintenance() != nil {
+		t.Error("expected no maintenance without a start time")
+	}
+	since := time.Now()
+	by := "u1"
+	cols = maintenanceColumns{since: &since, reason: "upgrade", by: &by}
+	m := cols.maintenance()
+	if m == nil || m.Reason != "upgrade" || *m.EnabledBy != "u1" || !m.Since.Equal(since) {
+		t.Errorf("unexpected maintenance %+v", m)
+	}
+}
+
+func TestCheckWritable_WithoutDatabase(t *testing.T) {
+	m := NewManager(nil, "")
+	if err := m.CheckWritable(context.Background(), testClusterID); err != nil {
+		t.Errorf("expected clusters to be writable without a database, got %v", err)
+	}
+}
@@ -3,13 +3,19 @@ package cluster
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/darkden-lab/argus/backend/internal/crypto"
 	"github.com/darkden-lab/argus/backend/internal/ws"
+	"github.com/darkden-lab/argus/backend/pkg/agentpb"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
@@ -31,15 +37,27 @@ type Manager struct {
 	mu            sync.RWMutex
 	encryptionKey string
 	agentServer   *AgentServer
+	discovery     *discoveryCache
 }
 
 func NewManager(pool *pgxpool.Pool, encryptionKey string) *Manager {
-	return &Manager{
+	m := &Manager{
 		pool:          pool,
 		store:         NewStore(pool),
 		clients:       make(map[string]*ClusterClient),
 		encryptionKey: encryptionKey,
 	}
+	m.discovery = newDiscoveryCache(m.discover, DefaultDiscoveryTTL)
+	return m
+}
+
+// setClient installs the client of a cluster. Discovery data fetched with
+// the previous credentials is dropped.
+func (m *Manager) setClient(clusterID string, client *ClusterClient) {
+	m.mu.Lock()
+	m.clients[clusterID] = client
+	m.mu.Unlock()
+	m.discovery.invalidate(clusterID)
 }
 
 // SetAgentServer sets the gRPC agent server reference so the manager can
@@ -48,7 +66,15 @@ func (m *Manager) SetAgentServer(srv *AgentServer) {
 	m.agentServer = srv
 }
 
+// AddCluster checks that the kubeconfig parses and reaches its API server,
+// then stores it encrypted and installs its client. A kubeconfig that fails
+// the check is not stored; the error is a *KubeconfigError saying why.
 func (m *Manager) AddCluster(ctx context.Context, name, apiServerURL string, kubeconfig []byte) (*Cluster, error) {
+	client, err := m.probeKubeconfig(ctx, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
 	encrypted, err := crypto.Encrypt(kubeconfig, m.encryptionKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt kubeconfig: %w", err)
@@ -59,22 +85,7 @@ func (m *Manager) AddCluster(ctx context.Context, name, apiServerURL string, kub
 		return nil, err
 	}
 
-	client, err := m.buildClient(kubeconfig)
-	if err != nil {
-		return cluster, fmt.Errorf("cluster stored but client creation failed: %w", err)
-	}
-
-	m.mu.Lock()
-	m.clients[cluster.ID] = client
-	m.mu.Unlock()
-
-	// Run immediate connectivity test
-	if _, err := client.Clientset.Discovery().ServerVersion(); err != nil {
-		log.Printf("cluster: connectivity test failed for %s: %v", cluster.ID, err)
-		cluster.Status = "unreachable"
-		_ = m.store.UpdateClusterStatus(ctx, cluster.ID, "unreachable")
-		return cluster, fmt.Errorf("cluster stored but connectivity test failed: %w", err)
-	}
+	m.setClient(cluster.ID, client)
 
 	_ = m.store.UpdateClusterStatus(ctx, cluster.ID, "connected")
 	cluster.Status = "connected"
@@ -90,6 +101,7 @@ func (m *Manager) RemoveCluster(ctx context.Context, id string) error {
 	m.mu.Lock()
 	delete(m.clients, id)
 	m.mu.Unlock()
+	m.discovery.invalidate(id)
 
 	return nil
 }
@@ -137,6 +149,146 @@ func (m *Manager) IsAgentCluster(ctx context.Context, clusterID string) bool {
 	return ct == "agent"
 }
 
+// ErrClusterUnavailable is returned by DoK8sRequest when a cluster has
+// neither a direct client nor a connected agent.
+var ErrClusterUnavailable = errors.New("cluster not found or agent not connected")
+
+// CheckAgent reports whether requests to a cluster without a direct client
+// can go through its agent: nil when the agent is connected,
+// ErrAgentNotConnected for an agent cluster whose agent is offline and
+// ErrClusterUnavailable for any other cluster.
+func (m *Manager) CheckAgent(ctx context.Context, clusterID string) error {
+	if m.agentServer != nil && m.agentServer.IsAgentConnected(clusterID) {
+		return nil
+	}
+	if m.pool != nil && m.IsAgentCluster(ctx, clusterID) {
+		return ErrAgentNotConnected
+	}
+	return ErrClusterUnavailable
+}
+
+// K8sRequestError is an error status returned by the Kubernetes API.
+type K8sRequestError struct {
+	StatusCode int
+	Message    string
+	// Details are the status details, e.g. the causes of an apply conflict.
+	Details *metav1.StatusDetails
+}
+
+func (e *K8sRequestError) Error() string {
+	return fmt.Sprintf("kubernetes API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// DoK8sRequest sends a request to a cluster's Kubernetes API and returns the
+// raw JSON response body. Clusters with a direct client are called directly,
+// agent clusters through their agent, so callers don't need to branch on the
+// connection type. path is an absolute API path such as
+// "/api/v1/namespaces/default/pods" and may carry a query string. Error
+// statuses are returned as *K8sRequestError.
+func (m *Manager) DoK8sRequest(ctx context.Context, clusterID, method, path string, body []byte) ([]byte, error) {
+	return m.DoK8sRequestWithContentType(ctx, clusterID, method, path, "application/json", body)
+}
+
+// DoK8sRequestWithContentType is DoK8sRequest with an explicit request body
+// content type, e.g. "application/apply-patch+yaml" for server-side apply.
+func (m *Manager) DoK8sRequestWithContentType(ctx context.Context, clusterID, method, path, contentType string, body []byte) ([]byte, error) {
+	m.mu.RLock()
+	client, ok := m.clients[clusterID]
+	m.mu.RUnlock()
+	if ok {
+		return doDirectRequest(ctx, client, method, path, contentType, body)
+	}
+
+	if err := m.CheckAgent(ctx, clusterID); err != nil {
+		return nil, err
+	}
+	req := &agentpb.K8SRequest{Method: method, Path: path, Body: body}
+	if len(body) > 0 {
+		req.Headers = map[string]string{"Content-Type": contentType}
+	}
+	resp, err := m.agentServer.SendK8sRequest(ctx, clusterID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := ResponseError(resp); err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// doDirectRequest sends a raw request through the cluster's REST client.
+func doDirectRequest(ctx context.Context, client *ClusterClient, method, path, contentType string, body []byte) ([]byte, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request path %q: %w", path, err)
+	}
+	restClient := client.Clientset.Discovery().RESTClient()
+	if restClient == nil {
+		return nil, fmt.Errorf("cluster client does not support raw requests")
+	}
+
+	req := restClient.Verb(method).AbsPath(u.Path)
+	for key, values := range u.Query() {
+		for _, v := range values {
+			req = req.Param(key, v)
+		}
+	}
+	if len(body) > 0 {
+		req = req.SetHeader("Content-Type", contentType).Body(body)
+	}
+
+	data, err := req.Do(ctx).Raw()
+	if err != nil {
+		var status apierrors.APIStatus
+		if errors.As(err, &status) {
+			// Raw returns a generic message for the status code; the
+			// response body carries the API server's own.
+			if len(data) > 0 {
+				return nil, statusError(int(status.Status().Code), data)
+			}
+			return nil, &K8sRequestError{
+				StatusCode: int(status.Status().Code),
+				Message:    status.Status().Message,
+				Details:    status.Status().Details,
+			}
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// ResponseError returns the failure an agent response reports as a
+// *K8sRequestError, or nil. The agent sets Error when it could not reach
+// the API server, with a 502 status unless it knows better; otherwise the
+// API server's own error status is used.
+func ResponseError(resp *agentpb.K8SResponse) error {
+	if resp.Error != "" {
+		status := int(resp.StatusCode)
+		if status < 400 {
+			status = http.StatusBadGateway
+		}
+		return &K8sRequestError{StatusCode: status, Message: resp.Error}
+	}
+	if resp.StatusCode >= 400 {
+		return statusError(int(resp.StatusCode), resp.Body)
+	}
+	return nil
+}
+
+// statusError builds the error of an error response from its metav1.Status
+// body. The message falls back to the raw body.
+func statusError(code int, body []byte) *K8sRequestError {
+	e := &K8sRequestError{StatusCode: code, Message: strings.TrimSpace(string(body))}
+	var status metav1.Status
+	if err := json.Unmarshal(body, &status); err == nil {
+		if status.Message != "" {
+			e.Message = status.Message
+		}
+		e.Details = status.Details
+	}
+	return e
+}
+
 func (m *Manager) UpdateCluster(ctx context.Context, id, name, apiServerURL string) (*Cluster, error) {
 	return m.store.UpdateCluster(ctx, id, name, apiServerURL)
 }
@@ -176,6 +328,13 @@ func (m *Manager) HealthCheck(ctx context.Context) {
 	}
 }
 
+// Ping checks that the cluster's API server answers, over its kubeconfig
+// client or agent tunnel.
+func (m *Manager) Ping(ctx context.Context, clusterID string) error {
+	_, err := m.DoK8sRequest(ctx, clusterID, "GET", "/version", nil)
+	return err
+}
+
 // LoadExisting loads kubeconfig-based clusters from the database on startup.
 // Agent clusters are not loaded here as they connect via gRPC.
 func (m *Manager) LoadExisting(ctx context.Context) error {
@@ -207,9 +366,7 @@ func (m *Manager) LoadExisting(ctx context.Context) error {
 			continue
 		}
 
-		m.mu.Lock()
-		m.clients[id] = client
-		m.mu.Unlock()
+		m.setClient(id, client)
 	}
 
 	return rows.Err()
@@ -284,6 +441,11 @@ func (m *Manager) buildClient(kubeconfig []byte) (*ClusterClient, error) {
 		return nil, fmt.Errorf("kubeconfig uses exec-based authentication (e.g., gcloud, aws-iam-authenticator) which is not supported in the server environment. Use the cluster agent instead")
 	}
 
+	return newClusterClient(config)
+}
+
+// newClusterClient builds the typed and dynamic clients for a REST config.
+func newClusterClient(config *rest.Config) (*ClusterClient, error) {
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create clientset: %w", err)
@@ -1,8 +1,15 @@
 package cluster
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/darkden-lab/argus/backend/pkg/agentpb"
+	"k8s.io/client-go/rest"
 )
 
 func TestNewManager(t *testing.T) {
@@ -82,6 +89,92 @@ func TestSetAgentServer(t *testing.T) {
 	}
 }
 
+func TestDoK8sRequest_Direct(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v1/namespaces/default/pods" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","message":"not found","code":404}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"kind":"PodList","labelSelector":"` + r.URL.Query().Get("labelSelector") + `"}`))
+	}))
+	defer srv.Close()
+
+	client, err := newClusterClient(&rest.Config{Host: srv.URL})
+	if err != nil {
+		t.Fatalf("newClusterClient failed: %v", err)
+	}
+	m := NewManager(nil, "")
+	m.clients["c1"] = client
+
+	body, err := m.DoK8sRequest(context.Background(), "c1", http.MethodGet, "/api/v1/namespaces/default/pods?labelSelector=app%3Dweb", nil)
+	if err != nil {
+		t.Fatalf("DoK8sRequest failed: %v", err)
+	}
+	if !strings.Contains(string(body), `"labelSelector":"app=web"`) {
+		t.Errorf("expected query string to be forwarded, got %s", body)
+	}
+
+	_, err = m.DoK8sRequest(context.Background(), "c1", http.MethodGet, "/api/v1/namespaces/default/pods/missing", nil)
+	var reqErr *K8sRequestError
+	if !errors.As(err, &reqErr) || reqErr.StatusCode != http.StatusNotFound || reqErr.Message != "not found" {
+		t.Errorf("expected a 404 K8sRequestError, got %v", err)
+	}
+}
+
+func TestDoK8sRequest_Agent(t *testing.T) {
+	m := NewManager(nil, "")
+	agentSrv := NewAgentServer(nil, nil, "test-secret")
+	conn := &AgentConnection{ClusterID: "c1", pending: make(map[string]chan *agentpb.K8SResponse)}
+	stream := &fakeAgentStream{server: agentSrv, conn: conn, body: []byte(`{"kind":"Pod"}`)}
+	conn.Stream = stream
+	agentSrv.agents["c1"] = conn
+	m.SetAgentServer(agentSrv)
+
+	body, err := m.DoK8sRequest(context.Background(), "c1", http.MethodGet, "/api/v1/namespaces/default/pods/web", nil)
+	if err != nil {
+		t.Fatalf("DoK8sRequest failed: %v", err)
+	}
+	if string(body) != `{"kind":"Pod"}` {
+		t.Errorf("unexpected body %s", body)
+	}
+
+	stream.status = http.StatusForbidden
+	stream.body = []byte(`{"kind":"Status","message":"pods is forbidden"}`)
+	_, err = m.DoK8sRequest(context.Background(), "c1", http.MethodGet, "/api/v1/namespaces/default/pods/web", nil)
+	var reqErr *K8sRequestError
+	if !errors.As(err, &reqErr) || reqErr.StatusCode != http.StatusForbidden || reqErr.Message != "pods is forbidden" {
+		t.Errorf("expected a 403 K8sRequestError, got %v", err)
+	}
+}
+
+func TestDoK8sRequest_ClusterUnavailable(t *testing.T) {
+	m := NewManager(nil, "")
+	if _, err := m.DoK8sRequest(context.Background(), "missing", http.MethodGet, "/api/v1/pods", nil); !errors.Is(err, ErrClusterUnavailable) {
+		t.Errorf("expected ErrClusterUnavailable, got %v", err)
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	var reqErr *K8sRequestError
+	err := ResponseError(&agentpb.K8SResponse{Error: "k8s API request failed: connection refused"})
+	if !errors.As(err, &reqErr) || reqErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected a 502 for a failed round trip without a status, got %v", err)
+	}
+	err = ResponseError(&agentpb.K8SResponse{StatusCode: http.StatusServiceUnavailable, Error: "agent not running in a Kubernetes cluster"})
+	if !errors.As(err, &reqErr) || reqErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected the agent's status to be kept, got %v", err)
+	}
+	err = ResponseError(&agentpb.K8SResponse{StatusCode: http.StatusNotFound, Body: []byte(`{"kind":"Status","message":"pods \"web\" not found"}`)})
+	if !errors.As(err, &reqErr) || reqErr.StatusCode != http.StatusNotFound || reqErr.Message != `pods "web" not found` {
+		t.Errorf("expected the API server's 404, got %v", err)
+	}
+	if err := ResponseError(&agentpb.K8SResponse{StatusCode: http.StatusOK}); err != nil {
+		t.Errorf("expected no error for a 200, got %v", err)
+	}
+}
+
 func TestBuildClient_InvalidKubeconfig(t *testing.T) {
 	key := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
 	m := NewManager(nil, key)
@@ -0,0 +1,36 @@
+package cluster
+
+import "encoding/json"
+
+// Endpoints and jobs that fan out across clusters return what the reachable
+// clusters produced and list the others in a ClusterErrors, rather than
+// failing as a whole when one cluster is down.
+
+// ClusterError tells why one cluster is missing from a multi-cluster result.
+type ClusterError struct {
+	ClusterID   string `json:"cluster_id"`
+	ClusterName string `json:"cluster_name,omitempty"`
+	Error       string `json:"error"`
+}
+
+// ClusterErrors collects the clusters left out of a multi-cluster result. It
+// is encoded as a JSON array, empty when every cluster answered.
+type ClusterErrors []ClusterError
+
+// Add records that the cluster was skipped because of err.
+func (e *ClusterErrors) Add(c *Cluster, err error) {
+	*e = append(*e, ClusterError{ClusterID: c.ID, ClusterName: c.Name, Error: err.Error()})
+}
+
+// Partial reports whether any cluster was left out.
+func (e ClusterErrors) Partial() bool {
+	return len(e) > 0
+}
+
+// MarshalJSON encodes a nil list as [] so clients need not check for null.
+func (e ClusterErrors) MarshalJSON() ([]byte, error) {
+	if e == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]ClusterError(e))
+}
@@ -0,0 +1,34 @@
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestClusterErrors(t *testing.T) {
+	var errs ClusterErrors
+	if errs.Partial() {
+		t.Error("expected an empty list not to be partial")
+	}
+	data, err := json.Marshal(errs)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if string(data) != "[]" {
+		t.Errorf("expected [], got %s", data)
+	}
+
+	errs.Add(&Cluster{ID: "c1", Name: "prod"}, errors.New("connection refused"))
+	if !errs.Partial() {
+		t.Error("expected partial after Add")
+	}
+	data, err = json.Marshal(errs)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	want := `[{"cluster_id":"c1","cluster_name":"prod","error":"connection refused"}]`
+	if string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+}
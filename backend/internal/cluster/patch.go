@@ -0,0 +1,89 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// patchTypes are the patch types accepted by PatchResource, keyed by their
+// content type. Server-side apply has its own endpoint and is not included.
+var patchTypes = map[string]types.PatchType{
+	string(types.JSONPatchType):           types.JSONPatchType,
+	string(types.MergePatchType):          types.MergePatchType,
+	string(types.StrategicMergePatchType): types.StrategicMergePatchType,
+}
+
+// PatchTypeFor returns the patch type named by a request's Content-Type
+// header, ignoring any parameters such as charset.
+func PatchTypeFor(contentType string) (types.PatchType, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	pt, ok := patchTypes[mediaType]
+	return pt, ok
+}
+
+// PatchRequest describes a patch of a single object.
+type PatchRequest struct {
+	Resource  schema.GroupVersionResource
+	Namespace string
+	Name      string
+	Type      types.PatchType
+	Patch     []byte
+	// FieldManager is recorded in the object's managedFields.
+	FieldManager string
+	// DryRun stops after validating the patch.
+	DryRun bool
+}
+
+// PatchResource patches an object on a cluster and returns the patched
+// object as JSON. The patch is first sent as a server-side dry run, so a
+// patch that does not apply or yields an invalid object is rejected before
+// anything changes; unless req.DryRun is set it is then applied. Errors are
+// those of DoK8sRequest.
+func PatchResource(ctx context.Context, k K8sRequester, clusterID string, req PatchRequest) ([]byte, error) {
+	if _, ok := patchTypes[string(req.Type)]; !ok {
+		return nil, fmt.Errorf("unsupported patch type %q", req.Type)
+	}
+
+	query := url.Values{}
+	if req.FieldManager != "" {
+		query.Set("fieldManager", req.FieldManager)
+	}
+	path := resourcePath(req.Resource, req.Namespace, req.Name)
+
+	dryRun := url.Values{"dryRun": {metav1.DryRunAll}}
+	for key, values := range query {
+		dryRun[key] = values
+	}
+	validated, err := k.DoK8sRequestWithContentType(ctx, clusterID, http.MethodPatch, path+"?"+dryRun.Encode(), string(req.Type), req.Patch)
+	if err != nil || req.DryRun {
+		return validated, err
+	}
+
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return k.DoK8sRequestWithContentType(ctx, clusterID, http.MethodPatch, path, string(req.Type), req.Patch)
+}
+
+// resourcePath builds the Kubernetes API path of a named object. namespace
+// is empty for cluster-scoped resources.
+func resourcePath(gvr schema.GroupVersionResource, namespace, name string) string {
+	path := "/apis/" + gvr.Group + "/" + gvr.Version
+	if gvr.Group == "" {
+		path = "/api/" + gvr.Version
+	}
+	if namespace != "" {
+		path += "/namespaces/" + namespace
+	}
+	return path + "/" + gvr.Resource + "/" + name
+}
@@ -0,0 +1,121 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestPatchTypeFor(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        types.PatchType
+		ok          bool
+	}{
+		{"application/json-patch+json", types.JSONPatchType, true},
+		{"application/merge-patch+json; charset=utf-8", types.MergePatchType, true},
+		{"application/strategic-merge-patch+json", types.StrategicMergePatchType, true},
+		{"application/apply-patch+yaml", "", false},
+		{"application/json", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := PatchTypeFor(tt.contentType)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("PatchTypeFor(%q) = %q, %v; want %q, %v", tt.contentType, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// patchRecorder records the requests it is sent and fails those whose path
+// is in fail.
+type patchRecorder struct {
+	paths        []string
+	contentTypes []string
+	fail         map[string]error
+}
+
+func (p *patchRecorder) DoK8sRequestWithContentType(_ context.Context, _, method, path, contentType string, _ []byte) ([]byte, error) {
+	if method != http.MethodPatch {
+		return nil, errors.New("unexpected method " + method)
+	}
+	p.paths = append(p.paths, path)
+	p.contentTypes = append(p.contentTypes, contentType)
+	if err := p.fail[path]; err != nil {
+		return nil, err
+	}
+	return []byte(`{"kind":"Deployment"}`), nil
+}
+
+func TestPatchResource(t *testing.T) {
+	rec := &patchRecorder{}
+	req := PatchRequest{
+		Resource:     schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+		Namespace:    "default",
+		Name:         "web",
+		Type:         types.StrategicMergePatchType,
+		Patch:        []byte(`{"spec":{"replicas":3}}`),
+		FieldManager: FieldManagerEditor,
+	}
+	if _, err := PatchResource(context.Background(), rec, "c1", req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"/apis/apps/v1/namespaces/default/deployments/web?dryRun=All&fieldManager=argus-editor",
+		"/apis/apps/v1/namespaces/default/deployments/web?fieldManager=argus-editor",
+	}
+	if len(rec.paths) != 2 || rec.paths[0] != want[0] || rec.paths[1] != want[1] {
+		t.Errorf("expected a dry run then the patch, got %v", rec.paths)
+	}
+	if rec.contentTypes[1] != string(types.StrategicMergePatchType) {
+		t.Errorf("expected the patch type as content type, got %q", rec.contentTypes[1])
+	}
+}
+
+func TestPatchResource_DryRunOnly(t *testing.T) {
+	rec := &patchRecorder{}
+	req := PatchRequest{
+		Resource: schema.GroupVersionResource{Version: "v1", Resource: "nodes"},
+		Name:     "node-1",
+		Type:     types.JSONPatchType,
+		Patch:    []byte(`[{"op":"add","path":"/spec/unschedulable","value":true}]`),
+		DryRun:   true,
+	}
+	if _, err := PatchResource(context.Background(), rec, "c1", req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rec.paths) != 1 || rec.paths[0] != "/api/v1/nodes/node-1?dryRun=All" {
+		t.Errorf("expected only the dry run, got %v", rec.paths)
+	}
+}
+
+func TestPatchResource_InvalidPatchNotApplied(t *testing.T) {
+	invalid := &K8sRequestError{StatusCode: http.StatusUnprocessableEntity, Message: "spec.replicas: Invalid value: -1"}
+	rec := &patchRecorder{fail: map[string]error{
+		"/api/v1/namespaces/default/configmaps/settings?dryRun=All": invalid,
+	}}
+	req := PatchRequest{
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+		Namespace: "default",
+		Name:      "settings",
+		Type:      types.MergePatchType,
+		Patch:     []byte(`{"data":{"a":"b"}}`),
+	}
+	_, err := PatchResource(context.Background(), rec, "c1", req)
+	if !errors.Is(err, invalid) {
+		t.Fatalf("expected the dry run error, got %v", err)
+	}
+	if len(rec.paths) != 1 {
+		t.Errorf("expected the patch not to be applied after a failed dry run, got %v", rec.paths)
+	}
+
+	req.Type = types.ApplyPatchType
+	if _, err := PatchResource(context.Background(), rec, "c1", req); err == nil {
+		t.Error("expected server-side apply to be rejected")
+	}
+}
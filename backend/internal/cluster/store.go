@@ -2,8 +2,10 @@ package cluster
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -29,22 +31,47 @@ func (s *Store) CreateCluster(ctx context.Context, name, apiServerURL string, ku
 	return &c, nil
 }
 
+// UpsertInClusterCluster creates or refreshes the row for the cluster the
+// dashboard runs in. The name is only set on creation so a rename by an
+// admin survives restarts.
+func (s *Store) UpsertInClusterCluster(ctx context.Context, id, name, apiServerURL string) (*Cluster, error) {
+	var c Cluster
+	err := s.pool.QueryRow(ctx,
+		`INSERT INTO clusters (id, name, api_server_url, status, connection_type)
+		 VALUES ($1, $2, $3, 'disconnected', 'in_cluster')
+		 ON CONFLICT (id) DO UPDATE
+		   SET api_server_url = EXCLUDED.api_server_url,
+		       connection_type = EXCLUDED.connection_type
+		 RETURNING id, name, api_server_url, status, connection_type, agent_id, created_at`,
+		id, name, apiServerURL,
+	).Scan(&c.ID, &c.Name, &c.APIServerURL, &c.Status, &c.ConnectionType, &c.AgentID, &c.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register in-cluster cluster: %w", err)
+	}
+	return &c, nil
+}
+
 func (s *Store) GetCluster(ctx context.Context, id string) (*Cluster, error) {
 	var c Cluster
+	var maint maintenanceColumns
 	err := s.pool.QueryRow(ctx,
-		`SELECT id, name, api_server_url, status, connection_type, agent_id, created_at, last_health
+		`SELECT id, name, api_server_url, status, connection_type, agent_id, created_at, last_health,
+		        maintenance_since, maintenance_reason, maintenance_by
 		 FROM clusters WHERE id = $1`,
 		id,
-	).Scan(&c.ID, &c.Name, &c.APIServerURL, &c.Status, &c.ConnectionType, &c.AgentID, &c.CreatedAt, &c.LastHealth)
+	).Scan(&c.ID, &c.Name, &c.APIServerURL, &c.Status, &c.ConnectionType, &c.AgentID, &c.CreatedAt, &c.LastHealth,
+		&maint.since, &maint.reason, &maint.by)
 	if err != nil {
 		return nil, fmt.Errorf("cluster not found: %w", err)
 	}
+	c.Maintenance = maint.maintenance()
 	return &c, nil
 }
 
 func (s *Store) ListClusters(ctx context.Context) ([]*Cluster, error) {
 	rows, err := s.pool.Query(ctx,
-		`SELECT id, name, api_server_url, status, connection_type, agent_id, created_at, last_health
+		`SELECT id, name, api_server_url, status, connection_type, agent_id, created_at, last_health,
+		        maintenance_since, maintenance_reason, maintenance_by
 		 FROM clusters ORDER BY created_at DESC`,
 	)
 	if err != nil {
@@ -55,9 +82,12 @@ func (s *Store) ListClusters(ctx context.Context) ([]*Cluster, error) {
 	var clusters []*Cluster
 	for rows.Next() {
 		var c Cluster
-		if err := rows.Scan(&c.ID, &c.Name, &c.APIServerURL, &c.Status, &c.ConnectionType, &c.AgentID, &c.CreatedAt, &c.LastHealth); err != nil {
+		var maint maintenanceColumns
+		if err := rows.Scan(&c.ID, &c.Name, &c.APIServerURL, &c.Status, &c.ConnectionType, &c.AgentID, &c.CreatedAt, &c.LastHealth,
+			&maint.since, &maint.reason, &maint.by); err != nil {
 			return nil, fmt.Errorf("failed to scan cluster: %w", err)
 		}
+		c.Maintenance = maint.maintenance()
 		clusters = append(clusters, &c)
 	}
 	return clusters, rows.Err()
@@ -65,15 +95,19 @@ func (s *Store) ListClusters(ctx context.Context) ([]*Cluster, error) {
 
 func (s *Store) UpdateCluster(ctx context.Context, id, name, apiServerURL string) (*Cluster, error) {
 	var c Cluster
+	var maint maintenanceColumns
 	err := s.pool.QueryRow(ctx,
 		`UPDATE clusters SET name = $2, api_server_url = $3
 		 WHERE id = $1
-		 RETURNING id, name, api_server_url, status, connection_type, agent_id, created_at, last_health`,
+		 RETURNING id, name, api_server_url, status, connection_type, agent_id, created_at, last_health,
+		        maintenance_since, maintenance_reason, maintenance_by`,
 		id, name, apiServerURL,
-	).Scan(&c.ID, &c.Name, &c.APIServerURL, &c.Status, &c.ConnectionType, &c.AgentID, &c.CreatedAt, &c.LastHealth)
+	).Scan(&c.ID, &c.Name, &c.APIServerURL, &c.Status, &c.ConnectionType, &c.AgentID, &c.CreatedAt, &c.LastHealth,
+		&maint.since, &maint.reason, &maint.by)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update cluster: %w", err)
 	}
+	c.Maintenance = maint.maintenance()
 	return &c, nil
 }
 
@@ -95,3 +129,55 @@ func (s *Store) UpdateClusterStatus(ctx context.Context, id, status string) erro
 	}
 	return nil
 }
+
+// UpsertClusterInfo stores the latest summary an agent reported for its
+// cluster, stamped with the time it was received.
+func (s *Store) UpsertClusterInfo(ctx context.Context, id string, info *ClusterInfo) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO cluster_agent_info (cluster_id, kubernetes_version, platform, node_count,
+		     capacity_cpu, capacity_memory, capacity_pods,
+		     allocatable_cpu, allocatable_memory, allocatable_pods, agent_version, reported_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+		 ON CONFLICT (cluster_id) DO UPDATE SET
+		     kubernetes_version = EXCLUDED.kubernetes_version,
+		     platform = EXCLUDED.platform,
+		     node_count = EXCLUDED.node_count,
+		     capacity_cpu = EXCLUDED.capacity_cpu,
+		     capacity_memory = EXCLUDED.capacity_memory,
+		     capacity_pods = EXCLUDED.capacity_pods,
+		     allocatable_cpu = EXCLUDED.allocatable_cpu,
+		     allocatable_memory = EXCLUDED.allocatable_memory,
+		     allocatable_pods = EXCLUDED.allocatable_pods,
+		     agent_version = EXCLUDED.agent_version,
+		     reported_at = EXCLUDED.reported_at`,
+		id, info.KubernetesVersion, info.Platform, info.NodeCount,
+		info.Capacity.CPU, info.Capacity.Memory, info.Capacity.Pods,
+		info.Allocatable.CPU, info.Allocatable.Memory, info.Allocatable.Pods, info.AgentVersion,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store cluster info: %w", err)
+	}
+	return nil
+}
+
+// GetClusterInfo returns the latest agent-reported summary for a cluster, or
+// nil if its agent has not reported one yet.
+func (s *Store) GetClusterInfo(ctx context.Context, id string) (*ClusterInfo, error) {
+	var info ClusterInfo
+	err := s.pool.QueryRow(ctx,
+		`SELECT kubernetes_version, platform, node_count,
+		        capacity_cpu, capacity_memory, capacity_pods,
+		        allocatable_cpu, allocatable_memory, allocatable_pods, agent_version, reported_at
+		 FROM cluster_agent_info WHERE cluster_id = $1`,
+		id,
+	).Scan(&info.KubernetesVersion, &info.Platform, &info.NodeCount,
+		&info.Capacity.CPU, &info.Capacity.Memory, &info.Capacity.Pods,
+		&info.Allocatable.CPU, &info.Allocatable.Memory, &info.Allocatable.Pods, &info.AgentVersion, &info.ReportedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cluster info: %w", err)
+	}
+	return &info, nil
+}
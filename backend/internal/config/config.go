@@ -3,7 +3,13 @@ package config
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/darkden-lab/argus/backend/pkg/agentpb"
 )
 
 // Default values for dev secrets — used to detect unchanged defaults in production.
@@ -20,11 +26,35 @@ type Config struct {
 	JWTSecret      string
 	EncryptionKey  string
 	MigrationsPath string
+	// RunMigrations applies pending migrations at startup. Disable when
+	// migrations run as a separate job (server --migrate-only).
+	RunMigrations bool
+	// MigrationsFailFast exits on migration failure or schema version
+	// mismatch instead of running against a broken schema. Defaults to true
+	// in production.
+	MigrationsFailFast bool
+
+	// Database pool. Zero values keep the pgxpool defaults.
+	DBMaxConns        int32
+	DBMinConns        int32
+	DBMaxConnLifetime time.Duration
+	// DBConnectTimeout bounds how long startup retries the database connection.
+	DBConnectTimeout time.Duration
+
 	OIDCIssuer     string
 	OIDCClientID   string
 	OIDCClientSecret string
 	OIDCRedirectURL  string
 
+	// OIDCTokenDelivery is how the OIDC callback hands tokens to the
+	// frontend: "fragment" redirects with them in the URL fragment, "cookie"
+	// sets them as HttpOnly session cookies and redirects to a clean URL.
+	// The cookies are Secure unless AuthCookieSecure is false and use the
+	// AuthCookieSameSite mode ("lax" or "strict").
+	OIDCTokenDelivery  string
+	AuthCookieSecure   bool
+	AuthCookieSameSite string
+
 	// Kafka / Notifications
 	KafkaBrokers       string
 	KafkaConsumerGroup string
@@ -41,10 +71,100 @@ type Config struct {
 	// Security
 	AllowedOrigins string
 
+	// Outbound connections to Prometheus, notification webhooks, OIDC and
+	// AI providers: OutboundCAFile is a PEM bundle trusted in addition to
+	// the system roots, OutboundClientCert and OutboundClientKey a client
+	// certificate for mutual TLS, and OutboundProxy the proxy for requests
+	// to hosts not in OutboundNoProxy. Without OutboundProxy, the standard
+	// HTTPS_PROXY and NO_PROXY variables apply.
+	OutboundCAFile     string
+	OutboundClientCert string
+	OutboundClientKey  string
+	OutboundProxy      string
+	OutboundNoProxy    string
+
 	// gRPC (Cluster Agent)
 	GRPCPort    string
 	GRPCTLSCert string
 	GRPCTLSKey  string
+
+	// Agent version checks. Agents older than MinAgentVersion (the built-in
+	// minimum when empty) are logged and flagged on the cluster, or refused
+	// when AgentVersionPolicy is "reject".
+	MinAgentVersion    string
+	AgentVersionPolicy string
+
+	// AgentMaxInFlight caps the requests waiting on one agent at a time;
+	// further requests fail with 503 until some complete. 0 uses the
+	// built-in default.
+	AgentMaxInFlight int
+
+	// Agent install script access: "public" serves it to anyone, "signed"
+	// only through the expiring signed links in generated install commands,
+	// valid for AgentInstallLinkTTL and until the agent token is used.
+	AgentInstallScript  string
+	AgentInstallLinkTTL time.Duration
+
+	// In-cluster: register the cluster the dashboard runs in using its
+	// service account.
+	InClusterEnabled bool
+	InClusterName    string
+
+	// Manifest import (apply from URL). Manifests can only be fetched from
+	// URLs under ManifestAllowedURLs or from a named git source, which maps
+	// to the raw-file base URL of a repository at a ref.
+	ManifestAllowedURLs []string
+	ManifestGitSources  map[string]string
+	ManifestMaxBytes    int64
+
+	// Applies from the editor and the AI assistant. ApplyCreateNamespace
+	// creates a missing target namespace, for users allowed to create
+	// namespaces. ApplyLabels and ApplyAnnotations are added to applied
+	// objects; "{user}" in a value is replaced with the applying user's ID.
+	ApplyCreateNamespace bool
+	ApplyLabels          map[string]string
+	ApplyAnnotations     map[string]string
+
+	// DiscoveryCacheTTL bounds how long a cluster's API discovery result is
+	// shared by the resource explorer, RAG indexer, and kind resolution
+	// before it is fetched again.
+	DiscoveryCacheTTL time.Duration
+
+	// RBAC delegation to the clusters' own RBAC: "off", "cluster" (the
+	// cluster decides requests on its resources) or "both" (the dashboard
+	// and the cluster must both allow them). Decisions are cached for
+	// RBACDelegationTTL.
+	RBACDelegation    string
+	RBACDelegationTTL time.Duration
+
+	// Response redaction: mask Secret data and sensitive ConfigMap and
+	// annotation values in Kubernetes objects returned to users without the
+	// secrets:reveal permission. RedactKeys adds key substrings to the
+	// built-in sensitive list.
+	ResponseRedaction bool
+	RedactKeys        []string
+
+	// Audit log writes happen in the background through a queue of
+	// AuditQueueSize entries, or synchronously when it is 0. When the queue
+	// is full, AuditOverflow "drop" discards new entries and "block" makes
+	// the request wait up to AuditBlockTimeout for room before dropping.
+	// Dropped entries are counted in /metrics.
+	AuditQueueSize    int
+	AuditOverflow     string
+	AuditBlockTimeout time.Duration
+
+	// Notification digests: preferences with the frequency "digest" are
+	// sent at NotificationDigestFrequency ("hourly", "daily" or "weekly"),
+	// and events are grouped by NotificationDigestGroupBy ("category",
+	// "cluster", "namespace" or "severity") unless a preference chooses.
+	NotificationDigestFrequency string
+	NotificationDigestGroupBy   string
+
+	// Kubernetes Events become notifications when their type is in
+	// NotificationEventTypes ("Normal", "Warning"; empty means Warning only)
+	// and, if NotificationEventReasons is set, their reason is listed there.
+	NotificationEventTypes   []string
+	NotificationEventReasons []string
 }
 
 // Validate checks that production environments do not use default dev secrets.
@@ -61,6 +181,92 @@ func (c *Config) Validate() error {
 		{"DATABASE_URL", c.DatabaseURL, defaultDatabaseURL},
 	}
 
+	if c.DBMaxConns < 0 || c.DBMinConns < 0 {
+		return fmt.Errorf("config: DB_MAX_CONNS and DB_MIN_CONNS must not be negative")
+	}
+	if c.DBMaxConns > 0 && c.DBMinConns > c.DBMaxConns {
+		return fmt.Errorf("config: DB_MIN_CONNS (%d) must not exceed DB_MAX_CONNS (%d)", c.DBMinConns, c.DBMaxConns)
+	}
+
+	if c.ManifestMaxBytes < 0 {
+		return fmt.Errorf("config: MANIFEST_MAX_BYTES must not be negative")
+	}
+	for _, raw := range c.ManifestAllowedURLs {
+		if err := validateBaseURL(raw); err != nil {
+			return fmt.Errorf("config: MANIFEST_ALLOWED_URLS: %w", err)
+		}
+	}
+	for name, raw := range c.ManifestGitSources {
+		if name == "" {
+			return fmt.Errorf("config: MANIFEST_GIT_SOURCES: source name must not be empty")
+		}
+		if err := validateBaseURL(raw); err != nil {
+			return fmt.Errorf("config: MANIFEST_GIT_SOURCES: %s: %w", name, err)
+		}
+	}
+
+	if c.MinAgentVersion != "" {
+		if _, err := agentpb.ParseVersion(c.MinAgentVersion); err != nil {
+			return fmt.Errorf("config: MIN_AGENT_VERSION: %w", err)
+		}
+	}
+	switch c.AgentVersionPolicy {
+	case "", "warn", "reject":
+	default:
+		return fmt.Errorf("config: AGENT_VERSION_POLICY must be one of warn, reject")
+	}
+	if c.AgentMaxInFlight < 0 {
+		return fmt.Errorf("config: AGENT_MAX_INFLIGHT must not be negative")
+	}
+
+	switch c.AgentInstallScript {
+	case "", "public", "signed":
+	default:
+		return fmt.Errorf("config: AGENT_INSTALL_SCRIPT must be one of public, signed")
+	}
+
+	switch c.RBACDelegation {
+	case "", "off", "cluster", "both":
+	default:
+		return fmt.Errorf("config: RBAC_DELEGATION must be one of off, cluster, both")
+	}
+
+	if c.AuditQueueSize < 0 {
+		return fmt.Errorf("config: AUDIT_QUEUE_SIZE must not be negative")
+	}
+	switch c.OIDCTokenDelivery {
+	case "", "fragment", "cookie":
+	default:
+		return fmt.Errorf("config: OIDC_TOKEN_DELIVERY must be one of fragment, cookie")
+	}
+	switch c.AuthCookieSameSite {
+	case "", "lax", "strict":
+	default:
+		return fmt.Errorf("config: AUTH_COOKIE_SAMESITE must be one of lax, strict")
+	}
+
+	switch c.AuditOverflow {
+	case "", "drop", "block":
+	default:
+		return fmt.Errorf("config: AUDIT_OVERFLOW must be one of drop, block")
+	}
+
+	switch c.NotificationDigestFrequency {
+	case "", "hourly", "daily", "weekly":
+	default:
+		return fmt.Errorf("config: NOTIFICATION_DIGEST_FREQUENCY must be one of hourly, daily, weekly")
+	}
+	switch c.NotificationDigestGroupBy {
+	case "", "category", "cluster", "namespace", "severity":
+	default:
+		return fmt.Errorf("config: NOTIFICATION_DIGEST_GROUP_BY must be one of category, cluster, namespace, severity")
+	}
+	for _, t := range c.NotificationEventTypes {
+		if !strings.EqualFold(t, "Normal") && !strings.EqualFold(t, "Warning") {
+			return fmt.Errorf("config: NOTIFICATION_EVENT_TYPES must list Normal or Warning")
+		}
+	}
+
 	isProduction := c.AppEnv == "production"
 	for _, ch := range checks {
 		if ch.value == ch.def {
@@ -74,18 +280,32 @@ func (c *Config) Validate() error {
 }
 
 func Load() *Config {
+	appEnv := getEnv("APP_ENV", "development")
 	return &Config{
-		AppEnv:         getEnv("APP_ENV", "development"),
+		AppEnv:         appEnv,
 		Port:           getEnv("PORT", "8080"),
 		DatabaseURL:    getEnv("DATABASE_URL", defaultDatabaseURL),
 		JWTSecret:      getEnv("JWT_SECRET", defaultJWTSecret),
 		EncryptionKey:  getEnv("ENCRYPTION_KEY", defaultEncryptionKey),
 		MigrationsPath:   getEnv("MIGRATIONS_PATH", "migrations"),
+
+		RunMigrations:      getEnvBool("RUN_MIGRATIONS", true),
+		MigrationsFailFast: getEnvBool("MIGRATIONS_FAIL_FAST", appEnv == "production"),
+
+		DBMaxConns:        int32(getEnvInt("DB_MAX_CONNS", 0)),
+		DBMinConns:        int32(getEnvInt("DB_MIN_CONNS", 0)),
+		DBMaxConnLifetime: getEnvDuration("DB_MAX_CONN_LIFETIME", 0),
+		DBConnectTimeout:  getEnvDuration("DB_CONNECT_TIMEOUT", 30*time.Second),
+
 		OIDCIssuer:       getEnv("OIDC_ISSUER", ""),
 		OIDCClientID:     getEnv("OIDC_CLIENT_ID", ""),
 		OIDCClientSecret: getEnv("OIDC_CLIENT_SECRET", ""),
 		OIDCRedirectURL:  getEnv("OIDC_REDIRECT_URL", "http://localhost:8080/api/auth/oidc/callback"),
 
+		OIDCTokenDelivery:  getEnv("OIDC_TOKEN_DELIVERY", "fragment"),
+		AuthCookieSecure:   getEnvBool("AUTH_COOKIE_SECURE", true),
+		AuthCookieSameSite: getEnv("AUTH_COOKIE_SAMESITE", "lax"),
+
 		KafkaBrokers:       getEnv("KAFKA_BROKERS", ""),
 		KafkaConsumerGroup: getEnv("KAFKA_CONSUMER_GROUP", "argus-notifications"),
 		SMTPHost:           getEnv("SMTP_HOST", ""),
@@ -98,10 +318,64 @@ func Load() *Config {
 		FrontendURL:    getEnv("FRONTEND_URL", "http://localhost:3000"),
 		AllowedOrigins: getEnv("ALLOWED_ORIGINS", "http://localhost:3000"),
 
+		OutboundCAFile:     getEnv("OUTBOUND_CA_FILE", ""),
+		OutboundClientCert: getEnv("OUTBOUND_CLIENT_CERT", ""),
+		OutboundClientKey:  getEnv("OUTBOUND_CLIENT_KEY", ""),
+		OutboundProxy:      getEnv("OUTBOUND_PROXY", ""),
+		OutboundNoProxy:    getEnv("OUTBOUND_NO_PROXY", ""),
+
 		GRPCPort:    getEnv("GRPC_PORT", "9090"),
 		GRPCTLSCert: getEnv("GRPC_TLS_CERT", ""),
 		GRPCTLSKey:  getEnv("GRPC_TLS_KEY", ""),
+
+		MinAgentVersion:    getEnv("MIN_AGENT_VERSION", ""),
+		AgentVersionPolicy: getEnv("AGENT_VERSION_POLICY", "warn"),
+		AgentMaxInFlight:   getEnvInt("AGENT_MAX_INFLIGHT", 256),
+
+		AgentInstallScript:  getEnv("AGENT_INSTALL_SCRIPT", "public"),
+		AgentInstallLinkTTL: getEnvDuration("AGENT_INSTALL_LINK_TTL", time.Hour),
+
+		InClusterEnabled: getEnvBool("IN_CLUSTER_ENABLED", false),
+		InClusterName:    getEnv("IN_CLUSTER_NAME", "local"),
+
+		ManifestAllowedURLs: getEnvList("MANIFEST_ALLOWED_URLS"),
+		ManifestGitSources:  getEnvMap("MANIFEST_GIT_SOURCES"),
+		ManifestMaxBytes:    int64(getEnvInt("MANIFEST_MAX_BYTES", 1<<20)),
+
+		ApplyCreateNamespace: getEnvBool("APPLY_CREATE_NAMESPACE", false),
+		ApplyLabels:          getEnvMap("APPLY_LABELS"),
+		ApplyAnnotations:     getEnvMap("APPLY_ANNOTATIONS"),
+
+		DiscoveryCacheTTL: getEnvDuration("DISCOVERY_CACHE_TTL", 10*time.Minute),
+
+		RBACDelegation:    getEnv("RBAC_DELEGATION", "off"),
+		RBACDelegationTTL: getEnvDuration("RBAC_DELEGATION_TTL", 30*time.Second),
+
+		ResponseRedaction: getEnvBool("RESPONSE_REDACTION", false),
+		RedactKeys:        getEnvList("REDACT_KEYS"),
+
+		AuditQueueSize:    getEnvInt("AUDIT_QUEUE_SIZE", 1000),
+		AuditOverflow:     getEnv("AUDIT_OVERFLOW", "drop"),
+		AuditBlockTimeout: getEnvDuration("AUDIT_BLOCK_TIMEOUT", 100*time.Millisecond),
+
+		NotificationDigestFrequency: getEnv("NOTIFICATION_DIGEST_FREQUENCY", "daily"),
+		NotificationDigestGroupBy:   getEnv("NOTIFICATION_DIGEST_GROUP_BY", "category"),
+
+		NotificationEventTypes:   getEnvList("NOTIFICATION_EVENT_TYPES"),
+		NotificationEventReasons: getEnvList("NOTIFICATION_EVENT_REASONS"),
+	}
+}
+
+// validateBaseURL checks that raw is an absolute http(s) URL with a host.
+func validateBaseURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("%q is not an absolute http(s) URL", raw)
 	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("%q must not carry credentials, a query or a fragment", raw)
+	}
+	return nil
 }
 
 func getEnv(key, fallback string) string {
@@ -110,3 +384,75 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvList parses a comma-separated environment variable, dropping empty
+// entries.
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvMap parses a comma-separated list of key=value pairs. Malformed
+// entries are skipped with a warning.
+func getEnvMap(key string) map[string]string {
+	m := make(map[string]string)
+	for _, item := range getEnvList(key) {
+		k, v, ok := strings.Cut(item, "=")
+		if !ok {
+			log.Printf("WARNING: ignoring malformed %s entry %q (want name=value)", key, item)
+			continue
+		}
+		m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return m
+}
+
+// getEnvInt parses an integer environment variable, falling back (with a
+// warning) if it is unset or malformed.
+func getEnvInt(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("WARNING: invalid %s=%q, using default %d", key, v, fallback)
+		return fallback
+	}
+	return n
+}
+
+// getEnvDuration parses a Go duration environment variable (e.g. "30s", "1h"),
+// falling back (with a warning) if it is unset or malformed.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		log.Printf("WARNING: invalid %s=%q, using default %s", key, v, fallback)
+		return fallback
+	}
+	return d
+}
+
+// getEnvBool parses a boolean environment variable ("true", "1", "false",
+// ...), falling back (with a warning) if it is unset or malformed.
+func getEnvBool(key string, fallback bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("WARNING: invalid %s=%q, using default %t", key, v, fallback)
+		return fallback
+	}
+	return b
+}
@@ -0,0 +1,411 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/darkden-lab/argus/backend/internal/audit"
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+// Sources of activity feed items.
+const (
+	activitySourceAudit = "audit"
+	activitySourceEvent = "event"
+	activitySourceHelm  = "helm"
+)
+
+var activitySources = []string{activitySourceAudit, activitySourceEvent, activitySourceHelm}
+
+const (
+	defaultActivityWindow = 24 * time.Hour
+	maxActivityWindow     = 7 * 24 * time.Hour
+
+	defaultActivityItems = 100
+	maxActivityItems     = 500
+
+	// activityAuditPage is the most audit entries the audit store returns
+	// for one query.
+	activityAuditPage = 100
+
+	// helmReleaseSelector selects the secrets Helm's default storage driver
+	// keeps release revisions in, one secret per revision.
+	helmReleaseSelector = "owner=helm"
+)
+
+var secretsGVR = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+// ActivityItem is one change or occurrence in a cluster's activity feed.
+type ActivityItem struct {
+	Time   time.Time `json:"time"`
+	Source string    `json:"source"`
+	// Namespace is empty for cluster-scoped changes and audit entries whose
+	// namespace is unknown.
+	Namespace string `json:"namespace,omitempty"`
+	// UserID and User name who made an audited change. Events and Helm
+	// revisions have no user.
+	UserID string `json:"userId,omitempty"`
+	User   string `json:"user,omitempty"`
+	// Kind and Name identify the object an event involves or the Helm
+	// release a revision belongs to.
+	Kind string `json:"kind,omitempty"`
+	Name string `json:"name,omitempty"`
+	// Type is the event type (Normal, Warning) or the Helm release status.
+	Type    string `json:"type,omitempty"`
+	Summary string `json:"summary"`
+}
+
+// activityResponse is the activity feed, newest first. Errors maps a source
+// to why it could not be read; the other sources are still returned.
+type activityResponse struct {
+	Items  []ActivityItem    `json:"items"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// activityAuditLog is where the feed reads changes made through the
+// dashboard. Implemented by *audit.Store.
+type activityAuditLog interface {
+	List(ctx context.Context, params audit.ListParams) ([]audit.Entry, int, error)
+}
+
+// ActivityHandler merges what changed on a cluster, from the audit log,
+// Kubernetes events and Helm release history, into one timeline.
+type ActivityHandler struct {
+	clusterMgr *cluster.Manager
+	rbacEngine *rbac.Engine
+	auditLog   activityAuditLog
+}
+
+// NewActivityHandler creates a new ActivityHandler.
+func NewActivityHandler(cm *cluster.Manager, engine *rbac.Engine) *ActivityHandler {
+	return &ActivityHandler{clusterMgr: cm, rbacEngine: engine}
+}
+
+// SetAuditLog adds the changes recorded in the audit log to the feed.
+// Without it the audit source is reported as unavailable.
+func (h *ActivityHandler) SetAuditLog(auditLog activityAuditLog) {
+	h.auditLog = auditLog
+}
+
+// RegisterRoutes wires the activity feed endpoint.
+func (h *ActivityHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clusters/{clusterID}/activity", h.List).Methods(http.MethodGet)
+}
+
+// activityQuery is the parsed query of the activity endpoint.
+type activityQuery struct {
+	sources   map[string]bool
+	namespace string
+	userID    string
+	since     time.Time
+	limit     int
+}
+
+// parseActivityQuery reads the feed filters from the query. It returns a
+// message describing the first invalid parameter, if any.
+func parseActivityQuery(q url.Values, now time.Time) (activityQuery, string) {
+	aq := activityQuery{
+		sources:   make(map[string]bool),
+		namespace: q.Get("namespace"),
+		userID:    q.Get("user"),
+		since:     now.Add(-defaultActivityWindow),
+		limit:     defaultActivityItems,
+	}
+
+	if v := q.Get("source"); v != "" {
+		for _, s := range strings.Split(v, ",") {
+			s = strings.ToLower(strings.TrimSpace(s))
+			switch s {
+			case activitySourceAudit, activitySourceEvent, activitySourceHelm:
+				aq.sources[s] = true
+			default:
+				return aq, fmt.Sprintf("unknown source %q; use %s", s, strings.Join(activitySources, ", "))
+			}
+		}
+	} else {
+		for _, s := range activitySources {
+			aq.sources[s] = true
+		}
+	}
+	if err := validate.Namespace(aq.namespace); err != nil {
+		return aq, err.Error()
+	}
+	if aq.userID != "" {
+		if _, err := uuid.Parse(aq.userID); err != nil {
+			return aq, "user must be a user ID"
+		}
+	}
+	if v := q.Get("since"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 || d > maxActivityWindow {
+			return aq, fmt.Sprintf("since must be a duration up to %s, e.g. 6h", maxActivityWindow)
+		}
+		aq.since = now.Add(-d)
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxActivityItems {
+			return aq, fmt.Sprintf("limit must be between 1 and %d", maxActivityItems)
+		}
+		aq.limit = n
+	}
+	return aq, ""
+}
+
+// List returns the cluster's recent activity, newest first: changes made
+// through the dashboard (audit), Kubernetes events (event) and Helm release
+// revisions (helm). Events and Helm revisions are limited to namespaces the
+// caller may read events and secrets in; the audit source needs audit:read.
+// A source that cannot be read is reported in errors.
+//
+// Query parameters:
+//   - source: comma-separated sources to include (default all)
+//   - namespace: only activity in this namespace
+//   - user: only changes made by this user ID, which leaves only audit entries
+//   - since: how far back to look, as a duration (default 24h, max 168h)
+//   - limit: maximum number of items (default 100, max 500)
+func (h *ActivityHandler) List(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	clusterID := mux.Vars(r)["clusterID"]
+	q, msg := parseActivityQuery(r.URL.Query(), time.Now())
+	if msg != "" {
+		httputil.WriteError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	resp := activityResponse{}
+	var lists [][]ActivityItem
+	collect := func(source string, items []ActivityItem, err error) {
+		if err != nil {
+			if resp.Errors == nil {
+				resp.Errors = make(map[string]string)
+			}
+			resp.Errors[source] = err.Error()
+			return
+		}
+		lists = append(lists, items)
+	}
+
+	ctx := r.Context()
+	if q.sources[activitySourceAudit] {
+		items, err := h.auditActivity(ctx, claims.UserID, clusterID, q)
+		collect(activitySourceAudit, items, err)
+	}
+	// Events and Helm revisions have no user, so a user filter leaves them out.
+	if q.userID == "" {
+		access := newReadAccess(h.rbacEngine, claims.UserID, clusterID)
+		if q.sources[activitySourceEvent] {
+			events, err := h.listReadable(ctx, clusterID, eventsGVR, q.namespace, "", access)
+			collect(activitySourceEvent, eventActivity(events, q.since), err)
+		}
+		if q.sources[activitySourceHelm] {
+			secrets, err := h.listReadable(ctx, clusterID, secretsGVR, q.namespace, helmReleaseSelector, access)
+			collect(activitySourceHelm, helmActivity(secrets, q.since), err)
+		}
+	}
+
+	resp.Items = mergeActivity(lists, q.limit)
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+// auditActivity reads the dashboard changes made on the cluster since
+// q.since, if the caller may read the audit log.
+func (h *ActivityHandler) auditActivity(ctx context.Context, callerID, clusterID string, q activityQuery) ([]ActivityItem, error) {
+	if h.auditLog == nil {
+		return nil, errors.New("audit log is not available")
+	}
+	allowed, err := h.rbacEngine.Evaluate(ctx, rbac.Request{UserID: callerID, Action: "read", Resource: "audit"})
+	if err != nil {
+		return nil, errors.New("permission check failed")
+	}
+	if !allowed {
+		return nil, errors.New("insufficient permissions")
+	}
+
+	entries, _, err := h.auditLog.List(ctx, audit.ListParams{
+		ClusterID: clusterID,
+		UserID:    q.userID,
+		FromDate:  q.since.Format(time.RFC3339),
+		Limit:     activityAuditPage,
+	})
+	if err != nil {
+		log.Printf("activity: failed to read audit log for cluster %s: %v", clusterID, err)
+		return nil, errors.New("failed to read the audit log")
+	}
+	return auditActivity(entries, q.namespace), nil
+}
+
+// listReadable lists objects of gvr in namespace, or in all namespaces when
+// it is empty, keeping those in namespaces the caller may read.
+func (h *ActivityHandler) listReadable(ctx context.Context, clusterID string, gvr schema.GroupVersionResource, namespace, labelSelector string, access *readAccess) ([]unstructured.Unstructured, error) {
+	if namespace != "" {
+		allowed, err := access.allowed(ctx, gvr, namespace)
+		if err != nil {
+			return nil, errors.New("permission check failed")
+		}
+		if !allowed {
+			return nil, errors.New("insufficient permissions")
+		}
+	}
+
+	backend, err := resourceBackendFor(h.clusterMgr, clusterID, gvr)
+	if err != nil {
+		return nil, err
+	}
+	items, err := backend.list(ctx, namespace, labelSelector, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+	}
+
+	readable := items[:0]
+	for _, item := range items {
+		allowed, err := access.allowed(ctx, gvr, item.GetNamespace())
+		if err != nil {
+			return nil, errors.New("permission check failed")
+		}
+		if allowed {
+			readable = append(readable, item)
+		}
+	}
+	return readable, nil
+}
+
+// auditActivity turns audit entries into feed items, keeping those in
+// namespace when it is set.
+func auditActivity(entries []audit.Entry, namespace string) []ActivityItem {
+	items := make([]ActivityItem, 0, len(entries))
+	for _, e := range entries {
+		ns := auditNamespace(e)
+		if namespace != "" && ns != namespace {
+			continue
+		}
+		item := ActivityItem{Time: e.Timestamp, Source: activitySourceAudit, Namespace: ns, Summary: e.Action}
+		if e.UserID != nil {
+			item.UserID = *e.UserID
+		}
+		switch {
+		case e.Username != nil:
+			item.User = *e.Username
+		case e.ServiceAccountName != nil:
+			item.User = *e.ServiceAccountName
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// auditNamespace returns the namespace an audited request acted in: the
+// namespace query parameter the audit middleware records, or else a
+// namespaces/{name} segment of the path. It is "" when neither is present.
+func auditNamespace(e audit.Entry) string {
+	var details struct {
+		Namespace string `json:"namespace"`
+	}
+	if json.Unmarshal(e.Details, &details) == nil && details.Namespace != "" {
+		return details.Namespace
+	}
+	segments := strings.Split(e.Resource, "/")
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i] == "namespaces" && segments[i+1] != "" {
+			return segments[i+1]
+		}
+	}
+	return ""
+}
+
+// eventActivity turns events last seen since since into feed items.
+func eventActivity(items []unstructured.Unstructured, since time.Time) []ActivityItem {
+	activity := make([]ActivityItem, 0, len(items))
+	for i := range items {
+		var ev corev1.Event
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(items[i].Object, &ev); err != nil {
+			continue
+		}
+		summary := summarizeEvent(&ev)
+		if summary.LastSeen.Before(since) {
+			continue
+		}
+		text := summary.Reason
+		if summary.Message != "" {
+			text += ": " + summary.Message
+		}
+		activity = append(activity, ActivityItem{
+			Time:      summary.LastSeen,
+			Source:    activitySourceEvent,
+			Namespace: ev.Namespace,
+			Kind:      ev.InvolvedObject.Kind,
+			Name:      ev.InvolvedObject.Name,
+			Type:      summary.Type,
+			Summary:   text,
+		})
+	}
+	return activity
+}
+
+// helmActivity turns Helm release secrets created since since into feed
+// items, one per revision. Only the secrets' labels are read, not the
+// release payload.
+func helmActivity(secrets []unstructured.Unstructured, since time.Time) []ActivityItem {
+	activity := make([]ActivityItem, 0, len(secrets))
+	for _, s := range secrets {
+		created := s.GetCreationTimestamp().Time
+		if created.Before(since) {
+			continue
+		}
+		labels := s.GetLabels()
+		name, revision, status := labels["name"], labels["version"], labels["status"]
+		if name == "" {
+			continue
+		}
+		activity = append(activity, ActivityItem{
+			Time:      created,
+			Source:    activitySourceHelm,
+			Namespace: s.GetNamespace(),
+			Kind:      "HelmRelease",
+			Name:      name,
+			Type:      status,
+			Summary:   fmt.Sprintf("Release %s revision %s (%s)", name, revision, status),
+		})
+	}
+	return activity
+}
+
+// mergeActivity combines the sources' items, newest first, keeping at most
+// limit.
+func mergeActivity(lists [][]ActivityItem, limit int) []ActivityItem {
+	merged := []ActivityItem{}
+	for _, l := range lists {
+		merged = append(merged, l...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Time.After(merged[j].Time)
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged
+}
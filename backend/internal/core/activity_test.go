@@ -0,0 +1,154 @@
+package core
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/darkden-lab/argus/backend/internal/audit"
+)
+
+func TestParseActivityQuery(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	q, msg := parseActivityQuery(url.Values{}, now)
+	if msg != "" || len(q.sources) != 3 || q.limit != defaultActivityItems || !q.since.Equal(now.Add(-24*time.Hour)) {
+		t.Errorf("unexpected defaults %+v %q", q, msg)
+	}
+
+	q, msg = parseActivityQuery(url.Values{
+		"source":    {"Event, helm"},
+		"namespace": {"shop"},
+		"since":     {"6h"},
+		"limit":     {"20"},
+	}, now)
+	if msg != "" || q.sources[activitySourceAudit] || !q.sources[activitySourceEvent] || !q.sources[activitySourceHelm] ||
+		q.namespace != "shop" || !q.since.Equal(now.Add(-6*time.Hour)) || q.limit != 20 {
+		t.Errorf("unexpected query %+v %q", q, msg)
+	}
+
+	for _, bad := range []url.Values{
+		{"source": {"logs"}},
+		{"namespace": {"a/b"}},
+		{"user": {"alice"}},
+		{"since": {"30d"}},
+		{"since": {"200h"}},
+		{"limit": {"0"}},
+	} {
+		if _, msg := parseActivityQuery(bad, now); msg == "" {
+			t.Errorf("expected %v to be refused", bad)
+		}
+	}
+}
+
+func TestAuditActivity(t *testing.T) {
+	userID, username := "u1", "Alice"
+	entries := []audit.Entry{
+		{
+			UserID: &userID, Username: &username,
+			Action:    "delete /api/clusters/c1/resources/apps/v1/deployments/web",
+			Resource:  "/api/clusters/c1/resources/apps/v1/deployments/web",
+			Details:   json.RawMessage(`{"method":"DELETE","namespace":"shop"}`),
+			Timestamp: time.Unix(300, 0),
+		},
+		{
+			Action:    "post /api/proxy/k8s/c1/api/v1/namespaces/batch/pods",
+			Resource:  "/api/proxy/k8s/c1/api/v1/namespaces/batch/pods",
+			Details:   json.RawMessage(`{"method":"POST"}`),
+			Timestamp: time.Unix(200, 0),
+		},
+		{
+			Action:    "post /api/clusters/c1/maintenance",
+			Resource:  "/api/clusters/c1/maintenance",
+			Details:   json.RawMessage(`{}`),
+			Timestamp: time.Unix(100, 0),
+		},
+	}
+
+	items := auditActivity(entries, "")
+	if len(items) != 3 || items[0].Namespace != "shop" || items[0].User != "Alice" || items[0].UserID != "u1" ||
+		items[1].Namespace != "batch" || items[2].Namespace != "" || items[0].Source != activitySourceAudit {
+		t.Fatalf("unexpected items %+v", items)
+	}
+	if items := auditActivity(entries, "batch"); len(items) != 1 || !strings.HasPrefix(items[0].Summary, "post /api/proxy") {
+		t.Errorf("expected only the batch entry, got %+v", items)
+	}
+}
+
+func TestEventActivity(t *testing.T) {
+	events := []unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"metadata":       map[string]interface{}{"name": "web.1", "namespace": "shop"},
+			"involvedObject": map[string]interface{}{"kind": "Pod", "name": "web-0"},
+			"type":           "Warning",
+			"reason":         "BackOff",
+			"message":        "Back-off restarting failed container",
+			"lastTimestamp":  "2026-03-01T11:00:00Z",
+		}},
+		{Object: map[string]interface{}{
+			"metadata":       map[string]interface{}{"name": "web.2", "namespace": "shop"},
+			"involvedObject": map[string]interface{}{"kind": "Pod", "name": "web-0"},
+			"type":           "Normal",
+			"reason":         "Pulled",
+			"lastTimestamp":  "2026-02-01T11:00:00Z",
+		}},
+	}
+
+	items := eventActivity(events, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	if len(items) != 1 {
+		t.Fatalf("expected the old event to be dropped, got %+v", items)
+	}
+	item := items[0]
+	if item.Kind != "Pod" || item.Name != "web-0" || item.Type != "Warning" || item.Namespace != "shop" ||
+		item.Summary != "BackOff: Back-off restarting failed container" || item.Time.Hour() != 11 {
+		t.Errorf("unexpected item %+v", item)
+	}
+}
+
+func TestHelmActivity(t *testing.T) {
+	secret := func(name, version, status, created string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":              "sh.helm.release.v1." + name + ".v" + version,
+				"namespace":         "shop",
+				"creationTimestamp": created,
+				"labels":            map[string]interface{}{"owner": "helm", "name": name, "version": version, "status": status},
+			},
+		}}
+	}
+	items := helmActivity([]unstructured.Unstructured{
+		secret("web", "3", "deployed", "2026-03-01T10:00:00Z"),
+		secret("web", "2", "superseded", "2026-02-01T10:00:00Z"),
+	}, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	if len(items) != 1 || items[0].Name != "web" || items[0].Type != "deployed" || items[0].Kind != "HelmRelease" ||
+		items[0].Summary != "Release web revision 3 (deployed)" {
+		t.Errorf("unexpected items %+v", items)
+	}
+}
+
+func TestMergeActivity(t *testing.T) {
+	at := func(source string, sec int64) ActivityItem {
+		return ActivityItem{Source: source, Time: time.Unix(sec, 0)}
+	}
+	merged := mergeActivity([][]ActivityItem{
+		{at("audit", 3), at("audit", 1)},
+		{at("event", 4), at("event", 2)},
+		{at("helm", 5)},
+	}, 4)
+
+	var got []string
+	for _, item := range merged {
+		got = append(got, item.Source)
+	}
+	if strings.Join(got, ",") != "helm,event,audit,event" {
+		t.Errorf("unexpected order %v", got)
+	}
+	if merged := mergeActivity(nil, 10); merged == nil || len(merged) != 0 {
+		t.Errorf("expected an empty feed, got %v", merged)
+	}
+}
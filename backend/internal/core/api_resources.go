@@ -1,13 +1,11 @@
 package core
 
 import (
-	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/darkden-lab/argus/backend/internal/httputil"
-	"github.com/darkden-lab/argus/backend/pkg/agentpb"
 )
 
 // APIResourceEntry represents a single API resource available in a cluster.
@@ -20,28 +18,17 @@ type APIResourceEntry struct {
 	Verbs      []string `json:"verbs"`
 }
 
-// ListAPIResources returns all preferred API resources discovered in the cluster.
+// ListAPIResources returns all preferred API resources discovered in the
+// cluster, from the cluster manager's discovery cache.
 func (h *ConvenienceHandlers) ListAPIResources(w http.ResponseWriter, r *http.Request) {
 	clusterID := mux.Vars(r)["clusterID"]
 
-	client, err := h.clusterMgr.GetClient(clusterID)
+	d, err := h.clusterMgr.Discovery(r.Context(), clusterID)
 	if err != nil {
-		// Fallback to agent proxy — request /apis endpoint.
-		proxyAgentResponse(w, r, h.clusterMgr, clusterID, &agentpb.K8SRequest{
-			Method: "GET",
-			Path:   "/apis",
-		})
+		writeClusterError(w, err, "discovery failed")
 		return
 	}
-
-	lists, err := client.Clientset.Discovery().ServerPreferredResources()
-	if err != nil {
-		// Discovery may return partial results along with an error.
-		if lists == nil {
-			httputil.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("discovery failed: %v", err))
-			return
-		}
-	}
+	lists := d.PreferredResources()
 
 	var entries []APIResourceEntry
 	for _, list := range lists {
@@ -0,0 +1,499 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+	"github.com/darkden-lab/argus/backend/pkg/agentpb"
+)
+
+// maxBulkTargets caps how many objects a single bulk request may touch.
+const maxBulkTargets = 500
+
+// Bulk actions supported by the bulk operations endpoint.
+const (
+	bulkActionDelete  = "delete"
+	bulkActionRestart = "restart"
+	bulkActionScale   = "scale"
+)
+
+// Per-object outcome statuses reported by the bulk operations endpoint.
+const (
+	bulkStatusSucceeded = "succeeded"
+	bulkStatusFailed    = "failed"
+	bulkStatusForbidden = "forbidden"
+)
+
+// restartableResources lists the workload resources that support a rolling
+// restart via the kubectl.kubernetes.io/restartedAt pod template annotation.
+var restartableResources = map[string]bool{
+	"deployments":  true,
+	"statefulsets": true,
+	"daemonsets":   true,
+}
+
+// scalableResources lists the workload resources that expose spec.replicas.
+var scalableResources = map[string]bool{
+	"deployments":  true,
+	"statefulsets": true,
+	"replicasets":  true,
+}
+
+// BulkHandler applies a single action (delete, restart, scale) to every
+// object matching a label/field selector, checking RBAC per object.
+type BulkHandler struct {
+	clusterMgr *cluster.Manager
+	authz      rbac.Evaluator
+}
+
+// NewBulkHandler creates a new BulkHandler.
+func NewBulkHandler(cm *cluster.Manager, engine *rbac.Engine) *BulkHandler {
+	return &BulkHandler{clusterMgr: cm, authz: engine}
+}
+
+// RegisterRoutes wires the bulk operations and fleet restart endpoints.
+func (h *BulkHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clusters/rollout-restart", h.RestartFleet).Methods(http.MethodPost)
+	r.HandleFunc("/api/clusters/{clusterID}/bulk", h.Execute).Methods(http.MethodPost)
+}
+
+// bulkRequest is the JSON body accepted by the bulk operations endpoint.
+type bulkRequest struct {
+	Action        string `json:"action"`
+	Group         string `json:"group"`
+	Version       string `json:"version"`
+	Resource      string `json:"resource"`
+	Namespace     string `json:"namespace"`
+	LabelSelector string `json:"labelSelector"`
+	FieldSelector string `json:"fieldSelector"`
+	Replicas      *int32 `json:"replicas,omitempty"`
+	DryRun        bool   `json:"dryRun"`
+}
+
+// bulkItemResult is the outcome of applying the action to a single object.
+type bulkItemResult struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// bulkResponse is the aggregated result of a bulk operation.
+type bulkResponse struct {
+	Action    string           `json:"action"`
+	DryRun    bool             `json:"dryRun"`
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Forbidden int              `json:"forbidden"`
+	Results   []bulkItemResult `json:"results"`
+}
+
+// record appends a per-object result and updates the counters.
+func (b *bulkResponse) record(res bulkItemResult) {
+	b.Results = append(b.Results, res)
+	switch res.Status {
+	case bulkStatusSucceeded:
+		b.Succeeded++
+	case bulkStatusForbidden:
+		b.Forbidden++
+	default:
+		b.Failed++
+	}
+}
+
+// validate checks the request and normalises the group placeholder.
+func (req *bulkRequest) validate() error {
+	if req.Group == "_" {
+		req.Group = ""
+	}
+	if req.Version == "" || req.Resource == "" {
+		return fmt.Errorf("version and resource are required")
+	}
+	if !validate.Segment(req.Group) || !validate.Segment(req.Version) || !validate.Segment(req.Resource) {
+		return fmt.Errorf("invalid group, version, or resource")
+	}
+	if !validate.Segment(req.Namespace) {
+		return fmt.Errorf("invalid namespace")
+	}
+	if err := validate.LabelSelector(req.LabelSelector); err != nil {
+		return err
+	}
+	if err := validate.FieldSelector(req.FieldSelector); err != nil {
+		return err
+	}
+	if req.Namespace == "" && req.LabelSelector == "" && req.FieldSelector == "" {
+		return fmt.Errorf("at least one of namespace, labelSelector, or fieldSelector is required")
+	}
+
+	switch req.Action {
+	case bulkActionDelete:
+	case bulkActionRestart:
+		if !restartableResources[req.Resource] {
+			return fmt.Errorf("restart is only supported for deployments, statefulsets, and daemonsets")
+		}
+	case bulkActionScale:
+		if !scalableResources[req.Resource] {
+			return fmt.Errorf("scale is only supported for deployments, statefulsets, and replicasets")
+		}
+		if req.Replicas == nil || *req.Replicas < 0 {
+			return fmt.Errorf("replicas must be a non-negative integer for scale")
+		}
+	default:
+		return fmt.Errorf("action must be one of: delete, restart, scale")
+	}
+	return nil
+}
+
+// rbacAction maps a bulk action to the RBAC action checked per object.
+func (req *bulkRequest) rbacAction() string {
+	if req.Action == bulkActionDelete {
+		return "delete"
+	}
+	return "write"
+}
+
+// patchBody returns the merge patch applied for restart and scale actions.
+func (req *bulkRequest) patchBody(now time.Time) []byte {
+	switch req.Action {
+	case bulkActionRestart:
+		return []byte(fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{"kubectl.kubernetes.io/restartedAt":"%s"}}}}}`, now.Format(time.RFC3339)))
+	case bulkActionScale:
+		return []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, *req.Replicas))
+	default:
+		return nil
+	}
+}
+
+// Execute lists the objects matching the request's selectors and applies the
+// requested action to each one. With ?stream=true the per-object results are
+// streamed as SSE "progress" events followed by a final "done" event.
+func (h *BulkHandler) Execute(w http.ResponseWriter, r *http.Request) {
+	clusterID := mux.Vars(r)["clusterID"]
+
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req bulkRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize)).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := req.validate(); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The requested scope is checked before listing, so callers cannot use
+	// the results to enumerate objects they may not modify.
+	scopeAllowed, err := h.authorize(r.Context(), &req, claims.UserID, clusterID, req.Namespace)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+		return
+	}
+	if !scopeAllowed && req.Namespace != "" {
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+
+	gvr := schema.GroupVersionResource{Group: req.Group, Version: req.Version, Resource: req.Resource}
+	backend, err := resourceBackendFor(h.clusterMgr, clusterID, gvr)
+	if err != nil {
+		writeClusterError(w, err, "bulk operation failed")
+		return
+	}
+
+	items, err := backend.list(r.Context(), req.Namespace, req.LabelSelector, req.FieldSelector)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("failed to list %s: %v", req.Resource, err))
+		return
+	}
+
+	// Without cluster-wide permission, a selector across all namespaces acts
+	// only on the namespaces the caller may modify; the rest are counted but
+	// not named.
+	forbidden := 0
+	if !scopeAllowed {
+		items, forbidden, err = h.authorizedTargets(r.Context(), &req, claims.UserID, clusterID, items)
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+			return
+		}
+	}
+	if len(items) > maxBulkTargets {
+		httputil.WriteError(w, http.StatusBadRequest,
+			fmt.Sprintf("selector matches %d objects; narrow it to at most %d", len(items), maxBulkTargets))
+		return
+	}
+
+	resp := &bulkResponse{
+		Action:    req.Action,
+		DryRun:    req.DryRun,
+		Total:     len(items) + forbidden,
+		Forbidden: forbidden,
+		Results:   make([]bulkItemResult, 0, len(items)),
+	}
+
+	stream := r.URL.Query().Get("stream") == "true"
+	var flusher http.Flusher
+	if stream {
+		flusher, ok = w.(http.Flusher)
+		if !ok {
+			httputil.WriteError(w, http.StatusInternalServerError, "streaming not supported")
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	patch := req.patchBody(time.Now())
+	for _, item := range items {
+		if r.Context().Err() != nil {
+			break
+		}
+
+		res := h.apply(r.Context(), backend, &req, patch, item)
+		resp.record(res)
+
+		if stream {
+			writeSSEEvent(w, "progress", res)
+			flusher.Flush()
+		}
+	}
+
+	if stream {
+		writeSSEEvent(w, "done", resp)
+		flusher.Flush()
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+// authorize reports whether userID may apply req's action to objects in
+// namespace; an empty namespace checks cluster-wide permission.
+func (h *BulkHandler) authorize(ctx context.Context, req *bulkRequest, userID, clusterID, namespace string) (bool, error) {
+	return h.authz.Evaluate(ctx, rbac.Request{
+		UserID:      userID,
+		Action:      req.rbacAction(),
+		Resource:    rbac.ResourceCategory(req.Resource),
+		ClusterID:   clusterID,
+		Namespace:   namespace,
+		K8sResource: rbac.QualifiedResource(req.Group, req.Resource),
+	})
+}
+
+// authorizedTargets keeps the objects userID may apply req's action to and
+// returns how many were dropped. Each namespace is checked once.
+func (h *BulkHandler) authorizedTargets(ctx context.Context, req *bulkRequest, userID, clusterID string, items []unstructured.Unstructured) ([]unstructured.Unstructured, int, error) {
+	namespaces := make(map[string]bool)
+	allowedItems := make([]unstructured.Unstructured, 0, len(items))
+	for _, item := range items {
+		ns := item.GetNamespace()
+		allowed, ok := namespaces[ns]
+		if !ok {
+			var err error
+			if allowed, err = h.authorize(ctx, req, userID, clusterID, ns); err != nil {
+				return nil, 0, err
+			}
+			namespaces[ns] = allowed
+		}
+		if allowed {
+			allowedItems = append(allowedItems, item)
+		}
+	}
+	return allowedItems, len(items) - len(allowedItems), nil
+}
+
+// applyOne checks RBAC for a single object and applies the action to it.
+func (h *BulkHandler) applyOne(ctx context.Context, backend resourceBackend, req *bulkRequest, patch []byte, userID, clusterID string, item unstructured.Unstructured) bulkItemResult {
+	allowed, err := h.authorize(ctx, req, userID, clusterID, item.GetNamespace())
+	if err != nil {
+		return bulkItemResult{Name: item.GetName(), Namespace: item.GetNamespace(), Status: bulkStatusFailed, Error: "permission check failed"}
+	}
+	if !allowed {
+		return bulkItemResult{Name: item.GetName(), Namespace: item.GetNamespace(), Status: bulkStatusForbidden, Error: "insufficient permissions"}
+	}
+	return h.apply(ctx, backend, req, patch, item)
+}
+
+// apply applies the action to a single object the caller may modify.
+func (h *BulkHandler) apply(ctx context.Context, backend resourceBackend, req *bulkRequest, patch []byte, item unstructured.Unstructured) bulkItemResult {
+	res := bulkItemResult{Name: item.GetName(), Namespace: item.GetNamespace()}
+
+	var err error
+	if req.Action == bulkActionDelete {
+		err = backend.delete(ctx, item.GetNamespace(), item.GetName(), req.DryRun)
+	} else {
+		err = backend.patch(ctx, item.GetNamespace(), item.GetName(), patch, req.DryRun)
+	}
+	if err != nil {
+		res.Status = bulkStatusFailed
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Status = bulkStatusSucceeded
+	return res
+}
+
+// writeSSEEvent writes a single named SSE event with a JSON payload.
+func writeSSEEvent(w http.ResponseWriter, event string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
+
+// resourceBackend abstracts listing and mutating objects of a single resource
+// type so multi-object operations work for both kubeconfig (dynamic client)
+// and agent-connected clusters.
+type resourceBackend interface {
+	list(ctx context.Context, namespace, labelSelector, fieldSelector string) ([]unstructured.Unstructured, error)
+	delete(ctx context.Context, namespace, name string, dryRun bool) error
+	patch(ctx context.Context, namespace, name string, patch []byte, dryRun bool) error
+}
+
+// resourceBackendFor returns the resource backend for a cluster, preferring a
+// direct client and falling back to the gRPC agent.
+func resourceBackendFor(mgr *cluster.Manager, clusterID string, gvr schema.GroupVersionResource) (resourceBackend, error) {
+	client, err := mgr.GetClient(clusterID)
+	if err == nil {
+		return &dynamicBackend{client: client.DynClient, gvr: gvr}, nil
+	}
+
+	agentSrv := mgr.GetAgentServer()
+	if agentSrv == nil || !agentSrv.IsAgentConnected(clusterID) {
+		return nil, cluster.ErrClusterUnavailable
+	}
+	return &agentBackend{srv: agentSrv, clusterID: clusterID, gvr: gvr}, nil
+}
+
+type dynamicBackend struct {
+	client dynamic.Interface
+	gvr    schema.GroupVersionResource
+}
+
+func (b *dynamicBackend) list(ctx context.Context, namespace, labelSelector, fieldSelector string) ([]unstructured.Unstructured, error) {
+	list, err := b.client.Resource(b.gvr).Namespace(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector,
+		FieldSelector: fieldSelector,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (b *dynamicBackend) delete(ctx context.Context, namespace, name string, dryRun bool) error {
+	opts := metav1.DeleteOptions{}
+	if dryRun {
+		opts.DryRun = []string{metav1.DryRunAll}
+	}
+	return b.client.Resource(b.gvr).Namespace(namespace).Delete(ctx, name, opts)
+}
+
+func (b *dynamicBackend) patch(ctx context.Context, namespace, name string, patch []byte, dryRun bool) error {
+	opts := metav1.PatchOptions{}
+	if dryRun {
+		opts.DryRun = []string{metav1.DryRunAll}
+	}
+	_, err := b.client.Resource(b.gvr).Namespace(namespace).Patch(ctx, name, types.MergePatchType, patch, opts)
+	return err
+}
+
+type agentBackend struct {
+	srv       *cluster.AgentServer
+	clusterID string
+	gvr       schema.GroupVersionResource
+}
+
+// send issues a request through the agent with the standard proxy timeout and
+// converts non-2xx responses into errors.
+func (b *agentBackend) send(ctx context.Context, req *agentpb.K8SRequest) (*agentpb.K8SResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, agentProxyTimeout)
+	defer cancel()
+
+	resp, err := b.srv.SendK8sRequest(ctx, b.clusterID, req)
+	if err != nil {
+		return nil, fmt.Errorf("agent request failed: %w", err)
+	}
+	if err := cluster.ResponseError(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (b *agentBackend) list(ctx context.Context, namespace, labelSelector, fieldSelector string) ([]unstructured.Unstructured, error) {
+	params := map[string]string{}
+	if labelSelector != "" {
+		params["labelSelector"] = labelSelector
+	}
+	if fieldSelector != "" {
+		params["fieldSelector"] = fieldSelector
+	}
+
+	resp, err := b.send(ctx, &agentpb.K8SRequest{
+		Method:      "GET",
+		Path:        k8sAPIPath(b.gvr, namespace, ""),
+		QueryParams: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	listObj, err := parseUnstructured(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse list response: %w", err)
+	}
+	return extractItems(listObj), nil
+}
+
+func (b *agentBackend) delete(ctx context.Context, namespace, name string, dryRun bool) error {
+	_, err := b.send(ctx, &agentpb.K8SRequest{
+		Method:      "DELETE",
+		Path:        k8sAPIPath(b.gvr, namespace, name),
+		QueryParams: dryRunParams(dryRun),
+	})
+	return err
+}
+
+func (b *agentBackend) patch(ctx context.Context, namespace, name string, patch []byte, dryRun bool) error {
+	_, err := b.send(ctx, &agentpb.K8SRequest{
+		Method:      "PATCH",
+		Path:        k8sAPIPath(b.gvr, namespace, name),
+		Body:        patch,
+		Headers:     map[string]string{"Content-Type": string(types.MergePatchType)},
+		QueryParams: dryRunParams(dryRun),
+	})
+	return err
+}
+
+// dryRunParams returns the query parameters requesting a server-side dry run.
+func dryRunParams(dryRun bool) map[string]string {
+	if !dryRun {
+		return nil
+	}
+	return map[string]string{"dryRun": metav1.DryRunAll}
+}
@@ -0,0 +1,198 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+func int32Ptr(v int32) *int32 { return &v }
+
+func TestBulkRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     bulkRequest
+		wantErr string
+	}{
+		{
+			name: "delete pods by field selector",
+			req:  bulkRequest{Action: "delete", Group: "_", Version: "v1", Resource: "pods", FieldSelector: "status.phase=Failed"},
+		},
+		{
+			name: "restart deployments in namespace",
+			req:  bulkRequest{Action: "restart", Group: "apps", Version: "v1", Resource: "deployments", Namespace: "default"},
+		},
+		{
+			name: "scale statefulsets by label",
+			req:  bulkRequest{Action: "scale", Group: "apps", Version: "v1", Resource: "statefulsets", LabelSelector: "app=db", Replicas: int32Ptr(0)},
+		},
+		{
+			name:    "missing resource",
+			req:     bulkRequest{Action: "delete", Version: "v1", Namespace: "default"},
+			wantErr: "version and resource are required",
+		},
+		{
+			name:    "no scope",
+			req:     bulkRequest{Action: "delete", Version: "v1", Resource: "pods"},
+			wantErr: "at least one of",
+		},
+		{
+			name:    "unknown action",
+			req:     bulkRequest{Action: "drain", Version: "v1", Resource: "pods", Namespace: "default"},
+			wantErr: "action must be one of",
+		},
+		{
+			name:    "restart pods rejected",
+			req:     bulkRequest{Action: "restart", Version: "v1", Resource: "pods", Namespace: "default"},
+			wantErr: "restart is only supported",
+		},
+		{
+			name:    "scale without replicas",
+			req:     bulkRequest{Action: "scale", Group: "apps", Version: "v1", Resource: "deployments", Namespace: "default"},
+			wantErr: "replicas must be",
+		},
+		{
+			name:    "scale negative replicas",
+			req:     bulkRequest{Action: "scale", Group: "apps", Version: "v1", Resource: "deployments", Namespace: "default", Replicas: int32Ptr(-1)},
+			wantErr: "replicas must be",
+		},
+		{
+			name:    "path traversal namespace",
+			req:     bulkRequest{Action: "delete", Version: "v1", Resource: "pods", Namespace: "../kube-system"},
+			wantErr: "invalid namespace",
+		},
+		{
+			name:    "malformed label selector",
+			req:     bulkRequest{Action: "delete", Version: "v1", Resource: "pods", LabelSelector: "app in (web"},
+			wantErr: "invalid label selector",
+		},
+		{
+			name:    "malformed field selector",
+			req:     bulkRequest{Action: "delete", Version: "v1", Resource: "pods", FieldSelector: "status.phase"},
+			wantErr: "invalid field selector",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestBulkRequestValidate_CoreGroupPlaceholder(t *testing.T) {
+	req := bulkRequest{Action: "delete", Group: "_", Version: "v1", Resource: "pods", Namespace: "default"}
+	if err := req.validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Group != "" {
+		t.Errorf("expected core group placeholder to be normalised, got %q", req.Group)
+	}
+}
+
+func TestBulkRequestRBACAction(t *testing.T) {
+	if got := (&bulkRequest{Action: "delete"}).rbacAction(); got != "delete" {
+		t.Errorf("expected delete, got %q", got)
+	}
+	if got := (&bulkRequest{Action: "restart"}).rbacAction(); got != "write" {
+		t.Errorf("expected write for restart, got %q", got)
+	}
+	if got := (&bulkRequest{Action: "scale"}).rbacAction(); got != "write" {
+		t.Errorf("expected write for scale, got %q", got)
+	}
+}
+
+func TestBulkRequestPatchBody(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	restart := (&bulkRequest{Action: "restart"}).patchBody(now)
+	if !strings.Contains(string(restart), `"kubectl.kubernetes.io/restartedAt":"2025-01-02T03:04:05Z"`) {
+		t.Errorf("unexpected restart patch: %s", restart)
+	}
+
+	scale := (&bulkRequest{Action: "scale", Replicas: int32Ptr(3)}).patchBody(now)
+	if string(scale) != `{"spec":{"replicas":3}}` {
+		t.Errorf("unexpected scale patch: %s", scale)
+	}
+
+	if del := (&bulkRequest{Action: "delete"}).patchBody(now); del != nil {
+		t.Errorf("expected nil patch for delete, got %s", del)
+	}
+}
+
+// appsWriter grants write on the apps category in the listed namespaces.
+type appsWriter map[string]bool
+
+func (a appsWriter) Evaluate(ctx context.Context, req rbac.Request) (bool, error) {
+	return req.Action == "write" && req.Resource == "apps" && a[req.Namespace], nil
+}
+
+func TestBulkAuthorizedTargets(t *testing.T) {
+	h := &BulkHandler{authz: appsWriter{"team-a": true}}
+	req := &bulkRequest{Action: "restart", Group: "apps", Version: "v1", Resource: "deployments"}
+
+	if allowed, _ := h.authorize(context.Background(), req, "u1", "c1", ""); allowed {
+		t.Fatal("expected no cluster-wide permission")
+	}
+	if allowed, _ := h.authorize(context.Background(), req, "u1", "c1", "team-a"); !allowed {
+		t.Fatal("expected deployments to be checked against the apps category")
+	}
+
+	var items []unstructured.Unstructured
+	for _, ns := range []string{"team-a", "team-b", "team-a", "kube-system"} {
+		var u unstructured.Unstructured
+		u.SetNamespace(ns)
+		u.SetName("web")
+		items = append(items, u)
+	}
+	allowed, dropped, err := h.authorizedTargets(context.Background(), req, "u1", "c1", items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allowed) != 2 || dropped != 2 {
+		t.Fatalf("expected the 2 team-a objects and 2 dropped, got %d and %d", len(allowed), dropped)
+	}
+	for _, item := range allowed {
+		if item.GetNamespace() != "team-a" {
+			t.Errorf("expected only team-a objects, got %s", item.GetNamespace())
+		}
+	}
+}
+
+func TestBulkResponseRecord(t *testing.T) {
+	resp := &bulkResponse{}
+	resp.record(bulkItemResult{Name: "a", Status: bulkStatusSucceeded})
+	resp.record(bulkItemResult{Name: "b", Status: bulkStatusFailed})
+	resp.record(bulkItemResult{Name: "c", Status: bulkStatusForbidden})
+	resp.record(bulkItemResult{Name: "d", Status: bulkStatusSucceeded})
+
+	if resp.Succeeded != 2 || resp.Failed != 1 || resp.Forbidden != 1 {
+		t.Errorf("unexpected counters: %+v", resp)
+	}
+	if len(resp.Results) != 4 {
+		t.Errorf("expected 4 results, got %d", len(resp.Results))
+	}
+}
+
+func TestDryRunParams(t *testing.T) {
+	if p := dryRunParams(false); p != nil {
+		t.Errorf("expected nil params, got %v", p)
+	}
+	if p := dryRunParams(true); p["dryRun"] != "All" {
+		t.Errorf("expected dryRun=All, got %v", p)
+	}
+}
@@ -0,0 +1,487 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+// Workload provisioning statuses reported by the capacity endpoint.
+const (
+	provisioningOver    = "over-provisioned"
+	provisioningUnder   = "under-provisioned"
+	provisioningOK      = "ok"
+	provisioningUnknown = "unknown"
+)
+
+const (
+	// overProvisionedRatio flags workloads using less than this fraction of
+	// what they request.
+	overProvisionedRatio = 0.5
+	// underProvisionedLimitRatio flags workloads running within this fraction
+	// of their limit, where they risk throttling or OOM kills.
+	underProvisionedLimitRatio = 0.9
+
+	defaultCapacityWorkloads = 50
+	maxCapacityWorkloads     = 500
+)
+
+var (
+	podsGVR       = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	podMetricsGVR = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+)
+
+// CapacityHandler compares pod resource requests and limits with actual
+// usage from metrics.k8s.io to surface over- and under-provisioned workloads.
+type CapacityHandler struct {
+	clusterMgr *cluster.Manager
+	rbacEngine *rbac.Engine
+}
+
+// NewCapacityHandler creates a new CapacityHandler.
+func NewCapacityHandler(cm *cluster.Manager, engine *rbac.Engine) *CapacityHandler {
+	return &CapacityHandler{clusterMgr: cm, rbacEngine: engine}
+}
+
+// RegisterRoutes wires the capacity report endpoint.
+func (h *CapacityHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clusters/{clusterID}/capacity", h.GetCapacity).Methods(http.MethodGet)
+}
+
+// resourceTotals sums CPU (millicores) and memory (bytes) for one resource.
+// Usage is nil when metrics are unavailable.
+type resourceTotals struct {
+	Request int64  `json:"request"`
+	Limit   int64  `json:"limit"`
+	Usage   *int64 `json:"usage"`
+}
+
+func (t *resourceTotals) add(o resourceTotals) {
+	t.Request += o.Request
+	t.Limit += o.Limit
+	if o.Usage != nil {
+		u := *o.Usage
+		if t.Usage != nil {
+			u += *t.Usage
+		}
+		t.Usage = &u
+	}
+}
+
+// workloadCapacity is the rollup for one workload (Deployment, StatefulSet,
+// DaemonSet, Job, ...) or a bare pod.
+type workloadCapacity struct {
+	Namespace string         `json:"namespace"`
+	Kind      string         `json:"kind"`
+	Name      string         `json:"name"`
+	Pods      int            `json:"pods"`
+	CPU       resourceTotals `json:"cpu"`
+	Memory    resourceTotals `json:"memory"`
+	Status    string         `json:"status"`
+	// CPUSlack and MemorySlack are request minus usage: positive values are
+	// reserved but idle, negative values are usage above the request.
+	CPUSlack    *int64 `json:"cpuSlack,omitempty"`
+	MemorySlack *int64 `json:"memorySlack,omitempty"`
+}
+
+// namespaceCapacity is the rollup for one namespace.
+type namespaceCapacity struct {
+	Namespace        string         `json:"namespace"`
+	Pods             int            `json:"pods"`
+	Workloads        int            `json:"workloads"`
+	CPU              resourceTotals `json:"cpu"`
+	Memory           resourceTotals `json:"memory"`
+	OverProvisioned  int            `json:"overProvisioned"`
+	UnderProvisioned int            `json:"underProvisioned"`
+}
+
+// capacityResponse is the full capacity report. Workloads holds the biggest
+// offenders first and is capped by the limit query parameter.
+type capacityResponse struct {
+	ClusterID        string              `json:"clusterId"`
+	MetricsAvailable bool                `json:"metricsAvailable"`
+	MetricsError     string              `json:"metricsError,omitempty"`
+	Namespaces       []namespaceCapacity `json:"namespaces"`
+	Workloads        []workloadCapacity  `json:"workloads"`
+	TotalWorkloads   int                 `json:"totalWorkloads"`
+}
+
+// GetCapacity returns per-namespace and per-workload request/limit/usage
+// rollups. Only namespaces the caller may read pods in are included.
+//
+// Query parameters:
+//   - namespace: restrict the report to one namespace
+//   - limit: maximum number of workloads returned (default 50, max 500)
+func (h *CapacityHandler) GetCapacity(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	clusterID := mux.Vars(r)["clusterID"]
+	namespace := r.URL.Query().Get("namespace")
+	if namespace != "" && !validate.Segment(namespace) {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid namespace")
+		return
+	}
+	limit := defaultCapacityWorkloads
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			httputil.WriteError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = n
+	}
+	if limit > maxCapacityWorkloads {
+		limit = maxCapacityWorkloads
+	}
+
+	// Fail fast with 403 when the single requested namespace is not readable.
+	if namespace != "" {
+		allowed, err := h.canReadPods(r, claims.UserID, clusterID, namespace)
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+			return
+		}
+		if !allowed {
+			httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
+			return
+		}
+	}
+
+	podsBackend, err := resourceBackendFor(h.clusterMgr, clusterID, podsGVR)
+	if err != nil {
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	pods, err := podsBackend.list(r.Context(), namespace, "", "")
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("failed to list pods: %v", err))
+		return
+	}
+
+	// Filter to namespaces the caller may read, checking each one once.
+	readable := make(map[string]bool)
+	visible := pods[:0]
+	for _, pod := range pods {
+		ns := pod.GetNamespace()
+		allowed, checked := readable[ns]
+		if !checked {
+			allowed, err = h.canReadPods(r, claims.UserID, clusterID, ns)
+			if err != nil {
+				httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+				return
+			}
+			readable[ns] = allowed
+		}
+		if allowed {
+			visible = append(visible, pod)
+		}
+	}
+
+	resp := capacityResponse{ClusterID: clusterID}
+
+	var usage map[string]podUsage
+	metricsBackend, err := resourceBackendFor(h.clusterMgr, clusterID, podMetricsGVR)
+	if err == nil {
+		var metrics []unstructured.Unstructured
+		metrics, err = metricsBackend.list(r.Context(), namespace, "", "")
+		if err == nil {
+			usage = parsePodMetrics(metrics)
+			resp.MetricsAvailable = true
+		}
+	}
+	if err != nil {
+		// metrics-server is optional; report requests and limits without usage.
+		resp.MetricsError = fmt.Sprintf("metrics.k8s.io unavailable: %v", err)
+	}
+
+	workloads := aggregateWorkloads(visible, usage)
+	resp.Namespaces = aggregateNamespaces(workloads)
+	sortByOffence(workloads)
+	resp.TotalWorkloads = len(workloads)
+	if len(workloads) > limit {
+		workloads = workloads[:limit]
+	}
+	resp.Workloads = workloads
+
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *CapacityHandler) canReadPods(r *http.Request, userID, clusterID, namespace string) (bool, error) {
+	return h.rbacEngine.Evaluate(r.Context(), rbac.Request{
+		UserID:      userID,
+		Action:      "read",
+		Resource:    rbac.ResourceCategory("pods"),
+		ClusterID:   clusterID,
+		Namespace:   namespace,
+		K8sResource: "pods",
+	})
+}
+
+// podUsage is the summed container usage of one pod.
+type podUsage struct {
+	CPU    int64
+	Memory int64
+}
+
+// parsePodMetrics indexes PodMetrics objects by namespace/name.
+func parsePodMetrics(items []unstructured.Unstructured) map[string]podUsage {
+	usage := make(map[string]podUsage, len(items))
+	for _, item := range items {
+		containers, _, _ := unstructured.NestedSlice(item.Object, "containers")
+		var u podUsage
+		for _, c := range containers {
+			cm, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			res, _, _ := unstructured.NestedStringMap(cm, "usage")
+			u.CPU += quantityMillis(res["cpu"])
+			u.Memory += quantityValue(res["memory"])
+		}
+		usage[item.GetNamespace()+"/"+item.GetName()] = u
+	}
+	return usage
+}
+
+// aggregateWorkloads rolls pods up to their owning workload. Completed pods
+// are skipped since they no longer hold resources.
+func aggregateWorkloads(pods []unstructured.Unstructured, usage map[string]podUsage) []workloadCapacity {
+	byKey := make(map[string]*workloadCapacity)
+	var order []string
+
+	for _, pod := range pods {
+		phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase")
+		if phase == "Succeeded" || phase == "Failed" {
+			continue
+		}
+
+		kind, name := podWorkload(pod)
+		key := pod.GetNamespace() + "/" + kind + "/" + name
+		wl, ok := byKey[key]
+		if !ok {
+			wl = &workloadCapacity{Namespace: pod.GetNamespace(), Kind: kind, Name: name}
+			byKey[key] = wl
+			order = append(order, key)
+		}
+		wl.Pods++
+
+		cpu, mem := podRequestsAndLimits(pod)
+		if usage != nil {
+			u := usage[pod.GetNamespace()+"/"+pod.GetName()]
+			cpu.Usage = &u.CPU
+			mem.Usage = &u.Memory
+		}
+		wl.CPU.add(cpu)
+		wl.Memory.add(mem)
+	}
+
+	workloads := make([]workloadCapacity, 0, len(order))
+	for _, key := range order {
+		wl := byKey[key]
+		wl.CPUSlack = slack(wl.CPU)
+		wl.MemorySlack = slack(wl.Memory)
+		wl.Status = provisioningStatus(wl.CPU, wl.Memory)
+		workloads = append(workloads, *wl)
+	}
+	return workloads
+}
+
+// podWorkload returns the kind and name of the workload that owns a pod.
+// Pods owned by a ReplicaSet are attributed to its Deployment by stripping
+// the pod-template-hash suffix from the ReplicaSet name.
+func podWorkload(pod unstructured.Unstructured) (string, string) {
+	for _, ref := range pod.GetOwnerReferences() {
+		if ref.Controller == nil || !*ref.Controller {
+			continue
+		}
+		if ref.Kind == "ReplicaSet" {
+			if hash := pod.GetLabels()["pod-template-hash"]; hash != "" && strings.HasSuffix(ref.Name, "-"+hash) {
+				return "Deployment", strings.TrimSuffix(ref.Name, "-"+hash)
+			}
+		}
+		return ref.Kind, ref.Name
+	}
+	return "Pod", pod.GetName()
+}
+
+// podRequestsAndLimits sums the container requests and limits of a pod.
+func podRequestsAndLimits(pod unstructured.Unstructured) (cpu, mem resourceTotals) {
+	containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", "containers")
+	for _, c := range containers {
+		cm, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		requests, _, _ := unstructured.NestedStringMap(cm, "resources", "requests")
+		limits, _, _ := unstructured.NestedStringMap(cm, "resources", "limits")
+		cpu.Request += quantityMillis(requests["cpu"])
+		cpu.Limit += quantityMillis(limits["cpu"])
+		mem.Request += quantityValue(requests["memory"])
+		mem.Limit += quantityValue(limits["memory"])
+	}
+	return cpu, mem
+}
+
+// aggregateNamespaces sums workload rollups per namespace, sorted by name.
+func aggregateNamespaces(workloads []workloadCapacity) []namespaceCapacity {
+	byNS := make(map[string]*namespaceCapacity)
+	for _, wl := range workloads {
+		ns, ok := byNS[wl.Namespace]
+		if !ok {
+			ns = &namespaceCapacity{Namespace: wl.Namespace}
+			byNS[wl.Namespace] = ns
+		}
+		ns.Pods += wl.Pods
+		ns.Workloads++
+		ns.CPU.add(wl.CPU)
+		ns.Memory.add(wl.Memory)
+		switch wl.Status {
+		case provisioningOver:
+			ns.OverProvisioned++
+		case provisioningUnder:
+			ns.UnderProvisioned++
+		}
+	}
+
+	result := make([]namespaceCapacity, 0, len(byNS))
+	for _, ns := range byNS {
+		result = append(result, *ns)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Namespace < result[j].Namespace })
+	return result
+}
+
+// provisioningStatus classifies a workload. It is under-provisioned if any
+// resource runs above its request or close to its limit, and over-provisioned
+// if every requested resource uses less than overProvisionedRatio of it.
+func provisioningStatus(cpu, mem resourceTotals) string {
+	if cpu.Usage == nil || mem.Usage == nil {
+		return provisioningUnknown
+	}
+
+	over := 0
+	requested := 0
+	for _, t := range []resourceTotals{cpu, mem} {
+		usage := float64(*t.Usage)
+		if t.Request > 0 && usage > float64(t.Request) {
+			return provisioningUnder
+		}
+		if t.Limit > 0 && usage >= underProvisionedLimitRatio*float64(t.Limit) {
+			return provisioningUnder
+		}
+		if t.Request > 0 {
+			requested++
+			if usage < overProvisionedRatio*float64(t.Request) {
+				over++
+			}
+		}
+	}
+	if requested > 0 && over == requested {
+		return provisioningOver
+	}
+	return provisioningOK
+}
+
+// sortByOffence orders workloads so the biggest offenders come first:
+// flagged workloads before the rest, then by relative deviation from the
+// request, with ties broken by namespace and name.
+func sortByOffence(workloads []workloadCapacity) {
+	rank := func(status string) int {
+		switch status {
+		case provisioningUnder, provisioningOver:
+			return 0
+		case provisioningOK:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(workloads, func(i, j int) bool {
+		a, b := workloads[i], workloads[j]
+		if ra, rb := rank(a.Status), rank(b.Status); ra != rb {
+			return ra < rb
+		}
+		if da, db := deviation(a), deviation(b); da != db {
+			return da > db
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+}
+
+// deviation is the larger of the CPU and memory distance between usage and
+// request, relative to the request. Without metrics it falls back to the
+// absolute CPU request so the heaviest reservations still sort first.
+func deviation(wl workloadCapacity) float64 {
+	if wl.CPU.Usage == nil || wl.Memory.Usage == nil {
+		return float64(wl.CPU.Request)
+	}
+	var d float64
+	for _, t := range []resourceTotals{wl.CPU, wl.Memory} {
+		if t.Request == 0 {
+			continue
+		}
+		rel := float64(*t.Usage-t.Request) / float64(t.Request)
+		if rel < 0 {
+			rel = -rel
+		}
+		if rel > d {
+			d = rel
+		}
+	}
+	return d
+}
+
+// slack returns request minus usage, or nil without usage or request.
+func slack(t resourceTotals) *int64 {
+	if t.Usage == nil || t.Request == 0 {
+		return nil
+	}
+	s := t.Request - *t.Usage
+	return &s
+}
+
+// quantityMillis parses a CPU quantity into millicores; invalid or empty
+// values count as zero.
+func quantityMillis(s string) int64 {
+	if s == "" {
+		return 0
+	}
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return 0
+	}
+	return q.MilliValue()
+}
+
+// quantityValue parses a memory quantity into bytes; invalid or empty values
+// count as zero.
+func quantityValue(s string) int64 {
+	if s == "" {
+		return 0
+	}
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return 0
+	}
+	return q.Value()
+}
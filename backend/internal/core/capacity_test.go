@@ -0,0 +1,189 @@
+package core
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func testPod(namespace, name, phase string, owner map[string]interface{}, labels map[string]interface{}, cpuReq, cpuLim, memReq, memLim string) unstructured.Unstructured {
+	metadata := map[string]interface{}{"name": name, "namespace": namespace}
+	if owner != nil {
+		metadata["ownerReferences"] = []interface{}{owner}
+	}
+	if labels != nil {
+		metadata["labels"] = labels
+	}
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": metadata,
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name": "app",
+					"resources": map[string]interface{}{
+						"requests": map[string]interface{}{"cpu": cpuReq, "memory": memReq},
+						"limits":   map[string]interface{}{"cpu": cpuLim, "memory": memLim},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{"phase": phase},
+	}}
+}
+
+func testPodMetrics(namespace, name, cpu, memory string) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": name, "namespace": namespace},
+		"containers": []interface{}{
+			map[string]interface{}{"name": "app", "usage": map[string]interface{}{"cpu": cpu, "memory": memory}},
+		},
+	}}
+}
+
+func controller(kind, name string) map[string]interface{} {
+	return map[string]interface{}{"apiVersion": "apps/v1", "kind": kind, "name": name, "uid": "u", "controller": true}
+}
+
+func TestPodWorkload(t *testing.T) {
+	tests := []struct {
+		name     string
+		pod      unstructured.Unstructured
+		wantKind string
+		wantName string
+	}{
+		{
+			name:     "deployment via replicaset",
+			pod:      testPod("default", "web-7d9f8-abcde", "Running", controller("ReplicaSet", "web-7d9f8"), map[string]interface{}{"pod-template-hash": "7d9f8"}, "", "", "", ""),
+			wantKind: "Deployment",
+			wantName: "web",
+		},
+		{
+			name:     "bare replicaset",
+			pod:      testPod("default", "rs-x", "Running", controller("ReplicaSet", "rs"), nil, "", "", "", ""),
+			wantKind: "ReplicaSet",
+			wantName: "rs",
+		},
+		{
+			name:     "statefulset",
+			pod:      testPod("default", "db-0", "Running", controller("StatefulSet", "db"), nil, "", "", "", ""),
+			wantKind: "StatefulSet",
+			wantName: "db",
+		},
+		{
+			name:     "bare pod",
+			pod:      testPod("default", "debug", "Running", nil, nil, "", "", "", ""),
+			wantKind: "Pod",
+			wantName: "debug",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, name := podWorkload(tt.pod)
+			if kind != tt.wantKind || name != tt.wantName {
+				t.Errorf("got %s/%s, want %s/%s", kind, name, tt.wantKind, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestAggregateWorkloads(t *testing.T) {
+	hash := map[string]interface{}{"pod-template-hash": "abc"}
+	pods := []unstructured.Unstructured{
+		testPod("shop", "web-abc-1", "Running", controller("ReplicaSet", "web-abc"), hash, "500m", "1", "256Mi", "512Mi"),
+		testPod("shop", "web-abc-2", "Running", controller("ReplicaSet", "web-abc"), hash, "500m", "1", "256Mi", "512Mi"),
+		testPod("shop", "db-0", "Running", controller("StatefulSet", "db"), nil, "100m", "200m", "128Mi", "256Mi"),
+		testPod("shop", "migrate-x", "Succeeded", controller("Job", "migrate"), nil, "1", "1", "1Gi", "1Gi"),
+	}
+	usage := parsePodMetrics([]unstructured.Unstructured{
+		testPodMetrics("shop", "web-abc-1", "50m", "64Mi"),
+		testPodMetrics("shop", "web-abc-2", "30m", "64Mi"),
+		testPodMetrics("shop", "db-0", "190m", "100Mi"),
+	})
+
+	workloads := aggregateWorkloads(pods, usage)
+	if len(workloads) != 2 {
+		t.Fatalf("expected 2 workloads (completed job skipped), got %d", len(workloads))
+	}
+
+	web := workloads[0]
+	if web.Kind != "Deployment" || web.Name != "web" || web.Pods != 2 {
+		t.Fatalf("unexpected first workload %+v", web)
+	}
+	if web.CPU.Request != 1000 || web.CPU.Limit != 2000 || *web.CPU.Usage != 80 {
+		t.Errorf("unexpected web cpu %+v (usage %d)", web.CPU, *web.CPU.Usage)
+	}
+	if web.Memory.Request != 512<<20 || *web.Memory.Usage != 128<<20 {
+		t.Errorf("unexpected web memory %+v", web.Memory)
+	}
+	if web.Status != provisioningOver {
+		t.Errorf("expected web to be over-provisioned, got %s", web.Status)
+	}
+	if *web.CPUSlack != 920 {
+		t.Errorf("expected cpu slack 920m, got %d", *web.CPUSlack)
+	}
+
+	db := workloads[1]
+	if db.Status != provisioningUnder {
+		t.Errorf("expected db above its CPU request to be under-provisioned, got %s", db.Status)
+	}
+
+	namespaces := aggregateNamespaces(workloads)
+	if len(namespaces) != 1 || namespaces[0].Pods != 3 || namespaces[0].OverProvisioned != 1 || namespaces[0].UnderProvisioned != 1 {
+		t.Errorf("unexpected namespace rollup %+v", namespaces)
+	}
+	if namespaces[0].CPU.Request != 1100 || *namespaces[0].CPU.Usage != 270 {
+		t.Errorf("unexpected namespace cpu %+v", namespaces[0].CPU)
+	}
+}
+
+func TestAggregateWorkloads_NoMetrics(t *testing.T) {
+	pods := []unstructured.Unstructured{
+		testPod("default", "a", "Running", nil, nil, "100m", "", "", ""),
+	}
+	workloads := aggregateWorkloads(pods, nil)
+	if len(workloads) != 1 || workloads[0].Status != provisioningUnknown {
+		t.Fatalf("expected unknown status without metrics, got %+v", workloads)
+	}
+	if workloads[0].CPU.Usage != nil || workloads[0].CPUSlack != nil {
+		t.Error("expected usage and slack to be omitted without metrics")
+	}
+}
+
+func TestProvisioningStatus(t *testing.T) {
+	u := func(v int64) *int64 { return &v }
+	tests := []struct {
+		name string
+		cpu  resourceTotals
+		mem  resourceTotals
+		want string
+	}{
+		{"idle", resourceTotals{Request: 1000, Usage: u(100)}, resourceTotals{Request: 1000, Usage: u(100)}, provisioningOver},
+		{"only cpu idle", resourceTotals{Request: 1000, Usage: u(100)}, resourceTotals{Request: 1000, Usage: u(800)}, provisioningOK},
+		{"above request", resourceTotals{Request: 100, Usage: u(150)}, resourceTotals{Request: 1000, Usage: u(100)}, provisioningUnder},
+		{"near limit", resourceTotals{Usage: u(10)}, resourceTotals{Limit: 1000, Usage: u(950)}, provisioningUnder},
+		{"no requests", resourceTotals{Usage: u(10)}, resourceTotals{Usage: u(10)}, provisioningOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := provisioningStatus(tt.cpu, tt.mem); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortByOffence(t *testing.T) {
+	u := func(v int64) *int64 { return &v }
+	workloads := []workloadCapacity{
+		{Name: "fine", Status: provisioningOK, CPU: resourceTotals{Request: 100, Usage: u(90)}, Memory: resourceTotals{Usage: u(0)}},
+		{Name: "slightly-idle", Status: provisioningOver, CPU: resourceTotals{Request: 100, Usage: u(40)}, Memory: resourceTotals{Usage: u(0)}},
+		{Name: "very-idle", Status: provisioningOver, CPU: resourceTotals{Request: 1000, Usage: u(10)}, Memory: resourceTotals{Usage: u(0)}},
+	}
+	sortByOffence(workloads)
+	want := []string{"very-idle", "slightly-idle", "fine"}
+	for i, name := range want {
+		if workloads[i].Name != name {
+			t.Errorf("position %d: got %s, want %s", i, workloads[i].Name, name)
+		}
+	}
+}
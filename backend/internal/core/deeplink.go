@@ -0,0 +1,258 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+// Reasons a deep link does not resolve, returned as details.reason so the
+// UI can tell them apart.
+const (
+	linkInvalid            = "invalid_link"
+	linkClusterUnavailable = "cluster_unavailable"
+	linkUnknownResource    = "unknown_resource"
+	linkForbidden          = "forbidden"
+	linkNotFound           = "not_found"
+)
+
+// errAmbiguousLinkResource is returned when an unqualified resource in a link
+// is served by several groups.
+var errAmbiguousLinkResource = errors.New("ambiguous resource")
+
+// resourceLink is a parsed deep link to one object:
+//
+//	/c/<cluster>/ns/<namespace>/<resource>[.<group>]/<name>
+//	/c/<cluster>/<resource>[.<group>]/<name>    (cluster-scoped)
+//
+// Resource is a plural resource name or a lowercase kind, e.g. "pods" or
+// "deployment.apps".
+type resourceLink struct {
+	ClusterID string
+	Namespace string
+	Resource  string
+	Group     string
+	Name      string
+}
+
+// parseResourceLink parses a deep link path. A full URL is accepted too; only
+// its path is used.
+func parseResourceLink(link string) (resourceLink, error) {
+	if u, err := url.Parse(link); err == nil && u.Scheme != "" {
+		link = u.Path
+	}
+	parts := strings.Split(strings.Trim(link, "/"), "/")
+	if len(parts) < 4 || parts[0] != "c" {
+		return resourceLink{}, fmt.Errorf("expected /c/<cluster>/[ns/<namespace>/]<resource>/<name>")
+	}
+
+	l := resourceLink{ClusterID: parts[1]}
+	rest := parts[2:]
+	if rest[0] == "ns" {
+		if len(rest) != 4 {
+			return resourceLink{}, fmt.Errorf("expected /c/<cluster>/ns/<namespace>/<resource>/<name>")
+		}
+		l.Namespace = rest[1]
+		rest = rest[2:]
+	}
+	if len(rest) != 2 {
+		return resourceLink{}, fmt.Errorf("expected /c/<cluster>/<resource>/<name> for a cluster-scoped resource")
+	}
+	l.Resource, l.Group, _ = strings.Cut(rest[0], ".")
+	l.Name = rest[1]
+
+	if l.ClusterID == "" || l.Resource == "" || l.Name == "" {
+		return resourceLink{}, fmt.Errorf("cluster, resource, and name are required")
+	}
+	for _, s := range []string{l.ClusterID, l.Namespace, l.Resource, l.Group, l.Name} {
+		if !validate.Segment(s) {
+			return resourceLink{}, fmt.Errorf("invalid link segment %q", s)
+		}
+	}
+	return l, nil
+}
+
+// resolveLinkResource picks the served resource a link names, matching the
+// plural name or lowercase kind at the group's preferred version. Without a
+// group the core group wins, then a resource served by exactly one group.
+func resolveLinkResource(resources []ServedResource, resource, group string) (ServedResource, error) {
+	var matches []ServedResource
+	for _, res := range resources {
+		if !res.Preferred || (group != "" && res.Group != group) {
+			continue
+		}
+		if res.Resource == resource || strings.ToLower(res.Kind) == resource {
+			matches = append(matches, res)
+		}
+	}
+	if len(matches) == 0 {
+		return ServedResource{}, fmt.Errorf("resource type %q is not served by this cluster", qualifiedLinkResource(resource, group))
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	groups := make([]string, 0, len(matches))
+	for _, res := range matches {
+		if res.Group == "" {
+			return res, nil
+		}
+		groups = append(groups, res.Group)
+	}
+	return ServedResource{}, fmt.Errorf("%w: %q is served by several groups (%s); qualify it as <resource>.<group>",
+		errAmbiguousLinkResource, resource, strings.Join(groups, ", "))
+}
+
+func qualifiedLinkResource(resource, group string) string {
+	if group == "" {
+		return resource
+	}
+	return resource + "." + group
+}
+
+// canonicalLink is the stable link of a resolved object: the plural resource
+// qualified by its group, and no namespace for cluster-scoped types.
+func canonicalLink(clusterID string, res ServedResource, namespace, name string) string {
+	resource := qualifiedLinkResource(res.Resource, res.Group)
+	if res.Namespaced {
+		return "/c/" + clusterID + "/ns/" + namespace + "/" + resource + "/" + name
+	}
+	return "/c/" + clusterID + "/" + resource + "/" + name
+}
+
+// resolvedLink is the canonical metadata of the object a deep link points at.
+type resolvedLink struct {
+	Link            string       `json:"link"`
+	ClusterID       string       `json:"clusterId"`
+	Group           string       `json:"group"`
+	Version         string       `json:"version"`
+	Resource        string       `json:"resource"`
+	Kind            string       `json:"kind"`
+	Namespaced      bool         `json:"namespaced"`
+	Namespace       string       `json:"namespace,omitempty"`
+	Name            string       `json:"name"`
+	UID             string       `json:"uid"`
+	ResourceVersion string       `json:"resourceVersion"`
+	Owners          []*ownerNode `json:"owners,omitempty"`
+}
+
+// DeepLinkHandler validates shareable deep links against the live cluster so
+// the UI does not have to guess GVRs or probe for access itself.
+type DeepLinkHandler struct {
+	tree *OwnerTreeHandler
+}
+
+// NewDeepLinkHandler creates a new DeepLinkHandler.
+func NewDeepLinkHandler(cm *cluster.Manager, engine *rbac.Engine, discovery *DiscoveryCache) *DeepLinkHandler {
+	return &DeepLinkHandler{tree: NewOwnerTreeHandler(cm, engine, discovery)}
+}
+
+// RegisterRoutes wires the deep link resolver.
+func (h *DeepLinkHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/links/resolve", h.Resolve).Methods(http.MethodGet)
+}
+
+// Resolve checks that the object a deep link points at exists and that the
+// caller may read it, and returns its canonical link, GVR, resourceVersion,
+// and owner chain. Failures carry details.reason: invalid_link (400),
+// cluster_unavailable, unknown_resource, or not_found (404), or forbidden
+// (403). The caller's read access is checked before the object is fetched,
+// so a 403 does not reveal whether the object exists.
+//
+// Query parameters:
+//   - path: the deep link, e.g. /c/<cluster>/ns/default/pods/web-0
+func (h *DeepLinkHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	link, err := parseResourceLink(r.URL.Query().Get("path"))
+	if err != nil {
+		writeLinkError(w, http.StatusBadRequest, httputil.CodeValidationFailed, linkInvalid, "invalid link: "+err.Error())
+		return
+	}
+
+	discovery, err := h.tree.discovery.Get(r.Context(), link.ClusterID)
+	if err != nil {
+		if errors.Is(err, errClusterUnavailable) {
+			writeLinkError(w, http.StatusNotFound, httputil.CodeNotConnected, linkClusterUnavailable, err.Error())
+			return
+		}
+		httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("discovery failed: %v", err))
+		return
+	}
+	res, err := resolveLinkResource(discovery.Resources, link.Resource, link.Group)
+	if errors.Is(err, errAmbiguousLinkResource) {
+		writeLinkError(w, http.StatusBadRequest, httputil.CodeValidationFailed, linkInvalid, "invalid link: "+err.Error())
+		return
+	}
+	if err != nil {
+		writeLinkError(w, http.StatusNotFound, httputil.CodeNotFound, linkUnknownResource, err.Error())
+		return
+	}
+	gvr := schema.GroupVersionResource{Group: res.Group, Version: res.Version, Resource: res.Resource}
+	namespace := link.Namespace
+	if !res.Namespaced {
+		namespace = ""
+	} else if namespace == "" {
+		writeLinkError(w, http.StatusBadRequest, httputil.CodeValidationFailed, linkInvalid,
+			fmt.Sprintf("invalid link: %s is namespaced; use /c/<cluster>/ns/<namespace>/...", res.Resource))
+		return
+	}
+
+	access := newReadAccess(h.tree.rbacEngine, claims.UserID, link.ClusterID)
+	allowed, err := access.allowed(r.Context(), gvr, namespace)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+		return
+	}
+	if !allowed {
+		writeLinkError(w, http.StatusForbidden, httputil.CodeForbidden, linkForbidden, "insufficient permissions")
+		return
+	}
+
+	obj, err := h.tree.getObject(r.Context(), link.ClusterID, gvr, namespace, link.Name)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	if obj == nil {
+		writeLinkError(w, http.StatusNotFound, httputil.CodeNotFound, linkNotFound, fmt.Sprintf("%s %q not found", res.Resource, link.Name))
+		return
+	}
+
+	// Owners the caller may not read are reported as errors, as in the owner tree.
+	owners := walkOwners(r.Context(), obj, h.tree.ownerResolver(link.ClusterID, access), defaultOwnerTreeDepth, map[types.UID]bool{obj.GetUID(): true})
+
+	httputil.WriteJSON(w, http.StatusOK, resolvedLink{
+		Link:            canonicalLink(link.ClusterID, res, namespace, obj.GetName()),
+		ClusterID:       link.ClusterID,
+		Group:           res.Group,
+		Version:         res.Version,
+		Resource:        res.Resource,
+		Kind:            res.Kind,
+		Namespaced:      res.Namespaced,
+		Namespace:       namespace,
+		Name:            obj.GetName(),
+		UID:             string(obj.GetUID()),
+		ResourceVersion: obj.GetResourceVersion(),
+		Owners:          owners,
+	})
+}
+
+func writeLinkError(w http.ResponseWriter, status int, code httputil.ErrorCode, reason, message string) {
+	httputil.WriteErrorDetails(w, status, code, message, map[string]string{"reason": reason})
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseResourceLink(t *testing.T) {
+	tests := []struct {
+		link string
+		want resourceLink
+	}{
+		{"/c/c1/ns/default/pods/web-0", resourceLink{ClusterID: "c1", Namespace: "default", Resource: "pods", Name: "web-0"}},
+		{"/c/c1/ns/default/deployments.apps/web", resourceLink{ClusterID: "c1", Namespace: "default", Resource: "deployments", Group: "apps", Name: "web"}},
+		{"/c/c1/nodes/node-a/", resourceLink{ClusterID: "c1", Resource: "nodes", Name: "node-a"}},
+		{"https://argus.example.com/c/c1/ingressclasses.networking.k8s.io/nginx", resourceLink{ClusterID: "c1", Resource: "ingressclasses", Group: "networking.k8s.io", Name: "nginx"}},
+	}
+	for _, tt := range tests {
+		got, err := parseResourceLink(tt.link)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.link, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.link, tt.want, got)
+		}
+	}
+}
+
+func TestParseResourceLink_Invalid(t *testing.T) {
+	for _, link := range []string{
+		"",
+		"/c/c1/pods",
+		"/x/c1/pods/web",
+		"/c/c1/ns/default/pods",
+		"/c/c1/ns/default/pods/web/extra",
+		"/c/c1/ns/default/pods/..",
+	} {
+		if _, err := parseResourceLink(link); err == nil {
+			t.Errorf("%q: expected an error", link)
+		}
+	}
+}
+
+func linkTestResources() []ServedResource {
+	served := func(group, version, resource, kind string, namespaced, preferred bool) ServedResource {
+		return ServedResource{
+			APIResourceEntry: APIResourceEntry{Group: group, Version: version, Resource: resource, Kind: kind, Namespaced: namespaced},
+			Preferred:        preferred,
+		}
+	}
+	return []ServedResource{
+		served("", "v1", "pods", "Pod", true, true),
+		served("", "v1", "events", "Event", true, true),
+		served("events.k8s.io", "v1", "events", "Event", true, true),
+		served("apps", "v1", "deployments", "Deployment", true, true),
+		served("autoscaling", "v2", "horizontalpodautoscalers", "HorizontalPodAutoscaler", true, true),
+		served("autoscaling", "v1", "horizontalpodautoscalers", "HorizontalPodAutoscaler", true, false),
+		served("a.example.com", "v1", "widgets", "Widget", true, true),
+		served("b.example.com", "v1", "widgets", "Widget", false, true),
+	}
+}
+
+func TestResolveLinkResource(t *testing.T) {
+	resources := linkTestResources()
+	tests := []struct {
+		resource, group string
+		wantGroup       string
+		wantVersion     string
+	}{
+		{"pods", "", "", "v1"},
+		{"pod", "", "", "v1"},
+		{"events", "", "", "v1"},
+		{"events", "events.k8s.io", "events.k8s.io", "v1"},
+		{"deployments", "", "apps", "v1"},
+		{"horizontalpodautoscalers", "autoscaling", "autoscaling", "v2"},
+		{"widgets", "b.example.com", "b.example.com", "v1"},
+	}
+	for _, tt := range tests {
+		res, err := resolveLinkResource(resources, tt.resource, tt.group)
+		if err != nil {
+			t.Errorf("%s.%s: unexpected error %v", tt.resource, tt.group, err)
+			continue
+		}
+		if res.Group != tt.wantGroup || res.Version != tt.wantVersion {
+			t.Errorf("%s.%s: expected %s/%s, got %s/%s", tt.resource, tt.group, tt.wantGroup, tt.wantVersion, res.Group, res.Version)
+		}
+	}
+
+	if _, err := resolveLinkResource(resources, "widgets", ""); !errors.Is(err, errAmbiguousLinkResource) {
+		t.Errorf("expected widgets to be ambiguous, got %v", err)
+	}
+	if _, err := resolveLinkResource(resources, "gadgets", ""); err == nil || errors.Is(err, errAmbiguousLinkResource) {
+		t.Errorf("expected gadgets to be unknown, got %v", err)
+	}
+}
+
+func TestCanonicalLink(t *testing.T) {
+	resources := linkTestResources()
+	if got := canonicalLink("c1", resources[3], "default", "web"); got != "/c/c1/ns/default/deployments.apps/web" {
+		t.Errorf("unexpected namespaced link %q", got)
+	}
+	if got := canonicalLink("c1", resources[7], "", "w"); got != "/c/c1/widgets.b.example.com/w" {
+		t.Errorf("unexpected cluster-scoped link %q", got)
+	}
+	if got := canonicalLink("c1", resources[0], "default", "web-0"); strings.Contains(got, "pods.") {
+		t.Errorf("expected core resources to stay unqualified, got %q", got)
+	}
+}
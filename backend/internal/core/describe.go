@@ -0,0 +1,408 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+// maxDescribeEvents caps the events returned with a described object.
+const maxDescribeEvents = 50
+
+var (
+	eventsGVR = schema.GroupVersionResource{Version: "v1", Resource: "events"}
+	nodesGVR  = schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+	pvcsGVR   = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+)
+
+// describeDetails is what the describe endpoint adds to an object under
+// podSummaryField. For pods it includes the same status summary as pod
+// lists. Errors maps a section (events, owners, children, node,
+// persistentVolumeClaims) to why it could not be filled in; the other
+// sections are still returned.
+type describeDetails struct {
+	*podSummary
+	Events                 []describeEvent   `json:"events"`
+	Owners                 []*ownerNode      `json:"owners,omitempty"`
+	Children               []*ownerNode      `json:"children,omitempty"`
+	Node                   *describedNode    `json:"node,omitempty"`
+	PersistentVolumeClaims []claimBinding    `json:"persistentVolumeClaims,omitempty"`
+	Errors                 map[string]string `json:"errors,omitempty"`
+}
+
+// describeEvent is an event involving the described object, as the Events
+// table of kubectl describe shows it.
+type describeEvent struct {
+	Type      string     `json:"type"`
+	Reason    string     `json:"reason"`
+	Message   string     `json:"message"`
+	Source    string     `json:"source,omitempty"`
+	Count     int64      `json:"count"`
+	FirstSeen *time.Time `json:"firstSeen,omitempty"`
+	LastSeen  time.Time  `json:"lastSeen"`
+}
+
+// describedNode is the node a pod is scheduled on.
+type describedNode struct {
+	Name string `json:"name"`
+	// Ready is the status of the node's Ready condition: True, False or Unknown.
+	Ready         string `json:"ready,omitempty"`
+	Unschedulable bool   `json:"unschedulable,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// claimBinding is a pod volume backed by a PersistentVolumeClaim and what
+// the claim is bound to.
+type claimBinding struct {
+	Volume           string   `json:"volume"`
+	ClaimName        string   `json:"claimName"`
+	Phase            string   `json:"phase,omitempty"`
+	VolumeName       string   `json:"volumeName,omitempty"`
+	StorageClassName string   `json:"storageClassName,omitempty"`
+	Capacity         string   `json:"capacity,omitempty"`
+	AccessModes      []string `json:"accessModes,omitempty"`
+	Error            string   `json:"error,omitempty"`
+}
+
+// DescribeHandler aggregates what kubectl describe shows for an object into
+// one response, so detail pages do not need a request per section.
+type DescribeHandler struct {
+	tree *OwnerTreeHandler
+}
+
+// NewDescribeHandler creates a new DescribeHandler.
+func NewDescribeHandler(cm *cluster.Manager, engine *rbac.Engine, discovery *DiscoveryCache) *DescribeHandler {
+	return &DescribeHandler{tree: NewOwnerTreeHandler(cm, engine, discovery)}
+}
+
+// RegisterRoutes wires the describe endpoint.
+func (h *DescribeHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clusters/{clusterID}/describe/{group}/{version}/{resource}/{name}", h.Get).Methods(http.MethodGet)
+}
+
+// Get returns the object with a describe section under podSummaryField: its
+// recent events (newest first), owner chain, owned objects, and for pods the
+// status summary, node, and PVC bindings. Sections the caller may not read
+// are reported in its errors map instead of failing the request.
+//
+// Query parameters:
+//   - namespace: the object's namespace (ignored for cluster-scoped types)
+func (h *DescribeHandler) Get(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	clusterID := vars["clusterID"]
+	name := vars["name"]
+	gvr := gvrFromVars(vars)
+	namespace := r.URL.Query().Get("namespace")
+	if !validatePathSegments(w, namespace, name) {
+		return
+	}
+	if !validate.Segment(gvr.Group) || !validate.Segment(gvr.Version) || !validate.Segment(gvr.Resource) {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid group, version, or resource")
+		return
+	}
+
+	res, found, err := h.tree.discovery.Lookup(r.Context(), clusterID, gvr)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("discovery failed: %v", err))
+		return
+	}
+	if !found {
+		httputil.WriteError(w, http.StatusNotFound, fmt.Sprintf("resource type %s is not served by this cluster", gvr.String()))
+		return
+	}
+	if !res.Namespaced {
+		namespace = ""
+	}
+
+	access := newReadAccess(h.tree.rbacEngine, claims.UserID, clusterID)
+	allowed, err := access.allowed(r.Context(), gvr, namespace)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+		return
+	}
+	if !allowed {
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+
+	obj, err := h.tree.getObject(r.Context(), clusterID, gvr, namespace, name)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	if obj == nil {
+		httputil.WriteError(w, http.StatusNotFound, fmt.Sprintf("%s %q not found", gvr.Resource, name))
+		return
+	}
+	// List items fetched through an agent carry no type; the response needs
+	// it, and so does response redaction.
+	if obj.GetKind() == "" {
+		obj.SetAPIVersion(gvr.GroupVersion().String())
+		obj.SetKind(res.Kind)
+	}
+
+	details := &describeDetails{Events: []describeEvent{}}
+	fail := func(section string, err error) {
+		if details.Errors == nil {
+			details.Errors = make(map[string]string)
+		}
+		details.Errors[section] = err.Error()
+	}
+
+	if events, err := h.listEvents(r.Context(), clusterID, obj, access); err != nil {
+		fail("events", err)
+	} else {
+		details.Events = events
+	}
+
+	details.Owners = walkOwners(r.Context(), obj, h.tree.ownerResolver(clusterID, access), defaultOwnerTreeDepth, map[types.UID]bool{obj.GetUID(): true})
+	if candidates, err := h.tree.listChildCandidates(r.Context(), clusterID, namespace, access); err != nil {
+		fail("children", fmt.Errorf("permission check failed"))
+	} else {
+		details.Children = walkChildren(obj.GetUID(), indexByOwner(candidates), defaultOwnerTreeDepth, map[types.UID]bool{obj.GetUID(): true})
+	}
+
+	if gvr == podsGVR {
+		var pod corev1.Pod
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &pod); err == nil {
+			h.describePod(r.Context(), clusterID, &pod, access, details, fail)
+		}
+	}
+
+	obj.Object[podSummaryField] = details
+	httputil.WriteJSON(w, http.StatusOK, obj.Object)
+}
+
+// listEvents returns the events whose involved object is obj, newest first.
+// Events of cluster-scoped objects may live in any namespace, so they are
+// listed everywhere and filtered to the namespaces the caller may read.
+func (h *DescribeHandler) listEvents(ctx context.Context, clusterID string, obj *unstructured.Unstructured, access *readAccess) ([]describeEvent, error) {
+	namespace := obj.GetNamespace()
+	if namespace != "" {
+		allowed, err := access.allowed(ctx, eventsGVR, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("permission check failed")
+		}
+		if !allowed {
+			return nil, fmt.Errorf("insufficient permissions")
+		}
+	}
+
+	backend, err := resourceBackendFor(h.tree.clusterMgr, clusterID, eventsGVR)
+	if err != nil {
+		return nil, err
+	}
+	items, err := backend.list(ctx, namespace, "", "involvedObject.uid="+string(obj.GetUID()))
+	if err != nil {
+		return nil, err
+	}
+
+	readable := items[:0]
+	for _, item := range items {
+		allowed, err := access.allowed(ctx, eventsGVR, item.GetNamespace())
+		if err != nil {
+			return nil, fmt.Errorf("permission check failed")
+		}
+		if allowed {
+			readable = append(readable, item)
+		}
+	}
+	return summarizeEvents(readable, maxDescribeEvents), nil
+}
+
+// describePod fills in the pod's status summary, node, and PVC bindings.
+func (h *DescribeHandler) describePod(ctx context.Context, clusterID string, pod *corev1.Pod, access *readAccess, details *describeDetails, fail func(string, error)) {
+	summary := summarizePod(pod)
+	details.podSummary = &summary
+
+	if pod.Spec.NodeName != "" {
+		node, err := h.describeNode(ctx, clusterID, pod.Spec.NodeName, access)
+		if err != nil {
+			fail("node", err)
+		}
+		details.Node = node
+	}
+
+	bindings := podClaims(pod)
+	if len(bindings) == 0 {
+		return
+	}
+	allowed, err := access.allowed(ctx, pvcsGVR, pod.Namespace)
+	switch {
+	case err != nil:
+		fail("persistentVolumeClaims", fmt.Errorf("permission check failed"))
+	case !allowed:
+		fail("persistentVolumeClaims", fmt.Errorf("insufficient permissions"))
+	default:
+		backend, err := resourceBackendFor(h.tree.clusterMgr, clusterID, pvcsGVR)
+		if err == nil {
+			var items []unstructured.Unstructured
+			if items, err = backend.list(ctx, pod.Namespace, "", ""); err == nil {
+				bindClaims(bindings, items)
+			}
+		}
+		if err != nil {
+			fail("persistentVolumeClaims", err)
+		}
+	}
+	details.PersistentVolumeClaims = bindings
+}
+
+// describeNode returns the node's readiness. The node's name is always
+// returned, even when the caller may not read the node itself.
+func (h *DescribeHandler) describeNode(ctx context.Context, clusterID, name string, access *readAccess) (*describedNode, error) {
+	described := &describedNode{Name: name}
+	allowed, err := access.allowed(ctx, nodesGVR, "")
+	if err != nil {
+		return described, fmt.Errorf("permission check failed")
+	}
+	if !allowed {
+		return described, fmt.Errorf("insufficient permissions")
+	}
+
+	obj, err := h.tree.getObject(ctx, clusterID, nodesGVR, "", name)
+	if err != nil {
+		return described, err
+	}
+	if obj == nil {
+		described.Error = "not found"
+		return described, nil
+	}
+	var node corev1.Node
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &node); err != nil {
+		return described, fmt.Errorf("invalid node: %v", err)
+	}
+	described.Unschedulable = node.Spec.Unschedulable
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			described.Ready = string(c.Status)
+		}
+	}
+	return described, nil
+}
+
+// summarizeEvents converts events to describeEvents, newest first, keeping
+// at most limit.
+func summarizeEvents(items []unstructured.Unstructured, limit int) []describeEvent {
+	events := make([]describeEvent, 0, len(items))
+	for i := range items {
+		var ev corev1.Event
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(items[i].Object, &ev); err != nil {
+			continue
+		}
+		events = append(events, summarizeEvent(&ev))
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].LastSeen.After(events[j].LastSeen)
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events
+}
+
+// summarizeEvent reads an event the way kubectl does, falling back from the
+// legacy count and timestamps to the event series and eventTime.
+func summarizeEvent(ev *corev1.Event) describeEvent {
+	out := describeEvent{
+		Type:    ev.Type,
+		Reason:  ev.Reason,
+		Message: strings.TrimSpace(ev.Message),
+		Source:  ev.Source.Component,
+		Count:   int64(ev.Count),
+	}
+	if out.Source == "" {
+		out.Source = ev.ReportingController
+	}
+	if !ev.FirstTimestamp.IsZero() {
+		first := ev.FirstTimestamp.Time
+		out.FirstSeen = &first
+	}
+
+	switch {
+	case !ev.LastTimestamp.IsZero():
+		out.LastSeen = ev.LastTimestamp.Time
+	case ev.Series != nil && !ev.Series.LastObservedTime.IsZero():
+		out.LastSeen = ev.Series.LastObservedTime.Time
+	case !ev.EventTime.IsZero():
+		out.LastSeen = ev.EventTime.Time
+	default:
+		out.LastSeen = ev.CreationTimestamp.Time
+	}
+	if ev.Series != nil && int64(ev.Series.Count) > out.Count {
+		out.Count = int64(ev.Series.Count)
+	}
+	if out.Count == 0 {
+		out.Count = 1
+	}
+	return out
+}
+
+// podClaims lists the pod's volumes backed by a PersistentVolumeClaim,
+// including generic ephemeral volumes, whose claim is named after the pod.
+func podClaims(pod *corev1.Pod) []claimBinding {
+	var claims []claimBinding
+	for _, v := range pod.Spec.Volumes {
+		switch {
+		case v.PersistentVolumeClaim != nil:
+			claims = append(claims, claimBinding{Volume: v.Name, ClaimName: v.PersistentVolumeClaim.ClaimName})
+		case v.Ephemeral != nil:
+			claims = append(claims, claimBinding{Volume: v.Name, ClaimName: pod.Name + "-" + v.Name})
+		}
+	}
+	return claims
+}
+
+// bindClaims fills in each binding from the claim of the same name in pvcs.
+func bindClaims(bindings []claimBinding, pvcs []unstructured.Unstructured) {
+	byName := make(map[string]*unstructured.Unstructured, len(pvcs))
+	for i := range pvcs {
+		byName[pvcs[i].GetName()] = &pvcs[i]
+	}
+	for i := range bindings {
+		obj, ok := byName[bindings[i].ClaimName]
+		if !ok {
+			bindings[i].Error = "not found"
+			continue
+		}
+		var pvc corev1.PersistentVolumeClaim
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &pvc); err != nil {
+			bindings[i].Error = fmt.Sprintf("invalid claim: %v", err)
+			continue
+		}
+		b := &bindings[i]
+		b.Phase = string(pvc.Status.Phase)
+		b.VolumeName = pvc.Spec.VolumeName
+		if pvc.Spec.StorageClassName != nil {
+			b.StorageClassName = *pvc.Spec.StorageClassName
+		}
+		if q, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+			b.Capacity = q.String()
+		}
+		for _, m := range pvc.Status.AccessModes {
+			b.AccessModes = append(b.AccessModes, string(m))
+		}
+	}
+}
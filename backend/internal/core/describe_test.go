@@ -0,0 +1,115 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func toUnstructured(t *testing.T, obj interface{}) unstructured.Unstructured {
+	t.Helper()
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return unstructured.Unstructured{Object: m}
+}
+
+func TestSummarizeEvents(t *testing.T) {
+	base := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	legacy := &corev1.Event{
+		Type: "Warning", Reason: "BackOff", Message: "Back-off restarting failed container\n", Count: 7,
+		Source:         corev1.EventSource{Component: "kubelet"},
+		FirstTimestamp: metav1.NewTime(base),
+		LastTimestamp:  metav1.NewTime(base.Add(10 * time.Minute)),
+	}
+	series := &corev1.Event{
+		Type: "Normal", Reason: "Pulled", ReportingController: "kubelet",
+		EventTime: metav1.NewMicroTime(base),
+		Series:    &corev1.EventSeries{Count: 3, LastObservedTime: metav1.NewMicroTime(base.Add(20 * time.Minute))},
+	}
+	single := &corev1.Event{Type: "Normal", Reason: "Scheduled", EventTime: metav1.NewMicroTime(base.Add(time.Minute))}
+
+	items := []unstructured.Unstructured{toUnstructured(t, legacy), toUnstructured(t, series), toUnstructured(t, single)}
+	events := summarizeEvents(items, 10)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	if events[0].Reason != "Pulled" || events[0].Count != 3 || events[0].Source != "kubelet" {
+		t.Errorf("expected the series event first, got %+v", events[0])
+	}
+	if events[1].Reason != "BackOff" || events[1].Count != 7 || events[1].FirstSeen == nil || events[1].Message != "Back-off restarting failed container" {
+		t.Errorf("unexpected legacy event %+v", events[1])
+	}
+	if events[2].Reason != "Scheduled" || events[2].Count != 1 || !events[2].LastSeen.Equal(base.Add(time.Minute)) {
+		t.Errorf("unexpected single event %+v", events[2])
+	}
+
+	if got := summarizeEvents(items, 1); len(got) != 1 || got[0].Reason != "Pulled" {
+		t.Errorf("expected only the newest event, got %+v", got)
+	}
+}
+
+func TestPodClaimsAndBindings(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default"},
+		Spec: corev1.PodSpec{Volumes: []corev1.Volume{
+			{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-web-0"}}},
+			{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}}},
+			{Name: "scratch", VolumeSource: corev1.VolumeSource{Ephemeral: &corev1.EphemeralVolumeSource{}}},
+		}},
+	}
+	bindings := podClaims(pod)
+	if len(bindings) != 2 || bindings[0].ClaimName != "data-web-0" || bindings[1].ClaimName != "web-0-scratch" {
+		t.Fatalf("unexpected claims %+v", bindings)
+	}
+
+	class := "fast"
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data-web-0", Namespace: "default"},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-123", StorageClassName: &class},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Phase:       corev1.ClaimBound,
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Capacity:    corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+		},
+	}
+	bindClaims(bindings, []unstructured.Unstructured{toUnstructured(t, pvc)})
+
+	want := claimBinding{Volume: "data", ClaimName: "data-web-0", Phase: "Bound", VolumeName: "pv-123",
+		StorageClassName: "fast", Capacity: "10Gi", AccessModes: []string{"ReadWriteOnce"}}
+	if b := bindings[0]; b.Phase != want.Phase || b.VolumeName != want.VolumeName || b.StorageClassName != want.StorageClassName ||
+		b.Capacity != want.Capacity || len(b.AccessModes) != 1 || b.AccessModes[0] != "ReadWriteOnce" {
+		t.Errorf("unexpected binding %+v", b)
+	}
+	if bindings[1].Error != "not found" {
+		t.Errorf("expected the missing ephemeral claim to be reported, got %+v", bindings[1])
+	}
+}
+
+func TestDescribeDetailsJSON(t *testing.T) {
+	details := &describeDetails{Events: []describeEvent{}}
+	body, err := json.Marshal(details)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	_ = json.Unmarshal(body, &out)
+	if _, ok := out["status"]; ok {
+		t.Errorf("expected no pod status for non-pods, got %s", body)
+	}
+
+	details.podSummary = &podSummary{Status: "Running", ReadyContainers: 1, TotalContainers: 1}
+	body, _ = json.Marshal(details)
+	out = nil
+	_ = json.Unmarshal(body, &out)
+	if out["status"] != "Running" || out["totalContainers"] != float64(1) {
+		t.Errorf("expected the pod summary inline, got %s", body)
+	}
+}
@@ -0,0 +1,193 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// errClusterUnavailable is returned when a cluster has neither a direct
+// client nor a connected agent.
+var errClusterUnavailable = cluster.ErrClusterUnavailable
+
+// ServedResource is a resource served by a cluster at one group/version.
+// Unlike ListAPIResources, discovery returns every served version, with
+// Preferred marking the one the API server prefers for the group.
+type ServedResource struct {
+	APIResourceEntry
+	Preferred bool `json:"preferred"`
+}
+
+// DiscoveryResult is the cached discovery data of one cluster.
+type DiscoveryResult struct {
+	Resources []ServedResource `json:"resources"`
+	FetchedAt time.Time        `json:"fetched_at"`
+}
+
+// discoverySource is the cluster manager's shared discovery cache.
+type discoverySource interface {
+	Discovery(ctx context.Context, clusterID string) (*cluster.Discovery, error)
+	InvalidateDiscovery(clusterID string)
+}
+
+// DiscoveryCache serves the discovery data cached by the cluster manager as
+// ServedResources for the resource explorer and the generic resource
+// handler. A cluster's converted result is reused until the manager
+// refetches.
+type DiscoveryCache struct {
+	source discoverySource
+
+	mu      sync.Mutex
+	entries map[string]*DiscoveryResult
+}
+
+// NewDiscoveryCache creates a discovery cache backed by the cluster manager.
+func NewDiscoveryCache(cm *cluster.Manager) *DiscoveryCache {
+	return newDiscoveryCache(cm)
+}
+
+func newDiscoveryCache(source discoverySource) *DiscoveryCache {
+	return &DiscoveryCache{
+		source:  source,
+		entries: make(map[string]*DiscoveryResult),
+	}
+}
+
+// Get returns the cluster's discovery result. The cluster manager fetches it
+// when it is missing or expired.
+func (c *DiscoveryCache) Get(ctx context.Context, clusterID string) (*DiscoveryResult, error) {
+	d, err := c.source.Discovery(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[clusterID]; ok && entry.FetchedAt.Equal(d.FetchedAt) {
+		return entry, nil
+	}
+	entry := &DiscoveryResult{Resources: servedResources(d.Groups, d.Resources), FetchedAt: d.FetchedAt}
+	c.entries[clusterID] = entry
+	return entry, nil
+}
+
+// Invalidate drops the cached result of a cluster so the next Get refetches it.
+func (c *DiscoveryCache) Invalidate(clusterID string) {
+	c.source.InvalidateDiscovery(clusterID)
+	c.mu.Lock()
+	delete(c.entries, clusterID)
+	c.mu.Unlock()
+}
+
+// Lookup finds a GVR in the cluster's discovery result.
+func (c *DiscoveryCache) Lookup(ctx context.Context, clusterID string, gvr schema.GroupVersionResource) (ServedResource, bool, error) {
+	result, err := c.Get(ctx, clusterID)
+	if err != nil {
+		return ServedResource{}, false, err
+	}
+	for _, res := range result.Resources {
+		if res.Group == gvr.Group && res.Version == gvr.Version && res.Resource == gvr.Resource {
+			return res, true, nil
+		}
+	}
+	return ServedResource{}, false, nil
+}
+
+// LookupKind finds the resource serving a kind in the cluster's discovery
+// result, e.g. "deployments" for apps/v1 Deployment.
+func (c *DiscoveryCache) LookupKind(ctx context.Context, clusterID string, gvk schema.GroupVersionKind) (ServedResource, bool, error) {
+	result, err := c.Get(ctx, clusterID)
+	if err != nil {
+		return ServedResource{}, false, err
+	}
+	for _, res := range result.Resources {
+		if res.Group == gvk.Group && res.Version == gvk.Version && res.Kind == gvk.Kind {
+			return res, true, nil
+		}
+	}
+	return ServedResource{}, false, nil
+}
+
+// servedResources flattens discovery resource lists into ServedResources,
+// skipping sub-resources such as pods/log. groups supplies each group's
+// preferred version.
+func servedResources(groups []metav1.APIGroup, lists []*metav1.APIResourceList) []ServedResource {
+	preferred := make(map[string]string, len(groups))
+	for _, g := range groups {
+		preferred[g.Name] = g.PreferredVersion.Version
+	}
+
+	resources := []ServedResource{}
+	for _, list := range lists {
+		if list == nil {
+			continue
+		}
+		group, version := parseGroupVersion(list.GroupVersion)
+		for _, res := range list.APIResources {
+			if strings.Contains(res.Name, "/") {
+				continue
+			}
+			verbs := make([]string, len(res.Verbs))
+			copy(verbs, res.Verbs)
+			resources = append(resources, ServedResource{
+				APIResourceEntry: APIResourceEntry{
+					Kind:       res.Kind,
+					Group:      group,
+					Version:    version,
+					Resource:   res.Name,
+					Namespaced: res.Namespaced,
+					Verbs:      verbs,
+				},
+				Preferred: preferred[group] == version,
+			})
+		}
+	}
+	return resources
+}
+
+// DiscoveryHandler serves the resource explorer's kind list: every resource
+// type a cluster serves, including custom resources.
+type DiscoveryHandler struct {
+	cache *DiscoveryCache
+}
+
+func NewDiscoveryHandler(cache *DiscoveryCache) *DiscoveryHandler {
+	return &DiscoveryHandler{cache: cache}
+}
+
+// RegisterRoutes wires the discovery endpoints.
+func (h *DiscoveryHandler) RegisterRoutes(r *mux.Router) {
+	api := r.PathPrefix("/api/clusters/{clusterID}/discovery").Subrouter()
+	api.HandleFunc("", h.Get).Methods(http.MethodGet)
+	api.HandleFunc("/refresh", h.Refresh).Methods(http.MethodPost)
+}
+
+// Get returns the cluster's served resources, from cache when fresh.
+func (h *DiscoveryHandler) Get(w http.ResponseWriter, r *http.Request) {
+	h.writeResult(w, r, mux.Vars(r)["clusterID"])
+}
+
+// Refresh drops the cluster's cached discovery result and returns a fresh one.
+func (h *DiscoveryHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	clusterID := mux.Vars(r)["clusterID"]
+	h.cache.Invalidate(clusterID)
+	h.writeResult(w, r, clusterID)
+}
+
+func (h *DiscoveryHandler) writeResult(w http.ResponseWriter, r *http.Request, clusterID string) {
+	result, err := h.cache.Get(r.Context(), clusterID)
+	if err != nil {
+		writeClusterError(w, err, "discovery failed")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,215 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+func TestServedResources(t *testing.T) {
+	groups := []metav1.APIGroup{
+		{Name: "", PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: "v1", Version: "v1"}},
+		{Name: "example.com", PreferredVersion: metav1.GroupVersionForDiscovery{GroupVersion: "example.com/v1", Version: "v1"}},
+	}
+	lists := []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: []string{"get", "list"}},
+			{Name: "pods/log", Kind: "Pod", Namespaced: true},
+			{Name: "nodes", Kind: "Node", Namespaced: false},
+		}},
+		{GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{
+			{Name: "widgets", Kind: "Widget", Namespaced: true},
+		}},
+		{GroupVersion: "example.com/v1beta1", APIResources: []metav1.APIResource{
+			{Name: "widgets", Kind: "Widget", Namespaced: true},
+		}},
+		nil,
+	}
+
+	got := servedResources(groups, lists)
+	if len(got) != 4 {
+		t.Fatalf("expected 4 resources (sub-resources skipped), got %d: %+v", len(got), got)
+	}
+
+	byKey := make(map[string]ServedResource)
+	for _, r := range got {
+		byKey[r.Group+"/"+r.Version+"/"+r.Resource] = r
+	}
+	if r := byKey["/v1/pods"]; !r.Namespaced || !r.Preferred || len(r.Verbs) != 2 {
+		t.Errorf("unexpected pods entry: %+v", r)
+	}
+	if r := byKey["/v1/nodes"]; r.Namespaced {
+		t.Errorf("nodes should be cluster-scoped: %+v", r)
+	}
+	if r := byKey["example.com/v1/widgets"]; !r.Preferred || r.Kind != "Widget" {
+		t.Errorf("unexpected preferred widgets entry: %+v", r)
+	}
+	if r, ok := byKey["example.com/v1beta1/widgets"]; !ok || r.Preferred {
+		t.Errorf("v1beta1 widgets should be listed and not preferred: %+v", r)
+	}
+}
+
+// fakeDiscovery stands in for the cluster manager's discovery cache: it
+// fetches once and serves the result until invalidated.
+type fakeDiscovery struct {
+	resources []ServedResource
+	err       error
+	fetches   int
+	cached    *cluster.Discovery
+}
+
+func (f *fakeDiscovery) Discovery(ctx context.Context, clusterID string) (*cluster.Discovery, error) {
+	if f.err != nil {
+		f.fetches++
+		return nil, f.err
+	}
+	if f.cached == nil {
+		f.fetches++
+		f.cached = discoveryOf(f.resources)
+		f.cached.FetchedAt = time.Unix(int64(f.fetches), 0)
+	}
+	return f.cached, nil
+}
+
+func (f *fakeDiscovery) InvalidateDiscovery(clusterID string) {
+	f.cached = nil
+}
+
+// discoveryOf builds the discovery data that serves the given resources.
+func discoveryOf(resources []ServedResource) *cluster.Discovery {
+	d := &cluster.Discovery{}
+	groups := make(map[string]int)
+	lists := make(map[string]*metav1.APIResourceList)
+	for _, r := range resources {
+		i, ok := groups[r.Group]
+		if !ok {
+			i = len(d.Groups)
+			groups[r.Group] = i
+			d.Groups = append(d.Groups, metav1.APIGroup{Name: r.Group})
+		}
+		gv := schema.GroupVersion{Group: r.Group, Version: r.Version}.String()
+		list, ok := lists[gv]
+		if !ok {
+			list = &metav1.APIResourceList{GroupVersion: gv}
+			lists[gv] = list
+			d.Resources = append(d.Resources, list)
+			version := metav1.GroupVersionForDiscovery{GroupVersion: gv, Version: r.Version}
+			d.Groups[i].Versions = append(d.Groups[i].Versions, version)
+			if r.Preferred || len(d.Groups[i].Versions) == 1 {
+				d.Groups[i].PreferredVersion = version
+			}
+		}
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name: r.Resource, Kind: r.Kind, Namespaced: r.Namespaced, Verbs: r.Verbs,
+		})
+	}
+	return d
+}
+
+func TestDiscoveryCache_ReusesConversionUntilRefetch(t *testing.T) {
+	source := &fakeDiscovery{resources: []ServedResource{{APIResourceEntry: APIResourceEntry{Resource: "pods", Version: "v1"}}}}
+	cache := newDiscoveryCache(source)
+
+	first, err := cache.Get(context.Background(), "c1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	again, _ := cache.Get(context.Background(), "c1")
+	if first != again || source.fetches != 1 {
+		t.Fatalf("expected the cached result to be reused, got %d fetches", source.fetches)
+	}
+	if len(first.Resources) != 1 || first.Resources[0].Resource != "pods" || !first.Resources[0].Preferred {
+		t.Errorf("unexpected resources: %+v", first.Resources)
+	}
+
+	cache.Invalidate("c1")
+	refetched, _ := cache.Get(context.Background(), "c1")
+	if refetched == first || source.fetches != 2 {
+		t.Errorf("expected invalidate to refetch through the manager, got %d fetches", source.fetches)
+	}
+}
+
+func TestDiscoveryCache_Lookup(t *testing.T) {
+	cache := newDiscoveryCache(&fakeDiscovery{resources: []ServedResource{
+		{APIResourceEntry: APIResourceEntry{Group: "example.com", Version: "v1", Resource: "widgets", Namespaced: true}},
+	}})
+
+	res, found, err := cache.Lookup(context.Background(), "c1", schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"})
+	if err != nil || !found || !res.Namespaced {
+		t.Errorf("expected widgets to be found: res=%+v found=%v err=%v", res, found, err)
+	}
+	_, found, _ = cache.Lookup(context.Background(), "c1", schema.GroupVersionResource{Group: "example.com", Version: "v2", Resource: "widgets"})
+	if found {
+		t.Error("expected unserved version not to be found")
+	}
+}
+
+func TestDiscoveryHandler_Refresh(t *testing.T) {
+	source := &fakeDiscovery{resources: []ServedResource{{APIResourceEntry: APIResourceEntry{Resource: "pods", Version: "v1"}}}}
+	cache := newDiscoveryCache(source)
+	r := mux.NewRouter()
+	NewDiscoveryHandler(cache).RegisterRoutes(r)
+
+	for _, method := range []string{http.MethodGet, http.MethodGet, http.MethodPost} {
+		path := "/api/clusters/c1/discovery"
+		if method == http.MethodPost {
+			path += "/refresh"
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s: expected 200, got %d: %s", method, path, w.Code, w.Body.String())
+		}
+		var result DiscoveryResult
+		if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(result.Resources) != 1 {
+			t.Errorf("expected 1 resource, got %d", len(result.Resources))
+		}
+	}
+	if source.fetches != 2 {
+		t.Errorf("expected a cached GET and a refetch on refresh, got %d fetches", source.fetches)
+	}
+}
+
+func TestDiscoveryHandler_ClusterUnavailable(t *testing.T) {
+	cache := newDiscoveryCache(&fakeDiscovery{err: errClusterUnavailable})
+	r := mux.NewRouter()
+	NewDiscoveryHandler(cache).RegisterRoutes(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/clusters/c1/discovery", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+	var body httputil.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Code != httputil.CodeNotConnected {
+		t.Errorf("expected code %q, got %q", httputil.CodeNotConnected, body.Code)
+	}
+}
+
+func TestResourceHandler_UnservedResourceType(t *testing.T) {
+	cache := newDiscoveryCache(&fakeDiscovery{resources: []ServedResource{{APIResourceEntry: APIResourceEntry{Version: "v1", Resource: "pods", Namespaced: true}}}})
+	r := mux.NewRouter()
+	NewResourceHandler(nil, cache).RegisterRoutes(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/clusters/c1/resources/example.com/v1/widgets", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unserved resource type, got %d", w.Code)
+	}
+}
@@ -0,0 +1,413 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/redact"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+// defaultDriftResources is the set of resources compared when the request
+// does not specify any.
+var defaultDriftResources = []driftResource{
+	{Group: "apps", Version: "v1", Resource: "deployments"},
+	{Group: "apps", Version: "v1", Resource: "statefulsets"},
+	{Group: "apps", Version: "v1", Resource: "daemonsets"},
+	{Group: "", Version: "v1", Resource: "services"},
+	{Group: "", Version: "v1", Resource: "configmaps"},
+}
+
+// driftNoisePaths are always stripped before comparison: server-populated
+// metadata and status that differ between clusters without signalling drift.
+var driftNoisePaths = []string{
+	"status",
+	"metadata.namespace",
+	"metadata.resourceVersion",
+	"metadata.uid",
+	"metadata.creationTimestamp",
+	"metadata.generation",
+	"metadata.managedFields",
+	"metadata.selfLink",
+	"metadata.ownerReferences",
+	"metadata.annotations.kubectl.kubernetes.io/last-applied-configuration",
+	"metadata.annotations.deployment.kubernetes.io/revision",
+	"spec.clusterIP",
+	"spec.clusterIPs",
+}
+
+// maxDriftResources caps how many resource types a single comparison may request.
+const maxDriftResources = 20
+
+// DriftHandler compares the same namespace across two clusters and reports
+// spec-level differences between matching objects.
+type DriftHandler struct {
+	backendFor func(clusterID string, gvr schema.GroupVersionResource) (resourceBackend, error)
+	authz      rbac.Evaluator
+	redactor   *redact.Redactor
+}
+
+// NewDriftHandler creates a new DriftHandler.
+func NewDriftHandler(cm *cluster.Manager, engine *rbac.Engine) *DriftHandler {
+	return &DriftHandler{
+		backendFor: func(clusterID string, gvr schema.GroupVersionResource) (resourceBackend, error) {
+			return resourceBackendFor(cm, clusterID, gvr)
+		},
+		authz: engine,
+	}
+}
+
+// SetRedactor masks secret material in compared objects for users without
+// the secrets:reveal permission on both clusters. Without a redactor, such
+// users may not compare Secrets.
+func (h *DriftHandler) SetRedactor(r *redact.Redactor) {
+	h.redactor = r
+}
+
+// RegisterRoutes wires the cluster comparison endpoint.
+func (h *DriftHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clusters/compare", h.Compare).Methods(http.MethodPost)
+}
+
+// driftResource identifies a resource type to compare.
+type driftResource struct {
+	Group    string `json:"group"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+}
+
+// driftRequest is the JSON body accepted by the comparison endpoint.
+type driftRequest struct {
+	SourceClusterID string          `json:"sourceClusterId"`
+	TargetClusterID string          `json:"targetClusterId"`
+	Namespace       string          `json:"namespace"`
+	Resources       []driftResource `json:"resources"`
+	IgnorePaths     []string        `json:"ignorePaths"`
+}
+
+// fieldDiff is a single differing field between the source and target object.
+// A nil Source or Target means the field is absent on that side.
+type fieldDiff struct {
+	Path   string      `json:"path"`
+	Source interface{} `json:"source"`
+	Target interface{} `json:"target"`
+}
+
+// objectDrift lists the differing fields of one object present in both clusters.
+type objectDrift struct {
+	Name  string      `json:"name"`
+	Diffs []fieldDiff `json:"diffs"`
+}
+
+// resourceDrift is the comparison result for one resource type.
+type resourceDrift struct {
+	Group        string        `json:"group"`
+	Version      string        `json:"version"`
+	Resource     string        `json:"resource"`
+	OnlyInSource []string      `json:"onlyInSource"`
+	OnlyInTarget []string      `json:"onlyInTarget"`
+	Drifted      []objectDrift `json:"drifted"`
+	Identical    int           `json:"identical"`
+	Error        string        `json:"error,omitempty"`
+}
+
+// driftResponse is the full comparison report.
+type driftResponse struct {
+	SourceClusterID string          `json:"sourceClusterId"`
+	TargetClusterID string          `json:"targetClusterId"`
+	Namespace       string          `json:"namespace"`
+	InSync          bool            `json:"inSync"`
+	Resources       []resourceDrift `json:"resources"`
+}
+
+// validate checks the request and fills in the default resource set.
+func (req *driftRequest) validate() error {
+	if req.SourceClusterID == "" || req.TargetClusterID == "" {
+		return fmt.Errorf("sourceClusterId and targetClusterId are required")
+	}
+	if req.SourceClusterID == req.TargetClusterID {
+		return fmt.Errorf("sourceClusterId and targetClusterId must differ")
+	}
+	if req.Namespace == "" {
+		return fmt.Errorf("namespace is required")
+	}
+	if !validate.Segment(req.Namespace) {
+		return fmt.Errorf("invalid namespace")
+	}
+	if len(req.Resources) == 0 {
+		req.Resources = defaultDriftResources
+	}
+	if len(req.Resources) > maxDriftResources {
+		return fmt.Errorf("at most %d resources may be compared at once", maxDriftResources)
+	}
+	for i := range req.Resources {
+		res := &req.Resources[i]
+		if res.Group == "_" {
+			res.Group = ""
+		}
+		if res.Version == "" || res.Resource == "" {
+			return fmt.Errorf("each resource requires version and resource")
+		}
+		if !validate.Segment(res.Group) || !validate.Segment(res.Version) || !validate.Segment(res.Resource) {
+			return fmt.Errorf("invalid group, version, or resource")
+		}
+	}
+	return nil
+}
+
+// Compare lists the requested resources in the same namespace on both
+// clusters and returns a structured diff of the objects found on both sides.
+func (h *DriftHandler) Compare(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req driftRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize)).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := req.validate(); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reveal := true
+	for _, clusterID := range []string{req.SourceClusterID, req.TargetClusterID} {
+		for _, res := range req.Resources {
+			allowed, err := h.authz.Evaluate(r.Context(), rbac.Request{
+				UserID:      claims.UserID,
+				Action:      "read",
+				Resource:    rbac.ResourceCategory(res.Resource),
+				ClusterID:   clusterID,
+				Namespace:   req.Namespace,
+				K8sResource: rbac.QualifiedResource(res.Group, res.Resource),
+			})
+			if err != nil {
+				httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+				return
+			}
+			if !allowed {
+				httputil.WriteError(w, http.StatusForbidden, "insufficient permissions to read "+res.Resource+" in "+clusterID)
+				return
+			}
+		}
+		allowed, err := h.authz.Evaluate(r.Context(), rbac.Request{
+			UserID:    claims.UserID,
+			Action:    redact.RevealAction,
+			Resource:  redact.RevealResource,
+			ClusterID: clusterID,
+		})
+		if err != nil || !allowed {
+			reveal = false
+		}
+	}
+
+	redactor := h.redactor
+	if reveal {
+		redactor = nil
+	} else if redactor == nil {
+		for _, res := range req.Resources {
+			if res.Group == "" && res.Resource == "secrets" {
+				httputil.WriteError(w, http.StatusForbidden, "comparing secrets requires the secrets:reveal permission")
+				return
+			}
+		}
+	}
+
+	ignore := append(append([]string{}, driftNoisePaths...), req.IgnorePaths...)
+
+	resp := driftResponse{
+		SourceClusterID: req.SourceClusterID,
+		TargetClusterID: req.TargetClusterID,
+		Namespace:       req.Namespace,
+		InSync:          true,
+		Resources:       make([]resourceDrift, 0, len(req.Resources)),
+	}
+
+	for _, res := range req.Resources {
+		gvr := schema.GroupVersionResource{Group: res.Group, Version: res.Version, Resource: res.Resource}
+		result := resourceDrift{Group: res.Group, Version: res.Version, Resource: res.Resource}
+
+		src, err := h.listForDrift(r, req.SourceClusterID, gvr, req.Namespace, redactor)
+		if err != nil {
+			result.Error = fmt.Sprintf("source: %v", err)
+			resp.InSync = false
+			resp.Resources = append(resp.Resources, result)
+			continue
+		}
+		dst, err := h.listForDrift(r, req.TargetClusterID, gvr, req.Namespace, redactor)
+		if err != nil {
+			result.Error = fmt.Sprintf("target: %v", err)
+			resp.InSync = false
+			resp.Resources = append(resp.Resources, result)
+			continue
+		}
+
+		compareObjectSets(&result, src, dst, ignore)
+		if len(result.OnlyInSource) > 0 || len(result.OnlyInTarget) > 0 || len(result.Drifted) > 0 {
+			resp.InSync = false
+		}
+		resp.Resources = append(resp.Resources, result)
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+// listForDrift lists a resource type in a namespace, redacts the objects
+// with redactor and indexes them by name.
+func (h *DriftHandler) listForDrift(r *http.Request, clusterID string, gvr schema.GroupVersionResource, namespace string, redactor *redact.Redactor) (map[string]unstructured.Unstructured, error) {
+	backend, err := h.backendFor(clusterID, gvr)
+	if err != nil {
+		return nil, err
+	}
+	items, err := backend.list(r.Context(), namespace, "", "")
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]unstructured.Unstructured, len(items))
+	for _, item := range items {
+		// List items fetched through an agent carry no kind, which the
+		// redactor needs to recognise Secrets and ConfigMaps.
+		if kind, ok := redactedKinds[gvr.Resource]; ok && gvr.Group == "" && item.GetKind() == "" {
+			item.SetKind(kind)
+		}
+		redactor.Redact(item.Object)
+		byName[item.GetName()] = item
+	}
+	return byName, nil
+}
+
+// redactedKinds names the core kinds whose data the redactor masks.
+var redactedKinds = map[string]string{
+	"secrets":    "Secret",
+	"configmaps": "ConfigMap",
+}
+
+// compareObjectSets fills result with the objects missing on either side and
+// the field-level differences for objects present on both.
+func compareObjectSets(result *resourceDrift, src, dst map[string]unstructured.Unstructured, ignore []string) {
+	result.OnlyInSource = []string{}
+	result.OnlyInTarget = []string{}
+	result.Drifted = []objectDrift{}
+
+	names := make([]string, 0, len(src))
+	for name := range src {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		target, ok := dst[name]
+		if !ok {
+			result.OnlyInSource = append(result.OnlyInSource, name)
+			continue
+		}
+		diffs := diffObjects(src[name].Object, target.Object, ignore)
+		if len(diffs) == 0 {
+			result.Identical++
+			continue
+		}
+		result.Drifted = append(result.Drifted, objectDrift{Name: name, Diffs: diffs})
+	}
+
+	for name := range dst {
+		if _, ok := src[name]; !ok {
+			result.OnlyInTarget = append(result.OnlyInTarget, name)
+		}
+	}
+	sort.Strings(result.OnlyInTarget)
+}
+
+// diffObjects returns the differing leaf fields between two objects, skipping
+// any path covered by the ignore list.
+func diffObjects(src, dst map[string]interface{}, ignore []string) []fieldDiff {
+	var diffs []fieldDiff
+	diffValues("", src, dst, ignore, &diffs)
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+func diffValues(path string, a, b interface{}, ignore []string, diffs *[]fieldDiff) {
+	if isIgnoredPath(path, ignore) {
+		return
+	}
+
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make(map[string]struct{}, len(av)+len(bv))
+		for k := range av {
+			keys[k] = struct{}{}
+		}
+		for k := range bv {
+			keys[k] = struct{}{}
+		}
+		for k := range keys {
+			diffValues(joinPath(path, k), av[k], bv[k], ignore, diffs)
+		}
+		return
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		n := len(av)
+		if len(bv) > n {
+			n = len(bv)
+		}
+		for i := 0; i < n; i++ {
+			var ai, bi interface{}
+			if i < len(av) {
+				ai = av[i]
+			}
+			if i < len(bv) {
+				bi = bv[i]
+			}
+			diffValues(fmt.Sprintf("%s[%d]", path, i), ai, bi, ignore, diffs)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*diffs = append(*diffs, fieldDiff{Path: path, Source: a, Target: b})
+	}
+}
+
+// joinPath appends a map key to a dotted path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// isIgnoredPath reports whether path equals or is nested under any ignore entry.
+func isIgnoredPath(path string, ignore []string) bool {
+	if path == "" {
+		return false
+	}
+	for _, p := range ignore {
+		if path == p || strings.HasPrefix(path, p+".") || strings.HasPrefix(path, p+"[") {
+			return true
+		}
+	}
+	return false
+}
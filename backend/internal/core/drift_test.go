@@ -0,0 +1,275 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/redact"
+)
+
+func TestDriftRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     driftRequest
+		wantErr string
+	}{
+		{
+			name: "defaults resources",
+			req:  driftRequest{SourceClusterID: "a", TargetClusterID: "b", Namespace: "default"},
+		},
+		{
+			name:    "missing cluster",
+			req:     driftRequest{SourceClusterID: "a", Namespace: "default"},
+			wantErr: "are required",
+		},
+		{
+			name:    "same cluster",
+			req:     driftRequest{SourceClusterID: "a", TargetClusterID: "a", Namespace: "default"},
+			wantErr: "must differ",
+		},
+		{
+			name:    "missing namespace",
+			req:     driftRequest{SourceClusterID: "a", TargetClusterID: "b"},
+			wantErr: "namespace is required",
+		},
+		{
+			name:    "invalid resource",
+			req:     driftRequest{SourceClusterID: "a", TargetClusterID: "b", Namespace: "default", Resources: []driftResource{{Version: "v1", Resource: "../secrets"}}},
+			wantErr: "invalid group",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if len(tt.req.Resources) != len(defaultDriftResources) {
+					t.Errorf("expected default resources to be filled in, got %d", len(tt.req.Resources))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDiffObjects_IgnoresNoise(t *testing.T) {
+	src := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":            "web",
+			"namespace":       "default",
+			"resourceVersion": "100",
+			"uid":             "aaa",
+			"managedFields":   []interface{}{map[string]interface{}{"manager": "kubectl"}},
+		},
+		"spec":   map[string]interface{}{"replicas": int64(3)},
+		"status": map[string]interface{}{"readyReplicas": int64(3)},
+	}
+	dst := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":            "web",
+			"namespace":       "default",
+			"resourceVersion": "999",
+			"uid":             "bbb",
+		},
+		"spec":   map[string]interface{}{"replicas": int64(3)},
+		"status": map[string]interface{}{"readyReplicas": int64(1)},
+	}
+
+	if diffs := diffObjects(src, dst, driftNoisePaths); len(diffs) != 0 {
+		t.Errorf("expected no diffs, got %+v", diffs)
+	}
+}
+
+func TestDiffObjects_ReportsSpecDrift(t *testing.T) {
+	src := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "app", "image": "app:1.0"},
+					},
+				},
+			},
+		},
+	}
+	dst := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(5),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "app", "image": "app:1.1"},
+						map[string]interface{}{"name": "sidecar", "image": "proxy:1"},
+					},
+				},
+			},
+		},
+	}
+
+	diffs := diffObjects(src, dst, driftNoisePaths)
+	paths := make([]string, len(diffs))
+	for i, d := range diffs {
+		paths[i] = d.Path
+	}
+	want := []string{
+		"spec.replicas",
+		"spec.template.spec.containers[0].image",
+		"spec.template.spec.containers[1]",
+	}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("expected paths %v, got %v", want, paths)
+	}
+	if diffs[2].Source != nil {
+		t.Errorf("expected missing source element to be nil, got %v", diffs[2].Source)
+	}
+}
+
+func TestDiffObjects_IgnorePaths(t *testing.T) {
+	src := map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(3), "paused": false}}
+	dst := map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(5), "paused": true}}
+
+	diffs := diffObjects(src, dst, []string{"spec.replicas"})
+	if len(diffs) != 1 || diffs[0].Path != "spec.paused" {
+		t.Errorf("expected only spec.paused diff, got %+v", diffs)
+	}
+}
+
+func TestIsIgnoredPath(t *testing.T) {
+	ignore := []string{"status", "spec.template.metadata.annotations"}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"status", true},
+		{"status.conditions[0]", true},
+		{"statusX", false},
+		{"spec.template.metadata.annotations.foo", true},
+		{"spec.template.metadata.labels", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isIgnoredPath(tt.path, ignore); got != tt.want {
+			t.Errorf("isIgnoredPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCompareObjectSets(t *testing.T) {
+	mk := func(name string, replicas int64) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": name},
+			"spec":     map[string]interface{}{"replicas": replicas},
+		}}
+	}
+	src := map[string]unstructured.Unstructured{"a": mk("a", 1), "b": mk("b", 2), "c": mk("c", 1)}
+	dst := map[string]unstructured.Unstructured{"a": mk("a", 1), "b": mk("b", 3), "d": mk("d", 1)}
+
+	var result resourceDrift
+	compareObjectSets(&result, src, dst, driftNoisePaths)
+
+	if result.Identical != 1 {
+		t.Errorf("expected 1 identical, got %d", result.Identical)
+	}
+	if len(result.Drifted) != 1 || result.Drifted[0].Name != "b" {
+		t.Errorf("expected b to drift, got %+v", result.Drifted)
+	}
+	if len(result.OnlyInSource) != 1 || result.OnlyInSource[0] != "c" {
+		t.Errorf("expected c only in source, got %v", result.OnlyInSource)
+	}
+	if len(result.OnlyInTarget) != 1 || result.OnlyInTarget[0] != "d" {
+		t.Errorf("expected d only in target, got %v", result.OnlyInTarget)
+	}
+}
+
+// listBackend serves list from a function; the write methods are unused.
+type listBackend func() ([]unstructured.Unstructured, error)
+
+func (b listBackend) list(ctx context.Context, namespace, labelSelector, fieldSelector string) ([]unstructured.Unstructured, error) {
+	return b()
+}
+func (b listBackend) delete(ctx context.Context, namespace, name string, dryRun bool) error {
+	return errors.New("unsupported")
+}
+func (b listBackend) patch(ctx context.Context, namespace, name string, patch []byte, dryRun bool) error {
+	return errors.New("unsupported")
+}
+
+// driftAccess grants read on the apps category in the listed clusters, and
+// secrets:reveal when reveal is set.
+type driftAccess struct {
+	clusters map[string]bool
+	reveal   bool
+}
+
+func (a driftAccess) Evaluate(ctx context.Context, req rbac.Request) (bool, error) {
+	if req.Action == redact.RevealAction {
+		return a.reveal, nil
+	}
+	return req.Action == "read" && req.Resource == "apps" && a.clusters[req.ClusterID], nil
+}
+
+// compareSecrets compares a Secret whose value differs between clusters a
+// and b, listed as an agent would, without a kind.
+func compareSecrets(t *testing.T, access driftAccess, redactor *redact.Redactor) *httptest.ResponseRecorder {
+	t.Helper()
+	secret := func(value string) []unstructured.Unstructured {
+		return []unstructured.Unstructured{{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "db", "namespace": "shop"},
+			"data":     map[string]interface{}{"password": value},
+		}}}
+	}
+	h := &DriftHandler{
+		authz:    access,
+		redactor: redactor,
+		backendFor: func(clusterID string, gvr schema.GroupVersionResource) (resourceBackend, error) {
+			return listBackend(func() ([]unstructured.Unstructured, error) { return secret(clusterID + "-value"), nil }), nil
+		},
+	}
+	body := `{"sourceClusterId":"a","targetClusterId":"b","namespace":"shop","resources":[{"version":"v1","resource":"secrets"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/clusters/compare", bytes.NewBufferString(body))
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), &auth.Claims{UserID: "u1"}))
+	w := httptest.NewRecorder()
+	h.Compare(w, req)
+	return w
+}
+
+func TestDriftCompare_Permissions(t *testing.T) {
+	both := map[string]bool{"a": true, "b": true}
+
+	if w := compareSecrets(t, driftAccess{clusters: map[string]bool{"a": true}}, redact.NewRedactor(nil)); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 without read on the target cluster, got %d", w.Code)
+	}
+	if w := compareSecrets(t, driftAccess{clusters: both}, nil); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 comparing secrets without reveal or a redactor, got %d", w.Code)
+	}
+
+	w := compareSecrets(t, driftAccess{clusters: both}, redact.NewRedactor(nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "-value") || !strings.Contains(w.Body.String(), `"identical":1`) {
+		t.Errorf("expected redacted, identical secrets, got %s", w.Body.String())
+	}
+
+	w = compareSecrets(t, driftAccess{clusters: both, reveal: true}, redact.NewRedactor(nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "a-value") {
+		t.Errorf("expected the secret values with reveal, got %d: %s", w.Code, w.Body.String())
+	}
+}
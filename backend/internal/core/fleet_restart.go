@@ -0,0 +1,257 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+// maxFleetClusters caps how many clusters a single fleet restart may target.
+const maxFleetClusters = 50
+
+// Fleet restart concurrency: how many clusters are listed and workloads
+// restarted at once, unless the request asks for another value up to the
+// maximum.
+const (
+	defaultFleetConcurrency = 5
+	maxFleetConcurrency     = 20
+)
+
+// fleetRestartRequest is the JSON body accepted by the fleet restart endpoint.
+type fleetRestartRequest struct {
+	ClusterIDs    []string `json:"clusterIds"`
+	Resources     []string `json:"resources"`
+	Namespace     string   `json:"namespace"`
+	LabelSelector string   `json:"labelSelector"`
+	DryRun        bool     `json:"dryRun"`
+	Concurrency   int      `json:"concurrency"`
+}
+
+// fleetWorkloadResult is the outcome of restarting a single workload.
+type fleetWorkloadResult struct {
+	Resource string `json:"resource"`
+	bulkItemResult
+}
+
+// fleetClusterResult lists the workloads restarted on one cluster. Error is
+// set when the cluster could not be reached or listed, in which case none of
+// its workloads were restarted.
+type fleetClusterResult struct {
+	ClusterID string                `json:"clusterId"`
+	Error     string                `json:"error,omitempty"`
+	Results   []fleetWorkloadResult `json:"results"`
+}
+
+// fleetRestartResponse is the aggregated result of a fleet restart.
+type fleetRestartResponse struct {
+	DryRun    bool                 `json:"dryRun"`
+	Total     int                  `json:"total"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Forbidden int                  `json:"forbidden"`
+	Clusters  []fleetClusterResult `json:"clusters"`
+}
+
+// fleetTarget is a workload matched on one of the clusters.
+type fleetTarget struct {
+	cluster  int
+	backend  resourceBackend
+	resource string
+	item     unstructured.Unstructured
+}
+
+// validate checks the request, removes duplicate clusters and resources, and
+// fills in the defaults.
+func (req *fleetRestartRequest) validate() error {
+	req.ClusterIDs = dedupeStrings(req.ClusterIDs)
+	if len(req.ClusterIDs) == 0 {
+		return fmt.Errorf("clusterIds is required")
+	}
+	if len(req.ClusterIDs) > maxFleetClusters {
+		return fmt.Errorf("at most %d clusters may be restarted at once", maxFleetClusters)
+	}
+	for _, id := range req.ClusterIDs {
+		if id == "" {
+			return fmt.Errorf("clusterIds must not contain empty IDs")
+		}
+	}
+
+	if req.LabelSelector == "" {
+		return fmt.Errorf("labelSelector is required")
+	}
+	selector, err := labels.Parse(req.LabelSelector)
+	if err != nil {
+		return fmt.Errorf("invalid labelSelector: %v", err)
+	}
+	if selector.Empty() {
+		return fmt.Errorf("labelSelector must select on at least one label")
+	}
+	if !validate.Segment(req.Namespace) {
+		return fmt.Errorf("invalid namespace")
+	}
+
+	req.Resources = dedupeStrings(req.Resources)
+	if len(req.Resources) == 0 {
+		req.Resources = []string{"deployments"}
+	}
+	for _, res := range req.Resources {
+		if !restartableResources[res] {
+			return fmt.Errorf("restart is only supported for deployments, statefulsets, and daemonsets")
+		}
+	}
+
+	if req.Concurrency == 0 {
+		req.Concurrency = defaultFleetConcurrency
+	}
+	if req.Concurrency < 1 || req.Concurrency > maxFleetConcurrency {
+		return fmt.Errorf("concurrency must be between 1 and %d", maxFleetConcurrency)
+	}
+	return nil
+}
+
+// record adds a workload result to its cluster and updates the counters.
+func (resp *fleetRestartResponse) record(cluster int, res fleetWorkloadResult) {
+	c := &resp.Clusters[cluster]
+	c.Results = append(c.Results, res)
+	switch res.Status {
+	case bulkStatusSucceeded:
+		resp.Succeeded++
+	case bulkStatusForbidden:
+		resp.Forbidden++
+	default:
+		resp.Failed++
+	}
+}
+
+// RestartFleet restarts the workloads matching a label selector on every
+// listed cluster. Clusters are listed first, so nothing is restarted when the
+// selector matches more than maxBulkTargets workloads in total; restarts then
+// run with at most the requested concurrency, checking RBAC per workload.
+func (h *BulkHandler) RestartFleet(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req fleetRestartRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize)).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := req.validate(); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp := &fleetRestartResponse{DryRun: req.DryRun, Clusters: make([]fleetClusterResult, len(req.ClusterIDs))}
+	targets := make([][]fleetTarget, len(req.ClusterIDs))
+	forEachLimit(len(req.ClusterIDs), req.Concurrency, func(i int) {
+		resp.Clusters[i].ClusterID = req.ClusterIDs[i]
+		resp.Clusters[i].Results = []fleetWorkloadResult{}
+		found, err := h.listFleetTargets(r.Context(), i, req.ClusterIDs[i], &req)
+		if err != nil {
+			resp.Clusters[i].Error = err.Error()
+			return
+		}
+		targets[i] = found
+	})
+
+	var all []fleetTarget
+	for _, found := range targets {
+		all = append(all, found...)
+	}
+	if len(all) > maxBulkTargets {
+		httputil.WriteError(w, http.StatusBadRequest,
+			fmt.Sprintf("selector matches %d workloads; narrow it to at most %d", len(all), maxBulkTargets))
+		return
+	}
+	resp.Total = len(all)
+
+	patch := (&bulkRequest{Action: bulkActionRestart}).patchBody(time.Now())
+	results := make([]fleetWorkloadResult, len(all))
+	forEachLimit(len(all), req.Concurrency, func(i int) {
+		t := all[i]
+		res := fleetWorkloadResult{Resource: t.resource}
+		if r.Context().Err() != nil {
+			res.bulkItemResult = bulkItemResult{Name: t.item.GetName(), Namespace: t.item.GetNamespace(), Status: bulkStatusFailed, Error: "request cancelled"}
+		} else {
+			bulkReq := &bulkRequest{Action: bulkActionRestart, Group: "apps", Version: "v1", Resource: t.resource, DryRun: req.DryRun}
+			res.bulkItemResult = h.applyOne(r.Context(), t.backend, bulkReq, patch, claims.UserID, req.ClusterIDs[t.cluster], t.item)
+		}
+		results[i] = res
+	})
+	for i, res := range results {
+		resp.record(all[i].cluster, res)
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+// listFleetTargets lists the workloads of every requested resource matching
+// the selector on one cluster. It fails for a cluster in maintenance mode.
+func (h *BulkHandler) listFleetTargets(ctx context.Context, cluster int, clusterID string, req *fleetRestartRequest) ([]fleetTarget, error) {
+	if err := h.clusterMgr.CheckWritable(ctx, clusterID); err != nil {
+		return nil, err
+	}
+	var targets []fleetTarget
+	for _, res := range req.Resources {
+		gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: res}
+		backend, err := resourceBackendFor(h.clusterMgr, clusterID, gvr)
+		if err != nil {
+			return nil, err
+		}
+		items, err := backend.list(ctx, req.Namespace, req.LabelSelector, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %v", res, err)
+		}
+		for _, item := range items {
+			targets = append(targets, fleetTarget{cluster: cluster, backend: backend, resource: res, item: item})
+		}
+	}
+	return targets, nil
+}
+
+// forEachLimit calls fn for every index below n, running at most limit calls
+// at once, and returns when all of them have.
+func forEachLimit(n, limit int, fn func(i int)) {
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
+
+// dedupeStrings returns values without duplicates, keeping the first
+// occurrence of each.
+func dedupeStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := values[:0]
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFleetRestartRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     fleetRestartRequest
+		wantErr string
+	}{
+		{
+			name: "deployments by label",
+			req:  fleetRestartRequest{ClusterIDs: []string{"a", "b"}, LabelSelector: "app=web"},
+		},
+		{
+			name: "statefulsets in namespace",
+			req:  fleetRestartRequest{ClusterIDs: []string{"a"}, LabelSelector: "tier in (db)", Namespace: "data", Resources: []string{"statefulsets"}, Concurrency: 2},
+		},
+		{
+			name:    "no clusters",
+			req:     fleetRestartRequest{LabelSelector: "app=web"},
+			wantErr: "clusterIds is required",
+		},
+		{
+			name:    "empty cluster ID",
+			req:     fleetRestartRequest{ClusterIDs: []string{"a", ""}, LabelSelector: "app=web"},
+			wantErr: "empty IDs",
+		},
+		{
+			name:    "no selector",
+			req:     fleetRestartRequest{ClusterIDs: []string{"a"}},
+			wantErr: "labelSelector is required",
+		},
+		{
+			name:    "malformed selector",
+			req:     fleetRestartRequest{ClusterIDs: []string{"a"}, LabelSelector: "app in web"},
+			wantErr: "invalid labelSelector",
+		},
+		{
+			name:    "pods rejected",
+			req:     fleetRestartRequest{ClusterIDs: []string{"a"}, LabelSelector: "app=web", Resources: []string{"pods"}},
+			wantErr: "restart is only supported",
+		},
+		{
+			name:    "concurrency too high",
+			req:     fleetRestartRequest{ClusterIDs: []string{"a"}, LabelSelector: "app=web", Concurrency: maxFleetConcurrency + 1},
+			wantErr: "concurrency must be",
+		},
+		{
+			name:    "path traversal namespace",
+			req:     fleetRestartRequest{ClusterIDs: []string{"a"}, LabelSelector: "app=web", Namespace: "../kube-system"},
+			wantErr: "invalid namespace",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFleetRestartRequestValidate_Defaults(t *testing.T) {
+	req := fleetRestartRequest{ClusterIDs: []string{"a", "b", "a"}, LabelSelector: "app=web"}
+	if err := req.validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(req.ClusterIDs, ",") != "a,b" {
+		t.Errorf("expected duplicate clusters to be dropped, got %v", req.ClusterIDs)
+	}
+	if len(req.Resources) != 1 || req.Resources[0] != "deployments" {
+		t.Errorf("expected deployments by default, got %v", req.Resources)
+	}
+	if req.Concurrency != defaultFleetConcurrency {
+		t.Errorf("expected default concurrency, got %d", req.Concurrency)
+	}
+}
+
+func TestFleetRestartResponseRecord(t *testing.T) {
+	resp := &fleetRestartResponse{Clusters: make([]fleetClusterResult, 2)}
+	resp.record(0, fleetWorkloadResult{Resource: "deployments", bulkItemResult: bulkItemResult{Name: "a", Status: bulkStatusSucceeded}})
+	resp.record(1, fleetWorkloadResult{Resource: "deployments", bulkItemResult: bulkItemResult{Name: "b", Status: bulkStatusForbidden}})
+	resp.record(1, fleetWorkloadResult{Resource: "daemonsets", bulkItemResult: bulkItemResult{Name: "c", Status: bulkStatusFailed}})
+
+	if resp.Succeeded != 1 || resp.Forbidden != 1 || resp.Failed != 1 {
+		t.Errorf("unexpected counters: %+v", resp)
+	}
+	if len(resp.Clusters[0].Results) != 1 || len(resp.Clusters[1].Results) != 2 {
+		t.Errorf("expected results grouped by cluster, got %+v", resp.Clusters)
+	}
+}
+
+func TestForEachLimit(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+	done := make([]bool, 20)
+	forEachLimit(len(done), 3, func(i int) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+
+		done[i] = true
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+
+	if peak > 3 {
+		t.Errorf("expected at most 3 concurrent calls, got %d", peak)
+	}
+	for i, ok := range done {
+		if !ok {
+			t.Errorf("expected index %d to be processed", i)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
 	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/validate"
 	"github.com/darkden-lab/argus/backend/pkg/agentpb"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -107,7 +108,7 @@ func (h *ConvenienceHandlers) ListNodes(w http.ResponseWriter, r *http.Request)
 func (h *ConvenienceHandlers) ListEvents(w http.ResponseWriter, r *http.Request) {
 	clusterID := mux.Vars(r)["clusterID"]
 	namespace := r.URL.Query().Get("namespace")
-	if !isValidK8sSegment(namespace) {
+	if !validate.Segment(namespace) {
 		httputil.WriteError(w, http.StatusBadRequest, "invalid namespace")
 		return
 	}
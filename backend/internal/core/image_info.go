@@ -0,0 +1,354 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+// Image risk types reported for a container.
+const (
+	// imageRiskLatestAlways is the latest tag pulled on every start: each
+	// restart or reschedule may run a different image.
+	imageRiskLatestAlways = "latestAlwaysPull"
+	// imageRiskLatest is the latest tag, named or implied by a missing tag.
+	imageRiskLatest = "latestTag"
+	// imageRiskUnpinned is an image referenced by tag only, with no digest.
+	imageRiskUnpinned = "unpinnedDigest"
+	// imageRiskDigestDrift is one container running different digests
+	// across the workload's pods, i.e. its tag moved between pulls.
+	imageRiskDigestDrift = "digestDrift"
+)
+
+// imageRisk is a supply-chain hygiene finding for a container image.
+type imageRisk struct {
+	Type string `json:"type"`
+	// Severity is warning for configurations that can silently change what
+	// runs, info for ones that merely make it hard to tell.
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// containerImage is one container's image as configured and as resolved by
+// the kubelet.
+type containerImage struct {
+	Container string `json:"container"`
+	// Init is set for init containers, including sidecars.
+	Init       bool   `json:"init,omitempty"`
+	Image      string `json:"image"`
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+	// PullPolicy is the effective pull policy, with the Kubernetes default
+	// applied when the spec leaves it empty.
+	PullPolicy string `json:"pullPolicy"`
+	// MutableTag is set when the image is referenced by a tag that can be
+	// moved to another image, i.e. it has no digest.
+	MutableTag bool `json:"mutableTag"`
+	// ResolvedDigests are the distinct digests the container's pods run,
+	// taken from status.containerStatuses[].imageID.
+	ResolvedDigests []string    `json:"resolvedDigests"`
+	Risks           []imageRisk `json:"risks"`
+}
+
+// imageInfoResponse is the image report of a pod or workload.
+type imageInfoResponse struct {
+	ClusterID  string           `json:"clusterId"`
+	Kind       string           `json:"kind"`
+	Name       string           `json:"name"`
+	Namespace  string           `json:"namespace"`
+	Pods       int              `json:"pods"`
+	Containers []containerImage `json:"containers"`
+	// Errors maps a section (pods) to why it could not be filled in.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// ImageInfoHandler reports the container images of a pod or workload, the
+// digests they resolved to, and risky tag and pull policy combinations. It
+// only uses what the pod spec and status already carry; no registry is
+// contacted.
+type ImageInfoHandler struct {
+	tree *OwnerTreeHandler
+}
+
+// NewImageInfoHandler creates a new ImageInfoHandler.
+func NewImageInfoHandler(cm *cluster.Manager, engine *rbac.Engine, discovery *DiscoveryCache) *ImageInfoHandler {
+	return &ImageInfoHandler{tree: NewOwnerTreeHandler(cm, engine, discovery)}
+}
+
+// RegisterRoutes wires the image info endpoint.
+func (h *ImageInfoHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clusters/{clusterID}/images/{group}/{version}/{resource}/{name}", h.Get).Methods(http.MethodGet)
+}
+
+// Get returns the image report of a pod or workload. For workloads, resolved
+// digests come from the pods matching its selector; CronJobs have none, so
+// only their spec is reported. If the caller may not read pods, the report
+// still covers the spec and says why digests are missing.
+//
+// Query parameters:
+//   - namespace: the object's namespace
+func (h *ImageInfoHandler) Get(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	clusterID := vars["clusterID"]
+	name := vars["name"]
+	gvr := gvrFromVars(vars)
+	namespace := r.URL.Query().Get("namespace")
+	if !validatePathSegments(w, namespace, name) {
+		return
+	}
+	if !validate.Segment(gvr.Group) || !validate.Segment(gvr.Version) || !validate.Segment(gvr.Resource) {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid group, version, or resource")
+		return
+	}
+
+	res, found, err := h.tree.discovery.Lookup(r.Context(), clusterID, gvr)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("discovery failed: %v", err))
+		return
+	}
+	if !found {
+		httputil.WriteError(w, http.StatusNotFound, fmt.Sprintf("resource type %s is not served by this cluster", gvr.String()))
+		return
+	}
+	specPath, ok := exportPodSpecPaths[res.Kind]
+	if !ok || (gvr.Group != "" && gvr.Group != "apps" && gvr.Group != "batch") {
+		httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("%s do not run containers", gvr.Resource))
+		return
+	}
+
+	access := newReadAccess(h.tree.rbacEngine, claims.UserID, clusterID)
+	allowed, err := access.allowed(r.Context(), gvr, namespace)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+		return
+	}
+	if !allowed {
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+
+	obj, err := h.tree.getObject(r.Context(), clusterID, gvr, namespace, name)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	if obj == nil {
+		httputil.WriteError(w, http.StatusNotFound, fmt.Sprintf("%s %q not found", gvr.Resource, name))
+		return
+	}
+
+	var spec corev1.PodSpec
+	if m, found, _ := unstructured.NestedMap(obj.Object, specPath...); found {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &spec); err != nil {
+			httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("invalid pod spec: %v", err))
+			return
+		}
+	}
+
+	resp := imageInfoResponse{ClusterID: clusterID, Kind: res.Kind, Name: name, Namespace: namespace}
+	var pods []corev1.Pod
+	if res.Kind == "Pod" {
+		var pod corev1.Pod
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &pod); err == nil {
+			pods = append(pods, pod)
+		}
+	} else if res.Kind != "CronJob" {
+		pods, err = h.listPods(r.Context(), clusterID, obj, access)
+		if err != nil {
+			resp.Errors = map[string]string{"pods": err.Error()}
+		}
+	}
+	resp.Pods = len(pods)
+	resp.Containers = inspectImages(spec, pods)
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+// listPods returns the pods matching a workload's selector.
+func (h *ImageInfoHandler) listPods(ctx context.Context, clusterID string, obj *unstructured.Unstructured, access *readAccess) ([]corev1.Pod, error) {
+	m, found, _ := unstructured.NestedMap(obj.Object, "spec", "selector")
+	if !found {
+		return nil, nil
+	}
+	var ls metav1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &ls); err != nil {
+		return nil, fmt.Errorf("invalid selector: %v", err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&ls)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector: %v", err)
+	}
+	if selector.Empty() {
+		return nil, nil
+	}
+
+	allowed, err := access.allowed(ctx, podsGVR, obj.GetNamespace())
+	if err != nil {
+		return nil, fmt.Errorf("permission check failed")
+	}
+	if !allowed {
+		return nil, fmt.Errorf("insufficient permissions")
+	}
+	backend, err := resourceBackendFor(h.tree.clusterMgr, clusterID, podsGVR)
+	if err != nil {
+		return nil, err
+	}
+	items, err := backend.list(ctx, obj.GetNamespace(), selector.String(), "")
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]corev1.Pod, 0, len(items))
+	for _, item := range items {
+		var pod corev1.Pod
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &pod); err == nil {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// inspectImages reports the init containers and containers of spec, with
+// the digests their counterparts in pods resolved to.
+func inspectImages(spec corev1.PodSpec, pods []corev1.Pod) []containerImage {
+	resolved := func(statuses func(*corev1.Pod) []corev1.ContainerStatus, name string) []string {
+		seen := make(map[string]bool)
+		digests := []string{}
+		for i := range pods {
+			for _, s := range statuses(&pods[i]) {
+				if s.Name != name || s.ImageID == "" {
+					continue
+				}
+				if d := imageIDDigest(s.ImageID); !seen[d] {
+					seen[d] = true
+					digests = append(digests, d)
+				}
+			}
+		}
+		sort.Strings(digests)
+		return digests
+	}
+	initStatuses := func(p *corev1.Pod) []corev1.ContainerStatus { return p.Status.InitContainerStatuses }
+	statuses := func(p *corev1.Pod) []corev1.ContainerStatus { return p.Status.ContainerStatuses }
+
+	images := make([]containerImage, 0, len(spec.InitContainers)+len(spec.Containers))
+	for _, c := range spec.InitContainers {
+		images = append(images, inspectImage(c, true, resolved(initStatuses, c.Name)))
+	}
+	for _, c := range spec.Containers {
+		images = append(images, inspectImage(c, false, resolved(statuses, c.Name)))
+	}
+	return images
+}
+
+// inspectImage describes one container's image and flags its risks.
+func inspectImage(c corev1.Container, init bool, digests []string) containerImage {
+	ref := parseImageRef(c.Image)
+	img := containerImage{
+		Container:       c.Name,
+		Init:            init,
+		Image:           c.Image,
+		Registry:        ref.registry,
+		Repository:      ref.repository,
+		Tag:             ref.tag,
+		Digest:          ref.digest,
+		PullPolicy:      string(c.ImagePullPolicy),
+		MutableTag:      ref.digest == "",
+		ResolvedDigests: digests,
+		Risks:           []imageRisk{},
+	}
+	latest := ref.digest == "" && (ref.tag == "" || ref.tag == "latest")
+	// Kubernetes defaults the policy the same way when the spec omits it.
+	if img.PullPolicy == "" {
+		img.PullPolicy = string(corev1.PullIfNotPresent)
+		if latest {
+			img.PullPolicy = string(corev1.PullAlways)
+		}
+	}
+
+	switch {
+	case latest && img.PullPolicy == string(corev1.PullAlways):
+		img.Risks = append(img.Risks, imageRisk{Type: imageRiskLatestAlways, Severity: "warning",
+			Message: "the latest tag is pulled on every container start, so a restart can run a different image"})
+	case latest:
+		img.Risks = append(img.Risks, imageRisk{Type: imageRiskLatest, Severity: "warning",
+			Message: "the latest tag does not identify a release; nodes may run whichever version they pulled last"})
+	}
+	if img.MutableTag {
+		img.Risks = append(img.Risks, imageRisk{Type: imageRiskUnpinned, Severity: "info",
+			Message: "the image is not pinned by digest, so the tag can be moved to a different image"})
+	}
+	if len(digests) > 1 {
+		img.Risks = append(img.Risks, imageRisk{Type: imageRiskDigestDrift, Severity: "warning",
+			Message: fmt.Sprintf("pods run %d different digests of this image", len(digests))})
+	}
+	return img
+}
+
+// imageRef is a parsed container image reference.
+type imageRef struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+// parseImageRef splits an image reference the way container runtimes
+// resolve it: a first component with a dot or port, or localhost, names the
+// registry, Docker Hub is the default, and its single-name images live
+// under library/. A missing tag is left empty rather than set to latest.
+func parseImageRef(image string) imageRef {
+	var ref imageRef
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.digest = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.tag = name[:i], name[i+1:]
+	}
+
+	ref.registry = "docker.io"
+	if i := strings.Index(name, "/"); i >= 0 {
+		if first := name[:i]; strings.ContainsAny(first, ".:") || first == "localhost" {
+			ref.registry, name = first, name[i+1:]
+		}
+	}
+	if ref.registry == "docker.io" && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.repository = name
+	return ref
+}
+
+// imageIDDigest extracts the digest from a container status imageID, which
+// runtimes report as e.g. docker-pullable://nginx@sha256:..., or
+// docker.io/library/nginx@sha256:..., or a bare sha256:... image ID.
+func imageIDDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		return imageID[i+1:]
+	}
+	if i := strings.Index(imageID, "://"); i >= 0 {
+		return imageID[i+3:]
+	}
+	return imageID
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		image string
+		want  imageRef
+	}{
+		{"nginx", imageRef{registry: "docker.io", repository: "library/nginx"}},
+		{"nginx:1.27", imageRef{registry: "docker.io", repository: "library/nginx", tag: "1.27"}},
+		{"bitnami/redis:latest", imageRef{registry: "docker.io", repository: "bitnami/redis", tag: "latest"}},
+		{"ghcr.io/org/app:v2@sha256:abc", imageRef{registry: "ghcr.io", repository: "org/app", tag: "v2", digest: "sha256:abc"}},
+		{"localhost:5000/app", imageRef{registry: "localhost:5000", repository: "app"}},
+		{"registry.example.com/team/app@sha256:def", imageRef{registry: "registry.example.com", repository: "team/app", digest: "sha256:def"}},
+	}
+	for _, tt := range tests {
+		if got := parseImageRef(tt.image); got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.image, tt.want, got)
+		}
+	}
+}
+
+func TestImageIDDigest(t *testing.T) {
+	for id, want := range map[string]string{
+		"docker-pullable://nginx@sha256:aaa": "sha256:aaa",
+		"docker.io/library/nginx@sha256:bbb": "sha256:bbb",
+		"sha256:ccc":                         "sha256:ccc",
+		"docker://sha256:ddd":                "sha256:ddd",
+	} {
+		if got := imageIDDigest(id); got != want {
+			t.Errorf("%s: expected %s, got %s", id, want, got)
+		}
+	}
+}
+
+func riskTypes(img containerImage) []string {
+	types := make([]string, 0, len(img.Risks))
+	for _, r := range img.Risks {
+		types = append(types, r.Type)
+	}
+	return types
+}
+
+func TestInspectImages(t *testing.T) {
+	spec := corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "migrate", Image: "ghcr.io/org/migrate@sha256:111", ImagePullPolicy: corev1.PullIfNotPresent}},
+		Containers: []corev1.Container{
+			{Name: "web", Image: "nginx"},
+			{Name: "api", Image: "ghcr.io/org/api:latest", ImagePullPolicy: corev1.PullIfNotPresent},
+			{Name: "worker", Image: "ghcr.io/org/worker:v1", ImagePullPolicy: corev1.PullIfNotPresent},
+		},
+	}
+	pod := func(worker string) corev1.Pod {
+		return corev1.Pod{Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{{Name: "migrate", ImageID: "ghcr.io/org/migrate@sha256:111"}},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "web", ImageID: "docker-pullable://nginx@sha256:222"},
+				{Name: "worker", ImageID: worker},
+			},
+		}}
+	}
+	images := inspectImages(spec, []corev1.Pod{pod("ghcr.io/org/worker@sha256:444"), pod("ghcr.io/org/worker@sha256:333")})
+	if len(images) != 4 {
+		t.Fatalf("expected four containers, got %+v", images)
+	}
+
+	migrate, web, api, worker := images[0], images[1], images[2], images[3]
+	if !migrate.Init || migrate.MutableTag || len(migrate.Risks) != 0 || len(migrate.ResolvedDigests) != 1 {
+		t.Errorf("expected a pinned init container without risks, got %+v", migrate)
+	}
+	if web.PullPolicy != "Always" || !web.MutableTag || web.ResolvedDigests[0] != "sha256:222" {
+		t.Errorf("expected web to default to Always, got %+v", web)
+	}
+	if got := riskTypes(web); len(got) != 2 || got[0] != imageRiskLatestAlways || got[1] != imageRiskUnpinned {
+		t.Errorf("expected latest+Always and unpinned risks for web, got %v", got)
+	}
+	if got := riskTypes(api); len(got) != 2 || got[0] != imageRiskLatest || len(api.ResolvedDigests) != 0 {
+		t.Errorf("expected a latest tag risk for api, got %+v", api)
+	}
+	if got := riskTypes(worker); len(got) != 2 || got[0] != imageRiskUnpinned || got[1] != imageRiskDigestDrift {
+		t.Errorf("expected unpinned and drift risks for worker, got %v", got)
+	}
+	if worker.ResolvedDigests[0] != "sha256:333" || worker.ResolvedDigests[1] != "sha256:444" {
+		t.Errorf("expected sorted digests, got %v", worker.ResolvedDigests)
+	}
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"unicode/utf16"
+)
+
+const (
+	// defaultLogSearchWindow and maxLogSearchWindow bound how many of the
+	// most recent lines a search looks back through.
+	defaultLogSearchWindow = 1000
+	maxLogSearchWindow     = 10000
+
+	defaultLogSearchMatches = 500
+	maxLogSearchMatches     = 5000
+
+	maxLogSearchPatternLen = 1024
+	// maxLogLineBytes caps a single log line; longer lines end the search
+	// with an error.
+	maxLogLineBytes = 1 << 20
+	// maxOffsetsPerLine caps the highlighted spans reported for one line.
+	maxOffsetsPerLine = 100
+)
+
+// LogMatch is a log line selected by a search. Offsets are [start, end)
+// spans of the pattern in Text, in UTF-16 code units so a browser can
+// highlight them directly. Inverted searches report no offsets.
+type LogMatch struct {
+	Line    int      `json:"line"`
+	Text    string   `json:"text"`
+	Offsets [][2]int `json:"offsets,omitempty"`
+}
+
+// logSearch selects log lines by regular expression.
+type logSearch struct {
+	re         *regexp.Regexp
+	invert     bool
+	window     int64
+	maxMatches int
+}
+
+// parseLogSearch reads the search from the query: pattern (required),
+// ignoreCase, invert, maxMatches and tailLines. It returns a message
+// describing the first invalid parameter, if any.
+func parseLogSearch(q url.Values) (*logSearch, string) {
+	pattern := q.Get("pattern")
+	if pattern == "" {
+		return nil, "pattern is required"
+	}
+	if len(pattern) > maxLogSearchPatternLen {
+		return nil, fmt.Sprintf("pattern must be %d characters or less", maxLogSearchPatternLen)
+	}
+	if q.Get("ignoreCase") == "true" {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, "invalid pattern: " + err.Error()
+	}
+
+	s := &logSearch{
+		re:         re,
+		invert:     q.Get("invert") == "true",
+		window:     defaultLogSearchWindow,
+		maxMatches: defaultLogSearchMatches,
+	}
+	if v := q.Get("tailLines"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 || n > maxLogSearchWindow {
+			return nil, fmt.Sprintf("tailLines must be between 1 and %d", maxLogSearchWindow)
+		}
+		s.window = n
+	}
+	if v := q.Get("maxMatches"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxLogSearchMatches {
+			return nil, fmt.Sprintf("maxMatches must be between 1 and %d", maxLogSearchMatches)
+		}
+		s.maxMatches = n
+	}
+	return s, ""
+}
+
+// match reports whether the line is selected and, for a normal search,
+// where the pattern occurs in it.
+func (s *logSearch) match(line string) (LogMatch, bool) {
+	if s.invert {
+		return LogMatch{Text: line}, !s.re.MatchString(line)
+	}
+	locs := s.re.FindAllStringIndex(line, maxOffsetsPerLine)
+	if locs == nil {
+		return LogMatch{}, false
+	}
+	return LogMatch{Text: line, Offsets: utf16Spans(line, locs)}, true
+}
+
+// scan reads lines from r and passes each selected one to emit, stopping
+// when emit returns false or maxMatches lines have been selected. It
+// returns the number of lines read and whether the match limit was reached.
+func (s *logSearch) scan(r io.Reader, emit func(LogMatch) bool) (int, bool, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxLogLineBytes)
+
+	lines, matches := 0, 0
+	for sc.Scan() {
+		lines++
+		m, ok := s.match(sc.Text())
+		if !ok {
+			continue
+		}
+		m.Line = lines
+		matches++
+		if !emit(m) {
+			return lines, false, nil
+		}
+		if matches == s.maxMatches {
+			return lines, true, nil
+		}
+	}
+	return lines, false, sc.Err()
+}
+
+// utf16Spans converts byte spans in s, in increasing order, to UTF-16 code
+// unit spans. Empty spans are dropped: there is nothing to highlight.
+func utf16Spans(s string, locs [][]int) [][2]int {
+	spans := make([][2]int, 0, len(locs))
+	pos, units := 0, 0
+	advance := func(to int) int {
+		for _, r := range s[pos:to] {
+			units += utf16.RuneLen(r)
+		}
+		pos = to
+		return units
+	}
+	for _, loc := range locs {
+		if loc[0] == loc[1] {
+			continue
+		}
+		start := advance(loc[0])
+		spans = append(spans, [2]int{start, advance(loc[1])})
+	}
+	return spans
+}
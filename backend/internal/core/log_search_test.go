@@ -0,0 +1,133 @@
+package core
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustParseLogSearch(t *testing.T, query string) *logSearch {
+	t.Helper()
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatalf("bad query: %v", err)
+	}
+	s, msg := parseLogSearch(q)
+	if msg != "" {
+		t.Fatalf("parseLogSearch(%q): %s", query, msg)
+	}
+	return s
+}
+
+func TestParseLogSearch_Validation(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr string
+	}{
+		{"", "pattern is required"},
+		{"pattern=" + strings.Repeat("a", maxLogSearchPatternLen+1), "pattern must be 1024 characters or less"},
+		{"pattern=(", "invalid pattern"},
+		{"pattern=x&tailLines=0", "tailLines must be between 1 and 10000"},
+		{"pattern=x&tailLines=10001", "tailLines must be between 1 and 10000"},
+		{"pattern=x&maxMatches=abc", "maxMatches must be between 1 and 5000"},
+		{"pattern=x&maxMatches=5001", "maxMatches must be between 1 and 5000"},
+	}
+	for _, tt := range tests {
+		q, _ := url.ParseQuery(tt.query)
+		if _, msg := parseLogSearch(q); !strings.HasPrefix(msg, tt.wantErr) {
+			t.Errorf("%q: expected %q, got %q", tt.query, tt.wantErr, msg)
+		}
+	}
+}
+
+func TestParseLogSearch_Defaults(t *testing.T) {
+	s := mustParseLogSearch(t, "pattern=error")
+	if s.window != defaultLogSearchWindow || s.maxMatches != defaultLogSearchMatches || s.invert {
+		t.Errorf("unexpected defaults: %+v", s)
+	}
+}
+
+func TestLogSearchScan(t *testing.T) {
+	logs := "GET /health 200\nERROR db timeout\nGET /api 500 error\nerror: retrying error\n"
+	tests := []struct {
+		name  string
+		query string
+		want  []LogMatch
+	}{
+		{"case sensitive", "pattern=error", []LogMatch{
+			{Line: 3, Text: "GET /api 500 error", Offsets: [][2]int{{13, 18}}},
+			{Line: 4, Text: "error: retrying error", Offsets: [][2]int{{0, 5}, {16, 21}}},
+		}},
+		{"ignore case", "pattern=error&ignoreCase=true&maxMatches=1", []LogMatch{
+			{Line: 2, Text: "ERROR db timeout", Offsets: [][2]int{{0, 5}}},
+		}},
+		{"invert", "pattern=error&ignoreCase=true&invert=true", []LogMatch{
+			{Line: 1, Text: "GET /health 200"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := mustParseLogSearch(t, tt.query)
+			var got []LogMatch
+			_, _, err := s.scan(strings.NewReader(logs), func(m LogMatch) bool {
+				got = append(got, m)
+				return true
+			})
+			if err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLogSearchScan_StopsAtMaxMatches(t *testing.T) {
+	s := mustParseLogSearch(t, "pattern=x&maxMatches=2")
+	var got int
+	lines, limited, err := s.scan(strings.NewReader("x\ny\nx\nx\nx\n"), func(LogMatch) bool {
+		got++
+		return true
+	})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if got != 2 || !limited || lines != 3 {
+		t.Errorf("expected 2 matches over 3 lines with the limit reached, got %d over %d (limited=%v)", got, lines, limited)
+	}
+}
+
+func TestLogSearchScan_LineTooLong(t *testing.T) {
+	s := mustParseLogSearch(t, "pattern=x")
+	_, _, err := s.scan(strings.NewReader(strings.Repeat("x", maxLogLineBytes+1)), func(LogMatch) bool { return true })
+	if err == nil {
+		t.Error("expected an error for an oversized line")
+	}
+}
+
+func TestUTF16Spans(t *testing.T) {
+	// "é" is two bytes but one UTF-16 unit; "😀" is four bytes and two units.
+	line := "é😀 fail fail"
+	s := mustParseLogSearch(t, "pattern=fail")
+	m, ok := s.match(line)
+	if !ok {
+		t.Fatal("expected a match")
+	}
+	want := [][2]int{{4, 8}, {9, 13}}
+	if !reflect.DeepEqual(m.Offsets, want) {
+		t.Errorf("expected %v, got %v", want, m.Offsets)
+	}
+}
+
+func TestUTF16Spans_DropsEmptyMatches(t *testing.T) {
+	s := mustParseLogSearch(t, "pattern=a*")
+	m, ok := s.match("bab")
+	if !ok {
+		t.Fatal("expected a match")
+	}
+	if want := [][2]int{{1, 2}}; !reflect.DeepEqual(m.Offsets, want) {
+		t.Errorf("expected %v, got %v", want, m.Offsets)
+	}
+}
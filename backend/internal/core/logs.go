@@ -1,6 +1,8 @@
 package core
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,6 +13,9 @@ import (
 	"github.com/darkden-lab/argus/backend/internal/auth"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
 	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+	"github.com/darkden-lab/argus/backend/pkg/agentpb"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -18,25 +23,27 @@ import (
 type LogsHandler struct {
 	clusterMgr *cluster.Manager
 	jwtService *auth.JWTService
+	authz      rbac.Evaluator
 }
 
-// NewLogsHandler creates a new LogsHandler.
-func NewLogsHandler(cm *cluster.Manager, jwtService *auth.JWTService) *LogsHandler {
-	return &LogsHandler{clusterMgr: cm, jwtService: jwtService}
+// NewLogsHandler creates a new LogsHandler. rbacEngine decides which pods'
+// logs a user may read.
+func NewLogsHandler(cm *cluster.Manager, jwtService *auth.JWTService, rbacEngine *rbac.Engine) *LogsHandler {
+	return &LogsHandler{clusterMgr: cm, jwtService: jwtService, authz: rbacEngine}
 }
 
-// RegisterRoutes registers the pod logs endpoint.
+// RegisterRoutes registers the pod logs endpoints.
 // The handler manages auth internally (via query param or header) to support
 // EventSource streaming which cannot send Authorization headers.
 func (h *LogsHandler) RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/api/clusters/{clusterID}/namespaces/{namespace}/pods/{pod}/logs", h.GetPodLogs).Methods(http.MethodGet)
+	r.HandleFunc("/api/clusters/{clusterID}/namespaces/{namespace}/pods/{pod}/logs/search", h.SearchPodLogs).Methods(http.MethodGet)
 }
 
-// GetPodLogs streams or returns logs for a pod.
-// Query params: container, tailLines (default 100), previous, follow, token.
-func (h *LogsHandler) GetPodLogs(w http.ResponseWriter, r *http.Request) {
-	// Authenticate via token query param or Authorization header
-	// (same pattern as terminal handler — needed for EventSource SSE)
+// authenticate validates the token from the token query param, the
+// Authorization header or the session cookie (same pattern as terminal
+// handler — needed for EventSource SSE) and injects its claims into the request context.
+func (h *LogsHandler) authenticate(w http.ResponseWriter, r *http.Request) (*http.Request, *auth.Claims, bool) {
 	token := r.URL.Query().Get("token")
 	if token == "" {
 		authHeader := r.Header.Get("Authorization")
@@ -45,40 +52,74 @@ func (h *LogsHandler) GetPodLogs(w http.ResponseWriter, r *http.Request) {
 			token = parts[1]
 		}
 	}
+	if token == "" {
+		token = auth.AccessTokenFromCookie(r)
+	}
 
 	if token == "" {
 		httputil.WriteError(w, http.StatusUnauthorized, "missing authorization")
-		return
+		return nil, nil, false
 	}
 
 	claims, err := h.jwtService.ValidateToken(token)
 	if err != nil {
 		httputil.WriteError(w, http.StatusUnauthorized, "invalid or expired token")
-		return
+		return nil, nil, false
 	}
 
-	// Inject claims into context for downstream use
 	ctx := auth.ContextWithClaims(r.Context(), claims)
-	r = r.WithContext(ctx)
+	return r.WithContext(ctx), claims, true
+}
+
+// authorizePodRead checks that the caller may read pods in the namespace,
+// the same permission the REST pod endpoints need, writing a 403 if not. It
+// runs before the log stream is opened so a refused follow request fails
+// with a status instead of an error event.
+func (h *LogsHandler) authorizePodRead(w http.ResponseWriter, r *http.Request, claims *auth.Claims, clusterID, namespace string) bool {
+	allowed, err := h.authz.Evaluate(r.Context(), rbac.Request{
+		UserID:      claims.UserID,
+		Action:      "read",
+		Resource:    rbac.ResourceCategory("pods"),
+		ClusterID:   clusterID,
+		Namespace:   namespace,
+		K8sResource: "pods",
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+		return false
+	}
+	if !allowed {
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
+		return false
+	}
+	return true
+}
+
+// GetPodLogs streams or returns logs for a pod.
+// Query params: container, tailLines (default 100), previous, follow, token.
+// The caller needs read access to pods in the namespace.
+func (h *LogsHandler) GetPodLogs(w http.ResponseWriter, r *http.Request) {
+	r, claims, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
 
 	vars := mux.Vars(r)
 	clusterID := vars["clusterID"]
 	namespace := vars["namespace"]
 	pod := vars["pod"]
 
-	if !isValidK8sSegment(namespace) || !isValidK8sSegment(pod) {
+	if !validate.Segment(namespace) || !validate.Segment(pod) {
 		httputil.WriteError(w, http.StatusBadRequest, "invalid namespace or pod name")
 		return
 	}
 
-	client, err := h.clusterMgr.GetClient(clusterID)
-	if err != nil {
-		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
-		return
-	}
-
 	q := r.URL.Query()
 	container := q.Get("container")
+	if !validate.Segment(container) {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid container name")
+		return
+	}
 	previous := q.Get("previous") == "true"
 	follow := q.Get("follow") == "true"
 
@@ -98,10 +139,11 @@ func (h *LogsHandler) GetPodLogs(w http.ResponseWriter, r *http.Request) {
 		opts.Container = container
 	}
 
-	logReq := client.Clientset.CoreV1().Pods(namespace).GetLogs(pod, opts)
-	stream, err := logReq.Stream(r.Context())
-	if err != nil {
-		httputil.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to stream logs: %v", err))
+	if !h.authorizePodRead(w, r, claims, clusterID, namespace) {
+		return
+	}
+	stream, ok := h.openLogStream(w, r, clusterID, namespace, pod, opts)
+	if !ok {
 		return
 	}
 	defer stream.Close()
@@ -147,3 +189,157 @@ func (h *LogsHandler) GetPodLogs(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 }
+
+// openLogStream opens the pod's log stream, directly or through the
+// cluster's agent, writing an error response if it cannot.
+func (h *LogsHandler) openLogStream(w http.ResponseWriter, r *http.Request, clusterID, namespace, pod string, opts *corev1.PodLogOptions) (io.ReadCloser, bool) {
+	if client, err := h.clusterMgr.GetClient(clusterID); err == nil {
+		stream, err := client.Clientset.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(r.Context())
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to stream logs: %v", err))
+			return nil, false
+		}
+		return stream, true
+	}
+
+	if err := h.clusterMgr.CheckAgent(r.Context(), clusterID); err != nil {
+		writeClusterError(w, err, "failed to stream logs")
+		return nil, false
+	}
+	stream, err := openAgentLogStream(r.Context(), h.clusterMgr.GetAgentServer(), clusterID, namespace, pod, opts)
+	if err != nil {
+		writeClusterError(w, err, "failed to stream logs")
+		return nil, false
+	}
+	return stream, true
+}
+
+// SearchPodLogs streams or returns only the log lines of a pod that match a
+// regular expression, with the spans to highlight. It takes the same
+// container, previous, follow and token params as GetPodLogs, plus pattern
+// (required), ignoreCase, invert and maxMatches (default 500, at most
+// 5000). tailLines is the look-back window (default 1000, at most 10000).
+// The caller needs read access to pods in the namespace.
+func (h *LogsHandler) SearchPodLogs(w http.ResponseWriter, r *http.Request) {
+	r, claims, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	clusterID := vars["clusterID"]
+	namespace := vars["namespace"]
+	pod := vars["pod"]
+
+	if !validate.Segment(namespace) || !validate.Segment(pod) {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid namespace or pod name")
+		return
+	}
+
+	q := r.URL.Query()
+	search, msg := parseLogSearch(q)
+	if msg != "" {
+		httputil.WriteError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if !validate.Segment(q.Get("container")) {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid container name")
+		return
+	}
+
+	if !h.authorizePodRead(w, r, claims, clusterID, namespace) {
+		return
+	}
+
+	follow := q.Get("follow") == "true"
+	opts := &corev1.PodLogOptions{
+		TailLines: &search.window,
+		Previous:  q.Get("previous") == "true",
+		Follow:    follow,
+		Container: q.Get("container"),
+	}
+
+	stream, ok := h.openLogStream(w, r, clusterID, namespace, pod, opts)
+	if !ok {
+		return
+	}
+	defer stream.Close()
+
+	if !follow {
+		matches := []LogMatch{}
+		lines, limited, err := search.scan(stream, func(m LogMatch) bool {
+			matches = append(matches, m)
+			return true
+		})
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to read logs: %v", err))
+			return
+		}
+		httputil.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"matches":       matches,
+			"scanned_lines": lines,
+			"limit_reached": limited,
+		})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// Each match is a data event; the stream ends with a limit event once
+	// maxMatches lines have matched.
+	_, limited, err := search.scan(stream, func(m LogMatch) bool {
+		data, _ := json.Marshal(m)
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	})
+	switch {
+	case limited:
+		_, _ = fmt.Fprintf(w, "event: limit\ndata: {\"max_matches\":%d}\n\n", search.maxMatches)
+	case err != nil:
+		_, _ = fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+	}
+	flusher.Flush()
+}
+
+// openAgentLogStream streams pod logs through the cluster's agent, so follow
+// mode works for agent-connected clusters without buffering the output.
+func openAgentLogStream(ctx context.Context, agentSrv *cluster.AgentServer, clusterID, namespace, pod string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+	params := map[string]string{
+		"tailLines": strconv.FormatInt(*opts.TailLines, 10),
+	}
+	if opts.Container != "" {
+		params["container"] = opts.Container
+	}
+	if opts.Previous {
+		params["previous"] = "true"
+	}
+	if opts.Follow {
+		params["follow"] = "true"
+	}
+
+	stream, err := agentSrv.OpenK8sStream(ctx, clusterID, &agentpb.K8SRequest{
+		Method:      http.MethodGet,
+		Path:        fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/log", namespace, pod),
+		QueryParams: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if stream.StatusCode >= http.StatusBadRequest {
+		defer stream.Close()
+		body, _ := io.ReadAll(io.LimitReader(stream, 4096))
+		return nil, &cluster.K8sRequestError{StatusCode: stream.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+	return stream, nil
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+)
+
+func TestPodLogs_NamespaceScopedUser(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret-key-at-least-32chars!!")
+	token, err := jwtSvc.GenerateToken("u1", "dev@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &LogsHandler{clusterMgr: cluster.NewManager(nil, ""), jwtService: jwtSvc, authz: readNamespaces{"team-a": true}}
+	r := mux.NewRouter()
+	h.RegisterRoutes(r)
+
+	tests := []struct {
+		name string
+		url  string
+		want int
+	}{
+		{"logs in another namespace", "/api/clusters/c1/namespaces/team-b/pods/web/logs", http.StatusForbidden},
+		{"followed logs in another namespace", "/api/clusters/c1/namespaces/team-b/pods/web/logs?follow=true", http.StatusForbidden},
+		{"log search in another namespace", "/api/clusters/c1/namespaces/team-b/pods/web/logs/search?pattern=error", http.StatusForbidden},
+		// Allowed requests get as far as the cluster, which is not connected.
+		{"logs in the user's namespace", "/api/clusters/c1/namespaces/team-a/pods/web/logs", http.StatusNotFound},
+		{"followed logs in the user's namespace", "/api/clusters/c1/namespaces/team-a/pods/web/logs?follow=true", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); ct == "text/event-stream" {
+				t.Errorf("expected the stream not to be opened, got %s", ct)
+			}
+		})
+	}
+}
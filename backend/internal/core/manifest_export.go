@@ -0,0 +1,630 @@
+package core
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/redact"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+// maxExportResources caps how many resource types a single export may list.
+const maxExportResources = 30
+
+// Bundle formats accepted by the export endpoint.
+const (
+	exportFormatYAML = "yaml"
+	exportFormatTar  = "tar"
+	exportFormatZip  = "zip"
+)
+
+// defaultExportResources are exported when the request does not select any:
+// the kinds that make up a typical application.
+var defaultExportResources = []schema.GroupVersionResource{
+	{Group: "apps", Version: "v1", Resource: "deployments"},
+	{Group: "apps", Version: "v1", Resource: "statefulsets"},
+	{Group: "apps", Version: "v1", Resource: "daemonsets"},
+	{Group: "batch", Version: "v1", Resource: "cronjobs"},
+	{Version: "v1", Resource: "services"},
+	{Version: "v1", Resource: "configmaps"},
+	{Version: "v1", Resource: "secrets"},
+	{Version: "v1", Resource: "serviceaccounts"},
+	{Version: "v1", Resource: "persistentvolumeclaims"},
+	{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
+}
+
+// exportNoisePaths are server-managed fields removed from every exported
+// object. ownerReferences are dropped too: they point at UIDs that do not
+// exist where the bundle is applied.
+var exportNoisePaths = [][]string{
+	{"status"},
+	{"metadata", "uid"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "generation"},
+	{"metadata", "creationTimestamp"},
+	{"metadata", "deletionTimestamp"},
+	{"metadata", "deletionGracePeriodSeconds"},
+	{"metadata", "managedFields"},
+	{"metadata", "selfLink"},
+	{"metadata", "ownerReferences"},
+}
+
+// exportNoiseAnnotations are annotations the API server or controllers add.
+var exportNoiseAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/revision",
+	"pv.kubernetes.io/bind-completed",
+	"pv.kubernetes.io/bound-by-controller",
+	"volume.beta.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/storage-provisioner",
+	"volume.kubernetes.io/selected-node",
+}
+
+// exportDefault is a field the API server fills in with a default value. It
+// is removed from an exported object when it still holds that value.
+type exportDefault struct {
+	path  []string
+	value interface{}
+}
+
+// exportKindDefaults are the defaulted fields of each exported kind, apart
+// from those of pod templates. Numbers are int64, as in decoded objects.
+var exportKindDefaults = map[string][]exportDefault{
+	"Deployment": {
+		{[]string{"spec", "progressDeadlineSeconds"}, int64(600)},
+		{[]string{"spec", "revisionHistoryLimit"}, int64(10)},
+		{[]string{"spec", "strategy"}, map[string]interface{}{
+			"type":          "RollingUpdate",
+			"rollingUpdate": map[string]interface{}{"maxSurge": "25%", "maxUnavailable": "25%"},
+		}},
+	},
+	"StatefulSet": {
+		{[]string{"spec", "podManagementPolicy"}, "OrderedReady"},
+		{[]string{"spec", "revisionHistoryLimit"}, int64(10)},
+		{[]string{"spec", "updateStrategy"}, map[string]interface{}{
+			"type":          "RollingUpdate",
+			"rollingUpdate": map[string]interface{}{"partition": int64(0)},
+		}},
+		{[]string{"spec", "persistentVolumeClaimRetentionPolicy"}, map[string]interface{}{
+			"whenDeleted": "Retain", "whenScaled": "Retain",
+		}},
+	},
+	"DaemonSet": {
+		{[]string{"spec", "revisionHistoryLimit"}, int64(10)},
+		{[]string{"spec", "updateStrategy"}, map[string]interface{}{
+			"type":          "RollingUpdate",
+			"rollingUpdate": map[string]interface{}{"maxSurge": int64(0), "maxUnavailable": int64(1)},
+		}},
+	},
+	"CronJob": {
+		{[]string{"spec", "concurrencyPolicy"}, "Allow"},
+		{[]string{"spec", "failedJobsHistoryLimit"}, int64(1)},
+		{[]string{"spec", "successfulJobsHistoryLimit"}, int64(3)},
+		{[]string{"spec", "suspend"}, false},
+	},
+	"Service": {
+		{[]string{"spec", "sessionAffinity"}, "None"},
+		{[]string{"spec", "ipFamilyPolicy"}, "SingleStack"},
+		{[]string{"spec", "internalTrafficPolicy"}, "Cluster"},
+	},
+	"PersistentVolumeClaim": {
+		{[]string{"spec", "volumeMode"}, "Filesystem"},
+	},
+}
+
+// exportPodSpecDefaults and exportContainerDefaults are the defaulted fields
+// of pod specs and their containers.
+var (
+	exportPodSpecDefaults = []exportDefault{
+		{[]string{"dnsPolicy"}, "ClusterFirst"},
+		{[]string{"schedulerName"}, "default-scheduler"},
+		{[]string{"securityContext"}, map[string]interface{}{}},
+		{[]string{"terminationGracePeriodSeconds"}, int64(30)},
+	}
+	exportContainerDefaults = []exportDefault{
+		{[]string{"terminationMessagePath"}, "/dev/termination-log"},
+		{[]string{"terminationMessagePolicy"}, "File"},
+		{[]string{"resources"}, map[string]interface{}{}},
+	}
+)
+
+// exportPodSpecPaths locates the pod spec of the kinds that embed one.
+var exportPodSpecPaths = map[string][]string{
+	"Pod":         {"spec"},
+	"Deployment":  {"spec", "template", "spec"},
+	"StatefulSet": {"spec", "template", "spec"},
+	"DaemonSet":   {"spec", "template", "spec"},
+	"ReplicaSet":  {"spec", "template", "spec"},
+	"Job":         {"spec", "template", "spec"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// ManifestExportHandler snapshots the live objects of a namespace as clean
+// manifests, for backup or for applying to another cluster.
+type ManifestExportHandler struct {
+	clusterMgr *cluster.Manager
+	rbacEngine *rbac.Engine
+	discovery  *DiscoveryCache
+	redactor   *redact.Redactor
+}
+
+// NewManifestExportHandler creates a new ManifestExportHandler.
+func NewManifestExportHandler(cm *cluster.Manager, engine *rbac.Engine, discovery *DiscoveryCache) *ManifestExportHandler {
+	return &ManifestExportHandler{clusterMgr: cm, rbacEngine: engine, discovery: discovery}
+}
+
+// SetRedactor masks secret material in exported objects for users without
+// secrets:reveal. Bundles are not JSON, so the response redaction middleware
+// does not see them.
+func (h *ManifestExportHandler) SetRedactor(r *redact.Redactor) {
+	h.redactor = r
+}
+
+// RegisterRoutes wires the manifest export endpoint.
+func (h *ManifestExportHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clusters/{clusterID}/manifests/export", h.Export).Methods(http.MethodGet)
+}
+
+// exportedObject is one object of an export bundle.
+type exportedObject struct {
+	gvr schema.GroupVersionResource
+	obj map[string]interface{}
+}
+
+// exportRequest is the parsed query of an export.
+type exportRequest struct {
+	namespace string
+	resources []schema.GroupVersionResource
+	// selected is set when the caller chose the resources; a resource they
+	// may not read is then an error instead of being skipped.
+	selected     bool
+	format       string
+	includeOwned bool
+}
+
+// parseExportRequest reads the export query parameters.
+func parseExportRequest(q map[string][]string) (*exportRequest, error) {
+	get := func(key string) string {
+		if v := q[key]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	req := &exportRequest{namespace: get("namespace"), format: get("format"), includeOwned: get("includeOwned") == "true"}
+	if req.namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if !validate.Segment(req.namespace) {
+		return nil, fmt.Errorf("invalid namespace")
+	}
+	switch req.format {
+	case "":
+		req.format = exportFormatYAML
+	case exportFormatYAML, exportFormatTar, exportFormatZip:
+	default:
+		return nil, fmt.Errorf("format must be yaml, tar, or zip")
+	}
+
+	seen := make(map[schema.GroupVersionResource]bool)
+	for _, value := range q["resource"] {
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			gvr, err := parseExportResource(s)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[gvr] {
+				seen[gvr] = true
+				req.resources = append(req.resources, gvr)
+			}
+		}
+	}
+	if len(req.resources) == 0 {
+		req.resources = defaultExportResources
+	} else {
+		req.selected = true
+	}
+	if len(req.resources) > maxExportResources {
+		return nil, fmt.Errorf("at most %d resources may be exported at once", maxExportResources)
+	}
+	return req, nil
+}
+
+// parseExportResource parses "group/version/resource", or "version/resource"
+// for the core group ("_" is accepted as the core group too).
+func parseExportResource(s string) (schema.GroupVersionResource, error) {
+	parts := strings.Split(s, "/")
+	var gvr schema.GroupVersionResource
+	switch len(parts) {
+	case 2:
+		gvr = schema.GroupVersionResource{Version: parts[0], Resource: parts[1]}
+	case 3:
+		gvr = schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]}
+		if gvr.Group == "_" {
+			gvr.Group = ""
+		}
+	default:
+		return gvr, fmt.Errorf("invalid resource %q: expected group/version/resource", s)
+	}
+	if gvr.Version == "" || gvr.Resource == "" ||
+		!validate.Segment(gvr.Group) || !validate.Segment(gvr.Version) || !validate.Segment(gvr.Resource) {
+		return gvr, fmt.Errorf("invalid resource %q", s)
+	}
+	return gvr, nil
+}
+
+// Export lists the selected resources in a namespace and returns them with
+// server-managed and defaulted fields removed, as a multi-document YAML
+// bundle or as a tar or zip archive with one file per object. Resources the
+// caller may not read fail the export when selected explicitly and are
+// skipped, and named in the bundle header, when exported by default.
+//
+// Query parameters:
+//   - namespace: the namespace to export (required)
+//   - resource: group/version/resource to include, repeatable or
+//     comma-separated, e.g. apps/v1/deployments or v1/configmaps; default
+//     the common application kinds
+//   - format: yaml (default), tar, or zip
+//   - includeOwned: also export objects created by a controller, such as
+//     the ReplicaSets of a Deployment
+func (h *ManifestExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	clusterID := mux.Vars(r)["clusterID"]
+	req, err := parseExportRequest(r.URL.Query())
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	access := newReadAccess(h.rbacEngine, claims.UserID, clusterID)
+	type exportKind struct {
+		gvr  schema.GroupVersionResource
+		kind string
+	}
+	var kinds []exportKind
+	var skipped, denied []string
+	for _, gvr := range req.resources {
+		res, found, err := h.discovery.Lookup(r.Context(), clusterID, gvr)
+		if err != nil {
+			httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("discovery failed: %v", err))
+			return
+		}
+		if !found || !res.Namespaced {
+			if req.selected {
+				httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("%s is not a namespaced resource served by this cluster", gvr.String()))
+				return
+			}
+			continue
+		}
+		allowed, err := access.allowed(r.Context(), gvr, req.namespace)
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+			return
+		}
+		if !allowed {
+			if req.selected {
+				denied = append(denied, exportResourceName(gvr))
+			} else {
+				skipped = append(skipped, exportResourceName(gvr))
+			}
+			continue
+		}
+		kinds = append(kinds, exportKind{gvr: gvr, kind: res.Kind})
+	}
+	if len(denied) > 0 {
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions to export "+strings.Join(denied, ", "))
+		return
+	}
+
+	redactor := h.redactor
+	if redactor != nil {
+		reveal, err := h.rbacEngine.Evaluate(r.Context(), rbac.Request{
+			UserID:    claims.UserID,
+			Action:    redact.RevealAction,
+			Resource:  redact.RevealResource,
+			ClusterID: clusterID,
+		})
+		if err == nil && reveal {
+			redactor = nil
+		}
+	}
+
+	var objects []exportedObject
+	for _, k := range kinds {
+		backend, err := resourceBackendFor(h.clusterMgr, clusterID, k.gvr)
+		if err != nil {
+			httputil.WriteError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		items, err := backend.list(r.Context(), req.namespace, "", "")
+		if err != nil {
+			httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("failed to list %s: %v", k.gvr.Resource, err))
+			return
+		}
+		sort.Slice(items, func(i, j int) bool { return items[i].GetName() < items[j].GetName() })
+		for i := range items {
+			item := &items[i]
+			// List items fetched through an agent carry no type.
+			if item.GetKind() == "" {
+				item.SetAPIVersion(k.gvr.GroupVersion().String())
+				item.SetKind(k.kind)
+			}
+			if (!req.includeOwned && exportOwnedByController(item)) || exportSystemObject(item) {
+				continue
+			}
+			cleanExportObject(item.Object)
+			redactor.Redact(item.Object)
+			objects = append(objects, exportedObject{gvr: k.gvr, obj: item.Object})
+		}
+	}
+
+	header := exportHeader(clusterID, req.namespace, time.Now().UTC(), skipped, redactor != nil)
+	var buf bytes.Buffer
+	var contentType string
+	switch req.format {
+	case exportFormatTar:
+		contentType = "application/x-tar"
+		err = writeExportTar(&buf, header, objects)
+	case exportFormatZip:
+		contentType = "application/zip"
+		err = writeExportZip(&buf, header, objects)
+	default:
+		contentType = "application/yaml"
+		err = writeExportYAML(&buf, header, objects)
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to write bundle: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", req.namespace+"."+req.format))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes()) //nolint:errcheck
+}
+
+// exportResourceName names a resource type the way kubectl does, e.g.
+// "deployments.apps" or "configmaps".
+func exportResourceName(gvr schema.GroupVersionResource) string {
+	if gvr.Group == "" {
+		return gvr.Resource
+	}
+	return gvr.Resource + "." + gvr.Group
+}
+
+// exportOwnedByController reports whether a controller created obj, such as
+// the ReplicaSets of a Deployment. Exporting the owner recreates it.
+func exportOwnedByController(obj *unstructured.Unstructured) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Controller != nil && *ref.Controller {
+			return true
+		}
+	}
+	return false
+}
+
+// exportSystemObject reports whether Kubernetes creates obj in every
+// namespace, so a bundle must not carry it.
+func exportSystemObject(obj *unstructured.Unstructured) bool {
+	switch obj.GetKind() {
+	case "ConfigMap":
+		return obj.GetName() == "kube-root-ca.crt"
+	case "ServiceAccount":
+		return obj.GetName() == "default"
+	case "Secret":
+		t, _, _ := unstructured.NestedString(obj.Object, "type")
+		return t == "kubernetes.io/service-account-token"
+	}
+	return false
+}
+
+// cleanExportObject removes server-managed fields, and defaulted fields
+// still at their default, from obj in place.
+func cleanExportObject(obj map[string]interface{}) {
+	for _, path := range exportNoisePaths {
+		unstructured.RemoveNestedField(obj, path...)
+	}
+	if annotations, ok, _ := unstructured.NestedMap(obj, "metadata", "annotations"); ok {
+		for _, key := range exportNoiseAnnotations {
+			delete(annotations, key)
+		}
+		if len(annotations) == 0 {
+			unstructured.RemoveNestedField(obj, "metadata", "annotations")
+		} else {
+			_ = unstructured.SetNestedMap(obj, annotations, "metadata", "annotations")
+		}
+	}
+
+	kind, _ := obj["kind"].(string)
+	removeExportDefaults(obj, exportKindDefaults[kind])
+	switch kind {
+	case "Service":
+		// Cluster IPs are allocated; headless services keep "None".
+		if ip, _, _ := unstructured.NestedString(obj, "spec", "clusterIP"); ip != "None" {
+			unstructured.RemoveNestedField(obj, "spec", "clusterIP")
+			unstructured.RemoveNestedField(obj, "spec", "clusterIPs")
+		}
+		unstructured.RemoveNestedField(obj, "spec", "ipFamilies")
+		cleanExportPorts(obj, "spec", "ports")
+	case "PersistentVolumeClaim":
+		// The bound volume does not exist where the claim is applied.
+		unstructured.RemoveNestedField(obj, "spec", "volumeName")
+	}
+
+	if path, ok := exportPodSpecPaths[kind]; ok {
+		if len(path) > 1 {
+			templateMeta := append(append([]string{}, path[:len(path)-1]...), "metadata", "creationTimestamp")
+			unstructured.RemoveNestedField(obj, templateMeta...)
+		}
+		if spec, ok := nestedObject(obj, path...); ok {
+			cleanExportPodSpec(spec)
+		}
+	}
+}
+
+// cleanExportPodSpec removes the defaulted fields of a pod spec and its
+// containers in place.
+func cleanExportPodSpec(spec map[string]interface{}) {
+	removeExportDefaults(spec, exportPodSpecDefaults)
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _ := spec[field].([]interface{})
+		for _, c := range containers {
+			if container, ok := c.(map[string]interface{}); ok {
+				removeExportDefaults(container, exportContainerDefaults)
+				cleanExportPorts(container, "ports")
+			}
+		}
+	}
+}
+
+// cleanExportPorts drops the default TCP protocol from a list of ports.
+func cleanExportPorts(obj map[string]interface{}, path ...string) {
+	ports, ok := nestedSlice(obj, path...)
+	if !ok {
+		return
+	}
+	for _, p := range ports {
+		if port, ok := p.(map[string]interface{}); ok && port["protocol"] == "TCP" {
+			delete(port, "protocol")
+		}
+	}
+}
+
+// removeExportDefaults removes each field of obj that holds its default.
+func removeExportDefaults(obj map[string]interface{}, defaults []exportDefault) {
+	for _, d := range defaults {
+		if v, ok, _ := unstructured.NestedFieldNoCopy(obj, d.path...); ok && reflect.DeepEqual(v, d.value) {
+			unstructured.RemoveNestedField(obj, d.path...)
+		}
+	}
+}
+
+// nestedObject returns the map at path without copying it.
+func nestedObject(obj map[string]interface{}, path ...string) (map[string]interface{}, bool) {
+	v, ok, _ := unstructured.NestedFieldNoCopy(obj, path...)
+	m, isMap := v.(map[string]interface{})
+	return m, ok && isMap
+}
+
+// nestedSlice returns the slice at path without copying it.
+func nestedSlice(obj map[string]interface{}, path ...string) ([]interface{}, bool) {
+	v, ok, _ := unstructured.NestedFieldNoCopy(obj, path...)
+	s, isSlice := v.([]interface{})
+	return s, ok && isSlice
+}
+
+// exportHeader describes a bundle: where it was exported from and what it
+// leaves out.
+func exportHeader(clusterID, namespace string, at time.Time, skipped []string, redacted bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Exported from cluster %s, namespace %s, at %s.\n", clusterID, namespace, at.Format(time.RFC3339))
+	if len(skipped) > 0 {
+		fmt.Fprintf(&b, "# Skipped for lack of read permission: %s.\n", strings.Join(skipped, ", "))
+	}
+	if redacted {
+		b.WriteString("# Secret values are redacted and must be filled in before applying.\n")
+	}
+	return b.String()
+}
+
+// writeExportYAML writes the objects as one multi-document YAML stream.
+func writeExportYAML(w io.Writer, header string, objects []exportedObject) error {
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	for _, o := range objects {
+		data, err := yaml.Marshal(o.obj)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportFileName is an object's path in an archive, e.g.
+// "deployments.apps/web.yaml".
+func exportFileName(o exportedObject) string {
+	name, _, _ := unstructured.NestedString(o.obj, "metadata", "name")
+	return exportResourceName(o.gvr) + "/" + name + ".yaml"
+}
+
+// writeExportTar writes a tar archive with the header as README.txt and one
+// YAML file per object.
+func writeExportTar(w io.Writer, header string, objects []exportedObject) error {
+	tw := tar.NewWriter(w)
+	now := time.Now()
+	add := func(name string, data []byte) error {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: now}); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	if err := add("README.txt", []byte(header)); err != nil {
+		return err
+	}
+	for _, o := range objects {
+		data, err := yaml.Marshal(o.obj)
+		if err != nil {
+			return err
+		}
+		if err := add(exportFileName(o), data); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// writeExportZip writes a zip archive laid out like writeExportTar's.
+func writeExportZip(w io.Writer, header string, objects []exportedObject) error {
+	zw := zip.NewWriter(w)
+	add := func(name string, data []byte) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	}
+	if err := add("README.txt", []byte(header)); err != nil {
+		return err
+	}
+	for _, o := range objects {
+		data, err := yaml.Marshal(o.obj)
+		if err != nil {
+			return err
+		}
+		if err := add(exportFileName(o), data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
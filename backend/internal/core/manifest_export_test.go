@@ -0,0 +1,274 @@
+package core
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestParseExportRequest(t *testing.T) {
+	req, err := parseExportRequest(url.Values{"namespace": {"shop"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.selected || len(req.resources) != len(defaultExportResources) || req.format != exportFormatYAML {
+		t.Errorf("expected the default resources as YAML, got %+v", req)
+	}
+
+	req, err = parseExportRequest(url.Values{
+		"namespace": {"shop"},
+		"resource":  {"apps/v1/deployments,v1/configmaps", "_/v1/configmaps"},
+		"format":    {"zip"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []schema.GroupVersionResource{
+		{Group: "apps", Version: "v1", Resource: "deployments"},
+		{Version: "v1", Resource: "configmaps"},
+	}
+	if !req.selected || len(req.resources) != 2 || req.resources[0] != want[0] || req.resources[1] != want[1] || req.format != exportFormatZip {
+		t.Errorf("unexpected request %+v", req)
+	}
+
+	for _, q := range []url.Values{
+		{},
+		{"namespace": {"../x"}},
+		{"namespace": {"shop"}, "format": {"json"}},
+		{"namespace": {"shop"}, "resource": {"deployments"}},
+		{"namespace": {"shop"}, "resource": {"apps//deployments"}},
+	} {
+		if _, err := parseExportRequest(q); err == nil {
+			t.Errorf("%v: expected an error", q)
+		}
+	}
+}
+
+func TestCleanExportObject_Deployment(t *testing.T) {
+	controller := true
+	deploy := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "web", Namespace: "shop", UID: "uid-1", ResourceVersion: "42", Generation: 3,
+			CreationTimestamp: metav1.NewTime(time.Now()),
+			Annotations: map[string]string{
+				"deployment.kubernetes.io/revision": "3",
+				"team":                              "payments",
+			},
+			ManagedFields:   []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+			OwnerReferences: []metav1.OwnerReference{{Name: "app", Controller: &controller}},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: int32Ptr(2),
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{
+					MaxSurge:       ptrIntOrString(intstr.FromString("25%")),
+					MaxUnavailable: ptrIntOrString(intstr.FromString("25%")),
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					DNSPolicy:       corev1.DNSClusterFirst,
+					SchedulerName:   "default-scheduler",
+					SecurityContext: &corev1.PodSecurityContext{},
+					Containers: []corev1.Container{{
+						Name: "web", Image: "nginx",
+						TerminationMessagePath:   "/dev/termination-log",
+						TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+						Ports:                    []corev1.ContainerPort{{ContainerPort: 80, Protocol: corev1.ProtocolTCP}},
+					}},
+				},
+			},
+		},
+		Status: appsv1.DeploymentStatus{ReadyReplicas: 2},
+	}
+	obj := toUnstructured(t, deploy)
+	cleanExportObject(obj.Object)
+
+	for _, path := range [][]string{
+		{"status"}, {"metadata", "uid"}, {"metadata", "resourceVersion"}, {"metadata", "generation"},
+		{"metadata", "creationTimestamp"}, {"metadata", "managedFields"}, {"metadata", "ownerReferences"},
+		{"spec", "strategy"}, {"spec", "template", "metadata", "creationTimestamp"},
+		{"spec", "template", "spec", "dnsPolicy"}, {"spec", "template", "spec", "schedulerName"},
+		{"spec", "template", "spec", "securityContext"},
+	} {
+		if _, found, _ := unstructured.NestedFieldNoCopy(obj.Object, path...); found {
+			t.Errorf("expected %s to be removed", strings.Join(path, "."))
+		}
+	}
+	if annotations := obj.GetAnnotations(); len(annotations) != 1 || annotations["team"] != "payments" {
+		t.Errorf("expected only user annotations to remain, got %v", annotations)
+	}
+	if n, _, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); n != 2 {
+		t.Errorf("expected replicas to be kept, got %d", n)
+	}
+	containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+	container := containers[0].(map[string]interface{})
+	if _, ok := container["terminationMessagePath"]; ok {
+		t.Error("expected the default termination message path to be removed")
+	}
+	port := container["ports"].([]interface{})[0].(map[string]interface{})
+	if _, ok := port["protocol"]; ok || port["containerPort"] != int64(80) {
+		t.Errorf("expected the default protocol to be removed, got %v", port)
+	}
+}
+
+func TestCleanExportObject_Service(t *testing.T) {
+	svc := func(clusterIP string) unstructured.Unstructured {
+		return toUnstructured(t, &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web"},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: clusterIP, ClusterIPs: []string{clusterIP},
+				SessionAffinity: corev1.ServiceAffinityClientIP,
+				Ports:           []corev1.ServicePort{{Port: 80, Protocol: corev1.ProtocolUDP}},
+			},
+		})
+	}
+
+	obj := svc("10.0.0.12")
+	cleanExportObject(obj.Object)
+	if _, found, _ := unstructured.NestedString(obj.Object, "spec", "clusterIP"); found {
+		t.Error("expected the allocated cluster IP to be removed")
+	}
+	if v, _, _ := unstructured.NestedString(obj.Object, "spec", "sessionAffinity"); v != "ClientIP" {
+		t.Errorf("expected a non-default session affinity to be kept, got %q", v)
+	}
+	ports, _, _ := unstructured.NestedSlice(obj.Object, "spec", "ports")
+	if ports[0].(map[string]interface{})["protocol"] != "UDP" {
+		t.Error("expected a non-default protocol to be kept")
+	}
+
+	headless := svc("None")
+	cleanExportObject(headless.Object)
+	if v, _, _ := unstructured.NestedString(headless.Object, "spec", "clusterIP"); v != "None" {
+		t.Error("expected a headless service to keep clusterIP: None")
+	}
+}
+
+func TestExportSkipsOwnedAndSystemObjects(t *testing.T) {
+	controller := true
+	owned := toUnstructured(t, &appsv1.ReplicaSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "ReplicaSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web-abc", OwnerReferences: []metav1.OwnerReference{{Name: "web", Controller: &controller}}},
+	})
+	if !exportOwnedByController(&owned) {
+		t.Error("expected a controller-owned object to be detected")
+	}
+
+	for _, obj := range []interface{}{
+		&corev1.ConfigMap{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}, ObjectMeta: metav1.ObjectMeta{Name: "kube-root-ca.crt"}},
+		&corev1.ServiceAccount{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"}, ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&corev1.Secret{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}, ObjectMeta: metav1.ObjectMeta{Name: "t"}, Type: corev1.SecretTypeServiceAccountToken},
+	} {
+		u := toUnstructured(t, obj)
+		if !exportSystemObject(&u) {
+			t.Errorf("expected %s %s to be skipped", u.GetKind(), u.GetName())
+		}
+	}
+	app := toUnstructured(t, &corev1.ConfigMap{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}, ObjectMeta: metav1.ObjectMeta{Name: "app-config"}})
+	if exportSystemObject(&app) {
+		t.Error("expected an application ConfigMap to be exported")
+	}
+}
+
+func exportTestObjects() []exportedObject {
+	return []exportedObject{
+		{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, obj: map[string]interface{}{
+			"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[string]interface{}{"name": "web"},
+		}},
+		{gvr: schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, obj: map[string]interface{}{
+			"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "app-config"},
+			"data": map[string]interface{}{"mode": "prod"},
+		}},
+	}
+}
+
+func TestWriteExportYAML(t *testing.T) {
+	header := exportHeader("c1", "shop", time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC), []string{"secrets"}, true)
+	var buf bytes.Buffer
+	if err := writeExportYAML(&buf, header, exportTestObjects()); err != nil {
+		t.Fatal(err)
+	}
+	want := `# Exported from cluster c1, namespace shop, at 2026-05-01T12:00:00Z.
+# Skipped for lack of read permission: secrets.
+# Secret values are redacted and must be filled in before applying.
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: v1
+data:
+  mode: prod
+kind: ConfigMap
+metadata:
+  name: app-config
+`
+	if buf.String() != want {
+		t.Errorf("unexpected bundle:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteExportArchives(t *testing.T) {
+	wantFiles := []string{"README.txt", "deployments.apps/web.yaml", "configmaps/app-config.yaml"}
+
+	var tarBuf bytes.Buffer
+	if err := writeExportTar(&tarBuf, "# header\n", exportTestObjects()); err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(&tarBuf)
+	var tarFiles []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		tarFiles = append(tarFiles, hdr.Name)
+	}
+	if strings.Join(tarFiles, ",") != strings.Join(wantFiles, ",") {
+		t.Errorf("unexpected tar entries %v", tarFiles)
+	}
+
+	var zipBuf bytes.Buffer
+	if err := writeExportZip(&zipBuf, "# header\n", exportTestObjects()); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(zipBuf.Bytes()), int64(zipBuf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var zipFiles []string
+	for _, f := range zr.File {
+		zipFiles = append(zipFiles, f.Name)
+	}
+	if strings.Join(zipFiles, ",") != strings.Join(wantFiles, ",") {
+		t.Errorf("unexpected zip entries %v", zipFiles)
+	}
+	f, _ := zr.Open("configmaps/app-config.yaml")
+	data, _ := io.ReadAll(f)
+	if !strings.Contains(string(data), "mode: prod") {
+		t.Errorf("unexpected file content %s", data)
+	}
+}
+
+func ptrIntOrString(v intstr.IntOrString) *intstr.IntOrString {
+	return &v
+}
@@ -0,0 +1,598 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+// defaultManifestMaxBytes is the manifest size limit when none is configured.
+const defaultManifestMaxBytes = 1 << 20
+
+// maxManifestObjects caps how many objects a single manifest may contain.
+const maxManifestObjects = 100
+
+// manifestFetchTimeout bounds fetching a manifest, redirects included.
+const manifestFetchTimeout = 15 * time.Second
+
+// maxManifestRedirects caps the redirects followed when fetching a manifest.
+const maxManifestRedirects = 3
+
+// Per-object actions reported by the manifest preview.
+const (
+	manifestActionCreate    = "create"
+	manifestActionUpdate    = "update"
+	manifestActionUnchanged = "unchanged"
+	manifestActionForbidden = "forbidden"
+	manifestActionInvalid   = "invalid"
+	// manifestActionConflict means applying would change fields other
+	// field managers own; applying with force takes them over.
+	manifestActionConflict = "conflict"
+)
+
+// Per-object statuses reported by the manifest apply.
+const (
+	manifestStatusApplied = "applied"
+	manifestStatusFailed  = "failed"
+)
+
+// ManifestImportOptions configures where manifests may be fetched from.
+type ManifestImportOptions struct {
+	// AllowedURLs are the URL prefixes manifests may be fetched from.
+	AllowedURLs []string
+	// GitSources maps a source name to the raw-file base URL of a
+	// repository at a ref, e.g. https://raw.githubusercontent.com/org/repo/main.
+	GitSources map[string]string
+	// MaxBytes limits the manifest size; 0 means 1 MiB.
+	MaxBytes int64
+}
+
+// manifestCluster sends requests to a cluster's Kubernetes API. Implemented
+// by *cluster.Manager.
+type manifestCluster interface {
+	DoK8sRequestWithContentType(ctx context.Context, clusterID, method, path, contentType string, body []byte) ([]byte, error)
+}
+
+// ManifestImportHandler applies manifests fetched from an allowlisted URL or
+// a configured git source: a preview validates every object with a
+// server-side dry run and diffs it against the live object, and an apply
+// confirms the previewed content by its digest.
+type ManifestImportHandler struct {
+	k8s       manifestCluster
+	authz     rbac.Evaluator
+	discovery *DiscoveryCache
+	fetcher   *manifestFetcher
+}
+
+// NewManifestImportHandler creates a new ManifestImportHandler.
+func NewManifestImportHandler(cm *cluster.Manager, engine *rbac.Engine, discovery *DiscoveryCache, opts ManifestImportOptions) (*ManifestImportHandler, error) {
+	fetcher, err := newManifestFetcher(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &ManifestImportHandler{k8s: cm, authz: engine, discovery: discovery, fetcher: fetcher}, nil
+}
+
+// RegisterRoutes wires the manifest import endpoints.
+func (h *ManifestImportHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clusters/{clusterID}/manifests/preview", h.Preview).Methods(http.MethodPost)
+	r.HandleFunc("/api/clusters/{clusterID}/manifests/apply", h.Apply).Methods(http.MethodPost)
+	r.HandleFunc("/api/clusters/{clusterID}/manifests/validate", h.Validate).Methods(http.MethodPost)
+}
+
+// manifestRequest is the JSON body accepted by the preview and apply
+// endpoints. Exactly one of URL or Source (with Path) must be set.
+type manifestRequest struct {
+	URL    string `json:"url"`
+	Source string `json:"source"`
+	Path   string `json:"path"`
+	// Namespace is used for namespaced objects that don't set one.
+	Namespace string `json:"namespace"`
+	// Force takes ownership of fields managed by another field manager
+	// instead of reporting a conflict.
+	Force bool `json:"force"`
+	// Digest confirms the previewed content; required by apply.
+	Digest string `json:"digest"`
+}
+
+// manifestObjectResult is the outcome for one object of the manifest.
+type manifestObjectResult struct {
+	APIVersion string                  `json:"apiVersion"`
+	Kind       string                  `json:"kind"`
+	Namespace  string                  `json:"namespace,omitempty"`
+	Name       string                  `json:"name"`
+	Action     string                  `json:"action,omitempty"`
+	Diffs      []fieldDiff             `json:"diffs,omitempty"`
+	Conflicts  []cluster.ApplyConflict `json:"conflicts,omitempty"`
+	Status     string                  `json:"status,omitempty"`
+	Error      string                  `json:"error,omitempty"`
+}
+
+// manifestResponse is the report of a preview or apply. In Diffs, Source is
+// the live value and Target the value after applying.
+type manifestResponse struct {
+	URL     string                 `json:"url"`
+	Digest  string                 `json:"digest"`
+	Objects []manifestObjectResult `json:"objects"`
+}
+
+// manifestObject is a manifest object resolved against the cluster.
+type manifestObject struct {
+	obj    *unstructured.Unstructured
+	gvr    schema.GroupVersionResource
+	result manifestObjectResult
+}
+
+// Preview fetches the manifest and reports, per object, whether applying it
+// would create, update or leave it unchanged, with the field-level diff
+// computed from a server-side dry run. Nothing is changed.
+func (h *ManifestImportHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	clusterID := mux.Vars(r)["clusterID"]
+	req, resp, objects, ok := h.load(w, r, clusterID)
+	if !ok {
+		return
+	}
+
+	for i := range objects {
+		mo := &objects[i]
+		if mo.result.Action == "" {
+			h.previewOne(r.Context(), clusterID, req, mo)
+		}
+		resp.Objects = append(resp.Objects, mo.result)
+	}
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+// Apply re-fetches the manifest and applies it with server-side apply once
+// its digest matches the previewed one. Nothing is applied when the content
+// changed, or when any object is invalid or forbidden.
+func (h *ManifestImportHandler) Apply(w http.ResponseWriter, r *http.Request) {
+	clusterID := mux.Vars(r)["clusterID"]
+	req, resp, objects, ok := h.load(w, r, clusterID)
+	if !ok {
+		return
+	}
+
+	if req.Digest == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "digest is required; preview the manifest first")
+		return
+	}
+	if req.Digest != resp.Digest {
+		httputil.WriteError(w, http.StatusConflict, "manifest changed since it was previewed")
+		return
+	}
+	for _, mo := range objects {
+		if mo.result.Action == manifestActionForbidden {
+			httputil.WriteError(w, http.StatusForbidden, fmt.Sprintf("permission denied: %s", mo.result.Error))
+			return
+		}
+		if mo.result.Action == manifestActionInvalid {
+			httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("%s %s: %s", mo.result.Kind, mo.result.Name, mo.result.Error))
+			return
+		}
+	}
+
+	for i := range objects {
+		mo := &objects[i]
+		if _, err := h.serverSideApply(r.Context(), clusterID, mo, req.Force, false); err != nil {
+			mo.result.Status = manifestStatusFailed
+			mo.result.Error = k8sErrorMessage(err)
+			if conflict, ok := cluster.AsApplyConflict(err); ok {
+				mo.result.Conflicts = conflict.Conflicts
+			}
+		} else {
+			mo.result.Status = manifestStatusApplied
+		}
+		resp.Objects = append(resp.Objects, mo.result)
+	}
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+// load decodes the request, fetches and parses the manifest and resolves
+// every object against the cluster's API and the caller's permissions.
+// Objects that cannot be applied are marked invalid or forbidden. It writes
+// the error response and returns false on failure.
+func (h *ManifestImportHandler) load(w http.ResponseWriter, r *http.Request, clusterID string) (*manifestRequest, *manifestResponse, []manifestObject, bool) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return nil, nil, nil, false
+	}
+
+	var req manifestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return nil, nil, nil, false
+	}
+	if !validate.Segment(req.Namespace) {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid namespace")
+		return nil, nil, nil, false
+	}
+	u, err := h.fetcher.resolve(req.URL, req.Source, req.Path)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return nil, nil, nil, false
+	}
+
+	data, err := h.fetcher.fetch(r.Context(), u)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, err.Error())
+		return nil, nil, nil, false
+	}
+	objs, err := parseManifest(data)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid manifest: %v", err))
+		return nil, nil, nil, false
+	}
+	if _, err := h.discovery.Get(r.Context(), clusterID); err != nil {
+		writeClusterError(w, err, "discovery failed")
+		return nil, nil, nil, false
+	}
+
+	sum := sha256.Sum256(data)
+	resp := &manifestResponse{
+		URL:     u.String(),
+		Digest:  "sha256:" + hex.EncodeToString(sum[:]),
+		Objects: make([]manifestObjectResult, 0, len(objs)),
+	}
+	objects := make([]manifestObject, 0, len(objs))
+	for _, obj := range objs {
+		objects = append(objects, h.resolve(r.Context(), clusterID, claims.UserID, req.Namespace, obj))
+	}
+	return &req, resp, objects, true
+}
+
+// resolve finds the resource serving obj, fills in its namespace and checks
+// that the user may write it.
+func (h *ManifestImportHandler) resolve(ctx context.Context, clusterID, userID, defaultNamespace string, obj *unstructured.Unstructured) manifestObject {
+	mo := manifestObject{obj: obj, result: manifestObjectResult{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+	}}
+	invalid := func(msg string) manifestObject {
+		mo.result.Action = manifestActionInvalid
+		mo.result.Error = msg
+		return mo
+	}
+
+	res, found, err := h.discovery.LookupKind(ctx, clusterID, obj.GroupVersionKind())
+	if err != nil {
+		return invalid(fmt.Sprintf("discovery failed: %v", err))
+	}
+	if !found {
+		return invalid(fmt.Sprintf("the cluster does not serve %s %s", obj.GetAPIVersion(), obj.GetKind()))
+	}
+	mo.gvr = schema.GroupVersionResource{Group: res.Group, Version: res.Version, Resource: res.Resource}
+
+	if res.Namespaced {
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(defaultNamespace)
+		}
+		if obj.GetNamespace() == "" {
+			return invalid("namespace is required")
+		}
+		if !validate.Segment(obj.GetNamespace()) {
+			return invalid("invalid namespace")
+		}
+	} else {
+		obj.SetNamespace("")
+	}
+	mo.result.Namespace = obj.GetNamespace()
+
+	allowed, err := h.authz.Evaluate(ctx, rbac.Request{
+		UserID:      userID,
+		Action:      "write",
+		Resource:    rbac.ResourceCategory(res.Resource),
+		ClusterID:   clusterID,
+		Namespace:   obj.GetNamespace(),
+		K8sResource: rbac.QualifiedResource(res.Group, res.Resource),
+	})
+	if err != nil || !allowed {
+		mo.result.Action = manifestActionForbidden
+		mo.result.Error = fmt.Sprintf("cannot write %s in namespace %q", res.Resource, obj.GetNamespace())
+	}
+	return mo
+}
+
+// previewOne dry-runs the apply of one object and diffs the result against
+// the live object.
+func (h *ManifestImportHandler) previewOne(ctx context.Context, clusterID string, req *manifestRequest, mo *manifestObject) {
+	path := k8sAPIPath(mo.gvr, mo.obj.GetNamespace(), mo.obj.GetName())
+
+	var live map[string]interface{}
+	data, err := h.k8s.DoK8sRequestWithContentType(ctx, clusterID, http.MethodGet, path, "application/json", nil)
+	var reqErr *cluster.K8sRequestError
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &live); err != nil {
+			mo.result.Action = manifestActionInvalid
+			mo.result.Error = "failed to decode the live object"
+			return
+		}
+	case errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusNotFound:
+	default:
+		mo.result.Action = manifestActionInvalid
+		mo.result.Error = k8sErrorMessage(err)
+		return
+	}
+
+	applied, err := h.serverSideApply(ctx, clusterID, mo, req.Force, true)
+	if conflict, ok := cluster.AsApplyConflict(err); ok {
+		mo.result.Action = manifestActionConflict
+		mo.result.Conflicts = conflict.Conflicts
+		mo.result.Error = k8sErrorMessage(err)
+		return
+	}
+	if err != nil {
+		mo.result.Action = manifestActionInvalid
+		mo.result.Error = k8sErrorMessage(err)
+		return
+	}
+
+	if live == nil {
+		mo.result.Action = manifestActionCreate
+		return
+	}
+	mo.result.Diffs = diffObjects(live, applied, driftNoisePaths)
+	if len(mo.result.Diffs) == 0 {
+		mo.result.Action = manifestActionUnchanged
+	} else {
+		mo.result.Action = manifestActionUpdate
+	}
+}
+
+// serverSideApply applies one object with server-side apply, optionally as a
+// dry run, and returns the resulting object.
+func (h *ManifestImportHandler) serverSideApply(ctx context.Context, clusterID string, mo *manifestObject, force, dryRun bool) (map[string]interface{}, error) {
+	body, err := json.Marshal(mo.obj.Object)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{"fieldManager": {cluster.FieldManagerImport}}
+	if force {
+		query.Set("force", "true")
+	}
+	if dryRun {
+		query.Set("dryRun", "All")
+	}
+	path := k8sAPIPath(mo.gvr, mo.obj.GetNamespace(), mo.obj.GetName()) + "?" + query.Encode()
+
+	data, err := h.k8s.DoK8sRequestWithContentType(ctx, clusterID, http.MethodPatch, path, "application/apply-patch+yaml", body)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode the applied object: %w", err)
+	}
+	return result, nil
+}
+
+// k8sErrorMessage returns the API server's message for err.
+func k8sErrorMessage(err error) string {
+	var reqErr *cluster.K8sRequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.Message
+	}
+	return err.Error()
+}
+
+// parseManifest decodes a multi-document YAML or JSON manifest. Empty
+// documents are skipped; List kinds are not supported.
+func parseManifest(data []byte) ([]*unstructured.Unstructured, error) {
+	decoder := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	var objs []*unstructured.Unstructured
+	for doc := 1; ; doc++ {
+		var raw map[string]interface{}
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("document %d: %w", doc, err)
+		}
+		if len(raw) == 0 {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{Object: raw}
+		if obj.GetAPIVersion() == "" || obj.GetKind() == "" || obj.GetName() == "" {
+			return nil, fmt.Errorf("document %d: apiVersion, kind and metadata.name are required", doc)
+		}
+		if strings.HasSuffix(obj.GetKind(), "List") {
+			return nil, fmt.Errorf("document %d: %s is not supported; list the objects as separate documents", doc, obj.GetKind())
+		}
+		if !validManifestName(obj.GetName()) {
+			return nil, fmt.Errorf("document %d: invalid name %q", doc, obj.GetName())
+		}
+		objs = append(objs, obj)
+		if len(objs) > maxManifestObjects {
+			return nil, fmt.Errorf("at most %d objects are allowed", maxManifestObjects)
+		}
+	}
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("no objects found")
+	}
+	return objs, nil
+}
+
+// validManifestName reports whether name can be used as an API path segment.
+func validManifestName(name string) bool {
+	return len(name) <= 253 && name != "." && name != ".." && !strings.ContainsAny(name, "/%?#")
+}
+
+// manifestFetcher downloads manifests from allowlisted URLs. Each request,
+// including every redirect, must match the allowlist, and connections to
+// loopback, link-local and unspecified addresses are refused at dial time so
+// a hostname cannot be re-pointed at the server itself or a cloud metadata
+// endpoint.
+type manifestFetcher struct {
+	allowed    []*url.URL
+	gitSources map[string]*url.URL
+	maxBytes   int64
+	client     *http.Client
+}
+
+func newManifestFetcher(opts ManifestImportOptions) (*manifestFetcher, error) {
+	f := &manifestFetcher{gitSources: make(map[string]*url.URL), maxBytes: opts.MaxBytes}
+	if f.maxBytes <= 0 {
+		f.maxBytes = defaultManifestMaxBytes
+	}
+	for _, raw := range opts.AllowedURLs {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid allowed manifest URL %q", raw)
+		}
+		f.allowed = append(f.allowed, u)
+	}
+	for name, raw := range opts.GitSources {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid git source %q: %q", name, raw)
+		}
+		f.gitSources[name] = u
+		f.allowed = append(f.allowed, u)
+	}
+
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: manifestDialControl}
+	f.client = &http.Client{
+		Timeout: manifestFetchTimeout,
+		// No proxy: the dial guard must see the real destination.
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 5 * time.Second},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxManifestRedirects {
+				return fmt.Errorf("too many redirects")
+			}
+			if !f.isAllowed(req.URL) {
+				return fmt.Errorf("redirect to %s is not allowed", req.URL.Redacted())
+			}
+			return nil
+		},
+	}
+	return f, nil
+}
+
+// manifestDialControl refuses connections to loopback, link-local and
+// unspecified addresses, checked after DNS resolution.
+func manifestDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("connections to %s are not allowed", host)
+	}
+	return nil
+}
+
+// resolve returns the URL to fetch for a request naming either a URL or a
+// git source and a path within it.
+func (f *manifestFetcher) resolve(rawURL, source, path string) (*url.URL, error) {
+	switch {
+	case rawURL != "" && source != "":
+		return nil, fmt.Errorf("set either url or source, not both")
+	case rawURL != "":
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url")
+		}
+		if !f.isAllowed(u) {
+			return nil, fmt.Errorf("url is not in the manifest allowlist")
+		}
+		return u, nil
+	case source != "":
+		base, ok := f.gitSources[source]
+		if !ok {
+			return nil, fmt.Errorf("unknown git source %q", source)
+		}
+		path = strings.TrimPrefix(path, "/")
+		if path == "" || hasDotDotSegment(path) || strings.ContainsAny(path, "?#\\") {
+			return nil, fmt.Errorf("invalid path")
+		}
+		u := *base
+		u.Path = strings.TrimSuffix(base.Path, "/") + "/" + path
+		u.RawPath = ""
+		return &u, nil
+	default:
+		return nil, fmt.Errorf("url or source is required")
+	}
+}
+
+// isAllowed reports whether u falls under one of the allowed URL prefixes:
+// same scheme and host, and a path at or below the prefix path.
+func (f *manifestFetcher) isAllowed(u *url.URL) bool {
+	if (u.Scheme != "https" && u.Scheme != "http") || u.User != nil || hasDotDotSegment(u.Path) {
+		return false
+	}
+	for _, prefix := range f.allowed {
+		if u.Scheme != prefix.Scheme || !strings.EqualFold(u.Host, prefix.Host) {
+			continue
+		}
+		base := strings.TrimSuffix(prefix.Path, "/")
+		if base == "" || u.Path == base || strings.HasPrefix(u.Path, base+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDotDotSegment reports whether a URL path contains a ".." segment.
+func hasDotDotSegment(path string) bool {
+	for _, seg := range strings.Split(path, "/") {
+		if seg == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// fetch downloads u, enforcing the size limit.
+func (f *manifestFetcher) fetch(ctx context.Context, u *url.URL) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url")
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch manifest: %s returned %d", u.Redacted(), resp.StatusCode)
+	}
+	if resp.ContentLength > f.maxBytes {
+		return nil, fmt.Errorf("manifest exceeds the %d byte limit", f.maxBytes)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %v", err)
+	}
+	if int64(len(data)) > f.maxBytes {
+		return nil, fmt.Errorf("manifest exceeds the %d byte limit", f.maxBytes)
+	}
+	return data, nil
+}
@@ -0,0 +1,319 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+const testManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  mode: fast
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: team-a
+spec:
+  replicas: 2
+`
+
+// fakeManifestCluster serves live objects by path and echoes applied objects.
+type fakeManifestCluster struct {
+	mu      sync.Mutex
+	live    map[string]string
+	applied []string
+	// conflicting paths fail an unforced apply with a field conflict.
+	conflicting map[string]bool
+}
+
+func (c *fakeManifestCluster) DoK8sRequestWithContentType(ctx context.Context, clusterID, method, path, contentType string, body []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch method {
+	case http.MethodGet:
+		if obj, ok := c.live[path]; ok {
+			return []byte(obj), nil
+		}
+		return nil, &cluster.K8sRequestError{StatusCode: http.StatusNotFound, Message: "not found"}
+	case http.MethodPatch:
+		if contentType != "application/apply-patch+yaml" {
+			return nil, fmt.Errorf("unexpected content type %q", contentType)
+		}
+		base := path[:strings.Index(path, "?")]
+		if c.conflicting[base] && !strings.Contains(path, "force=true") {
+			return nil, &cluster.K8sRequestError{StatusCode: http.StatusConflict, Message: "Apply failed with 1 conflict",
+				Details: &metav1.StatusDetails{Causes: []metav1.StatusCause{{
+					Type:    metav1.CauseTypeFieldManagerConflict,
+					Message: `conflict with "kube-controller-manager" using apps/v1`,
+					Field:   ".spec.replicas",
+				}}}}
+		}
+		if !strings.Contains(path, "dryRun=All") {
+			c.applied = append(c.applied, base)
+		}
+		return body, nil
+	}
+	return nil, fmt.Errorf("unexpected method %s", method)
+}
+
+// writeNamespaces grants write in the listed namespaces only.
+type writeNamespaces map[string]bool
+
+func (a writeNamespaces) Evaluate(ctx context.Context, req rbac.Request) (bool, error) {
+	return req.Action == "write" && a[req.Namespace], nil
+}
+
+// newManifestTestHandler serves manifest and returns a router for the import
+// endpoints, the fake cluster and the manifest's URL.
+func newManifestTestHandler(t *testing.T, manifest string, allowed writeNamespaces) (*mux.Router, *fakeManifestCluster, string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(manifest))
+	}))
+	t.Cleanup(srv.Close)
+
+	fetcher, err := newManifestFetcher(ManifestImportOptions{AllowedURLs: []string{srv.URL + "/manifests/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher.client.Transport = srv.Client().Transport
+
+	k8s := &fakeManifestCluster{live: map[string]string{
+		"/apis/apps/v1/namespaces/team-a/deployments/web": `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"team-a","resourceVersion":"7"},"spec":{"replicas":1}}`,
+	}}
+	discovery := newDiscoveryCache(&fakeDiscovery{resources: []ServedResource{
+		{APIResourceEntry: APIResourceEntry{Kind: "ConfigMap", Version: "v1", Resource: "configmaps", Namespaced: true}},
+		{APIResourceEntry: APIResourceEntry{Kind: "Deployment", Group: "apps", Version: "v1", Resource: "deployments", Namespaced: true}},
+	}})
+
+	r := mux.NewRouter()
+	(&ManifestImportHandler{k8s: k8s, authz: allowed, discovery: discovery, fetcher: fetcher}).RegisterRoutes(r)
+	return r, k8s, srv.URL + "/manifests/app.yaml"
+}
+
+func manifestRequestTo(t *testing.T, r *mux.Router, action string, body map[string]interface{}) (*httptest.ResponseRecorder, manifestResponse) {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/clusters/c1/manifests/"+action, strings.NewReader(string(data)))
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), &auth.Claims{UserID: "u1"}))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp manifestResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestManifestImport_PreviewAndApply(t *testing.T) {
+	r, k8s, url := newManifestTestHandler(t, testManifest, writeNamespaces{"default": true, "team-a": true})
+
+	w, preview := manifestRequestTo(t, r, "preview", map[string]interface{}{"url": url, "namespace": "default"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("preview: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(preview.Objects) != 2 || !strings.HasPrefix(preview.Digest, "sha256:") {
+		t.Fatalf("unexpected preview: %+v", preview)
+	}
+	cm, deploy := preview.Objects[0], preview.Objects[1]
+	if cm.Action != manifestActionCreate || cm.Namespace != "default" {
+		t.Errorf("expected the ConfigMap to be created in the default namespace: %+v", cm)
+	}
+	if deploy.Action != manifestActionUpdate || len(deploy.Diffs) != 1 || deploy.Diffs[0].Path != "spec.replicas" {
+		t.Errorf("expected a replicas diff for the Deployment: %+v", deploy)
+	}
+	if len(k8s.applied) != 0 {
+		t.Fatal("preview must not apply anything")
+	}
+
+	w, _ = manifestRequestTo(t, r, "apply", map[string]interface{}{"url": url, "namespace": "default", "digest": "sha256:stale"})
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a stale digest, got %d", w.Code)
+	}
+
+	w, applied := manifestRequestTo(t, r, "apply", map[string]interface{}{"url": url, "namespace": "default", "digest": preview.Digest})
+	if w.Code != http.StatusOK {
+		t.Fatalf("apply: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, obj := range applied.Objects {
+		if obj.Status != manifestStatusApplied {
+			t.Errorf("expected %s to be applied: %+v", obj.Name, obj)
+		}
+	}
+	if len(k8s.applied) != 2 || k8s.applied[0] != "/api/v1/namespaces/default/configmaps/settings" {
+		t.Errorf("unexpected applied paths: %v", k8s.applied)
+	}
+}
+
+func TestManifestImport_ApplyRefusesForbiddenObjects(t *testing.T) {
+	r, k8s, url := newManifestTestHandler(t, testManifest, writeNamespaces{"default": true})
+
+	_, preview := manifestRequestTo(t, r, "preview", map[string]interface{}{"url": url, "namespace": "default"})
+	if len(preview.Objects) != 2 || preview.Objects[1].Action != manifestActionForbidden {
+		t.Fatalf("expected the Deployment in team-a to be forbidden: %+v", preview.Objects)
+	}
+
+	w, _ := manifestRequestTo(t, r, "apply", map[string]interface{}{"url": url, "namespace": "default", "digest": preview.Digest})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", w.Code)
+	}
+	if len(k8s.applied) != 0 {
+		t.Error("nothing may be applied when any object is forbidden")
+	}
+}
+
+func TestManifestImport_RejectsURLOutsideAllowlist(t *testing.T) {
+	r, _, _ := newManifestTestHandler(t, testManifest, writeNamespaces{"default": true})
+
+	w, _ := manifestRequestTo(t, r, "preview", map[string]interface{}{"url": "http://169.254.169.254/latest/meta-data/"})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestManifestFetcher_Resolve(t *testing.T) {
+	f, err := newManifestFetcher(ManifestImportOptions{
+		AllowedURLs: []string{"https://raw.githubusercontent.com/acme/"},
+		GitSources:  map[string]string{"infra": "https://git.example.com/infra/raw/main"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allowed := []string{
+		"https://raw.githubusercontent.com/acme/app/main/deploy.yaml",
+		"https://RAW.githubusercontent.com/acme/app.yaml",
+	}
+	for _, raw := range allowed {
+		if _, err := f.resolve(raw, "", ""); err != nil {
+			t.Errorf("%s: expected to be allowed, got %v", raw, err)
+		}
+	}
+	denied := []string{
+		"http://raw.githubusercontent.com/acme/app.yaml",
+		"https://raw.githubusercontent.com/acmecorp/app.yaml",
+		"https://raw.githubusercontent.com/acme/../other/app.yaml",
+		"https://raw.githubusercontent.com/acme/%2e%2e/other/app.yaml",
+		"https://user@raw.githubusercontent.com/acme/app.yaml",
+		"file:///etc/passwd",
+	}
+	for _, raw := range denied {
+		if _, err := f.resolve(raw, "", ""); err == nil {
+			t.Errorf("%s: expected to be denied", raw)
+		}
+	}
+
+	u, err := f.resolve("", "infra", "apps/web.yaml")
+	if err != nil || u.String() != "https://git.example.com/infra/raw/main/apps/web.yaml" {
+		t.Errorf("unexpected git source URL %v: %v", u, err)
+	}
+	for _, path := range []string{"", "../secrets.yaml", "apps/../../x.yaml"} {
+		if _, err := f.resolve("", "infra", path); err == nil {
+			t.Errorf("path %q: expected to be rejected", path)
+		}
+	}
+	if _, err := f.resolve("", "unknown", "a.yaml"); err == nil {
+		t.Error("expected unknown git source to be rejected")
+	}
+}
+
+func TestManifestFetcher_SizeLimitAndRedirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok/big.yaml":
+			_, _ = w.Write([]byte(strings.Repeat("a", 2048)))
+		case "/ok/escape.yaml":
+			http.Redirect(w, r, "/private/token", http.StatusFound)
+		default:
+			_, _ = w.Write([]byte("kind: ConfigMap"))
+		}
+	}))
+	defer srv.Close()
+
+	f, err := newManifestFetcher(ManifestImportOptions{AllowedURLs: []string{srv.URL + "/ok/"}, MaxBytes: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.client.Transport = srv.Client().Transport
+
+	for path, want := range map[string]string{"/ok/big.yaml": "byte limit", "/ok/escape.yaml": "not allowed"} {
+		u, err := f.resolve(srv.URL+path, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.fetch(context.Background(), u); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", path, want, err)
+		}
+	}
+}
+
+func TestManifestDialControl(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1:443", "[::1]:443", "169.254.169.254:80", "0.0.0.0:80"} {
+		if err := manifestDialControl("tcp", addr, nil); err == nil {
+			t.Errorf("%s: expected the connection to be refused", addr)
+		}
+	}
+	if err := manifestDialControl("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("expected public address to be allowed, got %v", err)
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	objs, err := parseManifest([]byte("---\n" + testManifest + "---\n"))
+	if err != nil || len(objs) != 2 || objs[1].GetKind() != "Deployment" {
+		t.Fatalf("unexpected result: %v, %v", objs, err)
+	}
+
+	for _, bad := range []string{
+		"",
+		"apiVersion: v1\nkind: ConfigMap\n",
+		"apiVersion: v1\nkind: List\nmetadata:\n  name: x\n",
+		"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a/b\n",
+	} {
+		if _, err := parseManifest([]byte(bad)); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestManifestImport_PreviewReportsFieldConflicts(t *testing.T) {
+	r, k8s, url := newManifestTestHandler(t, testManifest, writeNamespaces{"default": true, "team-a": true})
+	k8s.conflicting = map[string]bool{"/apis/apps/v1/namespaces/team-a/deployments/web": true}
+
+	_, preview := manifestRequestTo(t, r, "preview", map[string]interface{}{"url": url, "namespace": "default"})
+	var web manifestObjectResult
+	for _, obj := range preview.Objects {
+		if obj.Name == "web" {
+			web = obj
+		}
+	}
+	if web.Action != manifestActionConflict || len(web.Conflicts) != 1 {
+		t.Fatalf("expected a conflict for web, got %+v", web)
+	}
+	if c := web.Conflicts[0]; c.Field != ".spec.replicas" || c.Manager != "kube-controller-manager" {
+		t.Errorf("unexpected conflict: %+v", c)
+	}
+
+	// Forcing takes the fields over, so the object previews as an update.
+	_, preview = manifestRequestTo(t, r, "preview", map[string]interface{}{"url": url, "namespace": "default", "force": true})
+	for _, obj := range preview.Objects {
+		if obj.Name == "web" && obj.Action != manifestActionUpdate {
+			t.Errorf("expected a forced preview to update web, got %+v", obj)
+		}
+	}
+}
@@ -0,0 +1,469 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+// Issue types reported by the manifest validation.
+const (
+	validationQuota       = "quota"
+	validationLimitRange  = "limit_range"
+	validationPodSecurity = "pod_security"
+	validationWebhook     = "admission_webhook"
+	validationInvalid     = "invalid"
+	validationConflict    = "conflict"
+	validationForbidden   = "forbidden"
+	validationRejected    = "rejected"
+)
+
+var (
+	// quotaExceededRe matches the ResourceQuota admission message, e.g.
+	// "exceeded quota: compute, requested: limits.memory=2Gi, used:
+	// limits.memory=1Gi, limited: limits.memory=2Gi". Only the exceeded
+	// resources are listed.
+	quotaExceededRe = regexp.MustCompile(`exceeded quota: ([^,]+), requested: (\S+), used: (\S+), limited: (\S+)`)
+	// quotaFailedRe matches a quota that could not be evaluated, e.g.
+	// "failed quota: compute: must specify limits.memory for: app".
+	quotaFailedRe = regexp.MustCompile(`failed quota: ([^:]+): `)
+	webhookRe     = regexp.MustCompile(`admission webhook "([^"]+)" (?:denied the request|does not support dry run)`)
+	podSecurityRe = regexp.MustCompile(`violates PodSecurity "([^"]+)"`)
+	limitRangeRe  = regexp.MustCompile(`(?:minimum|maximum) \S+ usage per \S+ is|max limit to request ratio per`)
+)
+
+// manifestValidateRequest is the JSON body accepted by the validate
+// endpoint.
+type manifestValidateRequest struct {
+	// Manifest is one or more YAML or JSON documents.
+	Manifest string `json:"manifest"`
+	// Namespace is used for namespaced objects that don't set one.
+	Namespace string `json:"namespace"`
+	// Force validates as if fields managed by another field manager were
+	// taken over, instead of reporting a conflict.
+	Force bool `json:"force"`
+}
+
+// validationIssue is one reason an object would be rejected. Quota issues
+// name the quota and resource with the requested, used and limited amounts,
+// webhook issues the webhook and Pod Security issues the level violated.
+type validationIssue struct {
+	Type      string `json:"type"`
+	Message   string `json:"message"`
+	Field     string `json:"field,omitempty"`
+	Quota     string `json:"quota,omitempty"`
+	Resource  string `json:"resource,omitempty"`
+	Requested string `json:"requested,omitempty"`
+	Used      string `json:"used,omitempty"`
+	Limited   string `json:"limited,omitempty"`
+	Webhook   string `json:"webhook,omitempty"`
+	Level     string `json:"level,omitempty"`
+}
+
+// validationResult is the outcome for one object of the manifest. Issues are
+// why it would be rejected; Warnings are quotas its pods would exceed once
+// created, which the dry run of a workload does not check.
+type validationResult struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Namespace  string            `json:"namespace,omitempty"`
+	Name       string            `json:"name"`
+	Allowed    bool              `json:"allowed"`
+	Issues     []validationIssue `json:"issues,omitempty"`
+	Warnings   []validationIssue `json:"warnings,omitempty"`
+}
+
+// validationResponse is the report of the validate endpoint. Allowed is true
+// when every object would be accepted.
+type validationResponse struct {
+	Allowed bool               `json:"allowed"`
+	Objects []validationResult `json:"objects"`
+}
+
+// Validate reports whether the manifest in the body could be applied, by
+// dry-running the server-side apply of every object as the import does.
+// Rejections by resource quotas, LimitRanges, Pod Security admission and
+// admission webhooks are broken out so a client can explain them, and
+// workloads whose pods would not fit a quota are warned about. Nothing is
+// changed.
+func (h *ManifestImportHandler) Validate(w http.ResponseWriter, r *http.Request) {
+	clusterID := mux.Vars(r)["clusterID"]
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req manifestValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !validate.Segment(req.Namespace) {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid namespace")
+		return
+	}
+	if int64(len(req.Manifest)) > h.fetcher.maxBytes {
+		httputil.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("manifest exceeds %d bytes", h.fetcher.maxBytes))
+		return
+	}
+	objs, err := parseManifest([]byte(req.Manifest))
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid manifest: %v", err))
+		return
+	}
+	if _, err := h.discovery.Get(r.Context(), clusterID); err != nil {
+		writeClusterError(w, err, "discovery failed")
+		return
+	}
+
+	quotas := newQuotaProjection(h.k8s, clusterID)
+	resp := validationResponse{Allowed: true, Objects: make([]validationResult, 0, len(objs))}
+	for _, obj := range objs {
+		mo := h.resolve(r.Context(), clusterID, claims.UserID, req.Namespace, obj)
+		result, err := h.validateOne(r.Context(), clusterID, &mo, req.Force, quotas)
+		if err != nil {
+			writeClusterError(w, err, "dry run failed")
+			return
+		}
+		resp.Allowed = resp.Allowed && result.Allowed
+		resp.Objects = append(resp.Objects, result)
+	}
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+// validateOne dry-runs the apply of one resolved object. Rejections become
+// issues; only a failure to get an answer from the cluster is returned.
+func (h *ManifestImportHandler) validateOne(ctx context.Context, clusterID string, mo *manifestObject, force bool, quotas *quotaProjection) (validationResult, error) {
+	result := validationResult{
+		APIVersion: mo.result.APIVersion,
+		Kind:       mo.result.Kind,
+		Namespace:  mo.result.Namespace,
+		Name:       mo.result.Name,
+	}
+	switch mo.result.Action {
+	case manifestActionForbidden:
+		result.Issues = []validationIssue{{Type: validationForbidden, Message: mo.result.Error}}
+		return result, nil
+	case manifestActionInvalid:
+		result.Issues = []validationIssue{{Type: validationInvalid, Message: mo.result.Error}}
+		return result, nil
+	}
+
+	applied, err := h.serverSideApply(ctx, clusterID, mo, force, true)
+	if err != nil {
+		var reqErr *cluster.K8sRequestError
+		if !errors.As(err, &reqErr) {
+			return result, err
+		}
+		result.Issues = rejectionIssues(reqErr)
+		return result, nil
+	}
+	result.Allowed = true
+	result.Warnings = quotas.add(ctx, mo, applied)
+	return result, nil
+}
+
+// rejectionIssues explains why the API server rejected a dry run.
+func rejectionIssues(err *cluster.K8sRequestError) []validationIssue {
+	if conflict, ok := cluster.AsApplyConflict(err); ok {
+		issues := make([]validationIssue, 0, len(conflict.Conflicts))
+		for _, c := range conflict.Conflicts {
+			issues = append(issues, validationIssue{Type: validationConflict, Field: c.Field, Message: c.Message})
+		}
+		return issues
+	}
+
+	msg := err.Message
+	if m := webhookRe.FindStringSubmatch(msg); m != nil {
+		return []validationIssue{{Type: validationWebhook, Webhook: m[1], Message: msg}}
+	}
+	if m := quotaExceededRe.FindStringSubmatch(msg); m != nil {
+		return quotaIssues(m[1], m[2], m[3], m[4])
+	}
+	if m := quotaFailedRe.FindStringSubmatch(msg); m != nil {
+		return []validationIssue{{Type: validationQuota, Quota: m[1], Message: msg}}
+	}
+	if m := podSecurityRe.FindStringSubmatch(msg); m != nil {
+		return []validationIssue{{Type: validationPodSecurity, Level: m[1], Message: msg}}
+	}
+	if limitRangeRe.MatchString(msg) {
+		return []validationIssue{{Type: validationLimitRange, Message: msg}}
+	}
+
+	if err.StatusCode == http.StatusUnprocessableEntity {
+		if err.Details != nil && len(err.Details.Causes) > 0 {
+			issues := make([]validationIssue, 0, len(err.Details.Causes))
+			for _, cause := range err.Details.Causes {
+				issues = append(issues, validationIssue{Type: validationInvalid, Field: cause.Field, Message: cause.Message})
+			}
+			return issues
+		}
+		return []validationIssue{{Type: validationInvalid, Message: msg}}
+	}
+	if err.StatusCode == http.StatusForbidden {
+		return []validationIssue{{Type: validationForbidden, Message: msg}}
+	}
+	return []validationIssue{{Type: validationRejected, Message: msg}}
+}
+
+// quotaIssues builds one issue per exceeded resource from the lists of an
+// "exceeded quota" message, which are comma-separated resource=amount pairs.
+func quotaIssues(quota, requested, used, limited string) []validationIssue {
+	usedByResource, limitedByResource := parseQuotaAmounts(used), parseQuotaAmounts(limited)
+	var issues []validationIssue
+	for _, pair := range strings.Split(requested, ",") {
+		res, amount, _ := strings.Cut(pair, "=")
+		issues = append(issues, validationIssue{
+			Type:      validationQuota,
+			Message:   fmt.Sprintf("%s exceeds quota %s: requested %s, used %s, limited to %s", res, quota, amount, usedByResource[res], limitedByResource[res]),
+			Quota:     quota,
+			Resource:  res,
+			Requested: amount,
+			Used:      usedByResource[res],
+			Limited:   limitedByResource[res],
+		})
+	}
+	return issues
+}
+
+func parseQuotaAmounts(list string) map[string]string {
+	amounts := make(map[string]string)
+	for _, pair := range strings.Split(list, ",") {
+		if res, amount, ok := strings.Cut(pair, "="); ok {
+			amounts[res] = amount
+		}
+	}
+	return amounts
+}
+
+// quotaProjection estimates whether the pods of validated workloads fit the
+// resource quotas of their namespace. The dry run of a Deployment only
+// checks quotas against the Deployment itself, so one whose pods exceed a
+// quota is accepted and then cannot scale up. Quotas are read once per
+// namespace and every validated object's pods are added to their usage, so
+// the objects of a manifest are counted together. Scoped quotas are skipped,
+// as whether a template falls in their scope is not known, and a namespace
+// whose quotas cannot be read is treated as having none.
+type quotaProjection struct {
+	k8s       manifestCluster
+	clusterID string
+	quotas    map[string][]*projectedQuota // namespace -> quotas
+}
+
+// projectedQuota is a quota with the usage projected so far.
+type projectedQuota struct {
+	name string
+	hard corev1.ResourceList
+	used corev1.ResourceList
+}
+
+func newQuotaProjection(k8s manifestCluster, clusterID string) *quotaProjection {
+	return &quotaProjection{k8s: k8s, clusterID: clusterID, quotas: make(map[string][]*projectedQuota)}
+}
+
+// add projects the pods of an applied object onto its namespace's quotas
+// and warns about each quota they would exceed. For an update, the pods of
+// the live object are already in the quota's usage and are taken out.
+// Pods are added without warnings, as their own dry run checks quotas.
+func (p *quotaProjection) add(ctx context.Context, mo *manifestObject, applied map[string]interface{}) []validationIssue {
+	namespace := mo.obj.GetNamespace()
+	if namespace == "" {
+		return nil
+	}
+	demand, ok := podDemand(applied)
+	if !ok {
+		return nil
+	}
+	path := k8sAPIPath(mo.gvr, namespace, mo.obj.GetName())
+	if data, err := p.k8s.DoK8sRequestWithContentType(ctx, p.clusterID, http.MethodGet, path, "application/json", nil); err == nil {
+		var live map[string]interface{}
+		if json.Unmarshal(data, &live) == nil {
+			if current, ok := podDemand(live); ok {
+				for name, q := range current {
+					d := demand[name]
+					d.Sub(q)
+					demand[name] = d
+				}
+			}
+		}
+	}
+
+	var warnings []validationIssue
+	for _, quota := range p.namespaceQuotas(ctx, namespace) {
+		for name, hard := range quota.hard {
+			delta, ok := demand[name]
+			if !ok || delta.Sign() == 0 {
+				continue
+			}
+			used := quota.used[name]
+			projected := used.DeepCopy()
+			projected.Add(delta)
+			if delta.Sign() > 0 && projected.Cmp(hard) > 0 && mo.obj.GetKind() != "Pod" {
+				warnings = append(warnings, validationIssue{
+					Type: validationQuota,
+					Message: fmt.Sprintf("pods of %s %s would exceed quota %s: they need %s more %s, %s of %s is used",
+						strings.ToLower(mo.obj.GetKind()), mo.obj.GetName(), quota.name, delta.String(), name, used.String(), hard.String()),
+					Quota:     quota.name,
+					Resource:  string(name),
+					Requested: delta.String(),
+					Used:      used.String(),
+					Limited:   hard.String(),
+				})
+			}
+			quota.used[name] = projected
+		}
+	}
+	return warnings
+}
+
+// namespaceQuotas reads the unscoped quotas of a namespace on first use.
+func (p *quotaProjection) namespaceQuotas(ctx context.Context, namespace string) []*projectedQuota {
+	if quotas, ok := p.quotas[namespace]; ok {
+		return quotas
+	}
+	var quotas []*projectedQuota
+	data, err := p.k8s.DoK8sRequestWithContentType(ctx, p.clusterID, http.MethodGet, "/api/v1/namespaces/"+namespace+"/resourcequotas", "application/json", nil)
+	var list corev1.ResourceQuotaList
+	if err == nil && json.Unmarshal(data, &list) == nil {
+		for _, q := range list.Items {
+			if len(q.Spec.Scopes) > 0 || q.Spec.ScopeSelector != nil {
+				continue
+			}
+			hard := q.Status.Hard
+			if len(hard) == 0 {
+				hard = q.Spec.Hard
+			}
+			used := q.Status.Used.DeepCopy()
+			if used == nil {
+				used = corev1.ResourceList{}
+			}
+			quotas = append(quotas, &projectedQuota{name: q.Name, hard: hard, used: used})
+		}
+	}
+	p.quotas[namespace] = quotas
+	return quotas
+}
+
+// podDemand returns the quota usage of the pods an object runs: a Pod, or
+// the replicas of a Deployment, ReplicaSet, StatefulSet or
+// ReplicationController, or the parallelism of a Job. Other kinds, including
+// DaemonSets whose pod count depends on the nodes, report false.
+func podDemand(obj map[string]interface{}) (corev1.ResourceList, bool) {
+	u := unstructured.Unstructured{Object: obj}
+	var specPath []string
+	count := int64(1)
+	switch u.GetKind() {
+	case "Pod":
+		specPath = []string{"spec"}
+	case "Deployment", "ReplicaSet", "StatefulSet", "ReplicationController":
+		specPath = []string{"spec", "template", "spec"}
+		if n, ok := specCount(obj, "replicas"); ok {
+			count = n
+		}
+	case "Job":
+		specPath = []string{"spec", "template", "spec"}
+		if n, ok := specCount(obj, "parallelism"); ok {
+			count = n
+		}
+	default:
+		return nil, false
+	}
+	raw, found, _ := unstructured.NestedMap(obj, specPath...)
+	if !found {
+		return nil, false
+	}
+	var spec corev1.PodSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &spec); err != nil {
+		return nil, false
+	}
+
+	perPod := podResources(spec)
+	demand := corev1.ResourceList{corev1.ResourcePods: *resource.NewQuantity(count, resource.DecimalSI)}
+	for name, q := range perPod {
+		demand[name] = *resource.NewMilliQuantity(q.MilliValue()*count, q.Format)
+	}
+	return demand, true
+}
+
+// specCount reads a count from an object's spec, which decodes from JSON as
+// a float64 and from YAML as an int64.
+func specCount(obj map[string]interface{}, field string) (int64, bool) {
+	v, found, _ := unstructured.NestedFieldNoCopy(obj, "spec", field)
+	if !found {
+		return 0, false
+	}
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case float64:
+		return int64(n), true
+	}
+	return 0, false
+}
+
+// podResources returns what one pod counts against quotas: its requests and
+// limits, the larger of the sum over its containers and any single init
+// container, with a request defaulting to the limit as the API server does.
+// Plain cpu and memory are the requests.
+func podResources(spec corev1.PodSpec) corev1.ResourceList {
+	total := corev1.ResourceList{}
+	maxInit := corev1.ResourceList{}
+	collect := func(containers []corev1.Container, into func(corev1.ResourceName, resource.Quantity)) {
+		for _, c := range containers {
+			for _, res := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+				limit, hasLimit := c.Resources.Limits[res]
+				request, hasRequest := c.Resources.Requests[res]
+				if !hasRequest && hasLimit {
+					request, hasRequest = limit, true
+				}
+				if hasRequest {
+					into(corev1.ResourceName("requests."+res), request)
+				}
+				if hasLimit {
+					into(corev1.ResourceName("limits."+res), limit)
+				}
+			}
+		}
+	}
+	collect(spec.Containers, func(name corev1.ResourceName, q resource.Quantity) {
+		sum := total[name]
+		sum.Add(q)
+		total[name] = sum
+	})
+	for _, c := range spec.InitContainers {
+		collect([]corev1.Container{c}, func(name corev1.ResourceName, q resource.Quantity) {
+			if current, ok := maxInit[name]; !ok || q.Cmp(current) > 0 {
+				maxInit[name] = q
+			}
+		})
+	}
+	for name, q := range maxInit {
+		if current, ok := total[name]; !ok || q.Cmp(current) > 0 {
+			total[name] = q
+		}
+	}
+
+	if q, ok := total[corev1.ResourceRequestsCPU]; ok {
+		total[corev1.ResourceCPU] = q
+	}
+	if q, ok := total[corev1.ResourceRequestsMemory]; ok {
+		total[corev1.ResourceMemory] = q
+	}
+	return total
+}
@@ -0,0 +1,276 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+)
+
+const quotaDeniedMessage = `pods "batch" is forbidden: exceeded quota: compute, requested: limits.memory=2Gi,requests.memory=1Gi, used: limits.memory=3Gi,requests.memory=1536Mi, limited: limits.memory=4Gi,requests.memory=2Gi`
+
+// fakeValidateCluster serves GETs from live and rejects dry runs of the
+// paths in rejected; other dry runs echo the applied object.
+type fakeValidateCluster struct {
+	live     map[string]string
+	rejected map[string]error
+}
+
+func (c *fakeValidateCluster) DoK8sRequestWithContentType(ctx context.Context, clusterID, method, path, contentType string, body []byte) ([]byte, error) {
+	switch method {
+	case http.MethodGet:
+		if obj, ok := c.live[path]; ok {
+			return []byte(obj), nil
+		}
+		return nil, &cluster.K8sRequestError{StatusCode: http.StatusNotFound, Message: "not found"}
+	case http.MethodPatch:
+		if !strings.Contains(path, "dryRun=All") {
+			return nil, fmt.Errorf("validate must only dry-run, got %s", path)
+		}
+		if err, ok := c.rejected[path[:strings.Index(path, "?")]]; ok {
+			return nil, err
+		}
+		return body, nil
+	}
+	return nil, fmt.Errorf("unexpected method %s", method)
+}
+
+func validateManifest(t *testing.T, k8s *fakeValidateCluster, allowed writeNamespaces, body map[string]interface{}) (*httptest.ResponseRecorder, validationResponse) {
+	t.Helper()
+	discovery := newDiscoveryCache(&fakeDiscovery{resources: []ServedResource{
+		{APIResourceEntry: APIResourceEntry{Kind: "Pod", Version: "v1", Resource: "pods", Namespaced: true}},
+		{APIResourceEntry: APIResourceEntry{Kind: "Deployment", Group: "apps", Version: "v1", Resource: "deployments", Namespaced: true}},
+	}})
+	fetcher, err := newManifestFetcher(ManifestImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := mux.NewRouter()
+	(&ManifestImportHandler{k8s: k8s, authz: allowed, discovery: discovery, fetcher: fetcher}).RegisterRoutes(r)
+
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/api/clusters/c1/manifests/validate", strings.NewReader(string(data)))
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), &auth.Claims{UserID: "u1"}))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp validationResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestManifestValidate_ReportsRejections(t *testing.T) {
+	k8s := &fakeValidateCluster{rejected: map[string]error{
+		"/api/v1/namespaces/team-a/pods/batch": &cluster.K8sRequestError{StatusCode: http.StatusForbidden, Message: quotaDeniedMessage},
+	}}
+	manifest := `apiVersion: v1
+kind: Pod
+metadata:
+  name: batch
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: other
+  namespace: team-b
+`
+	w, resp := validateManifest(t, k8s, writeNamespaces{"team-a": true}, map[string]interface{}{"manifest": manifest, "namespace": "team-a"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Allowed || len(resp.Objects) != 3 {
+		t.Fatalf("expected three objects and a rejection, got %+v", resp)
+	}
+
+	batch := resp.Objects[0]
+	if batch.Allowed || len(batch.Issues) != 2 {
+		t.Fatalf("expected two quota issues for batch, got %+v", batch)
+	}
+	want := validationIssue{Type: validationQuota, Quota: "compute", Resource: "limits.memory", Requested: "2Gi", Used: "3Gi", Limited: "4Gi"}
+	got := batch.Issues[0]
+	got.Message = ""
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if !resp.Objects[1].Allowed || len(resp.Objects[1].Issues) != 0 {
+		t.Errorf("expected web to be allowed, got %+v", resp.Objects[1])
+	}
+	if other := resp.Objects[2]; other.Allowed || other.Issues[0].Type != validationForbidden {
+		t.Errorf("expected other to be forbidden, got %+v", other)
+	}
+}
+
+func TestManifestValidate_ProjectsWorkloadQuota(t *testing.T) {
+	k8s := &fakeValidateCluster{live: map[string]string{
+		"/api/v1/namespaces/team-a/resourcequotas": `{"items":[
+			{"metadata":{"name":"compute"},"status":{"hard":{"requests.memory":"4Gi","pods":"10"},"used":{"requests.memory":"1Gi","pods":"2"}}},
+			{"metadata":{"name":"best-effort"},"spec":{"scopes":["BestEffort"]},"status":{"hard":{"pods":"1"},"used":{"pods":"1"}}}
+		]}`,
+		"/apis/apps/v1/namespaces/team-a/deployments/api": `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"api","namespace":"team-a"},
+			"spec":{"replicas":1,"template":{"spec":{"containers":[{"name":"api","resources":{"requests":{"memory":"1Gi"}}}]}}}}`,
+	}}
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: api
+        resources:
+          requests:
+            memory: 1Gi
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers:
+      - name: web
+        resources:
+          requests:
+            memory: 512Mi
+`
+	w, resp := validateManifest(t, k8s, writeNamespaces{"team-a": true}, map[string]interface{}{"manifest": manifest, "namespace": "team-a"})
+	if w.Code != http.StatusOK || len(resp.Objects) != 2 {
+		t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	if !resp.Allowed {
+		t.Errorf("expected the dry runs to pass, got %+v", resp)
+	}
+	// api grows by one 1Gi replica to 2Gi of 4Gi; web's 2560Mi then
+	// exceeds it.
+	if len(resp.Objects[0].Warnings) != 0 {
+		t.Errorf("expected no warning for api, got %+v", resp.Objects[0].Warnings)
+	}
+	warnings := resp.Objects[1].Warnings
+	if len(warnings) != 1 || warnings[0].Quota != "compute" || warnings[0].Resource != "requests.memory" ||
+		warnings[0].Requested != "2560Mi" || warnings[0].Used != "2Gi" || warnings[0].Limited != "4Gi" {
+		t.Errorf("expected a requests.memory warning for web, got %+v", warnings)
+	}
+}
+
+func TestManifestValidate_BadRequests(t *testing.T) {
+	k8s := &fakeValidateCluster{}
+	if w, _ := validateManifest(t, k8s, writeNamespaces{}, map[string]interface{}{"manifest": "kind: Pod"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid manifest, got %d", w.Code)
+	}
+	if w, _ := validateManifest(t, k8s, writeNamespaces{}, map[string]interface{}{"manifest": strings.Repeat("x", defaultManifestMaxBytes+1)}); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for an oversized manifest, got %d", w.Code)
+	}
+}
+
+func TestRejectionIssues(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *cluster.K8sRequestError
+		want validationIssue
+	}{
+		{
+			name: "webhook",
+			err:  &cluster.K8sRequestError{StatusCode: 400, Message: `admission webhook "validate.kyverno.svc" denied the request: image tag latest is not allowed`},
+			want: validationIssue{Type: validationWebhook, Webhook: "validate.kyverno.svc"},
+		},
+		{
+			name: "webhook without dry run support",
+			err:  &cluster.K8sRequestError{StatusCode: 400, Message: `admission webhook "audit.example.com" does not support dry run`},
+			want: validationIssue{Type: validationWebhook, Webhook: "audit.example.com"},
+		},
+		{
+			name: "pod security",
+			err:  &cluster.K8sRequestError{StatusCode: 403, Message: `pods "web" is forbidden: violates PodSecurity "restricted:latest": allowPrivilegeEscalation != false`},
+			want: validationIssue{Type: validationPodSecurity, Level: "restricted:latest"},
+		},
+		{
+			name: "quota without limits",
+			err:  &cluster.K8sRequestError{StatusCode: 403, Message: `pods "web" is forbidden: failed quota: compute: must specify limits.memory for: web`},
+			want: validationIssue{Type: validationQuota, Quota: "compute"},
+		},
+		{
+			name: "limit range",
+			err:  &cluster.K8sRequestError{StatusCode: 403, Message: `pods "web" is forbidden: maximum memory usage per Container is 1Gi, but limit is 2Gi`},
+			want: validationIssue{Type: validationLimitRange},
+		},
+		{
+			name: "invalid field",
+			err: &cluster.K8sRequestError{StatusCode: 422, Message: `Deployment.apps "web" is invalid`, Details: &metav1.StatusDetails{
+				Causes: []metav1.StatusCause{{Field: "spec.replicas", Message: "must be greater than or equal to 0"}},
+			}},
+			want: validationIssue{Type: validationInvalid, Field: "spec.replicas", Message: "must be greater than or equal to 0"},
+		},
+		{
+			name: "other",
+			err:  &cluster.K8sRequestError{StatusCode: 500, Message: "etcdserver: request timed out"},
+			want: validationIssue{Type: validationRejected, Message: "etcdserver: request timed out"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := rejectionIssues(tt.err)
+			if len(issues) != 1 {
+				t.Fatalf("expected one issue, got %+v", issues)
+			}
+			got := issues[0]
+			if tt.want.Message == "" {
+				if got.Message != tt.err.Message {
+					t.Errorf("expected the API server's message, got %q", got.Message)
+				}
+				got.Message = ""
+			}
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPodResources(t *testing.T) {
+	spec := corev1.PodSpec{
+		InitContainers: []corev1.Container{{Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("3Gi")},
+		}}},
+		Containers: []corev1.Container{
+			{Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+			}},
+			{Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+			}},
+		},
+	}
+	got := podResources(spec)
+	want := map[corev1.ResourceName]string{
+		corev1.ResourceRequestsCPU:    "750m",
+		corev1.ResourceCPU:            "750m",
+		corev1.ResourceLimitsCPU:      "500m",
+		corev1.ResourceRequestsMemory: "3Gi",
+		corev1.ResourceMemory:         "3Gi",
+		corev1.ResourceLimitsMemory:   "1Gi",
+	}
+	for name, q := range want {
+		if v, ok := got[name]; !ok || v.Cmp(resource.MustParse(q)) != 0 {
+			t.Errorf("%s: expected %s, got %s", name, q, v.String())
+		}
+	}
+}
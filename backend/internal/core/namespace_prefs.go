@@ -0,0 +1,203 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+// maxFavoriteNamespaces bounds the favorites a user can save per cluster.
+const maxFavoriteNamespaces = 50
+
+// namespaceReadCategories are the permission categories that grant read
+// access to something in a namespace. A namespace is readable when any of
+// them is.
+var namespaceReadCategories = []string{"apps", "jobs", "clusters"}
+
+// NamespacePreferences are a user's saved namespaces on one cluster.
+type NamespacePreferences struct {
+	ClusterID        string   `json:"cluster_id"`
+	DefaultNamespace string   `json:"default_namespace"`
+	Favorites        []string `json:"favorites"`
+}
+
+// namespacePrefsStore persists namespace preferences.
+type namespacePrefsStore interface {
+	Get(ctx context.Context, userID, clusterID string) (NamespacePreferences, error)
+	Put(ctx context.Context, userID string, prefs NamespacePreferences) error
+}
+
+// NamespacePrefsHandler serves the per-user, per-cluster default namespace
+// and favorite namespaces, so they sync across devices.
+type NamespacePrefsHandler struct {
+	store namespacePrefsStore
+	authz rbac.Evaluator
+}
+
+// NewNamespacePrefsHandler creates a handler backed by the database.
+func NewNamespacePrefsHandler(pool *pgxpool.Pool, engine *rbac.Engine) *NamespacePrefsHandler {
+	return &NamespacePrefsHandler{store: &pgNamespacePrefsStore{pool: pool}, authz: engine}
+}
+
+// RegisterRoutes wires the namespace preference endpoints.
+func (h *NamespacePrefsHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clusters/{clusterID}/namespace-preferences", h.Get).Methods(http.MethodGet)
+	r.HandleFunc("/api/clusters/{clusterID}/namespace-preferences", h.Put).Methods(http.MethodPut)
+}
+
+// Get returns the user's namespace preferences for the cluster. Namespaces
+// the user can no longer read are left out.
+func (h *NamespacePrefsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := namespacePrefsUser(w, r)
+	if !ok {
+		return
+	}
+	clusterID := mux.Vars(r)["clusterID"]
+
+	prefs, err := h.store.Get(r.Context(), userID, clusterID)
+	if err != nil {
+		log.Printf("core: failed to load namespace preferences for user %s: %v", userID, err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to load namespace preferences")
+		return
+	}
+
+	readable := make([]string, 0, len(prefs.Favorites))
+	for _, ns := range prefs.Favorites {
+		if h.canReadNamespace(r.Context(), userID, clusterID, ns) {
+			readable = append(readable, ns)
+		}
+	}
+	prefs.Favorites = readable
+	if prefs.DefaultNamespace != "" && !h.canReadNamespace(r.Context(), userID, clusterID, prefs.DefaultNamespace) {
+		prefs.DefaultNamespace = ""
+	}
+	httputil.WriteJSON(w, http.StatusOK, prefs)
+}
+
+// Put replaces the user's namespace preferences for the cluster. Every
+// namespace must be one the user can read.
+func (h *NamespacePrefsHandler) Put(w http.ResponseWriter, r *http.Request) {
+	userID, ok := namespacePrefsUser(w, r)
+	if !ok {
+		return
+	}
+	clusterID := mux.Vars(r)["clusterID"]
+
+	var req NamespacePreferences
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	favorites := make([]string, 0, len(req.Favorites))
+	seen := make(map[string]bool, len(req.Favorites))
+	for _, ns := range req.Favorites {
+		if ns == "" || seen[ns] {
+			continue
+		}
+		seen[ns] = true
+		favorites = append(favorites, ns)
+	}
+	if len(favorites) > maxFavoriteNamespaces {
+		httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("at most %d favorite namespaces are allowed", maxFavoriteNamespaces))
+		return
+	}
+
+	namespaces := favorites
+	if req.DefaultNamespace != "" && !seen[req.DefaultNamespace] {
+		namespaces = append(namespaces[:len(namespaces):len(namespaces)], req.DefaultNamespace)
+	}
+	for _, ns := range namespaces {
+		if !validate.Segment(ns) {
+			httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid namespace %q", ns))
+			return
+		}
+		if !h.canReadNamespace(r.Context(), userID, clusterID, ns) {
+			httputil.WriteError(w, http.StatusForbidden, fmt.Sprintf("permission denied: cannot read namespace %q", ns))
+			return
+		}
+	}
+
+	prefs := NamespacePreferences{ClusterID: clusterID, DefaultNamespace: req.DefaultNamespace, Favorites: favorites}
+	if err := h.store.Put(r.Context(), userID, prefs); err != nil {
+		log.Printf("core: failed to save namespace preferences for user %s: %v", userID, err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to save namespace preferences")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, prefs)
+}
+
+// canReadNamespace reports whether the user can read anything in namespace.
+func (h *NamespacePrefsHandler) canReadNamespace(ctx context.Context, userID, clusterID, namespace string) bool {
+	for _, category := range namespaceReadCategories {
+		allowed, err := h.authz.Evaluate(ctx, rbac.Request{
+			UserID:    userID,
+			Action:    "read",
+			Resource:  category,
+			ClusterID: clusterID,
+			Namespace: namespace,
+		})
+		if err == nil && allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// namespacePrefsUser returns the requesting user. Service accounts have no
+// preferences and are refused.
+func namespacePrefsUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok || claims == nil {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return "", false
+	}
+	if _, isServiceAccount := claims.ServiceAccountID(); isServiceAccount {
+		httputil.WriteError(w, http.StatusForbidden, "service accounts cannot have namespace preferences")
+		return "", false
+	}
+	return claims.UserID, true
+}
+
+// pgNamespacePrefsStore stores preferences in user_namespace_preferences.
+type pgNamespacePrefsStore struct {
+	pool *pgxpool.Pool
+}
+
+func (s *pgNamespacePrefsStore) Get(ctx context.Context, userID, clusterID string) (NamespacePreferences, error) {
+	prefs := NamespacePreferences{ClusterID: clusterID, Favorites: []string{}}
+	err := s.pool.QueryRow(ctx,
+		`SELECT default_namespace, favorites FROM user_namespace_preferences
+		 WHERE user_id = $1 AND cluster_id = $2`,
+		userID, clusterID,
+	).Scan(&prefs.DefaultNamespace, &prefs.Favorites)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return prefs, nil
+	}
+	return prefs, err
+}
+
+func (s *pgNamespacePrefsStore) Put(ctx context.Context, userID string, prefs NamespacePreferences) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO user_namespace_preferences (user_id, cluster_id, default_namespace, favorites, updated_at)
+		 VALUES ($1, $2, $3, $4, NOW())
+		 ON CONFLICT (user_id, cluster_id) DO UPDATE SET
+		     default_namespace = EXCLUDED.default_namespace,
+		     favorites = EXCLUDED.favorites,
+		     updated_at = EXCLUDED.updated_at`,
+		userID, prefs.ClusterID, prefs.DefaultNamespace, prefs.Favorites,
+	)
+	return err
+}
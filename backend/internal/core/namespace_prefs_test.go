@@ -0,0 +1,126 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+type memNamespacePrefsStore struct {
+	prefs map[string]NamespacePreferences
+}
+
+func (s *memNamespacePrefsStore) Get(ctx context.Context, userID, clusterID string) (NamespacePreferences, error) {
+	if p, ok := s.prefs[userID+"/"+clusterID]; ok {
+		return p, nil
+	}
+	return NamespacePreferences{ClusterID: clusterID, Favorites: []string{}}, nil
+}
+
+func (s *memNamespacePrefsStore) Put(ctx context.Context, userID string, prefs NamespacePreferences) error {
+	s.prefs[userID+"/"+prefs.ClusterID] = prefs
+	return nil
+}
+
+// namespaceAllowList grants read on the listed namespaces only.
+type namespaceAllowList map[string]bool
+
+func (a namespaceAllowList) Evaluate(ctx context.Context, req rbac.Request) (bool, error) {
+	return req.Action == "read" && a[req.Namespace], nil
+}
+
+func newNamespacePrefsRouter(store *memNamespacePrefsStore, allowed namespaceAllowList) *mux.Router {
+	r := mux.NewRouter()
+	(&NamespacePrefsHandler{store: store, authz: allowed}).RegisterRoutes(r)
+	return r
+}
+
+func namespacePrefsRequest(method, body, userID string) *http.Request {
+	req := httptest.NewRequest(method, "/api/clusters/c1/namespace-preferences", strings.NewReader(body))
+	return req.WithContext(auth.ContextWithClaims(req.Context(), &auth.Claims{UserID: userID}))
+}
+
+func TestNamespacePrefs_PutAndGet(t *testing.T) {
+	store := &memNamespacePrefsStore{prefs: map[string]NamespacePreferences{}}
+	r := newNamespacePrefsRouter(store, namespaceAllowList{"default": true, "team-a": true})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, namespacePrefsRequest(http.MethodPut, `{"default_namespace":"team-a","favorites":["default","team-a","default"]}`, "u1"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, namespacePrefsRequest(http.MethodGet, "", "u1"))
+	var prefs NamespacePreferences
+	if err := json.NewDecoder(w.Body).Decode(&prefs); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if prefs.DefaultNamespace != "team-a" || len(prefs.Favorites) != 2 {
+		t.Errorf("unexpected preferences (duplicates should be dropped): %+v", prefs)
+	}
+
+	// Preferences are per user.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, namespacePrefsRequest(http.MethodGet, "", "u2"))
+	prefs = NamespacePreferences{}
+	_ = json.NewDecoder(w.Body).Decode(&prefs)
+	if prefs.DefaultNamespace != "" || len(prefs.Favorites) != 0 {
+		t.Errorf("expected empty preferences for another user, got %+v", prefs)
+	}
+}
+
+func TestNamespacePrefs_PutRejectsUnreadableNamespace(t *testing.T) {
+	store := &memNamespacePrefsStore{prefs: map[string]NamespacePreferences{}}
+	r := newNamespacePrefsRouter(store, namespaceAllowList{"default": true})
+
+	for _, body := range []string{
+		`{"favorites":["default","kube-system"]}`,
+		`{"default_namespace":"kube-system","favorites":["default"]}`,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, namespacePrefsRequest(http.MethodPut, body, "u1"))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d", body, w.Code)
+		}
+	}
+	if len(store.prefs) != 0 {
+		t.Error("rejected preferences must not be saved")
+	}
+}
+
+func TestNamespacePrefs_GetDropsNamespacesNoLongerReadable(t *testing.T) {
+	store := &memNamespacePrefsStore{prefs: map[string]NamespacePreferences{
+		"u1/c1": {ClusterID: "c1", DefaultNamespace: "revoked", Favorites: []string{"default", "revoked"}},
+	}}
+	r := newNamespacePrefsRouter(store, namespaceAllowList{"default": true})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, namespacePrefsRequest(http.MethodGet, "", "u1"))
+	var prefs NamespacePreferences
+	if err := json.NewDecoder(w.Body).Decode(&prefs); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if prefs.DefaultNamespace != "" || len(prefs.Favorites) != 1 || prefs.Favorites[0] != "default" {
+		t.Errorf("expected only readable namespaces, got %+v", prefs)
+	}
+}
+
+func TestNamespacePrefs_RejectsServiceAccounts(t *testing.T) {
+	store := &memNamespacePrefsStore{prefs: map[string]NamespacePreferences{}}
+	r := newNamespacePrefsRouter(store, namespaceAllowList{"default": true})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, namespacePrefsRequest(http.MethodGet, "", auth.ServiceAccountPrincipalPrefix+"sa-1"))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a service account, got %d", w.Code)
+	}
+}
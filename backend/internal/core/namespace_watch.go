@@ -0,0 +1,446 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+	"github.com/darkden-lab/argus/backend/internal/ws"
+)
+
+const (
+	// namespaceWatchFlushInterval batches row changes, so a rollout that
+	// touches many pods at once reaches the client as a few events.
+	namespaceWatchFlushInterval = 500 * time.Millisecond
+	// namespaceWatchKeepalive keeps idle streams open through proxies.
+	namespaceWatchKeepalive = 30 * time.Second
+)
+
+// tableColumn is a column of a namespace watch table. Columns with a
+// priority are only shown in wide views, as in kubectl get -o wide.
+type tableColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Priority int32  `json:"priority,omitempty"`
+}
+
+// watchTableKind is a resource type the namespace watch can stream: what to
+// watch, its columns, and how an object becomes a row of cells.
+type watchTableKind struct {
+	gvr     schema.GroupVersionResource
+	columns []tableColumn
+	cells   func(data []byte) (name string, cells []interface{}, err error)
+}
+
+var (
+	deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	servicesGVR    = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+)
+
+// namespaceWatchKinds are the resource types of the namespace watch.
+var namespaceWatchKinds = map[string]watchTableKind{
+	"pods": {
+		gvr: podsGVR,
+		columns: []tableColumn{
+			{Name: "Name", Type: "string"},
+			{Name: "Ready", Type: "string"},
+			{Name: "Status", Type: "string"},
+			{Name: "Restarts", Type: "integer"},
+			{Name: "Age", Type: "date"},
+			{Name: "IP", Type: "string", Priority: 1},
+			{Name: "Node", Type: "string", Priority: 1},
+		},
+		cells: podCells,
+	},
+	"deployments": {
+		gvr: deploymentsGVR,
+		columns: []tableColumn{
+			{Name: "Name", Type: "string"},
+			{Name: "Ready", Type: "string"},
+			{Name: "Up-to-date", Type: "integer"},
+			{Name: "Available", Type: "integer"},
+			{Name: "Age", Type: "date"},
+		},
+		cells: deploymentCells,
+	},
+	"services": {
+		gvr: servicesGVR,
+		columns: []tableColumn{
+			{Name: "Name", Type: "string"},
+			{Name: "Type", Type: "string"},
+			{Name: "Cluster-IP", Type: "string"},
+			{Name: "External-IP", Type: "string"},
+			{Name: "Port(s)", Type: "string"},
+			{Name: "Age", Type: "date"},
+		},
+		cells: serviceCells,
+	},
+}
+
+// defaultNamespaceWatchKinds are streamed when the request names no kinds.
+var defaultNamespaceWatchKinds = []string{"pods", "deployments", "services"}
+
+// sharedWatcher starts or joins a shared resource watch; *ws.Hub implements
+// it, so table streams share informers with WebSocket watches.
+type sharedWatcher interface {
+	WatchFunc(userID string, spec ws.WatchSpec, fn func(ws.WatchEvent)) ([]json.RawMessage, func(), error)
+}
+
+// NamespaceWatchHandler streams live tables of a namespace's pods,
+// deployments and services, like kubectl get --watch. Each resource type is
+// watched once per cluster and namespace however many clients stream it,
+// and the watch stops when the last of them disconnects.
+type NamespaceWatchHandler struct {
+	watcher       sharedWatcher
+	flushInterval time.Duration
+}
+
+// NewNamespaceWatchHandler creates a NamespaceWatchHandler whose watches are
+// shared through hub. The hub checks read permission on each resource type.
+func NewNamespaceWatchHandler(hub *ws.Hub) *NamespaceWatchHandler {
+	return &NamespaceWatchHandler{watcher: hub, flushInterval: namespaceWatchFlushInterval}
+}
+
+// RegisterRoutes wires the namespace watch stream.
+func (h *NamespaceWatchHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clusters/{clusterID}/namespaces/{namespace}/watch", h.Stream).Methods(http.MethodGet)
+}
+
+// Stream sends a columns event with the table columns of each requested
+// kind, then rows events with the rows added, modified or deleted since the
+// previous one. The first rows event holds every existing object.
+// Query params: kinds (comma-separated; pods, deployments and services by
+// default).
+func (h *NamespaceWatchHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	clusterID := mux.Vars(r)["clusterID"]
+	namespace := mux.Vars(r)["namespace"]
+	if namespace == "" || !validate.Segment(namespace) {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid namespace")
+		return
+	}
+	kinds, err := parseWatchKinds(r.URL.Query().Get("kinds"))
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httputil.WriteError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	table := newWatchTable()
+	columns := make(map[string][]tableColumn, len(kinds))
+	for _, kind := range kinds {
+		kind := kind
+		k := namespaceWatchKinds[kind]
+		spec := ws.WatchSpec{Cluster: clusterID, Group: k.gvr.Group, Version: k.gvr.Version, Resource: k.gvr.Resource, Namespace: namespace}
+		snapshot, stop, err := h.watcher.WatchFunc(claims.UserID, spec, func(ev ws.WatchEvent) {
+			table.apply(kind, ev.Type, ev.Object)
+		})
+		if err != nil {
+			writeWatchError(w, kind, err)
+			return
+		}
+		defer stop()
+		for _, obj := range snapshot {
+			table.applySnapshot(kind, obj)
+		}
+		columns[kind] = k.columns
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	writeSSEEvent(w, "columns", columns)
+	flusher.Flush()
+
+	ticker := time.NewTicker(h.flushInterval)
+	defer ticker.Stop()
+	keepalive := time.NewTicker(namespaceWatchKeepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			changes := table.changes()
+			if len(changes) == 0 {
+				continue
+			}
+			writeSSEEvent(w, "rows", map[string]interface{}{"changes": changes})
+			flusher.Flush()
+		case <-keepalive.C:
+			_, _ = fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// parseWatchKinds returns the kinds of a comma-separated list, or the
+// default kinds for an empty one.
+func parseWatchKinds(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return defaultNamespaceWatchKinds, nil
+	}
+	var kinds []string
+	seen := make(map[string]bool)
+	for _, kind := range strings.Split(s, ",") {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		if _, ok := namespaceWatchKinds[kind]; !ok {
+			return nil, fmt.Errorf("unsupported kind %q (supported: %s)", kind, strings.Join(defaultNamespaceWatchKinds, ", "))
+		}
+		if !seen[kind] {
+			seen[kind] = true
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds, nil
+}
+
+func writeWatchError(w http.ResponseWriter, kind string, err error) {
+	switch {
+	case errors.Is(err, ws.ErrWatchForbidden):
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions to watch "+kind)
+	case errors.Is(err, ws.ErrWatchUnavailable):
+		httputil.WriteError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("failed to watch %s: %v", kind, err))
+	}
+}
+
+// tableChange is a row added, modified or deleted since the previous rows
+// event. Deleted rows have no cells.
+type tableChange struct {
+	Type  string        `json:"type"` // ADDED | MODIFIED | DELETED
+	Kind  string        `json:"kind"`
+	Name  string        `json:"name"`
+	Cells []interface{} `json:"cells,omitempty"`
+}
+
+// watchTable holds the rows of one stream. Watch events update the current
+// rows from the watches' goroutines; changes diffs them against the rows the
+// client has, so a row that changes several times between two flushes is
+// sent once and a row added and deleted in between is not sent at all.
+type watchTable struct {
+	mu      sync.Mutex
+	current map[string]tableChange // by kind/name; Type unused
+	sent    map[string]tableChange
+	dirty   map[string]bool
+	// touched records the rows set by watch events, which are newer than
+	// any snapshot of a running watch.
+	touched map[string]bool
+}
+
+func newWatchTable() *watchTable {
+	return &watchTable{
+		current: make(map[string]tableChange),
+		sent:    make(map[string]tableChange),
+		dirty:   make(map[string]bool),
+		touched: make(map[string]bool),
+	}
+}
+
+// apply records a watch event on an object of kind.
+func (t *watchTable) apply(kind, eventType string, obj []byte) {
+	t.update(kind, eventType, obj, false)
+}
+
+// applySnapshot records an object of a running watch's snapshot, unless a
+// watch event already updated or deleted it.
+func (t *watchTable) applySnapshot(kind string, obj []byte) {
+	t.update(kind, "ADDED", obj, true)
+}
+
+func (t *watchTable) update(kind, eventType string, obj []byte, snapshot bool) {
+	name, cells, err := namespaceWatchKinds[kind].cells(obj)
+	if err != nil || name == "" {
+		return
+	}
+	key := kind + "/" + name
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if snapshot && t.touched[key] {
+		return
+	}
+	if !snapshot {
+		t.touched[key] = true
+	}
+	if eventType == "DELETED" {
+		delete(t.current, key)
+	} else {
+		t.current[key] = tableChange{Kind: kind, Name: name, Cells: cells}
+	}
+	t.dirty[key] = true
+}
+
+// changes returns the rows that differ from what the client has, sorted by
+// kind and name, and records them as sent.
+func (t *watchTable) changes() []tableChange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var changes []tableChange
+	for key := range t.dirty {
+		cur, exists := t.current[key]
+		old, sent := t.sent[key]
+		switch {
+		case exists && !sent:
+			cur.Type = "ADDED"
+		case exists && !reflect.DeepEqual(cur.Cells, old.Cells):
+			cur.Type = "MODIFIED"
+		case !exists && sent:
+			cur = tableChange{Type: "DELETED", Kind: old.Kind, Name: old.Name}
+		default:
+			continue
+		}
+		changes = append(changes, cur)
+		if exists {
+			t.sent[key] = cur
+		} else {
+			delete(t.sent, key)
+		}
+	}
+	t.dirty = make(map[string]bool)
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// podCells renders a pod like kubectl get pods -o wide, using the same
+// status as the pod lists.
+func podCells(data []byte) (string, []interface{}, error) {
+	var pod corev1.Pod
+	if err := json.Unmarshal(data, &pod); err != nil {
+		return "", nil, err
+	}
+	s := summarizePod(&pod)
+	return pod.Name, []interface{}{
+		pod.Name,
+		fmt.Sprintf("%d/%d", s.ReadyContainers, s.TotalContainers),
+		s.Status,
+		s.Restarts,
+		creationTimestamp(pod.CreationTimestamp.Time),
+		noneIfEmpty(pod.Status.PodIP),
+		noneIfEmpty(pod.Spec.NodeName),
+	}, nil
+}
+
+// deploymentCells renders a deployment like kubectl get deployments.
+func deploymentCells(data []byte) (string, []interface{}, error) {
+	var d appsv1.Deployment
+	if err := json.Unmarshal(data, &d); err != nil {
+		return "", nil, err
+	}
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	return d.Name, []interface{}{
+		d.Name,
+		fmt.Sprintf("%d/%d", d.Status.ReadyReplicas, desired),
+		d.Status.UpdatedReplicas,
+		d.Status.AvailableReplicas,
+		creationTimestamp(d.CreationTimestamp.Time),
+	}, nil
+}
+
+// serviceCells renders a service like kubectl get services.
+func serviceCells(data []byte) (string, []interface{}, error) {
+	var svc corev1.Service
+	if err := json.Unmarshal(data, &svc); err != nil {
+		return "", nil, err
+	}
+	return svc.Name, []interface{}{
+		svc.Name,
+		string(svc.Spec.Type),
+		noneIfEmpty(svc.Spec.ClusterIP),
+		serviceExternalIP(&svc),
+		servicePorts(svc.Spec.Ports),
+		creationTimestamp(svc.CreationTimestamp.Time),
+	}, nil
+}
+
+// serviceExternalIP is kubectl's EXTERNAL-IP column: the load balancer's
+// addresses, <pending> until it has one, or the external name.
+func serviceExternalIP(svc *corev1.Service) string {
+	switch svc.Spec.Type {
+	case corev1.ServiceTypeExternalName:
+		return svc.Spec.ExternalName
+	case corev1.ServiceTypeLoadBalancer:
+		ips := append([]string{}, svc.Spec.ExternalIPs...)
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if ingress.IP != "" {
+				ips = append(ips, ingress.IP)
+			} else if ingress.Hostname != "" {
+				ips = append(ips, ingress.Hostname)
+			}
+		}
+		if len(ips) == 0 {
+			return "<pending>"
+		}
+		return strings.Join(ips, ",")
+	}
+	if len(svc.Spec.ExternalIPs) > 0 {
+		return strings.Join(svc.Spec.ExternalIPs, ",")
+	}
+	return "<none>"
+}
+
+// servicePorts is kubectl's PORT(S) column, e.g. 80/TCP,443:30443/TCP.
+func servicePorts(ports []corev1.ServicePort) string {
+	if len(ports) == 0 {
+		return "<none>"
+	}
+	parts := make([]string, 0, len(ports))
+	for _, p := range ports {
+		if p.NodePort != 0 {
+			parts = append(parts, fmt.Sprintf("%d:%d/%s", p.Port, p.NodePort, p.Protocol))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d/%s", p.Port, p.Protocol))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// creationTimestamp formats a date cell the way the Kubernetes API does, so
+// clients compute the age as they do for printer columns.
+func creationTimestamp(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func noneIfEmpty(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
@@ -0,0 +1,266 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/ws"
+)
+
+const (
+	watchPodWeb = `{"metadata": {"name": "web-0", "creationTimestamp": "2026-01-15T10:30:00Z"},
+	  "spec": {"nodeName": "node-1", "containers": [{"name": "app"}, {"name": "proxy"}]},
+	  "status": {"phase": "Running", "podIP": "10.0.0.5", "containerStatuses": [
+	    {"name": "app", "ready": true, "restartCount": 2, "state": {"running": {}}},
+	    {"name": "proxy", "ready": true, "restartCount": 1, "state": {"running": {}}}]}}`
+	watchPodCrashing = `{"metadata": {"name": "web-0", "creationTimestamp": "2026-01-15T10:30:00Z"},
+	  "spec": {"nodeName": "node-1", "containers": [{"name": "app"}, {"name": "proxy"}]},
+	  "status": {"phase": "Running", "podIP": "10.0.0.5", "containerStatuses": [
+	    {"name": "app", "ready": false, "restartCount": 3, "state": {"waiting": {"reason": "CrashLoopBackOff"}}},
+	    {"name": "proxy", "ready": true, "restartCount": 1, "state": {"running": {}}}]}}`
+	watchDeployment = `{"metadata": {"name": "web"}, "spec": {"replicas": 3},
+	  "status": {"readyReplicas": 2, "updatedReplicas": 3, "availableReplicas": 2}}`
+)
+
+// fakeSharedWatcher records watches and lets tests emit their events.
+type fakeSharedWatcher struct {
+	mu       sync.Mutex
+	specs    []ws.WatchSpec
+	emit     map[string]func(ws.WatchEvent)
+	stopped  int
+	snapshot map[string][]json.RawMessage
+	err      error
+}
+
+func (f *fakeSharedWatcher) WatchFunc(userID string, spec ws.WatchSpec, fn func(ws.WatchEvent)) ([]json.RawMessage, func(), error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+	f.specs = append(f.specs, spec)
+	if f.emit == nil {
+		f.emit = make(map[string]func(ws.WatchEvent))
+	}
+	f.emit[spec.Resource] = fn
+	return f.snapshot[spec.Resource], func() {
+		f.mu.Lock()
+		f.stopped++
+		f.mu.Unlock()
+	}, nil
+}
+
+func (f *fakeSharedWatcher) send(resource, eventType, obj string) {
+	f.mu.Lock()
+	fn := f.emit[resource]
+	f.mu.Unlock()
+	fn(ws.WatchEvent{Type: eventType, Object: json.RawMessage(obj)})
+}
+
+func TestParseWatchKinds(t *testing.T) {
+	if kinds, err := parseWatchKinds(""); err != nil || !reflect.DeepEqual(kinds, defaultNamespaceWatchKinds) {
+		t.Errorf("expected the default kinds, got %v, %v", kinds, err)
+	}
+	if kinds, err := parseWatchKinds("Services, pods,services"); err != nil || !reflect.DeepEqual(kinds, []string{"services", "pods"}) {
+		t.Errorf("unexpected kinds %v, %v", kinds, err)
+	}
+	if _, err := parseWatchKinds("pods,secrets"); err == nil || !strings.Contains(err.Error(), `"secrets"`) {
+		t.Errorf("expected secrets to be refused, got %v", err)
+	}
+}
+
+func TestPodCells(t *testing.T) {
+	name, cells, err := podCells([]byte(watchPodCrashing))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []interface{}{"web-0", "1/2", "CrashLoopBackOff", int64(4), "2026-01-15T10:30:00Z", "10.0.0.5", "node-1"}
+	if name != "web-0" || !reflect.DeepEqual(cells, want) {
+		t.Errorf("expected %v, got %v", want, cells)
+	}
+}
+
+func TestDeploymentCells(t *testing.T) {
+	_, cells, err := deploymentCells([]byte(watchDeployment))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []interface{}{"web", "2/3", int32(3), int32(2), nil}
+	if !reflect.DeepEqual(cells, want) {
+		t.Errorf("expected %v, got %v", want, cells)
+	}
+}
+
+func TestServiceCells(t *testing.T) {
+	tests := []struct {
+		svc        string
+		externalIP string
+		ports      string
+	}{
+		{`{"metadata": {"name": "a"}, "spec": {"type": "ClusterIP", "clusterIP": "10.96.0.10", "ports": [{"port": 80, "protocol": "TCP"}]}}`, "<none>", "80/TCP"},
+		{`{"metadata": {"name": "b"}, "spec": {"type": "LoadBalancer", "ports": [{"port": 443, "nodePort": 30443, "protocol": "TCP"}]}}`, "<pending>", "443:30443/TCP"},
+		{`{"metadata": {"name": "c"}, "spec": {"type": "LoadBalancer"}, "status": {"loadBalancer": {"ingress": [{"ip": "1.2.3.4"}, {"hostname": "lb.example.com"}]}}}`, "1.2.3.4,lb.example.com", "<none>"},
+		{`{"metadata": {"name": "d"}, "spec": {"type": "ExternalName", "externalName": "db.example.com"}}`, "db.example.com", "<none>"},
+	}
+	for _, tt := range tests {
+		name, cells, err := serviceCells([]byte(tt.svc))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cells[3] != tt.externalIP || cells[4] != tt.ports {
+			t.Errorf("%s: expected %s %s, got %v %v", name, tt.externalIP, tt.ports, cells[3], cells[4])
+		}
+	}
+}
+
+func TestWatchTable_Changes(t *testing.T) {
+	table := newWatchTable()
+	table.apply("pods", "ADDED", []byte(watchPodWeb))
+	table.apply("deployments", "ADDED", []byte(watchDeployment))
+
+	changes := table.changes()
+	if len(changes) != 2 || changes[0].Kind != "deployments" || changes[1].Type != "ADDED" || changes[1].Cells[2] != "Running" {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+	if changes := table.changes(); len(changes) != 0 {
+		t.Errorf("expected no changes without events, got %+v", changes)
+	}
+
+	// Several updates between flushes are sent once; an update that changes
+	// no cell is not sent.
+	table.apply("pods", "MODIFIED", []byte(watchPodWeb))
+	table.apply("pods", "MODIFIED", []byte(watchPodCrashing))
+	table.apply("deployments", "MODIFIED", []byte(watchDeployment))
+	changes = table.changes()
+	if len(changes) != 1 || changes[0].Type != "MODIFIED" || changes[0].Cells[2] != "CrashLoopBackOff" {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+
+	// A row added and deleted between flushes is never sent.
+	table.apply("pods", "ADDED", []byte(strings.Replace(watchPodWeb, "web-0", "web-1", 1)))
+	table.apply("pods", "DELETED", []byte(strings.Replace(watchPodWeb, "web-0", "web-1", 1)))
+	table.apply("pods", "DELETED", []byte(watchPodCrashing))
+	changes = table.changes()
+	if len(changes) != 1 || changes[0].Type != "DELETED" || changes[0].Name != "web-0" || changes[0].Cells != nil {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+}
+
+func TestWatchTable_SnapshotDoesNotOverrideEvents(t *testing.T) {
+	table := newWatchTable()
+	table.apply("pods", "DELETED", []byte(watchPodWeb))
+	table.applySnapshot("pods", []byte(watchPodWeb))
+	table.applySnapshot("deployments", []byte(watchDeployment))
+
+	changes := table.changes()
+	if len(changes) != 1 || changes[0].Kind != "deployments" {
+		t.Errorf("expected the deleted pod to stay deleted, got %+v", changes)
+	}
+}
+
+func newNamespaceWatchRequest(ctx context.Context, target string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil).WithContext(ctx)
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), &auth.Claims{UserID: "u1"}))
+	return mux.SetURLVars(req, map[string]string{"clusterID": "c1", "namespace": "shop"})
+}
+
+func TestNamespaceWatchHandler_Stream(t *testing.T) {
+	watcher := &fakeSharedWatcher{snapshot: map[string][]json.RawMessage{
+		"deployments": {json.RawMessage(watchDeployment)},
+	}}
+	h := &NamespaceWatchHandler{watcher: watcher, flushInterval: 10 * time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		h.Stream(&pipeRecorder{header: http.Header{}, pw: pw}, newNamespaceWatchRequest(ctx, "/api/clusters/c1/namespaces/shop/watch?kinds=pods,deployments"))
+		close(done)
+	}()
+
+	events := bufio.NewScanner(pr)
+	next := func() (string, string) {
+		t.Helper()
+		var event, data string
+		for events.Scan() {
+			line := events.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			case line == "" && event != "":
+				return event, data
+			}
+		}
+		t.Fatal("stream ended")
+		return "", ""
+	}
+
+	if event, data := next(); event != "columns" || !strings.Contains(data, `"pods":[{"name":"Name"`) || !strings.Contains(data, `"deployments"`) {
+		t.Fatalf("unexpected first event %s %s", event, data)
+	}
+	if event, data := next(); event != "rows" || !strings.Contains(data, `{"type":"ADDED","kind":"deployments","name":"web","cells":["web","2/3",3,2,null]}`) {
+		t.Fatalf("expected the snapshot rows, got %s %s", event, data)
+	}
+
+	watcher.send("pods", "ADDED", watchPodWeb)
+	if event, data := next(); event != "rows" || !strings.Contains(data, `"kind":"pods","name":"web-0","cells":["web-0","2/2","Running",3,`) {
+		t.Fatalf("expected the new pod, got %s %s", event, data)
+	}
+
+	cancel()
+	pr.Close()
+	<-done
+	if len(watcher.specs) != 2 || watcher.specs[0].Resource != "pods" || watcher.specs[1].Group != "apps" || watcher.specs[1].Namespace != "shop" {
+		t.Errorf("unexpected watches %+v", watcher.specs)
+	}
+	if watcher.stopped != 2 {
+		t.Errorf("expected both watches to be released, got %d", watcher.stopped)
+	}
+}
+
+func TestNamespaceWatchHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		err    error
+		status int
+	}{
+		{"unsupported kind", "/watch?kinds=secrets", nil, http.StatusBadRequest},
+		{"forbidden", "/watch", ws.ErrWatchForbidden, http.StatusForbidden},
+		{"unavailable", "/watch", ws.ErrWatchUnavailable, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		h := &NamespaceWatchHandler{watcher: &fakeSharedWatcher{err: tt.err}, flushInterval: time.Second}
+		w := httptest.NewRecorder()
+		h.Stream(w, newNamespaceWatchRequest(context.Background(), tt.target))
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, w.Code)
+		}
+	}
+}
+
+// pipeRecorder is a flushable ResponseWriter whose body can be read while
+// the handler is still writing.
+type pipeRecorder struct {
+	header http.Header
+	pw     *io.PipeWriter
+}
+
+func (p *pipeRecorder) Header() http.Header         { return p.header }
+func (p *pipeRecorder) WriteHeader(int)             {}
+func (p *pipeRecorder) Write(b []byte) (int, error) { return p.pw.Write(b) }
+func (p *pipeRecorder) Flush()                      {}
@@ -5,14 +5,14 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
 	"github.com/darkden-lab/argus/backend/internal/httputil"
-	"github.com/darkden-lab/argus/backend/pkg/agentpb"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"github.com/darkden-lab/argus/backend/internal/validate"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // NetPolSimulatorHandler evaluates network connectivity between two pods by
@@ -44,12 +44,19 @@ type simulationResult struct {
 // Simulate evaluates whether traffic from a source pod to a destination pod
 // on a given port would be allowed by the cluster's NetworkPolicies.
 //
+// The source is either an existing pod or, to test a pod before it is
+// deployed, a set of labels; the namespace labels can be given as well so
+// the source namespace does not have to exist either.
+//
 // Query params:
-//   - sourceNamespace (required)
-//   - sourcePod      (required)
-//   - destNamespace   (required)
-//   - destPod         (required)
-//   - port            (optional, integer)
+//   - sourceNamespace       (required)
+//   - sourcePod             (required unless sourceLabels is set)
+//   - sourceLabels          (instead of sourcePod, e.g. "app=web,tier=frontend")
+//   - sourceNamespaceLabels (optional, same format; replaces the live namespace's labels)
+//   - destNamespace         (required)
+//   - destPod               (required)
+//   - port                  (optional, integer)
+//   - protocol              (optional, TCP, UDP or SCTP; defaults to TCP)
 func (h *NetPolSimulatorHandler) Simulate(w http.ResponseWriter, r *http.Request) {
 	clusterID := mux.Vars(r)["clusterID"]
 	q := r.URL.Query()
@@ -60,15 +67,38 @@ func (h *NetPolSimulatorHandler) Simulate(w http.ResponseWriter, r *http.Request
 	dstPod := q.Get("destPod")
 	portStr := q.Get("port")
 
-	if srcNs == "" || srcPod == "" || dstNs == "" || dstPod == "" {
-		httputil.WriteError(w, http.StatusBadRequest, "sourceNamespace, sourcePod, destNamespace, and destPod are required")
+	if srcNs == "" || dstNs == "" || dstPod == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "sourceNamespace, destNamespace, and destPod are required")
+		return
+	}
+	if (srcPod == "") == (q.Get("sourceLabels") == "") {
+		httputil.WriteError(w, http.StatusBadRequest, "exactly one of sourcePod and sourceLabels is required")
 		return
 	}
-	if !isValidK8sSegment(srcNs) || !isValidK8sSegment(srcPod) || !isValidK8sSegment(dstNs) || !isValidK8sSegment(dstPod) {
+	if !validate.Segment(srcNs) || !validate.Segment(dstNs) || !validate.Segment(dstPod) || (srcPod != "" && !validate.Segment(srcPod)) {
 		httputil.WriteError(w, http.StatusBadRequest, "invalid namespace or pod name")
 		return
 	}
 
+	// Labels given in the query replace the objects that would be fetched.
+	var srcPodLabels, srcNsLabels map[string]string
+	if srcPod == "" {
+		set, err := parseLabelSet(q.Get("sourceLabels"))
+		if err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, "invalid sourceLabels: "+err.Error())
+			return
+		}
+		srcPodLabels = set
+	}
+	if q.Has("sourceNamespaceLabels") {
+		set, err := parseLabelSet(q.Get("sourceNamespaceLabels"))
+		if err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, "invalid sourceNamespaceLabels: "+err.Error())
+			return
+		}
+		srcNsLabels = set
+	}
+
 	var port int
 	if portStr != "" {
 		p, err := strconv.Atoi(portStr)
@@ -79,138 +109,77 @@ func (h *NetPolSimulatorHandler) Simulate(w http.ResponseWriter, r *http.Request
 		port = p
 	}
 
-	client, err := h.clusterMgr.GetClient(clusterID)
-	if err != nil {
-		// Fallback to agent proxy - we can't do the simulation server-side
-		// without direct K8s access, so return an error.
-		agentSrv := h.clusterMgr.GetAgentServer()
-		if agentSrv == nil || !agentSrv.IsAgentConnected(clusterID) {
-			httputil.WriteError(w, http.StatusNotFound, "cluster not found or not connected")
-			return
-		}
-		// Build the simulation via agent by fetching necessary resources
-		h.simulateViaAgent(w, r, clusterID, srcNs, srcPod, dstNs, dstPod, port)
-		return
-	}
-
-	// Fetch the destination pod to get its labels
-	destPodObj, err := client.Clientset.CoreV1().Pods(dstNs).Get(r.Context(), dstPod, metav1.GetOptions{})
-	if err != nil {
-		httputil.WriteError(w, http.StatusNotFound, fmt.Sprintf("destination pod not found: %v", err))
-		return
+	protocol := strings.ToUpper(q.Get("protocol"))
+	if protocol == "" {
+		protocol = defaultProtocol
 	}
-	destPodLabels := destPodObj.Labels
-
-	// Fetch the source pod to get its labels and namespace labels
-	srcPodObj, err := client.Clientset.CoreV1().Pods(srcNs).Get(r.Context(), srcPod, metav1.GetOptions{})
-	if err != nil {
-		httputil.WriteError(w, http.StatusNotFound, fmt.Sprintf("source pod not found: %v", err))
+	if !validProtocols[protocol] {
+		httputil.WriteError(w, http.StatusBadRequest, "protocol must be TCP, UDP or SCTP")
 		return
 	}
-	srcPodLabels := srcPodObj.Labels
-
-	// Fetch source namespace labels
-	srcNsObj, err := client.Clientset.CoreV1().Namespaces().Get(r.Context(), srcNs, metav1.GetOptions{})
-	if err != nil {
-		httputil.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to get source namespace: %v", err))
-		return
-	}
-	srcNsLabels := srcNsObj.Labels
-
-	// Fetch all NetworkPolicies in destination namespace
-	npGVR := schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}
-	npList, err := client.DynClient.Resource(npGVR).Namespace(dstNs).List(r.Context(), metav1.ListOptions{})
-	if err != nil {
-		httputil.WriteError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list network policies: %v", err))
-		return
-	}
-
-	result := evaluateNetworkPolicies(npList.Items, destPodLabels, srcPodLabels, srcNsLabels, srcNs, port)
-	httputil.WriteJSON(w, http.StatusOK, result)
-}
-
-// simulateViaAgent attempts to run the simulation by fetching resources through the agent.
-func (h *NetPolSimulatorHandler) simulateViaAgent(w http.ResponseWriter, r *http.Request, clusterID, srcNs, srcPod, dstNs, dstPod string, port int) {
-	agentSrv := h.clusterMgr.GetAgentServer()
 
 	ctx, cancel := context.WithTimeout(r.Context(), agentProxyTimeout)
 	defer cancel()
 
-	// Fetch destination pod
-	destPodResp, err := agentSrv.SendK8sRequest(ctx, clusterID, &agentpb.K8SRequest{
-		Method: "GET",
-		Path:   fmt.Sprintf("/api/v1/namespaces/%s/pods/%s", dstNs, dstPod),
-	})
-	if err != nil || destPodResp.Error != "" {
-		httputil.WriteError(w, http.StatusNotFound, "destination pod not found via agent")
-		return
-	}
-
-	destPodObj, err := parseUnstructured(destPodResp.Body)
-	if err != nil {
-		httputil.WriteError(w, http.StatusInternalServerError, "failed to parse destination pod")
-		return
-	}
-
-	// Fetch source pod
-	srcPodResp, err := agentSrv.SendK8sRequest(ctx, clusterID, &agentpb.K8SRequest{
-		Method: "GET",
-		Path:   fmt.Sprintf("/api/v1/namespaces/%s/pods/%s", srcNs, srcPod),
-	})
-	if err != nil || srcPodResp.Error != "" {
-		httputil.WriteError(w, http.StatusNotFound, "source pod not found via agent")
-		return
+	// get fetches an object directly or through the cluster's agent.
+	get := func(path string) (*unstructured.Unstructured, error) {
+		body, err := h.clusterMgr.DoK8sRequest(ctx, clusterID, http.MethodGet, path, nil)
+		if err != nil {
+			return nil, err
+		}
+		return parseUnstructured(body)
 	}
 
-	srcPodObj, err := parseUnstructured(srcPodResp.Body)
+	// Fetch the destination pod to get its labels
+	destPodObj, err := get(fmt.Sprintf("/api/v1/namespaces/%s/pods/%s", dstNs, dstPod))
 	if err != nil {
-		httputil.WriteError(w, http.StatusInternalServerError, "failed to parse source pod")
-		return
-	}
-
-	// Fetch source namespace
-	srcNsResp, err := agentSrv.SendK8sRequest(ctx, clusterID, &agentpb.K8SRequest{
-		Method: "GET",
-		Path:   fmt.Sprintf("/api/v1/namespaces/%s", srcNs),
-	})
-	if err != nil || srcNsResp.Error != "" {
-		httputil.WriteError(w, http.StatusInternalServerError, "failed to get source namespace via agent")
+		writeClusterError(w, err, "failed to get destination pod")
 		return
 	}
 
-	srcNsObj, err := parseUnstructured(srcNsResp.Body)
-	if err != nil {
-		httputil.WriteError(w, http.StatusInternalServerError, "failed to parse source namespace")
-		return
+	// Fetch the source pod to get its labels
+	if srcPodLabels == nil {
+		srcPodObj, err := get(fmt.Sprintf("/api/v1/namespaces/%s/pods/%s", srcNs, srcPod))
+		if err != nil {
+			writeClusterError(w, err, "failed to get source pod")
+			return
+		}
+		srcPodLabels = extractLabels(srcPodObj)
 	}
 
-	// Fetch network policies in dest namespace
-	npResp, err := agentSrv.SendK8sRequest(ctx, clusterID, &agentpb.K8SRequest{
-		Method: "GET",
-		Path:   fmt.Sprintf("/apis/networking.k8s.io/v1/namespaces/%s/networkpolicies", dstNs),
-	})
-	if err != nil || npResp.Error != "" {
-		httputil.WriteError(w, http.StatusInternalServerError, "failed to list network policies via agent")
-		return
+	// Fetch source namespace labels
+	if srcNsLabels == nil {
+		srcNsObj, err := get(fmt.Sprintf("/api/v1/namespaces/%s", srcNs))
+		if err != nil {
+			writeClusterError(w, err, "failed to get source namespace")
+			return
+		}
+		srcNsLabels = extractLabels(srcNsObj)
 	}
 
-	npListObj, err := parseUnstructured(npResp.Body)
+	// Fetch all NetworkPolicies in destination namespace
+	npListObj, err := get(fmt.Sprintf("/apis/networking.k8s.io/v1/namespaces/%s/networkpolicies", dstNs))
 	if err != nil {
-		httputil.WriteError(w, http.StatusInternalServerError, "failed to parse network policies")
+		writeClusterError(w, err, "failed to list network policies")
 		return
 	}
 
-	destPodLabels := extractLabels(destPodObj)
-	srcPodLabels := extractLabels(srcPodObj)
-	srcNsLabels := extractLabels(srcNsObj)
-
-	items := extractItems(npListObj)
-	result := evaluateNetworkPolicies(items, destPodLabels, srcPodLabels, srcNsLabels, srcNs, port)
+	result := evaluateNetworkPolicies(
+		extractItems(npListObj),
+		extractLabels(destPodObj),
+		srcPodLabels,
+		srcNsLabels,
+		srcNs,
+		port,
+		protocol,
+		extractNamedPorts(destPodObj),
+	)
 	httputil.WriteJSON(w, http.StatusOK, result)
 }
 
 // evaluateNetworkPolicies checks if ingress traffic from the source to the
 // destination pod would be allowed, given all NetworkPolicies in the dest namespace.
+// destPorts resolves rules that name a port of the destination pod.
 func evaluateNetworkPolicies(
 	policies []unstructured.Unstructured,
 	destPodLabels map[string]string,
@@ -218,7 +187,11 @@ func evaluateNetworkPolicies(
 	srcNsLabels map[string]string,
 	srcNs string,
 	port int,
+	protocol string,
+	destPorts map[namedPort]int,
 ) simulationResult {
+	srcNsLabels = withNamespaceNameLabel(srcNsLabels, srcNs)
+
 	// Find all policies that select the destination pod AND have Ingress policy type
 	var selectingPolicies []unstructured.Unstructured
 	var policyNames []string
@@ -290,11 +263,8 @@ func evaluateNetworkPolicies(
 				continue
 			}
 
-			// Check port match if port specified
-			if port > 0 {
-				if !portMatchesRule(port, rule) {
-					continue
-				}
+			if !portMatchesRule(port, protocol, rule, destPorts) {
+				continue
 			}
 
 			// Check "from" peers
@@ -335,6 +305,23 @@ func evaluateNetworkPolicies(
 	}
 }
 
+// namespaceNameLabel is set by Kubernetes (1.21+) on every namespace to the
+// namespace's own name, and is the usual way to select a namespace by name.
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// withNamespaceNameLabel returns the namespace labels with namespaceNameLabel
+// set to the namespace name, as the API server guarantees on current clusters.
+// Older clusters, or namespace objects fetched without labels, would otherwise
+// fail namespaceSelectors that match on it. The input map is not modified.
+func withNamespaceNameLabel(labels map[string]string, namespace string) map[string]string {
+	result := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		result[k] = v
+	}
+	result[namespaceNameLabel] = namespace
+	return result
+}
+
 // labelsMatchSelector checks if a set of labels satisfies a selector's matchLabels.
 func labelsMatchSelector(labels map[string]string, selectorLabels map[string]string) bool {
 	// Empty selector matches everything
@@ -383,8 +370,18 @@ func extractStringSlice(spec map[string]interface{}, field string) []string {
 	return result
 }
 
-// portMatchesRule checks if the given port is allowed by a rule.
-func portMatchesRule(port int, rule map[string]interface{}) bool {
+// defaultProtocol is the protocol assumed for a query without one and for a
+// NetworkPolicyPort without one, as in the Kubernetes API.
+const defaultProtocol = "TCP"
+
+// validProtocols are the protocols a NetworkPolicyPort can name.
+var validProtocols = map[string]bool{"TCP": true, "UDP": true, "SCTP": true}
+
+// portMatchesRule checks if the given port and protocol are allowed by a
+// rule. A port of 0 means any port, so only the protocol has to match. A
+// named port is looked up in destPorts and matches nothing when the
+// destination pod does not define it for that protocol.
+func portMatchesRule(port int, protocol string, rule map[string]interface{}, destPorts map[namedPort]int) bool {
 	portsArr, ok := rule["ports"].([]interface{})
 	if !ok || len(portsArr) == 0 {
 		// No ports restriction means all ports allowed
@@ -397,6 +394,14 @@ func portMatchesRule(port int, rule map[string]interface{}) bool {
 			continue
 		}
 
+		ruleProtocol, _ := p["protocol"].(string)
+		if ruleProtocol == "" {
+			ruleProtocol = defaultProtocol
+		}
+		if ruleProtocol != protocol {
+			continue
+		}
+
 		// Port can be a number or string
 		rulePort := 0
 		switch v := p["port"].(type) {
@@ -404,9 +409,15 @@ func portMatchesRule(port int, rule map[string]interface{}) bool {
 			rulePort = int(v)
 		case int64:
 			rulePort = int(v)
+		case string:
+			resolved, ok := destPorts[namedPort{name: v, protocol: ruleProtocol}]
+			if !ok {
+				continue
+			}
+			rulePort = resolved
 		}
 
-		if rulePort == 0 {
+		if rulePort == 0 || port == 0 {
 			// No specific port means all ports
 			return true
 		}
@@ -484,6 +495,16 @@ func extractPeerSelector(peer map[string]interface{}, field string) map[string]s
 	return result
 }
 
+// parseLabelSet parses labels written as "key=value,key2=value2". Keys and
+// values must be valid Kubernetes labels; the empty string is the empty set.
+func parseLabelSet(s string) (map[string]string, error) {
+	set, err := labels.ConvertSelectorToLabelsMap(s)
+	if err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
 // parseUnstructured parses raw JSON bytes into an Unstructured object.
 func parseUnstructured(data []byte) (*unstructured.Unstructured, error) {
 	obj := &unstructured.Unstructured{}
@@ -499,6 +520,46 @@ func extractLabels(obj *unstructured.Unstructured) map[string]string {
 	return labels
 }
 
+// namedPort identifies a container port by name and protocol, the way a
+// NetworkPolicyPort refers to it.
+type namedPort struct {
+	name     string
+	protocol string
+}
+
+// extractNamedPorts maps the named container ports of a pod, including
+// those of init containers, to their port numbers.
+func extractNamedPorts(pod *unstructured.Unstructured) map[namedPort]int {
+	ports := make(map[namedPort]int)
+	for _, field := range []string{"containers", "initContainers"} {
+		containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", field)
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			specs, _, _ := unstructured.NestedSlice(container, "ports")
+			for _, ps := range specs {
+				spec, ok := ps.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _ := spec["name"].(string)
+				number, _, _ := unstructured.NestedInt64(spec, "containerPort")
+				if name == "" || number == 0 {
+					continue
+				}
+				protocol, _ := spec["protocol"].(string)
+				if protocol == "" {
+					protocol = defaultProtocol
+				}
+				ports[namedPort{name: name, protocol: protocol}] = int(number)
+			}
+		}
+	}
+	return ports
+}
+
 // extractItems extracts the items array from a list Unstructured object.
 func extractItems(obj *unstructured.Unstructured) []unstructured.Unstructured {
 	items, ok := obj.Object["items"].([]interface{})
@@ -0,0 +1,237 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func testNetworkPolicy(name string, from []interface{}) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": name, "namespace": "backend"},
+		"spec": map[string]interface{}{
+			"podSelector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": "api"},
+			},
+			"policyTypes": []interface{}{"Ingress"},
+			"ingress":     []interface{}{map[string]interface{}{"from": from}},
+		},
+	}}
+}
+
+func namespaceByName(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"matchLabels": map[string]interface{}{namespaceNameLabel: name},
+	}
+}
+
+func TestEvaluateNetworkPolicies_NamespaceSelectedByName(t *testing.T) {
+	policies := []unstructured.Unstructured{
+		testNetworkPolicy("allow-frontend", []interface{}{
+			map[string]interface{}{"namespaceSelector": namespaceByName("frontend")},
+		}),
+	}
+	destLabels := map[string]string{"app": "api"}
+	srcLabels := map[string]string{"app": "web"}
+
+	tests := []struct {
+		name        string
+		srcNs       string
+		srcNsLabels map[string]string
+		want        bool
+	}{
+		{"namespace without the well-known label", "frontend", nil, true},
+		{"namespace with the well-known label", "frontend", map[string]string{namespaceNameLabel: "frontend"}, true},
+		{"other namespace", "batch", nil, false},
+		{"other namespace with a spoofed label", "batch", map[string]string{namespaceNameLabel: "frontend"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := evaluateNetworkPolicies(policies, destLabels, srcLabels, tt.srcNsLabels, tt.srcNs, 8080, "TCP", nil)
+			if result.Allowed != tt.want {
+				t.Errorf("expected allowed=%v, got %v (%s)", tt.want, result.Allowed, result.Reason)
+			}
+		})
+	}
+}
+
+func TestEvaluateNetworkPolicies_PodAndNamespaceSelectedByName(t *testing.T) {
+	policies := []unstructured.Unstructured{
+		testNetworkPolicy("allow-frontend-web", []interface{}{
+			map[string]interface{}{
+				"namespaceSelector": namespaceByName("frontend"),
+				"podSelector": map[string]interface{}{
+					"matchLabels": map[string]interface{}{"app": "web"},
+				},
+			},
+		}),
+	}
+	destLabels := map[string]string{"app": "api"}
+
+	if r := evaluateNetworkPolicies(policies, destLabels, map[string]string{"app": "web"}, nil, "frontend", 0, "TCP", nil); !r.Allowed {
+		t.Errorf("expected web pod in frontend to be allowed: %s", r.Reason)
+	}
+	if r := evaluateNetworkPolicies(policies, destLabels, map[string]string{"app": "worker"}, nil, "frontend", 0, "TCP", nil); r.Allowed {
+		t.Error("expected other pods in frontend to be denied")
+	}
+}
+
+func TestEvaluateNetworkPolicies_Protocol(t *testing.T) {
+	np := testNetworkPolicy("allow-dns", nil)
+	np.Object["spec"].(map[string]interface{})["ingress"] = []interface{}{
+		map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"protocol": "UDP", "port": int64(53)},
+			},
+		},
+	}
+	policies := []unstructured.Unstructured{np}
+	destLabels := map[string]string{"app": "api"}
+
+	tests := []struct {
+		name     string
+		port     int
+		protocol string
+		want     bool
+	}{
+		{"UDP/53", 53, "UDP", true},
+		{"TCP/53", 53, "TCP", false},
+		{"UDP/54", 54, "UDP", false},
+		{"any UDP port", 0, "UDP", true},
+		{"any TCP port", 0, "TCP", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := evaluateNetworkPolicies(policies, destLabels, nil, nil, "frontend", tt.port, tt.protocol, nil)
+			if result.Allowed != tt.want {
+				t.Errorf("expected allowed=%v, got %v (%s)", tt.want, result.Allowed, result.Reason)
+			}
+		})
+	}
+}
+
+func TestPortMatchesRule_DefaultProtocolIsTCP(t *testing.T) {
+	rule := map[string]interface{}{
+		"ports": []interface{}{map[string]interface{}{"port": int64(8080)}},
+	}
+	if !portMatchesRule(8080, "TCP", rule, nil) {
+		t.Error("expected a port without a protocol to match TCP")
+	}
+	if portMatchesRule(8080, "UDP", rule, nil) {
+		t.Error("expected a port without a protocol not to match UDP")
+	}
+}
+
+func TestEvaluateNetworkPolicies_NamedPort(t *testing.T) {
+	np := testNetworkPolicy("allow-http", nil)
+	np.Object["spec"].(map[string]interface{})["ingress"] = []interface{}{
+		map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"protocol": "TCP", "port": "http"},
+			},
+		},
+	}
+	policies := []unstructured.Unstructured{np}
+	destLabels := map[string]string{"app": "api"}
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name": "api",
+					"ports": []interface{}{
+						map[string]interface{}{"name": "http", "containerPort": int64(8080)},
+						map[string]interface{}{"name": "metrics", "containerPort": int64(9090)},
+					},
+				},
+			},
+		},
+	}}
+	destPorts := extractNamedPorts(pod)
+
+	tests := []struct {
+		name      string
+		port      int
+		protocol  string
+		destPorts map[namedPort]int
+		want      bool
+	}{
+		{"named port", 8080, "TCP", destPorts, true},
+		{"other port", 9090, "TCP", destPorts, false},
+		{"other protocol", 8080, "UDP", destPorts, false},
+		{"any TCP port", 0, "TCP", destPorts, true},
+		{"port not defined by the pod", 8080, "TCP", nil, false},
+		{"any port, not defined by the pod", 0, "TCP", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := evaluateNetworkPolicies(policies, destLabels, nil, nil, "frontend", tt.port, tt.protocol, tt.destPorts)
+			if result.Allowed != tt.want {
+				t.Errorf("expected allowed=%v, got %v (%s)", tt.want, result.Allowed, result.Reason)
+			}
+		})
+	}
+}
+
+func TestPortMatchesRule_SCTP(t *testing.T) {
+	rule := map[string]interface{}{
+		"ports": []interface{}{map[string]interface{}{"protocol": "SCTP", "port": int64(3868)}},
+	}
+	if !portMatchesRule(3868, "SCTP", rule, nil) {
+		t.Error("expected an SCTP port to match SCTP")
+	}
+	if portMatchesRule(3868, "TCP", rule, nil) {
+		t.Error("expected an SCTP port not to match TCP")
+	}
+}
+
+func TestWithNamespaceNameLabel(t *testing.T) {
+	labels := map[string]string{"team": "web"}
+	got := withNamespaceNameLabel(labels, "frontend")
+	if got[namespaceNameLabel] != "frontend" || got["team"] != "web" {
+		t.Errorf("unexpected labels %v", got)
+	}
+	if _, ok := labels[namespaceNameLabel]; ok {
+		t.Error("input labels must not be modified")
+	}
+}
+
+func TestParseLabelSet(t *testing.T) {
+	got, err := parseLabelSet("app=web, tier=frontend")
+	if err != nil || !reflect.DeepEqual(got, map[string]string{"app": "web", "tier": "frontend"}) {
+		t.Errorf("unexpected labels %v, %v", got, err)
+	}
+	if got, err := parseLabelSet(""); err != nil || got == nil || len(got) != 0 {
+		t.Errorf("expected an empty set, got %v, %v", got, err)
+	}
+	for _, bad := range []string{"app", "app in (web)", "app=we b", "-app=web"} {
+		if _, err := parseLabelSet(bad); err == nil {
+			t.Errorf("expected %q to be refused", bad)
+		}
+	}
+}
+
+func TestSimulate_SourceValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"no source", "sourceNamespace=web&destNamespace=api&destPod=api-0"},
+		{"pod and labels", "sourceNamespace=web&sourcePod=web-0&sourceLabels=app%3Dweb&destNamespace=api&destPod=api-0"},
+		{"invalid labels", "sourceNamespace=web&sourceLabels=app&destNamespace=api&destPod=api-0"},
+		{"invalid namespace labels", "sourceNamespace=web&sourceLabels=app%3Dweb&sourceNamespaceLabels=team%3D%2F&destNamespace=api&destPod=api-0"},
+	}
+	h := &NetPolSimulatorHandler{}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/clusters/c1/network-policies/simulate?"+tt.query, nil)
+		req = mux.SetURLVars(req, map[string]string{"clusterID": "c1"})
+		w := httptest.NewRecorder()
+		h.Simulate(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", tt.name, w.Code)
+		}
+	}
+}
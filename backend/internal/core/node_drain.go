@@ -0,0 +1,588 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/darkden-lab/argus/backend/internal/audit"
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+// maxDrainNodes caps how many nodes a single rolling drain may target.
+const maxDrainNodes = 100
+
+// Per-node drain timeout: evictions and the wait for the node's workloads to
+// be ready again must finish within it, unless the request asks for another
+// value up to the maximum.
+const (
+	defaultDrainNodeTimeout = 10 * time.Minute
+	maxDrainNodeTimeout     = time.Hour
+)
+
+// drainPollInterval is how often blocked evictions are retried and
+// rescheduled workloads checked.
+const drainPollInterval = 5 * time.Second
+
+// Per-node outcome statuses reported by the node drain endpoint.
+const (
+	drainStatusDrained = "drained"
+	drainStatusFailed  = "failed"
+	// drainStatusSkipped is reported for the nodes after a failed one,
+	// which are left untouched.
+	drainStatusSkipped = "skipped"
+)
+
+// mirrorPodAnnotation marks static pods the kubelet mirrors to the API
+// server; they cannot be evicted.
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// drainWaitResources maps the controllers whose ready replicas a drain waits
+// for to their API path segment. Pods of other controllers, such as Jobs,
+// are only waited on to leave the node.
+var drainWaitResources = map[string]string{
+	"ReplicaSet":            "/apis/apps/v1/namespaces/%s/replicasets/%s",
+	"StatefulSet":           "/apis/apps/v1/namespaces/%s/statefulsets/%s",
+	"ReplicationController": "/api/v1/namespaces/%s/replicationcontrollers/%s",
+}
+
+// nodeDrainRequest is the JSON body accepted by the node drain endpoint.
+type nodeDrainRequest struct {
+	LabelSelector string `json:"labelSelector"`
+	// TimeoutSeconds bounds the drain of each node.
+	TimeoutSeconds int `json:"timeoutSeconds"`
+	// Force evicts pods no controller manages, which are not recreated.
+	Force bool `json:"force"`
+	// DeleteEmptyDirData evicts pods with emptyDir volumes, whose data is
+	// lost.
+	DeleteEmptyDirData bool `json:"deleteEmptyDirData"`
+	// DryRun cordons nothing and evicts with a server-side dry run, which
+	// still checks PodDisruptionBudgets.
+	DryRun bool `json:"dryRun"`
+}
+
+// drainedPod names a pod evicted from a node.
+type drainedPod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// nodeDrainResult is the outcome for one node. A failed node stays cordoned.
+type nodeDrainResult struct {
+	Node    string       `json:"node"`
+	Status  string       `json:"status"`
+	Evicted []drainedPod `json:"evicted"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// nodeDrainResponse is the result of a rolling drain.
+type nodeDrainResponse struct {
+	ClusterID string            `json:"clusterId"`
+	DryRun    bool              `json:"dryRun"`
+	Total     int               `json:"total"`
+	Drained   int               `json:"drained"`
+	Failed    int               `json:"failed"`
+	Skipped   int               `json:"skipped"`
+	Nodes     []nodeDrainResult `json:"nodes"`
+}
+
+// nodeDrainEvent reports progress on one node: it was cordoned, a pod was
+// evicted, the drain is waiting for the node's workloads, or the node was
+// drained or failed.
+type nodeDrainEvent struct {
+	Node      string `json:"node"`
+	Phase     string `json:"phase"`
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// drainAuditLog records node actions. Implemented by *audit.Store.
+type drainAuditLog interface {
+	Insert(ctx context.Context, userID, serviceAccountID, clusterID *string, action, resource string, details json.RawMessage) error
+}
+
+// NodeDrainHandler drains the nodes matching a selector one at a time, for
+// rolling maintenance of a node pool: each node is cordoned and its pods
+// evicted, respecting PodDisruptionBudgets, and the next node is only
+// started once the evicted pods' workloads are ready again elsewhere.
+type NodeDrainHandler struct {
+	k8s          manifestCluster
+	authz        rbac.Evaluator
+	auditLog     drainAuditLog
+	pollInterval time.Duration
+}
+
+// NewNodeDrainHandler creates a new NodeDrainHandler.
+func NewNodeDrainHandler(cm *cluster.Manager, engine *rbac.Engine) *NodeDrainHandler {
+	return &NodeDrainHandler{k8s: cm, authz: engine, pollInterval: drainPollInterval}
+}
+
+// SetAuditLog records every cordon and drain in the audit log.
+func (h *NodeDrainHandler) SetAuditLog(auditLog drainAuditLog) {
+	h.auditLog = auditLog
+}
+
+// RegisterRoutes wires the node drain endpoint.
+func (h *NodeDrainHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clusters/{clusterID}/nodes/drain", h.Drain).Methods(http.MethodPost)
+}
+
+// validate checks the request and fills in the defaults.
+func (req *nodeDrainRequest) validate() error {
+	if req.LabelSelector == "" {
+		return fmt.Errorf("labelSelector is required")
+	}
+	if err := validate.LabelSelector(req.LabelSelector); err != nil {
+		return err
+	}
+	selector, err := labels.Parse(req.LabelSelector)
+	if err != nil {
+		return fmt.Errorf("invalid labelSelector: %v", err)
+	}
+	if selector.Empty() {
+		return fmt.Errorf("labelSelector must select on at least one label")
+	}
+	if req.TimeoutSeconds == 0 {
+		req.TimeoutSeconds = int(defaultDrainNodeTimeout / time.Second)
+	}
+	if req.TimeoutSeconds < 1 || req.TimeoutSeconds > int(maxDrainNodeTimeout/time.Second) {
+		return fmt.Errorf("timeoutSeconds must be between 1 and %d", int(maxDrainNodeTimeout/time.Second))
+	}
+	return nil
+}
+
+// record adds a node result and updates the counters.
+func (resp *nodeDrainResponse) record(res nodeDrainResult) {
+	resp.Nodes = append(resp.Nodes, res)
+	switch res.Status {
+	case drainStatusDrained:
+		resp.Drained++
+	case drainStatusSkipped:
+		resp.Skipped++
+	default:
+		resp.Failed++
+	}
+}
+
+// Drain drains the nodes matching the request's selector in name order and
+// stops at the first node that fails; the nodes after it are skipped. The
+// caller needs write access to nodes on the cluster. With ?stream=true
+// progress is streamed as SSE "progress" events followed by a final "done"
+// event.
+func (h *NodeDrainHandler) Drain(w http.ResponseWriter, r *http.Request) {
+	clusterID := mux.Vars(r)["clusterID"]
+
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req nodeDrainRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize)).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := req.validate(); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	allowed, err := h.authz.Evaluate(r.Context(), rbac.Request{
+		UserID:      claims.UserID,
+		Action:      "write",
+		Resource:    rbac.ResourceCategory("nodes"),
+		ClusterID:   clusterID,
+		K8sResource: "nodes",
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+		return
+	}
+	if !allowed {
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+
+	data, err := h.k8s.DoK8sRequestWithContentType(r.Context(), clusterID, http.MethodGet,
+		"/api/v1/nodes?labelSelector="+url.QueryEscape(req.LabelSelector), "application/json", nil)
+	if err != nil {
+		writeClusterError(w, err, "failed to list nodes")
+		return
+	}
+	var nodes corev1.NodeList
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, "failed to decode nodes")
+		return
+	}
+	if len(nodes.Items) > maxDrainNodes {
+		httputil.WriteError(w, http.StatusBadRequest,
+			fmt.Sprintf("selector matches %d nodes; narrow it to at most %d", len(nodes.Items), maxDrainNodes))
+		return
+	}
+	names := make([]string, len(nodes.Items))
+	for i, n := range nodes.Items {
+		names[i] = n.Name
+	}
+	sort.Strings(names)
+
+	stream := r.URL.Query().Get("stream") == "true"
+	var flusher http.Flusher
+	if stream {
+		flusher, ok = w.(http.Flusher)
+		if !ok {
+			httputil.WriteError(w, http.StatusInternalServerError, "streaming not supported")
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+	}
+
+	d := &nodeDrainer{
+		h:         h,
+		r:         r,
+		clusterID: clusterID,
+		req:       &req,
+		emit: func(ev nodeDrainEvent) {
+			if stream {
+				writeSSEEvent(w, "progress", ev)
+				flusher.Flush()
+			}
+		},
+	}
+
+	resp := &nodeDrainResponse{ClusterID: clusterID, DryRun: req.DryRun, Total: len(names), Nodes: make([]nodeDrainResult, 0, len(names))}
+	failed := false
+	for _, name := range names {
+		if failed {
+			resp.record(nodeDrainResult{Node: name, Status: drainStatusSkipped, Evicted: []drainedPod{}})
+			continue
+		}
+		res := d.drainNode(name)
+		resp.record(res)
+		failed = res.Status == drainStatusFailed
+	}
+
+	if stream {
+		writeSSEEvent(w, "done", resp)
+		flusher.Flush()
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+// nodeDrainer drains the nodes of one request.
+type nodeDrainer struct {
+	h         *NodeDrainHandler
+	r         *http.Request
+	clusterID string
+	req       *nodeDrainRequest
+	emit      func(nodeDrainEvent)
+}
+
+// drainNode cordons a node, evicts its pods and waits for their workloads to
+// be ready again, reporting progress and recording the drain in the audit
+// log.
+func (d *nodeDrainer) drainNode(name string) nodeDrainResult {
+	ctx, cancel := context.WithTimeout(d.r.Context(), time.Duration(d.req.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	res := nodeDrainResult{Node: name, Evicted: []drainedPod{}}
+	err := d.drain(ctx, name, &res)
+	if err != nil && ctx.Err() != nil && d.r.Context().Err() == nil {
+		err = fmt.Errorf("timed out after %ds: %v", d.req.TimeoutSeconds, err)
+	}
+	if err != nil {
+		res.Status = drainStatusFailed
+		res.Error = err.Error()
+		d.emit(nodeDrainEvent{Node: name, Phase: drainStatusFailed, Message: res.Error})
+	} else {
+		res.Status = drainStatusDrained
+		d.emit(nodeDrainEvent{Node: name, Phase: drainStatusDrained})
+	}
+	if !d.req.DryRun {
+		d.record("node.drain", name, map[string]interface{}{
+			"node":    name,
+			"status":  res.Status,
+			"evicted": len(res.Evicted),
+			"error":   res.Error,
+		})
+	}
+	return res
+}
+
+// drain does the work of drainNode, adding evicted pods to res.
+func (d *nodeDrainer) drain(ctx context.Context, name string, res *nodeDrainResult) error {
+	if !d.req.DryRun {
+		patch := []byte(`{"spec":{"unschedulable":true}}`)
+		if _, err := d.h.k8s.DoK8sRequestWithContentType(ctx, d.clusterID, http.MethodPatch,
+			"/api/v1/nodes/"+url.PathEscape(name), "application/merge-patch+json", patch); err != nil {
+			return fmt.Errorf("failed to cordon: %w", err)
+		}
+		d.record("node.cordon", name, map[string]interface{}{"node": name})
+		d.emit(nodeDrainEvent{Node: name, Phase: "cordoned"})
+	}
+
+	pods, err := d.nodePods(ctx, name)
+	if err != nil {
+		return err
+	}
+	evict, err := d.podsToEvict(pods)
+	if err != nil {
+		return err
+	}
+
+	for _, pod := range evict {
+		if err := d.evict(ctx, pod); err != nil {
+			return fmt.Errorf("failed to evict %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		res.Evicted = append(res.Evicted, drainedPod{Namespace: pod.Namespace, Name: pod.Name})
+		d.emit(nodeDrainEvent{Node: name, Phase: "evicted", Namespace: pod.Namespace, Pod: pod.Name})
+	}
+	if d.req.DryRun || len(evict) == 0 {
+		return nil
+	}
+
+	d.emit(nodeDrainEvent{Node: name, Phase: "waiting", Message: "waiting for the evicted pods to be rescheduled"})
+	return d.waitRescheduled(ctx, name, evict)
+}
+
+// nodePods lists the pods scheduled to a node.
+func (d *nodeDrainer) nodePods(ctx context.Context, node string) ([]corev1.Pod, error) {
+	data, err := d.h.k8s.DoK8sRequestWithContentType(ctx, d.clusterID, http.MethodGet,
+		"/api/v1/pods?fieldSelector="+url.QueryEscape("spec.nodeName="+node), "application/json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	var pods corev1.PodList
+	if err := json.Unmarshal(data, &pods); err != nil {
+		return nil, fmt.Errorf("failed to decode pods: %w", err)
+	}
+	return pods.Items, nil
+}
+
+// podsToEvict returns the pods a drain evicts, as kubectl drain does:
+// DaemonSet pods and mirror pods are left alone, and pods without a
+// controller or with emptyDir volumes fail the drain unless the request
+// allows evicting them.
+func (d *nodeDrainer) podsToEvict(pods []corev1.Pod) ([]corev1.Pod, error) {
+	var evict []corev1.Pod
+	var blocked []string
+	for _, pod := range pods {
+		if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+			continue
+		}
+		owner := metav1.GetControllerOf(&pod)
+		if owner != nil && owner.Kind == "DaemonSet" {
+			continue
+		}
+		finished := pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+		switch {
+		case owner == nil && !finished && !d.req.Force:
+			blocked = append(blocked, fmt.Sprintf("%s/%s is not managed by a controller (set force)", pod.Namespace, pod.Name))
+		case hasEmptyDir(&pod) && !finished && !d.req.DeleteEmptyDirData:
+			blocked = append(blocked, fmt.Sprintf("%s/%s uses emptyDir data (set deleteEmptyDirData)", pod.Namespace, pod.Name))
+		default:
+			evict = append(evict, pod)
+		}
+	}
+	if len(blocked) > 0 {
+		return nil, fmt.Errorf("cannot evict: %s", strings.Join(blocked, "; "))
+	}
+	return evict, nil
+}
+
+// hasEmptyDir reports whether the pod has an emptyDir volume.
+func hasEmptyDir(pod *corev1.Pod) bool {
+	for _, v := range pod.Spec.Volumes {
+		if v.EmptyDir != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// evict evicts a pod through the Eviction API, which refuses evictions a
+// PodDisruptionBudget does not allow. Those are retried until ctx is done,
+// as disruptions are allowed again once earlier evictions' replacements are
+// ready; a dry run reports them right away.
+func (d *nodeDrainer) evict(ctx context.Context, pod corev1.Pod) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "policy/v1",
+		"kind":       "Eviction",
+		"metadata":   map[string]string{"name": pod.Name, "namespace": pod.Namespace},
+	})
+	if err != nil {
+		return err
+	}
+	path := "/api/v1/namespaces/" + url.PathEscape(pod.Namespace) + "/pods/" + url.PathEscape(pod.Name) + "/eviction"
+	if d.req.DryRun {
+		path += "?dryRun=All"
+	}
+
+	for {
+		_, err := d.h.k8s.DoK8sRequestWithContentType(ctx, d.clusterID, http.MethodPost, path, "application/json", body)
+		var reqErr *cluster.K8sRequestError
+		switch {
+		case err == nil:
+			return nil
+		case errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusNotFound:
+			return nil // already gone
+		case errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusTooManyRequests:
+			if d.req.DryRun {
+				return fmt.Errorf("a PodDisruptionBudget does not allow it: %s", reqErr.Message)
+			}
+			if !sleepCtx(ctx, d.h.pollInterval) {
+				return fmt.Errorf("a PodDisruptionBudget kept blocking it: %s", reqErr.Message)
+			}
+		default:
+			return err
+		}
+	}
+}
+
+// drainOwner is a controller of evicted pods whose ready replicas a drain
+// waits for.
+type drainOwner struct {
+	kind, namespace, name string
+}
+
+// waitRescheduled waits until the evicted pods have left the node and the
+// controllers that own them have all their replicas ready again.
+func (d *nodeDrainer) waitRescheduled(ctx context.Context, node string, pods []corev1.Pod) error {
+	owners := make(map[drainOwner]bool)
+	for _, pod := range pods {
+		if ref := metav1.GetControllerOf(&pod); ref != nil {
+			if _, ok := drainWaitResources[ref.Kind]; ok {
+				owners[drainOwner{kind: ref.Kind, namespace: pod.Namespace, name: ref.Name}] = true
+			}
+		}
+	}
+
+	for {
+		var pending []string
+		for _, pod := range pods {
+			gone, err := d.podGone(ctx, pod)
+			if err != nil {
+				return err
+			}
+			if !gone {
+				pending = append(pending, fmt.Sprintf("pod %s/%s to terminate", pod.Namespace, pod.Name))
+			}
+		}
+		for owner := range owners {
+			ready, err := d.ownerReady(ctx, owner)
+			if err != nil {
+				return err
+			}
+			if ready {
+				delete(owners, owner)
+				continue
+			}
+			pending = append(pending, fmt.Sprintf("%s %s/%s to be ready", owner.kind, owner.namespace, owner.name))
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		sort.Strings(pending)
+		if !sleepCtx(ctx, d.h.pollInterval) {
+			return fmt.Errorf("waiting for %s", strings.Join(pending, ", "))
+		}
+	}
+}
+
+// podGone reports whether an evicted pod no longer exists. A pod recreated
+// under the same name, as StatefulSets do, has a new UID.
+func (d *nodeDrainer) podGone(ctx context.Context, pod corev1.Pod) (bool, error) {
+	data, err := d.h.k8s.DoK8sRequestWithContentType(ctx, d.clusterID, http.MethodGet,
+		"/api/v1/namespaces/"+url.PathEscape(pod.Namespace)+"/pods/"+url.PathEscape(pod.Name), "application/json", nil)
+	var reqErr *cluster.K8sRequestError
+	if errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusNotFound {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+	var current metav1.PartialObjectMetadata
+	if err := json.Unmarshal(data, &current); err != nil {
+		return false, fmt.Errorf("failed to decode pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+	return current.UID != pod.UID, nil
+}
+
+// ownerReady reports whether a controller has as many ready replicas as it
+// wants. A controller deleted in the meantime counts as ready.
+func (d *nodeDrainer) ownerReady(ctx context.Context, owner drainOwner) (bool, error) {
+	path := fmt.Sprintf(drainWaitResources[owner.kind], url.PathEscape(owner.namespace), url.PathEscape(owner.name))
+	data, err := d.h.k8s.DoK8sRequestWithContentType(ctx, d.clusterID, http.MethodGet, path, "application/json", nil)
+	var reqErr *cluster.K8sRequestError
+	if errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusNotFound {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get %s %s/%s: %w", owner.kind, owner.namespace, owner.name, err)
+	}
+	var obj struct {
+		Spec struct {
+			Replicas *int32 `json:"replicas"`
+		} `json:"spec"`
+		Status struct {
+			ReadyReplicas int32 `json:"readyReplicas"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return false, fmt.Errorf("failed to decode %s %s/%s: %w", owner.kind, owner.namespace, owner.name, err)
+	}
+	want := int32(1)
+	if obj.Spec.Replicas != nil {
+		want = *obj.Spec.Replicas
+	}
+	return obj.Status.ReadyReplicas >= want, nil
+}
+
+// record writes an audit entry for a node action.
+func (d *nodeDrainer) record(action, node string, details map[string]interface{}) {
+	log.Printf("node audit: %s cluster=%s node=%s", action, d.clusterID, node)
+	if d.h.auditLog == nil {
+		return
+	}
+	raw, _ := json.Marshal(details)
+	userID, serviceAccountID := audit.ActorFromContext(d.r.Context())
+	clusterID := d.clusterID
+	ctx, cancel := audit.DetachedContext(d.r.Context())
+	defer cancel()
+	if err := d.h.auditLog.Insert(ctx, userID, serviceAccountID, &clusterID, action, d.r.URL.Path, raw); err != nil {
+		log.Printf("node drain: failed to audit %s: %v", action, err)
+	}
+}
+
+// sleepCtx waits for d and reports whether ctx was still live afterwards.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,220 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+// fakeDrainCluster holds nodes and the pods scheduled to them. Evictions
+// delete the pod; blockOnce makes the first eviction of a pod fail as a
+// PodDisruptionBudget would.
+type fakeDrainCluster struct {
+	mu        sync.Mutex
+	nodes     []string
+	pods      map[string][]string // node -> pod JSON
+	blockOnce map[string]bool
+	calls     []string
+}
+
+func (c *fakeDrainCluster) DoK8sRequestWithContentType(ctx context.Context, clusterID, method, path, contentType string, body []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, method+" "+path)
+
+	switch {
+	case method == http.MethodGet && strings.HasPrefix(path, "/api/v1/nodes?"):
+		items := make([]string, len(c.nodes))
+		for i, n := range c.nodes {
+			items[i] = fmt.Sprintf(`{"metadata":{"name":%q}}`, n)
+		}
+		return []byte(`{"items":[` + strings.Join(items, ",") + `]}`), nil
+	case method == http.MethodPatch && strings.HasPrefix(path, "/api/v1/nodes/"):
+		if contentType != "application/merge-patch+json" {
+			return nil, &cluster.K8sRequestError{StatusCode: http.StatusUnsupportedMediaType, Message: contentType}
+		}
+		return []byte(`{}`), nil
+	case method == http.MethodGet && strings.HasPrefix(path, "/api/v1/pods?fieldSelector=spec.nodeName%3D"):
+		node := strings.TrimPrefix(path, "/api/v1/pods?fieldSelector=spec.nodeName%3D")
+		return []byte(`{"items":[` + strings.Join(c.pods[node], ",") + `]}`), nil
+	case method == http.MethodPost && strings.Contains(path, "/eviction"):
+		name := strings.Split(path, "/")[6]
+		if c.blockOnce[name] {
+			delete(c.blockOnce, name)
+			return nil, &cluster.K8sRequestError{StatusCode: http.StatusTooManyRequests, Message: "Cannot evict pod as it would violate the pod's disruption budget."}
+		}
+		for node, pods := range c.pods {
+			for i, p := range pods {
+				if strings.Contains(p, `"name":"`+name+`"`) {
+					c.pods[node] = append(pods[:i:i], pods[i+1:]...)
+					break
+				}
+			}
+		}
+		return []byte(`{}`), nil
+	case method == http.MethodGet && strings.Contains(path, "/replicasets/"):
+		return []byte(`{"spec":{"replicas":2},"status":{"readyReplicas":2}}`), nil
+	}
+	return nil, &cluster.K8sRequestError{StatusCode: http.StatusNotFound, Message: "not found"}
+}
+
+// drainPod returns a pod in team-a owned by the given controller kind, or by
+// none when kind is empty.
+func drainPod(name, kind string, extra string) string {
+	owner := ""
+	if kind != "" {
+		owner = fmt.Sprintf(`,"ownerReferences":[{"kind":%q,"name":"owner","controller":true}]`, kind)
+	}
+	return fmt.Sprintf(`{"metadata":{"name":%q,"namespace":"team-a","uid":%q%s},"spec":{%s},"status":{"phase":"Running"}}`, name, name+"-uid", owner, extra)
+}
+
+// nodesAccess allows writing nodes when write is set.
+type nodesAccess struct{ write bool }
+
+func (a nodesAccess) Evaluate(ctx context.Context, req rbac.Request) (bool, error) {
+	return a.write && req.Action == "write" && req.K8sResource == "nodes", nil
+}
+
+// fakeAuditLog records the actions inserted.
+type fakeAuditLog struct {
+	mu      sync.Mutex
+	actions []string
+}
+
+func (l *fakeAuditLog) Insert(ctx context.Context, userID, serviceAccountID, clusterID *string, action, resource string, details json.RawMessage) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var d map[string]interface{}
+	_ = json.Unmarshal(details, &d)
+	l.actions = append(l.actions, fmt.Sprintf("%s %v", action, d["node"]))
+	return nil
+}
+
+func postDrain(t *testing.T, c *fakeDrainCluster, authz nodesAccess, log *fakeAuditLog, body, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := mux.NewRouter()
+	h := &NodeDrainHandler{k8s: c, authz: authz, pollInterval: 0}
+	if log != nil {
+		h.SetAuditLog(log)
+	}
+	h.RegisterRoutes(r)
+	req := httptest.NewRequest(http.MethodPost, "/api/clusters/c1/nodes/drain"+query, bytes.NewBufferString(body))
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), &auth.Claims{UserID: "u1"}))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestNodeDrain_DrainsSequentiallyAndStopsOnFailure(t *testing.T) {
+	c := &fakeDrainCluster{
+		nodes: []string{"node-c", "node-a", "node-b"},
+		pods: map[string][]string{
+			"node-a": {drainPod("web-1", "ReplicaSet", ""), drainPod("agent", "DaemonSet", "")},
+			"node-b": {drainPod("bare", "", "")},
+			"node-c": {drainPod("web-2", "ReplicaSet", "")},
+		},
+		blockOnce: map[string]bool{"web-1": true},
+	}
+	log := &fakeAuditLog{}
+	w := postDrain(t, c, nodesAccess{write: true}, log, `{"labelSelector":"pool=blue"}`, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp nodeDrainResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Total != 3 || resp.Drained != 1 || resp.Failed != 1 || resp.Skipped != 1 {
+		t.Fatalf("unexpected counters %+v", resp)
+	}
+	a, b, cc := resp.Nodes[0], resp.Nodes[1], resp.Nodes[2]
+	if a.Node != "node-a" || a.Status != drainStatusDrained || len(a.Evicted) != 1 || a.Evicted[0].Name != "web-1" {
+		t.Errorf("expected node-a drained of web-1 only, got %+v", a)
+	}
+	if b.Status != drainStatusFailed || !strings.Contains(b.Error, "not managed by a controller") {
+		t.Errorf("expected node-b to fail on the unmanaged pod, got %+v", b)
+	}
+	if cc.Node != "node-c" || cc.Status != drainStatusSkipped {
+		t.Errorf("expected node-c to be skipped, got %+v", cc)
+	}
+	for _, call := range c.calls {
+		if strings.Contains(call, "node-c") {
+			t.Errorf("expected node-c to be left untouched, got %s", call)
+		}
+	}
+
+	want := []string{"node.cordon node-a", "node.drain node-a", "node.cordon node-b", "node.drain node-b"}
+	if strings.Join(log.actions, ",") != strings.Join(want, ",") {
+		t.Errorf("expected audit entries %v, got %v", want, log.actions)
+	}
+}
+
+func TestNodeDrain_WithoutAuditLog(t *testing.T) {
+	c := &fakeDrainCluster{
+		nodes: []string{"node-a"},
+		pods:  map[string][]string{"node-a": {drainPod("web-1", "ReplicaSet", "")}},
+	}
+	w := postDrain(t, c, nodesAccess{write: true}, nil, `{"labelSelector":"pool=blue"}`, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp nodeDrainResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Drained != 1 || resp.Nodes[0].Status != drainStatusDrained {
+		t.Errorf("expected node-a drained without an audit log, got %+v", resp)
+	}
+}
+
+func TestNodeDrain_DryRunAndStream(t *testing.T) {
+	c := &fakeDrainCluster{
+		nodes: []string{"node-a"},
+		pods:  map[string][]string{"node-a": {drainPod("web-1", "ReplicaSet", `"volumes":[{"name":"tmp","emptyDir":{}}]`)}},
+	}
+	log := &fakeAuditLog{}
+	w := postDrain(t, c, nodesAccess{write: true}, log, `{"labelSelector":"pool=blue","dryRun":true,"deleteEmptyDirData":true}`, "?stream=true")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %v", w.Code, w.Header())
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"phase":"evicted"`) || !strings.Contains(body, "event: done") {
+		t.Errorf("expected progress and done events, got %s", body)
+	}
+	for _, call := range c.calls {
+		if strings.HasPrefix(call, http.MethodPatch) || (strings.Contains(call, "/eviction") && !strings.Contains(call, "dryRun=All")) {
+			t.Errorf("expected a dry run to change nothing, got %s", call)
+		}
+	}
+	if len(log.actions) != 0 {
+		t.Errorf("expected no audit entries for a dry run, got %v", log.actions)
+	}
+}
+
+func TestNodeDrain_Validation(t *testing.T) {
+	c := &fakeDrainCluster{nodes: []string{"node-a"}}
+	if w := postDrain(t, c, nodesAccess{}, &fakeAuditLog{}, `{"labelSelector":"pool=blue"}`, ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 without nodes write, got %d", w.Code)
+	}
+	for _, body := range []string{`{}`, `{"labelSelector":"!!"}`, `{"labelSelector":"pool=blue","timeoutSeconds":-1}`} {
+		if w := postDrain(t, c, nodesAccess{write: true}, &fakeAuditLog{}, body, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+	if len(c.calls) != 0 {
+		t.Errorf("expected no cluster requests, got %v", c.calls)
+	}
+}
@@ -0,0 +1,405 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+const (
+	defaultOwnerTreeDepth = 5
+	maxOwnerTreeDepth     = 10
+)
+
+// ownerTreeChildResources are the resource types searched for children. They
+// cover the objects built-in controllers create on behalf of their owners.
+var ownerTreeChildResources = []schema.GroupVersionResource{
+	{Group: "apps", Version: "v1", Resource: "deployments"},
+	{Group: "apps", Version: "v1", Resource: "replicasets"},
+	{Group: "apps", Version: "v1", Resource: "statefulsets"},
+	{Group: "apps", Version: "v1", Resource: "daemonsets"},
+	{Group: "apps", Version: "v1", Resource: "controllerrevisions"},
+	{Group: "batch", Version: "v1", Resource: "cronjobs"},
+	{Group: "batch", Version: "v1", Resource: "jobs"},
+	{Group: "", Version: "v1", Resource: "pods"},
+	{Group: "", Version: "v1", Resource: "services"},
+	{Group: "", Version: "v1", Resource: "persistentvolumeclaims"},
+	{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"},
+}
+
+// OwnerTreeHandler shows how an object relates to others through
+// ownerReferences: the chain of owners up to the root, e.g. Pod → ReplicaSet
+// → Deployment, and the objects it owns in turn.
+type OwnerTreeHandler struct {
+	clusterMgr *cluster.Manager
+	rbacEngine *rbac.Engine
+	discovery  *DiscoveryCache
+}
+
+// NewOwnerTreeHandler creates a new OwnerTreeHandler. Discovery maps owner
+// kinds to resources, so owners cannot be resolved without it.
+func NewOwnerTreeHandler(cm *cluster.Manager, engine *rbac.Engine, discovery *DiscoveryCache) *OwnerTreeHandler {
+	return &OwnerTreeHandler{clusterMgr: cm, rbacEngine: engine, discovery: discovery}
+}
+
+// RegisterRoutes wires the owner tree endpoint.
+func (h *OwnerTreeHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clusters/{clusterID}/owner-tree/{group}/{version}/{resource}/{name}", h.Get).Methods(http.MethodGet)
+}
+
+// ownerNode is one object in an owner tree. Owners lead towards the root
+// owner and Children away from it; the requested object has both.
+type ownerNode struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	UID        string `json:"uid"`
+	// Controller is set when the edge to the node's dependent or owner is a
+	// controller reference.
+	Controller bool         `json:"controller,omitempty"`
+	Owners     []*ownerNode `json:"owners,omitempty"`
+	Children   []*ownerNode `json:"children,omitempty"`
+	// Truncated is set when the depth limit stopped the walk at this node.
+	Truncated bool `json:"truncated,omitempty"`
+	// Error tells why an owner could not be shown, e.g. because it no longer
+	// exists or the caller may not read it.
+	Error string `json:"error,omitempty"`
+}
+
+// ownerTreeResponse is the owner tree of one object.
+type ownerTreeResponse struct {
+	ClusterID string     `json:"clusterId"`
+	Depth     int        `json:"depth"`
+	Root      *ownerNode `json:"root"`
+}
+
+// ownerResolver fetches the object an owner reference points at, given the
+// namespace of the dependent. It returns nil and no error when the owner
+// does not exist.
+type ownerResolver func(ctx context.Context, dependentNamespace string, ref metav1.OwnerReference) (*unstructured.Unstructured, error)
+
+// Get returns the owner tree of an object.
+//
+// Query parameters:
+//   - namespace: the object's namespace (ignored for cluster-scoped types)
+//   - depth: how many levels to walk up and down (default 5, max 10)
+func (h *OwnerTreeHandler) Get(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	clusterID := vars["clusterID"]
+	name := vars["name"]
+	gvr := gvrFromVars(vars)
+	namespace := r.URL.Query().Get("namespace")
+	if !validatePathSegments(w, namespace, name) {
+		return
+	}
+	if !validate.Segment(gvr.Group) || !validate.Segment(gvr.Version) || !validate.Segment(gvr.Resource) {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid group, version, or resource")
+		return
+	}
+	depth := defaultOwnerTreeDepth
+	if v := r.URL.Query().Get("depth"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			httputil.WriteError(w, http.StatusBadRequest, "invalid depth")
+			return
+		}
+		depth = n
+	}
+	if depth > maxOwnerTreeDepth {
+		depth = maxOwnerTreeDepth
+	}
+
+	res, found, err := h.discovery.Lookup(r.Context(), clusterID, gvr)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("discovery failed: %v", err))
+		return
+	}
+	if !found {
+		httputil.WriteError(w, http.StatusNotFound, fmt.Sprintf("resource type %s is not served by this cluster", gvr.String()))
+		return
+	}
+	if !res.Namespaced {
+		namespace = ""
+	}
+
+	access := newReadAccess(h.rbacEngine, claims.UserID, clusterID)
+	allowed, err := access.allowed(r.Context(), gvr, namespace)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+		return
+	}
+	if !allowed {
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+
+	obj, err := h.getObject(r.Context(), clusterID, gvr, namespace, name)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	if obj == nil {
+		httputil.WriteError(w, http.StatusNotFound, fmt.Sprintf("%s %q not found", gvr.Resource, name))
+		return
+	}
+
+	root := nodeFor(obj, false)
+	root.Owners = walkOwners(r.Context(), obj, h.ownerResolver(clusterID, access), depth, map[types.UID]bool{obj.GetUID(): true})
+
+	candidates, err := h.listChildCandidates(r.Context(), clusterID, namespace, access)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+		return
+	}
+	root.Children = walkChildren(obj.GetUID(), indexByOwner(candidates), depth, map[types.UID]bool{obj.GetUID(): true})
+
+	httputil.WriteJSON(w, http.StatusOK, ownerTreeResponse{ClusterID: clusterID, Depth: depth, Root: root})
+}
+
+// getObject fetches one object by name, or nil if it does not exist. It lists
+// with a name field selector so it works through the same backends as bulk
+// operations, for both direct and agent clusters.
+func (h *OwnerTreeHandler) getObject(ctx context.Context, clusterID string, gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	backend, err := resourceBackendFor(h.clusterMgr, clusterID, gvr)
+	if err != nil {
+		return nil, err
+	}
+	items, err := backend.list(ctx, namespace, "", "metadata.name="+name)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return &items[0], nil
+}
+
+// ownerResolver resolves owner references through discovery. A namespaced
+// owner lives in its dependent's namespace; a cluster-scoped one has none.
+// Owners the caller may not read are reported as errors, not fetched.
+func (h *OwnerTreeHandler) ownerResolver(clusterID string, access *readAccess) ownerResolver {
+	return func(ctx context.Context, dependentNamespace string, ref metav1.OwnerReference) (*unstructured.Unstructured, error) {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid apiVersion %q", ref.APIVersion)
+		}
+		res, found, err := h.discovery.LookupKind(ctx, clusterID, gv.WithKind(ref.Kind))
+		if err != nil {
+			return nil, fmt.Errorf("discovery failed: %v", err)
+		}
+		if !found {
+			return nil, fmt.Errorf("kind %s is not served by this cluster", ref.Kind)
+		}
+		gvr := gv.WithResource(res.Resource)
+		namespace := ""
+		if res.Namespaced {
+			namespace = dependentNamespace
+		}
+
+		allowed, err := access.allowed(ctx, gvr, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("permission check failed")
+		}
+		if !allowed {
+			return nil, fmt.Errorf("insufficient permissions")
+		}
+
+		obj, err := h.getObject(ctx, clusterID, gvr, namespace, ref.Name)
+		if err != nil || obj == nil || obj.GetUID() != ref.UID {
+			// A different UID is a new object that reuses the owner's name.
+			return nil, err
+		}
+		return obj, nil
+	}
+}
+
+// listChildCandidates lists the objects that may be children, in the given
+// namespace or, for a cluster-scoped root, in all namespaces. Resource types
+// the cluster does not serve or that fail to list are skipped; objects in
+// namespaces the caller may not read are dropped.
+func (h *OwnerTreeHandler) listChildCandidates(ctx context.Context, clusterID, namespace string, access *readAccess) ([]unstructured.Unstructured, error) {
+	var candidates []unstructured.Unstructured
+	for _, gvr := range ownerTreeChildResources {
+		if _, found, err := h.discovery.Lookup(ctx, clusterID, gvr); err != nil || !found {
+			continue
+		}
+		if namespace != "" {
+			allowed, err := access.allowed(ctx, gvr, namespace)
+			if err != nil {
+				return nil, err
+			}
+			if !allowed {
+				continue
+			}
+		}
+		backend, err := resourceBackendFor(h.clusterMgr, clusterID, gvr)
+		if err != nil {
+			continue
+		}
+		items, err := backend.list(ctx, namespace, "", "")
+		if err != nil {
+			continue
+		}
+		for _, item := range items {
+			allowed, err := access.allowed(ctx, gvr, item.GetNamespace())
+			if err != nil {
+				return nil, err
+			}
+			if allowed {
+				candidates = append(candidates, item)
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// walkOwners builds the owner chain of obj, following every owner reference
+// up to depth levels. seen guards against reference cycles.
+func walkOwners(ctx context.Context, obj *unstructured.Unstructured, resolve ownerResolver, depth int, seen map[types.UID]bool) []*ownerNode {
+	refs := obj.GetOwnerReferences()
+	if len(refs) == 0 {
+		return nil
+	}
+	nodes := make([]*ownerNode, 0, len(refs))
+	for _, ref := range refs {
+		node := &ownerNode{
+			APIVersion: ref.APIVersion,
+			Kind:       ref.Kind,
+			Name:       ref.Name,
+			UID:        string(ref.UID),
+			Controller: ref.Controller != nil && *ref.Controller,
+		}
+		nodes = append(nodes, node)
+		if seen[ref.UID] {
+			continue
+		}
+
+		owner, err := resolve(ctx, obj.GetNamespace(), ref)
+		switch {
+		case err != nil:
+			node.Error = err.Error()
+			continue
+		case owner == nil:
+			node.Error = "not found"
+			continue
+		}
+		node.Namespace = owner.GetNamespace()
+		if depth <= 1 {
+			node.Truncated = len(owner.GetOwnerReferences()) > 0
+			continue
+		}
+		seen[ref.UID] = true
+		node.Owners = walkOwners(ctx, owner, resolve, depth-1, seen)
+	}
+	return nodes
+}
+
+// indexByOwner groups objects by the UIDs of their owners.
+func indexByOwner(items []unstructured.Unstructured) map[types.UID][]*unstructured.Unstructured {
+	index := make(map[types.UID][]*unstructured.Unstructured)
+	for i := range items {
+		for _, ref := range items[i].GetOwnerReferences() {
+			index[ref.UID] = append(index[ref.UID], &items[i])
+		}
+	}
+	return index
+}
+
+// walkChildren builds the tree of objects owned by uid, down to depth levels.
+func walkChildren(uid types.UID, index map[types.UID][]*unstructured.Unstructured, depth int, seen map[types.UID]bool) []*ownerNode {
+	children := index[uid]
+	if len(children) == 0 {
+		return nil
+	}
+	nodes := make([]*ownerNode, 0, len(children))
+	for _, child := range children {
+		if seen[child.GetUID()] {
+			continue
+		}
+		node := nodeFor(child, isControlledBy(child, uid))
+		nodes = append(nodes, node)
+		if depth <= 1 {
+			node.Truncated = len(index[child.GetUID()]) > 0
+			continue
+		}
+		seen[child.GetUID()] = true
+		node.Children = walkChildren(child.GetUID(), index, depth-1, seen)
+	}
+	return nodes
+}
+
+// nodeFor describes an object as an owner tree node.
+func nodeFor(obj *unstructured.Unstructured, controller bool) *ownerNode {
+	return &ownerNode{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+		UID:        string(obj.GetUID()),
+		Controller: controller,
+	}
+}
+
+// isControlledBy reports whether obj's controller reference points at uid.
+func isControlledBy(obj *unstructured.Unstructured, uid types.UID) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == uid && ref.Controller != nil && *ref.Controller {
+			return true
+		}
+	}
+	return false
+}
+
+// readAccess checks read permission on Kubernetes resources for one user and
+// cluster, remembering each answer for the duration of a request.
+type readAccess struct {
+	engine    *rbac.Engine
+	userID    string
+	clusterID string
+	cache     map[string]bool
+}
+
+func newReadAccess(engine *rbac.Engine, userID, clusterID string) *readAccess {
+	return &readAccess{engine: engine, userID: userID, clusterID: clusterID, cache: make(map[string]bool)}
+}
+
+func (a *readAccess) allowed(ctx context.Context, gvr schema.GroupVersionResource, namespace string) (bool, error) {
+	k8sResource := rbac.QualifiedResource(gvr.Group, gvr.Resource)
+	key := k8sResource + "/" + namespace
+	if allowed, ok := a.cache[key]; ok {
+		return allowed, nil
+	}
+	allowed, err := a.engine.Evaluate(ctx, rbac.Request{
+		UserID:      a.userID,
+		Action:      "read",
+		Resource:    rbac.ResourceCategory(gvr.Resource),
+		ClusterID:   a.clusterID,
+		Namespace:   namespace,
+		K8sResource: k8sResource,
+	})
+	if err != nil {
+		return false, err
+	}
+	a.cache[key] = allowed
+	return allowed, nil
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func testOwned(apiVersion, kind, namespace, name, uid string, owners ...metav1.OwnerReference) unstructured.Unstructured {
+	obj := unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetUID(types.UID(uid))
+	obj.SetOwnerReferences(owners)
+	return obj
+}
+
+func ownerRef(obj unstructured.Unstructured, controller bool) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		UID:        obj.GetUID(),
+		Controller: &controller,
+	}
+}
+
+// fakeResolver resolves owner references by UID from a fixed set of objects.
+func fakeResolver(objs ...unstructured.Unstructured) ownerResolver {
+	byUID := make(map[types.UID]unstructured.Unstructured, len(objs))
+	for _, o := range objs {
+		byUID[o.GetUID()] = o
+	}
+	return func(_ context.Context, _ string, ref metav1.OwnerReference) (*unstructured.Unstructured, error) {
+		if ref.Kind == "Secret" {
+			return nil, errors.New("insufficient permissions")
+		}
+		o, ok := byUID[ref.UID]
+		if !ok {
+			return nil, nil
+		}
+		return &o, nil
+	}
+}
+
+func TestWalkOwners(t *testing.T) {
+	deploy := testOwned("apps/v1", "Deployment", "default", "web", "d1")
+	rs := testOwned("apps/v1", "ReplicaSet", "default", "web-abc", "r1", ownerRef(deploy, true))
+	pod := testOwned("v1", "Pod", "default", "web-abc-x", "p1", ownerRef(rs, true))
+
+	owners := walkOwners(context.Background(), &pod, fakeResolver(deploy, rs), 5, map[types.UID]bool{"p1": true})
+	if len(owners) != 1 || owners[0].Kind != "ReplicaSet" || !owners[0].Controller {
+		t.Fatalf("expected controlling ReplicaSet owner, got %+v", owners)
+	}
+	if owners[0].Namespace != "default" {
+		t.Errorf("expected owner namespace default, got %q", owners[0].Namespace)
+	}
+	if len(owners[0].Owners) != 1 || owners[0].Owners[0].Name != "web" {
+		t.Fatalf("expected Deployment above ReplicaSet, got %+v", owners[0].Owners)
+	}
+	if owners[0].Owners[0].Owners != nil {
+		t.Errorf("expected Deployment to be the root, got %+v", owners[0].Owners[0].Owners)
+	}
+}
+
+func TestWalkOwners_DepthAndErrors(t *testing.T) {
+	deploy := testOwned("apps/v1", "Deployment", "default", "web", "d1")
+	rs := testOwned("apps/v1", "ReplicaSet", "default", "web-abc", "r1", ownerRef(deploy, true))
+	gone := testOwned("v1", "ConfigMap", "default", "gone", "g1")
+	secret := testOwned("v1", "Secret", "default", "s", "s1")
+	pod := testOwned("v1", "Pod", "default", "p", "p1", ownerRef(rs, true), ownerRef(gone, false), ownerRef(secret, false))
+
+	owners := walkOwners(context.Background(), &pod, fakeResolver(deploy, rs), 1, map[types.UID]bool{"p1": true})
+	if len(owners) != 3 {
+		t.Fatalf("expected 3 owners, got %d", len(owners))
+	}
+	if !owners[0].Truncated || owners[0].Owners != nil {
+		t.Errorf("expected ReplicaSet to be truncated at depth 1, got %+v", owners[0])
+	}
+	if owners[1].Error != "not found" {
+		t.Errorf("expected missing owner to report not found, got %q", owners[1].Error)
+	}
+	if owners[2].Error != "insufficient permissions" {
+		t.Errorf("expected unreadable owner to report the error, got %q", owners[2].Error)
+	}
+}
+
+func TestWalkOwners_Cycle(t *testing.T) {
+	a := testOwned("example.com/v1", "Widget", "default", "a", "a1")
+	b := testOwned("example.com/v1", "Widget", "default", "b", "b1", ownerRef(a, false))
+	a.SetOwnerReferences([]metav1.OwnerReference{ownerRef(b, false)})
+
+	owners := walkOwners(context.Background(), &a, fakeResolver(a, b), 10, map[types.UID]bool{"a1": true})
+	if len(owners) != 1 || owners[0].Name != "b" {
+		t.Fatalf("expected b as owner of a, got %+v", owners)
+	}
+	if len(owners[0].Owners) != 1 || owners[0].Owners[0].Owners != nil {
+		t.Errorf("expected the cycle back to a to stop, got %+v", owners[0].Owners)
+	}
+}
+
+func TestWalkChildren(t *testing.T) {
+	deploy := testOwned("apps/v1", "Deployment", "default", "web", "d1")
+	rs := testOwned("apps/v1", "ReplicaSet", "default", "web-abc", "r1", ownerRef(deploy, true))
+	pod1 := testOwned("v1", "Pod", "default", "web-abc-1", "p1", ownerRef(rs, true))
+	pod2 := testOwned("v1", "Pod", "default", "web-abc-2", "p2", ownerRef(rs, true))
+	other := testOwned("v1", "Pod", "default", "other", "o1")
+
+	index := indexByOwner([]unstructured.Unstructured{rs, pod1, pod2, other})
+
+	children := walkChildren("d1", index, 5, map[types.UID]bool{"d1": true})
+	if len(children) != 1 || children[0].Kind != "ReplicaSet" || !children[0].Controller {
+		t.Fatalf("expected controlled ReplicaSet child, got %+v", children)
+	}
+	if len(children[0].Children) != 2 {
+		t.Fatalf("expected 2 pods under the ReplicaSet, got %+v", children[0].Children)
+	}
+
+	children = walkChildren("d1", index, 1, map[types.UID]bool{"d1": true})
+	if !children[0].Truncated || children[0].Children != nil {
+		t.Errorf("expected ReplicaSet to be truncated at depth 1, got %+v", children[0])
+	}
+}
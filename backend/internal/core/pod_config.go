@@ -0,0 +1,634 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/redact"
+)
+
+// Kinds of the objects a pod takes configuration from.
+const (
+	configKindConfigMap = "ConfigMap"
+	configKindSecret    = "Secret"
+)
+
+// configValue is one resolved configuration value. Binary values and
+// redacted ones carry no value.
+type configValue struct {
+	Value    string `json:"value,omitempty"`
+	Binary   bool   `json:"binary,omitempty"`
+	Redacted bool   `json:"redacted,omitempty"`
+}
+
+// configRef names a key of a ConfigMap or Secret.
+type configRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	Key  string `json:"key,omitempty"`
+}
+
+// podEnvVar is one environment variable of a container as the kubelet sets
+// it. Values are as written: $(VAR) references are not expanded.
+type podEnvVar struct {
+	Name string `json:"name"`
+	configValue
+	// From is set for values taken from a ConfigMap or Secret.
+	From *configRef `json:"from,omitempty"`
+	// FieldPath is set for values taken from the pod's own fields.
+	FieldPath string `json:"fieldPath,omitempty"`
+	// Resource is set for values taken from the container's resources,
+	// which are not resolved.
+	Resource string `json:"resource,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// mountedFile is one file a ConfigMap or Secret volume puts in a container.
+type mountedFile struct {
+	Path string `json:"path"`
+	configValue
+	From configRef `json:"from"`
+	// Error is set when the key is missing and the volume would fail to
+	// mount.
+	Error string `json:"error,omitempty"`
+}
+
+// podConfigMount is one volume mount of a container. Files are only listed
+// for ConfigMap, Secret and projected volumes.
+type podConfigMount struct {
+	Volume    string        `json:"volume"`
+	Type      string        `json:"type"`
+	MountPath string        `json:"mountPath"`
+	SubPath   string        `json:"subPath,omitempty"`
+	ReadOnly  bool          `json:"readOnly"`
+	Files     []mountedFile `json:"files,omitempty"`
+	// Errors says why some of the volume's sources could not be listed.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// containerConfig is the effective configuration of one container.
+type containerConfig struct {
+	Name   string           `json:"name"`
+	Init   bool             `json:"init,omitempty"`
+	Env    []podEnvVar      `json:"env"`
+	Mounts []podConfigMount `json:"mounts"`
+}
+
+// configSource is a ConfigMap or Secret the pod references.
+type configSource struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Optional is set when every reference to the object is optional, so
+	// the pod starts without it.
+	Optional bool     `json:"optional"`
+	Found    bool     `json:"found"`
+	Keys     []string `json:"keys"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// podConfigResponse is the effective configuration of a pod.
+type podConfigResponse struct {
+	ClusterID string `json:"clusterId"`
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	// SecretsRevealed is set when Secret values are included, i.e. the
+	// caller holds secrets:reveal.
+	SecretsRevealed bool              `json:"secretsRevealed"`
+	Containers      []containerConfig `json:"containers"`
+	Sources         []configSource    `json:"sources"`
+}
+
+// PodConfigHandler resolves the ConfigMaps and Secrets a pod consumes
+// through env, envFrom and volumes into the configuration each container
+// actually sees, so it does not have to be pieced together from the pod spec
+// and the objects it references.
+type PodConfigHandler struct {
+	k8s      rbac.K8sRequester
+	authz    rbac.Evaluator
+	redactor *redact.Redactor
+}
+
+// NewPodConfigHandler creates a new PodConfigHandler.
+func NewPodConfigHandler(cm *cluster.Manager, engine *rbac.Engine) *PodConfigHandler {
+	return &PodConfigHandler{k8s: cm, authz: engine}
+}
+
+// SetRedactor masks sensitive ConfigMap keys for users without
+// secrets:reveal, as the response redaction middleware does for the
+// ConfigMaps themselves. Secret values are withheld from those users
+// whether or not a redactor is set.
+func (h *PodConfigHandler) SetRedactor(r *redact.Redactor) {
+	h.redactor = r
+}
+
+// RegisterRoutes wires the pod config endpoint.
+func (h *PodConfigHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clusters/{clusterID}/namespaces/{namespace}/pods/{pod}/config", h.Get).Methods(http.MethodGet)
+}
+
+// Get returns the effective configuration of a pod. The caller needs read
+// access to pods in the namespace. ConfigMaps and Secrets they may not read
+// are reported with an error instead of their values, and Secret values are
+// redacted unless they hold secrets:reveal on the cluster.
+func (h *PodConfigHandler) Get(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	clusterID, namespace, name := vars["clusterID"], vars["namespace"], vars["pod"]
+	if !validatePathSegments(w, namespace, name) {
+		return
+	}
+
+	canRead := make(map[string]bool, 3)
+	for kind, resource := range map[string]string{"Pod": "pods", configKindConfigMap: "configmaps", configKindSecret: "secrets"} {
+		allowed, err := h.authz.Evaluate(r.Context(), rbac.Request{
+			UserID:      claims.UserID,
+			Action:      "read",
+			Resource:    rbac.ResourceCategory(resource),
+			ClusterID:   clusterID,
+			Namespace:   namespace,
+			K8sResource: resource,
+		})
+		if err != nil {
+			httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+			return
+		}
+		canRead[kind] = allowed
+	}
+	if !canRead["Pod"] {
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	reveal, err := h.authz.Evaluate(r.Context(), rbac.Request{
+		UserID:    claims.UserID,
+		Action:    redact.RevealAction,
+		Resource:  redact.RevealResource,
+		ClusterID: clusterID,
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+		return
+	}
+
+	data, err := h.k8s.DoK8sRequest(r.Context(), clusterID, http.MethodGet, "/api/v1/namespaces/"+namespace+"/pods/"+name, nil)
+	if err != nil {
+		writeClusterError(w, err, "failed to get pod")
+		return
+	}
+	var pod corev1.Pod
+	if err := json.Unmarshal(data, &pod); err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, "failed to decode pod")
+		return
+	}
+
+	res := &configResolver{
+		ctx:       r.Context(),
+		k8s:       h.k8s,
+		clusterID: clusterID,
+		namespace: namespace,
+		canRead:   canRead,
+		reveal:    reveal,
+		redactor:  h.redactor,
+		sources:   make(map[configRef]*resolvedSource),
+	}
+	resp := podConfigResponse{ClusterID: clusterID, Namespace: namespace, Pod: name, SecretsRevealed: reveal}
+	resp.Containers = res.containers(&pod)
+	resp.Sources = res.sourceList()
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+// resolvedSource is a fetched ConfigMap or Secret.
+type resolvedSource struct {
+	configSource
+	values map[string]configValue
+}
+
+// configResolver fetches the ConfigMaps and Secrets of one pod, each once,
+// and resolves references to their keys.
+type configResolver struct {
+	ctx       context.Context
+	k8s       rbac.K8sRequester
+	clusterID string
+	namespace string
+	// canRead maps a kind to whether the caller may read it.
+	canRead  map[string]bool
+	reveal   bool
+	redactor *redact.Redactor
+	sources  map[configRef]*resolvedSource
+	order    []configRef
+}
+
+// containers resolves the configuration of the pod's init containers and
+// containers, in that order.
+func (c *configResolver) containers(pod *corev1.Pod) []containerConfig {
+	volumes := make(map[string]*corev1.Volume, len(pod.Spec.Volumes))
+	for i := range pod.Spec.Volumes {
+		volumes[pod.Spec.Volumes[i].Name] = &pod.Spec.Volumes[i]
+	}
+	configs := make([]containerConfig, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	for i := range pod.Spec.InitContainers {
+		cfg := c.container(pod, &pod.Spec.InitContainers[i], volumes)
+		cfg.Init = true
+		configs = append(configs, cfg)
+	}
+	for i := range pod.Spec.Containers {
+		configs = append(configs, c.container(pod, &pod.Spec.Containers[i], volumes))
+	}
+	return configs
+}
+
+// container resolves the environment and mounts of one container.
+func (c *configResolver) container(pod *corev1.Pod, ctr *corev1.Container, volumes map[string]*corev1.Volume) containerConfig {
+	cfg := containerConfig{Name: ctr.Name, Env: c.env(pod, ctr), Mounts: make([]podConfigMount, 0, len(ctr.VolumeMounts))}
+	for _, vm := range ctr.VolumeMounts {
+		mount := podConfigMount{Volume: vm.Name, Type: "unknown", MountPath: vm.MountPath, SubPath: vm.SubPath, ReadOnly: vm.ReadOnly}
+		if vol, ok := volumes[vm.Name]; ok {
+			mount.Type = volumeType(vol)
+			files, errs := c.volumeFiles(vol)
+			mount.Files = mountFiles(files, vm.MountPath, vm.SubPath)
+			mount.Errors = errs
+		}
+		cfg.Mounts = append(cfg.Mounts, mount)
+	}
+	return cfg
+}
+
+// env returns a container's environment as the kubelet builds it: envFrom
+// sources in order, then env, later definitions of a name replacing
+// earlier ones. Each name keeps the position it was first defined at.
+func (c *configResolver) env(pod *corev1.Pod, ctr *corev1.Container) []podEnvVar {
+	env := make([]podEnvVar, 0, len(ctr.Env))
+	index := make(map[string]int)
+	set := func(v podEnvVar) {
+		if i, ok := index[v.Name]; ok {
+			env[i] = v
+			return
+		}
+		index[v.Name] = len(env)
+		env = append(env, v)
+	}
+
+	for _, from := range ctr.EnvFrom {
+		var ref configRef
+		var optional bool
+		switch {
+		case from.ConfigMapRef != nil:
+			ref = configRef{Kind: configKindConfigMap, Name: from.ConfigMapRef.Name}
+			optional = isOptional(from.ConfigMapRef.Optional)
+		case from.SecretRef != nil:
+			ref = configRef{Kind: configKindSecret, Name: from.SecretRef.Name}
+			optional = isOptional(from.SecretRef.Optional)
+		default:
+			continue
+		}
+		// A source that cannot be read is reported once, on the source.
+		src := c.source(ref, optional)
+		if !src.Found {
+			continue
+		}
+		for _, key := range src.Keys {
+			set(podEnvVar{Name: from.Prefix + key, configValue: src.values[key], From: &configRef{Kind: ref.Kind, Name: ref.Name, Key: key}})
+		}
+	}
+
+	for _, e := range ctr.Env {
+		v := podEnvVar{Name: e.Name}
+		switch from := e.ValueFrom; {
+		case from == nil:
+			v.Value = e.Value
+		case from.ConfigMapKeyRef != nil:
+			v.From = &configRef{Kind: configKindConfigMap, Name: from.ConfigMapKeyRef.Name, Key: from.ConfigMapKeyRef.Key}
+			if !c.resolveEnv(&v, isOptional(from.ConfigMapKeyRef.Optional)) {
+				continue
+			}
+		case from.SecretKeyRef != nil:
+			v.From = &configRef{Kind: configKindSecret, Name: from.SecretKeyRef.Name, Key: from.SecretKeyRef.Key}
+			if !c.resolveEnv(&v, isOptional(from.SecretKeyRef.Optional)) {
+				continue
+			}
+		case from.FieldRef != nil:
+			v.FieldPath = from.FieldRef.FieldPath
+			if value, ok := podFieldValue(pod, v.FieldPath); ok {
+				v.Value = value
+			} else {
+				v.Error = "field is only resolved by the kubelet"
+			}
+		case from.ResourceFieldRef != nil:
+			v.Resource = from.ResourceFieldRef.Resource
+		}
+		set(v)
+	}
+	return env
+}
+
+// resolveEnv fills in v from the key v.From names. It returns false when the
+// kubelet would leave the variable unset, i.e. an optional reference to a
+// missing object or key.
+func (c *configResolver) resolveEnv(v *podEnvVar, optional bool) bool {
+	value, msg, ok := c.lookup(*v.From, optional)
+	if !ok && msg == "" {
+		return false
+	}
+	v.configValue = value
+	v.Error = msg
+	return true
+}
+
+// volumeFiles returns the files a ConfigMap, Secret or projected volume
+// holds, with paths relative to the volume, and why any of its sources
+// could not be listed.
+func (c *configResolver) volumeFiles(vol *corev1.Volume) ([]mountedFile, []string) {
+	var files []mountedFile
+	var errs []string
+	add := func(ref configRef, optional bool, items []corev1.KeyToPath) {
+		f, err := c.sourceFiles(ref, optional, items)
+		files = append(files, f...)
+		if err != "" {
+			errs = append(errs, err)
+		}
+	}
+	switch {
+	case vol.ConfigMap != nil:
+		add(configRef{Kind: configKindConfigMap, Name: vol.ConfigMap.Name}, isOptional(vol.ConfigMap.Optional), vol.ConfigMap.Items)
+	case vol.Secret != nil:
+		add(configRef{Kind: configKindSecret, Name: vol.Secret.SecretName}, isOptional(vol.Secret.Optional), vol.Secret.Items)
+	case vol.Projected != nil:
+		for _, p := range vol.Projected.Sources {
+			switch {
+			case p.ConfigMap != nil:
+				add(configRef{Kind: configKindConfigMap, Name: p.ConfigMap.Name}, isOptional(p.ConfigMap.Optional), p.ConfigMap.Items)
+			case p.Secret != nil:
+				add(configRef{Kind: configKindSecret, Name: p.Secret.Name}, isOptional(p.Secret.Optional), p.Secret.Items)
+			}
+		}
+	}
+	return files, errs
+}
+
+// sourceFiles returns the files one ConfigMap or Secret projects: the listed
+// items, or every key named after itself.
+func (c *configResolver) sourceFiles(ref configRef, optional bool, items []corev1.KeyToPath) ([]mountedFile, string) {
+	src := c.source(ref, optional)
+	if src.Error != "" {
+		return nil, fmt.Sprintf("%s %s: %s", ref.Kind, ref.Name, src.Error)
+	}
+	if !src.Found {
+		if optional {
+			return nil, ""
+		}
+		return nil, fmt.Sprintf("%s %s not found", ref.Kind, ref.Name)
+	}
+	if len(items) == 0 {
+		files := make([]mountedFile, 0, len(src.Keys))
+		for _, key := range src.Keys {
+			files = append(files, mountedFile{Path: key, configValue: src.values[key], From: configRef{Kind: ref.Kind, Name: ref.Name, Key: key}})
+		}
+		return files, ""
+	}
+	files := make([]mountedFile, 0, len(items))
+	for _, item := range items {
+		f := mountedFile{Path: item.Path, From: configRef{Kind: ref.Kind, Name: ref.Name, Key: item.Key}}
+		value, msg, ok := c.lookup(f.From, optional)
+		if !ok && msg == "" {
+			continue
+		}
+		f.configValue = value
+		f.Error = msg
+		files = append(files, f)
+	}
+	return files, ""
+}
+
+// lookup returns the value of ref's key. When it is unavailable, ok is false
+// and msg says why; msg is empty when the reference is optional and the
+// kubelet skips it.
+func (c *configResolver) lookup(ref configRef, optional bool) (value configValue, msg string, ok bool) {
+	src := c.source(configRef{Kind: ref.Kind, Name: ref.Name}, optional)
+	switch {
+	case src.Error != "":
+		return configValue{}, src.Error, false
+	case !src.Found && optional:
+		return configValue{}, "", false
+	case !src.Found:
+		return configValue{}, fmt.Sprintf("%s %s not found", ref.Kind, ref.Name), false
+	}
+	value, found := src.values[ref.Key]
+	switch {
+	case found:
+		return value, "", true
+	case optional:
+		return configValue{}, "", false
+	}
+	return configValue{}, fmt.Sprintf("key %q not found in %s %s", ref.Key, ref.Kind, ref.Name), false
+}
+
+// source returns a ConfigMap or Secret, fetching it on first use. The
+// object is optional while every reference to it is.
+func (c *configResolver) source(ref configRef, optional bool) *resolvedSource {
+	if src, ok := c.sources[ref]; ok {
+		src.Optional = src.Optional && optional
+		return src
+	}
+	src := &resolvedSource{configSource: configSource{Kind: ref.Kind, Name: ref.Name, Optional: optional, Keys: []string{}}}
+	c.sources[ref] = src
+	c.order = append(c.order, ref)
+
+	if !c.canRead[ref.Kind] {
+		src.Error = "insufficient permissions to read " + strings.ToLower(ref.Kind) + "s"
+		return src
+	}
+	resource := "configmaps"
+	if ref.Kind == configKindSecret {
+		resource = "secrets"
+	}
+	data, err := c.k8s.DoK8sRequest(c.ctx, c.clusterID, http.MethodGet, "/api/v1/namespaces/"+c.namespace+"/"+resource+"/"+ref.Name, nil)
+	var reqErr *cluster.K8sRequestError
+	switch {
+	case errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusNotFound:
+		return src
+	case err != nil:
+		src.Error = k8sErrorMessage(err)
+		return src
+	}
+
+	if ref.Kind == configKindSecret {
+		var secret corev1.Secret
+		if err := json.Unmarshal(data, &secret); err != nil {
+			src.Error = "failed to decode secret"
+			return src
+		}
+		src.values = make(map[string]configValue, len(secret.Data))
+		for key, b := range secret.Data {
+			src.values[key] = c.secretValue(b)
+		}
+	} else {
+		var cm corev1.ConfigMap
+		if err := json.Unmarshal(data, &cm); err != nil {
+			src.Error = "failed to decode configmap"
+			return src
+		}
+		src.values = c.configMapValues(&cm)
+	}
+	src.Found = true
+	for key := range src.values {
+		src.Keys = append(src.Keys, key)
+	}
+	sort.Strings(src.Keys)
+	return src
+}
+
+// secretValue returns a Secret value, or a redacted one unless the caller
+// holds secrets:reveal.
+func (c *configResolver) secretValue(b []byte) configValue {
+	switch {
+	case !c.reveal:
+		return configValue{Redacted: true}
+	case !utf8.Valid(b):
+		return configValue{Binary: true}
+	}
+	return configValue{Value: string(b)}
+}
+
+// configMapValues returns a ConfigMap's values, with the keys the redactor
+// considers sensitive redacted unless the caller holds secrets:reveal.
+func (c *configResolver) configMapValues(cm *corev1.ConfigMap) map[string]configValue {
+	values := make(map[string]configValue, len(cm.Data)+len(cm.BinaryData))
+	for key := range cm.BinaryData {
+		values[key] = configValue{Binary: true}
+	}
+	data := make(map[string]interface{}, len(cm.Data))
+	for key, v := range cm.Data {
+		data[key] = v
+	}
+	if !c.reveal {
+		c.redactor.Redact(map[string]interface{}{"kind": configKindConfigMap, "data": data})
+	}
+	for key, v := range data {
+		if v == redact.Value && cm.Data[key] != redact.Value {
+			values[key] = configValue{Redacted: true}
+			continue
+		}
+		values[key] = configValue{Value: cm.Data[key]}
+	}
+	return values
+}
+
+// sourceList returns the pod's ConfigMaps and Secrets in the order they
+// were first referenced. A missing object is an error unless every
+// reference to it is optional.
+func (c *configResolver) sourceList() []configSource {
+	sources := make([]configSource, 0, len(c.order))
+	for _, ref := range c.order {
+		src := c.sources[ref].configSource
+		if !src.Found && src.Error == "" && !src.Optional {
+			src.Error = "not found"
+		}
+		sources = append(sources, src)
+	}
+	return sources
+}
+
+// mountFiles places a volume's files under a mount. With a subPath only the
+// file or directory it names is mounted, at the mount path itself.
+func mountFiles(files []mountedFile, mountPath, subPath string) []mountedFile {
+	mounted := make([]mountedFile, 0, len(files))
+	for _, f := range files {
+		rel := path.Clean(f.Path)
+		if subPath != "" {
+			sub := path.Clean(subPath)
+			if rel != sub && !strings.HasPrefix(rel, sub+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(strings.TrimPrefix(rel, sub), "/")
+		}
+		f.Path = path.Join(mountPath, rel)
+		mounted = append(mounted, f)
+	}
+	return mounted
+}
+
+// volumeType names the source of a volume, as its field in the pod spec.
+func volumeType(vol *corev1.Volume) string {
+	switch {
+	case vol.ConfigMap != nil:
+		return "configMap"
+	case vol.Secret != nil:
+		return "secret"
+	case vol.Projected != nil:
+		return "projected"
+	case vol.DownwardAPI != nil:
+		return "downwardAPI"
+	case vol.EmptyDir != nil:
+		return "emptyDir"
+	case vol.PersistentVolumeClaim != nil:
+		return "persistentVolumeClaim"
+	case vol.HostPath != nil:
+		return "hostPath"
+	case vol.CSI != nil:
+		return "csi"
+	case vol.Ephemeral != nil:
+		return "ephemeral"
+	}
+	return "other"
+}
+
+// podFieldValue resolves the pod fields a fieldRef may name that are known
+// from the pod object alone.
+func podFieldValue(pod *corev1.Pod, fieldPath string) (string, bool) {
+	if key, ok := fieldPathKey(fieldPath, "metadata.labels"); ok {
+		return pod.Labels[key], true
+	}
+	if key, ok := fieldPathKey(fieldPath, "metadata.annotations"); ok {
+		return pod.Annotations[key], true
+	}
+	switch fieldPath {
+	case "metadata.name":
+		return pod.Name, true
+	case "metadata.namespace":
+		return pod.Namespace, true
+	case "metadata.uid":
+		return string(pod.UID), true
+	case "spec.nodeName":
+		return pod.Spec.NodeName, true
+	case "spec.serviceAccountName":
+		return pod.Spec.ServiceAccountName, true
+	case "status.hostIP":
+		return pod.Status.HostIP, true
+	case "status.podIP":
+		return pod.Status.PodIP, true
+	}
+	return "", false
+}
+
+// fieldPathKey returns the key of a subscripted field path such as
+// metadata.labels['app'].
+func fieldPathKey(fieldPath, field string) (string, bool) {
+	rest, ok := strings.CutPrefix(fieldPath, field+"['")
+	if !ok {
+		return "", false
+	}
+	return strings.CutSuffix(rest, "']")
+}
+
+// isOptional reports whether an optional field is set to true.
+func isOptional(optional *bool) bool {
+	return optional != nil && *optional
+}
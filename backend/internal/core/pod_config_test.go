@@ -0,0 +1,192 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/redact"
+)
+
+// fakeConfigCluster serves GETs of the objects it holds, keyed by path.
+type fakeConfigCluster map[string]string
+
+func (c fakeConfigCluster) DoK8sRequest(ctx context.Context, clusterID, method, path string, body []byte) ([]byte, error) {
+	if obj, ok := c[path]; ok && method == http.MethodGet {
+		return []byte(obj), nil
+	}
+	return nil, &cluster.K8sRequestError{StatusCode: http.StatusNotFound, Message: "not found"}
+}
+
+// configAccess grants read in one namespace, optionally without secrets,
+// and secrets:reveal when reveal is set.
+type configAccess struct {
+	namespace string
+	noSecrets bool
+	reveal    bool
+}
+
+func (a configAccess) Evaluate(ctx context.Context, req rbac.Request) (bool, error) {
+	if req.Action == redact.RevealAction {
+		return a.reveal, nil
+	}
+	if req.K8sResource == "secrets" && a.noSecrets {
+		return false, nil
+	}
+	return req.Action == "read" && req.Namespace == a.namespace, nil
+}
+
+var podConfigObjects = fakeConfigCluster{
+	"/api/v1/namespaces/team-a/pods/api": `{"metadata":{"name":"api","namespace":"team-a","labels":{"app":"api"}},
+		"spec":{
+			"containers":[{"name":"api",
+				"envFrom":[{"configMapRef":{"name":"app-config"},"prefix":"APP_"}],
+				"env":[
+					{"name":"APP_MODE","value":"debug"},
+					{"name":"DB_PASSWORD","valueFrom":{"secretKeyRef":{"name":"db","key":"password"}}},
+					{"name":"APP_NAME","valueFrom":{"fieldRef":{"fieldPath":"metadata.labels['app']"}}},
+					{"name":"FLAGS","valueFrom":{"configMapKeyRef":{"name":"flags","key":"all","optional":true}}}
+				],
+				"volumeMounts":[
+					{"name":"db","mountPath":"/etc/db","readOnly":true},
+					{"name":"config","mountPath":"/etc/app/settings.yaml","subPath":"settings.yaml"},
+					{"name":"data","mountPath":"/data"}
+				]}],
+			"volumes":[
+				{"name":"db","secret":{"secretName":"db","items":[{"key":"password","path":"creds/password"}]}},
+				{"name":"config","configMap":{"name":"app-config"}},
+				{"name":"data","emptyDir":{}}
+			]}}`,
+	"/api/v1/namespaces/team-a/configmaps/app-config": `{"metadata":{"name":"app-config"},"data":{"MODE":"prod","LOG_LEVEL":"info","settings.yaml":"port: 8080","API_TOKEN":"abc"}}`,
+	"/api/v1/namespaces/team-a/secrets/db":            `{"metadata":{"name":"db"},"data":{"password":"aHVudGVyMg=="}}`,
+}
+
+func getPodConfig(t *testing.T, authz configAccess, namespace string) (*httptest.ResponseRecorder, podConfigResponse) {
+	t.Helper()
+	r := mux.NewRouter()
+	h := &PodConfigHandler{k8s: podConfigObjects, authz: authz}
+	h.SetRedactor(redact.NewRedactor(nil))
+	h.RegisterRoutes(r)
+	req := httptest.NewRequest(http.MethodGet, "/api/clusters/c1/namespaces/"+namespace+"/pods/api/config", nil)
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), &auth.Claims{UserID: "u1"}))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp podConfigResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func envByName(env []podEnvVar) map[string]podEnvVar {
+	m := make(map[string]podEnvVar, len(env))
+	for _, v := range env {
+		m[v.Name] = v
+	}
+	return m
+}
+
+func TestPodConfig_ResolvesEnvAndMounts(t *testing.T) {
+	w, resp := getPodConfig(t, configAccess{namespace: "team-a"}, "team-a")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.SecretsRevealed || len(resp.Containers) != 1 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	ctr := resp.Containers[0]
+
+	env := envByName(ctr.Env)
+	if len(ctr.Env) != 6 {
+		t.Errorf("expected four envFrom keys and two more variables, got %+v", ctr.Env)
+	}
+	if v := env["APP_MODE"]; v.Value != "debug" || v.From != nil {
+		t.Errorf("expected env to override envFrom for APP_MODE, got %+v", v)
+	}
+	if v := env["APP_LOG_LEVEL"]; v.Value != "info" || v.From == nil || v.From.Key != "LOG_LEVEL" {
+		t.Errorf("expected APP_LOG_LEVEL from app-config, got %+v", v)
+	}
+	if v := env["APP_API_TOKEN"]; !v.Redacted || v.Value != "" {
+		t.Errorf("expected the sensitive ConfigMap key to be redacted, got %+v", v)
+	}
+	if v := env["DB_PASSWORD"]; !v.Redacted || v.Value != "" || v.From.Kind != configKindSecret {
+		t.Errorf("expected the Secret value to be redacted, got %+v", v)
+	}
+	if v := env["APP_NAME"]; v.Value != "api" {
+		t.Errorf("expected the label to be resolved, got %+v", v)
+	}
+	if _, ok := env["FLAGS"]; ok {
+		t.Errorf("expected the optional missing key to be left unset")
+	}
+
+	if len(ctr.Mounts) != 3 {
+		t.Fatalf("expected three mounts, got %+v", ctr.Mounts)
+	}
+	db, config, data := ctr.Mounts[0], ctr.Mounts[1], ctr.Mounts[2]
+	if db.Type != "secret" || len(db.Files) != 1 || db.Files[0].Path != "/etc/db/creds/password" || !db.Files[0].Redacted {
+		t.Errorf("expected one redacted secret file, got %+v", db)
+	}
+	if len(config.Files) != 1 || config.Files[0].Path != "/etc/app/settings.yaml" || config.Files[0].Value != "port: 8080" {
+		t.Errorf("expected only the subPath file, got %+v", config)
+	}
+	if data.Type != "emptyDir" || len(data.Files) != 0 {
+		t.Errorf("expected an emptyDir without files, got %+v", data)
+	}
+
+	if len(resp.Sources) != 3 {
+		t.Fatalf("expected app-config, db and flags, got %+v", resp.Sources)
+	}
+	if flags := resp.Sources[2]; flags.Name != "flags" || flags.Found || !flags.Optional || flags.Error != "" {
+		t.Errorf("expected flags to be optional and missing, got %+v", flags)
+	}
+}
+
+func TestPodConfig_RevealsSecrets(t *testing.T) {
+	_, resp := getPodConfig(t, configAccess{namespace: "team-a", reveal: true}, "team-a")
+	if !resp.SecretsRevealed || len(resp.Containers) != 1 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	env := envByName(resp.Containers[0].Env)
+	if v := env["DB_PASSWORD"]; v.Redacted || v.Value != "hunter2" {
+		t.Errorf("expected the decoded Secret value, got %+v", v)
+	}
+	if v := env["APP_API_TOKEN"]; v.Redacted || v.Value != "abc" {
+		t.Errorf("expected the ConfigMap value, got %+v", v)
+	}
+}
+
+func TestPodConfig_Permissions(t *testing.T) {
+	if w, _ := getPodConfig(t, configAccess{namespace: "team-a", reveal: true}, "team-b"); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a pod in another namespace, got %d", w.Code)
+	}
+
+	w, resp := getPodConfig(t, configAccess{namespace: "team-a", noSecrets: true, reveal: true}, "team-a")
+	if w.Code != http.StatusOK || len(resp.Containers) != 1 {
+		t.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	if v := envByName(resp.Containers[0].Env)["DB_PASSWORD"]; v.Value != "" || v.Error == "" {
+		t.Errorf("expected an error instead of the Secret value, got %+v", v)
+	}
+	if db := resp.Containers[0].Mounts[0]; len(db.Files) != 0 || len(db.Errors) != 1 {
+		t.Errorf("expected the secret volume to report an error, got %+v", db)
+	}
+	if db := resp.Sources[1]; db.Kind != configKindSecret || db.Found || db.Error == "" {
+		t.Errorf("expected the Secret source to report an error, got %+v", db)
+	}
+}
+
+func TestMountFiles(t *testing.T) {
+	files := []mountedFile{{Path: "a"}, {Path: "dir/b"}, {Path: "dir/c"}}
+	got := mountFiles(files, "/etc/x", "dir")
+	if len(got) != 2 || got[0].Path != "/etc/x/b" || got[1].Path != "/etc/x/c" {
+		t.Errorf("expected the subPath directory's files, got %+v", got)
+	}
+	if got := mountFiles(files, "/etc/x", ""); len(got) != 3 || got[1].Path != "/etc/x/dir/b" {
+		t.Errorf("expected every file, got %+v", got)
+	}
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// podSummaryField is the top-level field added to each pod in list
+// responses. It is computed by Argus and is not part of the Kubernetes object.
+const podSummaryField = "argus"
+
+// podSummary is the kubectl-style status of a pod: Status is what the STATUS
+// column of kubectl get pods shows, e.g. CrashLoopBackOff, Init:0/2, or
+// Terminating, rather than the raw phase.
+type podSummary struct {
+	Status          string `json:"status"`
+	ReadyContainers int    `json:"readyContainers"`
+	TotalContainers int    `json:"totalContainers"`
+	Restarts        int64  `json:"restarts"`
+}
+
+// summarizePod computes a pod's status the way kubectl's printer does:
+// waiting and terminated container reasons win over the phase, init
+// containers report their progress, and deleted pods show Terminating.
+// Restartable init containers (sidecars) count towards the container totals.
+func summarizePod(pod *corev1.Pod) podSummary {
+	s := podSummary{Status: string(pod.Status.Phase), TotalContainers: len(pod.Spec.Containers)}
+	if pod.Status.Reason != "" {
+		s.Status = pod.Status.Reason
+	}
+
+	sidecars := make(map[string]bool)
+	for _, c := range pod.Spec.InitContainers {
+		if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			sidecars[c.Name] = true
+			s.TotalContainers++
+		}
+	}
+
+	initializing := false
+	for i, c := range pod.Status.InitContainerStatuses {
+		s.Restarts += int64(c.RestartCount)
+		switch {
+		case c.State.Terminated != nil && c.State.Terminated.ExitCode == 0:
+			continue
+		case sidecars[c.Name] && c.Started != nil && *c.Started:
+			if c.Ready {
+				s.ReadyContainers++
+			}
+			continue
+		case c.State.Terminated != nil:
+			s.Status = "Init:" + terminatedReason(c.State.Terminated)
+		case c.State.Waiting != nil && c.State.Waiting.Reason != "" && c.State.Waiting.Reason != "PodInitializing":
+			s.Status = "Init:" + c.State.Waiting.Reason
+		default:
+			s.Status = fmt.Sprintf("Init:%d/%d", i, len(pod.Spec.InitContainers))
+		}
+		initializing = true
+		break
+	}
+
+	if !initializing || podConditionTrue(pod, corev1.PodInitialized) {
+		hasRunning := false
+		for i := len(pod.Status.ContainerStatuses) - 1; i >= 0; i-- {
+			c := pod.Status.ContainerStatuses[i]
+			s.Restarts += int64(c.RestartCount)
+			switch {
+			case c.State.Waiting != nil && c.State.Waiting.Reason != "":
+				s.Status = c.State.Waiting.Reason
+			case c.State.Terminated != nil:
+				s.Status = terminatedReason(c.State.Terminated)
+			case c.Ready && c.State.Running != nil:
+				hasRunning = true
+				s.ReadyContainers++
+			}
+		}
+		// A completed container next to running ones is not a completed pod.
+		if s.Status == "Completed" && hasRunning {
+			if podConditionTrue(pod, corev1.PodReady) {
+				s.Status = string(corev1.PodRunning)
+			} else {
+				s.Status = "NotReady"
+			}
+		}
+	}
+
+	if pod.DeletionTimestamp != nil {
+		if pod.Status.Reason == "NodeLost" {
+			s.Status = string(corev1.PodUnknown)
+		} else {
+			s.Status = "Terminating"
+		}
+	}
+	return s
+}
+
+// terminatedReason is the reason a container terminated, falling back to
+// its signal or exit code.
+func terminatedReason(t *corev1.ContainerStateTerminated) string {
+	switch {
+	case t.Reason != "":
+		return t.Reason
+	case t.Signal != 0:
+		return fmt.Sprintf("Signal:%d", t.Signal)
+	default:
+		return fmt.Sprintf("ExitCode:%d", t.ExitCode)
+	}
+}
+
+func podConditionTrue(pod *corev1.Pod, condType corev1.PodConditionType) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == condType {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// addPodSummaries sets podSummaryField on every pod in list. Items that do
+// not convert to a Pod are left as they are.
+func addPodSummaries(list *unstructured.UnstructuredList) {
+	for i := range list.Items {
+		var pod corev1.Pod
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(list.Items[i].Object, &pod); err != nil {
+			continue
+		}
+		s := summarizePod(&pod)
+		list.Items[i].Object[podSummaryField] = map[string]interface{}{
+			"status":          s.Status,
+			"readyContainers": int64(s.ReadyContainers),
+			"totalContainers": int64(s.TotalContainers),
+			"restarts":        s.Restarts,
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func runningContainer(name string, ready bool, restarts int32) corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		Name:         name,
+		Ready:        ready,
+		RestartCount: restarts,
+		State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	}
+}
+
+func waitingContainer(name, reason string, restarts int32) corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		Name:         name,
+		RestartCount: restarts,
+		State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}},
+	}
+}
+
+func terminatedContainer(name, reason string, exitCode int32) corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		Name:  name,
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: reason, ExitCode: exitCode}},
+	}
+}
+
+func podWithStatuses(containers int, phase corev1.PodPhase, statuses ...corev1.ContainerStatus) *corev1.Pod {
+	pod := &corev1.Pod{Status: corev1.PodStatus{Phase: phase, ContainerStatuses: statuses}}
+	for i := 0; i < containers; i++ {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: "c" + string(rune('0'+i))})
+	}
+	return pod
+}
+
+func TestSummarizePod(t *testing.T) {
+	now := metav1.Now()
+	alwaysRestart := corev1.ContainerRestartPolicyAlways
+	started := true
+
+	initWaiting := podWithStatuses(1, corev1.PodPending)
+	initWaiting.Spec.InitContainers = []corev1.Container{{Name: "migrate"}, {Name: "seed"}}
+	initWaiting.Status.InitContainerStatuses = []corev1.ContainerStatus{
+		terminatedContainer("migrate", "Completed", 0),
+		{Name: "seed", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+	}
+
+	initFailed := podWithStatuses(1, corev1.PodPending)
+	initFailed.Spec.InitContainers = []corev1.Container{{Name: "migrate"}}
+	initFailed.Status.InitContainerStatuses = []corev1.ContainerStatus{waitingContainer("migrate", "CrashLoopBackOff", 4)}
+
+	sidecar := podWithStatuses(1, corev1.PodRunning, runningContainer("c0", true, 0))
+	sidecar.Spec.InitContainers = []corev1.Container{{Name: "proxy", RestartPolicy: &alwaysRestart}}
+	sidecar.Status.InitContainerStatuses = []corev1.ContainerStatus{{Name: "proxy", Ready: true, Started: &started, RestartCount: 1,
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}}
+	sidecar.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodInitialized, Status: corev1.ConditionTrue}}
+
+	terminating := podWithStatuses(1, corev1.PodRunning, runningContainer("c0", true, 0))
+	terminating.DeletionTimestamp = &now
+
+	evicted := podWithStatuses(1, corev1.PodFailed)
+	evicted.Status.Reason = "Evicted"
+
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want podSummary
+	}{
+		{"running", podWithStatuses(2, corev1.PodRunning, runningContainer("c0", true, 0), runningContainer("c1", true, 2)),
+			podSummary{Status: "Running", ReadyContainers: 2, TotalContainers: 2, Restarts: 2}},
+		{"crash loop", podWithStatuses(2, corev1.PodRunning, runningContainer("c0", true, 0), waitingContainer("c1", "CrashLoopBackOff", 7)),
+			podSummary{Status: "CrashLoopBackOff", ReadyContainers: 1, TotalContainers: 2, Restarts: 7}},
+		{"image pull", podWithStatuses(1, corev1.PodPending, waitingContainer("c0", "ImagePullBackOff", 0)),
+			podSummary{Status: "ImagePullBackOff", TotalContainers: 1}},
+		{"oom killed", podWithStatuses(1, corev1.PodRunning, terminatedContainer("c0", "OOMKilled", 137)),
+			podSummary{Status: "OOMKilled", TotalContainers: 1}},
+		{"exit code without reason", podWithStatuses(1, corev1.PodFailed, terminatedContainer("c0", "", 3)),
+			podSummary{Status: "ExitCode:3", TotalContainers: 1}},
+		{"completed", podWithStatuses(1, corev1.PodSucceeded, terminatedContainer("c0", "Completed", 0)),
+			podSummary{Status: "Completed", TotalContainers: 1}},
+		{"completed next to running", podWithStatuses(2, corev1.PodRunning, terminatedContainer("c0", "Completed", 0), runningContainer("c1", true, 0)),
+			podSummary{Status: "NotReady", ReadyContainers: 1, TotalContainers: 2}},
+		{"init progress", initWaiting, podSummary{Status: "Init:1/2", TotalContainers: 1}},
+		{"init crash loop", initFailed, podSummary{Status: "Init:CrashLoopBackOff", TotalContainers: 1, Restarts: 4}},
+		{"sidecar", sidecar, podSummary{Status: "Running", ReadyContainers: 2, TotalContainers: 2, Restarts: 1}},
+		{"terminating", terminating, podSummary{Status: "Terminating", ReadyContainers: 1, TotalContainers: 1}},
+		{"evicted", evicted, podSummary{Status: "Evicted", TotalContainers: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summarizePod(tt.pod); got != tt.want {
+				t.Errorf("summarizePod() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddPodSummaries(t *testing.T) {
+	pod := podWithStatuses(1, corev1.PodRunning, waitingContainer("c0", "CrashLoopBackOff", 3))
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{{Object: obj}}}
+
+	addPodSummaries(list)
+
+	summary, ok := list.Items[0].Object[podSummaryField].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a %q field on the pod, got %v", podSummaryField, list.Items[0].Object)
+	}
+	if summary["status"] != "CrashLoopBackOff" || summary["restarts"] != int64(3) || summary["totalContainers"] != int64(1) {
+		t.Errorf("unexpected summary %v", summary)
+	}
+	// The list must still serialize, which fails on non-JSON-compatible values.
+	if _, err := list.MarshalJSON(); err != nil {
+		t.Errorf("expected the enriched list to serialize: %v", err)
+	}
+}
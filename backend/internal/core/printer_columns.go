@@ -0,0 +1,160 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+)
+
+// printerColumnsField is the top-level field added to custom resource lists
+// and their items. The list's holds the columns, each item's the values. Like
+// podSummaryField it is computed by Argus.
+const printerColumnsField = "argus"
+
+// printerColumn is a column of a custom resource table, as declared in the
+// additionalPrinterColumns of its CRD. The JSON names are the CRD's.
+type printerColumn struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Format      string `json:"format,omitempty"`
+	Description string `json:"description,omitempty"`
+	Priority    int32  `json:"priority,omitempty"`
+	JSONPath    string `json:"jsonPath"`
+}
+
+// nameColumn leads every table, as in kubectl get; ageColumn is shown when
+// the CRD declares no columns of its own.
+var (
+	nameColumn = printerColumn{Name: "Name", Type: "string", Format: "name", Description: "Name must be unique within a namespace.", JSONPath: ".metadata.name"}
+	ageColumn  = printerColumn{Name: "Age", Type: "date", Description: "Time since the object was created.", JSONPath: ".metadata.creationTimestamp"}
+)
+
+// customResourceDefinition is the part of a CRD that declares its columns.
+type customResourceDefinition struct {
+	Spec struct {
+		Versions []struct {
+			Name                     string          `json:"name"`
+			AdditionalPrinterColumns []printerColumn `json:"additionalPrinterColumns"`
+		} `json:"versions"`
+	} `json:"spec"`
+}
+
+// printerColumnsFromCRD returns the table columns of a CRD's version: the
+// name followed by its printer columns, or by the age if it has none.
+func printerColumnsFromCRD(body []byte, version string) ([]printerColumn, error) {
+	var crd customResourceDefinition
+	if err := json.Unmarshal(body, &crd); err != nil {
+		return nil, fmt.Errorf("invalid custom resource definition: %w", err)
+	}
+	columns := []printerColumn{nameColumn}
+	for _, v := range crd.Spec.Versions {
+		if v.Name == version && len(v.AdditionalPrinterColumns) > 0 {
+			return append(columns, v.AdditionalPrinterColumns...), nil
+		}
+	}
+	return append(columns, ageColumn), nil
+}
+
+// crdPrinterColumns returns the table columns of gvr, or false if it is not a
+// custom resource. Built-in groups without a dot are not looked up.
+func (h *ResourceHandler) crdPrinterColumns(ctx context.Context, clusterID string, gvr schema.GroupVersionResource) ([]printerColumn, bool, error) {
+	if !strings.Contains(gvr.Group, ".") {
+		return nil, false, nil
+	}
+	path := "/apis/apiextensions.k8s.io/v1/customresourcedefinitions/" + gvr.Resource + "." + gvr.Group
+	body, err := h.clusterMgr.DoK8sRequest(ctx, clusterID, http.MethodGet, path, nil)
+	if err != nil {
+		var reqErr *cluster.K8sRequestError
+		if errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusNotFound {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	columns, err := printerColumnsFromCRD(body, gvr.Version)
+	if err != nil {
+		return nil, false, err
+	}
+	return columns, true, nil
+}
+
+// addPrinterColumns sets printerColumnsField on list to the columns and on
+// every item to its cells, extracted the way the API server builds tables
+// for kubectl get: a value missing from an item, or not of the column's
+// type, is null.
+func addPrinterColumns(list *unstructured.UnstructuredList, columns []printerColumn) {
+	paths := make([]*jsonpath.JSONPath, len(columns))
+	defs := make([]interface{}, len(columns))
+	for i, col := range columns {
+		p := jsonpath.New(col.Name).AllowMissingKeys(true)
+		if err := p.Parse("{" + col.JSONPath + "}"); err == nil {
+			paths[i] = p
+		}
+		defs[i] = map[string]interface{}{
+			"name":        col.Name,
+			"type":        col.Type,
+			"format":      col.Format,
+			"description": col.Description,
+			"priority":    int64(col.Priority),
+			"jsonPath":    col.JSONPath,
+		}
+	}
+	list.Object[printerColumnsField] = map[string]interface{}{"columns": defs}
+
+	for i := range list.Items {
+		cells := make([]interface{}, len(columns))
+		for j, col := range columns {
+			if paths[j] != nil {
+				cells[j] = printerCell(paths[j], col.Type, list.Items[i].Object)
+			}
+		}
+		list.Items[i].Object[printerColumnsField] = map[string]interface{}{"cells": cells}
+	}
+}
+
+// printerCell extracts one cell. Only the first match counts; string columns
+// are printed as kubectl would, other types are returned as they are.
+func printerCell(p *jsonpath.JSONPath, colType string, obj map[string]interface{}) interface{} {
+	results, err := p.FindResults(obj)
+	if err != nil || len(results) == 0 || len(results[0]) == 0 {
+		return nil
+	}
+	value := results[0][0].Interface()
+
+	switch colType {
+	case "string":
+		var buf bytes.Buffer
+		if err := p.PrintResults(&buf, []reflect.Value{reflect.ValueOf(value)}); err != nil {
+			return nil
+		}
+		return buf.String()
+	case "integer":
+		if _, ok := value.(int64); ok {
+			return value
+		}
+	case "number":
+		switch value.(type) {
+		case int64, float64:
+			return value
+		}
+	case "boolean":
+		if _, ok := value.(bool); ok {
+			return value
+		}
+	case "date":
+		if _, ok := value.(string); ok {
+			return value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const certificateCRD = `{
+  "spec": {
+    "versions": [
+      {"name": "v1alpha1"},
+      {
+        "name": "v1",
+        "additionalPrinterColumns": [
+          {"name": "Ready", "type": "string", "jsonPath": ".status.conditions[?(@.type==\"Ready\")].status"},
+          {"name": "Secret", "type": "string", "jsonPath": ".spec.secretName"},
+          {"name": "Renewals", "type": "integer", "priority": 1, "jsonPath": ".status.renewals"},
+          {"name": "Age", "type": "date", "jsonPath": ".metadata.creationTimestamp"}
+        ]
+      }
+    ]
+  }
+}`
+
+func TestPrinterColumnsFromCRD(t *testing.T) {
+	columns, err := printerColumnsFromCRD([]byte(certificateCRD), "v1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, c := range columns {
+		names = append(names, c.Name)
+	}
+	want := []string{"Name", "Ready", "Secret", "Renewals", "Age"}
+	if len(names) != len(want) {
+		t.Fatalf("expected columns %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("expected columns %v, got %v", want, names)
+		}
+	}
+	if columns[3].Priority != 1 || columns[3].Type != "integer" {
+		t.Errorf("expected the CRD's column definition, got %+v", columns[3])
+	}
+}
+
+func TestPrinterColumnsFromCRD_Fallback(t *testing.T) {
+	for _, version := range []string{"v1alpha1", "v2"} {
+		columns, err := printerColumnsFromCRD([]byte(certificateCRD), version)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(columns) != 2 || columns[0] != nameColumn || columns[1] != ageColumn {
+			t.Errorf("%s: expected name and age, got %+v", version, columns)
+		}
+	}
+
+	if _, err := printerColumnsFromCRD([]byte("not json"), "v1"); err == nil {
+		t.Error("expected an error for an invalid CRD")
+	}
+}
+
+func TestAddPrinterColumns(t *testing.T) {
+	columns, err := printerColumnsFromCRD([]byte(certificateCRD), "v1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{"kind": "CertificateList"}}
+	if err := list.UnmarshalJSON([]byte(`{
+	  "apiVersion": "cert-manager.io/v1",
+	  "kind": "CertificateList",
+	  "items": [
+	    {
+	      "apiVersion": "cert-manager.io/v1",
+	      "kind": "Certificate",
+	      "metadata": {"name": "web", "creationTimestamp": "2026-01-15T10:30:00Z"},
+	      "spec": {"secretName": "web-tls"},
+	      "status": {"renewals": 3, "conditions": [{"type": "Issuing", "status": "False"}, {"type": "Ready", "status": "True"}]}
+	    },
+	    {
+	      "apiVersion": "cert-manager.io/v1",
+	      "kind": "Certificate",
+	      "metadata": {"name": "api"},
+	      "spec": {"secretName": 42},
+	      "status": {"renewals": "many"}
+	    }
+	  ]
+	}`)); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	addPrinterColumns(list, columns)
+
+	body, err := list.MarshalJSON()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var got struct {
+		Argus struct {
+			Columns []printerColumn `json:"columns"`
+		} `json:"argus"`
+		Items []struct {
+			Argus struct {
+				Cells []interface{} `json:"cells"`
+			} `json:"argus"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+
+	if len(got.Argus.Columns) != 5 || got.Argus.Columns[1].JSONPath != columns[1].JSONPath {
+		t.Fatalf("expected the columns on the list, got %+v", got.Argus.Columns)
+	}
+	first := got.Items[0].Argus.Cells
+	want := []interface{}{"web", "True", "web-tls", float64(3), "2026-01-15T10:30:00Z"}
+	for i := range want {
+		if first[i] != want[i] {
+			t.Errorf("cell %d: expected %v, got %v", i, want[i], first[i])
+		}
+	}
+
+	// Missing values are null, and so are values of the wrong type except
+	// for string columns, which print whatever they find.
+	second := got.Items[1].Argus.Cells
+	want = []interface{}{"api", nil, "42", nil, nil}
+	for i := range want {
+		if second[i] != want[i] {
+			t.Errorf("cell %d: expected %v, got %v", i, want[i], second[i])
+		}
+	}
+}
+
+func TestListIsDecorated(t *testing.T) {
+	tests := []struct {
+		group, resource string
+		want            bool
+	}{
+		{"", "pods", true},
+		{"", "services", false},
+		{"apps", "deployments", false},
+		{"cert-manager.io", "certificates", true},
+		{"networking.k8s.io", "ingresses", true},
+	}
+	for _, tt := range tests {
+		gvr := podsGVR
+		gvr.Group, gvr.Resource = tt.group, tt.resource
+		if got := listIsDecorated(gvr); got != tt.want {
+			t.Errorf("listIsDecorated(%s) = %v, want %v", gvr.String(), got, tt.want)
+		}
+	}
+}
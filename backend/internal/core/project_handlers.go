@@ -11,6 +11,7 @@ import (
 	"github.com/darkden-lab/argus/backend/internal/auth"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
 	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/validate"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -383,6 +384,12 @@ func (h *ConvenienceHandlers) AssignNamespaces(w http.ResponseWriter, r *http.Re
 		httputil.WriteError(w, http.StatusBadRequest, "namespaces array is required")
 		return
 	}
+	for _, ns := range req.Namespaces {
+		if ns == "" || !validate.Segment(ns) {
+			httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("invalid namespace %q", ns))
+			return
+		}
+	}
 
 	var projectID string
 	err := h.pool.QueryRow(ctx,
@@ -423,6 +430,11 @@ func (h *ConvenienceHandlers) RemoveNamespace(w http.ResponseWriter, r *http.Req
 	namespace := mux.Vars(r)["namespace"]
 	ctx := r.Context()
 
+	if !validate.Segment(namespace) {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid namespace")
+		return
+	}
+
 	var projectID string
 	err := h.pool.QueryRow(ctx,
 		`SELECT id FROM projects WHERE name = $1 AND cluster_id = $2`,
@@ -0,0 +1,480 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+// Request sizing actions suggested by the recommendation endpoint.
+const (
+	recommendIncrease = "increase"
+	recommendDecrease = "decrease"
+	recommendSet      = "set"
+	recommendKeep     = "keep"
+)
+
+const (
+	defaultRecommendSamples  = 3
+	maxRecommendSamples      = 10
+	defaultRecommendInterval = 15 * time.Second
+	minRecommendInterval     = 5 * time.Second
+	maxRecommendInterval     = time.Minute
+	maxRecommendWindow       = 2 * time.Minute
+
+	// recommendHeadroom is added on top of the peak usage seen when
+	// suggesting a request.
+	recommendHeadroom = 0.2
+	// hpaTargetCPUUtilization is the suggested HPA target, as a percentage
+	// of the suggested CPU request.
+	hpaTargetCPUUtilization = 70
+	// hpaMaxReplicasFactor sizes the suggested maxReplicas as a multiple of
+	// minReplicas, leaving room for load the short window did not see.
+	hpaMaxReplicasFactor = 3
+
+	minSuggestedCPU    = 10               // millicores
+	minSuggestedMemory = 16 * 1024 * 1024 // bytes
+)
+
+var hpaGVR = schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}
+
+// scalableKinds are the workload kinds an HPA can scale.
+var scalableKinds = map[string]bool{"Deployment": true, "StatefulSet": true, "ReplicaSet": true}
+
+// RecommendationHandler suggests request sizes and HPA settings for
+// workloads from a short window of metrics.k8s.io usage samples.
+type RecommendationHandler struct {
+	backendFor func(clusterID string, gvr schema.GroupVersionResource) (resourceBackend, error)
+	authz      rbac.Evaluator
+	sleep      func(ctx context.Context, d time.Duration) bool
+}
+
+// NewRecommendationHandler creates a new RecommendationHandler.
+func NewRecommendationHandler(cm *cluster.Manager, engine *rbac.Engine) *RecommendationHandler {
+	return &RecommendationHandler{
+		backendFor: func(clusterID string, gvr schema.GroupVersionResource) (resourceBackend, error) {
+			return resourceBackendFor(cm, clusterID, gvr)
+		},
+		authz: engine,
+		sleep: sleepCtx,
+	}
+}
+
+// RegisterRoutes wires the recommendation endpoint.
+func (h *RecommendationHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clusters/{clusterID}/namespaces/{namespace}/recommendations", h.GetRecommendations).Methods(http.MethodGet)
+}
+
+// resourceRecommendation sizes one resource per pod. CPU values are
+// millicores and memory values bytes.
+type resourceRecommendation struct {
+	Request          int64  `json:"request"`
+	Limit            int64  `json:"limit"`
+	AverageUsage     int64  `json:"averageUsage"`
+	PeakUsage        int64  `json:"peakUsage"`
+	SuggestedRequest int64  `json:"suggestedRequest"`
+	Action           string `json:"action"`
+	Reason           string `json:"reason"`
+}
+
+// hpaSettings is a suggested or existing HorizontalPodAutoscaler
+// configuration. TargetCPUUtilization is a percentage of the CPU request.
+type hpaSettings struct {
+	Name                 string `json:"name,omitempty"`
+	MinReplicas          int64  `json:"minReplicas"`
+	MaxReplicas          int64  `json:"maxReplicas"`
+	TargetCPUUtilization int64  `json:"targetCPUUtilization,omitempty"`
+	Reason               string `json:"reason,omitempty"`
+}
+
+// workloadRecommendation is the advice for one workload.
+type workloadRecommendation struct {
+	Kind       string                 `json:"kind"`
+	Name       string                 `json:"name"`
+	Pods       int                    `json:"pods"`
+	CPU        resourceRecommendation `json:"cpu"`
+	Memory     resourceRecommendation `json:"memory"`
+	HPA        *hpaSettings           `json:"hpa,omitempty"`
+	CurrentHPA *hpaSettings           `json:"currentHpa,omitempty"`
+}
+
+// recommendationResponse is the advice for a namespace or one workload in
+// it. Advisory is always true: the samples cover a short window and miss
+// daily peaks, batch runs and startup spikes.
+type recommendationResponse struct {
+	ClusterID     string                   `json:"clusterId"`
+	Namespace     string                   `json:"namespace"`
+	Advisory      bool                     `json:"advisory"`
+	Note          string                   `json:"note"`
+	Samples       int                      `json:"samples"`
+	WindowSeconds int                      `json:"windowSeconds"`
+	HPAError      string                   `json:"hpaError,omitempty"`
+	Workloads     []workloadRecommendation `json:"workloads"`
+}
+
+// usageStats accumulates per-pod usage of one resource over the samples.
+type usageStats struct {
+	sum   float64 // per-pod usage summed over samples
+	count int
+	peak  int64 // highest usage of a single pod in any sample
+}
+
+func (s *usageStats) add(total int64, pods int, peak int64) {
+	s.sum += float64(total) / float64(pods)
+	s.count++
+	if peak > s.peak {
+		s.peak = peak
+	}
+}
+
+func (s usageStats) average() int64 {
+	if s.count == 0 {
+		return 0
+	}
+	return int64(math.Round(s.sum / float64(s.count)))
+}
+
+// recommendWorkload is a workload's running pods and the usage seen.
+type recommendWorkload struct {
+	kind, name string
+	pods       map[string]bool
+	cpu, mem   resourceTotals // summed over pods
+	cpuUsage   usageStats
+	memUsage   usageStats
+}
+
+// GetRecommendations samples pod usage from metrics.k8s.io and suggests
+// requests and HPA settings for the workloads in a namespace. The caller
+// needs read access to pods in it; existing HPAs are included when the
+// caller may also read them.
+//
+// Query parameters:
+//   - kind, name: only this workload (both or neither)
+//   - samples: usage samples to take (default 3, max 10)
+//   - interval: time between samples (default 15s, 5s to 1m)
+func (h *RecommendationHandler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	clusterID, namespace := vars["clusterID"], vars["namespace"]
+	if !validate.Segment(namespace) {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid namespace")
+		return
+	}
+	q := r.URL.Query()
+	kind, name := q.Get("kind"), q.Get("name")
+	if (kind == "") != (name == "") {
+		httputil.WriteError(w, http.StatusBadRequest, "kind and name must be given together")
+		return
+	}
+	samples := defaultRecommendSamples
+	if v := q.Get("samples"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxRecommendSamples {
+			httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("samples must be between 1 and %d", maxRecommendSamples))
+			return
+		}
+		samples = n
+	}
+	interval := defaultRecommendInterval
+	if v := q.Get("interval"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < minRecommendInterval || d > maxRecommendInterval {
+			httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("interval must be a duration between %s and %s", minRecommendInterval, maxRecommendInterval))
+			return
+		}
+		interval = d
+	}
+	window := time.Duration(samples-1) * interval
+	if window > maxRecommendWindow {
+		httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("samples and interval span %s, more than %s", window, maxRecommendWindow))
+		return
+	}
+
+	allowed, err := h.canRead(r, claims.UserID, clusterID, namespace, "pods")
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+		return
+	}
+	if !allowed {
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+
+	podsBackend, err := h.backendFor(clusterID, podsGVR)
+	if err != nil {
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	pods, err := podsBackend.list(r.Context(), namespace, "", "")
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("failed to list pods: %v", err))
+		return
+	}
+	workloads, byPod := groupRunningPods(pods, kind, name)
+	if len(workloads) == 0 && kind != "" {
+		httputil.WriteError(w, http.StatusNotFound, fmt.Sprintf("no running pods found for %s %s", kind, name))
+		return
+	}
+
+	metricsBackend, err := h.backendFor(clusterID, podMetricsGVR)
+	if err != nil {
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	for i := 0; i < samples && len(workloads) > 0; i++ {
+		if i > 0 && !h.sleep(r.Context(), interval) {
+			return // client went away
+		}
+		metrics, err := metricsBackend.list(r.Context(), namespace, "", "")
+		if err != nil {
+			httputil.WriteError(w, http.StatusServiceUnavailable, fmt.Sprintf("metrics.k8s.io unavailable: %v", err))
+			return
+		}
+		recordUsageSample(parsePodMetrics(metrics), namespace, byPod, workloads)
+	}
+
+	resp := recommendationResponse{
+		ClusterID:     clusterID,
+		Namespace:     namespace,
+		Advisory:      true,
+		Samples:       samples,
+		WindowSeconds: int(window / time.Second),
+		Workloads:     make([]workloadRecommendation, 0, len(workloads)),
+	}
+	resp.Note = fmt.Sprintf("Advisory only: based on %d metrics.k8s.io usage sample(s) over %s, which can miss daily peaks, batch runs and startup spikes. Review before applying.", samples, window)
+
+	current := h.currentHPAs(r, claims.UserID, clusterID, namespace, &resp)
+	for _, wl := range workloads {
+		rec := recommendWorkloadSizing(wl)
+		if hpa, ok := current[wl.kind+"/"+wl.name]; ok {
+			rec.CurrentHPA = &hpa
+		}
+		resp.Workloads = append(resp.Workloads, rec)
+	}
+	sort.Slice(resp.Workloads, func(i, j int) bool {
+		a, b := resp.Workloads[i], resp.Workloads[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *RecommendationHandler) canRead(r *http.Request, userID, clusterID, namespace, resource string) (bool, error) {
+	return h.authz.Evaluate(r.Context(), rbac.Request{
+		UserID:      userID,
+		Action:      "read",
+		Resource:    rbac.ResourceCategory(resource),
+		ClusterID:   clusterID,
+		Namespace:   namespace,
+		K8sResource: resource,
+	})
+}
+
+// groupRunningPods rolls the running pods up to their workloads, keeping
+// only kind/name when given. byPod maps each kept pod name to its workload.
+func groupRunningPods(pods []unstructured.Unstructured, kind, name string) ([]*recommendWorkload, map[string]*recommendWorkload) {
+	byKey := make(map[string]*recommendWorkload)
+	byPod := make(map[string]*recommendWorkload)
+	var workloads []*recommendWorkload
+	for _, pod := range pods {
+		if phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase"); phase != "Running" {
+			continue
+		}
+		k, n := podWorkload(pod)
+		if kind != "" && (k != kind || n != name) {
+			continue
+		}
+		wl, ok := byKey[k+"/"+n]
+		if !ok {
+			wl = &recommendWorkload{kind: k, name: n, pods: make(map[string]bool)}
+			byKey[k+"/"+n] = wl
+			workloads = append(workloads, wl)
+		}
+		wl.pods[pod.GetName()] = true
+		byPod[pod.GetName()] = wl
+		cpu, mem := podRequestsAndLimits(pod)
+		wl.cpu.add(cpu)
+		wl.mem.add(mem)
+	}
+	return workloads, byPod
+}
+
+// recordUsageSample adds one metrics sample to the workloads. Pods without
+// metrics yet, such as ones just started, are left out of the sample.
+func recordUsageSample(usage map[string]podUsage, namespace string, byPod map[string]*recommendWorkload, workloads []*recommendWorkload) {
+	type sample struct {
+		pods                int
+		cpu, mem            int64
+		peakCPU, peakMemory int64
+	}
+	samples := make(map[*recommendWorkload]*sample)
+	for podName, wl := range byPod {
+		u, ok := usage[namespace+"/"+podName]
+		if !ok {
+			continue
+		}
+		s := samples[wl]
+		if s == nil {
+			s = &sample{}
+			samples[wl] = s
+		}
+		s.pods++
+		s.cpu += u.CPU
+		s.mem += u.Memory
+		s.peakCPU = max(s.peakCPU, u.CPU)
+		s.peakMemory = max(s.peakMemory, u.Memory)
+	}
+	for _, wl := range workloads {
+		if s := samples[wl]; s != nil {
+			wl.cpuUsage.add(s.cpu, s.pods, s.peakCPU)
+			wl.memUsage.add(s.mem, s.pods, s.peakMemory)
+		}
+	}
+}
+
+// recommendWorkloadSizing turns a workload's usage into its advice.
+func recommendWorkloadSizing(wl *recommendWorkload) workloadRecommendation {
+	pods := int64(len(wl.pods))
+	rec := workloadRecommendation{
+		Kind:   wl.kind,
+		Name:   wl.name,
+		Pods:   len(wl.pods),
+		CPU:    recommendResource(wl.cpu, pods, wl.cpuUsage, minSuggestedCPU, 5, "m"),
+		Memory: recommendResource(wl.mem, pods, wl.memUsage, minSuggestedMemory, 1024*1024, " bytes"),
+	}
+	if scalableKinds[wl.kind] && wl.cpuUsage.count > 0 {
+		rec.HPA = recommendHPA(rec.CPU, pods)
+	}
+	return rec
+}
+
+// recommendResource sizes one resource per pod: the peak usage plus
+// recommendHeadroom, rounded up to step and at least minimum. A request
+// below the peak is increased, and one more than twice the suggestion (the
+// capacity report's over-provisioned ratio) is decreased.
+func recommendResource(totals resourceTotals, pods int64, usage usageStats, minimum, step int64, unit string) resourceRecommendation {
+	rec := resourceRecommendation{
+		Request:      totals.Request / pods,
+		Limit:        totals.Limit / pods,
+		AverageUsage: usage.average(),
+		PeakUsage:    usage.peak,
+	}
+	if usage.count == 0 {
+		rec.SuggestedRequest = rec.Request
+		rec.Action = recommendKeep
+		rec.Reason = "no usage samples for the running pods yet"
+		return rec
+	}
+
+	suggested := int64(math.Ceil(float64(usage.peak) * (1 + recommendHeadroom)))
+	suggested = max(minimum, (suggested+step-1)/step*step)
+	rec.SuggestedRequest = suggested
+
+	switch {
+	case rec.Request == 0:
+		rec.Action = recommendSet
+		rec.Reason = fmt.Sprintf("no request set; peak usage per pod was %d%s", usage.peak, unit)
+	case usage.peak > rec.Request:
+		rec.Action = recommendIncrease
+		rec.Reason = fmt.Sprintf("peak usage per pod (%d%s) exceeded the request (%d%s)", usage.peak, unit, rec.Request, unit)
+	case float64(usage.peak) < overProvisionedRatio*float64(rec.Request) && suggested < rec.Request:
+		rec.Action = recommendDecrease
+		rec.Reason = fmt.Sprintf("peak usage per pod (%d%s) stayed below half the request (%d%s)", usage.peak, unit, rec.Request, unit)
+	default:
+		rec.SuggestedRequest = rec.Request
+		rec.Action = recommendKeep
+		rec.Reason = "usage is within the request"
+	}
+	if rec.Limit > 0 && rec.SuggestedRequest > rec.Limit {
+		rec.Reason += fmt.Sprintf("; the suggestion is above the limit (%d%s), which must be raised too", rec.Limit, unit)
+	}
+	return rec
+}
+
+// recommendHPA suggests HPA bounds for a CPU target of
+// hpaTargetCPUUtilization percent of the suggested request: minReplicas
+// serves the average usage seen, maxReplicas covers the peak and at least
+// hpaMaxReplicasFactor times minReplicas.
+func recommendHPA(cpu resourceRecommendation, pods int64) *hpaSettings {
+	perPod := float64(cpu.SuggestedRequest) * hpaTargetCPUUtilization / 100
+	if perPod <= 0 {
+		return nil
+	}
+	minReplicas := max(1, int64(math.Ceil(float64(cpu.AverageUsage*pods)/perPod)))
+	peakReplicas := int64(math.Ceil(float64(cpu.PeakUsage*pods) / perPod))
+	return &hpaSettings{
+		MinReplicas:          minReplicas,
+		MaxReplicas:          max(peakReplicas, minReplicas*hpaMaxReplicasFactor),
+		TargetCPUUtilization: hpaTargetCPUUtilization,
+		Reason: fmt.Sprintf("%d replicas at the average usage seen and %d at the peak, targeting %d%% of a %dm request",
+			minReplicas, peakReplicas, hpaTargetCPUUtilization, cpu.SuggestedRequest),
+	}
+}
+
+// currentHPAs returns the namespace's HPAs by target kind/name. HPAs are
+// optional context, so a missing permission or failed list is reported in
+// resp.HPAError rather than failing the request.
+func (h *RecommendationHandler) currentHPAs(r *http.Request, userID, clusterID, namespace string, resp *recommendationResponse) map[string]hpaSettings {
+	allowed, err := h.canRead(r, userID, clusterID, namespace, "horizontalpodautoscalers")
+	if err != nil || !allowed {
+		resp.HPAError = "insufficient permissions to read horizontalpodautoscalers"
+		return nil
+	}
+	backend, err := h.backendFor(clusterID, hpaGVR)
+	if err == nil {
+		var items []unstructured.Unstructured
+		if items, err = backend.list(r.Context(), namespace, "", ""); err == nil {
+			return parseHPAs(items)
+		}
+	}
+	resp.HPAError = fmt.Sprintf("failed to list horizontalpodautoscalers: %v", err)
+	return nil
+}
+
+// parseHPAs indexes autoscaling/v2 HPAs by their scale target's kind/name.
+func parseHPAs(items []unstructured.Unstructured) map[string]hpaSettings {
+	hpas := make(map[string]hpaSettings, len(items))
+	for _, item := range items {
+		kind, _, _ := unstructured.NestedString(item.Object, "spec", "scaleTargetRef", "kind")
+		name, _, _ := unstructured.NestedString(item.Object, "spec", "scaleTargetRef", "name")
+		hpa := hpaSettings{Name: item.GetName(), MinReplicas: 1}
+		if v, ok, _ := unstructured.NestedInt64(item.Object, "spec", "minReplicas"); ok {
+			hpa.MinReplicas = v
+		}
+		hpa.MaxReplicas, _, _ = unstructured.NestedInt64(item.Object, "spec", "maxReplicas")
+		metrics, _, _ := unstructured.NestedSlice(item.Object, "spec", "metrics")
+		for _, m := range metrics {
+			mm, ok := m.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if res, _, _ := unstructured.NestedString(mm, "resource", "name"); res == "cpu" {
+				hpa.TargetCPUUtilization, _, _ = unstructured.NestedInt64(mm, "resource", "target", "averageUtilization")
+			}
+		}
+		hpas[kind+"/"+name] = hpa
+	}
+	return hpas
+}
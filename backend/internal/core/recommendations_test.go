@@ -0,0 +1,175 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+// fakeReadAccess allows reading the listed Kubernetes resources.
+type fakeReadAccess map[string]bool
+
+func (a fakeReadAccess) Evaluate(ctx context.Context, req rbac.Request) (bool, error) {
+	return req.Action == "read" && a[req.K8sResource], nil
+}
+
+// newRecommendationRouter serves pods, then one metrics sample per call
+// from samples, and hpas.
+func newRecommendationRouter(access fakeReadAccess, pods []unstructured.Unstructured, samples [][]unstructured.Unstructured, hpas []unstructured.Unstructured) *mux.Router {
+	sample := 0
+	h := &RecommendationHandler{
+		authz: access,
+		sleep: func(context.Context, time.Duration) bool { return true },
+		backendFor: func(clusterID string, gvr schema.GroupVersionResource) (resourceBackend, error) {
+			switch gvr {
+			case podsGVR:
+				return listBackend(func() ([]unstructured.Unstructured, error) { return pods, nil }), nil
+			case podMetricsGVR:
+				return listBackend(func() ([]unstructured.Unstructured, error) {
+					if samples == nil {
+						return nil, errors.New("the server could not find the requested resource")
+					}
+					s := samples[sample%len(samples)]
+					sample++
+					return s, nil
+				}), nil
+			}
+			return listBackend(func() ([]unstructured.Unstructured, error) { return hpas, nil }), nil
+		},
+	}
+	r := mux.NewRouter()
+	h.RegisterRoutes(r)
+	return r
+}
+
+func getRecommendations(t *testing.T, r *mux.Router, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/clusters/c1/namespaces/shop/recommendations"+query, nil)
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), &auth.Claims{UserID: "u1"}))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetRecommendations(t *testing.T) {
+	rs := map[string]interface{}{"pod-template-hash": "7d9f8"}
+	pods := []unstructured.Unstructured{
+		testPod("shop", "web-7d9f8-a", "Running", controller("ReplicaSet", "web-7d9f8"), rs, "500m", "1", "512Mi", "1Gi"),
+		testPod("shop", "web-7d9f8-b", "Running", controller("ReplicaSet", "web-7d9f8"), rs, "500m", "1", "512Mi", "1Gi"),
+		testPod("shop", "db-0", "Running", controller("StatefulSet", "db"), nil, "100m", "", "256Mi", "512Mi"),
+		testPod("shop", "migrate-x", "Succeeded", controller("Job", "migrate"), nil, "1", "", "1Gi", ""),
+	}
+	samples := [][]unstructured.Unstructured{
+		{testPodMetrics("shop", "web-7d9f8-a", "100m", "100Mi"), testPodMetrics("shop", "web-7d9f8-b", "140m", "120Mi"), testPodMetrics("shop", "db-0", "150m", "200Mi")},
+		{testPodMetrics("shop", "web-7d9f8-a", "120m", "110Mi"), testPodMetrics("shop", "web-7d9f8-b", "160m", "130Mi"), testPodMetrics("shop", "db-0", "250m", "210Mi")},
+	}
+	hpas := []unstructured.Unstructured{{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web", "namespace": "shop"},
+		"spec": map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{"kind": "Deployment", "name": "web"},
+			"maxReplicas":    int64(10),
+			"metrics": []interface{}{map[string]interface{}{
+				"type":     "Resource",
+				"resource": map[string]interface{}{"name": "cpu", "target": map[string]interface{}{"type": "Utilization", "averageUtilization": int64(80)}},
+			}},
+		},
+	}}}
+
+	r := newRecommendationRouter(fakeReadAccess{"pods": true, "horizontalpodautoscalers": true}, pods, samples, hpas)
+	w := getRecommendations(t, r, "?samples=2&interval=10s")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp recommendationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Advisory || resp.Samples != 2 || resp.WindowSeconds != 10 || !strings.Contains(resp.Note, "Advisory") {
+		t.Errorf("expected an advisory two-sample response, got %+v", resp)
+	}
+	if len(resp.Workloads) != 2 {
+		t.Fatalf("expected the Deployment and StatefulSet only, got %+v", resp.Workloads)
+	}
+
+	web, db := resp.Workloads[0], resp.Workloads[1]
+	if web.Kind != "Deployment" || web.Name != "web" || web.Pods != 2 {
+		t.Fatalf("unexpected first workload %+v", web)
+	}
+	// Peak 160m per pod plus 20% headroom is 192m, rounded up to 195m.
+	if web.CPU.Request != 500 || web.CPU.AverageUsage != 130 || web.CPU.PeakUsage != 160 ||
+		web.CPU.Action != recommendDecrease || web.CPU.SuggestedRequest != 195 {
+		t.Errorf("unexpected web CPU advice %+v", web.CPU)
+	}
+	if web.Memory.Action != recommendDecrease || web.Memory.SuggestedRequest != 156*1024*1024 {
+		t.Errorf("unexpected web memory advice %+v", web.Memory)
+	}
+	// 2 pods averaging 130m need 2 replicas at 70% of 195m; the peak needs 3.
+	if web.HPA == nil || web.HPA.MinReplicas != 2 || web.HPA.MaxReplicas != 6 || web.HPA.TargetCPUUtilization != 70 {
+		t.Errorf("unexpected web HPA advice %+v", web.HPA)
+	}
+	if web.CurrentHPA == nil || web.CurrentHPA.Name != "web" || web.CurrentHPA.MinReplicas != 1 ||
+		web.CurrentHPA.MaxReplicas != 10 || web.CurrentHPA.TargetCPUUtilization != 80 {
+		t.Errorf("unexpected current HPA %+v", web.CurrentHPA)
+	}
+
+	if db.Kind != "StatefulSet" || db.CPU.Action != recommendIncrease || db.CPU.SuggestedRequest != 300 {
+		t.Errorf("unexpected db CPU advice %+v", db.CPU)
+	}
+	if db.Memory.Action != recommendKeep || db.Memory.SuggestedRequest != db.Memory.Request {
+		t.Errorf("unexpected db memory advice %+v", db.Memory)
+	}
+	if db.CurrentHPA != nil {
+		t.Errorf("expected no current HPA for db, got %+v", db.CurrentHPA)
+	}
+
+	// One workload; HPAs are left out without permission to read them.
+	r = newRecommendationRouter(fakeReadAccess{"pods": true}, pods, samples, hpas)
+	w = getRecommendations(t, r, "?kind=StatefulSet&name=db&samples=1")
+	resp = recommendationResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Workloads) != 1 || resp.Workloads[0].Name != "db" || resp.HPAError == "" {
+		t.Errorf("expected db only with an HPA error, got %+v", resp)
+	}
+}
+
+func TestGetRecommendations_Errors(t *testing.T) {
+	pods := []unstructured.Unstructured{testPod("shop", "db-0", "Running", controller("StatefulSet", "db"), nil, "100m", "", "256Mi", "")}
+
+	tests := []struct {
+		name     string
+		access   fakeReadAccess
+		samples  [][]unstructured.Unstructured
+		query    string
+		wantCode int
+	}{
+		{"no pod access", fakeReadAccess{}, nil, "", http.StatusForbidden},
+		{"kind without name", fakeReadAccess{"pods": true}, nil, "?kind=Deployment", http.StatusBadRequest},
+		{"too many samples", fakeReadAccess{"pods": true}, nil, "?samples=11", http.StatusBadRequest},
+		{"interval too short", fakeReadAccess{"pods": true}, nil, "?interval=1s", http.StatusBadRequest},
+		{"window too long", fakeReadAccess{"pods": true}, nil, "?samples=10&interval=1m", http.StatusBadRequest},
+		{"unknown workload", fakeReadAccess{"pods": true}, nil, "?kind=Deployment&name=web", http.StatusNotFound},
+		{"no metrics-server", fakeReadAccess{"pods": true}, nil, "", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRecommendationRouter(tt.access, pods, tt.samples, nil)
+			if w := getRecommendations(t, r, tt.query); w.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+		})
+	}
+}
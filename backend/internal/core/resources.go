@@ -1,22 +1,29 @@
 package core
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
-	"regexp"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/darkden-lab/argus/backend/internal/auth"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
 	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
 	"github.com/darkden-lab/argus/backend/pkg/agentpb"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
 )
 
 // agentProxyTimeout is the maximum time to wait for an agent to respond.
@@ -25,25 +32,33 @@ const agentProxyTimeout = 30 * time.Second
 // maxRequestBodySize limits request body size for Create/Update operations.
 const maxRequestBodySize = 2 * 1024 * 1024 // 2MB
 
-// k8sNameSegment validates a single Kubernetes path segment (namespace, name, resource, version, group).
-// Rejects path traversal characters (/, ..) and empty strings.
-var k8sNameSegment = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9.\-]{0,252}$`)
-
-func isValidK8sSegment(s string) bool {
-	if s == "" {
-		return true // empty is allowed (optional namespace/name)
-	}
-	return k8sNameSegment.MatchString(s) && !strings.Contains(s, "..")
-}
+// editorFieldManagerQuery attributes editor writes proxied to agents.
+const editorFieldManagerQuery = "?fieldManager=" + cluster.FieldManagerEditor
 
 // ResourceHandler handles generic CRUD operations on any K8s resource
 // using the dynamic client. Group "_" is treated as the core group (empty string).
 type ResourceHandler struct {
-	clusterMgr *cluster.Manager
+	clusterMgr  *cluster.Manager
+	discovery   *DiscoveryCache
+	applyPolicy cluster.ApplyPolicy
+	authz       rbac.Evaluator
+}
+
+// NewResourceHandler creates the generic resource handler. When discovery is
+// non-nil, requests are checked against the resource types the cluster
+// serves; see scopedNamespace.
+func NewResourceHandler(cm *cluster.Manager, discovery *DiscoveryCache) *ResourceHandler {
+	return &ResourceHandler{clusterMgr: cm, discovery: discovery}
 }
 
-func NewResourceHandler(cm *cluster.Manager) *ResourceHandler {
-	return &ResourceHandler{clusterMgr: cm}
+// SetApplyPolicy sets the labels, annotations and namespace creation applied
+// by Apply. engine checks that the user may create a missing namespace;
+// without it namespaces are never created.
+func (h *ResourceHandler) SetApplyPolicy(policy cluster.ApplyPolicy, engine *rbac.Engine) {
+	h.applyPolicy = policy
+	if engine != nil {
+		h.authz = engine
+	}
 }
 
 // RegisterRoutes wires the generic resource CRUD routes.
@@ -54,6 +69,8 @@ func (h *ResourceHandler) RegisterRoutes(r *mux.Router) {
 	base.HandleFunc("", h.Create).Methods(http.MethodPost)
 	base.HandleFunc("/{name}", h.Get).Methods(http.MethodGet)
 	base.HandleFunc("/{name}", h.Update).Methods(http.MethodPut)
+	base.HandleFunc("/{name}", h.Patch).Methods(http.MethodPatch)
+	base.HandleFunc("/{name}/apply", h.Apply).Methods(http.MethodPost)
 	base.HandleFunc("/{name}", h.Delete).Methods(http.MethodDelete)
 }
 
@@ -95,31 +112,46 @@ func k8sAPIPath(gvr schema.GroupVersionResource, namespace, name string) string
 // the raw JSON response. This is the shared implementation used by both
 // ResourceHandler and ConvenienceHandlers to avoid duplicating proxy logic.
 func proxyAgentResponse(w http.ResponseWriter, r *http.Request, mgr *cluster.Manager, clusterID string, req *agentpb.K8SRequest) {
-	agentSrv := mgr.GetAgentServer()
-	if agentSrv == nil || !agentSrv.IsAgentConnected(clusterID) {
-		httputil.WriteError(w, http.StatusNotFound, "cluster not found or agent not connected")
+	if err := mgr.CheckAgent(r.Context(), clusterID); err != nil {
+		writeAgentError(w, err)
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), agentProxyTimeout)
 	defer cancel()
 
-	resp, err := agentSrv.SendK8sRequest(ctx, clusterID, req)
+	resp, err := mgr.GetAgentServer().SendK8sRequestRaw(ctx, clusterID, req)
 	if err != nil {
-		httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("agent request failed: %v", err))
+		writeAgentError(w, err)
 		return
 	}
 
+	// Error statuses with a body are passed through below, as the API
+	// server's Status object; only failures of the round trip itself are
+	// reported here.
 	if resp.Error != "" {
-		status := int(resp.StatusCode)
-		if status == 0 {
-			status = http.StatusInternalServerError
-		}
-		httputil.WriteError(w, status, resp.Error)
+		writeAgentError(w, cluster.ResponseError(resp))
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	// Gzip bodies are passed through to clients that accept gzip; anything
+	// else is decompressed here.
+	if resp.Compressed && !(resp.ContentEncoding == agentpb.EncodingGzip && acceptsGzip(r)) {
+		if err := resp.DecompressBody(); err != nil {
+			httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("agent response could not be decompressed: %v", err))
+			return
+		}
+	}
+
+	contentType := resp.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept-Encoding")
+	if resp.Compressed {
+		w.Header().Set("Content-Encoding", resp.ContentEncoding)
+	}
 	status := int(resp.StatusCode)
 	if status == 0 {
 		status = http.StatusOK
@@ -128,20 +160,93 @@ func proxyAgentResponse(w http.ResponseWriter, r *http.Request, mgr *cluster.Man
 	w.Write(resp.Body) //nolint:errcheck
 }
 
+// writeAgentError reports a failed agent round trip; see writeClusterError.
+func writeAgentError(w http.ResponseWriter, err error) {
+	writeClusterError(w, err, "agent request failed")
+}
+
+// writeClusterError reports a failed request to a cluster with a status
+// saying what failed: 404 for an unknown cluster, 503 while its agent is
+// offline or overloaded, 504 when it did not answer in time and the API
+// server's own status for its errors. Anything else is a 502 whose message
+// starts with msg.
+func writeClusterError(w http.ResponseWriter, err error, msg string) {
+	var reqErr *cluster.K8sRequestError
+	switch {
+	case errors.Is(err, cluster.ErrAgentNotConnected):
+		httputil.WriteErrorCode(w, http.StatusServiceUnavailable, httputil.CodeAgentNotConnected, "cluster agent is not connected")
+	case errors.Is(err, cluster.ErrAgentDisconnected):
+		httputil.WriteErrorCode(w, http.StatusServiceUnavailable, httputil.CodeAgentNotConnected, "cluster agent disconnected before responding")
+	case errors.Is(err, cluster.ErrClusterUnavailable):
+		httputil.WriteErrorCode(w, http.StatusNotFound, httputil.CodeNotConnected, "cluster not found or not connected")
+	case errors.Is(err, context.DeadlineExceeded):
+		httputil.WriteErrorCode(w, http.StatusGatewayTimeout, httputil.CodeAgentTimeout, msg+": the cluster did not respond in time")
+	case errors.Is(err, cluster.ErrAgentOverloaded):
+		w.Header().Set("Retry-After", "1")
+		httputil.WriteErrorCode(w, http.StatusServiceUnavailable, httputil.CodeAgentOverloaded, "agent has too many requests in flight; retry shortly")
+	case errors.As(err, &reqErr) && reqErr.StatusCode != http.StatusUnauthorized:
+		httputil.WriteError(w, reqErr.StatusCode, reqErr.Message)
+	case errors.As(err, &reqErr):
+		// A 401 means the API server rejected the dashboard's own
+		// credentials; passed through, it would log the user out.
+		httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("%s: the cluster rejected the dashboard's credentials: %s", msg, reqErr.Message))
+	default:
+		httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("%s: %v", msg, err))
+	}
+}
+
+// acceptsGzip reports whether the client listed gzip in Accept-Encoding.
+func acceptsGzip(r *http.Request) bool {
+	for _, v := range r.Header.Values("Accept-Encoding") {
+		for _, enc := range strings.Split(v, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+			if strings.EqualFold(strings.TrimSpace(name), "gzip") && strings.TrimSpace(params) != "q=0" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // validatePathSegments checks that namespace and name values are safe for K8s API path construction.
 func validatePathSegments(w http.ResponseWriter, namespace, name string) bool {
-	if !isValidK8sSegment(namespace) {
+	if !validate.Segment(namespace) {
 		httputil.WriteError(w, http.StatusBadRequest, "invalid namespace")
 		return false
 	}
-	if !isValidK8sSegment(name) {
+	if !validate.Segment(name) {
 		httputil.WriteError(w, http.StatusBadRequest, "invalid resource name")
 		return false
 	}
 	return true
 }
 
+// scopedNamespace resolves the namespace to use for gvr. Resource types the
+// cluster doesn't serve are rejected with 404, and the namespace is dropped
+// for cluster-scoped types, so any resource, custom ones included, can be
+// browsed through the same URLs. If discovery is unavailable the namespace is
+// used as given and the API server has the final say.
+func (h *ResourceHandler) scopedNamespace(w http.ResponseWriter, r *http.Request, clusterID string, gvr schema.GroupVersionResource, namespace string) (string, bool) {
+	if h.discovery == nil {
+		return namespace, true
+	}
+	res, found, err := h.discovery.Lookup(r.Context(), clusterID, gvr)
+	if err != nil {
+		return namespace, true
+	}
+	if !found {
+		httputil.WriteError(w, http.StatusNotFound, fmt.Sprintf("resource type %s is not served by this cluster", gvr.String()))
+		return "", false
+	}
+	if !res.Namespaced {
+		return "", true
+	}
+	return namespace, true
+}
+
 // List returns a JSON array of resources matching the optional ?namespace= query param.
+// Pods carry a kubectl-style status summary and custom resources the columns
+// of their CRD; see decorateList.
 func (h *ResourceHandler) List(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	clusterID := vars["clusterID"]
@@ -150,9 +255,17 @@ func (h *ResourceHandler) List(w http.ResponseWriter, r *http.Request) {
 	if !validatePathSegments(w, namespace, "") {
 		return
 	}
+	namespace, ok := h.scopedNamespace(w, r, clusterID, gvr, namespace)
+	if !ok {
+		return
+	}
 
 	client, err := h.clusterMgr.GetClient(clusterID)
 	if err != nil {
+		if listIsDecorated(gvr) {
+			h.listViaAgent(w, r, clusterID, gvr, namespace)
+			return
+		}
 		// Fallback to agent proxy.
 		proxyAgentResponse(w, r, h.clusterMgr, clusterID, &agentpb.K8SRequest{
 			Method: "GET",
@@ -166,10 +279,56 @@ func (h *ResourceHandler) List(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	h.decorateList(r.Context(), clusterID, gvr, list)
 
 	httputil.WriteJSON(w, http.StatusOK, list)
 }
 
+// listIsDecorated reports whether decorateList may add to lists of gvr, so
+// they cannot be proxied from an agent as they are.
+func listIsDecorated(gvr schema.GroupVersionResource) bool {
+	return gvr == podsGVR || strings.Contains(gvr.Group, ".")
+}
+
+// decorateList adds what Argus computes to a list: status summaries to pods
+// and table columns to custom resources. Failing to read a CRD only costs the
+// columns.
+func (h *ResourceHandler) decorateList(ctx context.Context, clusterID string, gvr schema.GroupVersionResource, list *unstructured.UnstructuredList) {
+	if gvr == podsGVR {
+		addPodSummaries(list)
+		return
+	}
+	columns, ok, err := h.crdPrinterColumns(ctx, clusterID, gvr)
+	if err != nil {
+		log.Printf("core: failed to read printer columns of %s: %v", gvr.String(), err)
+		return
+	}
+	if ok {
+		addPrinterColumns(list, columns)
+	}
+}
+
+// listViaAgent lists resources through the cluster's agent and decorates
+// them, which a raw proxied response would not be.
+func (h *ResourceHandler) listViaAgent(w http.ResponseWriter, r *http.Request, clusterID string, gvr schema.GroupVersionResource, namespace string) {
+	ctx, cancel := context.WithTimeout(r.Context(), agentProxyTimeout)
+	defer cancel()
+
+	body, err := h.clusterMgr.DoK8sRequest(ctx, clusterID, http.MethodGet, k8sAPIPath(gvr, namespace, ""), nil)
+	if err != nil {
+		writeClusterError(w, err, "failed to list "+gvr.Resource)
+		return
+	}
+
+	list := &unstructured.UnstructuredList{}
+	if err := list.UnmarshalJSON(body); err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("invalid %s list from agent: %v", gvr.Resource, err))
+		return
+	}
+	h.decorateList(ctx, clusterID, gvr, list)
+	httputil.WriteJSON(w, http.StatusOK, list)
+}
+
 // Get returns a single named resource.
 func (h *ResourceHandler) Get(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -180,6 +339,10 @@ func (h *ResourceHandler) Get(w http.ResponseWriter, r *http.Request) {
 	if !validatePathSegments(w, namespace, name) {
 		return
 	}
+	namespace, ok := h.scopedNamespace(w, r, clusterID, gvr, namespace)
+	if !ok {
+		return
+	}
 
 	client, err := h.clusterMgr.GetClient(clusterID)
 	if err != nil {
@@ -208,8 +371,12 @@ func (h *ResourceHandler) Create(w http.ResponseWriter, r *http.Request) {
 	if !validatePathSegments(w, namespace, "") {
 		return
 	}
+	namespace, ok := h.scopedNamespace(w, r, clusterID, gvr, namespace)
+	if !ok {
+		return
+	}
 
-	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize))
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
 	if err != nil {
 		httputil.WriteError(w, http.StatusBadRequest, "failed to read request body")
 		return
@@ -219,7 +386,7 @@ func (h *ResourceHandler) Create(w http.ResponseWriter, r *http.Request) {
 	if clientErr != nil {
 		proxyAgentResponse(w, r, h.clusterMgr, clusterID, &agentpb.K8SRequest{
 			Method: "POST",
-			Path:   k8sAPIPath(gvr, namespace, ""),
+			Path:   k8sAPIPath(gvr, namespace, "") + editorFieldManagerQuery,
 			Body:   body,
 		})
 		return
@@ -231,7 +398,7 @@ func (h *ResourceHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	created, err := client.DynClient.Resource(gvr).Namespace(namespace).Create(r.Context(), &obj, metav1.CreateOptions{})
+	created, err := client.DynClient.Resource(gvr).Namespace(namespace).Create(r.Context(), &obj, metav1.CreateOptions{FieldManager: cluster.FieldManagerEditor})
 	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -250,8 +417,12 @@ func (h *ResourceHandler) Update(w http.ResponseWriter, r *http.Request) {
 	if !validatePathSegments(w, namespace, name) {
 		return
 	}
+	namespace, ok := h.scopedNamespace(w, r, clusterID, gvr, namespace)
+	if !ok {
+		return
+	}
 
-	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize))
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
 	if err != nil {
 		httputil.WriteError(w, http.StatusBadRequest, "failed to read request body")
 		return
@@ -261,7 +432,7 @@ func (h *ResourceHandler) Update(w http.ResponseWriter, r *http.Request) {
 	if clientErr != nil {
 		proxyAgentResponse(w, r, h.clusterMgr, clusterID, &agentpb.K8SRequest{
 			Method: "PUT",
-			Path:   k8sAPIPath(gvr, namespace, name),
+			Path:   k8sAPIPath(gvr, namespace, name) + editorFieldManagerQuery,
 			Body:   body,
 		})
 		return
@@ -273,7 +444,7 @@ func (h *ResourceHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updated, err := client.DynClient.Resource(gvr).Namespace(namespace).Update(r.Context(), &obj, metav1.UpdateOptions{})
+	updated, err := client.DynClient.Resource(gvr).Namespace(namespace).Update(r.Context(), &obj, metav1.UpdateOptions{FieldManager: cluster.FieldManagerEditor})
 	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -282,6 +453,209 @@ func (h *ResourceHandler) Update(w http.ResponseWriter, r *http.Request) {
 	httputil.WriteJSON(w, http.StatusOK, updated)
 }
 
+// Apply applies the YAML or JSON object in the body with server-side apply,
+// creating or updating it as the editor's field manager. Fields owned by
+// other managers are only taken over with ?force=true; otherwise such a
+// conflict is answered 409 with the conflicting fields and their owners.
+// The apply policy's labels and annotations are added to the object, and a
+// missing namespace is created if the policy allows it.
+func (h *ResourceHandler) Apply(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["clusterID"]
+	name := vars["name"]
+	gvr := gvrFromVars(vars)
+	namespace := r.URL.Query().Get("namespace")
+	if !validatePathSegments(w, namespace, name) {
+		return
+	}
+	namespace, ok := h.scopedNamespace(w, r, clusterID, gvr, namespace)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	var obj unstructured.Unstructured
+	if err := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(body), 4096).Decode(&obj.Object); err != nil || obj.Object == nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if obj.GetName() == "" {
+		obj.SetName(name)
+	}
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(namespace)
+	}
+	if obj.GetName() != name || obj.GetNamespace() != namespace {
+		httputil.WriteError(w, http.StatusBadRequest, "metadata.name and metadata.namespace must match the request path")
+		return
+	}
+
+	var userID string
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+		userID = claims.UserID
+	}
+	h.applyPolicy.Decorate(&obj, userID)
+	if _, err := h.applyPolicy.EnsureNamespace(r.Context(), h.clusterMgr, clusterID, namespace, cluster.FieldManagerEditor, userID, func() error {
+		return h.authorizeNamespaceCreate(r.Context(), userID, clusterID, namespace)
+	}); err != nil {
+		var denied *namespaceCreateDenied
+		if errors.As(err, &denied) {
+			httputil.WriteError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		writeApplyError(w, err)
+		return
+	}
+
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	query := url.Values{"fieldManager": {cluster.FieldManagerEditor}}
+	if r.URL.Query().Get("force") == "true" {
+		query.Set("force", "true")
+	}
+	path := k8sAPIPath(gvr, namespace, name) + "?" + query.Encode()
+	applied, err := h.clusterMgr.DoK8sRequestWithContentType(r.Context(), clusterID, http.MethodPatch, path, "application/apply-patch+yaml", data)
+	if err != nil {
+		writeApplyError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(applied) //nolint:errcheck
+}
+
+// namespaceCreateDenied is returned when an apply would create a namespace
+// the user may not create.
+type namespaceCreateDenied struct {
+	namespace string
+}
+
+func (e *namespaceCreateDenied) Error() string {
+	return fmt.Sprintf("namespace %q does not exist and you may not create namespaces", e.namespace)
+}
+
+// authorizeNamespaceCreate checks that the user may create namespaces on
+// the cluster, which is cluster-wide write access to namespaces.
+func (h *ResourceHandler) authorizeNamespaceCreate(ctx context.Context, userID, clusterID, namespace string) error {
+	if h.authz == nil || userID == "" {
+		return &namespaceCreateDenied{namespace: namespace}
+	}
+	allowed, err := h.authz.Evaluate(ctx, rbac.Request{
+		UserID:      userID,
+		Action:      "write",
+		Resource:    rbac.ResourceCategory("namespaces"),
+		ClusterID:   clusterID,
+		K8sResource: rbac.QualifiedResource("", "namespaces"),
+	})
+	if err != nil {
+		return fmt.Errorf("permission check failed: %w", err)
+	}
+	if !allowed {
+		return &namespaceCreateDenied{namespace: namespace}
+	}
+	return nil
+}
+
+// writeApplyError reports a failed server-side apply or patch. Field
+// ownership conflicts carry the conflicting fields and their managers as
+// details.
+func writeApplyError(w http.ResponseWriter, err error) {
+	if conflict, ok := cluster.AsApplyConflict(err); ok {
+		httputil.WriteErrorDetails(w, http.StatusConflict, httputil.CodeConflict, conflict.Error(), conflict.Conflicts)
+		return
+	}
+	writeClusterError(w, err, "request to the cluster failed")
+}
+
+// Patch applies the JSON patch, JSON merge patch or strategic merge patch in
+// the body, as named by its Content-Type, to an existing resource. The user
+// needs write access to the resource in its namespace. The patch is
+// validated with a server-side dry run before it is applied; with
+// ?dryRun=true it is only validated and the resulting object returned.
+func (h *ResourceHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["clusterID"]
+	name := vars["name"]
+	gvr := gvrFromVars(vars)
+	namespace := r.URL.Query().Get("namespace")
+	if !validatePathSegments(w, namespace, name) {
+		return
+	}
+	patchType, ok := cluster.PatchTypeFor(r.Header.Get("Content-Type"))
+	if !ok {
+		httputil.WriteError(w, http.StatusUnsupportedMediaType,
+			"Content-Type must be application/json-patch+json, application/merge-patch+json or application/strategic-merge-patch+json")
+		return
+	}
+	namespace, ok = h.scopedNamespace(w, r, clusterID, gvr, namespace)
+	if !ok {
+		return
+	}
+
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if h.authz == nil {
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+	allowed, err := h.authz.Evaluate(r.Context(), rbac.Request{
+		UserID:      claims.UserID,
+		Action:      "write",
+		Resource:    rbac.ResourceCategory(gvr.Resource),
+		ClusterID:   clusterID,
+		Namespace:   namespace,
+		K8sResource: rbac.QualifiedResource(gvr.Group, gvr.Resource),
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+		return
+	}
+	if !allowed {
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	if !json.Valid(body) {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	patched, err := cluster.PatchResource(r.Context(), h.clusterMgr, clusterID, cluster.PatchRequest{
+		Resource:     gvr,
+		Namespace:    namespace,
+		Name:         name,
+		Type:         patchType,
+		Patch:        body,
+		FieldManager: cluster.FieldManagerEditor,
+		DryRun:       r.URL.Query().Get("dryRun") == "true",
+	})
+	if err != nil {
+		writeApplyError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(patched) //nolint:errcheck
+}
+
 // Delete removes a named resource.
 func (h *ResourceHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -292,6 +666,10 @@ func (h *ResourceHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	if !validatePathSegments(w, namespace, name) {
 		return
 	}
+	namespace, ok := h.scopedNamespace(w, r, clusterID, gvr, namespace)
+	if !ok {
+		return
+	}
 
 	client, err := h.clusterMgr.GetClient(clusterID)
 	if err != nil {
@@ -1,9 +1,22 @@
 package core
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/gorilla/mux"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
 )
 
 func TestGvrFromVars_CoreGroup(t *testing.T) {
@@ -56,8 +69,96 @@ func TestGvrFromVars_BatchGroup(t *testing.T) {
 }
 
 func TestNewResourceHandler(t *testing.T) {
-	h := NewResourceHandler(nil)
+	h := NewResourceHandler(nil, nil)
 	if h == nil {
 		t.Fatal("expected non-nil ResourceHandler")
 	}
 }
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, gzip;q=0.8", true},
+		{"br, GZIP", true},
+		{"gzip;q=0", false},
+		{"zstd", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tt.header != "" {
+			r.Header.Set("Accept-Encoding", tt.header)
+		}
+		if got := acceptsGzip(r); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestWriteAgentError(t *testing.T) {
+	tests := []struct {
+		err        error
+		wantStatus int
+		wantCode   httputil.ErrorCode
+	}{
+		{fmt.Errorf("waiting for agent: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, httputil.CodeAgentTimeout},
+		{errors.New("failed to send request to agent"), http.StatusBadGateway, httputil.CodeUpstreamError},
+		{fmt.Errorf("cluster c1: %w", cluster.ErrAgentNotConnected), http.StatusServiceUnavailable, httputil.CodeAgentNotConnected},
+		{cluster.ErrAgentDisconnected, http.StatusServiceUnavailable, httputil.CodeAgentNotConnected},
+		{cluster.ErrClusterUnavailable, http.StatusNotFound, httputil.CodeNotConnected},
+		{cluster.ErrAgentOverloaded, http.StatusServiceUnavailable, httputil.CodeAgentOverloaded},
+		{fmt.Errorf("%w: %w", cluster.ErrAgentTimeout, context.DeadlineExceeded), http.StatusGatewayTimeout, httputil.CodeAgentTimeout},
+		{&cluster.K8sRequestError{StatusCode: http.StatusNotFound, Message: "pods \"web\" not found"}, http.StatusNotFound, httputil.CodeNotFound},
+		{&cluster.K8sRequestError{StatusCode: http.StatusUnauthorized, Message: "Unauthorized"}, http.StatusBadGateway, httputil.CodeUpstreamError},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		writeAgentError(w, tt.err)
+		if w.Code != tt.wantStatus {
+			t.Errorf("%v: expected status %d, got %d", tt.err, tt.wantStatus, w.Code)
+		}
+		var body httputil.ErrorResponse
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if body.Code != tt.wantCode {
+			t.Errorf("%v: expected code %q, got %q", tt.err, tt.wantCode, body.Code)
+		}
+	}
+}
+
+func TestResourceHandlerPatch_Rejected(t *testing.T) {
+	tests := []struct {
+		name        string
+		authz       rbac.Evaluator
+		contentType string
+		wantStatus  int
+	}{
+		{"unsupported content type", writeNamespaces{"default": true}, "application/json", http.StatusUnsupportedMediaType},
+		{"server-side apply", writeNamespaces{"default": true}, "application/apply-patch+yaml", http.StatusUnsupportedMediaType},
+		{"no authorizer", nil, "application/merge-patch+json", http.StatusForbidden},
+		{"no write access", writeNamespaces{"team-a": true}, "application/json-patch+json", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewResourceHandler(nil, nil)
+			h.authz = tt.authz
+			r := mux.NewRouter()
+			h.RegisterRoutes(r)
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/clusters/c1/resources/apps/v1/deployments/web?namespace=default",
+				strings.NewReader(`{"spec":{"replicas":2}}`))
+			req.Header.Set("Content-Type", tt.contentType)
+			req = req.WithContext(auth.ContextWithClaims(req.Context(), &auth.Claims{UserID: "u1"}))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
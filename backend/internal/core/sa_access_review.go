@@ -0,0 +1,219 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+const (
+	// maxAccessReviewChecks caps the verbs × resources matrix of one request;
+	// each cell is one SubjectAccessReview.
+	maxAccessReviewChecks = 100
+	maxAccessReviewVerbs  = 10
+)
+
+// defaultAccessReviewVerbs are checked when the request names no verbs.
+var defaultAccessReviewVerbs = []string{"get", "list", "watch", "create", "update", "patch", "delete"}
+
+// accessReviewVerb matches a Kubernetes verb such as "get" or "*".
+var accessReviewVerb = regexp.MustCompile(`^([a-z]{1,63}|\*)$`)
+
+// ServiceAccountAccessHandler answers "what can this ServiceAccount do" with
+// SubjectAccessReviews run as the ServiceAccount, the same decision the API
+// server makes when a pod using it calls the API.
+type ServiceAccountAccessHandler struct {
+	k8s   rbac.K8sRequester
+	authz rbac.Evaluator
+}
+
+// NewServiceAccountAccessHandler creates a new ServiceAccountAccessHandler.
+func NewServiceAccountAccessHandler(cm *cluster.Manager, engine *rbac.Engine) *ServiceAccountAccessHandler {
+	return &ServiceAccountAccessHandler{k8s: cm, authz: engine}
+}
+
+// RegisterRoutes wires the ServiceAccount access review endpoint.
+func (h *ServiceAccountAccessHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clusters/{clusterID}/namespaces/{namespace}/serviceaccounts/{name}/access-review", h.Review).Methods(http.MethodPost)
+}
+
+// accessReviewResource is one row of the matrix. Namespace defaults to the
+// ServiceAccount's namespace; "*" checks all namespaces.
+type accessReviewResource struct {
+	Group       string `json:"group"`
+	Resource    string `json:"resource"`
+	Subresource string `json:"subresource,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+}
+
+// accessReviewRequest is the JSON body accepted by the access review endpoint.
+type accessReviewRequest struct {
+	Verbs     []string               `json:"verbs"`
+	Resources []accessReviewResource `json:"resources"`
+}
+
+// accessDecision is one cell of the matrix.
+type accessDecision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// accessReviewRow holds the decisions for one resource, keyed by verb.
+type accessReviewRow struct {
+	accessReviewResource
+	Verbs map[string]accessDecision `json:"verbs"`
+}
+
+// accessReviewResponse is the allow/deny matrix of a ServiceAccount.
+type accessReviewResponse struct {
+	ServiceAccount string            `json:"serviceAccount"`
+	Verbs          []string          `json:"verbs"`
+	Resources      []accessReviewRow `json:"resources"`
+}
+
+// validate checks the request and fills in the default verbs and namespaces.
+func (req *accessReviewRequest) validate(namespace string) error {
+	if len(req.Verbs) == 0 {
+		req.Verbs = defaultAccessReviewVerbs
+	}
+	if len(req.Verbs) > maxAccessReviewVerbs {
+		return fmt.Errorf("at most %d verbs may be checked at once", maxAccessReviewVerbs)
+	}
+	for _, verb := range req.Verbs {
+		if !accessReviewVerb.MatchString(verb) {
+			return fmt.Errorf("invalid verb %q", verb)
+		}
+	}
+	if len(req.Resources) == 0 {
+		return fmt.Errorf("resources is required")
+	}
+	if len(req.Verbs)*len(req.Resources) > maxAccessReviewChecks {
+		return fmt.Errorf("at most %d verb and resource combinations may be checked at once", maxAccessReviewChecks)
+	}
+	for i := range req.Resources {
+		res := &req.Resources[i]
+		if res.Group == "_" {
+			res.Group = ""
+		}
+		switch res.Namespace {
+		case "":
+			res.Namespace = namespace
+		case "*":
+			res.Namespace = ""
+		}
+		if res.Resource == "" {
+			return fmt.Errorf("each resource requires resource")
+		}
+		if res.Resource != "*" && !validate.Segment(res.Resource) {
+			return fmt.Errorf("invalid resource %q", res.Resource)
+		}
+		if (res.Group != "*" && !validate.Segment(res.Group)) || !validate.Segment(res.Subresource) ||
+			!validate.Segment(res.Name) || !validate.Segment(res.Namespace) {
+			return fmt.Errorf("invalid group, subresource, name, or namespace")
+		}
+	}
+	return nil
+}
+
+// serviceAccountSubject returns the user name and groups the API server
+// authenticates a ServiceAccount's tokens as.
+func serviceAccountSubject(namespace, name string) (string, []string) {
+	return "system:serviceaccount:" + namespace + ":" + name,
+		[]string{"system:serviceaccounts", "system:serviceaccounts:" + namespace, "system:authenticated"}
+}
+
+// Review checks each verb against each resource as the ServiceAccount and
+// returns the allow/deny matrix. The caller needs read access to
+// ServiceAccounts in its namespace. The dashboard's own cluster identity must
+// be allowed to create SubjectAccessReviews; when it is not, the endpoint
+// answers 501 and says so.
+func (h *ServiceAccountAccessHandler) Review(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	clusterID, namespace, name := vars["clusterID"], vars["namespace"], vars["name"]
+	if !validatePathSegments(w, namespace, name) {
+		return
+	}
+
+	var req accessReviewRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize)).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := req.validate(namespace); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	allowed, err := h.authz.Evaluate(r.Context(), rbac.Request{
+		UserID:      claims.UserID,
+		Action:      "read",
+		Resource:    rbac.ResourceCategory("serviceaccounts"),
+		ClusterID:   clusterID,
+		Namespace:   namespace,
+		K8sResource: "serviceaccounts",
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+		return
+	}
+	if !allowed {
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
+		return
+	}
+
+	user, groups := serviceAccountSubject(namespace, name)
+	resp := accessReviewResponse{
+		ServiceAccount: user,
+		Verbs:          req.Verbs,
+		Resources:      make([]accessReviewRow, 0, len(req.Resources)),
+	}
+	for _, res := range req.Resources {
+		row := accessReviewRow{accessReviewResource: res, Verbs: make(map[string]accessDecision, len(req.Verbs))}
+		for _, verb := range req.Verbs {
+			decision, err := rbac.SubjectAccessReview(r.Context(), h.k8s, clusterID, user, groups, rbac.ResourceAttributes{
+				Namespace:   res.Namespace,
+				Verb:        verb,
+				Group:       res.Group,
+				Resource:    res.Resource,
+				Subresource: res.Subresource,
+				Name:        res.Name,
+			})
+			if err != nil {
+				var reqErr *cluster.K8sRequestError
+				switch {
+				case errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusForbidden:
+					httputil.WriteError(w, http.StatusNotImplemented,
+						"the dashboard is not allowed to create subjectaccessreviews in this cluster; grant its cluster identity create on subjectaccessreviews.authorization.k8s.io")
+					return
+				case errors.Is(err, cluster.ErrClusterUnavailable):
+					writeClusterError(w, err, "access review failed")
+					return
+				}
+				row.Verbs[verb] = accessDecision{Error: err.Error()}
+				continue
+			}
+			row.Verbs[verb] = accessDecision{Allowed: decision.Allowed, Reason: decision.Reason}
+		}
+		resp.Resources = append(resp.Resources, row)
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
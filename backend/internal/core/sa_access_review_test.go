@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+// fakeReviewCluster answers SubjectAccessReviews from a fixed set of allowed
+// "verb resource@namespace" keys and records the reviewed subjects.
+type fakeReviewCluster struct {
+	allowed   map[string]bool
+	forbidden bool
+	users     []string
+	groups    [][]string
+}
+
+func (c *fakeReviewCluster) DoK8sRequest(ctx context.Context, clusterID, method, path string, body []byte) ([]byte, error) {
+	if c.forbidden {
+		return nil, &cluster.K8sRequestError{StatusCode: http.StatusForbidden, Message: "cannot create subjectaccessreviews"}
+	}
+	var review struct {
+		Spec struct {
+			User               string                  `json:"user"`
+			Groups             []string                `json:"groups"`
+			ResourceAttributes rbac.ResourceAttributes `json:"resourceAttributes"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(body, &review); err != nil {
+		return nil, err
+	}
+	c.users = append(c.users, review.Spec.User)
+	c.groups = append(c.groups, review.Spec.Groups)
+	attrs := review.Spec.ResourceAttributes
+	allowed := c.allowed[attrs.Verb+" "+attrs.Resource+"@"+attrs.Namespace]
+	return json.Marshal(map[string]interface{}{"status": map[string]interface{}{"allowed": allowed}})
+}
+
+// readNamespaces grants read in the listed namespaces only.
+type readNamespaces map[string]bool
+
+func (a readNamespaces) Evaluate(ctx context.Context, req rbac.Request) (bool, error) {
+	return req.Action == "read" && a[req.Namespace], nil
+}
+
+func accessReviewRequestTo(t *testing.T, k8s *fakeReviewCluster, authz readNamespaces, namespace, body string) (*httptest.ResponseRecorder, accessReviewResponse) {
+	t.Helper()
+	r := mux.NewRouter()
+	(&ServiceAccountAccessHandler{k8s: k8s, authz: authz}).RegisterRoutes(r)
+	req := httptest.NewRequest(http.MethodPost, "/api/clusters/c1/namespaces/"+namespace+"/serviceaccounts/builder/access-review", strings.NewReader(body))
+	req = req.WithContext(auth.ContextWithClaims(req.Context(), &auth.Claims{UserID: "u1"}))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp accessReviewResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestServiceAccountAccessReview_Matrix(t *testing.T) {
+	k8s := &fakeReviewCluster{allowed: map[string]bool{"get pods@ci": true, "list secrets@": true}}
+	w, resp := accessReviewRequestTo(t, k8s, readNamespaces{"ci": true}, "ci",
+		`{"verbs":["get","delete"],"resources":[{"group":"","resource":"pods"},{"group":"","resource":"secrets","namespace":"*"}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.ServiceAccount != "system:serviceaccount:ci:builder" {
+		t.Errorf("unexpected subject %q", resp.ServiceAccount)
+	}
+	if len(resp.Resources) != 2 {
+		t.Fatalf("expected 2 rows, got %+v", resp.Resources)
+	}
+	pods := resp.Resources[0]
+	if pods.Namespace != "ci" || !pods.Verbs["get"].Allowed || pods.Verbs["delete"].Allowed {
+		t.Errorf("expected get but not delete on pods in ci: %+v", pods)
+	}
+	if secrets := resp.Resources[1]; secrets.Namespace != "" || secrets.Verbs["get"].Allowed {
+		t.Errorf("expected secrets checked cluster-wide and get denied: %+v", secrets)
+	}
+	if len(k8s.users) != 4 {
+		t.Fatalf("expected 4 reviews, got %d", len(k8s.users))
+	}
+	if g := k8s.groups[0]; len(g) != 3 || g[1] != "system:serviceaccounts:ci" {
+		t.Errorf("expected the ServiceAccount's groups, got %v", g)
+	}
+}
+
+func TestServiceAccountAccessReview_DefaultVerbs(t *testing.T) {
+	k8s := &fakeReviewCluster{}
+	w, resp := accessReviewRequestTo(t, k8s, readNamespaces{"ci": true}, "ci", `{"resources":[{"group":"apps","resource":"deployments"}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(resp.Verbs) != len(defaultAccessReviewVerbs) || len(resp.Resources[0].Verbs) != len(defaultAccessReviewVerbs) {
+		t.Errorf("expected the default verbs, got %v", resp.Verbs)
+	}
+}
+
+func TestServiceAccountAccessReview_Forbidden(t *testing.T) {
+	w, _ := accessReviewRequestTo(t, &fakeReviewCluster{}, readNamespaces{"other": true}, "ci", `{"resources":[{"resource":"pods"}]}`)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 without read on serviceaccounts, got %d", w.Code)
+	}
+}
+
+func TestServiceAccountAccessReview_DashboardMayNotReview(t *testing.T) {
+	w, _ := accessReviewRequestTo(t, &fakeReviewCluster{forbidden: true}, readNamespaces{"ci": true}, "ci", `{"resources":[{"resource":"pods"}]}`)
+	if w.Code != http.StatusNotImplemented || !strings.Contains(w.Body.String(), "subjectaccessreviews") {
+		t.Errorf("expected 501 naming the missing permission, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAccessReviewRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     accessReviewRequest
+		wantErr string
+	}{
+		{name: "no resources", req: accessReviewRequest{}, wantErr: "resources is required"},
+		{name: "bad verb", req: accessReviewRequest{Verbs: []string{"GET"}, Resources: []accessReviewResource{{Resource: "pods"}}}, wantErr: "invalid verb"},
+		{name: "bad resource", req: accessReviewRequest{Resources: []accessReviewResource{{Resource: "../pods"}}}, wantErr: "invalid resource"},
+		{name: "too many", req: accessReviewRequest{Resources: make([]accessReviewResource, 20)}, wantErr: "combinations"},
+		{name: "bad group", req: accessReviewRequest{Resources: []accessReviewResource{{Group: "apps/v1", Resource: "pods"}}}, wantErr: "invalid group"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.validate("ci")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAccessReviewRequestValidate_Wildcards(t *testing.T) {
+	req := accessReviewRequest{Verbs: []string{"*"}, Resources: []accessReviewResource{{Group: "*", Resource: "*", Namespace: "*"}}}
+	if err := req.validate("ci"); err != nil {
+		t.Fatalf("expected wildcards to be accepted, got %v", err)
+	}
+	if req.Resources[0].Namespace != "" {
+		t.Errorf("expected namespace * to mean all namespaces, got %q", req.Resources[0].Namespace)
+	}
+}
@@ -107,7 +107,7 @@ func TestGvrFromVarsOversizedValues(t *testing.T) {
 
 // TestNewResourceHandlerNilManager tests that creating a handler with nil manager doesn't panic.
 func TestNewResourceHandlerNilManager(t *testing.T) {
-	h := NewResourceHandler(nil)
+	h := NewResourceHandler(nil, nil)
 	if h == nil {
 		t.Fatal("expected non-nil ResourceHandler")
 	}
@@ -2,7 +2,13 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -14,17 +20,88 @@ type DB struct {
 	Pool *pgxpool.Pool
 }
 
+// PoolConfig tunes the connection pool. Zero values keep the pgxpool defaults.
+type PoolConfig struct {
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+}
+
+// Retry backoff bounds for Connect.
+const (
+	initialRetryDelay = 500 * time.Millisecond
+	maxRetryDelay     = 10 * time.Second
+)
+
 func New(ctx context.Context, databaseURL string) (*DB, error) {
-	pool, err := pgxpool.New(ctx, databaseURL)
+	return NewWithConfig(ctx, databaseURL, PoolConfig{})
+}
+
+// NewWithConfig creates a pool with the given settings and verifies the
+// connection with a ping.
+func NewWithConfig(ctx context.Context, databaseURL string, pc PoolConfig) (*DB, error) {
+	poolCfg, err := parsePoolConfig(databaseURL, pc)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create pool: %w", err)
 	}
 	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("failed to ping db: %w", err)
 	}
 	return &DB{Pool: pool}, nil
 }
 
+// Connect calls NewWithConfig until it succeeds, retrying with exponential
+// backoff for up to timeout. It returns the last error once timeout elapses
+// or ctx is cancelled.
+func Connect(ctx context.Context, databaseURL string, pc PoolConfig, timeout time.Duration) (*DB, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	delay := initialRetryDelay
+	for attempt := 1; ; attempt++ {
+		database, err := NewWithConfig(ctx, databaseURL, pc)
+		if err == nil {
+			if attempt > 1 {
+				log.Printf("db: connected after %d attempts", attempt)
+			}
+			return database, nil
+		}
+
+		log.Printf("db: connection attempt %d failed: %v (retrying in %s)", attempt, err, delay)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("database unavailable after %d attempts: %w", attempt, err)
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
+
+func parsePoolConfig(databaseURL string, pc PoolConfig) (*pgxpool.Config, error) {
+	poolCfg, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database URL: %w", err)
+	}
+	if pc.MaxConns > 0 {
+		poolCfg.MaxConns = pc.MaxConns
+	}
+	if pc.MinConns > 0 {
+		poolCfg.MinConns = pc.MinConns
+	}
+	if pc.MaxConnLifetime > 0 {
+		poolCfg.MaxConnLifetime = pc.MaxConnLifetime
+	}
+	return poolCfg, nil
+}
+
 func (d *DB) Close() {
 	d.Pool.Close()
 }
@@ -34,8 +111,75 @@ func RunMigrations(databaseURL, migrationsPath string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create migrator: %w", err)
 	}
+	defer m.Close() //nolint:errcheck
 	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 	return nil
 }
+
+// ExpectedSchemaVersion returns the highest migration version shipped in
+// migrationsPath, i.e. the schema version this binary was built against.
+func ExpectedSchemaVersion(migrationsPath string) (uint, error) {
+	entries, err := os.ReadDir(migrationsPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	var latest uint
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		if uint(v) > latest {
+			latest = uint(v)
+		}
+	}
+	if latest == 0 {
+		return 0, fmt.Errorf("no migrations found in %s", migrationsPath)
+	}
+	return latest, nil
+}
+
+// CheckSchemaVersion verifies that the database schema is exactly at the
+// version this binary expects and that no migration was left half-applied.
+func CheckSchemaVersion(databaseURL, migrationsPath string) error {
+	expected, err := ExpectedSchemaVersion(migrationsPath)
+	if err != nil {
+		return err
+	}
+	m, err := migrate.New("file://"+migrationsPath, databaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to create migrator: %w", err)
+	}
+	defer m.Close() //nolint:errcheck
+
+	current, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		current, dirty, err = 0, false, nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return compareSchemaVersion(current, dirty, expected)
+}
+
+func compareSchemaVersion(current uint, dirty bool, expected uint) error {
+	switch {
+	case dirty:
+		return fmt.Errorf("schema version %d is dirty (a migration failed part-way); fix it manually before starting", current)
+	case current > expected:
+		return fmt.Errorf("schema version %d is newer than this binary supports (%d); upgrade the binary", current, expected)
+	case current < expected:
+		return fmt.Errorf("schema version %d is older than this binary expects (%d); run migrations first", current, expected)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DatabaseCheck reports whether the database pool is connected and reachable.
+func DatabaseCheck(pool *pgxpool.Pool) CheckFunc {
+	return func(ctx context.Context) error {
+		if pool == nil {
+			return errors.New("database not connected")
+		}
+		return pool.Ping(ctx)
+	}
+}
+
+// FlagCheck reports ready once flag is set, e.g. by a startup step that
+// completed successfully. notReady is returned as the error until then.
+func FlagCheck(flag *atomic.Bool, notReady string) CheckFunc {
+	return func(context.Context) error {
+		if !flag.Load() {
+			return errors.New(notReady)
+		}
+		return nil
+	}
+}
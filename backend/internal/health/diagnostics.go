@@ -0,0 +1,220 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// diagnosticTimeout bounds each diagnostic probe. It is longer than the
+// readiness checkTimeout because probes reach external services.
+const diagnosticTimeout = 10 * time.Second
+
+// Diagnostic statuses.
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// skipError marks a probe whose subsystem is not configured.
+type skipError struct{ reason string }
+
+func (e *skipError) Error() string { return e.reason }
+
+// Skip returns an error that reports a probe as skipped rather than failed,
+// e.g. because its subsystem is not configured.
+func Skip(reason string) error {
+	return &skipError{reason: reason}
+}
+
+// TargetsFunc lists the targets of a subsystem, e.g. the connected clusters,
+// with a probe for each. A Skip error skips the whole subsystem.
+type TargetsFunc func(ctx context.Context) (map[string]CheckFunc, error)
+
+// DiagnosticResult is the outcome of one probe, or of a subsystem and each
+// of its targets.
+type DiagnosticResult struct {
+	Name       string             `json:"name"`
+	Status     string             `json:"status"`
+	DurationMs int64              `json:"duration_ms"`
+	Message    string             `json:"message,omitempty"`
+	Targets    []DiagnosticResult `json:"targets,omitempty"`
+}
+
+// DiagnosticReport is the result of a diagnostics run. Status is "fail" if
+// any check failed.
+type DiagnosticReport struct {
+	Status     string             `json:"status"`
+	StartedAt  time.Time          `json:"started_at"`
+	DurationMs int64              `json:"duration_ms"`
+	Checks     []DiagnosticResult `json:"checks"`
+}
+
+// Diagnostics runs an on-demand self-test of every subsystem for operators.
+// Unlike readiness checks, its probes actively reach out to external
+// services, so it is meant for admins troubleshooting an installation.
+type Diagnostics struct {
+	mu         sync.RWMutex
+	subsystems map[string]TargetsFunc
+	readGuard  mux.MiddlewareFunc
+}
+
+// NewDiagnostics creates a Diagnostics with no registered probes. readGuard,
+// if set, protects the endpoint.
+func NewDiagnostics(readGuard mux.MiddlewareFunc) *Diagnostics {
+	return &Diagnostics{
+		subsystems: make(map[string]TargetsFunc),
+		readGuard:  readGuard,
+	}
+}
+
+// Register adds a named probe, replacing any probe with the same name.
+func (d *Diagnostics) Register(name string, probe CheckFunc) {
+	d.RegisterTargets(name, func(context.Context) (map[string]CheckFunc, error) {
+		return map[string]CheckFunc{"": probe}, nil
+	})
+}
+
+// RegisterTargets adds a named subsystem whose targets are each probed. The
+// subsystem fails if any target fails, and is skipped if it has none.
+func (d *Diagnostics) RegisterTargets(name string, targets TargetsFunc) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subsystems[name] = targets
+}
+
+// RegisterRoutes mounts GET /api/admin/diagnostics.
+func (d *Diagnostics) RegisterRoutes(r *mux.Router) {
+	routes := r.PathPrefix("").Subrouter()
+	if d.readGuard != nil {
+		routes.Use(d.readGuard)
+	}
+	routes.HandleFunc("/api/admin/diagnostics", d.Handle).Methods("GET")
+}
+
+// Handle handles GET /api/admin/diagnostics. It always returns 200; the
+// report's status says whether every check passed.
+func (d *Diagnostics) Handle(w http.ResponseWriter, r *http.Request) {
+	httputil.WriteJSON(w, http.StatusOK, d.Run(r.Context()))
+}
+
+// Run probes every registered subsystem and its targets concurrently.
+func (d *Diagnostics) Run(ctx context.Context) DiagnosticReport {
+	d.mu.RLock()
+	names := make([]string, 0, len(d.subsystems))
+	for name := range d.subsystems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	subsystems := make([]TargetsFunc, len(names))
+	for i, name := range names {
+		subsystems[i] = d.subsystems[name]
+	}
+	d.mu.RUnlock()
+
+	start := time.Now()
+	report := DiagnosticReport{Status: StatusPass, StartedAt: start, Checks: make([]DiagnosticResult, len(names))}
+	var wg sync.WaitGroup
+	for i := range subsystems {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			report.Checks[i] = d.runSubsystem(ctx, names[i], subsystems[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for _, check := range report.Checks {
+		if check.Status == StatusFail {
+			report.Status = StatusFail
+		}
+	}
+	report.DurationMs = time.Since(start).Milliseconds()
+	return report
+}
+
+func (d *Diagnostics) runSubsystem(ctx context.Context, name string, list TargetsFunc) DiagnosticResult {
+	start := time.Now()
+	listCtx, cancel := context.WithTimeout(ctx, diagnosticTimeout)
+	probes, err := list(listCtx)
+	cancel()
+	if err != nil {
+		result := outcome(name, err)
+		result.DurationMs = time.Since(start).Milliseconds()
+		return result
+	}
+
+	// A plain probe is registered as a single unnamed target.
+	if probe, ok := probes[""]; ok && len(probes) == 1 {
+		result := outcome(name, d.probe(ctx, probe))
+		result.DurationMs = time.Since(start).Milliseconds()
+		return result
+	}
+
+	targets := make([]string, 0, len(probes))
+	for target := range probes {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	result := DiagnosticResult{Name: name, Status: StatusSkip, Message: "nothing to check", Targets: make([]DiagnosticResult, len(targets))}
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			targetStart := time.Now()
+			result.Targets[i] = outcome(target, d.probe(ctx, probes[target]))
+			result.Targets[i].DurationMs = time.Since(targetStart).Milliseconds()
+		}(i, target)
+	}
+	wg.Wait()
+
+	passed, failed := 0, 0
+	for _, target := range result.Targets {
+		switch target.Status {
+		case StatusPass:
+			passed++
+		case StatusFail:
+			failed++
+		}
+	}
+	switch {
+	case failed > 0:
+		result.Status = StatusFail
+		result.Message = fmt.Sprintf("%d of %d failed", failed, len(targets))
+	case passed > 0:
+		result.Status = StatusPass
+		result.Message = ""
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// probe runs a single probe under diagnosticTimeout.
+func (d *Diagnostics) probe(ctx context.Context, probe CheckFunc) error {
+	probeCtx, cancel := context.WithTimeout(ctx, diagnosticTimeout)
+	defer cancel()
+	return probe(probeCtx)
+}
+
+// outcome converts a probe's error into a result.
+func outcome(name string, err error) DiagnosticResult {
+	var skip *skipError
+	switch {
+	case err == nil:
+		return DiagnosticResult{Name: name, Status: StatusPass}
+	case errors.As(err, &skip):
+		return DiagnosticResult{Name: name, Status: StatusSkip, Message: skip.reason}
+	}
+	return DiagnosticResult{Name: name, Status: StatusFail, Message: err.Error()}
+}
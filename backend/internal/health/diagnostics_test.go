@@ -0,0 +1,109 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestDiagnosticsRun(t *testing.T) {
+	d := NewDiagnostics(nil)
+	d.Register("database", func(context.Context) error { return nil })
+	d.Register("oidc", func(context.Context) error { return Skip("OIDC is not configured") })
+	d.RegisterTargets("clusters", func(context.Context) (map[string]CheckFunc, error) {
+		return map[string]CheckFunc{
+			"prod":    func(context.Context) error { return nil },
+			"staging": func(context.Context) error { return errors.New("connection refused") },
+		}, nil
+	})
+	d.RegisterTargets("notifications", func(context.Context) (map[string]CheckFunc, error) {
+		return map[string]CheckFunc{}, nil
+	})
+
+	report := d.Run(context.Background())
+	if report.Status != StatusFail || len(report.Checks) != 4 {
+		t.Fatalf("expected a failed report with 4 checks, got %+v", report)
+	}
+	want := map[string]string{"clusters": StatusFail, "database": StatusPass, "notifications": StatusSkip, "oidc": StatusSkip}
+	for _, check := range report.Checks {
+		if check.Status != want[check.Name] {
+			t.Errorf("%s: expected %s, got %s (%s)", check.Name, want[check.Name], check.Status, check.Message)
+		}
+	}
+
+	clusters := report.Checks[0]
+	if clusters.Name != "clusters" || clusters.Message != "1 of 2 failed" || len(clusters.Targets) != 2 {
+		t.Fatalf("unexpected clusters result %+v", clusters)
+	}
+	if staging := clusters.Targets[1]; staging.Name != "staging" || staging.Status != StatusFail || staging.Message != "connection refused" {
+		t.Errorf("unexpected target result %+v", staging)
+	}
+	if oidc := report.Checks[3]; oidc.Message != "OIDC is not configured" || oidc.Targets != nil {
+		t.Errorf("unexpected oidc result %+v", oidc)
+	}
+}
+
+func TestDiagnosticsTargetsError(t *testing.T) {
+	d := NewDiagnostics(nil)
+	d.RegisterTargets("clusters", func(context.Context) (map[string]CheckFunc, error) {
+		return nil, errors.New("database not connected")
+	})
+	d.RegisterTargets("notifications", func(context.Context) (map[string]CheckFunc, error) {
+		return nil, Skip("notifications are disabled")
+	})
+
+	report := d.Run(context.Background())
+	if report.Status != StatusFail || report.Checks[0].Status != StatusFail || report.Checks[1].Status != StatusSkip {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
+
+func TestDiagnosticsProbeTimeout(t *testing.T) {
+	d := NewDiagnostics(nil)
+	d.Register("ai", func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			return errors.New("expected a deadline")
+		}
+		return nil
+	})
+	if report := d.Run(context.Background()); report.Status != StatusPass {
+		t.Errorf("expected probes to run with a deadline, got %+v", report.Checks)
+	}
+}
+
+func TestDiagnosticsRoute(t *testing.T) {
+	denied := func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		})
+	}
+	for _, tt := range []struct {
+		guard mux.MiddlewareFunc
+		want  int
+	}{{nil, http.StatusOK}, {denied, http.StatusForbidden}} {
+		d := NewDiagnostics(tt.guard)
+		d.Register("grpc", func(context.Context) error { return errors.New("gRPC listener not started") })
+		r := mux.NewRouter()
+		d.RegisterRoutes(r)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("GET", "/api/admin/diagnostics", nil))
+		if rec.Code != tt.want {
+			t.Fatalf("expected %d, got %d", tt.want, rec.Code)
+		}
+		if tt.guard != nil {
+			continue
+		}
+		var report DiagnosticReport
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+		if report.Status != StatusFail || report.Checks[0].Message != "gRPC listener not started" {
+			t.Errorf("unexpected report %+v", report)
+		}
+	}
+}
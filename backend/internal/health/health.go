@@ -0,0 +1,109 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// checkTimeout bounds how long a single readiness check may take, so a hung
+// dependency cannot stall the probe past the kubelet's own timeout.
+const checkTimeout = 2 * time.Second
+
+// CheckFunc reports whether a dependency is available. A nil error means ready.
+type CheckFunc func(ctx context.Context) error
+
+// Checker serves liveness and readiness probes. Liveness only reflects that
+// the process is serving HTTP; readiness runs every registered check and
+// fails if any critical dependency is unavailable.
+type Checker struct {
+	mu     sync.RWMutex
+	checks map[string]CheckFunc
+}
+
+// NewChecker creates a Checker with no registered checks.
+func NewChecker() *Checker {
+	return &Checker{checks: make(map[string]CheckFunc)}
+}
+
+// Register adds a named readiness check, replacing any check with the same name.
+func (c *Checker) Register(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// RegisterRoutes mounts /livez, /readyz and the legacy /healthz (an alias of
+// /livez). None of them require authentication.
+func (c *Checker) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/livez", c.Livez).Methods("GET")
+	r.HandleFunc("/healthz", c.Livez).Methods("GET")
+	r.HandleFunc("/readyz", c.Readyz).Methods("GET")
+}
+
+// Livez handles GET /livez. It always returns 200 while the process can serve
+// requests; dependency failures must not get the pod restarted.
+func (c *Checker) Livez(w http.ResponseWriter, r *http.Request) {
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readyz handles GET /readyz. It returns 200 when every check passes and 503
+// otherwise, with the per-check result in the body.
+func (c *Checker) Readyz(w http.ResponseWriter, r *http.Request) {
+	results, ready := c.Check(r.Context())
+
+	status := http.StatusOK
+	body := map[string]interface{}{"status": "ready", "checks": results}
+	if !ready {
+		status = http.StatusServiceUnavailable
+		body["status"] = "not_ready"
+	}
+	httputil.WriteJSON(w, status, body)
+}
+
+// Check runs all registered checks concurrently and returns each check's
+// result ("ok" or the error message) and whether all of them passed.
+func (c *Checker) Check(ctx context.Context) (map[string]string, bool) {
+	c.mu.RLock()
+	names := make([]string, 0, len(c.checks))
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]CheckFunc, len(names))
+	for i, name := range names {
+		checks[i] = c.checks[name]
+	}
+	c.mu.RUnlock()
+
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check CheckFunc) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			errs[i] = check(checkCtx)
+		}(i, check)
+	}
+	wg.Wait()
+
+	results := make(map[string]string, len(names))
+	ready := true
+	for i, name := range names {
+		if errs[i] != nil {
+			results[name] = errs[i].Error()
+			ready = false
+			continue
+		}
+		results[name] = "ok"
+	}
+	return results, ready
+}
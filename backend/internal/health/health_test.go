@@ -0,0 +1,106 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func serve(t *testing.T, c *Checker, path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+	r := mux.NewRouter()
+	c.RegisterRoutes(r)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON body: %v", err)
+	}
+	return rec, body
+}
+
+func TestLivezIgnoresFailingChecks(t *testing.T) {
+	c := NewChecker()
+	c.Register("database", func(context.Context) error { return errors.New("down") })
+
+	for _, path := range []string{"/livez", "/healthz"} {
+		rec, body := serve(t, c, path)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", path, rec.Code)
+		}
+		if body["status"] != "ok" {
+			t.Errorf("%s: expected status ok, got %v", path, body["status"])
+		}
+	}
+}
+
+func TestReadyzAllChecksPass(t *testing.T) {
+	c := NewChecker()
+	c.Register("database", func(context.Context) error { return nil })
+	c.Register("grpc", func(context.Context) error { return nil })
+
+	rec, body := serve(t, c, "/readyz")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if body["status"] != "ready" {
+		t.Errorf("expected status ready, got %v", body["status"])
+	}
+}
+
+func TestReadyzFailsOnDependency(t *testing.T) {
+	c := NewChecker()
+	c.Register("database", func(context.Context) error { return errors.New("database not connected") })
+	c.Register("grpc", func(context.Context) error { return nil })
+
+	rec, body := serve(t, c, "/readyz")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+	checks, _ := body["checks"].(map[string]interface{})
+	if checks["database"] != "database not connected" || checks["grpc"] != "ok" {
+		t.Errorf("unexpected check results: %v", checks)
+	}
+}
+
+func TestCheckTimesOutHungDependency(t *testing.T) {
+	c := NewChecker()
+	c.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	_, ready := c.Check(context.Background())
+	if ready {
+		t.Error("expected hung check to fail")
+	}
+	if elapsed := time.Since(start); elapsed > checkTimeout+time.Second {
+		t.Errorf("check took %v, expected it to be bounded by %v", elapsed, checkTimeout)
+	}
+}
+
+func TestFlagCheck(t *testing.T) {
+	var flag atomic.Bool
+	check := FlagCheck(&flag, "migrations not applied")
+	if err := check(context.Background()); err == nil || err.Error() != "migrations not applied" {
+		t.Errorf("expected not-ready error, got %v", err)
+	}
+	flag.Store(true)
+	if err := check(context.Background()); err != nil {
+		t.Errorf("expected ready, got %v", err)
+	}
+}
+
+func TestDatabaseCheckNilPool(t *testing.T) {
+	if err := DatabaseCheck(nil)(context.Background()); err == nil {
+		t.Error("expected nil pool to be reported as not ready")
+	}
+}
@@ -5,6 +5,87 @@ import (
 	"net/http"
 )
 
+// ErrorCode is a stable, machine-readable identifier for an error response.
+// Clients branch on the code; the message is for humans and may change.
+type ErrorCode string
+
+const (
+	CodeValidationFailed   ErrorCode = "validation_failed"
+	CodeUnauthorized       ErrorCode = "unauthorized"
+	CodeForbidden          ErrorCode = "forbidden"
+	CodeNotFound           ErrorCode = "not_found"
+	CodeConflict           ErrorCode = "conflict"
+	CodePayloadTooLarge    ErrorCode = "payload_too_large"
+	CodeRateLimited        ErrorCode = "rate_limited"
+	CodeInternal           ErrorCode = "internal_error"
+	CodeNotImplemented     ErrorCode = "not_implemented"
+	CodeUpstreamError      ErrorCode = "upstream_error"
+	CodeServiceUnavailable ErrorCode = "service_unavailable"
+	// CodeNotConnected means the target cluster has no client and no
+	// connected agent.
+	CodeNotConnected ErrorCode = "not_connected"
+	// CodeAgentTimeout means a cluster agent did not answer in time.
+	CodeAgentTimeout  ErrorCode = "agent_timeout"
+	CodeSetupRequired ErrorCode = "setup_required"
+	// CodeCapabilityMissing means the target cluster does not serve an API
+	// a plugin requires, e.g. because the operator's CRDs are not installed.
+	CodeCapabilityMissing ErrorCode = "capability_missing"
+	// CodeSessionIdle means a refresh token's session was ended by the
+	// session policy's idle timeout, so the user has to log in again.
+	CodeSessionIdle ErrorCode = "session_idle"
+	// CodeAIUnavailable means the AI provider is failing and its circuit
+	// breaker refuses new requests until the Retry-After delay has passed.
+	CodeAIUnavailable ErrorCode = "ai_unavailable"
+	// CodeClusterMaintenance means the target cluster is in maintenance mode
+	// and refuses writes.
+	CodeClusterMaintenance ErrorCode = "cluster_maintenance"
+	// CodeAgentOverloaded means a cluster agent already has the maximum
+	// number of requests in flight; retry after the Retry-After delay.
+	CodeAgentOverloaded ErrorCode = "agent_overloaded"
+	// CodeAgentNotConnected means the target is an agent cluster whose agent
+	// is not connected, or dropped its stream before responding.
+	CodeAgentNotConnected ErrorCode = "agent_not_connected"
+)
+
+// ErrorResponse is the envelope of every error response.
+type ErrorResponse struct {
+	Code    ErrorCode   `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+	// Error repeats Message for clients written against the original
+	// {"error": "..."} responses.
+	Error string `json:"error"`
+}
+
+// CodeForStatus returns the default error code of an HTTP status.
+func CodeForStatus(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	case http.StatusBadGateway:
+		return CodeUpstreamError
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeAgentTimeout
+	}
+	return CodeInternal
+}
+
 // WriteJSON writes v as JSON with the given HTTP status code.
 func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -12,7 +93,18 @@ func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
 	json.NewEncoder(w).Encode(v) //nolint:errcheck
 }
 
-// WriteError writes a JSON error response with the given status and message.
+// WriteError writes an error response with the default code for status.
 func WriteError(w http.ResponseWriter, status int, message string) {
-	WriteJSON(w, status, map[string]string{"error": message})
+	WriteErrorCode(w, status, CodeForStatus(status), message)
+}
+
+// WriteErrorCode writes an error response with an explicit code.
+func WriteErrorCode(w http.ResponseWriter, status int, code ErrorCode, message string) {
+	WriteErrorDetails(w, status, code, message, nil)
+}
+
+// WriteErrorDetails writes an error response carrying structured details,
+// e.g. per-field validation problems.
+func WriteErrorDetails(w http.ResponseWriter, status int, code ErrorCode, message string, details interface{}) {
+	WriteJSON(w, status, ErrorResponse{Code: code, Message: message, Details: details, Error: message})
 }
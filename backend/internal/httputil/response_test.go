@@ -114,3 +114,65 @@ func TestWriteJSONStruct(t *testing.T) {
 		t.Errorf("expected {test, 99}, got {%s, %d}", body.Name, body.Value)
 	}
 }
+
+func TestWriteErrorEnvelope(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteError(rec, http.StatusForbidden, "insufficient permissions")
+
+	var body ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if body.Code != CodeForbidden {
+		t.Errorf("expected code %q, got %q", CodeForbidden, body.Code)
+	}
+	if body.Message != "insufficient permissions" || body.Error != body.Message {
+		t.Errorf("expected message and error to be set, got %+v", body)
+	}
+	if body.Details != nil {
+		t.Errorf("expected no details, got %v", body.Details)
+	}
+}
+
+func TestWriteErrorDetails(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteErrorDetails(rec, http.StatusUnprocessableEntity, CodeValidationFailed, "Request validation failed",
+		map[string]string{"email": "required"})
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422, got %d", rec.Code)
+	}
+	var body struct {
+		Code    string            `json:"code"`
+		Details map[string]string `json:"details"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if body.Code != "validation_failed" || body.Details["email"] != "required" {
+		t.Errorf("unexpected body: %+v", body)
+	}
+}
+
+func TestCodeForStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   ErrorCode
+	}{
+		{http.StatusBadRequest, CodeValidationFailed},
+		{http.StatusUnauthorized, CodeUnauthorized},
+		{http.StatusForbidden, CodeForbidden},
+		{http.StatusNotFound, CodeNotFound},
+		{http.StatusConflict, CodeConflict},
+		{http.StatusTooManyRequests, CodeRateLimited},
+		{http.StatusBadGateway, CodeUpstreamError},
+		{http.StatusGatewayTimeout, CodeAgentTimeout},
+		{http.StatusInternalServerError, CodeInternal},
+		{http.StatusTeapot, CodeInternal},
+	}
+	for _, tt := range tests {
+		if got := CodeForStatus(tt.status); got != tt.want {
+			t.Errorf("CodeForStatus(%d) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
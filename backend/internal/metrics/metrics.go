@@ -0,0 +1,142 @@
+// Package metrics serves the server's internal counters and gauges in the
+// Prometheus text exposition format. Values are read from their owners at
+// scrape time, so packages only hand over a function and keep their own
+// counters.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Kind is the Prometheus type of a metric.
+type Kind string
+
+const (
+	Counter Kind = "counter"
+	Gauge   Kind = "gauge"
+)
+
+// Sample is one value of a metric and the labels that identify it.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// CollectFunc returns a metric's current samples.
+type CollectFunc func() []Sample
+
+type metric struct {
+	help    string
+	kind    Kind
+	collect CollectFunc
+}
+
+// Registry holds the metrics served at /metrics.
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]metric
+}
+
+// NewRegistry creates a Registry with no metrics.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// Register adds a metric whose samples are collected on every scrape,
+// replacing any metric with the same name.
+func (r *Registry) Register(name, help string, kind Kind, collect CollectFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics[name] = metric{help: help, kind: kind, collect: collect}
+}
+
+// RegisterValue adds an unlabeled metric read from value on every scrape.
+func (r *Registry) RegisterValue(name, help string, kind Kind, value func() float64) {
+	r.Register(name, help, kind, func() []Sample {
+		return []Sample{{Value: value()}}
+	})
+}
+
+// RegisterRoutes mounts GET /metrics. Like the health probes it does not
+// require authentication, so it should not be exposed beyond the cluster.
+func (r *Registry) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/metrics", r.ServeMetrics).Methods("GET")
+}
+
+// ServeMetrics handles GET /metrics.
+func (r *Registry) ServeMetrics(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = r.Write(w)
+}
+
+// Write writes every metric in the text exposition format, sorted by name.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	metrics := make([]metric, len(names))
+	for i, name := range names {
+		metrics[i] = r.metrics[name]
+	}
+	r.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	for i, name := range names {
+		m := metrics[i]
+		fmt.Fprintf(bw, "# HELP %s %s\n", name, escapeHelp(m.help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, m.kind)
+		for _, s := range m.collect() {
+			bw.WriteString(name)
+			writeLabels(bw, s.Labels)
+			bw.WriteByte(' ')
+			bw.WriteString(strconv.FormatFloat(s.Value, 'g', -1, 64))
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+func writeLabels(w *bufio.Writer, labels map[string]string) {
+	if len(labels) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(k)
+		w.WriteString(`="`)
+		w.WriteString(labelEscaper.Replace(labels[k]))
+		w.WriteByte('"')
+	}
+	w.WriteByte('}')
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestWriteSortsAndFormats(t *testing.T) {
+	reg := NewRegistry()
+	reg.RegisterValue("argus_b_total", "Second metric.", Counter, func() float64 { return 3 })
+	reg.Register("argus_a", "First metric.\nTwo lines.", Gauge, func() []Sample {
+		return []Sample{
+			{Labels: map[string]string{"zone": "b", "cluster": `pro"d`}, Value: 1.5},
+			{Value: 0},
+		}
+	})
+
+	var sb strings.Builder
+	if err := reg.Write(&sb); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP argus_a First metric.\nTwo lines.
+# TYPE argus_a gauge
+argus_a{cluster="pro\"d",zone="b"} 1.5
+argus_a 0
+# HELP argus_b_total Second metric.
+# TYPE argus_b_total counter
+argus_b_total 3
+`
+	if sb.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", sb.String(), want)
+	}
+}
+
+func TestRegisterReplaces(t *testing.T) {
+	reg := NewRegistry()
+	reg.RegisterValue("argus_x", "x", Gauge, func() float64 { return 1 })
+	reg.RegisterValue("argus_x", "x", Gauge, func() float64 { return 2 })
+
+	var sb strings.Builder
+	_ = reg.Write(&sb)
+	if !strings.Contains(sb.String(), "argus_x 2\n") || strings.Contains(sb.String(), "argus_x 1\n") {
+		t.Errorf("expected the second registration to win, got:\n%s", sb.String())
+	}
+}
+
+func TestServeMetrics(t *testing.T) {
+	reg := NewRegistry()
+	reg.RegisterValue("argus_up", "Up.", Gauge, func() float64 { return 1 })
+	r := mux.NewRouter()
+	reg.RegisterRoutes(r)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != 200 {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "argus_up 1\n") {
+		t.Errorf("unexpected body:\n%s", rec.Body.String())
+	}
+}
@@ -1,20 +1,48 @@
 package middleware
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
 	"strings"
 
 	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 func AuthMiddleware(jwtService *auth.JWTService, apiKeyService ...*auth.APIKeyService) func(http.Handler) http.Handler {
+	var keys *auth.APIKeyService
+	if len(apiKeyService) > 0 {
+		keys = apiKeyService[0]
+	}
+	return AuthMiddlewareWithServiceAccounts(jwtService, keys, nil)
+}
+
+// AuthMiddlewareWithServiceAccounts is AuthMiddleware that additionally
+// accepts service account tokens as bearer tokens. They are recognised by
+// their prefix and resolved through serviceAccounts instead of being parsed
+// as JWTs.
+func AuthMiddlewareWithServiceAccounts(jwtService *auth.JWTService, apiKeyService *auth.APIKeyService, serviceAccounts *auth.ServiceAccountService) func(http.Handler) http.Handler {
+	return AuthMiddlewareWithShareSessions(jwtService, apiKeyService, serviceAccounts, nil)
+}
+
+// ShareSessionValidator resolves share session tokens. Implemented by
+// *share.Service.
+type ShareSessionValidator interface {
+	ValidateSession(ctx context.Context, rawToken string) (*auth.Claims, error)
+}
+
+// AuthMiddlewareWithShareSessions is AuthMiddlewareWithServiceAccounts that
+// additionally accepts share session tokens, recognised by their prefix.
+// What a share session may reach is limited separately by share.SessionGuard.
+// Cluster credentials are accepted by every variant and limited to their
+// cluster's proxy by proxy.CredentialGuard.
+func AuthMiddlewareWithShareSessions(jwtService *auth.JWTService, apiKeyService *auth.APIKeyService, serviceAccounts *auth.ServiceAccountService, shareSessions ShareSessionValidator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Check X-API-Key header first
 			if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
-				if len(apiKeyService) > 0 && apiKeyService[0] != nil {
-					claims, err := apiKeyService[0].ValidateKey(r.Context(), apiKey)
+				if apiKeyService != nil {
+					claims, err := apiKeyService.ValidateKey(r.Context(), apiKey)
 					if err != nil {
 						writeError(w, http.StatusUnauthorized, "invalid or expired API key")
 						return
@@ -30,6 +58,10 @@ func AuthMiddleware(jwtService *auth.JWTService, apiKeyService ...*auth.APIKeySe
 			// Fall through to JWT authentication
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
+				if token := auth.AccessTokenFromCookie(r); token != "" {
+					serveCookieSession(w, r, next, jwtService, token)
+					return
+				}
 				writeError(w, http.StatusUnauthorized, "missing authorization header")
 				return
 			}
@@ -40,6 +72,47 @@ func AuthMiddleware(jwtService *auth.JWTService, apiKeyService ...*auth.APIKeySe
 				return
 			}
 
+			if strings.HasPrefix(parts[1], auth.ServiceAccountTokenPrefix) {
+				if serviceAccounts == nil {
+					writeError(w, http.StatusUnauthorized, "service account authentication not available")
+					return
+				}
+				claims, err := serviceAccounts.Validate(r.Context(), parts[1])
+				if err != nil {
+					writeError(w, http.StatusUnauthorized, "invalid, expired or revoked service account token")
+					return
+				}
+				ctx := auth.ContextWithClaims(r.Context(), claims)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if strings.HasPrefix(parts[1], auth.ClusterCredentialTokenPrefix) {
+				claims, err := jwtService.ValidateClusterCredential(parts[1])
+				if err != nil {
+					writeError(w, http.StatusUnauthorized, "invalid or expired cluster credential")
+					return
+				}
+				ctx := auth.ContextWithClaims(r.Context(), claims)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if strings.HasPrefix(parts[1], auth.ShareSessionTokenPrefix) {
+				if shareSessions == nil {
+					writeError(w, http.StatusUnauthorized, "share sessions not available")
+					return
+				}
+				claims, err := shareSessions.ValidateSession(r.Context(), parts[1])
+				if err != nil {
+					writeError(w, http.StatusUnauthorized, "invalid, expired or revoked share session")
+					return
+				}
+				ctx := auth.ContextWithClaims(r.Context(), claims)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			claims, err := jwtService.ValidateToken(parts[1])
 			if err != nil {
 				writeError(w, http.StatusUnauthorized, "invalid or expired token")
@@ -52,8 +125,27 @@ func AuthMiddleware(jwtService *auth.JWTService, apiKeyService ...*auth.APIKeySe
 	}
 }
 
+// serveCookieSession authenticates a request by the access token in its
+// session cookie. Browsers attach cookies to cross-site requests too, so
+// state-changing requests must also carry auth.CSRFHeader.
+func serveCookieSession(w http.ResponseWriter, r *http.Request, next http.Handler, jwtService *auth.JWTService, token string) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if r.Header.Get(auth.CSRFHeader) == "" {
+			writeError(w, http.StatusForbidden, "missing "+auth.CSRFHeader+" header")
+			return
+		}
+	}
+
+	claims, err := jwtService.ValidateToken(token)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid or expired token")
+		return
+	}
+	next.ServeHTTP(w, r.WithContext(auth.ContextWithClaims(r.Context(), claims)))
+}
+
 func writeError(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	httputil.WriteError(w, status, message)
 }
@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -370,3 +372,187 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+// --- Service Account Token Tests ---
+
+// TestAuthMiddlewareServiceAccountTokenWithoutService verifies service account
+// tokens are never parsed as JWTs when no service is configured.
+func TestAuthMiddlewareServiceAccountTokenWithoutService(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	middleware := AuthMiddleware(jwtSvc)
+
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+auth.ServiceAccountTokenPrefix+"0123456789abcdef")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rec.Code)
+	}
+
+	var resp map[string]string
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp["error"] != "service account authentication not available" {
+		t.Errorf("expected 'service account authentication not available', got %q", resp["error"])
+	}
+}
+
+// TestAuthMiddlewareWithServiceAccountsAcceptsJWT verifies JWTs still work
+// when service account tokens are enabled.
+func TestAuthMiddlewareWithServiceAccountsAcceptsJWT(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	token, _ := jwtSvc.GenerateToken("user-1", "user@test.com")
+	middleware := AuthMiddlewareWithServiceAccounts(jwtSvc, nil, &auth.ServiceAccountService{})
+
+	var gotUser string
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := auth.ClaimsFromContext(r.Context())
+		gotUser = claims.UserID
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || gotUser != "user-1" {
+		t.Errorf("expected JWT to authenticate user-1, got %d (%q)", rec.Code, gotUser)
+	}
+}
+
+type fakeShareSessions struct {
+	err error
+}
+
+func (f fakeShareSessions) ValidateSession(ctx context.Context, rawToken string) (*auth.Claims, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &auth.Claims{UserID: auth.ShareLinkPrincipalPrefix + "link-1", TokenType: auth.TokenTypeShareSession}, nil
+}
+
+// TestAuthMiddlewareShareSessionToken verifies share session tokens resolve
+// through the share validator and are rejected when it is absent or fails.
+func TestAuthMiddlewareShareSessionToken(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	tests := []struct {
+		name      string
+		validator ShareSessionValidator
+		wantCode  int
+	}{
+		{"without validator", nil, http.StatusUnauthorized},
+		{"invalid session", fakeShareSessions{err: errors.New("revoked")}, http.StatusUnauthorized},
+		{"valid session", fakeShareSessions{}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUser string
+			handler := AuthMiddlewareWithShareSessions(jwtSvc, nil, nil, tt.validator)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				claims, _ := auth.ClaimsFromContext(r.Context())
+				gotUser = claims.UserID
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+auth.ShareSessionTokenPrefix+"0123456789abcdef")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, rec.Code)
+			}
+			if tt.wantCode == http.StatusOK && gotUser != auth.ShareLinkPrincipalPrefix+"link-1" {
+				t.Errorf("expected share principal, got %q", gotUser)
+			}
+		})
+	}
+}
+
+// TestAuthMiddlewareClusterCredential verifies cluster credentials resolve
+// to their user and cluster, and forged or expired ones are rejected.
+func TestAuthMiddlewareClusterCredential(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	valid, _, _ := jwtSvc.GenerateClusterCredential("user-1", "user@test.com", "c1")
+	forged, _, _ := auth.NewJWTService("other-secret").GenerateClusterCredential("user-1", "user@test.com", "c1")
+	access, _ := jwtSvc.GenerateToken("user-1", "user@test.com")
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode int
+	}{
+		{"valid", valid, http.StatusOK},
+		{"forged", forged, http.StatusUnauthorized},
+		{"access token with prefix", auth.ClusterCredentialTokenPrefix + access, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *auth.Claims
+			handler := AuthMiddleware(jwtSvc)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = auth.ClaimsFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, rec.Code)
+			}
+			if tt.wantCode == http.StatusOK && (got.UserID != "user-1" || got.ClusterID != "c1") {
+				t.Errorf("unexpected claims %+v", got)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareSessionCookie(t *testing.T) {
+	jwtSvc := auth.NewJWTService("test-secret")
+	token, _ := jwtSvc.GenerateToken("user-1", "user@test.com")
+
+	tests := []struct {
+		name     string
+		method   string
+		token    string
+		csrf     bool
+		wantCode int
+	}{
+		{"read", http.MethodGet, token, false, http.StatusOK},
+		{"write with CSRF header", http.MethodPost, token, true, http.StatusOK},
+		{"write without CSRF header", http.MethodPost, token, false, http.StatusForbidden},
+		{"invalid token", http.MethodGet, "invalid-token", false, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *auth.Claims
+			handler := AuthMiddleware(jwtSvc)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = auth.ClaimsFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.AddCookie(&http.Cookie{Name: auth.AccessTokenCookie, Value: tt.token})
+			if tt.csrf {
+				req.Header.Set(auth.CSRFHeader, "XMLHttpRequest")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d", tt.wantCode, rec.Code)
+			}
+			if tt.wantCode == http.StatusOK && got.UserID != "user-1" {
+				t.Errorf("unexpected claims %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// BodyLimit caps the request body of the routes whose path template starts
+// with PathPrefix, e.g. "/api/auth/" or "/api/clusters/{clusterID}/resources/".
+type BodyLimit struct {
+	PathPrefix string
+	MaxBytes   int64
+}
+
+// BodyLimitMiddleware bounds how much of a request body handlers can read.
+// The limit of a request is that of the longest matching BodyLimit prefix, or
+// defaultMax. Bodies that declare a larger Content-Length are rejected with
+// 413 up front; streamed bodies are cut off at the limit, and the handler's
+// resulting error response is replaced with a 413.
+//
+// It must be registered with Router.Use so the matched route is known.
+func BodyLimitMiddleware(defaultMax int64, limits ...BodyLimit) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			limit := bodyLimitFor(r, defaultMax, limits)
+			if r.ContentLength > limit {
+				writeBodyTooLarge(w, limit)
+				return
+			}
+
+			body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, limit)}
+			r.Body = body
+			next.ServeHTTP(&bodyLimitWriter{ResponseWriter: w, body: body, limit: limit}, r)
+		})
+	}
+}
+
+// bodyLimitFor returns the limit of the longest BodyLimit prefix matching the
+// request's route template, falling back to the raw path for unmatched routes.
+func bodyLimitFor(r *http.Request, defaultMax int64, limits []BodyLimit) int64 {
+	path := r.URL.Path
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			path = tpl
+		}
+	}
+
+	limit, matched := defaultMax, 0
+	for _, l := range limits {
+		if strings.HasPrefix(path, l.PathPrefix) && len(l.PathPrefix) > matched {
+			limit, matched = l.MaxBytes, len(l.PathPrefix)
+		}
+	}
+	return limit
+}
+
+func writeBodyTooLarge(w http.ResponseWriter, limit int64) {
+	httputil.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds the %d byte limit", limit))
+}
+
+// limitedBody records whether the handler read past the limit.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// bodyLimitWriter turns the error response a handler writes after hitting
+// the body limit (typically 400 "invalid request body") into a 413.
+type bodyLimitWriter struct {
+	http.ResponseWriter
+	body        *limitedBody
+	limit       int64
+	wroteHeader bool
+	replaced    bool
+}
+
+func (w *bodyLimitWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if status >= 400 && w.body.exceeded {
+		w.replaced = true
+		writeBodyTooLarge(w.ResponseWriter, w.limit)
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *bodyLimitWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.replaced {
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush keeps streaming responses (SSE) working through the wrapper.
+func (w *bodyLimitWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *bodyLimitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// jsonEchoHandler decodes a JSON body the way most handlers do and reports
+// decode failures as 400.
+var jsonEchoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	var body map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+})
+
+func newBodyLimitRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.Use(BodyLimitMiddleware(1024,
+		BodyLimit{PathPrefix: "/api/auth/", MaxBytes: 64},
+		BodyLimit{PathPrefix: "/api/clusters/{clusterID}/resources/", MaxBytes: 4096},
+	))
+	r.Handle("/api/auth/login", jsonEchoHandler).Methods(http.MethodPost)
+	r.Handle("/api/clusters/{clusterID}/resources/{resource}", jsonEchoHandler).Methods(http.MethodPost)
+	r.Handle("/api/other", jsonEchoHandler).Methods(http.MethodPost)
+	r.Handle("/api/other", okHandler).Methods(http.MethodGet)
+	return r
+}
+
+func jsonBody(size int) string {
+	return `{"data":"` + strings.Repeat("a", size) + `"}`
+}
+
+func TestBodyLimitMiddleware_ContentLengthOverLimit(t *testing.T) {
+	r := newBodyLimitRouter()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", strings.NewReader(jsonBody(100)))
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", rr.Code)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if body["code"] != "payload_too_large" {
+		t.Errorf("expected code payload_too_large, got %q", body["code"])
+	}
+}
+
+func TestBodyLimitMiddleware_StreamedBodyOverLimit(t *testing.T) {
+	r := newBodyLimitRouter()
+
+	// No Content-Length: the limit is only hit while the handler reads.
+	req := httptest.NewRequest(http.MethodPost, "/api/other", io.NopCloser(strings.NewReader(jsonBody(2000))))
+	req.ContentLength = -1
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected handler's 400 to become 413, got %d", rr.Code)
+	}
+	if strings.Contains(rr.Body.String(), "invalid request body") {
+		t.Errorf("expected the handler's error body to be replaced, got %q", rr.Body.String())
+	}
+}
+
+func TestBodyLimitMiddleware_PerRouteLimits(t *testing.T) {
+	r := newBodyLimitRouter()
+
+	tests := []struct {
+		path string
+		size int
+		want int
+	}{
+		{"/api/other", 900, http.StatusOK},
+		{"/api/other", 2000, http.StatusRequestEntityTooLarge},
+		{"/api/clusters/c1/resources/pods", 2000, http.StatusOK},
+		{"/api/clusters/c1/resources/pods", 5000, http.StatusRequestEntityTooLarge},
+		{"/api/auth/login", 10, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(jsonBody(tt.size)))
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != tt.want {
+			t.Errorf("POST %s with %d bytes: expected %d, got %d", tt.path, tt.size, tt.want, rr.Code)
+		}
+	}
+}
+
+func TestBodyLimitMiddleware_NoBodyPassesThrough(t *testing.T) {
+	r := newBodyLimitRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/other", nil)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK || rr.Body.String() != "ok" {
+		t.Errorf("expected 200 ok, got %d %q", rr.Code, rr.Body.String())
+	}
+}
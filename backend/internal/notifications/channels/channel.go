@@ -1,6 +1,7 @@
 package channels
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 )
@@ -16,6 +17,9 @@ type Message struct {
 	Body      string          `json:"body"`
 	Metadata  json.RawMessage `json:"metadata,omitempty"`
 	Timestamp time.Time       `json:"timestamp"`
+	// IdempotencyKey is the same for every attempt to deliver the message
+	// to one recipient through one channel.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
 // Channel defines the interface for delivering notification messages through
@@ -30,3 +34,9 @@ type Channel interface {
 	// Type returns the channel type identifier (e.g. "email", "slack").
 	Type() string
 }
+
+// Pinger is implemented by channels that can check they reach their service
+// without delivering a message.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"net/smtp"
 	"strings"
+
+	"github.com/darkden-lab/argus/backend/internal/outbound"
 )
 
 // EmailConfig holds the configuration for the email channel.
@@ -214,7 +216,7 @@ type sendGridSender struct {
 
 func (s *sendGridSender) send(from, to, subject, htmlBody string) error {
 	if s.client == nil {
-		s.client = &http.Client{}
+		s.client = outbound.Client(0)
 	}
 
 	payload := map[string]interface{}{
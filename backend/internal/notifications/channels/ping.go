@@ -0,0 +1,82 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// Ping connects to the SMTP server, or to the SendGrid API.
+func (c *EmailChannel) Ping(ctx context.Context) error {
+	if c.config.Provider == "sendgrid" {
+		return dialURL(ctx, "https://api.sendgrid.com")
+	}
+	return dial(ctx, net.JoinHostPort(c.config.SMTPHost, c.config.SMTPPort))
+}
+
+// Ping connects to the webhook's host.
+func (c *SlackChannel) Ping(ctx context.Context) error {
+	return dialURL(ctx, c.config.WebhookURL)
+}
+
+// Ping connects to the webhook's host.
+func (c *TeamsChannel) Ping(ctx context.Context) error {
+	return dialURL(ctx, c.config.WebhookURL)
+}
+
+// Ping connects to the webhook's host.
+func (c *WebhookChannel) Ping(ctx context.Context) error {
+	return dialURL(ctx, c.config.URL)
+}
+
+// Ping calls the Bot API's getMe, which also checks the bot token.
+func (c *TelegramChannel) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/bot%s/getMe", c.baseURL, c.config.BotToken), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// The request URL carries the bot token; keep it out of the error.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("telegram api request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("telegram api returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// dialURL opens and closes a TCP connection to rawURL's host, without
+// sending a request that could trigger a delivery.
+func dialURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid URL")
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	return dial(ctx, net.JoinHostPort(u.Hostname(), port))
+}
+
+func dial(ctx context.Context, addr string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,80 @@
+package channels
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookChannels_Ping(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	webhookURL := server.URL + "/hooks/abc"
+
+	slack, _ := NewSlackChannel("slack", SlackConfig{WebhookURL: webhookURL})
+	teams, _ := NewTeamsChannel("teams", TeamsConfig{WebhookURL: webhookURL})
+	webhook, _ := NewWebhookChannel("webhook", WebhookConfig{URL: webhookURL})
+	pingers := []Pinger{slack, teams, webhook}
+	for _, p := range pingers {
+		if err := p.Ping(context.Background()); err != nil {
+			t.Errorf("%T: expected ping to succeed, got %v", p, err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("expected pings not to send requests, got %d", requests)
+	}
+
+	server.Close()
+	for _, p := range pingers {
+		if err := p.Ping(context.Background()); err == nil {
+			t.Errorf("%T: expected ping to fail once the host is down", p)
+		}
+	}
+}
+
+func TestEmailChannel_Ping(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	host, port, _ := strings.Cut(strings.TrimPrefix(server.URL, "http://"), ":")
+
+	ch, _ := NewEmailChannel("email", EmailConfig{Provider: "smtp", SMTPHost: host, SMTPPort: port})
+	if err := ch.Ping(context.Background()); err != nil {
+		t.Errorf("expected ping to succeed, got %v", err)
+	}
+	server.Close()
+	if err := ch.Ping(context.Background()); err == nil {
+		t.Error("expected ping to fail once the server is down")
+	}
+}
+
+func TestTelegramChannel_Ping(t *testing.T) {
+	var receivedPath string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		w.WriteHeader(status)
+	}))
+
+	ch, _ := NewTelegramChannel("tg", TelegramConfig{BotToken: "123:ABC", ChatID: "1"})
+	ch.baseURL = server.URL
+	if err := ch.Ping(context.Background()); err != nil {
+		t.Errorf("expected ping to succeed, got %v", err)
+	}
+	if receivedPath != "/bot123:ABC/getMe" {
+		t.Errorf("expected getMe, got %s", receivedPath)
+	}
+
+	status = http.StatusUnauthorized
+	if err := ch.Ping(context.Background()); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected an invalid token to fail, got %v", err)
+	}
+
+	server.Close()
+	err := ch.Ping(context.Background())
+	if err == nil || strings.Contains(err.Error(), "123:ABC") {
+		t.Errorf("expected a failure that does not leak the token, got %v", err)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+
+	"github.com/darkden-lab/argus/backend/internal/outbound"
 )
 
 // SlackConfig holds the configuration for a Slack webhook channel.
@@ -28,7 +30,7 @@ func NewSlackChannel(name string, config SlackConfig) (*SlackChannel, error) {
 	return &SlackChannel{
 		name:   name,
 		config: config,
-		client: &http.Client{},
+		client: outbound.Client(0),
 	}, nil
 }
 
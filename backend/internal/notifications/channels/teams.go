@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+
+	"github.com/darkden-lab/argus/backend/internal/outbound"
 )
 
 // TeamsConfig holds the configuration for a Microsoft Teams webhook channel.
@@ -29,7 +31,7 @@ func NewTeamsChannel(name string, config TeamsConfig) (*TeamsChannel, error) {
 	return &TeamsChannel{
 		name:   name,
 		config: config,
-		client: &http.Client{},
+		client: outbound.Client(0),
 	}, nil
 }
 
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+
+	"github.com/darkden-lab/argus/backend/internal/outbound"
 )
 
 // TelegramConfig holds the configuration for a Telegram Bot channel.
@@ -33,7 +35,7 @@ func NewTelegramChannel(name string, config TelegramConfig) (*TelegramChannel, e
 	return &TelegramChannel{
 		name:    name,
 		config:  config,
-		client:  &http.Client{},
+		client:  outbound.Client(0),
 		baseURL: "https://api.telegram.org",
 	}, nil
 }
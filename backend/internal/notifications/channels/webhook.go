@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"strings"
 	"text/template"
+
+	"github.com/darkden-lab/argus/backend/internal/outbound"
 )
 
 // WebhookConfig holds the configuration for a generic webhook channel.
@@ -39,7 +41,7 @@ func NewWebhookChannel(name string, config WebhookConfig) (*WebhookChannel, erro
 	ch := &WebhookChannel{
 		name:   name,
 		config: config,
-		client: &http.Client{},
+		client: outbound.Client(0),
 	}
 
 	if config.PayloadTemplate != "" {
@@ -76,6 +78,9 @@ func (c *WebhookChannel) Send(msg Message, _ []string) error {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if msg.IdempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", msg.IdempotencyKey)
+	}
 	for k, v := range c.config.Headers {
 		req.Header.Set(k, v)
 	}
@@ -123,6 +123,30 @@ func TestWebhookChannel_CustomMethod(t *testing.T) {
 	}
 }
 
+func TestWebhookChannel_IdempotencyKey(t *testing.T) {
+	var keys []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ch, _ := NewWebhookChannel("test", WebhookConfig{URL: server.URL})
+	msg := Message{Severity: "info", Title: "Test", Body: "Test", Timestamp: time.Now()}
+	if err := ch.Send(msg, nil); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	msg.IdempotencyKey = "evt-1:ch-1:user-1"
+	if err := ch.Send(msg, nil); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	if len(keys) != 2 || keys[0] != "" || keys[1] != "evt-1:ch-1:user-1" {
+		t.Errorf("expected the Idempotency-Key header only when the message has a key, got %q", keys)
+	}
+}
+
 func TestWebhookChannel_ServerError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -96,6 +96,14 @@ func (s *ChannelStore) Delete(ctx context.Context, id string) error {
 	return err
 }
 
+// CountEnabled returns the number of enabled notification channels.
+func (s *ChannelStore) CountEnabled(ctx context.Context) (int, error) {
+	var n int
+	err := s.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM notification_channels WHERE enabled = true`).Scan(&n)
+	return n, err
+}
+
 // ListEnabled returns all enabled notification channels.
 func (s *ChannelStore) ListEnabled(ctx context.Context) ([]ChannelConfig, error) {
 	rows, err := s.pool.Query(ctx,
@@ -13,8 +13,12 @@ var AllTopics = []string{
 	TopicWorkloadCrash,
 	TopicWorkloadScale,
 	TopicWorkloadDeploy,
+	TopicWorkloadUnschedulable,
+	TopicWorkloadDisruptionBlocked,
+	TopicWorkloadEvent,
 	TopicNodeReady,
 	TopicNodeNotReady,
+	TopicNodeEvent,
 	TopicSecurityRBAC,
 	TopicSecuritySecret,
 	TopicPluginInstall,
@@ -121,7 +121,8 @@ func TestAllTopics_ContainsExpectedTopics(t *testing.T) {
 	expected := []string{
 		TopicClusterHealth, TopicClusterAdded, TopicClusterRemoved,
 		TopicWorkloadCrash, TopicWorkloadScale, TopicWorkloadDeploy,
-		TopicNodeReady, TopicNodeNotReady,
+		TopicWorkloadUnschedulable, TopicWorkloadDisruptionBlocked, TopicWorkloadEvent,
+		TopicNodeReady, TopicNodeNotReady, TopicNodeEvent,
 		TopicSecurityRBAC, TopicSecuritySecret,
 		TopicPluginInstall, TopicPluginError,
 		TopicAuditAction,
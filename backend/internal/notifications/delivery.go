@@ -0,0 +1,319 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/darkden-lab/argus/backend/internal/notifications/channels"
+)
+
+// Delivery statuses. A dead delivery ran out of attempts; it stays as a dead
+// letter until an admin retries it.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+const (
+	// maxDeliveryAttempts is the number of sends before a delivery is dead.
+	maxDeliveryAttempts = 8
+	// deliveryBaseBackoff is the delay before the first retry; it doubles
+	// with every further attempt up to deliveryMaxBackoff.
+	deliveryBaseBackoff = 30 * time.Second
+	deliveryMaxBackoff  = time.Hour
+	// deliveryLease is how long a delivery being sent is hidden from other
+	// workers. If the sender dies it is retried once the lease expires.
+	deliveryLease = 2 * time.Minute
+	// deliveryPollInterval is how often the worker looks for due retries.
+	deliveryPollInterval = 15 * time.Second
+	// deliveryBatchSize caps the retries one poll sends.
+	deliveryBatchSize = 50
+)
+
+// ErrDeliveryNotFound is returned when retrying a delivery that does not
+// exist or is not dead.
+var ErrDeliveryNotFound = errors.New("dead delivery not found")
+
+// Delivery is the status of one notification's delivery to one channel.
+type Delivery struct {
+	ID             string     `json:"id"`
+	NotificationID string     `json:"notification_id"`
+	ChannelID      string     `json:"channel_id"`
+	ChannelName    string     `json:"channel_name"`
+	ChannelType    string     `json:"channel_type"`
+	UserID         string     `json:"user_id"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// pendingDelivery is a delivery claimed for sending.
+type pendingDelivery struct {
+	ID        string
+	ChannelID string
+	UserID    string
+	Message   channels.Message
+	Attempts  int
+}
+
+// deliveryBackoff returns the delay before retrying a delivery that has
+// failed attempts times.
+func deliveryBackoff(attempts int) time.Duration {
+	d := deliveryBaseBackoff
+	for i := 1; i < attempts && d < deliveryMaxBackoff; i++ {
+		d *= 2
+	}
+	if d > deliveryMaxBackoff {
+		d = deliveryMaxBackoff
+	}
+	return d
+}
+
+// deliveryKey returns the idempotency key of an event's delivery to one
+// user through one channel. It is also sent to channels that support it,
+// such as webhooks, so receivers can drop retries they already processed.
+func deliveryKey(eventID, channelID, userID string) string {
+	return eventID + ":" + channelID + ":" + userID
+}
+
+// DeliveryStore persists notification deliveries.
+type DeliveryStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewDeliveryStore creates a new DeliveryStore.
+func NewDeliveryStore(pool *pgxpool.Pool) *DeliveryStore {
+	return &DeliveryStore{pool: pool}
+}
+
+// Enqueue records a delivery that its caller sends right away; other
+// workers leave it alone until the lease expires. It returns false, and
+// records nothing, if a delivery with the same idempotency key exists.
+func (s *DeliveryStore) Enqueue(ctx context.Context, notificationID, channelID, userID string, msg channels.Message) (*pendingDelivery, bool, error) {
+	message, err := json.Marshal(msg)
+	if err != nil {
+		return nil, false, err
+	}
+	d := &pendingDelivery{ChannelID: channelID, UserID: userID, Message: msg}
+	err = s.pool.QueryRow(ctx,
+		`INSERT INTO notification_deliveries (notification_id, channel_id, user_id, idempotency_key, message, next_attempt_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (idempotency_key) DO NOTHING
+		 RETURNING id`,
+		notificationID, channelID, userID, msg.IdempotencyKey, message, time.Now().Add(deliveryLease),
+	).Scan(&d.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to enqueue delivery: %w", err)
+	}
+	return d, true, nil
+}
+
+// ClaimDue leases up to limit pending deliveries whose next attempt is due.
+func (s *DeliveryStore) ClaimDue(ctx context.Context, limit int) ([]*pendingDelivery, error) {
+	rows, err := s.pool.Query(ctx,
+		`UPDATE notification_deliveries SET next_attempt_at = $2, updated_at = NOW()
+		 WHERE id IN (
+		     SELECT id FROM notification_deliveries
+		     WHERE status = 'pending' AND next_attempt_at <= NOW()
+		     ORDER BY next_attempt_at
+		     LIMIT $1
+		     FOR UPDATE SKIP LOCKED)
+		 RETURNING id, channel_id, user_id, message, attempts`,
+		limit, time.Now().Add(deliveryLease),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var due []*pendingDelivery
+	for rows.Next() {
+		var d pendingDelivery
+		var message []byte
+		if err := rows.Scan(&d.ID, &d.ChannelID, &d.UserID, &message, &d.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan delivery: %w", err)
+		}
+		if err := json.Unmarshal(message, &d.Message); err != nil {
+			return nil, fmt.Errorf("failed to decode delivery %s: %w", d.ID, err)
+		}
+		due = append(due, &d)
+	}
+	return due, rows.Err()
+}
+
+// MarkDelivered records a successful send and adds the channel type to the
+// notification's channels_sent.
+func (s *DeliveryStore) MarkDelivered(ctx context.Context, id, channelType string) error {
+	_, err := s.pool.Exec(ctx,
+		`WITH d AS (
+		     UPDATE notification_deliveries
+		     SET status = 'delivered', attempts = attempts + 1, last_error = '', delivered_at = NOW(), updated_at = NOW()
+		     WHERE id = $1
+		     RETURNING notification_id)
+		 UPDATE notifications n SET channels_sent = array_append(COALESCE(n.channels_sent, '{}'), $2)
+		 FROM d WHERE n.id = d.notification_id AND NOT ($2 = ANY(COALESCE(n.channels_sent, '{}')))`,
+		id, channelType,
+	)
+	return err
+}
+
+// MarkFailed records a failed send. The delivery is retried at retryAt, or
+// becomes dead when retryAt is nil.
+func (s *DeliveryStore) MarkFailed(ctx context.Context, id, sendErr string, retryAt *time.Time) error {
+	status := DeliveryPending
+	if retryAt == nil {
+		status = DeliveryDead
+	}
+	_, err := s.pool.Exec(ctx,
+		`UPDATE notification_deliveries
+		 SET status = $2, attempts = attempts + 1, last_error = $3,
+		     next_attempt_at = COALESCE($4, next_attempt_at), updated_at = NOW()
+		 WHERE id = $1`,
+		id, status, sendErr, retryAt,
+	)
+	return err
+}
+
+const deliveryColumns = `d.id, d.notification_id, d.channel_id, COALESCE(c.name, ''), COALESCE(c.type, ''), d.user_id,
+	d.status, d.attempts, d.last_error, d.next_attempt_at, d.delivered_at, d.created_at, d.updated_at`
+
+func scanDeliveries(rows pgx.Rows) ([]Delivery, error) {
+	defer rows.Close()
+	deliveries := []Delivery{}
+	for rows.Next() {
+		var d Delivery
+		var next time.Time
+		if err := rows.Scan(&d.ID, &d.NotificationID, &d.ChannelID, &d.ChannelName, &d.ChannelType, &d.UserID,
+			&d.Status, &d.Attempts, &d.LastError, &next, &d.DeliveredAt, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if d.Status == DeliveryPending {
+			d.NextAttemptAt = &next
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// ListByNotification returns the deliveries of one of a user's
+// notifications, oldest first.
+func (s *DeliveryStore) ListByNotification(ctx context.Context, userID, notificationID string) ([]Delivery, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+deliveryColumns+`
+		 FROM notification_deliveries d
+		 LEFT JOIN notification_channels c ON c.id = d.channel_id
+		 WHERE d.notification_id = $1 AND d.user_id = $2
+		 ORDER BY d.created_at`,
+		notificationID, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows)
+}
+
+// ListDead returns up to limit dead deliveries, most recently failed first.
+func (s *DeliveryStore) ListDead(ctx context.Context, limit int) ([]Delivery, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+deliveryColumns+`
+		 FROM notification_deliveries d
+		 LEFT JOIN notification_channels c ON c.id = d.channel_id
+		 WHERE d.status = 'dead'
+		 ORDER BY d.updated_at DESC
+		 LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows)
+}
+
+// Retry moves a dead delivery back to the queue with a fresh set of
+// attempts.
+func (s *DeliveryStore) Retry(ctx context.Context, id string) error {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE notification_deliveries
+		 SET status = 'pending', attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+		 WHERE id = $1 AND status = 'dead'`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// DeliveryWorker retries failed deliveries once their backoff has passed.
+// Several replicas may run one; each delivery is claimed by one at a time.
+type DeliveryWorker struct {
+	router *Router
+	store  *DeliveryStore
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewDeliveryWorker creates a worker sending through the router's channels.
+func NewDeliveryWorker(router *Router, store *DeliveryStore) *DeliveryWorker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &DeliveryWorker{router: router, store: store, ctx: ctx, cancel: cancel}
+}
+
+// Start begins polling for due retries.
+func (w *DeliveryWorker) Start() {
+	if w.store.pool == nil {
+		log.Println("notifications: delivery worker has no database connection, not started")
+		return
+	}
+	go w.loop()
+	log.Println("notifications: delivery worker started")
+}
+
+// Stop ends the polling loop.
+func (w *DeliveryWorker) Stop() {
+	w.cancel()
+}
+
+func (w *DeliveryWorker) loop() {
+	ticker := time.NewTicker(deliveryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			w.retryDue()
+		}
+	}
+}
+
+// retryDue sends every delivery whose next attempt is due.
+func (w *DeliveryWorker) retryDue() {
+	due, err := w.store.ClaimDue(w.ctx, deliveryBatchSize)
+	if err != nil {
+		log.Printf("notifications: %v", err)
+		return
+	}
+	for _, d := range due {
+		w.router.attemptDelivery(w.ctx, w.store, d)
+	}
+}
@@ -0,0 +1,95 @@
+package notifications
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+)
+
+func TestDeliveryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := deliveryBackoff(tt.attempts); got != tt.want {
+			t.Errorf("deliveryBackoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDeliveryKey(t *testing.T) {
+	a := deliveryKey("evt-1", "ch-1", "user-1")
+	if a != deliveryKey("evt-1", "ch-1", "user-1") {
+		t.Error("expected the same key for the same delivery")
+	}
+	for _, other := range []string{
+		deliveryKey("evt-2", "ch-1", "user-1"),
+		deliveryKey("evt-1", "ch-2", "user-1"),
+		deliveryKey("evt-1", "ch-1", "user-2"),
+	} {
+		if other == a {
+			t.Errorf("expected distinct deliveries to have distinct keys, both got %q", a)
+		}
+	}
+}
+
+func TestRouter_SendOnce(t *testing.T) {
+	router := NewRouter(nil, nil, nil)
+	slack := &mockChannel{channelType: "slack"}
+	webhook := &mockChannel{channelType: "webhook", sendErr: errors.New("webhook returned status 503")}
+	router.RegisterChannel("ch-slack", slack)
+	router.RegisterChannel("ch-webhook", webhook)
+
+	event := NewEvent(TopicNodeNotReady, CategoryNode, SeverityCritical, "Node down", "node-1 is NotReady", nil)
+	sent := router.sendOnce(event, "user-1", []string{"ch-webhook", "ch-slack"})
+
+	if len(sent) != 1 || sent[0] != "slack" {
+		t.Errorf("expected only slack to be recorded as sent, got %v", sent)
+	}
+	if len(webhook.sentMessages) != 1 {
+		t.Errorf("expected a single attempt without a delivery store, got %d", len(webhook.sentMessages))
+	}
+	if msg := slack.sentMessages[0]; msg.ID != event.ID || msg.Title != "Node down" || msg.IdempotencyKey != "" {
+		t.Errorf("unexpected message %+v", msg)
+	}
+}
+
+func TestDeliveryWorker_NoDatabase(t *testing.T) {
+	w := NewDeliveryWorker(NewRouter(nil, nil, nil), NewDeliveryStore(nil))
+	w.Start()
+	w.Stop()
+}
+
+func TestHandlers_DeliveriesUnavailable(t *testing.T) {
+	h := NewHandlers(nil, nil, nil, nil, nil, "", nil)
+	r := mux.NewRouter()
+	h.RegisterRoutes(r)
+
+	for _, tt := range []struct{ method, path string }{
+		{http.MethodGet, "/api/notifications/n1/deliveries"},
+		{http.MethodGet, "/api/notifications/deliveries/dead"},
+		{http.MethodPost, "/api/notifications/deliveries/d1/retry"},
+	} {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req = req.WithContext(auth.ContextWithClaims(req.Context(), &auth.Claims{UserID: "user-1"}))
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s: expected 503, got %d", tt.method, tt.path, rec.Code)
+		}
+	}
+}
@@ -3,26 +3,81 @@ package notifications
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"net/url"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/darkden-lab/argus/backend/internal/notifications/channels"
 )
 
+// digestIntervals are the digest frequencies a preference can choose and how
+// often each is sent.
+var digestIntervals = map[string]time.Duration{
+	"hourly": time.Hour,
+	"daily":  24 * time.Hour,
+	"weekly": 7 * 24 * time.Hour,
+}
+
+// digestPeriods name the period each digest covers in its summary line.
+var digestPeriods = map[string]string{
+	"hourly": "in the last hour",
+	"daily":  "today",
+	"weekly": "this week",
+}
+
+// digestGroupings are the ways a digest can group its events.
+var digestGroupings = map[string]bool{"category": true, "cluster": true, "namespace": true, "severity": true}
+
+const (
+	// frequencyDigest is the preference frequency that leaves the cadence
+	// to the server's default digest frequency.
+	frequencyDigest = "digest"
+
+	defaultDigestFrequency = "daily"
+	defaultDigestGroupBy   = "category"
+
+	// digestRecentLimit is how many of a group's most recent events a digest
+	// lists by title.
+	digestRecentLimit = 10
+)
+
+// isDigestFrequency reports whether a preference frequency is delivered by
+// the DigestAggregator rather than in real time.
+func isDigestFrequency(frequency string) bool {
+	_, ok := digestIntervals[frequency]
+	return ok || frequency == frequencyDigest
+}
+
+// validateDigest checks that DigestGroupBy is empty or a known grouping.
+func (p *Preference) validateDigest() error {
+	if p.DigestGroupBy != "" && !digestGroupings[p.DigestGroupBy] {
+		return fmt.Errorf("invalid digest_group_by %q", p.DigestGroupBy)
+	}
+	return nil
+}
+
 // DigestAggregator collects notification events and periodically sends
-// aggregated digests via email or other channels. It supports daily and
-// weekly digest frequencies.
+// aggregated digests via email or other channels. It supports hourly, daily
+// and weekly digests, each grouping its events the way the preference it is
+// sent for chooses.
 type DigestAggregator struct {
 	prefStore  *PreferencesStore
 	chanStore  *ChannelStore
 	notifStore *NotificationStore
 	channels   map[string]channels.Channel
 
-	mu      sync.Mutex
-	buffer  map[string][]Event // userID -> events pending digest
-	ctx     context.Context
-	cancel  context.CancelFunc
+	frequency string // for preferences with the "digest" frequency
+	groupBy   string // for preferences that don't choose a grouping
+	linkBase  string // frontend URL the digests link to; no links when empty
+
+	mu     sync.Mutex
+	buffer map[string]map[string][]Event // frequency -> userID -> events pending digest
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // NewDigestAggregator creates a new DigestAggregator.
@@ -38,24 +93,71 @@ func NewDigestAggregator(
 		chanStore:  chanStore,
 		notifStore: notifStore,
 		channels:   chs,
-		buffer:     make(map[string][]Event),
+		frequency:  defaultDigestFrequency,
+		groupBy:    defaultDigestGroupBy,
+		buffer:     make(map[string]map[string][]Event),
 		ctx:        ctx,
 		cancel:     cancel,
 	}
 }
 
-// AddEvent buffers an event for future digest delivery.
-func (d *DigestAggregator) AddEvent(userID string, event Event) {
+// SetDefaults sets the frequency used by preferences set to "digest" and the
+// grouping used by preferences without one. Unknown values are ignored.
+func (d *DigestAggregator) SetDefaults(frequency, groupBy string) {
+	if _, ok := digestIntervals[frequency]; ok {
+		d.frequency = frequency
+	}
+	if digestGroupings[groupBy] {
+		d.groupBy = groupBy
+	}
+}
+
+// SetLinkBase sets the frontend URL digests use to link each group to the
+// in-app notification list.
+func (d *DigestAggregator) SetLinkBase(frontendURL string) {
+	d.linkBase = strings.TrimSuffix(frontendURL, "/")
+}
+
+// resolveFrequency maps the "digest" frequency to the default one.
+func (d *DigestAggregator) resolveFrequency(frequency string) string {
+	if frequency == frequencyDigest {
+		return d.frequency
+	}
+	return frequency
+}
+
+// groupingFor returns how a preference's digest groups its events.
+func (d *DigestAggregator) groupingFor(pref Preference) string {
+	if digestGroupings[pref.DigestGroupBy] {
+		return pref.DigestGroupBy
+	}
+	return d.groupBy
+}
+
+// AddEvent buffers an event for the user's next digest at the given
+// frequency, which may be "digest" for the default one.
+func (d *DigestAggregator) AddEvent(userID, frequency string, event Event) {
+	frequency = d.resolveFrequency(frequency)
+	if _, ok := digestIntervals[frequency]; !ok {
+		return
+	}
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	d.buffer[userID] = append(d.buffer[userID], event)
+	users := d.buffer[frequency]
+	if users == nil {
+		users = make(map[string][]Event)
+		d.buffer[frequency] = users
+	}
+	users[userID] = append(users[userID], event)
 }
 
-// Start begins the digest tick loops: daily at midnight UTC and weekly on
-// Mondays at midnight UTC.
+// Start begins the digest loops. Digests are sent on the hour, daily at
+// midnight UTC and weekly on Mondays at midnight UTC.
 func (d *DigestAggregator) Start() {
-	go d.tickLoop("daily", 24*time.Hour)
-	go d.tickLoop("weekly", 7*24*time.Hour)
+	for frequency, interval := range digestIntervals {
+		go d.tickLoop(frequency, interval)
+	}
 	log.Println("notifications: digest aggregator started")
 }
 
@@ -65,27 +167,31 @@ func (d *DigestAggregator) Stop() {
 }
 
 func (d *DigestAggregator) tickLoop(frequency string, interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
 	for {
+		timer := time.NewTimer(time.Until(nextDigestAt(time.Now(), interval)))
 		select {
 		case <-d.ctx.Done():
+			timer.Stop()
 			return
-		case <-ticker.C:
+		case <-timer.C:
 			d.flush(frequency)
 		}
 	}
 }
 
-// flush drains the buffer and sends digest messages for the given frequency.
+// nextDigestAt returns the first multiple of interval after now. Multiples
+// are counted from the zero time, a Monday at midnight UTC, so daily digests
+// fall at midnight UTC and weekly ones on Mondays.
+func nextDigestAt(now time.Time, interval time.Duration) time.Time {
+	return now.Truncate(interval).Add(interval)
+}
+
+// flush drains the buffer of the given frequency and sends digest messages
+// for it.
 func (d *DigestAggregator) flush(frequency string) {
 	d.mu.Lock()
-	snapshot := make(map[string][]Event, len(d.buffer))
-	for uid, events := range d.buffer {
-		snapshot[uid] = events
-	}
-	d.buffer = make(map[string][]Event)
+	snapshot := d.buffer[frequency]
+	delete(d.buffer, frequency)
 	d.mu.Unlock()
 
 	for userID, events := range snapshot {
@@ -100,7 +206,7 @@ func (d *DigestAggregator) flush(frequency string) {
 		}
 
 		for _, pref := range prefs {
-			if pref.Frequency != frequency || !pref.Enabled || pref.ChannelID == nil {
+			if d.resolveFrequency(pref.Frequency) != frequency || !pref.Enabled || pref.ChannelID == nil {
 				continue
 			}
 
@@ -109,10 +215,10 @@ func (d *DigestAggregator) flush(frequency string) {
 				continue
 			}
 
-			// Filter events matching this preference's category
+			// Filter events matching this preference's category and scope
 			var matching []Event
 			for _, e := range events {
-				if string(e.Category) == pref.Category {
+				if pref.Matches(e) {
 					matching = append(matching, e)
 				}
 			}
@@ -121,7 +227,7 @@ func (d *DigestAggregator) flush(frequency string) {
 				continue
 			}
 
-			msg := buildDigestMessage(frequency, matching)
+			msg := buildDigestMessage(frequency, d.groupingFor(pref), d.linkBase, matching)
 			if err := ch.Send(msg, []string{userID}); err != nil {
 				log.Printf("notifications: digest: failed to send %s digest to user %s: %v",
 					frequency, userID, err)
@@ -130,49 +236,155 @@ func (d *DigestAggregator) flush(frequency string) {
 	}
 }
 
-func buildDigestMessage(frequency string, events []Event) channels.Message {
+func buildDigestMessage(frequency, groupBy, linkBase string, events []Event) channels.Message {
 	title := "Your " + frequency + " notification digest"
-	body := buildDigestBody(events)
+	body := buildDigestBody(frequency, groupBy, linkBase, events)
 
-	meta, _ := json.Marshal(map[string]interface{}{
+	meta := map[string]interface{}{
 		"event_count": len(events),
 		"frequency":   frequency,
-	})
+		"group_by":    groupBy,
+	}
+	if linkBase != "" {
+		meta["link"] = linkBase + "/notifications"
+	}
+	metaJSON, _ := json.Marshal(meta)
 
+	now := time.Now().UTC()
 	return channels.Message{
-		ID:        "digest-" + time.Now().Format("2006-01-02"),
+		ID:        "digest-" + frequency + "-" + now.Format("2006-01-02T15"),
 		Topic:     "digest." + frequency,
 		Category:  "digest",
 		Severity:  "info",
 		Title:     title,
 		Body:      body,
-		Metadata:  meta,
-		Timestamp: time.Now().UTC(),
+		Metadata:  metaJSON,
+		Timestamp: now,
 	}
 }
 
-func buildDigestBody(events []Event) string {
-	body := ""
-	categoryCounts := make(map[Category]int)
+// buildDigestBody summarizes the events per group, e.g. "prod: 12 warnings",
+// and under each group links to the in-app notification list for it and
+// lists its most recent events.
+func buildDigestBody(frequency, groupBy, linkBase string, events []Event) string {
+	var b strings.Builder
+	b.WriteString(countOf(len(events), "notification") + " " + digestPeriods[frequency] + ", grouped by " + groupBy + ".\n")
+
+	for _, g := range groupDigestEvents(events, groupBy) {
+		b.WriteString("\n" + g.name + ": " + severitySummary(g.events) + "\n")
+		if linkBase != "" {
+			b.WriteString("View in Argus: " + notificationListLink(linkBase, groupBy, g.key) + "\n")
+		}
+
+		limit := digestRecentLimit
+		if len(g.events) < limit {
+			limit = len(g.events)
+		}
+		for i := 0; i < limit; i++ {
+			e := g.events[len(g.events)-1-i] // most recent first
+			b.WriteString("- [" + string(e.Severity) + "] " + e.Title + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// digestGroup is the events of a digest that share a category, cluster,
+// namespace or severity.
+type digestGroup struct {
+	key    string // empty for events without a cluster or namespace
+	name   string
+	events []Event
+}
+
+// groupDigestEvents groups events by groupBy, largest group first.
+func groupDigestEvents(events []Event, groupBy string) []digestGroup {
+	index := make(map[string]int)
+	var groups []digestGroup
 	for _, e := range events {
-		categoryCounts[e.Category]++
+		key := digestGroupKey(e, groupBy)
+		i, ok := index[key]
+		if !ok {
+			name := key
+			if name == "" {
+				name = "no " + groupBy
+			}
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, digestGroup{key: key, name: name})
+		}
+		groups[i].events = append(groups[i].events, e)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].events) != len(groups[j].events) {
+			return len(groups[i].events) > len(groups[j].events)
+		}
+		return groups[i].name < groups[j].name
+	})
+	return groups
+}
+
+func digestGroupKey(e Event, groupBy string) string {
+	switch groupBy {
+	case "cluster":
+		clusterID, _ := eventScope(e)
+		return clusterID
+	case "namespace":
+		_, namespace := eventScope(e)
+		return namespace
+	case "severity":
+		return string(e.Severity)
+	default:
+		return string(e.Category)
 	}
+}
 
-	for cat, count := range categoryCounts {
-		body += string(cat) + ": " + intToStr(count) + " events\n"
+// notificationListLink links to the in-app notification list, filtered to a
+// digest group where the list can filter on it.
+func notificationListLink(linkBase, groupBy, key string) string {
+	link := linkBase + "/notifications"
+	if key == "" {
+		return link
 	}
+	q := url.Values{}
+	switch groupBy {
+	case "category", "severity":
+		q.Set(groupBy, key)
+	default:
+		q.Set("search", key)
+	}
+	return link + "?" + q.Encode()
+}
 
-	body += "\nRecent:\n"
-	limit := 10
-	if len(events) < limit {
-		limit = len(events)
+// severitySummary counts events by severity, most severe first, e.g.
+// "2 critical, 10 warnings".
+func severitySummary(events []Event) string {
+	counts := make(map[Severity]int)
+	for _, e := range events {
+		counts[e.Severity]++
+	}
+	var parts []string
+	if n := counts[SeverityCritical]; n > 0 {
+		parts = append(parts, intToStr(n)+" critical")
 	}
-	for i := 0; i < limit; i++ {
-		e := events[len(events)-1-i] // most recent first
-		body += "- [" + string(e.Severity) + "] " + e.Title + "\n"
+	if n := counts[SeverityWarning]; n > 0 {
+		parts = append(parts, countOf(n, "warning"))
 	}
+	if n := counts[SeverityInfo]; n > 0 {
+		parts = append(parts, intToStr(n)+" info")
+	}
+	if other := len(events) - counts[SeverityCritical] - counts[SeverityWarning] - counts[SeverityInfo]; other > 0 {
+		parts = append(parts, countOf(other, "other event"))
+	}
+	return strings.Join(parts, ", ")
+}
 
-	return body
+func countOf(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return intToStr(n) + " " + noun + "s"
 }
 
 func intToStr(n int) string {
@@ -1,6 +1,7 @@
 package notifications
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 	"time"
@@ -11,18 +12,61 @@ func TestDigestAggregator_AddEvent(t *testing.T) {
 	defer agg.Stop()
 
 	event := NewEvent(TopicClusterHealth, CategoryCluster, SeverityWarning, "Test", "Body", nil)
-	agg.AddEvent("user-1", event)
-	agg.AddEvent("user-1", event)
-	agg.AddEvent("user-2", event)
+	agg.AddEvent("user-1", "daily", event)
+	agg.AddEvent("user-1", "daily", event)
+	agg.AddEvent("user-2", "daily", event)
 
 	agg.mu.Lock()
 	defer agg.mu.Unlock()
 
-	if len(agg.buffer["user-1"]) != 2 {
-		t.Errorf("expected 2 events for user-1, got %d", len(agg.buffer["user-1"]))
+	if len(agg.buffer["daily"]["user-1"]) != 2 {
+		t.Errorf("expected 2 events for user-1, got %d", len(agg.buffer["daily"]["user-1"]))
 	}
-	if len(agg.buffer["user-2"]) != 1 {
-		t.Errorf("expected 1 event for user-2, got %d", len(agg.buffer["user-2"]))
+	if len(agg.buffer["daily"]["user-2"]) != 1 {
+		t.Errorf("expected 1 event for user-2, got %d", len(agg.buffer["daily"]["user-2"]))
+	}
+}
+
+func TestDigestAggregator_AddEventFrequencies(t *testing.T) {
+	agg := NewDigestAggregator(nil, nil, nil, nil)
+	defer agg.Stop()
+	agg.SetDefaults("hourly", "namespace")
+
+	event := NewEvent(TopicClusterHealth, CategoryCluster, SeverityWarning, "Test", "Body", nil)
+	agg.AddEvent("user-1", "digest", event)
+	agg.AddEvent("user-1", "weekly", event)
+	agg.AddEvent("user-1", "realtime", event)
+
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+
+	if len(agg.buffer["hourly"]["user-1"]) != 1 || len(agg.buffer["weekly"]["user-1"]) != 1 {
+		t.Errorf("expected one event in the hourly and weekly buffers, got %v", agg.buffer)
+	}
+	if len(agg.buffer) != 2 {
+		t.Errorf("expected no buffer for realtime, got %d buffers", len(agg.buffer))
+	}
+}
+
+func TestDigestAggregator_Defaults(t *testing.T) {
+	agg := NewDigestAggregator(nil, nil, nil, nil)
+	defer agg.Stop()
+
+	if agg.resolveFrequency("digest") != "daily" || agg.groupingFor(Preference{}) != "category" {
+		t.Errorf("unexpected defaults %q %q", agg.frequency, agg.groupBy)
+	}
+
+	agg.SetDefaults("weekly", "cluster")
+	if agg.resolveFrequency("digest") != "weekly" || agg.resolveFrequency("hourly") != "hourly" {
+		t.Errorf("expected digest to resolve to weekly, got %q", agg.resolveFrequency("digest"))
+	}
+	if agg.groupingFor(Preference{}) != "cluster" || agg.groupingFor(Preference{DigestGroupBy: "severity"}) != "severity" {
+		t.Errorf("expected the preference grouping to win over the default")
+	}
+
+	agg.SetDefaults("monthly", "pod")
+	if agg.frequency != "weekly" || agg.groupBy != "cluster" {
+		t.Errorf("expected unknown defaults to be ignored, got %q %q", agg.frequency, agg.groupBy)
 	}
 }
 
@@ -40,7 +84,7 @@ func TestBuildDigestMessage(t *testing.T) {
 		NewEvent(TopicClusterHealth, CategoryCluster, SeverityInfo, "Cluster healthy", "Body 3", nil),
 	}
 
-	msg := buildDigestMessage("daily", events)
+	msg := buildDigestMessage("daily", "category", "", events)
 
 	if msg.Title != "Your daily notification digest" {
 		t.Errorf("expected title 'Your daily notification digest', got %q", msg.Title)
@@ -65,7 +109,7 @@ func TestBuildDigestBody_LimitsTenEvents(t *testing.T) {
 		events[i] = NewEvent(TopicClusterHealth, CategoryCluster, SeverityInfo, "Event "+intToStr(i), "Body", nil)
 	}
 
-	body := buildDigestBody(events)
+	body := buildDigestBody("daily", "category", "", events)
 
 	// Should contain max 10 "- [" entries
 	count := strings.Count(body, "- [")
@@ -79,7 +123,7 @@ func TestBuildDigestMessage_Timestamp(t *testing.T) {
 		NewEvent(TopicClusterHealth, CategoryCluster, SeverityInfo, "Test", "Body", nil),
 	}
 
-	msg := buildDigestMessage("weekly", events)
+	msg := buildDigestMessage("weekly", "category", "", events)
 
 	if msg.Timestamp.IsZero() {
 		t.Error("expected non-zero timestamp")
@@ -107,3 +151,92 @@ func TestIntToStr(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildDigestBody_GroupedByNamespace(t *testing.T) {
+	meta := func(ns string) json.RawMessage {
+		return json.RawMessage(`{"cluster_id":"prod","namespace":"` + ns + `"}`)
+	}
+	var events []Event
+	for i := 0; i < 12; i++ {
+		events = append(events, NewEvent(TopicWorkloadCrash, CategoryWorkload, SeverityWarning, "Pod crashed "+intToStr(i), "Body", meta("payments")))
+	}
+	events = append(events,
+		NewEvent(TopicWorkloadCrash, CategoryWorkload, SeverityCritical, "Pod evicted", "Body", meta("web")),
+		NewEvent(TopicClusterHealth, CategoryCluster, SeverityInfo, "Cluster healthy", "Body", nil),
+	)
+
+	body := buildDigestBody("daily", "namespace", "https://argus.example.com", events)
+
+	for _, want := range []string{
+		"14 notifications today, grouped by namespace.\n",
+		"\npayments: 12 warnings\nView in Argus: https://argus.example.com/notifications?search=payments\n",
+		"\nweb: 1 critical\n",
+		"\nno namespace: 1 info\nView in Argus: https://argus.example.com/notifications\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected body to contain %q, got:\n%s", want, body)
+		}
+	}
+	if strings.Index(body, "payments:") > strings.Index(body, "web:") {
+		t.Errorf("expected the largest group first, got:\n%s", body)
+	}
+}
+
+func TestNotificationListLink(t *testing.T) {
+	tests := []struct {
+		groupBy, key, want string
+	}{
+		{"category", "workload", "https://argus/notifications?category=workload"},
+		{"severity", "critical", "https://argus/notifications?severity=critical"},
+		{"cluster", "prod eu", "https://argus/notifications?search=prod+eu"},
+		{"namespace", "", "https://argus/notifications"},
+	}
+	for _, tt := range tests {
+		if got := notificationListLink("https://argus", tt.groupBy, tt.key); got != tt.want {
+			t.Errorf("notificationListLink(%q, %q) = %q, want %q", tt.groupBy, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestBuildDigestMessage_Metadata(t *testing.T) {
+	events := []Event{NewEvent(TopicClusterHealth, CategoryCluster, SeverityInfo, "Test", "Body", nil)}
+
+	msg := buildDigestMessage("hourly", "severity", "https://argus", events)
+
+	var meta map[string]interface{}
+	if err := json.Unmarshal(msg.Metadata, &meta); err != nil {
+		t.Fatal(err)
+	}
+	if meta["group_by"] != "severity" || meta["link"] != "https://argus/notifications" || msg.Topic != "digest.hourly" {
+		t.Errorf("unexpected message %s %s", msg.Topic, msg.Metadata)
+	}
+	if !strings.HasPrefix(msg.Body, "1 notification in the last hour, grouped by severity.") {
+		t.Errorf("unexpected body %q", msg.Body)
+	}
+}
+
+func TestNextDigestAt(t *testing.T) {
+	now := time.Date(2026, 10, 15, 13, 20, 0, 0, time.UTC) // a Thursday
+	tests := []struct {
+		frequency string
+		want      time.Time
+	}{
+		{"hourly", time.Date(2026, 10, 15, 14, 0, 0, 0, time.UTC)},
+		{"daily", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{"weekly", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := nextDigestAt(now, digestIntervals[tt.frequency]); !got.Equal(tt.want) {
+			t.Errorf("%s: got %s, want %s", tt.frequency, got, tt.want)
+		}
+	}
+}
+
+func TestPreferenceValidateDigest(t *testing.T) {
+	if err := (&Preference{DigestGroupBy: "namespace"}).validateDigest(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (&Preference{DigestGroupBy: "pod"}).validateDigest(); err == nil {
+		t.Error("expected an unknown grouping to be rejected")
+	}
+}
@@ -9,19 +9,23 @@ import (
 
 // Topic constants for notification events.
 const (
-	TopicClusterHealth  = "cluster.health"
-	TopicClusterAdded   = "cluster.added"
-	TopicClusterRemoved = "cluster.removed"
-	TopicWorkloadCrash  = "workload.crash"
-	TopicWorkloadScale  = "workload.scale"
-	TopicWorkloadDeploy = "workload.deploy"
-	TopicNodeReady      = "node.ready"
-	TopicNodeNotReady   = "node.not_ready"
-	TopicSecurityRBAC   = "security.rbac"
-	TopicSecuritySecret = "security.secret"
-	TopicPluginInstall  = "plugin.install"
-	TopicPluginError    = "plugin.error"
-	TopicAuditAction    = "audit.action"
+	TopicClusterHealth             = "cluster.health"
+	TopicClusterAdded              = "cluster.added"
+	TopicClusterRemoved            = "cluster.removed"
+	TopicWorkloadCrash             = "workload.crash"
+	TopicWorkloadScale             = "workload.scale"
+	TopicWorkloadDeploy            = "workload.deploy"
+	TopicWorkloadUnschedulable     = "workload.unschedulable"
+	TopicWorkloadDisruptionBlocked = "workload.disruption_blocked"
+	TopicWorkloadEvent             = "workload.event"
+	TopicNodeReady                 = "node.ready"
+	TopicNodeNotReady              = "node.not_ready"
+	TopicNodeEvent                 = "node.event"
+	TopicSecurityRBAC              = "security.rbac"
+	TopicSecuritySecret            = "security.secret"
+	TopicPluginInstall             = "plugin.install"
+	TopicPluginError               = "plugin.error"
+	TopicAuditAction               = "audit.action"
 )
 
 // Category groups related topics for user preference management.
@@ -2,8 +2,10 @@ package notifications
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -19,6 +21,8 @@ type Handlers struct {
 	prefStore      *PreferencesStore
 	chanStore      *ChannelStore
 	tmplStore      *TemplateStore
+	muteStore      *MuteStore
+	deliveryStore  *DeliveryStore
 	router         *Router
 	encryptionKey  string
 	rbacWriteGuard mux.MiddlewareFunc
@@ -37,6 +41,16 @@ func NewHandlers(notifStore *NotificationStore, prefStore *PreferencesStore, cha
 	}
 }
 
+// SetMuteStore sets the store backing the snooze and maintenance endpoints.
+func (h *Handlers) SetMuteStore(ms *MuteStore) {
+	h.muteStore = ms
+}
+
+// SetDeliveryStore sets the store backing the delivery history endpoints.
+func (h *Handlers) SetDeliveryStore(ds *DeliveryStore) {
+	h.deliveryStore = ds
+}
+
 // RegisterRoutes wires the notification endpoints onto the provided router.
 func (h *Handlers) RegisterRoutes(r *mux.Router) {
 	// User-level endpoints (no admin RBAC, user-scoped)
@@ -44,9 +58,13 @@ func (h *Handlers) RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/api/notifications/unread-count", h.UnreadCount).Methods("GET")
 	r.HandleFunc("/api/notifications/{id}/read", h.MarkRead).Methods("PUT")
 	r.HandleFunc("/api/notifications/read-all", h.MarkAllRead).Methods("PUT")
+	r.HandleFunc("/api/notifications/{id}/deliveries", h.ListDeliveries).Methods("GET")
 	r.HandleFunc("/api/notifications/preferences", h.GetPreferences).Methods("GET")
 	r.HandleFunc("/api/notifications/preferences", h.UpdatePreferences).Methods("PUT")
 	r.HandleFunc("/api/notifications/channels", h.ListChannels).Methods("GET")
+	r.HandleFunc("/api/notifications/snooze", h.GetSnooze).Methods("GET")
+	r.HandleFunc("/api/notifications/snooze", h.SetSnooze).Methods("PUT")
+	r.HandleFunc("/api/notifications/snooze", h.ClearSnooze).Methods("DELETE")
 
 	// Admin channel management requires notifications:write RBAC
 	writeRoutes := r.PathPrefix("").Subrouter()
@@ -63,6 +81,15 @@ func (h *Handlers) RegisterRoutes(r *mux.Router) {
 	writeRoutes.HandleFunc("/api/notifications/templates", h.CreateTemplate).Methods("POST")
 	writeRoutes.HandleFunc("/api/notifications/templates/{id}", h.UpdateTemplate).Methods("PUT")
 	writeRoutes.HandleFunc("/api/notifications/templates/{id}", h.DeleteTemplate).Methods("DELETE")
+
+	// Maintenance windows are visible to everyone; managing them is admin-only
+	r.HandleFunc("/api/notifications/maintenance", h.ListMaintenanceWindows).Methods("GET")
+	writeRoutes.HandleFunc("/api/notifications/maintenance", h.CreateMaintenanceWindow).Methods("POST")
+	writeRoutes.HandleFunc("/api/notifications/maintenance/{id}", h.DeleteMaintenanceWindow).Methods("DELETE")
+
+	// Dead-lettered deliveries across all users (admin)
+	writeRoutes.HandleFunc("/api/notifications/deliveries/dead", h.ListDeadDeliveries).Methods("GET")
+	writeRoutes.HandleFunc("/api/notifications/deliveries/{id}/retry", h.RetryDelivery).Methods("POST")
 }
 
 // getUserID extracts the user ID from the JWT claims in the request context.
@@ -100,20 +127,56 @@ func (h *Handlers) ListNotifications(w http.ResponseWriter, r *http.Request) {
 		Offset:   offset,
 	}
 
-	notifications, total, err := h.notifStore.List(r.Context(), params)
+	var err error
+	if params.Since, err = parseTimeParam(q.Get("since")); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "since must be an RFC 3339 timestamp")
+		return
+	}
+	if params.Until, err = parseTimeParam(q.Get("until")); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "until must be an RFC 3339 timestamp")
+		return
+	}
+
+	if c := q.Get("cursor"); c != "" {
+		if offset != 0 {
+			httputil.WriteError(w, http.StatusBadRequest, "cursor and offset cannot be combined")
+			return
+		}
+		cursor, err := ParseNotificationCursor(c)
+		if err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		params.Cursor = cursor
+	}
+
+	page, err := h.notifStore.List(r.Context(), params)
 	if err != nil {
 		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	httputil.WriteJSON(w, http.StatusOK, map[string]interface{}{
-		"notifications": notifications,
-		"total":         total,
+		"notifications": page.Notifications,
+		"total":         page.Total,
 		"limit":         params.Limit,
 		"offset":        params.Offset,
+		"next_cursor":   page.NextCursor,
 	})
 }
 
+// parseTimeParam parses an optional RFC 3339 query parameter.
+func parseTimeParam(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
 // UnreadCount handles GET /api/notifications/unread-count
 func (h *Handlers) UnreadCount(w http.ResponseWriter, r *http.Request) {
 	userID := getUserID(r)
@@ -209,10 +272,16 @@ func (h *Handlers) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
 
 	var req struct {
 		Preferences []struct {
-			Category  string  `json:"category"`
-			ChannelID *string `json:"channel_id"`
-			Frequency string  `json:"frequency"`
-			Enabled   bool    `json:"enabled"`
+			Category      string   `json:"category"`
+			ChannelID     *string  `json:"channel_id"`
+			Frequency     string   `json:"frequency"`
+			Enabled       bool     `json:"enabled"`
+			ClusterIDs    []string `json:"cluster_ids"`
+			Namespaces    []string `json:"namespaces"`
+			MinSeverity   string   `json:"min_severity"`
+			EventTypes    []string `json:"event_types"`
+			EventReasons  []string `json:"event_reasons"`
+			DigestGroupBy string   `json:"digest_group_by"`
 		} `json:"preferences"`
 	}
 
@@ -221,14 +290,33 @@ func (h *Handlers) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	prefs := make([]*Preference, 0, len(req.Preferences))
 	for _, p := range req.Preferences {
 		pref := &Preference{
-			UserID:    userID,
-			Category:  p.Category,
-			ChannelID: p.ChannelID,
-			Frequency: p.Frequency,
-			Enabled:   p.Enabled,
+			UserID:        userID,
+			Category:      p.Category,
+			ChannelID:     p.ChannelID,
+			Frequency:     p.Frequency,
+			Enabled:       p.Enabled,
+			ClusterIDs:    p.ClusterIDs,
+			Namespaces:    p.Namespaces,
+			MinSeverity:   p.MinSeverity,
+			EventTypes:    p.EventTypes,
+			EventReasons:  p.EventReasons,
+			DigestGroupBy: p.DigestGroupBy,
+		}
+		if err := pref.ValidateScope(); err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, err.Error())
+			return
 		}
+		if err := pref.validateDigest(); err != nil {
+			httputil.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		prefs = append(prefs, pref)
+	}
+
+	for _, pref := range prefs {
 		if err := h.prefStore.Set(r.Context(), pref); err != nil {
 			httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 			return
@@ -475,3 +563,217 @@ func (h *Handlers) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
 
 	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
+
+// snoozeResponse reports the caller's snooze; Until is nil when not snoozed.
+type snoozeResponse struct {
+	Snoozed bool       `json:"snoozed"`
+	Until   *time.Time `json:"until"`
+}
+
+// GetSnooze handles GET /api/notifications/snooze
+func (h *Handlers) GetSnooze(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if h.muteStore == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "notification muting is not available")
+		return
+	}
+
+	until, err := h.muteStore.GetSnooze(r.Context(), userID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, snoozeResponse{Snoozed: until != nil, Until: until})
+}
+
+// SetSnooze handles PUT /api/notifications/snooze. While snoozed, the
+// caller's notifications are stored in-app but not sent to any channel.
+func (h *Handlers) SetSnooze(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if h.muteStore == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "notification muting is not available")
+		return
+	}
+
+	var req struct {
+		Until time.Time `json:"until"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := validateSnooze(req.Until, time.Now()); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.muteStore.SetSnooze(r.Context(), userID, req.Until); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, snoozeResponse{Snoozed: true, Until: &req.Until})
+}
+
+// ClearSnooze handles DELETE /api/notifications/snooze
+func (h *Handlers) ClearSnooze(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if h.muteStore == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "notification muting is not available")
+		return
+	}
+
+	if err := h.muteStore.ClearSnooze(r.Context(), userID); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, snoozeResponse{})
+}
+
+// ListMaintenanceWindows handles GET /api/notifications/maintenance
+func (h *Handlers) ListMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	if h.muteStore == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "notification muting is not available")
+		return
+	}
+
+	windows, err := h.muteStore.ListWindows(r.Context())
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, windows)
+}
+
+// CreateMaintenanceWindow handles POST /api/notifications/maintenance. A
+// window mutes non-critical notifications for its clusters, or for all
+// clusters when cluster_ids is empty.
+func (h *Handlers) CreateMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	if h.muteStore == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "notification muting is not available")
+		return
+	}
+
+	var req struct {
+		StartsAt   *time.Time `json:"starts_at"`
+		EndsAt     time.Time  `json:"ends_at"`
+		ClusterIDs []string   `json:"cluster_ids"`
+		Reason     string     `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	now := time.Now()
+	window := &MaintenanceWindow{
+		StartsAt:   now,
+		EndsAt:     req.EndsAt,
+		ClusterIDs: req.ClusterIDs,
+		Reason:     req.Reason,
+	}
+	if req.StartsAt != nil {
+		window.StartsAt = *req.StartsAt
+	}
+	if userID := getUserID(r); userID != "" {
+		window.CreatedBy = &userID
+	}
+	if err := window.Validate(now); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.muteStore.CreateWindow(r.Context(), window); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.WriteJSON(w, http.StatusCreated, window)
+}
+
+// DeleteMaintenanceWindow handles DELETE /api/notifications/maintenance/:id
+func (h *Handlers) DeleteMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	if h.muteStore == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "notification muting is not available")
+		return
+	}
+
+	if err := h.muteStore.DeleteWindow(r.Context(), mux.Vars(r)["id"]); err != nil {
+		if errors.Is(err, ErrMaintenanceWindowNotFound) {
+			httputil.WriteError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// ListDeliveries handles GET /api/notifications/:id/deliveries. It shows
+// whether each channel the notification was sent to received it.
+func (h *Handlers) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID := getUserID(r)
+	if userID == "" {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if h.deliveryStore == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "delivery tracking is not available")
+		return
+	}
+
+	deliveries, err := h.deliveryStore.ListByNotification(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]interface{}{"deliveries": deliveries})
+}
+
+// ListDeadDeliveries handles GET /api/notifications/deliveries/dead
+func (h *Handlers) ListDeadDeliveries(w http.ResponseWriter, r *http.Request) {
+	if h.deliveryStore == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "delivery tracking is not available")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	deliveries, err := h.deliveryStore.ListDead(r.Context(), limit)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]interface{}{"deliveries": deliveries, "limit": limit})
+}
+
+// RetryDelivery handles POST /api/notifications/deliveries/:id/retry. The
+// dead delivery is queued again with a fresh set of attempts.
+func (h *Handlers) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	if h.deliveryStore == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "delivery tracking is not available")
+		return
+	}
+
+	if err := h.deliveryStore.Retry(r.Context(), mux.Vars(r)["id"]); err != nil {
+		if errors.Is(err, ErrDeliveryNotFound) {
+			httputil.WriteError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"status": "queued"})
+}
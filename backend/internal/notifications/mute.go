@@ -0,0 +1,196 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// maxSnooze bounds how far ahead a user may snooze notifications.
+	maxSnooze = 30 * 24 * time.Hour
+	// maxMaintenanceWindow bounds the length of one maintenance window.
+	maxMaintenanceWindow = 7 * 24 * time.Hour
+)
+
+// ErrMaintenanceWindowNotFound is returned when deleting a window that does
+// not exist.
+var ErrMaintenanceWindowNotFound = errors.New("maintenance window not found")
+
+// MaintenanceWindow mutes non-critical notifications between StartsAt and
+// EndsAt, for the listed clusters or, when ClusterIDs is empty, everywhere.
+// Muted notifications are still stored in-app but not sent to channels.
+type MaintenanceWindow struct {
+	ID         string    `json:"id"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	ClusterIDs []string  `json:"cluster_ids"`
+	Reason     string    `json:"reason"`
+	CreatedBy  *string   `json:"created_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Validate checks the window's time range.
+func (w *MaintenanceWindow) Validate(now time.Time) error {
+	if w.StartsAt.IsZero() || w.EndsAt.IsZero() {
+		return fmt.Errorf("starts_at and ends_at are required")
+	}
+	if !w.EndsAt.After(w.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	if !w.EndsAt.After(now) {
+		return fmt.Errorf("ends_at must be in the future")
+	}
+	if w.EndsAt.Sub(w.StartsAt) > maxMaintenanceWindow {
+		return fmt.Errorf("a maintenance window may last at most %s", maxMaintenanceWindow)
+	}
+	return nil
+}
+
+// Mutes reports whether the window suppresses the event at now. Critical
+// events are never muted, and a window limited to clusters does not mute
+// events that carry no cluster.
+func (w *MaintenanceWindow) Mutes(event Event, now time.Time) bool {
+	if event.Severity == SeverityCritical || now.Before(w.StartsAt) || !now.Before(w.EndsAt) {
+		return false
+	}
+	if len(w.ClusterIDs) == 0 {
+		return true
+	}
+	clusterID, _ := eventScope(event)
+	return containsString(w.ClusterIDs, clusterID)
+}
+
+// validateSnooze checks that a snooze ends in the future but within maxSnooze.
+func validateSnooze(until, now time.Time) error {
+	if !until.After(now) {
+		return fmt.Errorf("until must be in the future")
+	}
+	if until.Sub(now) > maxSnooze {
+		return fmt.Errorf("notifications may be snoozed for at most %s", maxSnooze)
+	}
+	return nil
+}
+
+// MuteStore keeps per-user snoozes and admin maintenance windows.
+type MuteStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewMuteStore creates a new MuteStore.
+func NewMuteStore(pool *pgxpool.Pool) *MuteStore {
+	return &MuteStore{pool: pool}
+}
+
+// GetSnooze returns the time until which the user's notifications are
+// snoozed, or nil when they are not.
+func (s *MuteStore) GetSnooze(ctx context.Context, userID string) (*time.Time, error) {
+	var until time.Time
+	err := s.pool.QueryRow(ctx,
+		`SELECT until FROM notification_snoozes WHERE user_id = $1 AND until > NOW()`,
+		userID,
+	).Scan(&until)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &until, nil
+}
+
+// SetSnooze snoozes the user's notifications until the given time.
+func (s *MuteStore) SetSnooze(ctx context.Context, userID string, until time.Time) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO notification_snoozes (user_id, until) VALUES ($1, $2)
+		 ON CONFLICT (user_id) DO UPDATE SET until = EXCLUDED.until, updated_at = NOW()`,
+		userID, until,
+	)
+	return err
+}
+
+// ClearSnooze ends the user's snooze.
+func (s *MuteStore) ClearSnooze(ctx context.Context, userID string) error {
+	_, err := s.pool.Exec(ctx, `DELETE FROM notification_snoozes WHERE user_id = $1`, userID)
+	return err
+}
+
+// SnoozedUsers returns the users whose notifications are snoozed now.
+func (s *MuteStore) SnoozedUsers(ctx context.Context) (map[string]bool, error) {
+	rows, err := s.pool.Query(ctx, `SELECT user_id FROM notification_snoozes WHERE until > NOW()`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snoozed := make(map[string]bool)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		snoozed[userID] = true
+	}
+	return snoozed, rows.Err()
+}
+
+// ListWindows returns the maintenance windows that have not ended yet,
+// soonest first.
+func (s *MuteStore) ListWindows(ctx context.Context) ([]MaintenanceWindow, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, starts_at, ends_at, cluster_ids, reason, created_by, created_at
+		 FROM notification_maintenance_windows WHERE ends_at > NOW() ORDER BY starts_at, id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := []MaintenanceWindow{}
+	for rows.Next() {
+		var w MaintenanceWindow
+		if err := rows.Scan(&w.ID, &w.StartsAt, &w.EndsAt, &w.ClusterIDs, &w.Reason, &w.CreatedBy, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, rows.Err()
+}
+
+// CreateWindow stores a maintenance window and fills in its ID and CreatedAt.
+func (s *MuteStore) CreateWindow(ctx context.Context, w *MaintenanceWindow) error {
+	if w.ClusterIDs == nil {
+		w.ClusterIDs = []string{}
+	}
+	return s.pool.QueryRow(ctx,
+		`INSERT INTO notification_maintenance_windows (starts_at, ends_at, cluster_ids, reason, created_by)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`,
+		w.StartsAt, w.EndsAt, w.ClusterIDs, w.Reason, w.CreatedBy,
+	).Scan(&w.ID, &w.CreatedAt)
+}
+
+// DeleteWindow removes a maintenance window, ending it early if it is active.
+func (s *MuteStore) DeleteWindow(ctx context.Context, id string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM notification_maintenance_windows WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrMaintenanceWindowNotFound
+	}
+	return nil
+}
+
+// mutedByMaintenance reports whether any of the windows suppresses the event.
+func mutedByMaintenance(windows []MaintenanceWindow, event Event, now time.Time) bool {
+	for i := range windows {
+		if windows[i].Mutes(event, now) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package notifications
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindowMutes(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	active := MaintenanceWindow{StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)}
+	scoped := MaintenanceWindow{StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour), ClusterIDs: []string{"c1"}}
+
+	tests := []struct {
+		name   string
+		window MaintenanceWindow
+		event  Event
+		at     time.Time
+		want   bool
+	}{
+		{"warning during window", active, scopedEvent(SeverityWarning, map[string]string{"cluster": "c2"}), now, true},
+		{"critical is never muted", active, scopedEvent(SeverityCritical, nil), now, false},
+		{"before start", active, scopedEvent(SeverityInfo, nil), now.Add(-2 * time.Hour), false},
+		{"at end", active, scopedEvent(SeverityInfo, nil), now.Add(time.Hour), false},
+		{"listed cluster", scoped, scopedEvent(SeverityWarning, map[string]string{"cluster_id": "c1"}), now, true},
+		{"other cluster", scoped, scopedEvent(SeverityWarning, map[string]string{"cluster": "c2"}), now, false},
+		{"no cluster", scoped, scopedEvent(SeverityWarning, nil), now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.window.Mutes(tt.event, tt.at); got != tt.want {
+				t.Errorf("Mutes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if !mutedByMaintenance([]MaintenanceWindow{scoped, active}, scopedEvent(SeverityInfo, nil), now) {
+		t.Error("expected any active window to mute the event")
+	}
+}
+
+func TestMaintenanceWindowValidate(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		window  MaintenanceWindow
+		wantErr string
+	}{
+		{"missing end", MaintenanceWindow{StartsAt: now}, "required"},
+		{"end before start", MaintenanceWindow{StartsAt: now, EndsAt: now.Add(-time.Minute)}, "after starts_at"},
+		{"already over", MaintenanceWindow{StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)}, "future"},
+		{"too long", MaintenanceWindow{StartsAt: now, EndsAt: now.Add(8 * 24 * time.Hour)}, "at most"},
+		{"valid", MaintenanceWindow{StartsAt: now, EndsAt: now.Add(2 * time.Hour)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.window.Validate(now)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateSnooze(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := validateSnooze(now.Add(4*time.Hour), now); err != nil {
+		t.Errorf("expected a 4h snooze to be valid, got %v", err)
+	}
+	if err := validateSnooze(now.Add(-time.Minute), now); err == nil {
+		t.Error("expected a snooze in the past to be rejected")
+	}
+	if err := validateSnooze(now.Add(31*24*time.Hour), now); err == nil {
+		t.Error("expected a snooze beyond the maximum to be rejected")
+	}
+}
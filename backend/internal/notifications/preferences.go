@@ -8,15 +8,25 @@ import (
 )
 
 // Preference represents a user's notification preference for a category/channel.
+// ClusterIDs, Namespaces and MinSeverity optionally narrow which events of the
+// category it applies to, and EventTypes and EventReasons which Kubernetes
+// Events; empty values mean no filter. DigestGroupBy chooses how a digest
+// frequency groups events; empty means the server default.
 type Preference struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	Category  string    `json:"category"`
-	ChannelID *string   `json:"channel_id,omitempty"`
-	Frequency string    `json:"frequency"` // realtime, daily, weekly, none
-	Enabled   bool      `json:"enabled"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            string    `json:"id"`
+	UserID        string    `json:"user_id"`
+	Category      string    `json:"category"`
+	ChannelID     *string   `json:"channel_id,omitempty"`
+	Frequency     string    `json:"frequency"` // realtime, hourly, daily, weekly, digest, none
+	Enabled       bool      `json:"enabled"`
+	ClusterIDs    []string  `json:"cluster_ids"`
+	Namespaces    []string  `json:"namespaces"`      // exact names or globs, e.g. "team-a-*"
+	MinSeverity   string    `json:"min_severity"`    // info, warning, critical or "" for all
+	EventTypes    []string  `json:"event_types"`     // Normal, Warning
+	EventReasons  []string  `json:"event_reasons"`   // e.g. "BackOff", "FailedMount"
+	DigestGroupBy string    `json:"digest_group_by"` // category, cluster, namespace, severity or "" for the default
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 // PreferencesStore provides CRUD operations for notification_preferences.
@@ -32,7 +42,7 @@ func NewPreferencesStore(pool *pgxpool.Pool) *PreferencesStore {
 // GetByUser returns all notification preferences for a user.
 func (s *PreferencesStore) GetByUser(ctx context.Context, userID string) ([]Preference, error) {
 	rows, err := s.pool.Query(ctx,
-		`SELECT id, user_id, category, channel_id, frequency, enabled, created_at, updated_at
+		`SELECT id, user_id, category, channel_id, frequency, enabled, cluster_ids, namespaces, min_severity, event_types, event_reasons, digest_group_by, created_at, updated_at
 		 FROM notification_preferences WHERE user_id = $1 ORDER BY category, channel_id`,
 		userID,
 	)
@@ -44,7 +54,7 @@ func (s *PreferencesStore) GetByUser(ctx context.Context, userID string) ([]Pref
 	var prefs []Preference
 	for rows.Next() {
 		var p Preference
-		if err := rows.Scan(&p.ID, &p.UserID, &p.Category, &p.ChannelID, &p.Frequency, &p.Enabled, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if err := rows.Scan(&p.ID, &p.UserID, &p.Category, &p.ChannelID, &p.Frequency, &p.Enabled, &p.ClusterIDs, &p.Namespaces, &p.MinSeverity, &p.EventTypes, &p.EventReasons, &p.DigestGroupBy, &p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, err
 		}
 		prefs = append(prefs, p)
@@ -61,7 +71,7 @@ func (s *PreferencesStore) GetByUser(ctx context.Context, userID string) ([]Pref
 // Useful for determining who should receive notifications for a specific event.
 func (s *PreferencesStore) GetByCategory(ctx context.Context, category string) ([]Preference, error) {
 	rows, err := s.pool.Query(ctx,
-		`SELECT id, user_id, category, channel_id, frequency, enabled, created_at, updated_at
+		`SELECT id, user_id, category, channel_id, frequency, enabled, cluster_ids, namespaces, min_severity, event_types, event_reasons, digest_group_by, created_at, updated_at
 		 FROM notification_preferences WHERE category = $1 AND enabled = true`,
 		category,
 	)
@@ -73,7 +83,7 @@ func (s *PreferencesStore) GetByCategory(ctx context.Context, category string) (
 	var prefs []Preference
 	for rows.Next() {
 		var p Preference
-		if err := rows.Scan(&p.ID, &p.UserID, &p.Category, &p.ChannelID, &p.Frequency, &p.Enabled, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if err := rows.Scan(&p.ID, &p.UserID, &p.Category, &p.ChannelID, &p.Frequency, &p.Enabled, &p.ClusterIDs, &p.Namespaces, &p.MinSeverity, &p.EventTypes, &p.EventReasons, &p.DigestGroupBy, &p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, err
 		}
 		prefs = append(prefs, p)
@@ -88,12 +98,29 @@ func (s *PreferencesStore) GetByCategory(ctx context.Context, category string) (
 
 // Set creates or updates a preference using upsert on the unique constraint.
 func (s *PreferencesStore) Set(ctx context.Context, pref *Preference) error {
+	clusterIDs, namespaces := pref.ClusterIDs, pref.Namespaces
+	if clusterIDs == nil {
+		clusterIDs = []string{}
+	}
+	if namespaces == nil {
+		namespaces = []string{}
+	}
+	eventTypes, eventReasons := pref.EventTypes, pref.EventReasons
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	if eventReasons == nil {
+		eventReasons = []string{}
+	}
 	_, err := s.pool.Exec(ctx,
-		`INSERT INTO notification_preferences (user_id, category, channel_id, frequency, enabled)
-		 VALUES ($1, $2, $3, $4, $5)
+		`INSERT INTO notification_preferences (user_id, category, channel_id, frequency, enabled, cluster_ids, namespaces, min_severity, event_types, event_reasons, digest_group_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		 ON CONFLICT (user_id, category, channel_id) DO UPDATE
-		 SET frequency = EXCLUDED.frequency, enabled = EXCLUDED.enabled, updated_at = NOW()`,
-		pref.UserID, pref.Category, pref.ChannelID, pref.Frequency, pref.Enabled,
+		 SET frequency = EXCLUDED.frequency, enabled = EXCLUDED.enabled,
+		     cluster_ids = EXCLUDED.cluster_ids, namespaces = EXCLUDED.namespaces,
+		     min_severity = EXCLUDED.min_severity, event_types = EXCLUDED.event_types,
+		     event_reasons = EXCLUDED.event_reasons, digest_group_by = EXCLUDED.digest_group_by, updated_at = NOW()`,
+		pref.UserID, pref.Category, pref.ChannelID, pref.Frequency, pref.Enabled, clusterIDs, namespaces, pref.MinSeverity, eventTypes, eventReasons, pref.DigestGroupBy,
 	)
 	return err
 }
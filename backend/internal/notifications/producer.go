@@ -3,20 +3,60 @@ package notifications
 import (
 	"encoding/json"
 	"log"
+	"path"
 	"strings"
 
 	"github.com/darkden-lab/argus/backend/internal/ws"
 )
 
+// Kubernetes Event types.
+const (
+	KubeEventNormal  = "Normal"
+	KubeEventWarning = "Warning"
+)
+
+// EventFilter selects which Kubernetes Events become notifications. Types
+// lists the allowed event types; Reasons, when set, further limits them to
+// those reasons, such as "BackOff" or "FailedMount". Both compare
+// case-insensitively.
+type EventFilter struct {
+	Types   []string
+	Reasons []string
+}
+
+// DefaultEventFilter surfaces Warning events of any reason and suppresses
+// routine Normal events such as Scheduled, Pulled or Started.
+var DefaultEventFilter = EventFilter{Types: []string{KubeEventWarning}}
+
+// Allows reports whether an event of the given type and reason passes the
+// filter. Empty Types means DefaultEventFilter's types.
+func (f EventFilter) Allows(eventType, reason string) bool {
+	types := f.Types
+	if len(types) == 0 {
+		types = DefaultEventFilter.Types
+	}
+	if !containsFold(types, eventType) {
+		return false
+	}
+	return len(f.Reasons) == 0 || containsFold(f.Reasons, reason)
+}
+
 // EventProducer translates system events (K8s watch events, audit actions,
 // health checks) into notification events and publishes them to the broker.
 type EventProducer struct {
 	broker MessageBroker
+	filter EventFilter
 }
 
 // NewEventProducer creates a new EventProducer that publishes to the given broker.
 func NewEventProducer(broker MessageBroker) *EventProducer {
-	return &EventProducer{broker: broker}
+	return &EventProducer{broker: broker, filter: DefaultEventFilter}
+}
+
+// SetEventFilter replaces DefaultEventFilter as the filter deciding which
+// Kubernetes Events are published.
+func (p *EventProducer) SetEventFilter(f EventFilter) {
+	p.filter = f
 }
 
 // HookIntoHub registers a WatchEvent hook on the WebSocket hub so that K8s
@@ -59,6 +99,11 @@ func (p *EventProducer) PublishClusterHealthEvent(clusterID, clusterName, status
 }
 
 func (p *EventProducer) handleWatchEvent(we ws.WatchEvent) {
+	if isKubeEventResource(we.Resource) {
+		p.handleKubeEvent(we)
+		return
+	}
+
 	topic, category, severity := classifyWatchEvent(we)
 	if topic == "" {
 		return // not a notifiable event
@@ -83,6 +128,97 @@ func (p *EventProducer) handleWatchEvent(we ws.WatchEvent) {
 	}
 }
 
+// kubeEvent holds the fields of a core/v1 or events.k8s.io/v1 Event that
+// notifications use. The two versions name the message and the object the
+// event is about differently.
+type kubeEvent struct {
+	Type           string       `json:"type"`
+	Reason         string       `json:"reason"`
+	Message        string       `json:"message"`
+	Note           string       `json:"note"`
+	InvolvedObject kubeEventRef `json:"involvedObject"`
+	Regarding      kubeEventRef `json:"regarding"`
+}
+
+type kubeEventRef struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// handleKubeEvent publishes a Kubernetes Event that passes the producer's
+// filter. Events are published when recorded and again when they recur;
+// their deletion when they expire is not notified.
+func (p *EventProducer) handleKubeEvent(we ws.WatchEvent) {
+	if strings.ToUpper(we.Type) == "DELETED" {
+		return
+	}
+	var ev kubeEvent
+	if err := json.Unmarshal(we.Object, &ev); err != nil {
+		return
+	}
+	if !p.filter.Allows(ev.Type, ev.Reason) {
+		return
+	}
+
+	obj, message := ev.InvolvedObject, ev.Message
+	if obj.Kind == "" {
+		obj = ev.Regarding
+	}
+	if message == "" {
+		message = ev.Note
+	}
+	namespace := obj.Namespace
+	if namespace == "" {
+		namespace = we.Namespace
+	}
+
+	topic, category, severity := TopicWorkloadEvent, CategoryWorkload, SeverityInfo
+	if obj.Kind == "Node" {
+		topic, category = TopicNodeEvent, CategoryNode
+	}
+	if strings.EqualFold(ev.Type, KubeEventWarning) {
+		severity = SeverityWarning
+	}
+
+	meta, _ := json.Marshal(map[string]string{
+		"cluster":    we.Cluster,
+		"resource":   we.Resource,
+		"namespace":  namespace,
+		"type":       we.Type,
+		"event_type": ev.Type,
+		"reason":     ev.Reason,
+		"kind":       obj.Kind,
+		"name":       obj.Name,
+	})
+
+	title := ev.Reason + " " + obj.Kind + " " + obj.Name
+	body := message
+	if body == "" {
+		body = ev.Type + " event " + ev.Reason + " in " + we.Cluster
+	}
+
+	event := NewEvent(topic, category, severity, title, body, meta)
+	if err := p.broker.Publish(topic, event); err != nil {
+		log.Printf("notifications: failed to publish kubernetes event: %v", err)
+	}
+}
+
+// isKubeEventResource reports whether a watch event's resource, a bare name
+// or a "group/version/resource" path, is Kubernetes Events.
+func isKubeEventResource(resource string) bool {
+	return strings.ToLower(path.Base(resource)) == "events"
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
 func classifyWatchEvent(we ws.WatchEvent) (string, Category, Severity) {
 	resource := strings.ToLower(we.Resource)
 	eventType := strings.ToUpper(we.Type)
@@ -189,6 +189,69 @@ func TestEventProducer_HookIntoHub(t *testing.T) {
 	}
 }
 
+func kubeEventWatch(eventType, reason, kind string) ws.WatchEvent {
+	obj, _ := json.Marshal(map[string]interface{}{
+		"type":           eventType,
+		"reason":         reason,
+		"message":        "Back-off restarting failed container",
+		"involvedObject": map[string]string{"kind": kind, "name": "web-1", "namespace": "shop"},
+	})
+	return ws.WatchEvent{Cluster: "prod-1", Resource: "v1/events", Namespace: "shop", Type: "ADDED", Object: obj}
+}
+
+func TestEventProducer_HandleWatchEvent_KubeEventDefaults(t *testing.T) {
+	broker := &collectingBroker{}
+	producer := NewEventProducer(broker)
+
+	producer.handleWatchEvent(kubeEventWatch("Normal", "Pulled", "Pod"))
+	producer.handleWatchEvent(kubeEventWatch("Warning", "BackOff", "Pod"))
+	producer.handleWatchEvent(kubeEventWatch("Warning", "NodeNotReady", "Node"))
+	deleted := kubeEventWatch("Warning", "BackOff", "Pod")
+	deleted.Type = "DELETED"
+	producer.handleWatchEvent(deleted)
+
+	events := broker.getEvents()
+	if len(events) != 2 {
+		t.Fatalf("expected the 2 Warning events only, got %d", len(events))
+	}
+
+	e := events[0]
+	if e.Topic != TopicWorkloadEvent || e.Category != CategoryWorkload || e.Severity != SeverityWarning {
+		t.Errorf("unexpected pod event %+v", e)
+	}
+	if e.Title != "BackOff Pod web-1" || e.Body != "Back-off restarting failed container" {
+		t.Errorf("unexpected title %q or body %q", e.Title, e.Body)
+	}
+	var meta map[string]string
+	if err := json.Unmarshal(e.Metadata, &meta); err != nil {
+		t.Fatal(err)
+	}
+	if meta["reason"] != "BackOff" || meta["event_type"] != "Warning" || meta["namespace"] != "shop" {
+		t.Errorf("unexpected metadata %v", meta)
+	}
+	if events[1].Topic != TopicNodeEvent || events[1].Category != CategoryNode {
+		t.Errorf("expected a node event, got %+v", events[1])
+	}
+}
+
+func TestEventProducer_SetEventFilter(t *testing.T) {
+	broker := &collectingBroker{}
+	producer := NewEventProducer(broker)
+	producer.SetEventFilter(EventFilter{Types: []string{"Warning", "Normal"}, Reasons: []string{"backoff", "Killing"}})
+
+	producer.handleWatchEvent(kubeEventWatch("Warning", "BackOff", "Pod"))
+	producer.handleWatchEvent(kubeEventWatch("Warning", "FailedMount", "Pod"))
+	producer.handleWatchEvent(kubeEventWatch("Normal", "Killing", "Pod"))
+
+	events := broker.getEvents()
+	if len(events) != 2 {
+		t.Fatalf("expected BackOff and Killing only, got %d", len(events))
+	}
+	if events[1].Severity != SeverityInfo {
+		t.Errorf("expected a Normal event to be info, got %s", events[1].Severity)
+	}
+}
+
 func TestClassifyWatchEvent(t *testing.T) {
 	tests := []struct {
 		name     string
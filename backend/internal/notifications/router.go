@@ -3,7 +3,9 @@ package notifications
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"time"
 
 	"github.com/darkden-lab/argus/backend/internal/notifications/channels"
 )
@@ -16,6 +18,9 @@ type Router struct {
 	chanStore        *ChannelStore
 	channels         map[string]channels.Channel // channel ID -> Channel instance
 	templateProvider channels.TemplateProvider
+	muteStore        *MuteStore
+	deliveries       *DeliveryStore
+	digest           *DigestAggregator
 }
 
 // NewRouter creates a Router. Call LoadChannels() to initialize channel instances.
@@ -34,6 +39,25 @@ func (r *Router) SetTemplateProvider(tp channels.TemplateProvider) {
 	r.templateProvider = tp
 }
 
+// SetMuteStore sets the store of user snoozes and maintenance windows that
+// Route consults before sending to external channels.
+func (r *Router) SetMuteStore(ms *MuteStore) {
+	r.muteStore = ms
+}
+
+// SetDeliveryStore makes Route queue channel deliveries so that failed sends
+// are retried by a DeliveryWorker. Without it each send is tried once.
+func (r *Router) SetDeliveryStore(ds *DeliveryStore) {
+	r.deliveries = ds
+}
+
+// SetDigestAggregator makes Route buffer events for preferences with a
+// digest frequency. Without it those preferences only get in-app
+// notifications.
+func (r *Router) SetDigestAggregator(d *DigestAggregator) {
+	r.digest = d
+}
+
 // RegisterChannel registers a Channel instance by ID for event delivery.
 // If the channel is an EmailChannel and a template provider is configured,
 // the provider is automatically injected.
@@ -49,6 +73,19 @@ func (r *Router) GetChannels() map[string]channels.Channel {
 	return r.channels
 }
 
+// CheckChannel checks that the registered channel id reaches its service,
+// without delivering a message.
+func (r *Router) CheckChannel(ctx context.Context, id string) error {
+	ch, ok := r.channels[id]
+	if !ok {
+		return errors.New("channel is not loaded")
+	}
+	if pinger, ok := ch.(channels.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
 // Route processes a notification event: stores it for all matching users and
 // dispatches it to the configured channels based on their preferences.
 func (r *Router) Route(ctx context.Context, event Event) {
@@ -64,70 +101,177 @@ func (r *Router) Route(ctx context.Context, event Event) {
 		return
 	}
 
-	// Group preferences by user
-	userPrefs := make(map[string][]Preference)
-	for _, p := range prefs {
-		userPrefs[p.UserID] = append(userPrefs[p.UserID], p)
-	}
+	userPrefs := groupMatchingPreferences(prefs, event)
+	muted, snoozed := r.mutes(ctx, event)
 
 	for userID, prefs := range userPrefs {
-		var sentChannels []string
+		var channelIDs []string
+		digested := make(map[string]bool)
 
 		for _, pref := range prefs {
+			// Muted and snoozed notifications are only kept in-app.
+			if muted || snoozed[userID] {
+				break
+			}
+
 			if !pref.Enabled || pref.Frequency == "none" {
 				continue
 			}
 
 			// For digest frequencies, skip realtime delivery (digest aggregator handles these)
-			if pref.Frequency == "daily" || pref.Frequency == "weekly" {
+			if isDigestFrequency(pref.Frequency) {
+				if r.digest != nil {
+					if frequency := r.digest.resolveFrequency(pref.Frequency); !digested[frequency] {
+						digested[frequency] = true
+						r.digest.AddEvent(userID, frequency, event)
+					}
+				}
 				continue
 			}
 
 			if pref.ChannelID != nil {
-				ch, ok := r.channels[*pref.ChannelID]
-				if !ok {
-					continue
-				}
-
-				msg := channels.Message{
-					ID:        event.ID,
-					Topic:     event.Topic,
-					Category:  string(event.Category),
-					Severity:  string(event.Severity),
-					Title:     event.Title,
-					Body:      event.Body,
-					Metadata:  event.Metadata,
-					Timestamp: event.Timestamp,
+				if _, ok := r.channels[*pref.ChannelID]; ok {
+					channelIDs = append(channelIDs, *pref.ChannelID)
 				}
-
-				if err := ch.Send(msg, []string{userID}); err != nil {
-					log.Printf("notifications: failed to send to channel %s for user %s: %v",
-						*pref.ChannelID, userID, err)
-					continue
-				}
-				sentChannels = append(sentChannels, ch.Type())
 			}
 		}
 
 		// Store notification for the user's in-app history
 		n := &Notification{
-			UserID:       userID,
-			Category:     string(event.Category),
-			Severity:     string(event.Severity),
-			Title:        event.Title,
-			Body:         event.Body,
-			Metadata:     event.Metadata,
-			ChannelsSent: sentChannels,
+			UserID:   userID,
+			Category: string(event.Category),
+			Severity: string(event.Severity),
+			Title:    event.Title,
+			Body:     event.Body,
+			Metadata: event.Metadata,
 		}
 		if n.Metadata == nil {
 			n.Metadata = json.RawMessage("{}")
 		}
-		if n.ChannelsSent == nil {
-			n.ChannelsSent = []string{}
+
+		if r.deliveries == nil {
+			n.ChannelsSent = r.sendOnce(event, userID, channelIDs)
+			if err := r.notifStore.Insert(ctx, n); err != nil {
+				log.Printf("notifications: failed to store notification for user %s: %v", userID, err)
+			}
+			continue
 		}
 
+		// Deliveries reference the notification, so it is stored first and
+		// its channels_sent filled in as deliveries succeed.
 		if err := r.notifStore.Insert(ctx, n); err != nil {
 			log.Printf("notifications: failed to store notification for user %s: %v", userID, err)
+			continue
+		}
+		for _, channelID := range channelIDs {
+			msg := eventMessage(event)
+			msg.IdempotencyKey = deliveryKey(event.ID, channelID, userID)
+			d, created, err := r.deliveries.Enqueue(ctx, n.ID, channelID, userID, msg)
+			if err != nil {
+				log.Printf("notifications: %v", err)
+				continue
+			}
+			if !created {
+				log.Printf("notifications: event %s was already delivered to channel %s for user %s", event.ID, channelID, userID)
+				continue
+			}
+			r.attemptDelivery(ctx, r.deliveries, d)
+		}
+	}
+}
+
+// eventMessage maps an event to the message sent to channels.
+func eventMessage(event Event) channels.Message {
+	return channels.Message{
+		ID:        event.ID,
+		Topic:     event.Topic,
+		Category:  string(event.Category),
+		Severity:  string(event.Severity),
+		Title:     event.Title,
+		Body:      event.Body,
+		Metadata:  event.Metadata,
+		Timestamp: event.Timestamp,
+	}
+}
+
+// sendOnce sends the event to each channel without retries and returns the
+// types of the channels that accepted it.
+func (r *Router) sendOnce(event Event, userID string, channelIDs []string) []string {
+	sent := []string{}
+	for _, channelID := range channelIDs {
+		ch := r.channels[channelID]
+		if err := ch.Send(eventMessage(event), []string{userID}); err != nil {
+			log.Printf("notifications: failed to send to channel %s for user %s: %v",
+				channelID, userID, err)
+			continue
 		}
+		sent = append(sent, ch.Type())
+	}
+	return sent
+}
+
+// attemptDelivery sends a claimed delivery and records the outcome: a failed
+// send is scheduled for a retry with exponential backoff, or dead-lettered
+// after maxDeliveryAttempts.
+func (r *Router) attemptDelivery(ctx context.Context, store *DeliveryStore, d *pendingDelivery) {
+	var sendErr error
+	ch, ok := r.channels[d.ChannelID]
+	if !ok {
+		sendErr = errors.New("channel is not loaded")
+	} else if sendErr = ch.Send(d.Message, []string{d.UserID}); sendErr == nil {
+		if err := store.MarkDelivered(ctx, d.ID, ch.Type()); err != nil {
+			log.Printf("notifications: failed to record delivery %s: %v", d.ID, err)
+		}
+		return
+	}
+
+	attempts := d.Attempts + 1
+	var retryAt *time.Time
+	if attempts < maxDeliveryAttempts {
+		t := time.Now().Add(deliveryBackoff(attempts))
+		retryAt = &t
+		log.Printf("notifications: delivery %s to channel %s failed (attempt %d), retrying at %s: %v",
+			d.ID, d.ChannelID, attempts, t.Format(time.RFC3339), sendErr)
+	} else {
+		log.Printf("notifications: delivery %s to channel %s failed %d times, giving up: %v",
+			d.ID, d.ChannelID, attempts, sendErr)
+	}
+	if err := store.MarkFailed(ctx, d.ID, sendErr.Error(), retryAt); err != nil {
+		log.Printf("notifications: failed to record delivery %s: %v", d.ID, err)
+	}
+}
+
+// mutes reports whether a maintenance window mutes the event and which users
+// have snoozed their notifications. Lookup failures are logged and treated as
+// not muted so that alerts are not lost.
+func (r *Router) mutes(ctx context.Context, event Event) (bool, map[string]bool) {
+	if r.muteStore == nil || r.muteStore.pool == nil {
+		return false, nil
+	}
+
+	muted := false
+	if windows, err := r.muteStore.ListWindows(ctx); err != nil {
+		log.Printf("notifications: failed to load maintenance windows: %v", err)
+	} else {
+		muted = mutedByMaintenance(windows, event, time.Now())
+	}
+
+	snoozed, err := r.muteStore.SnoozedUsers(ctx)
+	if err != nil {
+		log.Printf("notifications: failed to load snoozed users: %v", err)
+	}
+	return muted, snoozed
+}
+
+// groupMatchingPreferences groups the preferences whose scope filters match
+// the event by user. Users with no matching preference are not notified.
+func groupMatchingPreferences(prefs []Preference, event Event) map[string][]Preference {
+	userPrefs := make(map[string][]Preference)
+	for _, p := range prefs {
+		if !p.Matches(event) {
+			continue
+		}
+		userPrefs[p.UserID] = append(userPrefs[p.UserID], p)
 	}
+	return userPrefs
 }
@@ -1,6 +1,8 @@
 package notifications
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/darkden-lab/argus/backend/internal/notifications/channels"
@@ -69,6 +71,35 @@ func TestRouter_GetChannels_Empty(t *testing.T) {
 	}
 }
 
+// pingChannel is a mockChannel that implements channels.Pinger.
+type pingChannel struct {
+	mockChannel
+	pingErr error
+}
+
+func (p *pingChannel) Ping(context.Context) error { return p.pingErr }
+
+func TestRouter_CheckChannel(t *testing.T) {
+	router := NewRouter(nil, nil, nil)
+	router.RegisterChannel("plain", &mockChannel{channelType: "email"})
+	router.RegisterChannel("down", &pingChannel{pingErr: errors.New("connection refused")})
+
+	if err := router.CheckChannel(context.Background(), "plain"); err != nil {
+		t.Errorf("expected a channel without Ping to pass, got %v", err)
+	}
+	if err := router.CheckChannel(context.Background(), "down"); err == nil || err.Error() != "connection refused" {
+		t.Errorf("expected the ping error, got %v", err)
+	}
+	if err := router.CheckChannel(context.Background(), "missing"); err == nil {
+		t.Error("expected an error for a channel that is not loaded")
+	}
+	for _, m := range router.GetChannels() {
+		if mock, ok := m.(*mockChannel); ok && len(mock.sentMessages) > 0 {
+			t.Error("expected checks not to send messages")
+		}
+	}
+}
+
 func TestRouter_Route_NilPrefStore(t *testing.T) {
 	// Router with nil prefStore should not panic
 	router := NewRouter(nil, nil, nil)
@@ -0,0 +1,410 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// schedulingCheckInterval is how often the SchedulingWatcher checks clusters.
+const schedulingCheckInterval = time.Minute
+
+const (
+	pendingPodsPath         = "/api/v1/pods?fieldSelector=status.phase%3DPending"
+	disruptionBudgetsPath   = "/apis/policy/v1/poddisruptionbudgets"
+	podTemplateHashLabel    = "pod-template-hash"
+	podScheduledCondition   = "PodScheduled"
+	unschedulableReason     = "Unschedulable"
+	disruptionBudgetKind    = "PodDisruptionBudget"
+	reasonUnhealthyPods     = "unhealthy pods"
+	reasonNoDisruptionsLeft = "budget allows no disruptions"
+)
+
+// K8sRequester sends raw requests to a cluster's API server. Implemented by
+// *cluster.Manager.
+type K8sRequester interface {
+	DoK8sRequest(ctx context.Context, clusterID, method, path string, body []byte) ([]byte, error)
+}
+
+// WatchedCluster is a cluster the SchedulingWatcher checks.
+type WatchedCluster struct {
+	ID   string
+	Name string
+}
+
+// SchedulingWatcher notifies about pods the scheduler cannot place and about
+// PodDisruptionBudgets that allow no disruptions, which block node drains.
+// Unlike watch events, which only flow while someone has the resource open,
+// it checks every cluster periodically. Each problem is notified once per
+// workload or budget, and again only if its reasons change or it recurs
+// after being resolved.
+type SchedulingWatcher struct {
+	broker   MessageBroker
+	k8s      K8sRequester
+	clusters func(ctx context.Context) ([]WatchedCluster, error)
+
+	mu     sync.Mutex
+	firing map[string]string // alert key -> reasons last notified
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewSchedulingWatcher creates a watcher checking the clusters returned by
+// clusters through k8s and publishing to broker.
+func NewSchedulingWatcher(broker MessageBroker, k8s K8sRequester, clusters func(ctx context.Context) ([]WatchedCluster, error)) *SchedulingWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SchedulingWatcher{
+		broker:   broker,
+		k8s:      k8s,
+		clusters: clusters,
+		firing:   make(map[string]string),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start begins checking clusters in the background.
+func (w *SchedulingWatcher) Start() {
+	go w.loop()
+	log.Println("notifications: scheduling watcher started")
+}
+
+// Stop ends the checks.
+func (w *SchedulingWatcher) Stop() {
+	w.cancel()
+}
+
+func (w *SchedulingWatcher) loop() {
+	ticker := time.NewTicker(schedulingCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			w.checkAll(w.ctx)
+		}
+	}
+}
+
+// checkAll checks every cluster once.
+func (w *SchedulingWatcher) checkAll(ctx context.Context) {
+	clusters, err := w.clusters(ctx)
+	if err != nil {
+		log.Printf("notifications: scheduling watcher failed to list clusters: %v", err)
+		return
+	}
+	for _, c := range clusters {
+		w.checkCluster(ctx, c)
+	}
+}
+
+// schedulingAlert is a problem found in a cluster, keyed by what it is about.
+type schedulingAlert struct {
+	key      string
+	topic    string
+	title    string
+	body     string
+	reasons  []string
+	metadata map[string]interface{}
+}
+
+// checkCluster notifies about the cluster's new or changed problems and
+// forgets those that are gone. A cluster that can't be read keeps its
+// problems as they were.
+func (w *SchedulingWatcher) checkCluster(ctx context.Context, c WatchedCluster) {
+	pods, err := w.k8s.DoK8sRequest(ctx, c.ID, "GET", pendingPodsPath, nil)
+	if err != nil {
+		log.Printf("notifications: failed to list pending pods of cluster %s: %v", c.ID, err)
+		return
+	}
+	unschedulable, err := unschedulableAlerts(c, pods)
+	if err != nil {
+		log.Printf("notifications: cluster %s: %v", c.ID, err)
+		return
+	}
+	budgets, err := w.k8s.DoK8sRequest(ctx, c.ID, "GET", disruptionBudgetsPath, nil)
+	if err != nil {
+		log.Printf("notifications: failed to list disruption budgets of cluster %s: %v", c.ID, err)
+		return
+	}
+	blocked, err := disruptionBudgetAlerts(c, budgets)
+	if err != nil {
+		log.Printf("notifications: cluster %s: %v", c.ID, err)
+		return
+	}
+
+	alerts := append(unschedulable, blocked...)
+	current := make(map[string]bool, len(alerts))
+	var toPublish []schedulingAlert
+
+	w.mu.Lock()
+	for _, a := range alerts {
+		current[a.key] = true
+		fingerprint := strings.Join(a.reasons, ",")
+		if last, ok := w.firing[a.key]; ok && last == fingerprint {
+			continue
+		}
+		w.firing[a.key] = fingerprint
+		toPublish = append(toPublish, a)
+	}
+	prefix := c.ID + "/"
+	for key := range w.firing {
+		if strings.HasPrefix(key, prefix) && !current[key] {
+			delete(w.firing, key)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, a := range toPublish {
+		meta, _ := json.Marshal(a.metadata)
+		event := NewEvent(a.topic, CategoryWorkload, SeverityWarning, a.title, a.body, meta)
+		if err := w.broker.Publish(a.topic, event); err != nil {
+			log.Printf("notifications: failed to publish scheduling event: %v", err)
+		}
+	}
+}
+
+// pendingPod is the part of a pending pod the watcher reads.
+type pendingPod struct {
+	Metadata struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		Labels          map[string]string `json:"labels"`
+		OwnerReferences []struct {
+			Kind       string `json:"kind"`
+			Name       string `json:"name"`
+			Controller *bool  `json:"controller"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+	Status struct {
+		Conditions []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// unschedulableAlerts returns an alert per workload with pods the scheduler
+// marked unschedulable. The reasons come from the PodScheduled condition,
+// which carries the message of the pod's FailedScheduling events.
+func unschedulableAlerts(c WatchedCluster, body []byte) ([]schedulingAlert, error) {
+	var list struct {
+		Items []pendingPod `json:"items"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("invalid pod list: %w", err)
+	}
+
+	type workload struct {
+		namespace, kind, name string
+		pods                  []string
+		reasons               map[string]bool
+		message               string
+	}
+	byKey := map[string]*workload{}
+	var order []string
+	for _, pod := range list.Items {
+		message, ok := unschedulableMessage(pod)
+		if !ok {
+			continue
+		}
+		kind, name := podWorkload(pod)
+		key := c.ID + "/" + pod.Metadata.Namespace + "/" + kind + "/" + name
+		wl, ok := byKey[key]
+		if !ok {
+			wl = &workload{namespace: pod.Metadata.Namespace, kind: kind, name: name, reasons: map[string]bool{}, message: message}
+			byKey[key] = wl
+			order = append(order, key)
+		}
+		wl.pods = append(wl.pods, pod.Metadata.Name)
+		for _, r := range unschedulableReasons(message) {
+			wl.reasons[r] = true
+		}
+	}
+
+	alerts := make([]schedulingAlert, 0, len(order))
+	for _, key := range order {
+		wl := byKey[key]
+		reasons := make([]string, 0, len(wl.reasons))
+		for r := range wl.reasons {
+			reasons = append(reasons, r)
+		}
+		sort.Strings(reasons)
+		sort.Strings(wl.pods)
+
+		subject := fmt.Sprintf("%d pods of %s %s/%s cannot", len(wl.pods), wl.kind, wl.namespace, wl.name)
+		if len(wl.pods) == 1 {
+			subject = fmt.Sprintf("Pod %s/%s cannot", wl.namespace, wl.pods[0])
+			if wl.kind != "Pod" {
+				subject = fmt.Sprintf("Pod %s/%s of %s %s cannot", wl.namespace, wl.pods[0], wl.kind, wl.name)
+			}
+		}
+		alerts = append(alerts, schedulingAlert{
+			key:     key,
+			topic:   TopicWorkloadUnschedulable,
+			title:   "Pods cannot be scheduled",
+			body:    subject + " be scheduled in " + c.Name + ": " + strings.Join(reasons, ", "),
+			reasons: reasons,
+			metadata: map[string]interface{}{
+				"cluster_id":    c.ID,
+				"cluster_name":  c.Name,
+				"namespace":     wl.namespace,
+				"workload_kind": wl.kind,
+				"workload":      wl.name,
+				"pods":          wl.pods,
+				"reasons":       reasons,
+				"message":       wl.message,
+			},
+		})
+	}
+	return alerts, nil
+}
+
+// unschedulableMessage returns the scheduler's message if the pod is
+// unschedulable. Pods still waiting for their first attempt are not.
+func unschedulableMessage(pod pendingPod) (string, bool) {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == podScheduledCondition && cond.Status == "False" && cond.Reason == unschedulableReason {
+			return cond.Message, true
+		}
+	}
+	return "", false
+}
+
+// podWorkload returns the workload a pod belongs to: its controller, with
+// ReplicaSets of a Deployment resolved to the Deployment through the
+// pod-template-hash suffix of their name. Pods without a controller are
+// their own workload.
+func podWorkload(pod pendingPod) (kind, name string) {
+	for _, ref := range pod.Metadata.OwnerReferences {
+		if ref.Controller == nil || !*ref.Controller {
+			continue
+		}
+		if hash := pod.Metadata.Labels[podTemplateHashLabel]; ref.Kind == "ReplicaSet" && hash != "" && strings.HasSuffix(ref.Name, "-"+hash) {
+			return "Deployment", strings.TrimSuffix(ref.Name, "-"+hash)
+		}
+		return ref.Kind, ref.Name
+	}
+	return "Pod", pod.Metadata.Name
+}
+
+var insufficientResource = regexp.MustCompile(`Insufficient ([A-Za-z0-9./_-]+)`)
+
+// schedulingReasons maps fragments of scheduler messages to the reasons
+// reported for them.
+var schedulingReasons = []struct {
+	fragment, reason string
+}{
+	{"didn't match Pod's node affinity/selector", "no matching node"},
+	{"untolerated taint", "untolerated taint"},
+	{"didn't match pod affinity rules", "pod affinity"},
+	{"didn't match pod anti-affinity rules", "pod anti-affinity"},
+	{"didn't satisfy existing pods anti-affinity rules", "pod anti-affinity"},
+	{"didn't match pod topology spread constraints", "topology spread constraints"},
+	{"didn't have free ports", "no free ports"},
+	{"unbound immediate PersistentVolumeClaims", "unbound volume claims"},
+	{"volume node affinity conflict", "volume node affinity conflict"},
+	{"were unschedulable", "nodes unschedulable"},
+	{"too many pods", "too many pods"},
+}
+
+// unschedulableReasons extracts why the scheduler could not place a pod from
+// its message, e.g. "0/3 nodes are available: 1 node(s) had untolerated
+// taint {node-role.kubernetes.io/control-plane: }, 2 Insufficient cpu."
+// Messages it does not recognise give "unschedulable".
+func unschedulableReasons(message string) []string {
+	seen := map[string]bool{}
+	for _, m := range insufficientResource.FindAllStringSubmatch(message, -1) {
+		seen["insufficient "+strings.TrimSuffix(m[1], ".")] = true
+	}
+	for _, r := range schedulingReasons {
+		if strings.Contains(message, r.fragment) {
+			seen[r.reason] = true
+		}
+	}
+	if len(seen) == 0 {
+		return []string{"unschedulable"}
+	}
+	reasons := make([]string, 0, len(seen))
+	for r := range seen {
+		reasons = append(reasons, r)
+	}
+	sort.Strings(reasons)
+	return reasons
+}
+
+// disruptionBudget is the part of a PodDisruptionBudget the watcher reads.
+type disruptionBudget struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		MinAvailable   interface{} `json:"minAvailable"`
+		MaxUnavailable interface{} `json:"maxUnavailable"`
+	} `json:"spec"`
+	Status struct {
+		DisruptionsAllowed int `json:"disruptionsAllowed"`
+		CurrentHealthy     int `json:"currentHealthy"`
+		DesiredHealthy     int `json:"desiredHealthy"`
+		ExpectedPods       int `json:"expectedPods"`
+	} `json:"status"`
+}
+
+// disruptionBudgetAlerts returns an alert per budget that covers pods but
+// allows no disruptions, so evictions and node drains of its pods fail.
+func disruptionBudgetAlerts(c WatchedCluster, body []byte) ([]schedulingAlert, error) {
+	var list struct {
+		Items []disruptionBudget `json:"items"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("invalid disruption budget list: %w", err)
+	}
+
+	var alerts []schedulingAlert
+	for _, pdb := range list.Items {
+		st := pdb.Status
+		if st.ExpectedPods == 0 || st.DisruptionsAllowed > 0 {
+			continue
+		}
+		reason := reasonNoDisruptionsLeft
+		detail := fmt.Sprintf("its %d pods are all required", st.ExpectedPods)
+		if st.CurrentHealthy < st.DesiredHealthy {
+			reason = reasonUnhealthyPods
+			detail = fmt.Sprintf("%d of %d pods are healthy, %d required", st.CurrentHealthy, st.ExpectedPods, st.DesiredHealthy)
+		}
+		ns, name := pdb.Metadata.Namespace, pdb.Metadata.Name
+		alerts = append(alerts, schedulingAlert{
+			key:     c.ID + "/" + ns + "/" + disruptionBudgetKind + "/" + name,
+			topic:   TopicWorkloadDisruptionBlocked,
+			title:   "Disruption budget blocks evictions",
+			body:    fmt.Sprintf("PodDisruptionBudget %s/%s in %s allows no disruptions: %s", ns, name, c.Name, detail),
+			reasons: []string{reason},
+			metadata: map[string]interface{}{
+				"cluster_id":      c.ID,
+				"cluster_name":    c.Name,
+				"namespace":       ns,
+				"workload_kind":   disruptionBudgetKind,
+				"workload":        name,
+				"reasons":         []string{reason},
+				"min_available":   pdb.Spec.MinAvailable,
+				"max_unavailable": pdb.Spec.MaxUnavailable,
+				"current_healthy": st.CurrentHealthy,
+				"desired_healthy": st.DesiredHealthy,
+				"expected_pods":   st.ExpectedPods,
+			},
+		})
+	}
+	return alerts, nil
+}
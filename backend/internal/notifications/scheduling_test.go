@@ -0,0 +1,249 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeK8s answers requests by path for one cluster.
+type fakeK8s struct {
+	responses map[string]string
+	err       error
+}
+
+func (f *fakeK8s) DoK8sRequest(_ context.Context, _, _, path string, _ []byte) ([]byte, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []byte(f.responses[path]), nil
+}
+
+const unschedulablePods = `{"items": [
+  {
+    "metadata": {
+      "name": "web-7d9f8c6b5-abcde", "namespace": "shop",
+      "labels": {"pod-template-hash": "7d9f8c6b5"},
+      "ownerReferences": [{"kind": "ReplicaSet", "name": "web-7d9f8c6b5", "controller": true}]
+    },
+    "status": {"conditions": [{"type": "PodScheduled", "status": "False", "reason": "Unschedulable",
+      "message": "0/3 nodes are available: 1 node(s) had untolerated taint {node-role.kubernetes.io/control-plane: }, 2 Insufficient cpu. preemption: 0/3 nodes are available: 3 Preemption is not helpful for scheduling."}]}
+  },
+  {
+    "metadata": {
+      "name": "web-7d9f8c6b5-fghij", "namespace": "shop",
+      "labels": {"pod-template-hash": "7d9f8c6b5"},
+      "ownerReferences": [{"kind": "ReplicaSet", "name": "web-7d9f8c6b5", "controller": true}]
+    },
+    "status": {"conditions": [{"type": "PodScheduled", "status": "False", "reason": "Unschedulable",
+      "message": "0/3 nodes are available: 3 Insufficient memory."}]}
+  },
+  {
+    "metadata": {"name": "db-0", "namespace": "shop", "ownerReferences": [{"kind": "StatefulSet", "name": "db", "controller": true}]},
+    "status": {"conditions": [{"type": "PodScheduled", "status": "False", "reason": "Unschedulable",
+      "message": "0/3 nodes are available: 3 node(s) didn't match Pod's node affinity/selector."}]}
+  },
+  {
+    "metadata": {"name": "starting", "namespace": "shop"},
+    "status": {"conditions": [{"type": "PodScheduled", "status": "True"}]}
+  }
+]}`
+
+const disruptionBudgets = `{"items": [
+  {
+    "metadata": {"name": "web", "namespace": "shop"},
+    "spec": {"minAvailable": 2},
+    "status": {"disruptionsAllowed": 0, "currentHealthy": 1, "desiredHealthy": 2, "expectedPods": 2}
+  },
+  {
+    "metadata": {"name": "api", "namespace": "shop"},
+    "spec": {"maxUnavailable": "10%"},
+    "status": {"disruptionsAllowed": 1, "currentHealthy": 5, "desiredHealthy": 4, "expectedPods": 5}
+  },
+  {
+    "metadata": {"name": "orphan", "namespace": "shop"},
+    "spec": {"minAvailable": 1},
+    "status": {"disruptionsAllowed": 0, "currentHealthy": 0, "desiredHealthy": 1, "expectedPods": 0}
+  }
+]}`
+
+var testCluster = WatchedCluster{ID: "c1", Name: "prod"}
+
+func newTestSchedulingWatcher(k8s *fakeK8s) (*SchedulingWatcher, *collectingBroker) {
+	broker := &collectingBroker{}
+	w := NewSchedulingWatcher(broker, k8s, func(context.Context) ([]WatchedCluster, error) {
+		return []WatchedCluster{testCluster}, nil
+	})
+	return w, broker
+}
+
+func TestUnschedulableReasons(t *testing.T) {
+	tests := []struct {
+		message string
+		want    []string
+	}{
+		{
+			"0/3 nodes are available: 1 node(s) had untolerated taint {node-role.kubernetes.io/control-plane: }, 2 Insufficient cpu.",
+			[]string{"insufficient cpu", "untolerated taint"},
+		},
+		{
+			"0/2 nodes are available: 1 Insufficient memory, 1 Insufficient nvidia.com/gpu.",
+			[]string{"insufficient memory", "insufficient nvidia.com/gpu"},
+		},
+		{
+			"0/3 nodes are available: 3 node(s) didn't match Pod's node affinity/selector.",
+			[]string{"no matching node"},
+		},
+		{
+			"0/1 nodes are available: pod has unbound immediate PersistentVolumeClaims.",
+			[]string{"unbound volume claims"},
+		},
+		{"something new", []string{"unschedulable"}},
+	}
+	for _, tt := range tests {
+		if got := unschedulableReasons(tt.message); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("unschedulableReasons(%q) = %v, want %v", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestUnschedulableAlerts_GroupByWorkload(t *testing.T) {
+	alerts, err := unschedulableAlerts(testCluster, []byte(unschedulablePods))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("expected an alert for the deployment and one for the statefulset, got %d", len(alerts))
+	}
+
+	web := alerts[0]
+	if web.key != "c1/shop/Deployment/web" {
+		t.Errorf("unexpected key %q", web.key)
+	}
+	want := []string{"insufficient cpu", "insufficient memory", "untolerated taint"}
+	if !reflect.DeepEqual(web.reasons, want) {
+		t.Errorf("expected reasons %v, got %v", want, web.reasons)
+	}
+	if web.body != "2 pods of Deployment shop/web cannot be scheduled in prod: insufficient cpu, insufficient memory, untolerated taint" {
+		t.Errorf("unexpected body %q", web.body)
+	}
+
+	if db := alerts[1]; db.key != "c1/shop/StatefulSet/db" || db.body != "Pod shop/db-0 of StatefulSet db cannot be scheduled in prod: no matching node" {
+		t.Errorf("unexpected alert %+v", db)
+	}
+}
+
+func TestDisruptionBudgetAlerts(t *testing.T) {
+	alerts, err := disruptionBudgetAlerts(testCluster, []byte(disruptionBudgets))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("expected only the blocking budget with pods, got %d", len(alerts))
+	}
+	a := alerts[0]
+	if a.key != "c1/shop/PodDisruptionBudget/web" || a.reasons[0] != reasonUnhealthyPods {
+		t.Errorf("unexpected alert %+v", a)
+	}
+	if !strings.Contains(a.body, "1 of 2 pods are healthy, 2 required") {
+		t.Errorf("unexpected body %q", a.body)
+	}
+}
+
+func TestSchedulingWatcher_Dedup(t *testing.T) {
+	k8s := &fakeK8s{responses: map[string]string{
+		pendingPodsPath:       unschedulablePods,
+		disruptionBudgetsPath: disruptionBudgets,
+	}}
+	w, broker := newTestSchedulingWatcher(k8s)
+
+	w.checkAll(context.Background())
+	events := broker.getEvents()
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	topics := map[string]int{}
+	for _, e := range events {
+		topics[e.Topic]++
+		if e.Category != CategoryWorkload || e.Severity != SeverityWarning {
+			t.Errorf("unexpected category or severity %s/%s", e.Category, e.Severity)
+		}
+		var meta struct {
+			ClusterID string   `json:"cluster_id"`
+			Namespace string   `json:"namespace"`
+			Reasons   []string `json:"reasons"`
+		}
+		if err := json.Unmarshal(e.Metadata, &meta); err != nil || meta.ClusterID != "c1" || meta.Namespace != "shop" || len(meta.Reasons) == 0 {
+			t.Errorf("unexpected metadata %s", e.Metadata)
+		}
+	}
+	if topics[TopicWorkloadUnschedulable] != 2 || topics[TopicWorkloadDisruptionBlocked] != 1 {
+		t.Errorf("unexpected topics %v", topics)
+	}
+
+	// Unchanged problems are not notified again.
+	w.checkAll(context.Background())
+	if n := len(broker.getEvents()); n != 3 {
+		t.Errorf("expected no new events, got %d", n-3)
+	}
+
+	// A failed check keeps what is firing.
+	k8s.err = errors.New("cluster unavailable")
+	w.checkAll(context.Background())
+	k8s.err = nil
+	w.checkAll(context.Background())
+	if n := len(broker.getEvents()); n != 3 {
+		t.Errorf("expected no new events after a failed check, got %d", n-3)
+	}
+
+	// Resolved problems are forgotten and notified again if they recur.
+	k8s.responses[pendingPodsPath] = `{"items": []}`
+	w.checkAll(context.Background())
+	k8s.responses[pendingPodsPath] = unschedulablePods
+	w.checkAll(context.Background())
+	if n := len(broker.getEvents()); n != 5 {
+		t.Errorf("expected the recurring workloads to be notified again, got %d new events", n-3)
+	}
+}
+
+func TestSchedulingWatcher_ReasonChange(t *testing.T) {
+	k8s := &fakeK8s{responses: map[string]string{
+		pendingPodsPath:       unschedulablePods,
+		disruptionBudgetsPath: `{"items": []}`,
+	}}
+	w, broker := newTestSchedulingWatcher(k8s)
+	w.checkAll(context.Background())
+
+	k8s.responses[pendingPodsPath] = strings.Replace(unschedulablePods,
+		"didn't match Pod's node affinity/selector", "had untolerated taint {dedicated: db}", 1)
+	w.checkAll(context.Background())
+
+	events := broker.getEvents()
+	if len(events) != 3 {
+		t.Fatalf("expected the changed workload to be notified again, got %d events", len(events))
+	}
+	if !strings.HasSuffix(events[2].Body, "untolerated taint") {
+		t.Errorf("unexpected body %q", events[2].Body)
+	}
+}
+
+func TestPodWorkload(t *testing.T) {
+	var pod pendingPod
+	pod.Metadata.Name = "job-x"
+	if kind, name := podWorkload(pod); kind != "Pod" || name != "job-x" {
+		t.Errorf("expected a bare pod to be its own workload, got %s/%s", kind, name)
+	}
+
+	controller := true
+	pod.Metadata.OwnerReferences = append(pod.Metadata.OwnerReferences, struct {
+		Kind       string `json:"kind"`
+		Name       string `json:"name"`
+		Controller *bool  `json:"controller"`
+	}{Kind: "ReplicaSet", Name: "standalone", Controller: &controller})
+	if kind, name := podWorkload(pod); kind != "ReplicaSet" || name != "standalone" {
+		t.Errorf("expected a ReplicaSet without a template hash to be kept, got %s/%s", kind, name)
+	}
+}
@@ -0,0 +1,142 @@
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// severityRank orders severities for Preference.MinSeverity comparisons.
+var severityRank = map[Severity]int{
+	SeverityInfo:     1,
+	SeverityWarning:  2,
+	SeverityCritical: 3,
+}
+
+// ValidateScope checks a preference's scope filters: MinSeverity must be
+// empty or a known severity, namespace patterns must be valid globs and
+// event types must be Normal or Warning.
+func (p *Preference) ValidateScope() error {
+	if p.MinSeverity != "" {
+		if _, ok := severityRank[Severity(p.MinSeverity)]; !ok {
+			return fmt.Errorf("invalid min_severity %q", p.MinSeverity)
+		}
+	}
+	for _, ns := range p.Namespaces {
+		if _, err := path.Match(ns, ""); err != nil {
+			return fmt.Errorf("invalid namespace pattern %q", ns)
+		}
+	}
+	for _, t := range p.EventTypes {
+		if !strings.EqualFold(t, KubeEventNormal) && !strings.EqualFold(t, KubeEventWarning) {
+			return fmt.Errorf("invalid event type %q", t)
+		}
+	}
+	for _, reason := range p.EventReasons {
+		if strings.TrimSpace(reason) == "" {
+			return fmt.Errorf("event reasons must not be empty")
+		}
+	}
+	return nil
+}
+
+// Matches reports whether an event falls within the preference's category
+// and optional scope filters. Empty filters match everything, so preferences
+// without a scope behave as before. When a cluster or namespace filter is
+// set, events that carry no cluster or namespace do not match it. The event
+// type and reason filters only apply to notifications of Kubernetes Events.
+func (p *Preference) Matches(event Event) bool {
+	if p.Category != string(event.Category) {
+		return false
+	}
+	if p.MinSeverity != "" && severityRank[event.Severity] < severityRank[Severity(p.MinSeverity)] {
+		return false
+	}
+	if len(p.EventTypes) > 0 || len(p.EventReasons) > 0 {
+		if eventType, reason, ok := kubeEventOf(event); ok {
+			filter := EventFilter{Types: p.EventTypes, Reasons: p.EventReasons}
+			if len(filter.Types) == 0 {
+				filter.Types = []string{KubeEventNormal, KubeEventWarning}
+			}
+			if !filter.Allows(eventType, reason) {
+				return false
+			}
+		}
+	}
+	if len(p.ClusterIDs) == 0 && len(p.Namespaces) == 0 {
+		return true
+	}
+
+	clusterID, namespace := eventScope(event)
+	if len(p.ClusterIDs) > 0 && !containsString(p.ClusterIDs, clusterID) {
+		return false
+	}
+	if len(p.Namespaces) > 0 && !matchesNamespace(p.Namespaces, namespace) {
+		return false
+	}
+	return true
+}
+
+// eventScope extracts the cluster and namespace an event refers to from its
+// metadata. Producers use "cluster_id" or "cluster" for the cluster.
+func eventScope(event Event) (clusterID, namespace string) {
+	if len(event.Metadata) == 0 {
+		return "", ""
+	}
+	var meta struct {
+		ClusterID string `json:"cluster_id"`
+		Cluster   string `json:"cluster"`
+		Namespace string `json:"namespace"`
+	}
+	if err := json.Unmarshal(event.Metadata, &meta); err != nil {
+		return "", ""
+	}
+	clusterID = meta.ClusterID
+	if clusterID == "" {
+		clusterID = meta.Cluster
+	}
+	return clusterID, meta.Namespace
+}
+
+// kubeEventOf returns the Kubernetes Event type and reason of a notification
+// produced from one; ok is false for other notifications.
+func kubeEventOf(event Event) (eventType, reason string, ok bool) {
+	if len(event.Metadata) == 0 {
+		return "", "", false
+	}
+	var meta struct {
+		EventType string `json:"event_type"`
+		Reason    string `json:"reason"`
+	}
+	if err := json.Unmarshal(event.Metadata, &meta); err != nil || meta.EventType == "" {
+		return "", "", false
+	}
+	return meta.EventType, meta.Reason, true
+}
+
+// matchesNamespace reports whether namespace matches any of the patterns,
+// which may be exact names or globs such as "team-a-*".
+func matchesNamespace(patterns []string, namespace string) bool {
+	if namespace == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package notifications
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func scopedEvent(severity Severity, meta map[string]string) Event {
+	raw, _ := json.Marshal(meta)
+	return NewEvent(TopicWorkloadCrash, CategoryWorkload, severity, "Warning pod", "crash", raw)
+}
+
+func TestPreferenceMatches_NoFilterMatchesCategory(t *testing.T) {
+	p := Preference{Category: "workload"}
+	if !p.Matches(scopedEvent(SeverityInfo, nil)) {
+		t.Error("expected unscoped preference to match any workload event")
+	}
+	if p.Matches(NewEvent(TopicNodeReady, CategoryNode, SeverityInfo, "t", "b", nil)) {
+		t.Error("expected preference not to match another category")
+	}
+}
+
+func TestPreferenceMatches_NamespaceAndSeverity(t *testing.T) {
+	p := Preference{
+		Category:    "workload",
+		Namespaces:  []string{"team-a", "team-a-*"},
+		MinSeverity: "warning",
+	}
+
+	tests := []struct {
+		name     string
+		severity Severity
+		ns       string
+		want     bool
+	}{
+		{"warning in team-a", SeverityWarning, "team-a", true},
+		{"critical in glob match", SeverityCritical, "team-a-staging", true},
+		{"info is below threshold", SeverityInfo, "team-a", false},
+		{"other namespace", SeverityWarning, "team-b", false},
+		{"no namespace", SeverityWarning, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := scopedEvent(tt.severity, map[string]string{"cluster": "c1", "namespace": tt.ns})
+			if got := p.Matches(e); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreferenceMatches_ClusterFilter(t *testing.T) {
+	p := Preference{Category: "cluster", ClusterIDs: []string{"c1"}}
+
+	raw, _ := json.Marshal(map[string]string{"cluster_id": "c1"})
+	if !p.Matches(NewEvent(TopicClusterHealth, CategoryCluster, SeverityCritical, "t", "b", raw)) {
+		t.Error("expected cluster_id metadata to match")
+	}
+	raw, _ = json.Marshal(map[string]string{"cluster_id": "c2"})
+	if p.Matches(NewEvent(TopicClusterHealth, CategoryCluster, SeverityCritical, "t", "b", raw)) {
+		t.Error("expected other cluster not to match")
+	}
+	if p.Matches(NewEvent(TopicClusterHealth, CategoryCluster, SeverityCritical, "t", "b", nil)) {
+		t.Error("expected event without cluster not to match a cluster filter")
+	}
+}
+
+func TestPreferenceMatches_EventFilters(t *testing.T) {
+	p := Preference{Category: "workload", EventReasons: []string{"BackOff", "FailedMount"}}
+
+	tests := []struct {
+		name string
+		meta map[string]string
+		want bool
+	}{
+		{"listed reason", map[string]string{"event_type": "Warning", "reason": "BackOff"}, true},
+		{"other reason", map[string]string{"event_type": "Warning", "reason": "Unhealthy"}, false},
+		{"not a kubernetes event", map[string]string{"namespace": "team-a"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Matches(scopedEvent(SeverityWarning, tt.meta)); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	p = Preference{Category: "workload", EventTypes: []string{"warning"}}
+	if p.Matches(scopedEvent(SeverityInfo, map[string]string{"event_type": "Normal", "reason": "Pulled"})) {
+		t.Error("expected a Normal event not to match a Warning filter")
+	}
+}
+
+func TestPreferenceValidateScope(t *testing.T) {
+	if err := (&Preference{MinSeverity: "urgent"}).ValidateScope(); err == nil {
+		t.Error("expected unknown severity to be rejected")
+	}
+	if err := (&Preference{Namespaces: []string{"team-["}}).ValidateScope(); err == nil {
+		t.Error("expected malformed namespace pattern to be rejected")
+	}
+	if err := (&Preference{EventTypes: []string{"Error"}}).ValidateScope(); err == nil {
+		t.Error("expected unknown event type to be rejected")
+	}
+	if err := (&Preference{EventReasons: []string{" "}}).ValidateScope(); err == nil {
+		t.Error("expected empty event reason to be rejected")
+	}
+	if err := (&Preference{MinSeverity: "critical", Namespaces: []string{"team-a-*"}, EventTypes: []string{"Warning"}}).ValidateScope(); err != nil {
+		t.Errorf("expected valid scope, got %v", err)
+	}
+}
+
+func TestGroupMatchingPreferences(t *testing.T) {
+	prefs := []Preference{
+		{UserID: "alice", Category: "workload", Namespaces: []string{"team-a"}},
+		{UserID: "bob", Category: "workload", Namespaces: []string{"team-b"}},
+		{UserID: "carol", Category: "workload"},
+	}
+	e := scopedEvent(SeverityWarning, map[string]string{"cluster": "c1", "namespace": "team-a"})
+
+	got := groupMatchingPreferences(prefs, e)
+	if len(got) != 2 || got["alice"] == nil || got["carol"] == nil {
+		t.Errorf("expected alice and carol to be notified, got %v", got)
+	}
+	if _, ok := got["bob"]; ok {
+		t.Error("expected bob's team-b scope to exclude the event")
+	}
+}
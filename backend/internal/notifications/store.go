@@ -2,8 +2,11 @@ package notifications
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -24,14 +27,62 @@ type Notification struct {
 }
 
 // NotificationListParams holds filters and pagination for listing notifications.
+// Pages are either offset-based or, preferably for long histories, continue
+// after a Cursor returned with a previous page.
 type NotificationListParams struct {
 	UserID   string
 	Category string
-	ReadOnly *bool // nil = all, true = read only, false = unread only
+	ReadOnly *bool      // nil = all, true = read only, false = unread only
+	Since    *time.Time // created at or after
+	Until    *time.Time // created before
+	Cursor   *NotificationCursor
 	Limit    int
 	Offset   int
 }
 
+// NotificationPage is one page of a notification listing.
+type NotificationPage struct {
+	Notifications []Notification
+	// Total counts every notification matching the filters, across pages.
+	Total int
+	// NextCursor continues the listing after this page; it is empty on the
+	// last page.
+	NextCursor string
+}
+
+// NotificationCursor is a position in a user's notifications, which are
+// listed newest first by (created_at, id).
+type NotificationCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// ErrInvalidCursor is returned for a cursor that was not issued by Encode.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Encode returns the cursor as an opaque string.
+func (c NotificationCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseNotificationCursor decodes a cursor returned with a page.
+func ParseNotificationCursor(s string) (*NotificationCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &NotificationCursor{CreatedAt: createdAt, ID: id}, nil
+}
+
 // NotificationStore provides CRUD operations for the notifications table.
 type NotificationStore struct {
 	pool *pgxpool.Pool
@@ -42,7 +93,7 @@ func NewNotificationStore(pool *pgxpool.Pool) *NotificationStore {
 	return &NotificationStore{pool: pool}
 }
 
-// Insert stores a new notification.
+// Insert stores a new notification and sets its ID and CreatedAt.
 func (s *NotificationStore) Insert(ctx context.Context, n *Notification) error {
 	if n.Metadata == nil {
 		n.Metadata = json.RawMessage("{}")
@@ -50,16 +101,17 @@ func (s *NotificationStore) Insert(ctx context.Context, n *Notification) error {
 	if n.ChannelsSent == nil {
 		n.ChannelsSent = []string{}
 	}
-	_, err := s.pool.Exec(ctx,
+	return s.pool.QueryRow(ctx,
 		`INSERT INTO notifications (user_id, category, severity, title, body, metadata, channels_sent)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, created_at`,
 		n.UserID, n.Category, n.Severity, n.Title, n.Body, n.Metadata, n.ChannelsSent,
-	)
-	return err
+	).Scan(&n.ID, &n.CreatedAt)
 }
 
-// List returns notifications matching the given filters with pagination.
-func (s *NotificationStore) List(ctx context.Context, params NotificationListParams) ([]Notification, int, error) {
+// List returns a page of notifications matching the given filters, newest
+// first. With a cursor the page starts after it and Offset is ignored.
+func (s *NotificationStore) List(ctx context.Context, params NotificationListParams) (*NotificationPage, error) {
 	if params.Limit <= 0 || params.Limit > 100 {
 		params.Limit = 50
 	}
@@ -82,21 +134,42 @@ func (s *NotificationStore) List(ctx context.Context, params NotificationListPar
 		args = append(args, *params.ReadOnly)
 		argIdx++
 	}
+	if params.Since != nil {
+		query += ` AND created_at >= $` + strconv.Itoa(argIdx)
+		countQuery += ` AND created_at >= $` + strconv.Itoa(argIdx)
+		args = append(args, *params.Since)
+		argIdx++
+	}
+	if params.Until != nil {
+		query += ` AND created_at < $` + strconv.Itoa(argIdx)
+		countQuery += ` AND created_at < $` + strconv.Itoa(argIdx)
+		args = append(args, *params.Until)
+		argIdx++
+	}
 
 	var total int
 	if err := s.pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
-	query += ` ORDER BY created_at DESC LIMIT $` + strconv.Itoa(argIdx)
-	args = append(args, params.Limit)
+	if params.Cursor != nil {
+		query += ` AND (created_at, id) < ($` + strconv.Itoa(argIdx) + `, $` + strconv.Itoa(argIdx+1) + `)`
+		args = append(args, params.Cursor.CreatedAt, params.Cursor.ID)
+		argIdx += 2
+	}
+
+	// One extra row tells whether another page follows.
+	query += ` ORDER BY created_at DESC, id DESC LIMIT $` + strconv.Itoa(argIdx)
+	args = append(args, params.Limit+1)
 	argIdx++
-	query += ` OFFSET $` + strconv.Itoa(argIdx)
-	args = append(args, params.Offset)
+	if params.Cursor == nil {
+		query += ` OFFSET $` + strconv.Itoa(argIdx)
+		args = append(args, params.Offset)
+	}
 
 	rows, err := s.pool.Query(ctx, query, args...)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -104,16 +177,24 @@ func (s *NotificationStore) List(ctx context.Context, params NotificationListPar
 	for rows.Next() {
 		var n Notification
 		if err := rows.Scan(&n.ID, &n.UserID, &n.Category, &n.Severity, &n.Title, &n.Body, &n.Metadata, &n.Read, &n.ChannelsSent, &n.CreatedAt); err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 		notifications = append(notifications, n)
 	}
-
-	if notifications == nil {
-		notifications = []Notification{}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return notifications, total, rows.Err()
+	page := &NotificationPage{Notifications: notifications, Total: total}
+	if len(notifications) > params.Limit {
+		page.Notifications = notifications[:params.Limit]
+		last := page.Notifications[params.Limit-1]
+		page.NextCursor = NotificationCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	if page.Notifications == nil {
+		page.Notifications = []Notification{}
+	}
+	return page, nil
 }
 
 // MarkRead marks a single notification as read for the given user.
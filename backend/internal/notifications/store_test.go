@@ -1,6 +1,7 @@
 package notifications
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"testing"
 	"time"
@@ -143,6 +144,37 @@ func TestNotificationListParams_Defaults(t *testing.T) {
 	}
 }
 
+func TestNotificationCursor_RoundTrip(t *testing.T) {
+	want := NotificationCursor{
+		CreatedAt: time.Date(2026, 3, 4, 5, 6, 7, 891011000, time.UTC),
+		ID:        "7f1c9a52-3b1e-4d8e-9a36-0d4f5e6a7b8c",
+	}
+
+	got, err := ParseNotificationCursor(want.Encode())
+	if err != nil {
+		t.Fatalf("ParseNotificationCursor failed: %v", err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("expected created_at %v, got %v", want.CreatedAt, got.CreatedAt)
+	}
+	if got.ID != want.ID {
+		t.Errorf("expected id %s, got %s", want.ID, got.ID)
+	}
+}
+
+func TestParseNotificationCursor_Invalid(t *testing.T) {
+	for _, c := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("no-separator")),
+		base64.RawURLEncoding.EncodeToString([]byte("yesterday|id-1")),
+		base64.RawURLEncoding.EncodeToString([]byte("2026-03-04T05:06:07Z|")),
+	} {
+		if _, err := ParseNotificationCursor(c); err != ErrInvalidCursor {
+			t.Errorf("cursor %q: expected ErrInvalidCursor, got %v", c, err)
+		}
+	}
+}
+
 func TestNewNotificationStore_Constructor(t *testing.T) {
 	store := NewNotificationStore(nil)
 	if store == nil {
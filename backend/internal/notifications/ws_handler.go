@@ -10,6 +10,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 	"github.com/darkden-lab/argus/backend/internal/ws"
 )
 
@@ -65,15 +66,18 @@ func (h *WSHandler) ServeWS(w http.ResponseWriter, r *http.Request) {
 			token = parts[1]
 		}
 	}
+	if token == "" {
+		token = auth.AccessTokenFromCookie(r)
+	}
 
 	if token == "" {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	claims, err := h.jwtService.ValidateToken(token)
 	if err != nil {
-		http.Error(w, "invalid token", http.StatusUnauthorized)
+		httputil.WriteError(w, http.StatusUnauthorized, "invalid token")
 		return
 	}
 
@@ -0,0 +1,139 @@
+// Package outbound builds the HTTP clients used for connections the
+// dashboard makes to services outside the clusters it manages: Prometheus
+// and Alertmanager, notification webhooks, OIDC providers, and AI providers.
+// Configure applies a custom CA bundle, a client certificate, and a proxy to
+// all of them.
+package outbound
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/oauth2"
+)
+
+// Config is the TLS and proxy configuration of outbound connections.
+type Config struct {
+	// CAFile is a PEM bundle of certificates trusted in addition to the
+	// system roots, e.g. an internal CA.
+	CAFile string
+	// CertFile and KeyFile are a PEM client certificate and key presented
+	// to servers that request one.
+	CertFile string
+	KeyFile  string
+	// ProxyURL is the proxy for HTTP and HTTPS requests, except to hosts in
+	// NoProxy (a comma-separated list in the NO_PROXY format). When empty,
+	// the standard HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables apply.
+	ProxyURL string
+	NoProxy  string
+}
+
+var (
+	mu        sync.RWMutex
+	transport = newDefaultTransport()
+)
+
+// Configure replaces the transport shared by outbound clients. Clients
+// created before the call keep the previous transport, so it should be
+// called at startup.
+func Configure(cfg Config) error {
+	t, err := NewTransport(cfg)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	transport = t
+	mu.Unlock()
+	return nil
+}
+
+// Transport returns the shared outbound transport.
+func Transport() *http.Transport {
+	mu.RLock()
+	defer mu.RUnlock()
+	return transport
+}
+
+// Client returns a client using the shared outbound transport. A zero
+// timeout means no timeout.
+func Client(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: Transport(), Timeout: timeout}
+}
+
+// Context returns ctx carrying an outbound client for the oauth2 and
+// go-oidc packages, which take their HTTP client from the context.
+func Context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, Client(0))
+}
+
+// NewTransport builds a transport with the defaults of
+// http.DefaultTransport and the TLS and proxy settings of cfg.
+func NewTransport(cfg Config) (*http.Transport, error) {
+	t := newDefaultTransport()
+
+	if cfg.CAFile != "" || cfg.CertFile != "" || cfg.KeyFile != "" {
+		tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+		if cfg.CAFile != "" {
+			pool, err := loadCertPool(cfg.CAFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsCfg.RootCAs = pool
+		}
+		if cfg.CertFile != "" || cfg.KeyFile != "" {
+			if cfg.CertFile == "" || cfg.KeyFile == "" {
+				return nil, fmt.Errorf("outbound: a client certificate needs both a certificate and a key file")
+			}
+			cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("outbound: failed to load client certificate: %w", err)
+			}
+			tlsCfg.Certificates = []tls.Certificate{cert}
+		}
+		t.TLSClientConfig = tlsCfg
+	}
+
+	if cfg.ProxyURL != "" {
+		if u, err := url.Parse(cfg.ProxyURL); err != nil || u.Host == "" {
+			return nil, fmt.Errorf("outbound: invalid proxy URL %q", cfg.ProxyURL)
+		}
+		proxy := (&httpproxy.Config{
+			HTTPProxy:  cfg.ProxyURL,
+			HTTPSProxy: cfg.ProxyURL,
+			NoProxy:    cfg.NoProxy,
+		}).ProxyFunc()
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		}
+	}
+	return t, nil
+}
+
+func newDefaultTransport() *http.Transport {
+	return http.DefaultTransport.(*http.Transport).Clone()
+}
+
+// loadCertPool returns the system roots with the certificates of a PEM
+// bundle added.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("outbound: failed to read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("outbound: no certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
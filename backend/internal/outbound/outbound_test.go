@@ -0,0 +1,134 @@
+package outbound
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewTransport_CAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	if _, err := (&http.Client{Transport: newDefaultTransport()}).Get(server.URL); err == nil {
+		t.Fatal("expected the test server's certificate to be untrusted by default")
+	}
+
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	transport, err := NewTransport(Config{CAFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected the CA bundle to be trusted, got %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewTransport_ClientCertificate(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "argus"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "argus"}}, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	certFile := writePEM(t, "client.pem", "CERTIFICATE", der)
+	keyFile := writePEM(t, "client-key.pem", "EC PRIVATE KEY", keyDER)
+
+	var clientCN string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientCN = r.TLS.PeerCertificates[0].Subject.CommonName
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	transport, err := NewTransport(Config{CAFile: caFile, CertFile: certFile, KeyFile: keyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected the client certificate to be accepted, got %v", err)
+	}
+	resp.Body.Close()
+	if clientCN != "argus" {
+		t.Errorf("expected the client certificate to be presented, got %q", clientCN)
+	}
+
+	if _, err := NewTransport(Config{CertFile: certFile}); err == nil {
+		t.Error("expected a certificate without a key to be rejected")
+	}
+}
+
+func TestNewTransport_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	transport, err := NewTransport(Config{ProxyURL: proxy.URL, NoProxy: "internal.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get("http://hooks.example.com/abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if proxied != "http://hooks.example.com/abc" {
+		t.Errorf("expected the request to go through the proxy, got %q", proxied)
+	}
+
+	req := httptest.NewRequest("GET", "http://internal.example.com/", nil)
+	if u, err := transport.Proxy(req); err != nil || u != nil {
+		t.Errorf("expected NO_PROXY hosts to be reached directly, got %v %v", u, err)
+	}
+
+	if _, err := NewTransport(Config{ProxyURL: "://bad"}); err == nil {
+		t.Error("expected an invalid proxy URL to be rejected")
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	defer func() { transport = newDefaultTransport() }()
+
+	if err := Configure(Config{CAFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Fatal("expected a missing CA bundle to be rejected")
+	}
+	if err := Configure(Config{ProxyURL: "http://proxy.internal:3128"}); err != nil {
+		t.Fatal(err)
+	}
+	if Client(time.Second).Transport != Transport() {
+		t.Error("expected clients to share the configured transport")
+	}
+}
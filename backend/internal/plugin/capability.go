@@ -0,0 +1,153 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// Capability is an API a plugin needs a cluster to serve, typically a CRD of
+// the operator the plugin manages. Version and Resource narrow it down: with
+// no Resource any resource of the group (at Version, when set) will do.
+type Capability struct {
+	Group    string `json:"group"`
+	Version  string `json:"version,omitempty"`
+	Resource string `json:"resource,omitempty"`
+	// Provider names what installs the API, e.g. "KEDA", for error messages.
+	Provider string `json:"provider,omitempty"`
+}
+
+// String formats the capability the way kubectl names resources, e.g.
+// "scaledobjects.keda.sh/v1alpha1" or "crd.projectcalico.org/v1".
+func (c Capability) String() string {
+	s := c.Group
+	if c.Resource != "" {
+		s = c.Resource + "." + c.Group
+	}
+	if c.Version != "" {
+		s += "/" + c.Version
+	}
+	return s
+}
+
+// servedBy reports whether the discovery data includes the capability.
+func (c Capability) servedBy(d *cluster.Discovery) bool {
+	for _, list := range d.Resources {
+		if list == nil {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil || gv.Group != c.Group || (c.Version != "" && gv.Version != c.Version) {
+			continue
+		}
+		if c.Resource == "" && len(list.APIResources) > 0 {
+			return true
+		}
+		for _, r := range list.APIResources {
+			if r.Name == c.Resource {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DiscoverySource returns a cluster's cached discovery data. Implemented by
+// *cluster.Manager.
+type DiscoverySource interface {
+	Discovery(ctx context.Context, clusterID string) (*cluster.Discovery, error)
+}
+
+type capabilityKey struct {
+	pluginID  string
+	clusterID string
+}
+
+// capabilityCheck is the cached result of checking a plugin against one
+// cluster, valid while the cluster's discovery data is unchanged.
+type capabilityCheck struct {
+	fetchedAt time.Time
+	missing   []Capability
+}
+
+// MissingCapabilities returns the plugin's required capabilities that the
+// cluster does not serve. The result is cached per plugin and cluster until
+// the cluster's discovery data is refetched, e.g. after a CRD is installed
+// and discovery is refreshed.
+func (e *Engine) MissingCapabilities(ctx context.Context, pluginID, clusterID string) ([]Capability, error) {
+	e.mu.RLock()
+	p, ok := e.plugins[pluginID]
+	discovery := e.discovery
+	e.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("plugin %q not found", pluginID)
+	}
+	required := p.RequiredCapabilities()
+	if len(required) == 0 || discovery == nil {
+		return nil, nil
+	}
+
+	d, err := discovery.Discovery(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := capabilityKey{pluginID: pluginID, clusterID: clusterID}
+	e.capMu.Lock()
+	defer e.capMu.Unlock()
+	if check, ok := e.capChecks[key]; ok && check.fetchedAt.Equal(d.FetchedAt) {
+		return check.missing, nil
+	}
+	var missing []Capability
+	for _, c := range required {
+		if !c.servedBy(d) {
+			missing = append(missing, c)
+		}
+	}
+	e.capChecks[key] = capabilityCheck{fetchedAt: d.FetchedAt, missing: missing}
+	return missing, nil
+}
+
+// missingCapabilitiesMessage explains which capabilities a plugin lacks on a
+// cluster, e.g. `plugin "keda" requires scaledobjects.keda.sh/v1alpha1
+// (KEDA), which is not installed on this cluster`.
+func missingCapabilitiesMessage(pluginID string, missing []Capability) string {
+	names := make([]string, len(missing))
+	for i, c := range missing {
+		names[i] = c.String()
+		if c.Provider != "" {
+			names[i] += " (" + c.Provider + ")"
+		}
+	}
+	verb := "is"
+	if len(missing) > 1 {
+		verb = "are"
+	}
+	return fmt.Sprintf("plugin %q requires %s, which %s not installed on this cluster", pluginID, strings.Join(names, ", "), verb)
+}
+
+// writeMissingCapabilities answers a plugin request for a cluster that lacks
+// the plugin's capabilities with 424 Failed Dependency.
+func writeMissingCapabilities(w http.ResponseWriter, pluginID, clusterID string, missing []Capability) {
+	httputil.WriteErrorDetails(w, http.StatusFailedDependency, httputil.CodeCapabilityMissing,
+		missingCapabilitiesMessage(pluginID, missing),
+		map[string]interface{}{"plugin": pluginID, "cluster_id": clusterID, "missing": missing})
+}
+
+// requestClusterID returns the cluster a plugin request targets: the
+// {cluster} route variable most plugins use, or the ?clusterID= query
+// parameter of the others.
+func requestClusterID(r *http.Request) string {
+	if id := mux.Vars(r)["cluster"]; id != "" {
+		return id
+	}
+	return r.URL.Query().Get("clusterID")
+}
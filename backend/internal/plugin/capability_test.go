@@ -0,0 +1,171 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+)
+
+type fakeDiscovery struct {
+	discovery *cluster.Discovery
+	err       error
+	calls     int
+}
+
+func (f *fakeDiscovery) Discovery(_ context.Context, _ string) (*cluster.Discovery, error) {
+	f.calls++
+	return f.discovery, f.err
+}
+
+func discoveryServing(groupVersion string, resources ...string) *cluster.Discovery {
+	list := &metav1.APIResourceList{GroupVersion: groupVersion}
+	for _, r := range resources {
+		list.APIResources = append(list.APIResources, metav1.APIResource{Name: r})
+	}
+	return &cluster.Discovery{Resources: []*metav1.APIResourceList{list}, FetchedAt: time.Now()}
+}
+
+var kedaCapability = Capability{Group: "keda.sh", Version: "v1alpha1", Resource: "scaledobjects", Provider: "KEDA"}
+
+func TestCapabilityString(t *testing.T) {
+	if got := kedaCapability.String(); got != "scaledobjects.keda.sh/v1alpha1" {
+		t.Errorf("unexpected %q", got)
+	}
+	if got := (Capability{Group: "crd.projectcalico.org"}).String(); got != "crd.projectcalico.org" {
+		t.Errorf("unexpected %q", got)
+	}
+}
+
+func TestCapabilityServedBy(t *testing.T) {
+	d := discoveryServing("keda.sh/v1alpha1", "scaledobjects", "scaledjobs")
+	tests := []struct {
+		cap  Capability
+		want bool
+	}{
+		{kedaCapability, true},
+		{Capability{Group: "keda.sh"}, true},
+		{Capability{Group: "keda.sh", Version: "v1"}, false},
+		{Capability{Group: "keda.sh", Resource: "triggerauthentications"}, false},
+		{Capability{Group: "networking.istio.io"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.cap.servedBy(d); got != tt.want {
+			t.Errorf("%s: servedBy() = %v, want %v", tt.cap, got, tt.want)
+		}
+	}
+}
+
+func TestMissingCapabilities_CachedUntilDiscoveryChanges(t *testing.T) {
+	e := newTestEngine(&fakePlugin{id: "keda", manifest: Manifest{ID: "keda", Name: "KEDA", Version: "1.0.0"}, caps: []Capability{kedaCapability}})
+	src := &fakeDiscovery{discovery: discoveryServing("apps/v1", "deployments")}
+	e.discovery = src
+
+	missing, err := e.MissingCapabilities(context.Background(), "keda", "c1")
+	if err != nil || len(missing) != 1 {
+		t.Fatalf("expected KEDA to be missing, got %v %v", missing, err)
+	}
+	src.discovery.Resources = append(src.discovery.Resources, discoveryServing("keda.sh/v1alpha1", "scaledobjects").Resources...)
+	if missing, _ := e.MissingCapabilities(context.Background(), "keda", "c1"); len(missing) != 1 {
+		t.Errorf("expected the cached result while discovery is unchanged, got %v", missing)
+	}
+
+	src.discovery.FetchedAt = src.discovery.FetchedAt.Add(time.Minute)
+	if missing, _ := e.MissingCapabilities(context.Background(), "keda", "c1"); len(missing) != 0 {
+		t.Errorf("expected refreshed discovery to satisfy the plugin, got %v", missing)
+	}
+}
+
+func TestMissingCapabilities_NoRequirementsOrDiscovery(t *testing.T) {
+	e := newTestEngine(&fakePlugin{id: "helm", manifest: Manifest{ID: "helm", Name: "Helm", Version: "1.0.0"}})
+	src := &fakeDiscovery{err: errors.New("unreachable")}
+	e.discovery = src
+	if missing, err := e.MissingCapabilities(context.Background(), "helm", "c1"); err != nil || missing != nil {
+		t.Errorf("expected no check for a plugin without requirements, got %v %v", missing, err)
+	}
+	if src.calls != 0 {
+		t.Error("expected discovery not to be fetched")
+	}
+}
+
+func TestPluginGateMiddleware_MissingCapabilities(t *testing.T) {
+	e := newTestEngine(&fakePlugin{id: "keda", manifest: Manifest{ID: "keda", Name: "KEDA", Version: "1.0.0"}, caps: []Capability{kedaCapability}})
+	e.Enable(context.Background(), "keda") //nolint:errcheck
+	e.discovery = &fakeDiscovery{discovery: discoveryServing("apps/v1", "deployments")}
+
+	r := mux.NewRouter()
+	r.Use(e.PluginGateMiddleware())
+	ok := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }
+	r.HandleFunc("/api/plugins/keda/scaledobjects", ok)
+	r.HandleFunc("/api/plugins/keda/{cluster}/scaledjobs", ok)
+
+	for _, path := range []string{"/api/plugins/keda/scaledobjects?clusterID=c1", "/api/plugins/keda/c1/scaledjobs"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusFailedDependency {
+			t.Fatalf("%s: expected 424, got %d", path, rec.Code)
+		}
+		var body map[string]interface{}
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		if body["code"] != "capability_missing" || !strings.Contains(body["message"].(string), "scaledobjects.keda.sh/v1alpha1 (KEDA)") {
+			t.Errorf("%s: unexpected body %s", path, rec.Body.String())
+		}
+	}
+
+	// Without a cluster there is nothing to check.
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/api/plugins/keda/scaledobjects", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 without a cluster, got %d", rec.Code)
+	}
+
+	// Discovery failures let the request through.
+	e.discovery = &fakeDiscovery{err: errors.New("agent not connected")}
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/api/plugins/keda/c2/scaledjobs", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 when discovery fails, got %d", rec.Code)
+	}
+}
+
+func TestHandleCapabilities(t *testing.T) {
+	e := newTestEngine(&fakePlugin{id: "keda", manifest: Manifest{ID: "keda", Name: "KEDA", Version: "1.0.0"}, caps: []Capability{kedaCapability}})
+	e.discovery = &fakeDiscovery{discovery: discoveryServing("keda.sh/v1alpha1", "scaledobjects")}
+	r := mux.NewRouter()
+	NewHandlers(e, nil).RegisterRoutes(r)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/api/plugins/keda/capabilities?clusterID=c1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Required  []Capability `json:"required"`
+		Missing   []Capability `json:"missing"`
+		Satisfied bool         `json:"satisfied"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &body)
+	if !body.Satisfied || len(body.Required) != 1 || len(body.Missing) != 0 {
+		t.Errorf("unexpected body %s", rec.Body.String())
+	}
+
+	for path, want := range map[string]int{
+		"/api/plugins/keda/capabilities":                 http.StatusBadRequest,
+		"/api/plugins/unknown/capabilities?clusterID=c1": http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"strings"
+	"sort"
 	"sync"
 
 	"github.com/gorilla/mux"
@@ -22,24 +22,39 @@ type Engine struct {
 	hub     *ws.Hub
 	cm      *cluster.Manager
 	router  *mux.Router
+	// routes holds the routes each plugin registered, found by
+	// RegisterAllRoutes. Manifests returned by the engine list these
+	// instead of the routes they declare.
+	routes map[string][]RouteDefinition
+
+	// discovery checks plugins' required capabilities; checks are skipped
+	// while it is nil.
+	discovery DiscoverySource
+	capMu     sync.Mutex
+	capChecks map[capabilityKey]capabilityCheck
 }
 
 func NewEngine(pool *pgxpool.Pool) *Engine {
 	return &Engine{
-		plugins: make(map[string]Plugin),
-		enabled: make(map[string]bool),
-		pool:    pool,
+		plugins:   make(map[string]Plugin),
+		enabled:   make(map[string]bool),
+		pool:      pool,
+		capChecks: make(map[capabilityKey]capabilityCheck),
 	}
 }
 
 // SetDependencies stores references needed for hot-reload (registering watchers
-// and routes when a plugin is enabled at runtime).
+// and routes when a plugin is enabled at runtime). The cluster manager's
+// discovery data is also used to check plugins' required capabilities.
 func (e *Engine) SetDependencies(hub *ws.Hub, cm *cluster.Manager, router *mux.Router) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	e.hub = hub
 	e.cm = cm
 	e.router = router
+	if cm != nil {
+		e.discovery = cm
+	}
 }
 
 // IsEnabled returns whether the plugin with the given ID is currently enabled.
@@ -52,22 +67,24 @@ func (e *Engine) IsEnabled(id string) bool {
 // PluginGateMiddleware returns an HTTP middleware that blocks requests to
 // disabled plugins. It extracts the plugin ID from the URL path pattern
 // /api/plugins/{pluginID}/... and returns 404 if the plugin is not enabled.
+// Requests for a cluster that lacks the plugin's required capabilities get
+// 424 naming what is missing, rather than empty results. When the cluster's
+// discovery data cannot be fetched the request is let through, so the
+// handler reports the cluster's state itself.
 func (e *Engine) PluginGateMiddleware() mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Extract plugin ID from URL: /api/plugins/{pluginID}/...
-			path := r.URL.Path
-			const prefix = "/api/plugins/"
-			if strings.HasPrefix(path, prefix) {
-				rest := path[len(prefix):]
-				pluginID := rest
-				if idx := strings.Index(rest, "/"); idx >= 0 {
-					pluginID = rest[:idx]
-				}
-				if pluginID != "" && !e.IsEnabled(pluginID) {
+			if pluginID := pluginIDFromPath(r.URL.Path); pluginID != "" {
+				if !e.IsEnabled(pluginID) {
 					http.NotFound(w, r)
 					return
 				}
+				if clusterID := requestClusterID(r); clusterID != "" {
+					if missing, err := e.MissingCapabilities(r.Context(), pluginID, clusterID); err == nil && len(missing) > 0 {
+						writeMissingCapabilities(w, pluginID, clusterID, missing)
+						return
+					}
+				}
 			}
 			next.ServeHTTP(w, r)
 		})
@@ -159,10 +176,52 @@ func (e *Engine) GetManifest(pluginID string) (*Manifest, error) {
 		return nil, fmt.Errorf("plugin %q not found", pluginID)
 	}
 
-	m := p.Manifest()
+	m := e.manifest(pluginID, p)
 	return &m, nil
 }
 
+// manifest returns p's manifest with the routes it registered, once
+// RegisterAllRoutes has found them. The caller must hold e.mu.
+func (e *Engine) manifest(id string, p Plugin) Manifest {
+	m := p.Manifest()
+	if routes, ok := e.routes[id]; ok {
+		m.Backend.Routes = routes
+	}
+	return m
+}
+
+// Permissions returns the RBAC permissions the registered plugins' routes
+// require, sorted by plugin, so roles can be granted them. It is empty
+// until RegisterAllRoutes has run.
+func (e *Engine) Permissions() []PluginPermission {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	ids := make([]string, 0, len(e.routes))
+	for id := range e.routes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	perms := []PluginPermission{}
+	for _, id := range ids {
+		perms = append(perms, routePermissions(id, e.routes[id])...)
+	}
+	return perms
+}
+
+// RequiredCapabilities returns the capabilities a registered plugin requires.
+func (e *Engine) RequiredCapabilities(pluginID string) ([]Capability, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	p, ok := e.plugins[pluginID]
+	if !ok {
+		return nil, fmt.Errorf("plugin %q not found", pluginID)
+	}
+	return requiredCapabilities(p), nil
+}
+
 func (e *Engine) ListEnabled(_ context.Context) []Manifest {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
@@ -170,30 +229,53 @@ func (e *Engine) ListEnabled(_ context.Context) []Manifest {
 	var manifests []Manifest
 	for id, p := range e.plugins {
 		if e.enabled[id] {
-			manifests = append(manifests, p.Manifest())
+			manifests = append(manifests, e.manifest(id, p))
 		}
 	}
 	return manifests
 }
 
+// IDs returns the IDs of all registered plugins, sorted.
+func (e *Engine) IDs() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	ids := make([]string, 0, len(e.plugins))
+	for id := range e.plugins {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 func (e *Engine) ListAll() []PluginInfo {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
 	var infos []PluginInfo
 	for id, p := range e.plugins {
-		m := p.Manifest()
 		infos = append(infos, PluginInfo{
-			Manifest: m,
-			Enabled:  e.enabled[id],
+			Manifest:             e.manifest(id, p),
+			Enabled:              e.enabled[id],
+			RequiredCapabilities: requiredCapabilities(p),
 		})
 	}
 	return infos
 }
 
 type PluginInfo struct {
-	Manifest Manifest `json:"manifest"`
-	Enabled  bool     `json:"enabled"`
+	Manifest             Manifest     `json:"manifest"`
+	Enabled              bool         `json:"enabled"`
+	RequiredCapabilities []Capability `json:"required_capabilities"`
+}
+
+// requiredCapabilities returns the plugin's required capabilities, never nil.
+func requiredCapabilities(p Plugin) []Capability {
+	caps := p.RequiredCapabilities()
+	if caps == nil {
+		caps = []Capability{}
+	}
+	return caps
 }
 
 // RestoreEnabled loads the enabled state of plugins from the database.
@@ -236,13 +318,16 @@ func (e *Engine) RestoreEnabled(ctx context.Context) error {
 	return nil
 }
 
+// RegisterAllRoutes registers every plugin's routes on router, then walks it
+// to record which routes each plugin added and the permissions they need.
 func (e *Engine) RegisterAllRoutes(router *mux.Router, cm *cluster.Manager) {
-	e.mu.RLock()
-	defer e.mu.RUnlock()
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
 	for _, p := range e.plugins {
 		p.RegisterRoutes(router, cm)
 	}
+	e.routes = discoverRoutes(router, e.plugins)
 }
 
 func (e *Engine) RegisterAllWatchers(hub *ws.Hub, cm *cluster.Manager) {
@@ -31,6 +31,7 @@ func (m *mockPlugin) RegisterWatchers(hub *ws.Hub, cm *cluster.Manager) {
 }
 func (m *mockPlugin) OnEnable(ctx context.Context, pool *pgxpool.Pool) error  { return m.enableErr }
 func (m *mockPlugin) OnDisable(ctx context.Context, pool *pgxpool.Pool) error { return m.disableErr }
+func (m *mockPlugin) RequiredCapabilities() []Capability                      { return nil }
 
 func newMockPlugin(id, name, version string) *mockPlugin {
 	return &mockPlugin{
@@ -1,6 +1,7 @@
 package plugin
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -21,6 +22,8 @@ func (h *Handlers) RegisterRoutes(r *mux.Router) {
 	api := r.PathPrefix("/api/plugins").Subrouter()
 	api.HandleFunc("", h.handleList).Methods("GET")
 	api.HandleFunc("/enabled", h.handleListEnabled).Methods("GET")
+	api.HandleFunc("/permissions", h.handlePermissions).Methods("GET")
+	api.HandleFunc("/{id}/capabilities", h.handleCapabilities).Methods("GET")
 
 	// Write endpoints require plugins:write RBAC
 	writeAPI := api.PathPrefix("").Subrouter()
@@ -47,6 +50,47 @@ func (h *Handlers) handleListEnabled(w http.ResponseWriter, r *http.Request) {
 	httputil.WriteJSON(w, http.StatusOK, manifests)
 }
 
+// handlePermissions lists the RBAC permissions plugin routes require, so
+// roles can be granted them. Each route is listed with its permission in
+// the plugin's manifest.
+func (h *Handlers) handlePermissions(w http.ResponseWriter, r *http.Request) {
+	httputil.WriteJSON(w, http.StatusOK, h.engine.Permissions())
+}
+
+// handleCapabilities reports which of a plugin's required capabilities a
+// cluster lacks (?clusterID=), so the UI can explain before opening the
+// plugin's views. It works whether or not the plugin is enabled.
+func (h *Handlers) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	clusterID := r.URL.Query().Get("clusterID")
+	if clusterID == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "clusterID is required")
+		return
+	}
+	required, err := h.engine.RequiredCapabilities(id)
+	if err != nil {
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	missing, err := h.engine.MissingCapabilities(r.Context(), id, clusterID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadGateway, fmt.Sprintf("discovery failed: %v", err))
+		return
+	}
+
+	resp := map[string]interface{}{
+		"plugin":     id,
+		"cluster_id": clusterID,
+		"required":   required,
+		"missing":    append([]Capability{}, missing...),
+		"satisfied":  len(missing) == 0,
+	}
+	if len(missing) > 0 {
+		resp["message"] = missingCapabilitiesMessage(id, missing)
+	}
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
+
 func (h *Handlers) handleEnable(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 
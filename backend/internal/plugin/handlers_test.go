@@ -17,6 +17,7 @@ import (
 type fakePlugin struct {
 	id       string
 	manifest Manifest
+	caps     []Capability
 }
 
 func (f *fakePlugin) ID() string        { return f.id }
@@ -25,6 +26,7 @@ func (f *fakePlugin) RegisterRoutes(_ *mux.Router, _ *cluster.Manager) {}
 func (f *fakePlugin) RegisterWatchers(_ *ws.Hub, _ *cluster.Manager)   {}
 func (f *fakePlugin) OnEnable(_ context.Context, _ *pgxpool.Pool) error  { return nil }
 func (f *fakePlugin) OnDisable(_ context.Context, _ *pgxpool.Pool) error { return nil }
+func (f *fakePlugin) RequiredCapabilities() []Capability               { return f.caps }
 
 func newTestEngine(plugins ...*fakePlugin) *Engine {
 	e := NewEngine(nil)
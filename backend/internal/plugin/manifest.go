@@ -25,6 +25,16 @@ type RouteDefinition struct {
 	Method  string `json:"method"`
 	Path    string `json:"path"`
 	Handler string `json:"handler"`
+	// Permission is the RBAC permission the route requires. When a manifest
+	// leaves it out, the engine derives it; see defaultRoutePermission.
+	Permission *RoutePermission `json:"permission,omitempty"`
+}
+
+// RoutePermission is an RBAC permission on a plugin resource. A role grants
+// it with a permission on Resource for Action, or for "*".
+type RoutePermission struct {
+	Resource string `json:"resource"`
+	Action   string `json:"action"`
 }
 
 type WatcherDefinition struct {
@@ -17,4 +17,8 @@ type Plugin interface {
 	RegisterWatchers(hub *ws.Hub, cm *cluster.Manager)
 	OnEnable(ctx context.Context, pool *pgxpool.Pool) error
 	OnDisable(ctx context.Context, pool *pgxpool.Pool) error
+	// RequiredCapabilities lists the APIs a cluster must serve for the
+	// plugin to work there, typically its operator's CRDs. Requests for a
+	// cluster missing any of them are refused with a message naming them.
+	RequiredCapabilities() []Capability
 }
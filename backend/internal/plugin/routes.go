@@ -0,0 +1,122 @@
+package plugin
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// pluginRoutePrefix is the path prefix of every plugin route; the segment
+// after it is the plugin ID.
+const pluginRoutePrefix = "/api/plugins/"
+
+// pluginIDFromPath returns the plugin ID of a /api/plugins/{pluginID}/...
+// path or path template, or "" for any other path.
+func pluginIDFromPath(path string) string {
+	rest, ok := strings.CutPrefix(path, pluginRoutePrefix)
+	if !ok {
+		return ""
+	}
+	id, _, _ := strings.Cut(rest, "/")
+	return id
+}
+
+// PluginPermission is an RBAC permission some plugin route requires.
+type PluginPermission struct {
+	Plugin string `json:"plugin"`
+	RoutePermission
+}
+
+// discoverRoutes walks router for the routes plugins registered on it,
+// keyed by plugin ID. Each route is matched to its manifest entry for the
+// handler name and permission, and is sorted by path and method.
+func discoverRoutes(router *mux.Router, plugins map[string]Plugin) map[string][]RouteDefinition {
+	found := make(map[string][]RouteDefinition)
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil // a subrouter's prefix
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		id := pluginIDFromPath(path)
+		if _, ok := plugins[id]; !ok {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			found[id] = append(found[id], RouteDefinition{Method: method, Path: path})
+		}
+		return nil
+	})
+
+	for id, routes := range found {
+		m := plugins[id].Manifest()
+		declared := make(map[string]RouteDefinition, len(m.Backend.Routes))
+		for _, rd := range m.Backend.Routes {
+			declared[strings.ToUpper(rd.Method)+" "+rd.Path] = rd
+		}
+		for i := range routes {
+			rd := &routes[i]
+			if d, ok := declared[rd.Method+" "+rd.Path]; ok {
+				rd.Handler = d.Handler
+				rd.Permission = d.Permission
+			}
+			if rd.Permission == nil {
+				p := defaultRoutePermission(m, rd.Method)
+				rd.Permission = &p
+			}
+		}
+		sort.Slice(routes, func(i, j int) bool {
+			if routes[i].Path != routes[j].Path {
+				return routes[i].Path < routes[j].Path
+			}
+			return routes[i].Method < routes[j].Method
+		})
+	}
+	return found
+}
+
+// defaultRoutePermission is the permission of a route whose manifest entry
+// declares none: read for GET and HEAD requests and write for the rest. The
+// resource is the one named by the manifest's "read:<resource>" and
+// "write:<resource>" permissions, or else the plugin ID.
+func defaultRoutePermission(m Manifest, method string) RoutePermission {
+	action := "write"
+	if method == http.MethodGet || method == http.MethodHead {
+		action = "read"
+	}
+	for _, p := range m.Permissions {
+		if a, resource, ok := strings.Cut(p, ":"); ok && a == action && resource != "" && resource != "*" {
+			return RoutePermission{Resource: resource, Action: action}
+		}
+	}
+	return RoutePermission{Resource: m.ID, Action: action}
+}
+
+// routePermissions returns the distinct permissions of a plugin's routes,
+// sorted by resource and action.
+func routePermissions(id string, routes []RouteDefinition) []PluginPermission {
+	seen := make(map[RoutePermission]bool)
+	perms := []PluginPermission{}
+	for _, rd := range routes {
+		if rd.Permission == nil || seen[*rd.Permission] {
+			continue
+		}
+		seen[*rd.Permission] = true
+		perms = append(perms, PluginPermission{Plugin: id, RoutePermission: *rd.Permission})
+	}
+	sort.Slice(perms, func(i, j int) bool {
+		if perms[i].Resource != perms[j].Resource {
+			return perms[i].Resource < perms[j].Resource
+		}
+		return perms[i].Action < perms[j].Action
+	})
+	return perms
+}
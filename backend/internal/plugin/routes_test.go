@@ -0,0 +1,134 @@
+package plugin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+)
+
+// routedPlugin registers the routes set by register.
+type routedPlugin struct {
+	fakePlugin
+	register func(r *mux.Router)
+}
+
+func (p *routedPlugin) RegisterRoutes(r *mux.Router, _ *cluster.Manager) { p.register(r) }
+
+func noop(http.ResponseWriter, *http.Request) {}
+
+func newRoutedEngine(t *testing.T) *Engine {
+	t.Helper()
+	helm := &routedPlugin{
+		fakePlugin: fakePlugin{id: "helm", manifest: Manifest{
+			ID: "helm", Name: "Helm", Version: "1.0.0",
+			Permissions: []string{"read:releases", "write:releases"},
+			Backend: BackendManifest{Routes: []RouteDefinition{
+				{Method: "DELETE", Path: "/api/plugins/helm/{cluster}/releases/{name}", Handler: "UninstallRelease",
+					Permission: &RoutePermission{Resource: "releases", Action: "delete"}},
+			}},
+		}},
+		register: func(r *mux.Router) {
+			sub := r.PathPrefix("/api/plugins/helm").Subrouter()
+			sub.HandleFunc("/{cluster}/releases", noop).Methods("GET")
+			sub.HandleFunc("/{cluster}/releases", noop).Methods("POST")
+			sub.HandleFunc("/{cluster}/releases/{name}", noop).Methods("DELETE")
+		},
+	}
+	keda := &routedPlugin{
+		fakePlugin: fakePlugin{id: "keda", manifest: Manifest{
+			ID: "keda", Name: "KEDA", Version: "1.0.0", Permissions: []string{"keda:*"},
+		}},
+		register: func(r *mux.Router) {
+			r.HandleFunc("/api/plugins/keda/scaledobjects", noop).Methods("GET", "HEAD")
+			r.HandleFunc("/api/other", noop).Methods("GET")
+		},
+	}
+
+	e := NewEngine(nil)
+	for _, p := range []Plugin{helm, keda} {
+		if err := e.Register(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	e.RegisterAllRoutes(mux.NewRouter(), nil)
+	return e
+}
+
+func TestRegisterAllRoutes_DiscoversRoutes(t *testing.T) {
+	e := newRoutedEngine(t)
+
+	m, err := e.GetManifest("helm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		method, path, handler, resource, action string
+	}{
+		{"GET", "/api/plugins/helm/{cluster}/releases", "", "releases", "read"},
+		{"POST", "/api/plugins/helm/{cluster}/releases", "", "releases", "write"},
+		{"DELETE", "/api/plugins/helm/{cluster}/releases/{name}", "UninstallRelease", "releases", "delete"},
+	}
+	if len(m.Backend.Routes) != len(want) {
+		t.Fatalf("expected %d routes, got %+v", len(want), m.Backend.Routes)
+	}
+	for i, w := range want {
+		rd := m.Backend.Routes[i]
+		if rd.Method != w.method || rd.Path != w.path || rd.Handler != w.handler ||
+			rd.Permission == nil || rd.Permission.Resource != w.resource || rd.Permission.Action != w.action {
+			t.Errorf("route %d: expected %+v, got %+v (%+v)", i, w, rd, rd.Permission)
+		}
+	}
+
+	m, _ = e.GetManifest("keda")
+	if len(m.Backend.Routes) != 2 || m.Backend.Routes[0].Permission.Resource != "keda" {
+		t.Errorf("expected the plugin ID as the resource, got %+v", m.Backend.Routes)
+	}
+}
+
+func TestHandlePermissions(t *testing.T) {
+	r := mux.NewRouter()
+	NewHandlers(newRoutedEngine(t), nil).RegisterRoutes(r)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/plugins/permissions", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var perms []PluginPermission
+	if err := json.Unmarshal(rec.Body.Bytes(), &perms); err != nil {
+		t.Fatal(err)
+	}
+	want := []PluginPermission{
+		{Plugin: "helm", RoutePermission: RoutePermission{Resource: "releases", Action: "delete"}},
+		{Plugin: "helm", RoutePermission: RoutePermission{Resource: "releases", Action: "read"}},
+		{Plugin: "helm", RoutePermission: RoutePermission{Resource: "releases", Action: "write"}},
+		{Plugin: "keda", RoutePermission: RoutePermission{Resource: "keda", Action: "read"}},
+	}
+	if len(perms) != len(want) {
+		t.Fatalf("expected %+v, got %+v", want, perms)
+	}
+	for i := range want {
+		if perms[i] != want[i] {
+			t.Errorf("permission %d: expected %+v, got %+v", i, want[i], perms[i])
+		}
+	}
+}
+
+func TestPluginIDFromPath(t *testing.T) {
+	tests := map[string]string{
+		"/api/plugins/helm/c1/releases": "helm",
+		"/api/plugins/keda":             "keda",
+		"/api/plugins/":                 "",
+		"/api/clusters/c1":              "",
+	}
+	for path, want := range tests {
+		if got := pluginIDFromPath(path); got != want {
+			t.Errorf("pluginIDFromPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
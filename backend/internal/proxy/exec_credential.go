@@ -0,0 +1,120 @@
+package proxy
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// proxyRoute is the route template of the Kubernetes proxy, the only route
+// cluster credentials may reach.
+const proxyRoute = "/api/proxy/k8s/{cluster_id}/"
+
+// CredentialIssuer mints short-lived credentials scoped to one cluster's
+// proxy. Implemented by *auth.JWTService.
+type CredentialIssuer interface {
+	GenerateClusterCredential(userID, email, clusterID string) (string, time.Time, error)
+}
+
+// SetCredentialIssuer enables the exec credential endpoint. Without an
+// issuer it answers 503.
+func (p *K8sProxy) SetCredentialIssuer(issuer CredentialIssuer) {
+	p.credentials = issuer
+}
+
+// handleExecCredential exchanges the caller's token for an ExecCredential
+// holding a short-lived token for the proxy of the cluster named by
+// ?cluster=, by ID or name. It is meant to be called by a kubectl exec
+// credential plugin, so the caller's long-lived token never sits in the
+// kubeconfig and the proxy token rotates on its own.
+func (p *K8sProxy) handleExecCredential(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if p.credentials == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "exec credentials are not available")
+		return
+	}
+	filter := r.URL.Query().Get("cluster")
+	if filter == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "cluster is required")
+		return
+	}
+
+	clusters, ok := p.proxiedClusters(w, r, claims.UserID, filter)
+	if !ok {
+		return
+	}
+	if len(clusters) == 0 {
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
+		return
+	}
+	if len(clusters) > 1 {
+		httputil.WriteError(w, http.StatusConflict, "several clusters have this name; use the cluster ID")
+		return
+	}
+
+	token, expiresAt, err := p.credentials.GenerateClusterCredential(claims.UserID, claims.Email, clusters[0].ID)
+	if err != nil {
+		log.Printf("proxy: failed to mint cluster credential for user %s cluster %s: %v", claims.UserID, clusters[0].ID, err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to generate credential")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(execCredential(token, expiresAt))
+}
+
+// execCredential wraps token in the ExecCredential kubectl expects on the
+// exec plugin's stdout.
+func execCredential(token string, expiresAt time.Time) *clientauthv1.ExecCredential {
+	expiry := metav1.NewTime(expiresAt)
+	return &clientauthv1.ExecCredential{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: clientauthv1.SchemeGroupVersion.String(),
+			Kind:       "ExecCredential",
+		},
+		Status: &clientauthv1.ExecCredentialStatus{
+			Token:               token,
+			ExpirationTimestamp: &expiry,
+		},
+	}
+}
+
+// CredentialGuard confines cluster credentials to the Kubernetes proxy of
+// the cluster they were minted for; every other route is refused. Requests
+// from other principals pass through untouched. It must run after the auth
+// middleware, on a router whose routes are already matched.
+func CredentialGuard() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.ClaimsFromContext(r.Context())
+			if !ok || claims.TokenType != auth.TokenTypeClusterCredential {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var template string
+			if route := mux.CurrentRoute(r); route != nil {
+				template, _ = route.GetPathTemplate()
+			}
+			if template != proxyRoute || mux.Vars(r)["cluster_id"] != claims.ClusterID {
+				httputil.WriteError(w, http.StatusForbidden, "cluster credentials only grant access to their cluster's proxy")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
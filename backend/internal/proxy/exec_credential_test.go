@@ -0,0 +1,103 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+)
+
+func TestExecCredential(t *testing.T) {
+	expiresAt := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	body, err := json.Marshal(execCredential("argus_kc_tok", expiresAt))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var got struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Status     struct {
+			Token               string `json:"token"`
+			ExpirationTimestamp string `json:"expirationTimestamp"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.APIVersion != "client.authentication.k8s.io/v1" || got.Kind != "ExecCredential" {
+		t.Errorf("unexpected type %s %s", got.APIVersion, got.Kind)
+	}
+	if got.Status.Token != "argus_kc_tok" || got.Status.ExpirationTimestamp != "2026-03-04T05:06:07Z" {
+		t.Errorf("unexpected status %+v", got.Status)
+	}
+}
+
+func TestHandleExecCredential_Refused(t *testing.T) {
+	claims := &auth.Claims{UserID: "user-1", Email: "test@test.com"}
+	tests := []struct {
+		name   string
+		issuer CredentialIssuer
+		target string
+		status int
+	}{
+		{"no issuer", nil, "/api/proxy/exec-credential?cluster=c1", http.StatusServiceUnavailable},
+		{"no cluster", auth.NewJWTService("secret"), "/api/proxy/exec-credential", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		p := &K8sProxy{credentials: tt.issuer}
+		req := httptest.NewRequest(http.MethodPost, tt.target, nil)
+		req = req.WithContext(auth.ContextWithClaims(context.Background(), claims))
+		w := httptest.NewRecorder()
+		p.handleExecCredential(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, w.Code)
+		}
+	}
+}
+
+func TestCredentialGuard(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	credential := &auth.Claims{UserID: "user-1", TokenType: auth.TokenTypeClusterCredential, ClusterID: "c1"}
+	access := &auth.Claims{UserID: "user-1", TokenType: auth.TokenTypeAccess}
+
+	tests := []struct {
+		name   string
+		claims *auth.Claims
+		method string
+		target string
+		status int
+	}{
+		{"own cluster", credential, http.MethodGet, "/api/proxy/k8s/c1/api/v1/pods", http.StatusOK},
+		{"own cluster write", credential, http.MethodDelete, "/api/proxy/k8s/c1/api/v1/namespaces/shop/pods/web-0", http.StatusOK},
+		{"other cluster", credential, http.MethodGet, "/api/proxy/k8s/c2/api/v1/pods", http.StatusForbidden},
+		{"kubeconfig", credential, http.MethodGet, "/api/proxy/kubeconfig", http.StatusForbidden},
+		{"minting another", credential, http.MethodPost, "/api/proxy/exec-credential?cluster=c1", http.StatusForbidden},
+		{"other API", credential, http.MethodGet, "/api/clusters", http.StatusForbidden},
+		{"access token", access, http.MethodGet, "/api/clusters", http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := mux.NewRouter()
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				next.ServeHTTP(w, req.WithContext(auth.ContextWithClaims(req.Context(), tt.claims)))
+			})
+		})
+		r.Use(CredentialGuard())
+		r.HandleFunc("/api/proxy/kubeconfig", ok).Methods(http.MethodGet)
+		r.HandleFunc("/api/proxy/exec-credential", ok).Methods(http.MethodPost)
+		r.PathPrefix(proxyRoute).Handler(ok)
+		r.HandleFunc("/api/clusters", ok).Methods(http.MethodGet)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, w.Code)
+		}
+	}
+}
@@ -3,12 +3,13 @@ package proxy
 import (
 	"log"
 	"net/http"
-	"net/http/httputil"
+	nethttputil "net/http/httputil"
 	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/darkden-lab/argus/backend/internal/auth"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 	"github.com/darkden-lab/argus/backend/internal/rbac"
 	"k8s.io/client-go/transport"
 )
@@ -17,8 +18,9 @@ import (
 // Kubernetes API server. It extracts the cluster ID from the URL, looks up
 // the cluster's rest.Config, and proxies the request through.
 type K8sProxy struct {
-	clusterMgr *cluster.Manager
-	rbacEngine *rbac.Engine
+	clusterMgr  *cluster.Manager
+	rbacEngine  *rbac.Engine
+	credentials CredentialIssuer
 }
 
 // NewK8sProxy creates a new Kubernetes API proxy.
@@ -29,7 +31,9 @@ func NewK8sProxy(clusterMgr *cluster.Manager, rbacEngine *rbac.Engine) *K8sProxy
 // RegisterRoutes wires the proxy endpoints onto the given router.
 // The router should already have auth middleware applied.
 func (p *K8sProxy) RegisterRoutes(r *mux.Router) {
-	r.PathPrefix("/api/proxy/k8s/{cluster_id}/").HandlerFunc(p.handleProxy)
+	r.HandleFunc("/api/proxy/kubeconfig", p.handleKubeconfig).Methods("GET")
+	r.HandleFunc("/api/proxy/exec-credential", p.handleExecCredential).Methods("POST")
+	r.PathPrefix(proxyRoute).HandlerFunc(p.handleProxy)
 }
 
 func (p *K8sProxy) handleProxy(w http.ResponseWriter, r *http.Request) {
@@ -38,7 +42,7 @@ func (p *K8sProxy) handleProxy(w http.ResponseWriter, r *http.Request) {
 
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok {
-		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
@@ -51,19 +55,19 @@ func (p *K8sProxy) handleProxy(w http.ResponseWriter, r *http.Request) {
 	})
 	if err != nil {
 		log.Printf("proxy: RBAC evaluation failed for user %s cluster %s: %v", claims.UserID, clusterID, err)
-		http.Error(w, `{"error":"permission check failed"}`, http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
 		return
 	}
 	if !allowed {
 		log.Printf("proxy: forbidden access attempt by user %s to cluster %s %s %s",
 			claims.UserID, clusterID, r.Method, r.URL.Path)
-		http.Error(w, `{"error":"insufficient permissions"}`, http.StatusForbidden)
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
 		return
 	}
 
 	client, err := p.clusterMgr.GetClient(clusterID)
 	if err != nil {
-		http.Error(w, `{"error":"cluster not found"}`, http.StatusNotFound)
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
@@ -78,14 +82,14 @@ func (p *K8sProxy) handleProxy(w http.ResponseWriter, r *http.Request) {
 	transportConfig, err := client.RestConfig.TransportConfig()
 	if err != nil {
 		log.Printf("proxy: failed to get transport config for cluster %s: %v", clusterID, err)
-		http.Error(w, `{"error":"failed to configure transport"}`, http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to configure transport")
 		return
 	}
 
 	rt, err := transport.New(transportConfig)
 	if err != nil {
 		log.Printf("proxy: failed to create transport for cluster %s: %v", clusterID, err)
-		http.Error(w, `{"error":"failed to create transport"}`, http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to create transport")
 		return
 	}
 
@@ -111,12 +115,12 @@ func (p *K8sProxy) handleProxy(w http.ResponseWriter, r *http.Request) {
 		req.Header.Set("Impersonate-User", claims.Email)
 	}
 
-	proxy := &httputil.ReverseProxy{
+	proxy := &nethttputil.ReverseProxy{
 		Director:  director,
 		Transport: rt,
 		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
 			log.Printf("proxy: error forwarding to cluster %s: %v", clusterID, err)
-			http.Error(w, `{"error":"proxy error"}`, http.StatusBadGateway)
+			httputil.WriteError(w, http.StatusBadGateway, "proxy error")
 		},
 	}
 
@@ -0,0 +1,155 @@
+package proxy
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+// kubeconfigNamePrefix starts the names of the clusters, contexts and users
+// Argus puts in a kubeconfig. The CLI uses it to find the entries it manages.
+const kubeconfigNamePrefix = "argus-"
+
+// handleKubeconfig returns a kubeconfig with a context per cluster the user
+// can read, each pointing at this proxy and authenticating with the token of
+// the request. ?cluster= narrows it to one cluster, by ID or name. Agent
+// clusters are left out: the proxy cannot reach them.
+func (p *K8sProxy) handleKubeconfig(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	token := bearerToken(r)
+	if token == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "a kubeconfig can only be generated for bearer token authentication")
+		return
+	}
+
+	filter := r.URL.Query().Get("cluster")
+	accessible, ok := p.proxiedClusters(w, r, claims.UserID, filter)
+	if !ok {
+		return
+	}
+	if filter != "" && len(accessible) == 0 {
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
+		return
+	}
+
+	config := buildKubeconfig(externalBaseURL(r), token, claims.Email, accessible)
+	body, err := clientcmd.Write(*config)
+	if err != nil {
+		log.Printf("proxy: failed to encode kubeconfig for user %s: %v", claims.UserID, err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to generate kubeconfig")
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// proxiedClusters returns the clusters userID can read through the proxy,
+// or only the one whose ID or name is filter. Agent clusters are left out.
+// On failure it writes the error response and returns false.
+func (p *K8sProxy) proxiedClusters(w http.ResponseWriter, r *http.Request, userID, filter string) ([]*cluster.Cluster, bool) {
+	clusters, err := p.clusterMgr.ListClusters(r.Context())
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to list clusters")
+		return nil, false
+	}
+
+	var accessible []*cluster.Cluster
+	for _, c := range clusters {
+		if c.ConnectionType == "agent" || (filter != "" && c.ID != filter && c.Name != filter) {
+			continue
+		}
+		allowed, err := p.rbacEngine.Evaluate(r.Context(), rbac.Request{
+			UserID:    userID,
+			Resource:  "clusters",
+			Action:    "read",
+			ClusterID: c.ID,
+		})
+		if err != nil {
+			log.Printf("proxy: RBAC evaluation failed for user %s cluster %s: %v", userID, c.ID, err)
+			httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+			return nil, false
+		}
+		if allowed {
+			accessible = append(accessible, c)
+		}
+	}
+	return accessible, true
+}
+
+// buildKubeconfig returns a kubeconfig with one user for the token and one
+// cluster and context per cluster, served by the proxy at baseURL. With a
+// single cluster its context is made current.
+func buildKubeconfig(baseURL, token, email string, clusters []*cluster.Cluster) *clientcmdapi.Config {
+	config := clientcmdapi.NewConfig()
+	userName := kubeconfigNamePrefix + kubeconfigName(email)
+	config.AuthInfos[userName] = &clientcmdapi.AuthInfo{Token: token}
+
+	for _, c := range clusters {
+		name := kubeconfigNamePrefix + kubeconfigName(c.Name)
+		config.Clusters[name] = &clientcmdapi.Cluster{
+			Server: strings.TrimSuffix(baseURL, "/") + "/api/proxy/k8s/" + c.ID,
+		}
+		config.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: userName}
+	}
+	if len(clusters) == 1 {
+		config.CurrentContext = kubeconfigNamePrefix + kubeconfigName(clusters[0].Name)
+	}
+	return config
+}
+
+// kubeconfigName turns a cluster name or email into a kubeconfig name, the
+// same way the CLI does.
+func kubeconfigName(name string) string {
+	replacer := strings.NewReplacer(
+		" ", "-",
+		"@", "-at-",
+		"/", "-",
+		"\\", "-",
+		":", "-",
+	)
+	return strings.ToLower(replacer.Replace(name))
+}
+
+// bearerToken returns the bearer token of the request, or "" if it was
+// authenticated some other way.
+func bearerToken(r *http.Request) string {
+	if r.Header.Get("X-API-Key") != "" {
+		return ""
+	}
+	parts := strings.SplitN(r.Header.Get("Authorization"), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+		return ""
+	}
+	return parts[1]
+}
+
+// externalBaseURL returns the URL the client reached the server at, taking
+// the headers set by a reverse proxy in front of it into account.
+func externalBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	host := r.Host
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		host = strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	return scheme + "://" + host
+}
@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+)
+
+func TestBuildKubeconfig(t *testing.T) {
+	clusters := []*cluster.Cluster{
+		{ID: "c1", Name: "Prod EU"},
+		{ID: "c2", Name: "staging"},
+	}
+	config := buildKubeconfig("https://argus.example.com/", "tok", "Jane@example.com", clusters)
+
+	user, ok := config.AuthInfos["argus-jane-at-example.com"]
+	if !ok || user.Token != "tok" {
+		t.Fatalf("expected a user with the token, got %+v", config.AuthInfos)
+	}
+	if got := config.Clusters["argus-prod-eu"]; got == nil || got.Server != "https://argus.example.com/api/proxy/k8s/c1" {
+		t.Errorf("unexpected cluster %+v", got)
+	}
+	ctx := config.Contexts["argus-staging"]
+	if ctx == nil || ctx.Cluster != "argus-staging" || ctx.AuthInfo != "argus-jane-at-example.com" {
+		t.Errorf("unexpected context %+v", ctx)
+	}
+	if config.CurrentContext != "" {
+		t.Errorf("expected no current context with several clusters, got %q", config.CurrentContext)
+	}
+
+	// The result must load as a kubeconfig.
+	body, err := clientcmd.Write(*config)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	loaded, err := clientcmd.Load(body)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(loaded.Contexts) != 2 {
+		t.Errorf("expected 2 contexts, got %d", len(loaded.Contexts))
+	}
+}
+
+func TestBuildKubeconfig_SingleCluster(t *testing.T) {
+	config := buildKubeconfig("http://localhost:8080", "tok", "a@b.c", []*cluster.Cluster{{ID: "c1", Name: "dev"}})
+	if config.CurrentContext != "argus-dev" {
+		t.Errorf("expected the only context to be current, got %q", config.CurrentContext)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{"bearer", map[string]string{"Authorization": "Bearer abc"}, "abc"},
+		{"lowercase", map[string]string{"Authorization": "bearer abc"}, "abc"},
+		{"api key", map[string]string{"X-API-Key": "key", "Authorization": "Bearer abc"}, ""},
+		{"basic", map[string]string{"Authorization": "Basic abc"}, ""},
+		{"none", nil, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/proxy/kubeconfig", nil)
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		if got := bearerToken(req); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestExternalBaseURL(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://argus.internal:8080/api/proxy/kubeconfig", nil)
+	if got := externalBaseURL(req); got != "http://argus.internal:8080" {
+		t.Errorf("unexpected URL %q", got)
+	}
+
+	req.TLS = &tls.ConnectionState{}
+	if got := externalBaseURL(req); got != "https://argus.internal:8080" {
+		t.Errorf("unexpected URL over TLS %q", got)
+	}
+
+	req.TLS = nil
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "argus.example.com, lb.internal")
+	if got := externalBaseURL(req); got != "https://argus.example.com" {
+		t.Errorf("unexpected URL behind a proxy %q", got)
+	}
+}
+
+func TestHandleKubeconfig_NoAuth(t *testing.T) {
+	p := &K8sProxy{}
+	w := httptest.NewRecorder()
+	p.handleKubeconfig(w, httptest.NewRequest(http.MethodGet, "/api/proxy/kubeconfig", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/darkden-lab/argus/backend/internal/auth"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
 	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/validate"
 )
 
 const maxUploadSize = 100 * 1024 * 1024 // 100 MB
@@ -61,6 +62,10 @@ func (h *Handlers) handleStartSession(w http.ResponseWriter, r *http.Request) {
 		httputil.WriteError(w, http.StatusBadRequest, "namespace and pvc_name are required")
 		return
 	}
+	if !validate.Segment(req.Namespace) || !validate.Segment(req.PVCName) {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid namespace or pvc_name")
+		return
+	}
 
 	clusterID := mux.Vars(r)["clusterID"]
 	userID := getUserID(r)
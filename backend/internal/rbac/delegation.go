@@ -0,0 +1,247 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+)
+
+// DelegationMode says how the engine combines the dashboard's roles with the
+// target cluster's own RBAC for requests on Kubernetes resources.
+type DelegationMode string
+
+const (
+	// DelegationOff checks only the dashboard's roles.
+	DelegationOff DelegationMode = "off"
+	// DelegationCluster lets the cluster decide requests on its resources.
+	DelegationCluster DelegationMode = "cluster"
+	// DelegationBoth requires both the dashboard and the cluster to allow them.
+	DelegationBoth DelegationMode = "both"
+)
+
+// ParseDelegationMode parses a delegation mode; empty means DelegationOff.
+func ParseDelegationMode(s string) (DelegationMode, error) {
+	switch mode := DelegationMode(s); mode {
+	case "":
+		return DelegationOff, nil
+	case DelegationOff, DelegationCluster, DelegationBoth:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown delegation mode %q (want off, cluster or both)", s)
+}
+
+// K8sRequester sends raw requests to a cluster's API server. Implemented by
+// *cluster.Manager.
+type K8sRequester interface {
+	DoK8sRequest(ctx context.Context, clusterID, method, path string, body []byte) ([]byte, error)
+}
+
+// subjectAccessReviewPath is where SubjectAccessReviews are created.
+const subjectAccessReviewPath = "/apis/authorization.k8s.io/v1/subjectaccessreviews"
+
+// maxCachedReviews bounds the review cache; past it expired entries are
+// dropped, and if that is not enough the cache is cleared.
+const maxCachedReviews = 10000
+
+// delegationVerbs maps request actions to the Kubernetes verbs checked for
+// them. Writes in the dashboard are patches (server-side apply, scale,
+// restart). Other actions are not delegated.
+var delegationVerbs = map[string]string{
+	"read":   "get",
+	"write":  "patch",
+	"delete": "delete",
+}
+
+// ResourceAttributes is what a SubjectAccessReview asks about.
+type ResourceAttributes struct {
+	Namespace   string `json:"namespace,omitempty"`
+	Verb        string `json:"verb"`
+	Group       string `json:"group"`
+	Resource    string `json:"resource"`
+	Subresource string `json:"subresource,omitempty"`
+	Name        string `json:"name,omitempty"`
+}
+
+type reviewKey struct {
+	userID    string
+	clusterID string
+	attrs     ResourceAttributes
+}
+
+type cachedReview struct {
+	allowed   bool
+	expiresAt time.Time
+}
+
+type delegation struct {
+	requester K8sRequester
+	mode      DelegationMode
+	ttl       time.Duration
+	reviews   map[reviewKey]cachedReview
+}
+
+// SetClusterDelegation makes the engine check requests on Kubernetes
+// resources (those with a ClusterID and K8sResource) against the target
+// cluster's RBAC with a SubjectAccessReview for the user's cluster
+// identity: the email the Kubernetes proxy impersonates. Decisions are
+// cached for ttl. Service account and share link principals, and requests
+// on dashboard resources, are always checked locally. Call it before the
+// engine serves requests; DelegationOff turns delegation off.
+func (e *Engine) SetClusterDelegation(requester K8sRequester, mode DelegationMode, ttl time.Duration) {
+	if requester == nil || mode == DelegationOff || mode == "" {
+		e.delegation = nil
+		return
+	}
+	e.delegation = &delegation{
+		requester: requester,
+		mode:      mode,
+		ttl:       ttl,
+		reviews:   make(map[reviewKey]cachedReview),
+	}
+}
+
+// delegatedAccess returns the attributes to review in the cluster, if the
+// request is delegated.
+func (e *Engine) delegatedAccess(req Request) (ResourceAttributes, bool) {
+	if e.delegation == nil || req.ClusterID == "" || req.K8sResource == "" {
+		return ResourceAttributes{}, false
+	}
+	if strings.HasPrefix(req.UserID, auth.ServiceAccountPrincipalPrefix) ||
+		strings.HasPrefix(req.UserID, auth.ShareLinkPrincipalPrefix) {
+		return ResourceAttributes{}, false
+	}
+	verb, ok := delegationVerbs[req.Action]
+	if !ok {
+		return ResourceAttributes{}, false
+	}
+	resource, group, _ := strings.Cut(req.K8sResource, ".")
+	return ResourceAttributes{Namespace: req.Namespace, Verb: verb, Group: group, Resource: resource}, true
+}
+
+// reviewInCluster asks the cluster whether the user may act, using the
+// cached answer while it is fresh. Failed reviews are not cached.
+func (e *Engine) reviewInCluster(ctx context.Context, userID, clusterID string, attrs ResourceAttributes) (bool, error) {
+	d := e.delegation
+	key := reviewKey{userID: userID, clusterID: clusterID, attrs: attrs}
+
+	e.mu.RLock()
+	cached, ok := d.reviews[key]
+	e.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.allowed, nil
+	}
+
+	lookup := e.identity
+	if lookup == nil {
+		lookup = e.lookupClusterIdentity
+	}
+	user, err := lookup(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	allowed := false
+	if user != "" {
+		// The review carries the groups impersonation adds, so it answers as
+		// the API server would for the proxy's impersonated requests.
+		decision, err := SubjectAccessReview(ctx, d.requester, clusterID, user, []string{"system:authenticated"}, attrs)
+		if err != nil {
+			return false, err
+		}
+		allowed = decision.Allowed
+	}
+
+	e.mu.Lock()
+	if len(d.reviews) >= maxCachedReviews {
+		now := time.Now()
+		for k, r := range d.reviews {
+			if !now.Before(r.expiresAt) {
+				delete(d.reviews, k)
+			}
+		}
+		if len(d.reviews) >= maxCachedReviews {
+			d.reviews = make(map[reviewKey]cachedReview)
+		}
+	}
+	d.reviews[key] = cachedReview{allowed: allowed, expiresAt: time.Now().Add(d.ttl)}
+	e.mu.Unlock()
+
+	return allowed, nil
+}
+
+// lookupClusterIdentity returns the username a user has in clusters: their
+// email, as impersonated by the Kubernetes proxy. Unknown users have none.
+func (e *Engine) lookupClusterIdentity(ctx context.Context, userID string) (string, error) {
+	var email string
+	err := e.pool.QueryRow(ctx, `SELECT email FROM users WHERE id::text = $1`, userID).Scan(&email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up cluster identity: %w", err)
+	}
+	return email, nil
+}
+
+type subjectAccessReviewSpec struct {
+	User               string             `json:"user"`
+	Groups             []string           `json:"groups"`
+	ResourceAttributes ResourceAttributes `json:"resourceAttributes"`
+}
+
+type subjectAccessReviewStatus struct {
+	Allowed bool   `json:"allowed"`
+	Denied  bool   `json:"denied,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type subjectAccessReviewObject struct {
+	APIVersion string                    `json:"apiVersion"`
+	Kind       string                    `json:"kind"`
+	Spec       subjectAccessReviewSpec   `json:"spec"`
+	Status     subjectAccessReviewStatus `json:"status"`
+}
+
+// AccessDecision is a cluster's answer to a SubjectAccessReview.
+type AccessDecision struct {
+	Allowed bool
+	// Reason is the authorizer's explanation, if it gave one.
+	Reason string
+}
+
+// SubjectAccessReview asks the cluster whether user, as a member of groups,
+// may perform the access. Errors from the API server, such as a 403 when the
+// dashboard may not create reviews, are wrapped and can be unwrapped by the
+// caller.
+func SubjectAccessReview(ctx context.Context, requester K8sRequester, clusterID, user string, groups []string, attrs ResourceAttributes) (AccessDecision, error) {
+	body, err := json.Marshal(subjectAccessReviewObject{
+		APIVersion: "authorization.k8s.io/v1",
+		Kind:       "SubjectAccessReview",
+		Spec: subjectAccessReviewSpec{
+			User:               user,
+			Groups:             groups,
+			ResourceAttributes: attrs,
+		},
+	})
+	if err != nil {
+		return AccessDecision{}, err
+	}
+	resp, err := requester.DoK8sRequest(ctx, clusterID, "POST", subjectAccessReviewPath, body)
+	if err != nil {
+		return AccessDecision{}, fmt.Errorf("subject access review on cluster %s failed: %w", clusterID, err)
+	}
+	var review subjectAccessReviewObject
+	if err := json.Unmarshal(resp, &review); err != nil {
+		return AccessDecision{}, fmt.Errorf("invalid subject access review response from cluster %s: %w", clusterID, err)
+	}
+	return AccessDecision{
+		Allowed: review.Status.Allowed && !review.Status.Denied,
+		Reason:  review.Status.Reason,
+	}, nil
+}
@@ -0,0 +1,165 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+)
+
+// fakeCluster answers SubjectAccessReviews from a set of allowed
+// "user verb resource.group namespace" entries and records the reviews.
+type fakeCluster struct {
+	allowed map[string]bool
+	err     error
+	reviews []subjectAccessReviewSpec
+}
+
+func (f *fakeCluster) DoK8sRequest(ctx context.Context, clusterID, method, path string, body []byte) ([]byte, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if method != "POST" || path != subjectAccessReviewPath {
+		return nil, errors.New("unexpected request " + method + " " + path)
+	}
+	var review subjectAccessReviewObject
+	if err := json.Unmarshal(body, &review); err != nil {
+		return nil, err
+	}
+	f.reviews = append(f.reviews, review.Spec)
+	a := review.Spec.ResourceAttributes
+	review.Status.Allowed = f.allowed[review.Spec.User+" "+a.Verb+" "+QualifiedResource(a.Group, a.Resource)+" "+a.Namespace]
+	return json.Marshal(review)
+}
+
+func newDelegatingEngine(mode DelegationMode, cluster *fakeCluster) *Engine {
+	e := newTestEngine()
+	e.SetClusterDelegation(cluster, mode, time.Minute)
+	e.identity = func(ctx context.Context, userID string) (string, error) {
+		if userID == "ghost" {
+			return "", nil
+		}
+		return userID + "@example.com", nil
+	}
+	return e
+}
+
+func TestClusterDelegation(t *testing.T) {
+	cluster := &fakeCluster{allowed: map[string]bool{
+		"dev@example.com get deployments.apps web": true,
+		"dev@example.com delete pods web":          true,
+	}}
+	deployments := Request{UserID: "dev", Action: "read", Resource: "apps", ClusterID: "c1", Namespace: "web", K8sResource: "deployments.apps"}
+
+	tests := []struct {
+		name  string
+		mode  DelegationMode
+		local []Permission
+		req   Request
+		want  bool
+	}{
+		{"cluster allows without local role", DelegationCluster, nil, deployments, true},
+		{"cluster denies despite local role", DelegationCluster,
+			[]Permission{{Resource: "*", Action: "*", ScopeType: "global"}},
+			Request{UserID: "dev", Action: "write", Resource: "apps", ClusterID: "c1", Namespace: "web", K8sResource: "deployments.apps"}, false},
+		{"core group resource", DelegationCluster, nil,
+			Request{UserID: "dev", Action: "delete", Resource: "apps", ClusterID: "c1", Namespace: "web", K8sResource: "pods"}, true},
+		{"both needs local role", DelegationBoth, nil, deployments, false},
+		{"both with local role", DelegationBoth,
+			[]Permission{{Resource: "apps", Action: "read", ScopeType: "cluster", ScopeID: "c1"}}, deployments, true},
+		{"user without cluster identity", DelegationCluster, nil,
+			Request{UserID: "ghost", Action: "read", Resource: "apps", ClusterID: "c1", Namespace: "web", K8sResource: "deployments.apps"}, false},
+		{"dashboard resource stays local", DelegationCluster,
+			[]Permission{{Resource: "clusters", Action: "read", ScopeType: "global"}},
+			Request{UserID: "dev", Action: "read", Resource: "clusters", ClusterID: "c1"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newDelegatingEngine(tt.mode, cluster)
+			seedCache(e, tt.req.UserID, tt.local)
+			allowed, err := e.Evaluate(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if allowed != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, allowed)
+			}
+		})
+	}
+}
+
+func TestClusterDelegationReview(t *testing.T) {
+	cluster := &fakeCluster{}
+	e := newDelegatingEngine(DelegationCluster, cluster)
+	req := Request{UserID: "dev", Action: "write", Resource: "apps", ClusterID: "c1", Namespace: "web", K8sResource: "deployments.apps"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := e.Evaluate(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(cluster.reviews) != 1 {
+		t.Fatalf("expected the decision to be cached, got %d reviews", len(cluster.reviews))
+	}
+	got := cluster.reviews[0]
+	want := ResourceAttributes{Namespace: "web", Verb: "patch", Group: "apps", Resource: "deployments"}
+	if got.User != "dev@example.com" || got.ResourceAttributes != want {
+		t.Errorf("unexpected review: %+v", got)
+	}
+	if len(got.Groups) != 1 || got.Groups[0] != "system:authenticated" {
+		t.Errorf("expected the groups impersonation adds, got %v", got.Groups)
+	}
+}
+
+func TestClusterDelegationSkipsNonUserPrincipals(t *testing.T) {
+	cluster := &fakeCluster{}
+	e := newDelegatingEngine(DelegationCluster, cluster)
+	sa := auth.ServiceAccountPrincipalPrefix + "sa-1"
+	seedCache(e, sa, []Permission{{Resource: "apps", Action: "read", ScopeType: "global"}})
+
+	allowed, err := e.Evaluate(context.Background(), Request{UserID: sa, Action: "read", Resource: "apps", ClusterID: "c1", K8sResource: "pods"})
+	if err != nil || !allowed {
+		t.Fatalf("expected service account to be checked locally, got %v, %v", allowed, err)
+	}
+	if len(cluster.reviews) != 0 {
+		t.Errorf("expected no review for a service account, got %d", len(cluster.reviews))
+	}
+}
+
+func TestClusterDelegationErrorsAreNotCached(t *testing.T) {
+	cluster := &fakeCluster{err: errors.New("cluster unreachable")}
+	e := newDelegatingEngine(DelegationCluster, cluster)
+	req := Request{UserID: "dev", Action: "read", Resource: "apps", ClusterID: "c1", K8sResource: "pods"}
+
+	if _, err := e.Evaluate(context.Background(), req); err == nil {
+		t.Fatal("expected the review error to be returned")
+	}
+	cluster.err = nil
+	cluster.allowed = map[string]bool{"dev@example.com get pods ": true}
+	if allowed, err := e.Evaluate(context.Background(), req); err != nil || !allowed {
+		t.Errorf("expected a fresh review after the failure, got %v, %v", allowed, err)
+	}
+}
+
+func TestParseDelegationMode(t *testing.T) {
+	for in, want := range map[string]DelegationMode{"": DelegationOff, "off": DelegationOff, "cluster": DelegationCluster, "both": DelegationBoth} {
+		if got, err := ParseDelegationMode(in); err != nil || got != want {
+			t.Errorf("ParseDelegationMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseDelegationMode("either"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestQualifiedResource(t *testing.T) {
+	if got := QualifiedResource("", "pods"); got != "pods" {
+		t.Errorf("expected pods, got %q", got)
+	}
+	if got := QualifiedResource("networking.istio.io", "virtualservices"); got != "virtualservices.networking.istio.io" {
+		t.Errorf("unexpected qualified resource %q", got)
+	}
+}
@@ -3,10 +3,13 @@ package rbac
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
 )
 
 type Request struct {
@@ -15,20 +18,44 @@ type Request struct {
 	Resource  string // "pods", "deployments", "istio:virtualservices"
 	ClusterID string // optional - empty means any cluster
 	Namespace string // optional - empty means any namespace
+	// K8sResource optionally names the Kubernetes resource the request acts
+	// on, as returned by QualifiedResource. Clusters that delegate
+	// authorization check it with a SubjectAccessReview.
+	K8sResource string
 }
 
 type Permission struct {
 	Resource  string
 	Action    string
-	ScopeType string // "global", "cluster", "namespace"
+	ScopeType string // "global", "cluster", "namespace", "group"
 	ScopeID   string
 }
 
+// Evaluator evaluates RBAC requests. Implemented by *Engine; handlers depend
+// on it rather than on the engine so tests can supply their own decisions.
+type Evaluator interface {
+	Evaluate(ctx context.Context, req Request) (bool, error)
+}
+
 type Engine struct {
 	pool  *pgxpool.Pool
 	cache map[string]*cachedPermissions
 	mu    sync.RWMutex
 	ttl   time.Duration
+	// epoch counts invalidations. A load that overlaps an invalidation may
+	// have read the old permissions, so its result is not cached.
+	epoch uint64
+	// load replaces LoadPermissions in tests when set.
+	load func(ctx context.Context, userID string) ([]Permission, error)
+	// groups caches cluster group membership for group-scoped permissions.
+	groups *cachedGroups
+	// loadGroups replaces loadClusterGroups in tests when set.
+	loadGroups func(ctx context.Context) (map[string]map[string]bool, error)
+	// delegation, when set, sends requests on Kubernetes resources to the
+	// target cluster's own RBAC.
+	delegation *delegation
+	// identity replaces lookupClusterIdentity in tests when set.
+	identity func(ctx context.Context, userID string) (string, error)
 }
 
 type cachedPermissions struct {
@@ -44,11 +71,34 @@ func NewEngine(pool *pgxpool.Pool) *Engine {
 	}
 }
 
+// Evaluate reports whether the request is allowed. With cluster delegation
+// configured, requests on Kubernetes resources are also, or only, checked
+// by the target cluster.
 func (e *Engine) Evaluate(ctx context.Context, req Request) (bool, error) {
+	attrs, ok := e.delegatedAccess(req)
+	if !ok {
+		return e.evaluateLocal(ctx, req)
+	}
+	if e.delegation.mode == DelegationBoth {
+		allowed, err := e.evaluateLocal(ctx, req)
+		if err != nil || !allowed {
+			return false, err
+		}
+	}
+	return e.reviewInCluster(ctx, req.UserID, req.ClusterID, attrs)
+}
+
+// evaluateLocal checks the request against the dashboard's own roles.
+func (e *Engine) evaluateLocal(ctx context.Context, req Request) (bool, error) {
 	perms, err := e.getPermissions(ctx, req.UserID)
 	if err != nil {
 		return false, err
 	}
+	if req.ClusterID != "" && hasGroupScope(perms) {
+		if err := e.ensureClusterGroups(ctx); err != nil {
+			return false, err
+		}
+	}
 
 	for _, perm := range perms {
 		if e.matchPermission(perm, req) {
@@ -67,28 +117,72 @@ func (e *Engine) getPermissions(ctx context.Context, userID string) ([]Permissio
 	if ok && time.Now().Before(cached.expiresAt) {
 		return cached.permissions, nil
 	}
+	return e.loadAndCache(ctx, userID)
+}
+
+// Warm loads the user's permissions into the cache, replacing any cached
+// entry, so the first requests after login don't each wait on the
+// database. It is safe to call concurrently with InvalidateCache: a result
+// that raced with an invalidation is dropped rather than cached.
+func (e *Engine) Warm(ctx context.Context, userID string) error {
+	_, err := e.loadAndCache(ctx, userID)
+	return err
+}
 
-	perms, err := e.LoadPermissions(ctx, userID)
+// loadAndCache loads the user's permissions and caches them unless the
+// cache was invalidated while loading.
+func (e *Engine) loadAndCache(ctx context.Context, userID string) ([]Permission, error) {
+	e.mu.RLock()
+	epoch := e.epoch
+	e.mu.RUnlock()
+
+	load := e.load
+	if load == nil {
+		load = e.LoadPermissions
+	}
+	perms, err := load(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	// Share principals are not cached: their permissions depend on the
+	// creator's, and a share must stop working as soon as those change.
 	e.mu.Lock()
-	e.cache[userID] = &cachedPermissions{
-		permissions: perms,
-		expiresAt:   time.Now().Add(e.ttl),
+	if e.epoch == epoch && !strings.HasPrefix(userID, auth.ShareLinkPrincipalPrefix) {
+		e.cache[userID] = &cachedPermissions{
+			permissions: perms,
+			expiresAt:   time.Now().Add(e.ttl),
+		}
 	}
 	e.mu.Unlock()
 
 	return perms, nil
 }
 
+// LoadPermissions returns the user's effective permissions: those of every
+// assigned role plus everything those roles inherit from their parent roles.
+// UNION (rather than UNION ALL) makes the recursion stop on any cycle.
+// Service account principals resolve through their token instead, and
+// share principals through their link.
 func (e *Engine) LoadPermissions(ctx context.Context, userID string) ([]Permission, error) {
+	if saID, ok := strings.CutPrefix(userID, auth.ServiceAccountPrincipalPrefix); ok {
+		return e.loadServiceAccountPermissions(ctx, saID)
+	}
+	if linkID, ok := strings.CutPrefix(userID, auth.ShareLinkPrincipalPrefix); ok {
+		return e.loadShareLinkPermissions(ctx, linkID)
+	}
+
 	query := `
-		SELECT rp.resource, rp.action, rp.scope_type, COALESCE(rp.scope_id, '')
-		FROM user_roles ur
-		JOIN role_permissions rp ON ur.role_id = rp.role_id
-		WHERE ur.user_id = $1
+		WITH RECURSIVE effective_roles(role_id) AS (
+			SELECT role_id FROM user_roles WHERE user_id = $1
+			UNION
+			SELECT p.parent_role_id
+			FROM role_parents p
+			JOIN effective_roles er ON p.role_id = er.role_id
+		)
+		SELECT DISTINCT rp.resource, rp.action, rp.scope_type, COALESCE(rp.scope_id, '')
+		FROM effective_roles er
+		JOIN role_permissions rp ON er.role_id = rp.role_id
 	`
 
 	rows, err := e.pool.Query(ctx, query, userID)
@@ -112,12 +206,28 @@ func (e *Engine) LoadPermissions(ctx context.Context, userID string) ([]Permissi
 func (e *Engine) InvalidateCache(userID string) {
 	e.mu.Lock()
 	delete(e.cache, userID)
+	e.epoch++
 	e.mu.Unlock()
 }
 
-// InvalidateUsersWithRole invalidates the RBAC cache for all users assigned to the given role.
+// InvalidateUsersWithRole invalidates the RBAC cache for all users and service
+// accounts assigned to the given role or to any role that inherits from it.
 func (e *Engine) InvalidateUsersWithRole(ctx context.Context, roleID string) {
-	rows, err := e.pool.Query(ctx, "SELECT user_id FROM user_roles WHERE role_id = $1", roleID)
+	query := `
+		WITH RECURSIVE affected_roles(role_id) AS (
+			SELECT $1::uuid
+			UNION
+			SELECT p.role_id
+			FROM role_parents p
+			JOIN affected_roles ar ON p.parent_role_id = ar.role_id
+		)
+		SELECT DISTINCT ur.user_id::text FROM user_roles ur
+		JOIN affected_roles ar ON ur.role_id = ar.role_id
+		UNION
+		SELECT $2 || s.id::text FROM service_account_tokens s
+		JOIN affected_roles ar ON s.role_id = ar.role_id
+	`
+	rows, err := e.pool.Query(ctx, query, roleID, auth.ServiceAccountPrincipalPrefix)
 	if err != nil {
 		return
 	}
@@ -157,6 +267,12 @@ func (e *Engine) matchPermission(perm Permission, req Request) bool {
 			return true
 		}
 		return perm.ScopeID == req.ClusterID
+	case "group":
+		// ScopeID is a cluster group ID; it covers the group's clusters
+		if req.ClusterID == "" {
+			return true
+		}
+		return e.groupHasCluster(perm.ScopeID, req.ClusterID)
 	case "namespace":
 		if req.ClusterID != "" && req.Namespace != "" {
 			// ScopeID format for namespace: "clusterID/namespace"
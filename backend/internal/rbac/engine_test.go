@@ -1,6 +1,7 @@
 package rbac
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -696,3 +697,47 @@ func TestEvaluateUserNotInCache(t *testing.T) {
 		}
 	}()
 }
+
+func TestWarmPreloadsPermissions(t *testing.T) {
+	e := newTestEngine()
+	loads := 0
+	e.load = func(ctx context.Context, userID string) ([]Permission, error) {
+		loads++
+		return []Permission{{Resource: "pods", Action: "read", ScopeType: "global"}}, nil
+	}
+
+	if err := e.Warm(context.Background(), "warm-user"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	allowed, err := e.Evaluate(context.Background(), Request{UserID: "warm-user", Action: "read", Resource: "pods"})
+	if err != nil || !allowed {
+		t.Fatalf("expected warmed permissions to allow the request: allowed=%v err=%v", allowed, err)
+	}
+	if loads != 1 {
+		t.Errorf("expected Evaluate to use the warmed cache, got %d loads", loads)
+	}
+
+	// Warm replaces a cached entry, e.g. after roles changed at login.
+	if err := e.Warm(context.Background(), "warm-user"); err != nil || loads != 2 {
+		t.Errorf("expected Warm to reload, loads=%d err=%v", loads, err)
+	}
+}
+
+func TestWarmDoesNotCacheAcrossInvalidation(t *testing.T) {
+	e := newTestEngine()
+	e.load = func(ctx context.Context, userID string) ([]Permission, error) {
+		// A role change lands while the stale permissions are being read.
+		e.InvalidateCache(userID)
+		return []Permission{{Resource: "*", Action: "*", ScopeType: "global"}}, nil
+	}
+
+	if err := e.Warm(context.Background(), "racing-user"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e.mu.RLock()
+	_, cached := e.cache["racing-user"]
+	e.mu.RUnlock()
+	if cached {
+		t.Error("permissions loaded across an invalidation must not be cached")
+	}
+}
@@ -0,0 +1,122 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// cachedGroups is a snapshot of cluster group membership.
+type cachedGroups struct {
+	members   map[string]map[string]bool // group ID -> cluster IDs
+	expiresAt time.Time
+}
+
+func hasGroupScope(perms []Permission) bool {
+	for _, p := range perms {
+		if p.ScopeType == "group" {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureClusterGroups loads cluster group membership unless a fresh snapshot
+// is cached. Like permissions, a load that overlaps an invalidation is not
+// reused by later evaluations.
+func (e *Engine) ensureClusterGroups(ctx context.Context) error {
+	e.mu.RLock()
+	cached, epoch := e.groups, e.epoch
+	e.mu.RUnlock()
+	if cached != nil && time.Now().Before(cached.expiresAt) {
+		return nil
+	}
+
+	load := e.loadGroups
+	if load == nil {
+		load = e.loadClusterGroups
+	}
+	members, err := load(ctx)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(e.ttl)
+	e.mu.Lock()
+	if e.epoch != epoch {
+		// Raced with an invalidation: good for this evaluation only.
+		expiresAt = time.Time{}
+	}
+	e.groups = &cachedGroups{members: members, expiresAt: expiresAt}
+	e.mu.Unlock()
+	return nil
+}
+
+// groupHasCluster reports whether the cluster belongs to the group in the
+// cached membership snapshot.
+func (e *Engine) groupHasCluster(groupID, clusterID string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.groups == nil {
+		return false
+	}
+	return e.groups.members[groupID][clusterID]
+}
+
+func (e *Engine) loadClusterGroups(ctx context.Context) (map[string]map[string]bool, error) {
+	rows, err := e.pool.Query(ctx, `SELECT group_id::text, cluster_id::text FROM cluster_group_members`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cluster groups: %w", err)
+	}
+	defer rows.Close()
+
+	members := make(map[string]map[string]bool)
+	for rows.Next() {
+		var groupID, clusterID string
+		if err := rows.Scan(&groupID, &clusterID); err != nil {
+			return nil, fmt.Errorf("failed to scan cluster group member: %w", err)
+		}
+		if members[groupID] == nil {
+			members[groupID] = make(map[string]bool)
+		}
+		members[groupID][clusterID] = true
+	}
+	return members, rows.Err()
+}
+
+// InvalidateClusterGroups drops the cached group membership along with every
+// cached permission set, since service account permissions are narrowed
+// using membership when they are loaded. Call it whenever a group's clusters
+// change or a group is deleted.
+func (e *Engine) InvalidateClusterGroups() {
+	e.mu.Lock()
+	e.groups = nil
+	e.cache = make(map[string]*cachedPermissions)
+	e.epoch++
+	e.mu.Unlock()
+}
+
+// expandGroupPermissions adds a cluster-scoped copy of each group-scoped
+// permission for every cluster currently in the group, for clients that
+// only understand cluster and namespace scopes.
+func (e *Engine) expandGroupPermissions(ctx context.Context, perms []Permission) ([]Permission, error) {
+	if !hasGroupScope(perms) {
+		return perms, nil
+	}
+	if err := e.ensureClusterGroups(ctx); err != nil {
+		return nil, err
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	expanded := append([]Permission(nil), perms...)
+	for _, p := range perms {
+		if p.ScopeType != "group" || e.groups == nil {
+			continue
+		}
+		for clusterID := range e.groups.members[p.ScopeID] {
+			expanded = append(expanded, Permission{Resource: p.Resource, Action: p.Action, ScopeType: "cluster", ScopeID: clusterID})
+		}
+	}
+	return expanded, nil
+}
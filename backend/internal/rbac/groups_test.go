@@ -0,0 +1,114 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+)
+
+func groupLoader(members map[string]map[string]bool, calls *int) func(ctx context.Context) (map[string]map[string]bool, error) {
+	return func(ctx context.Context) (map[string]map[string]bool, error) {
+		*calls++
+		return members, nil
+	}
+}
+
+func TestEvaluateGroupScoped(t *testing.T) {
+	e := newTestEngine()
+	var calls int
+	e.loadGroups = groupLoader(map[string]map[string]bool{"prod": {"c1": true, "c2": true}}, &calls)
+	seedCache(e, "prod-admin", []Permission{
+		{Resource: "*", Action: "*", ScopeType: "group", ScopeID: "prod"},
+	})
+
+	tests := []struct {
+		name string
+		req  Request
+		want bool
+	}{
+		{"cluster in group", Request{ClusterID: "c1"}, true},
+		{"namespace in group cluster", Request{ClusterID: "c2", Namespace: "web"}, true},
+		{"cluster outside group", Request{ClusterID: "c3"}, false},
+		{"any cluster", Request{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.UserID = "prod-admin"
+			tt.req.Action = "delete"
+			tt.req.Resource = "pods"
+			allowed, err := e.Evaluate(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if allowed != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, allowed)
+			}
+		})
+	}
+	if calls != 1 {
+		t.Errorf("expected membership to be loaded once, got %d", calls)
+	}
+}
+
+func TestEvaluateSkipsGroupLoadWithoutGroupScopes(t *testing.T) {
+	e := newTestEngine()
+	var calls int
+	e.loadGroups = groupLoader(nil, &calls)
+	seedCache(e, "cluster-user", []Permission{
+		{Resource: "pods", Action: "read", ScopeType: "cluster", ScopeID: "c1"},
+	})
+
+	if _, err := e.Evaluate(context.Background(), Request{UserID: "cluster-user", Action: "read", Resource: "pods", ClusterID: "c1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("expected no membership load, got %d", calls)
+	}
+}
+
+func TestInvalidateClusterGroups(t *testing.T) {
+	e := newTestEngine()
+	members := map[string]map[string]bool{"prod": {"c1": true}}
+	var calls int
+	e.loadGroups = groupLoader(members, &calls)
+	perms := []Permission{{Resource: "pods", Action: "read", ScopeType: "group", ScopeID: "prod"}}
+	e.load = func(ctx context.Context, userID string) ([]Permission, error) { return perms, nil }
+
+	req := Request{UserID: "prod-user", Action: "read", Resource: "pods", ClusterID: "c2"}
+	if allowed, _ := e.Evaluate(context.Background(), req); allowed {
+		t.Fatal("expected c2 to be denied before it joins the group")
+	}
+
+	members["prod"]["c2"] = true
+	e.InvalidateClusterGroups()
+
+	e.mu.RLock()
+	cached := len(e.cache)
+	e.mu.RUnlock()
+	if cached != 0 {
+		t.Errorf("expected the permission cache to be cleared, %d entries left", cached)
+	}
+	if allowed, _ := e.Evaluate(context.Background(), req); !allowed {
+		t.Error("expected c2 to be allowed once it joins the group")
+	}
+	if calls != 2 {
+		t.Errorf("expected membership to be reloaded, got %d loads", calls)
+	}
+}
+
+func TestExpandGroupPermissions(t *testing.T) {
+	e := newTestEngine()
+	var calls int
+	e.loadGroups = groupLoader(map[string]map[string]bool{"prod": {"c1": true}}, &calls)
+
+	perms, err := e.expandGroupPermissions(context.Background(), []Permission{
+		{Resource: "pods", Action: "read", ScopeType: "global"},
+		{Resource: "pods", Action: "write", ScopeType: "group", ScopeID: "prod"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Permission{Resource: "pods", Action: "write", ScopeType: "cluster", ScopeID: "c1"}
+	if len(perms) != 3 || perms[2] != want {
+		t.Errorf("expected the group permission to be expanded to %+v, got %+v", want, perms)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 	"github.com/gorilla/mux"
 )
 
@@ -35,13 +36,14 @@ type permissionsEnvelope struct {
 func (h *Handlers) handleGetPermissions(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{"error": "unauthorized"})
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	perms, err := h.engine.getPermissions(r.Context(), claims.UserID)
+	if err == nil {
+		perms, err = h.engine.expandGroupPermissions(r.Context(), perms)
+	}
 	if err != nil {
 		log.Printf("WARNING: failed to load permissions for user %s: %v", claims.UserID, err)
 		perms = nil
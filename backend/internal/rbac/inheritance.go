@@ -0,0 +1,112 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// roleGraph maps a role ID to the IDs of its direct parent roles.
+type roleGraph map[string][]string
+
+// querier is the subset of pgxpool.Pool and pgx.Tx used to load the graph.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// loadRoleGraph reads every role-to-parent edge.
+func loadRoleGraph(ctx context.Context, q querier) (roleGraph, error) {
+	rows, err := q.Query(ctx, "SELECT role_id::text, parent_role_id::text FROM role_parents ORDER BY created_at")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load role parents: %w", err)
+	}
+	defer rows.Close()
+
+	g := make(roleGraph)
+	for rows.Next() {
+		var roleID, parentID string
+		if err := rows.Scan(&roleID, &parentID); err != nil {
+			return nil, fmt.Errorf("failed to scan role parent: %w", err)
+		}
+		g[roleID] = append(g[roleID], parentID)
+	}
+	return g, rows.Err()
+}
+
+// ancestors returns every role the given role inherits from, directly or
+// transitively, nearest first. The role itself is never included, even if
+// the graph contains a cycle.
+func (g roleGraph) ancestors(roleID string) []string {
+	seen := map[string]bool{roleID: true}
+	var result []string
+	queue := append([]string(nil), g[roleID]...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+		queue = append(queue, g[id]...)
+	}
+	return result
+}
+
+// wouldCycle reports whether making parentID a parent of roleID would create
+// an inheritance cycle, i.e. parentID is roleID or already inherits from it.
+func (g roleGraph) wouldCycle(roleID, parentID string) bool {
+	if roleID == parentID {
+		return true
+	}
+	for _, id := range g.ancestors(parentID) {
+		if id == roleID {
+			return true
+		}
+	}
+	return false
+}
+
+// applyInheritance fills in the parents and inherited permissions of each
+// role from the direct permissions of its ancestors. Permissions a role
+// already grants directly are not repeated as inherited.
+func applyInheritance(roles []roleResponse, g roleGraph) {
+	byID := make(map[string]*roleResponse, len(roles))
+	for i := range roles {
+		byID[roles[i].ID] = &roles[i]
+	}
+
+	for i := range roles {
+		role := &roles[i]
+		role.Parents = make([]roleRefResponse, 0, len(g[role.ID]))
+		for _, id := range g[role.ID] {
+			if parent, ok := byID[id]; ok {
+				role.Parents = append(role.Parents, roleRefResponse{ID: parent.ID, Name: parent.Name})
+			}
+		}
+
+		seen := make(map[Permission]bool)
+		for _, p := range role.Permissions {
+			seen[Permission{Resource: p.Resource, Action: p.Action, ScopeType: p.ScopeType, ScopeID: p.ScopeID}] = true
+		}
+		role.InheritedPermissions = make([]inheritedPermissionResponse, 0)
+		for _, id := range g.ancestors(role.ID) {
+			ancestor, ok := byID[id]
+			if !ok {
+				continue
+			}
+			for _, p := range ancestor.Permissions {
+				key := Permission{Resource: p.Resource, Action: p.Action, ScopeType: p.ScopeType, ScopeID: p.ScopeID}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				role.InheritedPermissions = append(role.InheritedPermissions, inheritedPermissionResponse{
+					rolePermissionResponse: p,
+					InheritedFrom:          ancestor.Name,
+				})
+			}
+		}
+	}
+}
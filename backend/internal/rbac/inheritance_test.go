@@ -0,0 +1,88 @@
+package rbac
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRoleGraphAncestors(t *testing.T) {
+	g := roleGraph{
+		"prod-admin": {"admin"},
+		"admin":      {"operator"},
+		"operator":   {"viewer"},
+	}
+
+	got := g.ancestors("prod-admin")
+	want := []string{"admin", "operator", "viewer"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if len(g.ancestors("viewer")) != 0 {
+		t.Error("expected root role to have no ancestors")
+	}
+}
+
+func TestRoleGraphAncestors_Cycle(t *testing.T) {
+	// Cycles are rejected on write, but resolution must still terminate.
+	g := roleGraph{"a": {"b"}, "b": {"a"}}
+	if got := g.ancestors("a"); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("expected [b], got %v", got)
+	}
+}
+
+func TestRoleGraphWouldCycle(t *testing.T) {
+	g := roleGraph{
+		"prod-admin": {"admin"},
+		"admin":      {"viewer"},
+	}
+
+	tests := []struct {
+		role, parent string
+		want         bool
+	}{
+		{"viewer", "prod-admin", true},
+		{"viewer", "admin", true},
+		{"admin", "admin", true},
+		{"prod-admin", "viewer", false},
+		{"ops", "prod-admin", false},
+	}
+	for _, tt := range tests {
+		if got := g.wouldCycle(tt.role, tt.parent); got != tt.want {
+			t.Errorf("wouldCycle(%s, %s) = %v, want %v", tt.role, tt.parent, got, tt.want)
+		}
+	}
+}
+
+func TestApplyInheritance(t *testing.T) {
+	readPods := rolePermissionResponse{ID: "p1", Resource: "pods", Action: "read", ScopeType: "global"}
+	writePods := rolePermissionResponse{ID: "p2", Resource: "pods", Action: "write", ScopeType: "global"}
+	roles := []roleResponse{
+		{ID: "1", Name: "viewer", Permissions: []rolePermissionResponse{readPods}},
+		{ID: "2", Name: "operator", Permissions: []rolePermissionResponse{writePods}},
+		{ID: "3", Name: "prod-operator", Permissions: []rolePermissionResponse{{ID: "p3", Resource: "pods", Action: "read", ScopeType: "global"}}},
+	}
+	g := roleGraph{"2": {"1"}, "3": {"2"}}
+
+	applyInheritance(roles, g)
+
+	if len(roles[0].Parents) != 0 || len(roles[0].InheritedPermissions) != 0 {
+		t.Errorf("expected viewer to inherit nothing, got %+v", roles[0])
+	}
+
+	op := roles[1]
+	if len(op.Parents) != 1 || op.Parents[0].Name != "viewer" {
+		t.Errorf("expected operator parent viewer, got %+v", op.Parents)
+	}
+	if len(op.InheritedPermissions) != 1 || op.InheritedPermissions[0].InheritedFrom != "viewer" {
+		t.Errorf("expected operator to inherit pods:read from viewer, got %+v", op.InheritedPermissions)
+	}
+
+	// prod-operator grants pods:read directly, so only pods:write is inherited.
+	prod := roles[2]
+	if len(prod.InheritedPermissions) != 1 {
+		t.Fatalf("expected one inherited permission, got %+v", prod.InheritedPermissions)
+	}
+	if inh := prod.InheritedPermissions[0]; inh.Action != "write" || inh.InheritedFrom != "operator" {
+		t.Errorf("unexpected inherited permission %+v", inh)
+	}
+}
@@ -1,10 +1,10 @@
 package rbac
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 func RBACMiddleware(engine *Engine, resource, action string) func(http.Handler) http.Handler {
@@ -39,7 +39,5 @@ func RBACMiddleware(engine *Engine, resource, action string) func(http.Handler)
 }
 
 func writeError(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	httputil.WriteError(w, status, message)
 }
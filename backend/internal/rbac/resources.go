@@ -0,0 +1,43 @@
+package rbac
+
+// resourceCategories maps lowercase plural Kubernetes resources to their
+// permission category. Anything not listed falls under "clusters".
+var resourceCategories = map[string]string{
+	"pods":                     "apps",
+	"deployments":              "apps",
+	"statefulsets":             "apps",
+	"daemonsets":               "apps",
+	"replicasets":              "apps",
+	"services":                 "apps",
+	"endpoints":                "apps",
+	"ingresses":                "apps",
+	"configmaps":               "apps",
+	"secrets":                  "apps",
+	"persistentvolumeclaims":   "apps",
+	"horizontalpodautoscalers": "apps",
+	"serviceaccounts":          "apps",
+	"networkpolicies":          "apps",
+	"jobs":                     "jobs",
+	"cronjobs":                 "jobs",
+}
+
+// ResourceCategory returns the permission category for a Kubernetes resource
+// (lowercase plural, e.g. "deployments"). Roles are granted coarse categories
+// ("apps", "jobs", "clusters") rather than individual resource types, so
+// checks on Kubernetes objects must use the category as Request.Resource.
+func ResourceCategory(resource string) string {
+	if category, ok := resourceCategories[resource]; ok {
+		return category
+	}
+	return "clusters"
+}
+
+// QualifiedResource names a Kubernetes resource for Request.K8sResource:
+// the lowercase plural resource followed by its API group, if any, as in
+// "deployments.apps". The core group is empty ("pods").
+func QualifiedResource(group, resource string) string {
+	if group == "" {
+		return resource
+	}
+	return resource + "." + group
+}
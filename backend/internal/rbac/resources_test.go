@@ -0,0 +1,27 @@
+package rbac
+
+import "testing"
+
+func TestResourceCategory(t *testing.T) {
+	tests := []struct {
+		resource string
+		expected string
+	}{
+		{"pods", "apps"},
+		{"deployments", "apps"},
+		{"configmaps", "apps"},
+		{"jobs", "jobs"},
+		{"cronjobs", "jobs"},
+		{"nodes", "clusters"},
+		{"namespaces", "clusters"},
+		{"customwidgets", "clusters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			if got := ResourceCategory(tt.resource); got != tt.expected {
+				t.Errorf("ResourceCategory(%q) = %q, want %q", tt.resource, got, tt.expected)
+			}
+		})
+	}
+}
@@ -35,6 +35,8 @@ func (h *RoleHandlers) RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/api/roles/{id}/permissions", h.handleListRolePermissions).Methods("GET")
 	r.HandleFunc("/api/roles/{id}/permissions", h.handleAddRolePermission).Methods("POST")
 	r.HandleFunc("/api/roles/{id}/permissions/{permId}", h.handleRemoveRolePermission).Methods("DELETE")
+	r.HandleFunc("/api/roles/{id}/parents", h.handleAddRoleParent).Methods("POST")
+	r.HandleFunc("/api/roles/{id}/parents/{parentId}", h.handleRemoveRoleParent).Methods("DELETE")
 }
 
 // --- Response types ---
@@ -47,11 +49,25 @@ type rolePermissionResponse struct {
 	ScopeID   string `json:"scope_id"`
 }
 
+// inheritedPermissionResponse is a permission a role receives from an
+// ancestor role rather than granting it directly.
+type inheritedPermissionResponse struct {
+	rolePermissionResponse
+	InheritedFrom string `json:"inherited_from"`
+}
+
+type roleRefResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
 type roleResponse struct {
-	ID          string                   `json:"id"`
-	Name        string                   `json:"name"`
-	Description string                   `json:"description"`
-	Permissions []rolePermissionResponse `json:"permissions"`
+	ID                   string                        `json:"id"`
+	Name                 string                        `json:"name"`
+	Description          string                        `json:"description"`
+	Permissions          []rolePermissionResponse      `json:"permissions"`
+	Parents              []roleRefResponse             `json:"parents"`
+	InheritedPermissions []inheritedPermissionResponse `json:"inherited_permissions"`
 }
 
 type assignmentResponse struct {
@@ -93,7 +109,8 @@ func (h *RoleHandlers) requirePermission(w http.ResponseWriter, r *http.Request,
 
 // --- Handlers ---
 
-// handleListRoles returns all roles with their associated permissions.
+// handleListRoles returns all roles with their direct permissions, their
+// parent roles, and the permissions inherited through those parents.
 func (h *RoleHandlers) handleListRoles(w http.ResponseWriter, r *http.Request) {
 	if !h.requirePermission(w, r, "roles", "read") {
 		return
@@ -147,6 +164,14 @@ func (h *RoleHandlers) handleListRoles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	graph, err := loadRoleGraph(r.Context(), h.pool)
+	if err != nil {
+		log.Printf("ERROR: %v", err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to list roles")
+		return
+	}
+	applyInheritance(roles, graph)
+
 	httputil.WriteJSON(w, http.StatusOK, roles)
 }
 
@@ -502,3 +527,125 @@ func (h *RoleHandlers) handleRemoveRolePermission(w http.ResponseWriter, r *http
 
 	httputil.WriteJSON(w, http.StatusOK, map[string]string{"message": "permission removed"})
 }
+
+// handleAddRoleParent makes a role inherit every permission of a parent role.
+// The parent may be given by ID or name. Links that would create an
+// inheritance cycle are rejected.
+func (h *RoleHandlers) handleAddRoleParent(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePermission(w, r, "roles", "write") {
+		return
+	}
+
+	roleID := mux.Vars(r)["id"]
+
+	var req struct {
+		ParentID   string `json:"parent_id"`
+		ParentName string `json:"parent_name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.ParentID == "" && req.ParentName == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "parent_id or parent_name is required")
+		return
+	}
+	if req.ParentID == roleID {
+		httputil.WriteError(w, http.StatusBadRequest, "a role cannot inherit from itself")
+		return
+	}
+
+	ctx := r.Context()
+
+	var exists string
+	if err := h.pool.QueryRow(ctx, "SELECT id FROM roles WHERE id = $1", roleID).Scan(&exists); err != nil {
+		httputil.WriteError(w, http.StatusNotFound, "role not found")
+		return
+	}
+
+	parentID := req.ParentID
+	var err error
+	if parentID != "" {
+		err = h.pool.QueryRow(ctx, "SELECT id::text FROM roles WHERE id = $1", parentID).Scan(&parentID)
+	} else {
+		err = h.pool.QueryRow(ctx, "SELECT id::text FROM roles WHERE name = $1", req.ParentName).Scan(&parentID)
+	}
+	if err != nil {
+		httputil.WriteError(w, http.StatusNotFound, "parent role not found")
+		return
+	}
+
+	tx, err := h.pool.Begin(ctx)
+	if err != nil {
+		log.Printf("ERROR: failed to begin transaction: %v", err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to add parent role")
+		return
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck // no-op after commit
+
+	// Serialize graph changes so two concurrent links cannot form a cycle
+	// that neither check would see on its own.
+	if _, err := tx.Exec(ctx, "LOCK TABLE role_parents IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		log.Printf("ERROR: failed to lock role_parents: %v", err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to add parent role")
+		return
+	}
+
+	graph, err := loadRoleGraph(ctx, tx)
+	if err != nil {
+		log.Printf("ERROR: %v", err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to add parent role")
+		return
+	}
+	if graph.wouldCycle(roleID, parentID) {
+		httputil.WriteError(w, http.StatusConflict, "parent role already inherits from this role")
+		return
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO role_parents (role_id, parent_role_id) VALUES ($1, $2)
+		 ON CONFLICT DO NOTHING`,
+		roleID, parentID,
+	); err != nil {
+		log.Printf("ERROR: failed to add parent role: %v", err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to add parent role")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("ERROR: failed to commit parent role: %v", err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to add parent role")
+		return
+	}
+
+	h.engine.InvalidateUsersWithRole(ctx, roleID)
+
+	httputil.WriteJSON(w, http.StatusCreated, map[string]string{"parent_id": parentID, "message": "parent role added"})
+}
+
+// handleRemoveRoleParent stops a role from inheriting a parent role.
+func (h *RoleHandlers) handleRemoveRoleParent(w http.ResponseWriter, r *http.Request) {
+	if !h.requirePermission(w, r, "roles", "write") {
+		return
+	}
+
+	vars := mux.Vars(r)
+	roleID := vars["id"]
+	parentID := vars["parentId"]
+
+	result, err := h.pool.Exec(r.Context(),
+		"DELETE FROM role_parents WHERE role_id = $1 AND parent_role_id = $2",
+		roleID, parentID)
+	if err != nil {
+		log.Printf("ERROR: failed to remove parent role: %v", err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to remove parent role")
+		return
+	}
+	if result.RowsAffected() == 0 {
+		httputil.WriteError(w, http.StatusNotFound, "parent role link not found")
+		return
+	}
+
+	h.engine.InvalidateUsersWithRole(r.Context(), roleID)
+
+	httputil.WriteJSON(w, http.StatusOK, map[string]string{"message": "parent role removed"})
+}
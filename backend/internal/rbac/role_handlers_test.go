@@ -298,6 +298,69 @@ func TestHandleRemoveRolePermission_ReachesDB(t *testing.T) {
 	}
 }
 
+// --- Role Parents ---
+
+func TestHandleAddRoleParent_Validation(t *testing.T) {
+	e := newTestEngine()
+	h := NewRoleHandlers(nil, e)
+	ctx := adminCtx(e)
+
+	tests := []struct {
+		name string
+		body map[string]string
+	}{
+		{"missing parent", map[string]string{}},
+		{"self parent", map[string]string{"parent_id": "role-id"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.body)
+			req := httptest.NewRequest("POST", "/api/roles/role-id/parents", bytes.NewBuffer(body)).WithContext(ctx)
+			req = mux.SetURLVars(req, map[string]string{"id": "role-id"})
+			rec := httptest.NewRecorder()
+
+			h.handleAddRoleParent(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected 400 for %s, got %d", tt.name, rec.Code)
+			}
+		})
+	}
+}
+
+func TestHandleAddRoleParent_Unauthorized(t *testing.T) {
+	e := newTestEngine()
+	h := NewRoleHandlers(nil, e)
+
+	body, _ := json.Marshal(map[string]string{"parent_name": "admin"})
+	req := httptest.NewRequest("POST", "/api/roles/role-id/parents", bytes.NewBuffer(body))
+	req = mux.SetURLVars(req, map[string]string{"id": "role-id"})
+	rec := httptest.NewRecorder()
+
+	h.handleAddRoleParent(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestHandleRemoveRoleParent_ReachesDB(t *testing.T) {
+	e := newTestEngine()
+	h := NewRoleHandlers(nil, e)
+	ctx := adminCtx(e)
+
+	req := httptest.NewRequest("DELETE", "/api/roles/role-id/parents/parent-id", nil).WithContext(ctx)
+	req = mux.SetURLVars(req, map[string]string{"id": "role-id", "parentId": "parent-id"})
+
+	code, panicked := callRoleHandlerSafe(func(rec *httptest.ResponseRecorder) {
+		h.handleRemoveRoleParent(rec, req)
+	})
+	if !panicked && (code == http.StatusUnauthorized || code == http.StatusForbidden) {
+		t.Fatal("expected to pass auth check (should reach DB layer)")
+	}
+}
+
 // --- Route Registration ---
 
 func TestRoleHandlersRegisterRoutes(t *testing.T) {
@@ -320,6 +383,8 @@ func TestRoleHandlersRegisterRoutes(t *testing.T) {
 		{"GET", "/api/roles/assignments"},
 		{"POST", "/api/roles/assign"},
 		{"DELETE", "/api/roles/revoke/123"},
+		{"POST", "/api/roles/123/parents"},
+		{"DELETE", "/api/roles/123/parents/456"},
 	}
 
 	for _, rt := range routes {
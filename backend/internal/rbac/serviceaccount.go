@@ -0,0 +1,104 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// loadServiceAccountPermissions returns the effective permissions of a
+// service account token: those of its role and the role's ancestors, each
+// narrowed to the token's cluster/namespace scope. Revoked and expired tokens
+// have no permissions. A group-scoped permission on a cluster-scoped token
+// becomes a cluster permission if the token's cluster is in the group.
+func (e *Engine) loadServiceAccountPermissions(ctx context.Context, serviceAccountID string) ([]Permission, error) {
+	query := `
+		WITH RECURSIVE sa AS (
+			SELECT role_id, COALESCE(cluster_id::text, '') AS cluster_id, COALESCE(namespace, '') AS namespace
+			FROM service_account_tokens
+			WHERE id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		), effective_roles(role_id) AS (
+			SELECT role_id FROM sa
+			UNION
+			SELECT p.parent_role_id
+			FROM role_parents p
+			JOIN effective_roles er ON p.role_id = er.role_id
+		)
+		SELECT DISTINCT rp.resource, rp.action, rp.scope_type, COALESCE(rp.scope_id, ''), sa.cluster_id, sa.namespace,
+			EXISTS (
+				SELECT 1 FROM cluster_group_members m
+				WHERE rp.scope_type = 'group' AND m.group_id::text = rp.scope_id AND m.cluster_id::text = sa.cluster_id
+			)
+		FROM effective_roles er
+		JOIN role_permissions rp ON er.role_id = rp.role_id
+		CROSS JOIN sa
+	`
+
+	rows, err := e.pool.Query(ctx, query, serviceAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load service account permissions: %w", err)
+	}
+	defer rows.Close()
+
+	var perms []Permission
+	seen := make(map[Permission]bool)
+	for rows.Next() {
+		var p Permission
+		var clusterID, namespace string
+		var inGroup bool
+		if err := rows.Scan(&p.Resource, &p.Action, &p.ScopeType, &p.ScopeID, &clusterID, &namespace, &inGroup); err != nil {
+			return nil, fmt.Errorf("failed to scan permission: %w", err)
+		}
+		if p.ScopeType == "group" && clusterID != "" {
+			if !inGroup {
+				continue
+			}
+			p = Permission{Resource: p.Resource, Action: p.Action, ScopeType: "cluster", ScopeID: clusterID}
+		}
+		narrowed, ok := narrowPermission(p, clusterID, namespace)
+		if !ok || seen[narrowed] {
+			continue
+		}
+		seen[narrowed] = true
+		perms = append(perms, narrowed)
+	}
+
+	return perms, rows.Err()
+}
+
+// narrowPermission restricts a role permission to a token scope. An empty
+// clusterID leaves the permission unchanged; otherwise the result never
+// reaches beyond the cluster, or beyond clusterID/namespace when a namespace
+// is set. Permissions that do not overlap the scope are dropped.
+func narrowPermission(p Permission, clusterID, namespace string) (Permission, bool) {
+	if clusterID == "" {
+		return p, true
+	}
+
+	if namespace == "" {
+		switch p.ScopeType {
+		case "global":
+			return Permission{Resource: p.Resource, Action: p.Action, ScopeType: "cluster", ScopeID: clusterID}, true
+		case "cluster":
+			return p, p.ScopeID == clusterID
+		case "namespace":
+			if !strings.Contains(p.ScopeID, "/") {
+				// A bare namespace applies to every cluster; pin it to ours.
+				return Permission{Resource: p.Resource, Action: p.Action, ScopeType: "namespace", ScopeID: clusterID + "/" + p.ScopeID}, true
+			}
+			return p, strings.HasPrefix(p.ScopeID, clusterID+"/")
+		}
+		return Permission{}, false
+	}
+
+	scoped := Permission{Resource: p.Resource, Action: p.Action, ScopeType: "namespace", ScopeID: clusterID + "/" + namespace}
+	switch p.ScopeType {
+	case "global":
+		return scoped, true
+	case "cluster":
+		return scoped, p.ScopeID == clusterID
+	case "namespace":
+		return scoped, p.ScopeID == namespace || p.ScopeID == scoped.ScopeID
+	}
+	return Permission{}, false
+}
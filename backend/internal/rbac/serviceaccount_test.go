@@ -0,0 +1,56 @@
+package rbac
+
+import "testing"
+
+func TestNarrowPermission(t *testing.T) {
+	perm := func(scopeType, scopeID string) Permission {
+		return Permission{Resource: "pods", Action: "read", ScopeType: scopeType, ScopeID: scopeID}
+	}
+	tests := []struct {
+		name      string
+		perm      Permission
+		clusterID string
+		namespace string
+		want      Permission
+		wantOK    bool
+	}{
+		{"unscoped token keeps global", perm("global", ""), "", "", perm("global", ""), true},
+		{"global narrowed to cluster", perm("global", ""), "c1", "", perm("cluster", "c1"), true},
+		{"same cluster kept", perm("cluster", "c1"), "c1", "", perm("cluster", "c1"), true},
+		{"other cluster dropped", perm("cluster", "c2"), "c1", "", Permission{}, false},
+		{"bare namespace pinned to cluster", perm("namespace", "web"), "c1", "", perm("namespace", "c1/web"), true},
+		{"namespace in cluster kept", perm("namespace", "c1/web"), "c1", "", perm("namespace", "c1/web"), true},
+		{"namespace in other cluster dropped", perm("namespace", "c2/web"), "c1", "", Permission{}, false},
+		{"global narrowed to namespace", perm("global", ""), "c1", "web", perm("namespace", "c1/web"), true},
+		{"cluster narrowed to namespace", perm("cluster", "c1"), "c1", "web", perm("namespace", "c1/web"), true},
+		{"other cluster dropped for namespace", perm("cluster", "c2"), "c1", "web", Permission{}, false},
+		{"bare namespace matches", perm("namespace", "web"), "c1", "web", perm("namespace", "c1/web"), true},
+		{"other namespace dropped", perm("namespace", "c1/api"), "c1", "web", Permission{}, false},
+		{"unknown scope dropped", perm("project", "p1"), "c1", "", Permission{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := narrowPermission(tt.perm, tt.clusterID, tt.namespace)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("got %+v (ok=%v), want %+v (ok=%v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNarrowPermission_NeverWidens(t *testing.T) {
+	e := &Engine{}
+	narrowed, ok := narrowPermission(Permission{Resource: "*", Action: "*", ScopeType: "global"}, "c1", "web")
+	if !ok {
+		t.Fatal("expected global permission to survive narrowing")
+	}
+	if e.matchPermission(narrowed, Request{Resource: "pods", Action: "read", ClusterID: "c2", Namespace: "web"}) {
+		t.Error("narrowed permission must not match another cluster")
+	}
+	if e.matchPermission(narrowed, Request{Resource: "pods", Action: "read", ClusterID: "c1", Namespace: "api"}) {
+		t.Error("narrowed permission must not match another namespace")
+	}
+	if !e.matchPermission(narrowed, Request{Resource: "pods", Action: "read", ClusterID: "c1", Namespace: "web"}) {
+		t.Error("narrowed permission should match its own scope")
+	}
+}
@@ -0,0 +1,50 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// loadShareLinkPermissions returns the permissions of a share link: read on
+// its resource within its cluster or namespace. The link only carries them
+// while its creator can still read the same thing, so a share never outlives
+// the access it was minted from. Revoked and expired links, and links whose
+// creator was deleted, have no permissions.
+func (e *Engine) loadShareLinkPermissions(ctx context.Context, linkID string) ([]Permission, error) {
+	var createdBy, clusterID, namespace, resource string
+	err := e.pool.QueryRow(ctx, `
+		SELECT created_by::text, cluster_id::text, COALESCE(namespace, ''), resource
+		FROM share_links
+		WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW() AND created_by IS NOT NULL`,
+		linkID,
+	).Scan(&createdBy, &clusterID, &namespace, &resource)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load share link: %w", err)
+	}
+
+	perm, req := sharePermission(resource, clusterID, namespace)
+	req.UserID = createdBy
+	allowed, err := e.Evaluate(ctx, req)
+	if err != nil || !allowed {
+		return nil, err
+	}
+	return []Permission{perm}, nil
+}
+
+// sharePermission returns the permission a share link grants and the request
+// its creator must be allowed to make for the link to hold it. The link's
+// permission names the resource itself; the creator is checked against its
+// category, as roles are granted.
+func sharePermission(resource, clusterID, namespace string) (Permission, Request) {
+	req := Request{Action: "read", Resource: ResourceCategory(resource), ClusterID: clusterID, Namespace: namespace}
+	if namespace == "" {
+		return Permission{Resource: resource, Action: "read", ScopeType: "cluster", ScopeID: clusterID}, req
+	}
+	return Permission{Resource: resource, Action: "read", ScopeType: "namespace", ScopeID: clusterID + "/" + namespace}, req
+}
@@ -0,0 +1,63 @@
+package rbac
+
+import (
+	"context"
+	"testing"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+)
+
+func TestSharePermission_MatchesOnlyItsScope(t *testing.T) {
+	e := &Engine{}
+	tests := []struct {
+		name      string
+		namespace string
+		req       Request
+		want      bool
+	}{
+		{"cluster link, same resource", "", Request{Resource: "pods", Action: "read", ClusterID: "c1", Namespace: "web"}, true},
+		{"cluster link, other cluster", "", Request{Resource: "pods", Action: "read", ClusterID: "c2"}, false},
+		{"cluster link, other resource", "", Request{Resource: "secrets", Action: "read", ClusterID: "c1"}, false},
+		{"cluster link, write", "", Request{Resource: "pods", Action: "write", ClusterID: "c1"}, false},
+		{"namespace link, same namespace", "web", Request{Resource: "pods", Action: "read", ClusterID: "c1", Namespace: "web"}, true},
+		{"namespace link, other namespace", "web", Request{Resource: "pods", Action: "read", ClusterID: "c1", Namespace: "api"}, false},
+		{"namespace link, cluster-wide list", "web", Request{Resource: "pods", Action: "read", ClusterID: "c1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perm, _ := sharePermission("pods", "c1", tt.namespace)
+			if got := e.matchPermission(perm, tt.req); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSharePermission_CreatorRequest(t *testing.T) {
+	_, req := sharePermission("pods", "c1", "web")
+	want := Request{Action: "read", Resource: "apps", ClusterID: "c1", Namespace: "web"}
+	if req != want {
+		t.Errorf("expected %+v, got %+v", want, req)
+	}
+}
+
+func TestLoadAndCache_DoesNotCacheSharePrincipals(t *testing.T) {
+	e := NewEngine(nil)
+	e.load = func(ctx context.Context, userID string) ([]Permission, error) {
+		return []Permission{{Resource: "pods", Action: "read", ScopeType: "cluster", ScopeID: "c1"}}, nil
+	}
+
+	share := auth.ShareLinkPrincipalPrefix + "link-1"
+	if _, err := e.getPermissions(context.Background(), share); err != nil {
+		t.Fatalf("getPermissions: %v", err)
+	}
+	if _, err := e.getPermissions(context.Background(), "user-1"); err != nil {
+		t.Fatalf("getPermissions: %v", err)
+	}
+	if _, ok := e.cache[share]; ok {
+		t.Error("expected share principal permissions not to be cached")
+	}
+	if _, ok := e.cache["user-1"]; !ok {
+		t.Error("expected user permissions to be cached")
+	}
+}
@@ -0,0 +1,232 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+// RevealResource and RevealAction name the permission that exempts a user
+// from response redaction.
+const (
+	RevealResource = "secrets"
+	RevealAction   = "reveal"
+)
+
+// Middleware redacts the Kubernetes objects in JSON responses unless the
+// user holds secrets:reveal, on the request's cluster when it has one.
+// Kubernetes watch streams (?watch=true) are redacted event by event.
+// Responses that are not JSON (logs, event streams, downloads) pass through
+// untouched. It must run after the auth middleware, on a router whose
+// routes are already matched.
+func Middleware(authz rbac.Evaluator, redactor *Redactor) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.ClaimsFromContext(r.Context())
+			if !ok || redactor == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			reveal, err := authz.Evaluate(r.Context(), rbac.Request{
+				UserID:    claims.UserID,
+				Action:    RevealAction,
+				Resource:  RevealResource,
+				ClusterID: mux.Vars(r)["clusterID"],
+			})
+			if err != nil {
+				log.Printf("redact: RBAC evaluation failed for %s: %v", claims.UserID, err)
+			}
+			if reveal {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Ask for identity-encoded bodies so they can be inspected.
+			r.Header.Del("Accept-Encoding")
+			rw := &redactingWriter{ResponseWriter: w, redactor: redactor, watch: isWatch(r)}
+			next.ServeHTTP(rw, r)
+			rw.finish()
+		})
+	}
+}
+
+// RedactJSON redacts the Kubernetes objects in a JSON document: a single
+// object or list, or an array of them. Anything else, including invalid
+// JSON, is returned unchanged.
+func (r *Redactor) RedactJSON(body []byte) []byte {
+	if r == nil {
+		return body
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return body
+	}
+
+	found := false
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		found = r.redactIfObject(v)
+	case []interface{}:
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok && r.redactIfObject(m) {
+				found = true
+			}
+		}
+	}
+	if !found {
+		return body
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // keep "<redacted>" readable
+	if err := enc.Encode(doc); err != nil {
+		return body
+	}
+	return buf.Bytes()
+}
+
+// redactIfObject redacts obj if it is a Kubernetes object, which always
+// carries apiVersion and kind.
+func (r *Redactor) redactIfObject(obj map[string]interface{}) bool {
+	_, hasVersion := obj["apiVersion"].(string)
+	_, hasKind := obj["kind"].(string)
+	if !hasVersion || !hasKind {
+		return false
+	}
+	r.Redact(obj)
+	return true
+}
+
+// redactWatchEvent redacts the object of one watch event line.
+func (r *Redactor) redactWatchEvent(line []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var event map[string]interface{}
+	if err := dec.Decode(&event); err != nil {
+		return line
+	}
+	obj, ok := event["object"].(map[string]interface{})
+	if !ok || !r.redactIfObject(obj) {
+		return line
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(event); err != nil {
+		return line
+	}
+	return buf.Bytes()
+}
+
+// isWatch reports whether the request asks the API server for a watch
+// stream.
+func isWatch(r *http.Request) bool {
+	switch r.URL.Query().Get("watch") {
+	case "true", "1":
+		return true
+	}
+	return false
+}
+
+// redactingWriter holds back JSON responses so they can be redacted once the
+// handler is done. Watch streams are held back a line (one event) at a time.
+// Other responses are written through as they come.
+type redactingWriter struct {
+	http.ResponseWriter
+	redactor    *Redactor
+	watch       bool
+	status      int
+	wroteHeader bool
+	buffering   bool
+	streaming   bool
+	buf         bytes.Buffer
+}
+
+func (w *redactingWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+	if isJSON(w.Header().Get("Content-Type")) && w.Header().Get("Content-Encoding") == "" {
+		w.Header().Del("Content-Length")
+		if !w.watch {
+			w.buffering = true
+			return
+		}
+		w.streaming = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	switch {
+	case w.buffering:
+		return w.buf.Write(p)
+	case w.streaming:
+		w.buf.Write(p)
+		for {
+			i := bytes.IndexByte(w.buf.Bytes(), '\n')
+			if i < 0 {
+				return len(p), nil
+			}
+			line := w.buf.Next(i + 1)
+			if _, err := w.ResponseWriter.Write(w.redactor.redactWatchEvent(line)); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush keeps streaming responses (SSE, watches) working through the
+// wrapper. Buffered JSON is written when the handler returns.
+func (w *redactingWriter) Flush() {
+	if w.buffering {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w *redactingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish writes what is still held back, redacted.
+func (w *redactingWriter) finish() {
+	switch {
+	case w.buffering:
+		body := w.redactor.RedactJSON(w.buf.Bytes())
+		w.ResponseWriter.WriteHeader(w.status)
+		w.ResponseWriter.Write(body) //nolint:errcheck
+	case w.streaming && w.buf.Len() > 0:
+		w.ResponseWriter.Write(w.redactor.redactWatchEvent(w.buf.Bytes())) //nolint:errcheck
+	}
+}
+
+// isJSON reports whether a Content-Type is JSON ("application/json" or a
+// "+json" type).
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
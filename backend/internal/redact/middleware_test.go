@@ -0,0 +1,116 @@
+package redact
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+type fakeAuthz struct {
+	allow bool
+	got   []rbac.Request
+}
+
+func (f *fakeAuthz) Evaluate(ctx context.Context, req rbac.Request) (bool, error) {
+	f.got = append(f.got, req)
+	return f.allow, nil
+}
+
+const secretJSON = `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"db"},"data":{"password":"aHVudGVyMg=="}}`
+
+func redactedRouter(authz rbac.Evaluator) *mux.Router {
+	r := mux.NewRouter()
+	protected := r.PathPrefix("").Subrouter()
+	protected.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := auth.ContextWithClaims(r.Context(), &auth.Claims{UserID: "user-1"})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	protected.Use(Middleware(authz, NewRedactor(nil)))
+	protected.HandleFunc("/api/clusters/{clusterID}/resources/_/v1/secrets/db", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", "999")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(secretJSON)) //nolint:errcheck
+	})
+	protected.HandleFunc("/api/proxy/k8s/{cluster_id}/api/v1/secrets", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		// Events arrive split across writes, as a chunked stream delivers them.
+		w.Write([]byte(`{"type":"ADDED","object":` + secretJSON[:40])) //nolint:errcheck
+		w.(http.Flusher).Flush()
+		w.Write([]byte(secretJSON[40:] + "}\n{\"type\":\"BOOKMARK\"}\n")) //nolint:errcheck
+	})
+	protected.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(secretJSON)) //nolint:errcheck
+	})
+	return r
+}
+
+func get(r *mux.Router, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddleware_RedactsJSON(t *testing.T) {
+	authz := &fakeAuthz{}
+	rec := get(redactedRouter(authz), "/api/clusters/c1/resources/_/v1/secrets/db")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); strings.Contains(body, "aHVudGVyMg==") || !strings.Contains(body, Value) {
+		t.Errorf("expected the secret data to be redacted, got %s", body)
+	}
+	if rec.Header().Get("Content-Length") != "" {
+		t.Error("expected the stale Content-Length to be dropped")
+	}
+	want := rbac.Request{UserID: "user-1", Action: "reveal", Resource: "secrets", ClusterID: "c1"}
+	if len(authz.got) != 1 || authz.got[0] != want {
+		t.Errorf("expected a secrets:reveal check on the cluster, got %+v", authz.got)
+	}
+}
+
+func TestMiddleware_RevealPermission(t *testing.T) {
+	rec := get(redactedRouter(&fakeAuthz{allow: true}), "/api/clusters/c1/resources/_/v1/secrets/db")
+	if rec.Body.String() != secretJSON {
+		t.Errorf("expected the response untouched for secrets:reveal, got %s", rec.Body.String())
+	}
+}
+
+func TestMiddleware_PassesOtherContent(t *testing.T) {
+	rec := get(redactedRouter(&fakeAuthz{}), "/logs")
+	if rec.Body.String() != secretJSON {
+		t.Errorf("expected non-JSON responses to pass through, got %s", rec.Body.String())
+	}
+}
+
+func TestMiddleware_RedactsWatchStreams(t *testing.T) {
+	rec := get(redactedRouter(&fakeAuthz{}), "/api/proxy/k8s/c1/api/v1/secrets?watch=true")
+
+	lines := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected two events, got %q", rec.Body.String())
+	}
+	if strings.Contains(lines[0], "aHVudGVyMg==") || !strings.Contains(lines[0], `"type":"ADDED"`) {
+		t.Errorf("expected the event object to be redacted, got %s", lines[0])
+	}
+	if lines[1] != `{"type":"BOOKMARK"}` {
+		t.Errorf("expected other events to pass through, got %s", lines[1])
+	}
+	if !rec.Flushed {
+		t.Error("expected the stream to be flushed through")
+	}
+}
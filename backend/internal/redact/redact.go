@@ -0,0 +1,146 @@
+// Package redact masks secret material in Kubernetes objects before they
+// leave the dashboard, both in API responses and in AI tool results.
+package redact
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Value replaces redacted values.
+const Value = "<redacted>"
+
+// lastAppliedAnnotation holds the full manifest last applied with kubectl,
+// including a Secret's data, so it is redacted like the object itself.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// DefaultSensitiveKeys are the key substrings whose ConfigMap data and
+// annotation values are redacted. Matching is case-insensitive.
+var DefaultSensitiveKeys = []string{
+	"password", "passwd", "secret", "token", "credential",
+	"apikey", "api_key", "api-key", "private_key", "private-key",
+	"access_key", "access-key",
+}
+
+// Redactor strips secret material from Kubernetes objects. Secret data is
+// always redacted; ConfigMap data and annotations are redacted when their
+// key looks sensitive. A nil Redactor leaves objects untouched.
+type Redactor struct {
+	keys []string
+}
+
+// NewRedactor creates a redactor matching DefaultSensitiveKeys plus extraKeys.
+func NewRedactor(extraKeys []string) *Redactor {
+	keys := make([]string, 0, len(DefaultSensitiveKeys)+len(extraKeys))
+	keys = append(keys, DefaultSensitiveKeys...)
+	for _, k := range extraKeys {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return &Redactor{keys: keys}
+}
+
+// Redact redacts obj in place. List objects have each of their items
+// redacted.
+func (r *Redactor) Redact(obj map[string]interface{}) {
+	if r == nil || obj == nil {
+		return
+	}
+	kind, _ := obj["kind"].(string)
+	r.redactObject(obj, kind)
+}
+
+// redactObject redacts obj as an object of the given kind. Items of lists
+// returned by the API server carry no kind of their own, so they are
+// redacted as the kind the list's kind names ("SecretList" holds Secrets).
+func (r *Redactor) redactObject(obj map[string]interface{}, kind string) {
+	if items, ok := obj["items"].([]interface{}); ok {
+		itemKind := strings.TrimSuffix(kind, "List")
+		for _, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if k, ok := m["kind"].(string); ok && k != "" {
+				r.redactObject(m, k)
+			} else {
+				r.redactObject(m, itemKind)
+			}
+		}
+	}
+
+	switch kind {
+	case "Secret":
+		redactAll(obj, "data")
+		redactAll(obj, "stringData")
+	case "ConfigMap":
+		r.redactSensitive(obj, "data")
+		r.redactSensitive(obj, "binaryData")
+	}
+
+	metadata, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	r.redactSensitive(metadata, "annotations")
+	annotations, ok := metadata["annotations"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if applied, ok := annotations[lastAppliedAnnotation].(string); ok && (kind == "Secret" || kind == "ConfigMap") {
+		annotations[lastAppliedAnnotation] = r.redactManifest(applied)
+	}
+}
+
+// isSensitive reports whether key contains one of the sensitive substrings.
+func (r *Redactor) isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactSensitive redacts the values of obj[field] whose key is sensitive.
+func (r *Redactor) redactSensitive(obj map[string]interface{}, field string) {
+	values, ok := obj[field].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for k := range values {
+		if r.isSensitive(k) {
+			values[k] = Value
+		}
+	}
+}
+
+// redactManifest redacts a JSON manifest stored in an annotation, dropping it
+// entirely when it cannot be parsed.
+func (r *Redactor) redactManifest(manifest string) string {
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(manifest), &obj); err != nil {
+		return Value
+	}
+	r.Redact(obj)
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(obj); err != nil {
+		return Value
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// redactAll redacts every value of obj[field].
+func redactAll(obj map[string]interface{}, field string) {
+	values, ok := obj[field].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for k := range values {
+		values[k] = Value
+	}
+}
@@ -0,0 +1,99 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+)
+
+func secretObject() map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":      "db-credentials",
+			"namespace": "default",
+			"annotations": map[string]interface{}{
+				lastAppliedAnnotation: `{"apiVersion":"v1","kind":"Secret","data":{"password":"aHVudGVyMg=="},"stringData":{"token":"plain-token-value"}}`,
+				"owner":               "team-a",
+			},
+		},
+		"type":       "Opaque",
+		"data":       map[string]interface{}{"password": "aHVudGVyMg==", "username": "YWRtaW4="},
+		"stringData": map[string]interface{}{"token": "plain-token-value"},
+	}
+}
+
+func TestRedactor_ListItems(t *testing.T) {
+	list := map[string]interface{}{
+		"kind":  "SecretList",
+		"items": []interface{}{secretObject(), secretObject()},
+	}
+	NewRedactor(nil).Redact(list)
+
+	for _, item := range list["items"].([]interface{}) {
+		data := item.(map[string]interface{})["data"].(map[string]interface{})
+		if data["password"] != Value {
+			t.Errorf("expected list item data to be redacted, got %v", data)
+		}
+	}
+}
+
+func TestRedactor_ListItemsWithoutKind(t *testing.T) {
+	// The API server leaves kind off the items of a list.
+	item := secretObject()
+	delete(item, "kind")
+	list := map[string]interface{}{"kind": "SecretList", "items": []interface{}{item}}
+	NewRedactor(nil).Redact(list)
+
+	if data := item["data"].(map[string]interface{}); data["username"] != Value {
+		t.Errorf("expected item data to be redacted, got %v", data)
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	r := NewRedactor([]string{"dsn"})
+	tests := []struct {
+		name   string
+		body   string
+		leaked []string
+		kept   []string
+	}{
+		{"secret", `{"apiVersion":"v1","kind":"Secret","metadata":{"name":"db"},"data":{"password":"aHVudGVyMg=="}}`,
+			[]string{"aHVudGVyMg=="}, []string{`"name":"db"`, `"password":"<redacted>"`}},
+		{"raw list", `{"apiVersion":"v1","kind":"SecretList","items":[{"metadata":{"name":"a"},"data":{"k":"dmFsdWU="}}]}`,
+			[]string{"dmFsdWU="}, []string{`"name":"a"`}},
+		{"array of objects", `[{"apiVersion":"v1","kind":"ConfigMap","metadata":{"annotations":{"app.io/token":"t0k"}},"data":{"DATABASE_DSN":"postgres://x","LEVEL":"debug"}}]`,
+			[]string{"t0k", "postgres://x"}, []string{`"LEVEL":"debug"`}},
+		{"large numbers survive", `{"apiVersion":"v1","kind":"Secret","metadata":{"generation":9007199254740993}}`,
+			nil, []string{"9007199254740993"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := string(r.RedactJSON([]byte(tt.body)))
+			for _, s := range tt.leaked {
+				if strings.Contains(out, s) {
+					t.Errorf("expected %q to be redacted:\n%s", s, out)
+				}
+			}
+			for _, s := range tt.kept {
+				if !strings.Contains(out, s) {
+					t.Errorf("expected %q to be kept:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactJSON_LeavesOtherDocumentsAlone(t *testing.T) {
+	r := NewRedactor(nil)
+	for _, body := range []string{
+		`{"kind":"Secret","data":{"password":"x"}}`,
+		`{"id":"u1","metadata":{"annotations":{"token":"x"}}}`,
+		`not json`,
+		`[1, 2]`,
+	} {
+		if out := string(r.RedactJSON([]byte(body))); out != body {
+			t.Errorf("expected %q to be unchanged, got %q", body, out)
+		}
+	}
+}
@@ -11,7 +11,6 @@ import (
 	"net/url"
 	"time"
 
-	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
 
@@ -24,16 +23,24 @@ import (
 // OidcConfig represents the OIDC configuration returned to and received from
 // the frontend. The client_secret is never exposed via the GET endpoint.
 type OidcConfig struct {
-	Enabled      bool     `json:"enabled"`
-	ProviderType string   `json:"provider_type"`
-	ProviderName string   `json:"provider_name"`
-	IssuerURL    string   `json:"issuer_url"`
-	ClientID     string   `json:"client_id"`
-	ClientSecret string   `json:"client_secret,omitempty"`
-	RedirectURL  string   `json:"redirect_url"`
-	GroupsClaim  string   `json:"groups_claim"`
-	ExtraScopes  []string `json:"extra_scopes,omitempty"`
-	TenantID     string   `json:"tenant_id,omitempty"`
+	Enabled      bool   `json:"enabled"`
+	ProviderType string `json:"provider_type"`
+	ProviderName string `json:"provider_name"`
+	IssuerURL    string `json:"issuer_url"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	RedirectURL  string `json:"redirect_url"`
+	GroupsClaim  string `json:"groups_claim"`
+	// Scopes replaces the default "profile email" requested after openid;
+	// ExtraScopes are requested in addition.
+	Scopes      []string `json:"scopes,omitempty"`
+	ExtraScopes []string `json:"extra_scopes,omitempty"`
+	// SubjectClaim, EmailClaim and NameClaim name the ID token claims a user
+	// is read from, for providers that don't use sub, email and name.
+	SubjectClaim string `json:"subject_claim,omitempty"`
+	EmailClaim   string `json:"email_claim,omitempty"`
+	NameClaim    string `json:"name_claim,omitempty"`
+	TenantID     string `json:"tenant_id,omitempty"`
 }
 
 // Handlers provides HTTP handlers for application settings.
@@ -52,6 +59,8 @@ func NewHandlers(pool *pgxpool.Pool, cfg *config.Config, rbacWriteGuard mux.Midd
 // RegisterRoutes wires the settings endpoints onto the provided router.
 func (h *Handlers) RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/api/settings/oidc", h.GetOIDC).Methods("GET")
+	r.HandleFunc("/api/settings/mfa", h.GetMFAPolicy).Methods("GET")
+	r.HandleFunc("/api/settings/sessions", h.GetSessionPolicy).Methods("GET")
 
 	// Write endpoints require settings:write RBAC
 	writeRoutes := r.PathPrefix("").Subrouter()
@@ -60,6 +69,8 @@ func (h *Handlers) RegisterRoutes(r *mux.Router) {
 	}
 	writeRoutes.HandleFunc("/api/settings/oidc", h.UpdateOIDC).Methods("PUT")
 	writeRoutes.HandleFunc("/api/settings/oidc/test", h.TestOIDC).Methods("POST")
+	writeRoutes.HandleFunc("/api/settings/mfa", h.UpdateMFAPolicy).Methods("PUT")
+	writeRoutes.HandleFunc("/api/settings/sessions", h.UpdateSessionPolicy).Methods("PUT")
 }
 
 // RegisterPublicRoutes registers settings routes that don't require authentication.
@@ -103,8 +114,10 @@ func (h *Handlers) GetOIDC(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateOIDC handles PUT /api/settings/oidc.
-// It upserts the provided OIDC configuration into the settings table and
-// returns the saved value.
+// It validates the provided OIDC configuration, performing provider discovery
+// (and a client credential check with ?verify_client=true), then upserts it
+// into the settings table and returns the saved value. A configuration that
+// fails discovery or the credential check is not saved unless ?force=true.
 func (h *Handlers) UpdateOIDC(w http.ResponseWriter, r *http.Request) {
 	if h.pool == nil {
 		httputil.WriteError(w, http.StatusServiceUnavailable, "database not available")
@@ -117,6 +130,34 @@ func (h *Handlers) UpdateOIDC(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	force := r.URL.Query().Get("force") == "true"
+	verifyClient := r.URL.Query().Get("verify_client") == "true"
+
+	var existingOC *OidcConfig
+	clientSecret := oc.ClientSecret
+	if clientSecret == "" {
+		existingOC = h.getExistingOIDCConfig(r.Context())
+		if existingOC != nil {
+			clientSecret = h.decryptClientSecret(existingOC.ClientSecret)
+		}
+	}
+
+	validateCtx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	failure := validateOIDCConfig(validateCtx, oc, clientSecret, verifyClient)
+	cancel()
+	if failure != nil {
+		if !force || !failure.forceable() {
+			status := http.StatusUnprocessableEntity
+			if !failure.forceable() {
+				status = http.StatusBadRequest
+			}
+			httputil.WriteErrorDetails(w, status, httputil.CodeValidationFailed,
+				"OIDC configuration failed validation", oidcFailureDetails{failure, failure.forceable()})
+			return
+		}
+		log.Printf("settings: saving OIDC config despite failed %s check (forced): %s", failure.Stage, failure.Message)
+	}
+
 	// If client_secret is provided, encrypt it before storing
 	if oc.ClientSecret != "" {
 		encrypted, err := crypto.Encrypt([]byte(oc.ClientSecret), h.cfg.EncryptionKey)
@@ -127,7 +168,6 @@ func (h *Handlers) UpdateOIDC(w http.ResponseWriter, r *http.Request) {
 		oc.ClientSecret = hex.EncodeToString(encrypted)
 	} else {
 		// If empty, preserve existing secret from DB
-		existingOC := h.getExistingOIDCConfig(r.Context())
 		if existingOC != nil {
 			oc.ClientSecret = existingOC.ClientSecret
 		}
@@ -182,7 +222,7 @@ func (h *Handlers) TestOIDC(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	_, err := oidc.NewProvider(ctx, req.IssuerURL)
+	_, err := discoverOIDC(ctx, req.IssuerURL)
 	if err != nil {
 		log.Printf("oidc test: provider discovery failed for URL %q: %v", req.IssuerURL, err)
 		httputil.WriteJSON(w, http.StatusOK, map[string]interface{}{
@@ -225,6 +265,94 @@ func validateIssuerURL(rawURL string) error {
 	return nil
 }
 
+// GetMFAPolicy handles GET /api/settings/mfa. Without a stored policy MFA
+// is optional for local accounts.
+func (h *Handlers) GetMFAPolicy(w http.ResponseWriter, r *http.Request) {
+	var policy auth.MFAPolicy
+	if h.pool != nil {
+		var raw []byte
+		err := h.pool.QueryRow(r.Context(),
+			"SELECT value FROM settings WHERE key = $1", auth.MFAPolicySettingKey,
+		).Scan(&raw)
+		if err == nil {
+			_ = json.Unmarshal(raw, &policy)
+		}
+	}
+	httputil.WriteJSON(w, http.StatusOK, policy)
+}
+
+// UpdateMFAPolicy handles PUT /api/settings/mfa. Requiring MFA takes effect
+// at each local user's next login, which then asks them to enroll.
+func (h *Handlers) UpdateMFAPolicy(w http.ResponseWriter, r *http.Request) {
+	if h.pool == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "database not available")
+		return
+	}
+
+	var policy auth.MFAPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	raw, err := json.Marshal(policy)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to marshal policy")
+		return
+	}
+	if err := upsertSetting(r.Context(), h.pool, auth.MFAPolicySettingKey, raw); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to save settings")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, policy)
+}
+
+// GetSessionPolicy handles GET /api/settings/sessions. Without a stored
+// policy sessions last until their refresh token expires.
+func (h *Handlers) GetSessionPolicy(w http.ResponseWriter, r *http.Request) {
+	var policy auth.SessionPolicy
+	if h.pool != nil {
+		var raw []byte
+		err := h.pool.QueryRow(r.Context(),
+			"SELECT value FROM settings WHERE key = $1", auth.SessionPolicySettingKey,
+		).Scan(&raw)
+		if err == nil {
+			_ = json.Unmarshal(raw, &policy)
+		}
+	}
+	httputil.WriteJSON(w, http.StatusOK, policy)
+}
+
+// UpdateSessionPolicy handles PUT /api/settings/sessions. The idle timeout
+// applies to existing sessions at their next refresh; the session cap is
+// enforced at each user's next login.
+func (h *Handlers) UpdateSessionPolicy(w http.ResponseWriter, r *http.Request) {
+	var policy auth.SessionPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if h.pool == nil {
+		httputil.WriteError(w, http.StatusServiceUnavailable, "database not available")
+		return
+	}
+
+	raw, err := json.Marshal(policy)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to marshal policy")
+		return
+	}
+	if err := upsertSetting(r.Context(), h.pool, auth.SessionPolicySettingKey, raw); err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to save settings")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, policy)
+}
+
 // GetOIDCProviders handles GET /api/settings/oidc/providers.
 // Returns all available OIDC provider presets (public endpoint).
 func (h *Handlers) GetOIDCProviders(w http.ResponseWriter, r *http.Request) {
@@ -250,6 +378,23 @@ func (h *Handlers) getExistingOIDCConfig(ctx context.Context) *OidcConfig {
 	return &oc
 }
 
+// decryptClientSecret returns the plaintext of a stored (hex-encoded,
+// encrypted) client secret, or "" if it cannot be decrypted.
+func (h *Handlers) decryptClientSecret(stored string) string {
+	if stored == "" {
+		return ""
+	}
+	ciphertext, err := hex.DecodeString(stored)
+	if err != nil {
+		return ""
+	}
+	plaintext, err := crypto.Decrypt(ciphertext, h.cfg.EncryptionKey)
+	if err != nil {
+		return ""
+	}
+	return string(plaintext)
+}
+
 // upsertSetting performs an INSERT ... ON CONFLICT UPDATE into the settings
 // table.
 func upsertSetting(ctx context.Context, pool *pgxpool.Pool, key string, value []byte) error {
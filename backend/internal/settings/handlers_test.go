@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/gorilla/mux"
+	"github.com/darkden-lab/argus/backend/internal/auth"
 	"github.com/darkden-lab/argus/backend/internal/config"
 )
 
@@ -124,6 +125,75 @@ func TestUpdateOIDCNilPool(t *testing.T) {
 	}
 }
 
+func TestGetMFAPolicyDefaultsToOptional(t *testing.T) {
+	h := NewHandlers(nil, &config.Config{}, nil, nil)
+	req := httptest.NewRequest("GET", "/api/settings/mfa", nil)
+	rec := httptest.NewRecorder()
+
+	h.GetMFAPolicy(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var policy auth.MFAPolicy
+	if err := json.NewDecoder(rec.Body).Decode(&policy); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if policy.RequireForLocal {
+		t.Error("expected MFA to be optional without a stored policy")
+	}
+}
+
+func TestUpdateMFAPolicyNilPool(t *testing.T) {
+	h := NewHandlers(nil, &config.Config{}, nil, nil)
+	req := httptest.NewRequest("PUT", "/api/settings/mfa", bytes.NewReader([]byte(`{"require_for_local":true}`)))
+	rec := httptest.NewRecorder()
+
+	h.UpdateMFAPolicy(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+}
+
+func TestGetSessionPolicyDefaultsToNone(t *testing.T) {
+	h := NewHandlers(nil, &config.Config{}, nil, nil)
+	rec := httptest.NewRecorder()
+
+	h.GetSessionPolicy(rec, httptest.NewRequest("GET", "/api/settings/sessions", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var policy auth.SessionPolicy
+	if err := json.NewDecoder(rec.Body).Decode(&policy); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if policy != (auth.SessionPolicy{}) {
+		t.Errorf("expected no session limits without a stored policy, got %+v", policy)
+	}
+}
+
+func TestUpdateSessionPolicy(t *testing.T) {
+	h := NewHandlers(nil, &config.Config{}, nil, nil)
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"idle_timeout_minutes":5}`, http.StatusBadRequest},
+		{`{"max_sessions_per_user":-1}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+		{`{"idle_timeout_minutes":60,"max_sessions_per_user":3}`, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.UpdateSessionPolicy(rec, httptest.NewRequest("PUT", "/api/settings/sessions", bytes.NewReader([]byte(tt.body))))
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.body, tt.want, rec.Code)
+		}
+	}
+}
+
 func TestUpdateOIDCBadJSON(t *testing.T) {
 	// UpdateOIDC checks pool==nil first (503), so bad JSON test goes through TestOIDC
 }
@@ -204,6 +274,10 @@ func TestRegisterRoutes(t *testing.T) {
 		{"PUT", "/api/settings/oidc"},
 		{"POST", "/api/settings/oidc/test"},
 		{"GET", "/api/settings/oidc/providers"},
+		{"GET", "/api/settings/mfa"},
+		{"PUT", "/api/settings/mfa"},
+		{"GET", "/api/settings/sessions"},
+		{"PUT", "/api/settings/sessions"},
 	}
 	for _, rt := range routes {
 		req := httptest.NewRequest(rt.method, rt.path, nil)
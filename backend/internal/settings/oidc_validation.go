@@ -0,0 +1,135 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+
+	"github.com/darkden-lab/argus/backend/internal/outbound"
+)
+
+// OIDC validation stages, reported in oidcValidationFailure.Stage.
+const (
+	oidcStageConfig            = "config"
+	oidcStageDiscovery         = "discovery"
+	oidcStageClientCredentials = "client_credentials"
+)
+
+// oidcValidationFailure describes why an OIDC configuration was rejected
+// before it was saved.
+type oidcValidationFailure struct {
+	Stage   string `json:"stage"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// oidcFailureDetails is the error details of a rejected OIDC config.
+type oidcFailureDetails struct {
+	*oidcValidationFailure
+	Forceable bool `json:"forceable"`
+}
+
+// forceable reports whether an admin may save the configuration anyway.
+// Malformed or unsafe configs are never saved; a provider that cannot be
+// reached right now may still be intentional (e.g. an IdP being set up).
+func (f *oidcValidationFailure) forceable() bool {
+	return f.Stage != oidcStageConfig
+}
+
+// discoverOIDC performs OIDC discovery against issuerURL and returns the
+// provider's token endpoint. Replaced in tests.
+var discoverOIDC = func(ctx context.Context, issuerURL string) (string, error) {
+	provider, err := oidc.NewProvider(outbound.Context(ctx), issuerURL)
+	if err != nil {
+		return "", err
+	}
+	return provider.Endpoint().TokenURL, nil
+}
+
+// checkClientCredentials asks the token endpoint for a client_credentials
+// token. Only an invalid_client response means the credentials are wrong:
+// many providers do not allow this grant for web clients and answer with
+// unauthorized_client or unsupported_grant_type after authenticating the
+// client, which is good enough to confirm the ID and secret. Replaced in tests.
+var checkClientCredentials = func(ctx context.Context, tokenURL, clientID, clientSecret string) error {
+	cfg := clientcredentials.Config{ClientID: clientID, ClientSecret: clientSecret, TokenURL: tokenURL}
+	_, err := cfg.Token(outbound.Context(ctx))
+	if err == nil {
+		return nil
+	}
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) && retrieveErr.ErrorCode != "invalid_client" {
+		return nil
+	}
+	return err
+}
+
+// validateOIDCConfig checks an OIDC configuration before it is persisted:
+// required fields, the issuer URL policy, and provider discovery. When
+// verifyClient is set, the client ID and secret are also tested against the
+// provider's token endpoint. Disabled configurations are not checked.
+func validateOIDCConfig(ctx context.Context, oc OidcConfig, clientSecret string, verifyClient bool) *oidcValidationFailure {
+	if !oc.Enabled {
+		return nil
+	}
+	if oc.IssuerURL == "" {
+		return &oidcValidationFailure{Stage: oidcStageConfig, Field: "issuer_url", Message: "issuer_url is required"}
+	}
+	if oc.ClientID == "" {
+		return &oidcValidationFailure{Stage: oidcStageConfig, Field: "client_id", Message: "client_id is required"}
+	}
+	if err := validateIssuerURL(oc.IssuerURL); err != nil {
+		return &oidcValidationFailure{Stage: oidcStageConfig, Field: "issuer_url", Message: err.Error()}
+	}
+	for field, scopes := range map[string][]string{"scopes": oc.Scopes, "extra_scopes": oc.ExtraScopes} {
+		for _, scope := range scopes {
+			if scope == "" || strings.ContainsAny(scope, " \t\r\n\"") {
+				return &oidcValidationFailure{Stage: oidcStageConfig, Field: field, Message: fmt.Sprintf("invalid scope %q", scope)}
+			}
+		}
+	}
+	for field, claim := range map[string]string{
+		"subject_claim": oc.SubjectClaim, "email_claim": oc.EmailClaim,
+		"name_claim": oc.NameClaim, "groups_claim": oc.GroupsClaim,
+	} {
+		if strings.TrimSpace(claim) != claim {
+			return &oidcValidationFailure{Stage: oidcStageConfig, Field: field, Message: fmt.Sprintf("invalid claim name %q", claim)}
+		}
+	}
+
+	tokenURL, err := discoverOIDC(ctx, oc.IssuerURL)
+	if err != nil {
+		log.Printf("settings: OIDC discovery failed for URL %q: %v", oc.IssuerURL, err)
+		return &oidcValidationFailure{
+			Stage:   oidcStageDiscovery,
+			Field:   "issuer_url",
+			Message: "Provider discovery failed. Check the issuer URL and try again.",
+		}
+	}
+
+	if !verifyClient {
+		return nil
+	}
+	if clientSecret == "" {
+		return &oidcValidationFailure{
+			Stage:   oidcStageClientCredentials,
+			Field:   "client_secret",
+			Message: "client_secret is required to verify client credentials",
+		}
+	}
+	if err := checkClientCredentials(ctx, tokenURL, oc.ClientID, clientSecret); err != nil {
+		log.Printf("settings: OIDC client credential check failed for client %q: %v", oc.ClientID, err)
+		return &oidcValidationFailure{
+			Stage:   oidcStageClientCredentials,
+			Field:   "client_secret",
+			Message: "The provider rejected the client ID or secret.",
+		}
+	}
+	return nil
+}
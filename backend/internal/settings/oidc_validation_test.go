@@ -0,0 +1,144 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// stubOIDC replaces the network-facing OIDC checks for the duration of a test.
+func stubOIDC(t *testing.T, discover func(context.Context, string) (string, error), creds func(context.Context, string, string, string) error) {
+	t.Helper()
+	origDiscover, origCreds := discoverOIDC, checkClientCredentials
+	t.Cleanup(func() {
+		discoverOIDC, checkClientCredentials = origDiscover, origCreds
+	})
+	if discover != nil {
+		discoverOIDC = discover
+	}
+	if creds != nil {
+		checkClientCredentials = creds
+	}
+}
+
+func okDiscovery(context.Context, string) (string, error) {
+	return "https://idp.example.com/token", nil
+}
+
+func validOIDCConfig() OidcConfig {
+	return OidcConfig{Enabled: true, IssuerURL: "https://idp.example.com", ClientID: "argus"}
+}
+
+func TestValidateOIDCConfig_DisabledSkipsChecks(t *testing.T) {
+	stubOIDC(t, func(context.Context, string) (string, error) {
+		t.Fatal("discovery should not run for a disabled config")
+		return "", nil
+	}, nil)
+
+	if f := validateOIDCConfig(context.Background(), OidcConfig{Enabled: false}, "", true); f != nil {
+		t.Errorf("expected no failure, got %+v", f)
+	}
+}
+
+func TestValidateOIDCConfig_MissingFields(t *testing.T) {
+	stubOIDC(t, okDiscovery, nil)
+
+	oc := validOIDCConfig()
+	oc.ClientID = ""
+	f := validateOIDCConfig(context.Background(), oc, "", false)
+	if f == nil || f.Stage != oidcStageConfig || f.Field != "client_id" {
+		t.Fatalf("expected client_id config failure, got %+v", f)
+	}
+	if f.forceable() {
+		t.Error("config failures must not be forceable")
+	}
+}
+
+func TestValidateOIDCConfig_ScopesAndClaims(t *testing.T) {
+	stubOIDC(t, okDiscovery, nil)
+
+	oc := validOIDCConfig()
+	oc.Scopes = []string{"openid", "User.Read"}
+	oc.ExtraScopes = []string{"offline_access"}
+	oc.EmailClaim = "preferred_username"
+	if f := validateOIDCConfig(context.Background(), oc, "", false); f != nil {
+		t.Fatalf("expected no failure, got %+v", f)
+	}
+
+	oc.ExtraScopes = []string{"profile email"}
+	f := validateOIDCConfig(context.Background(), oc, "", false)
+	if f == nil || f.Stage != oidcStageConfig || f.Field != "extra_scopes" {
+		t.Fatalf("expected extra_scopes config failure, got %+v", f)
+	}
+
+	oc.ExtraScopes = nil
+	oc.NameClaim = " name"
+	f = validateOIDCConfig(context.Background(), oc, "", false)
+	if f == nil || f.Stage != oidcStageConfig || f.Field != "name_claim" {
+		t.Fatalf("expected name_claim config failure, got %+v", f)
+	}
+}
+
+func TestValidateOIDCConfig_PrivateIssuerNotForceable(t *testing.T) {
+	stubOIDC(t, okDiscovery, nil)
+
+	oc := validOIDCConfig()
+	oc.IssuerURL = "https://10.0.0.1/oidc"
+	f := validateOIDCConfig(context.Background(), oc, "", false)
+	if f == nil || f.Stage != oidcStageConfig || f.forceable() {
+		t.Fatalf("expected non-forceable config failure, got %+v", f)
+	}
+}
+
+func TestValidateOIDCConfig_DiscoveryFailure(t *testing.T) {
+	stubOIDC(t, func(context.Context, string) (string, error) {
+		return "", errors.New("404 Not Found")
+	}, nil)
+
+	f := validateOIDCConfig(context.Background(), validOIDCConfig(), "", false)
+	if f == nil || f.Stage != oidcStageDiscovery || f.Field != "issuer_url" {
+		t.Fatalf("expected discovery failure, got %+v", f)
+	}
+	if !f.forceable() {
+		t.Error("discovery failures should be forceable")
+	}
+}
+
+func TestValidateOIDCConfig_DiscoverySuccess(t *testing.T) {
+	stubOIDC(t, okDiscovery, func(context.Context, string, string, string) error {
+		t.Fatal("client credentials should only be checked on request")
+		return nil
+	})
+
+	if f := validateOIDCConfig(context.Background(), validOIDCConfig(), "secret", false); f != nil {
+		t.Errorf("expected no failure, got %+v", f)
+	}
+}
+
+func TestValidateOIDCConfig_ClientCredentials(t *testing.T) {
+	var gotTokenURL, gotSecret string
+	stubOIDC(t, okDiscovery, func(_ context.Context, tokenURL, _, secret string) error {
+		gotTokenURL, gotSecret = tokenURL, secret
+		if secret != "right" {
+			return errors.New("invalid_client")
+		}
+		return nil
+	})
+
+	if f := validateOIDCConfig(context.Background(), validOIDCConfig(), "right", true); f != nil {
+		t.Fatalf("expected valid credentials to pass, got %+v", f)
+	}
+	if gotTokenURL != "https://idp.example.com/token" || gotSecret != "right" {
+		t.Errorf("unexpected credential check: tokenURL=%q secret=%q", gotTokenURL, gotSecret)
+	}
+
+	f := validateOIDCConfig(context.Background(), validOIDCConfig(), "wrong", true)
+	if f == nil || f.Stage != oidcStageClientCredentials {
+		t.Fatalf("expected client credential failure, got %+v", f)
+	}
+
+	f = validateOIDCConfig(context.Background(), validOIDCConfig(), "", true)
+	if f == nil || f.Field != "client_secret" {
+		t.Fatalf("expected missing secret failure, got %+v", f)
+	}
+}
@@ -14,6 +14,13 @@ import (
 	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
+// Error codes specific to the setup wizard.
+const (
+	codeSetupCheckFailed      httputil.ErrorCode = "setup_check_failed"
+	codeSetupAlreadyCompleted httputil.ErrorCode = "setup_already_completed"
+	codeUserCreationFailed    httputil.ErrorCode = "user_creation_failed"
+)
+
 // Handlers provides HTTP handlers for the first-run setup wizard.
 type Handlers struct {
 	service     *Service
@@ -78,11 +85,8 @@ func (h *Handlers) handleInit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if problems := validateInitRequest(req); len(problems) > 0 {
-		httputil.WriteJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
-			"error":   "validation_failed",
-			"message": "Request validation failed",
-			"details": problems,
-		})
+		httputil.WriteErrorDetails(w, http.StatusUnprocessableEntity, httputil.CodeValidationFailed,
+			"Request validation failed", problems)
 		return
 	}
 
@@ -111,10 +115,8 @@ func (h *Handlers) handleInit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if alreadyDone {
-		httputil.WriteJSON(w, http.StatusForbidden, map[string]string{
-			"error":   "setup_already_completed",
-			"message": "Initial setup has already been completed",
-		})
+		httputil.WriteErrorCode(w, http.StatusForbidden, codeSetupAlreadyCompleted,
+			"Initial setup has already been completed")
 		return
 	}
 
@@ -123,10 +125,8 @@ func (h *Handlers) handleInit(w http.ResponseWriter, r *http.Request) {
 	user, err := h.authService.Register(ctx, req.Email, req.Password, req.DisplayName)
 	if err != nil {
 		log.Printf("setup: failed to create admin user: %v", err)
-		httputil.WriteJSON(w, http.StatusConflict, map[string]string{
-			"error":   "user_creation_failed",
-			"message": "Failed to create admin account. The email may already be registered.",
-		})
+		httputil.WriteErrorCode(w, http.StatusConflict, codeUserCreationFailed,
+			"Failed to create admin account. The email may already be registered.")
 		return
 	}
 
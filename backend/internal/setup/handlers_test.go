@@ -261,8 +261,11 @@ func TestHandleInit_ValidationError_Returns422(t *testing.T) {
 
 	var result map[string]interface{}
 	json.NewDecoder(rec.Body).Decode(&result)
-	if result["error"] != "validation_failed" {
-		t.Errorf("expected error=validation_failed, got %v", result["error"])
+	if result["code"] != "validation_failed" {
+		t.Errorf("expected code=validation_failed, got %v", result["code"])
+	}
+	if result["details"] == nil {
+		t.Error("expected validation problems in details")
 	}
 }
 
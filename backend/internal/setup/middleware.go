@@ -75,18 +75,13 @@ func GuardMiddleware(service *Service) mux.MiddlewareFunc {
 					return
 				}
 				// Unknown state (never checked or was required) — deny.
-				httputil.WriteJSON(w, http.StatusServiceUnavailable, map[string]string{
-					"error":   "setup_check_failed",
-					"message": "Unable to verify system state. Please try again.",
-				})
+				httputil.WriteErrorCode(w, http.StatusServiceUnavailable, codeSetupCheckFailed,
+					"Unable to verify system state. Please try again.")
 				return
 			}
 
 			if required {
-				httputil.WriteJSON(w, http.StatusForbidden, map[string]string{
-					"error":   "setup_required",
-					"message": "Initial setup is required",
-				})
+				httputil.WriteErrorCode(w, http.StatusForbidden, httputil.CodeSetupRequired, "Initial setup is required")
 				return
 			}
 
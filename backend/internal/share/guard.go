@@ -0,0 +1,78 @@
+package share
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+// sessionPath describes the calling share session.
+const sessionPath = "/api/share-links/session"
+
+// sharedResourceRoutes are the route templates a share session may read,
+// checked against the link's permission on every request.
+var sharedResourceRoutes = map[string]bool{
+	"/api/clusters/{clusterID}/resources/{group}/{version}/{resource}":        true,
+	"/api/clusters/{clusterID}/resources/{group}/{version}/{resource}/{name}": true,
+}
+
+// SessionGuard confines share sessions to reading resources within their
+// link's scope. Every other route, and every method but GET, is refused.
+// Requests from other principals pass through untouched. It must run after
+// the auth middleware, on a router whose routes are already matched.
+func SessionGuard(authz rbac.Evaluator) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.ClaimsFromContext(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if _, isShare := claims.ShareLinkID(); !isShare {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.Method != http.MethodGet {
+				httputil.WriteError(w, http.StatusForbidden, "share sessions are read-only")
+				return
+			}
+			var template string
+			if route := mux.CurrentRoute(r); route != nil {
+				template, _ = route.GetPathTemplate()
+			}
+			if template == sessionPath {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !sharedResourceRoutes[template] {
+				httputil.WriteError(w, http.StatusForbidden, "not available to share sessions")
+				return
+			}
+
+			vars := mux.Vars(r)
+			allowed, err := authz.Evaluate(r.Context(), rbac.Request{
+				UserID:    claims.UserID,
+				Action:    "read",
+				Resource:  vars["resource"],
+				ClusterID: vars["clusterID"],
+				Namespace: r.URL.Query().Get("namespace"),
+			})
+			if err != nil {
+				log.Printf("share: RBAC evaluation failed for %s: %v", claims.UserID, err)
+				httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+				return
+			}
+			if !allowed {
+				httputil.WriteError(w, http.StatusForbidden, "outside the scope of this share link")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
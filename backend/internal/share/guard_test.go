@@ -0,0 +1,103 @@
+package share
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+type fakeAuthz struct {
+	allow bool
+	got   []rbac.Request
+}
+
+func (f *fakeAuthz) Evaluate(ctx context.Context, req rbac.Request) (bool, error) {
+	f.got = append(f.got, req)
+	return f.allow, nil
+}
+
+// guardedRouter mirrors how the server mounts resource routes: a prefix
+// subrouter under the protected router the guard is applied to.
+func guardedRouter(authz rbac.Evaluator, userID string) *mux.Router {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	r := mux.NewRouter()
+	protected := r.PathPrefix("").Subrouter()
+	protected.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := auth.ContextWithClaims(r.Context(), &auth.Claims{UserID: userID})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	protected.Use(SessionGuard(authz))
+
+	base := protected.PathPrefix("/api/clusters/{clusterID}/resources/{group}/{version}/{resource}").Subrouter()
+	base.HandleFunc("", ok).Methods(http.MethodGet)
+	base.HandleFunc("/{name}", ok).Methods(http.MethodGet)
+	base.HandleFunc("/{name}", ok).Methods(http.MethodDelete)
+	protected.HandleFunc(sessionPath, ok).Methods(http.MethodGet)
+	protected.HandleFunc("/api/users/me", ok).Methods(http.MethodGet)
+	return r
+}
+
+func serve(r *mux.Router, method, path string) int {
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec.Code
+}
+
+func TestSessionGuard_IgnoresOtherPrincipals(t *testing.T) {
+	authz := &fakeAuthz{}
+	r := guardedRouter(authz, "user-1")
+
+	if code := serve(r, http.MethodDelete, "/api/clusters/c1/resources/_/v1/pods/web"); code != http.StatusOK {
+		t.Errorf("expected users to pass, got %d", code)
+	}
+	if len(authz.got) != 0 {
+		t.Error("expected no RBAC evaluation for users")
+	}
+}
+
+func TestSessionGuard_ShareSessions(t *testing.T) {
+	share := auth.ShareLinkPrincipalPrefix + "link-1"
+	tests := []struct {
+		name   string
+		allow  bool
+		method string
+		path   string
+		want   int
+	}{
+		{"read in scope", true, http.MethodGet, "/api/clusters/c1/resources/_/v1/pods?namespace=web", http.StatusOK},
+		{"get in scope", true, http.MethodGet, "/api/clusters/c1/resources/_/v1/pods/api-0?namespace=web", http.StatusOK},
+		{"read out of scope", false, http.MethodGet, "/api/clusters/c1/resources/_/v1/secrets?namespace=web", http.StatusForbidden},
+		{"write", true, http.MethodDelete, "/api/clusters/c1/resources/_/v1/pods/api-0?namespace=web", http.StatusForbidden},
+		{"other route", true, http.MethodGet, "/api/users/me", http.StatusForbidden},
+		{"session info", false, http.MethodGet, sessionPath, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := guardedRouter(&fakeAuthz{allow: tt.allow}, share)
+			if code := serve(r, tt.method, tt.path); code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, code)
+			}
+		})
+	}
+}
+
+func TestSessionGuard_EvaluatesLinkScope(t *testing.T) {
+	authz := &fakeAuthz{allow: true}
+	share := auth.ShareLinkPrincipalPrefix + "link-1"
+	r := guardedRouter(authz, share)
+
+	serve(r, http.MethodGet, "/api/clusters/c1/resources/apps/v1/deployments/api?namespace=web")
+
+	want := rbac.Request{UserID: share, Action: "read", Resource: "deployments", ClusterID: "c1", Namespace: "web"}
+	if len(authz.got) != 1 || authz.got[0] != want {
+		t.Errorf("expected %+v, got %+v", want, authz.got)
+	}
+}
@@ -0,0 +1,349 @@
+package share
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/darkden-lab/argus/backend/internal/audit"
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+// linkStore is the part of *Service the handlers use.
+type linkStore interface {
+	Create(ctx context.Context, req CreateRequest) (*CreateResponse, error)
+	Get(ctx context.Context, id string) (*Link, error)
+	ListByCreator(ctx context.Context, userID string) ([]Link, error)
+	Revoke(ctx context.Context, id, userID string) (*Link, error)
+	Redeem(ctx context.Context, rawToken string) (*Session, error)
+}
+
+// discoverySource is the part of *cluster.Manager the handlers use to tell
+// namespaced resource types from cluster-scoped ones.
+type discoverySource interface {
+	Discovery(ctx context.Context, clusterID string) (*cluster.Discovery, error)
+}
+
+// Handlers serves share link management and redemption.
+type Handlers struct {
+	links      linkStore
+	discovery  discoverySource
+	authz      rbac.Evaluator
+	auditStore *audit.Store
+}
+
+// NewHandlers creates share link handlers. auditStore may be nil.
+func NewHandlers(service *Service, cm *cluster.Manager, authz rbac.Evaluator, auditStore *audit.Store) *Handlers {
+	return &Handlers{links: service, discovery: cm, authz: authz, auditStore: auditStore}
+}
+
+// RegisterPublicRoutes wires the redeem endpoint, which needs no account.
+// r should be rate limited.
+func (h *Handlers) RegisterPublicRoutes(r *mux.Router) {
+	r.HandleFunc("/api/share-links/redeem", h.handleRedeem).Methods(http.MethodPost)
+}
+
+// RegisterRoutes wires the authenticated endpoints.
+func (h *Handlers) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/share-links", h.handleList).Methods(http.MethodGet)
+	r.HandleFunc("/api/share-links", h.handleCreate).Methods(http.MethodPost)
+	r.HandleFunc(sessionPath, h.handleSession).Methods(http.MethodGet)
+	r.HandleFunc("/api/share-links/{id}", h.handleRevoke).Methods(http.MethodDelete)
+}
+
+// resourceNameRe matches RBAC resource names such as "pods" or
+// "istio:virtualservices". Wildcards are not allowed: a link shares one
+// resource type.
+var resourceNameRe = regexp.MustCompile(`^[a-z0-9]([a-z0-9.:-]*[a-z0-9])?$`)
+
+// maxCreateBodySize caps the create request body.
+const maxCreateBodySize = 64 << 10
+
+type createLinkRequest struct {
+	Description      string  `json:"description"`
+	ClusterID        string  `json:"cluster_id"`
+	Namespace        *string `json:"namespace,omitempty"`
+	Resource         string  `json:"resource"`
+	ExpiresInMinutes int     `json:"expires_in_minutes"`
+}
+
+// handleCreate handles POST /api/share-links. The caller must be able to
+// read what they share. Only namespaced resource types can be shared within
+// a namespace: the namespace of a cluster-scoped type would be ignored when
+// listing, exposing every object of the type.
+func (h *Handlers) handleCreate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !isUser(claims) {
+		httputil.WriteError(w, http.StatusForbidden, "share links can only be created by users")
+		return
+	}
+
+	var req createLinkRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCreateBodySize)).Decode(&req); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.ClusterID == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "cluster_id is required")
+		return
+	}
+	if !resourceNameRe.MatchString(req.Resource) || len(req.Resource) > 255 {
+		httputil.WriteError(w, http.StatusBadRequest, "resource must name a single resource type")
+		return
+	}
+	if req.Namespace != nil && *req.Namespace == "" {
+		req.Namespace = nil
+	}
+	if req.Namespace != nil {
+		d, err := h.discovery.Discovery(r.Context(), req.ClusterID)
+		if errors.Is(err, cluster.ErrClusterUnavailable) {
+			httputil.WriteError(w, http.StatusBadRequest, "cluster not found or not connected")
+			return
+		}
+		if err != nil {
+			log.Printf("share: discovery failed for cluster %s: %v", req.ClusterID, err)
+			httputil.WriteError(w, http.StatusBadGateway, "failed to discover the cluster's resource types")
+			return
+		}
+		namespaced, found := resourceScope(d, req.Resource)
+		if !found {
+			httputil.WriteError(w, http.StatusBadRequest, "resource type "+req.Resource+" is not served by this cluster")
+			return
+		}
+		if !namespaced {
+			httputil.WriteError(w, http.StatusBadRequest, "resource type "+req.Resource+" is cluster-scoped and cannot be shared within a namespace")
+			return
+		}
+	}
+	if req.ExpiresInMinutes < 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "expires_in_minutes must not be negative")
+		return
+	}
+	lifetime := defaultLinkLifetime
+	if req.ExpiresInMinutes > 0 {
+		lifetime = time.Duration(req.ExpiresInMinutes) * time.Minute
+	}
+	if lifetime > maxLinkLifetime {
+		httputil.WriteError(w, http.StatusBadRequest, "share links can last at most 7 days")
+		return
+	}
+
+	namespace := ""
+	if req.Namespace != nil {
+		namespace = *req.Namespace
+	}
+	allowed, err := h.authz.Evaluate(r.Context(), rbac.Request{
+		UserID:    claims.UserID,
+		Action:    "read",
+		Resource:  rbac.ResourceCategory(req.Resource),
+		ClusterID: req.ClusterID,
+		Namespace: namespace,
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+		return
+	}
+	if !allowed {
+		httputil.WriteError(w, http.StatusForbidden, "you can only share what you can read")
+		return
+	}
+
+	resp, err := h.links.Create(r.Context(), CreateRequest{
+		Description: req.Description,
+		ClusterID:   req.ClusterID,
+		Namespace:   req.Namespace,
+		Resource:    req.Resource,
+		CreatedBy:   claims.UserID,
+		ExpiresAt:   time.Now().Add(lifetime),
+	})
+	if errors.Is(err, ErrClusterNotFound) {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("share: create failed: %v", err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to create share link")
+		return
+	}
+
+	uid := claims.UserID
+	h.record(r, &uid, &resp.ClusterID, "share_link.create", map[string]interface{}{
+		"share_link_id": resp.ID,
+		"namespace":     resp.Namespace,
+		"resource":      resp.Resource,
+		"expires_at":    resp.ExpiresAt,
+	})
+	httputil.WriteJSON(w, http.StatusCreated, resp)
+}
+
+// resourceScope reports whether the cluster serves a resource type named
+// resource and whether it is namespaced. A name served by several groups
+// counts as namespaced only if it is namespaced in all of them.
+func resourceScope(d *cluster.Discovery, resource string) (namespaced, found bool) {
+	namespaced = true
+	for _, list := range d.Resources {
+		if list == nil {
+			continue
+		}
+		for _, res := range list.APIResources {
+			if res.Name == resource {
+				found = true
+				namespaced = namespaced && res.Namespaced
+			}
+		}
+	}
+	return namespaced && found, found
+}
+
+// handleList handles GET /api/share-links, listing the caller's own links.
+func (h *Handlers) handleList(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !isUser(claims) {
+		httputil.WriteJSON(w, http.StatusOK, []Link{})
+		return
+	}
+	links, err := h.links.ListByCreator(r.Context(), claims.UserID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to list share links")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, links)
+}
+
+// handleRevoke handles DELETE /api/share-links/{id}. Only the link's creator
+// can revoke it.
+func (h *Handlers) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !isUser(claims) {
+		httputil.WriteError(w, http.StatusNotFound, "share link not found")
+		return
+	}
+	link, err := h.links.Revoke(r.Context(), mux.Vars(r)["id"], claims.UserID)
+	if err != nil {
+		if errors.Is(err, ErrLinkNotFound) {
+			httputil.WriteError(w, http.StatusNotFound, "share link not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to revoke share link")
+		return
+	}
+
+	uid := claims.UserID
+	h.record(r, &uid, &link.ClusterID, "share_link.revoke", map[string]interface{}{
+		"share_link_id": link.ID,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type redeemRequest struct {
+	Token string `json:"token"`
+}
+
+// handleRedeem handles POST /api/share-links/redeem, exchanging a link
+// token for a read-only session token.
+func (h *Handlers) handleRedeem(w http.ResponseWriter, r *http.Request) {
+	var req redeemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "token is required")
+		return
+	}
+
+	sess, err := h.links.Redeem(r.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, ErrInvalidLink) {
+			httputil.WriteError(w, http.StatusUnauthorized, ErrInvalidLink.Error())
+			return
+		}
+		log.Printf("share: redeem failed: %v", err)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to redeem share link")
+		return
+	}
+
+	// A redemption has no user; it is recorded against the cluster with
+	// the link and its creator in the details.
+	h.record(r, nil, &sess.ClusterID, "share_link.redeem", map[string]interface{}{
+		"share_link_id":      sess.LinkID,
+		"created_by":         sess.CreatedBy,
+		"session_expires_at": sess.ExpiresAt,
+		"remote_addr":        r.RemoteAddr,
+	})
+	httputil.WriteJSON(w, http.StatusOK, sess)
+}
+
+// handleSession handles GET /api/share-links/session, describing the scope
+// of the calling share session.
+func (h *Handlers) handleSession(w http.ResponseWriter, r *http.Request) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	linkID, isShare := claims.ShareLinkID()
+	if !isShare {
+		httputil.WriteError(w, http.StatusNotFound, "not a share session")
+		return
+	}
+	link, err := h.links.Get(r.Context(), linkID)
+	if err != nil {
+		if errors.Is(err, ErrLinkNotFound) {
+			httputil.WriteError(w, http.StatusNotFound, "share link not found")
+			return
+		}
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to get share link")
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"share_link_id": link.ID,
+		"description":   link.Description,
+		"cluster_id":    link.ClusterID,
+		"namespace":     link.Namespace,
+		"resource":      link.Resource,
+		"expires_at":    link.ExpiresAt,
+	})
+}
+
+// isUser reports whether claims belong to a user rather than a service
+// account or share session.
+func isUser(claims *auth.Claims) bool {
+	if _, ok := claims.ServiceAccountID(); ok {
+		return false
+	}
+	if _, ok := claims.ShareLinkID(); ok {
+		return false
+	}
+	return true
+}
+
+// record writes an audit entry for a share link event.
+func (h *Handlers) record(r *http.Request, userID, clusterID *string, action string, details map[string]interface{}) {
+	if h.auditStore == nil {
+		return
+	}
+	raw, _ := json.Marshal(details)
+	ctx, cancel := audit.DetachedContext(r.Context())
+	defer cancel()
+	if err := h.auditStore.Insert(ctx, userID, nil, clusterID, action, r.URL.Path, raw); err != nil {
+		log.Printf("share: failed to audit %s: %v", action, err)
+	}
+}
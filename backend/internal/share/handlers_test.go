@@ -0,0 +1,224 @@
+package share
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+)
+
+type fakeLinks struct {
+	created   *CreateRequest
+	redeemErr error
+	revokeErr error
+}
+
+func (f *fakeLinks) Create(ctx context.Context, req CreateRequest) (*CreateResponse, error) {
+	f.created = &req
+	return &CreateResponse{
+		Link:  Link{ID: "link-1", ClusterID: req.ClusterID, Namespace: req.Namespace, Resource: req.Resource, ExpiresAt: req.ExpiresAt},
+		Token: auth.ShareLinkTokenPrefix + "secret",
+	}, nil
+}
+
+func (f *fakeLinks) Get(ctx context.Context, id string) (*Link, error) {
+	return &Link{ID: id, ClusterID: "c1", Resource: "pods"}, nil
+}
+
+func (f *fakeLinks) ListByCreator(ctx context.Context, userID string) ([]Link, error) {
+	return []Link{{ID: "link-1"}}, nil
+}
+
+func (f *fakeLinks) Revoke(ctx context.Context, id, userID string) (*Link, error) {
+	if f.revokeErr != nil {
+		return nil, f.revokeErr
+	}
+	return &Link{ID: id, ClusterID: "c1"}, nil
+}
+
+func (f *fakeLinks) Redeem(ctx context.Context, rawToken string) (*Session, error) {
+	if f.redeemErr != nil {
+		return nil, f.redeemErr
+	}
+	return &Session{Token: auth.ShareSessionTokenPrefix + "session", LinkID: "link-1", ClusterID: "c1", Resource: "pods"}, nil
+}
+
+// fakeDiscovery serves namespaced pods and cluster-scoped nodes.
+type fakeDiscovery struct{}
+
+func (fakeDiscovery) Discovery(ctx context.Context, clusterID string) (*cluster.Discovery, error) {
+	return &cluster.Discovery{Resources: []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "pods", Kind: "Pod", Namespaced: true},
+			{Name: "nodes", Kind: "Node"},
+		},
+	}}}, nil
+}
+
+func newRequest(method, path, body, userID string) *http.Request {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	if userID != "" {
+		req = req.WithContext(auth.ContextWithClaims(req.Context(), &auth.Claims{UserID: userID}))
+	}
+	return req
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp map[string]string
+	json.Unmarshal(rec.Body.Bytes(), &resp) //nolint:errcheck
+	return resp["error"]
+}
+
+func TestHandleCreateValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"bad json", "not json", "invalid request body"},
+		{"missing cluster", `{"resource":"pods"}`, "cluster_id is required"},
+		{"missing resource", `{"cluster_id":"c1"}`, "resource must name a single resource type"},
+		{"wildcard resource", `{"cluster_id":"c1","resource":"*"}`, "resource must name a single resource type"},
+		{"negative expiry", `{"cluster_id":"c1","resource":"pods","expires_in_minutes":-1}`, "expires_in_minutes must not be negative"},
+		{"expiry too long", `{"cluster_id":"c1","resource":"pods","expires_in_minutes":20160}`, "share links can last at most 7 days"},
+		{"body too large", `{"cluster_id":"c1","resource":"pods","description":"` + strings.Repeat("x", maxCreateBodySize) + `"}`, "invalid request body"},
+		{"namespaced cluster-scoped resource", `{"cluster_id":"c1","namespace":"web","resource":"nodes"}`, "resource type nodes is cluster-scoped and cannot be shared within a namespace"},
+		{"namespaced unknown resource", `{"cluster_id":"c1","namespace":"web","resource":"widgets"}`, "resource type widgets is not served by this cluster"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handlers{links: &fakeLinks{}, discovery: fakeDiscovery{}, authz: &fakeAuthz{allow: true}}
+			rec := httptest.NewRecorder()
+			h.handleCreate(rec, newRequest(http.MethodPost, "/api/share-links", tt.body, "user-1"))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", rec.Code)
+			}
+			if got := decodeError(t, rec); got != tt.wantErr {
+				t.Errorf("expected %q, got %q", tt.wantErr, got)
+			}
+		})
+	}
+}
+
+func TestHandleCreateRequiresUser(t *testing.T) {
+	for _, principal := range []string{auth.ServiceAccountPrincipalPrefix + "sa-1", auth.ShareLinkPrincipalPrefix + "link-1"} {
+		h := &Handlers{links: &fakeLinks{}, authz: &fakeAuthz{allow: true}}
+		rec := httptest.NewRecorder()
+		h.handleCreate(rec, newRequest(http.MethodPost, "/api/share-links", `{"cluster_id":"c1","resource":"pods"}`, principal))
+
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d", principal, rec.Code)
+		}
+	}
+}
+
+func TestHandleCreateRequiresReadAccess(t *testing.T) {
+	links := &fakeLinks{}
+	authz := &fakeAuthz{allow: false}
+	h := &Handlers{links: links, discovery: fakeDiscovery{}, authz: authz}
+	rec := httptest.NewRecorder()
+	h.handleCreate(rec, newRequest(http.MethodPost, "/api/share-links", `{"cluster_id":"c1","namespace":"web","resource":"pods"}`, "user-1"))
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+	if links.created != nil {
+		t.Error("expected no link to be created")
+	}
+	got := authz.got[0]
+	if got.UserID != "user-1" || got.Action != "read" || got.Resource != "apps" || got.ClusterID != "c1" || got.Namespace != "web" {
+		t.Errorf("unexpected RBAC request: %+v", got)
+	}
+}
+
+func TestHandleCreate(t *testing.T) {
+	links := &fakeLinks{}
+	h := &Handlers{links: links, authz: &fakeAuthz{allow: true}}
+	rec := httptest.NewRecorder()
+	h.handleCreate(rec, newRequest(http.MethodPost, "/api/share-links", `{"cluster_id":"c1","resource":"pods","expires_in_minutes":30}`, "user-1"))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if links.created.CreatedBy != "user-1" {
+		t.Errorf("expected the link to be attributed to user-1, got %q", links.created.CreatedBy)
+	}
+	if d := time.Until(links.created.ExpiresAt); d < 29*time.Minute || d > 31*time.Minute {
+		t.Errorf("expected the link to expire in 30 minutes, got %v", d)
+	}
+	var resp CreateResponse
+	json.Unmarshal(rec.Body.Bytes(), &resp) //nolint:errcheck
+	if resp.Token == "" {
+		t.Error("expected the token in the response")
+	}
+}
+
+func TestHandleRedeem(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		err      error
+		wantCode int
+	}{
+		{"missing token", `{}`, nil, http.StatusBadRequest},
+		{"invalid link", `{"token":"argus_share_x"}`, ErrInvalidLink, http.StatusUnauthorized},
+		{"valid link", `{"token":"argus_share_x"}`, nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handlers{links: &fakeLinks{redeemErr: tt.err}}
+			rec := httptest.NewRecorder()
+			h.handleRedeem(rec, newRequest(http.MethodPost, "/api/share-links/redeem", tt.body, ""))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d", tt.wantCode, rec.Code)
+			}
+		})
+	}
+}
+
+func TestHandleRevokeNotFound(t *testing.T) {
+	h := &Handlers{links: &fakeLinks{revokeErr: ErrLinkNotFound}}
+	r := mux.NewRouter()
+	r.HandleFunc("/api/share-links/{id}", h.handleRevoke).Methods(http.MethodDelete)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, newRequest(http.MethodDelete, "/api/share-links/link-1", "", "user-2"))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestHandleSession(t *testing.T) {
+	h := &Handlers{links: &fakeLinks{}}
+
+	rec := httptest.NewRecorder()
+	h.handleSession(rec, newRequest(http.MethodGet, sessionPath, "", "user-1"))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a user, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.handleSession(rec, newRequest(http.MethodGet, sessionPath, "", auth.ShareLinkPrincipalPrefix+"link-1"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a share session, got %d", rec.Code)
+	}
+	var resp map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &resp) //nolint:errcheck
+	if resp["share_link_id"] != "link-1" || resp["resource"] != "pods" {
+		t.Errorf("unexpected session scope: %v", resp)
+	}
+}
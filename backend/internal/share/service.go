@@ -0,0 +1,266 @@
+package share
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+)
+
+const (
+	// sessionDuration caps how long one redemption lasts. Sessions never
+	// outlive their link.
+	sessionDuration = time.Hour
+
+	defaultLinkLifetime = 24 * time.Hour
+	maxLinkLifetime     = 7 * 24 * time.Hour
+)
+
+var (
+	ErrLinkNotFound    = errors.New("share link not found")
+	ErrClusterNotFound = errors.New("cluster not found")
+	ErrInvalidLink     = errors.New("invalid, expired or revoked share link")
+	ErrInvalidSession  = errors.New("invalid, expired or revoked share session")
+)
+
+// Link is a read-only share of one resource type in a cluster, optionally
+// narrowed to a namespace.
+type Link struct {
+	ID             string     `json:"id"`
+	TokenPrefix    string     `json:"token_prefix"`
+	Description    string     `json:"description"`
+	ClusterID      string     `json:"cluster_id"`
+	Namespace      *string    `json:"namespace,omitempty"`
+	Resource       string     `json:"resource"`
+	CreatedBy      *string    `json:"created_by,omitempty"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	RedeemCount    int        `json:"redeem_count"`
+	LastRedeemedAt *time.Time `json:"last_redeemed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// CreateRequest describes a new share link.
+type CreateRequest struct {
+	Description string
+	ClusterID   string
+	Namespace   *string
+	Resource    string
+	CreatedBy   string
+	ExpiresAt   time.Time
+}
+
+// CreateResponse carries the plaintext link token, which is only ever
+// returned once.
+type CreateResponse struct {
+	Link
+	Token string `json:"token"`
+}
+
+// Session is the result of redeeming a link: a bearer token for read-only
+// requests within the link's scope.
+type Session struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	LinkID    string    `json:"share_link_id"`
+	ClusterID string    `json:"cluster_id"`
+	Namespace *string   `json:"namespace,omitempty"`
+	Resource  string    `json:"resource"`
+	// CreatedBy is the link's creator, kept for the redemption audit entry.
+	CreatedBy *string `json:"-"`
+}
+
+// Service manages share links and the sessions redeemed from them.
+type Service struct {
+	pool *pgxpool.Pool
+}
+
+func NewService(pool *pgxpool.Pool) *Service {
+	return &Service{pool: pool}
+}
+
+// generateToken returns a random token with the given prefix and its
+// displayable prefix.
+func generateToken(prefix string) (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := prefix + hex.EncodeToString(b)
+	return token, token[:len(prefix)+8], nil
+}
+
+// hashToken returns the hex SHA-256 of a token. Tokens carry 256 bits of
+// randomness, so a fast unsalted hash is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+const linkColumns = `id, token_prefix, description, cluster_id, namespace, resource, created_by,
+	expires_at, revoked_at, redeem_count, last_redeemed_at, created_at`
+
+func scanLink(row pgx.Row, l *Link) error {
+	return row.Scan(&l.ID, &l.TokenPrefix, &l.Description, &l.ClusterID, &l.Namespace, &l.Resource, &l.CreatedBy,
+		&l.ExpiresAt, &l.RevokedAt, &l.RedeemCount, &l.LastRedeemedAt, &l.CreatedAt)
+}
+
+func (s *Service) Create(ctx context.Context, req CreateRequest) (*CreateResponse, error) {
+	token, prefix, err := generateToken(auth.ShareLinkTokenPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate share link token: %w", err)
+	}
+
+	var link Link
+	err = scanLink(s.pool.QueryRow(ctx,
+		`INSERT INTO share_links (token_hash, token_prefix, description, cluster_id, namespace, resource, created_by, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING `+linkColumns,
+		hashToken(token), prefix, req.Description, req.ClusterID, req.Namespace, req.Resource, req.CreatedBy, req.ExpiresAt,
+	), &link)
+	if err != nil {
+		if strings.Contains(err.Error(), "share_links_cluster_id_fkey") || strings.Contains(err.Error(), "invalid input syntax for type uuid") {
+			return nil, ErrClusterNotFound
+		}
+		return nil, fmt.Errorf("failed to create share link: %w", err)
+	}
+	return &CreateResponse{Link: link, Token: token}, nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*Link, error) {
+	var link Link
+	err := scanLink(s.pool.QueryRow(ctx, `SELECT `+linkColumns+` FROM share_links WHERE id = $1`, id), &link)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrLinkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+	return &link, nil
+}
+
+// ListByCreator returns the links a user created, newest first.
+func (s *Service) ListByCreator(ctx context.Context, userID string) ([]Link, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT `+linkColumns+` FROM share_links WHERE created_by = $1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []Link{}
+	for rows.Next() {
+		var link Link
+		if err := scanLink(rows, &link); err != nil {
+			return nil, fmt.Errorf("failed to scan share link: %w", err)
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate share links: %w", err)
+	}
+	return links, nil
+}
+
+// Revoke disables a link created by userID, ending every session redeemed
+// from it. The row is kept so audit entries still resolve.
+func (s *Service) Revoke(ctx context.Context, id, userID string) (*Link, error) {
+	var link Link
+	err := scanLink(s.pool.QueryRow(ctx,
+		`UPDATE share_links SET revoked_at = NOW()
+		 WHERE id = $1 AND created_by = $2 AND revoked_at IS NULL
+		 RETURNING `+linkColumns, id, userID), &link)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrLinkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke share link: %w", err)
+	}
+	return &link, nil
+}
+
+// Redeem exchanges a link token for a session token. The session ends when
+// the link expires, after sessionDuration, or when the link is revoked,
+// whichever comes first.
+func (s *Service) Redeem(ctx context.Context, rawToken string) (*Session, error) {
+	if !strings.HasPrefix(rawToken, auth.ShareLinkTokenPrefix) {
+		return nil, ErrInvalidLink
+	}
+	token, _, err := generateToken(auth.ShareSessionTokenPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate share session token: %w", err)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	sess := Session{Token: token}
+	var linkExpiresAt time.Time
+	err = tx.QueryRow(ctx,
+		`UPDATE share_links SET redeem_count = redeem_count + 1, last_redeemed_at = NOW()
+		 WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		 RETURNING id, cluster_id, namespace, resource, created_by, expires_at`,
+		hashToken(rawToken),
+	).Scan(&sess.LinkID, &sess.ClusterID, &sess.Namespace, &sess.Resource, &sess.CreatedBy, &linkExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidLink
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem share link: %w", err)
+	}
+
+	sess.ExpiresAt = time.Now().Add(sessionDuration)
+	if linkExpiresAt.Before(sess.ExpiresAt) {
+		sess.ExpiresAt = linkExpiresAt
+	}
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO share_sessions (link_id, token_hash, expires_at) VALUES ($1, $2, $3)`,
+		sess.LinkID, hashToken(token), sess.ExpiresAt,
+	); err != nil {
+		return nil, fmt.Errorf("failed to create share session: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit share session: %w", err)
+	}
+	return &sess, nil
+}
+
+// ValidateSession resolves a session token to share claims. Sessions of
+// revoked or expired links are rejected.
+func (s *Service) ValidateSession(ctx context.Context, rawToken string) (*auth.Claims, error) {
+	if !strings.HasPrefix(rawToken, auth.ShareSessionTokenPrefix) {
+		return nil, ErrInvalidSession
+	}
+
+	var linkID string
+	err := s.pool.QueryRow(ctx,
+		`SELECT l.id FROM share_sessions s JOIN share_links l ON l.id = s.link_id
+		 WHERE s.token_hash = $1 AND s.expires_at > NOW()
+		   AND l.revoked_at IS NULL AND l.expires_at > NOW()`,
+		hashToken(rawToken),
+	).Scan(&linkID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidSession
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up share session: %w", err)
+	}
+
+	return &auth.Claims{
+		UserID:    auth.ShareLinkPrincipalPrefix + linkID,
+		Email:     auth.ShareLinkPrincipalPrefix + linkID,
+		TokenType: auth.TokenTypeShareSession,
+	}, nil
+}
@@ -3,15 +3,18 @@ package sse
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"runtime/debug"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/darkden-lab/argus/backend/internal/ai"
 	"github.com/darkden-lab/argus/backend/internal/ai/tools"
 	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 const (
@@ -90,35 +93,51 @@ type sendMessageRequest struct {
 	ConversationID string                 `json:"conversation_id"`
 	Context        map[string]interface{} `json:"context"`
 	AgentID        string                 `json:"agent_id"`
+	Provider       string                 `json:"provider"` // Named AI provider to answer with; empty uses the default
 }
 
 // HandleSendMessage handles POST /api/ai/messages — starts AI processing.
 func (h *AIHandler) HandleSendMessage(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok || claims == nil {
-		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 	userID := claims.UserID
 
 	var req sendMessageRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	// Validate lengths
-	if len(req.Content) > maxContentLen || len(req.ConversationID) > maxIDLen || len(req.AgentID) > maxIDLen {
-		http.Error(w, `{"error":"input too long"}`, http.StatusBadRequest)
+	if len(req.Content) > maxContentLen || len(req.ConversationID) > maxIDLen || len(req.AgentID) > maxIDLen || len(req.Provider) > maxIDLen {
+		httputil.WriteError(w, http.StatusBadRequest, "input too long")
 		return
 	}
 	if req.Content == "" {
-		http.Error(w, `{"error":"content is required"}`, http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, "content is required")
+		return
+	}
+
+	// Shed load while the selected provider's circuit breaker is open
+	breaker, err := h.aiService.ProviderBreaker(req.Provider)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := breaker.Check(); err != nil {
+		var unavailable *ai.UnavailableError
+		if errors.As(err, &unavailable) {
+			w.Header().Set("Retry-After", strconv.Itoa(unavailable.RetryAfterSeconds()))
+		}
+		httputil.WriteErrorCode(w, http.StatusServiceUnavailable, httputil.CodeAIUnavailable, err.Error())
 		return
 	}
 
 	// Build chat context
-	var chatCtx ai.ChatContext
+	chatCtx := ai.ChatContext{Provider: req.Provider}
 	if req.Context != nil {
 		if cid, ok := req.Context["cluster_id"].(string); ok {
 			chatCtx.ClusterID = cid
@@ -134,7 +153,7 @@ func (h *AIHandler) HandleSendMessage(w http.ResponseWriter, r *http.Request) {
 	if req.ConversationID != "" && h.historyStore != nil {
 		owned, ownerErr := h.historyStore.VerifyConversationOwnership(ctx, req.ConversationID, userID)
 		if ownerErr != nil || !owned {
-			http.Error(w, `{"error":"conversation not found"}`, http.StatusNotFound)
+			httputil.WriteError(w, http.StatusNotFound, "conversation not found")
 			return
 		}
 	}
@@ -196,7 +215,7 @@ func (h *AIHandler) processMessage(userID, conversationID, content string, chatC
 
 	stream, err := h.aiService.ProcessMessageStream(ctx, userID, conversationID, content, chatCtx)
 	if err != nil {
-		h.hub.SendToUser(userID, Event{Type: "ai:error", Data: map[string]string{"error": err.Error(), "content": err.Error()}})
+		h.hub.SendToUser(userID, Event{Type: "ai:error", Data: aiErrorData(err)})
 		h.hub.SendToUser(userID, Event{Type: "ai:stream_end", Data: map[string]interface{}{}})
 		return
 	}
@@ -248,8 +267,10 @@ func (h *AIHandler) processMessage(userID, conversationID, content string, chatC
 				Type: "ai:confirm_request",
 				Data: map[string]interface{}{
 					"confirmation_id": req.ID,
+					"tool_call_id":    req.ToolCall.ID,
 					"tool_name":       req.ToolCall.Name,
 					"tool_args":       req.ToolCall.Arguments,
+					"classification":  req.Classification,
 					"content":         "Confirm action: " + req.ToolCall.Name,
 				},
 			})
@@ -259,7 +280,7 @@ func (h *AIHandler) processMessage(userID, conversationID, content string, chatC
 			ctx, userID, conversationID, content, chatCtx, contentBuf, validToolCalls, confirmNotify,
 		)
 		if err != nil {
-			h.hub.SendToUser(userID, Event{Type: "ai:error", Data: map[string]string{"error": err.Error(), "content": err.Error()}})
+			h.hub.SendToUser(userID, Event{Type: "ai:error", Data: aiErrorData(err)})
 		} else {
 			h.hub.SendToUser(userID, Event{Type: "ai:stream_delta", Data: map[string]string{"content": resp.Message.Content}})
 			h.aiService.SaveMessage(ctx, conversationID, ai.Message{Role: ai.RoleAssistant, Content: resp.Message.Content})
@@ -271,6 +292,18 @@ func (h *AIHandler) processMessage(userID, conversationID, content string, chatC
 	h.hub.SendToUser(userID, Event{Type: "ai:stream_end", Data: map[string]interface{}{}})
 }
 
+// aiErrorData is the payload of an ai:error event. When the provider's
+// circuit breaker refused the call it says when to retry.
+func aiErrorData(err error) map[string]interface{} {
+	data := map[string]interface{}{"error": err.Error(), "content": err.Error()}
+	var unavailable *ai.UnavailableError
+	if errors.As(err, &unavailable) {
+		data["code"] = httputil.CodeAIUnavailable
+		data["retry_after_seconds"] = unavailable.RetryAfterSeconds()
+	}
+	return data
+}
+
 type confirmRequest struct {
 	ConfirmationID string `json:"confirmation_id"`
 	Approved       bool   `json:"approved"`
@@ -280,26 +313,26 @@ type confirmRequest struct {
 func (h *AIHandler) HandleConfirmAction(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok || claims == nil {
-		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	var req confirmRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	mgr := h.aiService.GetConfirmationManager()
-	var err error
-	if req.Approved {
-		err = mgr.Approve(req.ConfirmationID)
-	} else {
-		err = mgr.Reject(req.ConfirmationID)
+	if req.ConfirmationID == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "confirmation_id is required")
+		return
 	}
-	if err != nil {
+
+	// Only the user the confirmation was issued to may resolve it
+	mgr := h.aiService.GetConfirmationManager()
+	if err := mgr.Resolve(req.ConfirmationID, claims.UserID, req.Approved); err != nil {
 		log.Printf("sse/ai: confirm_action error for %s: %v", req.ConfirmationID, err)
-		http.Error(w, `{"error":"failed to process confirmation: `+err.Error()+`"}`, http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusNotFound, "confirmation not found or already resolved")
 		return
 	}
 
@@ -325,29 +358,29 @@ type startTaskRequest struct {
 func (h *AIHandler) HandleStartTask(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok || claims == nil {
-		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 	userID := claims.UserID
 
 	var req startTaskRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	if !h.aiService.HasAgentStore() {
-		http.Error(w, `{"error":"agent system not available"}`, http.StatusServiceUnavailable)
+		httputil.WriteError(w, http.StatusServiceUnavailable, "agent system not available")
 		return
 	}
 
 	if req.AgentID == "" {
-		http.Error(w, `{"error":"agent_id is required"}`, http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, "agent_id is required")
 		return
 	}
 
 	if len(req.AgentID) > maxIDLen || len(req.TaskTitle) > maxContentLen || len(req.Content) > maxContentLen {
-		http.Error(w, `{"error":"input exceeds maximum allowed length"}`, http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, "input exceeds maximum allowed length")
 		return
 	}
 
@@ -355,7 +388,7 @@ func (h *AIHandler) HandleStartTask(w http.ResponseWriter, r *http.Request) {
 
 	agent, err := h.aiService.GetAgent(ctx, req.AgentID)
 	if err != nil {
-		http.Error(w, `{"error":"agent not found"}`, http.StatusNotFound)
+		httputil.WriteError(w, http.StatusNotFound, "agent not found")
 		return
 	}
 
@@ -371,7 +404,7 @@ func (h *AIHandler) HandleStartTask(w http.ResponseWriter, r *http.Request) {
 		Status:  "pending",
 	}
 	if createErr := h.aiService.CreateTask(ctx, task); createErr != nil {
-		http.Error(w, `{"error":"failed to create task: `+createErr.Error()+`"}`, http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to create task: "+createErr.Error())
 		return
 	}
 
@@ -443,26 +476,26 @@ func (h *AIHandler) HandleStartTask(w http.ResponseWriter, r *http.Request) {
 func (h *AIHandler) HandleCancelTask(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok || claims == nil {
-		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 	userID := claims.UserID
 	taskID := mux.Vars(r)["taskID"]
 
 	if h.taskRunner == nil || !h.aiService.HasAgentStore() {
-		http.Error(w, `{"error":"agent system not available"}`, http.StatusServiceUnavailable)
+		httputil.WriteError(w, http.StatusServiceUnavailable, "agent system not available")
 		return
 	}
 
 	store := h.aiService.GetAgentStore()
 	if store == nil {
-		http.Error(w, `{"error":"agent system not available"}`, http.StatusServiceUnavailable)
+		httputil.WriteError(w, http.StatusServiceUnavailable, "agent system not available")
 		return
 	}
 
 	task, err := store.GetTask(r.Context(), taskID)
 	if err != nil || task.UserID != userID {
-		http.Error(w, `{"error":"task not found"}`, http.StatusNotFound)
+		httputil.WriteError(w, http.StatusNotFound, "task not found")
 		return
 	}
 
@@ -490,7 +523,7 @@ func truncateForTitle(content string) string {
 func writeSSEError(w http.ResponseWriter, eventType, message string) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		http.Error(w, message, http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, message)
 		return
 	}
 
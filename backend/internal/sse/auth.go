@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 // Authenticate extracts and validates auth credentials from an HTTP request.
@@ -33,6 +34,11 @@ func Authenticate(r *http.Request, jwtService *auth.JWTService, apiKeyService *a
 		}
 	}
 
+	// Try JWT from the session cookie (cookie-mode OIDC sign-in)
+	if token == "" {
+		token = auth.AccessTokenFromCookie(r)
+	}
+
 	if token == "" {
 		return nil
 	}
@@ -48,7 +54,7 @@ func Authenticate(r *http.Request, jwtService *auth.JWTService, apiKeyService *a
 func RequireAuth(w http.ResponseWriter, r *http.Request, jwtService *auth.JWTService, apiKeyService *auth.APIKeyService) *auth.Claims {
 	claims := Authenticate(r, jwtService, apiKeyService)
 	if claims == nil {
-		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return nil
 	}
 	return claims
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 // Event represents a Server-Sent Event.
@@ -71,7 +73,7 @@ func NewHub() *Hub {
 func (h *Hub) Register(userID string, w http.ResponseWriter) *Client {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, "streaming not supported")
 		return nil
 	}
 
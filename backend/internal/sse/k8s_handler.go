@@ -8,6 +8,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/darkden-lab/argus/backend/internal/auth"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 	"github.com/darkden-lab/argus/backend/internal/ws"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -86,21 +87,27 @@ type watchRequest struct {
 	Namespace string `json:"namespace"`
 }
 
-// HandleSubscribe subscribes the user's SSE clients to a K8s watch.
+// HandleSubscribe subscribes the user's SSE clients to a K8s watch. The
+// user needs the permission a WebSocket watch on the resource would.
 func (h *K8sHandler) HandleSubscribe(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok || claims == nil {
-		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	var req watchRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 	if req.Cluster == "" || req.Resource == "" {
-		http.Error(w, `{"error":"cluster and resource are required"}`, http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, "cluster and resource are required")
+		return
+	}
+
+	if !h.wsHub.Authorize(r.Context(), claims.UserID, ws.ResourceSpec(req.Cluster, req.Resource, req.Namespace)) {
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
 		return
 	}
 
@@ -120,13 +127,13 @@ func (h *K8sHandler) HandleSubscribe(w http.ResponseWriter, r *http.Request) {
 func (h *K8sHandler) HandleUnsubscribe(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok || claims == nil {
-		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	var req watchRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
@@ -146,33 +153,38 @@ type namespacesRequest struct {
 	Cluster string `json:"cluster"`
 }
 
-// HandleListNamespaces lists namespaces for a cluster.
+// HandleListNamespaces lists namespaces for a cluster. The user needs read
+// permission on namespaces across the cluster.
 func (h *K8sHandler) HandleListNamespaces(w http.ResponseWriter, r *http.Request) {
 	claims, ok := auth.ClaimsFromContext(r.Context())
 	if !ok || claims == nil {
-		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	var req namespacesRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 	if req.Cluster == "" {
-		http.Error(w, `{"error":"cluster is required"}`, http.StatusBadRequest)
+		httputil.WriteError(w, http.StatusBadRequest, "cluster is required")
+		return
+	}
+	if !h.wsHub.Authorize(r.Context(), claims.UserID, ws.WatchSpec{Cluster: req.Cluster, Version: "v1", Resource: "namespaces"}) {
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
 		return
 	}
 
 	k8sClient, err := h.clusterMgr.GetClient(req.Cluster)
 	if err != nil {
-		http.Error(w, `{"error":"cluster not available: `+err.Error()+`"}`, http.StatusNotFound)
+		httputil.WriteError(w, http.StatusNotFound, "cluster not available: "+err.Error())
 		return
 	}
 
 	nsList, err := k8sClient.Clientset.CoreV1().Namespaces().List(r.Context(), metav1.ListOptions{})
 	if err != nil {
-		http.Error(w, `{"error":"failed to list namespaces: `+err.Error()+`"}`, http.StatusInternalServerError)
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to list namespaces: "+err.Error())
 		return
 	}
 
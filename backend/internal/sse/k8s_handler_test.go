@@ -0,0 +1,45 @@
+package sse
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/ws"
+)
+
+func TestK8sHandler_SubscribeNamespaceScoped(t *testing.T) {
+	wsHub := ws.NewHub()
+	wsHub.SetWatchSource(nil, func(ctx context.Context, userID string, spec ws.WatchSpec) bool {
+		return spec.Namespace == "team-a"
+	})
+	hub := NewHub()
+	h := NewK8sHandler(hub, nil, nil, wsHub, nil)
+	client := hub.Register("u1", newFlushRecorder())
+	defer hub.Unregister(client.ID)
+
+	subscribe := func(namespace string) int {
+		body := `{"cluster":"c1","resource":"v1/pods","namespace":"` + namespace + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/k8s/watch/subscribe", strings.NewReader(body))
+		req = req.WithContext(auth.ContextWithClaims(req.Context(), &auth.Claims{UserID: "u1"}))
+		w := httptest.NewRecorder()
+		h.HandleSubscribe(w, req)
+		return w.Code
+	}
+
+	if code := subscribe("team-b"); code != http.StatusForbidden {
+		t.Errorf("expected 403 for another namespace, got %d", code)
+	}
+	if client.IsSubscribed("c1/v1/pods/team-b") {
+		t.Error("a refused subscription must not be recorded")
+	}
+	if code := subscribe("team-a"); code != http.StatusOK {
+		t.Errorf("expected 200 for the user's namespace, got %d", code)
+	}
+	if !client.IsSubscribed("c1/v1/pods/team-a") {
+		t.Error("expected the subscription in the user's namespace")
+	}
+}
@@ -0,0 +1,387 @@
+package terminal
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/darkden-lab/argus/backend/internal/audit"
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+const (
+	maxFileUploadSize   = 100 * 1024 * 1024 // 100 MB
+	maxFileDownloadSize = 500 * 1024 * 1024 // 500 MB
+	fileTransferTimeout = 10 * time.Minute
+	maxStderrCapture    = 4096
+)
+
+var errDownloadTooLarge = errors.New("download exceeds size limit")
+
+// podExecutor runs a command in a pod container, streaming stdin and stdout.
+type podExecutor func(ctx context.Context, client *cluster.ClusterClient, namespace, pod, container string, command []string, stdin io.Reader, stdout, stderr io.Writer) error
+
+// FileTransferHandler copies files into and out of pod containers, like
+// `kubectl cp`: a tar stream is piped through the exec subresource, so the
+// container image must provide tar.
+type FileTransferHandler struct {
+	clusterMgr *cluster.Manager
+	rbacEngine *rbac.Engine
+	auditStore *audit.Store
+	exec       podExecutor
+}
+
+// NewFileTransferHandler creates a file transfer handler. auditStore may be
+// nil, in which case transfers are only logged.
+func NewFileTransferHandler(cm *cluster.Manager, engine *rbac.Engine, auditStore *audit.Store) *FileTransferHandler {
+	return &FileTransferHandler{clusterMgr: cm, rbacEngine: engine, auditStore: auditStore, exec: streamPodExec}
+}
+
+// RegisterRoutes wires the file transfer endpoints.
+func (h *FileTransferHandler) RegisterRoutes(r *mux.Router) {
+	files := "/api/clusters/{clusterID}/namespaces/{namespace}/pods/{pod}/files"
+	r.HandleFunc(files, h.Download).Methods(http.MethodGet)
+	r.HandleFunc(files, h.Upload).Methods(http.MethodPut)
+}
+
+// transferTarget is a validated file location inside a pod container.
+type transferTarget struct {
+	clusterID string
+	namespace string
+	pod       string
+	container string
+	path      string
+	client    *cluster.ClusterClient
+}
+
+// Upload handles PUT .../files?path=&container= with the raw file as the
+// request body. The body must declare its Content-Length, which becomes the
+// tar entry size; existing files are overwritten.
+func (h *FileTransferHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	target, ok := h.prepare(w, r)
+	if !ok {
+		return
+	}
+
+	dir, name := path.Split(target.path)
+	if name == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "path must name a file, not a directory")
+		return
+	}
+	if r.ContentLength < 0 {
+		httputil.WriteError(w, http.StatusLengthRequired, "Content-Length is required")
+		return
+	}
+	if r.ContentLength > maxFileUploadSize {
+		httputil.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("file too large (max %d bytes)", maxFileUploadSize))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), fileTransferTimeout)
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(writeSingleFileTar(pw, name, r.ContentLength, r.Body))
+	}()
+
+	stderr := &cappedBuffer{limit: maxStderrCapture}
+	err := h.exec(ctx, target.client, target.namespace, target.pod, target.container,
+		[]string{"tar", "-xmf", "-", "-C", dir}, pr, io.Discard, stderr)
+	// Unblock the writer if tar exited early, and stop reading the body
+	// before returning.
+	pr.Close()
+	<-done
+	h.recordTransfer(r, target, "pod_file_upload", r.ContentLength, err)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, execErrorMessage("upload failed", err, stderr))
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusCreated, map[string]interface{}{
+		"status": "uploaded",
+		"path":   target.path,
+		"size":   r.ContentLength,
+	})
+}
+
+// Download handles GET .../files?path=&container= and streams the file or
+// directory as a tar archive. Transfers above the size limit are cut off,
+// leaving the client with an incomplete archive.
+func (h *FileTransferHandler) Download(w http.ResponseWriter, r *http.Request) {
+	target, ok := h.prepare(w, r)
+	if !ok {
+		return
+	}
+	p := strings.TrimSuffix(target.path, "/")
+	if p == "" {
+		httputil.WriteError(w, http.StatusBadRequest, "path must not be the root directory")
+		return
+	}
+	dir, name := path.Dir(p), path.Base(p)
+
+	ctx, cancel := context.WithTimeout(r.Context(), fileTransferTimeout)
+	defer cancel()
+
+	out := &downloadWriter{w: w, limit: maxFileDownloadSize, filename: name + ".tar"}
+	stderr := &cappedBuffer{limit: maxStderrCapture}
+	err := h.exec(ctx, target.client, target.namespace, target.pod, target.container,
+		[]string{"tar", "cf", "-", "-C", dir, name}, nil, out, stderr)
+	h.recordTransfer(r, target, "pod_file_download", out.written, err)
+	if err == nil {
+		if !out.started {
+			// Empty output still has to be a response.
+			out.start()
+		}
+		return
+	}
+
+	if out.started {
+		// Headers are gone; all we can do is stop the stream.
+		log.Printf("terminal: download of %s from %s/%s aborted after %d bytes: %v",
+			target.path, target.namespace, target.pod, out.written, err)
+		return
+	}
+	httputil.WriteError(w, http.StatusInternalServerError, execErrorMessage("download failed", err, stderr))
+}
+
+// prepare validates the request, checks the caller may exec into the pod and
+// resolves a direct cluster client.
+func (h *FileTransferHandler) prepare(w http.ResponseWriter, r *http.Request) (*transferTarget, bool) {
+	vars := mux.Vars(r)
+	q := r.URL.Query()
+	target := &transferTarget{
+		clusterID: vars["clusterID"],
+		namespace: vars["namespace"],
+		pod:       vars["pod"],
+		container: q.Get("container"),
+	}
+
+	if len(validation.IsDNS1123Label(target.namespace)) > 0 || len(validation.IsDNS1123Subdomain(target.pod)) > 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid namespace or pod name")
+		return nil, false
+	}
+	if target.container != "" && len(validation.IsDNS1123Label(target.container)) > 0 {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid container name")
+		return nil, false
+	}
+	cleaned, err := cleanTransferPath(q.Get("path"))
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	target.path = cleaned
+
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
+		return nil, false
+	}
+	// Copying files is exec into the pod, so it needs the same permission
+	// as opening a shell there.
+	allowed, err := h.rbacEngine.Evaluate(r.Context(), rbac.Request{
+		UserID:    claims.UserID,
+		Action:    "write",
+		Resource:  "terminal",
+		ClusterID: target.clusterID,
+		Namespace: target.namespace,
+	})
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, "permission check failed")
+		return nil, false
+	}
+	if !allowed {
+		httputil.WriteError(w, http.StatusForbidden, "insufficient permissions")
+		return nil, false
+	}
+
+	client, err := h.clusterMgr.GetClient(target.clusterID)
+	if err != nil {
+		if agentSrv := h.clusterMgr.GetAgentServer(); agentSrv != nil && agentSrv.IsAgentConnected(target.clusterID) {
+			// The agent protocol proxies plain API requests; exec needs a
+			// bidirectional stream it does not carry yet.
+			httputil.WriteError(w, http.StatusNotImplemented, "file transfer is not supported for agent-connected clusters")
+			return nil, false
+		}
+		httputil.WriteErrorCode(w, http.StatusNotFound, httputil.CodeNotConnected, "cluster not found or not connected")
+		return nil, false
+	}
+	target.client = client
+	return target, true
+}
+
+// recordTransfer writes an audit entry for a finished or failed transfer.
+func (h *FileTransferHandler) recordTransfer(r *http.Request, target *transferTarget, action string, size int64, transferErr error) {
+	status := "completed"
+	if transferErr != nil {
+		status = "failed"
+	}
+	log.Printf("terminal audit: %s cluster=%s pod=%s/%s container=%s path=%q bytes=%d status=%s",
+		action, target.clusterID, target.namespace, target.pod, target.container, target.path, size, status)
+	if h.auditStore == nil {
+		return
+	}
+
+	details, _ := json.Marshal(map[string]interface{}{
+		"namespace": target.namespace,
+		"pod":       target.pod,
+		"container": target.container,
+		"path":      target.path,
+		"bytes":     size,
+		"status":    status,
+	})
+	userID, serviceAccountID := audit.ActorFromContext(r.Context())
+	ctx, cancel := audit.DetachedContext(r.Context())
+	defer cancel()
+	if err := h.auditStore.Insert(ctx, userID, serviceAccountID, &target.clusterID, action, r.URL.Path, details); err != nil {
+		log.Printf("terminal: failed to audit %s: %v", action, err)
+	}
+}
+
+// cleanTransferPath validates an absolute container path and returns it in
+// clean form.
+func cleanTransferPath(p string) (string, error) {
+	if p == "" {
+		return "", errors.New("path is required")
+	}
+	if !strings.HasPrefix(p, "/") {
+		return "", errors.New("path must be absolute")
+	}
+	if strings.ContainsRune(p, 0) {
+		return "", errors.New("invalid path")
+	}
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		// Keep the trailing slash so uploads can reject directory targets.
+		cleaned += "/"
+	}
+	return cleaned, nil
+}
+
+// writeSingleFileTar writes a tar archive holding one regular file named name
+// with exactly size bytes read from content.
+func writeSingleFileTar(w io.Writer, name string, size int64, content io.Reader) error {
+	tw := tar.NewWriter(w)
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0o644,
+		Size:     size,
+		ModTime:  time.Now(),
+	}); err != nil {
+		return err
+	}
+	n, err := io.Copy(tw, io.LimitReader(content, size))
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("upload body ended after %d of %d bytes", n, size)
+	}
+	return tw.Close()
+}
+
+// downloadWriter sends the tar stream to the client, deferring the response
+// headers until the first byte so that early exec failures can still be
+// reported as errors, and cutting the stream off at limit bytes.
+type downloadWriter struct {
+	w        http.ResponseWriter
+	limit    int64
+	filename string
+	written  int64
+	started  bool
+}
+
+func (d *downloadWriter) start() {
+	d.started = true
+	d.w.Header().Set("Content-Type", "application/x-tar")
+	d.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", d.filename))
+	d.w.WriteHeader(http.StatusOK)
+}
+
+func (d *downloadWriter) Write(p []byte) (int, error) {
+	if d.written+int64(len(p)) > d.limit {
+		return 0, errDownloadTooLarge
+	}
+	if !d.started {
+		d.start()
+	}
+	n, err := d.w.Write(p)
+	d.written += int64(n)
+	return n, err
+}
+
+// cappedBuffer keeps the first limit bytes written to it, for error messages.
+type cappedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	if room := c.limit - c.buf.Len(); room > 0 {
+		if len(p) > room {
+			c.buf.Write(p[:room])
+		} else {
+			c.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func execErrorMessage(prefix string, err error, stderr *cappedBuffer) string {
+	if errors.Is(err, errDownloadTooLarge) {
+		return fmt.Sprintf("%s: exceeds %d bytes", prefix, maxFileDownloadSize)
+	}
+	if msg := strings.TrimSpace(stderr.buf.String()); msg != "" {
+		return fmt.Sprintf("%s: %s", prefix, msg)
+	}
+	return fmt.Sprintf("%s: %v", prefix, err)
+}
+
+// streamPodExec runs command in the container without a TTY, so binary
+// stdin/stdout pass through unmodified.
+func streamPodExec(ctx context.Context, client *cluster.ClusterClient, namespace, pod, container string, command []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	req := client.Clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod).
+		Namespace(namespace).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+			TTY:       false,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(client.RestConfig, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("exec: create executor: %w", err)
+	}
+	return executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+}
@@ -0,0 +1,158 @@
+package terminal
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestCleanTransferPath(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"/tmp/app.log", "/tmp/app.log", false},
+		{"/tmp/../etc/./hosts", "/etc/hosts", false},
+		{"/var/data/", "/var/data/", false},
+		{"/", "/", false},
+		{"", "", true},
+		{"relative/file", "", true},
+		{"/tmp/a\x00b", "", true},
+	}
+	for _, tt := range tests {
+		got, err := cleanTransferPath(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("cleanTransferPath(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("cleanTransferPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteSingleFileTar(t *testing.T) {
+	var buf bytes.Buffer
+	content := "hello from argus\n"
+	if err := writeSingleFileTar(&buf, "greeting.txt", int64(len(content)), strings.NewReader(content)); err != nil {
+		t.Fatalf("writeSingleFileTar failed: %v", err)
+	}
+
+	tr := tar.NewReader(&buf)
+	hdr, err := tr.Next()
+	if err != nil {
+		t.Fatalf("reading header failed: %v", err)
+	}
+	if hdr.Name != "greeting.txt" || hdr.Size != int64(len(content)) || hdr.Typeflag != tar.TypeReg {
+		t.Errorf("unexpected header %+v", hdr)
+	}
+	data, _ := io.ReadAll(tr)
+	if string(data) != content {
+		t.Errorf("unexpected content %q", data)
+	}
+	if _, err := tr.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected a single entry, got %v", err)
+	}
+}
+
+func TestWriteSingleFileTar_ShortBody(t *testing.T) {
+	err := writeSingleFileTar(io.Discard, "f", 10, strings.NewReader("short"))
+	if err == nil {
+		t.Error("expected error when the body is shorter than its declared size")
+	}
+}
+
+func TestDownloadWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	out := &downloadWriter{w: rec, limit: 8, filename: "logs.tar"}
+
+	if _, err := out.Write([]byte("12345")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rec.Header().Get("Content-Type") != "application/x-tar" {
+		t.Errorf("expected tar content type, got %q", rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Header().Get("Content-Disposition"), "logs.tar") {
+		t.Errorf("unexpected disposition %q", rec.Header().Get("Content-Disposition"))
+	}
+	if _, err := out.Write([]byte("6789")); !errors.Is(err, errDownloadTooLarge) {
+		t.Errorf("expected errDownloadTooLarge, got %v", err)
+	}
+	if out.written != 5 || rec.Body.String() != "12345" {
+		t.Errorf("expected only the first write to pass, got %d bytes %q", out.written, rec.Body.String())
+	}
+}
+
+func TestDownloadWriter_DefersHeaders(t *testing.T) {
+	rec := httptest.NewRecorder()
+	out := &downloadWriter{w: rec, limit: 8, filename: "x.tar"}
+	if out.started || rec.Header().Get("Content-Type") != "" {
+		t.Error("headers must not be sent before the first byte")
+	}
+}
+
+func TestCappedBuffer(t *testing.T) {
+	c := &cappedBuffer{limit: 4}
+	n, err := c.Write([]byte("abcdef"))
+	if err != nil || n != 6 {
+		t.Errorf("expected the full write to be reported, got %d, %v", n, err)
+	}
+	c.Write([]byte("gh")) //nolint:errcheck
+	if c.buf.String() != "abcd" {
+		t.Errorf("expected capped content, got %q", c.buf.String())
+	}
+}
+
+func TestFileTransferValidation(t *testing.T) {
+	h := NewFileTransferHandler(nil, nil, nil)
+	r := mux.NewRouter()
+	h.RegisterRoutes(r)
+
+	tests := []struct {
+		name    string
+		method  string
+		url     string
+		wantErr string
+	}{
+		{"missing path", http.MethodGet, "/api/clusters/c1/namespaces/default/pods/web/files", "path is required"},
+		{"relative path", http.MethodPut, "/api/clusters/c1/namespaces/default/pods/web/files?path=etc/hosts", "path must be absolute"},
+		{"invalid pod", http.MethodGet, "/api/clusters/c1/namespaces/default/pods/Web_1/files?path=/tmp", "invalid namespace or pod name"},
+		{"invalid container", http.MethodGet, "/api/clusters/c1/namespaces/default/pods/web/files?path=/tmp&container=App!", "invalid container name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, nil)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantErr) {
+				t.Errorf("expected %q in %s", tt.wantErr, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestFileTransferRequiresClaims(t *testing.T) {
+	h := NewFileTransferHandler(nil, nil, nil)
+	r := mux.NewRouter()
+	h.RegisterRoutes(r)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/clusters/c1/namespaces/default/pods/web/files?path=/tmp/app.log", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rec.Code)
+	}
+}
@@ -1,7 +1,9 @@
 package terminal
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
@@ -11,6 +13,9 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/darkden-lab/argus/backend/internal/auth"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
 	"github.com/darkden-lab/argus/backend/internal/ws"
 )
 
@@ -20,23 +25,62 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin:     ws.CheckOrigin,
 }
 
+// Errors returned when a terminal context or command is refused.
+var (
+	errPermissionCheck = errors.New("permission check failed")
+	errForbidden       = errors.New("insufficient permissions")
+)
+
 // Handler manages terminal WebSocket connections.
 type Handler struct {
 	jwtService *auth.JWTService
 	clusterMgr *cluster.Manager
+	authz      rbac.Evaluator
 	sessions   map[string]*Session
 	mu         sync.RWMutex
 }
 
-// NewHandler creates a new terminal WebSocket handler.
-func NewHandler(jwtService *auth.JWTService, clusterMgr *cluster.Manager) *Handler {
+// NewHandler creates a new terminal WebSocket handler. rbacEngine decides
+// which clusters and namespaces a user may open a terminal on, and what
+// smart mode commands may read.
+func NewHandler(jwtService *auth.JWTService, clusterMgr *cluster.Manager, rbacEngine *rbac.Engine) *Handler {
 	return &Handler{
 		jwtService: jwtService,
 		clusterMgr: clusterMgr,
+		authz:      rbacEngine,
 		sessions:   make(map[string]*Session),
 	}
 }
 
+// evaluate turns an RBAC decision into nil, errForbidden or
+// errPermissionCheck.
+func evaluate(ctx context.Context, authz rbac.Evaluator, req rbac.Request) error {
+	if authz == nil {
+		return errPermissionCheck
+	}
+	allowed, err := authz.Evaluate(ctx, req)
+	if err != nil {
+		return errPermissionCheck
+	}
+	if !allowed {
+		return errForbidden
+	}
+	return nil
+}
+
+// authorizeContext checks that userID may use a terminal on the cluster and
+// namespace. Like file transfers, that needs terminal:write, because raw
+// mode executes commands in the cluster.
+func authorizeContext(ctx context.Context, authz rbac.Evaluator, userID, clusterID, namespace string) error {
+	return evaluate(ctx, authz, rbac.Request{
+		UserID:    userID,
+		Action:    "write",
+		Resource:  "terminal",
+		ClusterID: clusterID,
+		Namespace: namespace,
+	})
+}
+
 // RegisterRoutes wires the terminal WebSocket endpoint.
 func (h *Handler) RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/ws/terminal", h.ServeTerminal).Methods(http.MethodGet)
@@ -56,7 +100,7 @@ type TerminalMessage struct {
 // ServeTerminal upgrades an HTTP GET /ws/terminal request to a WebSocket
 // connection for interactive terminal access.
 func (h *Handler) ServeTerminal(w http.ResponseWriter, r *http.Request) {
-	// Authenticate via token query param or Authorization header
+	// Authenticate via token query param, Authorization header or session cookie
 	token := r.URL.Query().Get("token")
 	if token == "" {
 		authHeader := r.Header.Get("Authorization")
@@ -65,29 +109,51 @@ func (h *Handler) ServeTerminal(w http.ResponseWriter, r *http.Request) {
 			token = parts[1]
 		}
 	}
+	if token == "" {
+		token = auth.AccessTokenFromCookie(r)
+	}
 
 	if token == "" {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	claims, err := h.jwtService.ValidateToken(token)
 	if err != nil {
-		http.Error(w, "invalid token", http.StatusUnauthorized)
+		httputil.WriteError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	// Extract cluster and namespace from query parameters (frontend sends these)
+	clusterID := r.URL.Query().Get("cluster")
+	namespace := r.URL.Query().Get("namespace")
+	if err := validate.Namespace(namespace); err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	// Refuse the handshake rather than a connected session, so clients see
+	// a status code.
+	if clusterID != "" {
+		if err := authorizeContext(r.Context(), h.authz, claims.UserID, clusterID, namespace); err != nil {
+			status := http.StatusForbidden
+			if errors.Is(err, errPermissionCheck) {
+				status = http.StatusInternalServerError
+			}
+			httputil.WriteError(w, status, err.Error())
+			return
+		}
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		return
 	}
 
 	session := NewSession(claims.UserID, conn, h.clusterMgr)
+	session.authz = h.authz
 	h.addSession(session)
 
-	// Extract cluster and namespace from query parameters (frontend sends these)
-	clusterID := r.URL.Query().Get("cluster")
-	namespace := r.URL.Query().Get("namespace")
 	if clusterID != "" {
 		session.SetContext(clusterID, namespace)
 		log.Printf("terminal: session %s initial context cluster=%s namespace=%s", session.ID, clusterID, namespace)
@@ -131,6 +197,14 @@ func (h *Handler) readPump(s *Session) {
 		case "resize":
 			s.HandleResize(tm.Cols, tm.Rows)
 		case "set_context":
+			if err := validate.Namespace(tm.Namespace); err != nil {
+				h.sendToSession(s, TerminalMessage{Type: "error", Data: err.Error()})
+				break
+			}
+			if err := authorizeContext(context.Background(), h.authz, s.UserID, tm.ClusterID, tm.Namespace); err != nil {
+				h.sendToSession(s, TerminalMessage{Type: "error", Data: "Cannot set context to cluster=" + tm.ClusterID + " namespace=" + tm.Namespace + ": " + err.Error() + "\r\n"})
+				break
+			}
 			s.SetContext(tm.ClusterID, tm.Namespace)
 			h.sendToSession(s, TerminalMessage{
 				Type:      "output",
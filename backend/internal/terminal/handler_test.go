@@ -0,0 +1,88 @@
+package terminal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+)
+
+// terminalNamespaces grants terminal:write and read in the listed
+// namespaces only.
+type terminalNamespaces map[string]bool
+
+func (a terminalNamespaces) Evaluate(ctx context.Context, req rbac.Request) (bool, error) {
+	return a[req.Namespace], nil
+}
+
+func newTerminalTestServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	jwtSvc := auth.NewJWTService("test-secret-key-at-least-32chars!!")
+	token, err := jwtSvc.GenerateToken("u1", "dev@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{jwtService: jwtSvc, authz: terminalNamespaces{"team-a": true}, sessions: make(map[string]*Session)}
+	r := mux.NewRouter()
+	h.RegisterRoutes(r)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv, token
+}
+
+func TestServeTerminal_RefusesOtherNamespaceOnHandshake(t *testing.T) {
+	srv, token := newTerminalTestServer(t)
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/terminal?token=" + token + "&cluster=c1"
+
+	conn, resp, err := websocket.DefaultDialer.Dial(wsURL+"&namespace=team-b", nil)
+	if err == nil {
+		conn.Close()
+		t.Fatal("expected the handshake to be refused")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403, got %+v", resp)
+	}
+
+	conn, _, err = websocket.DefaultDialer.Dial(wsURL+"&namespace=team-a", nil)
+	if err != nil {
+		t.Fatalf("expected the user's namespace to connect: %v", err)
+	}
+	conn.Close()
+}
+
+func TestServeTerminal_RefusesOtherNamespaceContext(t *testing.T) {
+	srv, token := newTerminalTestServer(t)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/terminal?token="+token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var msg TerminalMessage
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if err := conn.ReadJSON(&msg); err != nil || msg.Type != "connected" {
+		t.Fatalf("expected connected, got %+v (%v)", msg, err)
+	}
+
+	if err := conn.WriteJSON(TerminalMessage{Type: "set_context", ClusterID: "c1", Namespace: "team-b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.ReadJSON(&msg); err != nil || msg.Type != "error" || !strings.Contains(msg.Data, "insufficient permissions") {
+		t.Fatalf("expected the context to be refused, got %+v (%v)", msg, err)
+	}
+
+	if err := conn.WriteJSON(TerminalMessage{Type: "set_context", ClusterID: "c1", Namespace: "team-a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.ReadJSON(&msg); err != nil || msg.Type != "output" || msg.Namespace != "team-a" {
+		t.Fatalf("expected the context to be set, got %+v (%v)", msg, err)
+	}
+}
@@ -0,0 +1,540 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathTemplate is a compiled kubectl-style JSONPath template such as
+// "{range .items[*]}{.metadata.name}{\"\\n\"}{end}". It supports field
+// access, wildcards, indexes, slices, simple filters, range/end, and string
+// literals. Recursive descent, unions, slice steps, and compound filters are
+// rejected when the template is compiled.
+type jsonPathTemplate struct {
+	nodes []jsonPathNode
+}
+
+type jsonPathNodeKind int
+
+const (
+	jsonPathText jsonPathNodeKind = iota
+	jsonPathExpr
+	jsonPathRange
+)
+
+type jsonPathNode struct {
+	kind  jsonPathNodeKind
+	text  string         // jsonPathText
+	steps []jsonPathStep // jsonPathExpr and jsonPathRange
+	body  []jsonPathNode // jsonPathRange
+}
+
+type jsonPathStepKind int
+
+const (
+	stepField jsonPathStepKind = iota
+	stepWildcard
+	stepIndex
+	stepSlice
+	stepFilter
+)
+
+type jsonPathStep struct {
+	kind   jsonPathStepKind
+	field  string
+	index  int
+	start  *int
+	end    *int
+	filter *jsonPathFilter
+}
+
+// jsonPathFilter is a [?(@.path)] or [?(@.path <op> literal)] filter.
+type jsonPathFilter struct {
+	path    []jsonPathStep
+	op      string // empty for an existence test
+	literal interface{}
+}
+
+// compileJSONPath parses a JSONPath template.
+func compileJSONPath(template string) (*jsonPathTemplate, error) {
+	nodes, rest, err := parseJSONPathNodes(template, false)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("jsonpath: {end} without {range}")
+	}
+	return &jsonPathTemplate{nodes: nodes}, nil
+}
+
+// parseJSONPathNodes parses template text until its end or, inside a range,
+// until the matching {end}. It returns the unparsed remainder, which starts
+// after {end} when one closed the nodes.
+func parseJSONPathNodes(s string, inRange bool) ([]jsonPathNode, string, error) {
+	var nodes []jsonPathNode
+	for s != "" {
+		open := strings.IndexByte(s, '{')
+		if open < 0 {
+			nodes = append(nodes, jsonPathNode{kind: jsonPathText, text: s})
+			s = ""
+			break
+		}
+		if open > 0 {
+			nodes = append(nodes, jsonPathNode{kind: jsonPathText, text: s[:open]})
+		}
+		closeAt, err := matchingClose(s, open, '{', '}')
+		if err != nil {
+			return nil, "", err
+		}
+		expr := strings.TrimSpace(s[open+1 : closeAt])
+		s = s[closeAt+1:]
+
+		switch {
+		case expr == "end":
+			if !inRange {
+				return nil, "", fmt.Errorf("jsonpath: {end} without {range}")
+			}
+			return nodes, s, nil
+		case strings.HasPrefix(expr, "range "):
+			steps, err := parseJSONPathExpr(strings.TrimSpace(strings.TrimPrefix(expr, "range ")))
+			if err != nil {
+				return nil, "", err
+			}
+			body, rest, err := parseJSONPathNodes(s, true)
+			if err != nil {
+				return nil, "", err
+			}
+			if rest == s {
+				return nil, "", fmt.Errorf("jsonpath: {range} without {end}")
+			}
+			nodes = append(nodes, jsonPathNode{kind: jsonPathRange, steps: steps, body: body})
+			s = rest
+		case strings.HasPrefix(expr, `"`):
+			text, err := strconv.Unquote(expr)
+			if err != nil {
+				return nil, "", fmt.Errorf("jsonpath: invalid string literal %s", expr)
+			}
+			nodes = append(nodes, jsonPathNode{kind: jsonPathText, text: text})
+		default:
+			steps, err := parseJSONPathExpr(expr)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, jsonPathNode{kind: jsonPathExpr, steps: steps})
+		}
+	}
+	if inRange {
+		return nil, "", fmt.Errorf("jsonpath: {range} without {end}")
+	}
+	return nodes, "", nil
+}
+
+// matchingClose returns the index of the bracket closing the one at open,
+// skipping quoted strings.
+func matchingClose(s string, open int, openCh, closeCh byte) (int, error) {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == openCh:
+			depth++
+		case c == closeCh:
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("jsonpath: unclosed %q in %q", openCh, s[open:])
+}
+
+// parseJSONPathExpr parses a path such as ".items[*].metadata.name". A
+// leading "$" or "@" is allowed; "." alone is the current object.
+func parseJSONPathExpr(expr string) ([]jsonPathStep, error) {
+	s := strings.TrimPrefix(strings.TrimPrefix(expr, "$"), "@")
+	if s == "" || s == "." {
+		return nil, nil
+	}
+	var steps []jsonPathStep
+	for s != "" {
+		switch {
+		case strings.HasPrefix(s, ".."):
+			return nil, fmt.Errorf("jsonpath: recursive descent (..) is not supported")
+		case strings.HasPrefix(s, ".*"):
+			steps = append(steps, jsonPathStep{kind: stepWildcard})
+			s = s[2:]
+		case strings.HasPrefix(s, ".["):
+			s = s[1:]
+		case s[0] == '.':
+			name, rest := readJSONPathName(s[1:])
+			if name == "" {
+				return nil, fmt.Errorf("jsonpath: empty field name in %q", expr)
+			}
+			if strings.ContainsAny(name, "()") {
+				return nil, fmt.Errorf("jsonpath: functions are not supported: %q", name)
+			}
+			steps = append(steps, jsonPathStep{kind: stepField, field: name})
+			s = rest
+		case s[0] == '[':
+			closeAt, err := matchingClose(s, 0, '[', ']')
+			if err != nil {
+				return nil, err
+			}
+			step, err := parseJSONPathBracket(strings.TrimSpace(s[1:closeAt]))
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+			s = s[closeAt+1:]
+		default:
+			return nil, fmt.Errorf("jsonpath: unexpected %q in %q; paths start with \".\"", s, expr)
+		}
+	}
+	return steps, nil
+}
+
+// readJSONPathName reads a field name up to the next unescaped "." or "[".
+// "\." escapes a dot inside a name, e.g. a label key.
+func readJSONPathName(s string) (string, string) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '.':
+			sb.WriteByte('.')
+			i++
+		case s[i] == '.' || s[i] == '[':
+			return sb.String(), s[i:]
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String(), ""
+}
+
+// parseJSONPathBracket parses the content of a [...] step.
+func parseJSONPathBracket(content string) (jsonPathStep, error) {
+	switch {
+	case content == "*":
+		return jsonPathStep{kind: stepWildcard}, nil
+	case strings.HasPrefix(content, "?("):
+		if !strings.HasSuffix(content, ")") {
+			return jsonPathStep{}, fmt.Errorf("jsonpath: invalid filter [%s]", content)
+		}
+		filter, err := parseJSONPathFilter(strings.TrimSpace(content[2 : len(content)-1]))
+		if err != nil {
+			return jsonPathStep{}, err
+		}
+		return jsonPathStep{kind: stepFilter, filter: filter}, nil
+	case strings.HasPrefix(content, "'") || strings.HasPrefix(content, `"`):
+		if len(content) < 2 || content[len(content)-1] != content[0] {
+			return jsonPathStep{}, fmt.Errorf("jsonpath: invalid quoted field [%s]", content)
+		}
+		if strings.Contains(content[1:len(content)-1], string(content[0])) {
+			return jsonPathStep{}, fmt.Errorf("jsonpath: unions are not supported: [%s]", content)
+		}
+		return jsonPathStep{kind: stepField, field: content[1 : len(content)-1]}, nil
+	case strings.Contains(content, ","):
+		return jsonPathStep{}, fmt.Errorf("jsonpath: unions are not supported: [%s]", content)
+	case strings.Contains(content, ":"):
+		parts := strings.Split(content, ":")
+		if len(parts) > 2 {
+			return jsonPathStep{}, fmt.Errorf("jsonpath: slice steps are not supported: [%s]", content)
+		}
+		step := jsonPathStep{kind: stepSlice}
+		for i, part := range parts {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return jsonPathStep{}, fmt.Errorf("jsonpath: invalid slice [%s]", content)
+			}
+			if i == 0 {
+				step.start = &n
+			} else {
+				step.end = &n
+			}
+		}
+		return step, nil
+	default:
+		n, err := strconv.Atoi(content)
+		if err != nil {
+			return jsonPathStep{}, fmt.Errorf("jsonpath: invalid index [%s]", content)
+		}
+		return jsonPathStep{kind: stepIndex, index: n}, nil
+	}
+}
+
+// jsonPathOperators are the comparison operators filters support, longest
+// first so "<=" is not read as "<".
+var jsonPathOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// parseJSONPathFilter parses "@.path" or "@.path <op> literal".
+func parseJSONPathFilter(expr string) (*jsonPathFilter, error) {
+	if strings.Contains(expr, "&&") || strings.Contains(expr, "||") {
+		return nil, fmt.Errorf("jsonpath: compound filters are not supported: %q", expr)
+	}
+	left, op, right := expr, "", ""
+	for _, candidate := range jsonPathOperators {
+		if i := strings.Index(expr, candidate); i >= 0 {
+			left, op, right = strings.TrimSpace(expr[:i]), candidate, strings.TrimSpace(expr[i+len(candidate):])
+			break
+		}
+	}
+	if !strings.HasPrefix(left, "@") {
+		return nil, fmt.Errorf("jsonpath: filters must test @, got %q", expr)
+	}
+	path, err := parseJSONPathExpr(left)
+	if err != nil {
+		return nil, err
+	}
+	filter := &jsonPathFilter{path: path, op: op}
+	if op == "" {
+		return filter, nil
+	}
+
+	switch {
+	case strings.HasPrefix(right, "'") && strings.HasSuffix(right, "'") && len(right) >= 2:
+		filter.literal = right[1 : len(right)-1]
+	case strings.HasPrefix(right, `"`):
+		s, err := strconv.Unquote(right)
+		if err != nil {
+			return nil, fmt.Errorf("jsonpath: invalid string in filter %q", expr)
+		}
+		filter.literal = s
+	case right == "true" || right == "false":
+		filter.literal = right == "true"
+	default:
+		n, err := strconv.ParseFloat(right, 64)
+		if err != nil {
+			return nil, fmt.Errorf("jsonpath: filters compare with a string, number, or boolean, got %q", right)
+		}
+		filter.literal = n
+	}
+	if _, isNumber := filter.literal.(float64); !isNumber && op != "==" && op != "!=" {
+		return nil, fmt.Errorf("jsonpath: %s only compares numbers in %q", op, expr)
+	}
+	return filter, nil
+}
+
+// Execute renders the template against data. Each expression prints its
+// results separated by spaces; missing fields print nothing, as with
+// kubectl get.
+func (t *jsonPathTemplate) Execute(data interface{}) (string, error) {
+	var sb strings.Builder
+	if err := executeJSONPathNodes(&sb, t.nodes, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func executeJSONPathNodes(sb *strings.Builder, nodes []jsonPathNode, data interface{}) error {
+	for _, node := range nodes {
+		switch node.kind {
+		case jsonPathText:
+			sb.WriteString(node.text)
+		case jsonPathExpr:
+			values := evalJSONPath(node.steps, data)
+			for i, v := range values {
+				if i > 0 {
+					sb.WriteByte(' ')
+				}
+				s, err := formatJSONPathValue(v)
+				if err != nil {
+					return err
+				}
+				sb.WriteString(s)
+			}
+		case jsonPathRange:
+			values := evalJSONPath(node.steps, data)
+			// {range .items} iterates the list itself, like {range .items[*]}.
+			if len(values) == 1 {
+				if list, ok := values[0].([]interface{}); ok {
+					values = list
+				}
+			}
+			for _, v := range values {
+				if err := executeJSONPathNodes(sb, node.body, v); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// evalJSONPath returns the values a path selects from data.
+func evalJSONPath(steps []jsonPathStep, data interface{}) []interface{} {
+	values := []interface{}{data}
+	for _, step := range steps {
+		var next []interface{}
+		for _, v := range values {
+			next = append(next, applyJSONPathStep(step, v)...)
+		}
+		values = next
+	}
+	return values
+}
+
+func applyJSONPathStep(step jsonPathStep, v interface{}) []interface{} {
+	switch step.kind {
+	case stepField:
+		if m, ok := v.(map[string]interface{}); ok {
+			if field, ok := m[step.field]; ok {
+				return []interface{}{field}
+			}
+		}
+	case stepWildcard:
+		switch t := v.(type) {
+		case []interface{}:
+			return t
+		case map[string]interface{}:
+			keys := make([]string, 0, len(t))
+			for k := range t {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			out := make([]interface{}, 0, len(keys))
+			for _, k := range keys {
+				out = append(out, t[k])
+			}
+			return out
+		}
+	case stepIndex:
+		if list, ok := v.([]interface{}); ok {
+			i := step.index
+			if i < 0 {
+				i += len(list)
+			}
+			if i >= 0 && i < len(list) {
+				return []interface{}{list[i]}
+			}
+		}
+	case stepSlice:
+		if list, ok := v.([]interface{}); ok {
+			start, end := 0, len(list)
+			if step.start != nil {
+				start = clampSliceIndex(*step.start, len(list))
+			}
+			if step.end != nil {
+				end = clampSliceIndex(*step.end, len(list))
+			}
+			if start < end {
+				return list[start:end]
+			}
+		}
+	case stepFilter:
+		if list, ok := v.([]interface{}); ok {
+			var out []interface{}
+			for _, item := range list {
+				if step.filter.matches(item) {
+					out = append(out, item)
+				}
+			}
+			return out
+		}
+	}
+	return nil
+}
+
+func clampSliceIndex(i, n int) int {
+	if i < 0 {
+		i += n
+	}
+	if i < 0 {
+		return 0
+	}
+	if i > n {
+		return n
+	}
+	return i
+}
+
+func (f *jsonPathFilter) matches(item interface{}) bool {
+	values := evalJSONPath(f.path, item)
+	if f.op == "" {
+		return len(values) > 0 && values[0] != nil
+	}
+	if len(values) == 0 {
+		return f.op == "!="
+	}
+	v := values[0]
+
+	switch lit := f.literal.(type) {
+	case float64:
+		n, ok := jsonPathNumber(v)
+		if !ok {
+			return f.op == "!="
+		}
+		switch f.op {
+		case "==":
+			return n == lit
+		case "!=":
+			return n != lit
+		case "<":
+			return n < lit
+		case "<=":
+			return n <= lit
+		case ">":
+			return n > lit
+		case ">=":
+			return n >= lit
+		}
+	default:
+		equal := v == f.literal
+		if f.op == "==" {
+			return equal
+		}
+		return !equal
+	}
+	return false
+}
+
+func jsonPathNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// formatJSONPathValue prints a value the way kubectl does: strings and
+// scalars as is, objects and lists as JSON.
+func formatJSONPathValue(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return t, nil
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), nil
+	case int64, int, bool, json.Number:
+		return fmt.Sprint(t), nil
+	default:
+		data, err := json.Marshal(t)
+		if err != nil {
+			return "", fmt.Errorf("jsonpath: %w", err)
+		}
+		return string(data), nil
+	}
+}
@@ -0,0 +1,88 @@
+package terminal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const testPodList = `{
+	"kind": "List",
+	"items": [
+		{"metadata": {"name": "web-1", "labels": {"app.kubernetes.io/name": "web"}}, "status": {"phase": "Running", "restarts": 0}},
+		{"metadata": {"name": "web-2", "labels": {"app.kubernetes.io/name": "web"}}, "status": {"phase": "Pending", "restarts": 3}},
+		{"metadata": {"name": "db-0"}, "status": {"phase": "Running", "restarts": 1}}
+	]
+}`
+
+func testJSONData(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(testPodList), &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestJSONPathExecute(t *testing.T) {
+	data := testJSONData(t)
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{`{.items[*].metadata.name}`, "web-1 web-2 db-0"},
+		{`{.items[0].metadata.name}`, "web-1"},
+		{`{.items[-1].metadata.name}`, "db-0"},
+		{`{.items[1:].metadata.name}`, "web-2 db-0"},
+		{`{$.kind}`, "List"},
+		{`kind={.kind}`, "kind=List"},
+		{`{.items[?(@.status.phase=="Running")].metadata.name}`, "web-1 db-0"},
+		{`{.items[?(@.status.restarts>0)].metadata.name}`, "web-2 db-0"},
+		{`{.items[?(@.metadata.labels)].metadata.name}`, "web-1 web-2"},
+		{`{.items[0].metadata.labels.app\.kubernetes\.io/name}`, "web"},
+		{`{.items[0].metadata.labels['app.kubernetes.io/name']}`, "web"},
+		{`{range .items[*]}{.metadata.name}{"\t"}{.status.phase}{"\n"}{end}`, "web-1\tRunning\nweb-2\tPending\ndb-0\tRunning\n"},
+		{`{range .items}{.metadata.name},{end}`, "web-1,web-2,db-0,"},
+		{`{.items[2].metadata}`, `{"name":"db-0"}`},
+		{`{.items[*].metadata.missing}`, ""},
+		{`{.items[9].metadata.name}`, ""},
+	}
+	for _, tt := range tests {
+		tmpl, err := compileJSONPath(tt.template)
+		if err != nil {
+			t.Errorf("compile %s: %v", tt.template, err)
+			continue
+		}
+		got, err := tmpl.Execute(data)
+		if err != nil {
+			t.Errorf("execute %s: %v", tt.template, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.template, tt.want, got)
+		}
+	}
+}
+
+func TestCompileJSONPath_Unsupported(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  string
+	}{
+		{`{..name}`, "recursive descent"},
+		{`{.items[0,1]}`, "unions"},
+		{`{.items[0:4:2]}`, "slice steps"},
+		{`{.items[?(@.a=="x" && @.b=="y")]}`, "compound filters"},
+		{`{.items.length()}`, "functions"},
+		{`{range .items[*]}{.metadata.name}`, "without {end}"},
+		{`{.metadata.name}{end}`, "without {range}"},
+		{`{.metadata.name`, "unclosed"},
+		{`{metadata.name}`, "paths start with"},
+	}
+	for _, tt := range tests {
+		_, err := compileJSONPath(tt.template)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.template, tt.wantErr, err)
+		}
+	}
+}
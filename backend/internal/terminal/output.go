@@ -0,0 +1,147 @@
+package terminal
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// objectPrinter renders objects for a template-based -o format of smart-mode
+// get. The json, yaml, wide, and default formats are handled by executeGet.
+type objectPrinter interface {
+	printObject(obj map[string]interface{}) (string, error)
+	printList(items []unstructured.Unstructured) (string, error)
+}
+
+// newObjectPrinter returns the printer for an -o value. It returns nil for
+// the formats executeGet handles itself and an error for unsupported ones.
+func newObjectPrinter(output string) (objectPrinter, error) {
+	format, arg, _ := strings.Cut(output, "=")
+	switch format {
+	case "", "json", "yaml", "wide":
+		if arg != "" {
+			return nil, fmt.Errorf("output format %q takes no argument", format)
+		}
+		return nil, nil
+	case "jsonpath":
+		if arg == "" {
+			return nil, fmt.Errorf("jsonpath output requires a template, e.g. -o jsonpath='{.metadata.name}'")
+		}
+		tmpl, err := compileJSONPath(arg)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonPathPrinter{tmpl: tmpl}, nil
+	case "custom-columns":
+		if arg == "" {
+			return nil, fmt.Errorf("custom-columns output requires columns, e.g. -o custom-columns=NAME:.metadata.name")
+		}
+		return newCustomColumnsPrinter(arg)
+	default:
+		return nil, fmt.Errorf("unsupported output format %q (supported: json, yaml, wide, jsonpath=..., custom-columns=...)", format)
+	}
+}
+
+// jsonPathPrinter prints a JSONPath template. Lists are wrapped in a List
+// object, so templates address them as {.items[*]...} like kubectl.
+type jsonPathPrinter struct {
+	tmpl *jsonPathTemplate
+}
+
+func (p *jsonPathPrinter) printObject(obj map[string]interface{}) (string, error) {
+	return p.tmpl.Execute(obj)
+}
+
+func (p *jsonPathPrinter) printList(items []unstructured.Unstructured) (string, error) {
+	objs := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		objs = append(objs, item.Object)
+	}
+	return p.tmpl.Execute(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      objs,
+	})
+}
+
+// customColumn is one HEADER:path column of -o custom-columns.
+type customColumn struct {
+	header string
+	path   []jsonPathStep
+}
+
+// customColumnsPrinter prints one row per object with a column per spec.
+type customColumnsPrinter struct {
+	columns []customColumn
+}
+
+// newCustomColumnsPrinter parses "NAME:.metadata.name,STATUS:.status.phase".
+// Paths may be written with or without braces and the leading dot.
+func newCustomColumnsPrinter(spec string) (*customColumnsPrinter, error) {
+	p := &customColumnsPrinter{}
+	for _, part := range strings.Split(spec, ",") {
+		header, expr, ok := strings.Cut(part, ":")
+		if !ok || header == "" || expr == "" {
+			return nil, fmt.Errorf("custom-columns: expected <header>:<json-path>, got %q", part)
+		}
+		expr = strings.TrimSuffix(strings.TrimPrefix(expr, "{"), "}")
+		if !strings.HasPrefix(expr, ".") && !strings.HasPrefix(expr, "[") {
+			expr = "." + expr
+		}
+		path, err := parseJSONPathExpr(expr)
+		if err != nil {
+			return nil, err
+		}
+		p.columns = append(p.columns, customColumn{header: header, path: path})
+	}
+	return p, nil
+}
+
+func (p *customColumnsPrinter) printObject(obj map[string]interface{}) (string, error) {
+	return p.print([]map[string]interface{}{obj})
+}
+
+func (p *customColumnsPrinter) printList(items []unstructured.Unstructured) (string, error) {
+	objs := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		objs = append(objs, item.Object)
+	}
+	return p.print(objs)
+}
+
+func (p *customColumnsPrinter) print(objs []map[string]interface{}) (string, error) {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 8, 3, ' ', 0)
+
+	headers := make([]string, len(p.columns))
+	for i, col := range p.columns {
+		headers[i] = col.header
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for _, obj := range objs {
+		cells := make([]string, len(p.columns))
+		for i, col := range p.columns {
+			var values []string
+			for _, v := range evalJSONPath(col.path, obj) {
+				s, err := formatJSONPathValue(v)
+				if err != nil {
+					return "", err
+				}
+				values = append(values, s)
+			}
+			cells[i] = strings.Join(values, ",")
+			if cells[i] == "" {
+				cells[i] = "<none>"
+			}
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
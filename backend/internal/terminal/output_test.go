@@ -0,0 +1,66 @@
+package terminal
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNewObjectPrinter(t *testing.T) {
+	for _, output := range []string{"", "json", "yaml", "wide"} {
+		if p, err := newObjectPrinter(output); p != nil || err != nil {
+			t.Errorf("%q: expected no printer, got %v, %v", output, p, err)
+		}
+	}
+	for _, output := range []string{"jsonpath", "jsonpath-file=/tmp/t", "custom-columns", "custom-columns=NAME", "name", "json=x"} {
+		if _, err := newObjectPrinter(output); err == nil {
+			t.Errorf("%q: expected an error", output)
+		}
+	}
+}
+
+func TestJSONPathPrinter_List(t *testing.T) {
+	p, err := newObjectPrinter("jsonpath={.items[*].metadata.name}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := testJSONData(t)
+	var items []unstructured.Unstructured
+	for _, item := range data["items"].([]interface{}) {
+		items = append(items, unstructured.Unstructured{Object: item.(map[string]interface{})})
+	}
+	got, err := p.printList(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "web-1 web-2 db-0" {
+		t.Errorf("unexpected output %q", got)
+	}
+}
+
+func TestCustomColumnsPrinter(t *testing.T) {
+	p, err := newObjectPrinter("custom-columns=NAME:.metadata.name,PHASE:status.phase,APP:{.metadata.labels.app\\.kubernetes\\.io/name}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := testJSONData(t)
+	var items []unstructured.Unstructured
+	for _, item := range data["items"].([]interface{}) {
+		items = append(items, unstructured.Unstructured{Object: item.(map[string]interface{})})
+	}
+	got, err := p.printList(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header and 3 rows, got %q", got)
+	}
+	if fields := strings.Fields(lines[0]); strings.Join(fields, " ") != "NAME PHASE APP" {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if fields := strings.Fields(lines[3]); strings.Join(fields, " ") != "db-0 Running <none>" {
+		t.Errorf("expected <none> for a missing value, got %q", lines[3])
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
 )
 
 // Mode identifies how the terminal processes commands.
@@ -34,6 +35,10 @@ type Session struct {
 	done        chan struct{}
 	closeOnce   sync.Once
 
+	// authz checks what smart mode commands may read. Without it every
+	// command is refused.
+	authz rbac.Evaluator
+
 	// Terminal dimensions
 	cols int
 	rows int
@@ -122,6 +127,18 @@ func (s *Session) HandleInput(input string) {
 		if cmd.Namespace == "" {
 			cmd.Namespace = namespace
 		}
+		// -n and -A can reach past the session's namespace, so each command
+		// is checked against what it reads.
+		if req, ok := cmd.readRequest(clusterID); ok {
+			req.UserID = s.UserID
+			if err := evaluate(ctx, s.authz, req); err != nil {
+				s.output <- TerminalMessage{
+					Type: "error",
+					Data: "Error: " + err.Error() + "\r\n",
+				}
+				return
+			}
+		}
 		result, err := s.smartParser.Execute(ctx, clusterID, cmd)
 		if err != nil {
 			s.output <- TerminalMessage{
@@ -138,6 +155,14 @@ func (s *Session) HandleInput(input string) {
 		}
 
 	case ModeRaw:
+		// Raw commands can change anything, so maintenance mode refuses them.
+		if err := s.clusterMgr.CheckWritable(ctx, clusterID); err != nil {
+			s.output <- TerminalMessage{
+				Type: "error",
+				Data: "Error: " + err.Error() + "\r\n",
+			}
+			return
+		}
 		exec := NewExecSession(s.clusterMgr, clusterID, namespace)
 		_, err := exec.FindOrCreateToolsPod(ctx)
 		if err != nil {
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/rbac"
+	"github.com/darkden-lab/argus/backend/internal/validate"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -31,7 +33,7 @@ type ParsedCommand struct {
 	Resource  string   // pods, deployments, services, etc.
 	Name      string   // resource name (optional)
 	Namespace string   // -n flag value
-	Output    string   // -o flag value (json, yaml, wide)
+	Output    string   // -o flag value (json, yaml, wide, jsonpath=..., custom-columns=...)
 	Labels    string   // -l flag value
 	AllNS     bool     // --all-namespaces
 	Args      []string // remaining arguments
@@ -39,7 +41,10 @@ type ParsedCommand struct {
 
 // Parse takes a raw input string and parses it into a structured command.
 func (p *SmartParser) Parse(input string) (*ParsedCommand, error) {
-	parts := strings.Fields(input)
+	parts, err := splitCommandLine(input)
+	if err != nil {
+		return nil, err
+	}
 	if len(parts) == 0 {
 		return nil, fmt.Errorf("empty command")
 	}
@@ -88,6 +93,9 @@ func (p *SmartParser) Parse(input string) (*ParsedCommand, error) {
 		case strings.HasPrefix(parts[i], "-o="):
 			cmd.Output = strings.TrimPrefix(parts[i], "-o=")
 			i++
+		case strings.HasPrefix(parts[i], "--output="):
+			cmd.Output = strings.TrimPrefix(parts[i], "--output=")
+			i++
 		case strings.HasPrefix(parts[i], "-n="):
 			cmd.Namespace = strings.TrimPrefix(parts[i], "-n=")
 			i++
@@ -113,9 +121,112 @@ func (p *SmartParser) Parse(input string) (*ParsedCommand, error) {
 		}
 	}
 
+	// Reject identifiers that could not name a Kubernetes object before they
+	// reach the API server.
+	if err := validate.Namespace(cmd.Namespace); err != nil {
+		return nil, err
+	}
+	if !validate.Segment(cmd.Resource) {
+		return nil, fmt.Errorf("invalid resource type %q", cmd.Resource)
+	}
+	if err := validate.Name("resource", cmd.Name); err != nil {
+		return nil, err
+	}
+	if err := validate.LabelSelector(cmd.Labels); err != nil {
+		return nil, err
+	}
+
+	// Compile output templates now so a bad jsonpath fails before any API call.
+	if _, err := newObjectPrinter(cmd.Output); err != nil {
+		return nil, err
+	}
+
 	return cmd, nil
 }
 
+// splitCommandLine splits input into words like a shell would: single and
+// double quotes group words and are removed, and a backslash escapes the
+// next character outside quotes (inside double quotes, only " or \).
+// Nothing is expanded.
+func splitCommandLine(input string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	runes := []rune(input)
+	for i, r := range runes {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote == 0:
+			escaped = true
+			inWord = true
+		case r == '\\' && quote == '"' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\'):
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// readRequest is the read permission the command needs on clusterID: the
+// resource it reads, in the namespace it reads from, or in every namespace
+// for -A. version needs none, and neither do verbs Execute refuses.
+func (cmd *ParsedCommand) readRequest(clusterID string) (rbac.Request, bool) {
+	var gvr schema.GroupVersionResource
+	ns := cmd.Namespace
+	switch cmd.Verb {
+	case "get", "describe":
+		gvr = smartKindToGVR(cmd.Resource)
+		if cmd.Verb == "get" && cmd.AllNS {
+			ns = ""
+		}
+	case "logs", "log":
+		gvr = smartKindToGVR("pods")
+		if ns == "" {
+			ns = "default"
+		}
+	default:
+		return rbac.Request{}, false
+	}
+	return rbac.Request{
+		Action:      "read",
+		Resource:    rbac.ResourceCategory(gvr.Resource),
+		ClusterID:   clusterID,
+		Namespace:   ns,
+		K8sResource: rbac.QualifiedResource(gvr.Group, gvr.Resource),
+	}, true
+}
+
 // Execute runs a parsed command against the cluster and returns the output.
 func (p *SmartParser) Execute(ctx context.Context, clusterID string, cmd *ParsedCommand) (string, error) {
 	client, err := p.clusterMgr.GetClient(clusterID)
@@ -153,6 +264,11 @@ func (p *SmartParser) executeGet(ctx context.Context, client *cluster.ClusterCli
 		opts.LabelSelector = cmd.Labels
 	}
 
+	printer, err := newObjectPrinter(cmd.Output)
+	if err != nil {
+		return "", err
+	}
+
 	if cmd.Name != "" {
 		// Get specific resource
 		obj, err := client.DynClient.Resource(gvr).Namespace(ns).Get(ctx, cmd.Name, metav1.GetOptions{})
@@ -160,6 +276,9 @@ func (p *SmartParser) executeGet(ctx context.Context, client *cluster.ClusterCli
 			return "", err
 		}
 
+		if printer != nil {
+			return printer.printObject(obj.Object)
+		}
 		if cmd.Output == "json" {
 			data, _ := json.MarshalIndent(obj.Object, "", "  ")
 			return string(data), nil
@@ -177,6 +296,9 @@ func (p *SmartParser) executeGet(ctx context.Context, client *cluster.ClusterCli
 		return "", err
 	}
 
+	if printer != nil {
+		return printer.printList(list.Items)
+	}
 	if cmd.Output == "json" {
 		data, _ := json.MarshalIndent(list, "", "  ")
 		return string(data), nil
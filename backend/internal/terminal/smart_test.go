@@ -48,6 +48,61 @@ func TestParse_WithOutput(t *testing.T) {
 	}
 }
 
+func TestParse_JSONPathOutput(t *testing.T) {
+	p := &SmartParser{}
+	cmd, err := p.Parse(`get pods -o jsonpath='{.items[*].metadata.name}'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Output != "jsonpath={.items[*].metadata.name}" {
+		t.Errorf("expected quotes to be stripped from the template, got %q", cmd.Output)
+	}
+
+	cmd, err = p.Parse(`get pods --output="custom-columns=NAME:.metadata.name,NODE:.spec.nodeName"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd.Output != "custom-columns=NAME:.metadata.name,NODE:.spec.nodeName" {
+		t.Errorf("unexpected output %q", cmd.Output)
+	}
+}
+
+func TestParse_InvalidOutput(t *testing.T) {
+	p := &SmartParser{}
+	inputs := map[string]string{
+		`get pods -o jsonpath='{..name}'`:         "recursive descent",
+		`get pods -o jsonpath='{.items[*]`:        "unterminated ' quote",
+		`get pods -o jsonpath-file=/tmp/template`: "unsupported output format",
+	}
+	for input, wantErr := range inputs {
+		_, err := p.Parse(input)
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", input, wantErr, err)
+		}
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := map[string][]string{
+		`get pods`:                {"get", "pods"},
+		`  get   pods  `:          {"get", "pods"},
+		`-o jsonpath='{"\n"}'`:    {"-o", `jsonpath={"\n"}`},
+		`-o "jsonpath={\"a b\"}"`: {"-o", `jsonpath={"a b"}`},
+		`-l app=a\ b`:             {"-l", "app=a b"},
+		`get ''`:                  {"get", ""},
+	}
+	for input, want := range tests {
+		got, err := splitCommandLine(input)
+		if err != nil {
+			t.Errorf("%s: %v", input, err)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(want, "|") || len(got) != len(want) {
+			t.Errorf("%s: expected %q, got %q", input, want, got)
+		}
+	}
+}
+
 func TestParse_AllNamespaces(t *testing.T) {
 	p := &SmartParser{}
 	cmd, err := p.Parse("get pods -A")
@@ -95,6 +150,21 @@ func TestParse_WithLabels(t *testing.T) {
 	}
 }
 
+func TestParse_InvalidIdentifiers(t *testing.T) {
+	p := &SmartParser{}
+	inputs := []string{
+		"get pods -n ../kube-system",
+		"get pods/../../secrets",
+		"describe pod web%2F0",
+		"get pods -l 'app in (web'",
+	}
+	for _, input := range inputs {
+		if _, err := p.Parse(input); err == nil {
+			t.Errorf("expected %q to be rejected", input)
+		}
+	}
+}
+
 func TestParse_EmptyCommand(t *testing.T) {
 	p := &SmartParser{}
 	_, err := p.Parse("")
@@ -358,3 +428,32 @@ func TestSmartParserOversizedInput(t *testing.T) {
 		t.Errorf("unexpected verb from oversized input: %q", cmd.Verb)
 	}
 }
+
+func TestReadRequest(t *testing.T) {
+	p := &SmartParser{}
+	tests := []struct {
+		input     string
+		k8s       string
+		namespace string
+	}{
+		{"get pods -n team-b", "pods", "team-b"},
+		{"get deploy -A", "deployments.apps", ""},
+		{"describe secret db -n team-a", "secrets", "team-a"},
+		{"logs web", "pods", "default"},
+	}
+	for _, tt := range tests {
+		cmd, err := p.Parse(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, ok := cmd.readRequest("c1")
+		if !ok || req.Action != "read" || req.ClusterID != "c1" || req.K8sResource != tt.k8s || req.Namespace != tt.namespace {
+			t.Errorf("%s: unexpected request %+v", tt.input, req)
+		}
+	}
+
+	cmd, _ := p.Parse("version")
+	if _, ok := cmd.readRequest("c1"); ok {
+		t.Error("expected version to need no read permission")
+	}
+}
@@ -0,0 +1,63 @@
+// Package validate checks Kubernetes identifiers taken from requests before
+// they are put into API paths or queries, so malformed input is refused with
+// 400 instead of reaching a cluster or agent.
+package validate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// segment matches a single Kubernetes path segment: a namespace, name,
+// resource, version or group. It rules out path traversal (/, ..) and
+// characters that would need escaping.
+var segment = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9.\-]{0,252}$`)
+
+// Segment reports whether s is safe as a segment of a Kubernetes API path.
+// The empty string is valid: it stands for an omitted, optional segment.
+func Segment(s string) bool {
+	if s == "" {
+		return true
+	}
+	return segment.MatchString(s) && !strings.Contains(s, "..")
+}
+
+// Namespace returns an error if ns is not a valid namespace. An empty
+// namespace is valid; it means all namespaces or a cluster-scoped object.
+func Namespace(ns string) error {
+	if !Segment(ns) {
+		return fmt.Errorf("invalid namespace %q", ns)
+	}
+	return nil
+}
+
+// Name returns an error if name is not a valid object name. what names the
+// kind of object in the error, e.g. "pod" or "container".
+func Name(what, name string) error {
+	if !Segment(name) {
+		return fmt.Errorf("invalid %s name %q", what, name)
+	}
+	return nil
+}
+
+// LabelSelector returns an error if s is not a valid label selector, such as
+// "app=web,tier in (frontend)". The empty selector is valid.
+func LabelSelector(s string) error {
+	if _, err := labels.Parse(s); err != nil {
+		return fmt.Errorf("invalid label selector: %w", err)
+	}
+	return nil
+}
+
+// FieldSelector returns an error if s is not a valid field selector, such as
+// "status.phase=Running". The empty selector is valid.
+func FieldSelector(s string) error {
+	if _, err := fields.ParseSelector(s); err != nil {
+		return fmt.Errorf("invalid field selector: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSegment(t *testing.T) {
+	valid := []string{"", "default", "kube-system", "apps", "networking.k8s.io", "v1beta1", "Pod1", strings.Repeat("a", 253)}
+	for _, s := range valid {
+		if !Segment(s) {
+			t.Errorf("expected %q to be valid", s)
+		}
+	}
+	invalid := []string{"a/b", "..", "a..b", "-lead", ".hidden", "a b", "a%2Fb", "a?b", "ns#x", strings.Repeat("a", 254)}
+	for _, s := range invalid {
+		if Segment(s) {
+			t.Errorf("expected %q to be invalid", s)
+		}
+	}
+}
+
+func TestNamespaceAndName(t *testing.T) {
+	if err := Namespace(""); err != nil {
+		t.Errorf("expected the empty namespace to be valid, got %v", err)
+	}
+	if err := Namespace("../secrets"); err == nil || err.Error() != `invalid namespace "../secrets"` {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := Name("pod", "web-0"); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := Name("container", "app/../x"); err == nil || err.Error() != `invalid container name "app/../x"` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	for _, s := range []string{"", "app=web", "app=web,tier in (frontend,backend)", "!canary", "env!=prod"} {
+		if err := LabelSelector(s); err != nil {
+			t.Errorf("expected label selector %q to be valid, got %v", s, err)
+		}
+	}
+	for _, s := range []string{"=web", "app in (", "a b c", "app=web&x=y"} {
+		if err := LabelSelector(s); err == nil {
+			t.Errorf("expected label selector %q to be invalid", s)
+		}
+	}
+
+	for _, s := range []string{"", "status.phase=Running", "metadata.name!=web,spec.nodeName=n1"} {
+		if err := FieldSelector(s); err != nil {
+			t.Errorf("expected field selector %q to be valid, got %v", s, err)
+		}
+	}
+	if err := FieldSelector("status.phase"); err == nil {
+		t.Error("expected a field selector without an operator to be invalid")
+	}
+}
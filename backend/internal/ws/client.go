@@ -22,10 +22,13 @@ const (
 )
 
 // controlMessage is the JSON envelope sent by the frontend to subscribe or
-// unsubscribe from a K8s resource watch stream.
+// unsubscribe from a K8s resource watch stream. "watch" and "unwatch" start
+// and stop a live watch on the GVR given by group, version and resource.
 type controlMessage struct {
-	Action    string `json:"action"`    // "subscribe" | "unsubscribe"
+	Action    string `json:"action"`    // "subscribe" | "unsubscribe" | "watch" | "unwatch"
 	Cluster   string `json:"cluster"`   // cluster ID
+	Group     string `json:"group"`     // API group for watch, "" for core
+	Version   string `json:"version"`   // API version for watch, e.g. "v1"
 	Resource  string `json:"resource"`  // e.g. "pods", "deployments"
 	Namespace string `json:"namespace"` // "" means all namespaces
 }
@@ -36,6 +39,8 @@ type Client struct {
 	UserID        string
 	conn          *websocket.Conn
 	subscriptions map[string]bool
+	watches       map[string]bool // subscriptions backed by a live watch
+	revealed      map[string]bool // watches whose objects are sent unredacted
 	subMu         sync.RWMutex
 	send          chan []byte
 	hub           *Hub
@@ -48,6 +53,8 @@ func NewClient(hub *Hub, conn *websocket.Conn, userID string) *Client {
 		UserID:        userID,
 		conn:          conn,
 		subscriptions: make(map[string]bool),
+		watches:       make(map[string]bool),
+		revealed:      make(map[string]bool),
 		send:          make(chan []byte, 256),
 		hub:           hub,
 	}
@@ -60,6 +67,14 @@ func (c *Client) IsSubscribed(subKey string) bool {
 	return c.subscriptions[subKey]
 }
 
+// revealsRaw reports whether this client receives subKey's objects
+// unredacted.
+func (c *Client) revealsRaw(subKey string) bool {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	return c.revealed[subKey]
+}
+
 // ReadPump pumps messages from the WebSocket connection to the hub.
 // It runs in its own goroutine per client and handles subscribe / unsubscribe
 // control messages sent by the frontend.
@@ -91,18 +106,20 @@ func (c *Client) ReadPump() {
 			continue
 		}
 
+		spec := WatchSpec{Cluster: cm.Cluster, Group: cm.Group, Version: cm.Version, Resource: cm.Resource, Namespace: cm.Namespace}
 		key := subscriptionKey(cm.Cluster, cm.Resource, cm.Namespace)
 		switch cm.Action {
 		case "subscribe":
-			c.subMu.Lock()
-			c.subscriptions[key] = true
-			c.subMu.Unlock()
-			log.Printf("ws: client %s subscribed to %s", c.ID, key)
+			c.subscribe(cm.Cluster, cm.Resource, cm.Namespace)
 		case "unsubscribe":
 			c.subMu.Lock()
 			delete(c.subscriptions, key)
 			c.subMu.Unlock()
 			log.Printf("ws: client %s unsubscribed from %s", c.ID, key)
+		case "watch":
+			c.startWatch(spec)
+		case "unwatch":
+			c.stopWatch(spec)
 		default:
 			log.Printf("ws: client %s unknown action %q", c.ID, cm.Action)
 		}
@@ -1,12 +1,15 @@
 package ws
 
 import (
+	"context"
+	"log"
 	"net/http"
 	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/darkden-lab/argus/backend/internal/auth"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 )
 
 var upgrader = websocket.Upgrader{
@@ -20,12 +23,19 @@ var upgrader = websocket.Upgrader{
 type WSHandler struct {
 	hub        *Hub
 	jwtService *auth.JWTService
+	warmer     auth.PermissionWarmer
 }
 
 func NewWSHandler(hub *Hub, jwtService *auth.JWTService) *WSHandler {
 	return &WSHandler{hub: hub, jwtService: jwtService}
 }
 
+// SetPermissionWarmer sets the warmer that preloads a user's permissions when
+// their WebSocket connects, ahead of the subscriptions it is about to send.
+func (h *WSHandler) SetPermissionWarmer(warmer auth.PermissionWarmer) {
+	h.warmer = warmer
+}
+
 // RegisterRoutes wires the WebSocket endpoint.
 func (h *WSHandler) RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/ws", h.ServeWS).Methods(http.MethodGet)
@@ -34,7 +44,8 @@ func (h *WSHandler) RegisterRoutes(r *mux.Router) {
 // ServeWS upgrades an HTTP GET /ws request to a WebSocket connection.
 // Authentication is performed by reading the JWT from:
 //  1. The `token` query parameter, or
-//  2. The `Authorization: Bearer <token>` header.
+//  2. The `Authorization: Bearer <token>` header, or
+//  3. The access token session cookie.
 func (h *WSHandler) ServeWS(w http.ResponseWriter, r *http.Request) {
 	token := r.URL.Query().Get("token")
 	if token == "" {
@@ -44,15 +55,18 @@ func (h *WSHandler) ServeWS(w http.ResponseWriter, r *http.Request) {
 			token = parts[1]
 		}
 	}
+	if token == "" {
+		token = auth.AccessTokenFromCookie(r)
+	}
 
 	if token == "" {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		httputil.WriteError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	claims, err := h.jwtService.ValidateToken(token)
 	if err != nil {
-		http.Error(w, "invalid token", http.StatusUnauthorized)
+		httputil.WriteError(w, http.StatusUnauthorized, "invalid token")
 		return
 	}
 
@@ -64,6 +78,13 @@ func (h *WSHandler) ServeWS(w http.ResponseWriter, r *http.Request) {
 
 	client := NewClient(h.hub, conn, claims.UserID)
 	h.hub.Register(client)
+	if h.warmer != nil {
+		go func(userID string) {
+			if err := h.warmer.Warm(context.Background(), userID); err != nil {
+				log.Printf("ws: failed to warm permissions for user %s: %v", userID, err)
+			}
+		}(claims.UserID)
+	}
 
 	go client.WritePump()
 	go client.ReadPump()
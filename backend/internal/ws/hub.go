@@ -37,11 +37,30 @@ type Hub struct {
 	mu         sync.RWMutex
 	hooksMu    sync.RWMutex
 	hooks      []EventHook
+
+	watchMu        sync.Mutex
+	watchSource    WatchSource
+	authorizeWatch WatchAuthorizer
+	watches        map[string]*sharedWatch
+
+	// listenMu guards the WatchFunc consumers of each watch key.
+	listenMu     sync.RWMutex
+	listeners    map[string]map[int]func(WatchEvent)
+	nextListener int
+
+	// redactMu is separate from watchMu because events are published
+	// while a watch is being started.
+	redactMu     sync.RWMutex
+	redactObject ObjectRedactor
+	authorizeRaw WatchAuthorizer
 }
 
 type broadcastMsg struct {
 	subKey string
 	data   []byte
+	// redacted is data with the object redacted, sent to clients that may
+	// not see it raw. It is nil when redaction is off.
+	redacted []byte
 }
 
 // NewHub allocates and initialises a Hub. Call Run() in a goroutine to start
@@ -52,6 +71,8 @@ func NewHub() *Hub {
 		register:   make(chan *Client, 16),
 		unregister: make(chan *Client, 16),
 		broadcast:  make(chan broadcastMsg, 256),
+		watches:    make(map[string]*sharedWatch),
+		listeners:  make(map[string]map[int]func(WatchEvent)),
 	}
 }
 
@@ -68,19 +89,27 @@ func (h *Hub) Run() {
 
 		case client := <-h.unregister:
 			h.mu.Lock()
-			if _, ok := h.clients[client.ID]; ok {
+			_, ok := h.clients[client.ID]
+			if ok {
 				delete(h.clients, client.ID)
 				close(client.send)
 			}
 			h.mu.Unlock()
+			if ok {
+				client.releaseWatches()
+			}
 			log.Printf("ws: client %s unregistered", client.ID)
 
 		case msg := <-h.broadcast:
 			h.mu.RLock()
 			for _, client := range h.clients {
 				if client.IsSubscribed(msg.subKey) {
+					data := msg.data
+					if msg.redacted != nil && !client.revealsRaw(msg.subKey) {
+						data = msg.redacted
+					}
 					select {
-					case client.send <- msg.data:
+					case client.send <- data:
 					default:
 						// Slow consumer: drop the message to avoid blocking.
 					}
@@ -111,12 +140,29 @@ func (h *Hub) BroadcastToSubscribers(subKey string, event WatchEvent) {
 	}
 	h.hooksMu.RUnlock()
 
+	h.publish(subKey, event)
+}
+
+// publish enqueues event for the subscribers of subKey without invoking the
+// event hooks. Live watches use it so that a user opening a resource view
+// does not trigger notifications.
+func (h *Hub) publish(subKey string, event WatchEvent) {
+	h.notifyListeners(subKey, event)
+
 	data, err := json.Marshal(event)
 	if err != nil {
 		log.Printf("ws: failed to marshal event: %v", err)
 		return
 	}
-	h.broadcast <- broadcastMsg{subKey: subKey, data: data}
+	msg := broadcastMsg{subKey: subKey, data: data}
+	if redact := h.objectRedactor(); redact != nil {
+		event.Object = redact(event.Object)
+		if msg.redacted, err = json.Marshal(event); err != nil {
+			log.Printf("ws: failed to marshal redacted event: %v", err)
+			return
+		}
+	}
+	h.broadcast <- msg
 }
 
 // Register enqueues a new client for addition to the hub.
@@ -0,0 +1,380 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/darkden-lab/argus/backend/internal/validate"
+)
+
+// WatchSpec identifies a live watch on any resource type (GVR) in a cluster,
+// optionally restricted to one namespace.
+type WatchSpec struct {
+	Cluster   string `json:"cluster"`
+	Group     string `json:"group"`
+	Version   string `json:"version"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace"`
+}
+
+// ResourcePath is the resource identifier used in subscription keys and in
+// the Resource field of the watch's events: "group/version/resource", or
+// "version/resource" for the core group.
+func (s WatchSpec) ResourcePath() string {
+	if s.Group == "" {
+		return s.Version + "/" + s.Resource
+	}
+	return s.Group + "/" + s.Version + "/" + s.Resource
+}
+
+func (s WatchSpec) key() string {
+	return subscriptionKey(s.Cluster, s.ResourcePath(), s.Namespace)
+}
+
+// ResourceSpec is the WatchSpec of the events whose Resource field is
+// resource: a ResourcePath, or the bare resource name plugins publish under.
+func ResourceSpec(cluster, resource, namespace string) WatchSpec {
+	spec := WatchSpec{Cluster: cluster, Resource: resource, Namespace: namespace}
+	switch parts := strings.Split(resource, "/"); len(parts) {
+	case 2:
+		spec.Version, spec.Resource = parts[0], parts[1]
+	case 3:
+		spec.Group, spec.Version, spec.Resource = parts[0], parts[1], parts[2]
+	}
+	return spec
+}
+
+// Watch is a running resource watch started by a WatchSource.
+type Watch interface {
+	// Snapshot returns the objects currently known to the watch, so clients
+	// joining a running watch can be brought up to date.
+	Snapshot() []json.RawMessage
+	// Stop ends the underlying watch.
+	Stop()
+}
+
+// WatchSource starts the underlying Kubernetes watch of a WatchSpec. Events
+// must be passed to emit with Type ADDED, MODIFIED or DELETED. It is
+// implemented by the cluster package to avoid an import cycle.
+type WatchSource interface {
+	StartWatch(spec WatchSpec, emit func(WatchEvent)) (Watch, error)
+}
+
+// WatchAuthorizer reports whether userID may watch spec.
+type WatchAuthorizer func(ctx context.Context, userID string, spec WatchSpec) bool
+
+// ObjectRedactor returns a copy of a Kubernetes object with secret material
+// masked.
+type ObjectRedactor func(obj json.RawMessage) json.RawMessage
+
+// sharedWatch is one underlying watch, shared by every client subscribed to
+// its key.
+type sharedWatch struct {
+	watch Watch
+	refs  int
+}
+
+// watchReply tells a client that a watch or unwatch request failed.
+type watchReply struct {
+	Type  string    `json:"type"` // "watch_error"
+	Spec  WatchSpec `json:"spec"`
+	Error string    `json:"error"`
+}
+
+// Errors returned when a watch cannot be started.
+var (
+	ErrWatchUnavailable = errors.New("live watches are not available")
+	ErrWatchForbidden   = errors.New("permission denied")
+)
+
+// SetWatchSource enables live watches on arbitrary resource types. Each
+// (cluster, GVR, namespace) is watched once, however many clients subscribe,
+// and the watch is stopped when the last subscriber leaves. authorize is
+// checked for every watch request; a nil authorizer allows all requests.
+func (h *Hub) SetWatchSource(src WatchSource, authorize WatchAuthorizer) {
+	h.watchMu.Lock()
+	defer h.watchMu.Unlock()
+	h.watchSource = src
+	h.authorizeWatch = authorize
+}
+
+// SetWatchRedaction redacts the objects in events sent to clients, except on
+// watches allowRaw permits the client's user to see unredacted. A nil
+// redactor turns redaction off.
+func (h *Hub) SetWatchRedaction(redact ObjectRedactor, allowRaw WatchAuthorizer) {
+	h.redactMu.Lock()
+	defer h.redactMu.Unlock()
+	h.redactObject = redact
+	h.authorizeRaw = allowRaw
+}
+
+func (h *Hub) objectRedactor() ObjectRedactor {
+	h.redactMu.RLock()
+	defer h.redactMu.RUnlock()
+	return h.redactObject
+}
+
+// revealsRaw reports whether userID may see spec's objects unredacted.
+func (h *Hub) revealsRaw(userID string, spec WatchSpec) bool {
+	h.redactMu.RLock()
+	redact, allowRaw := h.redactObject, h.authorizeRaw
+	h.redactMu.RUnlock()
+	if redact == nil {
+		return true
+	}
+	return allowRaw != nil && allowRaw(context.Background(), userID, spec)
+}
+
+// Authorize reports whether userID may receive spec's events, using the
+// authorizer given to SetWatchSource. Subscriptions to published events are
+// checked with it like watches, since they see the same objects.
+func (h *Hub) Authorize(ctx context.Context, userID string, spec WatchSpec) bool {
+	h.watchMu.Lock()
+	authorize := h.authorizeWatch
+	h.watchMu.Unlock()
+	return authorize == nil || authorize(ctx, userID, spec)
+}
+
+// acquireWatch starts the watch for spec, or joins it when it is already
+// running. The returned snapshot is nil for a newly started watch, whose
+// initial ADDED events reach the client through the broadcast.
+func (h *Hub) acquireWatch(userID string, spec WatchSpec) ([]json.RawMessage, error) {
+	h.watchMu.Lock()
+	src := h.watchSource
+	h.watchMu.Unlock()
+
+	if src == nil {
+		return nil, ErrWatchUnavailable
+	}
+	if !h.Authorize(context.Background(), userID, spec) {
+		return nil, ErrWatchForbidden
+	}
+
+	h.watchMu.Lock()
+	defer h.watchMu.Unlock()
+
+	key := spec.key()
+	if shared, ok := h.watches[key]; ok {
+		shared.refs++
+		return shared.watch.Snapshot(), nil
+	}
+
+	resource := spec.ResourcePath()
+	watch, err := src.StartWatch(spec, func(event WatchEvent) {
+		event.Cluster = spec.Cluster
+		event.Resource = resource
+		event.Namespace = spec.Namespace
+		h.publish(key, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	h.watches[key] = &sharedWatch{watch: watch, refs: 1}
+	log.Printf("ws: started watch %s", key)
+	return nil, nil
+}
+
+// WatchFunc starts the shared watch for spec on behalf of userID, or joins it
+// when it is already running, and calls fn with each of its events. It lets
+// server-side consumers share watches with WebSocket clients. The returned
+// snapshot holds the objects already known to a running watch; a newly
+// started watch reports its objects to fn as ADDED events. fn is called from
+// the watch's goroutine and must not block. Call stop to release the watch.
+func (h *Hub) WatchFunc(userID string, spec WatchSpec, fn func(WatchEvent)) (snapshot []json.RawMessage, stop func(), err error) {
+	key := spec.key()
+
+	// Listen before the watch starts so its initial events are delivered.
+	h.listenMu.Lock()
+	h.nextListener++
+	id := h.nextListener
+	if h.listeners[key] == nil {
+		h.listeners[key] = make(map[int]func(WatchEvent))
+	}
+	h.listeners[key][id] = fn
+	h.listenMu.Unlock()
+
+	unlisten := func() {
+		h.listenMu.Lock()
+		delete(h.listeners[key], id)
+		if len(h.listeners[key]) == 0 {
+			delete(h.listeners, key)
+		}
+		h.listenMu.Unlock()
+	}
+
+	snapshot, err = h.acquireWatch(userID, spec)
+	if err != nil {
+		unlisten()
+		return nil, nil, err
+	}
+	var once sync.Once
+	return snapshot, func() {
+		once.Do(func() {
+			unlisten()
+			h.releaseWatch(key)
+		})
+	}, nil
+}
+
+// notifyListeners passes event to the WatchFunc consumers of subKey.
+func (h *Hub) notifyListeners(subKey string, event WatchEvent) {
+	h.listenMu.RLock()
+	defer h.listenMu.RUnlock()
+	for _, fn := range h.listeners[subKey] {
+		fn(event)
+	}
+}
+
+// releaseWatch drops one reference to the watch at key, stopping it when no
+// subscribers remain.
+func (h *Hub) releaseWatch(key string) {
+	h.watchMu.Lock()
+	defer h.watchMu.Unlock()
+
+	shared, ok := h.watches[key]
+	if !ok {
+		return
+	}
+	shared.refs--
+	if shared.refs > 0 {
+		return
+	}
+	delete(h.watches, key)
+	shared.watch.Stop()
+	log.Printf("ws: stopped watch %s", key)
+}
+
+// activeWatches returns the number of running watches.
+func (h *Hub) activeWatches() int {
+	h.watchMu.Lock()
+	defer h.watchMu.Unlock()
+	return len(h.watches)
+}
+
+// startWatch handles a client's watch request.
+func (c *Client) startWatch(spec WatchSpec) {
+	if spec.Cluster == "" || spec.Version == "" || spec.Resource == "" {
+		c.replyWatchError(spec, errors.New("cluster, version and resource are required"))
+		return
+	}
+	if !validate.Segment(spec.Group) || !validate.Segment(spec.Version) || !validate.Segment(spec.Resource) {
+		c.replyWatchError(spec, errors.New("invalid group, version, or resource"))
+		return
+	}
+	if err := validate.Namespace(spec.Namespace); err != nil {
+		c.replyWatchError(spec, err)
+		return
+	}
+	key := spec.key()
+	raw := c.hub.revealsRaw(c.UserID, spec)
+
+	c.subMu.Lock()
+	if c.watches[key] {
+		c.subMu.Unlock()
+		return
+	}
+	// Subscribe before the watch starts so its initial events are delivered.
+	c.watches[key] = true
+	c.subscriptions[key] = true
+	c.revealed[key] = raw
+	c.subMu.Unlock()
+
+	snapshot, err := c.hub.acquireWatch(c.UserID, spec)
+	if err != nil {
+		c.subMu.Lock()
+		delete(c.watches, key)
+		delete(c.subscriptions, key)
+		delete(c.revealed, key)
+		c.subMu.Unlock()
+		c.replyWatchError(spec, err)
+		return
+	}
+	log.Printf("ws: client %s watching %s", c.ID, key)
+
+	redact := c.hub.objectRedactor()
+	for _, obj := range snapshot {
+		if redact != nil && !raw {
+			obj = redact(obj)
+		}
+		data, err := json.Marshal(WatchEvent{
+			Cluster:   spec.Cluster,
+			Resource:  spec.ResourcePath(),
+			Namespace: spec.Namespace,
+			Type:      "ADDED",
+			Object:    obj,
+		})
+		if err != nil {
+			continue
+		}
+		c.trySend(data)
+	}
+}
+
+// subscribe handles a client's subscribe request for events published under
+// resource, e.g. by plugins. The client needs the permission a watch on the
+// same resource would.
+func (c *Client) subscribe(cluster, resource, namespace string) {
+	spec := ResourceSpec(cluster, resource, namespace)
+	if !c.hub.Authorize(context.Background(), c.UserID, spec) {
+		c.replyWatchError(spec, ErrWatchForbidden)
+		return
+	}
+	key := subscriptionKey(cluster, resource, namespace)
+	c.subMu.Lock()
+	c.subscriptions[key] = true
+	c.subMu.Unlock()
+	log.Printf("ws: client %s subscribed to %s", c.ID, key)
+}
+
+// stopWatch handles a client's unwatch request.
+func (c *Client) stopWatch(spec WatchSpec) {
+	key := spec.key()
+
+	c.subMu.Lock()
+	watching := c.watches[key]
+	delete(c.watches, key)
+	delete(c.subscriptions, key)
+	delete(c.revealed, key)
+	c.subMu.Unlock()
+
+	if watching {
+		c.hub.releaseWatch(key)
+		log.Printf("ws: client %s stopped watching %s", c.ID, key)
+	}
+}
+
+// releaseWatches drops all of the client's watches when it disconnects.
+func (c *Client) releaseWatches() {
+	c.subMu.Lock()
+	keys := make([]string, 0, len(c.watches))
+	for key := range c.watches {
+		keys = append(keys, key)
+	}
+	c.watches = make(map[string]bool)
+	c.subMu.Unlock()
+
+	for _, key := range keys {
+		c.hub.releaseWatch(key)
+	}
+}
+
+func (c *Client) replyWatchError(spec WatchSpec, err error) {
+	data, mErr := json.Marshal(watchReply{Type: "watch_error", Spec: spec, Error: err.Error()})
+	if mErr != nil {
+		return
+	}
+	c.trySend(data)
+	log.Printf("ws: client %s watch on %s refused: %v", c.ID, spec.key(), err)
+}
+
+// trySend queues data for the client, dropping it if the client is slow.
+func (c *Client) trySend(data []byte) {
+	select {
+	case c.send <- data:
+	default:
+	}
+}
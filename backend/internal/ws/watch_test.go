@@ -0,0 +1,320 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeWatchSource records started watches and lets tests emit events.
+type fakeWatchSource struct {
+	mu      sync.Mutex
+	started int
+	stopped int
+	emit    func(WatchEvent)
+}
+
+type fakeWatch struct {
+	src *fakeWatchSource
+}
+
+func (w *fakeWatch) Snapshot() []json.RawMessage {
+	return []json.RawMessage{json.RawMessage(`{"metadata":{"name":"existing"}}`)}
+}
+
+func (w *fakeWatch) Stop() {
+	w.src.mu.Lock()
+	w.src.stopped++
+	w.src.mu.Unlock()
+}
+
+func (s *fakeWatchSource) StartWatch(spec WatchSpec, emit func(WatchEvent)) (Watch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started++
+	s.emit = emit
+	return &fakeWatch{src: s}, nil
+}
+
+func (s *fakeWatchSource) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.started, s.stopped
+}
+
+func newWatchClient(h *Hub, id string) *Client {
+	c := &Client{
+		ID:            id,
+		UserID:        "user-" + id,
+		subscriptions: make(map[string]bool),
+		watches:       make(map[string]bool),
+		revealed:      make(map[string]bool),
+		send:          make(chan []byte, 8),
+		hub:           h,
+	}
+	h.mu.Lock()
+	h.clients[c.ID] = c
+	h.mu.Unlock()
+	return c
+}
+
+func readEvent(t *testing.T, c *Client) WatchEvent {
+	t.Helper()
+	select {
+	case data := <-c.send:
+		var ev WatchEvent
+		if err := json.Unmarshal(data, &ev); err != nil {
+			t.Fatalf("failed to unmarshal event: %v", err)
+		}
+		return ev
+	case <-time.After(time.Second):
+		t.Fatalf("client %s received no event", c.ID)
+		return WatchEvent{}
+	}
+}
+
+func TestHub_WatchIsSharedAndStoppedWithLastSubscriber(t *testing.T) {
+	h := NewHub()
+	go h.Run()
+	src := &fakeWatchSource{}
+	h.SetWatchSource(src, nil)
+
+	spec := WatchSpec{Cluster: "c1", Group: "apps", Version: "v1", Resource: "deployments", Namespace: "default"}
+	a := newWatchClient(h, "a")
+	b := newWatchClient(h, "b")
+
+	a.startWatch(spec)
+	b.startWatch(spec)
+	if started, _ := src.counts(); started != 1 {
+		t.Fatalf("expected one underlying watch for two clients, got %d", started)
+	}
+
+	// The second client joined a running watch and gets its current objects.
+	if ev := readEvent(t, b); ev.Type != "ADDED" || ev.Resource != "apps/v1/deployments" {
+		t.Errorf("unexpected snapshot event: %+v", ev)
+	}
+
+	src.emit(WatchEvent{Type: "MODIFIED", Object: json.RawMessage(`{"metadata":{"name":"web"}}`)})
+	for _, c := range []*Client{a, b} {
+		ev := readEvent(t, c)
+		if ev.Type != "MODIFIED" || ev.Cluster != "c1" || ev.Namespace != "default" {
+			t.Errorf("client %s: unexpected event %+v", c.ID, ev)
+		}
+	}
+
+	a.stopWatch(spec)
+	if _, stopped := src.counts(); stopped != 0 {
+		t.Fatal("watch should keep running while a subscriber remains")
+	}
+
+	// Disconnecting the last subscriber stops the watch.
+	h.Unregister(b)
+	deadline := time.Now().Add(time.Second)
+	for h.activeWatches() != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, stopped := src.counts(); stopped != 1 || h.activeWatches() != 0 {
+		t.Errorf("expected the watch to stop with its last subscriber, stopped=%d active=%d", stopped, h.activeWatches())
+	}
+}
+
+func TestHub_WatchFuncSharesWatch(t *testing.T) {
+	h := NewHub()
+	go h.Run()
+	src := &fakeWatchSource{}
+	h.SetWatchSource(src, nil)
+
+	spec := WatchSpec{Cluster: "c1", Version: "v1", Resource: "pods", Namespace: "default"}
+	events := make(chan WatchEvent, 4)
+	snapshot, stop, err := h.WatchFunc("u1", spec, func(ev WatchEvent) { events <- ev })
+	if err != nil {
+		t.Fatalf("WatchFunc: %v", err)
+	}
+	if snapshot != nil {
+		t.Errorf("expected no snapshot for a new watch, got %d objects", len(snapshot))
+	}
+
+	c := newWatchClient(h, "a")
+	c.startWatch(spec)
+	if started, _ := src.counts(); started != 1 {
+		t.Fatalf("expected one underlying watch for a consumer and a client, got %d", started)
+	}
+	readEvent(t, c) // snapshot
+
+	src.emit(WatchEvent{Type: "DELETED", Object: json.RawMessage(`{"metadata":{"name":"web-0"}}`)})
+	select {
+	case ev := <-events:
+		if ev.Type != "DELETED" || ev.Resource != "v1/pods" || ev.Namespace != "default" {
+			t.Errorf("unexpected event %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the consumer received no event")
+	}
+	if ev := readEvent(t, c); ev.Type != "DELETED" {
+		t.Errorf("unexpected client event %+v", ev)
+	}
+
+	c.stopWatch(spec)
+	stop()
+	stop() // idempotent
+	if _, stopped := src.counts(); stopped != 1 || h.activeWatches() != 0 {
+		t.Errorf("expected the watch to stop with its last consumer, stopped=%d active=%d", stopped, h.activeWatches())
+	}
+}
+
+func TestHub_WatchFuncRefused(t *testing.T) {
+	h := NewHub()
+	h.SetWatchSource(&fakeWatchSource{}, func(context.Context, string, WatchSpec) bool { return false })
+
+	_, _, err := h.WatchFunc("u1", WatchSpec{Cluster: "c1", Version: "v1", Resource: "secrets"}, func(WatchEvent) {})
+	if err != ErrWatchForbidden {
+		t.Fatalf("expected ErrWatchForbidden, got %v", err)
+	}
+	h.listenMu.RLock()
+	defer h.listenMu.RUnlock()
+	if len(h.listeners) != 0 {
+		t.Error("a refused watch must not leave a listener behind")
+	}
+}
+
+func TestHub_WatchAuthorization(t *testing.T) {
+	h := NewHub()
+	src := &fakeWatchSource{}
+	h.SetWatchSource(src, func(ctx context.Context, userID string, spec WatchSpec) bool {
+		return spec.Resource != "secrets"
+	})
+
+	c := newWatchClient(h, "a")
+	c.startWatch(WatchSpec{Cluster: "c1", Version: "v1", Resource: "secrets"})
+
+	var reply watchReply
+	if err := json.Unmarshal(<-c.send, &reply); err != nil {
+		t.Fatalf("failed to unmarshal reply: %v", err)
+	}
+	if reply.Type != "watch_error" || reply.Error != ErrWatchForbidden.Error() {
+		t.Errorf("unexpected reply: %+v", reply)
+	}
+	if started, _ := src.counts(); started != 0 {
+		t.Error("a denied watch must not start")
+	}
+	if c.IsSubscribed(WatchSpec{Cluster: "c1", Version: "v1", Resource: "secrets"}.key()) {
+		t.Error("a denied watch must not leave a subscription behind")
+	}
+}
+
+func TestHub_SubscribeAuthorization(t *testing.T) {
+	h := NewHub()
+	var checked []WatchSpec
+	h.SetWatchSource(&fakeWatchSource{}, func(ctx context.Context, userID string, spec WatchSpec) bool {
+		checked = append(checked, spec)
+		return spec.Namespace == "team-a"
+	})
+
+	c := newWatchClient(h, "a")
+	c.subscribe("c1", "v1/pods", "team-b")
+	var reply watchReply
+	if err := json.Unmarshal(<-c.send, &reply); err != nil {
+		t.Fatalf("failed to unmarshal reply: %v", err)
+	}
+	if reply.Type != "watch_error" || reply.Error != ErrWatchForbidden.Error() {
+		t.Errorf("unexpected reply: %+v", reply)
+	}
+	if c.IsSubscribed(subscriptionKey("c1", "v1/pods", "team-b")) {
+		t.Error("a denied subscription must not be recorded")
+	}
+
+	c.subscribe("c1", "v1/pods", "team-a")
+	if !c.IsSubscribed(subscriptionKey("c1", "v1/pods", "team-a")) {
+		t.Error("expected the subscription in the user's namespace")
+	}
+	want := WatchSpec{Cluster: "c1", Version: "v1", Resource: "pods", Namespace: "team-a"}
+	if len(checked) != 2 || checked[1] != want {
+		t.Errorf("expected the subscription to be checked as %+v, got %+v", want, checked)
+	}
+}
+
+func TestResourceSpec(t *testing.T) {
+	tests := map[string]WatchSpec{
+		"apps/v1/deployments": {Cluster: "c1", Group: "apps", Version: "v1", Resource: "deployments"},
+		"v1/pods":             {Cluster: "c1", Version: "v1", Resource: "pods"},
+		"scaledobjects":       {Cluster: "c1", Resource: "scaledobjects"},
+	}
+	for resource, want := range tests {
+		if got := ResourceSpec("c1", resource, ""); got != want {
+			t.Errorf("%s: expected %+v, got %+v", resource, want, got)
+		}
+		if want.Version != "" && want.ResourcePath() != resource {
+			t.Errorf("%s: ResourcePath does not round-trip: %s", resource, want.ResourcePath())
+		}
+	}
+}
+
+func TestHub_WatchWithoutSource(t *testing.T) {
+	h := NewHub()
+	c := newWatchClient(h, "a")
+	c.startWatch(WatchSpec{Cluster: "c1", Version: "v1", Resource: "pods"})
+
+	var reply watchReply
+	if err := json.Unmarshal(<-c.send, &reply); err != nil {
+		t.Fatalf("failed to unmarshal reply: %v", err)
+	}
+	if reply.Error != ErrWatchUnavailable.Error() {
+		t.Errorf("expected unavailable error, got %+v", reply)
+	}
+}
+
+func TestHub_WatchInvalidSpec(t *testing.T) {
+	h := NewHub()
+	src := &fakeWatchSource{}
+	h.SetWatchSource(src, nil)
+
+	c := newWatchClient(h, "a")
+	c.startWatch(WatchSpec{Cluster: "c1", Version: "v1", Resource: "pods", Namespace: "../kube-system"})
+	c.startWatch(WatchSpec{Cluster: "c1", Group: "apps/../", Version: "v1", Resource: "deployments"})
+
+	for i := 0; i < 2; i++ {
+		var reply watchReply
+		if err := json.Unmarshal(<-c.send, &reply); err != nil {
+			t.Fatalf("failed to unmarshal reply: %v", err)
+		}
+		if reply.Type != "watch_error" {
+			t.Errorf("unexpected reply: %+v", reply)
+		}
+	}
+	if started, _ := src.counts(); started != 0 {
+		t.Error("an invalid watch must not start")
+	}
+}
+
+func TestHub_WatchRedaction(t *testing.T) {
+	h := NewHub()
+	go h.Run()
+	src := &fakeWatchSource{}
+	h.SetWatchSource(src, nil)
+	h.SetWatchRedaction(func(obj json.RawMessage) json.RawMessage {
+		return json.RawMessage(`{"redacted":true}`)
+	}, func(ctx context.Context, userID string, spec WatchSpec) bool {
+		return userID == "user-admin"
+	})
+
+	spec := WatchSpec{Cluster: "c1", Version: "v1", Resource: "secrets", Namespace: "default"}
+	admin := newWatchClient(h, "admin")
+	viewer := newWatchClient(h, "viewer")
+	admin.startWatch(spec)
+	viewer.startWatch(spec)
+
+	// The snapshot for a client joining the running watch is redacted too.
+	if ev := readEvent(t, viewer); string(ev.Object) != `{"redacted":true}` {
+		t.Errorf("expected a redacted snapshot, got %s", ev.Object)
+	}
+
+	src.emit(WatchEvent{Type: "MODIFIED", Object: json.RawMessage(`{"data":{"password":"x"}}`)})
+	if ev := readEvent(t, admin); string(ev.Object) != `{"data":{"password":"x"}}` {
+		t.Errorf("expected the raw object for a user allowed to reveal it, got %s", ev.Object)
+	}
+	if ev := readEvent(t, viewer); string(ev.Object) != `{"redacted":true}` {
+		t.Errorf("expected a redacted object, got %s", ev.Object)
+	}
+}
@@ -0,0 +1,5 @@
+ALTER TABLE ai_tool_audit DROP COLUMN IF EXISTS confirmed_by;
+ALTER TABLE ai_tool_audit DROP COLUMN IF EXISTS confirmation_id;
+ALTER TABLE ai_tool_audit DROP COLUMN IF EXISTS classification;
+
+DROP TABLE IF EXISTS ai_tool_confirmations;
@@ -0,0 +1,20 @@
+-- Persisted confirmations for AI write tools. A confirmation is consumed
+-- (executed_at set) exactly once, so an approval cannot be replayed.
+CREATE TABLE IF NOT EXISTS ai_tool_confirmations (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    tool_call_id TEXT NOT NULL,
+    tool_name TEXT NOT NULL,
+    arguments TEXT,
+    classification TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    confirmed_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ,
+    executed_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_ai_tool_confirmations_user ON ai_tool_confirmations(user_id, created_at DESC);
+
+ALTER TABLE ai_tool_audit ADD COLUMN IF NOT EXISTS classification TEXT;
+ALTER TABLE ai_tool_audit ADD COLUMN IF NOT EXISTS confirmation_id UUID;
+ALTER TABLE ai_tool_audit ADD COLUMN IF NOT EXISTS confirmed_by UUID;
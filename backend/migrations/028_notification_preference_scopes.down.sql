@@ -0,0 +1,4 @@
+ALTER TABLE notification_preferences
+    DROP COLUMN IF EXISTS min_severity,
+    DROP COLUMN IF EXISTS namespaces,
+    DROP COLUMN IF EXISTS cluster_ids;
@@ -0,0 +1,6 @@
+-- Optional scope filters on notification preferences. Empty arrays / an empty
+-- min_severity mean "no filter", preserving the previous behavior.
+ALTER TABLE notification_preferences
+    ADD COLUMN cluster_ids TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN namespaces TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN min_severity VARCHAR(20) NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+-- Role inheritance: a role includes every permission of its parent roles,
+-- resolved transitively when a user's effective permissions are loaded.
+CREATE TABLE IF NOT EXISTS role_parents (
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    parent_role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (role_id, parent_role_id),
+    CHECK (role_id <> parent_role_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_parents_parent ON role_parents(parent_role_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_audit_log_service_account;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS service_account_id;
+DROP TABLE IF EXISTS service_account_tokens;
@@ -0,0 +1,27 @@
+-- Service account tokens: non-interactive credentials for CI/automation.
+-- Each token carries one role, optionally narrowed to a cluster or a
+-- namespace within it. Only a SHA-256 hash of the token is stored.
+CREATE TABLE IF NOT EXISTS service_account_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    token_prefix VARCHAR(20) NOT NULL,
+    role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    cluster_id UUID REFERENCES clusters(id) ON DELETE CASCADE,
+    namespace VARCHAR(255),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (namespace IS NULL OR cluster_id IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_account_tokens_role ON service_account_tokens(role_id);
+
+-- Actions performed with a service account token are attributed to it
+-- instead of a user.
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS service_account_id UUID REFERENCES service_account_tokens(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_service_account ON audit_log(service_account_id);
@@ -0,0 +1,10 @@
+-- Per-user, per-cluster namespace preferences: a default namespace to
+-- preselect and a list of favorite namespaces, synced across devices.
+CREATE TABLE IF NOT EXISTS user_namespace_preferences (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    cluster_id UUID NOT NULL REFERENCES clusters(id) ON DELETE CASCADE,
+    default_namespace VARCHAR(255) NOT NULL DEFAULT '',
+    favorites TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, cluster_id)
+);
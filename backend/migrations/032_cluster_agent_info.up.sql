@@ -0,0 +1,16 @@
+-- Latest cluster summary reported by each cluster's agent. Direct clusters
+-- are queried live, so only agent-connected clusters have a row.
+CREATE TABLE IF NOT EXISTS cluster_agent_info (
+    cluster_id UUID PRIMARY KEY REFERENCES clusters(id) ON DELETE CASCADE,
+    kubernetes_version VARCHAR(100) NOT NULL DEFAULT '',
+    platform VARCHAR(100) NOT NULL DEFAULT '',
+    node_count INTEGER NOT NULL DEFAULT 0,
+    capacity_cpu VARCHAR(50) NOT NULL DEFAULT '',
+    capacity_memory VARCHAR(50) NOT NULL DEFAULT '',
+    capacity_pods VARCHAR(50) NOT NULL DEFAULT '',
+    allocatable_cpu VARCHAR(50) NOT NULL DEFAULT '',
+    allocatable_memory VARCHAR(50) NOT NULL DEFAULT '',
+    allocatable_pods VARCHAR(50) NOT NULL DEFAULT '',
+    agent_version VARCHAR(50) NOT NULL DEFAULT '',
+    reported_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS share_sessions;
+DROP TABLE IF EXISTS share_links;
@@ -0,0 +1,32 @@
+-- Read-only share links. A link grants read access to one resource type in
+-- a cluster, optionally narrowed to a namespace, until it expires or is
+-- revoked. Only a SHA-256 hash of the link token is stored.
+CREATE TABLE IF NOT EXISTS share_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    token_prefix VARCHAR(24) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    cluster_id UUID NOT NULL REFERENCES clusters(id) ON DELETE CASCADE,
+    namespace VARCHAR(255),
+    resource VARCHAR(255) NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    redeem_count INTEGER NOT NULL DEFAULT 0,
+    last_redeemed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_links_created_by ON share_links(created_by);
+
+-- Sessions created by redeeming a link. They never outlive the link and
+-- stop working as soon as it is revoked.
+CREATE TABLE IF NOT EXISTS share_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    link_id UUID NOT NULL REFERENCES share_links(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_sessions_link ON share_sessions(link_id);
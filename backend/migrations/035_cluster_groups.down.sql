@@ -0,0 +1,3 @@
+DELETE FROM role_permissions WHERE scope_type = 'group';
+DROP TABLE IF EXISTS cluster_group_members;
+DROP TABLE IF EXISTS cluster_groups;
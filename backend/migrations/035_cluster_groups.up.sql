@@ -0,0 +1,17 @@
+-- Cluster groups organize clusters into named sets such as "prod". A
+-- cluster may belong to several groups. Role permissions can be scoped to a
+-- group (scope_type 'group', scope_id the group ID) to cover its clusters.
+CREATE TABLE IF NOT EXISTS cluster_groups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS cluster_group_members (
+    group_id UUID NOT NULL REFERENCES cluster_groups(id) ON DELETE CASCADE,
+    cluster_id UUID NOT NULL REFERENCES clusters(id) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, cluster_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cluster_group_members_cluster ON cluster_group_members(cluster_id);
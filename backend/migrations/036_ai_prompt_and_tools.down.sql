@@ -0,0 +1,3 @@
+ALTER TABLE ai_config DROP COLUMN IF EXISTS disabled_tools;
+ALTER TABLE ai_config DROP COLUMN IF EXISTS enabled_tools;
+ALTER TABLE ai_config DROP COLUMN IF EXISTS system_prompt;
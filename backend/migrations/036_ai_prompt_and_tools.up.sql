@@ -0,0 +1,6 @@
+-- Admin-editable system prompt and per-tool switches for the AI assistant.
+-- An empty system_prompt uses the built-in prompt. Destructive tools stay
+-- off unless listed in enabled_tools; disabled_tools are never offered.
+ALTER TABLE ai_config ADD COLUMN system_prompt TEXT NOT NULL DEFAULT '';
+ALTER TABLE ai_config ADD COLUMN enabled_tools TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE ai_config ADD COLUMN disabled_tools TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,3 @@
+-- Keyset pagination of a user's notifications walks (created_at, id)
+-- newest first; this index serves it without sorting.
+CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications(user_id, created_at DESC, id DESC);
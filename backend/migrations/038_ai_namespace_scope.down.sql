@@ -0,0 +1,2 @@
+ALTER TABLE ai_config DROP COLUMN IF EXISTS denied_namespaces;
+ALTER TABLE ai_config DROP COLUMN IF EXISTS allowed_namespaces;
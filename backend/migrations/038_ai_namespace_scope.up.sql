@@ -0,0 +1,5 @@
+-- Namespace guardrails for the AI assistant's tools, enforced before RBAC.
+-- Entries are names or patterns such as kube-*. Denied namespaces are never
+-- reached; a non-empty allowed list limits tools to the namespaces it matches.
+ALTER TABLE ai_config ADD COLUMN allowed_namespaces TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE ai_config ADD COLUMN denied_namespaces TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS notification_maintenance_windows;
+DROP TABLE IF EXISTS notification_snoozes;
@@ -0,0 +1,23 @@
+-- Per-user snoozes. While until is in the future the user's notifications
+-- are stored in-app but not sent to external channels.
+CREATE TABLE IF NOT EXISTS notification_snoozes (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    until TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Admin maintenance windows. Non-critical notifications for the listed
+-- clusters (all clusters when cluster_ids is empty) are not sent to external
+-- channels between starts_at and ends_at.
+CREATE TABLE IF NOT EXISTS notification_maintenance_windows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    cluster_ids TEXT[] NOT NULL DEFAULT '{}',
+    reason TEXT NOT NULL DEFAULT '',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_maintenance_windows_ends_at ON notification_maintenance_windows(ends_at);
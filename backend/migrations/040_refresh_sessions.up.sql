@@ -0,0 +1,13 @@
+-- Live refresh tokens, one row per login session, so the session policy can
+-- expire idle sessions and cap how many a user holds at once. Revoked
+-- sessions move to revoked_tokens.
+CREATE TABLE IF NOT EXISTS refresh_sessions (
+    token_jti    VARCHAR(255) PRIMARY KEY,
+    user_id      UUID         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    expires_at   TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_sessions_user_id ON refresh_sessions (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_refresh_sessions_expires_at ON refresh_sessions (expires_at);
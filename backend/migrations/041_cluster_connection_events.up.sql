@@ -0,0 +1,17 @@
+-- Connection history of agent clusters: registrations, stream connects and
+-- disconnects (with the reason), and refused connections. The newest 1000
+-- events are kept per cluster.
+CREATE TABLE IF NOT EXISTS cluster_connection_events (
+    id BIGSERIAL PRIMARY KEY,
+    cluster_id UUID NOT NULL REFERENCES clusters(id) ON DELETE CASCADE,
+    event VARCHAR(20) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    agent_id UUID,
+    token_id UUID,
+    token_issued_at TIMESTAMPTZ,
+    agent_version VARCHAR(50) NOT NULL DEFAULT '',
+    remote_addr VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_cluster_connection_events_cluster ON cluster_connection_events(cluster_id, id DESC);
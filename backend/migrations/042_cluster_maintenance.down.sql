@@ -0,0 +1,4 @@
+ALTER TABLE clusters
+    DROP COLUMN IF EXISTS maintenance_by,
+    DROP COLUMN IF EXISTS maintenance_reason,
+    DROP COLUMN IF EXISTS maintenance_since;
@@ -0,0 +1,6 @@
+-- Per-cluster maintenance mode. While maintenance_since is set the dashboard
+-- refuses every write to the cluster, whatever the user's RBAC allows.
+ALTER TABLE clusters
+    ADD COLUMN IF NOT EXISTS maintenance_since TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS maintenance_reason TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS maintenance_by UUID REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,4 @@
+-- How a preference's digest groups its events: category, cluster, namespace
+-- or severity. Empty means the server default (NOTIFICATION_DIGEST_GROUP_BY).
+ALTER TABLE notification_preferences
+    ADD COLUMN digest_group_by VARCHAR(20) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE ai_config DROP COLUMN IF EXISTS denied_kinds;
+ALTER TABLE ai_config DROP COLUMN IF EXISTS allowed_kinds;
@@ -0,0 +1,6 @@
+-- Kind guardrails for the AI assistant's write tools, enforced on the kind
+-- resolved from the cluster. Entries are kinds such as ClusterRoleBinding,
+-- optionally qualified with their group. Denied kinds are never changed; a
+-- non-empty allowed list limits writes to the kinds it names.
+ALTER TABLE ai_config ADD COLUMN allowed_kinds TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE ai_config ADD COLUMN denied_kinds TEXT[] NOT NULL DEFAULT '{}';
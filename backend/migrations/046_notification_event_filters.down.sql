@@ -0,0 +1,3 @@
+ALTER TABLE notification_preferences
+    DROP COLUMN IF EXISTS event_reasons,
+    DROP COLUMN IF EXISTS event_types;
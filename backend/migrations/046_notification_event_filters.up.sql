@@ -0,0 +1,7 @@
+-- Kubernetes Event filters on notification preferences: the event types
+-- (Normal, Warning) and reasons (e.g. BackOff) a preference notifies about.
+-- Empty arrays mean no filter beyond the server's NOTIFICATION_EVENT_TYPES
+-- and NOTIFICATION_EVENT_REASONS.
+ALTER TABLE notification_preferences
+    ADD COLUMN event_types TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN event_reasons TEXT[] NOT NULL DEFAULT '{}';
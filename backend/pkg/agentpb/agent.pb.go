@@ -148,6 +148,7 @@ type AgentMessage struct {
 	//	*AgentMessage_WatchEvent
 	//	*AgentMessage_Pong
 	//	*AgentMessage_ClusterInfo
+	//	*AgentMessage_K8SResponseChunk
 	Payload       isAgentMessage_Payload `protobuf_oneof:"payload"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -226,6 +227,15 @@ func (x *AgentMessage) GetClusterInfo() *ClusterInfo {
 	return nil
 }
 
+func (x *AgentMessage) GetK8SResponseChunk() *K8SResponseChunk {
+	if x != nil {
+		if x, ok := x.Payload.(*AgentMessage_K8SResponseChunk); ok {
+			return x.K8SResponseChunk
+		}
+	}
+	return nil
+}
+
 type isAgentMessage_Payload interface {
 	isAgentMessage_Payload()
 }
@@ -246,6 +256,10 @@ type AgentMessage_ClusterInfo struct {
 	ClusterInfo *ClusterInfo `protobuf:"bytes,4,opt,name=cluster_info,json=clusterInfo,proto3,oneof"`
 }
 
+type AgentMessage_K8SResponseChunk struct {
+	K8SResponseChunk *K8SResponseChunk `protobuf:"bytes,5,opt,name=k8s_response_chunk,json=k8sResponseChunk,proto3,oneof"`
+}
+
 func (*AgentMessage_K8SResponse) isAgentMessage_Payload() {}
 
 func (*AgentMessage_WatchEvent) isAgentMessage_Payload() {}
@@ -254,6 +268,8 @@ func (*AgentMessage_Pong) isAgentMessage_Payload() {}
 
 func (*AgentMessage_ClusterInfo) isAgentMessage_Payload() {}
 
+func (*AgentMessage_K8SResponseChunk) isAgentMessage_Payload() {}
+
 // DashboardMessage is sent from the dashboard to the agent.
 type DashboardMessage struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -263,6 +279,8 @@ type DashboardMessage struct {
 	//	*DashboardMessage_WatchSubscribe
 	//	*DashboardMessage_WatchUnsubscribe
 	//	*DashboardMessage_Ping
+	//	*DashboardMessage_K8SStreamRequest
+	//	*DashboardMessage_K8SStreamCancel
 	Payload       isDashboardMessage_Payload `protobuf_oneof:"payload"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -341,6 +359,24 @@ func (x *DashboardMessage) GetPing() *Ping {
 	return nil
 }
 
+func (x *DashboardMessage) GetK8SStreamRequest() *K8SStreamRequest {
+	if x != nil {
+		if x, ok := x.Payload.(*DashboardMessage_K8SStreamRequest); ok {
+			return x.K8SStreamRequest
+		}
+	}
+	return nil
+}
+
+func (x *DashboardMessage) GetK8SStreamCancel() *K8SStreamCancel {
+	if x != nil {
+		if x, ok := x.Payload.(*DashboardMessage_K8SStreamCancel); ok {
+			return x.K8SStreamCancel
+		}
+	}
+	return nil
+}
+
 type isDashboardMessage_Payload interface {
 	isDashboardMessage_Payload()
 }
@@ -361,6 +397,14 @@ type DashboardMessage_Ping struct {
 	Ping *Ping `protobuf:"bytes,4,opt,name=ping,proto3,oneof"`
 }
 
+type DashboardMessage_K8SStreamRequest struct {
+	K8SStreamRequest *K8SStreamRequest `protobuf:"bytes,5,opt,name=k8s_stream_request,json=k8sStreamRequest,proto3,oneof"`
+}
+
+type DashboardMessage_K8SStreamCancel struct {
+	K8SStreamCancel *K8SStreamCancel `protobuf:"bytes,6,opt,name=k8s_stream_cancel,json=k8sStreamCancel,proto3,oneof"`
+}
+
 func (*DashboardMessage_K8SRequest) isDashboardMessage_Payload() {}
 
 func (*DashboardMessage_WatchSubscribe) isDashboardMessage_Payload() {}
@@ -369,6 +413,10 @@ func (*DashboardMessage_WatchUnsubscribe) isDashboardMessage_Payload() {}
 
 func (*DashboardMessage_Ping) isDashboardMessage_Payload() {}
 
+func (*DashboardMessage_K8SStreamRequest) isDashboardMessage_Payload() {}
+
+func (*DashboardMessage_K8SStreamCancel) isDashboardMessage_Payload() {}
+
 type K8SRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Unique request ID to correlate with the response.
@@ -470,7 +518,14 @@ type K8SResponse struct {
 	// Response headers.
 	Headers map[string]string `protobuf:"bytes,4,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	// Error message if the request could not be executed locally.
-	Error         string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	Error string `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	// True when body is compressed with content_encoding.
+	Compressed bool `protobuf:"varint,6,opt,name=compressed,proto3" json:"compressed,omitempty"`
+	// Compression applied to body ("gzip" or "zstd"), empty when uncompressed.
+	// Only encodings the dashboard accepted at stream setup are used.
+	ContentEncoding string `protobuf:"bytes,7,opt,name=content_encoding,json=contentEncoding,proto3" json:"content_encoding,omitempty"`
+	// Content-Type of the original, uncompressed body.
+	ContentType   string `protobuf:"bytes,8,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -540,6 +595,230 @@ func (x *K8SResponse) GetError() string {
 	return ""
 }
 
+func (x *K8SResponse) GetCompressed() bool {
+	if x != nil {
+		return x.Compressed
+	}
+	return false
+}
+
+func (x *K8SResponse) GetContentEncoding() string {
+	if x != nil {
+		return x.ContentEncoding
+	}
+	return ""
+}
+
+func (x *K8SResponse) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+// K8sStreamRequest asks the agent to execute a request and send the response
+// body back incrementally as K8sResponseChunk messages instead of a single
+// K8sResponse. Used for watches, follow-mode logs and other long responses.
+type K8SStreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The request to execute. Its request_id correlates the response chunks.
+	Request       *K8SRequest `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *K8SStreamRequest) Reset() {
+	*x = K8SStreamRequest{}
+	mi := &file_agent_v1_agent_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *K8SStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*K8SStreamRequest) ProtoMessage() {}
+
+func (x *K8SStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_v1_agent_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use K8SStreamRequest.ProtoReflect.Descriptor instead.
+func (*K8SStreamRequest) Descriptor() ([]byte, []int) {
+	return file_agent_v1_agent_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *K8SStreamRequest) GetRequest() *K8SRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+// K8sStreamCancel stops a streaming request, e.g. when the client went away.
+type K8SStreamCancel struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Matches K8sStreamRequest.request.request_id.
+	RequestId     string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *K8SStreamCancel) Reset() {
+	*x = K8SStreamCancel{}
+	mi := &file_agent_v1_agent_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *K8SStreamCancel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*K8SStreamCancel) ProtoMessage() {}
+
+func (x *K8SStreamCancel) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_v1_agent_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use K8SStreamCancel.ProtoReflect.Descriptor instead.
+func (*K8SStreamCancel) Descriptor() ([]byte, []int) {
+	return file_agent_v1_agent_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *K8SStreamCancel) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+// K8sResponseChunk is one piece of a streamed response.
+type K8SResponseChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Matches K8sStreamRequest.request.request_id.
+	RequestId string `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// Position of this chunk in the stream, starting at 0.
+	Sequence int64 `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// HTTP status code from the Kubernetes API. Set on the first chunk only.
+	StatusCode int32 `protobuf:"varint,3,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	// Response headers. Set on the first chunk only.
+	Headers map[string]string `protobuf:"bytes,4,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Content-Type of the response body. Set on the first chunk only.
+	ContentType string `protobuf:"bytes,5,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// Next piece of the response body; may be empty.
+	Data []byte `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+	// Terminal marker: true on the last chunk, no chunks follow it.
+	Final bool `protobuf:"varint,7,opt,name=final,proto3" json:"final,omitempty"`
+	// Set on the final chunk if the stream ended with an error.
+	Error         string `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *K8SResponseChunk) Reset() {
+	*x = K8SResponseChunk{}
+	mi := &file_agent_v1_agent_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *K8SResponseChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*K8SResponseChunk) ProtoMessage() {}
+
+func (x *K8SResponseChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_v1_agent_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use K8SResponseChunk.ProtoReflect.Descriptor instead.
+func (*K8SResponseChunk) Descriptor() ([]byte, []int) {
+	return file_agent_v1_agent_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *K8SResponseChunk) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *K8SResponseChunk) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *K8SResponseChunk) GetStatusCode() int32 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *K8SResponseChunk) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *K8SResponseChunk) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *K8SResponseChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *K8SResponseChunk) GetFinal() bool {
+	if x != nil {
+		return x.Final
+	}
+	return false
+}
+
+func (x *K8SResponseChunk) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
 type WatchSubscribe struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Unique watch ID.
@@ -554,7 +833,7 @@ type WatchSubscribe struct {
 
 func (x *WatchSubscribe) Reset() {
 	*x = WatchSubscribe{}
-	mi := &file_agent_v1_agent_proto_msgTypes[6]
+	mi := &file_agent_v1_agent_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -566,7 +845,7 @@ func (x *WatchSubscribe) String() string {
 func (*WatchSubscribe) ProtoMessage() {}
 
 func (x *WatchSubscribe) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_v1_agent_proto_msgTypes[6]
+	mi := &file_agent_v1_agent_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -579,7 +858,7 @@ func (x *WatchSubscribe) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WatchSubscribe.ProtoReflect.Descriptor instead.
 func (*WatchSubscribe) Descriptor() ([]byte, []int) {
-	return file_agent_v1_agent_proto_rawDescGZIP(), []int{6}
+	return file_agent_v1_agent_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *WatchSubscribe) GetWatchId() string {
@@ -612,7 +891,7 @@ type WatchUnsubscribe struct {
 
 func (x *WatchUnsubscribe) Reset() {
 	*x = WatchUnsubscribe{}
-	mi := &file_agent_v1_agent_proto_msgTypes[7]
+	mi := &file_agent_v1_agent_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -624,7 +903,7 @@ func (x *WatchUnsubscribe) String() string {
 func (*WatchUnsubscribe) ProtoMessage() {}
 
 func (x *WatchUnsubscribe) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_v1_agent_proto_msgTypes[7]
+	mi := &file_agent_v1_agent_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -637,7 +916,7 @@ func (x *WatchUnsubscribe) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WatchUnsubscribe.ProtoReflect.Descriptor instead.
 func (*WatchUnsubscribe) Descriptor() ([]byte, []int) {
-	return file_agent_v1_agent_proto_rawDescGZIP(), []int{7}
+	return file_agent_v1_agent_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *WatchUnsubscribe) GetWatchId() string {
@@ -661,7 +940,7 @@ type WatchEvent struct {
 
 func (x *WatchEvent) Reset() {
 	*x = WatchEvent{}
-	mi := &file_agent_v1_agent_proto_msgTypes[8]
+	mi := &file_agent_v1_agent_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -673,7 +952,7 @@ func (x *WatchEvent) String() string {
 func (*WatchEvent) ProtoMessage() {}
 
 func (x *WatchEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_v1_agent_proto_msgTypes[8]
+	mi := &file_agent_v1_agent_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -686,7 +965,7 @@ func (x *WatchEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WatchEvent.ProtoReflect.Descriptor instead.
 func (*WatchEvent) Descriptor() ([]byte, []int) {
-	return file_agent_v1_agent_proto_rawDescGZIP(), []int{8}
+	return file_agent_v1_agent_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *WatchEvent) GetWatchId() string {
@@ -719,7 +998,7 @@ type Ping struct {
 
 func (x *Ping) Reset() {
 	*x = Ping{}
-	mi := &file_agent_v1_agent_proto_msgTypes[9]
+	mi := &file_agent_v1_agent_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -731,7 +1010,7 @@ func (x *Ping) String() string {
 func (*Ping) ProtoMessage() {}
 
 func (x *Ping) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_v1_agent_proto_msgTypes[9]
+	mi := &file_agent_v1_agent_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -744,7 +1023,7 @@ func (x *Ping) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Ping.ProtoReflect.Descriptor instead.
 func (*Ping) Descriptor() ([]byte, []int) {
-	return file_agent_v1_agent_proto_rawDescGZIP(), []int{9}
+	return file_agent_v1_agent_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *Ping) GetTimestamp() *timestamppb.Timestamp {
@@ -763,7 +1042,7 @@ type Pong struct {
 
 func (x *Pong) Reset() {
 	*x = Pong{}
-	mi := &file_agent_v1_agent_proto_msgTypes[10]
+	mi := &file_agent_v1_agent_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -775,7 +1054,7 @@ func (x *Pong) String() string {
 func (*Pong) ProtoMessage() {}
 
 func (x *Pong) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_v1_agent_proto_msgTypes[10]
+	mi := &file_agent_v1_agent_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -788,7 +1067,7 @@ func (x *Pong) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Pong.ProtoReflect.Descriptor instead.
 func (*Pong) Descriptor() ([]byte, []int) {
-	return file_agent_v1_agent_proto_rawDescGZIP(), []int{10}
+	return file_agent_v1_agent_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *Pong) GetTimestamp() *timestamppb.Timestamp {
@@ -811,14 +1090,18 @@ type ClusterInfo struct {
 	// List of installed CRD names.
 	Crds []string `protobuf:"bytes,5,rep,name=crds,proto3" json:"crds,omitempty"`
 	// Agent binary version.
-	AgentVersion  string `protobuf:"bytes,6,opt,name=agent_version,json=agentVersion,proto3" json:"agent_version,omitempty"`
+	AgentVersion string `protobuf:"bytes,6,opt,name=agent_version,json=agentVersion,proto3" json:"agent_version,omitempty"`
+	// Sum of the nodes' capacity.
+	Capacity *NodeResources `protobuf:"bytes,7,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	// Sum of the nodes' allocatable resources.
+	Allocatable   *NodeResources `protobuf:"bytes,8,opt,name=allocatable,proto3" json:"allocatable,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ClusterInfo) Reset() {
 	*x = ClusterInfo{}
-	mi := &file_agent_v1_agent_proto_msgTypes[11]
+	mi := &file_agent_v1_agent_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -830,7 +1113,7 @@ func (x *ClusterInfo) String() string {
 func (*ClusterInfo) ProtoMessage() {}
 
 func (x *ClusterInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_agent_v1_agent_proto_msgTypes[11]
+	mi := &file_agent_v1_agent_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -843,7 +1126,7 @@ func (x *ClusterInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ClusterInfo.ProtoReflect.Descriptor instead.
 func (*ClusterInfo) Descriptor() ([]byte, []int) {
-	return file_agent_v1_agent_proto_rawDescGZIP(), []int{11}
+	return file_agent_v1_agent_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *ClusterInfo) GetKubernetesVersion() string {
@@ -888,6 +1171,81 @@ func (x *ClusterInfo) GetAgentVersion() string {
 	return ""
 }
 
+func (x *ClusterInfo) GetCapacity() *NodeResources {
+	if x != nil {
+		return x.Capacity
+	}
+	return nil
+}
+
+func (x *ClusterInfo) GetAllocatable() *NodeResources {
+	if x != nil {
+		return x.Allocatable
+	}
+	return nil
+}
+
+// Resource totals as Kubernetes quantity strings (e.g. "16", "64Gi").
+type NodeResources struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cpu           string                 `protobuf:"bytes,1,opt,name=cpu,proto3" json:"cpu,omitempty"`
+	Memory        string                 `protobuf:"bytes,2,opt,name=memory,proto3" json:"memory,omitempty"`
+	Pods          string                 `protobuf:"bytes,3,opt,name=pods,proto3" json:"pods,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeResources) Reset() {
+	*x = NodeResources{}
+	mi := &file_agent_v1_agent_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeResources) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeResources) ProtoMessage() {}
+
+func (x *NodeResources) ProtoReflect() protoreflect.Message {
+	mi := &file_agent_v1_agent_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeResources.ProtoReflect.Descriptor instead.
+func (*NodeResources) Descriptor() ([]byte, []int) {
+	return file_agent_v1_agent_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *NodeResources) GetCpu() string {
+	if x != nil {
+		return x.Cpu
+	}
+	return ""
+}
+
+func (x *NodeResources) GetMemory() string {
+	if x != nil {
+		return x.Memory
+	}
+	return ""
+}
+
+func (x *NodeResources) GetPods() string {
+	if x != nil {
+		return x.Pods
+	}
+	return ""
+}
+
 var File_agent_v1_agent_proto protoreflect.FileDescriptor
 
 const file_agent_v1_agent_proto_rawDesc = "" +
@@ -901,20 +1259,23 @@ const file_agent_v1_agent_proto_rawDesc = "" +
 	"\n" +
 	"cluster_id\x18\x01 \x01(\tR\tclusterId\x12\x1f\n" +
 	"\vagent_token\x18\x02 \x01(\tR\n" +
-	"agentToken\"\xf0\x01\n" +
+	"agentToken\"\xbc\x02\n" +
 	"\fAgentMessage\x12:\n" +
 	"\fk8s_response\x18\x01 \x01(\v2\x15.agent.v1.K8sResponseH\x00R\vk8sResponse\x127\n" +
 	"\vwatch_event\x18\x02 \x01(\v2\x14.agent.v1.WatchEventH\x00R\n" +
 	"watchEvent\x12$\n" +
 	"\x04pong\x18\x03 \x01(\v2\x0e.agent.v1.PongH\x00R\x04pong\x12:\n" +
-	"\fcluster_info\x18\x04 \x01(\v2\x15.agent.v1.ClusterInfoH\x00R\vclusterInfoB\t\n" +
-	"\apayload\"\x8c\x02\n" +
+	"\fcluster_info\x18\x04 \x01(\v2\x15.agent.v1.ClusterInfoH\x00R\vclusterInfo\x12J\n" +
+	"\x12k8s_response_chunk\x18\x05 \x01(\v2\x1a.agent.v1.K8sResponseChunkH\x00R\x10k8sResponseChunkB\t\n" +
+	"\apayload\"\xa1\x03\n" +
 	"\x10DashboardMessage\x127\n" +
 	"\vk8s_request\x18\x01 \x01(\v2\x14.agent.v1.K8sRequestH\x00R\n" +
 	"k8sRequest\x12C\n" +
 	"\x0fwatch_subscribe\x18\x02 \x01(\v2\x18.agent.v1.WatchSubscribeH\x00R\x0ewatchSubscribe\x12I\n" +
 	"\x11watch_unsubscribe\x18\x03 \x01(\v2\x1a.agent.v1.WatchUnsubscribeH\x00R\x10watchUnsubscribe\x12$\n" +
-	"\x04ping\x18\x04 \x01(\v2\x0e.agent.v1.PingH\x00R\x04pingB\t\n" +
+	"\x04ping\x18\x04 \x01(\v2\x0e.agent.v1.PingH\x00R\x04ping\x12J\n" +
+	"\x12k8s_stream_request\x18\x05 \x01(\v2\x1a.agent.v1.K8sStreamRequestH\x00R\x10k8sStreamRequest\x12G\n" +
+	"\x11k8s_stream_cancel\x18\x06 \x01(\v2\x19.agent.v1.K8sStreamCancelH\x00R\x0fk8sStreamCancelB\t\n" +
 	"\apayload\"\xee\x02\n" +
 	"\n" +
 	"K8sRequest\x12\x1d\n" +
@@ -930,7 +1291,7 @@ const file_agent_v1_agent_proto_rawDesc = "" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a>\n" +
 	"\x10QueryParamsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xf1\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xdf\x02\n" +
 	"\vK8sResponse\x12\x1d\n" +
 	"\n" +
 	"request_id\x18\x01 \x01(\tR\trequestId\x12\x1f\n" +
@@ -938,7 +1299,31 @@ const file_agent_v1_agent_proto_rawDesc = "" +
 	"statusCode\x12\x12\n" +
 	"\x04body\x18\x03 \x01(\fR\x04body\x12<\n" +
 	"\aheaders\x18\x04 \x03(\v2\".agent.v1.K8sResponse.HeadersEntryR\aheaders\x12\x14\n" +
-	"\x05error\x18\x05 \x01(\tR\x05error\x1a:\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12\x1e\n" +
+	"\n" +
+	"compressed\x18\x06 \x01(\bR\n" +
+	"compressed\x12)\n" +
+	"\x10content_encoding\x18\a \x01(\tR\x0fcontentEncoding\x12!\n" +
+	"\fcontent_type\x18\b \x01(\tR\vcontentType\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"B\n" +
+	"\x10K8sStreamRequest\x12.\n" +
+	"\arequest\x18\x01 \x01(\v2\x14.agent.v1.K8sRequestR\arequest\"0\n" +
+	"\x0fK8sStreamCancel\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\"\xd0\x02\n" +
+	"\x10K8sResponseChunk\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x1a\n" +
+	"\bsequence\x18\x02 \x01(\x03R\bsequence\x12\x1f\n" +
+	"\vstatus_code\x18\x03 \x01(\x05R\n" +
+	"statusCode\x12A\n" +
+	"\aheaders\x18\x04 \x03(\v2'.agent.v1.K8sResponseChunk.HeadersEntryR\aheaders\x12!\n" +
+	"\fcontent_type\x18\x05 \x01(\tR\vcontentType\x12\x12\n" +
+	"\x04data\x18\x06 \x01(\fR\x04data\x12\x14\n" +
+	"\x05final\x18\a \x01(\bR\x05final\x12\x14\n" +
+	"\x05error\x18\b \x01(\tR\x05error\x1a:\n" +
 	"\fHeadersEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"j\n" +
@@ -957,7 +1342,7 @@ const file_agent_v1_agent_proto_rawDesc = "" +
 	"\x04Ping\x128\n" +
 	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"@\n" +
 	"\x04Pong\x128\n" +
-	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"\xd0\x01\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"\xc0\x02\n" +
 	"\vClusterInfo\x12-\n" +
 	"\x12kubernetes_version\x18\x01 \x01(\tR\x11kubernetesVersion\x12\x1a\n" +
 	"\bplatform\x18\x02 \x01(\tR\bplatform\x12\x1d\n" +
@@ -967,7 +1352,13 @@ const file_agent_v1_agent_proto_rawDesc = "" +
 	"namespaces\x18\x04 \x03(\tR\n" +
 	"namespaces\x12\x12\n" +
 	"\x04crds\x18\x05 \x03(\tR\x04crds\x12#\n" +
-	"\ragent_version\x18\x06 \x01(\tR\fagentVersion2\x93\x01\n" +
+	"\ragent_version\x18\x06 \x01(\tR\fagentVersion\x123\n" +
+	"\bcapacity\x18\a \x01(\v2\x17.agent.v1.NodeResourcesR\bcapacity\x129\n" +
+	"\vallocatable\x18\b \x01(\v2\x17.agent.v1.NodeResourcesR\vallocatable\"M\n" +
+	"\rNodeResources\x12\x10\n" +
+	"\x03cpu\x18\x01 \x01(\tR\x03cpu\x12\x16\n" +
+	"\x06memory\x18\x02 \x01(\tR\x06memory\x12\x12\n" +
+	"\x04pods\x18\x03 \x01(\tR\x04pods2\x93\x01\n" +
 	"\fClusterAgent\x12A\n" +
 	"\bRegister\x12\x19.agent.v1.RegisterRequest\x1a\x1a.agent.v1.RegisterResponse\x12@\n" +
 	"\x06Stream\x12\x16.agent.v1.AgentMessage\x1a\x1a.agent.v1.DashboardMessage(\x010\x01B:Z8github.com/darkden-lab/argus/backend/pkg/agentpb;agentpbb\x06proto3"
@@ -984,7 +1375,7 @@ func file_agent_v1_agent_proto_rawDescGZIP() []byte {
 	return file_agent_v1_agent_proto_rawDescData
 }
 
-var file_agent_v1_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_agent_v1_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
 var file_agent_v1_agent_proto_goTypes = []any{
 	(*RegisterRequest)(nil),       // 0: agent.v1.RegisterRequest
 	(*RegisterResponse)(nil),      // 1: agent.v1.RegisterResponse
@@ -992,41 +1383,53 @@ var file_agent_v1_agent_proto_goTypes = []any{
 	(*DashboardMessage)(nil),      // 3: agent.v1.DashboardMessage
 	(*K8SRequest)(nil),            // 4: agent.v1.K8sRequest
 	(*K8SResponse)(nil),           // 5: agent.v1.K8sResponse
-	(*WatchSubscribe)(nil),        // 6: agent.v1.WatchSubscribe
-	(*WatchUnsubscribe)(nil),      // 7: agent.v1.WatchUnsubscribe
-	(*WatchEvent)(nil),            // 8: agent.v1.WatchEvent
-	(*Ping)(nil),                  // 9: agent.v1.Ping
-	(*Pong)(nil),                  // 10: agent.v1.Pong
-	(*ClusterInfo)(nil),           // 11: agent.v1.ClusterInfo
-	nil,                           // 12: agent.v1.K8sRequest.HeadersEntry
-	nil,                           // 13: agent.v1.K8sRequest.QueryParamsEntry
-	nil,                           // 14: agent.v1.K8sResponse.HeadersEntry
-	(*timestamppb.Timestamp)(nil), // 15: google.protobuf.Timestamp
+	(*K8SStreamRequest)(nil),      // 6: agent.v1.K8sStreamRequest
+	(*K8SStreamCancel)(nil),       // 7: agent.v1.K8sStreamCancel
+	(*K8SResponseChunk)(nil),      // 8: agent.v1.K8sResponseChunk
+	(*WatchSubscribe)(nil),        // 9: agent.v1.WatchSubscribe
+	(*WatchUnsubscribe)(nil),      // 10: agent.v1.WatchUnsubscribe
+	(*WatchEvent)(nil),            // 11: agent.v1.WatchEvent
+	(*Ping)(nil),                  // 12: agent.v1.Ping
+	(*Pong)(nil),                  // 13: agent.v1.Pong
+	(*ClusterInfo)(nil),           // 14: agent.v1.ClusterInfo
+	(*NodeResources)(nil),         // 15: agent.v1.NodeResources
+	nil,                           // 16: agent.v1.K8sRequest.HeadersEntry
+	nil,                           // 17: agent.v1.K8sRequest.QueryParamsEntry
+	nil,                           // 18: agent.v1.K8sResponse.HeadersEntry
+	nil,                           // 19: agent.v1.K8sResponseChunk.HeadersEntry
+	(*timestamppb.Timestamp)(nil), // 20: google.protobuf.Timestamp
 }
 var file_agent_v1_agent_proto_depIdxs = []int32{
-	11, // 0: agent.v1.RegisterRequest.cluster_info:type_name -> agent.v1.ClusterInfo
+	14, // 0: agent.v1.RegisterRequest.cluster_info:type_name -> agent.v1.ClusterInfo
 	5,  // 1: agent.v1.AgentMessage.k8s_response:type_name -> agent.v1.K8sResponse
-	8,  // 2: agent.v1.AgentMessage.watch_event:type_name -> agent.v1.WatchEvent
-	10, // 3: agent.v1.AgentMessage.pong:type_name -> agent.v1.Pong
-	11, // 4: agent.v1.AgentMessage.cluster_info:type_name -> agent.v1.ClusterInfo
-	4,  // 5: agent.v1.DashboardMessage.k8s_request:type_name -> agent.v1.K8sRequest
-	6,  // 6: agent.v1.DashboardMessage.watch_subscribe:type_name -> agent.v1.WatchSubscribe
-	7,  // 7: agent.v1.DashboardMessage.watch_unsubscribe:type_name -> agent.v1.WatchUnsubscribe
-	9,  // 8: agent.v1.DashboardMessage.ping:type_name -> agent.v1.Ping
-	12, // 9: agent.v1.K8sRequest.headers:type_name -> agent.v1.K8sRequest.HeadersEntry
-	13, // 10: agent.v1.K8sRequest.query_params:type_name -> agent.v1.K8sRequest.QueryParamsEntry
-	14, // 11: agent.v1.K8sResponse.headers:type_name -> agent.v1.K8sResponse.HeadersEntry
-	15, // 12: agent.v1.Ping.timestamp:type_name -> google.protobuf.Timestamp
-	15, // 13: agent.v1.Pong.timestamp:type_name -> google.protobuf.Timestamp
-	0,  // 14: agent.v1.ClusterAgent.Register:input_type -> agent.v1.RegisterRequest
-	2,  // 15: agent.v1.ClusterAgent.Stream:input_type -> agent.v1.AgentMessage
-	1,  // 16: agent.v1.ClusterAgent.Register:output_type -> agent.v1.RegisterResponse
-	3,  // 17: agent.v1.ClusterAgent.Stream:output_type -> agent.v1.DashboardMessage
-	16, // [16:18] is the sub-list for method output_type
-	14, // [14:16] is the sub-list for method input_type
-	14, // [14:14] is the sub-list for extension type_name
-	14, // [14:14] is the sub-list for extension extendee
-	0,  // [0:14] is the sub-list for field type_name
+	11, // 2: agent.v1.AgentMessage.watch_event:type_name -> agent.v1.WatchEvent
+	13, // 3: agent.v1.AgentMessage.pong:type_name -> agent.v1.Pong
+	14, // 4: agent.v1.AgentMessage.cluster_info:type_name -> agent.v1.ClusterInfo
+	8,  // 5: agent.v1.AgentMessage.k8s_response_chunk:type_name -> agent.v1.K8sResponseChunk
+	4,  // 6: agent.v1.DashboardMessage.k8s_request:type_name -> agent.v1.K8sRequest
+	9,  // 7: agent.v1.DashboardMessage.watch_subscribe:type_name -> agent.v1.WatchSubscribe
+	10, // 8: agent.v1.DashboardMessage.watch_unsubscribe:type_name -> agent.v1.WatchUnsubscribe
+	12, // 9: agent.v1.DashboardMessage.ping:type_name -> agent.v1.Ping
+	6,  // 10: agent.v1.DashboardMessage.k8s_stream_request:type_name -> agent.v1.K8sStreamRequest
+	7,  // 11: agent.v1.DashboardMessage.k8s_stream_cancel:type_name -> agent.v1.K8sStreamCancel
+	16, // 12: agent.v1.K8sRequest.headers:type_name -> agent.v1.K8sRequest.HeadersEntry
+	17, // 13: agent.v1.K8sRequest.query_params:type_name -> agent.v1.K8sRequest.QueryParamsEntry
+	18, // 14: agent.v1.K8sResponse.headers:type_name -> agent.v1.K8sResponse.HeadersEntry
+	4,  // 15: agent.v1.K8sStreamRequest.request:type_name -> agent.v1.K8sRequest
+	19, // 16: agent.v1.K8sResponseChunk.headers:type_name -> agent.v1.K8sResponseChunk.HeadersEntry
+	20, // 17: agent.v1.Ping.timestamp:type_name -> google.protobuf.Timestamp
+	20, // 18: agent.v1.Pong.timestamp:type_name -> google.protobuf.Timestamp
+	15, // 19: agent.v1.ClusterInfo.capacity:type_name -> agent.v1.NodeResources
+	15, // 20: agent.v1.ClusterInfo.allocatable:type_name -> agent.v1.NodeResources
+	0,  // 21: agent.v1.ClusterAgent.Register:input_type -> agent.v1.RegisterRequest
+	2,  // 22: agent.v1.ClusterAgent.Stream:input_type -> agent.v1.AgentMessage
+	1,  // 23: agent.v1.ClusterAgent.Register:output_type -> agent.v1.RegisterResponse
+	3,  // 24: agent.v1.ClusterAgent.Stream:output_type -> agent.v1.DashboardMessage
+	23, // [23:25] is the sub-list for method output_type
+	21, // [21:23] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
 }
 
 func init() { file_agent_v1_agent_proto_init() }
@@ -1039,12 +1442,15 @@ func file_agent_v1_agent_proto_init() {
 		(*AgentMessage_WatchEvent)(nil),
 		(*AgentMessage_Pong)(nil),
 		(*AgentMessage_ClusterInfo)(nil),
+		(*AgentMessage_K8SResponseChunk)(nil),
 	}
 	file_agent_v1_agent_proto_msgTypes[3].OneofWrappers = []any{
 		(*DashboardMessage_K8SRequest)(nil),
 		(*DashboardMessage_WatchSubscribe)(nil),
 		(*DashboardMessage_WatchUnsubscribe)(nil),
 		(*DashboardMessage_Ping)(nil),
+		(*DashboardMessage_K8SStreamRequest)(nil),
+		(*DashboardMessage_K8SStreamCancel)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -1052,7 +1458,7 @@ func file_agent_v1_agent_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_agent_v1_agent_proto_rawDesc), len(file_agent_v1_agent_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   15,
+			NumMessages:   20,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -0,0 +1,156 @@
+package agentpb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Encodings for K8SResponse.ContentEncoding.
+const (
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+)
+
+// Stream metadata keys used to negotiate response compression. The agent
+// lists the encodings it can produce under AcceptEncodingMetadataKey when it
+// opens the stream, and the dashboard answers with the one it picked in the
+// EncodingMetadataKey response header. If either side leaves its key out the
+// agent sends bodies uncompressed, so mixed versions keep working.
+const (
+	AcceptEncodingMetadataKey = "argus-accept-encoding"
+	EncodingMetadataKey       = "argus-encoding"
+)
+
+// CompressMinSize is the smallest body worth compressing; below this the
+// framing overhead outweighs the savings.
+const CompressMinSize = 1024
+
+// maxDecompressedSize caps how large a decompressed body may grow, so a
+// misbehaving agent cannot exhaust the dashboard's memory.
+const maxDecompressedSize = 256 << 20
+
+// SupportedEncodings lists the encodings this package can read and write,
+// in order of preference.
+var SupportedEncodings = []string{EncodingZstd, EncodingGzip}
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+// zstdCodec lazily creates the shared zstd encoder and decoder. Both are safe
+// for concurrent use through EncodeAll and DecodeAll.
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+		if zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxDecompressedSize))
+	})
+	return zstdEncoder, zstdDecoder, zstdErr
+}
+
+// NegotiateEncoding returns the most preferred supported encoding among those
+// offered by the peer, or "" if there is none. Offered values may be
+// comma-separated lists.
+func NegotiateEncoding(offered []string) string {
+	accepted := make(map[string]bool)
+	for _, v := range offered {
+		for _, enc := range strings.Split(v, ",") {
+			accepted[strings.ToLower(strings.TrimSpace(enc))] = true
+		}
+	}
+	for _, enc := range SupportedEncodings {
+		if accepted[enc] {
+			return enc
+		}
+	}
+	return ""
+}
+
+// CompressBody compresses the body in place with encoding and marks the
+// response as compressed. It does nothing when encoding is empty, the body is
+// smaller than CompressMinSize, or the body is already compressed. On error
+// the response is left unchanged.
+func (x *K8SResponse) CompressBody(encoding string) error {
+	if encoding == "" || x.Compressed || len(x.Body) < CompressMinSize {
+		return nil
+	}
+
+	var out []byte
+	switch encoding {
+	case EncodingGzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(x.Body); err != nil {
+			return fmt.Errorf("gzip: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("gzip: %w", err)
+		}
+		out = buf.Bytes()
+	case EncodingZstd:
+		enc, _, err := zstdCodec()
+		if err != nil {
+			return fmt.Errorf("zstd: %w", err)
+		}
+		out = enc.EncodeAll(x.Body, make([]byte, 0, len(x.Body)/2))
+	default:
+		return fmt.Errorf("unsupported encoding %q", encoding)
+	}
+
+	x.Body = out
+	x.Compressed = true
+	x.ContentEncoding = encoding
+	return nil
+}
+
+// DecompressBody restores the original body of a compressed response and
+// clears the compression flag. It does nothing for uncompressed responses.
+func (x *K8SResponse) DecompressBody() error {
+	if !x.Compressed {
+		return nil
+	}
+
+	var out []byte
+	switch x.ContentEncoding {
+	case EncodingGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(x.Body))
+		if err != nil {
+			return fmt.Errorf("gzip: %w", err)
+		}
+		defer zr.Close()
+		out, err = io.ReadAll(io.LimitReader(zr, maxDecompressedSize+1))
+		if err != nil {
+			return fmt.Errorf("gzip: %w", err)
+		}
+		if len(out) > maxDecompressedSize {
+			return fmt.Errorf("gzip: decompressed body exceeds %d bytes", maxDecompressedSize)
+		}
+	case EncodingZstd:
+		_, dec, err := zstdCodec()
+		if err != nil {
+			return fmt.Errorf("zstd: %w", err)
+		}
+		out, err = dec.DecodeAll(x.Body, nil)
+		if err != nil {
+			return fmt.Errorf("zstd: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported encoding %q", x.ContentEncoding)
+	}
+
+	x.Body = out
+	x.Compressed = false
+	x.ContentEncoding = ""
+	return nil
+}
@@ -0,0 +1,90 @@
+package agentpb
+
+import (
+	"bytes"
+	"testing"
+)
+
+func largeBody() []byte {
+	return bytes.Repeat([]byte(`{"kind":"Pod","metadata":{"name":"web","namespace":"default"}},`), 200)
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		offered []string
+		want    string
+	}{
+		{nil, ""},
+		{[]string{"br"}, ""},
+		{[]string{"gzip"}, EncodingGzip},
+		{[]string{"gzip, zstd"}, EncodingZstd},
+		{[]string{"GZIP", "deflate"}, EncodingGzip},
+	}
+	for _, tt := range tests {
+		if got := NegotiateEncoding(tt.offered); got != tt.want {
+			t.Errorf("NegotiateEncoding(%q) = %q, want %q", tt.offered, got, tt.want)
+		}
+	}
+}
+
+func TestCompressBody_RoundTrip(t *testing.T) {
+	for _, enc := range SupportedEncodings {
+		t.Run(enc, func(t *testing.T) {
+			body := largeBody()
+			resp := &K8SResponse{Body: append([]byte(nil), body...), ContentType: "application/json"}
+
+			if err := resp.CompressBody(enc); err != nil {
+				t.Fatalf("CompressBody: %v", err)
+			}
+			if !resp.Compressed || resp.ContentEncoding != enc {
+				t.Fatalf("expected compressed with %s, got compressed=%v encoding=%q", enc, resp.Compressed, resp.ContentEncoding)
+			}
+			if len(resp.Body) >= len(body) {
+				t.Errorf("expected smaller body, got %d >= %d", len(resp.Body), len(body))
+			}
+
+			if err := resp.DecompressBody(); err != nil {
+				t.Fatalf("DecompressBody: %v", err)
+			}
+			if resp.Compressed || resp.ContentEncoding != "" {
+				t.Error("expected compression fields to be cleared")
+			}
+			if !bytes.Equal(resp.Body, body) {
+				t.Error("body changed after round trip")
+			}
+			if resp.ContentType != "application/json" {
+				t.Errorf("content type changed: %q", resp.ContentType)
+			}
+		})
+	}
+}
+
+func TestCompressBody_SkipsSmallOrDisabled(t *testing.T) {
+	small := &K8SResponse{Body: []byte(`{"kind":"Status"}`)}
+	if err := small.CompressBody(EncodingGzip); err != nil || small.Compressed {
+		t.Errorf("small body should not be compressed (err=%v)", err)
+	}
+
+	disabled := &K8SResponse{Body: largeBody()}
+	if err := disabled.CompressBody(""); err != nil || disabled.Compressed {
+		t.Errorf("empty encoding should disable compression (err=%v)", err)
+	}
+}
+
+func TestCompressBody_UnsupportedEncoding(t *testing.T) {
+	body := largeBody()
+	resp := &K8SResponse{Body: body}
+	if err := resp.CompressBody("br"); err == nil {
+		t.Fatal("expected error for unsupported encoding")
+	}
+	if resp.Compressed || !bytes.Equal(resp.Body, body) {
+		t.Error("response should be unchanged on error")
+	}
+}
+
+func TestDecompressBody_Corrupt(t *testing.T) {
+	resp := &K8SResponse{Body: []byte("not gzip"), Compressed: true, ContentEncoding: EncodingGzip}
+	if err := resp.DecompressBody(); err == nil {
+		t.Error("expected error for corrupt body")
+	}
+}
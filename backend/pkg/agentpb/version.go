@@ -0,0 +1,59 @@
+package agentpb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AgentVersionMetadataKey is the stream metadata key under which the agent
+// sends its build version, so the dashboard can check it before accepting
+// the stream. Agents that predate it leave it out.
+const AgentVersionMetadataKey = "argus-agent-version"
+
+// MinSupportedAgentVersion is the oldest agent this dashboard build speaks
+// the protocol with. Raise it when a change needs a newer agent.
+const MinSupportedAgentVersion = "0.1.0"
+
+// ParseVersion parses a "major.minor.patch" version with an optional "v"
+// prefix. Missing minor or patch parts are zero; pre-release and build
+// suffixes ("-rc.1", "+sha") are ignored.
+func ParseVersion(s string) ([3]int, error) {
+	var v [3]int
+	core := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+	parts := strings.Split(core, ".")
+	if core == "" || len(parts) > 3 {
+		return v, fmt.Errorf("invalid version %q", s)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+// AgentVersionOutdated reports whether an agent version is older than min.
+// An empty or unparseable agent version counts as outdated: it comes from an
+// agent that predates version reporting or from a development build.
+func AgentVersionOutdated(version, min string) bool {
+	want, err := ParseVersion(min)
+	if err != nil {
+		return false
+	}
+	got, err := ParseVersion(version)
+	if err != nil {
+		return true
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return got[i] < want[i]
+		}
+	}
+	return false
+}
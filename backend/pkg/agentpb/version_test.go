@@ -0,0 +1,51 @@
+package agentpb
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    [3]int
+		wantErr bool
+	}{
+		{in: "0.1.0", want: [3]int{0, 1, 0}},
+		{in: "v1.12.3", want: [3]int{1, 12, 3}},
+		{in: "2.1", want: [3]int{2, 1, 0}},
+		{in: "1.4.0-rc.1+abc", want: [3]int{1, 4, 0}},
+		{in: "", wantErr: true},
+		{in: "dev", wantErr: true},
+		{in: "1.2.3.4", wantErr: true},
+		{in: "1..2", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseVersion(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseVersion(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAgentVersionOutdated(t *testing.T) {
+	tests := []struct {
+		version, min string
+		want         bool
+	}{
+		{"0.1.0", "0.1.0", false},
+		{"0.2.0", "0.1.5", false},
+		{"1.0.0", "0.9.9", false},
+		{"0.1.9", "0.2.0", true},
+		{"v0.1.0", "0.1.1", true},
+		{"", "0.1.0", true},
+		{"dev", "0.1.0", true},
+		{"0.1.0", "", false},
+	}
+	for _, tt := range tests {
+		if got := AgentVersionOutdated(tt.version, tt.min); got != tt.want {
+			t.Errorf("AgentVersionOutdated(%q, %q) = %v, want %v", tt.version, tt.min, got, tt.want)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -38,7 +39,7 @@ func (h *Handlers) ListResources(resource string) http.HandlerFunc {
 
 		client, err := h.cm.GetClient(clusterID)
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cluster not found"})
+			httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 			return
 		}
 
@@ -49,7 +50,7 @@ func (h *Handlers) ListResources(resource string) http.HandlerFunc {
 			list, err = client.DynClient.Resource(h.gvr(resource)).Namespace("").List(context.Background(), metav1.ListOptions{})
 		}
 		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
@@ -67,13 +68,13 @@ func (h *Handlers) GetResource(resource string) http.HandlerFunc {
 
 		client, err := h.cm.GetClient(clusterID)
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cluster not found"})
+			httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 			return
 		}
 
 		obj, err := client.DynClient.Resource(h.gvr(resource)).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			httputil.WriteError(w, http.StatusNotFound, err.Error())
 			return
 		}
 
@@ -88,19 +89,19 @@ func (h *Handlers) CreateResource(resource string) http.HandlerFunc {
 
 		client, err := h.cm.GetClient(clusterID)
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cluster not found"})
+			httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 			return
 		}
 
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+			httputil.WriteError(w, http.StatusBadRequest, "invalid body")
 			return
 		}
 
 		obj := &unstructured.Unstructured{}
 		if err := json.Unmarshal(body, &obj.Object); err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+			httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
 			return
 		}
 
@@ -111,7 +112,7 @@ func (h *Handlers) CreateResource(resource string) http.HandlerFunc {
 
 		created, err := client.DynClient.Resource(h.gvr(resource)).Namespace(namespace).Create(context.Background(), obj, metav1.CreateOptions{})
 		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
@@ -129,13 +130,13 @@ func (h *Handlers) DeleteResource(resource string) http.HandlerFunc {
 
 		client, err := h.cm.GetClient(clusterID)
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cluster not found"})
+			httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 			return
 		}
 
 		err = client.DynClient.Resource(h.gvr(resource)).Namespace(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
 		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
@@ -150,13 +151,13 @@ func (h *Handlers) ListClusterResources(resource string) http.HandlerFunc {
 
 		client, err := h.cm.GetClient(clusterID)
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cluster not found"})
+			httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 			return
 		}
 
 		list, err := client.DynClient.Resource(h.gvr(resource)).List(context.Background(), metav1.ListOptions{})
 		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
@@ -173,13 +174,13 @@ func (h *Handlers) GetClusterResource(resource string) http.HandlerFunc {
 
 		client, err := h.cm.GetClient(clusterID)
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cluster not found"})
+			httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 			return
 		}
 
 		obj, err := client.DynClient.Resource(h.gvr(resource)).Get(context.Background(), name, metav1.GetOptions{})
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			httputil.WriteError(w, http.StatusNotFound, err.Error())
 			return
 		}
 
@@ -194,25 +195,25 @@ func (h *Handlers) CreateClusterResource(resource string) http.HandlerFunc {
 
 		client, err := h.cm.GetClient(clusterID)
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cluster not found"})
+			httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 			return
 		}
 
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+			httputil.WriteError(w, http.StatusBadRequest, "invalid body")
 			return
 		}
 
 		obj := &unstructured.Unstructured{}
 		if err := json.Unmarshal(body, &obj.Object); err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+			httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
 			return
 		}
 
 		created, err := client.DynClient.Resource(h.gvr(resource)).Create(context.Background(), obj, metav1.CreateOptions{})
 		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
@@ -229,13 +230,13 @@ func (h *Handlers) DeleteClusterResource(resource string) http.HandlerFunc {
 
 		client, err := h.cm.GetClient(clusterID)
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cluster not found"})
+			httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 			return
 		}
 
 		err = client.DynClient.Resource(h.gvr(resource)).Delete(context.Background(), name, metav1.DeleteOptions{})
 		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
@@ -57,6 +57,11 @@ func (p *CalicoPlugin) Manifest() plugin.Manifest {
 	return manifest
 }
 
+// RequiredCapabilities satisfies plugin.Plugin: Calico's CRDs.
+func (p *CalicoPlugin) RequiredCapabilities() []plugin.Capability {
+	return []plugin.Capability{{Group: "crd.projectcalico.org", Version: "v1", Provider: "Calico"}}
+}
+
 func (p *CalicoPlugin) RegisterRoutes(router *mux.Router, cm *cluster.Manager) {
 	h := NewHandlers(cm)
 	sub := router.PathPrefix("/api/plugins/calico").Subrouter()
@@ -37,3 +37,10 @@ func TestManifest(t *testing.T) {
 		t.Errorf("expected 2 widgets, got %d", len(m.Frontend.Widgets))
 	}
 }
+
+func TestCalicoPluginRequiredCapabilities(t *testing.T) {
+	caps := (&CalicoPlugin{}).RequiredCapabilities()
+	if len(caps) != 1 || caps[0].Group != "crd.projectcalico.org" {
+		t.Errorf("expected the crd.projectcalico.org API to be required, got %v", caps)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -133,13 +134,13 @@ func (h *handlers) list(w http.ResponseWriter, r *http.Request, gvr schema.Group
 	clusterID, namespace := clusterAndNamespace(r)
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	list, err := client.DynClient.Resource(gvr).Namespace(namespace).List(r.Context(), metav1.ListOptions{})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errMsg(err.Error()))
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, list)
@@ -151,13 +152,13 @@ func (h *handlers) get(w http.ResponseWriter, r *http.Request, gvr schema.GroupV
 
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	obj, err := client.DynClient.Resource(gvr).Namespace(namespace).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg(err.Error()))
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, obj)
@@ -168,19 +169,19 @@ func (h *handlers) create(w http.ResponseWriter, r *http.Request, gvr schema.Gro
 
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	var obj unstructured.Unstructured
 	if err := json.NewDecoder(r.Body).Decode(&obj.Object); err != nil {
-		writeJSON(w, http.StatusBadRequest, errMsg("invalid request body"))
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	created, err := client.DynClient.Resource(gvr).Namespace(namespace).Create(r.Context(), &obj, metav1.CreateOptions{})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errMsg(err.Error()))
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusCreated, created)
@@ -191,19 +192,19 @@ func (h *handlers) update(w http.ResponseWriter, r *http.Request, gvr schema.Gro
 
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	var obj unstructured.Unstructured
 	if err := json.NewDecoder(r.Body).Decode(&obj.Object); err != nil {
-		writeJSON(w, http.StatusBadRequest, errMsg("invalid request body"))
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	updated, err := client.DynClient.Resource(gvr).Namespace(namespace).Update(r.Context(), &obj, metav1.UpdateOptions{})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errMsg(err.Error()))
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, updated)
@@ -215,12 +216,12 @@ func (h *handlers) del(w http.ResponseWriter, r *http.Request, gvr schema.GroupV
 
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	if err := client.DynClient.Resource(gvr).Namespace(namespace).Delete(r.Context(), name, metav1.DeleteOptions{}); err != nil {
-		writeJSON(w, http.StatusInternalServerError, errMsg(err.Error()))
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -231,7 +232,3 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data) //nolint:errcheck
 }
-
-func errMsg(msg string) map[string]string {
-	return map[string]string{"error": msg}
-}
@@ -58,6 +58,11 @@ func (p *CephPlugin) ID() string { return "ceph" }
 // Manifest satisfies plugin.Plugin.
 func (p *CephPlugin) Manifest() plugin.Manifest { return p.manifest }
 
+// RequiredCapabilities satisfies plugin.Plugin: the Rook Ceph CRDs.
+func (p *CephPlugin) RequiredCapabilities() []plugin.Capability {
+	return []plugin.Capability{{Group: gvrCephClusters.Group, Version: gvrCephClusters.Version, Resource: gvrCephClusters.Resource, Provider: "Rook Ceph"}}
+}
+
 // RegisterRoutes wires all Ceph CRUD endpoints onto the provided router.
 func (p *CephPlugin) RegisterRoutes(r *mux.Router, cm *cluster.Manager) {
 	h := newHandlers(cm)
@@ -115,3 +115,10 @@ func buildTestManifest() plugin.Manifest {
 		},
 	}
 }
+
+func TestCephPluginRequiredCapabilities(t *testing.T) {
+	caps := (&CephPlugin{}).RequiredCapabilities()
+	if len(caps) != 1 || caps[0].Group != "ceph.rook.io" {
+		t.Errorf("expected the ceph.rook.io API to be required, got %v", caps)
+	}
+}
@@ -56,6 +56,11 @@ func (p *CnpgPlugin) ID() string { return "cnpg" }
 // Manifest satisfies plugin.Plugin.
 func (p *CnpgPlugin) Manifest() plugin.Manifest { return p.manifest }
 
+// RequiredCapabilities satisfies plugin.Plugin: the CloudNativePG CRDs.
+func (p *CnpgPlugin) RequiredCapabilities() []plugin.Capability {
+	return []plugin.Capability{{Group: gvrClusters.Group, Version: gvrClusters.Version, Resource: gvrClusters.Resource, Provider: "CloudNativePG"}}
+}
+
 // RegisterRoutes wires all CNPG CRUD endpoints onto the provided router.
 func (p *CnpgPlugin) RegisterRoutes(r *mux.Router, cm *cluster.Manager) {
 	h := newHandlers(cm)
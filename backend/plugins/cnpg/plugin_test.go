@@ -115,3 +115,10 @@ func buildTestManifest() plugin.Manifest {
 		},
 	}
 }
+
+func TestCnpgPluginRequiredCapabilities(t *testing.T) {
+	caps := (&CnpgPlugin{}).RequiredCapabilities()
+	if len(caps) != 1 || caps[0].Group != "postgresql.cnpg.io" {
+		t.Errorf("expected the postgresql.cnpg.io API to be required, got %v", caps)
+	}
+}
@@ -6,10 +6,13 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/release"
@@ -80,13 +83,22 @@ func releaseToInfo(r *release.Release) ReleaseInfo {
 	}
 }
 
+// ListReleases lists the latest revision of every release. With a limit the
+// list is paged in namespace/name order and wrapped in an object carrying
+// next_cursor; without one the whole list is returned as an array.
 func (h *Handlers) ListReleases(w http.ResponseWriter, r *http.Request) {
 	clusterID := mux.Vars(r)["cluster"]
 	namespace := r.URL.Query().Get("namespace")
 
+	limit, cursor, err := parseReleasePage(r)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	cfg, err := h.getActionConfig(clusterID, namespace)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -96,18 +108,34 @@ func (h *Handlers) ListReleases(w http.ResponseWriter, r *http.Request) {
 
 	results, err := listAction.Run()
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	nextCursor := ""
+	if limit > 0 {
+		results, nextCursor = pageReleases(results, cursor, limit)
+	}
+
 	releases := make([]ReleaseInfo, len(results))
 	for i, r := range results {
 		releases[i] = releaseToInfo(r)
 	}
 
-	writeJSON(w, http.StatusOK, releases)
+	if limit == 0 {
+		writeJSON(w, http.StatusOK, releases)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"releases":    releases,
+		"limit":       limit,
+		"next_cursor": nextCursor,
+	})
 }
 
+// GetRelease returns a release with its manifest, notes and values. The
+// manifest is left out with manifest=false, for clients that fetch it
+// separately from GetReleaseManifest.
 func (h *Handlers) GetRelease(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	clusterID := vars["cluster"]
@@ -116,27 +144,64 @@ func (h *Handlers) GetRelease(w http.ResponseWriter, r *http.Request) {
 
 	cfg, err := h.getActionConfig(clusterID, namespace)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
 	getAction := action.NewGet(cfg)
 	rel, err := getAction.Run(name)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
 	detail := map[string]interface{}{
-		"release":  releaseToInfo(rel),
-		"manifest": rel.Manifest,
-		"notes":    rel.Info.Notes,
-		"values":   rel.Config,
+		"release": releaseToInfo(rel),
+		"notes":   rel.Info.Notes,
+		"values":  rel.Config,
+	}
+	if r.URL.Query().Get("manifest") != "false" {
+		detail["manifest"] = rel.Manifest
 	}
 
 	writeJSON(w, http.StatusOK, detail)
 }
 
+// GetReleaseManifest returns the rendered manifest of a release, or of the
+// given revision, as YAML. It is gzip-compressed for clients that accept it.
+func (h *Handlers) GetReleaseManifest(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	clusterID := vars["cluster"]
+	name := vars["name"]
+	namespace := r.URL.Query().Get("namespace")
+
+	revision := 0
+	if v := r.URL.Query().Get("revision"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			httputil.WriteError(w, http.StatusBadRequest, "revision must be a positive integer")
+			return
+		}
+		revision = n
+	}
+
+	cfg, err := h.getActionConfig(clusterID, namespace)
+	if err != nil {
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	getAction := action.NewGet(cfg)
+	getAction.Version = revision
+	rel, err := getAction.Run(name)
+	if err != nil {
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeManifest(w, r, rel.Manifest)
+}
+
 type InstallRequest struct {
 	ChartRef    string                 `json:"chart_ref"`
 	ReleaseName string                 `json:"release_name"`
@@ -146,23 +211,70 @@ type InstallRequest struct {
 }
 
 func (h *Handlers) InstallRelease(w http.ResponseWriter, r *http.Request) {
-	clusterID := mux.Vars(r)["cluster"]
+	installAction, chartObj, req, ok := h.prepareInstall(w, r)
+	if !ok {
+		return
+	}
 
-	body, err := io.ReadAll(r.Body)
+	rel, err := installAction.Run(chartObj, req.Values)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	writeJSON(w, http.StatusCreated, releaseToInfo(rel))
+}
+
+// PreviewInstall renders a chart with a dry-run install and summarizes what
+// it would create: objects by kind, total CPU/memory requests and limits, and
+// PVC sizes. Nothing is applied to the cluster.
+func (h *Handlers) PreviewInstall(w http.ResponseWriter, r *http.Request) {
+	installAction, chartObj, req, ok := h.prepareInstall(w, r)
+	if !ok {
+		return
+	}
+	installAction.DryRun = true
+	installAction.CreateNamespace = false
+
+	rel, err := installAction.Run(chartObj, req.Values)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	summary, err := summarizeManifest(rel.Manifest)
+	if err != nil {
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"manifest": rel.Manifest,
+		"notes":    rel.Info.Notes,
+		"summary":  summary,
+	})
+}
+
+// prepareInstall decodes an InstallRequest and loads its chart. On failure it
+// writes the error response and returns ok=false.
+func (h *Handlers) prepareInstall(w http.ResponseWriter, r *http.Request) (*action.Install, *chart.Chart, InstallRequest, bool) {
+	clusterID := mux.Vars(r)["cluster"]
+
 	var req InstallRequest
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		httputil.WriteError(w, http.StatusBadRequest, "invalid body")
+		return nil, nil, req, false
+	}
+
 	if err := json.Unmarshal(body, &req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
-		return
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		return nil, nil, req, false
 	}
 
 	if req.ReleaseName == "" || req.ChartRef == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "release_name and chart_ref are required"})
-		return
+		httputil.WriteError(w, http.StatusBadRequest, "release_name and chart_ref are required")
+		return nil, nil, req, false
 	}
 	if req.Namespace == "" {
 		req.Namespace = "default"
@@ -170,8 +282,8 @@ func (h *Handlers) InstallRelease(w http.ResponseWriter, r *http.Request) {
 
 	cfg, err := h.getActionConfig(clusterID, req.Namespace)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
-		return
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
+		return nil, nil, req, false
 	}
 
 	installAction := action.NewInstall(cfg)
@@ -182,23 +294,17 @@ func (h *Handlers) InstallRelease(w http.ResponseWriter, r *http.Request) {
 	// Locate chart
 	chartPath, err := installAction.ChartPathOptions.LocateChart(req.ChartRef, cli.New())
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("chart not found: %v", err)})
-		return
+		httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("chart not found: %v", err))
+		return nil, nil, req, false
 	}
 
 	chartObj, err := loader.Load(chartPath)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("failed to load chart: %v", err)})
-		return
+		httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("failed to load chart: %v", err))
+		return nil, nil, req, false
 	}
 
-	rel, err := installAction.Run(chartObj, req.Values)
-	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
-		return
-	}
-
-	writeJSON(w, http.StatusCreated, releaseToInfo(rel))
+	return installAction, chartObj, req, true
 }
 
 type UpgradeRequest struct {
@@ -218,19 +324,19 @@ func (h *Handlers) UpgradeRelease(w http.ResponseWriter, r *http.Request) {
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid body")
 		return
 	}
 
 	var req UpgradeRequest
 	if err := json.Unmarshal(body, &req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
 		return
 	}
 
 	cfg, err := h.getActionConfig(clusterID, namespace)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -239,19 +345,19 @@ func (h *Handlers) UpgradeRelease(w http.ResponseWriter, r *http.Request) {
 
 	chartPath, err := upgradeAction.ChartPathOptions.LocateChart(req.ChartRef, cli.New())
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("chart not found: %v", err)})
+		httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("chart not found: %v", err))
 		return
 	}
 
 	chartObj, err := loader.Load(chartPath)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("failed to load chart: %v", err)})
+		httputil.WriteError(w, http.StatusBadRequest, fmt.Sprintf("failed to load chart: %v", err))
 		return
 	}
 
 	rel, err := upgradeAction.Run(name, chartObj, req.Values)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -273,19 +379,19 @@ func (h *Handlers) RollbackRelease(w http.ResponseWriter, r *http.Request) {
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid body")
 		return
 	}
 
 	var req RollbackRequest
 	if err := json.Unmarshal(body, &req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
 		return
 	}
 
 	cfg, err := h.getActionConfig(clusterID, namespace)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -295,7 +401,7 @@ func (h *Handlers) RollbackRelease(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := rollbackAction.Run(name); err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -313,14 +419,14 @@ func (h *Handlers) UninstallRelease(w http.ResponseWriter, r *http.Request) {
 
 	cfg, err := h.getActionConfig(clusterID, namespace)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
 	uninstallAction := action.NewUninstall(cfg)
 	resp, err := uninstallAction.Run(name)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -338,7 +444,7 @@ func (h *Handlers) GetReleaseHistory(w http.ResponseWriter, r *http.Request) {
 
 	cfg, err := h.getActionConfig(clusterID, namespace)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -347,7 +453,7 @@ func (h *Handlers) GetReleaseHistory(w http.ResponseWriter, r *http.Request) {
 
 	results, err := historyAction.Run(name)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -367,7 +473,7 @@ func (h *Handlers) GetReleaseValues(w http.ResponseWriter, r *http.Request) {
 
 	cfg, err := h.getActionConfig(clusterID, namespace)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -376,7 +482,7 @@ func (h *Handlers) GetReleaseValues(w http.ResponseWriter, r *http.Request) {
 
 	values, err := getValuesAction.Run(name)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -0,0 +1,132 @@
+package helm
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/release"
+)
+
+// maxReleasePageSize caps the limit parameter of a paged release listing.
+const maxReleasePageSize = 500
+
+// releaseCursor is a position in a release listing, which is ordered by
+// namespace and then name. Helm lists only the latest revision of each
+// release, so the pair is unique.
+type releaseCursor struct {
+	Namespace string
+	Name      string
+}
+
+// errInvalidCursor is returned for a cursor that was not issued by encode.
+var errInvalidCursor = errors.New("invalid cursor")
+
+// encode returns the cursor as an opaque string.
+func (c releaseCursor) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.Namespace + "/" + c.Name))
+}
+
+// parseReleaseCursor decodes a cursor returned with a page.
+func parseReleaseCursor(s string) (*releaseCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	namespace, name, ok := strings.Cut(string(raw), "/")
+	if !ok || name == "" {
+		return nil, errInvalidCursor
+	}
+	return &releaseCursor{Namespace: namespace, Name: name}, nil
+}
+
+// parseReleasePage reads the limit and cursor query parameters. A zero
+// limit means the listing is not paged.
+func parseReleasePage(r *http.Request) (int, *releaseCursor, error) {
+	q := r.URL.Query()
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return 0, nil, errors.New("limit must be a positive integer")
+		}
+		limit = min(n, maxReleasePageSize)
+	}
+	c := q.Get("cursor")
+	if c == "" {
+		return limit, nil, nil
+	}
+	if limit == 0 {
+		return 0, nil, errors.New("cursor requires limit")
+	}
+	cursor, err := parseReleaseCursor(c)
+	if err != nil {
+		return 0, nil, err
+	}
+	return limit, cursor, nil
+}
+
+// pageReleases sorts releases by namespace and name and returns at most limit
+// of them after the cursor, with the cursor continuing after the page. The
+// cursor is empty on the last page.
+func pageReleases(releases []*release.Release, after *releaseCursor, limit int) ([]*release.Release, string) {
+	sort.Slice(releases, func(i, j int) bool {
+		return compareRelease(releases[i], releases[j].Namespace, releases[j].Name) < 0
+	})
+
+	start := 0
+	if after != nil {
+		start = sort.Search(len(releases), func(i int) bool {
+			return compareRelease(releases[i], after.Namespace, after.Name) > 0
+		})
+	}
+	end := min(start+limit, len(releases))
+	page := releases[start:end]
+	if end == len(releases) || len(page) == 0 {
+		return page, ""
+	}
+	last := page[len(page)-1]
+	return page, releaseCursor{Namespace: last.Namespace, Name: last.Name}.encode()
+}
+
+// compareRelease orders a release against a namespace and name.
+func compareRelease(r *release.Release, namespace, name string) int {
+	if c := strings.Compare(r.Namespace, namespace); c != 0 {
+		return c
+	}
+	return strings.Compare(r.Name, name)
+}
+
+// writeManifest writes a release manifest as YAML, gzip-compressed for
+// clients that accept it.
+func writeManifest(w http.ResponseWriter, r *http.Request, manifest string) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Add("Vary", "Accept-Encoding")
+	if !acceptsGzip(r) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(manifest)) //nolint:errcheck
+		return
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	w.WriteHeader(http.StatusOK)
+	gz := gzip.NewWriter(w)
+	gz.Write([]byte(manifest)) //nolint:errcheck
+	gz.Close()                 //nolint:errcheck
+}
+
+// acceptsGzip reports whether the client listed gzip in Accept-Encoding.
+func acceptsGzip(r *http.Request) bool {
+	for _, v := range r.Header.Values("Accept-Encoding") {
+		for _, enc := range strings.Split(v, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+			if strings.EqualFold(strings.TrimSpace(name), "gzip") && strings.TrimSpace(params) != "q=0" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package helm
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/release"
+)
+
+func TestReleaseCursorRoundTrip(t *testing.T) {
+	c := releaseCursor{Namespace: "team-a", Name: "web"}
+	got, err := parseReleaseCursor(c.encode())
+	if err != nil || *got != c {
+		t.Fatalf("expected %+v, got %+v (%v)", c, got, err)
+	}
+	for _, s := range []string{"not base64!", "bm9zbGFzaA", "dGVhbS1hLw"} {
+		if _, err := parseReleaseCursor(s); err != errInvalidCursor {
+			t.Errorf("parseReleaseCursor(%q): expected errInvalidCursor, got %v", s, err)
+		}
+	}
+}
+
+func TestParseReleasePage(t *testing.T) {
+	tests := []struct {
+		query   string
+		limit   int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"limit=50", 50, false},
+		{"limit=100000", maxReleasePageSize, false},
+		{"limit=0", 0, true},
+		{"limit=abc", 0, true},
+		{"cursor=" + releaseCursor{Namespace: "a", Name: "b"}.encode(), 0, true},
+		{"limit=10&cursor=bad!", 0, true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/releases?"+tt.query, nil)
+		limit, _, err := parseReleasePage(r)
+		if (err != nil) != tt.wantErr || limit != tt.limit {
+			t.Errorf("%q: got limit %d, err %v", tt.query, limit, err)
+		}
+	}
+}
+
+func TestPageReleases(t *testing.T) {
+	releases := []*release.Release{
+		{Name: "web", Namespace: "b"},
+		{Name: "db", Namespace: "b"},
+		{Name: "web", Namespace: "a"},
+		{Name: "api", Namespace: "c"},
+		{Name: "cache", Namespace: "a"},
+	}
+
+	var seen []string
+	var cursor *releaseCursor
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("expected the listing to end after three pages")
+		}
+		page, next := pageReleases(releases, cursor, 2)
+		for _, r := range page {
+			seen = append(seen, r.Namespace+"/"+r.Name)
+		}
+		if next == "" {
+			break
+		}
+		var err error
+		if cursor, err = parseReleaseCursor(next); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"a/cache", "a/web", "b/db", "b/web", "c/api"}
+	if len(seen) != len(want) {
+		t.Fatalf("expected %v, got %v", want, seen)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, seen)
+		}
+	}
+
+	// A cursor for a release uninstalled since still continues after it.
+	page, next := pageReleases(releases, &releaseCursor{Namespace: "b", Name: "delta"}, 10)
+	if len(page) != 2 || page[0].Name != "web" || next != "" {
+		t.Errorf("expected b/web and c/api, got %v (next %q)", page, next)
+	}
+}
+
+func TestWriteManifest(t *testing.T) {
+	const manifest = "apiVersion: v1\nkind: ConfigMap\n"
+
+	r := httptest.NewRequest(http.MethodGet, "/manifest", nil)
+	w := httptest.NewRecorder()
+	writeManifest(w, r, manifest)
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != manifest {
+		t.Errorf("expected the plain manifest, got %q", w.Body.String())
+	}
+
+	r.Header.Set("Accept-Encoding", "br, gzip")
+	w = httptest.NewRecorder()
+	writeManifest(w, r, manifest)
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected a gzip body, got headers %v", w.Header())
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(gz)
+	if err != nil || string(body) != manifest {
+		t.Errorf("expected the manifest after decompression, got %q (%v)", body, err)
+	}
+}
@@ -48,18 +48,24 @@ func (p *HelmPlugin) Manifest() plugin.Manifest {
 	return manifest
 }
 
+// RequiredCapabilities satisfies plugin.Plugin. Helm stores releases in
+// Secrets, so it works on any cluster.
+func (p *HelmPlugin) RequiredCapabilities() []plugin.Capability { return nil }
+
 func (p *HelmPlugin) RegisterRoutes(router *mux.Router, cm *cluster.Manager) {
 	h := NewHandlers(cm)
 	sub := router.PathPrefix("/api/plugins/helm").Subrouter()
 
 	sub.HandleFunc("/{cluster}/releases", h.ListReleases).Methods("GET")
 	sub.HandleFunc("/{cluster}/releases", h.InstallRelease).Methods("POST")
+	sub.HandleFunc("/{cluster}/releases/preview", h.PreviewInstall).Methods("POST")
 	sub.HandleFunc("/{cluster}/releases/{name}", h.GetRelease).Methods("GET")
 	sub.HandleFunc("/{cluster}/releases/{name}", h.UpgradeRelease).Methods("PUT")
 	sub.HandleFunc("/{cluster}/releases/{name}", h.UninstallRelease).Methods("DELETE")
 	sub.HandleFunc("/{cluster}/releases/{name}/rollback", h.RollbackRelease).Methods("POST")
 	sub.HandleFunc("/{cluster}/releases/{name}/history", h.GetReleaseHistory).Methods("GET")
 	sub.HandleFunc("/{cluster}/releases/{name}/values", h.GetReleaseValues).Methods("GET")
+	sub.HandleFunc("/{cluster}/releases/{name}/manifest", h.GetReleaseManifest).Methods("GET")
 }
 
 func (p *HelmPlugin) RegisterWatchers(hub *ws.Hub, cm *cluster.Manager) {
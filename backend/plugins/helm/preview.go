@@ -0,0 +1,246 @@
+package helm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ManifestSummary estimates what a rendered chart will create: object counts
+// by kind, the total CPU/memory requested and limited by its pods, and the
+// storage claimed by its PVCs. It is a rough cost signal, not a scheduler
+// simulation.
+type ManifestSummary struct {
+	TotalObjects int            `json:"total_objects"`
+	Kinds        map[string]int `json:"kinds"`
+	Workloads    []WorkloadCost `json:"workloads"`
+	// CPU values are millicores; memory and storage values are bytes.
+	CPURequests    int64        `json:"cpu_requests_millicores"`
+	CPULimits      int64        `json:"cpu_limits_millicores"`
+	MemoryRequests int64        `json:"memory_requests_bytes"`
+	MemoryLimits   int64        `json:"memory_limits_bytes"`
+	PVCs           []PVCRequest `json:"pvcs"`
+	StorageTotal   int64        `json:"storage_total_bytes"`
+	// Notes explains parts of the estimate that are approximate, e.g.
+	// DaemonSets counted once rather than per node.
+	Notes []string `json:"notes,omitempty"`
+}
+
+// WorkloadCost is the resource footprint of one pod-creating object, with
+// per-pod values multiplied by its replica count.
+type WorkloadCost struct {
+	Kind           string `json:"kind"`
+	Name           string `json:"name"`
+	Replicas       int64  `json:"replicas"`
+	CPURequests    int64  `json:"cpu_requests_millicores"`
+	CPULimits      int64  `json:"cpu_limits_millicores"`
+	MemoryRequests int64  `json:"memory_requests_bytes"`
+	MemoryLimits   int64  `json:"memory_limits_bytes"`
+}
+
+// PVCRequest is a persistent volume claim the chart will create, either
+// directly or through a StatefulSet volumeClaimTemplate.
+type PVCRequest struct {
+	Name         string `json:"name"`
+	Source       string `json:"source"`
+	StorageClass string `json:"storage_class,omitempty"`
+	Size         string `json:"size"`
+	Count        int64  `json:"count"`
+	Bytes        int64  `json:"bytes"`
+}
+
+// summarizeManifest parses a rendered multi-document manifest and builds its
+// ManifestSummary. Documents that are empty or not Kubernetes objects are
+// skipped.
+func summarizeManifest(manifest string) (*ManifestSummary, error) {
+	summary := &ManifestSummary{
+		Kinds:     make(map[string]int),
+		Workloads: []WorkloadCost{},
+		PVCs:      []PVCRequest{},
+	}
+	notes := make(map[string]bool)
+
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(manifest)))
+	for {
+		var obj map[string]interface{}
+		err := dec.Decode(&obj)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rendered manifest: %w", err)
+		}
+		kind, _ := obj["kind"].(string)
+		if kind == "" {
+			continue
+		}
+		summary.TotalObjects++
+		summary.Kinds[kind]++
+
+		name := nestedString(obj, "metadata", "name")
+		switch kind {
+		case "PersistentVolumeClaim":
+			if pvc, ok := pvcRequest(nestedMap(obj, "spec"), name, kind+"/"+name, 1); ok {
+				summary.addPVC(pvc)
+			}
+			continue
+		case "DaemonSet":
+			notes["DaemonSet pods are counted once; actual usage scales with the number of nodes."] = true
+		case "CronJob":
+			notes["CronJob pods are counted for a single run."] = true
+		}
+
+		podSpec, replicas := podTemplate(kind, obj)
+		if podSpec == nil {
+			continue
+		}
+		cost := WorkloadCost{Kind: kind, Name: name, Replicas: replicas}
+		for _, c := range nestedSlice(podSpec, "containers") {
+			cm, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			cost.CPURequests += milliValue(nestedString(cm, "resources", "requests", "cpu")) * replicas
+			cost.CPULimits += milliValue(nestedString(cm, "resources", "limits", "cpu")) * replicas
+			cost.MemoryRequests += byteValue(nestedString(cm, "resources", "requests", "memory")) * replicas
+			cost.MemoryLimits += byteValue(nestedString(cm, "resources", "limits", "memory")) * replicas
+		}
+		summary.Workloads = append(summary.Workloads, cost)
+		summary.CPURequests += cost.CPURequests
+		summary.CPULimits += cost.CPULimits
+		summary.MemoryRequests += cost.MemoryRequests
+		summary.MemoryLimits += cost.MemoryLimits
+
+		if kind == "StatefulSet" {
+			for _, t := range nestedSlice(obj, "spec", "volumeClaimTemplates") {
+				tm, ok := t.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if pvc, ok := pvcRequest(nestedMap(tm, "spec"), nestedString(tm, "metadata", "name"), kind+"/"+name, replicas); ok {
+					summary.addPVC(pvc)
+				}
+			}
+		}
+	}
+
+	sort.Slice(summary.Workloads, func(i, j int) bool {
+		if summary.Workloads[i].CPURequests != summary.Workloads[j].CPURequests {
+			return summary.Workloads[i].CPURequests > summary.Workloads[j].CPURequests
+		}
+		return summary.Workloads[i].Name < summary.Workloads[j].Name
+	})
+	for note := range notes {
+		summary.Notes = append(summary.Notes, note)
+	}
+	sort.Strings(summary.Notes)
+	return summary, nil
+}
+
+func (s *ManifestSummary) addPVC(pvc PVCRequest) {
+	s.PVCs = append(s.PVCs, pvc)
+	s.StorageTotal += pvc.Bytes * pvc.Count
+}
+
+// podTemplate returns the pod spec an object creates and how many pods it
+// runs: spec.replicas for controllers (default 1), spec.parallelism for Jobs.
+func podTemplate(kind string, obj map[string]interface{}) (map[string]interface{}, int64) {
+	switch kind {
+	case "Pod":
+		return nestedMap(obj, "spec"), 1
+	case "Deployment", "StatefulSet", "ReplicaSet", "ReplicationController":
+		return nestedMap(obj, "spec", "template", "spec"), replicaCount(obj, "spec", "replicas")
+	case "DaemonSet":
+		return nestedMap(obj, "spec", "template", "spec"), 1
+	case "Job":
+		return nestedMap(obj, "spec", "template", "spec"), replicaCount(obj, "spec", "parallelism")
+	case "CronJob":
+		return nestedMap(obj, "spec", "jobTemplate", "spec", "template", "spec"), replicaCount(obj, "spec", "jobTemplate", "spec", "parallelism")
+	}
+	return nil, 0
+}
+
+// pvcRequest reads the storage request of a PVC spec.
+func pvcRequest(spec map[string]interface{}, name, source string, count int64) (PVCRequest, bool) {
+	if spec == nil {
+		return PVCRequest{}, false
+	}
+	size := nestedString(spec, "resources", "requests", "storage")
+	return PVCRequest{
+		Name:         name,
+		Source:       source,
+		StorageClass: nestedString(spec, "storageClassName"),
+		Size:         size,
+		Count:        count,
+		Bytes:        byteValue(size),
+	}, true
+}
+
+// replicaCount reads an integer field, defaulting to 1 when unset.
+func replicaCount(obj map[string]interface{}, path ...string) int64 {
+	parent := nestedMap(obj, path[:len(path)-1]...)
+	switch v := parent[path[len(path)-1]].(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 1
+}
+
+func nestedMap(obj map[string]interface{}, path ...string) map[string]interface{} {
+	cur := obj
+	for _, key := range path {
+		next, ok := cur[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = next
+	}
+	return cur
+}
+
+func nestedSlice(obj map[string]interface{}, path ...string) []interface{} {
+	parent := nestedMap(obj, path[:len(path)-1]...)
+	s, _ := parent[path[len(path)-1]].([]interface{})
+	return s
+}
+
+// nestedString reads a scalar field as a string. Quantities may be written
+// as YAML numbers ("cpu: 1"), so those are formatted too.
+func nestedString(obj map[string]interface{}, path ...string) string {
+	parent := nestedMap(obj, path[:len(path)-1]...)
+	switch v := parent[path[len(path)-1]].(type) {
+	case string:
+		return v
+	case int, int64, float64:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// milliValue parses a CPU quantity into millicores; invalid values count as zero.
+func milliValue(s string) int64 {
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return 0
+	}
+	return q.MilliValue()
+}
+
+// byteValue parses a memory or storage quantity into bytes; invalid values
+// count as zero.
+func byteValue(s string) int64 {
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return 0
+	}
+	return q.Value()
+}
@@ -0,0 +1,145 @@
+package helm
+
+import (
+	"testing"
+)
+
+const previewManifest = `---
+# Source: app/templates/serviceaccount.yaml
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: app
+---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app-web
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: web
+          resources:
+            requests:
+              cpu: 250m
+              memory: 128Mi
+            limits:
+              cpu: 1
+              memory: 256Mi
+        - name: sidecar
+          resources:
+            requests:
+              cpu: 50m
+---
+# Source: app/templates/statefulset.yaml
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: app-db
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+        - name: db
+          resources:
+            requests:
+              cpu: 500m
+              memory: 1Gi
+  volumeClaimTemplates:
+    - metadata:
+        name: data
+      spec:
+        storageClassName: fast
+        resources:
+          requests:
+            storage: 10Gi
+---
+# Source: app/templates/pvc.yaml
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: app-uploads
+spec:
+  resources:
+    requests:
+      storage: 5Gi
+---
+# Source: app/templates/agent.yaml
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: app-agent
+spec:
+  template:
+    spec:
+      containers:
+        - name: agent
+          resources:
+            requests:
+              cpu: 100m
+`
+
+func TestSummarizeManifest(t *testing.T) {
+	s, err := summarizeManifest(previewManifest)
+	if err != nil {
+		t.Fatalf("summarizeManifest: %v", err)
+	}
+
+	if s.TotalObjects != 5 {
+		t.Errorf("expected 5 objects, got %d", s.TotalObjects)
+	}
+	if s.Kinds["Deployment"] != 1 || s.Kinds["PersistentVolumeClaim"] != 1 || s.Kinds["ServiceAccount"] != 1 {
+		t.Errorf("unexpected kinds %v", s.Kinds)
+	}
+
+	// web: 3 x (250m + 50m), db: 2 x 500m, agent: 100m
+	if s.CPURequests != 900+1000+100 {
+		t.Errorf("expected 2000m cpu requests, got %d", s.CPURequests)
+	}
+	if s.CPULimits != 3000 {
+		t.Errorf("expected 3000m cpu limits, got %d", s.CPULimits)
+	}
+	if want := int64(3*128<<20 + 2<<30); s.MemoryRequests != want {
+		t.Errorf("expected %d memory requests, got %d", want, s.MemoryRequests)
+	}
+
+	if len(s.Workloads) != 3 || s.Workloads[0].Name != "app-db" || s.Workloads[0].Replicas != 2 {
+		t.Errorf("expected workloads sorted by cpu requests, got %+v", s.Workloads)
+	}
+
+	if len(s.PVCs) != 2 {
+		t.Fatalf("expected 2 PVC entries, got %+v", s.PVCs)
+	}
+	if want := int64(5<<30 + 2*10<<30); s.StorageTotal != want {
+		t.Errorf("expected %d storage, got %d", want, s.StorageTotal)
+	}
+	for _, pvc := range s.PVCs {
+		if pvc.Name == "data" && (pvc.Count != 2 || pvc.StorageClass != "fast" || pvc.Source != "StatefulSet/app-db") {
+			t.Errorf("unexpected volumeClaimTemplate entry %+v", pvc)
+		}
+	}
+
+	if len(s.Notes) != 1 {
+		t.Errorf("expected a DaemonSet note, got %v", s.Notes)
+	}
+}
+
+func TestSummarizeManifest_Empty(t *testing.T) {
+	s, err := summarizeManifest("")
+	if err != nil {
+		t.Fatalf("summarizeManifest: %v", err)
+	}
+	if s.TotalObjects != 0 || len(s.Workloads) != 0 || len(s.PVCs) != 0 {
+		t.Errorf("expected empty summary, got %+v", s)
+	}
+}
+
+func TestSummarizeManifest_Invalid(t *testing.T) {
+	if _, err := summarizeManifest("kind: [unterminated"); err == nil {
+		t.Error("expected error for invalid YAML")
+	}
+}
@@ -0,0 +1,315 @@
+package istio
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
+)
+
+// Finding severities, most severe first.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Finding codes reported by the config analysis.
+const (
+	CodeConflictingHosts     = "ConflictingVirtualServiceHosts"
+	CodeSubsetNotFound       = "ReferencedSubsetNotFound"
+	CodeGatewayWithoutRoutes = "GatewayWithoutVirtualService"
+)
+
+// meshGateway is the implicit gateway of VirtualServices that list none.
+const meshGateway = "mesh"
+
+// AnalysisFinding is one misconfiguration detected in a mesh resource.
+type AnalysisFinding struct {
+	Code      string `json:"code"`
+	Severity  string `json:"severity"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Message   string `json:"message"`
+}
+
+// AnalysisResponse is the response envelope for the analyze endpoint.
+type AnalysisResponse struct {
+	Findings []AnalysisFinding `json:"findings"`
+}
+
+// analyzeHandler handles GET /api/plugins/istio/{cluster}/analyze
+type analyzeHandler struct {
+	cm *cluster.Manager
+}
+
+func newAnalyzeHandler(cm *cluster.Manager) *analyzeHandler {
+	return &analyzeHandler{cm: cm}
+}
+
+// RegisterRoutes registers the analyze endpoint on the given router.
+func (a *analyzeHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/api/plugins/istio/{cluster}/analyze", a.Analyze).Methods("GET")
+}
+
+// Analyze runs istioctl-analyze-style checks over the namespace's
+// VirtualServices, DestinationRules and Gateways.
+func (a *analyzeHandler) Analyze(w http.ResponseWriter, r *http.Request) {
+	clusterID := mux.Vars(r)["cluster"]
+	namespace := r.URL.Query().Get("namespace")
+
+	client, err := a.cm.GetClient(clusterID)
+	if err != nil {
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
+		return
+	}
+
+	res := listMeshResources(r.Context(), client, namespace)
+	writeJSON(w, http.StatusOK, AnalysisResponse{Findings: analyzeMeshResources(res)})
+}
+
+// analyzeMeshResources checks the resources for conflicting host routes,
+// VirtualService destinations whose subset no DestinationRule defines, and
+// Gateways no VirtualService is bound to. Only the given resources are
+// considered, so a namespace-scoped analysis cannot see references from or
+// to other namespaces. Findings are sorted by severity, then resource.
+func analyzeMeshResources(res meshResources) []AnalysisFinding {
+	findings := make([]AnalysisFinding, 0)
+	findings = append(findings, conflictingHostFindings(res.virtualServices)...)
+	findings = append(findings, missingSubsetFindings(res.virtualServices, res.destinationRules)...)
+	findings = append(findings, unboundGatewayFindings(res.virtualServices, res.gateways)...)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity != b.Severity {
+			return a.Severity == SeverityError
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return findings
+}
+
+// conflictingHostFindings reports VirtualServices that route the same host on
+// the same gateway. On the mesh gateway only one of them takes effect; on
+// other gateways their rules are merged in no defined order.
+func conflictingHostFindings(virtualServices []unstructured.Unstructured) []AnalysisFinding {
+	type binding struct{ gateway, host string }
+	owners := make(map[binding][]*unstructured.Unstructured)
+	var order []binding
+	for i := range virtualServices {
+		vs := &virtualServices[i]
+		for _, gw := range virtualServiceGateways(vs) {
+			for _, host := range extractStringSlice(vs.Object, "spec", "hosts") {
+				b := binding{gateway: gw, host: serviceFQDN(host, vs.GetNamespace())}
+				if _, seen := owners[b]; !seen {
+					order = append(order, b)
+				}
+				owners[b] = append(owners[b], vs)
+			}
+		}
+	}
+
+	var findings []AnalysisFinding
+	for _, b := range order {
+		vss := owners[b]
+		if len(vss) < 2 {
+			continue
+		}
+		severity := SeverityWarning
+		if b.gateway == meshGateway {
+			severity = SeverityError
+		}
+		for _, vs := range vss {
+			var others []string
+			for _, other := range vss {
+				if other != vs {
+					others = append(others, other.GetNamespace()+"/"+other.GetName())
+				}
+			}
+			findings = append(findings, AnalysisFinding{
+				Code:      CodeConflictingHosts,
+				Severity:  severity,
+				Kind:      "VirtualService",
+				Namespace: vs.GetNamespace(),
+				Name:      vs.GetName(),
+				Message: "host " + b.host + " on gateway " + b.gateway + " is also routed by VirtualService " +
+					strings.Join(others, ", "),
+			})
+		}
+	}
+	return findings
+}
+
+// missingSubsetFindings reports VirtualService destinations that name a
+// subset no DestinationRule for the destination host defines.
+func missingSubsetFindings(virtualServices, destinationRules []unstructured.Unstructured) []AnalysisFinding {
+	type rule struct {
+		host    string
+		subsets map[string]bool
+	}
+	rules := make([]rule, 0, len(destinationRules))
+	for _, dr := range destinationRules {
+		host, _, _ := unstructured.NestedString(dr.Object, "spec", "host")
+		if host == "" {
+			continue
+		}
+		subsets := make(map[string]bool)
+		list, _, _ := unstructured.NestedSlice(dr.Object, "spec", "subsets")
+		for _, s := range list {
+			if m, ok := s.(map[string]interface{}); ok {
+				if name, ok := m["name"].(string); ok {
+					subsets[name] = true
+				}
+			}
+		}
+		rules = append(rules, rule{host: serviceFQDN(host, dr.GetNamespace()), subsets: subsets})
+	}
+
+	var findings []AnalysisFinding
+	for _, vs := range virtualServices {
+		reported := make(map[string]bool)
+		for _, dest := range virtualServiceSubsetDestinations(vs.Object) {
+			host := serviceFQDN(dest.host, vs.GetNamespace())
+			if reported[host+"/"+dest.subset] {
+				continue
+			}
+			found, hasRule := false, false
+			for _, rl := range rules {
+				if hostMatches(rl.host, host) {
+					hasRule = true
+					if rl.subsets[dest.subset] {
+						found = true
+						break
+					}
+				}
+			}
+			if found {
+				continue
+			}
+			reported[host+"/"+dest.subset] = true
+			msg := "subset " + dest.subset + " of host " + host + " is not defined by any DestinationRule"
+			if !hasRule {
+				msg = "subset " + dest.subset + " is referenced but no DestinationRule exists for host " + host
+			}
+			findings = append(findings, AnalysisFinding{
+				Code:      CodeSubsetNotFound,
+				Severity:  SeverityError,
+				Kind:      "VirtualService",
+				Namespace: vs.GetNamespace(),
+				Name:      vs.GetName(),
+				Message:   msg,
+			})
+		}
+	}
+	return findings
+}
+
+// unboundGatewayFindings reports Gateways that no VirtualService binds to, so
+// the traffic they admit is not routed anywhere.
+func unboundGatewayFindings(virtualServices, gateways []unstructured.Unstructured) []AnalysisFinding {
+	bound := make(map[string]bool)
+	for i := range virtualServices {
+		for _, gw := range virtualServiceGateways(&virtualServices[i]) {
+			bound[gw] = true
+		}
+	}
+
+	var findings []AnalysisFinding
+	for _, gw := range gateways {
+		if bound[gw.GetNamespace()+"/"+gw.GetName()] {
+			continue
+		}
+		findings = append(findings, AnalysisFinding{
+			Code:      CodeGatewayWithoutRoutes,
+			Severity:  SeverityWarning,
+			Kind:      "Gateway",
+			Namespace: gw.GetNamespace(),
+			Name:      gw.GetName(),
+			Message:   "no VirtualService is bound to this gateway",
+		})
+	}
+	return findings
+}
+
+// virtualServiceGateways returns the gateways a VirtualService is bound to as
+// "namespace/name", or "mesh" when it lists none.
+func virtualServiceGateways(vs *unstructured.Unstructured) []string {
+	gateways := extractStringSlice(vs.Object, "spec", "gateways")
+	if len(gateways) == 0 {
+		return []string{meshGateway}
+	}
+	refs := make([]string, 0, len(gateways))
+	for _, gw := range gateways {
+		if gw != meshGateway && !strings.Contains(gw, "/") {
+			gw = vs.GetNamespace() + "/" + gw
+		}
+		refs = append(refs, gw)
+	}
+	return refs
+}
+
+// subsetDestination is a route destination that names a subset.
+type subsetDestination struct {
+	host   string
+	subset string
+}
+
+// virtualServiceSubsetDestinations extracts the destinations with a subset
+// from a VirtualService's http, tcp and tls routes.
+func virtualServiceSubsetDestinations(obj map[string]interface{}) []subsetDestination {
+	var dests []subsetDestination
+	for _, kind := range []string{"http", "tcp", "tls"} {
+		routes, _, _ := unstructured.NestedSlice(obj, "spec", kind)
+		for _, route := range routes {
+			routeMap, ok := route.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			entries, _, _ := unstructured.NestedSlice(routeMap, "route")
+			for _, entry := range entries {
+				entryMap, ok := entry.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				host, _, _ := unstructured.NestedString(entryMap, "destination", "host")
+				subset, _, _ := unstructured.NestedString(entryMap, "destination", "subset")
+				if host != "" && subset != "" {
+					dests = append(dests, subsetDestination{host: host, subset: subset})
+				}
+			}
+		}
+	}
+	return dests
+}
+
+// serviceFQDN expands a short service name (one without dots) to its FQDN in
+// namespace, as Istio does. Other hosts are returned unchanged.
+func serviceFQDN(host, namespace string) string {
+	if host == "" || host == "*" || strings.Contains(host, ".") {
+		return host
+	}
+	return host + "." + namespace + ".svc.cluster.local"
+}
+
+// hostMatches reports whether a DestinationRule host, which may be a
+// "*.suffix" wildcard, applies to host.
+func hostMatches(ruleHost, host string) bool {
+	if ruleHost == "*" {
+		return true
+	}
+	if strings.HasPrefix(ruleHost, "*.") {
+		return strings.HasSuffix(host, ruleHost[1:])
+	}
+	return ruleHost == host
+}
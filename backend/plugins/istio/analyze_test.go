@@ -0,0 +1,135 @@
+package istio
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func meshObject(kind, namespace, name string, spec map[string]interface{}) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     kind,
+		"metadata": map[string]interface{}{"namespace": namespace, "name": name},
+		"spec":     spec,
+	}}
+}
+
+func httpRoute(host, subset string) map[string]interface{} {
+	return map[string]interface{}{
+		"route": []interface{}{
+			map[string]interface{}{"destination": map[string]interface{}{"host": host, "subset": subset}},
+		},
+	}
+}
+
+func findingsByCode(findings []AnalysisFinding, code string) []AnalysisFinding {
+	var out []AnalysisFinding
+	for _, f := range findings {
+		if f.Code == code {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func TestAnalyze_ConflictingHosts(t *testing.T) {
+	res := meshResources{virtualServices: []unstructured.Unstructured{
+		meshObject("VirtualService", "shop", "reviews-a", map[string]interface{}{"hosts": []interface{}{"reviews"}}),
+		meshObject("VirtualService", "shop", "reviews-b", map[string]interface{}{"hosts": []interface{}{"reviews.shop.svc.cluster.local"}}),
+		meshObject("VirtualService", "shop", "ingress-a", map[string]interface{}{
+			"hosts": []interface{}{"shop.example.com"}, "gateways": []interface{}{"public"},
+		}),
+		meshObject("VirtualService", "shop", "ingress-b", map[string]interface{}{
+			"hosts": []interface{}{"shop.example.com"}, "gateways": []interface{}{"shop/public"},
+		}),
+		meshObject("VirtualService", "shop", "ratings", map[string]interface{}{"hosts": []interface{}{"ratings"}}),
+	}}
+
+	conflicts := findingsByCode(analyzeMeshResources(res), CodeConflictingHosts)
+	if len(conflicts) != 4 {
+		t.Fatalf("expected 4 conflict findings, got %d: %+v", len(conflicts), conflicts)
+	}
+	severities := map[string]string{}
+	for _, f := range conflicts {
+		severities[f.Name] = f.Severity
+	}
+	if severities["reviews-a"] != SeverityError || severities["reviews-b"] != SeverityError {
+		t.Errorf("mesh host conflicts should be errors: %v", severities)
+	}
+	if severities["ingress-a"] != SeverityWarning || severities["ingress-b"] != SeverityWarning {
+		t.Errorf("gateway host conflicts should be warnings: %v", severities)
+	}
+	if _, ok := severities["ratings"]; ok {
+		t.Error("a host routed by one VirtualService is not a conflict")
+	}
+}
+
+func TestAnalyze_MissingSubsets(t *testing.T) {
+	res := meshResources{
+		virtualServices: []unstructured.Unstructured{
+			meshObject("VirtualService", "shop", "reviews", map[string]interface{}{
+				"hosts": []interface{}{"reviews"},
+				"http":  []interface{}{httpRoute("reviews", "v1"), httpRoute("reviews", "v3")},
+			}),
+			meshObject("VirtualService", "shop", "ratings", map[string]interface{}{
+				"hosts": []interface{}{"ratings"},
+				"tcp":   []interface{}{httpRoute("ratings", "v1")},
+			}),
+		},
+		destinationRules: []unstructured.Unstructured{
+			meshObject("DestinationRule", "shop", "reviews", map[string]interface{}{
+				"host":    "reviews.shop.svc.cluster.local",
+				"subsets": []interface{}{map[string]interface{}{"name": "v1"}, map[string]interface{}{"name": "v2"}},
+			}),
+		},
+	}
+
+	missing := findingsByCode(analyzeMeshResources(res), CodeSubsetNotFound)
+	if len(missing) != 2 {
+		t.Fatalf("expected 2 missing subset findings, got %d: %+v", len(missing), missing)
+	}
+	for _, f := range missing {
+		if f.Severity != SeverityError {
+			t.Errorf("missing subsets should be errors: %+v", f)
+		}
+		if f.Name == "reviews" && f.Message != "subset v3 of host reviews.shop.svc.cluster.local is not defined by any DestinationRule" {
+			t.Errorf("unexpected message: %q", f.Message)
+		}
+	}
+}
+
+func TestAnalyze_GatewayWithoutVirtualService(t *testing.T) {
+	res := meshResources{
+		gateways: []unstructured.Unstructured{
+			meshObject("Gateway", "shop", "public", nil),
+			meshObject("Gateway", "shop", "unused", nil),
+		},
+		virtualServices: []unstructured.Unstructured{
+			meshObject("VirtualService", "shop", "web", map[string]interface{}{
+				"hosts": []interface{}{"shop.example.com"}, "gateways": []interface{}{"public"},
+			}),
+		},
+	}
+
+	findings := analyzeMeshResources(res)
+	if len(findings) != 1 || findings[0].Code != CodeGatewayWithoutRoutes || findings[0].Name != "unused" {
+		t.Fatalf("expected only the unused gateway to be reported, got %+v", findings)
+	}
+}
+
+func TestAnalyze_FindingsSortedBySeverity(t *testing.T) {
+	res := meshResources{
+		gateways: []unstructured.Unstructured{meshObject("Gateway", "shop", "unused", nil)},
+		virtualServices: []unstructured.Unstructured{
+			meshObject("VirtualService", "shop", "reviews", map[string]interface{}{
+				"hosts": []interface{}{"reviews"},
+				"http":  []interface{}{httpRoute("reviews", "v1")},
+			}),
+		},
+	}
+
+	findings := analyzeMeshResources(res)
+	if len(findings) != 2 || findings[0].Severity != SeverityError || findings[1].Severity != SeverityWarning {
+		t.Errorf("expected errors before warnings, got %+v", findings)
+	}
+}
@@ -64,6 +64,11 @@ func (p *IstioPlugin) ID() string { return "istio" }
 // Manifest satisfies plugin.Plugin.
 func (p *IstioPlugin) Manifest() plugin.Manifest { return p.manifest }
 
+// RequiredCapabilities satisfies plugin.Plugin: Istio's networking CRDs.
+func (p *IstioPlugin) RequiredCapabilities() []plugin.Capability {
+	return []plugin.Capability{{Group: gvrVirtualServices.Group, Version: gvrVirtualServices.Version, Resource: gvrVirtualServices.Resource, Provider: "Istio"}}
+}
+
 // RegisterRoutes wires all Istio CRUD endpoints onto the provided router.
 func (p *IstioPlugin) RegisterRoutes(r *mux.Router, cm *cluster.Manager) {
 	h := newHandlers(cm)
@@ -97,6 +102,10 @@ func (p *IstioPlugin) RegisterRoutes(r *mux.Router, cm *cluster.Manager) {
 	topo := newTopologyHandler(cm)
 	topo.RegisterRoutes(r)
 
+	// Config analysis endpoint (istioctl analyze-style findings)
+	analyze := newAnalyzeHandler(cm)
+	analyze.RegisterRoutes(r)
+
 	// Traffic topology endpoint (with Prometheus integration)
 	traffic := newTrafficHandler(cm, p.pool)
 	traffic.RegisterTrafficRoutes(r)
@@ -116,3 +116,10 @@ func buildTestManifest() plugin.Manifest {
 		},
 	}
 }
+
+func TestIstioPluginRequiredCapabilities(t *testing.T) {
+	caps := (&IstioPlugin{}).RequiredCapabilities()
+	if len(caps) != 1 || caps[0].Group != "networking.istio.io" {
+		t.Errorf("expected the networking.istio.io API to be required, got %v", caps)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -54,7 +55,7 @@ func (t *topologyHandler) GetTopology(w http.ResponseWriter, r *http.Request) {
 
 	client, err := t.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
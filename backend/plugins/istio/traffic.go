@@ -13,6 +13,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 	"github.com/darkden-lab/argus/backend/internal/plugin"
 	"github.com/darkden-lab/argus/backend/internal/prometheus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -131,23 +132,23 @@ func (h *trafficHandler) SaveConfig(w http.ResponseWriter, r *http.Request) {
 
 	var cfg istioConfig
 	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
-		writeJSON(w, http.StatusBadRequest, errMsg("invalid config"))
+		httputil.WriteError(w, http.StatusBadRequest, "invalid config")
 		return
 	}
 
 	if h.store == nil {
-		writeJSON(w, http.StatusServiceUnavailable, errMsg("database not available"))
+		httputil.WriteError(w, http.StatusServiceUnavailable, "database not available")
 		return
 	}
 
 	configJSON, err := json.Marshal(cfg)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errMsg("failed to marshal config"))
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to marshal config")
 		return
 	}
 
 	if err := h.store.SavePluginState(r.Context(), "istio", clusterID, "configured", configJSON); err != nil {
-		writeJSON(w, http.StatusInternalServerError, errMsg("failed to save config"))
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to save config")
 		return
 	}
 
@@ -159,13 +160,13 @@ func (h *trafficHandler) DiscoverPrometheus(w http.ResponseWriter, r *http.Reque
 	clusterID := mux.Vars(r)["cluster"]
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	instances, err := prometheus.DiscoverInstances(r.Context(), client.Clientset)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errMsg(err.Error()))
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	if instances == nil {
@@ -268,7 +269,7 @@ func (h *trafficHandler) GetTraffic(w http.ResponseWriter, r *http.Request) {
 	// Get cluster client
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
@@ -319,93 +320,111 @@ func (h *trafficHandler) getResourceTopology(clusterID, namespace string, r *htt
 	}
 }
 
+// meshResources are the Services and Istio networking resources of a
+// namespace, as read for the resource graph and config analysis.
+type meshResources struct {
+	services         []unstructured.Unstructured
+	virtualServices  []unstructured.Unstructured
+	destinationRules []unstructured.Unstructured
+	gateways         []unstructured.Unstructured
+	serviceEntries   []unstructured.Unstructured
+}
+
+// listMeshResources lists the mesh resources in namespace ("" for all). A
+// resource type that cannot be listed (e.g. its CRD is missing) is left empty.
+func listMeshResources(ctx context.Context, client *cluster.ClusterClient, namespace string) meshResources {
+	list := func(gvr schema.GroupVersionResource) []unstructured.Unstructured {
+		l, err := client.DynClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil
+		}
+		return l.Items
+	}
+	return meshResources{
+		services:         list(gvrServices),
+		virtualServices:  list(gvrVirtualServices),
+		destinationRules: list(gvrDestinationRules),
+		gateways:         list(gvrGateways),
+		serviceEntries:   list(gvrServiceEntries),
+	}
+}
+
 // buildResourceGraph builds the resource-based topology graph directly (reuses topology logic).
 func buildResourceGraph(ctx context.Context, client *cluster.ClusterClient, namespace string) ([]TopologyNode, []TopologyEdge) {
 	nodes := make([]TopologyNode, 0)
 	edges := make([]TopologyEdge, 0)
 	nodeSet := make(map[string]bool)
-
-	svcList, err := client.DynClient.Resource(gvrServices).Namespace(namespace).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		for _, svc := range svcList.Items {
-			id := svc.GetNamespace() + "/" + svc.GetName()
-			if !nodeSet[id] {
-				nodeSet[id] = true
-				nodes = append(nodes, TopologyNode{
-					ID: id, Name: svc.GetName(), Namespace: svc.GetNamespace(), Type: "service", Status: "active",
-				})
-			}
+	res := listMeshResources(ctx, client, namespace)
+
+	for _, svc := range res.services {
+		id := svc.GetNamespace() + "/" + svc.GetName()
+		if !nodeSet[id] {
+			nodeSet[id] = true
+			nodes = append(nodes, TopologyNode{
+				ID: id, Name: svc.GetName(), Namespace: svc.GetNamespace(), Type: "service", Status: "active",
+			})
 		}
 	}
 
-	vsList, err := client.DynClient.Resource(gvrVirtualServices).Namespace(namespace).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		for _, vs := range vsList.Items {
-			id := vs.GetNamespace() + "/vs-" + vs.GetName()
-			if !nodeSet[id] {
-				nodeSet[id] = true
-				nodes = append(nodes, TopologyNode{
-					ID: id, Name: vs.GetName(), Namespace: vs.GetNamespace(), Type: "virtualservice", Status: "active",
-				})
-			}
-			hosts := extractStringSlice(vs.Object, "spec", "hosts")
-			destinations := extractHTTPRouteDestinations(vs.Object)
-			for _, host := range hosts {
-				sourceID := vs.GetNamespace() + "/" + host
-				if !nodeSet[sourceID] {
-					nodeSet[sourceID] = true
-					nodes = append(nodes, TopologyNode{
-						ID: sourceID, Name: host, Namespace: vs.GetNamespace(), Type: "service", Status: "active",
-					})
-				}
-				for _, dest := range destinations {
-					targetID := vs.GetNamespace() + "/" + dest.host
-					if !nodeSet[targetID] {
-						nodeSet[targetID] = true
-						nodes = append(nodes, TopologyNode{
-							ID: targetID, Name: dest.host, Namespace: vs.GetNamespace(), Type: "service", Status: "active",
-						})
-					}
-					edges = append(edges, TopologyEdge{Source: sourceID, Target: targetID, Protocol: "http", Weight: dest.weight})
-				}
-			}
+	for _, vs := range res.virtualServices {
+		id := vs.GetNamespace() + "/vs-" + vs.GetName()
+		if !nodeSet[id] {
+			nodeSet[id] = true
+			nodes = append(nodes, TopologyNode{
+				ID: id, Name: vs.GetName(), Namespace: vs.GetNamespace(), Type: "virtualservice", Status: "active",
+			})
 		}
-	}
-
-	drList, err := client.DynClient.Resource(gvrDestinationRules).Namespace(namespace).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		for _, dr := range drList.Items {
-			id := dr.GetNamespace() + "/dr-" + dr.GetName()
-			if !nodeSet[id] {
-				nodeSet[id] = true
+		hosts := extractStringSlice(vs.Object, "spec", "hosts")
+		destinations := extractHTTPRouteDestinations(vs.Object)
+		for _, host := range hosts {
+			sourceID := vs.GetNamespace() + "/" + host
+			if !nodeSet[sourceID] {
+				nodeSet[sourceID] = true
 				nodes = append(nodes, TopologyNode{
-					ID: id, Name: dr.GetName(), Namespace: dr.GetNamespace(), Type: "destinationrule", Status: "active",
+					ID: sourceID, Name: host, Namespace: vs.GetNamespace(), Type: "service", Status: "active",
 				})
 			}
-			host, _, _ := unstructured.NestedString(dr.Object, "spec", "host")
-			if host != "" {
-				targetID := dr.GetNamespace() + "/" + host
+			for _, dest := range destinations {
+				targetID := vs.GetNamespace() + "/" + dest.host
 				if !nodeSet[targetID] {
 					nodeSet[targetID] = true
 					nodes = append(nodes, TopologyNode{
-						ID: targetID, Name: host, Namespace: dr.GetNamespace(), Type: "service", Status: "active",
+						ID: targetID, Name: dest.host, Namespace: vs.GetNamespace(), Type: "service", Status: "active",
 					})
 				}
-				edges = append(edges, TopologyEdge{Source: id, Target: targetID})
+				edges = append(edges, TopologyEdge{Source: sourceID, Target: targetID, Protocol: "http", Weight: dest.weight})
 			}
 		}
 	}
 
-	seList, err := client.DynClient.Resource(gvrServiceEntries).Namespace(namespace).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		for _, se := range seList.Items {
-			id := se.GetNamespace() + "/se-" + se.GetName()
-			if !nodeSet[id] {
-				nodeSet[id] = true
+	for _, dr := range res.destinationRules {
+		id := dr.GetNamespace() + "/dr-" + dr.GetName()
+		if !nodeSet[id] {
+			nodeSet[id] = true
+			nodes = append(nodes, TopologyNode{
+				ID: id, Name: dr.GetName(), Namespace: dr.GetNamespace(), Type: "destinationrule", Status: "active",
+			})
+		}
+		host, _, _ := unstructured.NestedString(dr.Object, "spec", "host")
+		if host != "" {
+			targetID := dr.GetNamespace() + "/" + host
+			if !nodeSet[targetID] {
+				nodeSet[targetID] = true
 				nodes = append(nodes, TopologyNode{
-					ID: id, Name: se.GetName(), Namespace: se.GetNamespace(), Type: "serviceentry", Status: "active",
+					ID: targetID, Name: host, Namespace: dr.GetNamespace(), Type: "service", Status: "active",
 				})
 			}
+			edges = append(edges, TopologyEdge{Source: id, Target: targetID})
+		}
+	}
+
+	for _, se := range res.serviceEntries {
+		id := se.GetNamespace() + "/se-" + se.GetName()
+		if !nodeSet[id] {
+			nodeSet[id] = true
+			nodes = append(nodes, TopologyNode{
+				ID: id, Name: se.GetName(), Namespace: se.GetNamespace(), Type: "serviceentry", Status: "active",
+			})
 		}
 	}
 
@@ -433,8 +452,8 @@ func buildResourceGraph(ctx context.Context, client *cluster.ClusterClient, name
 			}
 
 			matchLabels, _, _ := unstructured.NestedStringMap(w.Object, "spec", "selector", "matchLabels")
-			if len(matchLabels) > 0 && svcList != nil {
-				for _, svc := range svcList.Items {
+			if len(matchLabels) > 0 {
+				for _, svc := range res.services {
 					svcSelector, _, _ := unstructured.NestedStringMap(svc.Object, "spec", "selector")
 					if len(svcSelector) > 0 && labelsMatch(matchLabels, svcSelector) {
 						svcID := svc.GetNamespace() + "/" + svc.GetName()
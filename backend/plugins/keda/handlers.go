@@ -6,6 +6,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -111,13 +112,13 @@ func (h *handlers) list(w http.ResponseWriter, r *http.Request, gvr schema.Group
 	clusterID, namespace := clusterAndNamespace(r)
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	list, err := client.DynClient.Resource(gvr).Namespace(namespace).List(r.Context(), metav1.ListOptions{})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errMsg(err.Error()))
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, list)
@@ -129,13 +130,13 @@ func (h *handlers) get(w http.ResponseWriter, r *http.Request, gvr schema.GroupV
 
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	obj, err := client.DynClient.Resource(gvr).Namespace(namespace).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg(err.Error()))
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, obj)
@@ -146,19 +147,19 @@ func (h *handlers) create(w http.ResponseWriter, r *http.Request, gvr schema.Gro
 
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	var obj unstructured.Unstructured
 	if err := json.NewDecoder(r.Body).Decode(&obj.Object); err != nil {
-		writeJSON(w, http.StatusBadRequest, errMsg("invalid request body"))
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	created, err := client.DynClient.Resource(gvr).Namespace(namespace).Create(r.Context(), &obj, metav1.CreateOptions{})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errMsg(err.Error()))
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusCreated, created)
@@ -169,19 +170,19 @@ func (h *handlers) update(w http.ResponseWriter, r *http.Request, gvr schema.Gro
 
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	var obj unstructured.Unstructured
 	if err := json.NewDecoder(r.Body).Decode(&obj.Object); err != nil {
-		writeJSON(w, http.StatusBadRequest, errMsg("invalid request body"))
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	updated, err := client.DynClient.Resource(gvr).Namespace(namespace).Update(r.Context(), &obj, metav1.UpdateOptions{})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errMsg(err.Error()))
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, updated)
@@ -193,12 +194,12 @@ func (h *handlers) del(w http.ResponseWriter, r *http.Request, gvr schema.GroupV
 
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	if err := client.DynClient.Resource(gvr).Namespace(namespace).Delete(r.Context(), name, metav1.DeleteOptions{}); err != nil {
-		writeJSON(w, http.StatusInternalServerError, errMsg(err.Error()))
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -210,13 +211,13 @@ func (h *handlers) listClusterScoped(w http.ResponseWriter, r *http.Request, gvr
 	clusterID := r.URL.Query().Get("clusterID")
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	list, err := client.DynClient.Resource(gvr).List(r.Context(), metav1.ListOptions{})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errMsg(err.Error()))
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, list)
@@ -228,13 +229,13 @@ func (h *handlers) getClusterScoped(w http.ResponseWriter, r *http.Request, gvr
 
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	obj, err := client.DynClient.Resource(gvr).Get(r.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg(err.Error()))
+		httputil.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, obj)
@@ -244,19 +245,19 @@ func (h *handlers) createClusterScoped(w http.ResponseWriter, r *http.Request, g
 	clusterID := r.URL.Query().Get("clusterID")
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	var obj unstructured.Unstructured
 	if err := json.NewDecoder(r.Body).Decode(&obj.Object); err != nil {
-		writeJSON(w, http.StatusBadRequest, errMsg("invalid request body"))
+		httputil.WriteError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	created, err := client.DynClient.Resource(gvr).Create(r.Context(), &obj, metav1.CreateOptions{})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, errMsg(err.Error()))
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusCreated, created)
@@ -268,12 +269,12 @@ func (h *handlers) delClusterScoped(w http.ResponseWriter, r *http.Request, gvr
 
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, errMsg("cluster not found"))
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	if err := client.DynClient.Resource(gvr).Delete(r.Context(), name, metav1.DeleteOptions{}); err != nil {
-		writeJSON(w, http.StatusInternalServerError, errMsg(err.Error()))
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -284,7 +285,3 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data) //nolint:errcheck
 }
-
-func errMsg(msg string) map[string]string {
-	return map[string]string{"error": msg}
-}
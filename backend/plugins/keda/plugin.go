@@ -56,6 +56,11 @@ func (p *KedaPlugin) ID() string { return "keda" }
 // Manifest satisfies plugin.Plugin.
 func (p *KedaPlugin) Manifest() plugin.Manifest { return p.manifest }
 
+// RequiredCapabilities satisfies plugin.Plugin: the KEDA CRDs.
+func (p *KedaPlugin) RequiredCapabilities() []plugin.Capability {
+	return []plugin.Capability{{Group: gvrScaledObjects.Group, Version: gvrScaledObjects.Version, Resource: gvrScaledObjects.Resource, Provider: "KEDA"}}
+}
+
 // RegisterRoutes wires all KEDA CRUD endpoints onto the provided router.
 func (p *KedaPlugin) RegisterRoutes(r *mux.Router, cm *cluster.Manager) {
 	h := newHandlers(cm)
@@ -113,3 +113,10 @@ func buildTestManifest() plugin.Manifest {
 		},
 	}
 }
+
+func TestKedaPluginRequiredCapabilities(t *testing.T) {
+	caps := (&KedaPlugin{}).RequiredCapabilities()
+	if len(caps) != 1 || caps[0].Group != "keda.sh" {
+		t.Errorf("expected the keda.sh API to be required, got %v", caps)
+	}
+}
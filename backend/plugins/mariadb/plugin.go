@@ -62,6 +62,11 @@ func (p *MariaDBPlugin) ID() string { return "mariadb" }
 // Manifest satisfies plugin.Plugin.
 func (p *MariaDBPlugin) Manifest() plugin.Manifest { return p.manifest }
 
+// RequiredCapabilities satisfies plugin.Plugin: the MariaDB operator CRDs.
+func (p *MariaDBPlugin) RequiredCapabilities() []plugin.Capability {
+	return []plugin.Capability{{Group: gvrMariaDBs.Group, Version: gvrMariaDBs.Version, Resource: gvrMariaDBs.Resource, Provider: "MariaDB operator"}}
+}
+
 // RegisterRoutes wires all MariaDB CRUD endpoints onto the provided router.
 func (p *MariaDBPlugin) RegisterRoutes(r *mux.Router, cm *cluster.Manager) {
 	h := newHandlers(cm)
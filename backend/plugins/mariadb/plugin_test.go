@@ -119,3 +119,10 @@ func buildTestManifest() plugin.Manifest {
 		},
 	}
 }
+
+func TestMariaDBPluginRequiredCapabilities(t *testing.T) {
+	caps := (&MariaDBPlugin{}).RequiredCapabilities()
+	if len(caps) != 1 || caps[0].Group != "k8s.mariadb.com" {
+		t.Errorf("expected the k8s.mariadb.com API to be required, got %v", caps)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/darkden-lab/argus/backend/internal/cluster"
+	"github.com/darkden-lab/argus/backend/internal/httputil"
 	"github.com/darkden-lab/argus/backend/internal/plugin"
 	"github.com/darkden-lab/argus/backend/internal/prometheus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -48,7 +49,7 @@ func (h *Handlers) ListResources(resource string) http.HandlerFunc {
 
 		client, err := h.cm.GetClient(clusterID)
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cluster not found"})
+			httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 			return
 		}
 
@@ -59,7 +60,7 @@ func (h *Handlers) ListResources(resource string) http.HandlerFunc {
 			list, err = client.DynClient.Resource(h.gvr(resource)).Namespace("").List(context.Background(), metav1.ListOptions{})
 		}
 		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
@@ -76,13 +77,13 @@ func (h *Handlers) GetResource(resource string) http.HandlerFunc {
 
 		client, err := h.cm.GetClient(clusterID)
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cluster not found"})
+			httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 			return
 		}
 
 		obj, err := client.DynClient.Resource(h.gvr(resource)).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			httputil.WriteError(w, http.StatusNotFound, err.Error())
 			return
 		}
 
@@ -96,19 +97,19 @@ func (h *Handlers) CreateResource(resource string) http.HandlerFunc {
 
 		client, err := h.cm.GetClient(clusterID)
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cluster not found"})
+			httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 			return
 		}
 
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+			httputil.WriteError(w, http.StatusBadRequest, "invalid body")
 			return
 		}
 
 		obj := &unstructured.Unstructured{}
 		if err := json.Unmarshal(body, &obj.Object); err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+			httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
 			return
 		}
 
@@ -119,7 +120,7 @@ func (h *Handlers) CreateResource(resource string) http.HandlerFunc {
 
 		created, err := client.DynClient.Resource(h.gvr(resource)).Namespace(namespace).Create(context.Background(), obj, metav1.CreateOptions{})
 		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
@@ -136,13 +137,13 @@ func (h *Handlers) DeleteResource(resource string) http.HandlerFunc {
 
 		client, err := h.cm.GetClient(clusterID)
 		if err != nil {
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cluster not found"})
+			httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 			return
 		}
 
 		err = client.DynClient.Resource(h.gvr(resource)).Namespace(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
 		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
@@ -155,24 +156,24 @@ func (h *Handlers) CreateServiceMonitorWizard(w http.ResponseWriter, r *http.Req
 
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": "cluster not found"})
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid body")
 		return
 	}
 
 	var cfg ServiceMonitorConfig
 	if err := json.Unmarshal(body, &cfg); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
 		return
 	}
 
 	if cfg.Name == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name is required"})
+		httputil.WriteError(w, http.StatusBadRequest, "name is required")
 		return
 	}
 
@@ -182,7 +183,7 @@ func (h *Handlers) CreateServiceMonitorWizard(w http.ResponseWriter, r *http.Req
 	namespace := obj.GetNamespace()
 	created, err := client.DynClient.Resource(h.gvr("servicemonitors")).Namespace(namespace).Create(context.Background(), obj, metav1.CreateOptions{})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
@@ -192,18 +193,18 @@ func (h *Handlers) CreateServiceMonitorWizard(w http.ResponseWriter, r *http.Req
 func (h *Handlers) PreviewServiceMonitorWizard(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid body")
 		return
 	}
 
 	var cfg ServiceMonitorConfig
 	if err := json.Unmarshal(body, &cfg); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid JSON")
 		return
 	}
 
 	if cfg.Name == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name is required"})
+		httputil.WriteError(w, http.StatusBadRequest, "name is required")
 		return
 	}
 
@@ -216,13 +217,13 @@ func (h *Handlers) DiscoverPrometheus(w http.ResponseWriter, r *http.Request) {
 	clusterID := mux.Vars(r)["cluster"]
 	client, err := h.cm.GetClient(clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusNotFound, map[string]string{"error": "cluster not found"})
+		httputil.WriteError(w, http.StatusNotFound, "cluster not found")
 		return
 	}
 
 	instances, err := prometheus.DiscoverInstances(r.Context(), client.Clientset)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	if instances == nil {
@@ -244,23 +245,23 @@ func (h *Handlers) SaveConfig(w http.ResponseWriter, r *http.Request) {
 
 	var cfg prometheus.PrometheusConfig
 	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid config"})
+		httputil.WriteError(w, http.StatusBadRequest, "invalid config")
 		return
 	}
 
 	if h.store == nil {
-		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "database not available"})
+		httputil.WriteError(w, http.StatusServiceUnavailable, "database not available")
 		return
 	}
 
 	configJSON, err := json.Marshal(cfg)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to marshal config"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to marshal config")
 		return
 	}
 
 	if err := h.store.SavePluginState(r.Context(), "prometheus", clusterID, "configured", configJSON); err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save config"})
+		httputil.WriteError(w, http.StatusInternalServerError, "failed to save config")
 		return
 	}
 
@@ -272,19 +273,19 @@ func (h *Handlers) ProxyQuery(w http.ResponseWriter, r *http.Request) {
 	clusterID := mux.Vars(r)["cluster"]
 	query := r.URL.Query().Get("query")
 	if query == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "query parameter required"})
+		httputil.WriteError(w, http.StatusBadRequest, "query parameter required")
 		return
 	}
 
 	client, cfg, err := h.getClientAndConfig(r.Context(), clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	result, err := prometheus.Query(r.Context(), client.RestConfig, cfg, query)
 	if err != nil {
-		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusBadGateway, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, result)
@@ -296,13 +297,13 @@ func (h *Handlers) GetAlerts(w http.ResponseWriter, r *http.Request) {
 
 	client, cfg, err := h.getClientAndConfig(r.Context(), clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	result, err := prometheus.GetAlerts(r.Context(), client.RestConfig, cfg)
 	if err != nil {
-		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusBadGateway, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, result)
@@ -314,13 +315,13 @@ func (h *Handlers) GetTargets(w http.ResponseWriter, r *http.Request) {
 
 	client, cfg, err := h.getClientAndConfig(r.Context(), clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	result, err := prometheus.GetTargets(r.Context(), client.RestConfig, cfg)
 	if err != nil {
-		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusBadGateway, err.Error())
 		return
 	}
 	writeJSON(w, http.StatusOK, result)
@@ -341,7 +342,7 @@ func (h *Handlers) GetMetricsOverview(w http.ResponseWriter, r *http.Request) {
 
 	client, cfg, err := h.getClientAndConfig(r.Context(), clusterID)
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		httputil.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -57,6 +57,13 @@ func (p *PrometheusPlugin) Manifest() plugin.Manifest {
 	return manifest
 }
 
+// RequiredCapabilities returns nil: the query, alert, and target endpoints
+// talk to any Prometheus server, so the plugin is useful without the
+// Prometheus Operator CRDs.
+func (p *PrometheusPlugin) RequiredCapabilities() []plugin.Capability {
+	return nil
+}
+
 func (p *PrometheusPlugin) RegisterRoutes(router *mux.Router, cm *cluster.Manager) {
 	h := NewHandlers(cm, p.pool)
 	sub := router.PathPrefix("/api/plugins/prometheus").Subrouter()
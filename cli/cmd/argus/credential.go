@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// execCredentialAPIVersion is the exec credential API the dashboard answers
+// with, and the one kubeconfig exec stanzas must ask for.
+const execCredentialAPIVersion = "client.authentication.k8s.io/v1"
+
+func newCredentialCmd() *cobra.Command {
+	var clusterName string
+
+	cmd := &cobra.Command{
+		Use:   "credential",
+		Short: "Print a short-lived kubectl exec credential for a cluster",
+		Long: `Exchanges your dashboard token for a short-lived token that only works on
+the Argus proxy of one cluster, and prints it as an ExecCredential. kubectl
+runs this command from a kubeconfig exec stanza and calls it again when the
+token expires.
+
+The dashboard token is taken from ARGUS_API_KEY if set, which suits CI, and
+from 'argus login' otherwise.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCredential(os.Stdout, clusterName)
+		},
+	}
+
+	cmd.Flags().StringVar(&clusterName, "cluster", "", "Cluster ID or name (required)")
+	_ = cmd.MarkFlagRequired("cluster")
+
+	return cmd
+}
+
+func runCredential(out io.Writer, clusterName string) error {
+	srv := getServer()
+	if srv == "" {
+		return fmt.Errorf("no server configured (use --server, ARGUS_SERVER or 'argus login')")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, srv+"/api/proxy/exec-credential?cluster="+url.QueryEscape(clusterName), nil)
+	if err != nil {
+		return err
+	}
+	if apiKey := os.Getenv("ARGUS_API_KEY"); apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	} else {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request credential: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("cluster %q not found", clusterName)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to request credential: %w", apiError(resp))
+	}
+
+	// kubectl reads the ExecCredential from stdout as is.
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
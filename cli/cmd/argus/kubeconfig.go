@@ -2,9 +2,15 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/tools/clientcmd"
@@ -36,15 +42,20 @@ func newKubeconfigGenerateCmd() *cobra.Command {
 		output             string
 		caCert             string
 		insecureSkipVerify bool
+		useExec            bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "generate",
 		Short: "Generate kubeconfig entries for Argus-proxied clusters",
-		Long: `Fetches available clusters from the dashboard and generates kubeconfig
-entries that use the Argus proxy as the API server. Merges with existing kubeconfig.`,
+		Long: `Fetches kubeconfig entries for the clusters you can access from the
+dashboard. They use the Argus proxy as the API server and are merged with the
+existing kubeconfig.
+
+With --exec, each context authenticates through 'argus credential' instead of
+embedding your token, so kubectl gets short-lived tokens scoped to the cluster.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runKubeconfigGenerate(clusterName, output, caCert, insecureSkipVerify)
+			return runKubeconfigGenerate(clusterName, output, caCert, insecureSkipVerify, useExec)
 		},
 	}
 
@@ -52,6 +63,7 @@ entries that use the Argus proxy as the API server. Merges with existing kubecon
 	cmd.Flags().StringVarP(&output, "output", "o", "", "Output path (default: ~/.kube/config)")
 	cmd.Flags().StringVar(&caCert, "ca-cert", "", "Path to CA certificate for the Argus proxy")
 	cmd.Flags().BoolVar(&insecureSkipVerify, "insecure-skip-tls-verify", false, "Skip TLS verification for proxy connection (not recommended)")
+	cmd.Flags().BoolVar(&useExec, "exec", false, "Authenticate with short-lived credentials from 'argus credential' instead of your token")
 
 	return cmd
 }
@@ -86,36 +98,22 @@ func newKubeconfigRemoveCmd() *cobra.Command {
 	return cmd
 }
 
-func runKubeconfigGenerate(clusterFilter, output, caCert string, insecureSkipVerify bool) error {
+func runKubeconfigGenerate(clusterFilter, output, caCert string, insecureSkipVerify, useExec bool) error {
 	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	clusters, err := fetchClusters(cfg.Server, cfg.Token)
+	generated, err := fetchKubeconfig(cfg.Server, cfg.Token, clusterFilter)
 	if err != nil {
-		return fmt.Errorf("failed to fetch clusters: %w", err)
+		return err
 	}
 
-	if len(clusters) == 0 {
+	if len(generated.Contexts) == 0 {
 		fmt.Println("No clusters available.")
 		return nil
 	}
 
-	// Filter to specific cluster if requested
-	if clusterFilter != "" {
-		var filtered []clusterInfo
-		for _, c := range clusters {
-			if c.Name == clusterFilter || c.ID == clusterFilter {
-				filtered = append(filtered, c)
-			}
-		}
-		if len(filtered) == 0 {
-			return fmt.Errorf("cluster %q not found", clusterFilter)
-		}
-		clusters = filtered
-	}
-
 	kubeconfigPath := resolveKubeconfigPath(output)
 
 	// Load existing kubeconfig or create empty one
@@ -124,34 +122,34 @@ func runKubeconfigGenerate(clusterFilter, output, caCert string, insecureSkipVer
 		return fmt.Errorf("failed to load kubeconfig: %w", err)
 	}
 
-	userName := argusContextPrefix + sanitizeName(cfg.Email)
-
-	// Ensure the argus user entry exists
-	kubeconfig.AuthInfos[userName] = &clientcmdapi.AuthInfo{
-		Token: cfg.Token,
+	if useExec {
+		useExecCredentials(generated, cfg.Server)
 	}
 
-	for _, c := range clusters {
-		contextName := argusContextPrefix + sanitizeName(c.Name)
-		clusterEntry := contextName
-		serverURL := fmt.Sprintf("%s/api/proxy/k8s/%s", cfg.Server, c.ID)
+	// Merge the dashboard's entries, replacing earlier ones of the same name
+	for name, authInfo := range generated.AuthInfos {
+		kubeconfig.AuthInfos[name] = authInfo
+	}
 
-		clusterConfig := &clientcmdapi.Cluster{
-			Server:                serverURL,
-			InsecureSkipTLSVerify: insecureSkipVerify,
+	var contextNames []string
+	for name, ctx := range generated.Contexts {
+		clusterConfig := generated.Clusters[ctx.Cluster]
+		if clusterConfig == nil {
+			continue
 		}
+		clusterConfig.InsecureSkipTLSVerify = insecureSkipVerify
 		if caCert != "" {
 			clusterConfig.CertificateAuthority = caCert
 			clusterConfig.InsecureSkipTLSVerify = false
 		}
-		kubeconfig.Clusters[clusterEntry] = clusterConfig
-
-		kubeconfig.Contexts[contextName] = &clientcmdapi.Context{
-			Cluster:  clusterEntry,
-			AuthInfo: userName,
-		}
+		kubeconfig.Clusters[ctx.Cluster] = clusterConfig
+		kubeconfig.Contexts[name] = ctx
+		contextNames = append(contextNames, name)
+	}
+	sort.Strings(contextNames)
 
-		fmt.Printf("  Added context %q → %s\n", contextName, serverURL)
+	for _, name := range contextNames {
+		fmt.Printf("  Added context %q → %s\n", name, kubeconfig.Clusters[kubeconfig.Contexts[name].Cluster].Server)
 	}
 
 	// Ensure parent directory exists
@@ -169,10 +167,10 @@ func runKubeconfigGenerate(clusterFilter, output, caCert string, insecureSkipVer
 	}
 
 	fmt.Printf("\nKubeconfig written to %s (permissions: 0600)\n", kubeconfigPath)
-	fmt.Printf("Generated %d context(s) for user %q.\n", len(clusters), userName)
+	fmt.Printf("Generated %d context(s) for user %q.\n", len(contextNames), argusContextPrefix+sanitizeName(cfg.Email))
 	fmt.Printf("\nUsage:\n")
-	if len(clusters) == 1 {
-		fmt.Printf("  kubectl --context %s get pods\n", argusContextPrefix+sanitizeName(clusters[0].Name))
+	if len(contextNames) == 1 {
+		fmt.Printf("  kubectl --context %s get pods\n", contextNames[0])
 	} else {
 		fmt.Printf("  kubectl --context argus-<cluster-name> get pods\n")
 	}
@@ -319,6 +317,77 @@ func runKubeconfigRemove(contextName string, all bool) error {
 	return nil
 }
 
+// fetchKubeconfig asks the dashboard for kubeconfig entries pointing at its
+// proxy, one per cluster the user can access, or only clusterFilter's.
+func fetchKubeconfig(serverURL, token, clusterFilter string) (*clientcmdapi.Config, error) {
+	endpoint := serverURL + "/api/proxy/kubeconfig"
+	if clusterFilter != "" {
+		endpoint += "?cluster=" + url.QueryEscape(clusterFilter)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch kubeconfig: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && clusterFilter != "" {
+		return nil, fmt.Errorf("cluster %q not found", clusterFilter)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch kubeconfig: %w", apiError(resp))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	config, err := clientcmd.Load(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig from server: %w", err)
+	}
+	return config, nil
+}
+
+// useExecCredentials replaces the token user of config with one exec user per
+// context that runs 'argus credential' for the context's cluster. The
+// cluster ID is the last segment of the proxy URL.
+func useExecCredentials(config *clientcmdapi.Config, serverURL string) {
+	command, err := os.Executable()
+	if err != nil {
+		command = "argus"
+	}
+	tokenUsers := make(map[string]bool)
+	for name, ctx := range config.Contexts {
+		cluster := config.Clusters[ctx.Cluster]
+		if cluster == nil {
+			continue
+		}
+		clusterID := path.Base(strings.TrimSuffix(cluster.Server, "/"))
+		tokenUsers[ctx.AuthInfo] = true
+		config.AuthInfos[name] = &clientcmdapi.AuthInfo{
+			Exec: &clientcmdapi.ExecConfig{
+				APIVersion:      execCredentialAPIVersion,
+				Command:         command,
+				Args:            []string{"credential", "--server", serverURL, "--cluster", clusterID},
+				InteractiveMode: clientcmdapi.NeverExecInteractiveMode,
+			},
+		}
+		ctx.AuthInfo = name
+	}
+	for name := range tokenUsers {
+		if config.Contexts[name] == nil {
+			delete(config.AuthInfos, name)
+		}
+	}
+}
+
 // resolveKubeconfigPath returns the kubeconfig path to use.
 func resolveKubeconfigPath(override string) string {
 	if override != "" {
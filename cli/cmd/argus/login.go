@@ -139,8 +139,7 @@ func fetchClusters(serverURL, token string) ([]clusterInfo, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+		return nil, apiError(resp)
 	}
 
 	var clusters []clusterInfo
@@ -150,6 +149,20 @@ func fetchClusters(serverURL, token string) ([]clusterInfo, error) {
 	return clusters, nil
 }
 
+// apiError builds an error from a non-2xx response, using the server's error
+// envelope ({"code", "message"}) when the body has one.
+func apiError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	var envelope struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Code != "" {
+		return fmt.Errorf("API error %d (%s): %s", resp.StatusCode, envelope.Code, envelope.Message)
+	}
+	return fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+}
+
 // Config management
 type cliConfig struct {
 	Server string `json:"server"`
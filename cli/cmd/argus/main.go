@@ -28,6 +28,7 @@ After logging in, kubectl commands are routed through the dashboard with full RB
 		newLogoutCmd(),
 		newContextsCmd(),
 		newKubeconfigCmd(),
+		newCredentialCmd(),
 		newVersionCmd(),
 	)
 
@@ -1,7 +1,8 @@
 "use client";
 
-import { useEffect, useState, useMemo, useCallback } from "react";
+import { Fragment, useEffect, useState, useMemo, useCallback } from "react";
 import { api } from "@/lib/api";
+import { formatAge } from "@/lib/abstractions";
 import { useClusterStore } from "@/stores/cluster";
 import { Input } from "@/components/ui/input";
 import { Badge } from "@/components/ui/badge";
@@ -45,11 +46,27 @@ interface ResourceInstance {
     creationTimestamp?: string;
     uid?: string;
   };
+  argus?: { cells?: unknown[] };
   [key: string]: unknown;
 }
 
+// PrinterColumn is a column from a CRD's additionalPrinterColumns, as added
+// by the backend to custom resource lists.
+interface PrinterColumn {
+  name: string;
+  type: string;
+  priority?: number;
+}
+
 interface ResourceListResponse {
   items?: ResourceInstance[];
+  argus?: { columns?: PrinterColumn[] };
+}
+
+function formatCell(value: unknown, type: string): string {
+  if (value === null || value === undefined || value === "") return "-";
+  if (type === "date") return formatAge(String(value));
+  return String(value);
 }
 
 export default function APIResourcesPage() {
@@ -62,6 +79,7 @@ export default function APIResourcesPage() {
   // Instance list state
   const [selectedResource, setSelectedResource] = useState<APIResource | null>(null);
   const [instances, setInstances] = useState<ResourceInstance[]>([]);
+  const [columns, setColumns] = useState<PrinterColumn[]>([]);
   const [instancesLoading, setInstancesLoading] = useState(false);
 
   // Detail view state
@@ -102,13 +120,17 @@ export default function APIResourcesPage() {
       setSelectedResource(res);
       setInstancesLoading(true);
       setInstances([]);
+      setColumns([]);
 
       const group = res.group || "_";
       api
         .get<ResourceListResponse>(
           `/api/clusters/${selectedClusterId}/resources/${group}/${res.version}/${res.resource}`
         )
-        .then((data) => setInstances(data.items ?? []))
+        .then((data) => {
+          setInstances(data.items ?? []);
+          setColumns(data.argus?.columns ?? []);
+        })
         .catch(() => setInstances([]))
         .finally(() => setInstancesLoading(false));
     },
@@ -137,6 +159,12 @@ export default function APIResourcesPage() {
     [selectedClusterId]
   );
 
+  // Custom resources are shown with the columns kubectl get would print,
+  // leaving out the ones only shown with -o wide.
+  const visibleColumns = columns
+    .map((col, index) => ({ ...col, index }))
+    .filter((col) => !col.priority);
+
   if (!selectedClusterId) {
     return (
       <div className="flex flex-col items-center justify-center py-16 text-center">
@@ -280,11 +308,24 @@ export default function APIResourcesPage() {
               <div className="rounded-md border max-h-[calc(100vh-320px)] overflow-auto">
                 <Table>
                   <TableHeader>
-                    <TableRow>
-                      <TableHead>Name</TableHead>
-                      {selectedResource.namespaced && <TableHead>Namespace</TableHead>}
-                      <TableHead>Created</TableHead>
-                    </TableRow>
+                    {visibleColumns.length > 0 ? (
+                      <TableRow>
+                        {visibleColumns.map((col) => (
+                          <Fragment key={col.index}>
+                            <TableHead>{col.name}</TableHead>
+                            {col.index === 0 && selectedResource.namespaced && (
+                              <TableHead>Namespace</TableHead>
+                            )}
+                          </Fragment>
+                        ))}
+                      </TableRow>
+                    ) : (
+                      <TableRow>
+                        <TableHead>Name</TableHead>
+                        {selectedResource.namespaced && <TableHead>Namespace</TableHead>}
+                        <TableHead>Created</TableHead>
+                      </TableRow>
+                    )}
                   </TableHeader>
                   <TableBody>
                     {instances.map((inst) => (
@@ -293,17 +334,36 @@ export default function APIResourcesPage() {
                         className="cursor-pointer hover:bg-muted/50"
                         onClick={() => handleInstanceClick(inst, selectedResource)}
                       >
-                        <TableCell className="font-medium">{inst.metadata.name}</TableCell>
-                        {selectedResource.namespaced && (
-                          <TableCell className="text-muted-foreground">
-                            {inst.metadata.namespace || "-"}
-                          </TableCell>
+                        {visibleColumns.length > 0 ? (
+                          visibleColumns.map((col) => (
+                            <Fragment key={col.index}>
+                              <TableCell
+                                className={col.index === 0 ? "font-medium" : "text-muted-foreground"}
+                              >
+                                {formatCell(inst.argus?.cells?.[col.index], col.type)}
+                              </TableCell>
+                              {col.index === 0 && selectedResource.namespaced && (
+                                <TableCell className="text-muted-foreground">
+                                  {inst.metadata.namespace || "-"}
+                                </TableCell>
+                              )}
+                            </Fragment>
+                          ))
+                        ) : (
+                          <>
+                            <TableCell className="font-medium">{inst.metadata.name}</TableCell>
+                            {selectedResource.namespaced && (
+                              <TableCell className="text-muted-foreground">
+                                {inst.metadata.namespace || "-"}
+                              </TableCell>
+                            )}
+                            <TableCell className="text-muted-foreground text-xs">
+                              {inst.metadata.creationTimestamp
+                                ? new Date(inst.metadata.creationTimestamp).toLocaleString()
+                                : "-"}
+                            </TableCell>
+                          </>
                         )}
-                        <TableCell className="text-muted-foreground text-xs">
-                          {inst.metadata.creationTimestamp
-                            ? new Date(inst.metadata.creationTimestamp).toLocaleString()
-                            : "-"}
-                        </TableCell>
                       </TableRow>
                     ))}
                   </TableBody>
@@ -150,6 +150,10 @@ export default function NetworkingPage() {
   // Simulator state
   const [simSourceNs, setSimSourceNs] = useState("");
   const [simSourcePod, setSimSourcePod] = useState("");
+  // "labels" simulates a source pod that does not exist yet
+  const [simSourceMode, setSimSourceMode] = useState<"pod" | "labels">("pod");
+  const [simSourceLabels, setSimSourceLabels] = useState("");
+  const [simSourceNsLabels, setSimSourceNsLabels] = useState("");
   const [simDestNs, setSimDestNs] = useState("");
   const [simDestPod, setSimDestPod] = useState("");
   const [simPort, setSimPort] = useState("");
@@ -264,18 +268,25 @@ export default function NetworkingPage() {
     setPolicyDetailOpen(true);
   };
 
+  const simSourceSet = simSourceMode === "pod" ? !!simSourcePod : !!simSourceLabels.trim();
+
   const handleSimulate = async () => {
-    if (!selectedCluster || !simSourceNs || !simSourcePod || !simDestNs || !simDestPod) return;
+    if (!selectedCluster || !simSourceNs || !simSourceSet || !simDestNs || !simDestPod) return;
     setSimLoading(true);
     setSimError(null);
     setSimResult(null);
     try {
       const params = new URLSearchParams({
         sourceNamespace: simSourceNs,
-        sourcePod: simSourcePod,
         destNamespace: simDestNs,
         destPod: simDestPod,
       });
+      if (simSourceMode === "pod") {
+        params.set("sourcePod", simSourcePod);
+      } else {
+        params.set("sourceLabels", simSourceLabels.trim());
+        if (simSourceNsLabels.trim()) params.set("sourceNamespaceLabels", simSourceNsLabels.trim());
+      }
       if (simPort) params.set("port", simPort);
       const res = await api.get<SimulationResult>(
         `/api/clusters/${selectedCluster}/network-policies/simulate?${params.toString()}`
@@ -644,6 +655,7 @@ export default function NetworkingPage() {
                 </CardTitle>
                 <p className="text-xs text-muted-foreground">
                   Test whether traffic between two pods is allowed or blocked by NetworkPolicies.
+                  Use labels as the source to test a pod before it is deployed.
                 </p>
               </CardHeader>
               <CardContent>
@@ -664,18 +676,49 @@ export default function NetworkingPage() {
                     />
                   </div>
                   <div className="space-y-2">
-                    <Label className="text-xs font-medium">Source Pod</Label>
-                    <Combobox
-                      options={simSourcePodOptions}
-                      value={simSourcePod}
-                      onValueChange={setSimSourcePod}
-                      placeholder="e.g. frontend-abc123"
-                      searchPlaceholder="Search pods..."
-                      loading={simLoadingSourcePods}
-                      disabled={!simSourceNs}
-                      allowCustomValue
-                    />
+                    <div className="flex items-center justify-between">
+                      <Label className="text-xs font-medium">
+                        {simSourceMode === "pod" ? "Source Pod" : "Source Pod Labels"}
+                      </Label>
+                      <Button
+                        variant="link"
+                        className="h-auto p-0 text-xs"
+                        onClick={() => setSimSourceMode(simSourceMode === "pod" ? "labels" : "pod")}
+                      >
+                        {simSourceMode === "pod" ? "Use labels" : "Use existing pod"}
+                      </Button>
+                    </div>
+                    {simSourceMode === "pod" ? (
+                      <Combobox
+                        options={simSourcePodOptions}
+                        value={simSourcePod}
+                        onValueChange={setSimSourcePod}
+                        placeholder="e.g. frontend-abc123"
+                        searchPlaceholder="Search pods..."
+                        loading={simLoadingSourcePods}
+                        disabled={!simSourceNs}
+                        allowCustomValue
+                      />
+                    ) : (
+                      <Input
+                        placeholder="e.g. app=web,tier=frontend"
+                        value={simSourceLabels}
+                        onChange={(e) => setSimSourceLabels(e.target.value)}
+                        className="h-8 text-xs"
+                      />
+                    )}
                   </div>
+                  {simSourceMode === "labels" && (
+                    <div className="space-y-2">
+                      <Label className="text-xs font-medium">Source Namespace Labels (optional)</Label>
+                      <Input
+                        placeholder="Defaults to the live namespace's labels"
+                        value={simSourceNsLabels}
+                        onChange={(e) => setSimSourceNsLabels(e.target.value)}
+                        className="h-8 text-xs"
+                      />
+                    </div>
+                  )}
                   <div className="space-y-2">
                     <Label className="text-xs font-medium">Destination Namespace</Label>
                     <Combobox
@@ -716,7 +759,7 @@ export default function NetworkingPage() {
                   <div className="flex items-end">
                     <Button
                       onClick={handleSimulate}
-                      disabled={simLoading || !simSourceNs || !simSourcePod || !simDestNs || !simDestPod || !selectedCluster}
+                      disabled={simLoading || !simSourceNs || !simSourceSet || !simDestNs || !simDestPod || !selectedCluster}
                       className="h-8 text-xs w-full"
                     >
                       {simLoading ? (
@@ -121,6 +121,20 @@ export default function NotificationsPage() {
     (state) => state.fetchUnreadCount
   );
 
+  // Digest messages link here with a filter for the group they summarize.
+  useEffect(() => {
+    const params = new URLSearchParams(window.location.search);
+    const category = params.get("category");
+    const severity = params.get("severity");
+    const search = params.get("search");
+    if (category) setCategoryFilter(category);
+    if (severity) setSeverityFilter(severity);
+    if (search) {
+      setSearchInput(search);
+      setSearchQuery(search);
+    }
+  }, []);
+
   function handleSearchChange(value: string) {
     setSearchInput(value);
     if (debounceRef.current) clearTimeout(debounceRef.current);
@@ -22,7 +22,7 @@ import {
   SelectValue,
 } from '@/components/ui/select';
 import { FileDropzone } from '@/components/ui/file-dropzone';
-import { api } from '@/lib/api';
+import { api, ApiError } from '@/lib/api';
 
 interface Cluster {
   id: string;
@@ -120,11 +120,12 @@ export function ConnectStep({
         { success: true, message: `Connected to cluster "${created.name}" successfully.`, cluster: created },
         'kubeconfig'
       );
-    } catch {
-      onNext(
-        { success: false, message: 'Failed to connect. Check your kubeconfig and try again.' },
-        'kubeconfig'
-      );
+    } catch (err) {
+      const message =
+        err instanceof ApiError && err.code === 'validation_failed'
+          ? err.message
+          : 'Failed to connect. Check your kubeconfig and try again.';
+      onNext({ success: false, message }, 'kubeconfig');
     }
   }
 
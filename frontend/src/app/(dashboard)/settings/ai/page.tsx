@@ -48,6 +48,13 @@ interface RagStatus {
   is_indexing: boolean;
 }
 
+interface ModelList {
+  supported: boolean;
+  models: { id: string; description?: string }[];
+  model_available?: boolean;
+  message?: string;
+}
+
 interface HeaderEntry {
   key: string;
   value: string;
@@ -120,6 +127,9 @@ export default function AiSettingsPage() {
     null
   );
   const [isReindexing, setIsReindexing] = useState(false);
+  const [providerModels, setProviderModels] = useState<ComboboxOption[] | null>(null);
+  const [modelMessage, setModelMessage] = useState<string | null>(null);
+  const [isLoadingModels, setIsLoadingModels] = useState(false);
 
   useEffect(() => {
     api
@@ -193,6 +203,30 @@ export default function AiSettingsPage() {
     }
   };
 
+  const handleLoadModels = async () => {
+    setIsLoadingModels(true);
+    setModelMessage(null);
+    try {
+      const result = await api.post<ModelList>("/api/ai/config/models", {
+        provider: config.provider,
+        model: config.model,
+        api_key: config.api_key,
+        base_url: config.base_url,
+        custom_headers: entriesToHeaders(headerEntries),
+      });
+      setProviderModels(
+        result.supported
+          ? result.models.map((m) => ({ value: m.id, label: m.id, description: m.description }))
+          : null
+      );
+      setModelMessage(result.message || null);
+    } catch {
+      // api.post already shows toast on error
+    } finally {
+      setIsLoadingModels(false);
+    }
+  };
+
   const handleReindex = async () => {
     setIsReindexing(true);
     try {
@@ -211,7 +245,7 @@ export default function AiSettingsPage() {
     }
   };
 
-  const availableModels = modelsByProvider[config.provider] || [];
+  const availableModels = providerModels ?? modelsByProvider[config.provider] ?? [];
 
   return (
     <div className="space-y-6">
@@ -249,13 +283,15 @@ export default function AiSettingsPage() {
               <Label>Provider</Label>
               <Select
                 value={config.provider}
-                onValueChange={(value) =>
+                onValueChange={(value) => {
                   setConfig({
                     ...config,
                     provider: value,
                     model: modelsByProvider[value]?.[0]?.value || "",
-                  })
-                }
+                  });
+                  setProviderModels(null);
+                  setModelMessage(null);
+                }}
               >
                 <SelectTrigger>
                   <SelectValue />
@@ -271,18 +307,38 @@ export default function AiSettingsPage() {
             </div>
 
             <div className="space-y-2">
-              <Label>Model</Label>
+              <div className="flex items-center justify-between">
+                <Label>Model</Label>
+                <Button
+                  variant="ghost"
+                  size="sm"
+                  className="h-6 px-2 text-xs"
+                  onClick={handleLoadModels}
+                  disabled={isLoadingModels}
+                >
+                  {isLoadingModels ? (
+                    <Loader2 className="mr-1 h-3 w-3 animate-spin" />
+                  ) : (
+                    <RefreshCw className="mr-1 h-3 w-3" />
+                  )}
+                  Load from provider
+                </Button>
+              </div>
               <Combobox
                 options={availableModels}
                 value={config.model}
-                onValueChange={(value) =>
-                  setConfig({ ...config, model: value })
-                }
+                onValueChange={(value) => {
+                  setConfig({ ...config, model: value });
+                  setModelMessage(null);
+                }}
                 placeholder="Select or type a model..."
                 searchPlaceholder="Search or type custom model..."
                 emptyMessage="No matching models."
                 allowCustomValue
               />
+              {modelMessage && (
+                <p className="text-xs text-muted-foreground">{modelMessage}</p>
+              )}
             </div>
           </div>
 
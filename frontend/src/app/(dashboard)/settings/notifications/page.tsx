@@ -4,15 +4,31 @@ import { useEffect, useState, useCallback } from "react";
 import { api } from "@/lib/api";
 import { Button } from "@/components/ui/button";
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "@/components/ui/select";
 import { toast } from "@/stores/toast";
 import {
   PreferencesMatrix,
   type PreferenceEntry,
   type ChannelType,
+  type DigestGroupBy,
   type Frequency,
 } from "@/components/notifications/preferences-matrix";
 import type { NotificationCategory } from "@/stores/notifications";
 
+const digestGroupOptions: { value: DigestGroupBy; label: string }[] = [
+  { value: "", label: "Server default" },
+  { value: "category", label: "Category" },
+  { value: "cluster", label: "Cluster" },
+  { value: "namespace", label: "Namespace" },
+  { value: "severity", label: "Severity" },
+];
+
 interface PreferencesResponse {
   preferences: PreferenceEntry[];
   available_channels: ChannelType[];
@@ -77,6 +93,16 @@ export default function NotificationPreferencesPage() {
     []
   );
 
+  const digestGroupBy =
+    preferences.find((p) => p.digest_group_by)?.digest_group_by ?? "";
+
+  function handleDigestGroupByChange(value: string) {
+    const groupBy = (value === "default" ? "" : value) as DigestGroupBy;
+    setPreferences((prev) =>
+      prev.map((p) => ({ ...p, digest_group_by: groupBy }))
+    );
+  }
+
   async function handleSave() {
     setSaving(true);
     try {
@@ -119,6 +145,27 @@ export default function NotificationPreferencesPage() {
             onFrequencyChange={handleFrequencyChange}
             disabled={saving}
           />
+          <div className="flex items-center gap-3">
+            <span className="text-sm text-muted-foreground">
+              Group hourly, daily and weekly digests by
+            </span>
+            <Select
+              value={digestGroupBy || "default"}
+              onValueChange={handleDigestGroupByChange}
+              disabled={saving}
+            >
+              <SelectTrigger className="w-40">
+                <SelectValue />
+              </SelectTrigger>
+              <SelectContent>
+                {digestGroupOptions.map((opt) => (
+                  <SelectItem key={opt.label} value={opt.value || "default"}>
+                    {opt.label}
+                  </SelectItem>
+                ))}
+              </SelectContent>
+            </Select>
+          </div>
           <div className="flex justify-end">
             <Button onClick={handleSave} disabled={saving}>
               {saving ? "Saving..." : "Save Preferences"}
@@ -85,6 +85,30 @@ interface NewMappingForm {
   namespace: string;
 }
 
+interface MappingPreview {
+  groups: string[];
+  groups_claim?: string;
+  matches: GroupMapping[];
+  roles: { role_name: string; cluster_id?: string; namespace?: string }[];
+  unmatched_groups: string[];
+  default_role?: string;
+}
+
+// A pasted value with two dots and no spaces or commas is taken as an ID
+// token; anything else as a list of groups.
+function previewPayload(input: string): { groups?: string[]; id_token?: string } {
+  const value = input.trim();
+  if (/^[\w-]+\.[\w-]+\.[\w-]*$/.test(value)) {
+    return { id_token: value };
+  }
+  return {
+    groups: value
+      .split(/[,\n]/)
+      .map((g) => g.trim())
+      .filter(Boolean),
+  };
+}
+
 const AVAILABLE_ROLES = ["admin", "operator", "developer", "viewer"] as const;
 const DEFAULT_ROLE_OPTIONS = ["none", "viewer", "developer", "operator"] as const;
 
@@ -154,6 +178,9 @@ export default function OidcSettingsPage() {
     cluster_id: "",
     namespace: "",
   });
+  const [previewInput, setPreviewInput] = useState("");
+  const [previewing, setPreviewing] = useState(false);
+  const [preview, setPreview] = useState<MappingPreview | null>(null);
 
   // -----------------------------------------------------------------------
   // Load presets + current config
@@ -470,6 +497,30 @@ export default function OidcSettingsPage() {
     }
   }
 
+  // -----------------------------------------------------------------------
+  // Group Mappings: Preview
+  // -----------------------------------------------------------------------
+
+  async function handlePreviewMappings() {
+    setPreviewing(true);
+    try {
+      setPreview(
+        await api.post<MappingPreview>(
+          "/api/settings/oidc/mappings/preview",
+          previewPayload(previewInput)
+        )
+      );
+    } catch {
+      setPreview(null);
+      toast("Preview Failed", {
+        description: "Enter group names or a valid ID token",
+        variant: "error",
+      });
+    } finally {
+      setPreviewing(false);
+    }
+  }
+
   // -----------------------------------------------------------------------
   // Render
   // -----------------------------------------------------------------------
@@ -1035,6 +1086,77 @@ export default function OidcSettingsPage() {
               </Button>
             </div>
           </RBACGate>
+
+          {/* Preview Mappings */}
+          <RBACGate resource="settings" action="write">
+            <div className="space-y-3">
+              <h3 className="text-sm font-medium">Preview Mappings</h3>
+              <p className="text-xs text-muted-foreground">
+                Paste group names, separated by commas or lines, or an ID token to
+                see which mappings a login would fire. Nothing is assigned.
+              </p>
+              <textarea
+                id="preview-groups"
+                aria-label="Groups or ID token to preview"
+                rows={3}
+                placeholder="K8s-Admins, shop-devs"
+                value={previewInput}
+                onChange={(e) => setPreviewInput(e.target.value)}
+                className="flex w-full rounded-md border border-input bg-background px-3 py-2 font-mono text-xs shadow-sm focus-visible:outline-none focus-visible:ring-1 focus-visible:ring-ring"
+              />
+              <Button
+                variant="outline"
+                size="sm"
+                onClick={handlePreviewMappings}
+                disabled={previewing || !previewInput.trim()}
+              >
+                {previewing ? (
+                  <Loader2 className="mr-2 h-3.5 w-3.5 animate-spin" />
+                ) : (
+                  <Eye className="mr-2 h-3.5 w-3.5" />
+                )}
+                Preview
+              </Button>
+              {preview && (
+                <div className="space-y-2 rounded-md border p-3 text-sm" data-testid="mapping-preview">
+                  {preview.groups_claim && (
+                    <p className="text-xs text-muted-foreground">
+                      Groups read from the <code>{preview.groups_claim}</code> claim:{" "}
+                      {preview.groups.length > 0 ? preview.groups.join(", ") : "none"}
+                    </p>
+                  )}
+                  {preview.roles.length > 0 ? (
+                    <ul className="space-y-1">
+                      {preview.matches.map((m) => (
+                        <li key={m.id}>
+                          <span className="font-mono">{m.oidc_group}</span> &rarr;{" "}
+                          <span className="font-medium">{m.role_name}</span>
+                          {m.cluster_id || m.namespace ? (
+                            <span className="text-muted-foreground">
+                              {" "}
+                              ({[m.cluster_id, m.namespace].filter(Boolean).join(" / ")})
+                            </span>
+                          ) : null}
+                        </li>
+                      ))}
+                    </ul>
+                  ) : (
+                    <p>
+                      No mapping fires.
+                      {preview.default_role
+                        ? ` A new user would get the default role "${preview.default_role}".`
+                        : " A new user would get no role."}
+                    </p>
+                  )}
+                  {preview.unmatched_groups.length > 0 && (
+                    <p className="text-xs text-muted-foreground">
+                      Unmatched groups: {preview.unmatched_groups.join(", ")}
+                    </p>
+                  )}
+                </div>
+              )}
+            </div>
+          </RBACGate>
         </CardContent>
       </Card>
     </div>
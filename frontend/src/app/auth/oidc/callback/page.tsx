@@ -39,15 +39,14 @@ function OidcCallbackContent() {
       return;
     }
 
-    if (!accessToken) {
-      setError("No access token received from OIDC provider.");
-      return;
-    }
-
-    localStorage.setItem("access_token", accessToken);
-    document.cookie = `access_token=${accessToken}; path=/; max-age=${60 * 60 * 24 * 7}; SameSite=Lax`;
-    if (refreshToken) {
-      localStorage.setItem("refresh_token", refreshToken);
+    // Without tokens in the URL, the backend delivered them as HttpOnly
+    // session cookies (OIDC_TOKEN_DELIVERY=cookie), which /me checks.
+    if (accessToken) {
+      localStorage.setItem("access_token", accessToken);
+      document.cookie = `access_token=${accessToken}; path=/; max-age=${60 * 60 * 24 * 7}; SameSite=Lax`;
+      if (refreshToken) {
+        localStorage.setItem("refresh_token", refreshToken);
+      }
     }
 
     api
@@ -60,7 +59,11 @@ function OidcCallbackContent() {
         router.replace("/dashboard");
       })
       .catch(() => {
-        setError("Failed to fetch user profile after OIDC login.");
+        setError(
+          accessToken
+            ? "Failed to fetch user profile after OIDC login."
+            : "No access token received from OIDC provider.",
+        );
       });
   }, [router, searchParams]);
 
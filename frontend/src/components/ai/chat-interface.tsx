@@ -108,6 +108,8 @@ export function ChatInterface({ mode }: ChatInterfaceProps) {
     isHistoryLoading,
     configChangedWhileOpen,
     setConfigChangedWhileOpen,
+    activeProvider,
+    setActiveProvider,
   } = useAiChatStore();
 
   const {
@@ -253,6 +255,22 @@ export function ChatInterface({ mode }: ChatInterfaceProps) {
         <span className="text-[10px] text-muted-foreground/40">
           Enter to send, Shift+Enter for new line
         </span>
+        {aiStatus?.providers && aiStatus.providers.length > 1 && (
+          <select
+            value={activeProvider ?? aiStatus.default_provider ?? ""}
+            onChange={(e) =>
+              setActiveProvider(e.target.value === aiStatus.default_provider ? null : e.target.value)
+            }
+            aria-label="AI provider"
+            className="bg-transparent text-[10px] text-muted-foreground outline-none"
+          >
+            {aiStatus.providers.map((name) => (
+              <option key={name} value={name}>
+                {name}
+              </option>
+            ))}
+          </select>
+        )}
         <span className="text-[10px] text-muted-foreground/40">
           AI can make mistakes
         </span>
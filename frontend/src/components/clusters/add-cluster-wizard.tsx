@@ -33,7 +33,7 @@ import {
 import { ProgressSteps } from "@/components/ui/progress-steps";
 import { FileDropzone } from "@/components/ui/file-dropzone";
 import { cn } from "@/lib/utils";
-import { api } from "@/lib/api";
+import { api, ApiError } from "@/lib/api";
 import yaml from "js-yaml";
 
 interface AgentTokenResponse {
@@ -212,10 +212,15 @@ export function AddClusterWizard({
         cluster: created,
       });
       onClusterAdded(created);
-    } catch {
+    } catch (err) {
+      // The backend says why a kubeconfig was refused (malformed, unreachable,
+      // auth or TLS failure); show that rather than a generic message.
       setVerifyResult({
         success: false,
-        message: "Failed to connect. Please check your kubeconfig and try again.",
+        message:
+          err instanceof ApiError && err.code === "validation_failed"
+            ? err.message
+            : "Failed to connect. Please check your kubeconfig and try again.",
       });
     } finally {
       setVerifying(false);
@@ -20,12 +20,14 @@ import type { NotificationCategory } from "@/stores/notifications";
 
 export type ChannelType = "in_app" | "email" | "slack" | "teams" | "telegram" | "webhook";
 export type Frequency = "instant" | "hourly" | "daily" | "weekly" | "off";
+export type DigestGroupBy = "" | "category" | "cluster" | "namespace" | "severity";
 
 export interface PreferenceEntry {
   category: NotificationCategory;
   channel: ChannelType;
   enabled: boolean;
   frequency: Frequency;
+  digest_group_by?: DigestGroupBy;
 }
 
 interface PreferencesMatrixProps {
@@ -50,6 +50,7 @@ export function useAiActions() {
             conversation_id: store.activeConversationId || undefined,
             context: pageContext,
             agent_id: store.activeAgentId || undefined,
+            provider: store.activeProvider || undefined,
           }
         )
         .catch((err) => {
@@ -11,28 +11,51 @@ export class ApiError extends Error {
   constructor(
     message: string,
     public status: number,
+    /** Machine-readable error code from the backend envelope, e.g. `not_connected`. */
+    public code?: string,
+    public details?: unknown,
   ) {
     super(message);
     this.name = 'ApiError';
   }
 }
 
+/** Error envelope returned by every backend handler. */
+interface ErrorEnvelope {
+  code?: string;
+  message?: string;
+  details?: unknown;
+  /** Same as `message`; kept by the backend for older clients. */
+  error?: string;
+}
+
+/**
+ * Header the backend requires on state-changing requests authenticated by
+ * the HttpOnly session cookie that cookie-mode OIDC sign-in sets.
+ */
+export const CSRF_HEADERS = { 'X-Requested-With': 'XMLHttpRequest' };
+
 async function tryRefreshToken(): Promise<boolean> {
-  const refreshToken = typeof window !== 'undefined' ? localStorage.getItem('refresh_token') : null;
-  if (!refreshToken) return false;
+  if (typeof window === 'undefined') return false;
+  const refreshToken = localStorage.getItem('refresh_token');
 
   try {
+    // Without a stored refresh token, the session cookie may hold one.
     const res = await fetch(`${API_URL}/api/auth/refresh`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
-      body: JSON.stringify({ refresh_token: refreshToken }),
+      credentials: 'include',
+      headers: { 'Content-Type': 'application/json', ...CSRF_HEADERS },
+      body: JSON.stringify(refreshToken ? { refresh_token: refreshToken } : {}),
     });
 
     if (!res.ok) return false;
 
+    // A cookie refresh sets the new access token as a cookie instead.
     const data = await res.json();
-    localStorage.setItem('access_token', data.access_token);
-    document.cookie = `access_token=${data.access_token}; path=/; max-age=${60 * 60 * 24 * 7}; SameSite=Lax`;
+    if (data.access_token) {
+      localStorage.setItem('access_token', data.access_token);
+      document.cookie = `access_token=${data.access_token}; path=/; max-age=${60 * 60 * 24 * 7}; SameSite=Lax`;
+    }
     return true;
   } catch {
     return false;
@@ -52,8 +75,10 @@ async function fetchWithAuth<T>(path: string, method: string, body?: unknown, re
 
   const res = await fetch(`${API_URL}${path}`, {
     method,
+    credentials: 'include',
     headers: {
       'Content-Type': 'application/json',
+      ...CSRF_HEADERS,
       ...(token ? { Authorization: `Bearer ${token}` } : {}),
     },
     ...(body !== undefined ? { body: JSON.stringify(body) } : {}),
@@ -75,29 +100,29 @@ async function fetchWithAuth<T>(path: string, method: string, body?: unknown, re
   }
 
   if (res.status === 403) {
-    const data = await res.json().catch(() => ({}));
-    if (data?.error === 'setup_required') {
+    const data: ErrorEnvelope = await res.json().catch(() => ({}));
+    if (data?.code === 'setup_required') {
       if (typeof window !== 'undefined' && !isRedirecting) {
         isRedirecting = true;
         window.location.href = '/setup';
       }
-      throw new ApiError('Setup required', 403);
+      throw new ApiError('Setup required', 403, data.code);
     }
-    const err = new ApiError('Forbidden: You do not have permission to perform this action.', 403);
+    const err = new ApiError('Forbidden: You do not have permission to perform this action.', 403, data?.code);
     toast('Access Denied', { description: err.message, variant: 'error' });
     throw err;
   }
 
   if (!res.ok) {
-    const data = await res.json().catch(() => ({}));
-    const message = data?.error ?? `HTTP ${res.status}`;
+    const data: ErrorEnvelope = await res.json().catch(() => ({}));
+    const message = data?.message ?? data?.error ?? `HTTP ${res.status}`;
 
     if (isRetryable(res.status) && retries < MAX_RETRIES) {
       await delay(RETRY_DELAY_MS * (retries + 1));
       return fetchWithAuth<T>(path, method, body, retries + 1);
     }
 
-    const err = new ApiError(message, res.status);
+    const err = new ApiError(message, res.status, data?.code, data?.details);
     toast('Request failed', { description: message, variant: 'error' });
     throw err;
   }
@@ -41,6 +41,9 @@ export interface AiStatus {
   provider: string;
   model: string;
   message: string;
+  /** Providers a message may select, and the one used when it selects none. */
+  providers?: string[];
+  default_provider?: string;
 }
 
 export type ConnectionState = "disconnected" | "connecting" | "connected" | "error";
@@ -150,6 +153,10 @@ interface AiChatState {
   activeAgentId: string | null;
   setActiveAgent: (id: string | null) => void;
 
+  // Provider selection (null uses the server's default provider)
+  activeProvider: string | null;
+  setActiveProvider: (name: string | null) => void;
+
   // Tasks
   tasks: AgentTask[];
   setTasks: (tasks: AgentTask[]) => void;
@@ -256,6 +263,10 @@ export const useAiChatStore = create<AiChatState>((set) => ({
   activeAgentId: null,
   setActiveAgent: (id) => set({ activeAgentId: id }),
 
+  // Provider selection
+  activeProvider: null,
+  setActiveProvider: (name) => set({ activeProvider: name }),
+
   // Tasks
   tasks: [],
   setTasks: (tasks) => set({ tasks }),
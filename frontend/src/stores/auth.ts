@@ -1,5 +1,5 @@
 import { create } from 'zustand';
-import { api } from '@/lib/api';
+import { api, CSRF_HEADERS } from '@/lib/api';
 
 const API_URL = process.env.NEXT_PUBLIC_API_URL || '';
 
@@ -94,20 +94,23 @@ export const useAuthStore = create<AuthState>((set, get) => ({
   },
 
   logout: async () => {
+    // Without stored tokens, the session cookies of a cookie-mode OIDC
+    // sign-in authenticate the request and are cleared by it.
     const refreshToken = localStorage.getItem('refresh_token');
-    if (refreshToken) {
-      try {
-        await fetch(`${API_URL}/api/auth/logout`, {
-          method: 'POST',
-          headers: {
-            'Content-Type': 'application/json',
-            Authorization: `Bearer ${localStorage.getItem('access_token')}`,
-          },
-          body: JSON.stringify({ refresh_token: refreshToken }),
-        });
-      } catch {
-        // Best effort - continue with local cleanup
-      }
+    const accessToken = localStorage.getItem('access_token');
+    try {
+      await fetch(`${API_URL}/api/auth/logout`, {
+        method: 'POST',
+        credentials: 'include',
+        headers: {
+          'Content-Type': 'application/json',
+          ...CSRF_HEADERS,
+          ...(accessToken ? { Authorization: `Bearer ${accessToken}` } : {}),
+        },
+        body: JSON.stringify(refreshToken ? { refresh_token: refreshToken } : {}),
+      });
+    } catch {
+      // Best effort - continue with local cleanup
     }
     removeTokens();
     set({ user: null, isAuthenticated: false, preferences: null });
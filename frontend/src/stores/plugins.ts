@@ -18,11 +18,28 @@ export interface PluginWidget {
   component: string;
 }
 
+/** RBAC permission a plugin API route requires */
+export interface PluginRoutePermission {
+  resource: string;
+  action: string;
+}
+
+export interface PluginBackendRoute {
+  method: string;
+  path: string;
+  handler: string;
+  permission?: PluginRoutePermission;
+}
+
 export interface PluginManifest {
   id: string;
   name: string;
   version: string;
   description: string;
+  permissions?: string[];
+  backend?: {
+    routes: PluginBackendRoute[];
+  };
   frontend: {
     navigation: PluginNavItem[];
     routes: PluginRoute[];